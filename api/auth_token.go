@@ -84,6 +84,31 @@ func (c *TokenAuth) CreateWithRoleWithContext(ctx context.Context, opts *TokenCr
 	return ParseSecret(resp.Body)
 }
 
+// Exchange exchanges the client token for a derived token intended for the
+// requested audience, holding a subset of the client token's policies and
+// expiring no later than it.
+func (c *TokenAuth) Exchange(opts *TokenExchangeRequest) (*Secret, error) {
+	return c.ExchangeWithContext(context.Background(), opts)
+}
+
+func (c *TokenAuth) ExchangeWithContext(ctx context.Context, opts *TokenExchangeRequest) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/auth/token/exchange")
+	if err := r.SetJSONBody(opts); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 func (c *TokenAuth) Lookup(token string) (*Secret, error) {
 	return c.LookupWithContext(context.Background(), token)
 }
@@ -151,6 +176,56 @@ func (c *TokenAuth) LookupSelfWithContext(ctx context.Context) (*Secret, error)
 	return ParseSecret(resp.Body)
 }
 
+// InventorySelf lists the active tokens of the entity of the client token,
+// by accessor.
+func (c *TokenAuth) InventorySelf() (*Secret, error) {
+	return c.InventorySelfWithContext(context.Background())
+}
+
+func (c *TokenAuth) InventorySelfWithContext(ctx context.Context) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/auth/token/self/inventory")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// RevokeOthersSelf revokes the tokens of the entity of the client token other
+// than the client token and its parents. If accessors are given, only those
+// tokens are revoked.
+func (c *TokenAuth) RevokeOthersSelf(accessors []string) (*Secret, error) {
+	return c.RevokeOthersSelfWithContext(context.Background(), accessors)
+}
+
+func (c *TokenAuth) RevokeOthersSelfWithContext(ctx context.Context, accessors []string) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/auth/token/self/inventory/revoke-others")
+	body := map[string]interface{}{}
+	if len(accessors) > 0 {
+		body["accessors"] = accessors
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 func (c *TokenAuth) RenewAccessor(accessor string, increment int) (*Secret, error) {
 	return c.RenewAccessorWithContext(context.Background(), accessor, increment)
 }
@@ -375,3 +450,11 @@ type TokenCreateRequest struct {
 	Type            string            `json:"type"`
 	EntityAlias     string            `json:"entity_alias"`
 }
+
+// TokenExchangeRequest is the options structure for exchanging a token.
+type TokenExchangeRequest struct {
+	Audience string   `json:"audience"`
+	Policies []string `json:"policies,omitempty"`
+	TTL      string   `json:"ttl,omitempty"`
+	NumUses  int      `json:"num_uses,omitempty"`
+}
@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped, when a request is rejected by the
+// client's CircuitBreaker without being sent to the server.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker tracks the outcome of requests per endpoint and rejects
+// requests to endpoints which are deemed unhealthy, so that clients fail fast
+// instead of piling more load onto a struggling server. Endpoints are
+// identified by the request method and path, e.g. "GET /v1/secret/data/foo".
+type CircuitBreaker interface {
+	// Allow returns an error if a request to the given endpoint should not
+	// be sent. Every allowed request is followed by a call to either Record
+	// or Release.
+	Allow(endpoint string) error
+
+	// Record records the outcome of a request to the given endpoint.
+	Record(endpoint string, success bool)
+
+	// Release records that a request to the given endpoint was abandoned by
+	// the caller before it had an outcome.
+	Release(endpoint string)
+}
+
+// ConsecutiveFailureBreaker is a CircuitBreaker which opens the circuit for
+// an endpoint after FailureThreshold consecutive failed requests. While open,
+// all requests to the endpoint are rejected for OpenDuration, after which a
+// single trial request is let through: if it succeeds the circuit is closed
+// again, otherwise it is re-opened for another OpenDuration.
+//
+// Requests which time out, including those whose context deadline is
+// exceeded, count as failures.
+type ConsecutiveFailureBreaker struct {
+	// FailureThreshold is the number of consecutive failures after which the
+	// circuit for an endpoint is opened.
+	FailureThreshold int
+
+	// OpenDuration is the time for which requests are rejected once the
+	// circuit has been opened.
+	OpenDuration time.Duration
+
+	l         sync.Mutex
+	endpoints map[string]*circuitState
+}
+
+type circuitState struct {
+	failures  int
+	openUntil time.Time
+	trialSent bool
+}
+
+var _ CircuitBreaker = (*ConsecutiveFailureBreaker)(nil)
+
+// NewConsecutiveFailureBreaker returns a ConsecutiveFailureBreaker with the
+// given failure threshold and open duration.
+func NewConsecutiveFailureBreaker(failureThreshold int, openDuration time.Duration) *ConsecutiveFailureBreaker {
+	return &ConsecutiveFailureBreaker{
+		FailureThreshold: failureThreshold,
+		OpenDuration:     openDuration,
+	}
+}
+
+// Allow implements CircuitBreaker.
+func (b *ConsecutiveFailureBreaker) Allow(endpoint string) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	state, ok := b.endpoints[endpoint]
+	if !ok || state.openUntil.IsZero() {
+		return nil
+	}
+
+	if time.Now().Before(state.openUntil) || state.trialSent {
+		return fmt.Errorf("%w for %q", ErrCircuitOpen, endpoint)
+	}
+
+	// Half-open: let a single trial request through.
+	state.trialSent = true
+	return nil
+}
+
+// Release implements CircuitBreaker. An abandoned trial request does not
+// close or re-open the circuit; the next request is let through as the trial
+// instead.
+func (b *ConsecutiveFailureBreaker) Release(endpoint string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if state, ok := b.endpoints[endpoint]; ok {
+		state.trialSent = false
+	}
+}
+
+// Record implements CircuitBreaker.
+func (b *ConsecutiveFailureBreaker) Record(endpoint string, success bool) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if success {
+		delete(b.endpoints, endpoint)
+		return
+	}
+
+	if b.endpoints == nil {
+		b.endpoints = make(map[string]*circuitState)
+	}
+	state, ok := b.endpoints[endpoint]
+	if !ok {
+		state = &circuitState{}
+		b.endpoints[endpoint] = state
+	}
+
+	state.failures++
+	if state.trialSent || (b.FailureThreshold > 0 && state.failures >= b.FailureThreshold) {
+		state.openUntil = time.Now().Add(b.OpenDuration)
+		state.trialSent = false
+	}
+}
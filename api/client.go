@@ -68,6 +68,18 @@ const (
 	// SSRF protection.
 	RequestHeaderName = "X-Vault-Request"
 
+	// RequestTimeoutHeaderName is the name of the header used to bound how
+	// long the server spends handling a request.
+	RequestTimeoutHeaderName = "X-Vault-Request-Timeout"
+
+	// IdempotencyKeyHeaderName is the name of the header containing a key
+	// which identifies retries of the same mutating request.
+	IdempotencyKeyHeaderName = "X-Vault-Idempotency-Key"
+
+	// IdempotentReplayedHeaderName is the name of the response header set by
+	// the server when a response is a replay of a previously recorded result.
+	IdempotentReplayedHeaderName = "X-Vault-Idempotent-Replayed"
+
 	TLSErrorString = "This error usually means that the server is running with TLS disabled\n" +
 		"but the client is configured to use TLS. Please either enable TLS\n" +
 		"on the server or run the client with -address set to an address\n" +
@@ -209,6 +221,16 @@ type Config struct {
 	// primary node.
 	DisableRedirects bool
 	clientTLSConfig  *tls.Config
+
+	// HedgingPolicy, if set, is consulted for idempotent reads to decide
+	// whether additional, parallel attempts of the request should be sent
+	// when the original is slow to complete. See HedgingPolicy.
+	HedgingPolicy HedgingPolicy
+
+	// CircuitBreaker, if set, tracks request outcomes per endpoint and
+	// rejects requests with ErrCircuitOpen to endpoints that keep failing.
+	// The same CircuitBreaker is shared with all Client clones.
+	CircuitBreaker CircuitBreaker
 }
 
 // TLSConfig contains the parameters needed to configure TLS on the HTTP client
@@ -688,6 +710,8 @@ func (c *Client) CloneConfig() *Config {
 	newConfig.CloneToken = c.config.CloneToken
 	newConfig.ReadYourWrites = c.config.ReadYourWrites
 	newConfig.clientTLSConfig = c.config.clientTLSConfig
+	newConfig.HedgingPolicy = c.config.HedgingPolicy
+	newConfig.CircuitBreaker = c.config.CircuitBreaker
 
 	// we specifically want a _copy_ of the client here, not a pointer to the original one
 	newClient := *c.config.HttpClient
@@ -900,6 +924,46 @@ func (c *Client) ClientTimeout() time.Duration {
 	return c.config.Timeout
 }
 
+// SetHedgingPolicy sets the HedgingPolicy to be used for future requests.
+// Passing nil disables hedging.
+func (c *Client) SetHedgingPolicy(policy HedgingPolicy) {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+
+	c.config.HedgingPolicy = policy
+}
+
+func (c *Client) HedgingPolicy() HedgingPolicy {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	c.config.modifyLock.RLock()
+	defer c.config.modifyLock.RUnlock()
+
+	return c.config.HedgingPolicy
+}
+
+// SetCircuitBreaker sets the CircuitBreaker to be used for future requests.
+// Passing nil disables circuit breaking.
+func (c *Client) SetCircuitBreaker(breaker CircuitBreaker) {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+
+	c.config.CircuitBreaker = breaker
+}
+
+func (c *Client) CircuitBreaker() CircuitBreaker {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	c.config.modifyLock.RLock()
+	defer c.config.modifyLock.RUnlock()
+
+	return c.config.CircuitBreaker
+}
+
 func (c *Client) OutputCurlString() bool {
 	c.modifyLock.RLock()
 	defer c.modifyLock.RUnlock()
@@ -1260,6 +1324,8 @@ func (c *Client) clone(cloneHeaders bool) (*Client, error) {
 		CloneHeaders:   config.CloneHeaders,
 		CloneToken:     config.CloneToken,
 		ReadYourWrites: config.ReadYourWrites,
+		HedgingPolicy:  config.HedgingPolicy,
+		CircuitBreaker: config.CircuitBreaker,
 	}
 
 	if config.CloneTLSConfig {
@@ -1400,6 +1466,8 @@ func (c *Client) rawRequestWithContext(ctx context.Context, r *Request) (*Respon
 	outputPolicy := c.config.OutputPolicy
 	logger := c.config.Logger
 	disableRedirects := c.config.DisableRedirects
+	hedgingPolicy := c.config.HedgingPolicy
+	circuitBreaker := c.config.CircuitBreaker
 	c.config.modifyLock.RUnlock()
 
 	c.modifyLock.RUnlock()
@@ -1482,8 +1550,34 @@ START:
 		ErrorHandler: retryablehttp.PassthroughErrorHandler,
 	}
 
+	endpoint := r.Method + " " + req.URL.Path
+	if circuitBreaker != nil {
+		if err := circuitBreaker.Allow(endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	var hedgeDelays []time.Duration
+	if hedgingPolicy != nil && isHedgeable(r) {
+		hedgeDelays = hedgingPolicy.HedgeDelays(r.Method, req.URL.Path)
+	}
+
 	var result *Response
-	resp, err := client.Do(req)
+	var resp *http.Response
+	if len(hedgeDelays) > 0 {
+		resp, err = doHedged(ctx, client, r, hedgeDelays)
+	} else {
+		resp, err = client.Do(req)
+	}
+	if circuitBreaker != nil {
+		if ctx.Err() == context.Canceled {
+			// The caller gave up on the request, which says nothing about
+			// the health of the endpoint. Timeouts are failures.
+			circuitBreaker.Release(endpoint)
+		} else {
+			circuitBreaker.Record(endpoint, err == nil && resp.StatusCode < http.StatusInternalServerError)
+		}
+	}
 	if resp != nil {
 		result = &Response{Response: resp}
 	}
@@ -1593,6 +1687,10 @@ func (c *Client) httpRequestWithContext(ctx context.Context, r *Request) (*Respo
 		req.Header.Set("X-Vault-Wrap-TTL", r.WrapTTL)
 	}
 
+	if len(r.IdempotencyKey) != 0 {
+		req.Header.Set(IdempotencyKeyHeaderName, r.IdempotencyKey)
+	}
+
 	if len(r.MFAHeaderVals) != 0 {
 		for _, mfaHeaderVal := range r.MFAHeaderVals {
 			req.Header.Add("X-Vault-MFA", mfaHeaderVal)
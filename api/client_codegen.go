@@ -0,0 +1,280 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ClientCodegenOptions configures the typed client generated by
+// GenerateClient.
+type ClientCodegenOptions struct {
+	// PackageName is the name of the package of the generated client.
+	PackageName string
+}
+
+var clientCodegenPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GenerateClient generates the Go source of a typed client of the endpoints
+// of the manifest, as returned by Sys().ClientManifest(). Each operation of
+// an endpoint gets a method named after its operation ID, taking a request
+// struct with a field per parameter, and wrapping the Logical client.
+// Optional boolean parameters are pointers, so that false can be sent; other
+// parameters are omitted when they are the zero value.
+func GenerateClient(manifest *ClientManifest, opts *ClientCodegenOptions) ([]byte, error) {
+	if manifest == nil {
+		return nil, errors.New("manifest is required")
+	}
+	if opts == nil || !token.IsIdentifier(opts.PackageName) {
+		return nil, errors.New("a valid package name is required")
+	}
+	if len(manifest.Endpoints) == 0 {
+		return nil, errors.New("manifest has no endpoints")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by the Vault api package from the client manifest of Vault %s. DO NOT EDIT.\n\n", manifest.VaultVersion)
+	fmt.Fprintf(&buf, "package %s\n\n", opts.PackageName)
+	buf.WriteString(clientCodegenPreamble)
+
+	methods := make(map[string]int)
+	for _, endpoint := range manifest.Endpoints {
+		for _, op := range endpoint.Operations {
+			name := clientCodegenIdentifier(op.OperationID)
+			if name == "" {
+				name = clientCodegenIdentifier(op.Method + " " + endpoint.Path)
+			}
+			// Operation IDs are unique in the OpenAPI document, but may not
+			// be once converted to identifiers.
+			if n := methods[name]; n > 0 {
+				methods[name]++
+				name += strconv.Itoa(n + 1)
+			} else {
+				methods[name] = 1
+			}
+			if err := generateClientOperation(&buf, name, endpoint, op); err != nil {
+				return nil, fmt.Errorf("failed to generate %s %s: %w", op.Method, endpoint.Path, err)
+			}
+		}
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated client: %w", err)
+	}
+	return source, nil
+}
+
+const clientCodegenPreamble = `import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Client is a typed client of the endpoints of a Vault cluster.
+type Client struct {
+	client *api.Client
+}
+
+// New returns a typed client using the Vault client.
+func New(client *api.Client) *Client {
+	return &Client{client: client}
+}
+
+func requestData(req interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func requestQuery(req interface{}) (map[string][]string, error) {
+	data, err := requestData(req)
+	if err != nil {
+		return nil, err
+	}
+	query := make(map[string][]string, len(data))
+	for k, v := range data {
+		if values, ok := v.([]interface{}); ok {
+			for _, value := range values {
+				query[k] = append(query[k], fmt.Sprint(value))
+			}
+			continue
+		}
+		query[k] = []string{fmt.Sprint(v)}
+	}
+	return query, nil
+}
+
+`
+
+func generateClientOperation(buf *bytes.Buffer, name string, endpoint *ClientManifestEndpoint, op *ClientManifestOperation) error {
+	requestType := name + "Request"
+	fields := make(map[string]string)
+	fieldNames := make(map[string]bool)
+
+	fmt.Fprintf(buf, "// %s are the parameters of %s.\n", requestType, name)
+	fmt.Fprintf(buf, "type %s struct {\n", requestType)
+	for _, param := range op.Parameters {
+		field := clientCodegenIdentifier(param.Name)
+		if field == "" {
+			continue
+		}
+		// A path parameter may also be a body property, and distinct names
+		// may convert to the same identifier.
+		if _, ok := fields[param.Name]; ok || fieldNames[field] {
+			continue
+		}
+		fields[param.Name] = field
+		fieldNames[field] = true
+
+		if param.Description != "" {
+			fmt.Fprintf(buf, "\t// %s\n", clientCodegenComment(param.Description))
+		}
+		if param.Required {
+			if param.Description != "" {
+				buf.WriteString("\t//\n")
+			}
+			buf.WriteString("\t// Required.\n")
+		}
+		if param.Deprecated {
+			if param.Description != "" || param.Required {
+				buf.WriteString("\t//\n")
+			}
+			fmt.Fprintf(buf, "\t// Deprecated: %s is deprecated.\n", param.Name)
+		}
+		tag := fmt.Sprintf("`json:%q`", param.Name+",omitempty")
+		if param.In == "path" {
+			tag = "`json:\"-\"`"
+		}
+		fmt.Fprintf(buf, "\t%s %s %s\n", field, clientCodegenType(param), tag)
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(buf, "// %s calls %s %s.\n", name, op.Method, endpoint.Path)
+	if op.Summary != "" {
+		fmt.Fprintf(buf, "//\n// %s\n", clientCodegenComment(op.Summary))
+	}
+	if endpoint.Sudo {
+		fmt.Fprintf(buf, "//\n// It requires sudo capability.\n")
+	}
+	if op.Deprecated {
+		fmt.Fprintf(buf, "//\n// Deprecated: %s %s is deprecated.\n", op.Method, endpoint.Path)
+	}
+	fmt.Fprintf(buf, "func (c *Client) %s(ctx context.Context, req *%s) (*api.Secret, error) {\n", name, requestType)
+	fmt.Fprintf(buf, "\tif req == nil {\n\t\treq = &%s{}\n\t}\n", requestType)
+
+	for _, param := range op.Parameters {
+		if param.In == "path" && param.Required && fields[param.Name] != "" && clientCodegenType(param) == "string" {
+			fmt.Fprintf(buf, "\tif req.%s == \"\" {\n\t\treturn nil, fmt.Errorf(\"%s is required\")\n\t}\n", fields[param.Name], param.Name)
+		}
+	}
+
+	// Build the path, substituting the path parameters.
+	path := strings.TrimPrefix(endpoint.Path, "/")
+	var pathExpr []string
+	last := 0
+	for _, match := range clientCodegenPathParam.FindAllStringSubmatchIndex(path, -1) {
+		if match[0] > last {
+			pathExpr = append(pathExpr, strconv.Quote(path[last:match[0]]))
+		}
+		field, ok := fields[path[match[2]:match[3]]]
+		if !ok {
+			return fmt.Errorf("path parameter %q is not a parameter", path[match[2]:match[3]])
+		}
+		pathExpr = append(pathExpr, "fmt.Sprint(req."+field+")")
+		last = match[1]
+	}
+	if last < len(path) || len(pathExpr) == 0 {
+		pathExpr = append(pathExpr, strconv.Quote(path[last:]))
+	}
+	fmt.Fprintf(buf, "\tpath := %s\n", strings.Join(pathExpr, " + "))
+
+	switch op.Method {
+	case "GET":
+		buf.WriteString("\tquery, err := requestQuery(req)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		buf.WriteString("\treturn c.client.Logical().ReadWithDataWithContext(ctx, path, query)\n")
+	case "DELETE":
+		buf.WriteString("\tquery, err := requestQuery(req)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		buf.WriteString("\treturn c.client.Logical().DeleteWithDataWithContext(ctx, path, query)\n")
+	case "POST":
+		buf.WriteString("\tdata, err := requestData(req)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		buf.WriteString("\treturn c.client.Logical().WriteWithContext(ctx, path, data)\n")
+	default:
+		return fmt.Errorf("unsupported method %q", op.Method)
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// clientCodegenType returns the Go type of the parameter.
+func clientCodegenType(param *ClientManifestParameter) string {
+	switch param.Type {
+	case "boolean":
+		if param.Required || param.In == "path" {
+			return "bool"
+		}
+		return "*bool"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "object":
+		return "map[string]interface{}"
+	case "array":
+		item := clientCodegenType(&ClientManifestParameter{Type: param.ItemType, Required: true})
+		if param.ItemType == "" {
+			item = "interface{}"
+		}
+		return "[]" + item
+	default:
+		return "string"
+	}
+}
+
+// clientCodegenIdentifier converts a name such as an operation ID or a
+// parameter name to an exported Go identifier.
+func clientCodegenIdentifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id == "" {
+		return ""
+	}
+	if unicode.IsDigit(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
+
+// clientCodegenComment flattens a description to fit on a comment line.
+func clientCodegenComment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
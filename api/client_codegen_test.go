@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClient(t *testing.T) {
+	manifest := &ClientManifest{
+		ManifestVersion: 1,
+		VaultVersion:    "1.16.0",
+		Endpoints: []*ClientManifestEndpoint{
+			{
+				Path: "/secret/data/{path}",
+				Operations: []*ClientManifestOperation{
+					{
+						Method:      "GET",
+						OperationID: "kv-v2-read",
+						Summary:     "Read a secret.",
+						Parameters: []*ClientManifestParameter{
+							{Name: "path", In: "path", Type: "string", Required: true},
+							{Name: "version", In: "query", Type: "integer"},
+						},
+					},
+					{
+						Method:      "POST",
+						OperationID: "kv-v2-write",
+						Parameters: []*ClientManifestParameter{
+							{Name: "path", In: "path", Type: "string", Required: true},
+							{Name: "data", In: "body", Type: "object"},
+							{Name: "cas_required", In: "body", Type: "boolean", Deprecated: true},
+							{Name: "tags", In: "body", Type: "array", ItemType: "string"},
+						},
+					},
+				},
+			},
+			{
+				Path: "/sys/seal",
+				Sudo: true,
+				Operations: []*ClientManifestOperation{
+					{Method: "POST", OperationID: "seal", Deprecated: true},
+				},
+			},
+		},
+	}
+
+	source, err := GenerateClient(manifest, &ClientCodegenOptions{PackageName: "vaultclient"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "client.go", source, parser.AllErrors); err != nil {
+		t.Fatalf("generated client does not parse: %v\n%s", err, source)
+	}
+
+	// Fields are aligned by gofmt.
+	normalized := strings.Join(strings.Fields(string(source)), " ")
+	for _, expected := range []string{
+		"package vaultclient",
+		"func (c *Client) KvV2Read(ctx context.Context, req *KvV2ReadRequest) (*api.Secret, error) {",
+		`path := "secret/data/" + fmt.Sprint(req.Path)`,
+		"Path string `json:\"-\"`",
+		"Version int64 `json:\"version,omitempty\"`",
+		"CasRequired *bool `json:\"cas_required,omitempty\"`",
+		"Tags []string `json:\"tags,omitempty\"`",
+		"Data map[string]interface{} `json:\"data,omitempty\"`",
+		"c.client.Logical().WriteWithContext(ctx, path, data)",
+		"// Deprecated: POST /sys/seal is deprecated.",
+		"// It requires sudo capability.",
+	} {
+		if !strings.Contains(normalized, expected) {
+			t.Errorf("expected generated client to contain %q:\n%s", expected, source)
+		}
+	}
+
+	if _, err := GenerateClient(manifest, &ClientCodegenOptions{PackageName: "not a package"}); err == nil {
+		t.Fatal("expected an error for an invalid package name")
+	}
+}
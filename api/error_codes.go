@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import "errors"
+
+// Error codes returned by Vault in the error_code field of error responses.
+// These are stable identifiers which, unlike error messages, are safe to
+// branch on.
+const (
+	ErrorCodeInvalidRequest           = "invalid_request"
+	ErrorCodePermissionDenied         = "permission_denied"
+	ErrorCodeNotFound                 = "not_found"
+	ErrorCodeUnsupportedOperation     = "unsupported_operation"
+	ErrorCodeUnsupportedPath          = "unsupported_path"
+	ErrorCodePathFunctionalityRemoved = "path_functionality_removed"
+	ErrorCodeInvalidWrappingToken     = "invalid_wrapping_token"
+	ErrorCodeRateLimitQuotaExceeded   = "rate_limit_quota_exceeded"
+	ErrorCodeLeaseCountQuotaExceeded  = "lease_count_quota_exceeded"
+	ErrorCodeUpstreamRateLimited      = "upstream_rate_limited"
+	ErrorCodeMissingRequiredState     = "missing_required_state"
+	ErrorCodeRequestTooLarge          = "request_too_large"
+	ErrorCodeSealed                   = "sealed"
+	ErrorCodeAPILocked                = "api_locked"
+	ErrorCodeUnavailable              = "unavailable"
+	ErrorCodeConflict                 = "conflict"
+	ErrorCodeDeadlineExceeded         = "deadline_exceeded"
+	ErrorCodeInternal                 = "internal_error"
+)
+
+// ErrorCode returns the error code of err if it is, or wraps, a
+// *ResponseError. An empty string is returned otherwise, or if the server
+// did not provide an error code.
+func ErrorCode(err error) string {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.ErrorCode
+	}
+	return ""
+}
+
+// ErrorDetails returns the structured error details of err if it is, or
+// wraps, a *ResponseError.
+func ErrorDetails(err error) map[string]interface{} {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.ErrorDetails
+	}
+	return nil
+}
+
+// IsErrorCode returns whether err is, or wraps, a *ResponseError with the
+// given error code.
+func IsErrorCode(err error, code string) bool {
+	return code != "" && ErrorCode(err) == code
+}
@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestResponseError_ErrorCode(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"],"error_code":"conflict","error_details":{"current_version":3}}`))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("secret/data/foo", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	wrapped := fmt.Errorf("writing secret: %w", err)
+	if !IsErrorCode(wrapped, ErrorCodeConflict) {
+		t.Fatalf("expected error code %q, got %q", ErrorCodeConflict, ErrorCode(wrapped))
+	}
+	if IsErrorCode(wrapped, ErrorCodeNotFound) {
+		t.Fatal("unexpected error code match")
+	}
+	if details := ErrorDetails(wrapped); fmt.Sprint(details["current_version"]) != "3" {
+		t.Fatalf("bad error details: %#v", details)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// HedgingPolicy determines whether, and when, hedged requests are issued for
+// a request. A hedged request is an identical copy of the original request
+// sent after a delay if the original has not completed yet; the first
+// attempt to succeed is used and all others are canceled. This trades a small
+// amount of extra server load for a large reduction in tail latency.
+//
+// Hedging is only ever applied to idempotent reads (GET and LIST requests
+// without a body), regardless of what the policy returns.
+type HedgingPolicy interface {
+	// HedgeDelays returns the delays, measured from the start of the original
+	// attempt, at which each additional attempt should be sent for a request
+	// with the given method and path. Returning no delays disables hedging
+	// for the request.
+	HedgeDelays(method, path string) []time.Duration
+}
+
+// ConstantHedgingPolicy is a HedgingPolicy that sends up to MaxHedgedRequests
+// additional attempts, spaced Delay apart.
+type ConstantHedgingPolicy struct {
+	// Delay is the time to wait between attempts.
+	Delay time.Duration
+
+	// MaxHedgedRequests is the maximum number of additional attempts sent
+	// for a single request.
+	MaxHedgedRequests int
+}
+
+var _ HedgingPolicy = (*ConstantHedgingPolicy)(nil)
+
+// HedgeDelays implements HedgingPolicy.
+func (p *ConstantHedgingPolicy) HedgeDelays(_, _ string) []time.Duration {
+	if p == nil || p.Delay <= 0 || p.MaxHedgedRequests <= 0 {
+		return nil
+	}
+
+	delays := make([]time.Duration, p.MaxHedgedRequests)
+	for i := range delays {
+		delays[i] = time.Duration(i+1) * p.Delay
+	}
+	return delays
+}
+
+// isHedgeable returns whether the request is an idempotent read which is safe
+// to send more than once.
+func isHedgeable(r *Request) bool {
+	if r.BodyBytes != nil || r.Body != nil {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, "LIST":
+		return true
+	}
+	return false
+}
+
+// cancelOnCloseBody cancels the context of the attempt which produced the
+// response once its body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type hedgedResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// doHedged sends the request using the given retryable client, issuing an
+// additional attempt at each of the given delays for as long as no attempt
+// has succeeded. The first successful response is returned and any other
+// outstanding attempts are canceled. If every attempt fails, the outcome of
+// the last attempt to complete is returned.
+func doHedged(ctx context.Context, client *retryablehttp.Client, r *Request, delays []time.Duration) (*http.Response, error) {
+	results := make(chan hedgedResult, len(delays)+1)
+	var cancels []context.CancelFunc
+
+	send := func() error {
+		req, err := r.toRetryableHTTP()
+		if err != nil {
+			return err
+		}
+
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		req.Request = req.Request.WithContext(attemptCtx)
+
+		attempt := len(cancels) - 1
+		go func() {
+			resp, err := client.Do(req)
+			results <- hedgedResult{attempt: attempt, resp: resp, err: err}
+		}()
+		return nil
+	}
+
+	// cancelOthers cancels all attempts but the given winner, and closes the
+	// bodies of responses that arrive afterwards so their connections can be
+	// reused. The attempt of the winner is canceled once its body is closed.
+	cancelOthers := func(winner *hedgedResult, outstanding int) {
+		for i, cancel := range cancels {
+			if winner != nil && i == winner.attempt && winner.resp != nil {
+				winner.resp.Body = &cancelOnCloseBody{ReadCloser: winner.resp.Body, cancel: cancel}
+				continue
+			}
+			cancel()
+		}
+		if outstanding == 0 {
+			return
+		}
+		go func() {
+			for i := 0; i < outstanding; i++ {
+				res := <-results
+				if res.resp != nil {
+					res.resp.Body.Close()
+				}
+			}
+		}()
+	}
+
+	if err := send(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(delays[0])
+	defer timer.Stop()
+
+	var last *hedgedResult
+	outstanding := 1
+	next := 0
+	for {
+		select {
+		case res := <-results:
+			outstanding--
+			if res.err == nil && res.resp.StatusCode < http.StatusInternalServerError {
+				if last != nil && last.resp != nil {
+					last.resp.Body.Close()
+				}
+				cancelOthers(&res, outstanding)
+				return res.resp, nil
+			}
+
+			if last != nil && last.resp != nil {
+				last.resp.Body.Close()
+			}
+			last = &res
+
+			if outstanding == 0 && next == len(delays) {
+				cancelOthers(&res, 0)
+				return res.resp, res.err
+			}
+
+			// The attempt failed outright; don't wait for the next delay to
+			// elapse before trying again.
+			if outstanding == 0 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(0)
+			}
+
+		case <-timer.C:
+			if next == len(delays) {
+				continue
+			}
+			if err := send(); err != nil {
+				cancelOthers(nil, outstanding)
+				return nil, err
+			}
+			outstanding++
+			next++
+			if next < len(delays) {
+				timer.Reset(time.Until(start.Add(delays[next])))
+			}
+
+		case <-ctx.Done():
+			cancelOthers(last, outstanding)
+			if last != nil {
+				return last.resp, last.err
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
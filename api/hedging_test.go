@@ -0,0 +1,223 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClient_Hedging verifies that a slow idempotent read is hedged and that
+// the response of the fastest attempt is returned.
+func TestClient_Hedging(t *testing.T) {
+	var numReqs int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		// Only the first attempt is slow.
+		if atomic.AddInt32(&numReqs, 1) == 1 {
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			w.Write([]byte("slow"))
+			return
+		}
+		w.Write([]byte("fast"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+	config.HedgingPolicy = &ConstantHedgingPolicy{
+		Delay:             50 * time.Millisecond,
+		MaxHedgedRequests: 1,
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "fast" {
+		t.Fatalf("expected hedged response, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("hedged request took too long: %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&numReqs); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+// TestClient_HedgingSkipsWrites verifies that requests which aren't
+// idempotent reads are never hedged.
+func TestClient_HedgingSkipsWrites(t *testing.T) {
+	var numReqs int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&numReqs, 1)
+		time.Sleep(200 * time.Millisecond)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+	config.HedgingPolicy = &ConstantHedgingPolicy{
+		Delay:             10 * time.Millisecond,
+		MaxHedgedRequests: 3,
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := client.NewRequest(http.MethodPut, "/")
+	if err := req.SetJSONBody(map[string]interface{}{"foo": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.rawRequestWithContext(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if n := atomic.LoadInt32(&numReqs); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+// TestClient_CircuitBreaker verifies that requests to an endpoint are rejected
+// once its circuit has been opened, and let through again after the open
+// duration has elapsed.
+func TestClient_CircuitBreaker(t *testing.T) {
+	var numReqs int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&numReqs, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+	config.MaxRetries = 0
+	config.CircuitBreaker = NewConsecutiveFailureBreaker(2, 100*time.Millisecond)
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/foo"))
+		if err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected a server error, got %v", err)
+		}
+	}
+
+	_, err = client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/foo"))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit to be open, got %v", err)
+	}
+	if n := atomic.LoadInt32(&numReqs); n != 2 {
+		t.Fatalf("expected 2 requests to reach the server, got %d", n)
+	}
+
+	// Other endpoints are unaffected.
+	_, err = client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/bar"))
+	if errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit for other endpoint to be closed")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	// A single trial request is allowed through once the circuit half-opens.
+	_, err = client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/foo"))
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a server error, got %v", err)
+	}
+	_, err = client.rawRequestWithContext(context.Background(), client.NewRequest(http.MethodGet, "/foo"))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit to be re-opened, got %v", err)
+	}
+}
+
+// TestClient_CircuitBreakerTimeouts verifies that timed out requests count as
+// failures, and that a half-open trial which times out or is canceled does
+// not leave the circuit open for good.
+func TestClient_CircuitBreakerTimeouts(t *testing.T) {
+	const (
+		modeFail int32 = iota
+		modeHang
+		modeOK
+	)
+	mode := modeFail
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		switch atomic.LoadInt32(&mode) {
+		case modeFail:
+			w.WriteHeader(http.StatusInternalServerError)
+		case modeHang:
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+	config.MaxRetries = 0
+	config.CircuitBreaker = NewConsecutiveFailureBreaker(1, 50*time.Millisecond)
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		resp, err := client.rawRequestWithContext(ctx, client.NewRequest(http.MethodGet, "/foo"))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := request(time.Second); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a server error, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The half-open trial times out, which re-opens the circuit.
+	atomic.StoreInt32(&mode, modeHang)
+	if err := request(50 * time.Millisecond); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the trial to time out, got %v", err)
+	}
+	if err := request(time.Second); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit to be re-opened, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// A canceled trial lets the next request through as the trial instead.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := client.rawRequestWithContext(ctx, client.NewRequest(http.MethodGet, "/foo")); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the trial to be canceled, got %v", err)
+	}
+
+	atomic.StoreInt32(&mode, modeOK)
+	if err := request(time.Second); err != nil {
+		t.Fatalf("expected the trial to succeed, got %v", err)
+	}
+	if err := request(time.Second); err != nil {
+		t.Fatalf("expected circuit to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// reservedNamespaceNames are the names a namespace cannot have, as they would
+// shadow the root namespace or the builtin mounts of the namespace's parent.
+var reservedNamespaceNames = map[string]struct{}{
+	"root":      {},
+	"sys":       {},
+	"audit":     {},
+	"auth":      {},
+	"cubbyhole": {},
+	"identity":  {},
+}
+
+// JoinNamespacePath joins namespace path elements into a single path, without
+// leading or trailing slashes, as expected by the X-Vault-Namespace header.
+// Elements may themselves be paths of several namespaces; empty elements and
+// redundant slashes are dropped. "root" is the root namespace and is dropped
+// as well, so the result is empty for the root namespace.
+func JoinNamespacePath(elems ...string) string {
+	var parts []string
+	for _, elem := range elems {
+		for _, part := range strings.Split(elem, "/") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			// Only a leading "root" refers to the root namespace
+			if part == "root" && len(parts) == 0 {
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// ValidateNamespacePath returns an error if the namespace path is not valid:
+// each of its namespaces must be named, must not be "." or "..", must not
+// contain whitespace or policy glob characters, and must not use a name
+// reserved by Vault. Leading and trailing slashes are allowed; the empty path
+// and "root" refer to the root namespace.
+func ValidateNamespacePath(path string) error {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" || trimmed == "root" {
+		return nil
+	}
+
+	for _, name := range strings.Split(trimmed, "/") {
+		switch {
+		case name == "":
+			return fmt.Errorf("namespace path %q contains an empty namespace name", path)
+		case name == "." || name == "..":
+			return fmt.Errorf("namespace path %q contains the relative name %q", path, name)
+		case strings.ContainsAny(name, "+*\\"):
+			return fmt.Errorf("namespace name %q contains an invalid character", name)
+		case strings.IndexFunc(name, unicode.IsSpace) >= 0:
+			return fmt.Errorf("namespace name %q contains whitespace", name)
+		}
+		if _, ok := reservedNamespaceNames[name]; ok {
+			return fmt.Errorf("namespace name %q is reserved", name)
+		}
+	}
+	return nil
+}
+
+// WithChildNamespace makes a shallow copy of Client, modifies it to use the
+// given namespace path relative to the namespace of the client, and returns
+// it. An empty child returns a copy using the namespace of the client.
+func (c *Client) WithChildNamespace(child string) *Client {
+	return c.WithNamespace(JoinNamespacePath(c.Namespace(), child))
+}
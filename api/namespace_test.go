@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJoinNamespacePath(t *testing.T) {
+	cases := []struct {
+		elems    []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"root"}, ""},
+		{[]string{"", "team-a"}, "team-a"},
+		{[]string{"root/", "/team-a/"}, "team-a"},
+		{[]string{"team-a/", "app//db/"}, "team-a/app/db"},
+		{[]string{"team-a", "root"}, "team-a/root"},
+	}
+	for _, tc := range cases {
+		if actual := JoinNamespacePath(tc.elems...); actual != tc.expected {
+			t.Fatalf("JoinNamespacePath(%q): expected %q, got %q", tc.elems, tc.expected, actual)
+		}
+	}
+}
+
+func TestValidateNamespacePath(t *testing.T) {
+	for _, path := range []string{"", "root", "team-a", "/team-a/app/"} {
+		if err := ValidateNamespacePath(path); err != nil {
+			t.Fatalf("expected %q to be valid: %v", path, err)
+		}
+	}
+	for _, path := range []string{"team-a//app", "team-a/..", "team a", "team-*", "team-a/sys", "identity"} {
+		if err := ValidateNamespacePath(path); err == nil {
+			t.Fatalf("expected %q to be invalid", path)
+		}
+	}
+}
+
+func TestClient_WithChildNamespace(t *testing.T) {
+	client, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetNamespace("team-a/")
+
+	child := client.WithChildNamespace("app")
+	if ns := child.Namespace(); ns != "team-a/app" {
+		t.Fatalf("expected the child namespace, got %q", ns)
+	}
+	if ns := client.Namespace(); ns != "team-a/" {
+		t.Fatalf("expected the namespace of the parent client to be unchanged, got %q", ns)
+	}
+}
+
+func TestSys_WalkNamespaces(t *testing.T) {
+	// The server does not paginate, and lists the children of the namespace
+	// of the request
+	tree := map[string][]string{
+		"":                {"team-b/", "team-a/"},
+		"team-a":          {"app/", "db/"},
+		"team-a/app":      {"prod/"},
+		"team-b":          {"legacy/"},
+		"team-b/legacy":   {"old/"},
+		"team-a/app/prod": nil,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns := strings.Trim(r.Header.Get(NamespaceHeaderName), "/")
+		keys := tree[ns]
+		if r.URL.Path != "/v1/sys/namespaces" || len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		keyInfo := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			keyInfo[key] = map[string]interface{}{"id": JoinNamespacePath(ns, key)}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys, "key_info": keyInfo},
+		})
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Address = server.URL
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	err = client.Sys().WalkNamespaces(func(ns *NamespaceEntry) error {
+		if ns.ID != ns.Path {
+			t.Fatalf("unexpected entry: %#v", ns)
+		}
+		visited = append(visited, ns.Path)
+		if ns.Path == "team-b/legacy" {
+			return SkipNamespaceChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"team-a", "team-a/app", "team-a/app/prod", "team-a/db", "team-b", "team-b/legacy"}
+	if !reflect.DeepEqual(expected, visited) {
+		t.Fatalf("expected %v, got %v", expected, visited)
+	}
+
+	page, err := client.WithChildNamespace("team-a").Sys().ListNamespacesPage("app", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].Path != "team-a/db" {
+		t.Fatalf("expected the page after app to hold db, got %#v", page)
+	}
+}
@@ -27,6 +27,11 @@ type Request struct {
 	WrapTTL       string
 	Obj           interface{}
 
+	// IdempotencyKey, if set on a mutating request, causes the server to
+	// record the result and replay it, rather than performing the mutation
+	// again, if the request is retried with the same key.
+	IdempotencyKey string
+
 	// When possible, use BodyBytes as it is more efficient due to how the
 	// retry logic works
 	BodyBytes []byte
@@ -138,6 +143,10 @@ func (r *Request) toRetryableHTTP() (*retryablehttp.Request, error) {
 		req.Header.Set("X-Vault-Wrap-TTL", r.WrapTTL)
 	}
 
+	if len(r.IdempotencyKey) != 0 {
+		req.Header.Set(IdempotencyKeyHeaderName, r.IdempotencyKey)
+	}
+
 	if len(r.MFAHeaderVals) != 0 {
 		for _, mfaHeaderVal := range r.MFAHeaderVals {
 			req.Header.Add("X-Vault-MFA", mfaHeaderVal)
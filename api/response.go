@@ -68,6 +68,8 @@ func (r *Response) Error() error {
 	} else {
 		// Store the decoded errors
 		respErr.Errors = resp.Errors
+		respErr.ErrorCode = resp.ErrorCode
+		respErr.ErrorDetails = resp.ErrorDetails
 	}
 
 	return respErr
@@ -76,7 +78,9 @@ func (r *Response) Error() error {
 // ErrorResponse is the raw structure of errors when they're returned by the
 // HTTP API.
 type ErrorResponse struct {
-	Errors []string
+	Errors       []string
+	ErrorCode    string                 `json:"error_code"`
+	ErrorDetails map[string]interface{} `json:"error_details"`
 }
 
 // ResponseError is the error returned when Vault responds with an error or
@@ -104,6 +108,15 @@ type ResponseError struct {
 	// Namespace path to be reported to the client if it is set to anything other
 	// than root
 	NamespacePath string
+
+	// ErrorCode is the stable, machine-readable identifier of the class of
+	// error returned by Vault, e.g. "permission_denied". It is empty if the
+	// server did not provide one. See the ErrorCode* constants.
+	ErrorCode string
+
+	// ErrorDetails holds structured details about the error, if any were
+	// provided by the server.
+	ErrorDetails map[string]interface{}
 }
 
 // Error returns a human-readable error string for the response error.
@@ -319,6 +319,13 @@ type SecretAuth struct {
 	Renewable     bool `json:"renewable"`
 
 	MFARequirement *MFARequirement `json:"mfa_requirement"`
+
+	// EntityMetadata, GroupNames, NamespaceID and NamespacePath are only set
+	// by auth mounts tuned to include them in their login responses.
+	EntityMetadata map[string]string `json:"entity_metadata,omitempty"`
+	GroupNames     []string          `json:"group_names,omitempty"`
+	NamespaceID    string            `json:"namespace_id,omitempty"`
+	NamespacePath  string            `json:"namespace_path,omitempty"`
 }
 
 // ParseSecret is used to parse a secret value from JSON from an io.Reader.
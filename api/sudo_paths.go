@@ -24,9 +24,20 @@ var sudoPaths = map[string]*regexp.Regexp{
 	"/sys/auth/{path}/tune":                         regexp.MustCompile(`^/sys/auth/.+/tune$`),
 	"/sys/config/auditing/request-headers":          regexp.MustCompile(`^/sys/config/auditing/request-headers$`),
 	"/sys/config/auditing/request-headers/{header}": regexp.MustCompile(`^/sys/config/auditing/request-headers/.+$`),
+	"/sys/config/client-hints":                      regexp.MustCompile(`^/sys/config/client-hints$`),
+	"/sys/config/fair-share":                        regexp.MustCompile(`^/sys/config/fair-share$`),
+	"/sys/config/login-enrichment":                  regexp.MustCompile(`^/sys/config/login-enrichment$`),
+	"/sys/config/path-rewrites":                     regexp.MustCompile(`^/sys/config/path-rewrites/?$`),
+	"/sys/config/path-rewrites/{name}":              regexp.MustCompile(`^/sys/config/path-rewrites/.+$`),
+	"/sys/config/token-anomaly/{path}":              regexp.MustCompile(`^/sys/config/token-anomaly/.+$`),
 	"/sys/config/cors":                              regexp.MustCompile(`^/sys/config/cors$`),
 	"/sys/config/ui/headers":                        regexp.MustCompile(`^/sys/config/ui/headers/?$`),
 	"/sys/config/ui/headers/{header}":               regexp.MustCompile(`^/sys/config/ui/headers/.+$`),
+	"/sys/deception/config":                         regexp.MustCompile(`^/sys/deception/config$`),
+	"/sys/deception/paths":                          regexp.MustCompile(`^/sys/deception/paths/?$`),
+	"/sys/deception/paths/{name}":                   regexp.MustCompile(`^/sys/deception/paths/.+$`),
+	"/sys/deception/tokens":                         regexp.MustCompile(`^/sys/deception/tokens/?$`),
+	"/sys/deception/tokens/{name}":                  regexp.MustCompile(`^/sys/deception/tokens/.+$`),
 	"/sys/internal/inspect/router/{tag}":            regexp.MustCompile(`^/sys/internal/inspect/router/.+$`),
 	"/sys/leases":                                   regexp.MustCompile(`^/sys/leases$`),
 	// This entry is a bit wrong... sys/leases/lookup does NOT require sudo. But sys/leases/lookup/ with a trailing
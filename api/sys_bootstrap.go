@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Bootstrap initializes the cluster if it is not yet, and enables the audit
+// device and auth method and writes the admin policy of the request if they
+// are not yet. The client token is only needed once the cluster is
+// initialized.
+func (c *Sys) Bootstrap(opts *BootstrapRequest) (*BootstrapResponse, error) {
+	return c.BootstrapWithContext(context.Background(), opts)
+}
+
+func (c *Sys) BootstrapWithContext(ctx context.Context, opts *BootstrapRequest) (*BootstrapResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/bootstrap")
+	if err := r.SetJSONBody(opts); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result BootstrapResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+type BootstrapRequest struct {
+	Init        *InitRequest     `json:"init,omitempty"`
+	Audit       *BootstrapMount  `json:"audit,omitempty"`
+	Auth        *BootstrapMount  `json:"auth,omitempty"`
+	AdminPolicy *BootstrapPolicy `json:"admin_policy,omitempty"`
+	WrapTTL     string           `json:"wrap_ttl,omitempty"`
+}
+
+type BootstrapMount struct {
+	Path        string            `json:"path"`
+	Type        string            `json:"type"`
+	Description string            `json:"description,omitempty"`
+	Options     map[string]string `json:"options,omitempty"`
+}
+
+type BootstrapPolicy struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+type BootstrapResponse struct {
+	Initialized bool            `json:"initialized"`
+	Changes     []string        `json:"changes"`
+	WrapInfo    *SecretWrapInfo `json:"wrap_info,omitempty"`
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ClientHints returns the client behavior defaults advertised by the server.
+func (c *Sys) ClientHints() (*ClientHintsResponse, error) {
+	return c.ClientHintsWithContext(context.Background())
+}
+
+func (c *Sys) ClientHintsWithContext(ctx context.Context) (*ClientHintsResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/internal/client-hints")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result ClientHintsResponse
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureClientHints sets the client behavior defaults advertised by the
+// server. It requires sudo capability on sys/config/client-hints.
+func (c *Sys) ConfigureClientHints(req *ClientHintsRequest) error {
+	return c.ConfigureClientHintsWithContext(context.Background(), req)
+}
+
+func (c *Sys) ConfigureClientHintsWithContext(ctx context.Context, req *ClientHintsRequest) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/config/client-hints")
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// ApplyClientHints configures the client's HedgingPolicy and CircuitBreaker
+// from the given server-advertised hints, replacing any existing policies.
+// Hints which are disabled clear the corresponding policy.
+func (c *Client) ApplyClientHints(hints *ClientHintsResponse) {
+	if hints == nil {
+		return
+	}
+
+	var hedgingPolicy HedgingPolicy
+	if hints.HedgingEnabled {
+		hedgingPolicy = &ConstantHedgingPolicy{
+			Delay:             time.Duration(hints.HedgeDelayMillis) * time.Millisecond,
+			MaxHedgedRequests: hints.MaxHedgedRequests,
+		}
+	}
+	c.SetHedgingPolicy(hedgingPolicy)
+
+	var circuitBreaker CircuitBreaker
+	if hints.CircuitBreakerEnabled {
+		circuitBreaker = NewConsecutiveFailureBreaker(
+			hints.CircuitBreakerFailureThreshold,
+			time.Duration(hints.CircuitBreakerOpenDuration)*time.Second,
+		)
+	}
+	c.SetCircuitBreaker(circuitBreaker)
+}
+
+type ClientHintsRequest struct {
+	HedgingEnabled                 bool `json:"hedging_enabled" mapstructure:"hedging_enabled"`
+	HedgeDelayMillis               int  `json:"hedge_delay_ms" mapstructure:"hedge_delay_ms"`
+	MaxHedgedRequests              int  `json:"max_hedged_requests" mapstructure:"max_hedged_requests"`
+	CircuitBreakerEnabled          bool `json:"circuit_breaker_enabled" mapstructure:"circuit_breaker_enabled"`
+	CircuitBreakerFailureThreshold int  `json:"circuit_breaker_failure_threshold" mapstructure:"circuit_breaker_failure_threshold"`
+	CircuitBreakerOpenDuration     int  `json:"circuit_breaker_open_duration" mapstructure:"circuit_breaker_open_duration"`
+}
+
+type ClientHintsResponse struct {
+	HedgingEnabled                 bool `json:"hedging_enabled" mapstructure:"hedging_enabled"`
+	HedgeDelayMillis               int  `json:"hedge_delay_ms" mapstructure:"hedge_delay_ms"`
+	MaxHedgedRequests              int  `json:"max_hedged_requests" mapstructure:"max_hedged_requests"`
+	CircuitBreakerEnabled          bool `json:"circuit_breaker_enabled" mapstructure:"circuit_breaker_enabled"`
+	CircuitBreakerFailureThreshold int  `json:"circuit_breaker_failure_threshold" mapstructure:"circuit_breaker_failure_threshold"`
+	CircuitBreakerOpenDuration     int  `json:"circuit_breaker_open_duration" mapstructure:"circuit_breaker_open_duration"`
+}
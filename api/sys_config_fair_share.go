@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// FairShareConfig returns the fair-share request scheduling configuration.
+func (c *Sys) FairShareConfig() (*FairShareConfigResponse, error) {
+	return c.FairShareConfigWithContext(context.Background())
+}
+
+func (c *Sys) FairShareConfigWithContext(ctx context.Context) (*FairShareConfigResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/config/fair-share")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result FairShareConfigResponse
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureFairShare updates the fair-share request scheduling
+// configuration. Fields which are not set retain their current value.
+func (c *Sys) ConfigureFairShare(req *FairShareConfigRequest) error {
+	return c.ConfigureFairShareWithContext(context.Background(), req)
+}
+
+func (c *Sys) ConfigureFairShareWithContext(ctx context.Context, req *FairShareConfigRequest) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/config/fair-share")
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DisableFairShare removes the fair-share request scheduling configuration.
+func (c *Sys) DisableFairShare() error {
+	return c.DisableFairShareWithContext(context.Background())
+}
+
+func (c *Sys) DisableFairShareWithContext(ctx context.Context) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodDelete, "/v1/sys/config/fair-share")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type FairShareConfigRequest struct {
+	Enabled               *bool          `json:"enabled,omitempty"`
+	MaxConcurrentRequests int            `json:"max_concurrent_requests,omitempty"`
+	DefaultShares         int            `json:"default_shares,omitempty"`
+	NamespaceShares       map[string]int `json:"namespace_shares,omitempty"`
+}
+
+type FairShareConfigResponse struct {
+	Enabled               bool           `json:"enabled" mapstructure:"enabled"`
+	MaxConcurrentRequests int            `json:"max_concurrent_requests" mapstructure:"max_concurrent_requests"`
+	DefaultShares         int            `json:"default_shares" mapstructure:"default_shares"`
+	NamespaceShares       map[string]int `json:"namespace_shares" mapstructure:"namespace_shares"`
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// LoginEnrichmentConfig returns the login enrichment configuration.
+func (c *Sys) LoginEnrichmentConfig() (*LoginEnrichmentConfigResponse, error) {
+	return c.LoginEnrichmentConfigWithContext(context.Background())
+}
+
+func (c *Sys) LoginEnrichmentConfigWithContext(ctx context.Context) (*LoginEnrichmentConfigResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/config/login-enrichment")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result LoginEnrichmentConfigResponse
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureLoginEnrichment updates the login enrichment configuration.
+// Fields which are not set retain their current value.
+func (c *Sys) ConfigureLoginEnrichment(req *LoginEnrichmentConfigRequest) error {
+	return c.ConfigureLoginEnrichmentWithContext(context.Background(), req)
+}
+
+func (c *Sys) ConfigureLoginEnrichmentWithContext(ctx context.Context, req *LoginEnrichmentConfigRequest) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/config/login-enrichment")
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DisableLoginEnrichment removes the login enrichment configuration.
+func (c *Sys) DisableLoginEnrichment() error {
+	return c.DisableLoginEnrichmentWithContext(context.Background())
+}
+
+func (c *Sys) DisableLoginEnrichmentWithContext(ctx context.Context) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodDelete, "/v1/sys/config/login-enrichment")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type LoginEnrichmentConfigRequest struct {
+	Enabled          *bool    `json:"enabled,omitempty"`
+	ServiceURL       string   `json:"service_url,omitempty"`
+	Timeout          string   `json:"timeout,omitempty"`
+	FailClosed       *bool    `json:"fail_closed,omitempty"`
+	BlockedCountries []string `json:"blocked_countries,omitempty"`
+	BlockedASNs      []int    `json:"blocked_asns,omitempty"`
+	MaxRiskScore     float64  `json:"max_risk_score,omitempty"`
+}
+
+type LoginEnrichmentConfigResponse struct {
+	Enabled          bool     `json:"enabled" mapstructure:"enabled"`
+	ServiceURL       string   `json:"service_url" mapstructure:"service_url"`
+	Timeout          int      `json:"timeout" mapstructure:"timeout"`
+	FailClosed       bool     `json:"fail_closed" mapstructure:"fail_closed"`
+	BlockedCountries []string `json:"blocked_countries" mapstructure:"blocked_countries"`
+	BlockedASNs      []int    `json:"blocked_asns" mapstructure:"blocked_asns"`
+	MaxRiskScore     float64  `json:"max_risk_score" mapstructure:"max_risk_score"`
+}
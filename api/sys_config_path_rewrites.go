@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ListPathRewriteRules returns the names of the path rewrite rules of the
+// namespace.
+func (c *Sys) ListPathRewriteRules() ([]string, error) {
+	return c.ListPathRewriteRulesWithContext(context.Background())
+}
+
+func (c *Sys) ListPathRewriteRulesWithContext(ctx context.Context) ([]string, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest("LIST", "/v1/sys/config/path-rewrites")
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	r.Method = http.MethodGet
+	r.Params.Set("list", "true")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result []string
+	err = mapstructure.Decode(secret.Data["keys"], &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, err
+}
+
+// GetPathRewriteRule returns the path rewrite rule with the given name, or
+// nil if it does not exist.
+func (c *Sys) GetPathRewriteRule(name string) (*PathRewriteRule, error) {
+	return c.GetPathRewriteRuleWithContext(context.Background(), name)
+}
+
+func (c *Sys) GetPathRewriteRuleWithContext(ctx context.Context, name string) (*PathRewriteRule, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, fmt.Sprintf("/v1/sys/config/path-rewrites/%s", name))
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result PathRewriteRule
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// PutPathRewriteRule creates or updates a rule rewriting requests to from,
+// to.
+func (c *Sys) PutPathRewriteRule(name, from, to string) error {
+	return c.PutPathRewriteRuleWithContext(context.Background(), name, from, to)
+}
+
+func (c *Sys) PutPathRewriteRuleWithContext(ctx context.Context, name, from, to string) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sys/config/path-rewrites/%s", name))
+	if err := r.SetJSONBody(map[string]string{
+		"from": from,
+		"to":   to,
+	}); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeletePathRewriteRule deletes the path rewrite rule with the given name.
+func (c *Sys) DeletePathRewriteRule(name string) error {
+	return c.DeletePathRewriteRuleWithContext(context.Background(), name)
+}
+
+func (c *Sys) DeletePathRewriteRuleWithContext(ctx context.Context, name string) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/sys/config/path-rewrites/%s", name))
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type PathRewriteRule struct {
+	Name         string `json:"name" mapstructure:"name"`
+	From         string `json:"from" mapstructure:"from"`
+	To           string `json:"to" mapstructure:"to"`
+	CreationTime string `json:"creation_time" mapstructure:"creation_time"`
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// TokenAnomalyConfig returns the token anomaly detection configuration of
+// the auth mount at mountPath, or nil if it has none.
+func (c *Sys) TokenAnomalyConfig(mountPath string) (*TokenAnomalyConfigResponse, error) {
+	return c.TokenAnomalyConfigWithContext(context.Background(), mountPath)
+}
+
+func (c *Sys) TokenAnomalyConfigWithContext(ctx context.Context, mountPath string) (*TokenAnomalyConfigResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, fmt.Sprintf("/v1/sys/config/token-anomaly/%s", mountPath))
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result TokenAnomalyConfigResponse
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureTokenAnomaly enables or updates token anomaly detection for the
+// auth mount at mountPath. Fields which are not set retain their current
+// value.
+func (c *Sys) ConfigureTokenAnomaly(mountPath string, req *TokenAnomalyConfigRequest) error {
+	return c.ConfigureTokenAnomalyWithContext(context.Background(), mountPath, req)
+}
+
+func (c *Sys) ConfigureTokenAnomalyWithContext(ctx context.Context, mountPath string, req *TokenAnomalyConfigRequest) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sys/config/token-anomaly/%s", mountPath))
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DisableTokenAnomaly disables token anomaly detection for the auth mount at
+// mountPath.
+func (c *Sys) DisableTokenAnomaly(mountPath string) error {
+	return c.DisableTokenAnomalyWithContext(context.Background(), mountPath)
+}
+
+func (c *Sys) DisableTokenAnomalyWithContext(ctx context.Context, mountPath string) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/sys/config/token-anomaly/%s", mountPath))
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type TokenAnomalyConfigRequest struct {
+	DetectNewNetwork   *bool   `json:"detect_new_network,omitempty"`
+	DetectNewUserAgent *bool   `json:"detect_new_user_agent,omitempty"`
+	RateMultiplier     float64 `json:"rate_multiplier,omitempty"`
+	RateMinRequests    int     `json:"rate_min_requests,omitempty"`
+	ReduceTTLTo        string  `json:"reduce_ttl_to,omitempty"`
+}
+
+type TokenAnomalyConfigResponse struct {
+	DetectNewNetwork   bool    `json:"detect_new_network" mapstructure:"detect_new_network"`
+	DetectNewUserAgent bool    `json:"detect_new_user_agent" mapstructure:"detect_new_user_agent"`
+	RateMultiplier     float64 `json:"rate_multiplier" mapstructure:"rate_multiplier"`
+	RateMinRequests    int     `json:"rate_min_requests" mapstructure:"rate_min_requests"`
+	ReduceTTLTo        int     `json:"reduce_ttl_to" mapstructure:"reduce_ttl_to"`
+}
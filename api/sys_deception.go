@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DeceptionConfig returns the configuration of how deception triggers are
+// reported.
+func (c *Sys) DeceptionConfig() (*DeceptionConfig, error) {
+	return c.DeceptionConfigWithContext(context.Background())
+}
+
+func (c *Sys) DeceptionConfigWithContext(ctx context.Context) (*DeceptionConfig, error) {
+	secret, err := c.readDeception(ctx, "/v1/sys/deception/config")
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result DeceptionConfig
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureDeception updates the configuration of how deception triggers
+// are reported.
+func (c *Sys) ConfigureDeception(config *DeceptionConfig) error {
+	return c.ConfigureDeceptionWithContext(context.Background(), config)
+}
+
+func (c *Sys) ConfigureDeceptionWithContext(ctx context.Context, config *DeceptionConfig) error {
+	return c.writeDeception(ctx, http.MethodPut, "/v1/sys/deception/config", config)
+}
+
+// CreateHoneytoken creates a honeytoken with the given name and returns the
+// token. The token cannot be retrieved again later.
+func (c *Sys) CreateHoneytoken(name string) (string, error) {
+	return c.CreateHoneytokenWithContext(context.Background(), name)
+}
+
+func (c *Sys) CreateHoneytokenWithContext(ctx context.Context, name string) (string, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sys/deception/tokens/%s", name))
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", errors.New("data from server response is empty")
+	}
+
+	token, ok := secret.Data["token"].(string)
+	if !ok {
+		return "", errors.New("token missing from server response")
+	}
+	return token, nil
+}
+
+// ListHoneytokens returns the names of all honeytokens.
+func (c *Sys) ListHoneytokens() ([]string, error) {
+	return c.ListHoneytokensWithContext(context.Background())
+}
+
+func (c *Sys) ListHoneytokensWithContext(ctx context.Context) ([]string, error) {
+	return c.listDeception(ctx, "/v1/sys/deception/tokens")
+}
+
+// DeleteHoneytoken deletes the honeytoken with the given name.
+func (c *Sys) DeleteHoneytoken(name string) error {
+	return c.DeleteHoneytokenWithContext(context.Background(), name)
+}
+
+func (c *Sys) DeleteHoneytokenWithContext(ctx context.Context, name string) error {
+	return c.writeDeception(ctx, http.MethodDelete, fmt.Sprintf("/v1/sys/deception/tokens/%s", name), nil)
+}
+
+// PutDecoyPath registers path, relative to the client's namespace, as a
+// decoy path with the given name.
+func (c *Sys) PutDecoyPath(name, path string) error {
+	return c.PutDecoyPathWithContext(context.Background(), name, path)
+}
+
+func (c *Sys) PutDecoyPathWithContext(ctx context.Context, name, path string) error {
+	body := map[string]string{
+		"path": path,
+	}
+	return c.writeDeception(ctx, http.MethodPut, fmt.Sprintf("/v1/sys/deception/paths/%s", name), body)
+}
+
+// GetDecoyPath returns the decoy path with the given name, or nil if it does
+// not exist.
+func (c *Sys) GetDecoyPath(name string) (*DecoyPath, error) {
+	return c.GetDecoyPathWithContext(context.Background(), name)
+}
+
+func (c *Sys) GetDecoyPathWithContext(ctx context.Context, name string) (*DecoyPath, error) {
+	secret, err := c.readDeception(ctx, fmt.Sprintf("/v1/sys/deception/paths/%s", name))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result DecoyPath
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ListDecoyPaths returns the names of all decoy paths.
+func (c *Sys) ListDecoyPaths() ([]string, error) {
+	return c.ListDecoyPathsWithContext(context.Background())
+}
+
+func (c *Sys) ListDecoyPathsWithContext(ctx context.Context) ([]string, error) {
+	return c.listDeception(ctx, "/v1/sys/deception/paths")
+}
+
+// DeleteDecoyPath deletes the decoy path with the given name.
+func (c *Sys) DeleteDecoyPath(name string) error {
+	return c.DeleteDecoyPathWithContext(context.Background(), name)
+}
+
+func (c *Sys) DeleteDecoyPathWithContext(ctx context.Context, name string) error {
+	return c.writeDeception(ctx, http.MethodDelete, fmt.Sprintf("/v1/sys/deception/paths/%s", name), nil)
+}
+
+func (c *Sys) readDeception(ctx context.Context, path string) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, path)
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSecret(resp.Body)
+}
+
+func (c *Sys) writeDeception(ctx context.Context, method, path string, body interface{}) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(method, path)
+	if body != nil {
+		if err := r.SetJSONBody(body); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) listDeception(ctx context.Context, path string) ([]string, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest("LIST", path)
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	r.Method = http.MethodGet
+	r.Params.Set("list", "true")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result []string
+	err = mapstructure.Decode(secret.Data["keys"], &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, err
+}
+
+type DeceptionConfig struct {
+	WebhookURL string `json:"webhook_url" mapstructure:"webhook_url"`
+}
+
+type DecoyPath struct {
+	Name         string `json:"name" mapstructure:"name"`
+	Path         string `json:"path" mapstructure:"path"`
+	CreationTime string `json:"creation_time" mapstructure:"creation_time"`
+}
@@ -282,6 +282,7 @@ type MountInput struct {
 	Local                 bool              `json:"local"`
 	SealWrap              bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
 	ExternalEntropyAccess bool              `json:"external_entropy_access" mapstructure:"external_entropy_access"`
+	MountKey              bool              `json:"mount_key,omitempty" mapstructure:"mount_key"`
 	Options               map[string]string `json:"options"`
 
 	// Deprecated: Newer server responses should be returning this information in the
@@ -290,22 +291,32 @@ type MountInput struct {
 }
 
 type MountConfigInput struct {
-	Options                   map[string]string       `json:"options" mapstructure:"options"`
-	DefaultLeaseTTL           string                  `json:"default_lease_ttl" mapstructure:"default_lease_ttl"`
-	Description               *string                 `json:"description,omitempty" mapstructure:"description"`
-	MaxLeaseTTL               string                  `json:"max_lease_ttl" mapstructure:"max_lease_ttl"`
-	ForceNoCache              bool                    `json:"force_no_cache" mapstructure:"force_no_cache"`
-	AuditNonHMACRequestKeys   []string                `json:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
-	AuditNonHMACResponseKeys  []string                `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
-	ListingVisibility         string                  `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
-	PassthroughRequestHeaders []string                `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
-	AllowedResponseHeaders    []string                `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
-	TokenType                 string                  `json:"token_type,omitempty" mapstructure:"token_type"`
-	AllowedManagedKeys        []string                `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
-	PluginVersion             string                  `json:"plugin_version,omitempty"`
-	UserLockoutConfig         *UserLockoutConfigInput `json:"user_lockout_config,omitempty"`
-	DelegatedAuthAccessors    []string                `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
-	IdentityTokenKey          string                  `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	Options                     map[string]string                `json:"options" mapstructure:"options"`
+	DefaultLeaseTTL             string                           `json:"default_lease_ttl" mapstructure:"default_lease_ttl"`
+	Description                 *string                          `json:"description,omitempty" mapstructure:"description"`
+	MaxLeaseTTL                 string                           `json:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	ForceNoCache                bool                             `json:"force_no_cache" mapstructure:"force_no_cache"`
+	AuditNonHMACRequestKeys     []string                         `json:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys    []string                         `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
+	ListingVisibility           string                           `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
+	PassthroughRequestHeaders   []string                         `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	AllowedResponseHeaders      []string                         `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
+	TokenType                   string                           `json:"token_type,omitempty" mapstructure:"token_type"`
+	AllowedManagedKeys          []string                         `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
+	PluginVersion               string                           `json:"plugin_version,omitempty"`
+	UserLockoutConfig           *UserLockoutConfigInput          `json:"user_lockout_config,omitempty"`
+	DelegatedAuthAccessors      []string                         `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
+	IdentityTokenKey            string                           `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	StrictFieldValidation       *bool                            `json:"strict_field_validation,omitempty" mapstructure:"strict_field_validation"`
+	CompatibilityLevel          *string                          `json:"compatibility_level,omitempty" mapstructure:"compatibility_level"`
+	CustomResponseHeaders       map[string]string                `json:"custom_response_headers,omitempty" mapstructure:"custom_response_headers"`
+	MaxChildTokenDepth          *int                             `json:"max_child_token_depth,omitempty" mapstructure:"max_child_token_depth"`
+	MaxEntityTokens             *int                             `json:"max_entity_tokens,omitempty" mapstructure:"max_entity_tokens"`
+	EntityTokenLimitAction      string                           `json:"entity_token_limit_action,omitempty" mapstructure:"entity_token_limit_action"`
+	LoginResponseEntityMetadata []string                         `json:"login_response_entity_metadata,omitempty" mapstructure:"login_response_entity_metadata"`
+	LoginResponseGroupNames     *bool                            `json:"login_response_group_names,omitempty" mapstructure:"login_response_group_names"`
+	LoginResponseNamespace      *bool                            `json:"login_response_namespace,omitempty" mapstructure:"login_response_namespace"`
+	LoginResponseRoles          map[string]*LoginResponseShaping `json:"login_response_roles,omitempty" mapstructure:"login_response_roles"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
@@ -321,26 +332,45 @@ type MountOutput struct {
 	Local                 bool              `json:"local"`
 	SealWrap              bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
 	ExternalEntropyAccess bool              `json:"external_entropy_access" mapstructure:"external_entropy_access"`
+	MountKey              bool              `json:"mount_key" mapstructure:"mount_key"`
 	PluginVersion         string            `json:"plugin_version" mapstructure:"plugin_version"`
 	RunningVersion        string            `json:"running_plugin_version" mapstructure:"running_plugin_version"`
 	RunningSha256         string            `json:"running_sha256" mapstructure:"running_sha256"`
 	DeprecationStatus     string            `json:"deprecation_status" mapstructure:"deprecation_status"`
 }
 
+// LoginResponseShaping selects the identity details included in the login
+// responses to a role of an auth mount.
+type LoginResponseShaping struct {
+	EntityMetadata []string `json:"entity_metadata,omitempty" mapstructure:"entity_metadata"`
+	GroupNames     bool     `json:"group_names,omitempty" mapstructure:"group_names"`
+	Namespace      bool     `json:"namespace,omitempty" mapstructure:"namespace"`
+}
+
 type MountConfigOutput struct {
-	DefaultLeaseTTL           int                      `json:"default_lease_ttl" mapstructure:"default_lease_ttl"`
-	MaxLeaseTTL               int                      `json:"max_lease_ttl" mapstructure:"max_lease_ttl"`
-	ForceNoCache              bool                     `json:"force_no_cache" mapstructure:"force_no_cache"`
-	AuditNonHMACRequestKeys   []string                 `json:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
-	AuditNonHMACResponseKeys  []string                 `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
-	ListingVisibility         string                   `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
-	PassthroughRequestHeaders []string                 `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
-	AllowedResponseHeaders    []string                 `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
-	TokenType                 string                   `json:"token_type,omitempty" mapstructure:"token_type"`
-	AllowedManagedKeys        []string                 `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
-	UserLockoutConfig         *UserLockoutConfigOutput `json:"user_lockout_config,omitempty"`
-	DelegatedAuthAccessors    []string                 `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
-	IdentityTokenKey          string                   `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	DefaultLeaseTTL             int                              `json:"default_lease_ttl" mapstructure:"default_lease_ttl"`
+	MaxLeaseTTL                 int                              `json:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	ForceNoCache                bool                             `json:"force_no_cache" mapstructure:"force_no_cache"`
+	AuditNonHMACRequestKeys     []string                         `json:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys    []string                         `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
+	ListingVisibility           string                           `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
+	PassthroughRequestHeaders   []string                         `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	AllowedResponseHeaders      []string                         `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
+	TokenType                   string                           `json:"token_type,omitempty" mapstructure:"token_type"`
+	AllowedManagedKeys          []string                         `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
+	UserLockoutConfig           *UserLockoutConfigOutput         `json:"user_lockout_config,omitempty"`
+	DelegatedAuthAccessors      []string                         `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
+	IdentityTokenKey            string                           `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	StrictFieldValidation       bool                             `json:"strict_field_validation,omitempty" mapstructure:"strict_field_validation"`
+	CompatibilityLevel          string                           `json:"compatibility_level,omitempty" mapstructure:"compatibility_level"`
+	CustomResponseHeaders       map[string]string                `json:"custom_response_headers,omitempty" mapstructure:"custom_response_headers"`
+	MaxChildTokenDepth          *int                             `json:"max_child_token_depth,omitempty" mapstructure:"max_child_token_depth"`
+	MaxEntityTokens             int                              `json:"max_entity_tokens,omitempty" mapstructure:"max_entity_tokens"`
+	EntityTokenLimitAction      string                           `json:"entity_token_limit_action,omitempty" mapstructure:"entity_token_limit_action"`
+	LoginResponseEntityMetadata []string                         `json:"login_response_entity_metadata,omitempty" mapstructure:"login_response_entity_metadata"`
+	LoginResponseGroupNames     bool                             `json:"login_response_group_names,omitempty" mapstructure:"login_response_group_names"`
+	LoginResponseNamespace      bool                             `json:"login_response_namespace,omitempty" mapstructure:"login_response_namespace"`
+	LoginResponseRoles          map[string]*LoginResponseShaping `json:"login_response_roles,omitempty" mapstructure:"login_response_roles"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
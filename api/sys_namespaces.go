@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DefaultNamespaceListPageSize is the number of namespaces requested per page
+// by WalkNamespaces.
+const DefaultNamespaceListPageSize = 100
+
+// SkipNamespaceChildren is returned by a NamespaceWalkFunc to skip the child
+// namespaces of the namespace it was called with.
+var SkipNamespaceChildren = errors.New("skip the child namespaces")
+
+// NamespaceEntry is a namespace listed in sys/namespaces.
+type NamespaceEntry struct {
+	// Name is the name of the namespace, without a trailing slash.
+	Name string `json:"-" mapstructure:"-"`
+	// Path is the full path of the namespace, including the namespace of the
+	// client which listed it, without leading or trailing slashes.
+	Path           string            `json:"-" mapstructure:"-"`
+	ID             string            `json:"id" mapstructure:"id"`
+	CustomMetadata map[string]string `json:"custom_metadata" mapstructure:"custom_metadata"`
+}
+
+// NamespaceWalkFunc is called by WalkNamespaces for each namespace visited.
+// Returning SkipNamespaceChildren skips the children of the namespace; any
+// other error stops the walk and is returned by WalkNamespaces.
+type NamespaceWalkFunc func(ns *NamespaceEntry) error
+
+// ListNamespacesPage calls ListNamespacesPageWithContext using a background
+// Context.
+func (c *Sys) ListNamespacesPage(after string, limit int) ([]*NamespaceEntry, error) {
+	return c.ListNamespacesPageWithContext(context.Background(), after, limit)
+}
+
+// ListNamespacesPageWithContext lists the child namespaces of the namespace
+// of the client, sorted by name, starting strictly after the name "after",
+// and returning at most limit of them if limit is positive. Pagination is
+// applied by the client as well, for servers which do not paginate the list.
+func (c *Sys) ListNamespacesPageWithContext(ctx context.Context, after string, limit int) ([]*NamespaceEntry, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest("LIST", "/v1/sys/namespaces")
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	r.Method = http.MethodGet
+	r.Params.Set("list", "true")
+	// Keys of namespaces end with a slash, and are paginated as such
+	afterKey := ""
+	if after != "" {
+		afterKey = strings.TrimSuffix(after, "/") + "/"
+		r.Params.Set("after", afterKey)
+	}
+	if limit > 0 {
+		r.Params.Set("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	var keyInfo map[string]*NamespaceEntry
+	if raw, ok := secret.Data["key_info"]; ok {
+		if err := mapstructure.Decode(raw, &keyInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(keys)
+	parent := c.c.Namespace()
+	var entries []*NamespaceEntry
+	for _, key := range keys {
+		if afterKey != "" && key <= afterKey {
+			continue
+		}
+		name := strings.TrimSuffix(key, "/")
+		entry := keyInfo[key]
+		if entry == nil {
+			entry = &NamespaceEntry{}
+		}
+		entry.Name = name
+		entry.Path = JoinNamespacePath(parent, name)
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// WalkNamespaces calls WalkNamespacesWithContext using a background Context.
+func (c *Sys) WalkNamespaces(fn NamespaceWalkFunc) error {
+	return c.WalkNamespacesWithContext(context.Background(), fn)
+}
+
+// WalkNamespacesWithContext visits the descendants of the namespace of the
+// client, depth first, parents before their children and siblings sorted by
+// name. Namespaces are listed a page of DefaultNamespaceListPageSize at a
+// time, through clients scoped to each parent namespace.
+func (c *Sys) WalkNamespacesWithContext(ctx context.Context, fn NamespaceWalkFunc) error {
+	return c.walkNamespaces(ctx, c.c.Namespace(), fn)
+}
+
+func (c *Sys) walkNamespaces(ctx context.Context, parent string, fn NamespaceWalkFunc) error {
+	sys := c.c.WithNamespace(parent).Sys()
+
+	var after string
+	for {
+		page, err := sys.ListNamespacesPageWithContext(ctx, after, DefaultNamespaceListPageSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page {
+			err := fn(entry)
+			switch {
+			case errors.Is(err, SkipNamespaceChildren):
+				continue
+			case err != nil:
+				return err
+			}
+			if err := c.walkNamespaces(ctx, entry.Path, fn); err != nil {
+				return err
+			}
+		}
+		if len(page) < DefaultNamespaceListPageSize {
+			return nil
+		}
+		after = page[len(page)-1].Name
+	}
+}
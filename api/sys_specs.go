@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// ClientManifest describes the endpoints of the mounts visible to the token,
+// for generating typed clients with GenerateClient.
+type ClientManifest struct {
+	ManifestVersion int                       `json:"manifest_version"`
+	VaultVersion    string                    `json:"vault_version"`
+	Namespace       string                    `json:"namespace"`
+	Endpoints       []*ClientManifestEndpoint `json:"endpoints"`
+}
+
+type ClientManifestEndpoint struct {
+	Path            string                     `json:"path"`
+	Sudo            bool                       `json:"sudo,omitempty"`
+	Unauthenticated bool                       `json:"unauthenticated,omitempty"`
+	Operations      []*ClientManifestOperation `json:"operations"`
+}
+
+type ClientManifestOperation struct {
+	Method      string                     `json:"method"`
+	OperationID string                     `json:"operation_id"`
+	Summary     string                     `json:"summary,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []*ClientManifestParameter `json:"parameters,omitempty"`
+}
+
+type ClientManifestParameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Type        string `json:"type"`
+	ItemType    string `json:"item_type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// ClientManifest returns the manifest of the endpoints of the mounts visible
+// to the token.
+func (c *Sys) ClientManifest() (*ClientManifest, error) {
+	return c.ClientManifestWithContext(context.Background())
+}
+
+func (c *Sys) ClientManifestWithContext(ctx context.Context) (*ClientManifest, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/internal/specs/clients")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ClientManifest
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
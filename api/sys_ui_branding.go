@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// UIBranding returns the UI branding configured in the namespace of the
+// client itself.
+func (c *Sys) UIBranding() (*UIBrandingResponse, error) {
+	return c.UIBrandingWithContext(context.Background())
+}
+
+func (c *Sys) UIBrandingWithContext(ctx context.Context) (*UIBrandingResponse, error) {
+	return c.uiBrandingWithContext(ctx, "/v1/sys/config/ui/branding")
+}
+
+// EffectiveUIBranding returns the UI branding in effect in the namespace of
+// the client, including the settings inherited from its ancestors. It does
+// not require a token.
+func (c *Sys) EffectiveUIBranding() (*UIBrandingResponse, error) {
+	return c.EffectiveUIBrandingWithContext(context.Background())
+}
+
+func (c *Sys) EffectiveUIBrandingWithContext(ctx context.Context) (*UIBrandingResponse, error) {
+	return c.uiBrandingWithContext(ctx, "/v1/sys/internal/ui/branding")
+}
+
+func (c *Sys) uiBrandingWithContext(ctx context.Context, path string) (*UIBrandingResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, path)
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result UIBrandingResponse
+	err = mapstructure.WeakDecode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
+}
+
+// ConfigureUIBranding updates the UI branding of the namespace of the client.
+// Fields which are nil retain their current value, and fields set to an empty
+// value are inherited from the parent namespace.
+func (c *Sys) ConfigureUIBranding(req *UIBrandingRequest) error {
+	return c.ConfigureUIBrandingWithContext(context.Background(), req)
+}
+
+func (c *Sys) ConfigureUIBrandingWithContext(ctx context.Context, req *UIBrandingRequest) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/config/ui/branding")
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeleteUIBranding removes the UI branding of the namespace of the client,
+// which then inherits the branding of its ancestors.
+func (c *Sys) DeleteUIBranding() error {
+	return c.DeleteUIBrandingWithContext(context.Background())
+}
+
+func (c *Sys) DeleteUIBrandingWithContext(ctx context.Context) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodDelete, "/v1/sys/config/ui/branding")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type UIBrandingRequest struct {
+	Logo         *string   `json:"logo,omitempty"`
+	PrimaryColor *string   `json:"primary_color,omitempty"`
+	LoginMessage *string   `json:"login_message,omitempty"`
+	FeatureFlags *[]string `json:"feature_flags,omitempty"`
+}
+
+type UIBrandingResponse struct {
+	Logo         string   `json:"logo" mapstructure:"logo"`
+	PrimaryColor string   `json:"primary_color" mapstructure:"primary_color"`
+	LoginMessage string   `json:"login_message" mapstructure:"login_message"`
+	FeatureFlags []string `json:"feature_flags" mapstructure:"feature_flags"`
+}
@@ -232,6 +232,11 @@ type UICustomMessageRequest struct {
 	EndTime       string               `json:"end_time,omitempty"`
 	Link          *uiCustomMessageLink `json:"link,omitempty"`
 	Options       map[string]any       `json:"options,omitempty"`
+
+	// RequireAcknowledgment requires interactive logins to acknowledge the
+	// message before they are issued a token. Only unauthenticated messages
+	// can require acknowledgment.
+	RequireAcknowledgment bool `json:"require_acknowledgment,omitempty"`
 }
 
 // WithLink sets the Link field to the address of a new uiCustomMessageLink
@@ -317,6 +317,9 @@ func (f *EntryFormatter) FormatRequest(ctx context.Context, in *logical.LogInput
 			ReplicationCluster:            req.ReplicationCluster,
 			Headers:                       req.Headers,
 			ClientCertificateSerialNumber: getClientCertificateSerialNumber(connState),
+			LoginEnrichment:               req.LoginEnrichment,
+			DeceptionTrigger:              req.DeceptionTrigger,
+			PathRewrite:                   req.PathRewrite,
 		},
 	}
 
@@ -521,6 +524,9 @@ func (f *EntryFormatter) FormatResponse(ctx context.Context, in *logical.LogInpu
 			ClientCertificateSerialNumber: getClientCertificateSerialNumber(connState),
 			ReplicationCluster:            req.ReplicationCluster,
 			Headers:                       req.Headers,
+			LoginEnrichment:               req.LoginEnrichment,
+			DeceptionTrigger:              req.DeceptionTrigger,
+			PathRewrite:                   req.PathRewrite,
 		},
 
 		Response: &Response{
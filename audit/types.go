@@ -93,29 +93,32 @@ type ResponseEntry struct {
 }
 
 type Request struct {
-	ID                            string                 `json:"id,omitempty"`
-	ClientID                      string                 `json:"client_id,omitempty"`
-	ReplicationCluster            string                 `json:"replication_cluster,omitempty"`
-	Operation                     logical.Operation      `json:"operation,omitempty"`
-	MountPoint                    string                 `json:"mount_point,omitempty"`
-	MountType                     string                 `json:"mount_type,omitempty"`
-	MountAccessor                 string                 `json:"mount_accessor,omitempty"`
-	MountRunningVersion           string                 `json:"mount_running_version,omitempty"`
-	MountRunningSha256            string                 `json:"mount_running_sha256,omitempty"`
-	MountClass                    string                 `json:"mount_class,omitempty"`
-	MountIsExternalPlugin         bool                   `json:"mount_is_external_plugin,omitempty"`
-	ClientToken                   string                 `json:"client_token,omitempty"`
-	ClientTokenAccessor           string                 `json:"client_token_accessor,omitempty"`
-	Namespace                     *Namespace             `json:"namespace,omitempty"`
-	Path                          string                 `json:"path,omitempty"`
-	Data                          map[string]interface{} `json:"data,omitempty"`
-	PolicyOverride                bool                   `json:"policy_override,omitempty"`
-	RemoteAddr                    string                 `json:"remote_address,omitempty"`
-	RemotePort                    int                    `json:"remote_port,omitempty"`
-	WrapTTL                       int                    `json:"wrap_ttl,omitempty"`
-	Headers                       map[string][]string    `json:"headers,omitempty"`
-	ClientCertificateSerialNumber string                 `json:"client_certificate_serial_number,omitempty"`
-	RequestURI                    string                 `json:"request_uri,omitempty"`
+	ID                            string                    `json:"id,omitempty"`
+	ClientID                      string                    `json:"client_id,omitempty"`
+	ReplicationCluster            string                    `json:"replication_cluster,omitempty"`
+	Operation                     logical.Operation         `json:"operation,omitempty"`
+	MountPoint                    string                    `json:"mount_point,omitempty"`
+	MountType                     string                    `json:"mount_type,omitempty"`
+	MountAccessor                 string                    `json:"mount_accessor,omitempty"`
+	MountRunningVersion           string                    `json:"mount_running_version,omitempty"`
+	MountRunningSha256            string                    `json:"mount_running_sha256,omitempty"`
+	MountClass                    string                    `json:"mount_class,omitempty"`
+	MountIsExternalPlugin         bool                      `json:"mount_is_external_plugin,omitempty"`
+	ClientToken                   string                    `json:"client_token,omitempty"`
+	ClientTokenAccessor           string                    `json:"client_token_accessor,omitempty"`
+	Namespace                     *Namespace                `json:"namespace,omitempty"`
+	Path                          string                    `json:"path,omitempty"`
+	Data                          map[string]interface{}    `json:"data,omitempty"`
+	PolicyOverride                bool                      `json:"policy_override,omitempty"`
+	RemoteAddr                    string                    `json:"remote_address,omitempty"`
+	RemotePort                    int                       `json:"remote_port,omitempty"`
+	WrapTTL                       int                       `json:"wrap_ttl,omitempty"`
+	Headers                       map[string][]string       `json:"headers,omitempty"`
+	ClientCertificateSerialNumber string                    `json:"client_certificate_serial_number,omitempty"`
+	RequestURI                    string                    `json:"request_uri,omitempty"`
+	LoginEnrichment               *logical.LoginEnrichment  `json:"login_enrichment,omitempty"`
+	DeceptionTrigger              *logical.DeceptionTrigger `json:"deception_trigger,omitempty"`
+	PathRewrite                   *logical.PathRewrite      `json:"path_rewrite,omitempty"`
 }
 
 type Response struct {
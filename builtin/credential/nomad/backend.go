@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixNomad = "nomad"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	b := &backend{
+		keySets: make(map[string]*keySet),
+	}
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"login",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListRegions(b),
+			pathRegions(b),
+			pathListRoles(b),
+			pathRoles(b),
+			pathLogin(b),
+		},
+
+		AuthRenew:   b.pathLoginRenew,
+		Invalidate:  b.invalidate,
+		BackendType: logical.TypeCredential,
+	}
+
+	return b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// keySets caches the signing keys of each region, keyed by region name
+	keySets     map[string]*keySet
+	keySetsLock sync.Mutex
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	if strings.HasPrefix(key, "region/") {
+		b.resetKeySet(strings.TrimPrefix(key, "region/"))
+	}
+}
+
+const backendHelp = `
+The Nomad credential provider allows Nomad workloads to authenticate using
+their workload identity, a JWT signed by the Nomad servers of their region.
+
+Regions are configured at the "regions/" endpoints, which define where the
+signing keys of each region are discovered. Roles at the "roles/" endpoints
+bind the namespaces, jobs and other claims allowed to log in, and map claims
+onto the entity alias and token metadata.
+`
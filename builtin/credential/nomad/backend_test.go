@@ -0,0 +1,240 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type testRegion struct {
+	server *httptest.Server
+	key    jose.JSONWebKey
+}
+
+func newTestRegion(t *testing.T, kid string) *testRegion {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRegion{
+		key: jose.JSONWebKey{Key: priv, KeyID: kid, Algorithm: string(jose.EdDSA), Use: "sig"},
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/.well-known/jwks.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{r.key.Public()}})
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testRegion) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: r.key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func workloadClaims(namespace, jobID string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"sub":                 "global:" + namespace + ":" + jobID + ":web:server:vault_default",
+		"aud":                 []string{"vault.io"},
+		"iat":                 now.Unix(),
+		"nbf":                 now.Unix(),
+		"exp":                 now.Add(time.Hour).Unix(),
+		"nomad_namespace":     namespace,
+		"nomad_job_id":        jobID,
+		"nomad_task":          "server",
+		"nomad_allocation_id": "5ad5ea35-9c25-4d41-bfd2-7a8b7e7a6c35",
+	}
+}
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func doRequest(t *testing.T, b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    s,
+		Data:       data,
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+	if err != nil && err != logical.ErrInvalidRequest {
+		t.Fatalf("%s %s: %v", op, path, err)
+	}
+	return resp
+}
+
+func TestBackend_Login(t *testing.T) {
+	b, s := getBackend(t)
+	east := newTestRegion(t, "east-key")
+	west := newTestRegion(t, "west-key")
+
+	for name, region := range map[string]*testRegion{"east": east, "west": west} {
+		resp := doRequest(t, b, s, logical.CreateOperation, "regions/"+name, map[string]interface{}{
+			"nomad_addr": region.server.URL,
+		})
+		if resp.IsError() {
+			t.Fatalf("failed to configure region %s: %v", name, resp.Error())
+		}
+	}
+
+	resp := doRequest(t, b, s, logical.CreateOperation, "roles/web", map[string]interface{}{
+		"bound_audiences":  "vault.io",
+		"bound_namespaces": "default",
+		"bound_job_ids":    "web-*",
+		"claim_mappings":   map[string]interface{}{"sub": "subject"},
+		"token_policies":   "web",
+	})
+	if resp.IsError() {
+		t.Fatalf("failed to create role: %v", resp.Error())
+	}
+
+	resp = doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "web",
+		"jwt":  west.sign(t, workloadClaims("default", "web-frontend")),
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("expected login to succeed, got %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "web-frontend" {
+		t.Fatalf("expected alias named after the job ID, got %q", resp.Auth.Alias.Name)
+	}
+	if resp.Auth.Metadata["region"] != "west" || resp.Auth.Metadata["nomad_task"] != "server" {
+		t.Fatalf("unexpected metadata: %v", resp.Auth.Metadata)
+	}
+	if resp.Auth.Alias.Metadata["subject"] == "" {
+		t.Fatalf("expected sub claim to be mapped, got %v", resp.Auth.Alias.Metadata)
+	}
+	if len(resp.Auth.Policies) != 1 || resp.Auth.Policies[0] != "web" {
+		t.Fatalf("unexpected policies: %v", resp.Auth.Policies)
+	}
+
+	expired := workloadClaims("default", "web-frontend")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAudience := workloadClaims("default", "web-frontend")
+	wrongAudience["aud"] = "consul.io"
+
+	for name, token := range map[string]string{
+		"wrong namespace": east.sign(t, workloadClaims("batch", "web-frontend")),
+		"wrong job":       east.sign(t, workloadClaims("default", "api")),
+		"expired":         east.sign(t, expired),
+		"wrong audience":  east.sign(t, wrongAudience),
+		"unknown key":     newTestRegion(t, "other-key").sign(t, workloadClaims("default", "web-frontend")),
+	} {
+		resp := doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+			"role": "web",
+			"jwt":  token,
+		})
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected login to fail", name)
+		}
+	}
+}
+
+func TestBackend_LoginBoundRegion(t *testing.T) {
+	b, s := getBackend(t)
+	east := newTestRegion(t, "east-key")
+	west := newTestRegion(t, "west-key")
+
+	doRequest(t, b, s, logical.CreateOperation, "regions/east", map[string]interface{}{"nomad_addr": east.server.URL})
+	doRequest(t, b, s, logical.CreateOperation, "regions/west", map[string]interface{}{"nomad_addr": west.server.URL})
+	doRequest(t, b, s, logical.CreateOperation, "roles/east-only", map[string]interface{}{
+		"bound_regions": "east",
+	})
+
+	resp := doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "east-only",
+		"jwt":  west.sign(t, workloadClaims("default", "web")),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected token of an unbound region to be rejected")
+	}
+
+	resp = doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role":   "east-only",
+		"region": "west",
+		"jwt":    west.sign(t, workloadClaims("default", "web")),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected explicitly unbound region to be rejected")
+	}
+
+	resp = doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "east-only",
+		"jwt":  east.sign(t, workloadClaims("default", "web")),
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("expected token of the bound region to be accepted, got %#v", resp)
+	}
+}
+
+func TestBackend_KeyRefresh(t *testing.T) {
+	b, s := getBackend(t)
+	region := newTestRegion(t, "key-1")
+
+	doRequest(t, b, s, logical.CreateOperation, "regions/global", map[string]interface{}{"nomad_addr": region.server.URL})
+	doRequest(t, b, s, logical.CreateOperation, "roles/any", nil)
+
+	login := func() *logical.Response {
+		return doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+			"role": "any",
+			"jwt":  region.sign(t, workloadClaims("default", "web")),
+		})
+	}
+	if resp := login(); resp == nil || resp.IsError() {
+		t.Fatalf("expected login to succeed, got %#v", resp)
+	}
+
+	// Rotate the key of the region; the new key is picked up once the
+	// minimum refresh interval has passed.
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	region.key = jose.JSONWebKey{Key: priv, KeyID: "key-2", Algorithm: string(jose.EdDSA), Use: "sig"}
+	if resp := login(); resp == nil || !resp.IsError() {
+		t.Fatal("expected refresh to be rate limited")
+	}
+
+	b.keySetsLock.Lock()
+	b.keySets["global"].fetched = time.Now().Add(-keySetMinRefresh)
+	b.keySetsLock.Unlock()
+	if resp := login(); resp == nil || resp.IsError() {
+		t.Fatalf("expected login with the rotated key to succeed, got %#v", resp)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	// keySetTTL is how long fetched keys are used before being refreshed.
+	keySetTTL = 10 * time.Minute
+
+	// keySetMinRefresh limits how often keys are refetched on encountering
+	// an unknown key ID, so that forged tokens cannot be used to flood the
+	// discovery endpoint.
+	keySetMinRefresh = 10 * time.Second
+
+	// maxDiscoveryResponseSize bounds the size of discovery responses.
+	maxDiscoveryResponseSize = 1 << 20
+)
+
+var errUnknownKey = errors.New("token is signed with an unknown key")
+
+type keySet struct {
+	keys    jose.JSONWebKeySet
+	fetched time.Time
+}
+
+// key returns the key with the given ID from the signing keys of the region,
+// refreshing the cached keys if they are stale or do not contain it.
+func (b *backend) key(ctx context.Context, name string, region *regionConfig, kid string) (*jose.JSONWebKey, error) {
+	b.keySetsLock.Lock()
+	defer b.keySetsLock.Unlock()
+
+	ks := b.keySets[name]
+	if ks != nil && time.Since(ks.fetched) < keySetTTL {
+		if keys := ks.keys.Key(kid); len(keys) > 0 {
+			return &keys[0], nil
+		}
+		if time.Since(ks.fetched) < keySetMinRefresh {
+			return nil, errUnknownKey
+		}
+	}
+
+	keys, err := fetchKeySet(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching keys of region %q: %w", name, err)
+	}
+	ks = &keySet{
+		keys:    *keys,
+		fetched: time.Now(),
+	}
+	b.keySets[name] = ks
+
+	if keys := ks.keys.Key(kid); len(keys) > 0 {
+		return &keys[0], nil
+	}
+	return nil, errUnknownKey
+}
+
+func (b *backend) resetKeySet(name string) {
+	b.keySetsLock.Lock()
+	defer b.keySetsLock.Unlock()
+
+	delete(b.keySets, name)
+}
+
+func fetchKeySet(ctx context.Context, region *regionConfig) (*jose.JSONWebKeySet, error) {
+	client := cleanhttp.DefaultClient()
+	if region.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(region.CACert)) {
+			return nil, errors.New("invalid ca_cert")
+		}
+		transport := cleanhttp.DefaultTransport()
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+		client.Transport = transport
+	}
+
+	jwksURL := region.JWKSURL
+	switch {
+	case jwksURL != "":
+	case region.NomadAddr != "":
+		jwksURL = strings.TrimSuffix(region.NomadAddr, "/") + "/.well-known/jwks.json"
+	default:
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		discoveryURL := strings.TrimSuffix(region.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getJSON(ctx, client, discoveryURL, &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.Issuer != region.Issuer {
+			return nil, fmt.Errorf("discovered issuer %q does not match configured issuer %q", discovery.Issuer, region.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("discovery document does not contain a jwks_uri")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := getJSON(ctx, client, jwksURL, &keys); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDiscoveryResponseSize)).Decode(out)
+}
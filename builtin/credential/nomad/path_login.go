@@ -0,0 +1,364 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// claimsLeeway is the clock skew allowed when validating the time claims of
+// a token.
+const claimsLeeway = 60 * time.Second
+
+// supportedAlgorithms are the signing algorithms accepted for workload
+// identities. Nomad signs identities with RS256 or EdDSA keys.
+var supportedAlgorithms = []string{
+	string(jose.RS256),
+	string(jose.RS384),
+	string(jose.RS512),
+	string(jose.ES256),
+	string(jose.ES384),
+	string(jose.ES512),
+	string(jose.EdDSA),
+}
+
+// reservedMetadata are the metadata keys always set on login, which cannot be
+// the target of claim mappings.
+var reservedMetadata = map[string]string{
+	"role":                "",
+	"region":              "",
+	"nomad_namespace":     "nomad_namespace",
+	"nomad_job_id":        "nomad_job_id",
+	"nomad_task":          "nomad_task",
+	"nomad_allocation_id": "nomad_allocation_id",
+}
+
+func pathLogin(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNomad,
+			OperationVerb:   "login",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: "Name of the role to log in with.",
+			},
+
+			"jwt": {
+				Type:        framework.TypeString,
+				Description: "Workload identity token of the Nomad workload.",
+			},
+
+			"region": {
+				Type:        framework.TypeString,
+				Description: "Region which issued the token. If not set, the region is found by the key the token is signed with.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation:         b.pathLogin,
+			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
+		},
+
+		HelpSynopsis:    pathLoginHelpSyn,
+		HelpDescription: pathLoginHelpDesc,
+	}
+}
+
+// workloadIdentity is a verified workload identity token.
+type workloadIdentity struct {
+	role   *nomadRole
+	region string
+	claims map[string]interface{}
+}
+
+func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	identity, resp, err := b.verifyWorkloadIdentity(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	name, err := identity.aliasName()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return &logical.Response{
+		Auth: &logical.Auth{
+			Alias: &logical.Alias{
+				Name: name,
+			},
+		},
+	}, nil
+}
+
+func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	identity, resp, err := b.verifyWorkloadIdentity(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+	role := identity.role
+
+	// Check for a CIDR match.
+	if len(role.TokenBoundCIDRs) > 0 {
+		if req.Connection == nil {
+			b.Logger().Warn("token bound CIDRs found but no connection information available for validation")
+			return nil, logical.ErrPermissionDenied
+		}
+		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, role.TokenBoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
+	name, err := identity.aliasName()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	metadata := identity.metadata(data.Get("role").(string))
+	auth := &logical.Auth{
+		InternalData: map[string]interface{}{
+			"role": data.Get("role").(string),
+		},
+		Metadata:    metadata,
+		DisplayName: name,
+		Alias: &logical.Alias{
+			Name:     name,
+			Metadata: metadata,
+		},
+	}
+	role.PopulateTokenAuth(auth)
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.Auth == nil {
+		return nil, errors.New("request auth was nil")
+	}
+
+	roleName, ok := req.Auth.InternalData["role"].(string)
+	if !ok {
+		return nil, errors.New("no role found in token internal data")
+	}
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %q no longer exists", roleName)
+	}
+
+	if !policyutil.EquivalentPolicies(role.TokenPolicies, req.Auth.TokenPolicies) {
+		return nil, errors.New("policies have changed, not renewing")
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+	resp.Auth.Period = role.TokenPeriod
+	resp.Auth.TTL = role.TokenTTL
+	resp.Auth.MaxTTL = role.TokenMaxTTL
+	return resp, nil
+}
+
+// verifyWorkloadIdentity verifies the signature of the token against the
+// keys of its region, and its claims against the role. Failures are returned
+// as error responses.
+func (b *backend) verifyWorkloadIdentity(ctx context.Context, req *logical.Request, data *framework.FieldData) (*workloadIdentity, *logical.Response, error) {
+	roleName := data.Get("role").(string)
+	if roleName == "" {
+		return nil, logical.ErrorResponse("missing role"), nil
+	}
+	rawToken := data.Get("jwt").(string)
+	if rawToken == "" {
+		return nil, logical.ErrorResponse("missing jwt"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role == nil {
+		return nil, logical.ErrorResponse("invalid role %q", roleName), nil
+	}
+
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, logical.ErrorResponse("error parsing token: %s", err), nil
+	}
+	if len(token.Headers) != 1 {
+		return nil, logical.ErrorResponse("token must have exactly one signature"), nil
+	}
+	header := token.Headers[0]
+	if !strutil.StrListContains(supportedAlgorithms, header.Algorithm) {
+		return nil, logical.ErrorResponse("unsupported signing algorithm %q", header.Algorithm), nil
+	}
+
+	// Find the regions the token may have been issued by
+	var regions []string
+	switch {
+	case data.Get("region").(string) != "":
+		regions = []string{data.Get("region").(string)}
+	case len(role.BoundRegions) > 0:
+		regions = role.BoundRegions
+	default:
+		regions, err = req.Storage.List(ctx, "region/")
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(regions)
+	}
+	if len(role.BoundRegions) > 0 && !strutil.StrListSubset(role.BoundRegions, regions) {
+		return nil, logical.ErrorResponse("region is not allowed by the role"), nil
+	}
+
+	var claims jwt.Claims
+	var allClaims map[string]interface{}
+	var regionName string
+	var regionConf *regionConfig
+	for _, name := range regions {
+		region, err := b.region(ctx, req.Storage, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if region == nil {
+			continue
+		}
+
+		key, err := b.key(ctx, name, region, header.KeyID)
+		if errors.Is(err, errUnknownKey) {
+			continue
+		}
+		if err != nil {
+			b.Logger().Warn("error fetching region keys", "region", name, "error", err)
+			continue
+		}
+
+		if err := token.Claims(key, &claims, &allClaims); err != nil {
+			return nil, logical.ErrorResponse("error verifying token: %s", err), nil
+		}
+		regionName, regionConf = name, region
+		break
+	}
+	if regionConf == nil {
+		return nil, logical.ErrorResponse("token is not signed by a key of any allowed region"), nil
+	}
+
+	expected := jwt.Expected{
+		Issuer: regionConf.Issuer,
+		Time:   time.Now(),
+	}
+	if err := claims.ValidateWithLeeway(expected, claimsLeeway); err != nil {
+		return nil, logical.ErrorResponse("error validating claims: %s", err), nil
+	}
+
+	if len(role.BoundAudiences) > 0 {
+		found := false
+		for _, aud := range role.BoundAudiences {
+			if claims.Audience.Contains(aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, logical.ErrorResponse("token audience does not match bound_audiences"), nil
+		}
+	}
+
+	for claim, patterns := range map[string][]string{
+		"nomad_namespace": role.BoundNamespaces,
+		"nomad_job_id":    role.BoundJobIDs,
+	} {
+		if len(patterns) == 0 {
+			continue
+		}
+		value, _ := claimString(allClaims[claim])
+		if value == "" || !strutil.StrListContainsGlob(patterns, value) {
+			return nil, logical.ErrorResponse("claim %q does not match the bound values of the role", claim), nil
+		}
+	}
+	for claim, patterns := range role.BoundClaims {
+		value, ok := claimString(allClaims[claim])
+		if !ok || !strutil.StrListContainsGlob(patterns, value) {
+			return nil, logical.ErrorResponse("claim %q does not match the bound values of the role", claim), nil
+		}
+	}
+
+	return &workloadIdentity{
+		role:   role,
+		region: regionName,
+		claims: allClaims,
+	}, nil, nil
+}
+
+func (w *workloadIdentity) aliasName() (string, error) {
+	name, ok := claimString(w.claims[w.role.UserClaim])
+	if !ok || name == "" {
+		return "", fmt.Errorf("claim %q not found in token", w.role.UserClaim)
+	}
+	return name, nil
+}
+
+func (w *workloadIdentity) metadata(roleName string) map[string]string {
+	metadata := map[string]string{
+		"role":   roleName,
+		"region": w.region,
+	}
+	for key, claim := range reservedMetadata {
+		if claim == "" {
+			continue
+		}
+		if value, ok := claimString(w.claims[claim]); ok {
+			metadata[key] = value
+		}
+	}
+	for claim, key := range w.role.ClaimMappings {
+		if value, ok := claimString(w.claims[claim]); ok {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// claimString returns the string representation of a scalar claim.
+func claimString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+const pathLoginHelpSyn = `
+Authenticate a Nomad workload using its workload identity.
+`
+
+const pathLoginHelpDesc = `
+The workload identity token is verified against the signing keys of the
+configured regions and the bindings of the role. On success, a token is
+issued with an entity alias named after the 'user_claim' of the role.
+`
@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// regionConfig defines how the signing keys of a Nomad region are
+// discovered. Keys are fetched from JWKSURL if set; otherwise from the
+// well-known JWKS endpoint of NomadAddr; otherwise through OIDC discovery
+// of Issuer.
+type regionConfig struct {
+	NomadAddr string `json:"nomad_addr"`
+	JWKSURL   string `json:"jwks_url"`
+	Issuer    string `json:"issuer"`
+	CACert    string `json:"ca_cert"`
+}
+
+func pathListRegions(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "regions/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNomad,
+			OperationSuffix: "regions",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRegionList,
+		},
+
+		HelpSynopsis:    pathRegionHelpSyn,
+		HelpDescription: pathRegionHelpDesc,
+	}
+}
+
+func pathRegions(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "regions/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNomad,
+			OperationSuffix: "region",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the Nomad region.",
+			},
+
+			"nomad_addr": {
+				Type:        framework.TypeString,
+				Description: "Address of the Nomad servers of the region. Keys are fetched from its /.well-known/jwks.json endpoint.",
+			},
+
+			"jwks_url": {
+				Type:        framework.TypeString,
+				Description: "URL of the JWKS of the region. Overrides nomad_addr.",
+			},
+
+			"issuer": {
+				Type:        framework.TypeString,
+				Description: "Issuer of workload identities in the region, if Nomad is configured with an OIDC issuer. Tokens must carry this 'iss' claim. Used for OIDC discovery of the JWKS if neither nomad_addr nor jwks_url is set.",
+			},
+
+			"ca_cert": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded CA certificates used to verify the TLS connection to the key discovery endpoint. Defaults to the system roots.",
+			},
+		},
+
+		ExistenceCheck: b.pathRegionExistenceCheck,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathRegionRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathRegionWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "configure",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathRegionWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "configure",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathRegionDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathRegionHelpSyn,
+		HelpDescription: pathRegionHelpDesc,
+	}
+}
+
+func (b *backend) region(ctx context.Context, s logical.Storage, name string) (*regionConfig, error) {
+	entry, err := s.Get(ctx, "region/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result regionConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRegionExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	region, err := b.region(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return region != nil, nil
+}
+
+func (b *backend) pathRegionList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "region/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRegionRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	region, err := b.region(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"nomad_addr": region.NomadAddr,
+			"jwks_url":   region.JWKSURL,
+			"issuer":     region.Issuer,
+			"ca_cert":    region.CACert,
+		},
+	}, nil
+}
+
+func (b *backend) pathRegionWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	region, err := b.region(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		region = &regionConfig{}
+	}
+
+	if nomadAddr, ok := data.GetOk("nomad_addr"); ok {
+		region.NomadAddr = nomadAddr.(string)
+	}
+	if jwksURL, ok := data.GetOk("jwks_url"); ok {
+		region.JWKSURL = jwksURL.(string)
+	}
+	if issuer, ok := data.GetOk("issuer"); ok {
+		region.Issuer = issuer.(string)
+	}
+	if caCert, ok := data.GetOk("ca_cert"); ok {
+		region.CACert = caCert.(string)
+	}
+
+	if region.NomadAddr == "" && region.JWKSURL == "" && region.Issuer == "" {
+		return logical.ErrorResponse("one of nomad_addr, jwks_url or issuer is required"), nil
+	}
+	for field, value := range map[string]string{
+		"nomad_addr": region.NomadAddr,
+		"jwks_url":   region.JWKSURL,
+		"issuer":     region.Issuer,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return logical.ErrorResponse("%s must be an absolute URL", field), nil
+		}
+	}
+	if region.CACert != "" {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(region.CACert)) {
+			return logical.ErrorResponse("ca_cert does not contain any PEM encoded certificates"), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("region/"+name, region)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.resetKeySet(name)
+	return nil, nil
+}
+
+func (b *backend) pathRegionDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	if err := req.Storage.Delete(ctx, "region/"+name); err != nil {
+		return nil, err
+	}
+
+	b.resetKeySet(name)
+	return nil, nil
+}
+
+const pathRegionHelpSyn = `
+Configure the Nomad regions workload identities are accepted from.
+`
+
+const pathRegionHelpDesc = `
+Each region defines where the keys its Nomad servers sign workload identities
+with are discovered: the JWKS endpoint of the Nomad servers ('nomad_addr'), an
+explicit JWKS URL ('jwks_url'), or OIDC discovery of the configured 'issuer'.
+Keys are cached and refreshed periodically, and whenever a token is signed
+with an unknown key.
+`
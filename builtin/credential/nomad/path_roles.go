@@ -0,0 +1,315 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package nomad
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const defaultUserClaim = "nomad_job_id"
+
+type nomadRole struct {
+	tokenutil.TokenParams
+
+	// BoundAudiences, if set, requires the token to carry one of these
+	// 'aud' values.
+	BoundAudiences []string `json:"bound_audiences"`
+
+	// BoundRegions, BoundNamespaces and BoundJobIDs restrict the regions,
+	// namespaces and jobs whose workloads may log in. Namespaces and job IDs
+	// may contain glob patterns.
+	BoundRegions    []string `json:"bound_regions"`
+	BoundNamespaces []string `json:"bound_namespaces"`
+	BoundJobIDs     []string `json:"bound_job_ids"`
+
+	// BoundClaims are further claims which must match one of the given glob
+	// patterns.
+	BoundClaims map[string][]string `json:"bound_claims"`
+
+	// UserClaim is the claim used as the name of the entity alias.
+	UserClaim string `json:"user_claim"`
+
+	// ClaimMappings maps claims onto entity alias and token metadata keys.
+	ClaimMappings map[string]string `json:"claim_mappings"`
+}
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNomad,
+			OperationSuffix: "roles",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	p := &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNomad,
+			OperationSuffix: "role",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"bound_audiences": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of 'aud' claims, one of which the token must carry.",
+			},
+
+			"bound_regions": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of regions whose workloads may log in. Defaults to all configured regions.",
+			},
+
+			"bound_namespaces": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of Nomad namespaces whose workloads may log in. Supports globs.",
+			},
+
+			"bound_job_ids": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of Nomad job IDs whose workloads may log in. Supports globs.",
+			},
+
+			"bound_claims": {
+				Type:        framework.TypeMap,
+				Description: "Map of further claims and the values, or lists of values, they must match. Supports globs.",
+			},
+
+			"user_claim": {
+				Type:        framework.TypeString,
+				Default:     defaultUserClaim,
+				Description: "Claim used as the name of the entity alias.",
+			},
+
+			"claim_mappings": {
+				Type:        framework.TypeKVPairs,
+				Description: "Mappings of claims to the metadata keys they are stored under on the entity alias and token.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathRoleRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "update",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathRoleDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+
+	tokenutil.AddTokenFields(p.Fields)
+	return p
+}
+
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*nomadRole, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result nomadRole
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	d := map[string]interface{}{
+		"bound_audiences":  role.BoundAudiences,
+		"bound_regions":    role.BoundRegions,
+		"bound_namespaces": role.BoundNamespaces,
+		"bound_job_ids":    role.BoundJobIDs,
+		"bound_claims":     role.BoundClaims,
+		"user_claim":       role.UserClaim,
+		"claim_mappings":   role.ClaimMappings,
+	}
+	role.PopulateTokenData(d)
+
+	return &logical.Response{
+		Data: d,
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &nomadRole{
+			UserClaim: data.Get("user_claim").(string),
+		}
+	}
+
+	if err := role.ParseTokenFields(req, data); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if boundAudiences, ok := data.GetOk("bound_audiences"); ok {
+		role.BoundAudiences = boundAudiences.([]string)
+	}
+	if boundRegions, ok := data.GetOk("bound_regions"); ok {
+		role.BoundRegions = boundRegions.([]string)
+	}
+	if boundNamespaces, ok := data.GetOk("bound_namespaces"); ok {
+		role.BoundNamespaces = boundNamespaces.([]string)
+	}
+	if boundJobIDs, ok := data.GetOk("bound_job_ids"); ok {
+		role.BoundJobIDs = boundJobIDs.([]string)
+	}
+	if boundClaimsRaw, ok := data.GetOk("bound_claims"); ok {
+		boundClaims, err := parseBoundClaims(boundClaimsRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		role.BoundClaims = boundClaims
+	}
+	if userClaim, ok := data.GetOk("user_claim"); ok {
+		role.UserClaim = userClaim.(string)
+	}
+	if claimMappings, ok := data.GetOk("claim_mappings"); ok {
+		role.ClaimMappings = claimMappings.(map[string]string)
+	}
+
+	if role.UserClaim == "" {
+		return logical.ErrorResponse("user_claim must not be empty"), nil
+	}
+	seen := make(map[string]bool)
+	for claim, key := range role.ClaimMappings {
+		if seen[key] {
+			return logical.ErrorResponse("metadata key %q is mapped from multiple claims", key), nil
+		}
+		if _, ok := reservedMetadata[key]; ok {
+			return logical.ErrorResponse("metadata key %q is reserved and cannot be mapped from claim %q", key, claim), nil
+		}
+		seen[key] = true
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// parseBoundClaims normalizes bound claims, which may be given as a single
+// value or a list of values.
+func parseBoundClaims(raw map[string]interface{}) (map[string][]string, error) {
+	boundClaims := make(map[string][]string, len(raw))
+	for claim, value := range raw {
+		switch v := value.(type) {
+		case string:
+			boundClaims[claim] = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("values of bound claim %q must be strings", claim)
+				}
+				boundClaims[claim] = append(boundClaims[claim], s)
+			}
+		default:
+			return nil, fmt.Errorf("bound claim %q must be a string or a list of strings", claim)
+		}
+	}
+	return boundClaims, nil
+}
+
+const pathRoleHelpSyn = `
+Manage the roles Nomad workloads log in with.
+`
+
+const pathRoleHelpDesc = `
+A role binds the workload identities allowed to log in with it, by audience,
+region, namespace, job ID or further claims, and defines the token issued to
+them. The entity alias is named after the 'user_claim' of the token, by
+default the job ID, and 'claim_mappings' add further claims to the alias and
+token metadata.
+`
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixTPM = "tpm"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	b := &backend{
+		usedNonces: make(map[string]time.Time),
+	}
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"challenge",
+				"login",
+			},
+			SealWrapStorage: []string{
+				challengeKeyPath,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListMachines(b),
+			pathMachines(b),
+			pathChallenge(b),
+			pathLogin(b),
+		},
+
+		AuthRenew:   b.pathLoginRenew,
+		Invalidate:  b.invalidate,
+		BackendType: logical.TypeCredential,
+	}
+
+	return b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// challengeKey is the cached key challenge nonces are authenticated
+	// with
+	challengeKey     []byte
+	challengeKeyLock sync.RWMutex
+
+	// usedNonces records the nonces logged in with on this node until they
+	// expire, so that a quote cannot be replayed
+	usedNonces     map[string]time.Time
+	usedNoncesLock sync.Mutex
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	if key == challengeKeyPath {
+		b.challengeKeyLock.Lock()
+		b.challengeKey = nil
+		b.challengeKeyLock.Unlock()
+	}
+}
+
+const backendHelp = `
+The TPM credential provider allows machines to authenticate using a quote
+signed by an attestation key held in their Trusted Platform Module.
+
+Machines are enrolled at the "machines/" endpoints with the public part of
+their attestation key and, optionally, the PCR values they are expected to
+boot into. To log in, a machine fetches a nonce from the "challenge"
+endpoint, has its TPM quote its PCRs over that nonce, and submits the quote
+and its signature to the "login" endpoint.
+`
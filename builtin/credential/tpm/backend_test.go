@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func doRequest(t *testing.T, b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    s,
+		Data:       data,
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+	if err != nil && err != logical.ErrInvalidRequest {
+		t.Fatalf("%s %s: %v", op, path, err)
+	}
+	return resp
+}
+
+type testTPM struct {
+	key  *ecdsa.PrivateKey
+	pcrs [][]byte
+}
+
+func newTestTPM(t *testing.T) *testTPM {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpm := &testTPM{key: key}
+	for i := 0; i <= maxPCR; i++ {
+		pcr := sha256.Sum256([]byte{byte(i)})
+		tpm.pcrs = append(tpm.pcrs, pcr[:])
+	}
+	return tpm
+}
+
+func (tpm *testTPM) attestationKey(t *testing.T) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&tpm.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func (tpm *testTPM) pcrDigest(pcrs []int) []byte {
+	h := sha256.New()
+	for _, pcr := range pcrs {
+		h.Write(tpm.pcrs[pcr])
+	}
+	return h.Sum(nil)
+}
+
+// quote builds and signs a TPMS_ATTEST structure as a TPM would.
+func (tpm *testTPM) quote(t *testing.T, nonce []byte, pcrs []int) (string, string) {
+	t.Helper()
+
+	var b []byte
+	b = binary.BigEndian.AppendUint32(b, tpmGeneratedValue)
+	b = binary.BigEndian.AppendUint16(b, tpmSTAttestQuote)
+	b = appendTPM2B(b, []byte("qualified-signer"))
+	b = appendTPM2B(b, nonce)
+	b = append(b, make([]byte, 17+8)...)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint16(b, tpmAlgSHA256)
+	bitmap := make([]byte, 3)
+	for _, pcr := range pcrs {
+		bitmap[pcr/8] |= 1 << (pcr % 8)
+	}
+	b = append(b, byte(len(bitmap)))
+	b = append(b, bitmap...)
+	b = appendTPM2B(b, tpm.pcrDigest(pcrs))
+
+	digest := sha256.Sum256(b)
+	signature, err := ecdsa.SignASN1(rand.Reader, tpm.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b), base64.StdEncoding.EncodeToString(signature)
+}
+
+func appendTPM2B(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func challenge(t *testing.T, b *backend, s logical.Storage) []byte {
+	t.Helper()
+
+	resp := doRequest(t, b, s, logical.ReadOperation, "challenge", nil)
+	if resp == nil || resp.IsError() {
+		t.Fatalf("failed to get challenge: %#v", resp)
+	}
+	nonce, err := hex.DecodeString(resp.Data["nonce"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+func login(t *testing.T, b *backend, s logical.Storage, machine string, nonce []byte, quote, signature string) *logical.Response {
+	t.Helper()
+
+	return doRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"machine":   machine,
+		"nonce":     hex.EncodeToString(nonce),
+		"quote":     quote,
+		"signature": signature,
+	})
+}
+
+func TestBackend_Login(t *testing.T) {
+	b, s := getBackend(t)
+	tpm := newTestTPM(t)
+	pcrs := []int{0, 2, 4, 7}
+
+	resp := doRequest(t, b, s, logical.CreateOperation, "machines/host-1", map[string]interface{}{
+		"attestation_key": tpm.attestationKey(t),
+		"pcrs":            "7,0,2,4",
+		"pcr_digest":      hex.EncodeToString(tpm.pcrDigest(pcrs)),
+		"token_policies":  "host",
+	})
+	if resp.IsError() {
+		t.Fatalf("failed to enroll machine: %v", resp.Error())
+	}
+
+	nonce := challenge(t, b, s)
+	quote, signature := tpm.quote(t, nonce, pcrs)
+	resp = login(t, b, s, "host-1", nonce, quote, signature)
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("expected login to succeed, got %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "host-1" {
+		t.Fatalf("expected alias named after the machine, got %q", resp.Auth.Alias.Name)
+	}
+	if len(resp.Auth.Policies) != 1 || resp.Auth.Policies[0] != "host" {
+		t.Fatalf("unexpected policies: %v", resp.Auth.Policies)
+	}
+
+	resp = login(t, b, s, "host-1", nonce, quote, signature)
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected replayed quote to be rejected")
+	}
+
+	otherNonce := challenge(t, b, s)
+	otherQuote, otherSignature := tpm.quote(t, otherNonce, pcrs)
+	badPCRs, badPCRsSignature := tpm.quote(t, otherNonce, []int{0, 2, 4})
+	otherTPMQuote, otherTPMSignature := newTestTPM(t).quote(t, otherNonce, pcrs)
+	expired, err := newNonce(b.challengeKey, time.Now().Add(-nonceTTL-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredQuote, expiredSignature := tpm.quote(t, expired, pcrs)
+	forged := append([]byte{}, otherNonce...)
+	forged[0] ^= 1
+	forgedQuote, forgedSignature := tpm.quote(t, forged, pcrs)
+
+	for name, attempt := range map[string]struct {
+		nonce            []byte
+		quote, signature string
+	}{
+		"wrong nonce":     {nonce: challenge(t, b, s), quote: otherQuote, signature: otherSignature},
+		"wrong signature": {nonce: otherNonce, quote: otherQuote, signature: signature},
+		"wrong PCRs":      {nonce: otherNonce, quote: badPCRs, signature: badPCRsSignature},
+		"wrong key":       {nonce: otherNonce, quote: otherTPMQuote, signature: otherTPMSignature},
+		"expired nonce":   {nonce: expired, quote: expiredQuote, signature: expiredSignature},
+		"forged nonce":    {nonce: forged, quote: forgedQuote, signature: forgedSignature},
+	} {
+		resp := login(t, b, s, "host-1", attempt.nonce, attempt.quote, attempt.signature)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected login to fail", name)
+		}
+	}
+
+	// Failed logins do not consume the nonce
+	if resp := login(t, b, s, "host-1", otherNonce, otherQuote, otherSignature); resp == nil || resp.IsError() {
+		t.Fatalf("expected login to succeed, got %#v", resp)
+	}
+}
+
+func TestBackend_MachineValidation(t *testing.T) {
+	b, s := getBackend(t)
+	tpm := newTestTPM(t)
+
+	for name, data := range map[string]map[string]interface{}{
+		"missing key":      {},
+		"invalid key":      {"attestation_key": "not a key"},
+		"invalid PCR":      {"attestation_key": tpm.attestationKey(t), "pcrs": "24"},
+		"duplicate PCR":    {"attestation_key": tpm.attestationKey(t), "pcrs": "0,0"},
+		"digest sans PCRs": {"attestation_key": tpm.attestationKey(t), "pcr_digest": hex.EncodeToString(tpm.pcrDigest(nil))},
+		"invalid digest":   {"attestation_key": tpm.attestationKey(t), "pcrs": "0", "pcr_digest": "abcd"},
+	} {
+		resp := doRequest(t, b, s, logical.CreateOperation, "machines/host-1", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected enrollment to fail", name)
+		}
+	}
+}
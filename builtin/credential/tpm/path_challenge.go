@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	challengeKeyPath = "config/challenge_key"
+
+	// nonceTTL is how long a nonce may be logged in with after it is
+	// issued.
+	nonceTTL = 2 * time.Minute
+
+	// A nonce is made of the time it was issued, random bytes and a
+	// truncated MAC over both. At 32 bytes it fits in the qualifying data
+	// of a quote on TPMs supporting only SHA-256.
+	nonceTimeLen   = 8
+	nonceRandomLen = 8
+	nonceMACLen    = 16
+	nonceLen       = nonceTimeLen + nonceRandomLen + nonceMACLen
+)
+
+func pathChallenge(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "challenge$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTPM,
+			OperationSuffix: "challenge",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathChallengeRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "generate",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathChallengeHelpSyn,
+		HelpDescription: pathChallengeHelpDesc,
+	}
+}
+
+func (b *backend) pathChallengeRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	key, err := b.getChallengeKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newNonce(key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"nonce": hex.EncodeToString(nonce),
+			"ttl":   int64(nonceTTL.Seconds()),
+		},
+	}, nil
+}
+
+// getChallengeKey returns the key nonces are authenticated with, generating
+// it on first use.
+func (b *backend) getChallengeKey(ctx context.Context, s logical.Storage) ([]byte, error) {
+	b.challengeKeyLock.RLock()
+	key := b.challengeKey
+	b.challengeKeyLock.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+
+	b.challengeKeyLock.Lock()
+	defer b.challengeKeyLock.Unlock()
+	if b.challengeKey != nil {
+		return b.challengeKey, nil
+	}
+
+	entry, err := s.Get(ctx, challengeKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		b.challengeKey = entry.Value
+		return b.challengeKey, nil
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("error generating challenge key: %w", err)
+	}
+	if err := s.Put(ctx, &logical.StorageEntry{Key: challengeKeyPath, Value: key}); err != nil {
+		return nil, err
+	}
+	b.challengeKey = key
+	return key, nil
+}
+
+func newNonce(key []byte, now time.Time) ([]byte, error) {
+	nonce := make([]byte, nonceLen)
+	binary.BigEndian.PutUint64(nonce, uint64(now.Unix()))
+	if _, err := rand.Read(nonce[nonceTimeLen : nonceTimeLen+nonceRandomLen]); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	copy(nonce[nonceTimeLen+nonceRandomLen:], nonceMAC(key, nonce))
+	return nonce, nil
+}
+
+func nonceMAC(key, nonce []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(nonce[:nonceTimeLen+nonceRandomLen])
+	return mac.Sum(nil)[:nonceMACLen]
+}
+
+// verifyNonce checks that the nonce was issued by this backend and has not
+// expired.
+func verifyNonce(key, nonce []byte, now time.Time) error {
+	if len(nonce) != nonceLen {
+		return errors.New("invalid nonce")
+	}
+	if !hmac.Equal(nonce[nonceTimeLen+nonceRandomLen:], nonceMAC(key, nonce)) {
+		return errors.New("invalid nonce")
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(nonce)), 0)
+	if now.Sub(issued) > nonceTTL || issued.Sub(now) > time.Minute {
+		return errors.New("nonce has expired")
+	}
+	return nil
+}
+
+// useNonce records the nonce as used, returning false if it already was.
+// Expired nonces are pruned, as they fail verification regardless.
+func (b *backend) useNonce(nonce []byte, now time.Time) bool {
+	b.usedNoncesLock.Lock()
+	defer b.usedNoncesLock.Unlock()
+
+	for n, expiry := range b.usedNonces {
+		if now.After(expiry) {
+			delete(b.usedNonces, n)
+		}
+	}
+
+	k := string(nonce)
+	if _, ok := b.usedNonces[k]; ok {
+		return false
+	}
+	b.usedNonces[k] = now.Add(nonceTTL)
+	return true
+}
+
+const pathChallengeHelpSyn = `
+Generate a nonce for a TPM quote.
+`
+
+const pathChallengeHelpDesc = `
+Returns a hex encoded nonce to pass to the TPM as the qualifying data of the
+quote submitted to the "login" endpoint. Nonces are valid for two minutes and
+may be used for a single login.
+`
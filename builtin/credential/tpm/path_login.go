@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathLogin(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTPM,
+			OperationVerb:   "login",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"machine": {
+				Type:        framework.TypeString,
+				Description: "Name of the machine to log in as.",
+			},
+
+			"nonce": {
+				Type:        framework.TypeString,
+				Description: `Hex encoded nonce returned by the "challenge" endpoint, which the quote was requested with.`,
+			},
+
+			"quote": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded TPMS_ATTEST structure of the quote.",
+			},
+
+			"signature": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded signature of the quote by the attestation key, in plain format.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation:         b.pathLogin,
+			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
+		},
+
+		HelpSynopsis:    pathLoginHelpSyn,
+		HelpDescription: pathLoginHelpDesc,
+	}
+}
+
+func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("machine").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing machine"), nil
+	}
+
+	return &logical.Response{
+		Auth: &logical.Auth{
+			Alias: &logical.Alias{
+				Name: name,
+			},
+		},
+	}, nil
+}
+
+func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("machine").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing machine"), nil
+	}
+	machine, err := b.machine(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return logical.ErrorResponse("invalid machine %q", name), nil
+	}
+
+	// Check for a CIDR match.
+	if len(machine.TokenBoundCIDRs) > 0 {
+		if req.Connection == nil {
+			b.Logger().Warn("token bound CIDRs found but no connection information available for validation")
+			return nil, logical.ErrPermissionDenied
+		}
+		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, machine.TokenBoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
+	if resp, err := b.verifyQuote(ctx, req, data, machine); resp != nil || err != nil {
+		return resp, err
+	}
+
+	metadata := map[string]string{
+		"machine": name,
+	}
+	auth := &logical.Auth{
+		InternalData: map[string]interface{}{
+			"machine": name,
+		},
+		Metadata:    metadata,
+		DisplayName: name,
+		Alias: &logical.Alias{
+			Name:     name,
+			Metadata: metadata,
+		},
+	}
+	machine.PopulateTokenAuth(auth)
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+// verifyQuote verifies that the quote was signed by the attestation key of
+// the machine over a fresh nonce, and matches the enrolled PCR state.
+// Failures are returned as error responses.
+func (b *backend) verifyQuote(ctx context.Context, req *logical.Request, data *framework.FieldData, machine *machineEntry) (*logical.Response, error) {
+	nonce, err := hex.DecodeString(data.Get("nonce").(string))
+	if err != nil || len(nonce) == 0 {
+		return logical.ErrorResponse("missing or invalid nonce"), nil
+	}
+	rawQuote, err := base64.StdEncoding.DecodeString(data.Get("quote").(string))
+	if err != nil || len(rawQuote) == 0 {
+		return logical.ErrorResponse("missing or invalid quote"), nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(data.Get("signature").(string))
+	if err != nil || len(signature) == 0 {
+		return logical.ErrorResponse("missing or invalid signature"), nil
+	}
+
+	key, err := b.getChallengeKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := verifyNonce(key, nonce, now); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	attestationKey, err := parseAttestationKey(machine.AttestationKey)
+	if err != nil {
+		return nil, err
+	}
+	rawQuote = trimAttestSize(rawQuote)
+	if err := verifyQuoteSignature(attestationKey, rawQuote, signature); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	q, err := parseQuote(rawQuote)
+	if err != nil {
+		return logical.ErrorResponse("error parsing quote: %s", err), nil
+	}
+	if !bytes.Equal(q.extraData, nonce) {
+		return logical.ErrorResponse("quote was not made over the nonce"), nil
+	}
+	if len(machine.PCRs) > 0 {
+		if len(q.selection) != 1 || !equalPCRs(q.selection[tpmAlgSHA256], machine.PCRs) {
+			return logical.ErrorResponse("quote is not over the SHA-256 PCRs of the machine"), nil
+		}
+	}
+	if machine.PCRDigest != "" {
+		expected, err := hex.DecodeString(machine.PCRDigest)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(q.pcrDigest, expected) {
+			return logical.ErrorResponse("PCR digest does not match the machine"), nil
+		}
+	}
+
+	// The nonce is only consumed once the quote is verified, so that
+	// invalid logins cannot burn the nonces of others.
+	if !b.useNonce(nonce, now) {
+		return logical.ErrorResponse("nonce has already been used"), nil
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.Auth == nil {
+		return nil, errors.New("request auth was nil")
+	}
+
+	name, ok := req.Auth.InternalData["machine"].(string)
+	if !ok {
+		return nil, errors.New("no machine found in token internal data")
+	}
+	machine, err := b.machine(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, fmt.Errorf("machine %q no longer exists", name)
+	}
+
+	if !policyutil.EquivalentPolicies(machine.TokenPolicies, req.Auth.TokenPolicies) {
+		return nil, errors.New("policies have changed, not renewing")
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+	resp.Auth.Period = machine.TokenPeriod
+	resp.Auth.TTL = machine.TokenTTL
+	resp.Auth.MaxTTL = machine.TokenMaxTTL
+	return resp, nil
+}
+
+func equalPCRs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+const pathLoginHelpSyn = `
+Authenticate a machine using a quote from its TPM.
+`
+
+const pathLoginHelpDesc = `
+The quote must be made by the TPM over a nonce from the "challenge" endpoint
+and signed by the attestation key the machine is enrolled with. If the
+machine is enrolled with PCRs, the quote must be over exactly those PCRs of
+the SHA-256 bank and report the enrolled digest. On success, a token is
+issued with an entity alias named after the machine.
+`
@@ -0,0 +1,275 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type machineEntry struct {
+	tokenutil.TokenParams
+
+	// AttestationKey is the PEM encoded public part of the attestation key
+	// of the machine's TPM.
+	AttestationKey string `json:"attestation_key"`
+
+	// PCRs is the selection of SHA-256 PCRs the quote must be over. If
+	// unset, any selection is accepted.
+	PCRs []int `json:"pcrs"`
+
+	// PCRDigest, if set, is the hex encoded digest of the selected PCRs
+	// the quote must report.
+	PCRDigest string `json:"pcr_digest"`
+}
+
+func pathListMachines(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "machines/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTPM,
+			OperationSuffix: "machines",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathMachineList,
+		},
+
+		HelpSynopsis:    pathMachineHelpSyn,
+		HelpDescription: pathMachineHelpDesc,
+	}
+}
+
+func pathMachines(b *backend) *framework.Path {
+	p := &framework.Path{
+		Pattern: "machines/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTPM,
+			OperationSuffix: "machine",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the machine.",
+			},
+
+			"attestation_key": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded public key of the attestation key of the machine's TPM. RSA and ECDSA keys are supported.",
+			},
+
+			"pcrs": {
+				Type:        framework.TypeCommaIntSlice,
+				Description: "Comma-separated list of the SHA-256 PCRs the quote must be over. If not set, any selection is accepted.",
+			},
+
+			"pcr_digest": {
+				Type:        framework.TypeString,
+				Description: "Hex encoded SHA-256 digest of the selected PCRs the quote must report. Requires 'pcrs'.",
+			},
+		},
+
+		ExistenceCheck: b.pathMachineExistenceCheck,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathMachineRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathMachineWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathMachineWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "update",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathMachineDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathMachineHelpSyn,
+		HelpDescription: pathMachineHelpDesc,
+	}
+
+	tokenutil.AddTokenFields(p.Fields)
+	return p
+}
+
+func (b *backend) machine(ctx context.Context, s logical.Storage, name string) (*machineEntry, error) {
+	entry, err := s.Get(ctx, "machine/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result machineEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathMachineExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	machine, err := b.machine(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return machine != nil, nil
+}
+
+func (b *backend) pathMachineList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "machine/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathMachineRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	machine, err := b.machine(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, nil
+	}
+
+	d := map[string]interface{}{
+		"attestation_key": machine.AttestationKey,
+		"pcrs":            machine.PCRs,
+		"pcr_digest":      machine.PCRDigest,
+	}
+	machine.PopulateTokenData(d)
+
+	return &logical.Response{
+		Data: d,
+	}, nil
+}
+
+func (b *backend) pathMachineWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	machine, err := b.machine(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		machine = &machineEntry{}
+	}
+
+	if err := machine.ParseTokenFields(req, data); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if attestationKey, ok := data.GetOk("attestation_key"); ok {
+		machine.AttestationKey = attestationKey.(string)
+	}
+	if pcrs, ok := data.GetOk("pcrs"); ok {
+		machine.PCRs = pcrs.([]int)
+	}
+	if pcrDigest, ok := data.GetOk("pcr_digest"); ok {
+		machine.PCRDigest = pcrDigest.(string)
+	}
+
+	if machine.AttestationKey == "" {
+		return logical.ErrorResponse("missing attestation_key"), nil
+	}
+	if _, err := parseAttestationKey(machine.AttestationKey); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	sort.Ints(machine.PCRs)
+	for i, pcr := range machine.PCRs {
+		if pcr < 0 || pcr > maxPCR {
+			return logical.ErrorResponse("invalid PCR %d", pcr), nil
+		}
+		if i > 0 && machine.PCRs[i-1] == pcr {
+			return logical.ErrorResponse("PCR %d is listed more than once", pcr), nil
+		}
+	}
+	if machine.PCRDigest != "" {
+		if len(machine.PCRs) == 0 {
+			return logical.ErrorResponse("pcr_digest requires pcrs to be set"), nil
+		}
+		digest, err := hex.DecodeString(machine.PCRDigest)
+		if err != nil || len(digest) != crypto.SHA256.Size() {
+			return logical.ErrorResponse("pcr_digest must be a hex encoded SHA-256 digest"), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("machine/"+name, machine)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathMachineDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "machine/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// parseAttestationKey parses a PEM encoded RSA or ECDSA public key.
+func parseAttestationKey(s string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("attestation_key must be PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing attestation_key: %w", err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported attestation_key type %T", key)
+	}
+}
+
+const pathMachineHelpSyn = `
+Manage the machines which may log in with their TPM.
+`
+
+const pathMachineHelpDesc = `
+A machine is enrolled with the public part of an attestation key held in its
+TPM, which must be a restricted signing key so that it only signs quotes the
+TPM itself generated. The quote may further be required to be over the PCRs
+in 'pcrs' and to report the digest in 'pcr_digest', binding logins to the
+measured boot state of the machine.
+`
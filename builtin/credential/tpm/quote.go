@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	// tpmGeneratedValue is the magic a TPM prefixes the structures it
+	// generates and signs with. Restricted signing keys refuse to sign
+	// external data starting with it.
+	tpmGeneratedValue = 0xff544347
+
+	// tpmSTAttestQuote is the tag of an attestation structure holding a
+	// quote.
+	tpmSTAttestQuote = 0x8018
+
+	// tpmAlgSHA256 is the identifier of the SHA-256 PCR bank.
+	tpmAlgSHA256 = 0x000b
+
+	maxPCR = 23
+)
+
+// quote is the content of a TPMS_ATTEST structure holding a quote.
+type quote struct {
+	// extraData is the qualifying data the quote was requested with.
+	extraData []byte
+
+	// selection maps hash algorithms to the PCRs selected in their bank.
+	selection map[uint16][]int
+
+	// pcrDigest is the digest of the selected PCRs.
+	pcrDigest []byte
+}
+
+// trimAttestSize strips the size of a TPM2B_ATTEST, which some tools keep
+// when writing the quote, leaving the TPMS_ATTEST structure the TPM signed.
+func trimAttestSize(b []byte) []byte {
+	if len(b) >= 2 && int(binary.BigEndian.Uint16(b)) == len(b)-2 {
+		return b[2:]
+	}
+	return b
+}
+
+// parseQuote parses a TPMS_ATTEST structure holding a quote.
+func parseQuote(b []byte) (*quote, error) {
+	r := bytes.NewReader(b)
+
+	var header struct {
+		Magic uint32
+		Type  uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, errors.New("quote is truncated")
+	}
+	if header.Magic != tpmGeneratedValue {
+		return nil, errors.New("quote was not generated by a TPM")
+	}
+	if header.Type != tpmSTAttestQuote {
+		return nil, fmt.Errorf("attestation structure is not a quote: type 0x%x", header.Type)
+	}
+
+	// qualifiedSigner
+	if _, err := readTPM2B(r); err != nil {
+		return nil, err
+	}
+	extraData, err := readTPM2B(r)
+	if err != nil {
+		return nil, err
+	}
+	// clockInfo is 17 bytes, followed by the 8 byte firmwareVersion
+	if _, err := r.Seek(17+8, io.SeekCurrent); err != nil || r.Len() == 0 {
+		return nil, errors.New("quote is truncated")
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, errors.New("quote is truncated")
+	}
+	if count > 16 {
+		return nil, fmt.Errorf("quote selects too many PCR banks: %d", count)
+	}
+	selection := make(map[uint16][]int, count)
+	for i := uint32(0); i < count; i++ {
+		var bank struct {
+			Hash uint16
+			Size uint8
+		}
+		if err := binary.Read(r, binary.BigEndian, &bank); err != nil {
+			return nil, errors.New("quote is truncated")
+		}
+		bitmap := make([]byte, bank.Size)
+		if _, err := io.ReadFull(r, bitmap); err != nil {
+			return nil, errors.New("quote is truncated")
+		}
+		var pcrs []int
+		for j, octet := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if octet&(1<<bit) != 0 {
+					pcrs = append(pcrs, j*8+bit)
+				}
+			}
+		}
+		selection[bank.Hash] = append(selection[bank.Hash], pcrs...)
+	}
+
+	pcrDigest, err := readTPM2B(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote{
+		extraData: extraData,
+		selection: selection,
+		pcrDigest: pcrDigest,
+	}, nil
+}
+
+func readTPM2B(r *bytes.Reader) ([]byte, error) {
+	var size uint16
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, errors.New("quote is truncated")
+	}
+	if int(size) > r.Len() {
+		return nil, errors.New("quote is truncated")
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errors.New("quote is truncated")
+	}
+	return b, nil
+}
+
+// verifyQuoteSignature verifies a SHA-256 signature over the quote by the
+// attestation key: PKCS #1 v1.5 for RSA keys, and ASN.1 encoded, as written
+// by tpm2_quote in its plain format, or the concatenated r and s values for
+// ECDSA keys.
+func verifyQuoteSignature(key crypto.PublicKey, quote, signature []byte) error {
+	digest := sha256.Sum256(quote)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid quote signature")
+		}
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(key, digest[:], signature) {
+			return nil
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid quote signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return errors.New("invalid quote signature")
+		}
+	default:
+		return fmt.Errorf("unsupported attestation key type %T", key)
+	}
+	return nil
+}
@@ -116,10 +116,13 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			pathRoles(&b),
 			pathCredsCreate(&b),
 			pathRotateRootCredentials(&b),
+			pathListLibrarySets(&b),
+			pathLibrarySets(&b),
 		),
 
 		Secrets: []*framework.Secret{
 			secretCreds(&b),
+			secretLibraryCreds(&b),
 		},
 		Clean:             b.clean,
 		Invalidate:        b.invalidate,
@@ -0,0 +1,762 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/go-uuid"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	databaseLibraryPath  = "library/"
+	databaseCheckOutPath = "library-check-out/"
+
+	SecretLibraryCredsType = "library_creds"
+
+	defaultLibraryTTL    = 24 * time.Hour
+	defaultLibraryMaxTTL = 24 * time.Hour
+)
+
+var errCheckedOutByOther = errors.New("static role is checked out by another client")
+
+// librarySet is a set of static roles whose accounts are lent exclusively to
+// one client at a time. An account is rotated when it is checked back in, so
+// that its borrower cannot use it anymore.
+type librarySet struct {
+	StaticRoles               []string      `json:"static_roles"`
+	TTL                       time.Duration `json:"ttl"`
+	MaxTTL                    time.Duration `json:"max_ttl"`
+	DisableCheckInEnforcement bool          `json:"disable_check_in_enforcement"`
+}
+
+// checkOut records the loan of the account of a static role. It is stored
+// under the name of the role.
+type checkOut struct {
+	ID                          string    `json:"id"`
+	Set                         string    `json:"set"`
+	BorrowerEntityID            string    `json:"borrower_entity_id"`
+	BorrowerClientTokenAccessor string    `json:"borrower_client_token_accessor"`
+	CheckOutTime                time.Time `json:"check_out_time"`
+}
+
+func pathListLibrarySets(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "library/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "list",
+				OperationSuffix: "library-sets",
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathLibrarySetList,
+			},
+
+			HelpSynopsis:    pathLibrarySetHelpSyn,
+			HelpDescription: pathLibrarySetHelpDesc,
+		},
+	}
+}
+
+func pathLibrarySets(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "library/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationSuffix: "library-set",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the set.",
+				},
+				"static_roles": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Static roles whose accounts are lent by the set.",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Default duration of a check-out. Defaults to 24 hours.",
+					Default:     int(defaultLibraryTTL.Seconds()),
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Maximum duration of a check-out, renewals included. Defaults to 24 hours.",
+					Default:     int(defaultLibraryMaxTTL.Seconds()),
+				},
+				"disable_check_in_enforcement": {
+					Type:        framework.TypeBool,
+					Description: "Whether any client, not only the borrower, may check an account in.",
+				},
+			},
+
+			ExistenceCheck: b.pathLibrarySetExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathLibrarySetRead,
+				logical.CreateOperation: b.pathLibrarySetCreateUpdate,
+				logical.UpdateOperation: b.pathLibrarySetCreateUpdate,
+				logical.DeleteOperation: b.pathLibrarySetDelete,
+			},
+
+			HelpSynopsis:    pathLibrarySetHelpSyn,
+			HelpDescription: pathLibrarySetHelpDesc,
+		},
+		{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/check-out$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "check-out",
+				OperationSuffix: "library-account",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the set.",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Requested duration of the check-out, up to the ttl of the set.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCheckOut,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryCheckOutHelpSyn,
+			HelpDescription: pathLibraryCheckOutHelpDesc,
+		},
+		{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/check-in$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "check-in",
+				OperationSuffix: "library-accounts",
+			},
+
+			Fields: checkInFields(),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCheckIn(false),
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryCheckInHelpSyn,
+			HelpDescription: pathLibraryCheckInHelpDesc,
+		},
+		{
+			Pattern: "library/manage/" + framework.GenericNameRegex("name") + "/check-in$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "force-check-in",
+				OperationSuffix: "library-accounts",
+			},
+
+			Fields: checkInFields(),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCheckIn(true),
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryManageCheckInHelpSyn,
+			HelpDescription: pathLibraryManageCheckInHelpDesc,
+		},
+		{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/status$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "check-status",
+				OperationSuffix: "library-set",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the set.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathLibraryStatus,
+			},
+
+			HelpSynopsis:    pathLibraryStatusHelpSyn,
+			HelpDescription: pathLibraryStatusHelpDesc,
+		},
+	}
+}
+
+func checkInFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the set.",
+		},
+		"static_roles": {
+			Type: framework.TypeCommaStringSlice,
+			Description: `Static roles to check in. Defaults to the roles of the set checked
+out by the caller, or to the only role checked out.`,
+		},
+	}
+}
+
+func secretLibraryCreds(b *databaseBackend) *framework.Secret {
+	return &framework.Secret{
+		Type:   SecretLibraryCredsType,
+		Fields: map[string]*framework.FieldSchema{},
+
+		Renew:  b.secretLibraryCredsRenew,
+		Revoke: b.secretLibraryCredsRevoke,
+	}
+}
+
+func (b *databaseBackend) librarySet(ctx context.Context, s logical.Storage, name string) (*librarySet, error) {
+	entry, err := s.Get(ctx, databaseLibraryPath+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var set librarySet
+	if err := entry.DecodeJSON(&set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func getCheckOut(ctx context.Context, s logical.Storage, roleName string) (*checkOut, error) {
+	entry, err := s.Get(ctx, databaseCheckOutPath+roleName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var co checkOut
+	if err := entry.DecodeJSON(&co); err != nil {
+		return nil, err
+	}
+	return &co, nil
+}
+
+// librarySetOfStaticRole returns the name of the set lending the account of
+// the static role, if any.
+func (b *databaseBackend) librarySetOfStaticRole(ctx context.Context, s logical.Storage, roleName string) (string, error) {
+	names, err := s.List(ctx, databaseLibraryPath)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		set, err := b.librarySet(ctx, s, name)
+		if err != nil {
+			return "", err
+		}
+		if set != nil && strutil.StrListContains(set.StaticRoles, roleName) {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+func (b *databaseBackend) pathLibrarySetExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	set, err := b.librarySet(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return set != nil, nil
+}
+
+func (b *databaseBackend) pathLibrarySetList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, databaseLibraryPath)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+func (b *databaseBackend) pathLibrarySetRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	set, err := b.librarySet(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"static_roles":                 set.StaticRoles,
+			"ttl":                          int64(set.TTL.Seconds()),
+			"max_ttl":                      int64(set.MaxTTL.Seconds()),
+			"disable_check_in_enforcement": set.DisableCheckInEnforcement,
+		},
+	}, nil
+}
+
+func (b *databaseBackend) pathLibrarySetCreateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, databaseLibraryPath+name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		set = &librarySet{}
+	}
+	previousRoles := set.StaticRoles
+
+	if _, ok := data.GetOk("static_roles"); ok || req.Operation == logical.CreateOperation {
+		set.StaticRoles = strutil.RemoveDuplicates(data.Get("static_roles").([]string), false)
+	}
+	if _, ok := data.GetOk("ttl"); ok || req.Operation == logical.CreateOperation {
+		set.TTL = time.Duration(data.Get("ttl").(int)) * time.Second
+	}
+	if _, ok := data.GetOk("max_ttl"); ok || req.Operation == logical.CreateOperation {
+		set.MaxTTL = time.Duration(data.Get("max_ttl").(int)) * time.Second
+	}
+	if raw, ok := data.GetOk("disable_check_in_enforcement"); ok {
+		set.DisableCheckInEnforcement = raw.(bool)
+	}
+
+	if len(set.StaticRoles) == 0 {
+		return logical.ErrorResponse("static_roles must not be empty"), nil
+	}
+	if set.TTL <= 0 || set.MaxTTL <= 0 {
+		return logical.ErrorResponse("ttl and max_ttl must be positive"), nil
+	}
+	if set.TTL > set.MaxTTL {
+		return logical.ErrorResponse("ttl must not be greater than max_ttl"), nil
+	}
+
+	for _, roleName := range set.StaticRoles {
+		role, err := b.StaticRole(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse("static role %q does not exist", roleName), nil
+		}
+		// A periodic rotation would cut off the borrower in the middle of
+		// the loan.
+		if role.StaticAccount.UsesRotationPeriod() && role.StaticAccount.RotationPeriod < set.MaxTTL {
+			return logical.ErrorResponse("the rotation_period of static role %q must not be shorter than the max_ttl of the set", roleName), nil
+		}
+		other, err := b.librarySetOfStaticRole(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if other != "" && other != name {
+			return logical.ErrorResponse("static role %q is already lent by set %q", roleName, other), nil
+		}
+	}
+	for _, roleName := range previousRoles {
+		if strutil.StrListContains(set.StaticRoles, roleName) {
+			continue
+		}
+		co, err := getCheckOut(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if co != nil {
+			return logical.ErrorResponse("static role %q cannot be removed from the set while it is checked out", roleName), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(databaseLibraryPath+name, set)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.dbEvent(ctx, fmt.Sprintf("library-set-%s", req.Operation), req.Path, name, true)
+	return nil, nil
+}
+
+func (b *databaseBackend) pathLibrarySetDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, databaseLibraryPath+name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+	for _, roleName := range set.StaticRoles {
+		co, err := getCheckOut(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if co != nil {
+			return logical.ErrorResponse("static role %q is checked out; check it in before deleting the set", roleName), nil
+		}
+	}
+
+	if err := req.Storage.Delete(ctx, databaseLibraryPath+name); err != nil {
+		return nil, err
+	}
+	b.dbEvent(ctx, "library-set-delete", req.Path, name, true)
+	return nil, nil
+}
+
+func (b *databaseBackend) pathLibraryCheckOut(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, databaseLibraryPath+name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return logical.ErrorResponse("unknown library set: %s", name), nil
+	}
+
+	ttl := set.TTL
+	if raw, ok := data.GetOk("ttl"); ok {
+		requested := time.Duration(raw.(int)) * time.Second
+		if requested > 0 && requested < ttl {
+			ttl = requested
+		}
+	}
+
+	for _, roleName := range set.StaticRoles {
+		co, err := getCheckOut(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if co != nil {
+			continue
+		}
+		role, err := b.StaticRole(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			continue
+		}
+
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		co = &checkOut{
+			ID:                          id,
+			Set:                         name,
+			BorrowerEntityID:            req.EntityID,
+			BorrowerClientTokenAccessor: req.ClientTokenAccessor,
+			CheckOutTime:                time.Now().UTC(),
+		}
+		entry, err := logical.StorageEntryJSON(databaseCheckOutPath+roleName, co)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		respData := map[string]interface{}{
+			"static_role": roleName,
+			"username":    role.StaticAccount.Username,
+		}
+		switch role.CredentialType {
+		case v5.CredentialTypePassword:
+			respData["password"] = role.StaticAccount.Password
+		case v5.CredentialTypeRSAPrivateKey:
+			respData["rsa_private_key"] = string(role.StaticAccount.PrivateKey)
+		}
+
+		resp := b.Secret(SecretLibraryCredsType).Response(respData, map[string]interface{}{
+			"set":         name,
+			"static_role": roleName,
+			"check_out":   id,
+		})
+		resp.Secret.TTL = ttl
+		resp.Secret.MaxTTL = set.MaxTTL
+		b.dbEvent(ctx, "library-check-out", req.Path, roleName, true)
+		return resp, nil
+	}
+
+	return logical.ErrorResponse("no static role of the set is available"), nil
+}
+
+func (b *databaseBackend) pathLibraryCheckIn(force bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+
+		lock := locksutil.LockForKey(b.roleLocks, databaseLibraryPath+name)
+		lock.Lock()
+		defer lock.Unlock()
+
+		set, err := b.librarySet(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if set == nil {
+			return logical.ErrorResponse("unknown library set: %s", name), nil
+		}
+		enforce := !force && !set.DisableCheckInEnforcement
+
+		roleNames := data.Get("static_roles").([]string)
+		if len(roleNames) == 0 {
+			var checkedOut []string
+			for _, roleName := range set.StaticRoles {
+				co, err := getCheckOut(ctx, req.Storage, roleName)
+				if err != nil {
+					return nil, err
+				}
+				if co == nil {
+					continue
+				}
+				if !enforce || isBorrower(req, co) {
+					roleNames = append(roleNames, roleName)
+				}
+				checkedOut = append(checkedOut, roleName)
+			}
+			// Without enforcement, only an unambiguous check-in may leave out
+			// the roles to check in.
+			if !enforce && len(checkedOut) > 1 {
+				return logical.ErrorResponse("static_roles is required when several roles of the set are checked out"), nil
+			}
+		}
+
+		var checkedIn []string
+		for _, roleName := range roleNames {
+			if !strutil.StrListContains(set.StaticRoles, roleName) {
+				return logical.ErrorResponse("static role %q is not part of the set", roleName), nil
+			}
+			co, err := getCheckOut(ctx, req.Storage, roleName)
+			if err != nil {
+				return nil, err
+			}
+			if co == nil {
+				continue
+			}
+			if enforce && !isBorrower(req, co) {
+				return nil, fmt.Errorf("%q: %w", roleName, errCheckedOutByOther)
+			}
+			if err := b.checkIn(ctx, req.Storage, roleName); err != nil {
+				return nil, err
+			}
+			checkedIn = append(checkedIn, roleName)
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"check_ins": checkedIn,
+			},
+		}, nil
+	}
+}
+
+func (b *databaseBackend) pathLibraryStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	set, err := b.librarySet(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	status := make(map[string]interface{}, len(set.StaticRoles))
+	for _, roleName := range set.StaticRoles {
+		co, err := getCheckOut(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		roleStatus := map[string]interface{}{
+			"available": co == nil,
+		}
+		if co != nil {
+			roleStatus["borrower_entity_id"] = co.BorrowerEntityID
+			roleStatus["borrower_client_token_accessor"] = co.BorrowerClientTokenAccessor
+			roleStatus["check_out_time"] = co.CheckOutTime.Format(time.RFC3339)
+		}
+		status[roleName] = roleStatus
+	}
+
+	return &logical.Response{Data: status}, nil
+}
+
+// checkIn rotates the credentials of the checked out static role, then makes
+// it available again. If the rotation fails, the role stays checked out, so
+// that it is not lent with credentials known to the previous borrower.
+func (b *databaseBackend) checkIn(ctx context.Context, s logical.Storage, roleName string) error {
+	role, err := b.StaticRole(ctx, s, roleName)
+	if err != nil {
+		return err
+	}
+	if role != nil {
+		if err := b.rotateStaticRole(ctx, s, roleName, role); err != nil {
+			return fmt.Errorf("failed to rotate the credentials of static role %q on check-in: %w", roleName, err)
+		}
+	}
+	return s.Delete(ctx, databaseCheckOutPath+roleName)
+}
+
+// isBorrower returns whether the request comes from the client which checked
+// out the account.
+func isBorrower(req *logical.Request, co *checkOut) bool {
+	if co.BorrowerEntityID != "" {
+		return req.EntityID == co.BorrowerEntityID
+	}
+	return co.BorrowerClientTokenAccessor != "" && req.ClientTokenAccessor == co.BorrowerClientTokenAccessor
+}
+
+// currentCheckOut returns the check-out of the lease, or nil if the account
+// was checked in since.
+func currentCheckOut(ctx context.Context, req *logical.Request) (string, *checkOut, error) {
+	roleName, _ := req.Secret.InternalData["static_role"].(string)
+	id, _ := req.Secret.InternalData["check_out"].(string)
+	if roleName == "" || id == "" {
+		return "", nil, errors.New("secret is missing check-out internal data")
+	}
+
+	co, err := getCheckOut(ctx, req.Storage, roleName)
+	if err != nil {
+		return "", nil, err
+	}
+	if co == nil || co.ID != id {
+		return roleName, nil, nil
+	}
+	return roleName, co, nil
+}
+
+func (b *databaseBackend) secretLibraryCredsRenew(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	_, co, err := currentCheckOut(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if co == nil {
+		return nil, errors.New("the account was checked in")
+	}
+
+	set, err := b.librarySet(ctx, req.Storage, co.Set)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, fmt.Errorf("library set %q no longer exists", co.Set)
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = set.TTL
+	resp.Secret.MaxTTL = set.MaxTTL
+	return resp, nil
+}
+
+// secretLibraryCredsRevoke checks the account in when its lease ends,
+// enforcing the max_ttl of the set.
+func (b *databaseBackend) secretLibraryCredsRevoke(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	roleName, co, err := currentCheckOut(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if co == nil {
+		return nil, nil
+	}
+
+	lock := locksutil.LockForKey(b.roleLocks, databaseLibraryPath+co.Set)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.checkIn(ctx, req.Storage, roleName); err != nil {
+		return nil, err
+	}
+	b.dbEvent(ctx, "library-check-in", req.Path, roleName, true)
+	return nil, nil
+}
+
+const pathLibrarySetHelpSyn = `
+Manage sets of static roles whose accounts are checked out exclusively.
+`
+
+const pathLibrarySetHelpDesc = `
+A library set lends the accounts of its static roles to one client at a time,
+for legacy systems where dynamic accounts per client are not feasible. A
+client checks an account out at library/:name/check-out, and checks it back
+in at library/:name/check-in or when the lease of the check-out ends. The
+credentials of the account are rotated on check-in.
+
+The rotation_period of the static roles must not be shorter than the max_ttl
+of the set, so that accounts are not rotated while lent.
+`
+
+const pathLibraryCheckOutHelpSyn = `
+Check out the account of an available static role of the set.
+`
+
+const pathLibraryCheckOutHelpDesc = `
+Returns the credentials of an account which is not checked out, with a lease.
+The account is checked in when the lease is revoked or expires.
+`
+
+const pathLibraryCheckInHelpSyn = `
+Check in accounts checked out from the set.
+`
+
+const pathLibraryCheckInHelpDesc = `
+Unless check-in enforcement is disabled on the set, only the entity or token
+which checked an account out may check it in. The credentials of the account
+are rotated before it is made available again.
+`
+
+const pathLibraryManageCheckInHelpSyn = `
+Check in accounts checked out from the set by any client.
+`
+
+const pathLibraryManageCheckInHelpDesc = `
+This endpoint is meant for operators, and bypasses check-in enforcement. The
+credentials of the account are rotated before it is made available again.
+`
+
+const pathLibraryStatusHelpSyn = `
+Report which accounts of the set are checked out, and by whom.
+`
+
+const pathLibraryStatusHelpDesc = pathLibraryStatusHelpSyn
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_Library_CheckOutCheckIn(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+	createRole(t, b, storage, mockDB, "alice")
+	createRole(t, b, storage, mockDB, "bob")
+
+	request := func(op logical.Operation, path, entityID string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			EntityID:  entityID,
+			Data:      data,
+		})
+	}
+
+	resp, err := request(logical.CreateOperation, "library/ops", "", map[string]interface{}{
+		"static_roles": "alice,bob",
+		"max_ttl":      "48h",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected max_ttl longer than the rotation period to be rejected")
+
+	resp, err = request(logical.CreateOperation, "library/ops", "", map[string]interface{}{
+		"static_roles": "alice,bob",
+		"ttl":          "1h",
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	first, err := request(logical.UpdateOperation, "library/ops/check-out", "entity-1", nil)
+	require.NoError(t, err)
+	require.False(t, first.IsError())
+	require.Equal(t, "alice", first.Data["static_role"])
+	require.NotEmpty(t, first.Data["password"])
+	require.Equal(t, "1h0m0s", first.Secret.TTL.String())
+
+	second, err := request(logical.UpdateOperation, "library/ops/check-out", "entity-2", nil)
+	require.NoError(t, err)
+	require.Equal(t, "bob", second.Data["static_role"])
+
+	resp, err = request(logical.UpdateOperation, "library/ops/check-out", "entity-3", nil)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected no account to be available")
+
+	_, err = request(logical.UpdateOperation, "library/ops/check-in", "entity-2", map[string]interface{}{
+		"static_roles": "alice",
+	})
+	require.ErrorIs(t, err, errCheckedOutByOther)
+
+	resp, err = request(logical.DeleteOperation, "static-roles/alice", "", nil)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected a lent static role not to be deleted")
+
+	// Checking in rotates the credentials of the account.
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	resp, err = request(logical.UpdateOperation, "library/ops/check-in", "entity-1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, resp.Data["check_ins"])
+	role, err := b.StaticRole(ctx, storage, "alice")
+	require.NoError(t, err)
+	require.NotEqual(t, first.Data["password"], role.StaticAccount.Password)
+
+	// The end of the lease checks the account in as well.
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    second.Secret,
+	})
+	require.NoError(t, err)
+
+	resp, err = request(logical.ReadOperation, "library/ops/status", "", nil)
+	require.NoError(t, err)
+	for _, name := range []string{"alice", "bob"} {
+		require.Equal(t, true, resp.Data[name].(map[string]interface{})["available"], name)
+	}
+
+	// Revoking the lease of an account checked in since has no effect.
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    first.Secret,
+	})
+	require.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
@@ -250,6 +250,14 @@ func (b *databaseBackend) pathRoleDelete(ctx context.Context, req *logical.Reque
 func (b *databaseBackend) pathStaticRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
+	set, err := b.librarySetOfStaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set != "" {
+		return logical.ErrorResponse("static role %q is lent by library set %q; remove it from the set first", name, set), nil
+	}
+
 	// Grab the exclusive lock
 	lock := locksutil.LockForKey(b.roleLocks, name)
 	lock.Lock()
@@ -258,7 +266,7 @@ func (b *databaseBackend) pathStaticRoleDelete(ctx context.Context, req *logical
 	// Remove the item from the queue
 	_, _ = b.popFromRotationQueueByKey(name)
 
-	err := req.Storage.Delete(ctx, databaseStaticRolePath+name)
+	err = req.Storage.Delete(ctx, databaseStaticRolePath+name)
 	if err != nil {
 		return nil, err
 	}
@@ -211,56 +211,64 @@ func (b *databaseBackend) pathRotateRoleCredentialsUpdate() framework.OperationF
 			return logical.ErrorResponse("no static role found for role name"), nil
 		}
 
-		// In create/update of static accounts, we only care if the operation
-		// err'd , and this call does not return credentials
-		item, err := b.popFromRotationQueueByKey(name)
-		if err != nil {
-			item = &queue.Item{
-				Key: name,
-			}
+		if err := b.rotateStaticRole(ctx, req.Storage, name, role); err != nil {
+			return nil, err
 		}
+		modified = true
+		return nil, nil
+	}
+}
 
-		input := &setStaticAccountInput{
-			RoleName: name,
-			Role:     role,
-		}
-		if walID, ok := item.Value.(string); ok {
-			input.WALID = walID
-		}
-		resp, err := b.setStaticAccount(ctx, req.Storage, input)
-		// if err is not nil, we need to attempt to update the priority and place
-		// this item back on the queue. The err should still be returned at the end
-		// of this method.
-		if err != nil {
-			b.logger.Warn("unable to rotate credentials in rotate-role", "error", err)
-			// Update the priority to re-try this rotation and re-add the item to
-			// the queue
-			item.Priority = time.Now().Add(10 * time.Second).Unix()
-
-			// Preserve the WALID if it was returned
-			if resp != nil && resp.WALID != "" {
-				item.Value = resp.WALID
-			}
-		} else {
-			item.Priority = role.StaticAccount.NextRotationTimeFromInput(resp.RotationTime).Unix()
-			// Clear any stored WAL ID as we must have successfully deleted our WAL to get here.
-			item.Value = ""
-			modified = true
+// rotateStaticRole rotates the credentials of the static role now, and
+// schedules its next rotation. On failure, the rotation is retried in the
+// background.
+func (b *databaseBackend) rotateStaticRole(ctx context.Context, s logical.Storage, name string, role *roleEntry) error {
+	// In create/update of static accounts, we only care if the operation
+	// err'd , and this call does not return credentials
+	item, err := b.popFromRotationQueueByKey(name)
+	if err != nil {
+		item = &queue.Item{
+			Key: name,
 		}
+	}
 
-		// Add their rotation to the queue
-		if err := b.pushItem(item); err != nil {
-			return nil, err
+	input := &setStaticAccountInput{
+		RoleName: name,
+		Role:     role,
+	}
+	if walID, ok := item.Value.(string); ok {
+		input.WALID = walID
+	}
+	resp, err := b.setStaticAccount(ctx, s, input)
+	// if err is not nil, we need to attempt to update the priority and place
+	// this item back on the queue. The err should still be returned at the end
+	// of this method.
+	if err != nil {
+		b.logger.Warn("unable to rotate credentials in rotate-role", "error", err)
+		// Update the priority to re-try this rotation and re-add the item to
+		// the queue
+		item.Priority = time.Now().Add(10 * time.Second).Unix()
+
+		// Preserve the WALID if it was returned
+		if resp != nil && resp.WALID != "" {
+			item.Value = resp.WALID
 		}
+	} else {
+		item.Priority = role.StaticAccount.NextRotationTimeFromInput(resp.RotationTime).Unix()
+		// Clear any stored WAL ID as we must have successfully deleted our WAL to get here.
+		item.Value = ""
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("unable to finish rotating credentials; retries will "+
-				"continue in the background but it is also safe to retry manually: %w", err)
-		}
+	// Add their rotation to the queue
+	if err := b.pushItem(item); err != nil {
+		return err
+	}
 
-		// return any err from the setStaticAccount call
-		return nil, nil
+	if err != nil {
+		return fmt.Errorf("unable to finish rotating credentials; retries will "+
+			"continue in the background but it is also safe to retry manually: %w", err)
 	}
+	return nil
 }
 
 const pathRotateCredentialsUpdateHelpSyn = `
@@ -0,0 +1,203 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// Rotator is implemented by secrets engines which manage the credentials of
+// static accounts outside of a database, such as message brokers and search
+// clusters, so that they can share the rotation settings of database static
+// roles.
+type Rotator interface {
+	// StaticAccounts returns the names of the static accounts to consider
+	// for rotation.
+	StaticAccounts(ctx context.Context, s logical.Storage) ([]string, error)
+
+	// StaticAccountRotation returns the rotation settings of the named
+	// static account, or nil if it no longer exists.
+	StaticAccountRotation(ctx context.Context, s logical.Storage, name string) (*Rotation, error)
+
+	// RotateStaticAccount sets new credentials for the named static account
+	// and records the time of the rotation.
+	RotateStaticAccount(ctx context.Context, s logical.Storage, name string) error
+}
+
+// Rotation holds the rotation settings of a static account. Either Period or
+// Schedule is set; Window only applies to schedule-based rotations.
+type Rotation struct {
+	Period            time.Duration `json:"rotation_period"`
+	Schedule          string        `json:"rotation_schedule"`
+	Window            time.Duration `json:"rotation_window"`
+	LastVaultRotation time.Time     `json:"last_vault_rotation"`
+}
+
+// RotationFields returns the fields used to configure the rotation of a static
+// account, matching those of database static roles.
+func RotationFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"rotation_period": {
+			Type: framework.TypeDurationSecond,
+			Description: `Period for automatic credential rotation of the
+	given username. Mutually exclusive with "rotation_schedule."`,
+		},
+		"rotation_schedule": {
+			Type: framework.TypeString,
+			Description: `Schedule for automatic credential rotation of the
+	given username. Mutually exclusive with "rotation_period."`,
+		},
+		"rotation_window": {
+			Type: framework.TypeDurationSecond,
+			Description: `The window of time in which rotations are allowed to
+	occur starting from a given "rotation_schedule". Requires "rotation_schedule"
+	to be specified`,
+		},
+	}
+}
+
+// Update applies the rotation fields in d to r. One of rotation_period or
+// rotation_schedule must be given when creating a static account, and a
+// rotation_period may not be shorter than minPeriod.
+func (r *Rotation) Update(s Scheduler, d *framework.FieldData, create bool, minPeriod time.Duration) error {
+	periodRaw, periodOk := d.GetOk("rotation_period")
+	scheduleRaw, scheduleOk := d.GetOk("rotation_schedule")
+	windowRaw, windowOk := d.GetOk("rotation_window")
+
+	switch {
+	case periodOk && scheduleOk:
+		return fmt.Errorf("mutually exclusive fields rotation_period and rotation_schedule were both specified; only one of them can be provided")
+	case create && !periodOk && !scheduleOk:
+		return fmt.Errorf("one of rotation_schedule or rotation_period must be provided to create a static account")
+	}
+
+	if periodOk {
+		period := time.Duration(periodRaw.(int)) * time.Second
+		if period < minPeriod {
+			return fmt.Errorf("rotation_period must be %d seconds or more", int(minPeriod.Seconds()))
+		}
+		if windowOk {
+			return fmt.Errorf("rotation_window is invalid with use of rotation_period")
+		}
+		r.Period = period
+		r.Schedule = ""
+		r.Window = 0
+	}
+
+	if scheduleOk {
+		if _, err := s.Parse(scheduleRaw.(string)); err != nil {
+			return fmt.Errorf("could not parse rotation_schedule: %w", err)
+		}
+		r.Schedule = scheduleRaw.(string)
+		r.Period = 0
+	}
+
+	if windowOk && !periodOk {
+		if r.Schedule == "" {
+			return fmt.Errorf("rotation_window is invalid with use of rotation_period")
+		}
+		if err := s.ValidateRotationWindow(windowRaw.(int)); err != nil {
+			return fmt.Errorf("rotation_window is invalid: %w", err)
+		}
+		r.Window = time.Duration(windowRaw.(int)) * time.Second
+	}
+
+	return nil
+}
+
+// NextRotation returns the time the credentials are next due for rotation.
+func (r *Rotation) NextRotation(s Scheduler) time.Time {
+	if r.Schedule == "" {
+		return r.LastVaultRotation.Add(r.Period)
+	}
+	spec, err := s.Parse(r.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return spec.Next(r.LastVaultRotation)
+}
+
+// ShouldRotate returns true if the credentials are due for rotation at now.
+// A schedule-based rotation with a window is only due within the window that
+// follows the latest scheduled time; a missed window waits for the next one.
+func (r *Rotation) ShouldRotate(s Scheduler, now time.Time) bool {
+	if r.Schedule == "" {
+		return !now.Before(r.LastVaultRotation.Add(r.Period))
+	}
+	spec, err := s.Parse(r.Schedule)
+	if err != nil {
+		return false
+	}
+
+	next := spec.Next(r.LastVaultRotation)
+	if now.Before(next) {
+		return false
+	}
+	if r.Window == 0 {
+		return true
+	}
+	for after := spec.Next(next); !after.After(now); after = spec.Next(after) {
+		next = after
+	}
+	return now.Before(next.Add(r.Window))
+}
+
+// TTL returns the approximate time remaining until the next rotation, or zero
+// if the rotation is overdue.
+func (r *Rotation) TTL(s Scheduler, now time.Time) time.Duration {
+	ttl := r.NextRotation(s).Sub(now).Round(time.Second)
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// ResponseData returns the rotation settings in the shape used by database
+// static roles.
+func (r *Rotation) ResponseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"last_vault_rotation": r.LastVaultRotation,
+	}
+	if r.Schedule == "" {
+		data["rotation_period"] = r.Period.Seconds()
+		return data
+	}
+	data["rotation_schedule"] = r.Schedule
+	if r.Window != 0 {
+		data["rotation_window"] = r.Window.Seconds()
+	}
+	return data
+}
+
+// RotateDue rotates the credentials of every static account of rt which is
+// due for rotation at now. Failures are collected and do not stop the
+// remaining rotations.
+func RotateDue(ctx context.Context, s logical.Storage, sched Scheduler, rt Rotator, now time.Time) error {
+	names, err := rt.StaticAccounts(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	var merr *multierror.Error
+	for _, name := range names {
+		rotation, err := rt.StaticAccountRotation(ctx, s, name)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if rotation == nil || !rotation.ShouldRotate(sched, now) {
+			continue
+		}
+		if err := rt.RotateStaticAccount(ctx, s, name); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("failed to rotate %q: %w", name, err))
+		}
+	}
+	return merr.ErrorOrNil()
+}
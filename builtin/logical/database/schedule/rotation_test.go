@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotation_Update(t *testing.T) {
+	s := &DefaultSchedule{}
+	fieldData := func(raw map[string]interface{}) *framework.FieldData {
+		return &framework.FieldData{Raw: raw, Schema: RotationFields()}
+	}
+
+	var r Rotation
+	require.Error(t, r.Update(s, fieldData(nil), true, time.Minute), "expected a rotation setting to be required on create")
+	require.Error(t, r.Update(s, fieldData(map[string]interface{}{"rotation_period": 30}), true, time.Minute))
+	require.Error(t, r.Update(s, fieldData(map[string]interface{}{
+		"rotation_period":   3600,
+		"rotation_schedule": "0 * * * *",
+	}), true, time.Minute))
+
+	require.NoError(t, r.Update(s, fieldData(map[string]interface{}{"rotation_period": 3600}), true, time.Minute))
+	require.Equal(t, time.Hour, r.Period)
+	require.Error(t, r.Update(s, fieldData(map[string]interface{}{"rotation_window": 3600}), false, time.Minute),
+		"expected a window to be rejected with a rotation period")
+
+	require.NoError(t, r.Update(s, fieldData(map[string]interface{}{
+		"rotation_schedule": "0 0 * * *",
+		"rotation_window":   7200,
+	}), false, time.Minute))
+	require.Zero(t, r.Period)
+	require.Equal(t, 2*time.Hour, r.Window)
+	require.Equal(t, map[string]interface{}{
+		"last_vault_rotation": time.Time{},
+		"rotation_schedule":   "0 0 * * *",
+		"rotation_window":     float64(7200),
+	}, r.ResponseData())
+}
+
+func TestRotation_ShouldRotate(t *testing.T) {
+	s := &DefaultSchedule{}
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	period := Rotation{Period: time.Hour, LastVaultRotation: last}
+	require.False(t, period.ShouldRotate(s, last.Add(59*time.Minute)))
+	require.True(t, period.ShouldRotate(s, last.Add(time.Hour)))
+	require.Equal(t, 30*time.Minute, period.TTL(s, last.Add(30*time.Minute)))
+
+	daily := Rotation{Schedule: "0 0 * * *", Window: 2 * time.Hour, LastVaultRotation: last}
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	require.False(t, daily.ShouldRotate(s, midnight.Add(-time.Minute)))
+	require.True(t, daily.ShouldRotate(s, midnight.Add(time.Hour)))
+	require.False(t, daily.ShouldRotate(s, midnight.Add(3*time.Hour)), "expected no rotation outside the window")
+	require.True(t, daily.ShouldRotate(s, midnight.Add(24*time.Hour+time.Minute)), "expected rotation in the next window")
+
+	daily.Window = 0
+	require.True(t, daily.ShouldRotate(s, midnight.Add(3*time.Hour)))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixJWT = "jwt"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"jwks",
+			},
+			SealWrapStorage: []string{
+				"key/",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListKeys(&b),
+			pathKeys(&b),
+			pathRotateKey(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathSign(&b),
+			pathJWKS(&b),
+		},
+
+		Secrets:      []*framework.Secret{},
+		BackendType:  logical.TypeLogical,
+		PeriodicFunc: b.periodicFunc,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// keyLock protects keys against concurrent rotation
+	keyLock sync.RWMutex
+}
+
+// periodicFunc rotates keys whose rotation period has elapsed, and removes
+// retired key versions which are no longer needed for verification.
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	if b.System().ReplicationState().HasState(consts.ReplicationDRSecondary|consts.ReplicationPerformanceStandby) ||
+		(!b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary)) {
+		return nil
+	}
+
+	names, err := req.Storage.List(ctx, "key/")
+	if err != nil {
+		return err
+	}
+
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+
+	for _, name := range names {
+		key, err := b.Key(ctx, req.Storage, name)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			continue
+		}
+
+		changed := key.prune()
+		if key.rotationDue() {
+			if err := key.rotate(); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			if err := b.saveKey(ctx, req.Storage, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const backendHelp = `
+The JWT backend signs short-lived JWTs with claims built from role-defined
+templates, for services which need signed assertions toward third parties.
+Signing keys are rotated automatically, and their public halves are
+published at the unauthenticated 'jwks' endpoint.
+`
@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &logical.StaticSystemView{
+		EntityVal: &logical.Entity{
+			ID:   "entity-id",
+			Name: "alice",
+			Metadata: map[string]string{
+				"email": "alice@example.com",
+			},
+		},
+	}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func doRequest(t *testing.T, b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+		EntityID:  "entity-id",
+	})
+	if err != nil {
+		t.Fatalf("%s %s: %v", op, path, err)
+	}
+	return resp
+}
+
+func readJWKS(t *testing.T, b *backend, s logical.Storage) jose.JSONWebKeySet {
+	t.Helper()
+
+	resp := doRequest(t, b, s, logical.ReadOperation, "jwks", nil)
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &jwks); err != nil {
+		t.Fatal(err)
+	}
+	return jwks
+}
+
+func TestBackend_SignAndVerify(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			b, s := getBackend(t)
+
+			doRequest(t, b, s, logical.CreateOperation, "keys/signing", map[string]interface{}{
+				"algorithm": alg,
+			})
+			resp := doRequest(t, b, s, logical.CreateOperation, "roles/service", map[string]interface{}{
+				"key":      "signing",
+				"issuer":   "https://vault.example.com",
+				"audience": "service-a",
+				"template": `{"email": {{identity.entity.metadata.email}}, "name": {{identity.entity.name}}}`,
+				"ttl":      "10m",
+			})
+			if resp.IsError() {
+				t.Fatalf("failed to create role: %v", resp.Error())
+			}
+
+			resp = doRequest(t, b, s, logical.UpdateOperation, "sign/service", nil)
+			if resp.IsError() {
+				t.Fatalf("failed to sign: %v", resp.Error())
+			}
+
+			token, err := jwt.ParseSigned(resp.Data["token"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwks := readJWKS(t, b, s)
+			keys := jwks.Key(resp.Data["key_id"].(string))
+			if len(keys) != 1 {
+				t.Fatalf("expected key %q in jwks", resp.Data["key_id"])
+			}
+
+			var std jwt.Claims
+			custom := map[string]interface{}{}
+			if err := token.Claims(keys[0], &std, &custom); err != nil {
+				t.Fatal(err)
+			}
+			if err := std.Validate(jwt.Expected{
+				Issuer:   "https://vault.example.com",
+				Subject:  "entity-id",
+				Audience: jwt.Audience{"service-a"},
+				Time:     time.Now(),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if ttl := std.Expiry.Time().Sub(std.IssuedAt.Time()); ttl != 10*time.Minute {
+				t.Fatalf("expected ttl of 10m, got %s", ttl)
+			}
+			if custom["email"] != "alice@example.com" || custom["name"] != "alice" {
+				t.Fatalf("unexpected templated claims: %v", custom)
+			}
+			if std.ID == "" {
+				t.Fatal("expected jti claim")
+			}
+		})
+	}
+}
+
+func TestBackend_RoleValidation(t *testing.T) {
+	b, s := getBackend(t)
+
+	doRequest(t, b, s, logical.CreateOperation, "keys/signing", nil)
+
+	for name, data := range map[string]map[string]interface{}{
+		"unknown key":    {"key": "missing"},
+		"reserved claim": {"key": "signing", "template": `{"exp": 1}`},
+		"invalid json":   {"key": "signing", "template": `{"email": `},
+		"ttl over max":   {"key": "signing", "ttl": "2h", "max_ttl": "1h"},
+	} {
+		resp := doRequest(t, b, s, logical.CreateOperation, "roles/role", data)
+		if !resp.IsError() {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	doRequest(t, b, s, logical.CreateOperation, "roles/role", map[string]interface{}{
+		"key":     "signing",
+		"max_ttl": "15m",
+	})
+	resp := doRequest(t, b, s, logical.UpdateOperation, "sign/role", map[string]interface{}{
+		"ttl": "1h",
+	})
+	if !resp.IsError() {
+		t.Fatal("expected ttl beyond max_ttl to be rejected")
+	}
+
+	resp = doRequest(t, b, s, logical.DeleteOperation, "keys/signing", nil)
+	if !resp.IsError() {
+		t.Fatal("expected deleting a key in use to fail")
+	}
+}
+
+func TestBackend_KeyRotation(t *testing.T) {
+	b, s := getBackend(t)
+
+	doRequest(t, b, s, logical.CreateOperation, "keys/signing", map[string]interface{}{
+		"algorithm":        "ES256",
+		"verification_ttl": "1h",
+	})
+	doRequest(t, b, s, logical.CreateOperation, "roles/role", map[string]interface{}{
+		"key": "signing",
+	})
+
+	before := doRequest(t, b, s, logical.UpdateOperation, "sign/role", nil).Data["key_id"].(string)
+	doRequest(t, b, s, logical.UpdateOperation, "keys/signing/rotate", nil)
+	after := doRequest(t, b, s, logical.UpdateOperation, "sign/role", nil).Data["key_id"].(string)
+	if before == after {
+		t.Fatal("expected tokens to be signed with a new key version after rotation")
+	}
+
+	jwks := readJWKS(t, b, s)
+	if len(jwks.Key(before)) != 1 || len(jwks.Key(after)) != 1 {
+		t.Fatalf("expected both key versions to be published, got %d keys", len(jwks.Keys))
+	}
+
+	// Retire the previous version beyond its verification TTL
+	key, err := b.Key(context.Background(), s, "signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Versions[0].RetiredTime = time.Now().Add(-2 * time.Hour)
+	key.LastRotated = time.Now().Add(-25 * time.Hour)
+	if err := b.saveKey(context.Background(), s, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+
+	jwks = readJWKS(t, b, s)
+	if len(jwks.Key(before)) != 0 {
+		t.Fatal("expected expired key version to be pruned")
+	}
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected periodic rotation to publish a new key version, got %d keys", len(jwks.Keys))
+	}
+}
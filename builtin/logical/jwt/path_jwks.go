@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathJWKS(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "jwks/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationVerb:   "read",
+			OperationSuffix: "public-keys",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathJWKSRead,
+		},
+
+		HelpSynopsis:    pathJWKSHelpSyn,
+		HelpDescription: pathJWKSHelpDesc,
+	}
+}
+
+func (b *backend) pathJWKSRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, "key/")
+	if err != nil {
+		return nil, err
+	}
+
+	b.keyLock.RLock()
+	defer b.keyLock.RUnlock()
+
+	jwks := jose.JSONWebKeySet{
+		Keys: make([]jose.JSONWebKey, 0),
+	}
+	for _, name := range names {
+		key, err := b.Key(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			continue
+		}
+		key.prune()
+		for _, v := range key.Versions {
+			jwks.Keys = append(jwks.Keys, v.Key.Public())
+		}
+	}
+
+	body, err := json.Marshal(jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     body,
+			logical.HTTPContentType: "application/json",
+		},
+	}, nil
+}
+
+const pathJWKSHelpSyn = `
+Retrieve the public keys JWTs can be verified with.
+`
+
+const pathJWKSHelpDesc = `
+This unauthenticated path returns a JSON Web Key Set of the current and
+recently rotated versions of every key.
+`
@@ -0,0 +1,415 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultKeyAlgorithm       = "RS256"
+	defaultRotationPeriod     = 24 * time.Hour
+	defaultVerificationTTL    = 24 * time.Hour
+	minimumRotationPeriod     = time.Minute
+	supportedAlgorithmsString = "RS256, RS384, RS512, ES256, ES384, ES512, EdDSA"
+)
+
+// namedKey is a signing key and the versions it was rotated through. The
+// last version is used for signing; earlier versions are retained for
+// VerificationTTL after being retired, so that tokens they signed can still
+// be verified.
+type namedKey struct {
+	Name            string        `json:"name"`
+	Algorithm       string        `json:"algorithm"`
+	RotationPeriod  time.Duration `json:"rotation_period"`
+	VerificationTTL time.Duration `json:"verification_ttl"`
+	LastRotated     time.Time     `json:"last_rotated"`
+	Versions        []*keyVersion `json:"versions"`
+}
+
+type keyVersion struct {
+	Key          *jose.JSONWebKey `json:"key"`
+	CreationTime time.Time        `json:"creation_time"`
+	RetiredTime  time.Time        `json:"retired_time,omitempty"`
+}
+
+func (k *namedKey) current() *keyVersion {
+	if len(k.Versions) == 0 {
+		return nil
+	}
+	return k.Versions[len(k.Versions)-1]
+}
+
+// rotate generates a new key version and retires the current one.
+func (k *namedKey) rotate() error {
+	jwk, err := generateKey(k.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if current := k.current(); current != nil {
+		current.RetiredTime = now
+	}
+	k.Versions = append(k.Versions, &keyVersion{
+		Key:          jwk,
+		CreationTime: now,
+	})
+	k.LastRotated = now
+	return nil
+}
+
+func (k *namedKey) rotationDue() bool {
+	return k.RotationPeriod > 0 && time.Now().After(k.LastRotated.Add(k.RotationPeriod))
+}
+
+// prune removes retired versions which are no longer needed for
+// verification, and reports whether any were removed.
+func (k *namedKey) prune() bool {
+	var versions []*keyVersion
+	for _, v := range k.Versions {
+		if v.RetiredTime.IsZero() || time.Now().Before(v.RetiredTime.Add(k.VerificationTTL)) {
+			versions = append(versions, v)
+		}
+	}
+	pruned := len(versions) != len(k.Versions)
+	k.Versions = versions
+	return pruned
+}
+
+func (k *namedKey) keyIDs() []string {
+	ids := make([]string, 0, len(k.Versions))
+	for _, v := range k.Versions {
+		ids = append(ids, v.Key.KeyID)
+	}
+	return ids
+}
+
+func generateKey(algorithm string) (*jose.JSONWebKey, error) {
+	var key interface{}
+	var err error
+
+	switch algorithm {
+	case "RS256", "RS384", "RS512":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &jose.JSONWebKey{
+		Key:       key,
+		KeyID:     id,
+		Algorithm: algorithm,
+		Use:       "sig",
+	}, nil
+}
+
+func pathListKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationSuffix: "keys",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKeyList,
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"algorithm": {
+				Type:        framework.TypeString,
+				Default:     defaultKeyAlgorithm,
+				Description: "Signing algorithm of the key. Options include " + supportedAlgorithmsString + ". Cannot be changed once the key is created.",
+			},
+
+			"rotation_period": {
+				Type:        framework.TypeDurationSecond,
+				Default:     int(defaultRotationPeriod.Seconds()),
+				Description: "How often the key is rotated. Zero disables automatic rotation.",
+			},
+
+			"verification_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Default:     int(defaultVerificationTTL.Seconds()),
+				Description: "How long a key version is published after being rotated out, so that tokens it signed can still be verified. Should be at least the longest TTL of the roles using the key.",
+			},
+		},
+
+		ExistenceCheck: b.pathKeyExistenceCheck,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKeyRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathKeyWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathKeyWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "update",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathKeyDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathRotateKey(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/rotate$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationVerb:   "rotate",
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKeyRotate,
+		},
+
+		HelpSynopsis:    pathRotateKeyHelpSyn,
+		HelpDescription: pathRotateKeyHelpDesc,
+	}
+}
+
+func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*namedKey, error) {
+	entry, err := s.Get(ctx, "key/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result namedKey
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) saveKey(ctx context.Context, s logical.Storage, key *namedKey) error {
+	entry, err := logical.StorageEntryJSON("key/"+key.Name, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) pathKeyExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	key, err := b.Key(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return key != nil, nil
+}
+
+func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "key/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.keyLock.RLock()
+	defer b.keyLock.RUnlock()
+
+	key, err := b.Key(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"algorithm":        key.Algorithm,
+			"rotation_period":  int64(key.RotationPeriod.Seconds()),
+			"verification_ttl": int64(key.VerificationTTL.Seconds()),
+			"last_rotated":     key.LastRotated.Format(time.RFC3339),
+			"current_key_id":   key.current().Key.KeyID,
+			"key_ids":          key.keyIDs(),
+		},
+	}, nil
+}
+
+func (b *backend) pathKeyWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	create := key == nil
+	if create {
+		key = &namedKey{
+			Name:            name,
+			Algorithm:       data.Get("algorithm").(string),
+			RotationPeriod:  time.Duration(data.Get("rotation_period").(int)) * time.Second,
+			VerificationTTL: time.Duration(data.Get("verification_ttl").(int)) * time.Second,
+		}
+	} else {
+		if algorithm, ok := data.GetOk("algorithm"); ok && algorithm.(string) != key.Algorithm {
+			return logical.ErrorResponse("the algorithm of an existing key cannot be changed"), nil
+		}
+		if rotationPeriod, ok := data.GetOk("rotation_period"); ok {
+			key.RotationPeriod = time.Duration(rotationPeriod.(int)) * time.Second
+		}
+		if verificationTTL, ok := data.GetOk("verification_ttl"); ok {
+			key.VerificationTTL = time.Duration(verificationTTL.(int)) * time.Second
+		}
+	}
+
+	if key.RotationPeriod != 0 && key.RotationPeriod < minimumRotationPeriod {
+		return logical.ErrorResponse("rotation_period must be at least %s, or zero to disable automatic rotation", minimumRotationPeriod), nil
+	}
+	if key.VerificationTTL < 0 {
+		return logical.ErrorResponse("verification_ttl must not be negative"), nil
+	}
+
+	if create {
+		if err := key.rotate(); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	return nil, b.saveKey(ctx, req.Storage, key)
+}
+
+func (b *backend) pathKeyRotate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+
+	key, err := b.Key(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("unknown key %q", data.Get("name").(string)), nil
+	}
+
+	key.prune()
+	if err := key.rotate(); err != nil {
+		return nil, err
+	}
+
+	return nil, b.saveKey(ctx, req.Storage, key)
+}
+
+func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	roles, err := b.rolesUsingKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) > 0 {
+		return logical.ErrorResponse("key %q is in use by roles %v", name, roles), nil
+	}
+
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+
+	return nil, req.Storage.Delete(ctx, "key/"+name)
+}
+
+const pathKeyHelpSyn = `
+Manage the keys JWTs are signed with.
+`
+
+const pathKeyHelpDesc = `
+This path lets you create, read, update and delete named signing keys. Keys
+are rotated every 'rotation_period'; after rotation, the previous key version
+remains published at the 'jwks' endpoint for 'verification_ttl' so that
+tokens it signed can still be verified.
+`
+
+const pathRotateKeyHelpSyn = `
+Rotate a signing key.
+`
+
+const pathRotateKeyHelpDesc = `
+This path generates a new version of the key which is used for signing from
+then on. The previous version remains published for 'verification_ttl'.
+`
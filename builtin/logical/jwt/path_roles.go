@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultRoleTTL    = 5 * time.Minute
+	defaultRoleMaxTTL = time.Hour
+)
+
+// reservedClaims are claims which are always set by the backend and cannot
+// be set by a role template.
+var reservedClaims = []string{
+	"iat",
+	"nbf",
+	"exp",
+	"jti",
+	"iss",
+	"aud",
+}
+
+type role struct {
+	Key      string        `json:"key"`
+	Issuer   string        `json:"issuer"`
+	Audience []string      `json:"audience"`
+	Template string        `json:"template"`
+	TTL      time.Duration `json:"ttl"`
+	MaxTTL   time.Duration `json:"max_ttl"`
+}
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationSuffix: "roles",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationSuffix: "role",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"key": {
+				Type:        framework.TypeString,
+				Description: "Name of the key tokens are signed with.",
+			},
+
+			"issuer": {
+				Type:        framework.TypeString,
+				Description: "Value of the 'iss' claim. Omitted if empty.",
+			},
+
+			"audience": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Values of the 'aud' claim. Omitted if empty.",
+			},
+
+			"template": {
+				Type:        framework.TypeString,
+				Description: "JSON template of the claims, which may use identity templating, e.g. {\"email\": {{identity.entity.metadata.email}}}. May be base64 encoded.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Default:     int(defaultRoleTTL.Seconds()),
+				Description: "Default lifetime of tokens.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Default:     int(defaultRoleMaxTTL.Seconds()),
+				Description: "Maximum lifetime of tokens which may be requested.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathRoleRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "update",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathRoleDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func (b *backend) Role(ctx context.Context, s logical.Storage, n string) (*role, error) {
+	entry, err := s.Get(ctx, "role/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result role
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// rolesUsingKey returns the names of the roles which sign with the key.
+func (b *backend) rolesUsingKey(ctx context.Context, s logical.Storage, key string) ([]string, error) {
+	names, err := s.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []string
+	for _, name := range names {
+		r, err := b.Role(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil && r.Key == key {
+			roles = append(roles, name)
+		}
+	}
+	return roles, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	r, err := b.Role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return r != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	r, err := b.Role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key":      r.Key,
+			"issuer":   r.Issuer,
+			"audience": r.Audience,
+			"template": r.Template,
+			"ttl":      int64(r.TTL.Seconds()),
+			"max_ttl":  int64(r.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		r = &role{}
+	}
+
+	if key, ok := data.GetOk("key"); ok {
+		r.Key = key.(string)
+	}
+	if issuer, ok := data.GetOk("issuer"); ok {
+		r.Issuer = issuer.(string)
+	}
+	if audience, ok := data.GetOk("audience"); ok {
+		r.Audience = audience.([]string)
+	}
+	if template, ok := data.GetOk("template"); ok {
+		r.Template = template.(string)
+		// Attempt to decode as base64 and use that if it works
+		if decoded, err := base64.StdEncoding.DecodeString(r.Template); err == nil {
+			r.Template = string(decoded)
+		}
+	}
+	if ttl, ok := data.GetOk("ttl"); ok {
+		r.TTL = time.Duration(ttl.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		r.TTL = time.Duration(data.Get("ttl").(int)) * time.Second
+	}
+	if maxTTL, ok := data.GetOk("max_ttl"); ok {
+		r.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		r.MaxTTL = time.Duration(data.Get("max_ttl").(int)) * time.Second
+	}
+
+	if r.Key == "" {
+		return logical.ErrorResponse("key is required"), nil
+	}
+	key, err := b.Key(ctx, req.Storage, r.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("unknown key %q", r.Key), nil
+	}
+	if r.TTL <= 0 || r.MaxTTL <= 0 {
+		return logical.ErrorResponse("ttl and max_ttl must be greater than zero"), nil
+	}
+	if r.TTL > r.MaxTTL {
+		return logical.ErrorResponse("ttl must not exceed max_ttl"), nil
+	}
+
+	// Validate that the template can be parsed and results in valid JSON
+	if r.Template != "" {
+		_, populatedTemplate, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:   identitytpl.JSONTemplating,
+			String: r.Template,
+			Entity: new(logical.Entity),
+			Groups: make([]*logical.Group, 0),
+		})
+		if err != nil {
+			return logical.ErrorResponse("error parsing template: %s", err.Error()), nil
+		}
+
+		var tmp map[string]interface{}
+		if err := json.Unmarshal([]byte(populatedTemplate), &tmp); err != nil {
+			return logical.ErrorResponse("error parsing template JSON: %s", err.Error()), nil
+		}
+
+		for claim := range tmp {
+			if strutil.StrListContains(reservedClaims, claim) {
+				return logical.ErrorResponse("top level key %q not allowed. Restricted keys: %s",
+					claim, strings.Join(reservedClaims, ", ")), nil
+			}
+		}
+	}
+
+	var resp *logical.Response
+	if r.MaxTTL > key.VerificationTTL && key.RotationPeriod > 0 {
+		resp = &logical.Response{}
+		resp.AddWarning("max_ttl exceeds the verification_ttl of the key; tokens may outlive the key they were signed with")
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const pathRoleHelpSyn = `
+Manage the roles JWTs are signed for.
+`
+
+const pathRoleHelpDesc = `
+This path lets you create, read, update and delete roles. A role defines the
+key tokens are signed with, their lifetime, and a JSON template of their
+claims. Templates may use identity templating to include details of the
+requesting entity, e.g.:
+
+  {"email": {{identity.entity.metadata.email}}, "groups": {{identity.entity.groups.names}}}
+
+The 'iat', 'nbf', 'exp', 'jti', 'iss' and 'aud' claims are set by Vault and
+cannot be templated. The 'sub' claim defaults to the entity ID.
+`
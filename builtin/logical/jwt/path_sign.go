@@ -0,0 +1,201 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathSign(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixJWT,
+			OperationVerb:   "sign",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Lifetime of the token. Defaults to the ttl of the role, and cannot exceed its max_ttl.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathSignWrite,
+		},
+
+		HelpSynopsis:    pathSignHelpSyn,
+		HelpDescription: pathSignHelpDesc,
+	}
+}
+
+func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return logical.ErrorResponse("unknown role %q", name), nil
+	}
+
+	ttl := r.TTL
+	if ttlRaw, ok := data.GetOk("ttl"); ok {
+		ttl = time.Duration(ttlRaw.(int)) * time.Second
+	}
+	if ttl <= 0 {
+		return logical.ErrorResponse("ttl must be greater than zero"), nil
+	}
+	if ttl > r.MaxTTL {
+		return logical.ErrorResponse("ttl exceeds the max_ttl of the role"), nil
+	}
+
+	claims, err := b.templateClaims(r, req.EntityID)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	jti, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiry := now.Add(ttl)
+	if _, ok := claims["sub"]; !ok && req.EntityID != "" {
+		claims["sub"] = req.EntityID
+	}
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expiry.Unix()
+	claims["jti"] = jti
+	if r.Issuer != "" {
+		claims["iss"] = r.Issuer
+	}
+	switch len(r.Audience) {
+	case 0:
+	case 1:
+		claims["aud"] = r.Audience[0]
+	default:
+		claims["aud"] = r.Audience
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token, keyID, err := b.signPayload(ctx, req.Storage, r.Key, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"token":      token,
+			"key_id":     keyID,
+			"expiration": expiry.Unix(),
+		},
+	}, nil
+}
+
+// templateClaims returns the claims from the template of the role, populated
+// with the identity of the entity.
+func (b *backend) templateClaims(r *role, entityID string) (map[string]interface{}, error) {
+	claims := make(map[string]interface{})
+	if r.Template == "" {
+		return claims, nil
+	}
+
+	input := identitytpl.PopulateStringInput{
+		Mode:   identitytpl.JSONTemplating,
+		String: r.Template,
+	}
+	if entityID != "" {
+		entity, err := b.System().EntityInfo(entityID)
+		if err != nil {
+			return nil, err
+		}
+		groups, err := b.System().GroupsForEntity(entityID)
+		if err != nil {
+			return nil, err
+		}
+		input.Entity = entity
+		input.Groups = groups
+		if entity != nil {
+			input.NamespaceID = entity.NamespaceID
+		}
+	}
+
+	_, populated, err := identitytpl.PopulateString(input)
+	if err != nil {
+		return nil, fmt.Errorf("error populating template: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(populated), &claims); err != nil {
+		return nil, fmt.Errorf("error parsing populated template: %w", err)
+	}
+	return claims, nil
+}
+
+// signPayload signs payload with the current version of the named key, and
+// returns the compact serialized JWS and the ID of the key version.
+func (b *backend) signPayload(ctx context.Context, s logical.Storage, keyName string, payload []byte) (string, string, error) {
+	b.keyLock.RLock()
+	defer b.keyLock.RUnlock()
+
+	key, err := b.Key(ctx, s, keyName)
+	if err != nil {
+		return "", "", err
+	}
+	if key == nil || key.current() == nil {
+		return "", "", fmt.Errorf("key %q not found", keyName)
+	}
+	jwk := key.current().Key
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.SignatureAlgorithm(key.Algorithm),
+		Key:       jwk,
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", "", err
+	}
+
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := signature.CompactSerialize()
+	if err != nil {
+		return "", "", err
+	}
+	return token, jwk.KeyID, nil
+}
+
+const pathSignHelpSyn = `
+Sign a JWT for a role.
+`
+
+const pathSignHelpDesc = `
+This path returns a JWT with the claims defined by the role, signed with the
+current version of its key. The token can be verified using the public keys
+published at the 'jwks' endpoint.
+`
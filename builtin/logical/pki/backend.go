@@ -116,6 +116,16 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"unified-ocsp",   // Unified OCSP POST
 				"unified-ocsp/*", // Unified OCSP GET
 
+				"scep",
+				"scep/pkiclient.exe",
+				"roles/+/scep",
+				"roles/+/scep/pkiclient.exe",
+				"issuer/+/cmp",
+
+				"bundles/+/pem",
+				"bundles/+/p7b",
+				"bundles/+/jks",
+
 				// ACME paths are added below
 			},
 
@@ -126,6 +136,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 				clusterConfigPath,
 				"crls/",
 				"certs/",
+				certMetadataPath,
 				acmePathPrefix,
 			},
 
@@ -156,6 +167,19 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ocsp/*",         // OCSP GET
 				"unified-ocsp",   // Unified OCSP POST
 				"unified-ocsp/*", // Unified OCSP GET
+				"scep",
+				"scep/pkiclient.exe",
+				"roles/+/scep",
+				"roles/+/scep/pkiclient.exe",
+				"issuer/+/cmp",
+			},
+
+			PaginatedList: []string{
+				"certs/",
+				"certs/revoked/",
+				"issuers/",
+				"keys/",
+				"roles/",
 			},
 		},
 
@@ -219,6 +243,12 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathFetchValidRaw(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathCertMetadata(&b),
+
+			// Trust bundles
+			pathListTrustBundles(&b),
+			pathTrustBundle(&b),
+			pathFetchTrustBundle(&b),
 
 			// OCSP APIs
 			buildPathOcspGet(&b),
@@ -232,6 +262,15 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathAcmeConfig(&b),
 			pathAcmeEabList(&b),
 			pathAcmeEabDelete(&b),
+
+			// SCEP
+			pathScepConfig(&b),
+			pathScepChallengePolicy(&b),
+			pathScepChallengeGenerate(&b),
+
+			// CMP
+			pathCmpConfig(&b),
+			pathCmp(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -245,6 +284,8 @@ func Backend(conf *logical.BackendConfig) *backend {
 		Clean:          b.cleanup,
 	}
 
+	b.Backend.Paths = append(b.Backend.Paths, pathScep(&b)...)
+
 	// Add ACME paths to backend
 	for _, prefix := range []struct {
 		acmePrefix   string
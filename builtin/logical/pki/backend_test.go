@@ -4071,6 +4071,11 @@ func TestBackend_RevokePlusTidy_Intermediate(t *testing.T) {
 			"acme_account_revoked_count":            json.Number("0"),
 			"acme_account_deleted_count":            json.Number("0"),
 			"total_acme_account_count":              json.Number("0"),
+			"tidy_issued_certs":                     false,
+			"issued_cert_revoked_count":             json.Number("0"),
+			"issued_cert_deleted_count":             json.Number("0"),
+			"tidy_deleted_entity_certs":             false,
+			"deleted_entity_cert_revoked_count":     json.Number("0"),
 		}
 		// Let's copy the times from the response so that we can use deep.Equal()
 		timeStarted, ok := tidyStatus.Data["time_started"]
@@ -6879,6 +6884,11 @@ func TestProperAuthing(t *testing.T) {
 		"unified-ocsp/dGVzdAo=":                  shouldBeUnauthedReadList,
 		"eab/":                                   shouldBeAuthed,
 		"eab/" + eabKid:                          shouldBeAuthed,
+		"bundles/":                               shouldBeAuthed,
+		"bundles/test":                           shouldBeAuthed,
+		"bundles/test/pem":                       shouldBeUnauthedReadList,
+		"bundles/test/p7b":                       shouldBeUnauthedReadList,
+		"bundles/test/jks":                       shouldBeUnauthedReadList,
 	}
 
 	entPaths := getEntProperAuthingPaths(serial)
@@ -6930,6 +6940,9 @@ func TestProperAuthing(t *testing.T) {
 		if strings.Contains(raw_path, "roles/") && strings.Contains(raw_path, "{name}") {
 			raw_path = strings.ReplaceAll(raw_path, "{name}", "test")
 		}
+		if strings.Contains(raw_path, "bundles/") && strings.Contains(raw_path, "{name}") {
+			raw_path = strings.ReplaceAll(raw_path, "{name}", "test")
+		}
 		if strings.Contains(raw_path, "{role}") {
 			raw_path = strings.ReplaceAll(raw_path, "{role}", "test")
 		}
@@ -0,0 +1,434 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package cmp implements the message format of the Certificate Management
+// Protocol (RFC 4210), and of the Certificate Request Message Format it
+// carries (RFC 4211).
+package cmp
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// ContentType is the content type of CMP messages transferred over HTTP
+// (RFC 6712).
+const ContentType = "application/pkixcmp"
+
+// BodyType is the type of the body of a PKIMessage.
+type BodyType int
+
+const (
+	BodyIR       BodyType = 0
+	BodyIP       BodyType = 1
+	BodyCR       BodyType = 2
+	BodyCP       BodyType = 3
+	BodyP10CR    BodyType = 4
+	BodyKUR      BodyType = 7
+	BodyKUP      BodyType = 8
+	BodyPKIConf  BodyType = 19
+	BodyError    BodyType = 23
+	BodyCertConf BodyType = 24
+)
+
+func (t BodyType) String() string {
+	switch t {
+	case BodyIR:
+		return "ir"
+	case BodyIP:
+		return "ip"
+	case BodyCR:
+		return "cr"
+	case BodyCP:
+		return "cp"
+	case BodyP10CR:
+		return "p10cr"
+	case BodyKUR:
+		return "kur"
+	case BodyKUP:
+		return "kup"
+	case BodyPKIConf:
+		return "pkiconf"
+	case BodyError:
+		return "error"
+	case BodyCertConf:
+		return "certConf"
+	default:
+		return fmt.Sprintf("body(%d)", int(t))
+	}
+}
+
+// FailInfo is a bit of the PKIFailureInfo of a rejected request.
+type FailInfo int
+
+const (
+	BadAlg           FailInfo = 0
+	BadMessageCheck  FailInfo = 1
+	BadRequest       FailInfo = 2
+	BadTime          FailInfo = 3
+	BadCertID        FailInfo = 4
+	BadDataFormat    FailInfo = 5
+	WrongAuthority   FailInfo = 6
+	IncorrectData    FailInfo = 7
+	BadPOP           FailInfo = 9
+	CertRevoked      FailInfo = 10
+	BadCertTemplate  FailInfo = 19
+	SignerNotTrusted FailInfo = 20
+	NotAuthorized    FailInfo = 23
+	SystemFailure    FailInfo = 25
+)
+
+const (
+	statusAccepted  = 0
+	statusRejection = 2
+)
+
+var oidImplicitConfirm = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 4, 13}
+
+type pkiMessage struct {
+	Header     asn1.RawValue
+	Body       asn1.RawValue
+	Protection asn1.BitString  `asn1:"explicit,optional,tag:0"`
+	ExtraCerts []asn1.RawValue `asn1:"explicit,optional,tag:1"`
+}
+
+type protectedPart struct {
+	Header asn1.RawValue
+	Body   asn1.RawValue
+}
+
+type pkiHeader struct {
+	PVNO          int
+	Sender        asn1.RawValue
+	Recipient     asn1.RawValue
+	MessageTime   time.Time                `asn1:"generalized,explicit,optional,tag:0"`
+	ProtectionAlg pkix.AlgorithmIdentifier `asn1:"explicit,optional,tag:1"`
+	SenderKID     []byte                   `asn1:"explicit,optional,tag:2"`
+	RecipKID      []byte                   `asn1:"explicit,optional,tag:3"`
+	TransactionID []byte                   `asn1:"explicit,optional,tag:4"`
+	SenderNonce   []byte                   `asn1:"explicit,optional,tag:5"`
+	RecipNonce    []byte                   `asn1:"explicit,optional,tag:6"`
+	FreeText      asn1.RawValue            `asn1:"explicit,optional,tag:7"`
+	GeneralInfo   []infoTypeAndValue       `asn1:"explicit,optional,tag:8"`
+}
+
+type infoTypeAndValue struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"`
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+type certStatus struct {
+	CertHash   []byte
+	CertReqID  *big.Int
+	StatusInfo pkiStatusInfo `asn1:"optional"`
+}
+
+type certRepMessage struct {
+	CAPubs   []asn1.RawValue `asn1:"explicit,optional,tag:1"`
+	Response []certResponse
+}
+
+type certResponse struct {
+	CertReqID        *big.Int
+	Status           pkiStatusInfo
+	CertifiedKeyPair certifiedKeyPair `asn1:"optional"`
+}
+
+type certifiedKeyPair struct {
+	CertOrEncCert asn1.RawValue
+}
+
+type errorMsgContent struct {
+	PKIStatusInfo pkiStatusInfo
+	ErrorDetails  []asn1.RawValue `asn1:"optional"`
+}
+
+// Message is a CMP request.
+type Message struct {
+	Type BodyType
+
+	TransactionID []byte
+	SenderNonce   []byte
+	SenderKID     []byte
+
+	// ImplicitConfirm is set when the requester does not intend to confirm
+	// the issued certificates with a certConf message.
+	ImplicitConfirm bool
+
+	// Requests are the certificate requests of ir, cr, kur and p10cr
+	// messages.
+	Requests []*CertRequest
+
+	// Confirmations are the certificate confirmations of certConf messages.
+	Confirmations []CertStatus
+
+	// ExtraCerts are the certificates sent with the message; with signature
+	// protection, the first one is the certificate of the signer.
+	ExtraCerts []*x509.Certificate
+
+	protectionAlg pkix.AlgorithmIdentifier
+	protected     []byte
+	protection    []byte
+	sender        asn1.RawValue
+}
+
+// CertStatus is the confirmation of an issued certificate.
+type CertStatus struct {
+	CertHash []byte
+	ID       *big.Int
+	Accepted bool
+}
+
+// ParseMessage parses a CMP request. The protection of the message is not
+// verified; see VerifySignature and VerifyMAC.
+func ParseMessage(der []byte) (*Message, error) {
+	var msg pkiMessage
+	rest, err := asn1.Unmarshal(der, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PKIMessage: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after PKIMessage")
+	}
+
+	var header pkiHeader
+	if _, err := asn1.Unmarshal(msg.Header.FullBytes, &header); err != nil {
+		return nil, fmt.Errorf("error parsing PKIHeader: %w", err)
+	}
+	if header.PVNO != 2 && header.PVNO != 3 {
+		return nil, fmt.Errorf("unsupported protocol version %d", header.PVNO)
+	}
+
+	protected, err := asn1.Marshal(protectedPart{Header: msg.Header, Body: msg.Body})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		Type:          BodyType(msg.Body.Tag),
+		TransactionID: header.TransactionID,
+		SenderNonce:   header.SenderNonce,
+		SenderKID:     header.SenderKID,
+		protectionAlg: header.ProtectionAlg,
+		protected:     protected,
+		protection:    msg.Protection.RightAlign(),
+		sender:        header.Sender,
+	}
+	for _, info := range header.GeneralInfo {
+		if info.Type.Equal(oidImplicitConfirm) {
+			m.ImplicitConfirm = true
+		}
+	}
+	for _, raw := range msg.ExtraCerts {
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing extra certificate: %w", err)
+		}
+		m.ExtraCerts = append(m.ExtraCerts, cert)
+	}
+
+	if msg.Body.Class != asn1.ClassContextSpecific || !msg.Body.IsCompound {
+		return nil, errors.New("malformed PKIBody")
+	}
+	switch m.Type {
+	case BodyIR, BodyCR, BodyKUR:
+		m.Requests, err = parseCertReqMessages(msg.Body.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	case BodyP10CR:
+		csr, err := x509.ParseCertificateRequest(msg.Body.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate request: %w", err)
+		}
+		m.Requests = []*CertRequest{{ID: big.NewInt(-1), CSR: csr}}
+	case BodyCertConf:
+		var statuses []certStatus
+		if _, err := asn1.Unmarshal(msg.Body.Bytes, &statuses); err != nil {
+			return nil, fmt.Errorf("error parsing certConf: %w", err)
+		}
+		for _, status := range statuses {
+			m.Confirmations = append(m.Confirmations, CertStatus{
+				CertHash: status.CertHash,
+				ID:       status.CertReqID,
+				Accepted: status.StatusInfo.Status == statusAccepted,
+			})
+		}
+	}
+
+	return m, nil
+}
+
+// CertResult is the outcome of a certificate request: either the issued
+// certificate, or the reason it was rejected.
+type CertResult struct {
+	ID          *big.Int
+	Certificate *x509.Certificate
+	FailInfo    FailInfo
+	Reason      string
+}
+
+// Response is a CMP response, to be protected with Sign or MAC.
+type Response struct {
+	req  *Message
+	typ  BodyType
+	body []byte
+}
+
+// CertRep returns the response to an ir, cr, p10cr or kur message. The
+// caPubs are sent with responses to initialization requests, to provide the
+// requester with its trust anchors.
+func (m *Message) CertRep(results []CertResult, caPubs []*x509.Certificate) (*Response, error) {
+	var typ BodyType
+	switch m.Type {
+	case BodyIR:
+		typ = BodyIP
+	case BodyCR, BodyP10CR:
+		typ = BodyCP
+	case BodyKUR:
+		typ = BodyKUP
+	default:
+		return nil, fmt.Errorf("message type %s does not carry certificate requests", m.Type)
+	}
+
+	var content certRepMessage
+	if typ == BodyIP {
+		for _, cert := range caPubs {
+			content.CAPubs = append(content.CAPubs, asn1.RawValue{FullBytes: cert.Raw})
+		}
+	}
+	for _, result := range results {
+		resp := certResponse{CertReqID: result.ID}
+		if result.Certificate != nil {
+			resp.Status = pkiStatusInfo{Status: statusAccepted}
+			resp.CertifiedKeyPair.CertOrEncCert = asn1.RawValue{
+				Class:      asn1.ClassContextSpecific,
+				Tag:        0,
+				IsCompound: true,
+				Bytes:      result.Certificate.Raw,
+			}
+		} else {
+			resp.Status = rejection(result.FailInfo, result.Reason)
+		}
+		content.Response = append(content.Response, resp)
+	}
+
+	body, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{req: m, typ: typ, body: body}, nil
+}
+
+// PKIConf returns the response to a certConf message.
+func (m *Message) PKIConf() *Response {
+	return &Response{req: m, typ: BodyPKIConf, body: asn1.NullBytes}
+}
+
+// Error returns an error message rejecting the request.
+func (m *Message) Error(info FailInfo, reason string) (*Response, error) {
+	body, err := asn1.Marshal(errorMsgContent{
+		PKIStatusInfo: rejection(info, reason),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Response{req: m, typ: BodyError, body: body}, nil
+}
+
+func rejection(info FailInfo, reason string) pkiStatusInfo {
+	status := pkiStatusInfo{
+		Status:   statusRejection,
+		FailInfo: failInfoBits(info),
+	}
+	if reason != "" {
+		status.StatusString = freeText(reason)
+	}
+	return status
+}
+
+// failInfoBits encodes a PKIFailureInfo with a single bit set, without
+// trailing zero bits as required for named bit lists in DER.
+func failInfoBits(info FailInfo) asn1.BitString {
+	bytes := make([]byte, int(info)/8+1)
+	bytes[int(info)/8] = 0x80 >> (uint(info) % 8)
+	return asn1.BitString{Bytes: bytes, BitLength: int(info) + 1}
+}
+
+func freeText(text string) []asn1.RawValue {
+	return []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(text)}}
+}
+
+// marshal encodes the response with the given protection, computed over the
+// encoded header and body by protect.
+func (r *Response) marshal(sender *x509.Certificate, alg pkix.AlgorithmIdentifier, senderKID []byte, extraCerts []*x509.Certificate, protect func([]byte) ([]byte, error)) ([]byte, error) {
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	header := pkiHeader{
+		PVNO: 2,
+		Sender: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        4,
+			IsCompound: true,
+			Bytes:      sender.RawSubject,
+		},
+		Recipient:     r.req.sender,
+		MessageTime:   time.Now().UTC().Truncate(time.Second),
+		ProtectionAlg: alg,
+		SenderKID:     senderKID,
+		TransactionID: r.req.TransactionID,
+		SenderNonce:   nonce,
+		RecipNonce:    r.req.SenderNonce,
+	}
+	if r.req.ImplicitConfirm && (r.typ == BodyIP || r.typ == BodyCP || r.typ == BodyKUP) {
+		header.GeneralInfo = []infoTypeAndValue{{Type: oidImplicitConfirm, Value: asn1.NullRawValue}}
+	}
+	headerDER, err := asn1.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	bodyDER, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        int(r.typ),
+		IsCompound: true,
+		Bytes:      r.body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg := pkiMessage{
+		Header: asn1.RawValue{FullBytes: headerDER},
+		Body:   asn1.RawValue{FullBytes: bodyDER},
+	}
+	protected, err := asn1.Marshal(protectedPart{Header: msg.Header, Body: msg.Body})
+	if err != nil {
+		return nil, err
+	}
+	protection, err := protect(protected)
+	if err != nil {
+		return nil, err
+	}
+	msg.Protection = asn1.BitString{Bytes: protection, BitLength: len(protection) * 8}
+	for _, cert := range extraCerts {
+		msg.ExtraCerts = append(msg.ExtraCerts, asn1.RawValue{FullBytes: cert.Raw})
+	}
+
+	return asn1.Marshal(msg)
+}
@@ -0,0 +1,419 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cmp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newCert(t *testing.T, cn string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer, pub crypto.PublicKey) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		SubjectKeyId:          []byte(cn),
+	}
+	if parent == nil {
+		parent, parentKey, pub = template, key, key.Public()
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// implicit replaces the tag of an encoded SEQUENCE with a context-specific
+// tag.
+func implicit(t *testing.T, tag int, der []byte) asn1.RawValue {
+	t.Helper()
+
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &seq); err != nil {
+		t.Fatal(err)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: seq.Bytes}
+}
+
+// newCertReqMessages creates the CertReqMessages of a request for a
+// certificate of pub, with a signature proof-of-possession by popKey.
+func newCertReqMessages(t *testing.T, cn string, dnsNames []string, pub crypto.PublicKey, popKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	spki, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sans []asn1.RawValue
+	for _, name := range dnsNames {
+		sans = append(sans, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte(name)})
+	}
+	extensions := mustMarshal(t, []pkix.Extension{{Id: oidSubjectAltName, Value: mustMarshal(t, sans)}})
+
+	var template []byte
+	template = append(template, mustMarshal(t, asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        templateSubject,
+		IsCompound: true,
+		Bytes:      mustMarshal(t, pkix.Name{CommonName: cn}.ToRDNSequence()),
+	})...)
+	template = append(template, mustMarshal(t, implicit(t, templatePublicKey, spki))...)
+	template = append(template, mustMarshal(t, implicit(t, templateExtensions, extensions))...)
+
+	certReq := mustMarshal(t, struct {
+		ID       int
+		Template asn1.RawValue
+	}{7, asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: template}})
+
+	digest := sha256.Sum256(certReq)
+	signature, err := popKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	popo := implicit(t, popSignature, mustMarshal(t, struct {
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}{
+		pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+		asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	}))
+
+	msg := mustMarshal(t, struct {
+		CertReq asn1.RawValue
+		Popo    asn1.RawValue
+	}{asn1.RawValue{FullBytes: certReq}, popo})
+	return mustMarshal(t, []asn1.RawValue{{FullBytes: msg}})
+}
+
+var testPBMParams = pbmParameter{
+	Salt:           []byte("0123456789abcdef"),
+	OWF:            pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+	IterationCount: 500,
+	MAC:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256},
+}
+
+// newRequest creates a PKIMessage as a CMP client would.
+func newRequest(t *testing.T, typ BodyType, body []byte, alg pkix.AlgorithmIdentifier, extraCerts []*x509.Certificate, protect func([]byte) []byte) []byte {
+	t.Helper()
+
+	header := mustMarshal(t, pkiHeader{
+		PVNO: 2,
+		Sender: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        4,
+			IsCompound: true,
+			Bytes:      mustMarshal(t, pkix.Name{CommonName: "device"}.ToRDNSequence()),
+		},
+		Recipient: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        4,
+			IsCompound: true,
+			Bytes:      mustMarshal(t, pkix.RDNSequence{}),
+		},
+		ProtectionAlg: alg,
+		TransactionID: []byte("transaction-1"),
+		SenderNonce:   []byte("sender-nonce-123"),
+		GeneralInfo:   []infoTypeAndValue{{Type: oidImplicitConfirm, Value: asn1.NullRawValue}},
+	})
+	bodyDER := mustMarshal(t, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: int(typ), IsCompound: true, Bytes: body})
+
+	msg := pkiMessage{
+		Header: asn1.RawValue{FullBytes: header},
+		Body:   asn1.RawValue{FullBytes: bodyDER},
+	}
+	protection := protect(mustMarshal(t, protectedPart{Header: msg.Header, Body: msg.Body}))
+	msg.Protection = asn1.BitString{Bytes: protection, BitLength: len(protection) * 8}
+	for _, cert := range extraCerts {
+		msg.ExtraCerts = append(msg.ExtraCerts, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	return mustMarshal(t, msg)
+}
+
+func macRequest(t *testing.T, typ BodyType, body, secret []byte) []byte {
+	t.Helper()
+
+	alg := pkix.AlgorithmIdentifier{
+		Algorithm:  oidPasswordBasedMAC,
+		Parameters: asn1.RawValue{FullBytes: mustMarshal(t, testPBMParams)},
+	}
+	return newRequest(t, typ, body, alg, nil, func(data []byte) []byte {
+		mac, err := passwordBasedMAC(testPBMParams, secret, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mac
+	})
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// parseResponse parses a response as a client, returning its header and the
+// contents of its body.
+func parseResponse(t *testing.T, der []byte) (*Message, pkiHeader, asn1.RawValue) {
+	t.Helper()
+
+	resp, err := ParseMessage(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg pkiMessage
+	var header pkiHeader
+	if _, err := asn1.Unmarshal(der, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(msg.Header.FullBytes, &header); err != nil {
+		t.Fatal(err)
+	}
+	return resp, header, msg.Body
+}
+
+func TestInitializationRequest_MAC(t *testing.T) {
+	secret := []byte("shared secret")
+	caKey := newECKey(t)
+	caCert := newCert(t, "CMP CA", caKey, nil, nil, nil)
+	deviceKey := newECKey(t)
+
+	body := newCertReqMessages(t, "device.example.com", []string{"device.example.com"}, deviceKey.Public(), deviceKey)
+	msg, err := ParseMessage(macRequest(t, BodyIR, body, secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != BodyIR || !msg.IsMACProtected() || !msg.ImplicitConfirm {
+		t.Fatalf("unexpected message: %#v", msg)
+	}
+	if err := msg.VerifyMAC(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.VerifyMAC([]byte("wrong secret")); err == nil {
+		t.Fatal("expected MAC with the wrong secret to be rejected")
+	}
+
+	if len(msg.Requests) != 1 {
+		t.Fatalf("expected a single request, got %d", len(msg.Requests))
+	}
+	req := msg.Requests[0]
+	if err := req.VerifyPOP(); err != nil {
+		t.Fatal(err)
+	}
+	if req.ID.Int64() != 7 || req.CSR.Subject.CommonName != "device.example.com" ||
+		len(req.CSR.DNSNames) != 1 || req.CSR.DNSNames[0] != "device.example.com" {
+		t.Fatalf("unexpected request: %d %v %v", req.ID, req.CSR.Subject, req.CSR.DNSNames)
+	}
+
+	issued := newCert(t, req.CSR.Subject.CommonName, nil, caCert, caKey, req.CSR.PublicKey)
+	resp, err := msg.CertRep([]CertResult{{ID: req.ID, Certificate: issued}}, []*x509.Certificate{caCert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	respDER, err := resp.MAC(caCert, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, header, respBody := parseResponse(t, respDER)
+	if err := parsed.VerifyMAC(secret); err != nil {
+		t.Fatalf("expected response to be protected with the shared secret: %v", err)
+	}
+	if parsed.Type != BodyIP || !parsed.ImplicitConfirm {
+		t.Fatalf("expected ip with implicit confirmation, got %s", parsed.Type)
+	}
+	if !bytes.Equal(header.RecipNonce, []byte("sender-nonce-123")) || !bytes.Equal(header.TransactionID, []byte("transaction-1")) {
+		t.Fatal("expected response to echo the nonce and transaction ID")
+	}
+
+	var rep certRepMessage
+	if _, err := asn1.Unmarshal(respBody.Bytes, &rep); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.CAPubs) != 1 || !bytes.Equal(rep.CAPubs[0].FullBytes, caCert.Raw) {
+		t.Fatal("expected the CA certificate in caPubs")
+	}
+	if len(rep.Response) != 1 || rep.Response[0].Status.Status != statusAccepted ||
+		!bytes.Equal(rep.Response[0].CertifiedKeyPair.CertOrEncCert.Bytes, issued.Raw) {
+		t.Fatal("expected the issued certificate in the response")
+	}
+}
+
+func TestCertificationRequest_Signature(t *testing.T) {
+	caKey := newECKey(t)
+	caCert := newCert(t, "CMP CA", caKey, nil, nil, nil)
+
+	_, vendorKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vendorCert := newCert(t, "vendor device", vendorKey, nil, nil, nil)
+	deviceKey := newECKey(t)
+
+	body := newCertReqMessages(t, "device", nil, deviceKey.Public(), deviceKey)
+	der := newRequest(t, BodyCR, body, pkix.AlgorithmIdentifier{Algorithm: oidEd25519}, []*x509.Certificate{vendorCert}, func(data []byte) []byte {
+		return ed25519.Sign(vendorKey, data)
+	})
+
+	msg, err := ParseMessage(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.IsMACProtected() {
+		t.Fatal("expected signature protection")
+	}
+	signer, err := msg.VerifySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.Equal(vendorCert) {
+		t.Fatal("expected the vendor certificate as signer")
+	}
+
+	// Tampering with the message invalidates the protection
+	tampered := bytes.Replace(der, []byte("transaction-1"), []byte("transaction-2"), 1)
+	msg2, err := ParseMessage(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := msg2.VerifySignature(); err == nil {
+		t.Fatal("expected tampered message to be rejected")
+	}
+
+	resp, err := msg.CertRep([]CertResult{{ID: msg.Requests[0].ID, FailInfo: BadCertTemplate, Reason: "not allowed"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respDER, err := resp.Sign(caCert, nil, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _, respBody := parseResponse(t, respDER)
+	if parsed.Type != BodyCP {
+		t.Fatalf("expected cp, got %s", parsed.Type)
+	}
+	if signer, err := parsed.VerifySignature(); err != nil || !signer.Equal(caCert) {
+		t.Fatalf("expected response signed by the CA: %v", err)
+	}
+	var rep certRepMessage
+	if _, err := asn1.Unmarshal(respBody.Bytes, &rep); err != nil {
+		t.Fatal(err)
+	}
+	status := rep.Response[0].Status
+	if status.Status != statusRejection || status.FailInfo.At(int(BadCertTemplate)) != 1 || len(rep.Response[0].CertifiedKeyPair.CertOrEncCert.FullBytes) != 0 {
+		t.Fatalf("expected rejection with badCertTemplate, got %#v", status)
+	}
+}
+
+func TestProofOfPossession(t *testing.T) {
+	deviceKey := newECKey(t)
+
+	// Signed with another key than the requested one
+	body := newCertReqMessages(t, "device", nil, deviceKey.Public(), newECKey(t))
+	msg, err := ParseMessage(macRequest(t, BodyIR, body, []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.Requests[0].VerifyPOP(); err == nil {
+		t.Fatal("expected proof-of-possession by another key to be rejected")
+	}
+
+	// raVerified is not accepted
+	var msgs []asn1.RawValue
+	if _, err := asn1.Unmarshal(newCertReqMessages(t, "device", nil, deviceKey.Public(), deviceKey), &msgs); err != nil {
+		t.Fatal(err)
+	}
+	var certReq asn1.RawValue
+	if _, err := asn1.Unmarshal(msgs[0].Bytes, &certReq); err != nil {
+		t.Fatal(err)
+	}
+	raVerified := mustMarshal(t, []asn1.RawValue{{FullBytes: mustMarshal(t, struct {
+		CertReq asn1.RawValue
+		Popo    asn1.RawValue
+	}{certReq, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: popRAVerified, Bytes: []byte{}}})}})
+	msg, err = ParseMessage(macRequest(t, BodyIR, raVerified, []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.Requests[0].VerifyPOP(); err == nil {
+		t.Fatal("expected raVerified proof-of-possession to be rejected")
+	}
+}
+
+func TestCertConfAndError(t *testing.T) {
+	certConf := mustMarshal(t, []certStatus{
+		{CertHash: []byte("hash"), CertReqID: big.NewInt(7)},
+		{CertHash: []byte("hash"), CertReqID: big.NewInt(8), StatusInfo: pkiStatusInfo{Status: statusRejection}},
+	})
+	msg, err := ParseMessage(macRequest(t, BodyCertConf, certConf, []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Confirmations) != 2 || !msg.Confirmations[0].Accepted || msg.Confirmations[1].Accepted {
+		t.Fatalf("unexpected confirmations: %#v", msg.Confirmations)
+	}
+
+	caKey := newECKey(t)
+	caCert := newCert(t, "CMP CA", caKey, nil, nil, nil)
+	respDER, err := msg.PKIConf().Sign(caCert, nil, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, _, _ := parseResponse(t, respDER); parsed.Type != BodyPKIConf {
+		t.Fatalf("expected pkiconf, got %s", parsed.Type)
+	}
+
+	resp, err := msg.Error(BadPOP, "invalid proof-of-possession")
+	if err != nil {
+		t.Fatal(err)
+	}
+	respDER, err = resp.MAC(caCert, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, body := parseResponse(t, respDER)
+	var content errorMsgContent
+	if _, err := asn1.Unmarshal(body.Bytes, &content); err != nil {
+		t.Fatal(err)
+	}
+	info := content.PKIStatusInfo.FailInfo
+	if info.BitLength != int(BadPOP)+1 || info.At(int(BadPOP)) != 1 || !bytes.Equal(info.Bytes, []byte{0x00, 0x40}) {
+		t.Fatalf("unexpected failInfo encoding: %#v", info)
+	}
+}
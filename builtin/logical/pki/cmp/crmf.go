@@ -0,0 +1,248 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cmp
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+)
+
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// Tags of the CertTemplate fields (RFC 4211 section 5).
+const (
+	templateSubject    = 5
+	templatePublicKey  = 6
+	templateExtensions = 9
+)
+
+// Tags of the ProofOfPossession choices (RFC 4211 section 4).
+const (
+	popRAVerified      = 0
+	popSignature       = 1
+	popKeyEncipherment = 2
+	popKeyAgreement    = 3
+)
+
+// CertRequest is a request for a certificate.
+type CertRequest struct {
+	ID *big.Int
+
+	// CSR holds the subject, public key and extensions of the request. For
+	// CRMF requests, its signature is the proof-of-possession signature over
+	// the CertRequest, so that CSR.CheckSignature verifies the requester
+	// holds the private key.
+	CSR *x509.CertificateRequest
+
+	popErr error
+}
+
+// VerifyPOP verifies the proof-of-possession of the private key of the
+// request.
+func (r *CertRequest) VerifyPOP() error {
+	if r.popErr != nil {
+		return r.popErr
+	}
+	if err := r.CSR.CheckSignature(); err != nil {
+		return fmt.Errorf("invalid proof-of-possession: %w", err)
+	}
+	return nil
+}
+
+type certRequest struct {
+	CertReqID    *big.Int
+	CertTemplate asn1.RawValue
+	Controls     asn1.RawValue `asn1:"optional"`
+}
+
+type popoSigningKey struct {
+	Input     asn1.RawValue `asn1:"optional,tag:0"`
+	Algorithm pkix.AlgorithmIdentifier
+	Signature asn1.BitString
+}
+
+func parseCertReqMessages(der []byte) ([]*CertRequest, error) {
+	var msgs []asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &msgs); err != nil {
+		return nil, fmt.Errorf("error parsing CertReqMessages: %w", err)
+	}
+	if len(msgs) == 0 {
+		return nil, errors.New("no certificate requests in message")
+	}
+
+	var requests []*CertRequest
+	for _, msg := range msgs {
+		req, err := parseCertReqMsg(msg.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// parseCertReqMsg parses the contents of a CertReqMsg; its optional popo and
+// regInfo fields cannot be told apart by encoding/asn1.
+func parseCertReqMsg(der []byte) (*CertRequest, error) {
+	var rawReq asn1.RawValue
+	rest, err := asn1.Unmarshal(der, &rawReq)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CertReqMsg: %w", err)
+	}
+
+	var cr certRequest
+	if _, err := asn1.Unmarshal(rawReq.FullBytes, &cr); err != nil {
+		return nil, fmt.Errorf("error parsing CertRequest: %w", err)
+	}
+
+	csr, err := parseCertTemplate(cr.CertTemplate.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	req := &CertRequest{ID: cr.CertReqID, CSR: csr}
+
+	var popo asn1.RawValue
+	if len(rest) > 0 {
+		if _, err := asn1.Unmarshal(rest, &popo); err != nil {
+			return nil, fmt.Errorf("error parsing proof-of-possession: %w", err)
+		}
+	}
+	if popo.Class != asn1.ClassContextSpecific {
+		req.popErr = errors.New("request has no proof-of-possession")
+		return req, nil
+	}
+
+	switch popo.Tag {
+	case popSignature:
+		var signing popoSigningKey
+		if _, err := asn1.Unmarshal(retag(popo.Bytes), &signing); err != nil {
+			return nil, fmt.Errorf("error parsing POPOSigningKey: %w", err)
+		}
+		if len(signing.Input.FullBytes) != 0 {
+			// The input is only used when the template has no subject or
+			// public key, which are required for issuance anyway.
+			req.popErr = errors.New("proof-of-possession over POPOSigningKeyInput is not supported")
+			return req, nil
+		}
+		csr.SignatureAlgorithm = signatureAlgorithm(signing.Algorithm.Algorithm)
+		if csr.SignatureAlgorithm == x509.UnknownSignatureAlgorithm {
+			req.popErr = fmt.Errorf("unsupported proof-of-possession algorithm %s", signing.Algorithm.Algorithm)
+			return req, nil
+		}
+		csr.RawTBSCertificateRequest = rawReq.FullBytes
+		csr.Signature = signing.Signature.RightAlign()
+	case popRAVerified:
+		req.popErr = errors.New("raVerified proof-of-possession is not accepted")
+	case popKeyEncipherment, popKeyAgreement:
+		req.popErr = errors.New("only signature proof-of-possession is supported")
+	default:
+		req.popErr = fmt.Errorf("unknown proof-of-possession type %d", popo.Tag)
+	}
+	return req, nil
+}
+
+// parseCertTemplate returns the subject, public key and extensions of a
+// CertTemplate as a certificate request without signature.
+func parseCertTemplate(der []byte) (*x509.CertificateRequest, error) {
+	csr := &x509.CertificateRequest{}
+
+	for rest := der; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &field)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CertTemplate: %w", err)
+		}
+		if field.Class != asn1.ClassContextSpecific {
+			return nil, errors.New("malformed CertTemplate")
+		}
+
+		switch field.Tag {
+		case templateSubject:
+			// Name is a CHOICE, so its tag is explicit.
+			var subject pkix.RDNSequence
+			if _, err := asn1.Unmarshal(field.Bytes, &subject); err != nil {
+				return nil, fmt.Errorf("error parsing template subject: %w", err)
+			}
+			csr.Subject.FillFromRDNSequence(&subject)
+			csr.RawSubject = field.Bytes
+		case templatePublicKey:
+			csr.PublicKey, err = x509.ParsePKIXPublicKey(retag(field.Bytes))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing template public key: %w", err)
+			}
+		case templateExtensions:
+			if _, err := asn1.Unmarshal(retag(field.Bytes), &csr.Extensions); err != nil {
+				return nil, fmt.Errorf("error parsing template extensions: %w", err)
+			}
+		}
+	}
+
+	if csr.PublicKey == nil {
+		return nil, errors.New("certificate template has no public key")
+	}
+	switch csr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		csr.PublicKeyAlgorithm = x509.RSA
+	case *ecdsa.PublicKey:
+		csr.PublicKeyAlgorithm = x509.ECDSA
+	case ed25519.PublicKey:
+		csr.PublicKeyAlgorithm = x509.Ed25519
+	}
+
+	for _, ext := range csr.Extensions {
+		if ext.Id.Equal(oidSubjectAltName) {
+			if err := parseSubjectAltNames(csr, ext.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return csr, nil
+}
+
+func parseSubjectAltNames(csr *x509.CertificateRequest, der []byte) error {
+	var names []asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &names); err != nil {
+		return fmt.Errorf("error parsing subject alternative names: %w", err)
+	}
+
+	for _, name := range names {
+		if name.Class != asn1.ClassContextSpecific {
+			continue
+		}
+		switch name.Tag {
+		case 1:
+			csr.EmailAddresses = append(csr.EmailAddresses, string(name.Bytes))
+		case 2:
+			csr.DNSNames = append(csr.DNSNames, string(name.Bytes))
+		case 6:
+			uri, err := url.Parse(string(name.Bytes))
+			if err != nil {
+				return fmt.Errorf("error parsing URI subject alternative name: %w", err)
+			}
+			csr.URIs = append(csr.URIs, uri)
+		case 7:
+			if len(name.Bytes) != net.IPv4len && len(name.Bytes) != net.IPv6len {
+				return errors.New("invalid IP address subject alternative name")
+			}
+			csr.IPAddresses = append(csr.IPAddresses, net.IP(name.Bytes))
+		}
+	}
+	return nil
+}
+
+// retag returns the contents of an implicitly tagged SEQUENCE as a SEQUENCE.
+func retag(contents []byte) []byte {
+	der, _ := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: contents})
+	return der
+}
@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cmp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// maxIterationCount bounds the work an unauthenticated request can cause
+// when deriving the key of a password-based MAC.
+const maxIterationCount = 100000
+
+var (
+	oidPasswordBasedMAC = asn1.ObjectIdentifier{1, 2, 840, 113533, 7, 66, 13}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 8, 1, 2}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidSHA1WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+type pbmParameter struct {
+	Salt           []byte
+	OWF            pkix.AlgorithmIdentifier
+	IterationCount int
+	MAC            pkix.AlgorithmIdentifier
+}
+
+func signatureAlgorithm(oid asn1.ObjectIdentifier) x509.SignatureAlgorithm {
+	switch {
+	case oid.Equal(oidSHA1WithRSA):
+		return x509.SHA1WithRSA
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512
+	case oid.Equal(oidEd25519):
+		return x509.PureEd25519
+	default:
+		return x509.UnknownSignatureAlgorithm
+	}
+}
+
+// IsMACProtected returns whether the message is protected with a MAC based
+// on a shared secret, rather than with a signature.
+func (m *Message) IsMACProtected() bool {
+	return m.protectionAlg.Algorithm.Equal(oidPasswordBasedMAC)
+}
+
+// VerifySignature verifies the signature protection of the message, and
+// returns the certificate of the signer. Establishing trust in the signer is
+// up to the caller.
+func (m *Message) VerifySignature() (*x509.Certificate, error) {
+	if len(m.protection) == 0 {
+		return nil, errors.New("message is not protected")
+	}
+	alg := signatureAlgorithm(m.protectionAlg.Algorithm)
+	if alg == x509.UnknownSignatureAlgorithm {
+		return nil, fmt.Errorf("unsupported protection algorithm %s", m.protectionAlg.Algorithm)
+	}
+	if len(m.ExtraCerts) == 0 {
+		return nil, errors.New("signature protected message has no signer certificate")
+	}
+
+	signer := m.ExtraCerts[0]
+	if err := signer.CheckSignature(alg, m.protected, m.protection); err != nil {
+		return nil, fmt.Errorf("invalid message protection: %w", err)
+	}
+	return signer, nil
+}
+
+// VerifyMAC verifies the password-based MAC protection of the message.
+func (m *Message) VerifyMAC(secret []byte) error {
+	if len(m.protection) == 0 {
+		return errors.New("message is not protected")
+	}
+	if !m.IsMACProtected() {
+		return fmt.Errorf("unsupported protection algorithm %s", m.protectionAlg.Algorithm)
+	}
+
+	var params pbmParameter
+	if _, err := asn1.Unmarshal(m.protectionAlg.Parameters.FullBytes, &params); err != nil {
+		return fmt.Errorf("error parsing PBMParameter: %w", err)
+	}
+	mac, err := passwordBasedMAC(params, secret, m.protected)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, m.protection) {
+		return errors.New("invalid message protection")
+	}
+	return nil
+}
+
+// passwordBasedMAC computes the MAC of RFC 4210 section 5.1.3.1: the key is
+// derived by applying the one-way function iterationCount times to the
+// secret and the salt.
+func passwordBasedMAC(params pbmParameter, secret, data []byte) ([]byte, error) {
+	owf := hashForOID(params.OWF.Algorithm)
+	if owf == nil {
+		return nil, fmt.Errorf("unsupported one-way function %s", params.OWF.Algorithm)
+	}
+	var macHash func() hash.Hash
+	switch {
+	case params.MAC.Algorithm.Equal(oidHMACWithSHA1):
+		macHash = sha1.New
+	case params.MAC.Algorithm.Equal(oidHMACWithSHA256):
+		macHash = sha256.New
+	case params.MAC.Algorithm.Equal(oidHMACWithSHA384):
+		macHash = sha512.New384
+	case params.MAC.Algorithm.Equal(oidHMACWithSHA512):
+		macHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported MAC algorithm %s", params.MAC.Algorithm)
+	}
+	if params.IterationCount < 1 || params.IterationCount > maxIterationCount {
+		return nil, fmt.Errorf("iteration count must be between 1 and %d", maxIterationCount)
+	}
+
+	key := append(append([]byte{}, secret...), params.Salt...)
+	for i := 0; i < params.IterationCount; i++ {
+		h := owf()
+		h.Write(key)
+		key = h.Sum(nil)
+	}
+
+	mac := hmac.New(macHash, key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) func() hash.Hash {
+	switch {
+	case oid.Equal(oidSHA1):
+		return sha1.New
+	case oid.Equal(oidSHA256):
+		return sha256.New
+	case oid.Equal(oidSHA384):
+		return sha512.New384
+	case oid.Equal(oidSHA512):
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// Sign encodes the response protected with a signature of the CA, which is
+// sent along with its chain.
+func (r *Response) Sign(cert *x509.Certificate, chain []*x509.Certificate, key crypto.Signer) ([]byte, error) {
+	var alg pkix.AlgorithmIdentifier
+	var hashFunc crypto.Hash
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		alg = pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue}
+		hashFunc = crypto.SHA256
+	case *ecdsa.PublicKey:
+		alg = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+		hashFunc = crypto.SHA256
+	case ed25519.PublicKey:
+		alg = pkix.AlgorithmIdentifier{Algorithm: oidEd25519}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Public())
+	}
+
+	extraCerts := append([]*x509.Certificate{cert}, chain...)
+	return r.marshal(cert, alg, cert.SubjectKeyId, extraCerts, func(data []byte) ([]byte, error) {
+		digest := data
+		if hashFunc != 0 {
+			h := hashFunc.New()
+			h.Write(data)
+			digest = h.Sum(nil)
+		}
+		return key.Sign(rand.Reader, digest, hashFunc)
+	})
+}
+
+// MAC encodes the response protected with a password-based MAC, using the
+// parameters of the request with a fresh salt.
+func (r *Response) MAC(sender *x509.Certificate, secret []byte) ([]byte, error) {
+	if !r.req.IsMACProtected() {
+		return nil, errors.New("request is not protected with a password-based MAC")
+	}
+
+	var params pbmParameter
+	if _, err := asn1.Unmarshal(r.req.protectionAlg.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("error parsing PBMParameter: %w", err)
+	}
+	params.Salt = make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, params.Salt); err != nil {
+		return nil, err
+	}
+	paramsDER, err := asn1.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	alg := pkix.AlgorithmIdentifier{
+		Algorithm:  oidPasswordBasedMAC,
+		Parameters: asn1.RawValue{FullBytes: paramsDER},
+	}
+	return r.marshal(sender, alg, nil, nil, func(data []byte) ([]byte, error) {
+		return passwordBasedMAC(params, secret, data)
+	})
+}
@@ -1074,6 +1074,15 @@ func revokeCert(sc *storageContext, config *crlConfig, cert *x509.Certificate) (
 		},
 	}
 
+	// Surface the owner of the certificate in the audit log of the
+	// revocation.
+	metadataEntry, err := sc.fetchCertMetadata(hyphenSerial)
+	if err != nil {
+		resp.AddWarning(fmt.Sprintf("failed to fetch metadata of certificate: %v", err))
+	} else if metadataEntry != nil && len(metadataEntry.Metadata) > 0 {
+		resp.Data["cert_metadata"] = metadataEntry.Metadata
+	}
+
 	// If this flag is enabled after the fact, existing local entries will be published to
 	// the unified storage space through a periodic function.
 	failedWritingUnifiedCRL := false
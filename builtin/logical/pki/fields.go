@@ -167,6 +167,17 @@ Any values are added with OID 0.9.2342.19200300.100.1.1.`,
 		},
 	}
 
+	fields["cert_metadata"] = &framework.FieldSchema{
+		Type: framework.TypeKVPairs,
+		Description: `Arbitrary key/value metadata to attach to the
+certificate, such as its owner, service or change ticket.
+The metadata is stored alongside the certificate, unless the
+role sets no_store, and can be used to filter the certs/ listing.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Certificate Metadata",
+		},
+	}
+
 	fields = addIssuerRefField(fields)
 
 	return fields
@@ -509,6 +520,14 @@ deactivated ACME account is deleted.`,
 		Default: false,
 	}
 
+	fields["tidy_deleted_entity_certs"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Set to true to revoke the unexpired certificates
+issued to identity entities which have since been deleted, so that
+offboarding an entity revokes its certificates on the next tidy.`,
+		Default: false,
+	}
+
 	fields["safety_buffer"] = &framework.FieldSchema{
 		Type: framework.TypeDurationSecond,
 		Description: `The amount of extra time that must have passed
@@ -0,0 +1,467 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/builtin/logical/pki/scep"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	trustBundlePrefix = "bundles/"
+
+	trustBundleFormatPEM   = "pem"
+	trustBundleFormatPKCS7 = "p7b"
+	trustBundleFormatJKS   = "jks"
+
+	defaultTrustBundleMaxAge = time.Hour
+
+	// defaultTrustBundleJKSPassword is the password protecting the integrity
+	// of JKS truststores, which is that of the truststore shipped with Java.
+	defaultTrustBundleJKSPassword = "changeit"
+)
+
+// trustBundle is a curated set of trust anchors distributed to clients in
+// a single document. The issuers of the mount are referenced by ID, so that
+// their renaming does not change the bundle; certificates of other mounts and
+// namespaces are stored as provided.
+type trustBundle struct {
+	Issuers                []issuing.IssuerID `json:"issuers"`
+	AdditionalCertificates []string           `json:"additional_certificates"`
+	MaxAge                 time.Duration      `json:"max_age"`
+	Version                int                `json:"version"`
+	LastModified           time.Time          `json:"last_modified"`
+}
+
+func pathListTrustBundles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "bundles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "trust-bundles",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{
+				Callback: b.pathListTrustBundles,
+			},
+		},
+
+		HelpSynopsis:    pathListTrustBundlesHelpSyn,
+		HelpDescription: pathListTrustBundlesHelpDesc,
+	}
+}
+
+func pathTrustBundle(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "bundles/" + framework.GenericNameRegex("name") + "$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "trust-bundle",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the trust bundle",
+			},
+			"issuers": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `References (names or IDs) of the issuers of this mount
+included in the bundle.`,
+			},
+			"additional_certificates": {
+				Type: framework.TypeString,
+				Description: `PEM-encoded CA certificates included in the bundle, such as
+the issuers of other mounts or namespaces.`,
+			},
+			"max_age": {
+				Type: framework.TypeDurationSecond,
+				Description: `How long clients may cache the bundle before fetching it
+again. Defaults to 1 hour.`,
+				Default: int(defaultTrustBundleMaxAge.Seconds()),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathTrustBundleRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.pathTrustBundleWrite,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback:                    b.pathTrustBundleDelete,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathTrustBundleHelpSyn,
+		HelpDescription: pathTrustBundleHelpDesc,
+	}
+}
+
+func pathFetchTrustBundle(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "bundles/" + framework.GenericNameRegex("name") + "/(pem|p7b|jks)$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "fetch",
+			OperationSuffix: "trust-bundle",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the trust bundle",
+			},
+			"password": {
+				Type: framework.TypeString,
+				Description: `Password protecting the integrity of the JKS truststore.
+Defaults to "changeit".`,
+				Default: defaultTrustBundleJKSPassword,
+				Query:   true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathFetchTrustBundle,
+			},
+		},
+
+		HelpSynopsis:    pathFetchTrustBundleHelpSyn,
+		HelpDescription: pathFetchTrustBundleHelpDesc,
+	}
+}
+
+func getTrustBundle(ctx context.Context, s logical.Storage, name string) (*trustBundle, error) {
+	entry, err := s.Get(ctx, trustBundlePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var bundle trustBundle
+	if err := entry.DecodeJSON(&bundle); err != nil {
+		return nil, fmt.Errorf("unable to decode trust bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+func (b *backend) pathListTrustBundles(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, trustBundlePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+func (b *backend) pathTrustBundleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	bundle, err := getTrustBundle(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, nil
+	}
+
+	issuers := make([]string, 0, len(bundle.Issuers))
+	for _, id := range bundle.Issuers {
+		issuers = append(issuers, id.String())
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuers":                 issuers,
+			"additional_certificates": strings.Join(bundle.AdditionalCertificates, ""),
+			"max_age":                 int64(bundle.MaxAge.Seconds()),
+			"version":                 bundle.Version,
+			"last_modified":           bundle.LastModified.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+func (b *backend) pathTrustBundleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	bundle, err := getTrustBundle(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		bundle = &trustBundle{MaxAge: defaultTrustBundleMaxAge}
+	}
+	updated := *bundle
+
+	if raw, ok := data.GetOk("issuers"); ok {
+		sc := b.makeStorageContext(ctx, req.Storage)
+		updated.Issuers = nil
+		for _, ref := range raw.([]string) {
+			id, err := sc.resolveIssuerReference(ref)
+			if err != nil {
+				return logical.ErrorResponse("unable to resolve issuer %q: %v", ref, err), nil
+			}
+			updated.Issuers = append(updated.Issuers, id)
+		}
+	}
+	if raw, ok := data.GetOk("additional_certificates"); ok {
+		updated.AdditionalCertificates = nil
+		if pemCerts := strings.TrimSpace(raw.(string)); pemCerts != "" {
+			certs, err := certutil.ParseCertsPEM([]byte(pemCerts))
+			if err != nil {
+				return logical.ErrorResponse("unable to parse additional_certificates: %v", err), nil
+			}
+			for _, cert := range certs {
+				if !cert.IsCA {
+					return logical.ErrorResponse("additional certificate %q is not a CA certificate", cert.Subject.String()), nil
+				}
+				updated.AdditionalCertificates = append(updated.AdditionalCertificates,
+					string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+			}
+		}
+	}
+	if raw, ok := data.GetOk("max_age"); ok {
+		updated.MaxAge = time.Duration(raw.(int)) * time.Second
+	}
+	if updated.MaxAge < 0 {
+		return logical.ErrorResponse("max_age must not be negative"), nil
+	}
+	if len(updated.Issuers) == 0 && len(updated.AdditionalCertificates) == 0 {
+		return logical.ErrorResponse("a trust bundle needs at least one issuer or additional certificate"), nil
+	}
+
+	// Clients compare versions to know whether their copy of the bundle is
+	// current, so only changes of its content bump it.
+	if updated.Version == 0 || !sameTrustAnchors(bundle, &updated) {
+		updated.Version++
+		updated.LastModified = time.Now().UTC()
+	}
+
+	entry, err := logical.StorageEntryJSON(trustBundlePrefix+name, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"version": updated.Version,
+		},
+	}, nil
+}
+
+func (b *backend) pathTrustBundleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, trustBundlePrefix+data.Get("name").(string))
+}
+
+func (b *backend) pathFetchTrustBundle(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	bundle, err := getTrustBundle(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return logical.RespondWithStatusCode(logical.ErrorResponse("trust bundle not found"), req, http.StatusNotFound)
+	}
+
+	certs, err := b.trustBundleCertificates(ctx, req.Storage, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	var contentType string
+	switch format := req.Path[strings.LastIndex(req.Path, "/")+1:]; format {
+	case trustBundleFormatPEM:
+		contentType = "application/pem-certificate-chain"
+		for _, cert := range certs {
+			body = append(body, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+	case trustBundleFormatPKCS7:
+		contentType = "application/pkcs7-mime"
+		body, err = scep.CertificatesOnly(certs)
+		if err != nil {
+			return nil, err
+		}
+	case trustBundleFormatJKS:
+		contentType = "application/x-java-keystore"
+		body = encodeJKSTrustStore(certs, bundle.LastModified, data.Get("password").(string))
+	default:
+		return logical.ErrorResponse("unknown format %q", format), nil
+	}
+
+	etag := sha256.Sum256(body)
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType:        contentType,
+			logical.HTTPRawBody:            body,
+			logical.HTTPStatusCode:         http.StatusOK,
+			logical.HTTPCacheControlHeader: fmt.Sprintf("max-age=%d", int64(bundle.MaxAge.Seconds())),
+			logical.HTTPETagHeader:         `"` + hex.EncodeToString(etag[:]) + `"`,
+		},
+	}, nil
+}
+
+// trustBundleCertificates returns the certificates of the bundle, without
+// duplicates. Issuers deleted since the bundle was written are left out.
+func (b *backend) trustBundleCertificates(ctx context.Context, s logical.Storage, bundle *trustBundle) ([]*x509.Certificate, error) {
+	sc := b.makeStorageContext(ctx, s)
+	ids, err := sc.listIssuers()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[issuing.IssuerID]bool, len(ids))
+	for _, id := range ids {
+		existing[id] = true
+	}
+
+	var certs []*x509.Certificate
+	seen := make(map[string]bool)
+	add := func(cert *x509.Certificate) {
+		if !seen[string(cert.Raw)] {
+			seen[string(cert.Raw)] = true
+			certs = append(certs, cert)
+		}
+	}
+
+	for _, id := range bundle.Issuers {
+		if !existing[id] {
+			continue
+		}
+		issuer, err := sc.fetchIssuerById(id)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := issuer.GetCertificate()
+		if err != nil {
+			return nil, err
+		}
+		add(cert)
+	}
+	for _, pemCert := range bundle.AdditionalCertificates {
+		extra, err := certutil.ParseCertsPEM([]byte(pemCert))
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range extra {
+			add(cert)
+		}
+	}
+	return certs, nil
+}
+
+// sameTrustAnchors returns whether both bundles distribute the same
+// certificates.
+func sameTrustAnchors(a, b *trustBundle) bool {
+	if len(a.Issuers) != len(b.Issuers) || len(a.AdditionalCertificates) != len(b.AdditionalCertificates) {
+		return false
+	}
+	for i := range a.Issuers {
+		if a.Issuers[i] != b.Issuers[i] {
+			return false
+		}
+	}
+	for i := range a.AdditionalCertificates {
+		if a.AdditionalCertificates[i] != b.AdditionalCertificates[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeJKSTrustStore returns a Java KeyStore holding the certificates as
+// trusted certificate entries, which is all a truststore needs. The keystore
+// is protected by the SHA-1 integrity digest of the format, keyed with the
+// password.
+func encodeJKSTrustStore(certs []*x509.Certificate, created time.Time, password string) []byte {
+	var buf bytes.Buffer
+	writeUTF := func(s string) {
+		binary.Write(&buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+
+	binary.Write(&buf, binary.BigEndian, uint32(0xFEEDFEED))
+	binary.Write(&buf, binary.BigEndian, uint32(2))
+	binary.Write(&buf, binary.BigEndian, uint32(len(certs)))
+	for i, cert := range certs {
+		// Trusted certificate entry.
+		binary.Write(&buf, binary.BigEndian, uint32(2))
+		writeUTF(fmt.Sprintf("trust-anchor-%d", i))
+		binary.Write(&buf, binary.BigEndian, created.UnixMilli())
+		writeUTF("X.509")
+		binary.Write(&buf, binary.BigEndian, uint32(len(cert.Raw)))
+		buf.Write(cert.Raw)
+	}
+
+	h := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(buf.Bytes())
+	buf.Write(h.Sum(nil))
+	return buf.Bytes()
+}
+
+const pathListTrustBundlesHelpSyn = `
+List the trust bundles of this mount.
+`
+
+const pathListTrustBundlesHelpDesc = `
+Trust bundles are curated sets of CA certificates served to clients at
+bundles/:name/pem, bundles/:name/p7b and bundles/:name/jks.
+`
+
+const pathTrustBundleHelpSyn = `
+Configure a trust bundle distributed by this mount.
+`
+
+const pathTrustBundleHelpDesc = `
+A trust bundle combines selected issuers of this mount with CA certificates
+provided in additional_certificates, such as those of the issuers of other
+mounts and namespaces, which the mount cannot read itself. The version of the
+bundle is incremented whenever its certificates change.
+
+The bundle is served without authentication at bundles/:name/pem,
+bundles/:name/p7b and bundles/:name/jks, with an ETag and a Cache-Control
+max-age so that clients can cache it.
+`
+
+const pathFetchTrustBundleHelpSyn = `
+Fetch a trust bundle, in PEM, PKCS#7 or JKS format.
+`
+
+const pathFetchTrustBundleHelpDesc = `
+This endpoint does not require authentication. The PEM format concatenates
+the certificates, the p7b format is a certs-only PKCS#7 SignedData, and the
+jks format is a Java truststore whose integrity is protected by the password
+query parameter, "changeit" by default. Issuers deleted from the mount are
+left out of the bundle.
+`
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustBundles(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+	other, otherStorage := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"issuer_name": "root",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootPEM := resp.Data["certificate"].(string)
+
+	resp, err = CBWrite(other, otherStorage, "root/generate/internal", map[string]interface{}{
+		"common_name": "other.example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	otherPEM := resp.Data["certificate"].(string)
+
+	_, err = CBWrite(b, s, "bundles/fleet", map[string]interface{}{})
+	require.Error(t, err, "expected empty bundle to be rejected")
+
+	resp, err = CBWrite(b, s, "bundles/fleet", map[string]interface{}{
+		"issuers":                 "root",
+		"additional_certificates": otherPEM,
+		"max_age":                 "10m",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 1, resp.Data["version"])
+
+	resp, err = CBRead(b, s, "bundles/fleet/pem")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "application/pem-certificate-chain", resp.Data[logical.HTTPContentType])
+	require.Equal(t, "max-age=600", resp.Data[logical.HTTPCacheControlHeader])
+	require.NotEmpty(t, resp.Data[logical.HTTPETagHeader])
+	certs, err := certutil.ParseCertsPEM(resp.Data[logical.HTTPRawBody].([]byte))
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	require.Equal(t, parseCert(t, rootPEM).Raw, certs[0].Raw)
+	require.Equal(t, parseCert(t, otherPEM).Raw, certs[1].Raw)
+	etag := resp.Data[logical.HTTPETagHeader]
+
+	resp, err = CBRead(b, s, "bundles/fleet/p7b")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "application/pkcs7-mime", resp.Data[logical.HTTPContentType])
+
+	resp, err = CBRead(b, s, "bundles/fleet/jks")
+	requireSuccessNonNilResponse(t, resp, err)
+	jks := resp.Data[logical.HTTPRawBody].([]byte)
+	require.Equal(t, uint32(0xFEEDFEED), binary.BigEndian.Uint32(jks))
+	require.Equal(t, uint32(2), binary.BigEndian.Uint32(jks[8:]), "expected two trusted certificate entries")
+	require.True(t, bytes.Contains(jks, certs[1].Raw))
+
+	// Changing the cache lifetime alone keeps the version of the bundle.
+	resp, err = CBWrite(b, s, "bundles/fleet", map[string]interface{}{
+		"max_age": "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 1, resp.Data["version"])
+
+	resp, err = CBRead(b, s, "bundles/fleet/pem")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, etag, resp.Data[logical.HTTPETagHeader])
+
+	resp, err = CBWrite(b, s, "bundles/fleet", map[string]interface{}{
+		"additional_certificates": "",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 2, resp.Data["version"])
+
+	resp, err = CBRead(b, s, "bundles/fleet/pem")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.NotEqual(t, etag, resp.Data[logical.HTTPETagHeader])
+	certs, err = certutil.ParseCertsPEM(resp.Data[logical.HTTPRawBody].([]byte))
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+
+	resp, err = CBList(b, s, "bundles")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{"fleet"}, resp.Data["keys"])
+
+	_, err = CBDelete(b, s, "bundles/fleet")
+	require.NoError(t, err)
+	resp, err = CBRead(b, s, "bundles/fleet/pem")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.Data[logical.HTTPStatusCode])
+}
@@ -0,0 +1,203 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	certMetadataPath = "cert-metadata/"
+
+	maxCertMetadataKeys        = 32
+	maxCertMetadataKeyLength   = 128
+	maxCertMetadataValueLength = 1024
+)
+
+// certMetadataEntry is stored for every certificate issued by a role, to an
+// entity, or with metadata, so that certificates can be found by how they
+// were issued.
+type certMetadataEntry struct {
+	Metadata map[string]string `json:"metadata"`
+	Role     string            `json:"role,omitempty"`
+	EntityID string            `json:"entity_id,omitempty"`
+}
+
+// matches returns whether the certificate was issued by the role and to the
+// entity, if not empty, with metadata containing every key/value pair of the
+// filter.
+func (e *certMetadataEntry) matches(role, entityID string, filter map[string]string) bool {
+	if role != "" && e.Role != role {
+		return false
+	}
+	if entityID != "" && e.EntityID != entityID {
+		return false
+	}
+	return certMetadataMatches(e.Metadata, filter)
+}
+
+// validateCertMetadata bounds the metadata a caller can attach to a
+// certificate, as it is stored for every issued certificate.
+func validateCertMetadata(metadata map[string]string) error {
+	if len(metadata) > maxCertMetadataKeys {
+		return fmt.Errorf("cert_metadata may contain at most %d keys", maxCertMetadataKeys)
+	}
+	for key, value := range metadata {
+		if key == "" {
+			return fmt.Errorf("cert_metadata keys cannot be empty")
+		}
+		if len(key) > maxCertMetadataKeyLength {
+			return fmt.Errorf("cert_metadata key %q exceeds %d characters", key, maxCertMetadataKeyLength)
+		}
+		if len(value) > maxCertMetadataValueLength {
+			return fmt.Errorf("cert_metadata value of key %q exceeds %d characters", key, maxCertMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+func (sc *storageContext) storeCertMetadata(serial *big.Int, role, entityID string, metadata map[string]string) error {
+	entry, err := logical.StorageEntryJSON(certMetadataPath+normalizeSerialFromBigInt(serial), &certMetadataEntry{
+		Metadata: metadata,
+		Role:     role,
+		EntityID: entityID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, entry); err != nil {
+		return fmt.Errorf("failed writing certificate metadata: %w", err)
+	}
+	return nil
+}
+
+// fetchCertMetadata returns the metadata entry of the certificate with the
+// given serial, or nil if none was stored.
+func (sc *storageContext) fetchCertMetadata(serial string) (*certMetadataEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, certMetadataPath+normalizeSerial(serial))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var metadata certMetadataEntry
+	if err := entry.DecodeJSON(&metadata); err != nil {
+		return nil, fmt.Errorf("unable to decode certificate metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// certMetadataMatches returns whether the metadata contains every key/value
+// pair of the filter.
+func certMetadataMatches(metadata, filter map[string]string) bool {
+	for key, value := range filter {
+		if actual, ok := metadata[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+func pathCertMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `cert-metadata/(?P<serial>[0-9A-Fa-f-:]+)`,
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "cert-metadata",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"serial": {
+				Type: framework.TypeString,
+				Description: `Certificate serial number, in colon- or
+hyphen-separated octal`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathCertMetadataRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"serial_number": {
+								Type:     framework.TypeString,
+								Required: true,
+							},
+							"cert_metadata": {
+								Type:     framework.TypeKVPairs,
+								Required: true,
+							},
+							"role": {
+								Type:     framework.TypeString,
+								Required: false,
+							},
+							"entity_id": {
+								Type:     framework.TypeString,
+								Required: false,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathCertMetadataHelpSyn,
+		HelpDescription: pathCertMetadataHelpDesc,
+	}
+}
+
+func (b *backend) pathCertMetadataRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	serial := data.Get("serial").(string)
+	if len(serial) == 0 {
+		return logical.ErrorResponse("The serial number must be provided"), nil
+	}
+
+	sc := b.makeStorageContext(ctx, req.Storage)
+	entry, err := sc.fetchCertMetadata(serial)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"serial_number": denormalizeSerial(normalizeSerial(serial)),
+			"cert_metadata": entry.Metadata,
+		},
+	}
+	if entry.Role != "" {
+		resp.Data["role"] = entry.Role
+	}
+	if entry.EntityID != "" {
+		resp.Data["entity_id"] = entry.EntityID
+	}
+	return resp, nil
+}
+
+const pathCertMetadataHelpSyn = `
+Fetch the metadata attached to a certificate at issuance.
+`
+
+const pathCertMetadataHelpDesc = `
+This endpoint returns the key/value metadata, such as the owner or service
+of the certificate, given in the cert_metadata parameter when the
+certificate was issued or signed, along with the role it was issued by
+and the identity entity of the requester.
+Unlike the certificate itself, the metadata is only available to
+authenticated callers.
+`
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertMetadata(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "roles/example", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/ephemeral", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+		"no_store":       true,
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/example", map[string]interface{}{
+		"common_name":   "api.example.com",
+		"cert_metadata": map[string]interface{}{"owner": "payments", "ticket": "CHG-1"},
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	apiSerial := resp.Data["serial_number"].(string)
+
+	resp, err = CBWrite(b, s, "issue/example", map[string]interface{}{
+		"common_name":   "web.example.com",
+		"cert_metadata": "owner=frontend",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "issue/example", map[string]interface{}{
+		"common_name":   "bad.example.com",
+		"cert_metadata": map[string]interface{}{"owner": strings.Repeat("a", maxCertMetadataValueLength+1)},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected oversized metadata value to be rejected")
+
+	resp, err = CBWrite(b, s, "issue/ephemeral", map[string]interface{}{
+		"common_name":   "tmp.example.com",
+		"cert_metadata": "owner=payments",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.NotEmpty(t, resp.Warnings, "expected warning about metadata of unstored certificate")
+
+	resp, err = CBRead(b, s, "cert-metadata/"+apiSerial)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, map[string]string{"owner": "payments", "ticket": "CHG-1"}, resp.Data["cert_metadata"])
+
+	resp, err = CBList(b, s, "certs")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["keys"], 3, "expected unfiltered listing to include the root and both leaves")
+
+	resp, err = CBReq(b, s, logical.ListOperation, "certs", map[string]interface{}{"cert_metadata": "owner=payments"})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{apiSerial}, resp.Data["keys"])
+
+	resp, err = CBWrite(b, s, "revoke", map[string]interface{}{"serial_number": apiSerial})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "payments", resp.Data["cert_metadata"].(map[string]string)["owner"])
+}
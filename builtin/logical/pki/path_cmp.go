@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/cmp"
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// maximumCmpRequestSize bounds CMP requests, which carry the certificate
+// requests along with the certificates of the requester.
+const maximumCmpRequestSize = 64 * 1024
+
+func pathCmp(b *backend) *framework.Path {
+	fields := map[string]*framework.FieldSchema{}
+	fields = addIssuerRefField(fields)
+
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex(issuerRefParam) + "/cmp",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKIIssuer,
+			OperationSuffix: "cmp",
+		},
+
+		Fields: fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "write",
+				},
+				Callback:                    b.pathCmpHandler,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathCmpHelpSyn,
+		HelpDescription: pathCmpHelpDesc,
+	}
+}
+
+func (b *backend) pathCmpHandler(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	issuerId, resp, err := b.resolveCmpIssuer(sc, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	config, err := sc.getCmpConfig(issuerId)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return logical.RespondWithStatusCode(logical.ErrorResponse("CMP is disabled for this issuer"), req, http.StatusForbidden)
+	}
+
+	der, err := fetchCmpMessage(req)
+	if err != nil {
+		return logical.ErrorResponse("invalid CMP message: %s", err), nil
+	}
+	msg, err := cmp.ParseMessage(der)
+	if err != nil {
+		return logical.ErrorResponse("invalid CMP message: %s", err), nil
+	}
+
+	caInfo, err := sc.fetchCAInfoByIssuerId(issuerId, issuing.IssuanceUsage)
+	if err != nil {
+		return logical.ErrorResponse("failed loading issuer %s: %s", issuerId, err), nil
+	}
+	role, err := b.GetRole(ctx, req.Storage, config.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("role %q configured for CMP does not exist", config.Role), nil
+	}
+
+	var chain []*x509.Certificate
+	for _, block := range caInfo.GetCAChain() {
+		if !block.Certificate.Equal(caInfo.Certificate) {
+			chain = append(chain, block.Certificate)
+		}
+	}
+
+	// Responses are protected the same way as the request, falling back to
+	// a signature of the issuer when the MAC of the request was not
+	// verified.
+	macVerified := false
+	respond := func(r *cmp.Response) (*logical.Response, error) {
+		var respDER []byte
+		var err error
+		if macVerified {
+			respDER, err = r.MAC(caInfo.Certificate, []byte(config.SharedSecret))
+		} else {
+			respDER, err = r.Sign(caInfo.Certificate, chain, caInfo.PrivateKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return cmpResponse(respDER), nil
+	}
+	failure := func(info cmp.FailInfo, reason error) (*logical.Response, error) {
+		b.Logger().Debug("rejecting CMP request", "issuer_id", issuerId, "type", msg.Type, "error", reason)
+		r, err := msg.Error(info, reason.Error())
+		if err != nil {
+			return nil, err
+		}
+		return respond(r)
+	}
+
+	signer, info, err := verifyCmpProtection(config, caInfo, msg)
+	if err != nil {
+		return failure(info, err)
+	}
+	macVerified = signer == nil
+
+	switch msg.Type {
+	case cmp.BodyIR, cmp.BodyCR, cmp.BodyP10CR, cmp.BodyKUR:
+		var oldCert *x509.Certificate
+		if msg.Type == cmp.BodyKUR {
+			if signer == nil {
+				return failure(cmp.NotAuthorized, errors.New("key update requests must be signed with the certificate being updated"))
+			}
+			if info, err := checkCmpKeyUpdateSigner(sc, caInfo, signer); err != nil {
+				return failure(info, err)
+			}
+			oldCert = signer
+		}
+
+		var results []cmp.CertResult
+		for _, certReq := range msg.Requests {
+			results = append(results, b.issueCmpCertificate(sc, req, role, caInfo, certReq, oldCert))
+		}
+
+		r, err := msg.CertRep(results, cmpCAPubs(caInfo.Certificate, chain))
+		if err != nil {
+			return nil, err
+		}
+		return respond(r)
+	case cmp.BodyCertConf:
+		for _, status := range msg.Confirmations {
+			if !status.Accepted {
+				b.Logger().Debug("CMP requester rejected issued certificate", "issuer_id", issuerId, "cert_req_id", status.ID)
+			}
+		}
+		return respond(msg.PKIConf())
+	default:
+		return failure(cmp.BadRequest, fmt.Errorf("unsupported message type %s", msg.Type))
+	}
+}
+
+// verifyCmpProtection verifies the protection of the message, and returns the
+// certificate of the signer of signature protected messages. Signers must
+// chain to the issuer or to one of the trusted_ca_certs of the configuration.
+func verifyCmpProtection(config *cmpConfigEntry, caInfo *certutil.CAInfoBundle, msg *cmp.Message) (*x509.Certificate, cmp.FailInfo, error) {
+	if msg.IsMACProtected() {
+		if config.SharedSecret == "" {
+			return nil, cmp.NotAuthorized, errors.New("no shared_secret is configured for password-based MAC protection")
+		}
+		if err := msg.VerifyMAC([]byte(config.SharedSecret)); err != nil {
+			return nil, cmp.BadMessageCheck, err
+		}
+		return nil, 0, nil
+	}
+
+	signer, err := msg.VerifySignature()
+	if err != nil {
+		return nil, cmp.BadMessageCheck, err
+	}
+
+	trusted, err := config.trustedCACerts()
+	if err != nil {
+		return nil, cmp.SystemFailure, err
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caInfo.Certificate)
+	for _, cert := range trusted {
+		roots.AddCert(cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range msg.ExtraCerts[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, cmp.SignerNotTrusted, fmt.Errorf("signer certificate is not trusted: %w", err)
+	}
+	return signer, 0, nil
+}
+
+// checkCmpKeyUpdateSigner ensures the certificate being updated was issued by
+// this issuer and has not been revoked.
+func checkCmpKeyUpdateSigner(sc *storageContext, caInfo *certutil.CAInfoBundle, signer *x509.Certificate) (cmp.FailInfo, error) {
+	if err := signer.CheckSignatureFrom(caInfo.Certificate); err != nil {
+		return cmp.WrongAuthority, errors.New("the certificate being updated was not issued by this issuer")
+	}
+
+	revEntry, err := fetchCertBySerial(sc, revokedPath, serialFromCert(signer))
+	if err != nil {
+		return cmp.SystemFailure, err
+	}
+	if revEntry != nil {
+		return cmp.CertRevoked, errors.New("the certificate being updated has been revoked")
+	}
+	return 0, nil
+}
+
+func (b *backend) issueCmpCertificate(sc *storageContext, req *logical.Request, role *issuing.RoleEntry, caInfo *certutil.CAInfoBundle, certReq *cmp.CertRequest, oldCert *x509.Certificate) cmp.CertResult {
+	result := cmp.CertResult{ID: certReq.ID}
+	reject := func(info cmp.FailInfo, err error) cmp.CertResult {
+		result.FailInfo = info
+		result.Reason = err.Error()
+		return result
+	}
+
+	if err := certReq.VerifyPOP(); err != nil {
+		return reject(cmp.BadPOP, err)
+	}
+
+	csr := certReq.CSR
+	if oldCert != nil {
+		// Key updates keep the names of the certificate being updated.
+		if len(csr.Subject.Names) == 0 {
+			csr.Subject = oldCert.Subject
+		} else if csr.Subject.String() != oldCert.Subject.String() {
+			return reject(cmp.BadCertTemplate, errors.New("key update requests cannot change the subject"))
+		}
+		if len(csr.DNSNames)+len(csr.EmailAddresses)+len(csr.IPAddresses)+len(csr.URIs) == 0 {
+			csr.DNSNames = oldCert.DNSNames
+			csr.EmailAddresses = oldCert.EmailAddresses
+			csr.IPAddresses = oldCert.IPAddresses
+			csr.URIs = oldCert.URIs
+		}
+	}
+
+	// Devices cannot pick a TTL, so truncate to the issuer's expiration
+	// rather than failing enrollment.
+	if caInfo.LeafNotAfterBehavior == certutil.ErrNotAfterBehavior {
+		caInfo.LeafNotAfterBehavior = certutil.TruncateNotAfterBehavior
+	}
+
+	parsedBundle, _, err := issuing.SignCert(b.System(), role, issuing.NewEntityInfoFromReq(req), caInfo, issuing.NewBasicSignCertInput(csr, false /* is_ca=false */, false /* use_csr_values */))
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return reject(cmp.BadCertTemplate, err)
+		}
+		b.Logger().Error("failed issuing CMP certificate", "error", err)
+		return reject(cmp.SystemFailure, errors.New("failed to issue certificate"))
+	}
+
+	if !role.NoStore {
+		if err := issuing.StoreCertificate(sc.Context, sc.Storage, b.GetCertificateCounter(), parsedBundle); err != nil {
+			b.Logger().Error("failed storing CMP certificate", "error", err)
+			return reject(cmp.SystemFailure, errors.New("failed to store certificate"))
+		}
+	}
+
+	result.Certificate = parsedBundle.Certificate
+	return result
+}
+
+// cmpCAPubs returns the trust anchors sent to requesters: the self-signed
+// certificates of the chain of the issuer.
+func cmpCAPubs(cert *x509.Certificate, chain []*x509.Certificate) []*x509.Certificate {
+	var roots []*x509.Certificate
+	for _, c := range append([]*x509.Certificate{cert}, chain...) {
+		if bytes.Equal(c.RawSubject, c.RawIssuer) && c.CheckSignatureFrom(c) == nil {
+			roots = append(roots, c)
+		}
+	}
+	return roots
+}
+
+func fetchCmpMessage(req *logical.Request) ([]byte, error) {
+	if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+		return nil, errors.New("no data in request body")
+	}
+	rawBody := req.HTTPRequest.Body
+	defer rawBody.Close()
+
+	requestBytes, err := io.ReadAll(io.LimitReader(rawBody, maximumCmpRequestSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(requestBytes) >= maximumCmpRequestSize {
+		return nil, errors.New("request is too large")
+	}
+	return requestBytes, nil
+}
+
+func cmpResponse(der []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: cmp.ContentType,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     der,
+		},
+	}
+}
+
+const pathCmpHelpSyn = `
+Enroll with the Certificate Management Protocol (CMPv2).
+`
+
+const pathCmpHelpDesc = `
+This endpoint implements a CMP server (RFC 4210, with the HTTP transfer of
+RFC 6712) for the issuer, which must first be enabled at
+issuer/:issuer_ref/config/cmp. Supported messages are initialization (ir),
+certification (cr, p10cr) and key update (kur) requests, and certificate
+confirmations (certConf).
+
+Requests must be protected either with a password-based MAC using the
+shared_secret of the configuration, or with a signature of a certificate
+chaining to the issuer or one of the trusted_ca_certs. Key update requests
+must be signed with the certificate being updated. Certificates are issued
+with the role of the configuration, and only signature proof-of-possession
+of the requested keys is accepted.
+`
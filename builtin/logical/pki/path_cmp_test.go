@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func sendCmpRequest(b *backend, s logical.Storage, path string, body []byte) (*logical.Response, error) {
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       path,
+		Storage:    s,
+		MountPoint: "pki/",
+		HTTPRequest: &http.Request{
+			Body: io.NopCloser(bytes.NewReader(body)),
+		},
+	})
+}
+
+func TestCmp_Config(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"issuer_name": "root",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issuerId := resp.Data["issuer_id"]
+
+	resp, err = CBRead(b, s, "issuer/root/config/cmp")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.Equal(t, issuerId, resp.Data["issuer_id"])
+
+	resp, err = CBWrite(b, s, "issuer/root/config/cmp", map[string]interface{}{"enabled": true})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected enabling CMP without a role to be rejected")
+
+	resp, err = CBWrite(b, s, "issuer/root/config/cmp", map[string]interface{}{
+		"enabled": true,
+		"role":    "missing",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected unknown role to be rejected")
+
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issuer/root/config/cmp", map[string]interface{}{
+		"enabled":          true,
+		"role":             "devices",
+		"trusted_ca_certs": "not a certificate",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected invalid trusted_ca_certs to be rejected")
+
+	resp, err = CBWrite(b, s, "issuer/root/config/cmp", map[string]interface{}{
+		"enabled":       true,
+		"role":          "devices",
+		"shared_secret": "shared-secret",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, true, resp.Data["shared_secret_set"])
+	require.NotContains(t, resp.Data, "shared_secret")
+
+	resp, err = CBRead(b, s, "issuer/"+issuerId.(string)+"/config/cmp")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, true, resp.Data["enabled"])
+	require.Equal(t, "devices", resp.Data["role"])
+
+	_, err = CBDelete(b, s, "issuer/root")
+	require.NoError(t, err)
+	sc := b.makeStorageContext(context.Background(), s)
+	entry, err := s.Get(sc.Context, storageCmpConfigPrefix+issuerId.(string))
+	require.NoError(t, err)
+	require.Nil(t, entry, "expected CMP configuration to be removed with the issuer")
+}
+
+func TestCmp_Requests(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+
+	resp, err = sendCmpRequest(b, s, "issuer/default/cmp", []byte("garbage"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.Data[logical.HTTPStatusCode], "expected CMP to be disabled by default")
+
+	_, err = CBWrite(b, s, "issuer/default/config/cmp", map[string]interface{}{
+		"enabled": true,
+		"role":    "devices",
+	})
+	require.NoError(t, err)
+
+	resp, err = sendCmpRequest(b, s, "issuer/default/cmp", []byte("garbage"))
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected malformed message to be rejected")
+
+	resp, err = sendCmpRequest(b, s, "issuer/default/cmp", bytes.Repeat([]byte{0}, maximumCmpRequestSize))
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected oversized message to be rejected")
+}
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageCmpConfigPrefix = "config/cmp/"
+	pathConfigCmpHelpSyn   = "Configuration of the CMP endpoint of an issuer"
+	pathConfigCmpHelpDesc  = "Here we configure:\n\nenabled=false, whether CMP is enabled for this issuer,\nrole=\"\", the role certificates are issued with,\nshared_secret=\"\", the secret of password-based MAC protected requests,\ntrusted_ca_certs=\"\", the CAs whose certificates may sign requests, in addition to the issuer itself."
+)
+
+type cmpConfigEntry struct {
+	Enabled        bool   `json:"enabled"`
+	Role           string `json:"role"`
+	SharedSecret   string `json:"shared_secret"`
+	TrustedCACerts string `json:"trusted_ca_certs"`
+}
+
+// trustedCACerts returns the parsed trusted_ca_certs bundle.
+func (c *cmpConfigEntry) trustedCACerts() ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := []byte(c.TrustedCACerts); ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func (sc *storageContext) getCmpConfig(issuerId issuing.IssuerID) (*cmpConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageCmpConfigPrefix+issuerId.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping cmpConfigEntry
+	if entry == nil {
+		return &mapping, nil
+	}
+
+	if err := entry.DecodeJSON(&mapping); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode CMP configuration: %v", err)}
+	}
+
+	return &mapping, nil
+}
+
+func (sc *storageContext) setCmpConfig(issuerId issuing.IssuerID, entry *cmpConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageCmpConfigPrefix+issuerId.String(), entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func (sc *storageContext) deleteCmpConfig(issuerId issuing.IssuerID) error {
+	return sc.Storage.Delete(sc.Context, storageCmpConfigPrefix+issuerId.String())
+}
+
+func pathCmpConfig(b *backend) *framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		"enabled": {
+			Type:        framework.TypeBool,
+			Description: `whether CMP is enabled for this issuer, defaults to false`,
+			Default:     false,
+		},
+		"role": {
+			Type:        framework.TypeString,
+			Description: `the role certificates requested over CMP are issued with; required when CMP is enabled`,
+		},
+		"shared_secret": {
+			Type:        framework.TypeString,
+			Description: `the shared secret of requests protected with a password-based MAC; when empty, only signature protected requests are accepted`,
+			DisplayAttrs: &framework.DisplayAttributes{
+				Sensitive: true,
+			},
+		},
+		"trusted_ca_certs": {
+			Type:        framework.TypeString,
+			Description: `PEM-encoded CA certificates, such as those of device vendors, trusted to issue the certificates signing requests; certificates of the issuer itself are always trusted`,
+		},
+	}
+	fields = addIssuerRefField(fields)
+
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex(issuerRefParam) + "/config/cmp",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKIIssuer,
+		},
+
+		Fields: fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "cmp-configuration",
+				},
+				Callback: b.pathCmpConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathCmpConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "cmp",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathCmpConfigDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "cmp-configuration",
+				},
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigCmpHelpSyn,
+		HelpDescription: pathConfigCmpHelpDesc,
+	}
+}
+
+func (b *backend) resolveCmpIssuer(sc *storageContext, data *framework.FieldData) (issuing.IssuerID, *logical.Response, error) {
+	if b.UseLegacyBundleCaStorage() {
+		return "", logical.ErrorResponse("cannot use CMP until migration has completed"), nil
+	}
+
+	issuerName := GetIssuerRef(data)
+	if len(issuerName) == 0 {
+		return "", logical.ErrorResponse("missing issuer reference"), nil
+	}
+
+	issuerId, err := sc.resolveIssuerReference(issuerName)
+	if err != nil {
+		return "", nil, err
+	}
+	if issuerId == "" {
+		return "", logical.ErrorResponse("unable to resolve issuer id for reference: " + issuerName), nil
+	}
+	return issuerId, nil, nil
+}
+
+func (b *backend) pathCmpConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	issuerId, resp, err := b.resolveCmpIssuer(sc, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	config, err := sc.getCmpConfig(issuerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromCmpConfig(issuerId, config), nil
+}
+
+func genResponseFromCmpConfig(issuerId issuing.IssuerID, config *cmpConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":         issuerId.String(),
+			"enabled":           config.Enabled,
+			"role":              config.Role,
+			"shared_secret_set": config.SharedSecret != "",
+			"trusted_ca_certs":  config.TrustedCACerts,
+		},
+	}
+}
+
+func (b *backend) pathCmpConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	issuerId, resp, err := b.resolveCmpIssuer(sc, d)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	config, err := sc.getCmpConfig(issuerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+
+	if roleRaw, ok := d.GetOk("role"); ok {
+		config.Role = roleRaw.(string)
+	}
+
+	if secretRaw, ok := d.GetOk("shared_secret"); ok {
+		config.SharedSecret = secretRaw.(string)
+	}
+
+	if trustedRaw, ok := d.GetOk("trusted_ca_certs"); ok {
+		config.TrustedCACerts = strings.TrimSpace(trustedRaw.(string))
+		certs, err := config.trustedCACerts()
+		if err != nil {
+			return logical.ErrorResponse("failed to parse trusted_ca_certs: %v", err), nil
+		}
+		if config.TrustedCACerts != "" && len(certs) == 0 {
+			return logical.ErrorResponse("trusted_ca_certs contains no PEM-encoded certificates"), nil
+		}
+		for _, cert := range certs {
+			if !cert.IsCA {
+				return logical.ErrorResponse("trusted_ca_certs entry %q is not a CA certificate", cert.Subject.String()), nil
+			}
+		}
+	}
+
+	if config.Enabled {
+		if config.Role == "" {
+			return logical.ErrorResponse("role is required when CMP is enabled"), nil
+		}
+		role, err := b.GetRole(ctx, req.Storage, config.Role)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse("role %v does not exist", config.Role), nil
+		}
+	}
+
+	if err := sc.setCmpConfig(issuerId, config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromCmpConfig(issuerId, config), nil
+}
+
+func (b *backend) pathCmpConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	issuerId, resp, err := b.resolveCmpIssuer(sc, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	if err := sc.deleteCmpConfig(issuerId); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageScepConfig      = "config/scep"
+	pathConfigScepHelpSyn  = "Configuration of SCEP Endpoints"
+	pathConfigScepHelpDesc = "Here we configure:\n\nenabled=false, whether SCEP is enabled, defaults to false meaning that clusters will by default not get SCEP support,\nallowed_roles=\"*\", which roles are allowed for use with SCEP; by default these will be all roles,\ndefault_role=\"\", the role used for enrollment on the non-role-qualified /pki/scep endpoint; when empty, that endpoint is disabled."
+)
+
+type scepConfigEntry struct {
+	Enabled      bool     `json:"enabled"`
+	AllowedRoles []string `json:"allowed_roles"`
+	DefaultRole  string   `json:"default_role"`
+}
+
+var defaultScepConfig = scepConfigEntry{
+	Enabled:      false,
+	AllowedRoles: []string{"*"},
+	DefaultRole:  "",
+}
+
+func (c *scepConfigEntry) isRoleAllowed(name string) bool {
+	return strutil.StrListContains(c.AllowedRoles, "*") || strutil.StrListContains(c.AllowedRoles, name)
+}
+
+func (sc *storageContext) getScepConfig() (*scepConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageScepConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping scepConfigEntry
+	if entry == nil {
+		mapping = defaultScepConfig
+		return &mapping, nil
+	}
+
+	if err := entry.DecodeJSON(&mapping); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode SCEP configuration: %v", err)}
+	}
+
+	return &mapping, nil
+}
+
+func (sc *storageContext) setScepConfig(entry *scepConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageScepConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathScepConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/scep",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": {
+				Type:        framework.TypeBool,
+				Description: `whether SCEP is enabled, defaults to false meaning that clusters will by default not get SCEP support`,
+				Default:     false,
+			},
+			"allowed_roles": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `which roles are allowed for use with SCEP; by default via '*', these will be all roles; when concrete role names are specified, any default_role must be included.`,
+				Default:     []string{"*"},
+			},
+			"default_role": {
+				Type:        framework.TypeString,
+				Description: `the role used for enrollment on the non-role-qualified /pki/scep endpoint; when empty, only the /pki/roles/:role/scep endpoints can be used`,
+				Default:     "",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "scep-configuration",
+				},
+				Callback: b.pathScepConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathScepConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "scep",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigScepHelpSyn,
+		HelpDescription: pathConfigScepHelpDesc,
+	}
+}
+
+func (b *backend) pathScepConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getScepConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromScepConfig(config), nil
+}
+
+func genResponseFromScepConfig(config *scepConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":       config.Enabled,
+			"allowed_roles": config.AllowedRoles,
+			"default_role":  config.DefaultRole,
+		},
+	}
+}
+
+func (b *backend) pathScepConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	config, err := sc.getScepConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+
+	if allowedRolesRaw, ok := d.GetOk("allowed_roles"); ok {
+		config.AllowedRoles = allowedRolesRaw.([]string)
+		if len(config.AllowedRoles) == 0 {
+			return nil, fmt.Errorf("allowed_roles must take a non-zero length value; specify '*' as the value to allow anything or specify enabled=false to disable SCEP entirely")
+		}
+	}
+
+	if defaultRoleRaw, ok := d.GetOk("default_role"); ok {
+		config.DefaultRole = defaultRoleRaw.(string)
+	}
+
+	// Validate Allowed Roles
+	if !strutil.StrListContains(config.AllowedRoles, "*") {
+		for _, name := range config.AllowedRoles {
+			role, err := b.GetRole(ctx, req.Storage, name)
+			if err != nil {
+				return nil, err
+			}
+			if role == nil {
+				return logical.ErrorResponse("allowed_role %v does not exist", name), nil
+			}
+		}
+	}
+
+	if config.DefaultRole != "" {
+		role, err := b.GetRole(ctx, req.Storage, config.DefaultRole)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse("default_role %v does not exist", config.DefaultRole), nil
+		}
+		if !config.isRoleAllowed(config.DefaultRole) {
+			return logical.ErrorResponse("default_role %v was not specified in allowed_roles: %v", config.DefaultRole, config.AllowedRoles), nil
+		}
+	}
+
+	if err := sc.setScepConfig(config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromScepConfig(config), nil
+}
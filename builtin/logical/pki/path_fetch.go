@@ -236,6 +236,15 @@ func pathFetchListCerts(b *backend) *framework.Path {
 			OperationSuffix: "certs",
 		},
 
+		Fields: map[string]*framework.FieldSchema{
+			"cert_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `Only list certificates whose metadata contains
+all of the given key/value pairs.`,
+				Query: true,
+			},
+		},
+
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ListOperation: &framework.PathOperation{
 				Callback: b.pathFetchCertList,
@@ -247,7 +256,12 @@ func pathFetchListCerts(b *backend) *framework.Path {
 	}
 }
 
-func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (response *logical.Response, retErr error) {
+func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
+	filter := data.Get("cert_metadata").(map[string]string)
+	if len(filter) > 0 {
+		return b.listCertsByMetadata(ctx, req, filter)
+	}
+
 	entries, err := req.Storage.List(ctx, "certs/")
 	if err != nil {
 		return nil, err
@@ -258,6 +272,37 @@ func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, _
 	return logical.ListResponse(entries), nil
 }
 
+// listCertsByMetadata lists the certificates whose metadata matches the
+// filter, along with their metadata. Only certificates issued with metadata
+// or by a role have an entry to match against, so their index is walked
+// rather than the whole certificate store.
+func (b *backend) listCertsByMetadata(ctx context.Context, req *logical.Request, filter map[string]string) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	entries, err := req.Storage.List(ctx, certMetadataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	keyInfo := map[string]interface{}{}
+	for _, entry := range entries {
+		metadataEntry, err := sc.fetchCertMetadata(entry)
+		if err != nil {
+			return nil, err
+		}
+		if metadataEntry == nil || !metadataEntry.matches("", "", filter) {
+			continue
+		}
+
+		serial := denormalizeSerial(entry)
+		keys = append(keys, serial)
+		keyInfo[serial] = map[string]interface{}{
+			"cert_metadata": metadataEntry.Metadata,
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
 func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
 	var serial, pemType, contentType string
 	var certEntry, revokedEntry *logical.StorageEntry
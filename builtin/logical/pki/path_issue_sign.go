@@ -376,6 +376,11 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 			`the "format" path parameter must be "pem", "der", or "pem_bundle"`), nil
 	}
 
+	metadata := data.Get("cert_metadata").(map[string]string)
+	if err := validateCertMetadata(metadata); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	var caErr error
 	sc := b.makeStorageContext(ctx, req.Storage)
 	signingBundle, caErr := sc.fetchCAInfo(issuerName, issuing.IssuanceUsage)
@@ -429,6 +434,14 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if err != nil {
 			return nil, err
 		}
+
+		if len(metadata) > 0 || role.Name != "" || req.EntityID != "" {
+			if err := sc.storeCertMetadata(parsedBundle.Certificate.SerialNumber, role.Name, req.EntityID, metadata); err != nil {
+				return nil, err
+			}
+		}
+	} else if len(metadata) > 0 {
+		resp.AddWarning("cert_metadata was not stored as the role is set with \"no_store\"")
 	}
 
 	if useCSR {
@@ -901,6 +901,18 @@ func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data
 		return nil, err
 	}
 
+	err = req.Storage.Delete(ctx, scepChallengePolicyPrefix+data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	// Certificates issued over SCEP with the role cannot be renewed with a
+	// new role of the same name.
+	err = logical.ClearView(ctx, logical.NewStorageView(req.Storage, scepCertsPrefix+data.Get("name").(string)+"/"))
+	if err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
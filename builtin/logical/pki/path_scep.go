@@ -0,0 +1,367 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/builtin/logical/pki/scep"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	scepOperationParam = "operation"
+	scepMessageParam   = "message"
+
+	// maximumScepRequestSize bounds PKIOperation messages, which carry an
+	// encrypted CSR and the self-signed certificate of the requester.
+	maximumScepRequestSize = 64 * 1024
+)
+
+func pathScep(b *backend) []*framework.Path {
+	var paths []*framework.Path
+	for _, prefix := range []string{
+		"scep",
+		"roles/" + framework.GenericNameRegex("role") + "/scep",
+	} {
+		// Most clients append the CGI path of the original implementation
+		// to the configured URL.
+		for _, suffix := range []string{"", "/pkiclient.exe"} {
+			paths = append(paths, patternScep(b, prefix+suffix))
+		}
+	}
+	return paths
+}
+
+func patternScep(b *backend, pattern string) *framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		scepOperationParam: {
+			Type:        framework.TypeString,
+			Description: "The SCEP operation: GetCACaps, GetCACert or PKIOperation.",
+			Query:       true,
+		},
+		scepMessageParam: {
+			Type:        framework.TypeString,
+			Description: "The base64-encoded message of a PKIOperation sent with GET.",
+			Query:       true,
+		},
+	}
+	if strings.HasPrefix(pattern, "roles/") {
+		fields["role"] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "The role to enroll with.",
+			Required:    true,
+		}
+	}
+
+	return &framework.Path{
+		Pattern: pattern + "$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "scep",
+		},
+
+		Fields: fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+				Callback:                    b.pathScepHandler,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "write",
+				},
+				Callback:                    b.pathScepHandler,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathScepHelpSyn,
+		HelpDescription: pathScepHelpDesc,
+	}
+}
+
+func (b *backend) pathScepHandler(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	roleName, role, err := b.getScepRole(sc, data)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.RespondWithStatusCode(logical.ErrorResponse(err.Error()), req, http.StatusForbidden)
+		}
+		return nil, err
+	}
+
+	operation := data.Get(scepOperationParam).(string)
+	if operation == "" && req.HTTPRequest != nil && req.HTTPRequest.URL != nil {
+		// Binary POST bodies are not parsed, so the query parameters must
+		// be read from the request itself.
+		operation = req.HTTPRequest.URL.Query().Get(scepOperationParam)
+	}
+
+	switch operation {
+	case "GetCACaps":
+		return &logical.Response{
+			Data: map[string]interface{}{
+				logical.HTTPContentType: "text/plain",
+				logical.HTTPStatusCode:  http.StatusOK,
+				logical.HTTPRawBody:     []byte(strings.Join(scep.Capabilities, "\n")),
+			},
+		}, nil
+	case "GetCACert":
+		caInfo, _, err := b.getScepCA(sc, role)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		var chain []*x509.Certificate
+		for _, block := range caInfo.GetCAChain() {
+			if !block.Certificate.Equal(caInfo.Certificate) {
+				chain = append(chain, block.Certificate)
+			}
+		}
+		der, contentType, err := scep.CACertificates(caInfo.Certificate, chain)
+		if err != nil {
+			return nil, err
+		}
+		return &logical.Response{
+			Data: map[string]interface{}{
+				logical.HTTPContentType: contentType,
+				logical.HTTPStatusCode:  http.StatusOK,
+				logical.HTTPRawBody:     der,
+			},
+		}, nil
+	case "PKIOperation":
+		return b.scepPKIOperation(sc, req, data, roleName, role)
+	default:
+		return logical.ErrorResponse("unsupported SCEP operation %q", operation), nil
+	}
+}
+
+// getScepRole returns the role to enroll with, as given by the path or the
+// default_role of the SCEP configuration.
+func (b *backend) getScepRole(sc *storageContext, data *framework.FieldData) (string, *issuing.RoleEntry, error) {
+	config, err := sc.getScepConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	if !config.Enabled {
+		return "", nil, errutil.UserError{Err: "SCEP is disabled on this mount"}
+	}
+
+	name := config.DefaultRole
+	if roleRaw, ok := data.GetOk("role"); ok {
+		name = roleRaw.(string)
+	}
+	if name == "" {
+		return "", nil, errutil.UserError{Err: "no default_role is configured for SCEP; use a role-qualified SCEP endpoint"}
+	}
+	if !config.isRoleAllowed(name) {
+		return "", nil, errutil.UserError{Err: fmt.Sprintf("role %q is not allowed for use with SCEP", name)}
+	}
+
+	role, err := b.GetRole(sc.Context, sc.Storage, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if role == nil {
+		return "", nil, errutil.UserError{Err: fmt.Sprintf("role %q does not exist", name)}
+	}
+	return name, role, nil
+}
+
+// getScepCA returns the issuer of the role and its key. SCEP requests are
+// encrypted to the CA, so its key must be able to decrypt with RSA.
+func (b *backend) getScepCA(sc *storageContext, role *issuing.RoleEntry) (*certutil.CAInfoBundle, crypto.Decrypter, error) {
+	issuerRef := role.Issuer
+	if issuerRef == "" {
+		issuerRef = defaultRef
+	}
+
+	caInfo, _, err := sc.fetchCAInfoWithIssuer(issuerRef, issuing.IssuanceUsage)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed loading CA %s: %w", issuerRef, err)
+	}
+
+	decrypter, ok := caInfo.PrivateKey.(crypto.Decrypter)
+	if !ok {
+		return nil, nil, fmt.Errorf("the key of issuer %s cannot be used for SCEP as it does not support decryption", issuerRef)
+	}
+	if _, ok := decrypter.Public().(*rsa.PublicKey); !ok {
+		return nil, nil, fmt.Errorf("the key of issuer %s cannot be used for SCEP as it is not an RSA key", issuerRef)
+	}
+	return caInfo, decrypter, nil
+}
+
+func (b *backend) scepPKIOperation(sc *storageContext, req *logical.Request, data *framework.FieldData, roleName string, role *issuing.RoleEntry) (*logical.Response, error) {
+	der, err := fetchScepMessage(req, data)
+	if err != nil {
+		return logical.ErrorResponse("invalid PKIOperation message: %s", err), nil
+	}
+
+	msg, err := scep.ParsePKIMessage(der)
+	if err != nil {
+		return logical.ErrorResponse("invalid PKIOperation message: %s", err), nil
+	}
+
+	caInfo, caKey, err := b.getScepCA(sc, role)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Once the message is parsed, failures are reported in a signed
+	// response the client is able to process.
+	failure := func(info scep.FailInfo, reason error) (*logical.Response, error) {
+		b.Logger().Debug("rejecting SCEP request", "role", roleName, "transaction_id", msg.TransactionID, "error", reason)
+		respDER, err := msg.Failure(caInfo.Certificate, caInfo.PrivateKey, info)
+		if err != nil {
+			return nil, err
+		}
+		return scepResponse(respDER), nil
+	}
+
+	if err := msg.DecryptPKIEnvelope(caInfo.Certificate, caKey); err != nil {
+		return failure(scep.BadMessageCheck, err)
+	}
+
+	if err := b.checkScepChallenge(sc, roleName, caInfo.Certificate, msg); err != nil {
+		return failure(scep.BadRequest, err)
+	}
+
+	parsedBundle, err := b.issueScepCertificate(sc, req, roleName, role, caInfo, msg.CSR)
+	if err != nil {
+		return failure(scep.BadRequest, err)
+	}
+
+	respDER, err := msg.Success(caInfo.Certificate, caInfo.PrivateKey, parsedBundle.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	return scepResponse(respDER), nil
+}
+
+func (b *backend) issueScepCertificate(sc *storageContext, req *logical.Request, roleName string, role *issuing.RoleEntry, caInfo *certutil.CAInfoBundle, csr *x509.CertificateRequest) (*certutil.ParsedCertBundle, error) {
+	pemCsr := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr.Raw,
+	}))
+
+	data := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"csr": pemCsr,
+		},
+		Schema: getCsrSignVerbatimSchemaFields(),
+	}
+
+	// Devices cannot pick a TTL, so truncate to the issuer's expiration
+	// rather than failing enrollment.
+	if caInfo.LeafNotAfterBehavior == certutil.ErrNotAfterBehavior {
+		caInfo.LeafNotAfterBehavior = certutil.TruncateNotAfterBehavior
+	}
+
+	input := &inputBundle{
+		req:     req,
+		apiData: data,
+		role:    role,
+	}
+	parsedBundle, _, err := signCert(b, input, caInfo, false /* is_ca=false */, false /* use_csr_values */)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to sign CSR: %w", err)
+	}
+
+	if !role.NoStore {
+		if err := issuing.StoreCertificate(sc.Context, sc.Storage, b.GetCertificateCounter(), parsedBundle); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordScepCertificate(sc, roleName, parsedBundle.Certificate); err != nil {
+		return nil, err
+	}
+
+	return parsedBundle, nil
+}
+
+func fetchScepMessage(req *logical.Request, data *framework.FieldData) ([]byte, error) {
+	switch req.Operation {
+	case logical.ReadOperation:
+		message := data.Get(scepMessageParam).(string)
+		if message == "" {
+			return nil, errors.New("no base64 encoded message was found")
+		}
+		if len(message) >= maximumScepRequestSize {
+			return nil, errors.New("request is too large")
+		}
+
+		// Clients do not always escape the message, in which case its '+'
+		// characters are decoded as spaces.
+		return base64.StdEncoding.DecodeString(strings.ReplaceAll(message, " ", "+"))
+	case logical.UpdateOperation:
+		if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+			return nil, errors.New("no data in request body")
+		}
+		rawBody := req.HTTPRequest.Body
+		defer rawBody.Close()
+
+		requestBytes, err := io.ReadAll(io.LimitReader(rawBody, maximumScepRequestSize))
+		if err != nil {
+			return nil, err
+		}
+		if len(requestBytes) >= maximumScepRequestSize {
+			return nil, errors.New("request is too large")
+		}
+		return requestBytes, nil
+	default:
+		return nil, fmt.Errorf("unsupported request method: %s", req.Operation)
+	}
+}
+
+func scepResponse(der []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: scep.ContentTypePKIMessage,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     der,
+		},
+	}
+}
+
+const pathScepHelpSyn = `
+Enroll devices with the Simple Certificate Enrollment Protocol (SCEP).
+`
+
+const pathScepHelpDesc = `
+This endpoint implements a SCEP server (RFC 8894) for devices and MDM
+platforms which cannot enroll with ACME. The operation is selected with the
+'operation' query parameter:
+
+  GetCACaps     the capabilities of the server.
+  GetCACert     the issuer of the role and its chain.
+  PKIOperation  enroll with a PKCSReq or RenewalReq message.
+
+Certificates are issued with the role of the path, or the default_role of
+config/scep. Requests must carry a challenge password accepted by the
+challenge policy of the role, configured at roles/:role/scep-challenge.
+SCEP requests are encrypted to the issuer, which must have an RSA key.
+`
@@ -0,0 +1,421 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/base62"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/builtin/logical/pki/scep"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	scepChallengePolicyPrefix = "scep/challenge-policy/"
+	scepChallengesPrefix      = "scep/challenges/"
+	scepCertsPrefix           = "scep/certs/"
+
+	scepChallengePolicyNone    = "none"
+	scepChallengePolicyStatic  = "static"
+	scepChallengePolicyDynamic = "dynamic"
+
+	defaultScepChallengeTTL = time.Hour
+)
+
+// scepChallengePolicy decides which challenge passwords are accepted for
+// enrollment with a role. Roles without a policy reject all enrollments.
+type scepChallengePolicy struct {
+	Policy       string        `json:"policy"`
+	PasswordHash []byte        `json:"password_hash,omitempty"`
+	ChallengeTTL time.Duration `json:"challenge_ttl"`
+}
+
+// scepChallenge is a one-time challenge password of the dynamic policy,
+// stored under the hash of the password.
+type scepChallenge struct {
+	Expiration time.Time `json:"expiration"`
+}
+
+// scepCertEntry records a certificate issued over SCEP with a role, which
+// may then be renewed with that role without a challenge password.
+type scepCertEntry struct {
+	NotAfter time.Time `json:"not_after"`
+}
+
+func pathScepChallengePolicy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name") + "/scep-challenge$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "scep-challenge-policy",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+			"policy": {
+				Type: framework.TypeString,
+				Description: `Which challenge passwords are accepted: "none" to accept any
+request, "static" to require the configured password, or "dynamic" to require
+a one-time password generated with roles/:name/scep-challenge/generate.`,
+				Default: scepChallengePolicyDynamic,
+			},
+			"password": {
+				Type:        framework.TypeString,
+				Description: `The challenge password of the static policy.`,
+			},
+			"challenge_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: `The validity of generated challenges of the dynamic policy. Defaults to 1 hour.`,
+				Default:     int(defaultScepChallengeTTL.Seconds()),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathScepChallengePolicyRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathScepChallengePolicyWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "configure",
+				},
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback:                    b.pathScepChallengePolicyDelete,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathScepChallengePolicyHelpSyn,
+		HelpDescription: pathScepChallengePolicyHelpDesc,
+	}
+}
+
+func pathScepChallengeGenerate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name") + "/scep-challenge/generate$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "generate",
+			OperationSuffix: "scep-challenge",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.pathScepChallengeGenerate,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathScepChallengeGenerateHelpSyn,
+		HelpDescription: pathScepChallengeGenerateHelpDesc,
+	}
+}
+
+func getScepChallengePolicy(ctx context.Context, s logical.Storage, roleName string) (*scepChallengePolicy, error) {
+	entry, err := s.Get(ctx, scepChallengePolicyPrefix+roleName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var policy scepChallengePolicy
+	if err := entry.DecodeJSON(&policy); err != nil {
+		return nil, fmt.Errorf("unable to decode SCEP challenge policy: %w", err)
+	}
+	return &policy, nil
+}
+
+func (b *backend) pathScepChallengePolicyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	policy, err := getScepChallengePolicy(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"policy":        policy.Policy,
+			"challenge_ttl": int64(policy.ChallengeTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathScepChallengePolicyWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	role, err := b.GetRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("role %q does not exist", roleName), nil
+	}
+
+	policy := &scepChallengePolicy{
+		Policy:       data.Get("policy").(string),
+		ChallengeTTL: time.Duration(data.Get("challenge_ttl").(int)) * time.Second,
+	}
+	password := data.Get("password").(string)
+
+	switch policy.Policy {
+	case scepChallengePolicyNone, scepChallengePolicyDynamic:
+		if password != "" {
+			return logical.ErrorResponse("password can only be set with the %q policy", scepChallengePolicyStatic), nil
+		}
+	case scepChallengePolicyStatic:
+		if password == "" {
+			return logical.ErrorResponse("password is required with the %q policy", scepChallengePolicyStatic), nil
+		}
+		hash := sha256.Sum256([]byte(password))
+		policy.PasswordHash = hash[:]
+	default:
+		return logical.ErrorResponse("unknown policy %q; must be one of %q, %q or %q",
+			policy.Policy, scepChallengePolicyNone, scepChallengePolicyStatic, scepChallengePolicyDynamic), nil
+	}
+	if policy.ChallengeTTL <= 0 {
+		return logical.ErrorResponse("challenge_ttl must be positive"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(scepChallengePolicyPrefix+roleName, policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	var resp *logical.Response
+	if policy.Policy == scepChallengePolicyNone {
+		resp = &logical.Response{}
+		resp.AddWarning("any client able to reach the SCEP endpoint can now enroll with this role")
+	}
+	return resp, nil
+}
+
+func (b *backend) pathScepChallengePolicyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, scepChallengePolicyPrefix+data.Get("name").(string))
+}
+
+func (b *backend) pathScepChallengeGenerate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	policy, err := getScepChallengePolicy(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || policy.Policy != scepChallengePolicyDynamic {
+		return logical.ErrorResponse("role %q does not have a %q SCEP challenge policy", roleName, scepChallengePolicyDynamic), nil
+	}
+
+	challenge, err := base62.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	expiration := time.Now().Add(policy.ChallengeTTL)
+
+	entry, err := logical.StorageEntryJSON(scepChallengeKey(roleName, challenge), &scepChallenge{Expiration: expiration})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"challenge":  challenge,
+			"expiration": expiration.Unix(),
+		},
+	}, nil
+}
+
+func scepChallengeKey(roleName, challenge string) string {
+	hash := sha256.Sum256([]byte(challenge))
+	return scepChallengesPrefix + roleName + "/" + hex.EncodeToString(hash[:])
+}
+
+func scepCertKey(roleName string, cert *x509.Certificate) string {
+	return scepCertsPrefix + roleName + "/" + normalizeSerialFromBigInt(cert.SerialNumber)
+}
+
+// recordScepCertificate records the certificate as issued over SCEP with the
+// role, so that it can be renewed.
+func recordScepCertificate(sc *storageContext, roleName string, cert *x509.Certificate) error {
+	entry, err := logical.StorageEntryJSON(scepCertKey(roleName, cert), &scepCertEntry{NotAfter: cert.NotAfter})
+	if err != nil {
+		return err
+	}
+	return sc.Storage.Put(sc.Context, entry)
+}
+
+// checkScepChallenge verifies the challenge password of a request against
+// the policy of the role. Renewals signed with a certificate of the CA do not
+// need a challenge, but must pass checkScepRenewal instead.
+func (b *backend) checkScepChallenge(sc *storageContext, roleName string, caCert *x509.Certificate, msg *scep.PKIMessage) error {
+	if msg.MessageType == scep.RenewalReq && msg.SignerCert.CheckSignatureFrom(caCert) == nil {
+		return checkScepRenewal(sc, roleName, msg)
+	}
+
+	policy, err := getScepChallengePolicy(sc.Context, sc.Storage, roleName)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return errors.New("role has no SCEP challenge policy")
+	}
+
+	switch policy.Policy {
+	case scepChallengePolicyNone:
+		return nil
+	case scepChallengePolicyStatic:
+		hash := sha256.Sum256([]byte(msg.ChallengePassword))
+		if subtle.ConstantTimeCompare(hash[:], policy.PasswordHash) != 1 {
+			return errors.New("invalid challenge password")
+		}
+		return nil
+	case scepChallengePolicyDynamic:
+		if msg.ChallengePassword == "" {
+			return errors.New("missing challenge password")
+		}
+
+		key := scepChallengeKey(roleName, msg.ChallengePassword)
+		entry, err := sc.Storage.Get(sc.Context, key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return errors.New("invalid challenge password")
+		}
+
+		// Challenges are single use, even when they have expired.
+		if err := sc.Storage.Delete(sc.Context, key); err != nil {
+			return err
+		}
+
+		var challenge scepChallenge
+		if err := entry.DecodeJSON(&challenge); err != nil {
+			return err
+		}
+		if time.Now().After(challenge.Expiration) {
+			return errors.New("challenge password has expired")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown SCEP challenge policy %q", policy.Policy)
+	}
+}
+
+// checkScepRenewal ensures the certificate being renewed is valid, was issued
+// over SCEP with the role and has not been revoked, and that the renewal keeps
+// its names.
+func checkScepRenewal(sc *storageContext, roleName string, msg *scep.PKIMessage) error {
+	signer := msg.SignerCert
+	now := time.Now()
+	if now.Before(signer.NotBefore) || now.After(signer.NotAfter) {
+		return errors.New("the certificate being renewed is not currently valid")
+	}
+
+	entry, err := sc.Storage.Get(sc.Context, scepCertKey(roleName, signer))
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errors.New("the certificate being renewed was not issued over SCEP with this role")
+	}
+
+	revEntry, err := fetchCertBySerial(sc, revokedPath, serialFromCert(signer))
+	if err != nil {
+		return err
+	}
+	if revEntry != nil {
+		return errors.New("the certificate being renewed has been revoked")
+	}
+
+	csr := msg.CSR
+	if csr.Subject.String() != signer.Subject.String() {
+		return errors.New("renewal requests cannot change the subject")
+	}
+	if !strutil.EquivalentSlices(csr.DNSNames, signer.DNSNames) ||
+		!strutil.EquivalentSlices(csr.EmailAddresses, signer.EmailAddresses) ||
+		!strutil.EquivalentSlices(ipStrings(csr.IPAddresses), ipStrings(signer.IPAddresses)) ||
+		!strutil.EquivalentSlices(uriStrings(csr.URIs), uriStrings(signer.URIs)) {
+		return errors.New("renewal requests cannot change the subject alternative names")
+	}
+	return nil
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func uriStrings(uris []*url.URL) []string {
+	out := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		out = append(out, uri.String())
+	}
+	return out
+}
+
+const pathScepChallengePolicyHelpSyn = `
+Configure the challenge passwords accepted for SCEP enrollment with a role.
+`
+
+const pathScepChallengePolicyHelpDesc = `
+SCEP clients authenticate with the challenge password of their certificate
+request. Roles without a challenge policy cannot be used for enrollment.
+
+With the "static" policy, all clients share the configured password. With
+the "dynamic" policy, an MDM platform or operator generates a single use
+password for each enrollment at roles/:name/scep-challenge/generate. The
+"none" policy accepts any request, and should only be used when the SCEP
+endpoint is not reachable by untrusted clients.
+
+Renewals signed with an unexpired, unrevoked certificate issued over SCEP
+with the same role are accepted without a challenge password, as long as
+they keep the subject and subject alternative names of that certificate.
+`
+
+const pathScepChallengeGenerateHelpSyn = `
+Generate a single use SCEP challenge password for a role.
+`
+
+const pathScepChallengeGenerateHelpDesc = `
+The role must have the "dynamic" challenge policy. The challenge is valid for
+the challenge_ttl of the policy, and is consumed by the first enrollment
+attempt using it.
+`
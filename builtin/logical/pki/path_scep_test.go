@@ -0,0 +1,238 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/scep"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScep_Config(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/scep")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.Equal(t, []string{"*"}, resp.Data["allowed_roles"])
+
+	resp, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":      true,
+		"default_role": "missing",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected unknown default_role to be rejected")
+
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/servers", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":       true,
+		"allowed_roles": "servers",
+		"default_role":  "devices",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected default_role outside of allowed_roles to be rejected")
+
+	resp, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":       true,
+		"allowed_roles": "devices",
+		"default_role":  "devices",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBReq(b, s, logical.ReadOperation, "roles/servers/scep", map[string]interface{}{"operation": "GetCACaps"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.Data[logical.HTTPStatusCode], "expected role outside of allowed_roles to be rejected")
+}
+
+func TestScep_GetCACert(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "rsa",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+
+	resp, err = CBReq(b, s, logical.ReadOperation, "scep", map[string]interface{}{"operation": "GetCACaps"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.Data[logical.HTTPStatusCode], "expected SCEP to be disabled by default")
+
+	_, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":      true,
+		"default_role": "devices",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBReq(b, s, logical.ReadOperation, "scep/pkiclient.exe", map[string]interface{}{"operation": "GetCACaps"})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Contains(t, string(resp.Data[logical.HTTPRawBody].([]byte)), "POSTPKIOperation")
+
+	resp, err = CBReq(b, s, logical.ReadOperation, "roles/devices/scep", map[string]interface{}{"operation": "GetCACert"})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, scep.ContentTypeCACert, resp.Data[logical.HTTPContentType])
+	cert, err := x509.ParseCertificate(resp.Data[logical.HTTPRawBody].([]byte))
+	require.NoError(t, err)
+	require.Equal(t, "root.example.com", cert.Subject.CommonName)
+
+	resp, err = CBReq(b, s, logical.ReadOperation, "scep", map[string]interface{}{"operation": "GetNextCACert"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected unsupported operation to be rejected")
+}
+
+func TestScep_ChallengePolicy(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+	sc := b.makeStorageContext(context.Background(), s)
+
+	_, err := CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+
+	check := func(password string) error {
+		return b.checkScepChallenge(sc, "devices", nil, &scep.PKIMessage{
+			MessageType:       scep.PKCSReq,
+			ChallengePassword: password,
+		})
+	}
+	require.Error(t, check(""), "expected role without a challenge policy to reject enrollment")
+
+	resp, err := CBWrite(b, s, "roles/devices/scep-challenge", map[string]interface{}{"policy": "static"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected static policy without password to be rejected")
+
+	_, err = CBWrite(b, s, "roles/devices/scep-challenge", map[string]interface{}{
+		"policy":   "static",
+		"password": "shared-secret",
+	})
+	require.NoError(t, err)
+	require.NoError(t, check("shared-secret"))
+	require.Error(t, check("wrong-secret"))
+
+	resp, err = CBRead(b, s, "roles/devices/scep-challenge")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "static", resp.Data["policy"])
+	require.NotContains(t, resp.Data, "password")
+
+	resp, err = CBWrite(b, s, "roles/devices/scep-challenge/generate", nil)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected generate to require the dynamic policy")
+
+	_, err = CBWrite(b, s, "roles/devices/scep-challenge", map[string]interface{}{"policy": "dynamic"})
+	require.NoError(t, err)
+	resp, err = CBWrite(b, s, "roles/devices/scep-challenge/generate", nil)
+	requireSuccessNonNilResponse(t, resp, err)
+	challenge := resp.Data["challenge"].(string)
+
+	require.Error(t, check("shared-secret"))
+	require.NoError(t, check(challenge))
+	require.Error(t, check(challenge), "expected challenge to be single use")
+
+	_, err = CBDelete(b, s, "roles/devices")
+	require.NoError(t, err)
+	policy, err := getScepChallengePolicy(context.Background(), s, "devices")
+	require.NoError(t, err)
+	require.Nil(t, policy, "expected challenge policy to be removed with the role")
+}
+
+func TestScep_Renewal(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+	sc := b.makeStorageContext(context.Background(), s)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "rsa",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	caCert := parseCert(t, resp.Data["certificate"].(string))
+
+	for _, role := range []string{"devices", "servers"} {
+		_, err = CBWrite(b, s, "roles/"+role, map[string]interface{}{"allow_any_name": true})
+		require.NoError(t, err)
+		_, err = CBWrite(b, s, "roles/"+role+"/scep-challenge", map[string]interface{}{
+			"policy":   "static",
+			"password": "shared-secret",
+		})
+		require.NoError(t, err)
+	}
+
+	issue := func(role string) *x509.Certificate {
+		resp, err := CBWrite(b, s, "issue/"+role, map[string]interface{}{
+			"common_name": "device.example.com",
+			"alt_names":   "device.example.com,alt.example.com",
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		return parseCert(t, resp.Data["certificate"].(string))
+	}
+	csr := func(cn string, dnsNames ...string) *x509.CertificateRequest {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: cn},
+			DNSNames: dnsNames,
+		}, key)
+		require.NoError(t, err)
+		parsed, err := x509.ParseCertificateRequest(der)
+		require.NoError(t, err)
+		return parsed
+	}
+	renew := func(signer *x509.Certificate, csr *x509.CertificateRequest) error {
+		return b.checkScepChallenge(sc, "devices", caCert, &scep.PKIMessage{
+			MessageType: scep.RenewalReq,
+			SignerCert:  signer,
+			CSR:         csr,
+		})
+	}
+
+	// Certificates issued over SCEP with the role can be renewed with the
+	// same names.
+	renewable := issue("devices")
+	require.NoError(t, recordScepCertificate(sc, "devices", renewable))
+	require.NoError(t, renew(renewable, csr("device.example.com", "alt.example.com", "device.example.com")))
+
+	require.Error(t, renew(renewable, csr("other.example.com", "device.example.com", "alt.example.com")),
+		"expected renewal changing the subject to be rejected")
+	require.Error(t, renew(renewable, csr("device.example.com", "device.example.com", "other.example.com")),
+		"expected renewal changing the subject alternative names to be rejected")
+
+	// Certificates issued outside of SCEP, or with another role, cannot be
+	// used for renewal.
+	require.Error(t, renew(issue("devices"), csr("device.example.com", "device.example.com", "alt.example.com")),
+		"expected renewal with a certificate not issued over SCEP to be rejected")
+	otherRole := issue("servers")
+	require.NoError(t, recordScepCertificate(sc, "servers", otherRole))
+	require.Error(t, renew(otherRole, csr("device.example.com", "device.example.com", "alt.example.com")),
+		"expected renewal with a certificate of another role to be rejected")
+
+	// Revoked certificates cannot be renewed.
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{"serial_number": serialFromCert(renewable)})
+	require.NoError(t, err)
+	require.Error(t, renew(renewable, csr("device.example.com", "device.example.com", "alt.example.com")),
+		"expected renewal with a revoked certificate to be rejected")
+
+	// Recreating the role does not make earlier certificates renewable.
+	renewable = issue("devices")
+	require.NoError(t, recordScepCertificate(sc, "devices", renewable))
+	_, err = CBDelete(b, s, "roles/devices")
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{"allow_any_name": true})
+	require.NoError(t, err)
+	require.Error(t, renew(renewable, csr("device.example.com", "device.example.com", "alt.example.com")),
+		"expected renewal with a certificate of a deleted role to be rejected")
+}
@@ -41,15 +41,17 @@ type tidyStatus struct {
 	revQueueSafetyBuffer    int
 	acmeAccountSafetyBuffer int
 
-	tidyCertStore         bool
-	tidyRevokedCerts      bool
-	tidyRevokedAssocs     bool
-	tidyExpiredIssuers    bool
-	tidyBackupBundle      bool
-	tidyRevocationQueue   bool
-	tidyCrossRevokedCerts bool
-	tidyAcme              bool
-	pauseDuration         string
+	tidyCertStore          bool
+	tidyRevokedCerts       bool
+	tidyRevokedAssocs      bool
+	tidyExpiredIssuers     bool
+	tidyBackupBundle       bool
+	tidyRevocationQueue    bool
+	tidyCrossRevokedCerts  bool
+	tidyAcme               bool
+	tidyIssuedCerts        bool
+	tidyDeletedEntityCerts bool
+	pauseDuration          string
 
 	// Status
 	state        tidyStatusState
@@ -70,6 +72,11 @@ type tidyStatus struct {
 	acmeAccountsRevokedCount uint
 	acmeAccountsDeletedCount uint
 	acmeOrdersDeletedCount   uint
+
+	issuedCertRevokedCount uint
+	issuedCertDeletedCount uint
+
+	deletedEntityCertRevokedCount uint
 }
 
 type tidyConfig struct {
@@ -87,6 +94,10 @@ type tidyConfig struct {
 	CrossRevokedCerts bool `json:"tidy_cross_cluster_revoked_certs"`
 	TidyAcme          bool `json:"tidy_acme"`
 
+	// DeletedEntityCerts revokes the certificates issued to identity
+	// entities which have since been deleted.
+	DeletedEntityCerts bool `json:"tidy_deleted_entity_certs"`
+
 	// Safety Buffers
 	SafetyBuffer            time.Duration `json:"safety_buffer"`
 	IssuerSafetyBuffer      time.Duration `json:"issuer_safety_buffer"`
@@ -97,14 +108,27 @@ type tidyConfig struct {
 	// Metrics.
 	MaintainCount  bool `json:"maintain_stored_certificate_counts"`
 	PublishMetrics bool `json:"publish_stored_certificate_count_metrics"`
+
+	// Targeted cleanup of the certificates matching how they were issued,
+	// which is only available to manual tidies.
+	IssuedCerts         bool              `json:"-"`
+	IssuedCertsRole     string            `json:"-"`
+	IssuedCertsMetadata map[string]string `json:"-"`
+	IssuedCertsEntityID string            `json:"-"`
+	IssuedCertsAction   string            `json:"-"`
 }
 
+const (
+	issuedCertsActionRevoke = "revoke"
+	issuedCertsActionDelete = "delete"
+)
+
 func (tc *tidyConfig) IsAnyTidyEnabled() bool {
-	return tc.CertStore || tc.RevokedCerts || tc.IssuerAssocs || tc.ExpiredIssuers || tc.BackupBundle || tc.TidyAcme || tc.CrossRevokedCerts || tc.RevocationQueue
+	return tc.CertStore || tc.RevokedCerts || tc.IssuerAssocs || tc.ExpiredIssuers || tc.BackupBundle || tc.TidyAcme || tc.CrossRevokedCerts || tc.RevocationQueue || tc.IssuedCerts || tc.DeletedEntityCerts
 }
 
 func (tc *tidyConfig) AnyTidyConfig() string {
-	return "tidy_cert_store / tidy_revoked_certs / tidy_revoked_cert_issuer_associations / tidy_expired_issuers / tidy_move_legacy_ca_bundle / tidy_revocation_queue / tidy_cross_cluster_revoked_certs / tidy_acme"
+	return "tidy_cert_store / tidy_revoked_certs / tidy_revoked_cert_issuer_associations / tidy_expired_issuers / tidy_move_legacy_ca_bundle / tidy_revocation_queue / tidy_cross_cluster_revoked_certs / tidy_acme / tidy_deleted_entity_certs"
 }
 
 var defaultTidyConfig = tidyConfig{
@@ -125,6 +149,7 @@ var defaultTidyConfig = tidyConfig{
 	RevocationQueue:         false,
 	QueueSafetyBuffer:       48 * time.Hour,
 	CrossRevokedCerts:       false,
+	DeletedEntityCerts:      false,
 }
 
 func pathTidy(b *backend) *framework.Path {
@@ -136,7 +161,38 @@ func pathTidy(b *backend) *framework.Path {
 			OperationVerb:   "tidy",
 		},
 
-		Fields: addTidyFields(map[string]*framework.FieldSchema{}),
+		Fields: addTidyFields(map[string]*framework.FieldSchema{
+			"tidy_issued_certs": {
+				Type: framework.TypeBool,
+				Description: `Set to true to revoke or delete the certificates
+issued by issued_cert_role, to issued_cert_entity_id and/or with
+metadata matching issued_cert_metadata, regardless of their expiry. At
+least one of the filters must be set.`,
+				Default: false,
+			},
+			"issued_cert_role": {
+				Type: framework.TypeString,
+				Description: `Only tidy the certificates issued by this role,
+when tidy_issued_certs is set.`,
+			},
+			"issued_cert_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `Only tidy the certificates whose cert_metadata
+contains all of these key/value pairs, when tidy_issued_certs is set.`,
+			},
+			"issued_cert_entity_id": {
+				Type: framework.TypeString,
+				Description: `Only tidy the certificates requested by this
+identity entity, when tidy_issued_certs is set.`,
+			},
+			"issued_cert_action": {
+				Type: framework.TypeString,
+				Description: `What to do with the certificates matched by
+tidy_issued_certs: "revoke" adds them to the CRL, while "delete" removes
+them from the certificate store. Defaults to "revoke".`,
+				Default: issuedCertsActionRevoke,
+			},
+		}),
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
 				Callback: b.pathTidyWrite,
@@ -320,6 +376,31 @@ func pathTidyCancel(b *backend) *framework.Path {
 								Description: `The number of expired, unused acme orders removed`,
 								Required:    false,
 							},
+							"tidy_issued_certs": {
+								Type:        framework.TypeBool,
+								Description: `Tidy certificates matching how they were issued`,
+								Required:    false,
+							},
+							"issued_cert_revoked_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates revoked by tidy_issued_certs`,
+								Required:    false,
+							},
+							"issued_cert_deleted_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates deleted by tidy_issued_certs`,
+								Required:    false,
+							},
+							"tidy_deleted_entity_certs": {
+								Type:        framework.TypeBool,
+								Description: `Revoke the certificates of deleted entities`,
+								Required:    false,
+							},
+							"deleted_entity_cert_revoked_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates of deleted entities revoked`,
+								Required:    false,
+							},
 						},
 					}},
 				},
@@ -498,6 +579,31 @@ func pathTidyStatus(b *backend) *framework.Path {
 								Description: `The number of expired, unused acme orders removed`,
 								Required:    false,
 							},
+							"tidy_issued_certs": {
+								Type:        framework.TypeBool,
+								Description: `Tidy certificates matching how they were issued`,
+								Required:    false,
+							},
+							"issued_cert_revoked_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates revoked by tidy_issued_certs`,
+								Required:    false,
+							},
+							"issued_cert_deleted_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates deleted by tidy_issued_certs`,
+								Required:    false,
+							},
+							"tidy_deleted_entity_certs": {
+								Type:        framework.TypeBool,
+								Description: `Revoke the certificates of deleted entities`,
+								Required:    false,
+							},
+							"deleted_entity_cert_revoked_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of certificates of deleted entities revoked`,
+								Required:    false,
+							},
 						},
 					}},
 				},
@@ -586,6 +692,11 @@ available on the tidy-status endpoint.`,
 								Description: `Tidy Unused Acme Accounts, and Orders`,
 								Required:    true,
 							},
+							"tidy_deleted_entity_certs": {
+								Type:        framework.TypeBool,
+								Description: `Revoke the certificates of deleted entities`,
+								Required:    true,
+							},
 							"safety_buffer": {
 								Type:        framework.TypeInt,
 								Description: `Safety buffer time duration`,
@@ -679,6 +790,11 @@ available on the tidy-status endpoint.`,
 								Description: `Tidy Unused Acme Accounts, and Orders`,
 								Required:    true,
 							},
+							"tidy_deleted_entity_certs": {
+								Type:        framework.TypeBool,
+								Description: `Revoke the certificates of deleted entities`,
+								Required:    true,
+							},
 							"safety_buffer": {
 								Type:        framework.TypeInt,
 								Description: `Safety buffer time duration`,
@@ -752,6 +868,12 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 	tidyCrossRevokedCerts := d.Get("tidy_cross_cluster_revoked_certs").(bool)
 	tidyAcme := d.Get("tidy_acme").(bool)
 	acmeAccountSafetyBuffer := d.Get("acme_account_safety_buffer").(int)
+	tidyIssuedCerts := d.Get("tidy_issued_certs").(bool)
+	issuedCertRole := d.Get("issued_cert_role").(string)
+	issuedCertMetadata := d.Get("issued_cert_metadata").(map[string]string)
+	issuedCertEntityID := d.Get("issued_cert_entity_id").(string)
+	tidyDeletedEntityCerts := d.Get("tidy_deleted_entity_certs").(bool)
+	issuedCertAction := d.Get("issued_cert_action").(string)
 
 	if safetyBuffer < 1 {
 		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
@@ -769,6 +891,15 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		return logical.ErrorResponse("acme_account_safety_buffer must be greater than zero"), nil
 	}
 
+	if tidyIssuedCerts {
+		if issuedCertRole == "" && issuedCertEntityID == "" && len(issuedCertMetadata) == 0 {
+			return logical.ErrorResponse("tidy_issued_certs requires issued_cert_role, issued_cert_entity_id and/or issued_cert_metadata to be set"), nil
+		}
+		if issuedCertAction != issuedCertsActionRevoke && issuedCertAction != issuedCertsActionDelete {
+			return logical.ErrorResponse(fmt.Sprintf("issued_cert_action must be %q or %q", issuedCertsActionRevoke, issuedCertsActionDelete)), nil
+		}
+	}
+
 	if pauseDurationStr != "" {
 		var err error
 		pauseDuration, err = parseutil.ParseDurationSecond(pauseDurationStr)
@@ -803,6 +934,12 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		CrossRevokedCerts:       tidyCrossRevokedCerts,
 		TidyAcme:                tidyAcme,
 		AcmeAccountSafetyBuffer: acmeAccountSafetyBufferDuration,
+		IssuedCerts:             tidyIssuedCerts,
+		IssuedCertsRole:         issuedCertRole,
+		IssuedCertsMetadata:     issuedCertMetadata,
+		IssuedCertsEntityID:     issuedCertEntityID,
+		IssuedCertsAction:       issuedCertAction,
+		DeletedEntityCerts:      tidyDeletedEntityCerts,
 	}
 
 	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
@@ -929,6 +1066,28 @@ func (b *backend) startTidyOperation(req *logical.Request, config *tidyConfig) {
 				}
 			}
 
+			// Check for cancel before continuing.
+			if atomic.CompareAndSwapUint32(b.tidyCancelCAS, 1, 0) {
+				return tidyCancelledError
+			}
+
+			if config.IssuedCerts {
+				if err := b.doTidyIssuedCerts(ctx, req, logger, config); err != nil {
+					return err
+				}
+			}
+
+			// Check for cancel before continuing.
+			if atomic.CompareAndSwapUint32(b.tidyCancelCAS, 1, 0) {
+				return tidyCancelledError
+			}
+
+			if config.DeletedEntityCerts {
+				if err := b.doTidyDeletedEntityCerts(ctx, req, logger, config); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		}
 
@@ -1002,6 +1161,9 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 				return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
 			}
+			if err := req.Storage.Delete(ctx, certMetadataPath+serial); err != nil {
+				return fmt.Errorf("error deleting metadata of serial %q from storage: %w", serial, err)
+			}
 			b.tidyStatusIncCertStoreCount()
 		}
 	}
@@ -1013,6 +1175,199 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 	return nil
 }
 
+// doTidyIssuedCerts revokes or deletes the certificates matching the role and
+// metadata filters. Only certificates issued by a role or with metadata have
+// an entry to match against, so their index is walked rather than the whole
+// certificate store.
+func (b *backend) doTidyIssuedCerts(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	serials, err := req.Storage.List(ctx, certMetadataPath)
+	if err != nil {
+		return fmt.Errorf("error fetching list of certificate metadata: %w", err)
+	}
+
+	var crlConf *crlConfig
+	if config.IssuedCertsAction == issuedCertsActionRevoke {
+		crlConf, err = b.CrlBuilder().getConfigWithUpdate(sc)
+		if err != nil {
+			return fmt.Errorf("error fetching CRL config: %w", err)
+		}
+	}
+
+	serialCount := len(serials)
+	for i, serial := range serials {
+		b.tidyStatusMessage(fmt.Sprintf("Tidying issued certificates: checking entry %d of %d", i, serialCount))
+
+		// Check for cancel before continuing.
+		if atomic.CompareAndSwapUint32(b.tidyCancelCAS, 1, 0) {
+			return tidyCancelledError
+		}
+
+		// Check for pause duration to reduce resource consumption.
+		if config.PauseDuration > (0 * time.Second) {
+			time.Sleep(config.PauseDuration)
+		}
+
+		metadataEntry, err := sc.fetchCertMetadata(serial)
+		if err != nil {
+			return fmt.Errorf("error fetching metadata of certificate %q: %w", serial, err)
+		}
+		if metadataEntry == nil || !metadataEntry.matches(config.IssuedCertsRole, config.IssuedCertsEntityID, config.IssuedCertsMetadata) {
+			continue
+		}
+
+		certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+		if err != nil {
+			return fmt.Errorf("error fetching certificate %q: %w", serial, err)
+		}
+		if certEntry == nil || len(certEntry.Value) == 0 {
+			// The certificate was already tidied, leaving its metadata behind
+			if err := req.Storage.Delete(ctx, certMetadataPath+serial); err != nil {
+				return fmt.Errorf("error deleting metadata of serial %q from storage: %w", serial, err)
+			}
+			continue
+		}
+
+		switch config.IssuedCertsAction {
+		case issuedCertsActionDelete:
+			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
+			}
+			if err := req.Storage.Delete(ctx, certMetadataPath+serial); err != nil {
+				return fmt.Errorf("error deleting metadata of serial %q from storage: %w", serial, err)
+			}
+			logger.Debug("deleted issued certificate", "serial", serial, "role", metadataEntry.Role)
+			b.tidyStatusIncIssuedCertDeletedCount()
+
+		case issuedCertsActionRevoke:
+			cert, err := x509.ParseCertificate(certEntry.Value)
+			if err != nil {
+				return fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
+			}
+			if time.Now().After(cert.NotAfter) {
+				continue
+			}
+
+			revoked, err := b.tidyRevokeIssuedCert(sc, crlConf, cert)
+			if err != nil {
+				return fmt.Errorf("error revoking certificate %q: %w", serial, err)
+			}
+			if revoked {
+				logger.Debug("revoked issued certificate", "serial", serial, "role", metadataEntry.Role)
+				b.tidyStatusIncIssuedCertRevokedCount()
+			}
+		}
+	}
+
+	return nil
+}
+
+// doTidyDeletedEntityCerts revokes the unexpired certificates issued to
+// identity entities which no longer exist, so that the certificates of
+// offboarded entities are revoked along with their other credentials.
+func (b *backend) doTidyDeletedEntityCerts(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	serials, err := req.Storage.List(ctx, certMetadataPath)
+	if err != nil {
+		return fmt.Errorf("error fetching list of certificate metadata: %w", err)
+	}
+
+	crlConf, err := b.CrlBuilder().getConfigWithUpdate(sc)
+	if err != nil {
+		return fmt.Errorf("error fetching CRL config: %w", err)
+	}
+
+	// Entities usually hold several certificates, so whether they still
+	// exist is only looked up once.
+	deletedEntities := make(map[string]bool)
+
+	serialCount := len(serials)
+	for i, serial := range serials {
+		b.tidyStatusMessage(fmt.Sprintf("Tidying certificates of deleted entities: checking entry %d of %d", i, serialCount))
+
+		// Check for cancel before continuing.
+		if atomic.CompareAndSwapUint32(b.tidyCancelCAS, 1, 0) {
+			return tidyCancelledError
+		}
+
+		// Check for pause duration to reduce resource consumption.
+		if config.PauseDuration > (0 * time.Second) {
+			time.Sleep(config.PauseDuration)
+		}
+
+		metadataEntry, err := sc.fetchCertMetadata(serial)
+		if err != nil {
+			return fmt.Errorf("error fetching metadata of certificate %q: %w", serial, err)
+		}
+		if metadataEntry == nil || metadataEntry.EntityID == "" {
+			continue
+		}
+
+		deleted, ok := deletedEntities[metadataEntry.EntityID]
+		if !ok {
+			entity, err := b.System().EntityInfo(metadataEntry.EntityID)
+			if err != nil {
+				return fmt.Errorf("error looking up entity %q: %w", metadataEntry.EntityID, err)
+			}
+			deleted = entity == nil
+			deletedEntities[metadataEntry.EntityID] = deleted
+		}
+		if !deleted {
+			continue
+		}
+
+		certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+		if err != nil {
+			return fmt.Errorf("error fetching certificate %q: %w", serial, err)
+		}
+		if certEntry == nil || len(certEntry.Value) == 0 {
+			continue
+		}
+		cert, err := x509.ParseCertificate(certEntry.Value)
+		if err != nil {
+			return fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
+		}
+		if time.Now().After(cert.NotAfter) {
+			continue
+		}
+
+		revoked, err := b.tidyRevokeIssuedCert(sc, crlConf, cert)
+		if err != nil {
+			return fmt.Errorf("error revoking certificate %q: %w", serial, err)
+		}
+		if revoked {
+			logger.Debug("revoked certificate of deleted entity", "serial", serial, "entity_id", metadataEntry.EntityID)
+			b.tidyStatusIncDeletedEntityCertRevokedCount()
+		}
+	}
+
+	return nil
+}
+
+// tidyRevokeIssuedCert revokes the certificate, unless it is already revoked,
+// returning whether it was.
+func (b *backend) tidyRevokeIssuedCert(sc *storageContext, config *crlConfig, cert *x509.Certificate) (bool, error) {
+	b.GetRevokeStorageLock().Lock()
+	defer b.GetRevokeStorageLock().Unlock()
+
+	revInfo, err := sc.fetchRevocationInfo(serialFromCert(cert))
+	if err != nil {
+		return false, err
+	}
+	if revInfo != nil {
+		return false, nil
+	}
+
+	resp, err := revokeCert(sc, config, cert)
+	if err != nil {
+		return false, err
+	}
+	if resp != nil && resp.IsError() {
+		return false, resp.Error()
+	}
+	return true, nil
+}
+
 func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
 	b.GetRevokeStorageLock().Lock()
 	defer b.GetRevokeStorageLock().Unlock()
@@ -1113,6 +1468,9 @@ func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Reques
 				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 					return fmt.Errorf("error deleting serial %q from store when tidying revoked: %w", serial, err)
 				}
+				if err := req.Storage.Delete(ctx, certMetadataPath+serial); err != nil {
+					return fmt.Errorf("error deleting metadata of serial %q when tidying revoked: %w", serial, err)
+				}
 				rebuildCRL = true
 				storeCert = false
 				b.tidyStatusIncRevokedCertCount()
@@ -1665,6 +2023,11 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 			"acme_account_revoked_count":            nil,
 			"acme_orders_deleted_count":             nil,
 			"acme_account_safety_buffer":            nil,
+			"tidy_issued_certs":                     nil,
+			"issued_cert_revoked_count":             nil,
+			"issued_cert_deleted_count":             nil,
+			"tidy_deleted_entity_certs":             nil,
+			"deleted_entity_cert_revoked_count":     nil,
 		},
 	}
 
@@ -1713,6 +2076,11 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 	resp.Data["acme_account_revoked_count"] = b.tidyStatus.acmeAccountsRevokedCount
 	resp.Data["acme_orders_deleted_count"] = b.tidyStatus.acmeOrdersDeletedCount
 	resp.Data["acme_account_safety_buffer"] = b.tidyStatus.acmeAccountSafetyBuffer
+	resp.Data["tidy_issued_certs"] = b.tidyStatus.tidyIssuedCerts
+	resp.Data["issued_cert_revoked_count"] = b.tidyStatus.issuedCertRevokedCount
+	resp.Data["issued_cert_deleted_count"] = b.tidyStatus.issuedCertDeletedCount
+	resp.Data["tidy_deleted_entity_certs"] = b.tidyStatus.tidyDeletedEntityCerts
+	resp.Data["deleted_entity_cert_revoked_count"] = b.tidyStatus.deletedEntityCertRevokedCount
 
 	switch b.tidyStatus.state {
 	case tidyStatusStarted:
@@ -1838,6 +2206,10 @@ func (b *backend) pathConfigAutoTidyWrite(ctx context.Context, req *logical.Requ
 		}
 	}
 
+	if deletedEntityCertsRaw, ok := d.GetOk("tidy_deleted_entity_certs"); ok {
+		config.DeletedEntityCerts = deletedEntityCertsRaw.(bool)
+	}
+
 	if config.Enabled && !config.IsAnyTidyEnabled() {
 		return logical.ErrorResponse("Auto-tidy enabled but no tidy operations were requested. Enable at least one tidy operation to be run (" + config.AnyTidyConfig() + ")."), nil
 	}
@@ -1880,6 +2252,8 @@ func (b *backend) tidyStatusStart(config *tidyConfig) {
 		tidyRevocationQueue:     config.RevocationQueue,
 		tidyCrossRevokedCerts:   config.CrossRevokedCerts,
 		tidyAcme:                config.TidyAcme,
+		tidyIssuedCerts:         config.IssuedCerts,
+		tidyDeletedEntityCerts:  config.DeletedEntityCerts,
 		pauseDuration:           config.PauseDuration.String(),
 
 		state:       tidyStatusStarted,
@@ -1931,6 +2305,29 @@ func (b *backend) tidyStatusIncCertStoreCount() {
 	b.GetCertificateCounter().DecrementTotalCertificatesCountReport()
 }
 
+func (b *backend) tidyStatusIncIssuedCertRevokedCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.issuedCertRevokedCount++
+}
+
+func (b *backend) tidyStatusIncDeletedEntityCertRevokedCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.deletedEntityCertRevokedCount++
+}
+
+func (b *backend) tidyStatusIncIssuedCertDeletedCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.issuedCertDeletedCount++
+
+	b.GetCertificateCounter().DecrementTotalCertificatesCountReport()
+}
+
 func (b *backend) tidyStatusIncRevokedCertCount() {
 	b.tidyStatusLock.Lock()
 	defer b.tidyStatusLock.Unlock()
@@ -1995,6 +2392,14 @@ For safety, this function is a noop if called without parameters; cleanup from
 normal certificate storage must be enabled with 'tidy_cert_store' and cleanup
 from revocation information must be enabled with 'tidy_revocation_list'.
 
+Certificates can also be cleaned up by how they were issued, rather than by
+their expiry: with 'tidy_issued_certs', the certificates issued by the role
+'issued_cert_role', to the identity entity 'issued_cert_entity_id' and/or with
+the metadata 'issued_cert_metadata' are revoked, or deleted from the
+certificate store if 'issued_cert_action' is "delete". With
+'tidy_deleted_entity_certs', the certificates issued to identity entities which
+have since been deleted are revoked.
+
 The 'safety_buffer' parameter is useful to ensure that clock skew amongst your
 hosts cannot lead to a certificate being removed from the CRL while it is still
 considered valid by other hosts (for instance, if their clocks are a few
@@ -2057,6 +2462,8 @@ The result includes the following fields:
 * 'acme_account_deleted_count': the number of revoked acme accounts deleted during the operation
 * 'acme_account_revoked_count': the number of acme accounts revoked during the operation
 * 'acme_orders_deleted_count': the number of acme orders deleted during the operation
+* 'tidy_deleted_entity_certs': the value of this parameter when initiating the tidy operation
+* 'deleted_entity_cert_revoked_count': the number of certificates of deleted entities revoked during the operation
 `
 
 const pathConfigAutoTidySyn = `
@@ -2084,6 +2491,7 @@ func getTidyConfigData(config tidyConfig) map[string]interface{} {
 		"tidy_expired_issuers":                     config.ExpiredIssuers,
 		"tidy_move_legacy_ca_bundle":               config.BackupBundle,
 		"tidy_acme":                                config.TidyAcme,
+		"tidy_deleted_entity_certs":                config.DeletedEntityCerts,
 		"safety_buffer":                            int(config.SafetyBuffer / time.Second),
 		"issuer_safety_buffer":                     int(config.IssuerSafetyBuffer / time.Second),
 		"acme_account_safety_buffer":               int(config.AcmeAccountSafetyBuffer / time.Second),
@@ -530,6 +530,170 @@ func TestTidyIssuers(t *testing.T) {
 	require.Equal(t, statusResp.Data["tidy_expired_issuers"], true)
 }
 
+// TestTidyIssuedCerts verifies that tidy_issued_certs revokes or deletes only
+// the certificates matching the role and metadata filters.
+func TestTidyIssuedCerts(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	for _, role := range []string{"svc-a", "svc-b"} {
+		_, err = CBWrite(b, s, "roles/"+role, map[string]interface{}{
+			"allow_any_name": true,
+			"key_type":       "ec",
+		})
+		require.NoError(t, err)
+	}
+
+	issue := func(role, owner string) string {
+		resp, err := CBWrite(b, s, "issue/"+role, map[string]interface{}{
+			"common_name":   "host.example.com",
+			"cert_metadata": "owner=" + owner,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["serial_number"].(string)
+	}
+	legacyA1 := issue("svc-a", "legacy")
+	legacyA2 := issue("svc-a", "legacy")
+	otherA := issue("svc-a", "other")
+	legacyB := issue("svc-b", "legacy")
+
+	resp, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_issued_certs": true,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected tidy_issued_certs without filters to be rejected")
+
+	resp, err = CBRead(b, s, "cert-metadata/"+legacyA1)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "svc-a", resp.Data["role"])
+
+	waitForTidy := func() map[string]interface{} {
+		var status map[string]interface{}
+		require.Eventually(t, func() bool {
+			resp, err := CBRead(b, s, "tidy-status")
+			require.NoError(t, err)
+			status = resp.Data
+			return status["state"] == "Finished"
+		}, 10*time.Second, 50*time.Millisecond)
+		return status
+	}
+	revoked := func(serial string) bool {
+		resp, err := CBRead(b, s, "cert/"+serial)
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["revocation_time"].(int64) > 0
+	}
+
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_issued_certs":    true,
+		"issued_cert_role":     "svc-a",
+		"issued_cert_metadata": "owner=legacy",
+	})
+	require.NoError(t, err)
+	status := waitForTidy()
+	require.Equal(t, uint(2), status["issued_cert_revoked_count"])
+	require.True(t, revoked(legacyA1))
+	require.True(t, revoked(legacyA2))
+	require.False(t, revoked(otherA))
+	require.False(t, revoked(legacyB))
+
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_issued_certs":  true,
+		"issued_cert_role":   "svc-b",
+		"issued_cert_action": "delete",
+	})
+	require.NoError(t, err)
+	status = waitForTidy()
+	require.Equal(t, uint(1), status["issued_cert_deleted_count"])
+
+	resp, err = CBRead(b, s, "cert/"+legacyB)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	resp, err = CBRead(b, s, "cert-metadata/"+legacyB)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestTidyIssuedCerts_Entity(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "roles/svc", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+	})
+	require.NoError(t, err)
+
+	issue := func(entityID string) string {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "issue/svc",
+			Data:       map[string]interface{}{"common_name": "host.example.com"},
+			Storage:    s,
+			MountPoint: "pki/",
+			EntityID:   entityID,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["serial_number"].(string)
+	}
+	entityA1 := issue("entity-a")
+	entityA2 := issue("entity-a")
+	entityB := issue("entity-b")
+	noEntity := issue("")
+
+	resp, err = CBRead(b, s, "cert-metadata/"+entityA1)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "entity-a", resp.Data["entity_id"])
+
+	waitForTidy := func() map[string]interface{} {
+		var status map[string]interface{}
+		require.Eventually(t, func() bool {
+			resp, err := CBRead(b, s, "tidy-status")
+			require.NoError(t, err)
+			status = resp.Data
+			return status["state"] == "Finished"
+		}, 10*time.Second, 50*time.Millisecond)
+		return status
+	}
+	revoked := func(serial string) bool {
+		resp, err := CBRead(b, s, "cert/"+serial)
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["revocation_time"].(int64) > 0
+	}
+
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_issued_certs":     true,
+		"issued_cert_entity_id": "entity-a",
+	})
+	require.NoError(t, err)
+	status := waitForTidy()
+	require.Equal(t, uint(2), status["issued_cert_revoked_count"])
+	require.True(t, revoked(entityA1))
+	require.True(t, revoked(entityA2))
+	require.False(t, revoked(entityB))
+
+	// The test system view knows no entity, so entity-b counts as deleted
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_deleted_entity_certs": true,
+	})
+	require.NoError(t, err)
+	status = waitForTidy()
+	require.Equal(t, uint(1), status["deleted_entity_cert_revoked_count"])
+	require.True(t, revoked(entityB))
+	require.False(t, revoked(noEntity))
+}
+
 func TestTidyIssuerConfig(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,629 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package scep
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// This file implements the subset of PKCS #7 (RFC 2315) needed by SCEP:
+// parsing and verifying SignedData, decrypting EnvelopedData with RSA key
+// transport, and creating both for responses.
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	oidDigestSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidDigestSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidDigestSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidDigestSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA1WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+	oidEncryptionDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidEncryptionAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidEncryptionAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidEncryptionAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []recipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type recipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// signedMessage is a parsed and verified SignedData.
+type signedMessage struct {
+	content    []byte
+	signer     *x509.Certificate
+	attributes []attribute
+}
+
+// parseContentInfo parses a ContentInfo of the expected type and returns its
+// content.
+func parseContentInfo(der []byte, contentType asn1.ObjectIdentifier) ([]byte, error) {
+	var info contentInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing content info: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after content info")
+	}
+	if !info.ContentType.Equal(contentType) {
+		return nil, fmt.Errorf("unexpected content type %s", info.ContentType)
+	}
+	return info.Content.Bytes, nil
+}
+
+// parseSignedData parses a ContentInfo holding SignedData and verifies the
+// signature of its single signer, whose certificate must be included.
+func parseSignedData(der []byte) (*signedMessage, error) {
+	content, err := parseContentInfo(der, oidSignedData)
+	if err != nil {
+		return nil, err
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(content, &sd); err != nil {
+		return nil, fmt.Errorf("error parsing signed data: %w", err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("expected exactly one signer, found %d", len(sd.SignerInfos))
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificates: %w", err)
+	}
+
+	var data []byte
+	if len(sd.ContentInfo.Content.Bytes) > 0 {
+		data, err = unmarshalOctetString(sd.ContentInfo.Content.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing signed content: %w", err)
+		}
+	}
+
+	si := sd.SignerInfos[0]
+	var signer *x509.Certificate
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, si.IssuerAndSerialNumber.IssuerName.FullBytes) &&
+			cert.SerialNumber.Cmp(si.IssuerAndSerialNumber.SerialNumber) == 0 {
+			signer = cert
+			break
+		}
+	}
+	if signer == nil {
+		return nil, errors.New("signer certificate not found")
+	}
+
+	hash, err := hashForOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &signedMessage{
+		content: data,
+		signer:  signer,
+	}
+
+	signed := data
+	if len(si.AuthenticatedAttributes.FullBytes) > 0 {
+		msg.attributes, err = parseAttributes(si.AuthenticatedAttributes.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := msg.attribute(oidAttributeMessageDigest)
+		if err != nil {
+			return nil, err
+		}
+		var expected []byte
+		if _, err := asn1.Unmarshal(digest, &expected); err != nil {
+			return nil, fmt.Errorf("error parsing message digest: %w", err)
+		}
+		h := hash.New()
+		h.Write(data)
+		if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+			return nil, errors.New("message digest does not match content")
+		}
+
+		// The signature is computed over the DER encoding of the attributes
+		// as a SET OF, rather than with their implicit [0] tag
+		signed = append([]byte{0x31}, si.AuthenticatedAttributes.FullBytes[1:]...)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	if err := verifySignature(signer.PublicKey, hash, h.Sum(nil), si.EncryptedDigest); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// attribute returns the single value of an authenticated attribute.
+func (m *signedMessage) attribute(oid asn1.ObjectIdentifier) ([]byte, error) {
+	for _, attr := range m.attributes {
+		if attr.Type.Equal(oid) {
+			var value asn1.RawValue
+			rest, err := asn1.Unmarshal(attr.Value.Bytes, &value)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing attribute %s: %w", oid, err)
+			}
+			if len(rest) > 0 {
+				return nil, fmt.Errorf("attribute %s has multiple values", oid)
+			}
+			return value.FullBytes, nil
+		}
+	}
+	return nil, fmt.Errorf("attribute %s not found", oid)
+}
+
+func parseAttributes(der []byte) ([]attribute, error) {
+	var attrs []attribute
+	for len(der) > 0 {
+		var attr attribute
+		rest, err := asn1.Unmarshal(der, &attr)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing attributes: %w", err)
+		}
+		attrs = append(attrs, attr)
+		der = rest
+	}
+	return attrs, nil
+}
+
+// unmarshalOctetString returns the contents of an OCTET STRING, which BER
+// encoders may have split into constructed segments.
+func unmarshalOctetString(der []byte) ([]byte, error) {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, err
+	}
+	return octetStringContents(raw)
+}
+
+func octetStringContents(raw asn1.RawValue) ([]byte, error) {
+	if !raw.IsCompound {
+		return raw.Bytes, nil
+	}
+
+	var out []byte
+	rest := raw.Bytes
+	for len(rest) > 0 {
+		var segment asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &segment)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := octetStringContents(segment)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, contents...)
+	}
+	return out, nil
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidDigestSHA1), oid.Equal(oidSHA1WithRSA):
+		return crypto.SHA1, nil
+	case oid.Equal(oidDigestSHA256), oid.Equal(oidSHA256WithRSA):
+		return crypto.SHA256, nil
+	case oid.Equal(oidDigestSHA384), oid.Equal(oidSHA384WithRSA):
+		return crypto.SHA384, nil
+	case oid.Equal(oidDigestSHA512), oid.Equal(oidSHA512WithRSA):
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported digest algorithm %s", oid)
+	}
+}
+
+func verifySignature(pub crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, signature) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signer key type %T", pub)
+	}
+	return nil
+}
+
+// signData creates a ContentInfo holding SignedData over content, signed by
+// cert and key with the given authenticated attributes. The content is
+// omitted if nil.
+func signData(content []byte, cert *x509.Certificate, key crypto.Signer, certs []*x509.Certificate, attrs []attribute) ([]byte, error) {
+	hash := crypto.SHA256
+	h := hash.New()
+	h.Write(content)
+
+	messageDigest, err := asn1.Marshal(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := asn1.Marshal(oidData)
+	if err != nil {
+		return nil, err
+	}
+	signingTime, err := asn1.Marshal(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	attrs = append(attrs,
+		newAttribute(oidAttributeContentType, contentType),
+		newAttribute(oidAttributeMessageDigest, messageDigest),
+		newAttribute(oidAttributeSigningTime, signingTime),
+	)
+
+	// DER requires the elements of a SET OF to be sorted by their encoding,
+	// and verifiers re-encode the attributes before checking the signature.
+	encoded := make([][]byte, 0, len(attrs))
+	for _, attr := range attrs {
+		der, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, der)
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	attrBytes := bytes.Join(encoded, nil)
+
+	signed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrBytes})
+	if err != nil {
+		return nil, err
+	}
+	h = hash.New()
+	h.Write(signed)
+	signature, err := key.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var signatureAlgorithm asn1.ObjectIdentifier
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		signatureAlgorithm = oidRSAEncryption
+	case *ecdsa.PublicKey:
+		signatureAlgorithm = oidECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", key.Public())
+	}
+
+	info := contentInfo{ContentType: oidData}
+	if content != nil {
+		octets, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		info.Content = explicitContent(octets)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidDigestSHA256, Parameters: asn1.NullRawValue}},
+		ContentInfo:      info,
+		Certificates:     rawCertificates(append([]*x509.Certificate{cert}, certs...)),
+		SignerInfos: []signerInfo{
+			{
+				Version: 1,
+				IssuerAndSerialNumber: issuerAndSerial{
+					IssuerName:   asn1.RawValue{FullBytes: cert.RawIssuer},
+					SerialNumber: cert.SerialNumber,
+				},
+				DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidDigestSHA256, Parameters: asn1.NullRawValue},
+				AuthenticatedAttributes:   asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrBytes},
+				DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureAlgorithm},
+				EncryptedDigest:           signature,
+			},
+		},
+	}
+	if signatureAlgorithm.Equal(oidRSAEncryption) {
+		sd.SignerInfos[0].DigestEncryptionAlgorithm.Parameters = asn1.NullRawValue
+	}
+
+	return wrapContentInfo(oidSignedData, sd)
+}
+
+// degenerateCertificates creates a ContentInfo holding certificates-only
+// SignedData, used to transport certificate chains.
+func degenerateCertificates(certs []*x509.Certificate) ([]byte, error) {
+	return wrapContentInfo(oidSignedData, signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		Certificates:     rawCertificates(certs),
+		SignerInfos:      []signerInfo{},
+	})
+}
+
+func newAttribute(oid asn1.ObjectIdentifier, value []byte) attribute {
+	return attribute{
+		Type:  oid,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+	}
+}
+
+func rawCertificates(certs []*x509.Certificate) asn1.RawValue {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		buf.Write(cert.Raw)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: buf.Bytes()}
+}
+
+func explicitContent(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func wrapContentInfo(contentType asn1.ObjectIdentifier, content interface{}) ([]byte, error) {
+	der, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: contentType,
+		Content:     explicitContent(der),
+	})
+}
+
+// decryptEnvelopedData decrypts a ContentInfo holding EnvelopedData for the
+// recipient, and returns the plaintext and the content encryption algorithm.
+func decryptEnvelopedData(der []byte, recipient *x509.Certificate, key crypto.Decrypter) ([]byte, asn1.ObjectIdentifier, error) {
+	content, err := parseContentInfo(der, oidEnvelopedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ed envelopedData
+	if _, err := asn1.Unmarshal(content, &ed); err != nil {
+		return nil, nil, fmt.Errorf("error parsing enveloped data: %w", err)
+	}
+
+	var ri *recipientInfo
+	for i := range ed.RecipientInfos {
+		candidate := &ed.RecipientInfos[i]
+		if bytes.Equal(candidate.IssuerAndSerialNumber.IssuerName.FullBytes, recipient.RawIssuer) &&
+			candidate.IssuerAndSerialNumber.SerialNumber.Cmp(recipient.SerialNumber) == 0 {
+			ri = candidate
+			break
+		}
+	}
+	if ri == nil {
+		return nil, nil, errors.New("message is not encrypted for this CA")
+	}
+	if !ri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAEncryption) {
+		return nil, nil, fmt.Errorf("unsupported key encryption algorithm %s", ri.KeyEncryptionAlgorithm.Algorithm)
+	}
+
+	contentKey, err := key.Decrypt(rand.Reader, ri.EncryptedKey, &rsa.PKCS1v15DecryptOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decrypting content key: %w", err)
+	}
+
+	eci := ed.EncryptedContentInfo
+	alg := eci.ContentEncryptionAlgorithm.Algorithm
+	block, err := newBlockCipher(alg, contentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, fmt.Errorf("error parsing encryption parameters: %w", err)
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, nil, errors.New("invalid initialization vector")
+	}
+
+	ciphertext, err := octetStringContents(eci.EncryptedContent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing encrypted content: %w", err)
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, nil, errors.New("invalid encrypted content length")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	plaintext, err = unpad(plaintext, block.BlockSize())
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, alg, nil
+}
+
+// encryptEnvelopedData creates a ContentInfo holding EnvelopedData of
+// content, encrypted with the given algorithm for the RSA key of recipient.
+func encryptEnvelopedData(content []byte, recipient *x509.Certificate, alg asn1.ObjectIdentifier) ([]byte, error) {
+	pub, ok := recipient.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported recipient key type %T", recipient.PublicKey)
+	}
+
+	keySize, err := keySizeForAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	contentKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, contentKey); err != nil {
+		return nil, err
+	}
+	block, err := newBlockCipher(alg, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, block.BlockSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	plaintext := pad(content, block.BlockSize())
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapContentInfo(oidEnvelopedData, envelopedData{
+		Version: 0,
+		RecipientInfos: []recipientInfo{
+			{
+				Version: 0,
+				IssuerAndSerialNumber: issuerAndSerial{
+					IssuerName:   asn1.RawValue{FullBytes: recipient.RawIssuer},
+					SerialNumber: recipient.SerialNumber,
+				},
+				KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+				EncryptedKey:           encryptedKey,
+			},
+		},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: alg, Parameters: asn1.RawValue{FullBytes: ivParam}},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+		},
+	})
+}
+
+func keySizeForAlgorithm(alg asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case alg.Equal(oidEncryptionDESEDE3CBC):
+		return 24, nil
+	case alg.Equal(oidEncryptionAES128CBC):
+		return 16, nil
+	case alg.Equal(oidEncryptionAES192CBC):
+		return 24, nil
+	case alg.Equal(oidEncryptionAES256CBC):
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("unsupported content encryption algorithm %s", alg)
+	}
+}
+
+func newBlockCipher(alg asn1.ObjectIdentifier, key []byte) (cipher.Block, error) {
+	size, err := keySizeForAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != size {
+		return nil, errors.New("invalid content key length")
+	}
+	if alg.Equal(oidEncryptionDESEDE3CBC) {
+		return des.NewTripleDESCipher(key)
+	}
+	return aes.NewCipher(key)
+}
+
+func pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,304 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package scep implements the message format of the Simple Certificate
+// Enrollment Protocol (RFC 8894).
+package scep
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MessageType is the type of a SCEP message.
+type MessageType string
+
+const (
+	CertRep    MessageType = "3"
+	RenewalReq MessageType = "17"
+	PKCSReq    MessageType = "19"
+	CertPoll   MessageType = "20"
+	GetCert    MessageType = "21"
+	GetCRL     MessageType = "22"
+)
+
+// PKIStatus is the status of a CertRep message.
+type PKIStatus string
+
+const (
+	StatusSuccess PKIStatus = "0"
+	StatusFailure PKIStatus = "2"
+	StatusPending PKIStatus = "3"
+)
+
+// FailInfo is the reason for a failed request.
+type FailInfo string
+
+const (
+	BadAlg          FailInfo = "0"
+	BadMessageCheck FailInfo = "1"
+	BadRequest      FailInfo = "2"
+	BadTime         FailInfo = "3"
+	BadCertID       FailInfo = "4"
+)
+
+var (
+	oidSCEPMessageType    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidSCEPPKIStatus      = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidSCEPFailInfo       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidSCEPSenderNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidSCEPRecipientNonce = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidSCEPTransactionID  = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+)
+
+// Capabilities are the capabilities returned by GetCACaps.
+var Capabilities = []string{
+	"POSTPKIOperation",
+	"SHA-1",
+	"SHA-256",
+	"SHA-512",
+	"AES",
+	"DES3",
+	"SCEPStandard",
+}
+
+const (
+	// ContentTypeCACert is the content type of a single CA certificate.
+	ContentTypeCACert = "application/x-x509-ca-cert"
+
+	// ContentTypeCARACert is the content type of a CA certificate chain.
+	ContentTypeCARACert = "application/x-x509-ca-ra-cert"
+
+	// ContentTypePKIMessage is the content type of PKIOperation responses.
+	ContentTypePKIMessage = "application/x-pki-message"
+)
+
+// PKIMessage is a SCEP request.
+type PKIMessage struct {
+	MessageType   MessageType
+	TransactionID string
+	SenderNonce   []byte
+
+	// SignerCert is the certificate the request is signed with; for initial
+	// enrollment, a self-signed certificate of the key being enrolled.
+	SignerCert *x509.Certificate
+
+	// CSR and ChallengePassword are set by DecryptPKIEnvelope for PKCSReq
+	// and RenewalReq messages.
+	CSR               *x509.CertificateRequest
+	ChallengePassword string
+
+	envelope            []byte
+	encryptionAlgorithm asn1.ObjectIdentifier
+}
+
+// ParsePKIMessage parses a SCEP request and verifies its signature.
+func ParsePKIMessage(der []byte) (*PKIMessage, error) {
+	sm, err := parseSignedData(der)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &PKIMessage{
+		SignerCert: sm.signer,
+		envelope:   sm.content,
+	}
+
+	messageType, err := printableAttribute(sm, oidSCEPMessageType)
+	if err != nil {
+		return nil, err
+	}
+	msg.MessageType = MessageType(messageType)
+
+	msg.TransactionID, err = printableAttribute(sm, oidSCEPTransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := sm.attribute(oidSCEPSenderNonce)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := asn1.Unmarshal(nonce, &msg.SenderNonce); err != nil {
+		return nil, fmt.Errorf("error parsing sender nonce: %w", err)
+	}
+
+	return msg, nil
+}
+
+func printableAttribute(sm *signedMessage, oid asn1.ObjectIdentifier) (string, error) {
+	raw, err := sm.attribute(oid)
+	if err != nil {
+		return "", err
+	}
+	var value asn1.RawValue
+	if _, err := asn1.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("error parsing attribute %s: %w", oid, err)
+	}
+	switch value.Tag {
+	case asn1.TagPrintableString, asn1.TagUTF8String, asn1.TagIA5String:
+		return string(value.Bytes), nil
+	default:
+		return "", fmt.Errorf("attribute %s is not a string", oid)
+	}
+}
+
+// DecryptPKIEnvelope decrypts the envelope of a PKCSReq or RenewalReq
+// message with the key of the CA, and parses the CSR it contains.
+func (m *PKIMessage) DecryptPKIEnvelope(caCert *x509.Certificate, caKey crypto.Decrypter) error {
+	if m.MessageType != PKCSReq && m.MessageType != RenewalReq {
+		return fmt.Errorf("message type %s does not carry a certificate request", m.MessageType)
+	}
+	if len(m.envelope) == 0 {
+		return errors.New("message has no envelope")
+	}
+
+	plaintext, alg, err := decryptEnvelopedData(m.envelope, caCert, caKey)
+	if err != nil {
+		return err
+	}
+	m.encryptionAlgorithm = alg
+
+	m.CSR, err = x509.ParseCertificateRequest(plaintext)
+	if err != nil {
+		return fmt.Errorf("error parsing certificate request: %w", err)
+	}
+	if err := m.CSR.CheckSignature(); err != nil {
+		return fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+
+	m.ChallengePassword, err = challengePassword(m.CSR)
+	return err
+}
+
+// challengePassword returns the challengePassword attribute of a CSR, which
+// the standard library does not expose.
+func challengePassword(csr *x509.CertificateRequest) (string, error) {
+	var tbs struct {
+		Version       int
+		Subject       asn1.RawValue
+		PublicKeyInfo asn1.RawValue
+		Attributes    []struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		} `asn1:"tag:0"`
+	}
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", fmt.Errorf("error parsing certificate request attributes: %w", err)
+	}
+
+	for _, attr := range tbs.Attributes {
+		if !attr.Type.Equal(oidChallengePassword) {
+			continue
+		}
+		if len(attr.Values) != 1 {
+			return "", errors.New("challenge password must have a single value")
+		}
+		switch attr.Values[0].Tag {
+		case asn1.TagPrintableString, asn1.TagUTF8String, asn1.TagIA5String, asn1.TagT61String:
+			return string(attr.Values[0].Bytes), nil
+		default:
+			return "", errors.New("challenge password is not a string")
+		}
+	}
+	return "", nil
+}
+
+// Success returns a CertRep message delivering the issued certificate,
+// encrypted for the requester and signed by the CA.
+func (m *PKIMessage) Success(caCert *x509.Certificate, caKey crypto.Signer, issued *x509.Certificate) ([]byte, error) {
+	certs, err := degenerateCertificates([]*x509.Certificate{issued})
+	if err != nil {
+		return nil, err
+	}
+
+	alg := m.encryptionAlgorithm
+	if alg == nil {
+		alg = oidEncryptionAES256CBC
+	}
+	envelope, err := encryptEnvelopedData(certs, m.SignerCert, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := m.certRepAttributes(StatusSuccess, "")
+	if err != nil {
+		return nil, err
+	}
+	return signData(envelope, caCert, caKey, nil, attrs)
+}
+
+// Failure returns a CertRep message rejecting the request.
+func (m *PKIMessage) Failure(caCert *x509.Certificate, caKey crypto.Signer, info FailInfo) ([]byte, error) {
+	attrs, err := m.certRepAttributes(StatusFailure, info)
+	if err != nil {
+		return nil, err
+	}
+	return signData(nil, caCert, caKey, nil, attrs)
+}
+
+func (m *PKIMessage) certRepAttributes(status PKIStatus, info FailInfo) ([]attribute, error) {
+	senderNonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, senderNonce); err != nil {
+		return nil, err
+	}
+
+	var attrs []attribute
+	add := func(oid asn1.ObjectIdentifier, value interface{}, params string) error {
+		der, err := asn1.MarshalWithParams(value, params)
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, newAttribute(oid, der))
+		return nil
+	}
+
+	if err := add(oidSCEPMessageType, string(CertRep), "printable"); err != nil {
+		return nil, err
+	}
+	if err := add(oidSCEPPKIStatus, string(status), "printable"); err != nil {
+		return nil, err
+	}
+	if status == StatusFailure {
+		if err := add(oidSCEPFailInfo, string(info), "printable"); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(oidSCEPTransactionID, m.TransactionID, "printable"); err != nil {
+		return nil, err
+	}
+	if err := add(oidSCEPSenderNonce, senderNonce, ""); err != nil {
+		return nil, err
+	}
+	if err := add(oidSCEPRecipientNonce, m.SenderNonce, ""); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// CACertificates returns the GetCACert response for the CA and its chain,
+// and its content type.
+func CACertificates(caCert *x509.Certificate, chain []*x509.Certificate) ([]byte, string, error) {
+	if len(chain) == 0 {
+		return caCert.Raw, ContentTypeCACert, nil
+	}
+
+	der, err := degenerateCertificates(append([]*x509.Certificate{caCert}, chain...))
+	if err != nil {
+		return nil, "", err
+	}
+	return der, ContentTypeCARACert, nil
+}
+
+// CertificatesOnly returns a certs-only PKCS #7 SignedData holding the
+// certificates, as distributed in .p7b files.
+func CertificatesOnly(certs []*x509.Certificate) ([]byte, error) {
+	return degenerateCertificates(certs)
+}
@@ -0,0 +1,310 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package scep
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newCert(t *testing.T, cn string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newCSR creates a CSR with a challengePassword attribute, which the
+// standard library cannot create.
+func newCSR(t *testing.T, key *rsa.PrivateKey, cn, challenge string) []byte {
+	t.Helper()
+
+	subject, err := asn1.Marshal(pkix.Name{CommonName: cn}.ToRDNSequence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := asn1.MarshalWithParams(challenge, "printable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attr, err := asn1.Marshal(struct {
+		Type  asn1.ObjectIdentifier
+		Value asn1.RawValue
+	}{oidChallengePassword, asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: password}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbs, err := asn1.Marshal(struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes asn1.RawValue
+	}{
+		0,
+		asn1.RawValue{FullBytes: subject},
+		asn1.RawValue{FullBytes: spki},
+		asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attr},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256(tbs)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := asn1.Marshal(struct {
+		TBS       asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}{
+		asn1.RawValue{FullBytes: tbs},
+		pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue},
+		asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+// newPKCSReq creates a PKCSReq message, as a SCEP client would.
+func newPKCSReq(t *testing.T, csr []byte, caCert *x509.Certificate, signer *x509.Certificate, key crypto.Signer, alg asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	envelope, err := encryptEnvelopedData(csr, caCert, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attrs []attribute
+	for _, a := range []struct {
+		oid    asn1.ObjectIdentifier
+		value  interface{}
+		params string
+	}{
+		{oidSCEPMessageType, string(PKCSReq), "printable"},
+		{oidSCEPTransactionID, "transaction-1", "printable"},
+		{oidSCEPSenderNonce, []byte("0123456789abcdef"), ""},
+	} {
+		der, err := asn1.MarshalWithParams(a.value, a.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attrs = append(attrs, newAttribute(a.oid, der))
+	}
+
+	req, err := signData(envelope, signer, key, nil, attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestPKIOperation(t *testing.T) {
+	caKey := newKey(t)
+	caCert := newCert(t, "SCEP CA", caKey, nil, nil)
+
+	for name, alg := range map[string]asn1.ObjectIdentifier{
+		"aes256": oidEncryptionAES256CBC,
+		"des3":   oidEncryptionDESEDE3CBC,
+	} {
+		t.Run(name, func(t *testing.T) {
+			clientKey := newKey(t)
+			selfSigned := newCert(t, "device", clientKey, nil, nil)
+			req := newPKCSReq(t, newCSR(t, clientKey, "device", "secret"), caCert, selfSigned, clientKey, alg)
+
+			msg, err := ParsePKIMessage(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.MessageType != PKCSReq || msg.TransactionID != "transaction-1" {
+				t.Fatalf("unexpected message: %#v", msg)
+			}
+			if err := msg.DecryptPKIEnvelope(caCert, caKey); err != nil {
+				t.Fatal(err)
+			}
+			if msg.CSR.Subject.CommonName != "device" || msg.ChallengePassword != "secret" {
+				t.Fatalf("unexpected CSR %q with challenge %q", msg.CSR.Subject.CommonName, msg.ChallengePassword)
+			}
+
+			issued := newCert(t, "device", clientKey, caCert, caKey)
+			respDER, err := msg.Success(caCert, caKey, issued)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Process the response as the client would
+			resp, err := parseSignedData(respDER)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.signer.SerialNumber.Cmp(caCert.SerialNumber) != 0 {
+				t.Fatal("expected response to be signed by the CA")
+			}
+			status, err := printableAttribute(resp, oidSCEPPKIStatus)
+			if err != nil || PKIStatus(status) != StatusSuccess {
+				t.Fatalf("expected success status, got %q (%v)", status, err)
+			}
+			nonce, err := resp.attribute(oidSCEPRecipientNonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var recipientNonce []byte
+			if _, err := asn1.Unmarshal(nonce, &recipientNonce); err != nil || string(recipientNonce) != "0123456789abcdef" {
+				t.Fatalf("expected recipient nonce to echo the sender nonce, got %q", recipientNonce)
+			}
+
+			degenerate, gotAlg, err := decryptEnvelopedData(resp.content, selfSigned, clientKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !gotAlg.Equal(alg) {
+				t.Fatalf("expected response to be encrypted with %s, got %s", alg, gotAlg)
+			}
+			content, err := parseContentInfo(degenerate, oidSignedData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sd signedData
+			if _, err := asn1.Unmarshal(content, &sd); err != nil {
+				t.Fatal(err)
+			}
+			certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(certs) != 1 || !certs[0].Equal(issued) {
+				t.Fatal("expected the issued certificate in the response")
+			}
+		})
+	}
+}
+
+func TestPKIOperationFailure(t *testing.T) {
+	caKey := newKey(t)
+	caCert := newCert(t, "SCEP CA", caKey, nil, nil)
+	clientKey := newKey(t)
+	selfSigned := newCert(t, "device", clientKey, nil, nil)
+
+	req := newPKCSReq(t, newCSR(t, clientKey, "device", "secret"), caCert, selfSigned, clientKey, oidEncryptionAES128CBC)
+	msg, err := ParsePKIMessage(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respDER, err := msg.Failure(caCert, caKey, BadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := parseSignedData(respDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := printableAttribute(resp, oidSCEPPKIStatus); PKIStatus(status) != StatusFailure {
+		t.Fatalf("expected failure status, got %q", status)
+	}
+	if info, _ := printableAttribute(resp, oidSCEPFailInfo); FailInfo(info) != BadRequest {
+		t.Fatalf("expected badRequest fail info, got %q", info)
+	}
+	if len(resp.content) != 0 {
+		t.Fatal("expected failure response to carry no content")
+	}
+}
+
+func TestParsePKIMessage_Tampered(t *testing.T) {
+	caKey := newKey(t)
+	caCert := newCert(t, "SCEP CA", caKey, nil, nil)
+	clientKey := newKey(t)
+	selfSigned := newCert(t, "device", clientKey, nil, nil)
+
+	req := newPKCSReq(t, newCSR(t, clientKey, "device", "secret"), caCert, selfSigned, clientKey, oidEncryptionAES256CBC)
+
+	// Flip a bit of the signature, which ends the message
+	req[len(req)-1] ^= 0x01
+	if _, err := ParsePKIMessage(req); err == nil {
+		t.Fatal("expected tampered message to be rejected")
+	}
+
+	// Decrypting with a different CA fails
+	req[len(req)-1] ^= 0x01
+	msg, err := ParsePKIMessage(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey := newKey(t)
+	if err := msg.DecryptPKIEnvelope(newCert(t, "other CA", otherKey, nil, nil), otherKey); err == nil {
+		t.Fatal("expected message encrypted for another CA to be rejected")
+	}
+}
+
+func TestCACertificates(t *testing.T) {
+	rootKey := newKey(t)
+	root := newCert(t, "root", rootKey, nil, nil)
+	intKey := newKey(t)
+	intermediate := newCert(t, "intermediate", intKey, root, rootKey)
+
+	der, contentType, err := CACertificates(root, nil)
+	if err != nil || contentType != ContentTypeCACert || string(der) != string(root.Raw) {
+		t.Fatalf("expected a single DER certificate, got %s (%v)", contentType, err)
+	}
+
+	der, contentType, err = CACertificates(intermediate, []*x509.Certificate{root})
+	if err != nil || contentType != ContentTypeCARACert {
+		t.Fatalf("expected a certificate chain, got %s (%v)", contentType, err)
+	}
+	content, err := parseContentInfo(der, oidSignedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(content, &sd); err != nil {
+		t.Fatal(err)
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) != 2 {
+		t.Fatalf("expected two certificates, got %d (%v)", len(certs), err)
+	}
+}
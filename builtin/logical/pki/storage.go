@@ -265,7 +265,13 @@ func (sc *storageContext) writeIssuer(issuer *issuing.IssuerEntry) error {
 }
 
 func (sc *storageContext) deleteIssuer(id issuing.IssuerID) (bool, error) {
-	return issuing.DeleteIssuer(sc.Context, sc.Storage, id)
+	wasDefault, err := issuing.DeleteIssuer(sc.Context, sc.Storage, id)
+	if err != nil {
+		return wasDefault, err
+	}
+
+	// The CMP endpoint of the issuer goes away along with it.
+	return wasDefault, sc.deleteCmpConfig(id)
 }
 
 func (sc *storageContext) importIssuer(certValue string, issuerName string) (*issuing.IssuerEntry, bool, error) {
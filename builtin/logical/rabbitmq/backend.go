@@ -9,7 +9,9 @@ import (
 	"sync"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/builtin/logical/database/schedule"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
 )
@@ -43,17 +45,25 @@ func Backend() *backend {
 			pathListRoles(&b),
 			pathCreds(&b),
 			pathRoles(&b),
+			pathListStaticRoles(&b),
+			pathStaticRoles(&b),
+			pathStaticCreds(&b),
+			pathRotateStaticRole(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCreds(&b),
 		},
 
-		Clean:       b.resetClient,
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Clean:        b.resetClient,
+		Invalidate:   b.invalidate,
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
 	}
 
+	b.roleLocks = locksutil.CreateLocks()
+	b.schedule = &schedule.DefaultSchedule{}
+
 	return &b
 }
 
@@ -62,6 +72,10 @@ type backend struct {
 
 	client *rabbithole.Client
 	lock   sync.RWMutex
+
+	// roleLocks serializes the rotation of static roles.
+	roleLocks []*locksutil.LockEntry
+	schedule  schedule.Scheduler
 }
 
 // DB returns the database connection.
@@ -134,7 +148,8 @@ func (b *backend) Lease(ctx context.Context, s logical.Storage) (*configLease, e
 }
 
 const backendHelp = `
-The RabbitMQ backend dynamically generates RabbitMQ users.
+The RabbitMQ backend dynamically generates RabbitMQ users, and rotates the
+passwords of existing users bound to static roles.
 
 After mounting this backend, configure it using the endpoints within
 the "config/" path.
@@ -0,0 +1,418 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/schedule"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+)
+
+const (
+	staticRolePrefix = "static-role/"
+
+	// minStaticRotationPeriod matches the interval at which the periodic
+	// function checks static roles for rotation.
+	minStaticRotationPeriod = time.Minute
+)
+
+var _ schedule.Rotator = &backend{}
+
+func pathListStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/?$",
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixRabbitMQ,
+			OperationSuffix: "static-roles",
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathStaticRoleList,
+		},
+		HelpSynopsis:    pathStaticRoleHelpSyn,
+		HelpDescription: pathStaticRoleHelpDesc,
+	}
+}
+
+func pathStaticRoles(b *backend) *framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the static role.",
+		},
+		"username": {
+			Type:        framework.TypeString,
+			Description: "Name of the existing RabbitMQ user whose password Vault manages.",
+		},
+	}
+	for k, v := range schedule.RotationFields() {
+		fields[k] = v
+	}
+
+	return &framework.Path{
+		Pattern: "static-roles/" + framework.GenericNameRegex("name"),
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixRabbitMQ,
+			OperationSuffix: "static-role",
+		},
+		Fields:         fields,
+		ExistenceCheck: b.pathStaticRoleExistenceCheck,
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.CreateOperation: b.pathStaticRoleWrite,
+			logical.UpdateOperation: b.pathStaticRoleWrite,
+			logical.ReadOperation:   b.pathStaticRoleRead,
+			logical.DeleteOperation: b.pathStaticRoleDelete,
+		},
+		HelpSynopsis:    pathStaticRoleHelpSyn,
+		HelpDescription: pathStaticRoleHelpDesc,
+	}
+}
+
+func pathStaticCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-creds/" + framework.GenericNameRegex("name"),
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixRabbitMQ,
+			OperationVerb:   "request",
+			OperationSuffix: "static-role-credentials",
+		},
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathStaticCredsRead,
+		},
+		HelpSynopsis:    pathStaticCredsHelpSyn,
+		HelpDescription: pathStaticCredsHelpDesc,
+	}
+}
+
+func pathRotateStaticRole(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotate-role/" + framework.GenericNameRegex("name"),
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixRabbitMQ,
+			OperationVerb:   "rotate",
+			OperationSuffix: "static-role-credentials",
+		},
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRotateStaticRoleUpdate,
+		},
+		HelpSynopsis:    pathRotateStaticRoleHelpSyn,
+		HelpDescription: pathRotateStaticRoleHelpDesc,
+	}
+}
+
+// staticRoleEntry binds an existing RabbitMQ user to Vault, which rotates its
+// password according to the rotation settings.
+type staticRoleEntry struct {
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Rotation schedule.Rotation `json:"rotation"`
+}
+
+// StaticRole reads the static role configuration from the storage
+func (b *backend) StaticRole(ctx context.Context, s logical.Storage, name string) (*staticRoleEntry, error) {
+	entry, err := s.Get(ctx, staticRolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result staticRoleEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) putStaticRole(ctx context.Context, s logical.Storage, name string, role *staticRoleEntry) error {
+	entry, err := logical.StorageEntryJSON(staticRolePrefix+name, role)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) pathStaticRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	role, err := b.StaticRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+// Lists all the static roles registered with the backend
+func (b *backend) pathStaticRoleList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	roles, err := b.StaticAccounts(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(roles), nil
+}
+
+// Reads an existing static role, without its password
+func (b *backend) pathStaticRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.StaticRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	data := role.Rotation.ResponseData()
+	data["username"] = role.Username
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// Registers a new static role, or changes the rotation settings of an
+// existing one. New static roles have their password rotated immediately so
+// that Vault knows the credentials it hands out.
+func (b *backend) pathStaticRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.StaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	create := role == nil
+	if create {
+		role = &staticRoleEntry{}
+	}
+
+	username := d.Get("username").(string)
+	switch {
+	case create && username == "":
+		return logical.ErrorResponse("missing username"), nil
+	case !create && username != "" && username != role.Username:
+		return logical.ErrorResponse("cannot update static role username"), nil
+	}
+	if create {
+		role.Username = username
+	}
+
+	if err := role.Rotation.Update(b.schedule, d, create, minStaticRotationPeriod); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if create {
+		if err := b.setStaticRolePassword(ctx, req.Storage, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.putStaticRole(ctx, req.Storage, name, role); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Deletes a static role. The RabbitMQ user is left in place with its current
+// password.
+func (b *backend) pathStaticRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return nil, req.Storage.Delete(ctx, staticRolePrefix+name)
+}
+
+// Returns the current credentials of a static role
+func (b *backend) pathStaticCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.StaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown static role: %s", name)), nil
+	}
+
+	data := role.Rotation.ResponseData()
+	data["username"] = role.Username
+	data["password"] = role.Password
+	data["ttl"] = role.Rotation.TTL(b.schedule, time.Now()).Seconds()
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// Rotates the password of a static role on demand
+func (b *backend) pathRotateStaticRoleUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.StaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown static role: %s", name)), nil
+	}
+
+	return nil, b.RotateStaticAccount(ctx, req.Storage, name)
+}
+
+// StaticAccounts lists the static roles for rotation.
+func (b *backend) StaticAccounts(ctx context.Context, s logical.Storage) ([]string, error) {
+	return s.List(ctx, staticRolePrefix)
+}
+
+// StaticAccountRotation returns the rotation settings of a static role.
+func (b *backend) StaticAccountRotation(ctx context.Context, s logical.Storage, name string) (*schedule.Rotation, error) {
+	role, err := b.StaticRole(ctx, s, name)
+	if err != nil || role == nil {
+		return nil, err
+	}
+	return &role.Rotation, nil
+}
+
+// RotateStaticAccount sets a new password for the user of a static role.
+func (b *backend) RotateStaticAccount(ctx context.Context, s logical.Storage, name string) error {
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.StaticRole(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return nil
+	}
+
+	if err := b.setStaticRolePassword(ctx, s, role); err != nil {
+		return err
+	}
+	return b.putStaticRole(ctx, s, name, role)
+}
+
+// setStaticRolePassword generates a new password for the user of role and sets
+// it in RabbitMQ, keeping the tags of the user.
+func (b *backend) setStaticRolePassword(ctx context.Context, s logical.Storage, role *staticRoleEntry) error {
+	config, err := readConfig(ctx, s)
+	if err != nil {
+		return fmt.Errorf("unable to read configuration: %w", err)
+	}
+
+	client, err := b.Client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	user, err := client.GetUser(role.Username)
+	if err != nil {
+		return fmt.Errorf("unable to read user %s: %w", role.Username, err)
+	}
+
+	password, err := b.generatePassword(ctx, config.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.PutUser(role.Username, rabbithole.UserSettings{
+		Password: password,
+		Tags:     user.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the password of user %s: %w", role.Username, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			b.Logger().Error(fmt.Sprintf("unable to close response body: %s", err))
+		}
+	}()
+	if !isIn200s(resp.StatusCode) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("error updating user %s - %d: %s", role.Username, resp.StatusCode, body)
+	}
+
+	role.Password = password
+	role.Rotation.LastVaultRotation = time.Now()
+	return nil
+}
+
+// periodicFunc rotates the passwords of the static roles which are due.
+// Performance secondaries leave the rotation to the primary cluster.
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	replicationState := b.System().ReplicationState()
+	if !b.System().LocalMount() && replicationState.HasState(consts.ReplicationPerformanceSecondary) {
+		return nil
+	}
+
+	return schedule.RotateDue(ctx, req.Storage, b.schedule, b, time.Now())
+}
+
+const pathStaticRoleHelpSyn = `
+Manage the static roles that can be created with this backend.
+`
+
+const pathStaticRoleHelpDesc = `
+This path lets you manage static roles, which bind an existing RabbitMQ user
+to Vault. Vault sets a new password for the user when the static role is
+created, and rotates it either every "rotation_period" or on the cron-style
+"rotation_schedule", optionally restricted to a "rotation_window" after each
+scheduled time.
+
+Deleting a static role stops the rotation; the RabbitMQ user is not removed.
+`
+
+const pathStaticCredsHelpSyn = `
+Request the current credentials of a static role.
+`
+
+const pathStaticCredsHelpDesc = `
+This path returns the username and current password of a static role, along
+with the time remaining until the password is next rotated.
+`
+
+const pathRotateStaticRoleHelpSyn = `
+Rotate the password of a static role.
+`
+
+const pathRotateStaticRoleHelpDesc = `
+This path sets a new password for the user of a static role immediately. The
+next automatic rotation is scheduled from this rotation.
+`
@@ -43,6 +43,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			Unauthenticated: []string{
 				"verify",
 				"public_key",
+				"krl",
 			},
 
 			LocalStorage: []string{
@@ -68,6 +69,11 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			pathIssue(&b),
 			pathFetchPublicKey(&b),
 			pathCleanupKeys(&b),
+			pathListCerts(&b),
+			pathCert(&b),
+			pathRevokeCert(&b),
+			pathFetchKRL(&b),
+			pathTidyCerts(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -0,0 +1,484 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	certsStoragePrefix = "certs/"
+
+	// Constants of the OpenSSH key revocation list format, described in
+	// PROTOCOL.krl of the OpenSSH sources.
+	krlMagic                 = 0x5353484b524c0a00
+	krlFormatVersion         = 1
+	krlSectionCertificates   = 1
+	krlSectionCertSerialList = 0x20
+	krlContentType           = "application/octet-stream"
+)
+
+// sshCertEntry is the inventory entry of a signed certificate, stored under
+// its serial number.
+type sshCertEntry struct {
+	SerialNumber    string    `json:"serial_number"`
+	KeyID           string    `json:"key_id"`
+	CertType        string    `json:"cert_type"`
+	ValidPrincipals []string  `json:"valid_principals"`
+	Role            string    `json:"role"`
+	EntityID        string    `json:"entity_id"`
+	ValidAfter      time.Time `json:"valid_after"`
+	ValidBefore     time.Time `json:"valid_before"`
+	RevocationTime  time.Time `json:"revocation_time,omitempty"`
+}
+
+func (e *sshCertEntry) expired(now time.Time) bool {
+	return !e.ValidBefore.After(now)
+}
+
+func (e *sshCertEntry) revoked() bool {
+	return !e.RevocationTime.IsZero()
+}
+
+func (e *sshCertEntry) toResponseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"serial_number":    e.SerialNumber,
+		"key_id":           e.KeyID,
+		"cert_type":        e.CertType,
+		"valid_principals": e.ValidPrincipals,
+		"role":             e.Role,
+		"entity_id":        e.EntityID,
+		"valid_after":      e.ValidAfter.Format(time.RFC3339),
+		"valid_before":     e.ValidBefore.Format(time.RFC3339),
+		"revoked":          e.revoked(),
+	}
+	if e.revoked() {
+		data["revocation_time"] = e.RevocationTime.Format(time.RFC3339)
+	}
+	return data
+}
+
+func pathListCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixSSH,
+			OperationSuffix: "certificates",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates signed with this role.`,
+				Query:       true,
+			},
+			"key_id": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates with this key ID.`,
+				Query:       true,
+			},
+			"principal": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates valid for this principal.`,
+				Query:       true,
+			},
+			"entity_id": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates requested by this entity.`,
+				Query:       true,
+			},
+			"include_expired": {
+				Type:        framework.TypeBool,
+				Description: `Whether to list expired certificates which were not tidied yet.`,
+				Query:       true,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathCertsList,
+		},
+
+		HelpSynopsis:    pathListCertsHelpSyn,
+		HelpDescription: pathListCertsHelpDesc,
+	}
+}
+
+func pathCert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "cert/" + framework.GenericNameRegex("serial"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixSSH,
+			OperationSuffix: "certificate",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"serial": {
+				Type:        framework.TypeString,
+				Description: `Serial number of the certificate, in hexadecimal.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCertRead,
+		},
+
+		HelpSynopsis:    `Read the inventory entry of a signed certificate.`,
+		HelpDescription: `Returns the key ID, principals, validity, role and requesting entity of a certificate signed by this backend, and whether it was revoked.`,
+	}
+}
+
+func pathRevokeCert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "revoke",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixSSH,
+			OperationVerb:   "revoke",
+			OperationSuffix: "certificate",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"serial_number": {
+				Type:        framework.TypeString,
+				Description: `Serial number of the certificate to revoke, in hexadecimal.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRevokeCert,
+		},
+
+		HelpSynopsis:    pathRevokeCertHelpSyn,
+		HelpDescription: pathRevokeCertHelpDesc,
+	}
+}
+
+func pathFetchKRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "krl",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixSSH,
+			OperationSuffix: "key-revocation-list",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchKRL,
+		},
+
+		HelpSynopsis:    pathFetchKRLHelpSyn,
+		HelpDescription: pathFetchKRLHelpDesc,
+	}
+}
+
+func pathTidyCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy/certs",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixSSH,
+			OperationVerb:   "tidy",
+			OperationSuffix: "certificates",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyCerts,
+		},
+
+		HelpSynopsis:    `Remove the inventory entries of expired certificates.`,
+		HelpDescription: `Expired certificates no longer need to be revoked, so their entries are removed from the inventory and from the key revocation list.`,
+	}
+}
+
+// storeCertificate adds the certificate signed with the role to the
+// inventory.
+func storeCertificate(ctx context.Context, req *logical.Request, roleName string, certificate *ssh.Certificate) error {
+	certType := "user"
+	if certificate.CertType == ssh.HostCert {
+		certType = "host"
+	}
+	serial := strconv.FormatUint(certificate.Serial, 16)
+	entry, err := logical.StorageEntryJSON(certsStoragePrefix+serial, &sshCertEntry{
+		SerialNumber:    serial,
+		KeyID:           certificate.KeyId,
+		CertType:        certType,
+		ValidPrincipals: certificate.ValidPrincipals,
+		Role:            roleName,
+		EntityID:        req.EntityID,
+		ValidAfter:      time.Unix(int64(certificate.ValidAfter), 0).UTC(),
+		ValidBefore:     time.Unix(int64(certificate.ValidBefore), 0).UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return req.Storage.Put(ctx, entry)
+}
+
+func getCertEntry(ctx context.Context, s logical.Storage, serial string) (*sshCertEntry, error) {
+	entry, err := s.Get(ctx, certsStoragePrefix+strings.ToLower(serial))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var cert sshCertEntry
+	if err := entry.DecodeJSON(&cert); err != nil {
+		return nil, fmt.Errorf("failed to decode certificate entry: %w", err)
+	}
+	return &cert, nil
+}
+
+// listCertEntries returns the inventory entries of all the certificates.
+func listCertEntries(ctx context.Context, s logical.Storage) ([]*sshCertEntry, error) {
+	serials, err := s.List(ctx, certsStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*sshCertEntry
+	for _, serial := range serials {
+		cert, err := getCertEntry(ctx, s, serial)
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
+
+func (b *backend) pathCertsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	certs, err := listCertEntries(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	role := d.Get("role").(string)
+	keyID := d.Get("key_id").(string)
+	principal := d.Get("principal").(string)
+	entityID := d.Get("entity_id").(string)
+	includeExpired := d.Get("include_expired").(bool)
+
+	now := time.Now()
+	var serials []string
+	keyInfo := make(map[string]interface{})
+	for _, cert := range certs {
+		switch {
+		case !includeExpired && cert.expired(now):
+		case role != "" && cert.Role != role:
+		case keyID != "" && cert.KeyID != keyID:
+		case principal != "" && !strutil.StrListContains(cert.ValidPrincipals, principal):
+		case entityID != "" && cert.EntityID != entityID:
+		default:
+			serials = append(serials, cert.SerialNumber)
+			keyInfo[cert.SerialNumber] = cert.toResponseData()
+		}
+	}
+
+	return logical.ListResponseWithInfo(serials, keyInfo), nil
+}
+
+func (b *backend) pathCertRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cert, err := getCertEntry(ctx, req.Storage, d.Get("serial").(string))
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, nil
+	}
+	return &logical.Response{Data: cert.toResponseData()}, nil
+}
+
+func (b *backend) pathRevokeCert(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	serial := d.Get("serial_number").(string)
+	if serial == "" {
+		return logical.ErrorResponse("missing serial_number"), nil
+	}
+
+	cert, err := getCertEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return logical.ErrorResponse("certificate with serial %q not found", serial), nil
+	}
+
+	resp := &logical.Response{}
+	switch {
+	case cert.revoked():
+		resp.AddWarning("certificate was already revoked")
+	case cert.expired(time.Now()):
+		resp.AddWarning("certificate has already expired")
+	default:
+		cert.RevocationTime = time.Now().UTC()
+		entry, err := logical.StorageEntryJSON(certsStoragePrefix+cert.SerialNumber, cert)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Data = cert.toResponseData()
+	return resp, nil
+}
+
+func (b *backend) pathFetchKRL(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+		return logical.ErrorResponse("no CA key configured"), nil
+	}
+	publicKey, err := parsePublicSSHKey(publicKeyEntry.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA public key: %w", err)
+	}
+
+	certs, err := listCertEntries(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: krlContentType,
+			logical.HTTPRawBody:     buildKRL(publicKey, certs, time.Now()),
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+func (b *backend) pathTidyCerts(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	certs, err := listCertEntries(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var removed int
+	for _, cert := range certs {
+		if !cert.expired(now) {
+			continue
+		}
+		if err := req.Storage.Delete(ctx, certsStoragePrefix+cert.SerialNumber); err != nil {
+			return nil, fmt.Errorf("unable to delete certificate %q: %w", cert.SerialNumber, err)
+		}
+		removed++
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"message": fmt.Sprintf("Removed %v of %v certificates.", removed, len(certs)),
+		},
+	}, nil
+}
+
+// buildKRL returns an OpenSSH key revocation list revoking, by serial
+// number, the certificates of the CA which were revoked before they expired.
+// The version of the list is the time of the last revocation, so that it
+// increases whenever a certificate is revoked.
+func buildKRL(caPublicKey ssh.PublicKey, certs []*sshCertEntry, now time.Time) []byte {
+	var serials []uint64
+	var version int64
+	for _, cert := range certs {
+		if !cert.revoked() || cert.expired(now) {
+			continue
+		}
+		serial, err := strconv.ParseUint(cert.SerialNumber, 16, 64)
+		if err != nil {
+			continue
+		}
+		serials = append(serials, serial)
+		if t := cert.RevocationTime.Unix(); t > version {
+			version = t
+		}
+	}
+	sort.Slice(serials, func(i, j int) bool { return serials[i] < serials[j] })
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint64(krlMagic))
+	binary.Write(&buf, binary.BigEndian, uint32(krlFormatVersion))
+	binary.Write(&buf, binary.BigEndian, uint64(version))
+	binary.Write(&buf, binary.BigEndian, uint64(now.Unix()))
+	binary.Write(&buf, binary.BigEndian, uint64(0)) // flags
+	writeKRLString(&buf, nil)                       // reserved
+	writeKRLString(&buf, []byte("Vault SSH CA"))
+
+	if len(serials) == 0 {
+		return buf.Bytes()
+	}
+
+	var serialList bytes.Buffer
+	for _, serial := range serials {
+		binary.Write(&serialList, binary.BigEndian, serial)
+	}
+
+	var section bytes.Buffer
+	writeKRLString(&section, caPublicKey.Marshal())
+	writeKRLString(&section, nil) // reserved
+	section.WriteByte(krlSectionCertSerialList)
+	writeKRLString(&section, serialList.Bytes())
+
+	buf.WriteByte(krlSectionCertificates)
+	writeKRLString(&buf, section.Bytes())
+	return buf.Bytes()
+}
+
+func writeKRLString(buf *bytes.Buffer, s []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
+
+const pathListCertsHelpSyn = `
+List the certificates signed by this backend.
+`
+
+const pathListCertsHelpDesc = `
+Every certificate signed through the sign and issue endpoints is recorded in
+an inventory with its key ID, principals, validity, role and requesting
+entity. The list can be filtered by role, key_id, principal and entity_id.
+Expired certificates are only listed with include_expired, until they are
+removed with tidy/certs.
+`
+
+const pathRevokeCertHelpSyn = `
+Revoke a certificate signed by this backend before it expires.
+`
+
+const pathRevokeCertHelpDesc = `
+SSH servers do not check the revocation of certificates by themselves; they
+must be configured with the key revocation list served at the krl endpoint,
+for example with the RevokedKeys option of sshd.
+`
+
+const pathFetchKRLHelpSyn = `
+Fetch the key revocation list of the certificates revoked by this backend.
+`
+
+const pathFetchKRLHelpDesc = `
+This endpoint does not require authentication. It returns a binary OpenSSH
+key revocation list (KRL) revoking, by serial number, the certificates signed
+by the configured CA which were revoked and have not expired yet. It can be
+verified with "ssh-keygen -Q -f <krl> <certificate>".
+`
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSH_CertInventoryAndKRL(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	require.NoError(t, err)
+
+	request := func(op logical.Operation, path, entityID string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			EntityID:  entityID,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		require.False(t, resp.IsError(), "%s %s: %v", op, path, resp.Error())
+		return resp
+	}
+
+	request(logical.UpdateOperation, "config/ca", "", map[string]interface{}{
+		"public_key":  testCAPublicKey,
+		"private_key": testCAPrivateKey,
+	})
+	request(logical.UpdateOperation, "roles/ops", "", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "alice,bob",
+	})
+
+	alice := request(logical.UpdateOperation, "sign/ops", "entity-alice", map[string]interface{}{
+		"public_key":       publicKey4096,
+		"valid_principals": "alice",
+	}).Data["serial_number"].(string)
+	request(logical.UpdateOperation, "sign/ops", "entity-bob", map[string]interface{}{
+		"public_key":       publicKey4096,
+		"valid_principals": "bob",
+	})
+
+	resp := request(logical.ListOperation, "certs/", "", nil)
+	require.Len(t, resp.Data["keys"], 2)
+
+	resp = request(logical.ListOperation, "certs/", "", map[string]interface{}{"principal": "alice"})
+	require.Equal(t, []string{alice}, resp.Data["keys"])
+	resp = request(logical.ListOperation, "certs/", "", map[string]interface{}{"entity_id": "entity-alice"})
+	require.Equal(t, []string{alice}, resp.Data["keys"])
+
+	resp = request(logical.ReadOperation, "cert/"+alice, "", nil)
+	require.Equal(t, "ops", resp.Data["role"])
+	require.Equal(t, "entity-alice", resp.Data["entity_id"])
+	require.Equal(t, []string{"alice"}, resp.Data["valid_principals"])
+	require.Equal(t, false, resp.Data["revoked"])
+
+	krl := request(logical.ReadOperation, "krl", "", nil).Data[logical.HTTPRawBody].([]byte)
+	require.Equal(t, uint64(krlMagic), binary.BigEndian.Uint64(krl))
+	require.Equal(t, uint64(0), binary.BigEndian.Uint64(krl[12:]), "expected an empty list to have version 0")
+
+	resp = request(logical.UpdateOperation, "revoke", "", map[string]interface{}{"serial_number": alice})
+	require.Equal(t, true, resp.Data["revoked"])
+	resp = request(logical.UpdateOperation, "revoke", "", map[string]interface{}{"serial_number": alice})
+	require.NotEmpty(t, resp.Warnings, "expected a warning revoking the certificate again")
+
+	krl = request(logical.ReadOperation, "krl", "", nil).Data[logical.HTTPRawBody].([]byte)
+	require.NotZero(t, binary.BigEndian.Uint64(krl[12:]), "expected the version to change on revocation")
+	serial, err := strconv.ParseUint(alice, 16, 64)
+	require.NoError(t, err)
+	require.Equal(t, serial, binary.BigEndian.Uint64(krl[len(krl)-8:]), "expected the revoked serial at the end of the list")
+
+	resp = request(logical.UpdateOperation, "tidy/certs", "", nil)
+	require.Equal(t, "Removed 0 of 2 certificates.", resp.Data["message"])
+}
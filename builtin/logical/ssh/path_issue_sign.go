@@ -131,6 +131,10 @@ func (b *backend) pathSignIssueCertificateHelper(ctx context.Context, req *logic
 		return nil, err
 	}
 
+	if err := storeCertificate(ctx, req, data.Get("role").(string), certificate); err != nil {
+		return nil, fmt.Errorf("failed to store certificate: %w", err)
+	}
+
 	signedSSHCertificate := ssh.MarshalAuthorizedKey(certificate)
 	if len(signedSSHCertificate) == 0 {
 		return nil, errors.New("error marshaling signed certificate")
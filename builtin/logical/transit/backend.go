@@ -45,6 +45,10 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 				"archive/",
 				"policy/",
 			},
+
+			PaginatedList: []string{
+				"*",
+			},
 		},
 
 		Paths: []*framework.Path{
@@ -66,6 +70,10 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
+			b.pathTokenize(),
+			b.pathDetokenize(),
+			b.pathListFPETemplates(),
+			b.pathFPETemplates(),
 			b.pathSign(),
 			b.pathVerify(),
 			b.pathBackup(),
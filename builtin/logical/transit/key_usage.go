@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// Operations that may be listed in the allowed_operations of a key.
+const (
+	keyUsageEncrypt    = "encrypt"
+	keyUsageDecrypt    = "decrypt"
+	keyUsageRewrap     = "rewrap"
+	keyUsageDatakey    = "datakey"
+	keyUsageSign       = "sign"
+	keyUsageVerify     = "verify"
+	keyUsageHMAC       = "hmac"
+	keyUsageTokenize   = "tokenize"
+	keyUsageDetokenize = "detokenize"
+	keyUsageExport     = "export"
+)
+
+var keyUsageOperations = []string{
+	keyUsageEncrypt,
+	keyUsageDecrypt,
+	keyUsageRewrap,
+	keyUsageDatakey,
+	keyUsageSign,
+	keyUsageVerify,
+	keyUsageHMAC,
+	keyUsageTokenize,
+	keyUsageDetokenize,
+	keyUsageExport,
+}
+
+func validateKeyUsageOperations(operations []string) error {
+	for _, operation := range operations {
+		if !strutil.StrListContains(keyUsageOperations, operation) {
+			return fmt.Errorf("unknown operation %q; valid operations are %v", operation, keyUsageOperations)
+		}
+	}
+	return nil
+}
+
+// checkKeyUsage enforces the usage policy of the key for the operation and
+// the entity of the request. A non-nil response or error denies the request.
+func (b *backend) checkKeyUsage(req *logical.Request, p *keysutil.Policy, operation string) (*logical.Response, error) {
+	if len(p.AllowedOperations) > 0 && !strutil.StrListContains(p.AllowedOperations, operation) {
+		return logical.ErrorResponse("operation %q is not allowed for key %q", operation, p.Name), logical.ErrPermissionDenied
+	}
+
+	if len(p.AllowedEntityIDs) == 0 && len(p.AllowedGroupIDs) == 0 {
+		return nil, nil
+	}
+	if req.EntityID == "" {
+		return logical.ErrorResponse("key %q may only be used by identity entities", p.Name), logical.ErrPermissionDenied
+	}
+	if strutil.StrListContains(p.AllowedEntityIDs, req.EntityID) {
+		return nil, nil
+	}
+	if len(p.AllowedGroupIDs) > 0 {
+		groups, err := b.System().GroupsForEntity(req.EntityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up groups of entity: %w", err)
+		}
+		for _, group := range groups {
+			if strutil.StrListContains(p.AllowedGroupIDs, group.ID) {
+				return nil, nil
+			}
+		}
+	}
+
+	return logical.ErrorResponse("entity is not allowed to use key %q", p.Name), logical.ErrPermissionDenied
+}
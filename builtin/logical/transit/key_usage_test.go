@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_KeyUsagePolicy(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	sysView := logical.TestSystemView()
+	sysView.GroupsVal = []*logical.Group{{ID: "group-1"}}
+	config.System = sysView
+
+	b, _ := Backend(context.Background(), config)
+	require.NotNil(t, b)
+	require.NoError(t, b.Backend.Setup(context.Background(), config))
+
+	write := func(path, entityID string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			EntityID:  entityID,
+			Data:      data,
+		})
+	}
+
+	_, err := write("keys/restricted", "", nil)
+	require.NoError(t, err)
+	resp, err := write("encrypt/restricted", "", map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+	})
+	require.NoError(t, err)
+	ciphertext := resp.Data["ciphertext"].(string)
+
+	// Allow encryption only.
+	_, err = write("keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "encrypt,encrypt",
+	})
+	require.NoError(t, err)
+	resp, err = write("keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "encrypt,launch",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected unknown operation to be rejected")
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.ReadOperation,
+		Path:      "keys/restricted",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"encrypt"}, resp.Data["allowed_operations"])
+
+	_, err = write("encrypt/restricted", "", map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+	})
+	require.NoError(t, err)
+	_, err = write("decrypt/restricted", "", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	_, err = write("rewrap/restricted", "", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	// Restrict the key to an entity, in addition to the operations.
+	_, err = write("keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "encrypt,decrypt",
+		"allowed_entity_ids": "entity-1",
+	})
+	require.NoError(t, err)
+
+	_, err = write("decrypt/restricted", "", map[string]interface{}{"ciphertext": ciphertext})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied, "expected request without entity to be denied")
+	_, err = write("decrypt/restricted", "entity-2", map[string]interface{}{"ciphertext": ciphertext})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	_, err = write("decrypt/restricted", "entity-1", map[string]interface{}{"ciphertext": ciphertext})
+	require.NoError(t, err)
+
+	// Members of an allowed group may use the key as well.
+	_, err = write("keys/restricted/config", "", map[string]interface{}{
+		"allowed_group_ids": "group-1",
+	})
+	require.NoError(t, err)
+	_, err = write("decrypt/restricted", "entity-2", map[string]interface{}{"ciphertext": ciphertext})
+	require.NoError(t, err)
+
+	// Clearing the lists lifts the restrictions.
+	_, err = write("keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "",
+		"allowed_entity_ids": "",
+		"allowed_group_ids":  "",
+	})
+	require.NoError(t, err)
+	_, err = write("rewrap/restricted", "", map[string]interface{}{"ciphertext": ciphertext})
+	require.NoError(t, err)
+}
+
+func TestTransit_KeyUsagePolicy_KeyMaterial(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, _ := Backend(context.Background(), config)
+	require.NotNil(t, b)
+	require.NoError(t, b.Backend.Setup(context.Background(), config))
+
+	request := func(operation logical.Operation, path, entityID string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: operation,
+			Path:      path,
+			EntityID:  entityID,
+			Data:      data,
+		})
+	}
+
+	_, err := request(logical.UpdateOperation, "keys/restricted", "", map[string]interface{}{
+		"type":       "rsa-2048",
+		"exportable": true,
+	})
+	require.NoError(t, err)
+	_, err = request(logical.UpdateOperation, "keys/wrapping", "", map[string]interface{}{"type": "rsa-2048"})
+	require.NoError(t, err)
+
+	csr := func(entityID string) error {
+		_, err := request(logical.UpdateOperation, "keys/restricted/csr", entityID, nil)
+		return err
+	}
+	export := func(entityID string) error {
+		_, err := request(logical.ReadOperation, "export/signing-key/restricted", entityID, nil)
+		return err
+	}
+	byokExport := func(entityID string) error {
+		_, err := request(logical.ReadOperation, "byok-export/wrapping/restricted", entityID, nil)
+		return err
+	}
+
+	// Keys limited to encryption cannot sign CSRs or have their private key
+	// material exported, but their public key can still be read.
+	_, err = request(logical.UpdateOperation, "keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "encrypt,decrypt",
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, csr(""), logical.ErrPermissionDenied)
+	require.ErrorIs(t, export(""), logical.ErrPermissionDenied)
+	require.ErrorIs(t, byokExport(""), logical.ErrPermissionDenied)
+	_, err = request(logical.ReadOperation, "export/public-key/restricted", "", nil)
+	require.NoError(t, err)
+
+	// Keys restricted to an entity are only usable by that entity.
+	_, err = request(logical.UpdateOperation, "keys/restricted/config", "", map[string]interface{}{
+		"allowed_operations": "sign,export",
+		"allowed_entity_ids": "entity-1",
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, csr("entity-2"), logical.ErrPermissionDenied)
+	require.ErrorIs(t, export("entity-2"), logical.ErrPermissionDenied)
+	require.ErrorIs(t, byokExport("entity-2"), logical.ErrPermissionDenied)
+	require.NoError(t, csr("entity-1"))
+	require.NoError(t, export("entity-1"))
+	require.NoError(t, byokExport("entity-1"))
+}
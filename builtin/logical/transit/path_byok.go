@@ -96,6 +96,9 @@ func (b *backend) pathPolicyBYOKExportRead(ctx context.Context, req *logical.Req
 	if !srcP.Exportable {
 		return logical.ErrorResponse("key is not exportable"), nil
 	}
+	if resp, err := b.checkKeyUsage(req, srcP, keyUsageExport); resp != nil || err != nil {
+		return resp, err
+	}
 
 	retKeys := map[string]string{}
 	switch version {
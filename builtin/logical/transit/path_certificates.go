@@ -111,6 +111,10 @@ func (b *backend) pathCreateCsrWrite(ctx context.Context, req *logical.Request,
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageSign); resp != nil || err != nil {
+		return resp, err
+	}
+
 	// Check if transit key supports signing
 	if !p.Type.SigningSupported() {
 		return logical.ErrorResponse(fmt.Sprintf("key type '%s' does not support signing", p.Type)), logical.ErrInvalidRequest
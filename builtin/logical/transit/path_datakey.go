@@ -126,6 +126,10 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageDatakey); resp != nil || err != nil {
+		return resp, err
+	}
+
 	newKey := make([]byte, 32)
 	bits := d.Get("bits").(int)
 	switch bits {
@@ -186,6 +186,10 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageDecrypt); resp != nil || err != nil {
+		return resp, err
+	}
+
 	successesInBatch := false
 	for i, item := range batchInputItems {
 		if batchResponseItems[i].Error != "" {
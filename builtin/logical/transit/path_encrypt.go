@@ -461,6 +461,10 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageEncrypt); resp != nil || err != nil {
+		return resp, err
+	}
+
 	// Process batch request items. If encryption of any request
 	// item fails, respectively mark the error in the response
 	// collection and continue to process other items.
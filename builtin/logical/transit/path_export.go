@@ -96,6 +96,11 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	if !p.Exportable && exportType != exportTypePublicKey && exportType != exportTypeCertificateChain {
 		return logical.ErrorResponse("private key material is not exportable"), nil
 	}
+	if exportType != exportTypePublicKey && exportType != exportTypeCertificateChain {
+		if resp, err := b.checkKeyUsage(req, p, keyUsageExport); resp != nil || err != nil {
+			return resp, err
+		}
+	}
 
 	switch exportType {
 	case exportTypeEncryptionKey:
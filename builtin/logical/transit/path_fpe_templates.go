@@ -0,0 +1,313 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/fpe"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	fpeTemplatePrefix  = "fpe-template/"
+	builtinFPEPrefix   = "builtin-"
+	defaultFPETemplate = "builtin-numeric"
+)
+
+// builtinFPEAlphabets are the named alphabets templates may refer to.
+var builtinFPEAlphabets = map[string]string{
+	"numeric":           "0123456789",
+	"alphalower":        "abcdefghijklmnopqrstuvwxyz",
+	"alphaupper":        "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alphanumericlower": "0123456789abcdefghijklmnopqrstuvwxyz",
+	"alphanumericupper": "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alphanumeric":      "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+// builtinFPETemplates are always available and cannot be modified.
+var builtinFPETemplates = map[string]*fpeTemplate{
+	"builtin-numeric": {
+		Name:     "builtin-numeric",
+		Alphabet: "numeric",
+		Pattern:  `(\d+)`,
+	},
+	"builtin-creditcardnumber": {
+		Name:     "builtin-creditcardnumber",
+		Alphabet: "numeric",
+		Pattern:  `(\d{4})[- ]?(\d{4})[- ]?(\d{4})[- ]?(\d{4})`,
+	},
+}
+
+// fpeTemplate describes the format of the values to tokenize: the
+// characters of the capture groups of the pattern are encrypted together
+// over the alphabet, while the rest of the value is preserved.
+type fpeTemplate struct {
+	Name     string `json:"name"`
+	Alphabet string `json:"alphabet"`
+	Pattern  string `json:"pattern"`
+
+	re *regexp.Regexp
+}
+
+func (t *fpeTemplate) alphabet() string {
+	if chars, ok := builtinFPEAlphabets[t.Alphabet]; ok {
+		return chars
+	}
+	return t.Alphabet
+}
+
+func (t *fpeTemplate) compile() error {
+	re, err := regexp.Compile(`^(?:` + t.Pattern + `)$`)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if re.NumSubexp() == 0 {
+		return errors.New("pattern must contain at least one capture group")
+	}
+	t.re = re
+	return nil
+}
+
+// transform encrypts or decrypts the captured characters of the value,
+// keeping the characters outside of the capture groups in place.
+func (t *fpeTemplate) transform(c *fpe.FF31, value string, tweak []byte, encrypt bool) (string, error) {
+	match := t.re.FindStringSubmatchIndex(value)
+	if match == nil {
+		return "", fmt.Errorf("value does not match the pattern of template %q", t.Name)
+	}
+
+	var groups [][2]int
+	var captured strings.Builder
+	end := 0
+	for i := 2; i < len(match); i += 2 {
+		if match[i] < 0 {
+			continue
+		}
+		if match[i] < end {
+			return "", fmt.Errorf("capture groups of template %q must not be nested", t.Name)
+		}
+		groups = append(groups, [2]int{match[i], match[i+1]})
+		captured.WriteString(value[match[i]:match[i+1]])
+		end = match[i+1]
+	}
+
+	var out string
+	var err error
+	if encrypt {
+		out, err = c.Encrypt(captured.String(), tweak)
+	} else {
+		out, err = c.Decrypt(captured.String(), tweak)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Split the result back into the groups; the alphabet may contain
+	// multi-byte characters, so lengths are counted in characters.
+	outRunes := []rune(out)
+	var result strings.Builder
+	prev := 0
+	for _, group := range groups {
+		result.WriteString(value[prev:group[0]])
+		n := len([]rune(value[group[0]:group[1]]))
+		result.WriteString(string(outRunes[:n]))
+		outRunes = outRunes[n:]
+		prev = group[1]
+	}
+	result.WriteString(value[prev:])
+	return result.String(), nil
+}
+
+func getFPETemplate(ctx context.Context, s logical.Storage, name string) (*fpeTemplate, error) {
+	if t, ok := builtinFPETemplates[name]; ok {
+		template := *t
+		if err := template.compile(); err != nil {
+			return nil, err
+		}
+		return &template, nil
+	}
+
+	entry, err := s.Get(ctx, fpeTemplatePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var template fpeTemplate
+	if err := entry.DecodeJSON(&template); err != nil {
+		return nil, err
+	}
+	if err := template.compile(); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (b *backend) pathListFPETemplates() *framework.Path {
+	return &framework.Path{
+		Pattern: "fpe-templates/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "fpe-templates",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathFPETemplatesList,
+		},
+
+		HelpSynopsis:    pathFPETemplatesHelpSyn,
+		HelpDescription: pathFPETemplatesHelpDesc,
+	}
+}
+
+func (b *backend) pathFPETemplates() *framework.Path {
+	return &framework.Path{
+		Pattern: "fpe-templates/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "fpe-template",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the template",
+			},
+
+			"alphabet": {
+				Type: framework.TypeString,
+				Description: `The characters of the values to tokenize; either
+one of the built-in alphabets "numeric", "alphalower", "alphaupper",
+"alphanumericlower", "alphanumericupper" and "alphanumeric", or the
+characters themselves.`,
+			},
+
+			"pattern": {
+				Type: framework.TypeString,
+				Description: `Regular expression the whole value must match.
+The characters of its capture groups are tokenized together, while
+the rest of the value, such as separators, is preserved.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathFPETemplateRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathFPETemplateWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "write",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathFPETemplateDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathFPETemplatesHelpSyn,
+		HelpDescription: pathFPETemplatesHelpDesc,
+	}
+}
+
+func (b *backend) pathFPETemplatesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, fpeTemplatePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for name := range builtinFPETemplates {
+		entries = append(entries, name)
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathFPETemplateRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	template, err := getFPETemplate(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":     template.Name,
+			"alphabet": template.Alphabet,
+			"pattern":  template.Pattern,
+		},
+	}, nil
+}
+
+func (b *backend) pathFPETemplateWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinFPEPrefix) {
+		return logical.ErrorResponse("template names starting with %q are reserved", builtinFPEPrefix), logical.ErrInvalidRequest
+	}
+
+	template := &fpeTemplate{
+		Name:     name,
+		Alphabet: d.Get("alphabet").(string),
+		Pattern:  d.Get("pattern").(string),
+	}
+	if template.Alphabet == "" {
+		return logical.ErrorResponse("missing alphabet"), logical.ErrInvalidRequest
+	}
+	if template.Pattern == "" {
+		return logical.ErrorResponse("missing pattern"), logical.ErrInvalidRequest
+	}
+	if err := template.compile(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	// Validate the alphabet with a throwaway key.
+	if _, err := fpe.NewFF31(make([]byte, 32), template.alphabet()); err != nil {
+		return logical.ErrorResponse("invalid alphabet: %s", err), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(fpeTemplatePrefix+name, template)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathFPETemplateDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinFPEPrefix) {
+		return logical.ErrorResponse("built-in templates cannot be deleted"), logical.ErrInvalidRequest
+	}
+
+	return nil, req.Storage.Delete(ctx, fpeTemplatePrefix+name)
+}
+
+const pathFPETemplatesHelpSyn = `Manage the formats of values tokenized with format-preserving encryption`
+
+const pathFPETemplatesHelpDesc = `
+Templates describe the values tokenized with "aes256-ff3-1" keys: the
+alphabet of their characters, and a pattern whose capture groups select
+the characters to tokenize. The built-in templates "builtin-numeric"
+(digits only) and "builtin-creditcardnumber" (16-digit card numbers,
+optionally separated by spaces or dashes) are always available.
+`
@@ -138,6 +138,10 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageHMAC); resp != nil || err != nil {
+		return resp, err
+	}
+
 	switch {
 	case ver == 0:
 		// Allowed, will use latest; set explicitly here to ensure the string
@@ -276,6 +280,10 @@ func (b *backend) pathHMACVerify(ctx context.Context, req *logical.Request, d *f
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keyUsageHMAC); resp != nil || err != nil {
+		return resp, err
+	}
+
 	hashAlgorithm, ok := keysutil.HashTypeMap[algorithm]
 	if !ok {
 		return logical.ErrorResponse("unsupported algorithm %q", hashAlgorithm), nil
@@ -59,7 +59,8 @@ func (b *backend) pathKeys() *framework.Path {
 				Description: `
 The type of key to create. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric), "hmac" and "aes256-ff3-1" (format-preserving
+tokenization) are supported.  Defaults to "aes256-gcm96".
 `,
 			},
 
@@ -222,6 +223,8 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		polReq.KeyType = keysutil.KeyType_HMAC
 	case "managed_key":
 		polReq.KeyType = keysutil.KeyType_MANAGED_KEY
+	case "aes256-ff3-1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF3_1
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
@@ -321,6 +324,7 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 			"supports_decryption":    p.Type.DecryptionSupported(),
 			"supports_signing":       p.Type.SigningSupported(),
 			"supports_derivation":    p.Type.DerivationSupported(),
+			"supports_tokenization":  p.Type.FormatPreservingEncryptionSupported(),
 			"auto_rotate_period":     int64(p.AutoRotatePeriod.Seconds()),
 			"imported_key":           p.Imported,
 		},
@@ -333,6 +337,16 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 		resp.Data["imported_key_allow_rotation"] = p.AllowImportedKeyRotation
 	}
 
+	if len(p.AllowedOperations) > 0 {
+		resp.Data["allowed_operations"] = p.AllowedOperations
+	}
+	if len(p.AllowedEntityIDs) > 0 {
+		resp.Data["allowed_entity_ids"] = p.AllowedEntityIDs
+	}
+	if len(p.AllowedGroupIDs) > 0 {
+		resp.Data["allowed_group_ids"] = p.AllowedGroupIDs
+	}
+
 	if p.BackupInfo != nil {
 		resp.Data["backup_info"] = map[string]interface{}{
 			"time":    p.BackupInfo.Time,
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -65,6 +66,29 @@ the latest version of the key is allowed.`,
 being automatically rotated. A value of 0
 disables automatic rotation for the key.`,
 			},
+
+			"allowed_operations": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, the operations the key may be used for,
+out of "encrypt", "decrypt", "rewrap", "datakey", "sign",
+"verify", "hmac", "tokenize", "detokenize" and "export".
+Signing a CSR with the key requires "sign", and exporting
+its private key material, including with BYOK, requires
+"export". An empty list allows all operations.`,
+			},
+
+			"allowed_entity_ids": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, the identity entities allowed to use
+the key. Requests without an entity are denied when
+either this or allowed_group_ids is set.`,
+			},
+
+			"allowed_group_ids": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, the identity groups whose member
+entities are allowed to use the key.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -104,6 +128,9 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAllowedOperations := p.AllowedOperations
+	originalAllowedEntityIDs := p.AllowedEntityIDs
+	originalAllowedGroupIDs := p.AllowedGroupIDs
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -112,6 +139,9 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AllowedOperations = originalAllowedOperations
+			p.AllowedEntityIDs = originalAllowedEntityIDs
+			p.AllowedGroupIDs = originalAllowedGroupIDs
 		}
 	}()
 
@@ -224,6 +254,36 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 		}
 	}
 
+	allowedOperationsRaw, ok := d.GetOk("allowed_operations")
+	if ok {
+		allowedOperations := strutil.RemoveDuplicates(allowedOperationsRaw.([]string), true)
+		if err := validateKeyUsageOperations(allowedOperations); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if !strutil.EquivalentSlices(allowedOperations, p.AllowedOperations) {
+			p.AllowedOperations = allowedOperations
+			persistNeeded = true
+		}
+	}
+
+	allowedEntityIDsRaw, ok := d.GetOk("allowed_entity_ids")
+	if ok {
+		allowedEntityIDs := strutil.RemoveDuplicates(allowedEntityIDsRaw.([]string), false)
+		if !strutil.EquivalentSlices(allowedEntityIDs, p.AllowedEntityIDs) {
+			p.AllowedEntityIDs = allowedEntityIDs
+			persistNeeded = true
+		}
+	}
+
+	allowedGroupIDsRaw, ok := d.GetOk("allowed_group_ids")
+	if ok {
+		allowedGroupIDs := strutil.RemoveDuplicates(allowedGroupIDsRaw.([]string), false)
+		if !strutil.EquivalentSlices(allowedGroupIDs, p.AllowedGroupIDs) {
+			p.AllowedGroupIDs = allowedGroupIDs
+			persistNeeded = true
+		}
+	}
+
 	if !persistNeeded {
 		resp, err := b.formatKeyPolicy(p, nil)
 		if err != nil {
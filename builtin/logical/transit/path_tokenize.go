@@ -0,0 +1,279 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/fpe"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// batchRequestTokenizeItem represents a request item for batch processing.
+type batchRequestTokenizeItem map[string]string
+
+// batchResponseTokenizeItem represents a response item for batch processing
+type batchResponseTokenizeItem struct {
+	// Value is the token or the detokenized value of the corresponding
+	// batch request item
+	Value string `json:"value,omitempty" mapstructure:"value"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	err error
+
+	// Reference is an arbitrary caller supplied string value that will be placed on the
+	// batch response to ease correlation between inputs and outputs
+	Reference string `json:"reference" mapstructure:"reference"`
+}
+
+func (b *backend) pathTokenize() *framework.Path {
+	return &framework.Path{
+		Pattern: "tokenize/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "tokenize",
+		},
+
+		Fields: tokenizeFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTokenizeWrite(true),
+		},
+
+		HelpSynopsis:    pathTokenizeHelpSyn,
+		HelpDescription: pathTokenizeHelpDesc,
+	}
+}
+
+func (b *backend) pathDetokenize() *framework.Path {
+	return &framework.Path{
+		Pattern: "detokenize/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "detokenize",
+		},
+
+		Fields: tokenizeFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTokenizeWrite(false),
+		},
+
+		HelpSynopsis:    pathTokenizeHelpSyn,
+		HelpDescription: pathTokenizeHelpDesc,
+	}
+}
+
+func tokenizeFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "The key to use, which must be of type aes256-ff3-1",
+		},
+
+		"value": {
+			Type:        framework.TypeString,
+			Description: "The value to tokenize, or the token to detokenize",
+		},
+
+		"template": {
+			Type:        framework.TypeString,
+			Default:     defaultFPETemplate,
+			Description: `The template describing the format of the value. Defaults to "builtin-numeric".`,
+		},
+
+		"tweak": {
+			Type: framework.TypeString,
+			Description: fmt.Sprintf(`Base64 encoded tweak of %d bytes. The same value
+tokenized with different tweaks gives different tokens; the same tweak
+must be given to detokenize. Defaults to all zero bytes.`, fpe.TweakSize),
+		},
+
+		"key_version": {
+			Type: framework.TypeInt,
+			Description: `The version of the key to use. Tokens do not carry
+the version they were generated with, so it must be given to
+detokenize tokens of older versions. Defaults to the latest version.`,
+		},
+
+		"batch_input": {
+			Type: framework.TypeSlice,
+			Description: `
+Specifies a list of items to be processed in a single batch, each with
+a 'value' and optionally a 'tweak' and a 'reference'. When this parameter
+is set, if the parameters 'value' and 'tweak' are also set, they will
+be ignored. Any batch output will preserve the order of the batch input.`,
+		},
+	}
+}
+
+func (b *backend) pathTokenizeWrite(tokenize bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		ver := d.Get("key_version").(int)
+
+		template, err := getFPETemplate(ctx, req.Storage, d.Get("template").(string))
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return logical.ErrorResponse("template not found"), logical.ErrInvalidRequest
+		}
+
+		// Get the policy
+		p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+		defer p.Unlock()
+
+		operation := keyUsageTokenize
+		if !tokenize {
+			operation = keyUsageDetokenize
+		}
+		if resp, err := b.checkKeyUsage(req, p, operation); resp != nil || err != nil {
+			return resp, err
+		}
+
+		if !p.Type.FormatPreservingEncryptionSupported() {
+			return logical.ErrorResponse("key type %v does not support tokenization", p.Type), logical.ErrInvalidRequest
+		}
+
+		switch {
+		case ver == 0:
+			ver = p.LatestVersion
+		case ver == p.LatestVersion:
+			// Allowed
+		case tokenize && p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+			return logical.ErrorResponse("cannot tokenize: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+		case !tokenize && ver < p.MinDecryptionVersion:
+			return logical.ErrorResponse("cannot detokenize: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+		}
+
+		key, err := p.FPEKey(ver)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		c, err := fpe.NewFF31(key, template.alphabet())
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		batchInputRaw := d.Raw["batch_input"]
+		var batchInputItems []batchRequestTokenizeItem
+		if batchInputRaw != nil {
+			err = mapstructure.Decode(batchInputRaw, &batchInputItems)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse batch input: %w", err)
+			}
+
+			if len(batchInputItems) == 0 {
+				return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+			}
+		} else {
+			valueRaw, ok := d.GetOk("value")
+			if !ok {
+				return logical.ErrorResponse("missing value"), logical.ErrInvalidRequest
+			}
+
+			batchInputItems = make([]batchRequestTokenizeItem, 1)
+			batchInputItems[0] = batchRequestTokenizeItem{
+				"value": valueRaw.(string),
+				"tweak": d.Get("tweak").(string),
+			}
+		}
+
+		response := make([]batchResponseTokenizeItem, len(batchInputItems))
+
+		for i, item := range batchInputItems {
+			value, ok := item["value"]
+			if !ok {
+				response[i].Error = "missing value"
+				response[i].err = logical.ErrInvalidRequest
+				continue
+			}
+
+			tweak := make([]byte, fpe.TweakSize)
+			if rawTweak := item["tweak"]; rawTweak != "" {
+				tweak, err = base64.StdEncoding.DecodeString(rawTweak)
+				if err != nil {
+					response[i].Error = fmt.Sprintf("unable to decode tweak as base64: %s", err)
+					response[i].err = logical.ErrInvalidRequest
+					continue
+				}
+			}
+
+			out, err := template.transform(c, value, tweak, tokenize)
+			if err != nil {
+				response[i].Error = err.Error()
+				response[i].err = logical.ErrInvalidRequest
+				continue
+			}
+			response[i].Value = out
+		}
+
+		// Generate the response
+		resp := &logical.Response{}
+		if batchInputRaw != nil {
+			// Copy the references
+			for i := range batchInputItems {
+				response[i].Reference = batchInputItems[i]["reference"]
+			}
+			resp.Data = map[string]interface{}{
+				"batch_results": response,
+				"key_version":   ver,
+			}
+		} else {
+			if response[0].Error != "" || response[0].err != nil {
+				if response[0].Error != "" {
+					return logical.ErrorResponse(response[0].Error), response[0].err
+				}
+				return nil, response[0].err
+			}
+
+			field := "token"
+			if !tokenize {
+				field = "value"
+			}
+			resp.Data = map[string]interface{}{
+				field:         response[0].Value,
+				"key_version": ver,
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+const pathTokenizeHelpSyn = `Tokenize or detokenize values with format-preserving encryption`
+
+const pathTokenizeHelpDesc = `
+These endpoints encrypt values such as card numbers with the FF3-1 mode
+of format-preserving encryption (NIST SP 800-38G Revision 1), using a key
+of type "aes256-ff3-1". Tokens have the same length and alphabet as the
+values they replace, and the characters outside of the capture groups of
+the template, such as separators, are kept in place.
+
+Tokenization is convergent: the same value, tweak and key version always
+give the same token, so tokens can be compared and used for lookups.
+`
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_Tokenize(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	write := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+
+	resp, err := write("keys/pan", map[string]interface{}{"type": "aes256-ff3-1"})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["supports_tokenization"])
+	require.Equal(t, false, resp.Data["supports_encryption"])
+
+	_, err = write("keys/derived-pan", map[string]interface{}{"type": "aes256-ff3-1", "derived": true})
+	require.Error(t, err, "expected derivation to be rejected")
+
+	pan := "4111-1111-1111-1111"
+	resp, err = write("tokenize/pan", map[string]interface{}{
+		"value":    pan,
+		"template": "builtin-creditcardnumber",
+	})
+	require.NoError(t, err)
+	token := resp.Data["token"].(string)
+	require.Len(t, token, len(pan))
+	require.NotEqual(t, pan, token)
+	require.Equal(t, "-", token[4:5], "expected separators to be preserved")
+	require.Equal(t, 1, resp.Data["key_version"])
+
+	resp, err = write("tokenize/pan", map[string]interface{}{
+		"value":    pan,
+		"template": "builtin-creditcardnumber",
+	})
+	require.NoError(t, err)
+	require.Equal(t, token, resp.Data["token"], "expected tokenization to be convergent")
+
+	resp, err = write("detokenize/pan", map[string]interface{}{
+		"value":    token,
+		"template": "builtin-creditcardnumber",
+	})
+	require.NoError(t, err)
+	require.Equal(t, pan, resp.Data["value"])
+
+	// A template keeping the last four digits of the card number.
+	_, err = write("fpe-templates/last-four", map[string]interface{}{
+		"alphabet": "numeric",
+		"pattern":  `(\d{4})(\d{4})(\d{4})\d{4}`,
+	})
+	require.NoError(t, err)
+	_, err = write("fpe-templates/builtin-mine", map[string]interface{}{
+		"alphabet": "numeric",
+		"pattern":  `(\d+)`,
+	})
+	require.Error(t, err, "expected reserved template name to be rejected")
+	_, err = write("fpe-templates/no-groups", map[string]interface{}{
+		"alphabet": "numeric",
+		"pattern":  `\d+`,
+	})
+	require.Error(t, err, "expected pattern without capture groups to be rejected")
+
+	tweak := base64.StdEncoding.EncodeToString([]byte("merchnt"))
+	resp, err = write("tokenize/pan", map[string]interface{}{
+		"template": "last-four",
+		"batch_input": []interface{}{
+			map[string]interface{}{"value": "4111111111111111", "tweak": tweak, "reference": "a"},
+			map[string]interface{}{"value": "not-a-card", "reference": "b"},
+		},
+	})
+	require.NoError(t, err)
+	results := resp.Data["batch_results"].([]batchResponseTokenizeItem)
+	require.Len(t, results, 2)
+	require.Equal(t, "1111", results[0].Value[12:])
+	require.Empty(t, results[0].Error)
+	require.NotEmpty(t, results[1].Error)
+	require.Equal(t, "b", results[1].Reference)
+
+	resp, err = write("detokenize/pan", map[string]interface{}{
+		"value":    results[0].Value,
+		"template": "last-four",
+		"tweak":    tweak,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "4111111111111111", resp.Data["value"])
+
+	// Encryption keys cannot be used for tokenization, and tokenization keys
+	// cannot be used for encryption.
+	_, err = write("keys/aes", nil)
+	require.NoError(t, err)
+	_, err = write("tokenize/aes", map[string]interface{}{"value": "123456"})
+	require.Error(t, err)
+	_, err = write("encrypt/pan", map[string]interface{}{"plaintext": base64.StdEncoding.EncodeToString([]byte("123456"))})
+	require.Error(t, err)
+}
@@ -40,6 +40,7 @@ type Config struct {
 	Vault                       *Vault                     `hcl:"vault"`
 	TemplateConfig              *TemplateConfig            `hcl:"template_config"`
 	Templates                   []*ctconfig.TemplateConfig `hcl:"templates"`
+	TemplateGroups              []*TemplateGroup           `hcl:"-"`
 	DisableIdleConns            []string                   `hcl:"disable_idle_connections"`
 	DisableIdleConnsAPIProxy    bool                       `hcl:"-"`
 	DisableIdleConnsTemplating  bool                       `hcl:"-"`
@@ -172,6 +173,23 @@ type TemplateConfig struct {
 	LeaseRenewalThreshold    *float64      `hcl:"lease_renewal_threshold"`
 }
 
+// TemplateGroup is a group of templates whose rendered files are committed to
+// their destinations all at once, such as a certificate and its key, for them
+// to never be read mismatched.
+type TemplateGroup struct {
+	Name         string   `hcl:"-"`
+	Destinations []string `hcl:"destinations"`
+
+	// Command is run once the files of the group are committed.
+	Command           []string      `hcl:"command"`
+	CommandTimeoutRaw interface{}   `hcl:"command_timeout"`
+	CommandTimeout    time.Duration `hcl:"-"`
+
+	// AllowPartialChange commits the files of the group as soon as any of
+	// them changes, instead of waiting for all of them to change.
+	AllowPartialChange bool `hcl:"allow_partial_change"`
+}
+
 type ExecConfig struct {
 	Command                []string  `hcl:"command,attr" mapstructure:"command"`
 	RestartOnSecretChanges string    `hcl:"restart_on_secret_changes,optional" mapstructure:"restart_on_secret_changes"`
@@ -262,6 +280,13 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.Templates = append(result.Templates, l)
 	}
 
+	for _, g := range c.TemplateGroups {
+		result.TemplateGroups = append(result.TemplateGroups, g)
+	}
+	for _, g := range c2.TemplateGroups {
+		result.TemplateGroups = append(result.TemplateGroups, g)
+	}
+
 	result.ExitAfterAuth = c.ExitAfterAuth
 	if c2.ExitAfterAuth {
 		result.ExitAfterAuth = c2.ExitAfterAuth
@@ -360,9 +385,49 @@ func (c *Config) ValidateConfig() error {
 		return fmt.Errorf("no auto_auth, cache, or listener block found in config")
 	}
 
+	if err := c.validateTemplateGroups(); err != nil {
+		return err
+	}
+
 	return c.validateEnvTemplateConfig()
 }
 
+func (c *Config) validateTemplateGroups() error {
+	templates := make(map[string]*ctconfig.TemplateConfig, len(c.Templates))
+	for _, template := range c.Templates {
+		if template.Destination != nil {
+			templates[*template.Destination] = template
+		}
+	}
+
+	groups := make(map[string]string)
+	for _, group := range c.TemplateGroups {
+		if len(group.Destinations) < 2 {
+			return fmt.Errorf("template_group[%s]: at least 2 destinations are required", group.Name)
+		}
+		for _, destination := range group.Destinations {
+			template, ok := templates[destination]
+			if !ok {
+				return fmt.Errorf("template_group[%s]: no template renders to %q", group.Name, destination)
+			}
+			if other, ok := groups[destination]; ok {
+				return fmt.Errorf("template_group[%s]: %q is already in template_group[%s]", group.Name, destination, other)
+			}
+			groups[destination] = group.Name
+
+			// The files are rendered apart from their destinations, so their
+			// own commands would run before the files are committed
+			if len(template.Command) > 0 || template.Exec != nil {
+				return fmt.Errorf("template_group[%s]: the template rendering to %q cannot have a command, use the command of the group instead", group.Name, destination)
+			}
+			if template.Backup != nil && *template.Backup {
+				return fmt.Errorf("template_group[%s]: the template rendering to %q cannot be backed up", group.Name, destination)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Config) validateEnvTemplateConfig() error {
 	// if we are not in env-template mode, exit early
 	if c.Exec == nil && len(c.EnvTemplates) == 0 {
@@ -645,6 +710,10 @@ func LoadConfigFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("error parsing 'template': %w", err)
 	}
 
+	if err := parseTemplateGroups(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'template_group': %w", err)
+	}
+
 	if err := parseExec(result, list); err != nil {
 		return nil, fmt.Errorf("error parsing 'exec': %w", err)
 	}
@@ -917,6 +986,15 @@ func parsePersist(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	if p.MaxSizeRaw != nil {
+		maxSize, err := parseutil.ParseCapacityString(p.MaxSizeRaw)
+		if err != nil {
+			return fmt.Errorf("error parsing max_size: %w", err)
+		}
+		p.MaxSize = int64(maxSize)
+		p.MaxSizeRaw = nil
+	}
+
 	result.Cache.Persist = &p
 
 	return nil
@@ -1215,6 +1293,43 @@ func parseTemplates(result *Config, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseTemplateGroups(result *Config, list *ast.ObjectList) error {
+	name := "template_group"
+
+	groupList := list.Filter(name)
+	if len(groupList.Items) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(groupList.Items))
+	for _, item := range groupList.Items {
+		var g TemplateGroup
+		if err := hcl.DecodeObject(&g, item.Val); err != nil {
+			return err
+		}
+
+		if len(item.Keys) != 1 {
+			return errors.New("template_group name must be specified")
+		}
+		g.Name = item.Keys[0].Token.Value().(string)
+		if names[g.Name] {
+			return fmt.Errorf("template_group[%s] is defined more than once", g.Name)
+		}
+		names[g.Name] = true
+
+		if g.CommandTimeoutRaw != nil {
+			var err error
+			if g.CommandTimeout, err = parseutil.ParseDurationSecond(g.CommandTimeoutRaw); err != nil {
+				return fmt.Errorf("template_group[%s]: error parsing command_timeout: %w", g.Name, err)
+			}
+			g.CommandTimeoutRaw = nil
+		}
+
+		result.TemplateGroups = append(result.TemplateGroups, &g)
+	}
+	return nil
+}
+
 func parseExec(result *Config, list *ast.ObjectList) error {
 	name := "exec"
 
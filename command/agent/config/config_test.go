@@ -2451,3 +2451,36 @@ func TestLoadConfigFile_Bad_EnvTemplates_DisalowedFields(t *testing.T) {
 		t.Fatal("expected an error from ValidateConfig: disallowed fields specified in env_template")
 	}
 }
+
+// TestLoadConfigFile_TemplateGroups verifies that template groups are parsed,
+// and that ValidateConfig errors when they do not match the templates.
+func TestLoadConfigFile_TemplateGroups(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-template-groups.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	expected := []*TemplateGroup{{
+		Name:           "tls",
+		Destinations:   []string{"/etc/tls/cert.pem", "/etc/tls/key.pem"},
+		Command:        []string{"systemctl reload nginx"},
+		CommandTimeout: 10 * time.Second,
+	}}
+	if diff := deep.Equal(config.TemplateGroups, expected); diff != nil {
+		t.Fatal(diff)
+	}
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("unexpected error from ValidateConfig: %s", err)
+	}
+
+	config.TemplateGroups[0].Destinations = append(config.TemplateGroups[0].Destinations, "/etc/tls/ca.pem")
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected an error from ValidateConfig: no template renders to the destination")
+	}
+
+	config.TemplateGroups[0].Destinations = []string{"/etc/tls/cert.pem", "/etc/tls/key.pem"}
+	config.Templates[0].Command = []string{"systemctl reload nginx"}
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected an error from ValidateConfig: grouped template has a command")
+	}
+}
@@ -0,0 +1,43 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+pid_file = "./pidfile"
+
+auto_auth {
+  method {
+    type      = "aws"
+    namespace = "/my-namespace"
+
+    config = {
+      role = "foobar"
+    }
+  }
+
+  sink {
+    type = "file"
+
+    config = {
+      path = "/tmp/file-foo"
+    }
+
+    aad     = "foobar"
+    dh_type = "curve25519"
+    dh_path = "/tmp/file-foo-dhpath"
+  }
+}
+
+template {
+  source      = "/path/on/disk/to/cert.ctmpl"
+  destination = "/etc/tls/cert.pem"
+}
+
+template {
+  source      = "/path/on/disk/to/key.ctmpl"
+  destination = "/etc/tls/key.pem"
+}
+
+template_group "tls" {
+  destinations    = ["/etc/tls/cert.pem", "/etc/tls/key.pem"]
+  command         = ["systemctl reload nginx"]
+  command_timeout = "10s"
+}
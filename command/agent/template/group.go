@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/consul-template/child"
+	ctconfig "github.com/hashicorp/consul-template/config"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/command/agent/config"
+)
+
+// defaultGroupCommandTimeout is the timeout of the commands of template groups
+// which do not configure one.
+const defaultGroupCommandTimeout = 30 * time.Second
+
+// groupFile is a file of a template group, rendered to a staging file next to
+// its destination until the files of the group are committed.
+type groupFile struct {
+	destination string
+	staged      string
+}
+
+// templateGroup commits the files of a group of templates to their
+// destinations all at once, and runs the command of the group once they are.
+type templateGroup struct {
+	name               string
+	files              []*groupFile
+	command            []string
+	commandTimeout     time.Duration
+	allowPartialChange bool
+
+	logger hclog.Logger
+}
+
+// stagedPath returns the path of the staging file of a destination.
+func stagedPath(destination string) string {
+	dir, file := filepath.Split(destination)
+	return filepath.Join(dir, "."+file+".staged")
+}
+
+// stageTemplateGroups returns the templates with those of the groups rendering
+// to staging files instead of their destinations, along with the groups.
+func stageTemplateGroups(templates []*ctconfig.TemplateConfig, groups []*config.TemplateGroup, logger hclog.Logger) ([]*ctconfig.TemplateConfig, []*templateGroup) {
+	if len(groups) == 0 {
+		return templates, nil
+	}
+
+	staged := make(map[string]string)
+	var tgs []*templateGroup
+	for _, group := range groups {
+		tg := &templateGroup{
+			name:               group.Name,
+			command:            group.Command,
+			commandTimeout:     group.CommandTimeout,
+			allowPartialChange: group.AllowPartialChange,
+			logger:             logger.With("template_group", group.Name),
+		}
+		if tg.commandTimeout == 0 {
+			tg.commandTimeout = defaultGroupCommandTimeout
+		}
+		for _, destination := range group.Destinations {
+			file := &groupFile{
+				destination: destination,
+				staged:      stagedPath(destination),
+			}
+			staged[destination] = file.staged
+			tg.files = append(tg.files, file)
+		}
+		tgs = append(tgs, tg)
+	}
+
+	result := make([]*ctconfig.TemplateConfig, 0, len(templates))
+	for _, template := range templates {
+		if template.Destination != nil {
+			if path, ok := staged[*template.Destination]; ok {
+				template = template.Copy()
+				template.Destination = &path
+			}
+		}
+		result = append(result, template)
+	}
+	return result, tgs
+}
+
+// commit renames the staging files of the group to their destinations, once
+// all of them are rendered and they changed, and runs the command of the
+// group. Either all the changed files are committed, or none of them is.
+func (g *templateGroup) commit(ctx context.Context) error {
+	var changed []*groupFile
+	for _, file := range g.files {
+		staged, err := os.ReadFile(file.staged)
+		if errors.Is(err, os.ErrNotExist) {
+			// Not all the templates of the group are rendered yet
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		committed, err := os.ReadFile(file.destination)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err != nil || !bytes.Equal(staged, committed) {
+			changed = append(changed, file)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if len(changed) < len(g.files) && !g.allowPartialChange {
+		g.logger.Debug("waiting for all the files of the template group to change", "changed", len(changed), "files", len(g.files))
+		return nil
+	}
+
+	if err := commitFiles(changed); err != nil {
+		return fmt.Errorf("failed to commit the files of template group %q: %w", g.name, err)
+	}
+	g.logger.Info("committed the files of the template group", "changed", len(changed))
+
+	if len(g.command) == 0 {
+		return nil
+	}
+	return g.runCommand(ctx)
+}
+
+// commitFiles links the staging files to temporary files next to their
+// destinations, and renames them to the destinations, restoring the previous
+// destinations if any rename fails.
+func commitFiles(files []*groupFile) error {
+	type pending struct {
+		file   *groupFile
+		temp   string
+		backup string
+	}
+	var pendings []*pending
+	defer func() {
+		for _, p := range pendings {
+			os.Remove(p.temp)
+			os.Remove(p.backup)
+		}
+	}()
+
+	for _, file := range files {
+		p := &pending{
+			file:   file,
+			temp:   file.staged + ".commit",
+			backup: file.staged + ".previous",
+		}
+		pendings = append(pendings, p)
+		os.Remove(p.temp)
+		os.Remove(p.backup)
+		if err := os.Link(file.staged, p.temp); err != nil {
+			return err
+		}
+		if err := os.Link(file.destination, p.backup); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			p.backup = ""
+		}
+	}
+
+	for i, p := range pendings {
+		if err := os.Rename(p.temp, p.file.destination); err != nil {
+			var errs *multierror.Error
+			errs = multierror.Append(errs, err)
+			for _, done := range pendings[:i] {
+				var err error
+				if done.backup != "" {
+					err = os.Rename(done.backup, done.file.destination)
+				} else {
+					err = os.Remove(done.file.destination)
+				}
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("failed to restore %q: %w", done.file.destination, err))
+				}
+			}
+			return errs.ErrorOrNil()
+		}
+	}
+	return nil
+}
+
+func (g *templateGroup) runCommand(ctx context.Context) error {
+	args, _, err := child.CommandPrep(g.command)
+	if err != nil {
+		return fmt.Errorf("unable to parse the command of template group %q: %w", g.name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.commandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("the command of template group %q failed: %w: %s", g.name, err, output)
+	}
+	g.logger.Debug("ran the command of the template group", "output", string(output))
+	return nil
+}
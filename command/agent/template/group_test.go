@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ctconfig "github.com/hashicorp/consul-template/config"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/sdk/helper/pointerutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTemplateGroup_Commit verifies that the files of a template group are
+// committed only once all of them changed, and that the command of the group
+// runs once they are.
+func TestTemplateGroup_Commit(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	other := filepath.Join(dir, "other.txt")
+	marker := filepath.Join(dir, "reloaded")
+
+	templates, groups := stageTemplateGroups([]*ctconfig.TemplateConfig{
+		{Destination: pointerutil.StringPtr(cert)},
+		{Destination: pointerutil.StringPtr(key)},
+		{Destination: pointerutil.StringPtr(other)},
+	}, []*config.TemplateGroup{{
+		Name:         "tls",
+		Destinations: []string{cert, key},
+		Command:      []string{"touch " + marker},
+	}}, hclog.NewNullLogger())
+	require.Equal(t, stagedPath(cert), *templates[0].Destination)
+	require.Equal(t, stagedPath(key), *templates[1].Destination)
+	require.Equal(t, other, *templates[2].Destination)
+	require.Len(t, groups, 1)
+	group := groups[0]
+
+	render := func(destination, contents string) {
+		require.NoError(t, os.WriteFile(stagedPath(destination), []byte(contents), 0o600))
+	}
+	requireContents := func(destination, contents string) {
+		actual, err := os.ReadFile(destination)
+		require.NoError(t, err)
+		require.Equal(t, contents, string(actual))
+	}
+
+	// Nothing is committed until every file is rendered
+	render(cert, "cert1")
+	require.NoError(t, group.commit(context.Background()))
+	require.NoFileExists(t, cert)
+
+	render(key, "key1")
+	require.NoError(t, group.commit(context.Background()))
+	requireContents(cert, "cert1")
+	requireContents(key, "key1")
+	require.FileExists(t, marker)
+	require.NoError(t, os.Remove(marker))
+
+	// Nothing is committed until every file changed
+	render(cert, "cert2")
+	require.NoError(t, group.commit(context.Background()))
+	requireContents(cert, "cert1")
+	require.NoFileExists(t, marker)
+
+	render(key, "key2")
+	require.NoError(t, group.commit(context.Background()))
+	requireContents(cert, "cert2")
+	requireContents(key, "key2")
+	require.FileExists(t, marker)
+}
+
+// TestTemplateGroup_CommitFailure verifies that none of the files of a group
+// is committed when any of them cannot be.
+func TestTemplateGroup_CommitFailure(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(cert, []byte("cert1"), 0o600))
+	require.NoError(t, os.WriteFile(stagedPath(cert), []byte("cert2"), 0o600))
+	require.NoError(t, os.WriteFile(stagedPath(key), []byte("key2"), 0o600))
+
+	// The key destination is a directory, which cannot be replaced
+	require.NoError(t, os.MkdirAll(filepath.Join(key, "dir"), 0o700))
+
+	err := commitFiles([]*groupFile{
+		{destination: cert, staged: stagedPath(cert)},
+		{destination: key, staged: stagedPath(key)},
+	})
+	require.Error(t, err)
+	actual, err := os.ReadFile(cert)
+	require.NoError(t, err)
+	require.Equal(t, "cert1", string(actual))
+	require.NoFileExists(t, stagedPath(cert)+".commit")
+	require.NoFileExists(t, stagedPath(cert)+".previous")
+}
@@ -66,6 +66,10 @@ type Server struct {
 	// from the runner in the event we're using exit after auth.
 	lookupMap map[string][]*ctconfig.TemplateConfig
 
+	// groups are the groups of templates whose files are committed to their
+	// destinations all at once.
+	groups []*templateGroup
+
 	DoneCh  chan struct{}
 	stopped *atomic.Bool
 
@@ -109,6 +113,10 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 		return nil
 	}
 
+	// Templates of groups are rendered to staging files, committed once
+	// every file of their group is rendered
+	templates, ts.groups = stageTemplateGroups(templates, ts.config.AgentConfig.TemplateGroups, ts.logger)
+
 	// construct a consul template vault config based the agents vault
 	// configuration
 	var runnerConfig *ctconfig.Config
@@ -218,6 +226,11 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 
 		case <-ts.runner.TemplateRenderedCh():
 			// A template has been rendered, figure out what to do
+			for _, group := range ts.groups {
+				if err := group.commit(ctx); err != nil {
+					ts.logger.Error("template server failed to commit template group", "error", err)
+				}
+			}
 			events := ts.runner.RenderEvents()
 
 			// events are keyed by template ID, and can be matched up to the id's from
@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agentproxyshared/auth"
+)
+
+const (
+	defaultQuotePath = "tpm2_quote"
+	defaultPCRs      = "0,1,2,3,4,5,6,7"
+)
+
+type tpmMethod struct {
+	logger    hclog.Logger
+	mountPath string
+
+	machine   string
+	akContext string
+	pcrs      string
+	quotePath string
+	tcti      string
+}
+
+// NewTPMAuthMethod returns an auto-auth method which logs in with a quote
+// from the local TPM, made with the tpm2_quote tool of tpm2-tools.
+func NewTPMAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &tpmMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+		pcrs:      defaultPCRs,
+		quotePath: defaultQuotePath,
+	}
+
+	for key, field := range map[string]*string{
+		"machine":         &a.machine,
+		"ak_context":      &a.akContext,
+		"pcrs":            &a.pcrs,
+		"tpm2_quote_path": &a.quotePath,
+		"tcti":            &a.tcti,
+	} {
+		raw, ok := conf.Config[key]
+		if !ok {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("could not convert '%s' config value to string", key)
+		}
+		*field = value
+	}
+
+	switch {
+	case a.machine == "":
+		return nil, errors.New("missing 'machine' value")
+	case a.akContext == "":
+		return nil, errors.New("missing 'ak_context' value")
+	case a.quotePath == "":
+		return nil, errors.New("'tpm2_quote_path' value is empty")
+	}
+	for _, pcr := range strings.Split(a.pcrs, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(pcr)); err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid PCR %q in 'pcrs' value", pcr)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *tpmMethod) Authenticate(ctx context.Context, client *api.Client) (string, http.Header, map[string]interface{}, error) {
+	a.logger.Trace("beginning authentication")
+
+	// The challenge endpoint is unauthenticated; clear any token left on
+	// the client.
+	clonedClient, err := client.Clone()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error cloning client to fetch challenge: %w", err)
+	}
+	clonedClient.ClearToken()
+	resp, err := clonedClient.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/challenge", a.mountPath))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error fetching challenge: %w", err)
+	}
+	if resp == nil || resp.Data == nil {
+		return "", nil, nil, errors.New("empty response fetching challenge")
+	}
+	nonce, ok := resp.Data["nonce"].(string)
+	if !ok || nonce == "" {
+		return "", nil, nil, errors.New("no nonce in challenge response")
+	}
+
+	quote, signature, err := a.quote(ctx, nonce)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), nil, map[string]interface{}{
+		"machine":   a.machine,
+		"nonce":     nonce,
+		"quote":     base64.StdEncoding.EncodeToString(quote),
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// quote has the TPM quote the configured PCRs over the hex encoded nonce,
+// returning the attestation structure and its signature.
+func (a *tpmMethod) quote(ctx context.Context, nonce string) ([]byte, []byte, error) {
+	dir, err := os.MkdirTemp("", "vault-tpm-quote")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating directory for quote: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	messagePath := filepath.Join(dir, "quote.msg")
+	signaturePath := filepath.Join(dir, "quote.sig")
+	args := []string{
+		"--key-context", a.akContext,
+		"--pcr-list", "sha256:" + strings.ReplaceAll(a.pcrs, " ", ""),
+		"--qualification", nonce,
+		"--hash-algorithm", "sha256",
+		"--message", messagePath,
+		"--signature", signaturePath,
+		"--format", "plain",
+	}
+	if a.tcti != "" {
+		args = append(args, "--tcti", a.tcti)
+	}
+
+	cmd := exec.CommandContext(ctx, a.quotePath, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("error running %s: %w: %s", a.quotePath, err, strings.TrimSpace(string(out)))
+	}
+
+	quote, err := os.ReadFile(messagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading quote: %w", err)
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading quote signature: %w", err)
+	}
+	return quote, signature, nil
+}
+
+func (a *tpmMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *tpmMethod) CredSuccess() {
+}
+
+func (a *tpmMethod) Shutdown() {
+}
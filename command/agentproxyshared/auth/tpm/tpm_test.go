@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package tpm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agentproxyshared/auth"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+)
+
+func TestNewTPMAuthMethod_Config(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Trace)
+
+	for name, config := range map[string]map[string]interface{}{
+		"missing machine":    {"ak_context": "0x81010002"},
+		"missing ak_context": {"machine": "host-1"},
+		"invalid pcrs":       {"machine": "host-1", "ak_context": "0x81010002", "pcrs": "0,x"},
+		"non-string value":   {"machine": "host-1", "ak_context": 0x81010002},
+	} {
+		_, err := NewTPMAuthMethod(&auth.AuthConfig{
+			Logger:    logger.Named("auth.method"),
+			MountPath: "auth/tpm",
+			Config:    config,
+		})
+		if err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	method, err := NewTPMAuthMethod(&auth.AuthConfig{
+		Logger:    logger.Named("auth.method"),
+		MountPath: "auth/tpm",
+		Config: map[string]interface{}{
+			"machine":    "host-1",
+			"ak_context": "0x81010002",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := method.(*tpmMethod)
+	if a.pcrs != defaultPCRs || a.quotePath != defaultQuotePath {
+		t.Fatalf("expected defaults, got pcrs %q and tpm2_quote_path %q", a.pcrs, a.quotePath)
+	}
+}
+
+func TestTPMAuthMethod_Quote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a shell")
+	}
+
+	// A stand-in for tpm2_quote, which writes its arguments as the quote
+	// and a fixed signature.
+	dir := t.TempDir()
+	script := filepath.Join(dir, "tpm2_quote")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+args="$*"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --message) msg="$2"; shift ;;
+    --signature) sig="$2"; shift ;;
+  esac
+  shift
+done
+printf '%s' "$args" > "$msg"
+printf 'signature' > "$sig"
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method, err := NewTPMAuthMethod(&auth.AuthConfig{
+		Logger:    logging.NewVaultLogger(log.Trace),
+		MountPath: "auth/tpm",
+		Config: map[string]interface{}{
+			"machine":         "host-1",
+			"ak_context":      "0x81010002",
+			"pcrs":            "0, 7",
+			"tpm2_quote_path": script,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote, signature, err := method.(*tpmMethod).quote(context.Background(), "abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(signature) != "signature" {
+		t.Fatalf("unexpected signature %q", signature)
+	}
+	for _, arg := range []string{"--key-context 0x81010002", "--pcr-list sha256:0,7", "--qualification abcd", "--format plain"} {
+		if !strings.Contains(string(quote), arg) {
+			t.Fatalf("expected %q in arguments %q", arg, quote)
+		}
+	}
+}
@@ -6,11 +6,14 @@ package cacheboltdb
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/golang/protobuf/proto"
 	bolt "github.com/hashicorp-forge/bbolt"
 	"github.com/hashicorp/go-hclog"
@@ -68,6 +71,14 @@ const (
 	RetrievalTokenMaterial = "retrieval-token-material"
 )
 
+// dataBuckets are the buckets holding cached items, which count towards the
+// size quota of the cache.
+var dataBuckets = []string{TokenType, LeaseType, StaticSecretType, TokenCapabilitiesType}
+
+// ErrQuotaExceeded is returned when an item cannot be stored without the size
+// of the cache exceeding its quota, even once every lease is evicted.
+var ErrQuotaExceeded = errors.New("persistent cache size quota exceeded")
+
 // BoltStorage is a persistent cache using a bolt db. Items are organized with
 // the version and bootstrapping items in the "meta" bucket, and tokens, auth
 // leases, and secret leases in their own buckets.
@@ -76,6 +87,13 @@ type BoltStorage struct {
 	logger  hclog.Logger
 	wrapper wrapping.Wrapper
 	aad     string
+	maxSize int64
+
+	// sizeLock protects size, the total size of the cached items, and
+	// evicted, the IDs of the leases evicted to stay within maxSize.
+	sizeLock sync.Mutex
+	size     int64
+	evicted  map[string]struct{}
 }
 
 // BoltStorageConfig is the collection of input parameters for setting up bolt
@@ -85,6 +103,10 @@ type BoltStorageConfig struct {
 	Logger  hclog.Logger
 	Wrapper wrapping.Wrapper
 	AAD     string
+
+	// MaxSize is the quota, in bytes, of the size of the cached items, above
+	// which the oldest leases are evicted. Zero is unlimited.
+	MaxSize int64
 }
 
 // NewBoltStorage opens a new bolt db at the specified file path and returns it.
@@ -107,7 +129,25 @@ func NewBoltStorage(config *BoltStorageConfig) (*BoltStorage, error) {
 		logger:  config.Logger,
 		wrapper: config.Wrapper,
 		aad:     config.AAD,
+		maxSize: config.MaxSize,
+		evicted: make(map[string]struct{}),
 	}
+	err = db.View(func(tx *bolt.Tx) error {
+		for _, name := range dataBuckets {
+			if err := tx.Bucket([]byte(name)).ForEach(func(_, value []byte) error {
+				bs.size += int64(len(value))
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	bs.emitSize()
 	return bs, nil
 }
 
@@ -249,7 +289,9 @@ func (b *BoltStorage) Set(ctx context.Context, id string, plaintext []byte, inde
 		return err
 	}
 
-	return b.db.Update(func(tx *bolt.Tx) error {
+	b.sizeLock.Lock()
+	defer b.sizeLock.Unlock()
+	err = b.db.Update(func(tx *bolt.Tx) error {
 		var key []byte
 		switch indexType {
 		case LeaseType:
@@ -278,13 +320,102 @@ func (b *BoltStorage) Set(ctx context.Context, id string, plaintext []byte, inde
 		if s == nil {
 			return fmt.Errorf("bucket %q not found", indexType)
 		}
-		return s.Put(key, protoBlob)
+		size := b.size - int64(len(s.Get(key))) + int64(len(protoBlob))
+		if err := s.Put(key, protoBlob); err != nil {
+			return err
+		}
+		if b.maxSize > 0 && size > b.maxSize {
+			size, err = b.evictLeases(tx, size, key, indexType)
+			if err != nil {
+				return err
+			}
+		}
+		b.size = size
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	delete(b.evicted, id)
+	b.emitSize()
+	return nil
+}
+
+// evictLeases deletes the oldest leases, other than the one of the given key
+// just stored, until the size of the cache is within its quota, and returns
+// the size once evicted. Call with sizeLock held.
+func (b *BoltStorage) evictLeases(tx *bolt.Tx, size int64, storedKey []byte, storedType string) (int64, error) {
+	leases := tx.Bucket([]byte(LeaseType))
+	lookup := tx.Bucket([]byte(lookupType))
+
+	ids := make(map[string]string)
+	if err := lookup.ForEach(func(id, key []byte) error {
+		ids[string(key)] = string(id)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	// Keys are ordered by creation, so the oldest leases come first
+	var keys [][]byte
+	cursor := leases.Cursor()
+	for key, value := cursor.First(); key != nil && size > b.maxSize; key, value = cursor.Next() {
+		if storedType == LeaseType && string(key) == string(storedKey) {
+			continue
+		}
+		size -= int64(len(value))
+		keys = append(keys, key)
+	}
+	if size > b.maxSize {
+		return 0, ErrQuotaExceeded
+	}
+
+	var evicted []string
+	for _, key := range keys {
+		if err := leases.Delete(key); err != nil {
+			return 0, fmt.Errorf("failed to evict lease: %w", err)
+		}
+		if id, ok := ids[string(key)]; ok {
+			if err := lookup.Delete([]byte(id)); err != nil {
+				return 0, fmt.Errorf("failed to evict lease %q: %w", id, err)
+			}
+			evicted = append(evicted, id)
+		}
+	}
+
+	for _, id := range evicted {
+		b.evicted[id] = struct{}{}
+	}
+	if len(evicted) > 0 {
+		b.logger.Debug("evicted leases from persistent cache to stay within quota", "count", len(evicted), "max_size", b.maxSize)
+		metrics.IncrCounter([]string{"agent", "cache", "persist", "evicted"}, float32(len(evicted)))
+	}
+	return size, nil
+}
+
+// emitSize reports the size of the cached items. Call with sizeLock held or
+// before b is shared.
+func (b *BoltStorage) emitSize() {
+	metrics.SetGauge([]string{"agent", "cache", "persist", "size"}, float32(b.size))
+}
+
+// Size returns the total size, in bytes, of the cached items.
+func (b *BoltStorage) Size() int64 {
+	b.sizeLock.Lock()
+	defer b.sizeLock.Unlock()
+	return b.size
 }
 
 // Delete an index (token or lease) by key from bolt storage
 func (b *BoltStorage) Delete(id string, indexType string) error {
-	return b.db.Update(func(tx *bolt.Tx) error {
+	b.sizeLock.Lock()
+	defer b.sizeLock.Unlock()
+	if _, ok := b.evicted[id]; ok && indexType == LeaseType {
+		delete(b.evicted, id)
+		return nil
+	}
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
 		key := []byte(id)
 		if indexType == LeaseType {
 			key = tx.Bucket([]byte(lookupType)).Get(key)
@@ -302,12 +433,19 @@ func (b *BoltStorage) Delete(id string, indexType string) error {
 		if bucket == nil {
 			return fmt.Errorf("bucket %q not found during delete", indexType)
 		}
+		size := b.size - int64(len(bucket.Get(key)))
 		if err := bucket.Delete(key); err != nil {
 			return fmt.Errorf("failed to delete %q from %q bucket: %w", id, indexType, err)
 		}
+		b.size = size
 		b.logger.Trace("deleted index from bolt db", "id", id)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	b.emitSize()
+	return nil
 }
 
 func (b *BoltStorage) decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
@@ -422,7 +560,9 @@ func (b *BoltStorage) Close() error {
 // Clear the boltdb by deleting all the token and lease buckets and recreating
 // the schema/layout
 func (b *BoltStorage) Clear() error {
-	return b.db.Update(func(tx *bolt.Tx) error {
+	b.sizeLock.Lock()
+	defer b.sizeLock.Unlock()
+	err := b.db.Update(func(tx *bolt.Tx) error {
 		for _, name := range []string{TokenType, LeaseType, lookupType, StaticSecretType, TokenCapabilitiesType} {
 			b.logger.Trace("deleting bolt bucket", "name", name)
 			if err := tx.DeleteBucket([]byte(name)); err != nil {
@@ -431,6 +571,42 @@ func (b *BoltStorage) Clear() error {
 		}
 		return createBoltSchema(tx, storageVersion)
 	})
+	if err != nil {
+		return err
+	}
+	b.size = 0
+	b.evicted = make(map[string]struct{})
+	b.emitSize()
+	return nil
+}
+
+// Check verifies the integrity of the bolt db pages, and that every cached
+// item can be decrypted, and returns the problems found.
+func (b *BoltStorage) Check(ctx context.Context) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		var errs *multierror.Error
+		for err := range tx.Check() {
+			errs = multierror.Append(errs, err)
+		}
+		if errs.ErrorOrNil() != nil {
+			return errs
+		}
+
+		for _, name := range dataBuckets {
+			tx.Bucket([]byte(name)).ForEach(func(key, ciphertext []byte) error {
+				if _, err := b.decrypt(ctx, ciphertext); err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("error decrypting %s entry %s: %w", name, key, err))
+				}
+				return nil
+			})
+		}
+		if value := tx.Bucket([]byte(metaBucketName)).Get([]byte(AutoAuthToken)); value != nil {
+			if _, err := b.decrypt(ctx, value); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("error decrypting auto-auth token: %w", err))
+			}
+		}
+		return errs.ErrorOrNil()
+	})
 }
 
 // DBFileExists checks whether the vault agent cache file at `filePath` exists
@@ -446,3 +622,14 @@ func DBFileExists(path string) (bool, error) {
 		return false, fmt.Errorf("failed to check if bolt file exists at path %s: %w", path, err)
 	}
 }
+
+// SetAsideDBFile renames the vault agent cache file at `path`, for a new one to
+// be created in its place, and returns the new name of the file.
+func SetAsideDBFile(path string) (string, error) {
+	dbFile := filepath.Join(path, DatabaseFileName)
+	corruptFile := fmt.Sprintf("%s.corrupt-%d", dbFile, time.Now().Unix())
+	if err := os.Rename(dbFile, corruptFile); err != nil {
+		return "", fmt.Errorf("failed to rename bolt file at path %s: %w", path, err)
+	}
+	return corruptFile, nil
+}
@@ -88,6 +88,72 @@ func TestBoltDelete(t *testing.T) {
 	assert.Equal(t, []byte("hello2"), secrets[0])
 }
 
+// TestBolt_MaxSize verifies that the oldest leases are evicted for the cache to
+// stay within its size quota, and that evicted leases can still be deleted.
+func TestBolt_MaxSize(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+	wrapper := getTestKeyManager(t).Wrapper()
+
+	b, err := NewBoltStorage(&BoltStorageConfig{
+		Path:    path,
+		Logger:  hclog.Default(),
+		Wrapper: wrapper,
+	})
+	require.NoError(t, err)
+	require.NoError(t, b.Set(ctx, "lease1", []byte("hello1"), LeaseType))
+	itemSize := b.Size()
+	require.NoError(t, b.Close())
+
+	b, err = NewBoltStorage(&BoltStorageConfig{
+		Path:    path,
+		Logger:  hclog.Default(),
+		Wrapper: wrapper,
+		MaxSize: 2 * itemSize,
+	})
+	require.NoError(t, err)
+	require.Equal(t, itemSize, b.Size())
+
+	require.NoError(t, b.Set(ctx, "lease2", []byte("hello2"), LeaseType))
+	require.NoError(t, b.Set(ctx, "lease3", []byte("hello3"), LeaseType))
+	require.Equal(t, 2*itemSize, b.Size())
+	leases, err := b.GetByType(ctx, LeaseType)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("hello2"), []byte("hello3")}, leases)
+
+	require.NoError(t, b.Delete("lease1", LeaseType))
+	require.NoError(t, b.Delete("lease2", LeaseType))
+	require.Equal(t, itemSize, b.Size())
+
+	require.ErrorIs(t, b.Set(ctx, "secret", []byte(strings.Repeat("a", int(2*itemSize))), StaticSecretType), ErrQuotaExceeded)
+}
+
+// TestBolt_Check verifies that items which cannot be decrypted fail the
+// integrity check.
+func TestBolt_Check(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	b, err := NewBoltStorage(&BoltStorageConfig{
+		Path:    path,
+		Logger:  hclog.Default(),
+		Wrapper: getTestKeyManager(t).Wrapper(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, b.Set(ctx, "lease1", []byte("hello1"), LeaseType))
+	require.NoError(t, b.Check(ctx))
+	require.NoError(t, b.Close())
+
+	b, err = NewBoltStorage(&BoltStorageConfig{
+		Path:    path,
+		Logger:  hclog.Default(),
+		Wrapper: getTestKeyManager(t).Wrapper(),
+	})
+	require.NoError(t, err)
+	defer b.Close()
+	require.Error(t, b.Check(ctx))
+}
+
 func TestBoltClear(t *testing.T) {
 	ctx := context.Background()
 
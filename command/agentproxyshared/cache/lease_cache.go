@@ -917,7 +917,13 @@ func hashStaticSecretIndex(unhashedIndex string) string {
 // used in the request. We care only about the path.
 // This will return "" if the index does not have a /v1 prefix, and therefore
 // cannot be a static secret.
+// Requests with query parameters, such as reads of a specific KV v2 version or
+// lists, are not cached either, as events only identify the secrets they
+// concern by path.
 func computeStaticSecretCacheIndex(req *SendRequest) string {
+	if req.Request.URL.RawQuery != "" {
+		return ""
+	}
 	path := getStaticSecretPathFromRequest(req)
 	if path == "" {
 		return path
@@ -181,6 +181,10 @@ func TestCache_ComputeStaticSecretIndexID(t *testing.T) {
 	expectedIndex = "b117a962f19f17fa372c8681cadcd6fd370d28ee6e0a7012196b780bef601b53"
 	index2 := computeStaticSecretCacheIndex(req)
 	require.Equal(t, expectedIndex, index2)
+
+	// Reads of specific KV v2 versions are not cached
+	req.Request.URL.RawQuery = "version=1"
+	require.Equal(t, "", computeStaticSecretCacheIndex(req))
 }
 
 // Test_GetStaticSecretPathFromRequestNoNamespaces tests that getStaticSecretPathFromRequest
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -155,15 +156,17 @@ func (updater *StaticSecretCacheUpdater) streamStaticSecretEvents(ctx context.Co
 			}
 			modified, ok := metadata["modified"].(string)
 			if ok && modified == "true" {
-				path, ok := metadata["path"].(string)
-				if !ok {
-					return fmt.Errorf("unexpected event format when decoding 'path' element, message: %s\nerror: %w", string(message), err)
-				}
-				err := updater.updateStaticSecret(ctx, path)
+				paths, err := staticSecretEventPaths(data, metadata)
 				if err != nil {
-					// While we are kind of 'missing' an event this way, re-calling this function will
-					// result in the secret remaining up to date.
-					return fmt.Errorf("error updating static secret: path: %q, message: %s error: %w", path, message, err)
+					return fmt.Errorf("unexpected event format, message: %s\nerror: %w", string(message), err)
+				}
+				for _, path := range paths {
+					err := updater.updateStaticSecret(ctx, path)
+					if err != nil {
+						// While we are kind of 'missing' an event this way, re-calling this function will
+						// result in the secret remaining up to date.
+						return fmt.Errorf("error updating static secret: path: %q, message: %s error: %w", path, message, err)
+					}
 				}
 			} else {
 				// This is an event we're not interested in, ignore it and
@@ -176,6 +179,50 @@ func (updater *StaticSecretCacheUpdater) streamStaticSecretEvents(ctx context.Co
 	return nil
 }
 
+// kvV2EndpointPrefixes are the prefixes, relative to their mount, of the KV v2
+// endpoints whose events concern a secret.
+var kvV2EndpointPrefixes = []string{"data/", "metadata/", "subkeys/", "delete/", "undelete/", "destroy/"}
+
+// kvV2ReadPrefixes are the prefixes, relative to their mount, of the KV v2
+// endpoints whose reads of a secret are cached.
+var kvV2ReadPrefixes = []string{"data/", "metadata/", "subkeys/"}
+
+// staticSecretEventPaths returns the paths of the cached secrets which an
+// event modifying a secret makes stale. A KV v2 event makes all the cached
+// reads of its secret stale, as, for example, soft-deleting a secret changes
+// both its data and its metadata.
+func staticSecretEventPaths(data, metadata map[string]interface{}) ([]string, error) {
+	path, ok := metadata["path"].(string)
+	if !ok {
+		return nil, errors.New("unexpected event format when decoding 'path' element")
+	}
+	paths := []string{path}
+
+	eventType, _ := data["event_type"].(string)
+	if !strings.HasPrefix(eventType, "kv-v2/") {
+		return paths, nil
+	}
+	pluginInfo, _ := data["plugin_info"].(map[string]interface{})
+	mountPath, _ := pluginInfo["mount_path"].(string)
+	if mountPath == "" || !strings.HasPrefix(path, mountPath) {
+		return paths, nil
+	}
+	relativePath := strings.TrimPrefix(path, mountPath)
+	for _, prefix := range kvV2EndpointPrefixes {
+		if !strings.HasPrefix(relativePath, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(relativePath, prefix)
+		for _, readPrefix := range kvV2ReadPrefixes {
+			if readPath := mountPath + readPrefix + key; readPath != path {
+				paths = append(paths, readPath)
+			}
+		}
+		break
+	}
+	return paths, nil
+}
+
 // preEventStreamUpdate is called after successful connection to the event system but before
 // we process any events, to ensure we don't miss any updates.
 // In some cases, this will result in multiple processing of the same updates, but
@@ -601,6 +601,35 @@ func TestUpdateStaticSecret_HandlesNonCachedPaths(t *testing.T) {
 	require.Nil(t, err)
 }
 
+// TestStaticSecretEventPaths tests that KV v2 events make all the cached reads
+// of their secret stale, and KV v1 events only their path.
+func TestStaticSecretEventPaths(t *testing.T) {
+	t.Parallel()
+	kvV2Data := func(eventType string) map[string]interface{} {
+		return map[string]interface{}{
+			"event_type": eventType,
+			"plugin_info": map[string]interface{}{
+				"mount_path": "secret/",
+			},
+		}
+	}
+
+	paths, err := staticSecretEventPaths(kvV2Data("kv-v2/data-write"), map[string]interface{}{"path": "secret/data/foo/bar"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret/data/foo/bar", "secret/metadata/foo/bar", "secret/subkeys/foo/bar"}, paths)
+
+	paths, err = staticSecretEventPaths(kvV2Data("kv-v2/delete"), map[string]interface{}{"path": "secret/delete/foo"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret/delete/foo", "secret/data/foo", "secret/metadata/foo", "secret/subkeys/foo"}, paths)
+
+	paths, err = staticSecretEventPaths(map[string]interface{}{"event_type": "kv-v1/write"}, map[string]interface{}{"path": "kv/foo"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"kv/foo"}, paths)
+
+	_, err = staticSecretEventPaths(kvV2Data("kv-v2/data-write"), map[string]interface{}{})
+	require.Error(t, err)
+}
+
 // TestPreEventStreamUpdate tests that preEventStreamUpdate correctly
 // updates old static secrets in the cache.
 func TestPreEventStreamUpdate(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/armon/go-metrics"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/command/agentproxyshared/auth"
 	"github.com/hashicorp/vault/command/agentproxyshared/auth/alicloud"
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/vault/command/agentproxyshared/auth/ldap"
 	"github.com/hashicorp/vault/command/agentproxyshared/auth/oci"
 	token_file "github.com/hashicorp/vault/command/agentproxyshared/auth/token-file"
+	"github.com/hashicorp/vault/command/agentproxyshared/auth/tpm"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache/cacheboltdb"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache/cachememdb"
@@ -61,6 +63,8 @@ func GetAutoAuthMethodFromConfig(autoAuthMethodType string, authConfig *auth.Aut
 		return oci.NewOCIAuthMethod(authConfig, vaultAddress)
 	case "token_file":
 		return token_file.NewTokenFileAuthMethod(authConfig)
+	case "tpm":
+		return tpm.NewTPMAuthMethod(authConfig)
 	case "pcf": // Deprecated.
 		return cf.NewCFAuthMethod(authConfig)
 	case "ldap":
@@ -77,6 +81,16 @@ type PersistConfig struct {
 	KeepAfterImport         bool   `hcl:"keep_after_import"`
 	ExitOnErr               bool   `hcl:"exit_on_err"`
 	ServiceAccountTokenFile string `hcl:"service_account_token_file"`
+
+	// AutoRecover sets aside a persistent cache file which fails its
+	// integrity check or cannot be restored, and starts an empty one,
+	// rebuilt from the server, instead of failing.
+	AutoRecover bool `hcl:"auto_recover"`
+
+	// MaxSize is the quota, in bytes, of the size of the persistent cache,
+	// above which the oldest leases are evicted from it. Zero is unlimited.
+	MaxSize    int64       `hcl:"-"`
+	MaxSizeRaw interface{} `hcl:"max_size"`
 }
 
 // AddPersistentStorageToLeaseCache adds persistence to a lease cache, based on a given PersistConfig
@@ -113,116 +127,161 @@ func AddPersistentStorageToLeaseCache(ctx context.Context, leaseCache *cache.Lea
 		return nil, "", fmt.Errorf("failed to check if bolt file exists at path %s: %w", persistConfig.Path, err)
 	}
 	if dbFileExists {
-		// Open the bolt file, but wait to setup Encryption
-		ps, err := cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
-			Path:   persistConfig.Path,
-			Logger: logger.Named("cacheboltdb"),
-		})
-		if err != nil {
-			return nil, "", fmt.Errorf("error opening persistent cache %v", err)
+		closer, previousToken, err := restorePersistentStorage(ctx, leaseCache, persistConfig, aad, logger)
+		if err == nil || !persistConfig.AutoRecover {
+			return closer, previousToken, err
 		}
 
-		// Get the token from bolt for retrieving the encryption key,
-		// then setup encryption so that restore is possible
-		token, err := ps.GetRetrievalToken()
+		// The cache is rebuilt from the server as requests are proxied
+		logger.Warn("persistent cache is unusable, rebuilding it", "error", err)
+		metrics.IncrCounter([]string{"agent", "cache", "persist", "recovered"}, 1)
+		if err := leaseCache.Flush(); err != nil {
+			return nil, "", fmt.Errorf("failed to flush cache restored from unusable persistent cache: %w", err)
+		}
+		corruptFile, err := cacheboltdb.SetAsideDBFile(persistConfig.Path)
 		if err != nil {
-			return nil, "", fmt.Errorf("error getting retrieval token from persistent cache: %w", err)
+			return nil, "", fmt.Errorf("failed to set aside unusable persistent cache: %w", err)
 		}
+		logger.Warn("unusable persistent cache file set aside", "path", corruptFile)
+	}
 
-		if err := ps.Close(); err != nil {
-			return nil, "", fmt.Errorf("failed to close persistent cache file after getting retrieval token: %w", err)
-		}
+	km, err := keymanager.NewPassthroughKeyManager(ctx, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to configure persistence encryption for cache: %w", err)
+	}
+	ps, err := cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
+		Path:    persistConfig.Path,
+		Logger:  logger.Named("cacheboltdb"),
+		Wrapper: km.Wrapper(),
+		AAD:     aad,
+		MaxSize: persistConfig.MaxSize,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating persistent cache: %w", err)
+	}
+	logger.Info("configured persistent storage", "path", persistConfig.Path)
 
-		km, err := keymanager.NewPassthroughKeyManager(ctx, token)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to configure persistence encryption for cache: %w", err)
+	// Stash the key material in bolt
+	token, err := km.RetrievalToken(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting persistence key: %w", err)
+	}
+	if err := ps.StoreRetrievalToken(token); err != nil {
+		return nil, "", fmt.Errorf("error setting key in persistent cache: %w", err)
+	}
+
+	leaseCache.SetPersistentStorage(ps)
+	return ps.Close, "", nil
+}
+
+// restorePersistentStorage restores the lease cache from an existing persistent
+// cache file. Restore errors are only returned if exit_on_err or auto_recover
+// is set, for the file to be rebuilt in the latter case.
+func restorePersistentStorage(ctx context.Context, leaseCache *cache.LeaseCache, persistConfig *PersistConfig, aad string, logger log.Logger) (func() error, string, error) {
+	restoreErr := func(err error) error {
+		if persistConfig.AutoRecover {
+			return err
 		}
+		return fmt.Errorf("exiting with error as exit_on_err is set to true")
+	}
+	strict := persistConfig.ExitOnErr || persistConfig.AutoRecover
 
-		// Open the bolt file with the wrapper provided
-		ps, err = cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
-			Path:    persistConfig.Path,
-			Logger:  logger.Named("cacheboltdb"),
-			Wrapper: km.Wrapper(),
-			AAD:     aad,
-		})
-		if err != nil {
-			return nil, "", fmt.Errorf("error opening persistent cache with wrapper: %w", err)
+	// Open the bolt file, but wait to setup Encryption
+	ps, err := cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
+		Path:   persistConfig.Path,
+		Logger: logger.Named("cacheboltdb"),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening persistent cache %v", err)
+	}
+
+	// Get the token from bolt for retrieving the encryption key,
+	// then setup encryption so that restore is possible
+	token, err := ps.GetRetrievalToken()
+	if err != nil {
+		ps.Close()
+		return nil, "", fmt.Errorf("error getting retrieval token from persistent cache: %w", err)
+	}
+
+	if err := ps.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close persistent cache file after getting retrieval token: %w", err)
+	}
+
+	km, err := keymanager.NewPassthroughKeyManager(ctx, token)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to configure persistence encryption for cache: %w", err)
+	}
+
+	// Open the bolt file with the wrapper provided
+	ps, err = cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
+		Path:    persistConfig.Path,
+		Logger:  logger.Named("cacheboltdb"),
+		Wrapper: km.Wrapper(),
+		AAD:     aad,
+		MaxSize: persistConfig.MaxSize,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening persistent cache with wrapper: %w", err)
+	}
+
+	// Verify the integrity of the file before restoring from it
+	if persistConfig.AutoRecover {
+		if err := ps.Check(ctx); err != nil {
+			ps.Close()
+			return nil, "", fmt.Errorf("persistent cache failed integrity check: %w", err)
 		}
+	}
 
-		// Restore anything in the persistent cache to the memory cache
-		if err := leaseCache.Restore(ctx, ps); err != nil {
-			logger.Error(fmt.Sprintf("error restoring in-memory cache from persisted file: %v", err))
-			if persistConfig.ExitOnErr {
-				return nil, "", fmt.Errorf("exiting with error as exit_on_err is set to true")
-			}
+	// Restore anything in the persistent cache to the memory cache
+	if err := leaseCache.Restore(ctx, ps); err != nil {
+		logger.Error(fmt.Sprintf("error restoring in-memory cache from persisted file: %v", err))
+		if strict {
+			ps.Close()
+			return nil, "", restoreErr(err)
 		}
-		logger.Info("loaded memcache from persistent storage")
+	}
+	logger.Info("loaded memcache from persistent storage")
 
-		// Check for previous auto-auth token
-		oldTokenBytes, err := ps.GetAutoAuthToken(ctx)
-		if err != nil {
-			logger.Error(fmt.Sprintf("error in fetching previous auto-auth token: %v", err))
-			if persistConfig.ExitOnErr {
-				return nil, "", fmt.Errorf("exiting with error as exit_on_err is set to true")
-			}
+	// Check for previous auto-auth token
+	oldTokenBytes, err := ps.GetAutoAuthToken(ctx)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error in fetching previous auto-auth token: %v", err))
+		if strict {
+			ps.Close()
+			return nil, "", restoreErr(err)
 		}
-		var previousToken string
-		if len(oldTokenBytes) > 0 {
-			oldToken, err := cachememdb.Deserialize(oldTokenBytes)
-			if err != nil {
-				logger.Error(fmt.Sprintf("error in deserializing previous auto-auth token cache entryn: %v", err))
-				if persistConfig.ExitOnErr {
-					return nil, "", fmt.Errorf("exiting with error as exit_on_err is set to true")
-				}
+	}
+	var previousToken string
+	if len(oldTokenBytes) > 0 {
+		oldToken, err := cachememdb.Deserialize(oldTokenBytes)
+		if err != nil {
+			logger.Error(fmt.Sprintf("error in deserializing previous auto-auth token cache entryn: %v", err))
+			if strict {
+				ps.Close()
+				return nil, "", restoreErr(err)
 			}
+		} else {
 			previousToken = oldToken.Token
 		}
+	}
 
-		// If keep_after_import true, set persistent storage layer in
-		// leaseCache, else remove db file
-		if persistConfig.KeepAfterImport {
-			leaseCache.SetPersistentStorage(ps)
-			return ps.Close, previousToken, nil
-		} else {
-			if err := ps.Close(); err != nil {
-				logger.Warn(fmt.Sprintf("failed to close persistent cache file: %s", err))
-			}
-			dbFile := filepath.Join(persistConfig.Path, cacheboltdb.DatabaseFileName)
-			if err := os.Remove(dbFile); err != nil {
-				logger.Error(fmt.Sprintf("failed to remove persistent storage file %s: %v", dbFile, err))
-				if persistConfig.ExitOnErr {
-					return nil, "", fmt.Errorf("exiting with error as exit_on_err is set to true")
-				}
-			}
-			return nil, previousToken, nil
-		}
+	// If keep_after_import true, set persistent storage layer in
+	// leaseCache, else remove db file
+	if persistConfig.KeepAfterImport {
+		leaseCache.SetPersistentStorage(ps)
+		return ps.Close, previousToken, nil
 	} else {
-		km, err := keymanager.NewPassthroughKeyManager(ctx, nil)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to configure persistence encryption for cache: %w", err)
-		}
-		ps, err := cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
-			Path:    persistConfig.Path,
-			Logger:  logger.Named("cacheboltdb"),
-			Wrapper: km.Wrapper(),
-			AAD:     aad,
-		})
-		if err != nil {
-			return nil, "", fmt.Errorf("error creating persistent cache: %w", err)
-		}
-		logger.Info("configured persistent storage", "path", persistConfig.Path)
-
-		// Stash the key material in bolt
-		token, err := km.RetrievalToken(ctx)
-		if err != nil {
-			return nil, "", fmt.Errorf("error getting persistence key: %w", err)
+		if err := ps.Close(); err != nil {
+			logger.Warn(fmt.Sprintf("failed to close persistent cache file: %s", err))
 		}
-		if err := ps.StoreRetrievalToken(token); err != nil {
-			return nil, "", fmt.Errorf("error setting key in persistent cache: %w", err)
+		dbFile := filepath.Join(persistConfig.Path, cacheboltdb.DatabaseFileName)
+		if err := os.Remove(dbFile); err != nil {
+			logger.Error(fmt.Sprintf("failed to remove persistent storage file %s: %v", dbFile, err))
+			if persistConfig.ExitOnErr {
+				return nil, "", fmt.Errorf("exiting with error as exit_on_err is set to true")
+			}
 		}
-
-		leaseCache.SetPersistentStorage(ps)
-		return ps.Close, "", nil
+		return nil, previousToken, nil
 	}
 }
 
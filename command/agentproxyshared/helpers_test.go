@@ -6,11 +6,13 @@ package agentproxyshared
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache"
+	"github.com/hashicorp/vault/command/agentproxyshared/cache/cacheboltdb"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 )
 
@@ -92,3 +94,47 @@ func Test_AddPersistentStorageToLeaseCache(t *testing.T) {
 		t.Fatal("expected deferFunc to not be nil")
 	}
 }
+
+// Test_AddPersistentStorageToLeaseCache_AutoRecover tests that an unusable
+// persistent cache file is set aside and replaced when auto_recover is set.
+func Test_AddPersistentStorageToLeaseCache_AutoRecover(t *testing.T) {
+	tempDir := t.TempDir()
+	serviceAccountTokenFile := populateTempFile(t, "proxy-config.hcl", "token")
+	dbFile := filepath.Join(tempDir, cacheboltdb.DatabaseFileName)
+	if err := os.WriteFile(dbFile, []byte("corrupted"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	persistConfig := &PersistConfig{
+		Type:                    "kubernetes",
+		Path:                    tempDir,
+		KeepAfterImport:         true,
+		ServiceAccountTokenFile: serviceAccountTokenFile.Name(),
+	}
+
+	leaseCache := testNewLeaseCache(t, nil)
+	if _, _, err := AddPersistentStorageToLeaseCache(context.Background(), leaseCache, persistConfig, logging.NewVaultLogger(hclog.Info)); err == nil {
+		t.Fatal("expected the corrupted cache to fail without auto_recover")
+	}
+
+	persistConfig.AutoRecover = true
+	deferFunc, token, err := AddPersistentStorageToLeaseCache(context.Background(), leaseCache, persistConfig, logging.NewVaultLogger(hclog.Info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer deferFunc()
+
+	if leaseCache.PersistentStorage() == nil {
+		t.Fatal("persistent storage was not added")
+	}
+	if token != "" {
+		t.Fatal("expected token to be empty")
+	}
+	corruptFiles, err := filepath.Glob(dbFile + ".corrupt-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corruptFiles) != 1 {
+		t.Fatalf("expected the corrupted cache to be set aside, found %v", corruptFiles)
+	}
+}
@@ -395,6 +395,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"ssh",
 				"terraform",
 				"totp",
+				"tpm",
 				"transform",
 				"transit",
 				"userpass",
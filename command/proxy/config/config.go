@@ -670,6 +670,15 @@ func parsePersist(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	if p.MaxSizeRaw != nil {
+		maxSize, err := parseutil.ParseCapacityString(p.MaxSizeRaw)
+		if err != nil {
+			return fmt.Errorf("error parsing max_size: %w", err)
+		}
+		p.MaxSize = int64(maxSize)
+		p.MaxSizeRaw = nil
+	}
+
 	result.Cache.Persist = &p
 
 	return nil
@@ -12,12 +12,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"runtime/pprof"
 	"sort"
 	"strconv"
@@ -34,6 +36,7 @@ import (
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
 	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/go-secure-stdlib/gatedwriter"
 	"github.com/hashicorp/go-secure-stdlib/mlock"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
@@ -121,6 +124,9 @@ type ServerCommand struct {
 
 	allLoggers []hclog.Logger
 
+	// httpServers are the servers of the listeners, shut down when draining
+	httpServers []*http.Server
+
 	flagConfigs            []string
 	flagRecovery           bool
 	flagExperiments        []string
@@ -131,6 +137,7 @@ type ServerCommand struct {
 	flagDevRootTokenID     string
 	flagDevListenAddr      string
 	flagDevNoStoreToken    bool
+	flagDevAutoCluster     bool
 	flagDevPluginDir       string
 	flagDevPluginInit      bool
 	flagDevHA              bool
@@ -148,6 +155,8 @@ type ServerCommand struct {
 	flagTestServerConfig   bool
 	flagDevConsul          bool
 	flagExitOnCoreShutdown bool
+	flagPreflight          bool
+	flagPreflightReport    string
 }
 
 func (c *ServerCommand) Synopsis() string {
@@ -214,6 +223,23 @@ func (c *ServerCommand) Flags() *FlagSets {
 			"Using a recovery token, \"sys/raw\" API can be used to manipulate the storage.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:   "preflight",
+		Target: &c.flagPreflight,
+		Usage: "Run the preflight checks of storage, seal, TLS and clock which " +
+			"precede every startup, then exit instead of starting the server. The " +
+			"exit code is non-zero if a check failed.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "preflight-report",
+		Target:     &c.flagPreflightReport,
+		EnvVar:     "VAULT_PREFLIGHT_REPORT",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of a file to write the JSON report of the preflight checks " +
+			"to on startup, to diagnose a server which fails to start.",
+	})
+
 	f.StringSliceVar(&StringSliceVar{
 		Name:       "experiment",
 		Target:     &c.flagExperiments,
@@ -288,6 +314,17 @@ func (c *ServerCommand) Flags() *FlagSets {
 			"(usually the local filesystem) for use in future requests. " +
 			"The token will only be displayed in the command output.",
 	})
+	f.BoolVar(&BoolVar{
+		Name:    "dev-auto-cluster",
+		Target:  &c.flagDevAutoCluster,
+		Default: false,
+		Usage: "Form an ephemeral raft cluster from the nodes discovered with " +
+			"retry_join, for integration testing. The first of the nodes " +
+			"initializes the cluster with its auto-unseal seal and the others " +
+			"join it. Raft data is kept in a temporary directory unless a path " +
+			"is configured, and the root token is only displayed in the " +
+			"command output. Requires -config.",
+	})
 
 	// Internal-only flags to follow.
 	//
@@ -1121,6 +1158,15 @@ func (c *ServerCommand) Run(args []string) int {
 	// Ensure logging is flushed if initialization fails
 	defer c.flushLog()
 
+	if config.Telemetry != nil && config.Telemetry.OTLPLogsEndpoint != "" {
+		otlpSink, err := c.configureOTLPLogs(config)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error configuring OTLP log export: %s", err))
+			return 1
+		}
+		defer otlpSink.Close()
+	}
+
 	// create GRPC logger
 	namedGRPCLogFaker := c.logger.Named("grpclogfaker")
 	c.allLoggers = append(c.allLoggers, namedGRPCLogFaker)
@@ -1196,12 +1242,32 @@ func (c *ServerCommand) Run(args []string) int {
 	}
 	metricsHelper := metricsutil.NewMetricsHelper(inmemMetrics, prometheusEnabled)
 
+	if c.flagDevAutoCluster {
+		cleanup, err := c.prepareDevAutoCluster(config)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		defer cleanup()
+	}
+
+	// The preflight checks validate the environment before the listeners are
+	// bound. They are skipped in dev mode unless explicitly requested.
+	var preflight *preflightReport
+	if !c.flagDev || c.flagPreflight {
+		preflight = &preflightReport{Time: time.Now().UTC()}
+	}
+
 	// Initialize the storage backend
 	var backend physical.Backend
 	if !c.flagDev || config.Storage != nil {
 		backend, err = c.setupStorage(config)
 		if err != nil {
 			c.UI.Error(err.Error())
+			if preflight != nil {
+				preflight.Checks = append(preflight.Checks, newPreflightCheck("storage").fail(err, "Check the storage stanza of the configuration."))
+				c.finishPreflight(preflight)
+			}
 			return 1
 		}
 		// Prevent server startup if migration is active
@@ -1211,6 +1277,18 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 
+	if preflight != nil {
+		preflight.Checks = append(preflight.Checks,
+			preflightStorage(context.Background(), config, backend),
+			preflightTLS(config.Listeners),
+			preflightClock(time.Now(), version.BuildDate, config.Listeners),
+		)
+		if !preflight.passed() {
+			code, _ := c.finishPreflight(preflight)
+			return code
+		}
+	}
+
 	// Initialize the Service Discovery, if there is one
 	var configSR sr.ServiceRegistration
 	if config.ServiceRegistration != nil {
@@ -1251,12 +1329,23 @@ func (c *ServerCommand) Run(args []string) int {
 	setSealResponse, secureRandomReader, err := c.configureSeals(ctx, config, backend, infoKeys, info)
 	if err != nil {
 		c.UI.Error(err.Error())
+		if preflight != nil {
+			preflight.Checks = append(preflight.Checks, newPreflightCheck("seal").fail(err, "Check the seal stanza of the configuration."))
+			c.finishPreflight(preflight)
+		}
 		return 1
 	}
 
 	currentSeals := setSealResponse.getCreatedSeals()
 	defer c.finalizeSeals(ctx, &currentSeals)
 
+	if preflight != nil {
+		preflight.Checks = append(preflight.Checks, preflightSeal(ctx, setSealResponse.barrierSeal))
+		if code, exit := c.finishPreflight(preflight); exit {
+			return code
+		}
+	}
+
 	coreConfig := createCoreConfig(c, config, backend, configSR, setSealResponse.barrierSeal, setSealResponse.unwrapSeal, metricsHelper, metricSink, secureRandomReader)
 	if c.flagDevThreeNode {
 		return c.enableThreeNodeDevCluster(&coreConfig, info, infoKeys, c.flagDevListenAddr, os.Getenv("VAULT_DEV_TEMP_DIR"))
@@ -1335,6 +1424,19 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 
+	// Set the soft memory limit, unless GOMEMLIMIT already did, for core to
+	// shed its caches under memory pressure
+	if config.MemoryLimit != 0 {
+		if _, ok := os.LookupEnv("GOMEMLIMIT"); ok {
+			c.logger.Warn("GOMEMLIMIT is set, ignoring memory_limit")
+		} else if config.MemoryLimit > math.MaxInt64 {
+			c.UI.Error("memory_limit is too large")
+			return 1
+		} else {
+			debug.SetMemoryLimit(int64(config.MemoryLimit))
+		}
+	}
+
 	// Initialize the core
 	core, newCoreError := vault.NewCore(&coreConfig)
 	if newCoreError != nil {
@@ -1349,6 +1451,18 @@ func (c *ServerCommand) Run(args []string) int {
 
 	}
 
+	// Persist the in-flight requests to the request journal, if enabled,
+	// when the server command panics. Request handlers record their own
+	// panics, as they run on other goroutines.
+	defer func() {
+		if r := recover(); r != nil {
+			if core != nil {
+				core.RecordPanicInRequestJournal(r)
+			}
+			panic(r)
+		}
+	}()
+
 	// Copy the reload funcs pointers back
 	c.reloadFuncs = coreConfig.ReloadFuncs
 	c.reloadFuncsLock = coreConfig.ReloadFuncsLock
@@ -1490,6 +1604,15 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 
+	if c.flagDevAutoCluster {
+		autoClusterCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := c.startDevAutoCluster(autoClusterCtx, core, coreConfig.RedirectAddr); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
 	// Perform initialization of HTTP server after the verifyOnly check.
 
 	// Instantiate the wait group
@@ -1605,6 +1728,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 	// Wait for shutdown
 	shutdownTriggered := false
+	drainTriggered := false
 	retCode := 0
 
 	for !shutdownTriggered {
@@ -1616,6 +1740,7 @@ func (c *ServerCommand) Run(args []string) int {
 		case <-c.ShutdownCh:
 			c.UI.Output("==> Vault shutdown triggered")
 			shutdownTriggered = true
+			drainTriggered = config.ShutdownDrainTimeout > 0
 		case <-c.SighupCh:
 			c.UI.Output("==> Vault reload triggered")
 
@@ -1802,6 +1927,10 @@ func (c *ServerCommand) Run(args []string) int {
 	// Notify systemd that the server is shutting down
 	c.notifySystemd(systemd.SdNotifyStopping)
 
+	if drainTriggered {
+		c.drain(core, config.ShutdownDrainTimeout)
+	}
+
 	// Stop the listeners so that we don't process further client requests.
 	c.cleanupGuard.Do(listenerCloseFunc)
 
@@ -1820,6 +1949,9 @@ func (c *ServerCommand) Run(args []string) int {
 
 	// Wait for dependent goroutines to complete
 	c.WaitGroup.Wait()
+
+	// Stop the storage plugin, if any, now that Vault is done with storage
+	plugin.CleanupClients()
 	return retCode
 }
 
@@ -1907,6 +2039,39 @@ func (c *ServerCommand) configureLogging(config *server.Config) (hclog.Intercept
 	return loghelper.Setup(logCfg, c.logWriter)
 }
 
+// configureOTLPLogs registers a sink exporting the server logs to the OTLP
+// logs endpoint of the telemetry configuration.
+func (c *ServerCommand) configureOTLPLogs(config *server.Config) (*loghelper.OTLPSink, error) {
+	level, err := loghelper.ParseLogLevel(config.Telemetry.OTLPLogsLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{
+		"service.version": version.GetVersion().VersionNumber(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes["host.name"] = hostname
+	}
+	if config.ClusterName != "" {
+		attributes["vault.cluster_name"] = config.ClusterName
+	}
+
+	sink, err := loghelper.NewOTLPSink(loghelper.OTLPSinkConfig{
+		Endpoint:    config.Telemetry.OTLPLogsEndpoint,
+		Headers:     config.Telemetry.OTLPLogsHeaders,
+		Level:       level,
+		ServiceName: "vault",
+		Attributes:  attributes,
+		Logger:      c.logger.Named("otlp-logs"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.logger.RegisterSink(sink)
+	return sink, nil
+}
+
 func (c *ServerCommand) reloadHCPLink(hcpLinkVault *hcp_link.HCPLinkVault, conf *server.Config, core *vault.Core, hcpLogger hclog.Logger) (*hcp_link.HCPLinkVault, error) {
 	// trigger a shutdown
 	if hcpLinkVault != nil {
@@ -1932,6 +2097,31 @@ func (c *ServerCommand) reloadHCPLink(hcpLinkVault *hcp_link.HCPLinkVault, conf
 	return hcpLink, nil
 }
 
+// drain stops accepting new connections, lets the in-flight requests
+// complete and drains the core, for at most timeout, so that a restart is
+// not noticed by clients.
+func (c *ServerCommand) drain(core *vault.Core, timeout time.Duration) {
+	c.UI.Output(fmt.Sprintf("==> Vault draining for up to %s", timeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range c.httpServers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				c.logger.Warn("connections were still open at the end of the drain", "error", err)
+			}
+		}(server)
+	}
+
+	// Errors are logged by the core
+	_ = core.Drain(ctx)
+	wg.Wait()
+}
+
 func (c *ServerCommand) notifySystemd(status string) {
 	sent, err := systemd.SdNotify(false, status)
 	if err != nil {
@@ -3089,6 +3279,7 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		ImpreciseLeaseRoleTracking:     config.ImpreciseLeaseRoleTracking,
 		DisableSentinelTrace:           config.DisableSentinelTrace,
 		DisableCache:                   config.DisableCache,
+		DisableReadCoalescing:          config.DisableReadCoalescing,
 		DisableMlock:                   config.DisableMlock,
 		MaxLeaseTTL:                    config.MaxLeaseTTL,
 		DefaultLeaseTTL:                config.DefaultLeaseTTL,
@@ -3112,11 +3303,29 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		SecureRandomReader:             secureRandomReader,
 		EnableResponseHeaderHostname:   config.EnableResponseHeaderHostname,
 		EnableResponseHeaderRaftNodeID: config.EnableResponseHeaderRaftNodeID,
+		EnableBuiltinAuditorPolicy:     config.EnableBuiltinAuditorPolicy,
 		License:                        config.License,
 		LicensePath:                    config.LicensePath,
 		DisableSSCTokens:               config.DisableSSCTokens,
 		Experiments:                    config.Experiments,
 		AdministrativeNamespacePath:    config.AdministrativeNamespacePath,
+		RequestJournalPath:             config.RequestJournalPath,
+		TidyMaxConcurrency:             config.TidyMaxConcurrency,
+		TidyIORateLimit:                config.TidyIORateLimit,
+		ResponseCacheTTLs:              config.ResponseCache,
+	}
+
+	if config.PprofWatchdog != nil {
+		coreConfig.PprofWatchdog = &vault.PprofWatchdogConfig{
+			Directory:          config.PprofWatchdog.Directory,
+			GoroutineThreshold: config.PprofWatchdog.GoroutineThreshold,
+			HeapThreshold:      config.PprofWatchdog.HeapThreshold,
+			GCPauseThreshold:   config.PprofWatchdog.GCPauseThreshold,
+			CheckInterval:      config.PprofWatchdog.CheckInterval,
+			Cooldown:           config.PprofWatchdog.Cooldown,
+			MaxDumps:           config.PprofWatchdog.MaxDumps,
+			MaxAge:             config.PprofWatchdog.MaxAge,
+		}
 	}
 
 	if c.flagDev {
@@ -3337,6 +3546,7 @@ func startHttpServers(c *ServerCommand, core *vault.Core, config *server.Config,
 		}
 
 		go server.Serve(ln.Listener)
+		c.httpServers = append(c.httpServers, server)
 	}
 	return nil
 }
@@ -55,6 +55,8 @@ type Config struct {
 	CacheSize                int         `hcl:"cache_size"`
 	DisableCache             bool        `hcl:"-"`
 	DisableCacheRaw          interface{} `hcl:"disable_cache"`
+	DisableReadCoalescing    bool        `hcl:"-"`
+	DisableReadCoalescingRaw interface{} `hcl:"disable_read_coalescing"`
 	DisablePrintableCheck    bool        `hcl:"-"`
 	DisablePrintableCheckRaw interface{} `hcl:"disable_printable_check"`
 
@@ -109,9 +111,29 @@ type Config struct {
 
 	ImpreciseLeaseRoleTracking bool `hcl:"imprecise_lease_role_tracking"`
 
+	RequestJournalPath string `hcl:"request_journal_path"`
+
+	MemoryLimit    uint64      `hcl:"-"`
+	MemoryLimitRaw interface{} `hcl:"memory_limit"`
+
+	ShutdownDrainTimeout    time.Duration `hcl:"-"`
+	ShutdownDrainTimeoutRaw interface{}   `hcl:"shutdown_drain_timeout"`
+
+	TidyMaxConcurrency int     `hcl:"tidy_max_concurrency"`
+	TidyIORateLimit    float64 `hcl:"tidy_io_rate_limit"`
+
+	PprofWatchdog *PprofWatchdog `hcl:"-"`
+
+	// ResponseCache is the TTL of the cached responses of each path
+	// template for which response caching is enabled.
+	ResponseCache map[string]time.Duration `hcl:"-"`
+
 	EnableResponseHeaderRaftNodeID    bool        `hcl:"-"`
 	EnableResponseHeaderRaftNodeIDRaw interface{} `hcl:"enable_response_header_raft_node_id"`
 
+	EnableBuiltinAuditorPolicy    bool        `hcl:"-"`
+	EnableBuiltinAuditorPolicyRaw interface{} `hcl:"enable_builtin_auditor_policy"`
+
 	License          string `hcl:"-"`
 	LicensePath      string `hcl:"license_path"`
 	DisableSSCTokens bool   `hcl:"-"`
@@ -129,6 +151,9 @@ func (c *Config) Validate(sourceFilePath string) []configutil.ConfigError {
 	if c.ServiceRegistration != nil {
 		results = append(results, c.ServiceRegistration.Validate(sourceFilePath)...)
 	}
+	if c.PprofWatchdog != nil {
+		results = append(results, c.PprofWatchdog.Validate(sourceFilePath)...)
+	}
 	for _, l := range c.Listeners {
 		results = append(results, l.Validate(sourceFilePath)...)
 	}
@@ -240,6 +265,36 @@ func (b *Storage) GoString() string {
 	return fmt.Sprintf("*%#v", *b)
 }
 
+// PprofWatchdog configures the automatic capture of pprof dumps when the
+// goroutine count, the heap, or the GC pauses exceed their thresholds.
+type PprofWatchdog struct {
+	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
+
+	Directory          string `hcl:"directory"`
+	GoroutineThreshold int    `hcl:"goroutine_threshold"`
+
+	HeapThreshold    uint64      `hcl:"-"`
+	HeapThresholdRaw interface{} `hcl:"heap_threshold"`
+
+	GCPauseThreshold    time.Duration `hcl:"-"`
+	GCPauseThresholdRaw interface{}   `hcl:"gc_pause_threshold"`
+
+	CheckInterval    time.Duration `hcl:"-"`
+	CheckIntervalRaw interface{}   `hcl:"check_interval"`
+
+	Cooldown    time.Duration `hcl:"-"`
+	CooldownRaw interface{}   `hcl:"cooldown"`
+
+	MaxDumps int `hcl:"max_dumps"`
+
+	MaxAge    time.Duration `hcl:"-"`
+	MaxAgeRaw interface{}   `hcl:"max_age"`
+}
+
+func (w *PprofWatchdog) Validate(source string) []configutil.ConfigError {
+	return configutil.ValidateUnusedFields(w.UnusedKeys, source)
+}
+
 // ServiceRegistration is the optional service discovery for the server.
 type ServiceRegistration struct {
 	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
@@ -289,6 +344,21 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ServiceRegistration = c2.ServiceRegistration
 	}
 
+	result.PprofWatchdog = c.PprofWatchdog
+	if c2.PprofWatchdog != nil {
+		result.PprofWatchdog = c2.PprofWatchdog
+	}
+
+	if len(c.ResponseCache) > 0 || len(c2.ResponseCache) > 0 {
+		result.ResponseCache = make(map[string]time.Duration)
+		for k, v := range c.ResponseCache {
+			result.ResponseCache[k] = v
+		}
+		for k, v := range c2.ResponseCache {
+			result.ResponseCache[k] = v
+		}
+	}
+
 	result.CacheSize = c.CacheSize
 	if c2.CacheSize != 0 {
 		result.CacheSize = c2.CacheSize
@@ -300,6 +370,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.DisableCache = c2.DisableCache
 	}
 
+	result.DisableReadCoalescing = c.DisableReadCoalescing
+	if c2.DisableReadCoalescing {
+		result.DisableReadCoalescing = c2.DisableReadCoalescing
+	}
+
 	result.DisableSentinelTrace = c.DisableSentinelTrace
 	if c2.DisableSentinelTrace {
 		result.DisableSentinelTrace = c2.DisableSentinelTrace
@@ -415,11 +490,41 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ImpreciseLeaseRoleTracking = c2.ImpreciseLeaseRoleTracking
 	}
 
+	result.RequestJournalPath = c.RequestJournalPath
+	if c2.RequestJournalPath != "" {
+		result.RequestJournalPath = c2.RequestJournalPath
+	}
+
+	result.MemoryLimit = c.MemoryLimit
+	if c2.MemoryLimit != 0 {
+		result.MemoryLimit = c2.MemoryLimit
+	}
+
+	result.ShutdownDrainTimeout = c.ShutdownDrainTimeout
+	if c2.ShutdownDrainTimeout != 0 {
+		result.ShutdownDrainTimeout = c2.ShutdownDrainTimeout
+	}
+
+	result.TidyMaxConcurrency = c.TidyMaxConcurrency
+	if c2.TidyMaxConcurrency != 0 {
+		result.TidyMaxConcurrency = c2.TidyMaxConcurrency
+	}
+
+	result.TidyIORateLimit = c.TidyIORateLimit
+	if c2.TidyIORateLimit != 0 {
+		result.TidyIORateLimit = c2.TidyIORateLimit
+	}
+
 	result.EnableResponseHeaderRaftNodeID = c.EnableResponseHeaderRaftNodeID
 	if c2.EnableResponseHeaderRaftNodeID {
 		result.EnableResponseHeaderRaftNodeID = c2.EnableResponseHeaderRaftNodeID
 	}
 
+	result.EnableBuiltinAuditorPolicy = c.EnableBuiltinAuditorPolicy
+	if c2.EnableBuiltinAuditorPolicy {
+		result.EnableBuiltinAuditorPolicy = c2.EnableBuiltinAuditorPolicy
+	}
+
 	result.LicensePath = c.LicensePath
 	if c2.LicensePath != "" {
 		result.LicensePath = c2.LicensePath
@@ -621,6 +726,12 @@ func ParseConfig(d, source string) (*Config, error) {
 		}
 	}
 
+	if result.DisableReadCoalescingRaw != nil {
+		if result.DisableReadCoalescing, err = parseutil.ParseBool(result.DisableReadCoalescingRaw); err != nil {
+			return nil, err
+		}
+	}
+
 	if result.DisablePrintableCheckRaw != nil {
 		if result.DisablePrintableCheck, err = parseutil.ParseBool(result.DisablePrintableCheckRaw); err != nil {
 			return nil, err
@@ -701,6 +812,26 @@ func ParseConfig(d, source string) (*Config, error) {
 		}
 	}
 
+	if result.EnableBuiltinAuditorPolicyRaw != nil {
+		if result.EnableBuiltinAuditorPolicy, err = parseutil.ParseBool(result.EnableBuiltinAuditorPolicyRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.MemoryLimitRaw != nil {
+		if result.MemoryLimit, err = parseutil.ParseCapacityString(result.MemoryLimitRaw); err != nil {
+			return nil, fmt.Errorf("error parsing memory_limit: %w", err)
+		}
+		result.MemoryLimitRaw = nil
+	}
+
+	if result.ShutdownDrainTimeoutRaw != nil {
+		if result.ShutdownDrainTimeout, err = parseutil.ParseDurationSecond(result.ShutdownDrainTimeoutRaw); err != nil {
+			return nil, fmt.Errorf("error parsing shutdown_drain_timeout: %w", err)
+		}
+		result.ShutdownDrainTimeoutRaw = nil
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -744,6 +875,20 @@ func ParseConfig(d, source string) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("pprof_watchdog"); len(o.Items) > 0 {
+		delete(result.UnusedKeys, "pprof_watchdog")
+		if err := parsePprofWatchdog(result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'pprof_watchdog': %w", err)
+		}
+	}
+
+	if o := list.Filter("response_cache"); len(o.Items) > 0 {
+		delete(result.UnusedKeys, "response_cache")
+		if err := parseResponseCache(result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'response_cache': %w", err)
+		}
+	}
+
 	if err := validateExperiments(result.Experiments); err != nil {
 		return nil, fmt.Errorf("error validating experiment(s) from config: %w", err)
 	}
@@ -1090,6 +1235,79 @@ func parseServiceRegistration(result *Config, list *ast.ObjectList, name string)
 	return nil
 }
 
+func parsePprofWatchdog(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return errors.New("only one 'pprof_watchdog' block is permitted")
+	}
+
+	var w PprofWatchdog
+	if err := hcl.DecodeObject(&w, list.Items[0].Val); err != nil {
+		return multierror.Prefix(err, "pprof_watchdog:")
+	}
+
+	var err error
+	if w.HeapThresholdRaw != nil {
+		if w.HeapThreshold, err = parseutil.ParseCapacityString(w.HeapThresholdRaw); err != nil {
+			return fmt.Errorf("invalid heap_threshold: %w", err)
+		}
+		w.HeapThresholdRaw = nil
+	}
+	for _, d := range []struct {
+		name  string
+		raw   *interface{}
+		value *time.Duration
+	}{
+		{"gc_pause_threshold", &w.GCPauseThresholdRaw, &w.GCPauseThreshold},
+		{"check_interval", &w.CheckIntervalRaw, &w.CheckInterval},
+		{"cooldown", &w.CooldownRaw, &w.Cooldown},
+		{"max_age", &w.MaxAgeRaw, &w.MaxAge},
+	} {
+		if *d.raw == nil {
+			continue
+		}
+		if *d.value, err = parseutil.ParseDurationSecond(*d.raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.raw = nil
+	}
+
+	if w.Directory == "" {
+		return errors.New("directory is required")
+	}
+	if w.GoroutineThreshold <= 0 && w.HeapThreshold == 0 && w.GCPauseThreshold <= 0 {
+		return errors.New("at least one of goroutine_threshold, heap_threshold, or gc_pause_threshold is required")
+	}
+
+	result.PprofWatchdog = &w
+	return nil
+}
+
+func parseResponseCache(result *Config, list *ast.ObjectList) error {
+	result.ResponseCache = make(map[string]time.Duration)
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return errors.New("response_cache blocks must be labeled with a path")
+		}
+		path := strings.TrimPrefix(item.Keys[0].Token.Value().(string), "/")
+
+		var c struct {
+			TTL interface{} `hcl:"ttl"`
+		}
+		if err := hcl.DecodeObject(&c, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("response_cache.%s:", path))
+		}
+		if c.TTL == nil {
+			return fmt.Errorf("ttl is required for %q", path)
+		}
+		ttl, err := parseutil.ParseDurationSecond(c.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid ttl for %q: %w", path, err)
+		}
+		result.ResponseCache[path] = ttl
+	}
+	return nil
+}
+
 // Sanitized returns a copy of the config with all values that are considered
 // sensitive stripped. It also strips all `*Raw` values that are mainly
 // used for parsing.
@@ -1110,6 +1328,7 @@ func (c *Config) Sanitized() map[string]interface{} {
 		"cache_size":              c.CacheSize,
 		"disable_sentinel_trace":  c.DisableSentinelTrace,
 		"disable_cache":           c.DisableCache,
+		"disable_read_coalescing": c.DisableReadCoalescing,
 		"disable_printable_check": c.DisablePrintableCheck,
 
 		"enable_ui": c.EnableUI,
@@ -1144,17 +1363,34 @@ func (c *Config) Sanitized() map[string]interface{} {
 
 		"enable_response_header_raft_node_id": c.EnableResponseHeaderRaftNodeID,
 
+		"enable_builtin_auditor_policy": c.EnableBuiltinAuditorPolicy,
+
 		"log_requests_level": c.LogRequestsLevel,
 		"experiments":        c.Experiments,
 
 		"detect_deadlocks": c.DetectDeadlocks,
 
 		"imprecise_lease_role_tracking": c.ImpreciseLeaseRoleTracking,
+
+		"request_journal_path": c.RequestJournalPath,
 	}
 	for k, v := range sharedResult {
 		result[k] = v
 	}
 
+	if c.MemoryLimit != 0 {
+		result["memory_limit"] = c.MemoryLimit
+	}
+	if c.ShutdownDrainTimeout != 0 {
+		result["shutdown_drain_timeout"] = c.ShutdownDrainTimeout
+	}
+	if c.TidyMaxConcurrency != 0 {
+		result["tidy_max_concurrency"] = c.TidyMaxConcurrency
+	}
+	if c.TidyIORateLimit != 0 {
+		result["tidy_io_rate_limit"] = c.TidyIORateLimit
+	}
+
 	// Sanitize storage stanza
 	if c.Storage != nil {
 		storageType := c.Storage.Type
@@ -1201,6 +1437,31 @@ func (c *Config) Sanitized() map[string]interface{} {
 		result["service_registration"] = sanitizedServiceRegistration
 	}
 
+	// Sanitize pprof_watchdog stanza
+	if c.PprofWatchdog != nil {
+		result["pprof_watchdog"] = map[string]interface{}{
+			"directory":           c.PprofWatchdog.Directory,
+			"goroutine_threshold": c.PprofWatchdog.GoroutineThreshold,
+			"heap_threshold":      c.PprofWatchdog.HeapThreshold,
+			"gc_pause_threshold":  c.PprofWatchdog.GCPauseThreshold,
+			"check_interval":      c.PprofWatchdog.CheckInterval,
+			"cooldown":            c.PprofWatchdog.Cooldown,
+			"max_dumps":           c.PprofWatchdog.MaxDumps,
+			"max_age":             c.PprofWatchdog.MaxAge,
+		}
+	}
+
+	// Sanitize response_cache stanza
+	if len(c.ResponseCache) > 0 {
+		sanitizedResponseCache := make(map[string]interface{}, len(c.ResponseCache))
+		for path, ttl := range c.ResponseCache {
+			sanitizedResponseCache[path] = map[string]interface{}{
+				"ttl": ttl / time.Second,
+			}
+		}
+		result["response_cache"] = sanitizedResponseCache
+	}
+
 	entConfigResult := c.entConfig.Sanitized()
 	for k, v := range entConfigResult {
 		result[k] = v
@@ -773,6 +773,7 @@ func testConfig_Sanitized(t *testing.T) {
 		"plugin_file_uid":                     0,
 		"plugin_file_permissions":             0,
 		"disable_printable_check":             false,
+		"disable_read_coalescing":             false,
 		"disable_sealwrap":                    true,
 		"raw_storage_endpoint":                true,
 		"introspection_endpoint":              false,
@@ -781,6 +782,7 @@ func testConfig_Sanitized(t *testing.T) {
 		"enable_ui":                           true,
 		"enable_response_header_hostname":     false,
 		"enable_response_header_raft_node_id": false,
+		"enable_builtin_auditor_policy":       false,
 		"log_requests_level":                  "basic",
 		"ha_storage": map[string]interface{}{
 			"cluster_addr":       "top_level_cluster_addr",
@@ -854,6 +856,7 @@ func testConfig_Sanitized(t *testing.T) {
 		},
 		"administrative_namespace_path": "admin/",
 		"imprecise_lease_role_tracking": false,
+		"request_journal_path":          "",
 	}
 
 	addExpectedEntSanitizedConfig(expected, []string{"http"})
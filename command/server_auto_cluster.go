@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/vault"
+)
+
+// prepareDevAutoCluster validates the configuration of a node of an
+// ephemeral cluster, and stores its raft data in a temporary directory unless
+// a path is configured. The returned function removes the directory.
+func (c *ServerCommand) prepareDevAutoCluster(config *server.Config) (func(), error) {
+	if config.Storage == nil || config.Storage.Type != storageTypeRaft {
+		return nil, errors.New("-dev-auto-cluster requires raft storage")
+	}
+	if len(config.Seals) == 0 {
+		return nil, errors.New("-dev-auto-cluster requires an auto-unseal seal")
+	}
+	for _, seal := range config.Seals {
+		if seal.Type == vault.SealConfigTypeShamir.String() {
+			return nil, errors.New("-dev-auto-cluster requires an auto-unseal seal")
+		}
+	}
+
+	if config.Storage.Config["path"] != "" || os.Getenv(raft.EnvVaultRaftPath) != "" {
+		return func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "vault-raft")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the raft data directory: %w", err)
+	}
+	config.Storage.Config["path"] = dir
+	return func() {
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// startDevAutoCluster initializes the cluster in the background if the node is
+// the first of its discovered raft peers. The root token and recovery key are
+// only displayed in the command output.
+func (c *ServerCommand) startDevAutoCluster(ctx context.Context, core *vault.Core, apiAddr string) error {
+	if apiAddr == "" {
+		return errors.New("-dev-auto-cluster requires an api_addr for the nodes to be told apart")
+	}
+
+	go func() {
+		result, err := core.RaftAutoInit(ctx, apiAddr)
+		if err != nil {
+			c.logger.Error("failed to auto-initialize the cluster", "error", err)
+			return
+		}
+		if result == nil {
+			return
+		}
+
+		c.UI.Warn(wrapAtLength(
+			"WARNING! This node initialized the ephemeral cluster. The root token " +
+				"and recovery key are not stored anywhere, and are displayed below " +
+				"only once."))
+		c.UI.Warn("")
+		if len(result.RecoveryShares) > 0 {
+			c.UI.Warn(fmt.Sprintf("Recovery Key: %s", base64.StdEncoding.EncodeToString(result.RecoveryShares[0])))
+		}
+		c.UI.Warn(fmt.Sprintf("Root Token: %s", result.RootToken))
+		c.UI.Warn("")
+	}()
+	return nil
+}
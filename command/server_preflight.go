@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/hashicorp/vault/vault/diagnose"
+)
+
+const (
+	preflightStatusOK      = "ok"
+	preflightStatusWarning = "warning"
+	preflightStatusFailed  = "failed"
+	preflightStatusSkipped = "skipped"
+
+	preflightTimeout = 30 * time.Second
+)
+
+// preflightReport is the structured result of the checks run on startup,
+// before the listeners are bound.
+type preflightReport struct {
+	Time   time.Time         `json:"time"`
+	Passed bool              `json:"passed"`
+	Checks []*preflightCheck `json:"checks"`
+}
+
+func (r *preflightReport) passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == preflightStatusFailed {
+			return false
+		}
+	}
+	return true
+}
+
+type preflightCheck struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Message  string   `json:"message,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Advice   string   `json:"advice,omitempty"`
+}
+
+func newPreflightCheck(name string) *preflightCheck {
+	return &preflightCheck{Name: name, Status: preflightStatusOK}
+}
+
+func (p *preflightCheck) warn(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+	if p.Status == preflightStatusOK {
+		p.Status = preflightStatusWarning
+	}
+}
+
+func (p *preflightCheck) fail(err error, advice string) *preflightCheck {
+	p.Status = preflightStatusFailed
+	p.Message = err.Error()
+	p.Advice = advice
+	return p
+}
+
+func (p *preflightCheck) skip(message string) *preflightCheck {
+	p.Status = preflightStatusSkipped
+	p.Message = message
+	return p
+}
+
+// preflightStorage checks that the storage backend can be written to, read
+// from and deleted from. The raft backend cannot be used before it is set up
+// by the core, so only the permissions of its data directory are checked.
+func preflightStorage(ctx context.Context, config *server.Config, backend physical.Backend) *preflightCheck {
+	check := newPreflightCheck("storage")
+	if backend == nil {
+		return check.skip("No storage backend is configured.")
+	}
+
+	if config.Storage != nil && config.Storage.Type == storageTypeRaft {
+		path := os.Getenv(raft.EnvVaultRaftPath)
+		if path == "" {
+			path = config.Storage.Config["path"]
+		}
+		f, err := os.CreateTemp(path, ".vault-preflight-")
+		if err != nil {
+			return check.fail(fmt.Errorf("raft data directory %q is not writable: %w", path, err),
+				"Ensure the directory exists and is owned by the user Vault runs as.")
+		}
+		f.Close()
+		os.Remove(f.Name())
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	suffix, err := uuid.GenerateUUID()
+	if err != nil {
+		return check.fail(err, "")
+	}
+	key := "preflight/" + suffix
+	advice := "Ensure the storage backend is reachable from this host and that the credentials Vault uses can read, write and delete entries."
+
+	dur, err := diagnose.EndToEndLatencyCheckWrite(ctx, key, backend)
+	if err != nil {
+		return check.fail(fmt.Errorf("failed to write to storage: %w", err), advice)
+	}
+	if dur > 0 {
+		check.warn("Writing to storage took %s.", dur)
+	}
+	dur, err = diagnose.EndToEndLatencyCheckRead(ctx, key, backend)
+	if err != nil {
+		return check.fail(fmt.Errorf("failed to read from storage: %w", err), advice)
+	}
+	if dur > 0 {
+		check.warn("Reading from storage took %s.", dur)
+	}
+	dur, err = diagnose.EndToEndLatencyCheckDelete(ctx, key, backend)
+	if err != nil {
+		return check.fail(fmt.Errorf("failed to delete from storage: %w", err), advice)
+	}
+	if dur > 0 {
+		check.warn("Deleting from storage took %s.", dur)
+	}
+	return check
+}
+
+// preflightSeal checks that auto-unseal seals can encrypt and decrypt. A
+// Shamir seal needs no external access, so it is not checked.
+func preflightSeal(ctx context.Context, seal vault.Seal) *preflightCheck {
+	check := newPreflightCheck("seal")
+	if seal == nil {
+		return check.fail(fmt.Errorf("no barrier seal was configured"), "Check the seal stanza of the configuration.")
+	}
+	if seal.BarrierSealConfigType() == vault.SealConfigTypeShamir {
+		return check.skip("Shamir seals are not checked.")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	advice := "Ensure the KMS is reachable from this host and that the credentials Vault uses are allowed to encrypt and decrypt with the key."
+	value := []byte("preflight-" + time.Now().UTC().Format(time.RFC3339Nano))
+	ciphertext, errs := seal.GetAccess().Encrypt(ctx, value, nil)
+	if ciphertext == nil {
+		var msgs []string
+		for name, err := range errs {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", name, err))
+		}
+		return check.fail(fmt.Errorf("failed to encrypt with the seal: %s", strings.Join(msgs, "; ")), advice)
+	}
+	for name, err := range errs {
+		check.warn("Failed to encrypt with seal %q: %s.", name, err)
+	}
+
+	plaintext, _, err := seal.GetAccess().Decrypt(ctx, ciphertext, nil)
+	if err != nil {
+		return check.fail(fmt.Errorf("failed to decrypt with the seal: %w", err), advice)
+	}
+	if string(plaintext) != string(value) {
+		return check.fail(fmt.Errorf("the seal decrypted an unexpected value"), advice)
+	}
+	return check
+}
+
+// preflightTLS checks that the certificate and key of every TLS listener can
+// be loaded, and warns about certificates which are expired, near expiry or
+// otherwise suspect.
+func preflightTLS(listeners []*configutil.Listener) *preflightCheck {
+	check := newPreflightCheck("tls")
+	for _, l := range listeners {
+		if l.TLSDisable {
+			continue
+		}
+		if _, err := tls.LoadX509KeyPair(l.TLSCertFile, l.TLSKeyFile); err != nil {
+			return check.fail(fmt.Errorf("listener at address %s: failed to load the certificate and key: %w", l.Address, err),
+				"Ensure tls_cert_file and tls_key_file point to a readable PEM certificate and matching private key.")
+		}
+		warnings, err := diagnose.TLSCertCheck(l.TLSCertFile)
+		for _, w := range warnings {
+			check.warn("Listener at address %s: %s", l.Address, w)
+		}
+		if err != nil {
+			check.warn("Listener at address %s: %s", l.Address, err)
+		}
+		if _, err := diagnose.TLSClientCAFileCheck(l); err != nil {
+			return check.fail(fmt.Errorf("listener at address %s: invalid client CA file: %w", l.Address, err),
+				"Ensure tls_client_ca_file points to a readable PEM CA certificate.")
+		}
+	}
+	return check
+}
+
+// preflightClock checks that the system clock is not set before the build of
+// Vault, and warns if it is before the start of validity of a listener
+// certificate. Both indicate that the clock was not synchronized, which
+// breaks TLS and the expiry of tokens and leases.
+func preflightClock(now time.Time, buildDate string, listeners []*configutil.Listener) *preflightCheck {
+	check := newPreflightCheck("clock")
+	advice := "Ensure the system clock is synchronized, e.g. with NTP."
+
+	if built, err := time.Parse(time.RFC3339, buildDate); err == nil && now.Before(built) {
+		return check.fail(fmt.Errorf("the system time %s is before the build date of Vault, %s", now.UTC().Format(time.RFC3339), buildDate), advice)
+	}
+
+	for _, l := range listeners {
+		if l.TLSDisable || l.TLSCertFile == "" {
+			continue
+		}
+		leafCerts, _, _, err := diagnose.ParseTLSInformation(l.TLSCertFile)
+		if err != nil {
+			continue
+		}
+		for _, cert := range leafCerts {
+			if now.Before(cert.NotBefore) {
+				check.warn("The system time %s is before the start of validity of the certificate of the listener at address %s, %s.",
+					now.UTC().Format(time.RFC3339), l.Address, cert.NotBefore.UTC().Format(time.RFC3339))
+				check.Advice = advice
+			}
+		}
+	}
+	return check
+}
+
+// finishPreflight outputs the preflight report and writes it to the
+// configured file. It returns whether the server should exit, and with which
+// code: after a failed check, or once the checks have passed when the server
+// was started with -preflight.
+func (c *ServerCommand) finishPreflight(report *preflightReport) (int, bool) {
+	report.Passed = report.passed()
+
+	for _, check := range report.Checks {
+		switch check.Status {
+		case preflightStatusFailed:
+			c.UI.Error(fmt.Sprintf("Preflight check %q failed: %s", check.Name, check.Message))
+			if check.Advice != "" {
+				c.UI.Error("  " + check.Advice)
+			}
+		case preflightStatusWarning:
+			for _, w := range check.Warnings {
+				c.UI.Warn(fmt.Sprintf("Preflight check %q: %s", check.Name, w))
+			}
+		}
+	}
+
+	if c.flagPreflightReport != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(c.flagPreflightReport, data, 0o640)
+		}
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error writing preflight report: %s", err))
+		}
+	}
+
+	switch {
+	case !report.Passed:
+		return 1, true
+	case c.flagPreflight:
+		c.UI.Output("Preflight checks passed.")
+		return 0, true
+	default:
+		return 0, false
+	}
+}
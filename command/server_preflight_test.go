@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer_Preflight verifies the outcome of the preflight checks which do
+// not need external services.
+func TestServer_Preflight(t *testing.T) {
+	backend, err := inmem.NewInmem(nil, hclog.NewNullLogger())
+	require.NoError(t, err)
+
+	config := &server.Config{
+		SharedConfig: &configutil.SharedConfig{},
+		Storage:      &server.Storage{Type: "inmem"},
+	}
+	check := preflightStorage(context.Background(), config, backend)
+	require.Equal(t, preflightStatusOK, check.Status, check.Message)
+
+	keys, err := backend.List(context.Background(), "preflight/")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	dir := t.TempDir()
+	config.Storage = &server.Storage{Type: storageTypeRaft, Config: map[string]string{"path": dir}}
+	check = preflightStorage(context.Background(), config, backend)
+	require.Equal(t, preflightStatusOK, check.Status, check.Message)
+
+	config.Storage.Config["path"] = filepath.Join(dir, "missing")
+	check = preflightStorage(context.Background(), config, backend)
+	require.Equal(t, preflightStatusFailed, check.Status)
+	require.NotEmpty(t, check.Advice)
+
+	listeners := []*configutil.Listener{
+		{Address: "127.0.0.1:8200", TLSDisable: true},
+	}
+	check = preflightTLS(listeners)
+	require.Equal(t, preflightStatusOK, check.Status, check.Message)
+
+	listeners = append(listeners, &configutil.Listener{
+		Address:     "127.0.0.1:8201",
+		TLSCertFile: filepath.Join(dir, "missing.pem"),
+		TLSKeyFile:  filepath.Join(dir, "missing-key.pem"),
+	})
+	check = preflightTLS(listeners)
+	require.Equal(t, preflightStatusFailed, check.Status)
+	require.Contains(t, check.Message, "127.0.0.1:8201")
+
+	buildDate := "2024-01-01T00:00:00Z"
+	check = preflightClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), buildDate, nil)
+	require.Equal(t, preflightStatusOK, check.Status, check.Message)
+	check = preflightClock(time.Unix(0, 0), buildDate, nil)
+	require.Equal(t, preflightStatusFailed, check.Status)
+	check = preflightClock(time.Unix(0, 0), "", nil)
+	require.Equal(t, preflightStatusOK, check.Status, check.Message)
+}
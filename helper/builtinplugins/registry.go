@@ -36,11 +36,14 @@ import (
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
+	credNomad "github.com/hashicorp/vault/builtin/credential/nomad"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
+	credTPM "github.com/hashicorp/vault/builtin/credential/tpm"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	logicalAws "github.com/hashicorp/vault/builtin/logical/aws"
 	logicalConsul "github.com/hashicorp/vault/builtin/logical/consul"
+	logicalJWT "github.com/hashicorp/vault/builtin/logical/jwt"
 	logicalNomad "github.com/hashicorp/vault/builtin/logical/nomad"
 	logicalPki "github.com/hashicorp/vault/builtin/logical/pki"
 	logicalRabbit "github.com/hashicorp/vault/builtin/logical/rabbitmq"
@@ -117,6 +120,7 @@ func newRegistry() *registry {
 			"kerberos":   {Factory: credKerb.Factory},
 			"kubernetes": {Factory: credKube.Factory},
 			"ldap":       {Factory: credLdap.Factory},
+			"nomad":      {Factory: credNomad.Factory},
 			"oci":        {Factory: credOCI.Factory},
 			"oidc":       {Factory: credJWT.Factory},
 			"okta":       {Factory: credOkta.Factory},
@@ -125,6 +129,7 @@ func newRegistry() *registry {
 				DeprecationStatus: consts.Deprecated,
 			},
 			"radius":   {Factory: credRadius.Factory},
+			"tpm":      {Factory: credTPM.Factory},
 			"userpass": {Factory: credUserpass.Factory},
 		},
 		databasePlugins: map[string]databasePlugin{
@@ -164,6 +169,7 @@ func newRegistry() *registry {
 			"consul":     {Factory: logicalConsul.Factory},
 			"gcp":        {Factory: logicalGcp.Factory},
 			"gcpkms":     {Factory: logicalGcpKms.Factory},
+			"jwt":        {Factory: logicalJWT.Factory},
 			"kubernetes": {Factory: logicalKube.Factory},
 			"kv":         {Factory: logicalKv.Factory},
 			"mongodb": {
@@ -45,6 +45,10 @@ type JobManager struct {
 	// track queues by index for round robin worker assignment
 	queuesIndex       []string
 	lastQueueAccessed int
+
+	// priorityQueues are the queues whose jobs are assigned before those of
+	// the other queues, regardless of the share of workers they hold
+	priorityQueues map[string]struct{}
 }
 
 // NewJobManager creates a job manager, with an optional name
@@ -75,6 +79,7 @@ func NewJobManager(name string, numWorkers int, l log.Logger, metricSink *metric
 		metricSink:        metricSink,
 		queuesIndex:       make([]string, 0),
 		lastQueueAccessed: -1,
+		priorityQueues:    make(map[string]struct{}),
 	}
 
 	j.logger.Trace("created job manager", "name", name, "pool_size", numWorkers)
@@ -102,6 +107,17 @@ func (j *JobManager) Stop() {
 
 // AddJob adds a job to the given queue, creating the queue if it doesn't exist
 func (j *JobManager) AddJob(job Job, queueID string) {
+	j.addJob(job, queueID, false)
+}
+
+// AddPriorityJob adds a job to the given queue, creating the queue if it
+// doesn't exist, and makes it a priority queue until it empties. The jobs of
+// priority queues are assigned to workers before those of other queues.
+func (j *JobManager) AddPriorityJob(job Job, queueID string) {
+	j.addJob(job, queueID, true)
+}
+
+func (j *JobManager) addJob(job Job, queueID string, priority bool) {
 	j.l.Lock()
 	if len(j.queues) == 0 {
 		defer func() {
@@ -115,6 +131,9 @@ func (j *JobManager) AddJob(job Job, queueID string) {
 	if _, ok := j.queues[queueID]; !ok {
 		j.addQueue(queueID)
 	}
+	if priority {
+		j.priorityQueues[queueID] = struct{}{}
+	}
 
 	j.queues[queueID].PushBack(job)
 	j.totalJobs++
@@ -207,6 +226,16 @@ func (j *JobManager) getNextQueue() (string, bool) {
 	var nextQueue string
 	var canAssignWorker bool
 
+	// priority queues are served first, by any available worker
+	if len(j.priorityQueues) > 0 {
+		for idx, queueID := range j.queuesIndex {
+			if _, ok := j.priorityQueues[queueID]; ok {
+				j.lastQueueAccessed = idx
+				return queueID, true
+			}
+		}
+	}
+
 	// ensure we loop through all existing queues until we find an eligible
 	// queue, if one exists.
 	queueIdx := j.nextQueueIndex(j.lastQueueAccessed)
@@ -350,6 +379,7 @@ func (j *JobManager) removeLastQueueAccessed() {
 
 	// remove the queue
 	delete(j.queues, queueID)
+	delete(j.priorityQueues, queueID)
 
 	// remove the index for the queue
 	j.queuesIndex = append(j.queuesIndex[:j.lastQueueAccessed], j.queuesIndex[j.lastQueueAccessed+1:]...)
@@ -663,6 +663,37 @@ func TestJobManager_pruneEmptyQueues(t *testing.T) {
 	}
 }
 
+func TestJobManager_priorityJobs(t *testing.T) {
+	j := NewJobManager("test-job-mgr", 18, nil, nil)
+
+	testJob := newDefaultTestJob(t, "job-0")
+	j.AddJob(&testJob, "a")
+	j.AddJob(&testJob, "b")
+	j.AddPriorityJob(&testJob, "p")
+	j.AddPriorityJob(&testJob, "p")
+
+	// saturate the priority queue, which must not prevent it from being served
+	j.incrementWorkerCount("p")
+	j.incrementWorkerCount("p")
+	j.incrementWorkerCount("p")
+	j.incrementWorkerCount("p")
+	j.incrementWorkerCount("p")
+	j.incrementWorkerCount("p")
+
+	for i, expected := range []string{"p", "p", "a", "b"} {
+		job, queueID := j.getNextJob()
+		if queueID != expected || job == nil {
+			t.Fatalf("bad job %d: expected queue %q, got queueID %s, job: %#v", i, expected, queueID, job)
+		}
+	}
+
+	j.l.RLock()
+	defer j.l.RUnlock()
+	if len(j.priorityQueues) != 0 {
+		t.Errorf("expected the emptied priority queue to be removed, got %v", j.priorityQueues)
+	}
+}
+
 func TestFairshare_WorkerCount_IncrementAndDecrement(t *testing.T) {
 	j := NewJobManager("test-job-mgr", 18, nil, nil)
 
@@ -0,0 +1,424 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	defaultOTLPBufferSize    = 4096
+	defaultOTLPBatchSize     = 512
+	defaultOTLPFlushInterval = 5 * time.Second
+	defaultOTLPTimeout       = 10 * time.Second
+
+	// OTLPTraceIDKey and OTLPSpanIDKey are the keys of the log arguments
+	// which the OTLP sink exports as the trace context of the log record.
+	OTLPTraceIDKey = "trace_id"
+	OTLPSpanIDKey  = "span_id"
+)
+
+// OTLPSinkConfig configures an OTLPSink.
+type OTLPSinkConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP logs endpoint of the collector,
+	// such as http://localhost:4318/v1/logs.
+	Endpoint string
+
+	// Headers are sent with every export request, such as for
+	// authenticating to the collector.
+	Headers map[string]string
+
+	// Level is the minimum level of the exported logs.
+	Level hclog.Level
+
+	// ServiceName is the service.name resource attribute of the logs.
+	ServiceName string
+
+	// Attributes are additional resource attributes of the logs.
+	Attributes map[string]string
+
+	// BufferSize is the number of log records buffered for export, past
+	// which records are dropped. BatchSize is the maximum number of records
+	// per export request, and FlushInterval the longest a record waits in
+	// the buffer.
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// Client is the HTTP client used to export the logs.
+	Client *http.Client
+
+	// Logger reports failed exports. The records of this logger are not
+	// exported, so that failures do not feed back into the sink.
+	Logger hclog.Logger
+}
+
+// OTLPSink is a sink of an hclog.InterceptLogger which exports the logs as
+// OTLP log records over HTTP, in the JSON encoding. Log records carrying a
+// trace_id and span_id argument are correlated with the trace.
+type OTLPSink struct {
+	config   OTLPSinkConfig
+	resource otlpResource
+	records  chan otlpLogRecord
+	dropped  atomic.Uint64
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ hclog.SinkAdapter = (*OTLPSink)(nil)
+
+// NewOTLPSink returns an OTLPSink and starts exporting the logs it accepts.
+// Close must be called to flush the buffered logs and stop the export.
+func NewOTLPSink(config OTLPSinkConfig) (*OTLPSink, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("OTLP logs endpoint is required")
+	}
+	if !strings.HasPrefix(config.Endpoint, "http://") && !strings.HasPrefix(config.Endpoint, "https://") {
+		return nil, fmt.Errorf("OTLP logs endpoint %q must be an http or https URL", config.Endpoint)
+	}
+	if config.Level == hclog.NoLevel {
+		config.Level = hclog.Info
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "vault"
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultOTLPBufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultOTLPBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultOTLPFlushInterval
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: defaultOTLPTimeout}
+	}
+
+	resource := otlpResource{
+		Attributes: []otlpKeyValue{otlpAttribute("service.name", config.ServiceName)},
+	}
+	for k, v := range config.Attributes {
+		resource.Attributes = append(resource.Attributes, otlpAttribute(k, v))
+	}
+
+	s := &OTLPSink{
+		config:   config,
+		resource: resource,
+		records:  make(chan otlpLogRecord, config.BufferSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Accept implements hclog.SinkAdapter. It never blocks: records are dropped
+// when the buffer is full.
+func (s *OTLPSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < s.config.Level || level == hclog.Off {
+		return
+	}
+	if s.config.Logger != nil && name == s.config.Logger.Name() {
+		return
+	}
+
+	now := time.Now()
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(now.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+		SeverityNumber:       otlpSeverityNumber(level),
+		SeverityText:         strings.ToUpper(level.String()),
+		Body:                 otlpAnyValue{StringValue: &msg},
+	}
+	if name != "" {
+		record.Attributes = append(record.Attributes, otlpAttribute("logger", name))
+	}
+	for i := 0; i < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		var value interface{} = hclog.MissingKey
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch key {
+		case OTLPTraceIDKey:
+			if id, ok := otlpID(value, 16); ok {
+				record.TraceID = id
+				continue
+			}
+		case OTLPSpanIDKey:
+			if id, ok := otlpID(value, 8); ok {
+				record.SpanID = id
+				continue
+			}
+		}
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: key, Value: otlpValue(value)})
+	}
+
+	select {
+	case s.records <- record:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of log records dropped, because the buffer was
+// full or the export failed.
+func (s *OTLPSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close flushes the buffered logs and stops the export.
+func (s *OTLPSink) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *OTLPSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, s.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.export(batch); err != nil {
+			s.dropped.Add(uint64(len(batch)))
+			if s.config.Logger != nil {
+				s.config.Logger.Warn("failed to export logs", "records", len(batch), "error", err)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record := <-s.records:
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case record := <-s.records:
+					batch = append(batch, record)
+					if len(batch) >= s.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *OTLPSink) export(batch []otlpLogRecord) error {
+	body, err := json.Marshal(otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: s.resource,
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: s.config.ServiceName},
+				LogRecords: batch,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultOTLPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from collector", resp.StatusCode)
+	}
+	return nil
+}
+
+// ParseTraceparent returns the trace ID and parent span ID of a W3C
+// traceparent header, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	traceID, ok = otlpID(parts[1], 16)
+	if !ok {
+		return "", "", false
+	}
+	spanID, ok = otlpID(parts[2], 8)
+	if !ok {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// otlpID returns the value as a lowercase hex ID of the given number of
+// bytes, if it is one and it is not all zeroes.
+func otlpID(value interface{}, size int) (string, bool) {
+	id, ok := value.(string)
+	if !ok || len(id) != size*2 {
+		return "", false
+	}
+	id = strings.ToLower(id)
+	decoded, err := hex.DecodeString(id)
+	if err != nil {
+		return "", false
+	}
+	for _, b := range decoded {
+		if b != 0 {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// otlpSeverityNumber maps the level to the OTLP severity number of the first
+// severity of its range.
+func otlpSeverityNumber(level hclog.Level) int {
+	switch level {
+	case hclog.Trace:
+		return 1
+	case hclog.Debug:
+		return 5
+	case hclog.Info:
+		return 9
+	case hclog.Warn:
+		return 13
+	case hclog.Error:
+		return 17
+	default:
+		return 0
+	}
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue is an OTLP AnyValue, of which exactly one field is set. Int
+// values are strings, as the JSON encoding of OTLP encodes 64-bit integers.
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpValue(value interface{}) otlpAnyValue {
+	var intValue string
+	switch v := value.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		intValue = strconv.FormatInt(int64(v), 10)
+	case int32:
+		intValue = strconv.FormatInt(int64(v), 10)
+	case int64:
+		intValue = strconv.FormatInt(v, 10)
+	case uint:
+		intValue = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		intValue = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		intValue = strconv.FormatUint(v, 10)
+	case float32:
+		f := float64(v)
+		return otlpAnyValue{DoubleValue: &f}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	case error:
+		s := v.Error()
+		return otlpAnyValue{StringValue: &s}
+	case fmt.Stringer:
+		s := v.String()
+		return otlpAnyValue{StringValue: &s}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return otlpAnyValue{StringValue: &s}
+	}
+	return otlpAnyValue{IntValue: &intValue}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPSink_Export(t *testing.T) {
+	var l sync.Mutex
+	var records []map[string]interface{}
+	var authHeaders []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		l.Lock()
+		defer l.Unlock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		for _, rl := range body["resourceLogs"].([]interface{}) {
+			for _, sl := range rl.(map[string]interface{})["scopeLogs"].([]interface{}) {
+				for _, record := range sl.(map[string]interface{})["logRecords"].([]interface{}) {
+					records = append(records, record.(map[string]interface{}))
+				}
+			}
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := NewOTLPSink(OTLPSinkConfig{
+		Endpoint: srv.URL + "/v1/logs",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Level:    hclog.Info,
+	})
+	require.NoError(t, err)
+
+	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
+		Name:   "vault",
+		Level:  hclog.Error,
+		Output: hclog.DefaultOutput,
+	})
+	logger.RegisterSink(sink)
+
+	logger.Named("core").Debug("below the sink level")
+	logger.Named("core").Info("completed_request",
+		"status_code", 200,
+		"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id", "00f067aa0ba902b7",
+	)
+	logger.Warn("not correlated", "trace_id", "not-a-trace-id")
+	sink.Close()
+
+	l.Lock()
+	defer l.Unlock()
+	require.Len(t, records, 2)
+	require.Equal(t, []string{"Bearer token"}, authHeaders)
+
+	require.Equal(t, "completed_request", records[0]["body"].(map[string]interface{})["stringValue"])
+	require.Equal(t, float64(9), records[0]["severityNumber"])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", records[0]["traceId"])
+	require.Equal(t, "00f067aa0ba902b7", records[0]["spanId"])
+	require.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"key": "logger", "value": map[string]interface{}{"stringValue": "vault.core"}},
+		map[string]interface{}{"key": "status_code", "value": map[string]interface{}{"intValue": "200"}},
+	}, records[0]["attributes"])
+
+	require.Equal(t, float64(13), records[1]["severityNumber"])
+	require.NotContains(t, records[1], "traceId")
+}
+
+func TestOTLPSink_DropsWhenFull(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sink, err := NewOTLPSink(OTLPSinkConfig{
+		Endpoint:      srv.URL,
+		BufferSize:    1,
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		sink.Accept("vault", hclog.Error, "message")
+	}
+	sink.Close()
+
+	// Every record is either dropped from the full buffer or in a failed
+	// export.
+	require.Equal(t, uint64(10), sink.Dropped())
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, ok := ParseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	require.Equal(t, "00f067aa0ba902b7", spanID)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-zzf067aa0ba902b7-01",
+	} {
+		_, _, ok := ParseTraceparent(header)
+		require.False(t, ok, header)
+	}
+}
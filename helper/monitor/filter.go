@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package monitor
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+// namespaceArgs are the keys of the log message arguments which identify the
+// namespace a message is about.
+var namespaceArgs = map[string]struct{}{
+	"namespace":      {},
+	"namespace_id":   {},
+	"namespace_path": {},
+	"ns":             {},
+}
+
+// Filter selects the log messages streamed by a Monitor, in addition to the
+// level of the monitor. The zero value selects all messages.
+type Filter struct {
+	// Subsystems are the names of the loggers to stream the messages of,
+	// including the messages of their sub-loggers, such as "core" or
+	// "secrets.kv".
+	Subsystems []string
+
+	// Namespaces are the paths or IDs of the namespaces to stream the
+	// messages of. Paths include the child namespaces. Only messages with a
+	// namespace argument match.
+	Namespaces []string
+}
+
+// Empty returns whether the filter selects all messages.
+func (f *Filter) Empty() bool {
+	return f == nil || (len(f.Subsystems) == 0 && len(f.Namespaces) == 0)
+}
+
+// Match returns whether the filter selects a message of the named logger with
+// the given arguments.
+func (f *Filter) Match(name string, args []interface{}) bool {
+	if f.Empty() {
+		return true
+	}
+	return f.matchSubsystem(name) && f.matchNamespace(args)
+}
+
+func (f *Filter) matchSubsystem(name string) bool {
+	if len(f.Subsystems) == 0 {
+		return true
+	}
+	for _, subsystem := range f.Subsystems {
+		if name == subsystem || strings.HasPrefix(name, subsystem+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchNamespace(args []interface{}) bool {
+	if len(f.Namespaces) == 0 {
+		return true
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		if _, ok := namespaceArgs[key]; !ok {
+			continue
+		}
+
+		var candidates []string
+		switch v := args[i+1].(type) {
+		case *namespace.Namespace:
+			if v != nil {
+				candidates = []string{v.ID, v.Path}
+			}
+		default:
+			candidates = []string{fmt.Sprint(v)}
+		}
+		for _, candidate := range candidates {
+			if f.matchNamespaceValue(candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchNamespaceValue(value string) bool {
+	canonical := namespace.Canonicalize(value)
+	for _, ns := range f.Namespaces {
+		if value == ns {
+			return true
+		}
+		if nsPath := namespace.Canonicalize(ns); nsPath != "" && strings.HasPrefix(canonical, nsPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSink only passes the log messages selected by the filter on to the
+// wrapped sink.
+type filterSink struct {
+	log.SinkAdapter
+	filter *Filter
+}
+
+func (s *filterSink) Accept(name string, level log.Level, msg string, args ...interface{}) {
+	if s.filter.Match(name, args) {
+		s.SinkAdapter.Accept(name, level, msg, args...)
+	}
+}
@@ -48,19 +48,43 @@ type monitor struct {
 	// to 3 seconds
 	dropCheckInterval time.Duration
 
+	// droppedMessage formats the message sent when log messages were
+	// dropped.
+	droppedMessage func(count uint32) []byte
+
 	// started is whether the monitor has been started or not.
 	// This is to ensure that we don't start it again until
 	// it has been shut down.
 	started *atomic.Bool
 }
 
+// Options are the options of a Monitor created with NewMonitorWithOptions.
+type Options struct {
+	// Filter selects the log messages to stream.
+	Filter *Filter
+
+	// DroppedMessage formats the message sent when log messages were dropped
+	// from the stream. It defaults to a line of text.
+	DroppedMessage func(count uint32) []byte
+}
+
 // NewMonitor creates a new Monitor. Start must be called in order to actually start
 // streaming logs. buf is the buffer size of the channel that sends log messages.
 func NewMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (Monitor, error) {
-	return newMonitor(buf, logger, opts)
+	return newMonitor(buf, logger, opts, nil)
+}
+
+// NewMonitorWithOptions creates a new Monitor like NewMonitor, streaming the
+// log messages selected by the filter of the monitor options.
+func NewMonitorWithOptions(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, monitorOpts *Options) (Monitor, error) {
+	return newMonitor(buf, logger, opts, monitorOpts)
 }
 
-func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*monitor, error) {
+func defaultDroppedMessage(count uint32) []byte {
+	return []byte(fmt.Sprintf("Monitor dropped %d logs during monitor request\n", count))
+}
+
+func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, monitorOpts *Options) (*monitor, error) {
 	if buf <= 0 {
 		return nil, fmt.Errorf("buf must be greater than zero")
 	}
@@ -72,6 +96,7 @@ func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*
 		bufSize:           buf,
 		dropCheckInterval: 3 * time.Second,
 		droppedCount:      atomic.NewUint32(0),
+		droppedMessage:    defaultDroppedMessage,
 		started:           atomic.NewBool(false),
 	}
 
@@ -79,6 +104,15 @@ func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*
 	sink := log.NewSinkAdapter(opts)
 	sw.sink = sink
 
+	if monitorOpts != nil {
+		if !monitorOpts.Filter.Empty() {
+			sw.sink = &filterSink{SinkAdapter: sink, filter: monitorOpts.Filter}
+		}
+		if monitorOpts.DroppedMessage != nil {
+			sw.droppedMessage = monitorOpts.DroppedMessage
+		}
+	}
+
 	return sw, nil
 }
 
@@ -125,7 +159,7 @@ func (d *monitor) Start() <-chan []byte {
 				dc := d.droppedCount.Load()
 
 				if dc > 0 {
-					logMessage = []byte(fmt.Sprintf("Monitor dropped %d logs during monitor request\n", dc))
+					logMessage = d.droppedMessage(dc)
 					d.droppedCount.Swap(0)
 				}
 			case logMessage = <-d.logCh:
@@ -112,7 +112,7 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 
 	m, _ := newMonitor(5, logger, &log.LoggerOptions{
 		Level: log.Debug,
-	})
+	}, nil)
 	m.dropCheckInterval = 5 * time.Millisecond
 
 	logCh := m.Start()
@@ -138,3 +138,44 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 		require.Fail(t, "expected to see warn dropped messages")
 	}
 }
+
+// TestMonitor_Filter verifies that only the messages of the subsystems and
+// namespaces of the filter are streamed.
+func TestMonitor_Filter(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	m, _ := NewMonitorWithOptions(512, logger, &log.LoggerOptions{
+		Level: log.Debug,
+	}, &Options{
+		Filter: &Filter{
+			Subsystems: []string{"core"},
+			Namespaces: []string{"team-a"},
+		},
+	})
+
+	logCh := m.Start()
+	defer m.Stop()
+
+	go func() {
+		logger.Named("expiration").Debug("other subsystem", "namespace", "team-a/")
+		logger.Named("core").Debug("other namespace", "namespace", "team-b/")
+		logger.Named("core").Debug("no namespace")
+		logger.Named("core").Named("cluster").Debug("child namespace", "namespace_path", "team-a/app/")
+	}()
+
+	select {
+	case l := <-logCh:
+		require.Contains(t, string(l), "child namespace")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to receive from log channel")
+	}
+
+	f := &Filter{Namespaces: []string{"nsid"}}
+	require.True(t, f.Match("core", []interface{}{"namespace_id", "nsid"}))
+	require.False(t, f.Match("core", []interface{}{"namespace_id", "nsid2"}))
+	require.True(t, (&Filter{}).Match("anything", nil))
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -146,6 +147,12 @@ func MonthsPreviousTo(months int, now time.Time) time.Time {
 	return firstOfMonth.AddDate(0, -months, 0)
 }
 
+// MonthsPreviousToInZone is MonthsPreviousTo, with the months computed in the
+// time zone of the given date rather than in UTC.
+func MonthsPreviousToInZone(months int, now time.Time) time.Time {
+	return StartOfMonth(now).AddDate(0, -months, 0)
+}
+
 // Skip this test if too close to the end of a month!
 func SkipAtEndOfMonth(t *testing.T) {
 	t.Helper()
@@ -179,3 +186,72 @@ func (_ DefaultClock) NewTicker(d time.Duration) *time.Ticker {
 func (_ DefaultClock) NewTimer(d time.Duration) *time.Timer {
 	return time.NewTimer(d)
 }
+
+// AdjustableClock is a Clock running at the pace of the wall clock from a
+// time which can be moved, for tests to skip ahead in time rather than wait
+// for it. Timers and tickers run at the pace of the wall clock, so the users
+// of the clock need to reschedule them when it is adjusted. Their channels
+// deliver wall clock times, which AdjustTime moves to the time of the clock.
+type AdjustableClock struct {
+	l      sync.RWMutex
+	offset time.Duration
+}
+
+var _ Clock = (*AdjustableClock)(nil)
+
+func (c *AdjustableClock) Now() time.Time {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	return time.Now().Add(c.offset)
+}
+
+func (c *AdjustableClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+func (c *AdjustableClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+// Adjust moves a wall clock time, such as one delivered by a timer of the
+// clock, to the time of the clock.
+func (c *AdjustableClock) Adjust(t time.Time) time.Time {
+	return t.Add(c.Offset())
+}
+
+// AdjustTime moves a time delivered by a timer or ticker of the clock to the
+// time of the clock, if the clock can be adjusted.
+func AdjustTime(clock Clock, t time.Time) time.Time {
+	if c, ok := clock.(interface{ Adjust(time.Time) time.Time }); ok {
+		return c.Adjust(t)
+	}
+	return t
+}
+
+// Set moves the clock to the given time.
+func (c *AdjustableClock) Set(t time.Time) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.offset = time.Until(t)
+}
+
+// Advance moves the clock by the given duration, back if it is negative.
+func (c *AdjustableClock) Advance(d time.Duration) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.offset += d
+}
+
+// Reset moves the clock back to the wall clock.
+func (c *AdjustableClock) Reset() {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.offset = 0
+}
+
+// Offset returns how far the clock is from the wall clock.
+func (c *AdjustableClock) Offset() time.Duration {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	return c.offset
+}
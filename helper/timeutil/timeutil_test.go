@@ -367,3 +367,69 @@ func TestTimeUtil_ParseTimeFromPath(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeutil_MonthsPreviousToInZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	// 2020-03-01T03:00:00Z is still February in New York
+	now := time.Date(2020, 3, 1, 3, 0, 0, 0, time.UTC).In(loc)
+
+	result := MonthsPreviousToInZone(1, now)
+	expected := time.Date(2020, 1, 1, 0, 0, 0, 0, loc)
+	if !result.Equal(expected) {
+		t.Errorf("1 month previous to %v in %v is %v, got %v", now, loc, expected, result)
+	}
+
+	result = MonthsPreviousTo(1, now)
+	expected = time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	if !result.Equal(expected) {
+		t.Errorf("1 month previous to %v is %v, got %v", now, expected, result)
+	}
+}
+
+func TestTimeUtil_AdjustableClock(t *testing.T) {
+	clock := &AdjustableClock{}
+	if d := time.Since(clock.Now()); d < 0 || d > time.Second {
+		t.Fatalf("expected the clock to start at the wall clock, off by %s", d)
+	}
+
+	target := time.Date(2030, time.January, 31, 23, 59, 0, 0, time.UTC)
+	clock.Set(target)
+	if d := clock.Now().Sub(target); d < 0 || d > time.Second {
+		t.Fatalf("expected the clock to be set to %s, got %s", target, clock.Now())
+	}
+
+	clock.Advance(2 * time.Minute)
+	if !IsCurrentMonth(StartOfNextMonth(target), clock.Now()) {
+		t.Fatalf("expected the clock to be advanced to the next month, got %s", clock.Now())
+	}
+
+	// Timers keep their semantics: once stopped and drained, a reset timer
+	// only fires after the new duration, at the time of the clock.
+	timer := clock.NewTimer(0)
+	time.Sleep(10 * time.Millisecond)
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(time.Hour)
+	select {
+	case fired := <-timer.C:
+		t.Fatalf("expected the reset timer not to fire, got %s", fired)
+	case <-time.After(50 * time.Millisecond):
+	}
+	timer.Reset(0)
+	if fired := AdjustTime(clock, <-timer.C); !IsCurrentMonth(StartOfNextMonth(target), fired) {
+		t.Fatalf("expected the timer to fire at the time of the clock, got %s", fired)
+	}
+
+	clock.Reset()
+	if clock.Offset() != 0 {
+		t.Fatalf("expected the clock to be reset, got an offset of %s", clock.Offset())
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/vault"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// DefaultResponseCompressionMinSize is the size a response must reach
+	// before it is compressed; smaller responses don't shrink enough to be
+	// worth the cost.
+	DefaultResponseCompressionMinSize = 1400
+
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+var (
+	defaultCompressionAlgorithms = []string{compressionZstd, compressionGzip}
+
+	defaultCompressionContentTypes = []string{
+		"application/json",
+		"application/csv",
+		"text/csv",
+		"text/plain",
+	}
+
+	// compressiblePaths are the exact paths, relative to the namespace of the
+	// request, whose responses may be compressed.
+	// Compression of a response containing secret material, next to input
+	// controlled by an attacker, lets the attacker recover the secret from
+	// the size of the compressed response (the BREACH attack). Only responses
+	// which are large and contain no secret material are thus compressed:
+	// these, and list responses.
+	compressiblePaths = map[string]struct{}{
+		"sys/internal/specs/openapi":             {},
+		"sys/internal/counters/activity":         {},
+		"sys/internal/counters/activity/monthly": {},
+		"sys/internal/counters/activity/export":  {},
+		"sys/internal/counters/cost/export":      {},
+		"sys/metrics":                            {},
+	}
+
+	gzipWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+			return w
+		},
+	}
+
+	zstdWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+			return w
+		},
+	}
+)
+
+// wrapResponseCompressionHandler compresses the responses of compressible
+// requests with the best algorithm the client accepts, if response
+// compression is enabled on the listener.
+func wrapResponseCompressionHandler(handler http.Handler, props *vault.HandlerProperties) http.Handler {
+	if props.ListenerConfig == nil || !props.ListenerConfig.ResponseCompression.Enabled {
+		return handler
+	}
+
+	config := props.ListenerConfig.ResponseCompression
+	minSize := int(config.MinSize)
+	if minSize <= 0 {
+		minSize = DefaultResponseCompressionMinSize
+	}
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = defaultCompressionAlgorithms
+	}
+	contentTypes := config.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultCompressionContentTypes
+	}
+
+	// namespacePath returns the path of the namespace a request path starts
+	// with, so that the compressible paths of namespaces are matched as well
+	namespacePath := func(path string) string {
+		if props.Core == nil {
+			return ""
+		}
+		if ns := props.Core.NamespaceByPath(path); ns != nil {
+			return ns.Path
+		}
+		return ""
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !compressibleRequest(r, namespacePath) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		// The response depends on the accepted encodings, whether or not it
+		// ends up compressed
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateCompression(r.Header.Get("Accept-Encoding"), algorithms)
+		if encoding == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			minSize:        minSize,
+			contentTypes:   contentTypes,
+			statusCode:     http.StatusOK,
+		}
+		defer cw.Close()
+		handler.ServeHTTP(cw, r)
+	})
+}
+
+// compressibleRequest returns whether the response to the request may be
+// compressed: it must be a list, or a request to one of the compressible
+// paths, and must not be response wrapped, as the response would then hold
+// a wrapping token. The namespace the path starts with, as returned by
+// namespacePath, is removed before it is matched.
+func compressibleRequest(r *http.Request, namespacePath func(string) string) bool {
+	if r.Header.Get(consts.WrapTTLHeaderName) != "" || r.Header.Get("Range") != "" {
+		return false
+	}
+
+	switch r.Method {
+	case "LIST":
+		return true
+	case http.MethodGet:
+	default:
+		return false
+	}
+
+	if path, ok := strings.CutPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/v1/"); ok {
+		path = strings.TrimPrefix(path, namespacePath(path))
+		if _, ok := compressiblePaths[path]; ok {
+			return true
+		}
+	}
+	list, _ := strconv.ParseBool(r.URL.Query().Get("list"))
+	return list
+}
+
+// negotiateCompression returns the first of the algorithms accepted by the
+// Accept-Encoding header, or an empty string if none of them is.
+func negotiateCompression(acceptEncoding string, algorithms []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(entry, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		ok := true
+		for _, param := range strings.Split(params, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(key) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q <= 0 {
+				ok = false
+			}
+		}
+		accepted[coding] = ok
+	}
+
+	for _, algorithm := range algorithms {
+		if ok, found := accepted[algorithm]; found {
+			if ok {
+				return algorithm
+			}
+			continue
+		}
+		if accepted["*"] {
+			return algorithm
+		}
+	}
+	return ""
+}
+
+// compressResponseWriter buffers the start of a response until it reaches the
+// minimum size, and then compresses it if its content type allows it.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	encoding     string
+	minSize      int
+	contentTypes []string
+
+	statusCode    int
+	headerWritten bool
+	buf           []byte
+
+	// decided is set once the response is either compressed, through
+	// encoder, or written as is.
+	decided bool
+	encoder io.WriteCloser
+}
+
+var (
+	_ http.ResponseWriter = (*compressResponseWriter)(nil)
+	_ http.Flusher        = (*compressResponseWriter)(nil)
+)
+
+func (w *compressResponseWriter) WriteHeader(statusCode int) {
+	if w.headerWritten {
+		return
+	}
+	w.statusCode = statusCode
+	w.headerWritten = true
+
+	// Responses without a body are written straight away
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified || statusCode < http.StatusOK {
+		w.decide(false)
+	}
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	w.headerWritten = true
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush writes the buffered response, compressed if it reached the minimum
+// size, and flushes the encoder and the underlying response writer.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.minSize)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes the rest of the response and releases the encoder.
+func (w *compressResponseWriter) Close() error {
+	if !w.decided {
+		if !w.headerWritten {
+			// Nothing was written, let the server write the default response
+			return nil
+		}
+		if err := w.decide(len(w.buf) >= w.minSize); err != nil {
+			return err
+		}
+	}
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Close()
+	switch e := w.encoder.(type) {
+	case *gzip.Writer:
+		e.Reset(nil)
+		gzipWriterPool.Put(e)
+	case *zstd.Encoder:
+		e.Reset(nil)
+		zstdWriterPool.Put(e)
+	}
+	w.encoder = nil
+	return err
+}
+
+// decide writes the header, compressing the response if requested and if the
+// response can be compressed, and then writes the buffered response.
+func (w *compressResponseWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if compress && header.Get("Content-Encoding") == "" && w.allowedContentType(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		switch w.encoding {
+		case compressionGzip:
+			e := gzipWriterPool.Get().(*gzip.Writer)
+			e.Reset(w.ResponseWriter)
+			w.encoder = e
+		case compressionZstd:
+			e := zstdWriterPool.Get().(*zstd.Encoder)
+			e.Reset(w.ResponseWriter)
+			w.encoder = e
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressResponseWriter) allowedContentType(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range w.contentTypes {
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
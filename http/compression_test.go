@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/vault"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	cases := map[string]string{
+		"":                     "",
+		"identity":             "",
+		"gzip":                 compressionGzip,
+		"gzip, zstd":           compressionZstd,
+		"zstd;q=0, gzip;q=0.5": compressionGzip,
+		"*":                    compressionZstd,
+		"*, zstd;q=0":          compressionGzip,
+		"br, deflate":          "",
+	}
+	for acceptEncoding, expected := range cases {
+		require.Equal(t, expected, negotiateCompression(acceptEncoding, defaultCompressionAlgorithms), acceptEncoding)
+	}
+}
+
+// TestResponseCompression verifies that only large list and allowlisted
+// responses are compressed, and never response wrapped ones.
+func TestResponseCompression(t *testing.T) {
+	body := `{"data":{"keys":["` + strings.Repeat("key", 1000) + `"]}}`
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("small") != "" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(body))
+	})
+	handler := wrapResponseCompressionHandler(inner, &vault.HandlerProperties{
+		ListenerConfig: &configutil.Listener{
+			ResponseCompression: configutil.ListenerResponseCompression{Enabled: true},
+		},
+	})
+
+	do := func(method, target string, headers map[string]string) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		resp := rec.Result()
+
+		var reader io.Reader = resp.Body
+		switch resp.Header.Get("Content-Encoding") {
+		case compressionGzip:
+			gr, err := gzip.NewReader(resp.Body)
+			require.NoError(t, err)
+			reader = gr
+		case compressionZstd:
+			zr, err := zstd.NewReader(resp.Body)
+			require.NoError(t, err)
+			defer zr.Close()
+			reader = zr
+		}
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return resp, string(decoded)
+	}
+
+	resp, decoded := do("LIST", "/v1/secret/", map[string]string{"Accept-Encoding": "gzip, zstd"})
+	require.Equal(t, compressionZstd, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	require.Equal(t, body, decoded)
+
+	resp, decoded = do(http.MethodGet, "/v1/sys/internal/specs/openapi", map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, compressionGzip, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, body, decoded)
+
+	for name, tc := range map[string]struct {
+		method  string
+		target  string
+		headers map[string]string
+	}{
+		"not accepted":   {"LIST", "/v1/secret/", nil},
+		"too small":      {"LIST", "/v1/secret/?small=true", map[string]string{"Accept-Encoding": "gzip"}},
+		"secret read":    {http.MethodGet, "/v1/secret/foo", map[string]string{"Accept-Encoding": "gzip"}},
+		"secret write":   {http.MethodPost, "/v1/sys/internal/specs/openapi", map[string]string{"Accept-Encoding": "gzip"}},
+		"wrapped listed": {"LIST", "/v1/secret/", map[string]string{"Accept-Encoding": "gzip", "X-Vault-Wrap-TTL": "5m"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, decoded := do(tc.method, tc.target, tc.headers)
+			require.Empty(t, resp.Header.Get("Content-Encoding"))
+			require.NotEmpty(t, decoded)
+		})
+	}
+}
+
+// TestCompressibleRequest_Namespaces verifies that the compressible paths of
+// namespaces are matched once the namespace is removed from the path, and
+// that paths of mounts are not mistaken for them.
+func TestCompressibleRequest_Namespaces(t *testing.T) {
+	namespacePath := func(path string) string {
+		if strings.HasPrefix(path, "ns1/") {
+			return "ns1/"
+		}
+		return ""
+	}
+
+	for target, expected := range map[string]bool{
+		"/v1/sys/metrics":                    true,
+		"/v1/ns1/sys/metrics":                true,
+		"/v1/ns1/sys/internal/specs/openapi": true,
+		"/v1/ns1/sys/policies/acl/foo":       false,
+		"/v1/secret/sys/metrics":             false,
+		"/v1/ns1/secret/sys/metrics":         false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		require.Equal(t, expected, compressibleRequest(req, namespacePath), target)
+	}
+}
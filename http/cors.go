@@ -6,9 +6,12 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -22,14 +25,31 @@ var allowedMethods = []string{
 	"LIST", // LIST is not an official HTTP method, but Vault supports it.
 }
 
-func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
+// wrapCORSHandler applies the CORS policy of the request. The policy of the
+// namespace of the request takes precedence, followed by the policy of the
+// listener, if CORS is enabled on the listener, and then the global CORS
+// configuration.
+func wrapCORSHandler(h http.Handler, core *vault.Core, props *vault.HandlerProperties) http.Handler {
+	var listenerPolicy *vault.CORSPolicy
+	var chrootNamespace string
+	if props.ListenerConfig != nil {
+		chrootNamespace = namespace.Canonicalize(props.ListenerConfig.ChrootNamespace)
+		if props.ListenerConfig.CorsEnabled {
+			listenerPolicy = &vault.CORSPolicy{
+				AllowedOrigins: props.ListenerConfig.CorsAllowedOrigins,
+				AllowedHeaders: append(append([]string{}, vault.StdAllowedHeaders...), props.ListenerConfig.CorsAllowedHeaders...),
+				MaxAge:         int(props.ListenerConfig.CorsMaxAge.Seconds()),
+			}
+		}
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		corsConf := core.CORSConfig()
+		policy := corsPolicy(core.CORSConfig(), listenerPolicy, chrootNamespace, req)
 
 		// If CORS is not enabled or if no Origin header is present (i.e. the request
 		// is from the Vault CLI. A browser will always send an Origin header), then
 		// just return a 204.
-		if !corsConf.IsEnabled() {
+		if policy == nil {
 			h.ServeHTTP(w, req)
 			return
 		}
@@ -43,7 +63,7 @@ func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
 		}
 
 		// Return a 403 if the origin is not allowed to make cross-origin requests.
-		if !corsConf.IsValidOrigin(origin) {
+		if !policy.IsValidOrigin(origin) {
 			respondError(w, http.StatusForbidden, fmt.Errorf("origin not allowed"))
 			return
 		}
@@ -59,8 +79,8 @@ func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
 		// apply headers for preflight requests
 		if req.Method == http.MethodOptions {
 			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ","))
-			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsConf.AllowedHeaders, ","))
-			w.Header().Set("Access-Control-Max-Age", "300")
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ","))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAgeOrDefault()))
 
 			return
 		}
@@ -69,3 +89,22 @@ func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
 		return
 	})
 }
+
+// corsPolicy returns the CORS policy which applies to the request, or nil if
+// CORS is not enabled for it. The namespace of the request is derived from the
+// chroot namespace of the listener, the namespace header and the request
+// path. Browsers do not send the namespace header on preflight requests, so
+// the policy of a namespace only applies to the preflight requests of a
+// cross-origin request if the namespace is part of the request path.
+func corsPolicy(corsConf *vault.CORSConfig, listenerPolicy *vault.CORSPolicy, chrootNamespace string, req *http.Request) *vault.CORSPolicy {
+	requestPath := chrootNamespace +
+		namespace.Canonicalize(req.Header.Get(consts.NamespaceHeaderName)) +
+		strings.TrimPrefix(req.URL.Path, "/v1/")
+	if policy := corsConf.NamespacePolicy(requestPath); policy != nil {
+		return policy
+	}
+	if listenerPolicy != nil {
+		return listenerPolicy
+	}
+	return corsConf.Policy()
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/limits"
@@ -170,6 +171,7 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/v1/sys/host-info", handleLogicalNoForward(core, chrootNamespace))
 
 		mux.Handle("/v1/sys/init", handleSysInit(core))
+		mux.Handle("/v1/sys/bootstrap", handleSysBootstrap(core))
 		mux.Handle("/v1/sys/seal-status", handleSysSealStatus(core,
 			WithRedactClusterName(props.ListenerConfig.RedactClusterName),
 			WithRedactVersion(props.ListenerConfig.RedactVersion)))
@@ -230,6 +232,10 @@ func handler(props *vault.HandlerProperties) http.Handler {
 			mux.Handle("/v1/sys/pprof/profile", http.HandlerFunc(pprof.Profile))
 			mux.Handle("/v1/sys/pprof/symbol", http.HandlerFunc(pprof.Symbol))
 			mux.Handle("/v1/sys/pprof/trace", http.HandlerFunc(pprof.Trace))
+			// The dumps of the watchdog are stored on disk, and are only
+			// served to authenticated requests
+			mux.Handle("/v1/sys/pprof/auto", handleLogicalNoForward(core, chrootNamespace))
+			mux.Handle("/v1/sys/pprof/auto/", handleLogicalNoForward(core, chrootNamespace))
 		} else {
 			mux.Handle("/v1/sys/pprof/", handleLogicalNoForward(core, chrootNamespace))
 		}
@@ -244,10 +250,11 @@ func handler(props *vault.HandlerProperties) http.Handler {
 
 	// Build up a chain of wrapping handlers.
 	wrappedHandler := wrapHelpHandler(mux, core)
-	wrappedHandler = wrapCORSHandler(wrappedHandler, core)
+	wrappedHandler = wrapCORSHandler(wrappedHandler, core, props)
 	wrappedHandler = rateLimitQuotaWrapping(wrappedHandler, core)
 	wrappedHandler = entWrapGenericHandler(core, wrappedHandler, props)
 	wrappedHandler = wrapMaxRequestSizeHandler(wrappedHandler, props)
+	wrappedHandler = wrapResponseCompressionHandler(wrappedHandler, props)
 
 	// Add an extra wrapping handler if the DisablePrintableCheck listener
 	// setting isn't true that checks for non-printable characters in the
@@ -387,7 +394,21 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 		if strings.HasSuffix(r.URL.Path, "sys/monitor") || strings.Contains(r.URL.Path, "sys/events") {
 			ctx, cancelFunc = context.WithCancel(ctx)
 		} else {
-			ctx, cancelFunc = context.WithTimeout(ctx, maxRequestDuration)
+			// Clients may ask for a tighter bound than the listener's maximum
+			// request duration; the resulting deadline is propagated to
+			// backends, plugins and storage.
+			requestTimeout, err := parseRequestTimeout(r)
+			if err != nil {
+				respondError(nw, http.StatusBadRequest, err)
+				return
+			}
+			if requestTimeout > 0 && requestTimeout < maxRequestDuration {
+				deadline := time.Now().Add(requestTimeout)
+				ctx, cancelFunc = context.WithDeadline(ctx, deadline)
+				ctx = logical.CreateContextRequestDeadline(ctx, deadline)
+			} else {
+				ctx, cancelFunc = context.WithTimeout(ctx, maxRequestDuration)
+			}
 		}
 
 		ctx = logical.CreateContextOriginalRequestPath(ctx, r.URL.Path)
@@ -476,6 +497,9 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 		// getting the request method
 		requestMethod := r.Method
 
+		// extracting the trace context of the request, if it is traced
+		traceID, spanID, _ := logging.ParseTraceparent(r.Header.Get("traceparent"))
+
 		// Storing the in-flight requests. Path should include namespace as well
 		core.StoreInFlightReqData(
 			inFlightReqID,
@@ -484,12 +508,28 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 				ReqPath:          r.URL.Path,
 				ClientRemoteAddr: clientAddr,
 				Method:           requestMethod,
+				TraceID:          traceID,
+				SpanID:           spanID,
+				CancelFunc:       cancelFunc,
 			})
 		defer func() {
 			// Not expecting this fail, so skipping the assertion check
 			core.FinalizeInFlightReqData(inFlightReqID, nw.StatusCode)
 		}()
 
+		// Persist the in-flight requests, this one included, to the request
+		// journal if the request panics. net/http recovers the panic after,
+		// and uses http.ErrAbortHandler to abort responses on purpose.
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				core.RecordPanicInRequestJournal(rec)
+				panic(rec)
+			}
+		}()
+
 		// Setting the namespace in the header to be included in the error message
 		if ns != "" {
 			nw.Header().Set(consts.NamespaceHeaderName, ns)
@@ -925,6 +965,26 @@ func forwardRequest(core *vault.Core, w http.ResponseWriter, r *http.Request) {
 	w.Write(retBytes)
 }
 
+// setDeprecationHeaders tells the client that the request was served by a
+// deprecated endpoint, with the Deprecation header, and if the endpoint
+// declares them, when it is removed with the Sunset header (RFC 8594) and
+// what replaces it with a successor-version link.
+func setDeprecationHeaders(w http.ResponseWriter, r *logical.Request) {
+	if r.DeprecatedEndpoint() == "" {
+		return
+	}
+	header := w.Header()
+	header.Set("Deprecation", "true")
+
+	replacement, sunset := r.DeprecationHints()
+	if !sunset.IsZero() {
+		header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	if replacement != "" {
+		header.Add("Link", fmt.Sprintf(`</v1/%s%s>; rel="successor-version"`, r.MountPoint, replacement))
+	}
+}
+
 // request is a helper to perform a request and properly exit in the
 // case of an error.
 func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *logical.Request) (*logical.Response, bool, bool) {
@@ -948,6 +1008,7 @@ func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *l
 	defer lsnr.OnIgnore()
 
 	resp, err := core.HandleRequest(rawReq.Context(), r)
+	setDeprecationHeaders(w, r)
 
 	// Do the limiter measurement
 	if err != nil {
@@ -963,6 +1024,12 @@ func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *l
 		resp.AddWarning("Timeout hit while waiting for local replicated cluster to apply primary's write; this client may encounter stale reads of values written during this operation.")
 	}
 	if errwrap.Contains(err, consts.ErrStandby.Error()) {
+		// A draining node which stepped down while the request was in
+		// flight forwards it to the new active node rather than redirecting
+		// the client.
+		if core.Draining() {
+			return nil, false, true
+		}
 		respondStandby(core, w, rawReq.URL)
 		return resp, false, false
 	}
@@ -1151,6 +1218,25 @@ func requestWrapInfo(r *http.Request, req *logical.Request) (*logical.Request, e
 	return req, nil
 }
 
+// parseRequestTimeout returns the timeout requested by the client via the
+// X-Vault-Request-Timeout header, or zero if none was given.
+func parseRequestTimeout(r *http.Request) (time.Duration, error) {
+	raw := r.Header.Get(consts.RequestTimeoutHeaderName)
+	if raw == "" {
+		return 0, nil
+	}
+
+	dur, err := parseutil.ParseDurationSecond(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s header: %w", consts.RequestTimeoutHeaderName, err)
+	}
+	if dur <= 0 {
+		return 0, fmt.Errorf("requested timeout must be positive")
+	}
+
+	return dur, nil
+}
+
 // parseMFAHeader parses the MFAHeaderName in the request headers and organizes
 // them with MFA method name as the index.
 func parseMFAHeader(req *logical.Request) error {
@@ -14,10 +14,13 @@ import (
 	"net/http/httptest"
 	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	"github.com/hashicorp/go-cleanhttp"
@@ -319,6 +322,53 @@ func TestHandler_CacheControlNoStore(t *testing.T) {
 	}
 }
 
+// TestHandler_RequestTimeout verifies that the X-Vault-Request-Timeout header
+// is validated, and that an expired deadline surfaces as a typed error.
+func TestHandler_RequestTimeout(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	doRequest := func(timeout string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", addr+"/v1/sys/mounts", nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		req.Header.Set(consts.RequestTimeoutHeaderName, timeout)
+
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return resp
+	}
+
+	testResponseStatus(t, doRequest("30s"), http.StatusOK)
+	testResponseStatus(t, doRequest("not-a-duration"), http.StatusBadRequest)
+	testResponseStatus(t, doRequest("-1s"), http.StatusBadRequest)
+
+	ctx, cancel := context.WithDeadline(namespace.RootContext(nil), time.Now().Add(-time.Second))
+	defer cancel()
+	ctx = logical.CreateContextRequestDeadline(ctx, time.Now().Add(-time.Second))
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/mounts")
+	req.ClientToken = token
+	_, err := core.HandleRequest(ctx, req)
+	if !errors.Is(err, logical.ErrRequestDeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+
+	status, err := logical.RespondErrorCommon(req, nil, err)
+	if status != http.StatusGatewayTimeout {
+		t.Fatalf("expected status %d, got %d", http.StatusGatewayTimeout, status)
+	}
+	if code, _ := logical.ErrorCodeFromError(status, err); code != logical.ErrorCodeDeadlineExceeded {
+		t.Fatalf("expected error code %q, got %q", logical.ErrorCodeDeadlineExceeded, code)
+	}
+}
+
 func TestHandler_InFlightRequest(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -972,3 +1022,58 @@ func TestHandler_MaxRequestSize_Memory(t *testing.T) {
 	runtime.ReadMemStats(&end)
 	require.Less(t, end.TotalAlloc-start.TotalAlloc, uint64(1024*1024))
 }
+
+// TestSetDeprecationHeaders verifies that the responses to requests served by
+// deprecated endpoints carry the deprecation, sunset, and replacement of the
+// endpoints.
+func TestSetDeprecationHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := &logical.Request{MountPoint: "secret/"}
+	setDeprecationHeaders(w, req)
+	require.Empty(t, w.Header())
+
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	req.SetDeprecatedEndpoint("old/?$")
+	req.SetDeprecationHints("new/", sunset)
+	setDeprecationHeaders(w, req)
+	require.Equal(t, "true", w.Header().Get("Deprecation"))
+	require.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	require.Equal(t, `</v1/secret/new/>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+// TestHandler_PanicRecordedInRequestJournal verifies that a panicking request
+// handler records the panic, and the in-flight requests, in the request
+// journal before the panic is recovered by net/http.
+func TestHandler_PanicRecordedInRequestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.journal")
+	core, _, _ := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{RequestJournalPath: path})
+
+	handler := wrapGenericHandler(core, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}), &vault.HandlerProperties{Core: core})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/secret/foo", nil)
+	require.PanicsWithValue(t, "boom", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var journal struct {
+		Reason   string `json:"reason"`
+		Panic    string `json:"panic"`
+		Requests []struct {
+			Path string `json:"path"`
+		} `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &journal))
+	require.Equal(t, "panic", journal.Reason)
+	require.Equal(t, "boom", journal.Panic)
+	require.Len(t, journal.Requests, 1)
+	require.Equal(t, "/v1/secret/foo", journal.Requests[0].Path)
+
+	// The process survived the panic; a clean shutdown keeps its journal.
+	require.NoError(t, core.Shutdown())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+}
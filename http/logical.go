@@ -571,10 +571,34 @@ WRITE_RESPONSE:
 		w.Header().Set("WWW-Authenticate", wwwAuthn)
 	}
 
+	if etag, ok := resp.Data[logical.HTTPETagHeader].(string); ok && etag != "" {
+		w.Header().Set("ETag", etag)
+		if status == http.StatusOK && etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
 	w.WriteHeader(status)
 	w.Write(body)
 }
 
+// etagMatches returns whether the If-None-Match header value matches the
+// entity tag, using the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // getConnection is used to format the connection information for
 // attaching to a logical request
 func getConnection(r *http.Request) (connection *logical.Connection) {
@@ -592,9 +592,12 @@ func TestLogical_Audit_invalidWrappingToken(t *testing.T) {
 			"token": "foo",
 		})
 		testResponseStatus(t, resp, 400)
-		body := map[string][]string{}
+		body := map[string]interface{}{}
 		testResponseBody(t, resp, &body)
-		if body["errors"][0] != "wrapping token is not valid or does not exist" {
+		if body["errors"].([]interface{})[0] != "wrapping token is not valid or does not exist" {
+			t.Fatal(body)
+		}
+		if body["error_code"] != string(logical.ErrorCodeInvalidWrappingToken) {
 			t.Fatal(body)
 		}
 
@@ -1027,3 +1030,41 @@ func TestLogical_AuditEnabled_ShouldLogPluginMetadata_Secret(t *testing.T) {
 		testBuiltinPluginMetadataAuditLog(t, auditResponse, consts.PluginTypeSecrets.String())
 	}
 }
+
+func TestLogical_RespondRawETag(t *testing.T) {
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     []byte(`{"openapi":"3.0.2"}`),
+			logical.HTTPContentType: "application/json",
+			logical.HTTPETagHeader:  `"abc"`,
+		},
+	}
+
+	for ifNoneMatch, expectedStatus := range map[string]int{
+		"":                  http.StatusOK,
+		`"def"`:             http.StatusOK,
+		`"abc"`:             http.StatusNotModified,
+		`W/"abc"`:           http.StatusNotModified,
+		`"def", "abc"`:      http.StatusNotModified,
+		"*":                 http.StatusNotModified,
+		`"abc-suffix", "d"`: http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/sys/internal/specs/openapi", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		respondRaw(w, r, resp)
+
+		if w.Code != expectedStatus {
+			t.Fatalf("If-None-Match %q: expected status %d, got %d", ifNoneMatch, expectedStatus, w.Code)
+		}
+		if w.Header().Get("ETag") != `"abc"` {
+			t.Fatalf("If-None-Match %q: unexpected ETag %q", ifNoneMatch, w.Header().Get("ETag"))
+		}
+		if expectedStatus == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Fatalf("If-None-Match %q: expected no body", ifNoneMatch)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLogin_MessageAcknowledgment verifies that interactive logins are only
+// issued a token once they acknowledge the current version of the login
+// messages which require it, and that the acknowledgments are recorded.
+func TestLogin_MessageAcknowledgment(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": credUserpass.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+
+	require.NoError(t, client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{Type: "userpass"}))
+	_, err := client.Logical().Write("auth/userpass/users/alice", map[string]interface{}{
+		"password": "secret",
+	})
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Write("sys/config/ui/custom-messages", map[string]interface{}{
+		"title":                  "Acceptable use",
+		"message":                base64.StdEncoding.EncodeToString([]byte("Use responsibly")),
+		"type":                   "modal",
+		"authenticated":          false,
+		"start_time":             time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"require_acknowledgment": true,
+	})
+	require.NoError(t, err)
+	messageID := secret.Data["id"].(string)
+
+	login := func(acknowledgment string) (*api.Secret, error) {
+		loginClient, err := client.Clone()
+		require.NoError(t, err)
+		loginClient.ClearToken()
+		if acknowledgment != "" {
+			loginClient.AddHeader(vault.MessageAcknowledgmentHeaderName, acknowledgment)
+		}
+		return loginClient.Logical().Write("auth/userpass/login/alice", map[string]interface{}{
+			"password": "secret",
+		})
+	}
+
+	_, err = login("")
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), messageID+":1"), err.Error())
+
+	// Acknowledging a previous version does not count
+	_, err = login(messageID + ":0")
+	require.Error(t, err)
+
+	secret, err = login(fmt.Sprintf("%s:%d", messageID, 1))
+	require.NoError(t, err)
+	require.NotEmpty(t, secret.Auth.ClientToken)
+	entityID := secret.Auth.EntityID
+
+	// The acknowledgment is remembered
+	_, err = login("")
+	require.NoError(t, err)
+
+	secret, err = client.Logical().Read("sys/config/ui/custom-messages/" + messageID + "/acknowledgments")
+	require.NoError(t, err)
+	acks := secret.Data["acknowledgments"].([]interface{})
+	require.Len(t, acks, 1)
+	ack := acks[0].(map[string]interface{})
+	require.Equal(t, entityID, ack["entity_id"])
+	require.Equal(t, true, ack["current"])
+}
@@ -0,0 +1,404 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+const (
+	// bootstrapDefaultWrapTTL is the TTL of the wrapping token of the unseal
+	// keys and root token, when the request does not set one.
+	bootstrapDefaultWrapTTL = 5 * time.Minute
+
+	// bootstrapActiveTimeout is how long the node is waited for to become
+	// active once it is initialized and unsealed.
+	bootstrapActiveTimeout = 30 * time.Second
+)
+
+// bootstrapLock serializes the bootstrap calls handled by the node, for
+// concurrent calls not to race initializing it.
+var bootstrapLock sync.Mutex
+
+// handleSysBootstrap initializes and configures the cluster in one call. The
+// node receiving the call initializes the cluster, while the rest of the
+// configuration is applied by the active node.
+func handleSysBootstrap(core *vault.Core) http.Handler {
+	forwarded := handleRequestForwarding(core, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleSysBootstrapPut(core, w, r)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT", "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		if core.Sealed() {
+			handleSysBootstrapPut(core, w, r)
+			return
+		}
+		forwarded.ServeHTTP(w, r)
+	})
+}
+
+func handleSysBootstrapPut(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	bootstrapLock.Lock()
+	defer bootstrapLock.Unlock()
+
+	ctx := namespace.RootContext(context.Background())
+
+	// Parse the request
+	var req BootstrapRequest
+	if _, err := parseJSONRequest(core.PerfStandby(), r, w, &req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateBootstrapParameters(core, req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	wrapTTL := bootstrapDefaultWrapTTL
+	if req.WrapTTL != "" {
+		var err error
+		if wrapTTL, err = parseutil.ParseDurationSecond(req.WrapTTL); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid wrap_ttl: %w", err))
+			return
+		}
+	}
+
+	initialized, err := core.Initialized(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &BootstrapResponse{
+		Changes: []string{},
+	}
+	var token string
+	switch {
+	case !initialized:
+		var initResp *InitResponse
+		initResp, err = bootstrapInit(ctx, core, req.Init)
+		switch {
+		case err != nil && initResp == nil:
+			respondError(w, http.StatusBadRequest, err)
+			return
+		case err != nil:
+			respondErrorAndData(w, http.StatusInternalServerError, initResp, err)
+			return
+		}
+		resp.Initialized = true
+		resp.Changes = append(resp.Changes, "init")
+		token = initResp.RootToken
+
+		// The unseal keys and root token cannot be lost, so they are returned
+		// as they are if they cannot be wrapped.
+		if resp.WrapInfo, err = bootstrapWrap(ctx, core, token, initResp, wrapTTL); err != nil {
+			respondErrorAndData(w, http.StatusInternalServerError, initResp, fmt.Errorf("failed to wrap the unseal keys and root token: %w", err))
+			return
+		}
+
+	case core.Sealed():
+		respondError(w, http.StatusServiceUnavailable, consts.ErrSealed)
+		return
+
+	default:
+		token, _ = getTokenFromReq(r)
+		if token == "" {
+			respondError(w, http.StatusBadRequest, errors.New("Vault is already initialized, a token is required to bootstrap it"))
+			return
+		}
+	}
+
+	// From here on, the response is returned along with errors, for the
+	// wrapped unseal keys and root token not to be lost
+	respondFailure := func(err error) {
+		status := http.StatusBadRequest
+		var coded logical.HTTPCodedError
+		if errors.As(err, &coded) {
+			status = coded.Code()
+		}
+		respondErrorAndData(w, status, resp, err)
+	}
+
+	if req.Audit != nil {
+		changed, err := bootstrapMount(ctx, core, token, "sys/audit", "audit device", req.Audit)
+		if err != nil {
+			respondFailure(err)
+			return
+		}
+		if changed {
+			resp.Changes = append(resp.Changes, "sys/audit/"+req.Audit.Path)
+		}
+	}
+
+	if req.Auth != nil {
+		changed, err := bootstrapMount(ctx, core, token, "sys/auth", "auth method", req.Auth)
+		if err != nil {
+			respondFailure(err)
+			return
+		}
+		if changed {
+			resp.Changes = append(resp.Changes, "sys/auth/"+req.Auth.Path)
+		}
+	}
+
+	if req.AdminPolicy != nil {
+		changed, err := bootstrapPolicy(ctx, core, token, req.AdminPolicy)
+		if err != nil {
+			respondFailure(err)
+			return
+		}
+		if changed {
+			resp.Changes = append(resp.Changes, "sys/policies/acl/"+req.AdminPolicy.Name)
+		}
+	}
+
+	respondOk(w, resp)
+}
+
+// validateBootstrapParameters validates the request, in addition to the
+// validation of the parameters of the initialization.
+func validateBootstrapParameters(core *vault.Core, req BootstrapRequest) error {
+	if err := validateInitParameters(core, req.Init); err != nil {
+		return err
+	}
+	// The unseal keys and root token are used to finish the bootstrap
+	if len(req.Init.PGPKeys) > 0 {
+		return errors.New("pgp_keys cannot be used to bootstrap, the unseal keys are response wrapped instead")
+	}
+	if req.Init.RootTokenPGPKey != "" {
+		return errors.New("root_token_pgp_key cannot be used to bootstrap, the root token is response wrapped instead")
+	}
+
+	for kind, m := range map[string]*BootstrapMount{"audit": req.Audit, "auth": req.Auth} {
+		if m == nil {
+			continue
+		}
+		if m.Path == "" || m.Type == "" {
+			return fmt.Errorf("%s requires a path and a type", kind)
+		}
+	}
+	if req.AdminPolicy != nil && (req.AdminPolicy.Name == "" || req.AdminPolicy.Policy == "") {
+		return errors.New("admin_policy requires a name and a policy")
+	}
+	return nil
+}
+
+// bootstrapInit initializes and unseals the cluster, and waits for the node
+// to become active.
+func bootstrapInit(ctx context.Context, core *vault.Core, req InitRequest) (*InitResponse, error) {
+	result, err := core.Initialize(ctx, &vault.InitParams{
+		BarrierConfig: &vault.SealConfig{
+			SecretShares:    req.SecretShares,
+			SecretThreshold: req.SecretThreshold,
+			StoredShares:    req.StoredShares,
+		},
+		RecoveryConfig: &vault.SealConfig{
+			SecretShares:    req.RecoveryShares,
+			SecretThreshold: req.RecoveryThreshold,
+			PGPKeys:         req.RecoveryPGPKeys,
+		},
+	})
+	if err != nil && vault.IsFatalError(err) {
+		return nil, err
+	}
+
+	resp := &InitResponse{
+		RootToken: result.RootToken,
+	}
+	for _, k := range result.SecretShares {
+		resp.Keys = append(resp.Keys, hex.EncodeToString(k))
+		resp.KeysB64 = append(resp.KeysB64, base64.StdEncoding.EncodeToString(k))
+	}
+	for _, k := range result.RecoveryShares {
+		resp.RecoveryKeys = append(resp.RecoveryKeys, hex.EncodeToString(k))
+		resp.RecoveryKeysB64 = append(resp.RecoveryKeysB64, base64.StdEncoding.EncodeToString(k))
+	}
+
+	if err := core.UnsealWithStoredKeys(ctx); err != nil {
+		return resp, err
+	}
+	// Shamir seals without stored shares are unsealed with the unseal keys
+	for _, k := range result.SecretShares {
+		if !core.Sealed() {
+			break
+		}
+		if _, err := core.Unseal(k); err != nil {
+			return resp, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bootstrapActiveTimeout)
+	defer cancel()
+	for {
+		standby, err := core.Standby()
+		if err != nil {
+			return resp, err
+		}
+		if !standby {
+			return resp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resp, errors.New("timed out waiting for the node to become active")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// bootstrapWrap response wraps the unseal keys and root token.
+func bootstrapWrap(ctx context.Context, core *vault.Core, token string, initResp *InitResponse, ttl time.Duration) (*logical.HTTPWrapInfo, error) {
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/wrapping/wrap",
+		ClientToken: token,
+		Data: map[string]interface{}{
+			"keys":                 initResp.Keys,
+			"keys_base64":          initResp.KeysB64,
+			"recovery_keys":        initResp.RecoveryKeys,
+			"recovery_keys_base64": initResp.RecoveryKeysB64,
+			"root_token":           initResp.RootToken,
+		},
+		WrapInfo: &logical.RequestWrapInfo{
+			TTL: ttl,
+		},
+	}
+	resp, err := bootstrapRequest(ctx, core, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.WrapInfo == nil {
+		return nil, errors.New("no wrapping token was returned")
+	}
+	return &logical.HTTPWrapInfo{
+		Token:        resp.WrapInfo.Token,
+		Accessor:     resp.WrapInfo.Accessor,
+		TTL:          int(resp.WrapInfo.TTL.Seconds()),
+		CreationTime: resp.WrapInfo.CreationTime.Format(time.RFC3339Nano),
+		CreationPath: resp.WrapInfo.CreationPath,
+	}, nil
+}
+
+// bootstrapMount enables the audit device or auth method, unless it is
+// already enabled at the path with the same type, and returns whether it was
+// enabled.
+func bootstrapMount(ctx context.Context, core *vault.Core, token, tablePath, kind string, m *BootstrapMount) (bool, error) {
+	path := strings.Trim(m.Path, "/") + "/"
+
+	resp, err := bootstrapRequest(ctx, core, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        tablePath,
+		ClientToken: token,
+	})
+	if err != nil {
+		return false, err
+	}
+	if resp != nil {
+		if info, ok := resp.Data[path].(map[string]interface{}); ok {
+			if info["type"] != m.Type {
+				return false, logical.CodedError(http.StatusConflict, fmt.Sprintf("%s at %q has type %q instead of %q", kind, path, info["type"], m.Type))
+			}
+			return false, nil
+		}
+	}
+
+	data := map[string]interface{}{
+		"type":        m.Type,
+		"description": m.Description,
+	}
+	if len(m.Options) > 0 {
+		data["options"] = m.Options
+	}
+	_, err = bootstrapRequest(ctx, core, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        tablePath + "/" + path,
+		ClientToken: token,
+		Data:        data,
+	})
+	return err == nil, err
+}
+
+// bootstrapPolicy writes the policy, unless it already has the same rules,
+// and returns whether it was written.
+func bootstrapPolicy(ctx context.Context, core *vault.Core, token string, p *BootstrapPolicy) (bool, error) {
+	path := "sys/policies/acl/" + p.Name
+
+	resp, err := bootstrapRequest(ctx, core, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        path,
+		ClientToken: token,
+	})
+	if err != nil {
+		return false, err
+	}
+	if resp != nil && resp.Data["policy"] == p.Policy {
+		return false, nil
+	}
+
+	_, err = bootstrapRequest(ctx, core, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        path,
+		ClientToken: token,
+		Data: map[string]interface{}{
+			"policy": p.Policy,
+		},
+	})
+	return err == nil, err
+}
+
+// bootstrapRequest handles the request, returning the errors of the response
+// as errors coded with the status they are returned with.
+func bootstrapRequest(ctx context.Context, core *vault.Core, req *logical.Request) (*logical.Response, error) {
+	resp, err := core.HandleRequest(ctx, req)
+	status, err := logical.RespondErrorCommon(req, resp, err)
+	if err != nil {
+		return nil, logical.CodedError(status, fmt.Sprintf("%s %s: %s", req.Operation, req.Path, err))
+	}
+	return resp, nil
+}
+
+type BootstrapRequest struct {
+	Init        InitRequest      `json:"init"`
+	Audit       *BootstrapMount  `json:"audit"`
+	Auth        *BootstrapMount  `json:"auth"`
+	AdminPolicy *BootstrapPolicy `json:"admin_policy"`
+	WrapTTL     string           `json:"wrap_ttl"`
+}
+
+type BootstrapMount struct {
+	Path        string            `json:"path"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Options     map[string]string `json:"options"`
+}
+
+type BootstrapPolicy struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+type BootstrapResponse struct {
+	Initialized bool                  `json:"initialized"`
+	Changes     []string              `json:"changes"`
+	WrapInfo    *logical.HTTPWrapInfo `json:"wrap_info,omitempty"`
+}
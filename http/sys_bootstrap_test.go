@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSysBootstrap verifies that bootstrapping initializes and configures the
+// cluster, returning the wrapped root token, and that bootstrapping it again
+// changes nothing.
+func TestSysBootstrap(t *testing.T) {
+	core := vault.TestCore(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	body := map[string]interface{}{
+		"init": map[string]interface{}{
+			"secret_shares":    3,
+			"secret_threshold": 2,
+		},
+		"audit": map[string]interface{}{
+			"path": "noop",
+			"type": "noop",
+		},
+		"auth": map[string]interface{}{
+			"path": "admins",
+			"type": "noop",
+		},
+		"admin_policy": map[string]interface{}{
+			"name":   "admin",
+			"policy": `path "*" { capabilities = ["sudo", "read"] }`,
+		},
+	}
+
+	resp := testHttpPut(t, "", addr+"/v1/sys/bootstrap", body)
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	require.Equal(t, true, actual["initialized"])
+	require.Equal(t, []interface{}{"init", "sys/audit/noop", "sys/auth/admins", "sys/policies/acl/admin"}, actual["changes"])
+	require.False(t, core.Sealed())
+
+	wrapToken := actual["wrap_info"].(map[string]interface{})["token"].(string)
+	resp = testHttpPut(t, wrapToken, addr+"/v1/sys/wrapping/unwrap", nil)
+	testResponseStatus(t, resp, 200)
+	var unwrapped map[string]interface{}
+	testResponseBody(t, resp, &unwrapped)
+	data := unwrapped["data"].(map[string]interface{})
+	require.Len(t, data["keys"], 3)
+	rootToken := data["root_token"].(string)
+
+	// Bootstrapping again requires a token, and changes nothing
+	resp = testHttpPut(t, "", addr+"/v1/sys/bootstrap", body)
+	testResponseStatus(t, resp, 400)
+
+	resp = testHttpPut(t, rootToken, addr+"/v1/sys/bootstrap", body)
+	testResponseStatus(t, resp, 200)
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	require.Equal(t, false, actual["initialized"])
+	require.Empty(t, actual["changes"])
+	require.Nil(t, actual["wrap_info"])
+
+	body["auth"] = map[string]interface{}{
+		"path": "admins",
+		"type": "http",
+	}
+	resp = testHttpPut(t, rootToken, addr+"/v1/sys/bootstrap", body)
+	testResponseStatus(t, resp, 409)
+}
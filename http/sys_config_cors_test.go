@@ -64,10 +64,12 @@ func TestSysConfigCors(t *testing.T) {
 			"enabled":         true,
 			"allowed_origins": []interface{}{addr},
 			"allowed_headers": expectedHeaders,
+			"max_age":         json.Number("300"),
 		},
 		"enabled":         true,
 		"allowed_origins": []interface{}{addr},
 		"allowed_headers": expectedHeaders,
+		"max_age":         json.Number("300"),
 	}
 
 	testResponseStatus(t, resp, 200)
@@ -79,3 +81,77 @@ func TestSysConfigCors(t *testing.T) {
 		t.Fatalf("bad: expected: %#v\nactual: %#v", expected, actual)
 	}
 }
+
+// TestSysConfigCors_NamespacePolicy verifies that the CORS policy of a
+// namespace takes precedence over the global CORS configuration for requests
+// to the namespace.
+func TestSysConfigCors_NamespacePolicy(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/config/cors", map[string]interface{}{
+		"allowed_origins": "https://global.example.com",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/config/cors/namespaces/team1", map[string]interface{}{
+		"allowed_origins": "https://team1.example.com",
+		"max_age":         "1h",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/config/cors/namespaces/team1/")
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	data := actual["data"].(map[string]interface{})
+	if data["namespace"] != "team1/" || data["max_age"] != json.Number("3600") {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	preflight := func(path, origin string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodOptions, addr+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp = preflight("/v1/team1/secret/foo", "https://team1.example.com")
+	testResponseStatus(t, resp, 200)
+	if maxAge := resp.Header.Get("Access-Control-Max-Age"); maxAge != "3600" {
+		t.Fatalf("bad max age: %q", maxAge)
+	}
+	resp = preflight("/v1/team1/sub/secret/foo", "https://global.example.com")
+	testResponseStatus(t, resp, 403)
+	resp = preflight("/v1/team10/secret/foo", "https://team1.example.com")
+	testResponseStatus(t, resp, 403)
+	resp = preflight("/v1/secret/foo", "https://global.example.com")
+	testResponseStatus(t, resp, 200)
+	if maxAge := resp.Header.Get("Access-Control-Max-Age"); maxAge != "300" {
+		t.Fatalf("bad max age: %q", maxAge)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/config/cors/namespaces?list=true")
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	keys := actual["data"].(map[string]interface{})["keys"].([]interface{})
+	if len(keys) != 1 || keys[0] != "team1/" {
+		t.Fatalf("bad keys: %#v", keys)
+	}
+
+	resp = testHttpDelete(t, token, addr+"/v1/sys/config/cors/namespaces/team1")
+	testResponseStatus(t, resp, 204)
+	resp = preflight("/v1/team1/secret/foo", "https://team1.example.com")
+	testResponseStatus(t, resp, 403)
+}
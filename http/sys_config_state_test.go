@@ -150,6 +150,7 @@ func TestSysConfigState_Sanitized(t *testing.T) {
 				"disable_mlock":                       false,
 				"disable_performance_standby":         false,
 				"disable_printable_check":             false,
+				"disable_read_coalescing":             false,
 				"disable_sealwrap":                    false,
 				"experiments":                         nil,
 				"raw_storage_endpoint":                false,
@@ -177,6 +178,7 @@ func TestSysConfigState_Sanitized(t *testing.T) {
 				"storage":                       tc.expectedStorageOutput,
 				"administrative_namespace_path": "",
 				"imprecise_lease_role_tracking": false,
+				"request_journal_path":          "",
 			}
 
 			if tc.expectedHAStorageOutput != nil {
@@ -144,9 +144,15 @@ func TestSysInit_Put_ValidateParams(t *testing.T) {
 		"recovery_threshold": 3,
 	})
 	testResponseStatus(t, resp, http.StatusBadRequest)
-	body := map[string][]string{}
+	var body struct {
+		Errors    []string `json:"errors"`
+		ErrorCode string   `json:"error_code"`
+	}
 	testResponseBody(t, resp, &body)
-	if body["errors"][0] != "parameters recovery_shares,recovery_threshold not applicable to seal type shamir" {
+	if body.Errors[0] != "parameters recovery_shares,recovery_threshold not applicable to seal type shamir" {
+		t.Fatal(body)
+	}
+	if body.ErrorCode != string(logical.ErrorCodeInvalidRequest) {
 		t.Fatal(body)
 	}
 }
@@ -184,10 +190,12 @@ func TestSysInit_Put_ValidateParams_AutoUnseal(t *testing.T) {
 		"recovery_threshold": 3,
 	})
 	testResponseStatus(t, resp, http.StatusBadRequest)
-	body := map[string][]string{}
+	var body struct {
+		Errors []string `json:"errors"`
+	}
 	testResponseBody(t, resp, &body)
-	if body["errors"][0] != "parameters secret_shares,secret_threshold not applicable to seal type transit" &&
-		body["errors"][0] != "parameters secret_shares,secret_threshold not applicable to seal type test-auto" {
+	if body.Errors[0] != "parameters secret_shares,secret_threshold not applicable to seal type transit" &&
+		body.Errors[0] != "parameters secret_shares,secret_threshold not applicable to seal type test-auto" {
 		t.Fatal(body)
 	}
 }
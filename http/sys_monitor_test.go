@@ -4,6 +4,7 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"strings"
@@ -125,3 +126,64 @@ func TestSysMonitorStreamingLogs(t *testing.T) {
 		})
 	}
 }
+
+// TestSysMonitorJSONLFraming verifies that JSONL streams start with a start
+// frame, frame the filtered logs, and send heartbeats.
+func TestSysMonitorJSONLFraming(t *testing.T) {
+	t.Parallel()
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	stopCh := testhelpers.GenerateDebugLogs(t, client)
+	defer close(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	request := client.NewRequest("GET", "/v1/sys/monitor")
+	request.Params.Add("log_level", "debug")
+	request.Params.Add("framing", "jsonl")
+	request.Params.Add("heartbeat_interval", "1")
+	subsystem := cluster.Cores[0].Logger().Name()
+	request.Params.Add("subsystems", subsystem)
+	resp, err := client.RawRequestWithContext(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	type frame struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && !(seen["log"] && seen["heartbeat"]) {
+		var f frame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			t.Fatalf("expected a JSON frame, got %q", scanner.Text())
+		}
+		if len(seen) == 0 && f.Type != "start" {
+			t.Fatalf("expected a start frame first, got %q", f.Type)
+		}
+		if f.Type == "log" {
+			var l struct {
+				Module string `json:"@module"`
+			}
+			if err := json.Unmarshal(f.Data, &l); err != nil {
+				t.Fatal(err)
+			}
+			if l.Module != subsystem && !strings.HasPrefix(l.Module, subsystem+".") {
+				t.Fatalf("expected logs of the %q subsystem, got %q", subsystem, l.Module)
+			}
+		}
+		seen[f.Type] = true
+	}
+	if !seen["log"] || !seen["heartbeat"] {
+		t.Fatalf("expected log and heartbeat frames, got %v", seen)
+	}
+}
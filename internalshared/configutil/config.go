@@ -281,6 +281,10 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			"add_lease_metrics_namespace_labels":     c.Telemetry.LeaseMetricsNameSpaceLabels,
 			"add_mount_point_rollback_metrics":       c.Telemetry.RollbackMetricsIncludeMountPoint,
 		}
+		if c.Telemetry.OTLPLogsEndpoint != "" {
+			sanitizedTelemetry["otlp_logs_endpoint"] = c.Telemetry.OTLPLogsEndpoint
+			sanitizedTelemetry["otlp_logs_level"] = c.Telemetry.OTLPLogsLevel
+		}
 		result["telemetry"] = sanitizedTelemetry
 	}
 
@@ -48,6 +48,16 @@ type ListenerInFlightRequestLogging struct {
 	UnauthenticatedInFlightAccessRaw interface{}  `hcl:"unauthenticated_in_flight_requests_access,alias:unauthenticatedInFlightAccessRaw"`
 }
 
+type ListenerResponseCompression struct {
+	UnusedKeys   UnusedKeyMap `hcl:",unusedKeyPositions"`
+	Enabled      bool         `hcl:"-"`
+	EnabledRaw   interface{}  `hcl:"enabled"`
+	MinSize      int64        `hcl:"-"`
+	MinSizeRaw   interface{}  `hcl:"min_size"`
+	Algorithms   []string     `hcl:"algorithms"`
+	ContentTypes []string     `hcl:"content_types"`
+}
+
 // Listener is the listener configuration for the server.
 type Listener struct {
 	UnusedKeys UnusedKeyMap `hcl:",unusedKeyPositions"`
@@ -114,15 +124,18 @@ type Listener struct {
 	Telemetry              ListenerTelemetry              `hcl:"telemetry"`
 	Profiling              ListenerProfiling              `hcl:"profiling"`
 	InFlightRequestLogging ListenerInFlightRequestLogging `hcl:"inflight_requests_logging"`
+	ResponseCompression    ListenerResponseCompression    `hcl:"response_compression"`
 
 	// RandomPort is used only for some testing purposes
 	RandomPort bool `hcl:"-"`
 
-	CorsEnabledRaw        interface{} `hcl:"cors_enabled"`
-	CorsEnabled           bool        `hcl:"-"`
-	CorsAllowedOrigins    []string    `hcl:"cors_allowed_origins"`
-	CorsAllowedHeaders    []string    `hcl:"-"`
-	CorsAllowedHeadersRaw []string    `hcl:"cors_allowed_headers,alias:cors_allowed_headers"`
+	CorsEnabledRaw        interface{}   `hcl:"cors_enabled"`
+	CorsEnabled           bool          `hcl:"-"`
+	CorsAllowedOrigins    []string      `hcl:"cors_allowed_origins"`
+	CorsAllowedHeaders    []string      `hcl:"-"`
+	CorsAllowedHeadersRaw []string      `hcl:"cors_allowed_headers,alias:cors_allowed_headers"`
+	CorsMaxAgeRaw         interface{}   `hcl:"cors_max_age"`
+	CorsMaxAge            time.Duration `hcl:"-"`
 
 	// Custom Http response headers
 	CustomResponseHeaders    map[string]map[string]string `hcl:"-"`
@@ -165,7 +178,8 @@ func (l *Listener) GoString() string {
 
 func (l *Listener) Validate(path string) []ConfigError {
 	results := append(ValidateUnusedFields(l.UnusedKeys, path), ValidateUnusedFields(l.Telemetry.UnusedKeys, path)...)
-	return append(results, ValidateUnusedFields(l.Profiling.UnusedKeys, path)...)
+	results = append(results, ValidateUnusedFields(l.Profiling.UnusedKeys, path)...)
+	return append(results, ValidateUnusedFields(l.ResponseCompression.UnusedKeys, path)...)
 }
 
 // ParseSingleIPTemplate is used as a helper function to parse out a single IP
@@ -256,6 +270,7 @@ func parseListener(item *ast.ObjectItem) (*Listener, error) {
 		l.parseTelemetrySettings,
 		l.parseProfilingSettings,
 		l.parseInFlightRequestSettings,
+		l.parseResponseCompressionSettings,
 		l.parseCORSSettings,
 		l.parseHTTPHeaderSettings,
 		l.parseChrootNamespaceSettings,
@@ -647,6 +662,42 @@ func (l *Listener) parseInFlightRequestSettings() error {
 	return nil
 }
 
+// parseResponseCompressionSettings attempts to parse the raw listener response
+// compression settings. The state of the listener will be modified, raw data
+// will be cleared upon successful parsing.
+func (l *Listener) parseResponseCompressionSettings() error {
+	rc := &l.ResponseCompression
+
+	if err := parseAndClearBool(&rc.EnabledRaw, &rc.Enabled); err != nil {
+		return fmt.Errorf("invalid value for response_compression.enabled: %w", err)
+	}
+
+	if rc.MinSizeRaw != nil {
+		minSize, err := parseutil.ParseCapacityString(rc.MinSizeRaw)
+		if err != nil {
+			return fmt.Errorf("invalid value for response_compression.min_size: %w", err)
+		}
+		rc.MinSize = int64(minSize)
+		rc.MinSizeRaw = nil
+	}
+
+	for i, algorithm := range rc.Algorithms {
+		algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+		switch algorithm {
+		case "gzip", "zstd":
+		default:
+			return fmt.Errorf("unsupported response_compression.algorithms value %q, must be one of gzip or zstd", algorithm)
+		}
+		rc.Algorithms[i] = algorithm
+	}
+
+	for i, contentType := range rc.ContentTypes {
+		rc.ContentTypes[i] = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	return nil
+}
+
 // parseCORSSettings attempts to parse the raw listener CORS settings.
 // The state of the listener will be modified, raw data will be cleared upon
 // successful parsing.
@@ -667,6 +718,13 @@ func (l *Listener) parseCORSSettings() error {
 
 	l.CorsAllowedHeadersRaw = nil
 
+	if err := parseAndClearDurationSecond(&l.CorsMaxAgeRaw, &l.CorsMaxAge); err != nil {
+		return fmt.Errorf("invalid value for cors_max_age: %w", err)
+	}
+	if l.CorsMaxAge < 0 {
+		return errors.New("cors_max_age cannot be negative")
+	}
+
 	return nil
 }
 
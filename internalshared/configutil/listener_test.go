@@ -797,6 +797,75 @@ func TestListener_parseInFlightRequestSettings(t *testing.T) {
 	}
 }
 
+// TestListener_parseResponseCompressionSettings exercises the listener receiver
+// parseResponseCompressionSettings.
+func TestListener_parseResponseCompressionSettings(t *testing.T) {
+	tests := map[string]struct {
+		raw             ListenerResponseCompression
+		expected        ListenerResponseCompression
+		isErrorExpected bool
+		errorMessage    string
+	}{
+		"nil": {
+			isErrorExpected: false,
+		},
+		"bad-enabled": {
+			raw:             ListenerResponseCompression{EnabledRaw: "juan"},
+			isErrorExpected: true,
+			errorMessage:    "invalid value for response_compression.enabled",
+		},
+		"bad-min-size": {
+			raw:             ListenerResponseCompression{MinSizeRaw: "lots"},
+			isErrorExpected: true,
+			errorMessage:    "invalid value for response_compression.min_size",
+		},
+		"bad-algorithm": {
+			raw:             ListenerResponseCompression{Algorithms: []string{"br"}},
+			isErrorExpected: true,
+			errorMessage:    "unsupported response_compression.algorithms value",
+		},
+		"good": {
+			raw: ListenerResponseCompression{
+				EnabledRaw:   "true",
+				MinSizeRaw:   "2kib",
+				Algorithms:   []string{"GZIP", "zstd"},
+				ContentTypes: []string{" Application/JSON"},
+			},
+			expected: ListenerResponseCompression{
+				Enabled:      true,
+				MinSize:      2048,
+				Algorithms:   []string{"gzip", "zstd"},
+				ContentTypes: []string{"application/json"},
+			},
+			isErrorExpected: false,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Configure listener with raw values
+			l := &Listener{ResponseCompression: tc.raw}
+
+			err := l.parseResponseCompressionSettings()
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.errorMessage)
+			default:
+				// Assert we got the relevant values, and that the state was
+				// modified for the raw values.
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, l.ResponseCompression)
+			}
+		})
+	}
+}
+
 // TestListener_parseCORSSettings exercises the listener receiver parseCORSSettings.
 // We check various inputs to ensure we can parse the values as expected and
 // assign the relevant value on the SharedConfig struct.
@@ -805,8 +874,10 @@ func TestListener_parseCORSSettings(t *testing.T) {
 		rawCorsEnabled                any
 		rawCorsAllowedHeaders         []string
 		corsAllowedOrigins            []string
+		rawCorsMaxAge                 any
 		expectedCorsEnabled           bool
 		expectedNumCorsAllowedHeaders int
+		expectedCorsMaxAge            time.Duration
 		isErrorExpected               bool
 		errorMessage                  string
 	}{
@@ -838,6 +909,21 @@ func TestListener_parseCORSSettings(t *testing.T) {
 			expectedNumCorsAllowedHeaders: 2,
 			isErrorExpected:               false,
 		},
+		"cors-max-age-good": {
+			rawCorsMaxAge:      "10m",
+			expectedCorsMaxAge: 10 * time.Minute,
+			isErrorExpected:    false,
+		},
+		"cors-max-age-bad": {
+			rawCorsMaxAge:   "juan",
+			isErrorExpected: true,
+			errorMessage:    "invalid value for cors_max_age",
+		},
+		"cors-max-age-negative": {
+			rawCorsMaxAge:   "-5s",
+			isErrorExpected: true,
+			errorMessage:    "cors_max_age cannot be negative",
+		},
 	}
 
 	for name, tc := range tests {
@@ -851,6 +937,7 @@ func TestListener_parseCORSSettings(t *testing.T) {
 				CorsEnabledRaw:        tc.rawCorsEnabled,
 				CorsAllowedHeadersRaw: tc.rawCorsAllowedHeaders,
 				CorsAllowedOrigins:    tc.corsAllowedOrigins,
+				CorsMaxAgeRaw:         tc.rawCorsMaxAge,
 			}
 
 			err := l.parseCORSSettings()
@@ -864,10 +951,12 @@ func TestListener_parseCORSSettings(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, tc.expectedCorsEnabled, l.CorsEnabled)
 				require.Len(t, l.CorsAllowedHeaders, tc.expectedNumCorsAllowedHeaders)
+				require.Equal(t, tc.expectedCorsMaxAge, l.CorsMaxAge)
 
 				// Ensure the state was modified for the raw values.
 				require.Nil(t, l.CorsEnabledRaw)
 				require.Nil(t, l.CorsAllowedHeadersRaw)
+				require.Nil(t, l.CorsMaxAgeRaw)
 			}
 		})
 	}
@@ -165,6 +165,15 @@ type Telemetry struct {
 	// Whether or not telemetry should include the mount point in the rollback
 	// metrics
 	RollbackMetricsIncludeMountPoint bool `hcl:"add_mount_point_rollback_metrics"`
+
+	// OTLPLogsEndpoint is the OTLP/HTTP logs endpoint of a collector, such as
+	// http://localhost:4318/v1/logs, to export the server logs to.
+	OTLPLogsEndpoint string `hcl:"otlp_logs_endpoint"`
+	// OTLPLogsHeaders are sent with every export request to the collector.
+	OTLPLogsHeaders map[string]string `hcl:"otlp_logs_headers"`
+	// OTLPLogsLevel is the minimum level of the exported logs, which defaults
+	// to info.
+	OTLPLogsLevel string `hcl:"otlp_logs_level"`
 }
 
 func (t *Telemetry) Validate(source string) []ConfigError {
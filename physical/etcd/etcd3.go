@@ -24,6 +24,17 @@ import (
 	"go.etcd.io/etcd/client/v3/concurrency"
 )
 
+const (
+	// defaultMaxTxnOps is the default limit of operations per transaction of
+	// etcd, set by its --max-txn-ops flag.
+	defaultMaxTxnOps = 128
+
+	// maxTxnSize is the limit of the size of the keys and values of a
+	// transaction, leaving a margin below the default 1.5 MiB limit of the
+	// size of etcd requests for their encoding.
+	maxTxnSize = 1024 * 1024
+)
+
 // EtcdBackend is a physical backend that stores data at specific
 // prefix within etcd. It is used for most production situations as
 // it allows Vault to run on multiple machines in a highly-available manner.
@@ -33,6 +44,7 @@ type EtcdBackend struct {
 	haEnabled      bool
 	lockTimeout    time.Duration
 	requestTimeout time.Duration
+	maxTxnOps      int
 
 	permitPool *physical.PermitPool
 
@@ -41,9 +53,11 @@ type EtcdBackend struct {
 
 // Verify EtcdBackend satisfies the correct interfaces
 var (
-	_ physical.Backend   = (*EtcdBackend)(nil)
-	_ physical.HABackend = (*EtcdBackend)(nil)
-	_ physical.Lock      = (*EtcdLock)(nil)
+	_ physical.Backend             = (*EtcdBackend)(nil)
+	_ physical.HABackend           = (*EtcdBackend)(nil)
+	_ physical.Transactional       = (*EtcdBackend)(nil)
+	_ physical.TransactionalLimits = (*EtcdBackend)(nil)
+	_ physical.Lock                = (*EtcdLock)(nil)
 )
 
 // newEtcd3Backend constructs a etcd3 backend.
@@ -83,11 +97,15 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 	cert, hasCert := conf["tls_cert_file"]
 	key, hasKey := conf["tls_key_file"]
 	ca, hasCa := conf["tls_ca_file"]
-	if (hasCert && hasKey) || hasCa {
+	if hasCert != hasKey {
+		return nil, errors.New("'tls_cert_file' and 'tls_key_file' must be set together to authenticate to etcd with a client certificate")
+	}
+	if hasCert || hasCa {
 		tls := transport.TLSInfo{
 			TrustedCAFile: ca,
 			CertFile:      cert,
 			KeyFile:       key,
+			ServerName:    conf["tls_server_name"],
 		}
 
 		tlscfg, err := tls.ClientConfig()
@@ -174,6 +192,21 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 	if err != nil {
 		return nil, fmt.Errorf("value [%v] of 'lock_timeout' could not be understood: %w", sLock, err)
 	}
+	// The lock is held through an etcd lease, whose TTL is in whole seconds
+	if lock < time.Second {
+		return nil, fmt.Errorf("value [%v] of 'lock_timeout' must be at least 1s", sLock)
+	}
+
+	maxTxnOps := defaultMaxTxnOps
+	if sMaxTxnOps, ok := conf["max_txn_ops"]; ok {
+		maxTxnOps, err = strconv.Atoi(sMaxTxnOps)
+		if err != nil {
+			return nil, fmt.Errorf("value [%v] of 'max_txn_ops' could not be understood: %w", sMaxTxnOps, err)
+		}
+		if maxTxnOps < 1 {
+			return nil, fmt.Errorf("value [%v] of 'max_txn_ops' must be positive", sMaxTxnOps)
+		}
+	}
 
 	return &EtcdBackend{
 		path:           path,
@@ -183,6 +216,7 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		haEnabled:      haEnabledBool,
 		lockTimeout:    lock,
 		requestTimeout: reqTimeout,
+		maxTxnOps:      maxTxnOps,
 	}, nil
 }
 
@@ -270,6 +304,79 @@ func (c *EtcdBackend) List(ctx context.Context, prefix string) ([]string, error)
 	return keys, nil
 }
 
+// Transaction runs the operations of txns atomically in a single etcd
+// transaction. The values read by its get operations are set on their
+// entries.
+func (c *EtcdBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	if len(txns) == 0 {
+		return nil
+	}
+	defer metrics.MeasureSince([]string{"etcd", "transaction"}, time.Now())
+
+	// etcd rejects transactions writing the same key more than once, so only
+	// the last write of each key is kept, as it would win anyway
+	lastWrites := make(map[string]int, len(txns))
+	for i, txn := range txns {
+		switch txn.Operation {
+		case physical.PutOperation, physical.DeleteOperation:
+			lastWrites[txn.Entry.Key] = i
+		case physical.GetOperation:
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", txn.Operation)
+		}
+	}
+
+	ops := make([]clientv3.Op, 0, len(txns))
+	getOps := make(map[int]int)
+	for i, txn := range txns {
+		key := path.Join(c.path, txn.Entry.Key)
+		switch txn.Operation {
+		case physical.PutOperation:
+			if lastWrites[txn.Entry.Key] == i {
+				ops = append(ops, clientv3.OpPut(key, string(txn.Entry.Value)))
+			}
+		case physical.DeleteOperation:
+			if lastWrites[txn.Entry.Key] == i {
+				ops = append(ops, clientv3.OpDelete(key))
+			}
+		case physical.GetOperation:
+			getOps[i] = len(ops)
+			ops = append(ops, clientv3.OpGet(key))
+		}
+	}
+	if len(ops) > c.maxTxnOps {
+		return fmt.Errorf("transaction of %d operations exceeds the limit of %d operations", len(ops), c.maxTxnOps)
+	}
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+	resp, err := c.etcd.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		if strings.Contains(err.Error(), "request is too large") {
+			return fmt.Errorf("%s: %w", physical.ErrValueTooLarge, err)
+		}
+		return err
+	}
+
+	for i, op := range getOps {
+		txn := txns[i]
+		txn.Entry.Value = nil
+		if rangeResp := resp.Responses[op].GetResponseRange(); rangeResp != nil && len(rangeResp.Kvs) > 0 {
+			txn.Entry.Value = rangeResp.Kvs[0].Value
+		}
+	}
+	return nil
+}
+
+// TransactionLimits returns the limits of the number of operations and of
+// the size of the keys and values of a transaction.
+func (c *EtcdBackend) TransactionLimits() (int, int) {
+	return c.maxTxnOps, maxTxnSize
+}
+
 func (e *EtcdBackend) HAEnabled() bool {
 	return e.haEnabled
 }
@@ -361,7 +468,14 @@ func (c *EtcdLock) Unlock() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
-	return c.etcdMu.Unlock(ctx)
+	if err := c.etcdMu.Unlock(ctx); err != nil {
+		return err
+	}
+	c.held = false
+
+	// Revoke the lease of the session, which deletes the lock value along
+	// with it and stops its keepalives. The next Lock starts a new session.
+	return c.etcdSession.Close()
 }
 
 func (c *EtcdLock) Value() (bool, string, error) {
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// APISnapshot records the request and response fields of the operations of
+// the paths of a backend, with their types, to be compared across versions
+// for backwards compatibility.
+type APISnapshot struct {
+	// Paths maps the patterns of the paths to their operations.
+	Paths map[string]map[string]*APIOperationSnapshot `json:"paths"`
+}
+
+// APIOperationSnapshot records the fields of an operation, by name, with
+// their types.
+type APIOperationSnapshot struct {
+	Request map[string]string `json:"request"`
+
+	// Responses maps the status codes of the responses to their fields.
+	Responses map[string]map[string]string `json:"responses,omitempty"`
+}
+
+// NewAPISnapshot records the paths of the backend.
+func NewAPISnapshot(b *Backend) *APISnapshot {
+	s := &APISnapshot{
+		Paths: make(map[string]map[string]*APIOperationSnapshot, len(b.Paths)),
+	}
+	for _, p := range b.Paths {
+		request := make(map[string]string, len(p.Fields))
+		for name, field := range p.Fields {
+			request[name] = field.Type.String()
+		}
+
+		operations := make(map[string]*APIOperationSnapshot)
+		for op := range p.Callbacks {
+			operations[string(op)] = &APIOperationSnapshot{Request: request}
+		}
+		for op, handler := range p.Operations {
+			snapshot := &APIOperationSnapshot{Request: request}
+			for status, responses := range handler.Properties().Responses {
+				fields := make(map[string]string)
+				for _, response := range responses {
+					for name, field := range response.Fields {
+						fields[name] = field.Type.String()
+					}
+				}
+				if snapshot.Responses == nil {
+					snapshot.Responses = make(map[string]map[string]string)
+				}
+				snapshot.Responses[strconv.Itoa(status)] = fields
+			}
+			operations[string(op)] = snapshot
+		}
+		s.Paths[p.Pattern] = operations
+	}
+	return s
+}
+
+// Incompatibilities returns the changes from s to next which break clients of
+// s: removed paths, operations, request fields, and response fields, and
+// changed field types. Additions are compatible.
+func (s *APISnapshot) Incompatibilities(next *APISnapshot) []string {
+	var changes []string
+	for pattern, operations := range s.Paths {
+		nextOperations, ok := next.Paths[pattern]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: path removed", pattern))
+			continue
+		}
+		for op, operation := range operations {
+			nextOperation, ok := nextOperations[op]
+			if !ok {
+				changes = append(changes, fmt.Sprintf("%s: %s operation removed", pattern, op))
+				continue
+			}
+			changes = append(changes, compareAPIFields(fmt.Sprintf("%s: %s request", pattern, op), operation.Request, nextOperation.Request)...)
+			for status, fields := range operation.Responses {
+				changes = append(changes, compareAPIFields(fmt.Sprintf("%s: %s response %s", pattern, op, status), fields, nextOperation.Responses[status])...)
+			}
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func compareAPIFields(prefix string, fields, nextFields map[string]string) []string {
+	var changes []string
+	for name, typ := range fields {
+		nextType, ok := nextFields[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s field %q removed", prefix, name))
+		case nextType != typ:
+			changes = append(changes, fmt.Sprintf("%s field %q changed from %s to %s", prefix, name, typ, nextType))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testAPICompatBackend(responseType FieldType, extraField bool) *Backend {
+	fields := map[string]*FieldSchema{
+		"name": {Type: TypeString},
+	}
+	if extraField {
+		fields["ttl"] = &FieldSchema{Type: TypeDurationSecond}
+	}
+	return &Backend{
+		Paths: []*Path{
+			{
+				Pattern: "roles/(?P<name>.+)",
+				Fields:  fields,
+				Operations: map[logical.Operation]OperationHandler{
+					logical.ReadOperation: &PathOperation{
+						Responses: map[int][]Response{
+							http.StatusOK: {{
+								Fields: map[string]*FieldSchema{
+									"policies": {Type: responseType},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAPISnapshot_Incompatibilities(t *testing.T) {
+	old := NewAPISnapshot(testAPICompatBackend(TypeCommaStringSlice, false))
+
+	require.Empty(t, old.Incompatibilities(NewAPISnapshot(testAPICompatBackend(TypeCommaStringSlice, true))))
+	require.Equal(t, []string{
+		`roles/(?P<name>.+): read response 200 field "policies" changed from slice to string`,
+	}, old.Incompatibilities(NewAPISnapshot(testAPICompatBackend(TypeString, false))))
+	require.Equal(t, []string{
+		`roles/(?P<name>.+): path removed`,
+	}, old.Incompatibilities(NewAPISnapshot(&Backend{})))
+}
+
+func TestTestBackendAPICompatibility(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "api.json")
+	TestBackendAPICompatibility(t, testAPICompatBackend(TypeCommaStringSlice, false), golden)
+	require.FileExists(t, golden)
+	TestBackendAPICompatibility(t, testAPICompatBackend(TypeCommaStringSlice, true), golden)
+}
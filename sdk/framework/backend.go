@@ -294,15 +294,32 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		return nil, logical.ErrUnsupportedOperation
 	}
 
+	// Record that a deprecated endpoint handles the request
+	if req.Operation != logical.HelpOperation {
+		deprecated := path.Deprecated
+		if op, ok := path.Operations[req.Operation]; ok && op.Properties().Deprecated {
+			deprecated = true
+		}
+		if deprecated {
+			req.SetDeprecatedEndpoint(path.Pattern)
+			req.SetDeprecationHints(path.DeprecationReplacement, path.Sunset)
+		}
+	}
+
 	fd := FieldData{
 		Raw:    raw,
 		Schema: path.Fields,
 	}
 
 	if req.Operation != logical.HelpOperation {
+		if req.StrictFieldValidation() {
+			if details := fd.strictValidation(ignored); details != nil {
+				return logical.CodedErrorResponse(logical.ErrorCodeInvalidRequest, details, "Field validation failed"), nil
+			}
+		}
 		err := fd.Validate()
 		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Field validation failed: %s", err.Error())), nil
+			return logical.CodedErrorResponse(logical.ErrorCodeInvalidRequest, nil, "Field validation failed: %s", err.Error()), nil
 		}
 	}
 
@@ -311,6 +328,10 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		return resp, err
 	}
 
+	if op, ok := path.Operations[req.Operation]; ok && req.CompatibilityLevel() != "" {
+		applyCompatibilityLevel(req.CompatibilityLevel(), op.Properties().Responses, resp)
+	}
+
 	switch resp {
 	case nil:
 	default:
@@ -784,6 +805,26 @@ type FieldSchema struct {
 	// DisplayAttrs provides hints for UI and documentation generators. They
 	// will be included in OpenAPI output if set.
 	DisplayAttrs *DisplayAttributes
+
+	// MinDuration and MaxDuration bound the values of TypeDurationSecond and
+	// TypeSignedDurationSecond fields when non-zero. Values out of bounds
+	// fail the validation of the request.
+	MinDuration time.Duration
+	MaxDuration time.Duration
+
+	// MinSize and MaxSize bound the values of TypeSize fields, in bytes, when
+	// non-zero. Values out of bounds fail the validation of the request.
+	MinSize int64
+	MaxSize int64
+
+	// AddedIn, RenamedIn and PreviousName describe how a response field has
+	// changed across the compatibility levels of the backend, which are
+	// versions. Responses to requests to mounts pinned to a level before
+	// AddedIn omit the field, and those to mounts pinned to a level before
+	// RenamedIn return it under PreviousName.
+	AddedIn      string
+	RenamedIn    string
+	PreviousName string
 }
 
 // DefaultOrZero returns the default value if it is set, or otherwise
@@ -798,6 +839,13 @@ func (s *FieldSchema) DefaultOrZero() interface{} {
 			}
 			return int(resultDur.Seconds())
 
+		case TypeSize:
+			size, err := parseutil.ParseCapacityString(s.Default)
+			if err != nil {
+				return s.Type.Zero()
+			}
+			return int64(size)
+
 		default:
 			return s.Default
 		}
@@ -835,6 +883,8 @@ func (t FieldType) Zero() interface{} {
 		return 0.0
 	case TypeTime:
 		return time.Time{}
+	case TypeSize:
+		return int64(0)
 	default:
 		panic("unknown type: " + t.String())
 	}
@@ -97,6 +97,124 @@ func TestBackendHandleRequestFieldWarnings(t *testing.T) {
 	require.True(t, strutil.StrListContains(resp.Warnings, "Endpoint replaced the value of these parameters with the values captured from the endpoint's path: [name]"))
 }
 
+// TestBackendHandleRequestStrictFieldValidation verifies that requests with
+// unrecognized or invalid fields are rejected with structured details when
+// strict field validation is set.
+func TestBackendHandleRequestStrictFieldValidation(t *testing.T) {
+	backend := &Backend{
+		Paths: []*Path{
+			{
+				Pattern: "foo",
+				Fields: map[string]*FieldSchema{
+					"an_int":   {Type: TypeInt},
+					"a_string": {Type: TypeString},
+				},
+				Operations: map[logical.Operation]OperationHandler{
+					logical.UpdateOperation: &PathOperation{
+						Callback: func(context.Context, *logical.Request, *FieldData) (*logical.Response, error) {
+							return &logical.Response{}, nil
+						},
+					},
+				},
+			},
+		},
+	}
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo",
+		Data: map[string]interface{}{
+			"an_int":       "ten",
+			"a_string":     "accepted",
+			"unrecognized": true,
+		},
+	}
+	ctx := context.Background()
+
+	resp, err := backend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	req.Data["an_int"] = 10
+	resp, err = backend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError())
+	require.Len(t, resp.Warnings, 1)
+
+	req.SetStrictFieldValidation(true)
+	req.Data["an_int"] = "ten"
+	resp, err = backend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+	details := resp.Data[logical.ErrorDetailsKey].(map[string]interface{})
+	require.Contains(t, details["invalid_fields"], "an_int")
+	require.Equal(t, []string{"unrecognized"}, details["unrecognized_fields"])
+}
+
+// TestBackendHandleRequestCompatibilityLevel verifies that response fields
+// are removed or renamed according to the compatibility level the mount is
+// pinned to.
+func TestBackendHandleRequestCompatibilityLevel(t *testing.T) {
+	backend := &Backend{
+		Paths: []*Path{
+			{
+				Pattern: "foo",
+				Operations: map[logical.Operation]OperationHandler{
+					logical.ReadOperation: &PathOperation{
+						Callback: func(context.Context, *logical.Request, *FieldData) (*logical.Response, error) {
+							return &logical.Response{
+								Data: map[string]interface{}{
+									"name":       "foo",
+									"created_at": 1,
+									"expires":    2,
+								},
+							}, nil
+						},
+						Responses: map[int][]Response{
+							http.StatusOK: {{
+								Fields: map[string]*FieldSchema{
+									"name": {Type: TypeString},
+									"created_at": {
+										Type:         TypeInt,
+										RenamedIn:    "1.2",
+										PreviousName: "creation_time",
+									},
+									"expires": {
+										Type:    TypeInt,
+										AddedIn: "2.0",
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+
+	for level, expected := range map[string]map[string]interface{}{
+		"":    {"name": "foo", "created_at": 1, "expires": 2},
+		"2.0": {"name": "foo", "created_at": 1, "expires": 2},
+		"1.2": {"name": "foo", "created_at": 1},
+		"1.0": {"name": "foo", "creation_time": 1},
+	} {
+		req := &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "foo",
+		}
+		req.SetCompatibilityLevel(level)
+		resp, err := backend.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, expected, resp.Data, "level %q", level)
+	}
+
+	req := &logical.Request{}
+	req.SetCompatibilityLevel("1.5")
+	require.True(t, CompatibilityLevelBefore(req, "2.0"))
+	require.False(t, CompatibilityLevelBefore(req, "1.5"))
+	require.False(t, CompatibilityLevelBefore(&logical.Request{}, "2.0"))
+}
+
 func TestBackendHandleRequest(t *testing.T) {
 	callback := func(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
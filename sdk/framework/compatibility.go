@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// CompatibilityLevelBefore returns whether the mount the request was routed
+// to is pinned to a compatibility level before level, in which case backends
+// should respond as they did before the changes introduced at level. It
+// returns false when the mount is not pinned, or when either level is not a
+// valid version.
+func CompatibilityLevelBefore(req *logical.Request, level string) bool {
+	return compatibilityLevelBefore(req.CompatibilityLevel(), level)
+}
+
+func compatibilityLevelBefore(pinned, level string) bool {
+	if pinned == "" || level == "" {
+		return false
+	}
+	pinnedVersion, err := version.NewVersion(pinned)
+	if err != nil {
+		return false
+	}
+	levelVersion, err := version.NewVersion(level)
+	if err != nil {
+		return false
+	}
+	return pinnedVersion.LessThan(levelVersion)
+}
+
+// applyCompatibilityLevel removes and renames the fields of a successful
+// response according to how their schema says they have changed since the
+// pinned compatibility level.
+func applyCompatibilityLevel(pinned string, responses map[int][]Response, resp *logical.Response) {
+	if resp == nil || resp.Data == nil || resp.IsError() {
+		return
+	}
+
+	for _, rs := range responses {
+		for _, r := range rs {
+			for name, schema := range r.Fields {
+				value, ok := resp.Data[name]
+				if !ok {
+					continue
+				}
+				switch {
+				case compatibilityLevelBefore(pinned, schema.AddedIn):
+					delete(resp.Data, name)
+				case schema.PreviousName != "" && compatibilityLevelBefore(pinned, schema.RenamedIn):
+					delete(resp.Data, name)
+					resp.Data[schema.PreviousName] = value
+				}
+			}
+		}
+	}
+}
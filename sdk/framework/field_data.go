@@ -8,9 +8,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
@@ -43,7 +46,7 @@ func (d *FieldData) Validate() error {
 		switch schema.Type {
 		case TypeBool, TypeInt, TypeInt64, TypeMap, TypeDurationSecond, TypeSignedDurationSecond, TypeString,
 			TypeLowerCaseString, TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice,
-			TypeKVPairs, TypeCommaIntSlice, TypeHeader, TypeFloat, TypeTime:
+			TypeKVPairs, TypeCommaIntSlice, TypeHeader, TypeFloat, TypeTime, TypeSize:
 			_, _, err := d.getPrimitive(field, schema)
 			if err != nil {
 				return errwrap.Wrapf(fmt.Sprintf("error converting input %v for field %q: {{err}}", value, field), err)
@@ -90,6 +93,36 @@ func (d *FieldData) ValidateStrict() error {
 	return nil
 }
 
+// strictValidation validates the conversion of every raw field in the
+// schema, and returns the structured details of the fields which are invalid
+// along with the unrecognized ones, or nil if there are none.
+func (d *FieldData) strictValidation(unrecognized []string) map[string]interface{} {
+	invalid := make(map[string]interface{})
+	for field, value := range d.Raw {
+		schema, ok := d.Schema[field]
+		if !ok {
+			continue
+		}
+		if _, _, err := d.getPrimitive(field, schema); err != nil {
+			invalid[field] = fmt.Sprintf("invalid %s value %v: %s", schema.Type, value, err)
+		}
+	}
+	if len(invalid) == 0 && len(unrecognized) == 0 {
+		return nil
+	}
+
+	details := make(map[string]interface{})
+	if len(invalid) > 0 {
+		details["invalid_fields"] = invalid
+	}
+	if len(unrecognized) > 0 {
+		sorted := append([]string(nil), unrecognized...)
+		sort.Strings(sorted)
+		details["unrecognized_fields"] = sorted
+	}
+	return details
+}
+
 // Get gets the value for the given field. If the key is an invalid field,
 // FieldData will panic. If you want a safer version of this method, use
 // GetOk. If the field k is not set, the default value (if set) will be
@@ -170,7 +203,7 @@ func (d *FieldData) GetOkErr(k string) (interface{}, bool, error) {
 	switch schema.Type {
 	case TypeBool, TypeInt, TypeInt64, TypeMap, TypeDurationSecond, TypeSignedDurationSecond, TypeString,
 		TypeLowerCaseString, TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice,
-		TypeKVPairs, TypeCommaIntSlice, TypeHeader, TypeFloat, TypeTime:
+		TypeKVPairs, TypeCommaIntSlice, TypeHeader, TypeFloat, TypeTime, TypeSize:
 		return d.getPrimitive(k, schema)
 	default:
 		return nil, false,
@@ -263,6 +296,33 @@ func (d *FieldData) getPrimitive(k string, schema *FieldSchema) (interface{}, bo
 		if t == TypeDurationSecond && result < 0 {
 			return nil, false, fmt.Errorf("cannot provide negative value '%d'", result)
 		}
+		dur := time.Duration(result) * time.Second
+		if schema.MinDuration != 0 && dur < schema.MinDuration {
+			return nil, false, fmt.Errorf("value %s is below the minimum of %s", dur, schema.MinDuration)
+		}
+		if schema.MaxDuration != 0 && dur > schema.MaxDuration {
+			return nil, false, fmt.Errorf("value %s is above the maximum of %s", dur, schema.MaxDuration)
+		}
+		return result, true, nil
+
+	case TypeSize:
+		if raw == nil {
+			return nil, false, nil
+		}
+		size, err := parseutil.ParseCapacityString(raw)
+		if err != nil {
+			return nil, false, err
+		}
+		if size > math.MaxInt64 {
+			return nil, false, fmt.Errorf("value %d is too large", size)
+		}
+		result := int64(size)
+		if schema.MinSize != 0 && result < schema.MinSize {
+			return nil, false, fmt.Errorf("value %s is below the minimum of %s", FormatSize(result), FormatSize(schema.MinSize))
+		}
+		if schema.MaxSize != 0 && result > schema.MaxSize {
+			return nil, false, fmt.Errorf("value %s is above the maximum of %s", FormatSize(result), FormatSize(schema.MaxSize))
+		}
 		return result, true, nil
 
 	case TypeTime:
@@ -995,6 +995,60 @@ func TestFieldDataGet(t *testing.T) {
 			time.Time{},
 			true,
 		},
+
+		"type size, capacity string value": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize},
+			},
+			map[string]interface{}{
+				"foo": "10MiB",
+			},
+			"foo",
+			int64(10 << 20),
+			false,
+		},
+		"type size, int value": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize},
+			},
+			map[string]interface{}{
+				"foo": 2048,
+			},
+			"foo",
+			int64(2048),
+			false,
+		},
+		"type size, unset value with default": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize, Default: "1KiB"},
+			},
+			map[string]interface{}{},
+			"foo",
+			int64(1024),
+			false,
+		},
+		"type size, value within bounds": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize, MinSize: 1 << 10, MaxSize: 1 << 20},
+			},
+			map[string]interface{}{
+				"foo": "64KiB",
+			},
+			"foo",
+			int64(64 << 10),
+			false,
+		},
+		"duration type, value within bounds": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeDurationSecond, MinDuration: time.Minute, MaxDuration: time.Hour},
+			},
+			map[string]interface{}{
+				"foo": "30m",
+			},
+			"foo",
+			1800,
+			false,
+		},
 	}
 
 	for name, tc := range cases {
@@ -1113,6 +1167,42 @@ func TestFieldDataGet_Error(t *testing.T) {
 			},
 			"foo",
 		},
+		"duration type, value below minimum": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeDurationSecond, MinDuration: time.Minute},
+			},
+			map[string]interface{}{
+				"foo": "30s",
+			},
+			"foo",
+		},
+		"duration type, value above maximum": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeDurationSecond, MaxDuration: time.Hour},
+			},
+			map[string]interface{}{
+				"foo": "2h",
+			},
+			"foo",
+		},
+		"size type, invalid value": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize},
+			},
+			map[string]interface{}{
+				"foo": "10 parsecs",
+			},
+			"foo",
+		},
+		"size type, value above maximum": {
+			map[string]*FieldSchema{
+				"foo": {Type: TypeSize, MaxSize: 1 << 20},
+			},
+			map[string]interface{}{
+				"foo": "2MiB",
+			},
+			"foo",
+		},
 	}
 
 	for name, tc := range cases {
@@ -1275,3 +1365,19 @@ func TestValidateStrict(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatSize(t *testing.T) {
+	for size, expected := range map[int64]string{
+		0:         "0",
+		1000:      "1000",
+		1024:      "1KiB",
+		1536:      "1536",
+		10 << 20:  "10MiB",
+		3 << 30:   "3GiB",
+		1<<40 + 1: "1099511627777",
+	} {
+		if actual := FormatSize(size); actual != expected {
+			t.Fatalf("expected %d to be formatted as %q, got %q", size, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import "strconv"
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+// FormatSize returns the canonical representation of a size in bytes, as
+// accepted by TypeSize fields: the size in the largest binary unit that
+// divides it exactly, or in bytes otherwise. Backends should use it when
+// returning sizes, so that they read back the same as they were written.
+func FormatSize(size int64) string {
+	if size != 0 {
+		for _, unit := range sizeUnits {
+			if size%unit.bytes == 0 {
+				return strconv.FormatInt(size/unit.bytes, 10) + unit.suffix
+			}
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
@@ -67,6 +67,10 @@ const (
 	// is converted to UTC.
 	TypeTime
 
+	// TypeSize represents a size in bytes, this can be either an integer or
+	// a capacity string with a unit suffix (e.g. 10MiB or 5GB)
+	TypeSize
+
 	// DO NOT USE. Any new values must be inserted before this value.
 	// Used to write tests that ensure type methods handle all possible values.
 	typeInvalidMax
@@ -100,6 +104,8 @@ func (t FieldType) String() string {
 		return "float"
 	case TypeTime:
 		return "time"
+	case TypeSize:
+		return "size"
 	default:
 		return "unknown type"
 	}
@@ -113,6 +113,7 @@ type OASPathItem struct {
 	Unauthenticated bool               `json:"x-vault-unauthenticated,omitempty" mapstructure:"x-vault-unauthenticated"`
 	CreateSupported bool               `json:"x-vault-createSupported,omitempty" mapstructure:"x-vault-createSupported"`
 	DisplayAttrs    *DisplayAttributes `json:"x-vault-displayAttrs,omitempty" mapstructure:"x-vault-displayAttrs"`
+	Sensitivity     PathSensitivity    `json:"x-vault-sensitivity,omitempty" mapstructure:"x-vault-sensitivity"`
 
 	Get    *OASOperation `json:"get,omitempty"`
 	Post   *OASOperation `json:"post,omitempty"`
@@ -277,6 +278,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 		pi.Sudo = specialPathMatch(path, sudoPaths)
 		pi.Unauthenticated = specialPathMatch(path, unauthPaths)
 		pi.DisplayAttrs = withoutOperationHints(p.DisplayAttrs)
+		pi.Sensitivity = p.Sensitivity
 
 		// If the newer style Operations map isn't defined, create one from the legacy fields.
 		operations := p.Operations
@@ -621,6 +623,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 				Description:  pi.Description,
 				Parameters:   pi.Parameters,
 				DisplayAttrs: pi.DisplayAttrs,
+				Sensitivity:  pi.Sensitivity,
 
 				// Since the path may now have an extra slash on the end, we need to recalculate the special path
 				// matches, as the sudo or unauthenticated status may be changed as a result!
@@ -1085,6 +1088,9 @@ func convertType(t FieldType) schemaType {
 	case TypeTime:
 		ret.baseType = "string"
 		ret.format = "date-time"
+	case TypeSize:
+		ret.baseType = "string"
+		ret.format = "size"
 	case TypeFloat:
 		ret.baseType = "number"
 		ret.format = "float"
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/helper/license"
@@ -49,6 +50,44 @@ func PathAppend(paths ...[]*Path) []*Path {
 	return result
 }
 
+// PathSensitivity classifies how sensitive the data and operations of a path
+// are.
+type PathSensitivity string
+
+const (
+	// SensitivityLow is for paths exposing no secret material, such as
+	// informational or health endpoints.
+	SensitivityLow PathSensitivity = "low"
+
+	// SensitivityModerate is for paths exposing or changing configuration
+	// which is not secret.
+	SensitivityModerate PathSensitivity = "moderate"
+
+	// SensitivityHigh is for paths exposing or generating secret material,
+	// such as credentials or keys.
+	SensitivityHigh PathSensitivity = "high"
+
+	// SensitivityCritical is for paths whose access compromises the whole
+	// backend, such as root credential configuration or rotation.
+	SensitivityCritical PathSensitivity = "critical"
+)
+
+// Rank orders the sensitivities, from 0 for an unset one.
+func (s PathSensitivity) Rank() int {
+	switch s {
+	case SensitivityLow:
+		return 1
+	case SensitivityModerate:
+		return 2
+	case SensitivityHigh:
+		return 3
+	case SensitivityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
 // Path is a single path that the backend responds to.
 type Path struct {
 	// Pattern is the pattern of the URL that matches this path.
@@ -125,6 +164,13 @@ type Path struct {
 	// be reflected in help and documentation.
 	Deprecated bool
 
+	// DeprecationReplacement is the path, relative to the mount, to use
+	// instead of the path when it is deprecated, and Sunset is when the path
+	// is planned to be removed. Both are returned to clients in response
+	// headers when they use the path, and reported with its usage.
+	DeprecationReplacement string
+	Sunset                 time.Time
+
 	// Help is text describing how to use this path. This will be used
 	// to auto-generate the help operation. The Path will automatically
 	// generate a parameter listing and URL structure based on the
@@ -143,6 +189,11 @@ type Path struct {
 	// will be included in OpenAPI output if set.
 	DisplayAttrs *DisplayAttributes
 
+	// Sensitivity classifies how sensitive the data and operations of the
+	// path are, for policy authoring tools to highlight the access granted
+	// to it. It is included in OpenAPI output if set.
+	Sensitivity PathSensitivity
+
 	// TakesArbitraryInput is used for endpoints that take arbitrary input, instead
 	// of or as well as their Fields. This is taken into account when printing
 	// warnings about ignored fields. If this is set, we will not warn when data is
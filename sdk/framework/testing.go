@@ -4,9 +4,21 @@
 package framework
 
 import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+// EnvUpdateGoldenFiles is the environment variable which, when set, makes
+// TestBackendAPICompatibility record the golden files instead of comparing
+// them.
+const EnvUpdateGoldenFiles = "VAULT_UPDATE_GOLDEN_FILES"
+
 // TestBackendRoutes is a helper to test that all the given routes will
 // route properly in the backend.
 func TestBackendRoutes(t *testing.T, b *Backend, rs []string) {
@@ -16,3 +28,43 @@ func TestBackendRoutes(t *testing.T, b *Backend, rs []string) {
 		}
 	}
 }
+
+// TestBackendAPICompatibility is a helper to test that the paths of the
+// backend are backwards compatible with the snapshot recorded in the golden
+// file, failing if any path, operation or field was removed or changed type.
+// The golden file is recorded if it does not exist or EnvUpdateGoldenFiles is
+// set, and is to be recorded again when the API is extended.
+func TestBackendAPICompatibility(t *testing.T, b *Backend, golden string) {
+	t.Helper()
+	snapshot := NewAPISnapshot(b)
+
+	buf, err := os.ReadFile(golden)
+	if errors.Is(err, fs.ErrNotExist) || os.Getenv(EnvUpdateGoldenFiles) != "" {
+		buf, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, append(buf, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("recorded golden file %s", golden)
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded APISnapshot
+	if err := json.Unmarshal(buf, &recorded); err != nil {
+		t.Fatalf("failed to parse golden file %s: %s", golden, err)
+	}
+	if changes := recorded.Incompatibilities(snapshot); len(changes) > 0 {
+		t.Fatalf("backwards incompatible API changes from golden file %s:\n%s", golden, strings.Join(changes, "\n"))
+	}
+	if !reflect.DeepEqual(&recorded, snapshot) {
+		t.Logf("the API was extended, set %s to record golden file %s again", EnvUpdateGoldenFiles, golden)
+	}
+}
@@ -7,6 +7,7 @@ package clientcountutil
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -164,6 +165,18 @@ func WithClientID(id string) ClientOption {
 	}
 }
 
+// WithClientOverlap records the new clients as also seen in the namespace and
+// mount, with the same IDs, as happens with entities that have aliases in
+// several mounts
+func WithClientOverlap(namespace, mount string) ClientOption {
+	return func(client *generation.Client) {
+		client.Overlaps = append(client.Overlaps, &generation.ClientLocation{
+			Namespace: namespace,
+			Mount:     mount,
+		})
+	}
+}
+
 // ClientsSeen adds clients to the month that was most recently opened with
 // NewPreviousMonthData or NewCurrentMonthData.
 func (d *ActivityLogDataGenerator) ClientsSeen(clients ...*generation.Client) *ActivityLogDataGenerator {
@@ -276,36 +289,64 @@ func (d *ActivityLogDataGenerator) ToProto() *generation.ActivityLogMockInput {
 	return d.data
 }
 
+// MonthSummary holds the number of distinct clients and namespaces of a month
+// of generated data, and the number of segments written for it.
+type MonthSummary struct {
+	MonthsAgo       int `json:"months_ago"`
+	Entities        int `json:"entities"`
+	NonEntityTokens int `json:"non_entity_tokens"`
+	Namespaces      int `json:"namespaces"`
+	Segments        int `json:"segments"`
+}
+
 // Write writes the data to the API with the given write options. The method
 // returns the new paths that have been written. Note that the API endpoint will
 // only be present when Vault has been compiled with the "testonly" flag.
 func (d *ActivityLogDataGenerator) Write(ctx context.Context, writeOptions ...generation.WriteOptions) ([]string, error) {
+	paths, _, err := d.WriteWithSummary(ctx, writeOptions...)
+	return paths, err
+}
+
+// WriteWithSummary writes the data to the API like Write, and also returns
+// the summary of each month of written data, from the current month.
+func (d *ActivityLogDataGenerator) WriteWithSummary(ctx context.Context, writeOptions ...generation.WriteOptions) ([]string, []MonthSummary, error) {
 	d.data.Write = writeOptions
 	err := VerifyInput(d.data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	data, err := d.ToJSON()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	resp, err := d.client.Logical().WriteWithContext(ctx, "sys/internal/counters/activity/write", map[string]interface{}{"input": string(data)})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if resp.Data == nil {
-		return nil, fmt.Errorf("received no data")
+		return nil, nil, fmt.Errorf("received no data")
 	}
 	paths := resp.Data["paths"]
 	castedPaths, ok := paths.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid paths data: %v", paths)
+		return nil, nil, fmt.Errorf("invalid paths data: %v", paths)
 	}
 	returnPaths := make([]string, 0, len(castedPaths))
 	for _, path := range castedPaths {
 		returnPaths = append(returnPaths, path.(string))
 	}
-	return returnPaths, nil
+
+	var summary []MonthSummary
+	if months, ok := resp.Data["months"]; ok {
+		buf, err := json.Marshal(months)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(buf, &summary); err != nil {
+			return nil, nil, fmt.Errorf("invalid months data: %w", err)
+		}
+	}
+	return returnPaths, summary, nil
 }
 
 // VerifyInput checks that the input data is valid
@@ -374,6 +415,20 @@ func VerifyInput(input *generation.ActivityLogMockInput) error {
 		}
 	}
 
+	// only new clients can be seen in several namespaces or mounts, as the
+	// repeated clients are those of the earlier months
+	for _, month := range input.Data {
+		clients := month.GetAll().GetClients()
+		for _, segment := range month.GetSegments().GetSegments() {
+			clients = append(clients, segment.GetClients().GetClients()...)
+		}
+		for _, client := range clients {
+			if len(client.Overlaps) > 0 && (client.Repeated || client.RepeatedFromMonth != 0) {
+				return fmt.Errorf("repeated clients cannot have overlaps")
+			}
+		}
+	}
+
 	// check that the corresponding month exists for all the RepeatedFromMonth
 	// values
 	for repeated := range repeatedFromMonths {
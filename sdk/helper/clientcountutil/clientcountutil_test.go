@@ -143,6 +143,35 @@ func TestWrite(t *testing.T) {
 	require.Equal(t, []string{"path1", "path2"}, paths)
 }
 
+// TestWriteWithSummary verifies that the summary of the written months is
+// parsed from the response
+func TestWriteWithSummary(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{"data":{"paths":["path1"],"months":[{"months_ago":0,"entities":3,"non_entity_tokens":1,"namespaces":1,"segments":1}]}}`)
+		require.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{
+		Address: ts.URL,
+	})
+	require.NoError(t, err)
+	paths, summary, err := NewActivityLogData(client).
+		NewCurrentMonthData().
+		NewClientsSeen(3).
+		NewClientSeen(WithClientIsNonEntity()).
+		WriteWithSummary(context.Background(), generation.WriteOptions_WRITE_ENTITIES)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"path1"}, paths)
+	require.Equal(t, []MonthSummary{{
+		Entities:        3,
+		NonEntityTokens: 1,
+		Namespaces:      1,
+		Segments:        1,
+	}}, summary)
+}
+
 func testAddClients(t *testing.T, makeGenerator func() *ActivityLogDataGenerator, getClient func(data *ActivityLogDataGenerator) *generation.Client) {
 	t.Helper()
 	clientOptions := []ClientOption{
@@ -263,6 +292,14 @@ func TestVerifyInput(t *testing.T) {
 				Segment(WithSegmentIndex(1)).
 				Segment(WithSegmentIndex(1)),
 		},
+		{
+			name: "repeated client with overlap",
+			generator: NewActivityLogData(nil).
+				NewPreviousMonthData(1).
+				NewClientSeen().
+				NewCurrentMonthData().
+				RepeatedClientSeen(WithClientOverlap("ns1/", "mount")),
+		},
 		{
 			name: "segment with num segments",
 			generator: NewActivityLogData(nil).
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clientcountutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/vault/sdk/helper/clientcountutil/generation"
+)
+
+// The names of the dataset presets, which can be set as the preset of an
+// ActivityLogMockInput instead of its data.
+const (
+	// PresetSteadyGrowth12Months has 12 months of entity and non-entity
+	// clients, with more new clients every month and most of the clients of a
+	// month seen again the next month.
+	PresetSteadyGrowth12Months = "steady-growth-12mo"
+
+	// PresetChurnHeavy has 6 months of entity clients, of which few are seen
+	// again the next month.
+	PresetChurnHeavy = "churn-heavy"
+
+	// PresetMultiNamespaceSkewed has 3 months of entity clients spread over
+	// the root namespace and the ns1/, ns2/ and ns3/ namespaces, most of them
+	// in the root namespace. The namespaces must exist, or be created by the
+	// activity write endpoint.
+	PresetMultiNamespaceSkewed = "multi-namespace-skewed"
+)
+
+var presets = map[string]func(d *ActivityLogDataGenerator){
+	PresetSteadyGrowth12Months: presetSteadyGrowth12Months,
+	PresetChurnHeavy:           presetChurnHeavy,
+	PresetMultiNamespaceSkewed: presetMultiNamespaceSkewed,
+}
+
+// Presets returns the names of the dataset presets, sorted.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandPreset replaces the data of the input with the dataset of its preset,
+// if it has one. The months of the dataset are ordered from the earliest, for
+// their repeated clients to be seen before.
+func ExpandPreset(input *generation.ActivityLogMockInput) error {
+	if input.Preset == "" {
+		return nil
+	}
+	if len(input.Data) > 0 {
+		return fmt.Errorf("cannot specify both a preset and data")
+	}
+	preset, ok := presets[input.Preset]
+	if !ok {
+		return fmt.Errorf("unknown preset %q, expected one of %v", input.Preset, Presets())
+	}
+	d := NewActivityLogData(nil)
+	preset(d)
+	input.Data = d.ToProto().Data
+	input.Preset = ""
+	return nil
+}
+
+// Preset sets the preset of the input, for its dataset to be generated by the
+// API instead of the months opened on the generator.
+func (d *ActivityLogDataGenerator) Preset(name string) *ActivityLogDataGenerator {
+	d.data.Preset = name
+	return d
+}
+
+// month opens the month, the current month if monthsAgo is 0.
+func (d *ActivityLogDataGenerator) month(monthsAgo int) *ActivityLogDataGenerator {
+	if monthsAgo == 0 {
+		return d.NewCurrentMonthData()
+	}
+	return d.NewPreviousMonthData(monthsAgo)
+}
+
+func presetSteadyGrowth12Months(d *ActivityLogDataGenerator) {
+	entities, nonEntities := 0, 0
+	for monthsAgo := 11; monthsAgo >= 0; monthsAgo-- {
+		d.month(monthsAgo)
+		newEntities := 20 + 5*(11-monthsAgo)
+		newNonEntities := newEntities / 4
+		d.NewClientsSeen(newEntities)
+		d.NewClientsSeen(newNonEntities, WithClientIsNonEntity())
+
+		repeatedEntities, repeatedNonEntities := entities*3/4, nonEntities*3/4
+		if repeatedEntities > 0 {
+			d.RepeatedClientsSeen(repeatedEntities)
+		}
+		if repeatedNonEntities > 0 {
+			d.RepeatedClientsSeen(repeatedNonEntities, WithClientIsNonEntity())
+		}
+		entities = newEntities + repeatedEntities
+		nonEntities = newNonEntities + repeatedNonEntities
+	}
+}
+
+func presetChurnHeavy(d *ActivityLogDataGenerator) {
+	for monthsAgo := 5; monthsAgo >= 0; monthsAgo-- {
+		d.month(monthsAgo)
+		d.NewClientsSeen(50)
+		if monthsAgo < 5 {
+			d.RepeatedClientsSeen(5)
+		}
+	}
+}
+
+func presetMultiNamespaceSkewed(d *ActivityLogDataGenerator) {
+	shares := []struct {
+		namespace string
+		clients   int
+	}{
+		{"", 60},
+		{"ns1/", 25},
+		{"ns2/", 10},
+		{"ns3/", 5},
+	}
+	for monthsAgo := 2; monthsAgo >= 0; monthsAgo-- {
+		d.month(monthsAgo)
+		for _, share := range shares {
+			d.NewClientsSeen(share.clients, WithClientNamespace(share.namespace))
+			if monthsAgo < 2 {
+				d.RepeatedClientsSeen(share.clients/2, WithClientNamespace(share.namespace))
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clientcountutil
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/clientcountutil/generation"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpandPreset verifies that every preset expands into valid data, with
+// the earliest month first, and that a preset cannot be combined with data.
+func TestExpandPreset(t *testing.T) {
+	for _, name := range Presets() {
+		t.Run(name, func(t *testing.T) {
+			input := &generation.ActivityLogMockInput{Preset: name}
+			require.NoError(t, ExpandPreset(input))
+			require.NotEmpty(t, input.Data)
+			require.Empty(t, input.Preset)
+			require.NoError(t, VerifyInput(input))
+			require.Greater(t, input.Data[0].GetMonthsAgo(), input.Data[len(input.Data)-1].GetMonthsAgo())
+		})
+	}
+
+	input := &generation.ActivityLogMockInput{Preset: "unknown"}
+	require.Error(t, ExpandPreset(input))
+
+	input = NewActivityLogData(nil).Preset(PresetChurnHeavy).NewCurrentMonthData().NewClientSeen().ToProto()
+	require.Error(t, ExpandPreset(input))
+}
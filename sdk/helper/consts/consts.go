@@ -23,6 +23,18 @@ const (
 	// wrap the response
 	WrapTTLHeaderName = "X-Vault-Wrap-TTL"
 
+	// RequestTimeoutHeaderName is the name of the header containing the
+	// maximum duration the client is willing to wait for the request.
+	RequestTimeoutHeaderName = "X-Vault-Request-Timeout"
+
+	// IdempotencyKeyHeaderName is the name of the header containing a client
+	// generated key which identifies retries of the same mutating request.
+	IdempotencyKeyHeaderName = "X-Vault-Idempotency-Key"
+
+	// IdempotentReplayedHeaderName is the name of the response header set
+	// when a response is a replay of a previously recorded result.
+	IdempotentReplayedHeaderName = "X-Vault-Idempotent-Replayed"
+
 	// PerformanceReplicationALPN is the negotiated protocol used for
 	// performance replication.
 	PerformanceReplicationALPN = "replication_v1"
@@ -36,6 +48,10 @@ const (
 
 	RaftStorageALPN = "raft_storage_v1"
 
+	// OffloadWorkALPN is the negotiated protocol used by the active node to
+	// delegate work to standby nodes.
+	OffloadWorkALPN = "offload_work_v1"
+
 	// ReplicationResolverALPN is the negotiated protocol used for
 	// resolving replicaiton addresses
 	ReplicationResolverALPN = "replication_resolver_v1"
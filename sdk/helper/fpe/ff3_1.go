@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// This package implements the FF3-1 format-preserving encryption mode of
+// NIST SP 800-38G Revision 1. Values are encrypted into values of the same
+// length over the same alphabet, which allows tokenizing data such as card
+// numbers without changing their format.
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+)
+
+const (
+	// TweakSize is the size in bytes of FF3-1 tweaks.
+	TweakSize = 7
+
+	// minDomainSize is the minimum number of possible values of an input,
+	// as required by SP 800-38G Revision 1.
+	minDomainSize = 1000000
+
+	rounds = 8
+)
+
+// FF31 encrypts values over an alphabet with the FF3-1 mode.
+type FF31 struct {
+	block    cipher.Block
+	alphabet []rune
+	index    map[rune]uint16
+	minLen   int
+	maxLen   int
+}
+
+// NewFF31 returns an FF3-1 cipher with the given AES key, which must be 16,
+// 24 or 32 bytes, over the given alphabet of 2 to 65536 distinct
+// characters.
+func NewFF31(key []byte, alphabet string) (*FF31, error) {
+	if !utf8.ValidString(alphabet) {
+		return nil, errors.New("alphabet is not valid UTF-8")
+	}
+	runes := []rune(alphabet)
+	if len(runes) < 2 || len(runes) > 1<<16 {
+		return nil, errors.New("alphabet must contain between 2 and 65536 characters")
+	}
+	index := make(map[rune]uint16, len(runes))
+	for i, r := range runes {
+		if _, ok := index[r]; ok {
+			return nil, fmt.Errorf("alphabet contains duplicate character %q", r)
+		}
+		index[r] = uint16(i)
+	}
+
+	// The key is used with its bytes reversed.
+	revKey := make([]byte, len(key))
+	for i := range key {
+		revKey[len(key)-1-i] = key[i]
+	}
+	block, err := aes.NewCipher(revKey)
+	if err != nil {
+		return nil, err
+	}
+
+	minLen, maxLen := lengthBounds(len(runes))
+	if minLen > maxLen {
+		return nil, errors.New("alphabet is too small for FF3-1")
+	}
+	return &FF31{
+		block:    block,
+		alphabet: runes,
+		index:    index,
+		minLen:   minLen,
+		maxLen:   maxLen,
+	}, nil
+}
+
+// lengthBounds returns the minimum length for which the domain has at least
+// a million values, and the maximum length 2*floor(log_radix(2^96)).
+func lengthBounds(radix int) (int, int) {
+	r := big.NewInt(int64(radix))
+
+	minLen := 0
+	for n, min := big.NewInt(1), big.NewInt(minDomainSize); n.Cmp(min) < 0; n.Mul(n, r) {
+		minLen++
+	}
+
+	half := 0
+	for n, max := new(big.Int).Set(r), new(big.Int).Lsh(big.NewInt(1), 96); n.Cmp(max) <= 0; n.Mul(n, r) {
+		half++
+	}
+	return minLen, 2 * half
+}
+
+// MinLen returns the minimum length of values.
+func (c *FF31) MinLen() int {
+	return c.minLen
+}
+
+// MaxLen returns the maximum length of values.
+func (c *FF31) MaxLen() int {
+	return c.maxLen
+}
+
+// Encrypt encrypts the value with the given 7 byte tweak.
+func (c *FF31) Encrypt(value string, tweak []byte) (string, error) {
+	return c.transform(value, tweak, true)
+}
+
+// Decrypt decrypts the value with the given 7 byte tweak.
+func (c *FF31) Decrypt(value string, tweak []byte) (string, error) {
+	return c.transform(value, tweak, false)
+}
+
+func (c *FF31) transform(value string, tweak []byte, encrypt bool) (string, error) {
+	if len(tweak) != TweakSize {
+		return "", fmt.Errorf("tweak must be %d bytes", TweakSize)
+	}
+	numerals, err := c.numerals(value)
+	if err != nil {
+		return "", err
+	}
+	if len(numerals) < c.minLen || len(numerals) > c.maxLen {
+		return "", fmt.Errorf("value must be between %d and %d characters long", c.minLen, c.maxLen)
+	}
+
+	// The 56-bit tweak is split in two 32-bit halves, each taking half of
+	// the middle byte.
+	var tl, tr [4]byte
+	copy(tl[:], tweak[:4])
+	tl[3] &= 0xf0
+	copy(tr[:], tweak[4:])
+	tr[3] = tweak[3] << 4
+
+	var out []uint16
+	if encrypt {
+		out = c.encrypt(numerals, tl, tr)
+	} else {
+		out = c.decrypt(numerals, tl, tr)
+	}
+
+	result := make([]rune, len(out))
+	for i, n := range out {
+		result[i] = c.alphabet[n]
+	}
+	return string(result), nil
+}
+
+func (c *FF31) numerals(value string) ([]uint16, error) {
+	var numerals []uint16
+	for _, r := range value {
+		n, ok := c.index[r]
+		if !ok {
+			return nil, fmt.Errorf("value contains character %q outside of the alphabet", r)
+		}
+		numerals = append(numerals, n)
+	}
+	return numerals, nil
+}
+
+// encrypt implements the FF3 Feistel network over the tweak halves; FF3-1
+// only differs from FF3 in how they are derived.
+func (c *FF31) encrypt(x []uint16, tl, tr [4]byte) []uint16 {
+	u := (len(x) + 1) / 2
+	v := len(x) - u
+	a := append([]uint16{}, x[:u]...)
+	b := append([]uint16{}, x[u:]...)
+
+	for i := 0; i < rounds; i++ {
+		m, w := u, tr
+		if i%2 == 1 {
+			m, w = v, tl
+		}
+		y := c.roundValue(w, i, b)
+
+		n := c.num(a)
+		n.Add(n, y)
+		n.Mod(n, c.modulus(m))
+		a, b = b, c.str(n, m)
+	}
+	return append(a, b...)
+}
+
+func (c *FF31) decrypt(x []uint16, tl, tr [4]byte) []uint16 {
+	u := (len(x) + 1) / 2
+	v := len(x) - u
+	a := append([]uint16{}, x[:u]...)
+	b := append([]uint16{}, x[u:]...)
+
+	for i := rounds - 1; i >= 0; i-- {
+		m, w := u, tr
+		if i%2 == 1 {
+			m, w = v, tl
+		}
+		y := c.roundValue(w, i, a)
+
+		n := c.num(b)
+		n.Sub(n, y)
+		n.Mod(n, c.modulus(m))
+		a, b = c.str(n, m), a
+	}
+	return append(a, b...)
+}
+
+// roundValue computes y = NUM(REVB(CIPH(REVB(P)))), where
+// P = W xor [i]^4 || [NUM_radix(REV(x))]^12.
+func (c *FF31) roundValue(w [4]byte, i int, x []uint16) *big.Int {
+	var p [aes.BlockSize]byte
+	copy(p[:4], w[:])
+	p[3] ^= byte(i)
+	c.num(x).FillBytes(p[4:])
+
+	reverseBytes(p[:])
+	c.block.Encrypt(p[:], p[:])
+	reverseBytes(p[:])
+	return new(big.Int).SetBytes(p[:])
+}
+
+// num returns NUM_radix(REV(x)): the numerals are read least significant
+// first.
+func (c *FF31) num(x []uint16) *big.Int {
+	radix := big.NewInt(int64(len(c.alphabet)))
+	n := new(big.Int)
+	for i := len(x) - 1; i >= 0; i-- {
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(x[i])))
+	}
+	return n
+}
+
+// str returns REV(STR^m_radix(n)), the inverse of num.
+func (c *FF31) str(n *big.Int, m int) []uint16 {
+	radix := big.NewInt(int64(len(c.alphabet)))
+	n = new(big.Int).Set(n)
+	digit := new(big.Int)
+	out := make([]uint16, m)
+	for i := 0; i < m; i++ {
+		n.DivMod(n, radix, digit)
+		out[i] = uint16(digit.Int64())
+	}
+	return out
+}
+
+func (c *FF31) modulus(m int) *big.Int {
+	radix := big.NewInt(int64(len(c.alphabet)))
+	return new(big.Int).Exp(radix, big.NewInt(int64(m)), nil)
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package fpe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	digits    = "0123456789"
+	base26    = "0123456789abcdefghijklmnop"
+	sampleKey = "EF4359D8D580AA4F7F036D6F04FC6A94"
+)
+
+// The FF3-1 Feistel network is the one of FF3, so it is checked against the
+// FF3 samples published by NIST, which use 64-bit tweaks.
+func TestFF31_FF3Samples(t *testing.T) {
+	tests := []struct {
+		alphabet   string
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{digits, "D8E7920AFA330A73", "890121234567890000", "750918814058654607"},
+		{digits, "9A768A92F60E12D8", "890121234567890000", "018989839189395384"},
+		{digits, "D8E7920AFA330A73", "89012123456789000000789000000", "48598367162252569629397416226"},
+		{digits, "0000000000000000", "89012123456789000000789000000", "34695224821734535122613701434"},
+		{base26, "9A768A92F60E12D8", "0123456789abcdefghi", "g2pk40i992fn20cjakb"},
+	}
+
+	key, _ := hex.DecodeString(sampleKey)
+	for _, test := range tests {
+		c, err := NewFF31(key, test.alphabet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak, _ := hex.DecodeString(test.tweak)
+		var tl, tr [4]byte
+		copy(tl[:], tweak[:4])
+		copy(tr[:], tweak[4:])
+
+		x, err := c.numerals(test.plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := c.encrypt(x, tl, tr)
+		if got := string(runes(c, ct)); got != test.ciphertext {
+			t.Fatalf("expected %s, got %s", test.ciphertext, got)
+		}
+		if got := string(runes(c, c.decrypt(ct, tl, tr))); got != test.plaintext {
+			t.Fatalf("expected %s, got %s", test.plaintext, got)
+		}
+	}
+}
+
+func runes(c *FF31, x []uint16) []rune {
+	out := make([]rune, len(x))
+	for i, n := range x {
+		out[i] = c.alphabet[n]
+	}
+	return out
+}
+
+func TestFF31_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x2b}, 32)
+	tweak := []byte{1, 2, 3, 4, 5, 6, 7}
+
+	c, err := NewFF31(key, digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MinLen() != 6 || c.MaxLen() != 56 {
+		t.Fatalf("unexpected length bounds %d..%d", c.MinLen(), c.MaxLen())
+	}
+
+	for _, value := range []string{"123456", "4111111111111111", "0000000000000000000"} {
+		ct, err := c.Encrypt(value, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != len(value) || ct == value {
+			t.Fatalf("unexpected ciphertext %q for %q", ct, value)
+		}
+		again, err := c.Encrypt(value, tweak)
+		if err != nil || again != ct {
+			t.Fatalf("expected encryption to be deterministic")
+		}
+		other, err := c.Encrypt(value, []byte{7, 6, 5, 4, 3, 2, 1})
+		if err != nil || other == ct {
+			t.Fatalf("expected the tweak to change the ciphertext")
+		}
+		pt, err := c.Decrypt(ct, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pt != value {
+			t.Fatalf("expected %q, got %q", value, pt)
+		}
+	}
+
+	if _, err := c.Encrypt("12345", tweak); err == nil {
+		t.Fatal("expected value below the minimum length to be rejected")
+	}
+	if _, err := c.Encrypt("12345a", tweak); err == nil {
+		t.Fatal("expected character outside of the alphabet to be rejected")
+	}
+	if _, err := c.Encrypt("123456", tweak[:6]); err == nil {
+		t.Fatal("expected short tweak to be rejected")
+	}
+}
+
+func TestFF31_Alphabet(t *testing.T) {
+	key := bytes.Repeat([]byte{0x2b}, 16)
+	if _, err := NewFF31(key, "a"); err == nil {
+		t.Fatal("expected single character alphabet to be rejected")
+	}
+	if _, err := NewFF31(key, "abca"); err == nil {
+		t.Fatal("expected duplicate characters to be rejected")
+	}
+	if _, err := NewFF31(key[:5], digits); err == nil {
+		t.Fatal("expected invalid key size to be rejected")
+	}
+
+	c, err := NewFF31(key, "αβγδεζηθικλμνξοπ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := c.Encrypt("αβγδεζηθ", []byte{0, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := c.Decrypt(ct, []byte{0, 0, 0, 0, 0, 0, 0}); err != nil || pt != "αβγδεζηθ" {
+		t.Fatalf("unexpected round trip %q: %v", pt, err)
+	}
+}
@@ -68,6 +68,7 @@ const (
 	KeyType_RSA3072
 	KeyType_MANAGED_KEY
 	KeyType_HMAC
+	KeyType_AES256_FF3_1
 )
 
 const (
@@ -168,6 +169,14 @@ func (kt KeyType) AssociatedDataSupported() bool {
 	return false
 }
 
+func (kt KeyType) FormatPreservingEncryptionSupported() bool {
+	switch kt {
+	case KeyType_AES256_FF3_1:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) ImportPublicKeySupported() bool {
 	switch kt {
 	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519:
@@ -202,6 +211,8 @@ func (kt KeyType) String() string {
 		return "hmac"
 	case KeyType_MANAGED_KEY:
 		return "managed_key"
+	case KeyType_AES256_FF3_1:
+		return "aes256-ff3-1"
 	}
 
 	return "[unknown]"
@@ -466,6 +477,16 @@ type Policy struct {
 
 	// AllowImportedKeyRotation indicates whether an imported key may be rotated by Vault
 	AllowImportedKeyRotation bool
+
+	// AllowedOperations restricts the operations the key may be used for.
+	// An empty list allows every operation supported by the key type.
+	AllowedOperations []string `json:"allowed_operations,omitempty"`
+
+	// AllowedEntityIDs and AllowedGroupIDs restrict the identity entities
+	// that may use the key, either directly or through membership of one of
+	// the groups. When both are empty the key is not restricted by identity.
+	AllowedEntityIDs []string `json:"allowed_entity_ids,omitempty"`
+	AllowedGroupIDs  []string `json:"allowed_group_ids,omitempty"`
 }
 
 func (p *Policy) Lock(exclusive bool) {
@@ -1071,6 +1092,25 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 	return keyEntry.HMACKey, nil
 }
 
+// FPEKey returns the key of the given version for format-preserving
+// encryption.
+func (p *Policy) FPEKey(version int) ([]byte, error) {
+	if !p.Type.FormatPreservingEncryptionSupported() {
+		return nil, fmt.Errorf("format-preserving encryption not supported for key type %v", p.Type)
+	}
+	switch {
+	case version < 0:
+		return nil, fmt.Errorf("key version does not exist (cannot be negative)")
+	case version > p.LatestVersion:
+		return nil, fmt.Errorf("key version does not exist; latest key version is %d", p.LatestVersion)
+	}
+	keyEntry, err := p.safeGetKeyEntry(version)
+	if err != nil {
+		return nil, err
+	}
+	return keyEntry.Key, nil
+}
+
 func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
 	return p.SignWithOptions(ver, context, input, &SigningOptions{
 		HashAlgorithm: hashAlgorithm,
@@ -1618,7 +1658,7 @@ func (p *Policy) RotateInMemory(randReader io.Reader) (retErr error) {
 	entry.HMACKey = hmacKey
 
 	switch p.Type {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_HMAC:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_HMAC, KeyType_AES256_FF3_1:
 		// Default to 256 bit key
 		numBytes := 32
 		if p.Type == KeyType_AES128_GCM96 {
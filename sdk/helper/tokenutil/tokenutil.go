@@ -42,6 +42,10 @@ type TokenParams struct {
 	// The policies to set
 	TokenPolicies []string `json:"token_policies" mapstructure:"token_policies"`
 
+	// If set, batch tokens issued using this role only keep the policies
+	// in this list
+	TokenBatchPolicies []string `json:"token_batch_policies" mapstructure:"token_batch_policies"`
+
 	// The type of token this role should issue
 	TokenType logical.TokenType `json:"token_type" mapstructure:"token_type"`
 
@@ -130,10 +134,20 @@ func TokenFields() map[string]*framework.FieldSchema {
 			},
 		},
 
+		"token_batch_policies": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: tokenBatchPoliciesHelp,
+			DisplayAttrs: &framework.DisplayAttributes{
+				Name:        "Generated Batch Token's Policies",
+				Group:       "Tokens",
+				Description: "A list of policies. If set, batch tokens generated for this role only keep the policies in this list.",
+			},
+		},
+
 		"token_type": {
 			Type:        framework.TypeString,
 			Default:     "default-service",
-			Description: "The type of token to generate, service or batch",
+			Description: "The type of token to generate, service or batch. With default-service or default-batch, the type configured on the mount takes precedence.",
 			DisplayAttrs: &framework.DisplayAttributes{
 				Name:  "Generated Token's Type",
 				Group: "Tokens",
@@ -206,12 +220,23 @@ func (t *TokenParams) ParseTokenFields(req *logical.Request, d *framework.FieldD
 			tokenType = logical.TokenTypeService
 		case "batch":
 			tokenType = logical.TokenTypeBatch
+		case "default-service":
+			tokenType = logical.TokenTypeDefaultService
+		case "default-batch":
+			tokenType = logical.TokenTypeDefaultBatch
 		default:
 			return fmt.Errorf("invalid 'token_type' value %q", tokenTypeStr)
 		}
 		t.TokenType = tokenType
 	}
 
+	if batchPoliciesRaw, ok := d.GetOk("token_batch_policies"); ok {
+		t.TokenBatchPolicies = batchPoliciesRaw.([]string)
+	}
+	if len(t.TokenBatchPolicies) > 0 && t.TokenType == logical.TokenTypeService {
+		return errors.New("'token_batch_policies' cannot be set when 'token_type' is 'service'")
+	}
+
 	if tokenNumUses, ok := d.GetOk("token_num_uses"); ok {
 		t.TokenNumUses = tokenNumUses.(int)
 	}
@@ -260,6 +285,10 @@ func (t *TokenParams) PopulateTokenData(m map[string]interface{}) {
 	if len(t.TokenBoundCIDRs) == 0 {
 		m["token_bound_cidrs"] = []string{}
 	}
+
+	if len(t.TokenBatchPolicies) > 0 {
+		m["token_batch_policies"] = t.TokenBatchPolicies
+	}
 }
 
 // PopulateTokenAuth populates Auth with parameters
@@ -270,6 +299,7 @@ func (t *TokenParams) PopulateTokenAuth(auth *logical.Auth) {
 	auth.NoDefaultPolicy = t.TokenNoDefaultPolicy
 	auth.Period = t.TokenPeriod
 	auth.Policies = t.TokenPolicies
+	auth.BatchTokenPolicies = t.TokenBatchPolicies
 	auth.Renewable = true
 	auth.TokenType = t.TokenType
 	auth.TTL = t.TokenTTL
@@ -413,6 +443,12 @@ will have no max lifetime; instead, their
 renewal period will be fixed to this value.
 This takes an integer number of seconds,
 or a string duration (e.g. "24h").`
+	tokenBatchPoliciesHelp = `If set, batch tokens created via this
+role only keep the policies in this list, so
+that ephemeral workloads can be issued batch
+tokens with less access than the service
+tokens of the role. Identity policies still
+apply.`
 	tokenExplicitMaxTTLHelp = `If set, tokens created via this role
 carry an explicit maximum TTL. During renewal,
 the current maximum TTL values of the role
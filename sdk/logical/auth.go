@@ -33,6 +33,11 @@ type Auth struct {
 	// is associated with.
 	Policies []string `json:"policies" mapstructure:"policies" structs:"policies"`
 
+	// BatchTokenPolicies, if set, constrains the policies of the token to
+	// those in this list when a batch token is issued. Core applies it once
+	// the type of the token is known, as the mount can override it.
+	BatchTokenPolicies []string `json:"batch_token_policies" mapstructure:"batch_token_policies" structs:"batch_token_policies"`
+
 	// TokenPolicies and IdentityPolicies break down the list in Policies to
 	// help determine where a policy was sourced
 	TokenPolicies    []string `json:"token_policies" mapstructure:"token_policies" structs:"token_policies"`
@@ -114,6 +119,14 @@ type Auth struct {
 
 	// EntityCreated is set to true if an entity is created as part of a login request
 	EntityCreated bool `json:"entity_created"`
+
+	// EntityMetadata, GroupNames, NamespaceID and NamespacePath are
+	// informational identity details which auth mounts may be tuned to include
+	// in their login responses, so that clients don't need to look them up.
+	EntityMetadata map[string]string `json:"entity_metadata,omitempty" mapstructure:"entity_metadata" structs:"entity_metadata"`
+	GroupNames     []string          `json:"group_names,omitempty" mapstructure:"group_names" structs:"group_names"`
+	NamespaceID    string            `json:"namespace_id,omitempty" mapstructure:"namespace_id" structs:"namespace_id"`
+	NamespacePath  string            `json:"namespace_path,omitempty" mapstructure:"namespace_path" structs:"namespace_path"`
 }
 
 func (a *Auth) GoString() string {
@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+const (
+	// DeceptionTriggerHoneytoken is the type of triggers caused by the use of
+	// a decoy token.
+	DeceptionTriggerHoneytoken = "honeytoken"
+
+	// DeceptionTriggerDecoyPath is the type of triggers caused by a request
+	// to a decoy path.
+	DeceptionTriggerDecoyPath = "decoy_path"
+)
+
+// DeceptionTrigger records that a request used a decoy token or accessed a
+// decoy path. Legitimate clients never do either, so any trigger is a strong
+// indication that credentials have been compromised.
+type DeceptionTrigger struct {
+	// Type is either DeceptionTriggerHoneytoken or DeceptionTriggerDecoyPath.
+	Type string `json:"type"`
+
+	// Name is the name the decoy was registered under.
+	Name string `json:"name"`
+
+	// Severity is the severity of the trigger, currently always "high".
+	Severity string `json:"severity"`
+}
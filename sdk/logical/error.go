@@ -59,6 +59,10 @@ var (
 	// X-Vault-Index request header.
 	ErrMissingRequiredState = errors.New("required index state not present")
 
+	// ErrMountSealed is returned when a request is routed to a mount which
+	// has been sealed, isolating it from the rest of the cluster.
+	ErrMountSealed = errors.New("mount is sealed")
+
 	// Error indicating that the requested path used to serve a purpose in older
 	// versions, but the functionality has now been removed
 	ErrPathFunctionalityRemoved = errors.New("functionality on this path has been removed")
@@ -66,6 +70,11 @@ var (
 	// ErrNotFound is an error used to indicate that a particular resource was
 	// not found.
 	ErrNotFound = errors.New("not found")
+
+	// ErrRequestDeadlineExceeded is returned when a request could not be
+	// completed within the timeout requested by the client via the
+	// X-Vault-Request-Timeout header.
+	ErrRequestDeadlineExceeded = errors.New("request deadline exceeded")
 )
 
 type DelegatedAuthErrorHandler func(ctx context.Context, initiatingRequest, authRequest *Request, authResponse *Response, err error) (*Response, error)
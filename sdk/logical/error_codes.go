@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+const (
+	// ErrorCodeKey is the response data key holding the ErrorCode of an
+	// error response.
+	ErrorCodeKey = "error_code"
+
+	// ErrorDetailsKey is the response data key holding the structured
+	// details of an error response.
+	ErrorDetailsKey = "error_details"
+)
+
+// ErrorCode is a stable, machine-readable identifier for a class of error.
+// Unlike error messages, error codes are part of the API contract: clients
+// may branch on them and they must not change once published.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest           ErrorCode = "invalid_request"
+	ErrorCodePermissionDenied         ErrorCode = "permission_denied"
+	ErrorCodeNotFound                 ErrorCode = "not_found"
+	ErrorCodeUnsupportedOperation     ErrorCode = "unsupported_operation"
+	ErrorCodeUnsupportedPath          ErrorCode = "unsupported_path"
+	ErrorCodePathFunctionalityRemoved ErrorCode = "path_functionality_removed"
+	ErrorCodeInvalidWrappingToken     ErrorCode = "invalid_wrapping_token"
+	ErrorCodeRateLimitQuotaExceeded   ErrorCode = "rate_limit_quota_exceeded"
+	ErrorCodeLeaseCountQuotaExceeded  ErrorCode = "lease_count_quota_exceeded"
+	ErrorCodeUpstreamRateLimited      ErrorCode = "upstream_rate_limited"
+	ErrorCodeMissingRequiredState     ErrorCode = "missing_required_state"
+	ErrorCodeRequestTooLarge          ErrorCode = "request_too_large"
+	ErrorCodeSealed                   ErrorCode = "sealed"
+	ErrorCodeAPILocked                ErrorCode = "api_locked"
+	ErrorCodeUnavailable              ErrorCode = "unavailable"
+	ErrorCodeConflict                 ErrorCode = "conflict"
+	ErrorCodeDeadlineExceeded         ErrorCode = "deadline_exceeded"
+	ErrorCodeInternal                 ErrorCode = "internal_error"
+)
+
+var _ error = (*TypedError)(nil)
+
+// TypedError is an error annotated with an ErrorCode and optional structured
+// details. When returned from a request handler, the code and details are
+// surfaced to clients in the JSON error body alongside the error message.
+type TypedError struct {
+	Code    ErrorCode
+	Details map[string]interface{}
+	Err     error
+}
+
+// NewTypedError returns a TypedError with the given code and details wrapping
+// err.
+func NewTypedError(code ErrorCode, details map[string]interface{}, err error) *TypedError {
+	return &TypedError{
+		Code:    code,
+		Details: details,
+		Err:     err,
+	}
+}
+
+func (e *TypedError) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *TypedError) Unwrap() error {
+	return e.Err
+}
+
+// CodedErrorResponse is used to format an error response carrying an
+// ErrorCode and optional structured details. The code and details are
+// preserved across plugin boundaries since they are carried in the
+// response data.
+func CodedErrorResponse(code ErrorCode, details map[string]interface{}, text string, vargs ...interface{}) *Response {
+	resp := ErrorResponse(text, vargs...)
+	resp.Data[ErrorCodeKey] = string(code)
+	if len(details) > 0 {
+		resp.Data[ErrorDetailsKey] = details
+	}
+	return resp
+}
+
+// ErrorCodeFromError returns the ErrorCode and details for err. If err (or
+// an error it wraps) is a TypedError, its code and details are used.
+// Otherwise, well-known sentinel errors are mapped to their corresponding
+// code, falling back to a code derived from the HTTP status.
+func ErrorCodeFromError(status int, err error) (ErrorCode, map[string]interface{}) {
+	if err == nil {
+		return "", nil
+	}
+
+	var typed *TypedError
+	if errors.As(err, &typed) && typed.Code != "" {
+		return typed.Code, typed.Details
+	}
+
+	// Note that these are matched by message rather than identity as most
+	// errors have crossed a plugin or forwarding boundary by now.
+	switch {
+	case errwrap.Contains(err, consts.ErrSealed.Error()):
+		return ErrorCodeSealed, nil
+	case errwrap.Contains(err, consts.ErrAPILocked.Error()):
+		return ErrorCodeAPILocked, nil
+	case errwrap.Contains(err, ErrPermissionDenied.Error()):
+		return ErrorCodePermissionDenied, nil
+	case errwrap.Contains(err, consts.ErrInvalidWrappingToken.Error()):
+		return ErrorCodeInvalidWrappingToken, nil
+	case errwrap.Contains(err, ErrUnsupportedOperation.Error()):
+		return ErrorCodeUnsupportedOperation, nil
+	case errwrap.Contains(err, ErrUnsupportedPath.Error()):
+		return ErrorCodeUnsupportedPath, nil
+	case errwrap.Contains(err, ErrUpstreamRateLimited.Error()):
+		return ErrorCodeUpstreamRateLimited, nil
+	case errwrap.Contains(err, ErrRateLimitQuotaExceeded.Error()):
+		return ErrorCodeRateLimitQuotaExceeded, nil
+	case errwrap.Contains(err, ErrLeaseCountQuotaExceeded.Error()):
+		return ErrorCodeLeaseCountQuotaExceeded, nil
+	case errwrap.Contains(err, ErrMissingRequiredState.Error()):
+		return ErrorCodeMissingRequiredState, nil
+	case errwrap.Contains(err, ErrPathFunctionalityRemoved.Error()):
+		return ErrorCodePathFunctionalityRemoved, nil
+	case errwrap.Contains(err, ErrRequestDeadlineExceeded.Error()):
+		return ErrorCodeDeadlineExceeded, nil
+	case errwrap.Contains(err, ErrInvalidRequest.Error()):
+		return ErrorCodeInvalidRequest, nil
+	}
+
+	return errorCodeFromStatus(status), nil
+}
+
+func errorCodeFromStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusPreconditionFailed:
+		return ErrorCodeInvalidRequest
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorCodePermissionDenied
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrorCodeUnsupportedOperation
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimitQuotaExceeded
+	case http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrorCodeDeadlineExceeded
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrorCodeInternal
+	}
+	return ""
+}
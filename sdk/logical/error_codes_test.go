@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+func TestErrorCodeFromError(t *testing.T) {
+	testCases := []struct {
+		title           string
+		status          int
+		err             error
+		expectedCode    ErrorCode
+		expectedDetails map[string]interface{}
+	}{
+		{
+			title: "nil error",
+		},
+		{
+			title:        "typed error",
+			status:       http.StatusBadRequest,
+			err:          NewTypedError(ErrorCodeConflict, map[string]interface{}{"version": 3}, errors.New("check-and-set mismatch")),
+			expectedCode: ErrorCodeConflict,
+			expectedDetails: map[string]interface{}{
+				"version": 3,
+			},
+		},
+		{
+			title:        "wrapped typed error",
+			status:       http.StatusInternalServerError,
+			err:          fmt.Errorf("failed: %w", NewTypedError(ErrorCodeNotFound, nil, ErrNotFound)),
+			expectedCode: ErrorCodeNotFound,
+		},
+		{
+			title:        "sentinel error",
+			status:       http.StatusForbidden,
+			err:          ErrPermissionDenied,
+			expectedCode: ErrorCodePermissionDenied,
+		},
+		{
+			title:        "sealed",
+			status:       http.StatusServiceUnavailable,
+			err:          consts.ErrSealed,
+			expectedCode: ErrorCodeSealed,
+		},
+		{
+			title:        "request deadline exceeded",
+			status:       http.StatusGatewayTimeout,
+			err:          ErrRequestDeadlineExceeded,
+			expectedCode: ErrorCodeDeadlineExceeded,
+		},
+		{
+			title:        "status fallback",
+			status:       http.StatusInternalServerError,
+			err:          errors.New("something broke"),
+			expectedCode: ErrorCodeInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			code, details := ErrorCodeFromError(tc.status, tc.err)
+			if code != tc.expectedCode {
+				t.Fatalf("expected code %q, got %q", tc.expectedCode, code)
+			}
+			if !reflect.DeepEqual(details, tc.expectedDetails) {
+				t.Fatalf("expected details %#v, got %#v", tc.expectedDetails, details)
+			}
+		})
+	}
+}
+
+func TestCodedErrorResponse(t *testing.T) {
+	resp := CodedErrorResponse(ErrorCodeInvalidRequest, map[string]interface{}{"field": "ttl"}, "invalid %s", "ttl")
+	if !resp.IsError() {
+		t.Fatalf("expected an error response: %#v", resp)
+	}
+
+	status, err := RespondErrorCommon(&Request{Operation: UpdateOperation}, resp, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, status)
+	}
+
+	w := httptest.NewRecorder()
+	RespondError(w, status, err)
+
+	var body struct {
+		Errors       []string               `json:"errors"`
+		ErrorCode    string                 `json:"error_code"`
+		ErrorDetails map[string]interface{} `json:"error_details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Errors) != 1 || body.Errors[0] != "invalid ttl" {
+		t.Fatalf("bad errors: %#v", body.Errors)
+	}
+	if body.ErrorCode != string(ErrorCodeInvalidRequest) {
+		t.Fatalf("bad error code: %q", body.ErrorCode)
+	}
+	if body.ErrorDetails["field"] != "ttl" {
+		t.Fatalf("bad error details: %#v", body.ErrorDetails)
+	}
+}
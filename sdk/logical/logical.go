@@ -174,6 +174,11 @@ type Paths struct {
 	//
 	// For more details, consult limits/registry.go.
 	Limited []string
+
+	// PaginatedList paths are those whose LIST responses are sorted and
+	// paginated by the router. The "after" and "limit" request parameters
+	// are consumed by the router and are not passed to the backend.
+	PaginatedList []string
 }
 
 type Auditor interface {
@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+// LoginEnrichment holds network intelligence gathered about the origin of a
+// login attempt, such as its autonomous system, geolocation and risk score,
+// along with the decision taken as a result.
+type LoginEnrichment struct {
+	// ASN is the autonomous system number the remote address belongs to.
+	ASN int `json:"asn,omitempty"`
+
+	// ASNOrganization is the name of the organization owning ASN.
+	ASNOrganization string `json:"asn_organization,omitempty"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the country the remote
+	// address is located in.
+	Country string `json:"country,omitempty"`
+
+	// RiskScore is a provider specific score of how risky the remote address
+	// is considered to be, where higher is riskier.
+	RiskScore float64 `json:"risk_score,omitempty"`
+
+	// Tags are arbitrary labels attached by the provider, e.g. "tor" or
+	// "hosting".
+	Tags []string `json:"tags,omitempty"`
+
+	// Blocked is true if the login attempt was rejected.
+	Blocked bool `json:"blocked"`
+
+	// Reason explains why the login attempt was blocked, or why enrichment
+	// could not be performed.
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+// PathRewrite records that the path of a request was rewritten by an
+// operator-configured rewrite rule before it was routed.
+type PathRewrite struct {
+	// Rule is the name of the rewrite rule which matched the request.
+	Rule string `json:"rule"`
+
+	// OriginalPath is the path of the request as sent by the client,
+	// relative to the namespace of the request.
+	OriginalPath string `json:"original_path"`
+}
@@ -174,6 +174,27 @@ type Request struct {
 	// mountClass is used internally to propagate the mount class of the mounted plugin to audit logging
 	mountClass string
 
+	// strictFieldValidation is set from the tuning of the mount, for the
+	// framework to reject unrecognized and invalid request fields instead of
+	// ignoring them
+	strictFieldValidation bool
+
+	// compatibilityLevel is set from the tuning of the mount, for the
+	// framework and backends to shape responses as they were at that
+	// compatibility level
+	compatibilityLevel string
+
+	// deprecatedEndpoint is set by the framework to the pattern of the path
+	// which handled the request, when the path or its operation is deprecated,
+	// for the usage of deprecated endpoints to be tracked
+	deprecatedEndpoint string
+
+	// deprecationReplacement and deprecationSunset are set by the framework
+	// along with deprecatedEndpoint, from what the path declares replaces it
+	// and when it is planned to be removed
+	deprecationReplacement string
+	deprecationSunset      time.Time
+
 	// WrapInfo contains requested response wrapping parameters
 	WrapInfo *RequestWrapInfo `json:"wrap_info" structs:"wrap_info" mapstructure:"wrap_info" sentinel:""`
 
@@ -258,6 +279,20 @@ type Request struct {
 
 	// RequestLimiterDisabled tells whether the request context has Request Limiter applied.
 	RequestLimiterDisabled bool `json:"request_limiter_disabled,omitempty"`
+
+	// LoginEnrichment holds the network intelligence gathered about a login
+	// attempt and whether it was blocked as a result. It is only set on login
+	// requests when login enrichment is enabled, and is recorded in audit
+	// entries.
+	LoginEnrichment *LoginEnrichment `json:"login_enrichment,omitempty" sentinel:""`
+
+	// DeceptionTrigger is set if the request used a honeytoken or accessed a
+	// decoy path, so that audit entries record the trigger.
+	DeceptionTrigger *DeceptionTrigger `json:"deception_trigger,omitempty" sentinel:""`
+
+	// PathRewrite is set if Path was rewritten by a path rewrite rule, so
+	// that audit entries record the path the client requested.
+	PathRewrite *PathRewrite `json:"path_rewrite,omitempty" sentinel:""`
 }
 
 // Clone returns a deep copy (almost) of the request.
@@ -279,6 +314,10 @@ func (r *Request) Clone() (*Request, error) {
 	req.mountRunningVersion = r.MountRunningVersion()
 	req.mountRunningSha256 = r.MountRunningSha256()
 	req.mountIsExternalPlugin = r.MountIsExternalPlugin()
+	req.deprecatedEndpoint = r.DeprecatedEndpoint()
+	req.deprecationReplacement, req.deprecationSunset = r.DeprecationHints()
+	req.strictFieldValidation = r.StrictFieldValidation()
+	req.compatibilityLevel = r.CompatibilityLevel()
 	// This needs to be overwritten as the internal connection state is not cloned properly
 	// mainly the big.Int serial numbers within the x509.Certificate objects get mangled.
 	req.Connection = r.Connection
@@ -363,6 +402,39 @@ func (r *Request) SetMountClass(mountClass string) {
 	r.mountClass = mountClass
 }
 
+func (r *Request) StrictFieldValidation() bool {
+	return r.strictFieldValidation
+}
+
+func (r *Request) SetStrictFieldValidation(strict bool) {
+	r.strictFieldValidation = strict
+}
+
+func (r *Request) CompatibilityLevel() string {
+	return r.compatibilityLevel
+}
+
+func (r *Request) SetCompatibilityLevel(level string) {
+	r.compatibilityLevel = level
+}
+
+func (r *Request) DeprecatedEndpoint() string {
+	return r.deprecatedEndpoint
+}
+
+func (r *Request) SetDeprecatedEndpoint(pattern string) {
+	r.deprecatedEndpoint = pattern
+}
+
+func (r *Request) DeprecationHints() (replacement string, sunset time.Time) {
+	return r.deprecationReplacement, r.deprecationSunset
+}
+
+func (r *Request) SetDeprecationHints(replacement string, sunset time.Time) {
+	r.deprecationReplacement = replacement
+	r.deprecationSunset = sunset
+}
+
 func (r *Request) LastRemoteWAL() uint64 {
 	return r.lastRemoteWAL
 }
@@ -547,6 +619,36 @@ func CreateContextOriginalBody(parent context.Context, body io.ReadCloser) conte
 	return context.WithValue(parent, ctxKeyOriginalBody{}, body)
 }
 
+type ctxKeyRequestDeadline struct{}
+
+// ContextRequestDeadlineValue returns the deadline requested by the client
+// for the request, if any.
+func ContextRequestDeadlineValue(ctx context.Context) (time.Time, bool) {
+	value, ok := ctx.Value(ctxKeyRequestDeadline{}).(time.Time)
+	return value, ok
+}
+
+// CreateContextRequestDeadline returns a context recording the deadline
+// requested by the client. The returned context is not itself bounded by the
+// deadline; callers are expected to derive one with context.WithDeadline
+// wherever the request is handed off to a new context.
+func CreateContextRequestDeadline(parent context.Context, deadline time.Time) context.Context {
+	return context.WithValue(parent, ctxKeyRequestDeadline{}, deadline)
+}
+
+// RequestDeadlineExceeded returns true if ctx carries a client requested
+// deadline, that deadline has passed, and ctx has been canceled as a result.
+// The cancellation itself may have propagated from a parent context, so the
+// deadline is checked directly rather than relying on ctx.Err() being
+// context.DeadlineExceeded.
+func RequestDeadlineExceeded(ctx context.Context) bool {
+	deadline, ok := ContextRequestDeadlineValue(ctx)
+	if !ok || ctx.Err() == nil {
+		return false
+	}
+	return !time.Now().Before(deadline)
+}
+
 type CtxKeyDisableRequestLimiter struct{}
 
 func (c CtxKeyDisableRequestLimiter) String() string {
@@ -51,6 +51,11 @@ const (
 	// If set, HTTPWWWAuthenticateHeader will set the WWW-Authenticate response header.
 	// The value must be a string.
 	HTTPWWWAuthenticateHeader = "http_www_authenticate"
+
+	// If set, HTTPETagHeader will set the ETag response header, and a request
+	// with a matching If-None-Match header gets a 304 response without the
+	// body. The value must be a string.
+	HTTPETagHeader = "http_raw_etag"
 )
 
 // Response is a struct that stores the response of a request.
@@ -101,8 +106,23 @@ func (r *Response) AddWarning(warning string) {
 
 // IsError returns true if this response seems to indicate an error.
 func (r *Response) IsError() bool {
-	// If the response data contains only an 'error' element, or an 'error' and a 'data' element only
-	return r != nil && r.Data != nil && r.Data["error"] != nil && (len(r.Data) == 1 || (r.Data["data"] != nil && len(r.Data) == 2))
+	// If the response data contains only an 'error' element, or an 'error' and a 'data' element only,
+	// optionally accompanied by an error code and its details
+	if r == nil || r.Data == nil || r.Data["error"] == nil {
+		return false
+	}
+	for k, v := range r.Data {
+		switch k {
+		case "error", ErrorCodeKey, ErrorDetailsKey:
+		case "data":
+			if v == nil {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 func (r *Response) Error() error {
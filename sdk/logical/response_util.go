@@ -134,17 +134,25 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 			statusCode = http.StatusPreconditionFailed
 		case errwrap.Contains(err, ErrPathFunctionalityRemoved.Error()):
 			statusCode = http.StatusNotFound
+		case errwrap.Contains(err, ErrMountSealed.Error()):
+			statusCode = http.StatusServiceUnavailable
 		case errwrap.Contains(err, ErrRelativePath.Error()):
 			statusCode = http.StatusBadRequest
 		case errwrap.Contains(err, ErrInvalidCredentials.Error()):
 			statusCode = http.StatusBadRequest
 		case errors.Is(err, ErrNotFound):
 			statusCode = http.StatusNotFound
+		case errwrap.Contains(err, ErrRequestDeadlineExceeded.Error()):
+			statusCode = http.StatusGatewayTimeout
 		}
 	}
 
 	if resp != nil && resp.IsError() {
 		err = fmt.Errorf("%s", resp.Data["error"].(string))
+		if code, ok := resp.Data[ErrorCodeKey].(string); ok && code != "" {
+			details, _ := resp.Data[ErrorDetailsKey].(map[string]interface{})
+			err = NewTypedError(ErrorCode(code), details, err)
+		}
 	}
 
 	return statusCode, err
@@ -188,11 +196,14 @@ func RespondError(w http.ResponseWriter, status int, err error) {
 	w.WriteHeader(status)
 
 	type ErrorResponse struct {
-		Errors []string `json:"errors"`
+		Errors       []string               `json:"errors"`
+		ErrorCode    ErrorCode              `json:"error_code,omitempty"`
+		ErrorDetails map[string]interface{} `json:"error_details,omitempty"`
 	}
 	resp := &ErrorResponse{Errors: make([]string, 0, 1)}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
+		resp.ErrorCode, resp.ErrorDetails = ErrorCodeFromError(status, err)
 	}
 
 	enc := json.NewEncoder(w)
@@ -206,12 +217,15 @@ func RespondErrorAndData(w http.ResponseWriter, status int, data interface{}, er
 	w.WriteHeader(status)
 
 	type ErrorAndDataResponse struct {
-		Errors []string    `json:"errors"`
-		Data   interface{} `json:"data"`
+		Errors       []string               `json:"errors"`
+		ErrorCode    ErrorCode              `json:"error_code,omitempty"`
+		ErrorDetails map[string]interface{} `json:"error_details,omitempty"`
+		Data         interface{}            `json:"data"`
 	}
 	resp := &ErrorAndDataResponse{Errors: make([]string, 0, 1)}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
+		resp.ErrorCode, resp.ErrorDetails = ErrorCodeFromError(status, err)
 	}
 	resp.Data = data
 
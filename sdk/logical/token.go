@@ -142,6 +142,13 @@ type TokenEntry struct {
 	// Explicit maximum TTL on the token
 	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl" mapstructure:"explicit_max_ttl" structs:"explicit_max_ttl" sentinel:""`
 
+	// If ChildTokenDepthLimited is set, the token may only create child tokens
+	// down to ChildTokenDepth levels below it; a depth of zero means that it
+	// cannot create child tokens at all. The restriction is fixed when the
+	// token is created, from those of its parent, role and auth mount.
+	ChildTokenDepthLimited bool `json:"child_token_depth_limited" mapstructure:"child_token_depth_limited" structs:"child_token_depth_limited"`
+	ChildTokenDepth        int  `json:"child_token_depth" mapstructure:"child_token_depth" structs:"child_token_depth"`
+
 	// If set, the role that was used for parameters at creation time
 	Role string `json:"role" mapstructure:"role" structs:"role"`
 
@@ -45,6 +45,10 @@ func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 			Orphan:           input.Auth.Orphan,
 			MFARequirement:   input.Auth.MFARequirement,
 			NumUses:          input.Auth.NumUses,
+			EntityMetadata:   input.Auth.EntityMetadata,
+			GroupNames:       input.Auth.GroupNames,
+			NamespaceID:      input.Auth.NamespaceID,
+			NamespacePath:    input.Auth.NamespacePath,
 		}
 	}
 
@@ -77,6 +81,10 @@ func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 			Metadata:         input.Auth.Metadata,
 			EntityID:         input.Auth.EntityID,
 			Orphan:           input.Auth.Orphan,
+			EntityMetadata:   input.Auth.EntityMetadata,
+			GroupNames:       input.Auth.GroupNames,
+			NamespaceID:      input.Auth.NamespaceID,
+			NamespacePath:    input.Auth.NamespacePath,
 		}
 		logicalResp.Auth.Renewable = input.Auth.Renewable
 		logicalResp.Auth.TTL = time.Second * time.Duration(input.Auth.LeaseDuration)
@@ -118,6 +126,10 @@ type HTTPAuth struct {
 	Orphan           bool              `json:"orphan"`
 	MFARequirement   *MFARequirement   `json:"mfa_requirement"`
 	NumUses          int               `json:"num_uses"`
+	EntityMetadata   map[string]string `json:"entity_metadata,omitempty"`
+	GroupNames       []string          `json:"group_names,omitempty"`
+	NamespaceID      string            `json:"namespace_id,omitempty"`
+	NamespacePath    string            `json:"namespace_path,omitempty"`
 }
 
 type HTTPWrapInfo struct {
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package physical
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+// Coalescing is used to wrap an underlying physical backend and coalesce
+// identical concurrent reads into a single read of the backend, whose result
+// is shared by all the readers. This cuts the load on the backend when many
+// requests read the same hot keys at once, such as the mount tables and the
+// policies while a fleet of clients reconnects, before the cache is warm.
+//
+// A read which starts after a write to the same key has returned never shares
+// the result of a read which started before it, so coalescing does not make
+// reads any more stale than concurrent reads already are.
+type Coalescing struct {
+	backend    Backend
+	metricSink metrics.MetricSink
+
+	l     sync.Mutex
+	reads map[string]*coalescedRead
+}
+
+// TransactionalCoalescing is a Coalescing that wraps the physical that is
+// transactional
+type TransactionalCoalescing struct {
+	*Coalescing
+	Transactional
+}
+
+// coalescedRead is a read of the backend in flight. doneCh is closed once
+// the entry and the error are set.
+type coalescedRead struct {
+	doneCh chan struct{}
+	entry  *Entry
+	err    error
+}
+
+// Verify Coalescing satisfies the correct interfaces
+var (
+	_ Backend             = (*Coalescing)(nil)
+	_ Transactional       = (*TransactionalCoalescing)(nil)
+	_ TransactionalLimits = (*TransactionalCoalescing)(nil)
+)
+
+// NewCoalescing returns a physical backend coalescing the concurrent reads
+// of the given backend. It is transactional if the given backend is.
+func NewCoalescing(b Backend, metricSink metrics.MetricSink) Backend {
+	if metricSink == nil {
+		metricSink = &metrics.BlackholeSink{}
+	}
+	c := &Coalescing{
+		backend:    b,
+		metricSink: metricSink,
+		reads:      make(map[string]*coalescedRead),
+	}
+
+	if txn, ok := b.(Transactional); ok {
+		return &TransactionalCoalescing{
+			Coalescing:    c,
+			Transactional: txn,
+		}
+	}
+	return c
+}
+
+func (c *Coalescing) Get(ctx context.Context, key string) (*Entry, error) {
+	c.l.Lock()
+	read, ok := c.reads[key]
+	if !ok {
+		read = &coalescedRead{doneCh: make(chan struct{})}
+		c.reads[key] = read
+	}
+	c.l.Unlock()
+
+	if !ok {
+		c.metricSink.IncrCounter([]string{"storage", "coalesce", "miss"}, 1)
+		read.entry, read.err = c.backend.Get(ctx, key)
+		c.forget(key, read)
+		close(read.doneCh)
+		return read.entry, read.err
+	}
+
+	c.metricSink.IncrCounter([]string{"storage", "coalesce", "hit"}, 1)
+	select {
+	case <-read.doneCh:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// The read may have failed because the context of the reader which made
+	// it was canceled, in which case this reader reads the key on its own
+	if read.err != nil && (errors.Is(read.err, context.Canceled) || errors.Is(read.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+		return c.backend.Get(ctx, key)
+	}
+	return read.entry, read.err
+}
+
+func (c *Coalescing) Put(ctx context.Context, entry *Entry) error {
+	err := c.backend.Put(ctx, entry)
+	c.forget(entry.Key, nil)
+	return err
+}
+
+func (c *Coalescing) Delete(ctx context.Context, key string) error {
+	err := c.backend.Delete(ctx, key)
+	c.forget(key, nil)
+	return err
+}
+
+func (c *Coalescing) List(ctx context.Context, prefix string) ([]string, error) {
+	return c.backend.List(ctx, prefix)
+}
+
+// forget stops later reads of the key from sharing the read in flight, if
+// any. If read is set, the read in flight is only forgotten if it is that
+// one.
+func (c *Coalescing) forget(key string, read *coalescedRead) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if current, ok := c.reads[key]; ok && (read == nil || current == read) {
+		delete(c.reads, key)
+	}
+}
+
+func (c *TransactionalCoalescing) Transaction(ctx context.Context, txns []*TxnEntry) error {
+	err := c.Transactional.Transaction(ctx, txns)
+	for _, txn := range txns {
+		if txn.Operation != GetOperation {
+			c.forget(txn.Entry.Key, nil)
+		}
+	}
+	return err
+}
+
+// TransactionLimits implements physical.TransactionalLimits
+func (c *TransactionalCoalescing) TransactionLimits() (int, int) {
+	if tl, ok := c.Transactional.(TransactionalLimits); ok {
+		return tl.TransactionLimits()
+	}
+	return 0, 0
+}
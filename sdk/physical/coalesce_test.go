@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package physical
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingBackend is a backend whose reads block until released, and which
+// counts them.
+type blockingBackend struct {
+	l       sync.Mutex
+	entries map[string][]byte
+
+	gets    int32
+	startCh chan struct{}
+	release chan struct{}
+}
+
+func newBlockingBackend() *blockingBackend {
+	return &blockingBackend{
+		entries: make(map[string][]byte),
+		startCh: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (b *blockingBackend) Get(ctx context.Context, key string) (*Entry, error) {
+	atomic.AddInt32(&b.gets, 1)
+	b.l.Lock()
+	value, ok := b.entries[key]
+	b.l.Unlock()
+
+	b.startCh <- struct{}{}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	return &Entry{Key: key, Value: value}, nil
+}
+
+func (b *blockingBackend) Put(_ context.Context, entry *Entry) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.entries[entry.Key] = entry.Value
+	return nil
+}
+
+func (b *blockingBackend) Delete(_ context.Context, key string) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	delete(b.entries, key)
+	return nil
+}
+
+func (b *blockingBackend) List(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func TestCoalescing_Get(t *testing.T) {
+	be := newBlockingBackend()
+	require.NoError(t, be.Put(context.Background(), &Entry{Key: "foo", Value: []byte("bar")}))
+	c := NewCoalescing(be, nil)
+
+	var wg sync.WaitGroup
+	results := make(chan *Entry, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := c.Get(context.Background(), "foo")
+			require.NoError(t, err)
+			results <- entry
+		}()
+	}
+
+	// Let the readers pile up behind the first one
+	<-be.startCh
+	time.Sleep(50 * time.Millisecond)
+	close(be.release)
+	wg.Wait()
+	close(results)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&be.gets))
+	for entry := range results {
+		require.Equal(t, []byte("bar"), entry.Value)
+	}
+}
+
+func TestCoalescing_ReadAfterWrite(t *testing.T) {
+	be := newBlockingBackend()
+	require.NoError(t, be.Put(context.Background(), &Entry{Key: "foo", Value: []byte("old")}))
+	c := NewCoalescing(be, nil)
+
+	oldCh := make(chan *Entry, 1)
+	go func() {
+		entry, err := c.Get(context.Background(), "foo")
+		require.NoError(t, err)
+		oldCh <- entry
+	}()
+	<-be.startCh
+
+	// A read starting after the write returned must not share the read in
+	// flight, which may have read the old value
+	require.NoError(t, c.Put(context.Background(), &Entry{Key: "foo", Value: []byte("new")}))
+
+	newCh := make(chan *Entry, 1)
+	go func() {
+		entry, err := c.Get(context.Background(), "foo")
+		require.NoError(t, err)
+		newCh <- entry
+	}()
+	<-be.startCh
+
+	close(be.release)
+	require.Equal(t, []byte("old"), (<-oldCh).Value)
+	require.Equal(t, []byte("new"), (<-newCh).Value)
+	require.Equal(t, int32(2), atomic.LoadInt32(&be.gets))
+}
+
+func TestCoalescing_CanceledReader(t *testing.T) {
+	be := newBlockingBackend()
+	require.NoError(t, be.Put(context.Background(), &Entry{Key: "foo", Value: []byte("bar")}))
+	c := NewCoalescing(be, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstCh := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "foo")
+		firstCh <- err
+	}()
+	<-be.startCh
+
+	secondCh := make(chan *Entry, 1)
+	go func() {
+		entry, err := c.Get(context.Background(), "foo")
+		require.NoError(t, err)
+		secondCh <- entry
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The second reader reads the key on its own once the read it shared
+	// fails because the first reader went away
+	cancel()
+	require.ErrorIs(t, <-firstCh, context.Canceled)
+	<-be.startCh
+	close(be.release)
+	require.Equal(t, []byte("bar"), (<-secondCh).Value)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+// NewBackend runs the storage plugin configured by the plugin_command,
+// plugin_args and plugin_sha256 parameters, and sets up its physical backend
+// with the rest of the parameters. Storage plugins cannot be registered in
+// the plugin catalog, as the catalog is kept in storage.
+func NewBackend(conf map[string]string, logger log.Logger) (physical.Backend, error) {
+	command := conf["plugin_command"]
+	if command == "" {
+		return nil, errors.New("missing plugin_command")
+	}
+
+	rawSHA256 := conf["plugin_sha256"]
+	if rawSHA256 == "" {
+		return nil, errors.New("missing plugin_sha256")
+	}
+	sha256Sum, err := hex.DecodeString(rawSHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode plugin_sha256: %w", err)
+	}
+
+	backendConf := make(map[string]string)
+	for k, v := range conf {
+		if !strings.HasPrefix(k, "plugin_") {
+			backendConf[k] = v
+		}
+	}
+
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: HandshakeConfig,
+		VersionedPlugins: map[int]plugin.PluginSet{
+			1: {
+				pluginName: &GRPCStoragePlugin{},
+			},
+		},
+		Cmd: exec.Command(command, strings.Fields(conf["plugin_args"])...),
+		SecureConfig: &plugin.SecureConfig{
+			Checksum: sha256Sum,
+			Hash:     sha256.New(),
+		},
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		Logger:           logger.Named("plugin"),
+		AutoMTLS:         true,
+		Managed:          true,
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to start storage plugin: %w", err)
+	}
+
+	raw, err := rpcClient.Dispense(pluginName)
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to dispense storage plugin: %w", err)
+	}
+
+	backend, err := raw.(*gRPCClient).setup(context.Background(), backendConf)
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to set up storage plugin: %w", err)
+	}
+	return backend, nil
+}
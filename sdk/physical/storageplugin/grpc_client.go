@@ -0,0 +1,227 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/storageplugin/proto"
+)
+
+var (
+	_ physical.Backend             = &gRPCClient{}
+	_ physical.HABackend           = &gRPCClient{}
+	_ physical.TransactionalLimits = &transactionalGRPCClient{}
+	_ physical.Lock                = &gRPCLock{}
+)
+
+// gRPCClient is the physical backend of a storage plugin, used by Vault.
+type gRPCClient struct {
+	client    proto.StorageClient
+	haEnabled bool
+}
+
+// transactionalGRPCClient is the physical backend of a storage plugin which
+// supports transactions.
+type transactionalGRPCClient struct {
+	*gRPCClient
+
+	maxEntries int
+	maxSize    int
+}
+
+// setup sets up the physical backend of the storage plugin with the given
+// configuration, and returns it.
+func (c *gRPCClient) setup(ctx context.Context, conf map[string]string) (physical.Backend, error) {
+	resp, err := c.client.Setup(ctx, &proto.SetupRequest{Config: conf})
+	if err != nil {
+		return nil, err
+	}
+
+	c.haEnabled = resp.GetHaEnabled()
+	if !resp.GetTransactional() {
+		return c, nil
+	}
+	return &transactionalGRPCClient{
+		gRPCClient: c,
+		maxEntries: int(resp.GetMaxTxnEntries()),
+		maxSize:    int(resp.GetMaxTxnSize()),
+	}, nil
+}
+
+func (c *gRPCClient) Put(ctx context.Context, entry *physical.Entry) error {
+	_, err := c.client.Put(ctx, &proto.PutRequest{
+		Entry: &proto.Entry{
+			Key:   entry.Key,
+			Value: entry.Value,
+		},
+	})
+	return err
+}
+
+func (c *gRPCClient) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	resp, err := c.client.Get(ctx, &proto.GetRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetEntry() == nil {
+		return nil, nil
+	}
+	return &physical.Entry{
+		Key:   resp.GetEntry().GetKey(),
+		Value: resp.GetEntry().GetValue(),
+	}, nil
+}
+
+func (c *gRPCClient) Delete(ctx context.Context, key string) error {
+	_, err := c.client.Delete(ctx, &proto.DeleteRequest{Key: key})
+	return err
+}
+
+func (c *gRPCClient) List(ctx context.Context, prefix string) ([]string, error) {
+	resp, err := c.client.List(ctx, &proto.ListRequest{Prefix: prefix})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetKeys(), nil
+}
+
+func (c *gRPCClient) HAEnabled() bool {
+	return c.haEnabled
+}
+
+func (c *gRPCClient) LockWith(key, value string) (physical.Lock, error) {
+	return &gRPCLock{
+		client: c.client,
+		key:    key,
+		value:  value,
+	}, nil
+}
+
+func (c *transactionalGRPCClient) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	req := &proto.TransactionRequest{Txns: make([]*proto.TxnEntry, 0, len(txns))}
+	for _, txn := range txns {
+		req.Txns = append(req.Txns, &proto.TxnEntry{
+			Operation: string(txn.Operation),
+			Entry: &proto.Entry{
+				Key:   txn.Entry.Key,
+				Value: txn.Entry.Value,
+			},
+		})
+	}
+
+	resp, err := c.client.Transaction(ctx, req)
+	if err != nil {
+		return err
+	}
+	if len(resp.GetTxns()) != len(txns) {
+		return errors.New("storage plugin returned an unexpected number of transaction entries")
+	}
+
+	// Populate the values read by the get operations
+	for i, txn := range txns {
+		if txn.Operation == physical.GetOperation {
+			txn.Entry.Value = resp.GetTxns()[i].GetEntry().GetValue()
+		}
+	}
+	return nil
+}
+
+func (c *transactionalGRPCClient) TransactionLimits() (int, int) {
+	return c.maxEntries, c.maxSize
+}
+
+// gRPCLock is a lock of the physical backend of a storage plugin. It is held
+// for as long as the lock stream of the plugin is open.
+type gRPCLock struct {
+	client proto.StorageClient
+	key    string
+	value  string
+
+	l      sync.Mutex
+	lockID string
+	cancel context.CancelFunc
+}
+
+func (l *gRPCLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if l.lockID != "" {
+		return nil, errors.New("lock already held")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := l.client.Lock(ctx, &proto.LockRequest{Key: l.key, Value: l.value})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	type lockResult struct {
+		event *proto.LockEvent
+		err   error
+	}
+	acquiredCh := make(chan lockResult, 1)
+	go func() {
+		event, err := stream.Recv()
+		acquiredCh <- lockResult{event: event, err: err}
+	}()
+
+	var acquired lockResult
+	select {
+	case <-stopCh:
+		// Canceling the stream makes the plugin give up on the lock, or
+		// release it if it was just acquired
+		cancel()
+		return nil, nil
+	case acquired = <-acquiredCh:
+	}
+	if acquired.err != nil {
+		cancel()
+		return nil, acquired.err
+	}
+
+	l.lockID = acquired.event.GetLockId()
+	l.cancel = cancel
+
+	leaderLostCh := make(chan struct{})
+	go func() {
+		defer close(leaderLostCh)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+	return leaderLostCh, nil
+}
+
+func (l *gRPCLock) Unlock() error {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if l.lockID == "" {
+		return nil
+	}
+	defer func() {
+		l.cancel()
+		l.lockID = ""
+		l.cancel = nil
+	}()
+
+	_, err := l.client.Unlock(context.Background(), &proto.UnlockRequest{LockId: l.lockID})
+	return err
+}
+
+func (l *gRPCLock) Value() (bool, string, error) {
+	resp, err := l.client.LockValue(context.Background(), &proto.LockValueRequest{Key: l.key})
+	if err != nil {
+		return false, "", err
+	}
+	return resp.GetHeld(), resp.GetValue(), nil
+}
@@ -0,0 +1,315 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/storageplugin/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ proto.StorageServer = &gRPCServer{}
+
+// gRPCServer serves the physical backend of a storage plugin.
+type gRPCServer struct {
+	proto.UnimplementedStorageServer
+
+	factory physical.Factory
+	logger  log.Logger
+
+	l       sync.RWMutex
+	backend physical.Backend
+
+	// locks are the locks held by Vault, keyed by lock ID
+	locksLock sync.Mutex
+	locks     map[string]*heldLock
+}
+
+// heldLock is a lock held by Vault. releasedCh is closed once Vault releases
+// it.
+type heldLock struct {
+	lock       physical.Lock
+	releasedCh chan struct{}
+}
+
+func newGRPCServer(factory physical.Factory, logger log.Logger) *gRPCServer {
+	if logger == nil {
+		logger = log.NewNullLogger()
+	}
+	return &gRPCServer{
+		factory: factory,
+		logger:  logger,
+		locks:   make(map[string]*heldLock),
+	}
+}
+
+func (s *gRPCServer) getBackend() (physical.Backend, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if s.backend == nil {
+		return nil, status.Error(codes.FailedPrecondition, "storage backend is not set up")
+	}
+	return s.backend, nil
+}
+
+func (s *gRPCServer) getHABackend() (physical.HABackend, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	ha, ok := backend.(physical.HABackend)
+	if !ok || !ha.HAEnabled() {
+		return nil, status.Error(codes.Unimplemented, "storage backend does not support high availability")
+	}
+	return ha, nil
+}
+
+func (s *gRPCServer) Setup(_ context.Context, req *proto.SetupRequest) (*proto.SetupResponse, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.factory == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no storage backend factory")
+	}
+	if s.backend != nil {
+		return nil, status.Error(codes.FailedPrecondition, "storage backend is already set up")
+	}
+
+	backend, err := s.factory(req.GetConfig(), s.logger)
+	if err != nil {
+		return nil, err
+	}
+	s.backend = backend
+
+	resp := &proto.SetupResponse{}
+	if ha, ok := backend.(physical.HABackend); ok {
+		resp.HaEnabled = ha.HAEnabled()
+	}
+	if _, ok := backend.(physical.Transactional); ok {
+		resp.Transactional = true
+	}
+	if limits, ok := backend.(physical.TransactionalLimits); ok {
+		maxEntries, maxSize := limits.TransactionLimits()
+		resp.MaxTxnEntries = int64(maxEntries)
+		resp.MaxTxnSize = int64(maxSize)
+	}
+	return resp, nil
+}
+
+func (s *gRPCServer) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := backend.Get(ctx, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return &proto.GetResponse{}, nil
+	}
+	return &proto.GetResponse{
+		Entry: &proto.Entry{
+			Key:   entry.Key,
+			Value: entry.Value,
+		},
+	}, nil
+}
+
+func (s *gRPCServer) Put(ctx context.Context, req *proto.PutRequest) (*proto.Empty, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := req.GetEntry()
+	if entry == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing entry")
+	}
+	if err := backend.Put(ctx, &physical.Entry{Key: entry.GetKey(), Value: entry.GetValue()}); err != nil {
+		return nil, err
+	}
+	return &proto.Empty{}, nil
+}
+
+func (s *gRPCServer) Delete(ctx context.Context, req *proto.DeleteRequest) (*proto.Empty, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := backend.Delete(ctx, req.GetKey()); err != nil {
+		return nil, err
+	}
+	return &proto.Empty{}, nil
+}
+
+func (s *gRPCServer) List(ctx context.Context, req *proto.ListRequest) (*proto.ListResponse, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := backend.List(ctx, req.GetPrefix())
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ListResponse{Keys: keys}, nil
+}
+
+func (s *gRPCServer) Transaction(ctx context.Context, req *proto.TransactionRequest) (*proto.TransactionResponse, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	transactional, ok := backend.(physical.Transactional)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage backend does not support transactions")
+	}
+
+	txns := make([]*physical.TxnEntry, 0, len(req.GetTxns()))
+	for _, txn := range req.GetTxns() {
+		entry := txn.GetEntry()
+		if entry == nil {
+			return nil, status.Error(codes.InvalidArgument, "missing transaction entry")
+		}
+		txns = append(txns, &physical.TxnEntry{
+			Operation: physical.Operation(txn.GetOperation()),
+			Entry:     &physical.Entry{Key: entry.GetKey(), Value: entry.GetValue()},
+		})
+	}
+
+	if err := transactional.Transaction(ctx, txns); err != nil {
+		return nil, err
+	}
+
+	// Return the operations with the values read by the get operations
+	resp := &proto.TransactionResponse{Txns: make([]*proto.TxnEntry, 0, len(txns))}
+	for _, txn := range txns {
+		resp.Txns = append(resp.Txns, &proto.TxnEntry{
+			Operation: string(txn.Operation),
+			Entry: &proto.Entry{
+				Key:   txn.Entry.Key,
+				Value: txn.Entry.Value,
+			},
+		})
+	}
+	return resp, nil
+}
+
+// Lock acquires the lock and sends its ID once it is held. The stream ends
+// once the lock is lost or released. If Vault goes away without releasing the
+// lock, it is released on its behalf.
+func (s *gRPCServer) Lock(req *proto.LockRequest, stream proto.Storage_LockServer) error {
+	ha, err := s.getHABackend()
+	if err != nil {
+		return err
+	}
+
+	lock, err := ha.LockWith(req.GetKey(), req.GetValue())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	leaderLostCh, err := lock.Lock(ctx.Done())
+	if err != nil {
+		return err
+	}
+	if leaderLostCh == nil {
+		// The lock acquisition was interrupted
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	lockID, err := uuid.GenerateUUID()
+	if err != nil {
+		s.unlock(lock)
+		return err
+	}
+	held := &heldLock{
+		lock:       lock,
+		releasedCh: make(chan struct{}),
+	}
+
+	s.locksLock.Lock()
+	s.locks[lockID] = held
+	s.locksLock.Unlock()
+
+	if err := stream.Send(&proto.LockEvent{LockId: lockID}); err != nil {
+		if s.removeLock(lockID) != nil {
+			s.unlock(lock)
+		}
+		return err
+	}
+
+	select {
+	case <-held.releasedCh:
+	case <-leaderLostCh:
+		s.removeLock(lockID)
+	case <-ctx.Done():
+		if s.removeLock(lockID) != nil {
+			s.unlock(lock)
+		}
+	}
+	return nil
+}
+
+func (s *gRPCServer) Unlock(_ context.Context, req *proto.UnlockRequest) (*proto.Empty, error) {
+	held := s.removeLock(req.GetLockId())
+	if held == nil {
+		// The lock has already been lost or released
+		return &proto.Empty{}, nil
+	}
+	defer close(held.releasedCh)
+
+	if err := held.lock.Unlock(); err != nil {
+		return nil, err
+	}
+	return &proto.Empty{}, nil
+}
+
+func (s *gRPCServer) LockValue(_ context.Context, req *proto.LockValueRequest) (*proto.LockValueResponse, error) {
+	ha, err := s.getHABackend()
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := ha.LockWith(req.GetKey(), "")
+	if err != nil {
+		return nil, err
+	}
+	held, value, err := lock.Value()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.LockValueResponse{Held: held, Value: value}, nil
+}
+
+// removeLock stops tracking the held lock with the given ID and returns it,
+// or nil if it is not tracked.
+func (s *gRPCServer) removeLock(lockID string) *heldLock {
+	s.locksLock.Lock()
+	defer s.locksLock.Unlock()
+
+	held, ok := s.locks[lockID]
+	if !ok {
+		return nil
+	}
+	delete(s.locks, lockID)
+	return held
+}
+
+func (s *gRPCServer) unlock(lock physical.Lock) {
+	if err := lock.Unlock(); err != nil {
+		s.logger.Warn("failed to release lock", "error", err)
+	}
+}
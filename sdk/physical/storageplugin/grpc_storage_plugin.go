@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"context"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/storageplugin/proto"
+	"google.golang.org/grpc"
+)
+
+// pluginName is the name the storage plugin is dispensed by.
+const pluginName = "storage"
+
+// HandshakeConfig is used to do a basic handshake between a storage plugin
+// and Vault. If the handshake fails, a user friendly error is shown. This
+// prevents users from executing bad plugins or executing a plugin directory.
+// It is a UX feature, not a security feature.
+var HandshakeConfig = plugin.HandshakeConfig{
+	MagicCookieKey:   "VAULT_STORAGE_PLUGIN",
+	MagicCookieValue: "5d3b6ad2-3c8e-4f0a-9d21-e4b7f8c6a19f",
+}
+
+// GRPCStoragePlugin serves the physical backends created by Factory over
+// gRPC, and dispenses the client Vault uses them with.
+type GRPCStoragePlugin struct {
+	Factory physical.Factory
+	Logger  log.Logger
+
+	// Embeding this will disable the netRPC protocol
+	plugin.NetRPCUnsupportedPlugin
+}
+
+var (
+	_ plugin.Plugin     = &GRPCStoragePlugin{}
+	_ plugin.GRPCPlugin = &GRPCStoragePlugin{}
+)
+
+func (p GRPCStoragePlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterStorageServer(s, newGRPCServer(p.Factory, p.Logger))
+	return nil
+}
+
+func (GRPCStoragePlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &gRPCClient{client: proto.NewStorageClient(c)}, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+)
+
+func testStoragePlugin(t *testing.T, factory physical.Factory) physical.Backend {
+	t.Helper()
+
+	client, _ := plugin.TestPluginGRPCConn(t, false, map[string]plugin.Plugin{
+		pluginName: &GRPCStoragePlugin{
+			Factory: factory,
+			Logger:  logging.NewVaultLogger(log.Debug),
+		},
+	})
+	t.Cleanup(func() { client.Close() })
+
+	raw, err := client.Dispense(pluginName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := raw.(*gRPCClient).setup(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend
+}
+
+func TestStoragePlugin(t *testing.T) {
+	b := testStoragePlugin(t, inmem.NewInmem)
+
+	if _, ok := b.(physical.Transactional); ok {
+		t.Fatal("backend should not be transactional")
+	}
+	if b.(physical.HABackend).HAEnabled() {
+		t.Fatal("backend should not be HA enabled")
+	}
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseBackend_ListPrefix(t, b)
+}
+
+func TestStoragePlugin_Transactional(t *testing.T) {
+	b := testStoragePlugin(t, inmem.NewTransactionalInmemHA)
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseTransactionalBackend(t, b)
+}
+
+func TestStoragePlugin_HA(t *testing.T) {
+	b := testStoragePlugin(t, inmem.NewTransactionalInmemHA)
+
+	ha, ok := b.(physical.HABackend)
+	if !ok || !ha.HAEnabled() {
+		t.Fatal("backend should be HA enabled")
+	}
+
+	// Use the same plugin to acquire the same set of locks
+	physical.ExerciseHABackend(t, ha, ha)
+}
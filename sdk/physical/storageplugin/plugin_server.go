@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package storageplugin
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+// Serve is called from within a storage plugin and serves the physical
+// backend created by the factory to Vault. The factory is called with the
+// parameters of the storage stanza of the Vault server configuration.
+func Serve(factory physical.Factory) {
+	plugin.Serve(ServeConfig(factory))
+}
+
+func ServeConfig(factory physical.Factory) *plugin.ServeConfig {
+	err := pluginutil.OptionallyEnableMlock()
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	// The logs are forwarded to Vault through stderr
+	logger := log.New(&log.LoggerOptions{
+		Level:      log.Trace,
+		Output:     os.Stderr,
+		JSONFormat: true,
+	})
+
+	// pluginSets is the map of plugins we can dispense.
+	pluginSets := map[int]plugin.PluginSet{
+		1: {
+			pluginName: &GRPCStoragePlugin{
+				Factory: factory,
+				Logger:  logger,
+			},
+		},
+	}
+
+	conf := &plugin.ServeConfig{
+		HandshakeConfig:  HandshakeConfig,
+		VersionedPlugins: pluginSets,
+		GRPCServer:       plugin.DefaultGRPCServer,
+		Logger:           logger,
+	}
+
+	return conf
+}
@@ -0,0 +1,411 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	accessAdvisorSubPath = "access-advisor/"
+
+	// accessAdvisorFlushInterval is how often the capability usage gathered
+	// in memory is merged into storage.
+	accessAdvisorFlushInterval = time.Minute
+
+	// defaultAccessAdvisorWindow is the period a capability must not have
+	// been used for to be reported as unused, if not given.
+	defaultAccessAdvisorWindow = 90 * 24 * time.Hour
+)
+
+// capabilityUsageKey identifies a capability granted by a rule of a policy.
+type capabilityUsageKey struct {
+	NamespaceID string `json:"namespace_id"`
+	Policy      string `json:"policy"`
+	Rule        string `json:"rule"`
+	Capability  string `json:"capability"`
+}
+
+type capabilityUsage struct {
+	capabilityUsageKey
+	LastUsed time.Time `json:"last_used"`
+}
+
+// entityAccessUsage is the stored record of the capabilities an entity has
+// used, and of the policies attached to the tokens it used them with.
+type entityAccessUsage struct {
+	TrackingSince time.Time          `json:"tracking_since"`
+	Capabilities  []*capabilityUsage `json:"capabilities"`
+	TokenPolicies []*capabilityUsage `json:"token_policies"`
+}
+
+// accessAdvisor gathers, per entity, the last time each capability granted
+// by a policy rule was used, for reporting the capabilities entities hold
+// but do not use. Usage is aggregated in memory and merged into storage in
+// the background, so the last minute of usage is lost if the node seals.
+type accessAdvisor struct {
+	view   *BarrierView
+	logger log.Logger
+
+	lock          sync.Mutex
+	capabilities  map[string]map[capabilityUsageKey]time.Time
+	tokenPolicies map[string]map[capabilityUsageKey]time.Time
+
+	// storageLock serializes merges into storage with reports reading it
+	storageLock sync.Mutex
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+}
+
+func (c *Core) setupAccessAdvisor(_ context.Context) error {
+	a := &accessAdvisor{
+		view:          c.systemBarrierView.SubView(accessAdvisorSubPath),
+		logger:        c.baseLogger.Named("access-advisor"),
+		capabilities:  make(map[string]map[capabilityUsageKey]time.Time),
+		tokenPolicies: make(map[string]map[capabilityUsageKey]time.Time),
+		doneCh:        make(chan struct{}),
+	}
+	c.AddLogger(a.logger)
+
+	var ctx context.Context
+	ctx, a.cancel = context.WithCancel(c.activeContext)
+	go a.run(ctx)
+
+	c.accessAdvisor.Store(a)
+	return nil
+}
+
+func (c *Core) stopAccessAdvisor() {
+	a := c.accessAdvisor.Swap(nil)
+	if a == nil {
+		return
+	}
+	a.cancel()
+	<-a.doneCh
+}
+
+func (a *accessAdvisor) run(ctx context.Context) {
+	defer close(a.doneCh)
+
+	ticker := time.NewTicker(accessAdvisorFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.flush(ctx); err != nil {
+				a.logger.Error("failed to store capability usage", "error", err)
+			}
+		case <-ctx.Done():
+			// Storage may still be written to while the node steps down
+			if err := a.flush(context.Background()); err != nil {
+				a.logger.Error("failed to store capability usage", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// operationCapabilities maps request operations to the capability they
+// require, as checked by ACL.AllowOperation.
+var operationCapabilities = map[logical.Operation]uint32{
+	logical.ReadOperation:     ReadCapabilityInt,
+	logical.ListOperation:     ListCapabilityInt,
+	logical.UpdateOperation:   UpdateCapabilityInt,
+	logical.DeleteOperation:   DeleteCapabilityInt,
+	logical.CreateOperation:   CreateCapabilityInt,
+	logical.PatchOperation:    PatchCapabilityInt,
+	logical.RevokeOperation:   UpdateCapabilityInt,
+	logical.RenewOperation:    UpdateCapabilityInt,
+	logical.RollbackOperation: UpdateCapabilityInt,
+}
+
+// recordCapabilityUsage records the capabilities the token of an entity
+// used for an allowed request, against the policies granting them.
+func (c *Core) recordCapabilityUsage(te *logical.TokenEntry, req *logical.Request, results *ACLResults, rootPrivsRequired bool) {
+	a := c.accessAdvisor.Load()
+	if a == nil || te == nil || te.EntityID == "" || results == nil || results.IsRoot || results.permissions == nil {
+		return
+	}
+	capability, ok := operationCapabilities[req.Operation]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	perms := results.permissions
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	used := a.capabilities[te.EntityID]
+	if used == nil {
+		used = make(map[capabilityUsageKey]time.Time)
+		a.capabilities[te.EntityID] = used
+	}
+	record := func(capability uint32) {
+		for _, p := range perms.GrantingPoliciesMap[capability] {
+			used[capabilityUsageKey{
+				NamespaceID: p.NamespaceId,
+				Policy:      p.Name,
+				Rule:        perms.rulePath,
+				Capability:  capabilityName(capability),
+			}] = now
+		}
+	}
+	record(capability)
+	if rootPrivsRequired {
+		record(SudoCapabilityInt)
+	}
+
+	seen := a.tokenPolicies[te.EntityID]
+	if seen == nil {
+		seen = make(map[capabilityUsageKey]time.Time)
+		a.tokenPolicies[te.EntityID] = seen
+	}
+	for _, policy := range te.Policies {
+		seen[capabilityUsageKey{NamespaceID: te.NamespaceID, Policy: policy}] = now
+	}
+}
+
+func capabilityName(capability uint32) string {
+	for name, c := range cap2Int {
+		if c == capability {
+			return name
+		}
+	}
+	return ""
+}
+
+// flush merges the usage gathered in memory into storage.
+func (a *accessAdvisor) flush(ctx context.Context) error {
+	a.lock.Lock()
+	capabilities, tokenPolicies := a.capabilities, a.tokenPolicies
+	a.capabilities = make(map[string]map[capabilityUsageKey]time.Time)
+	a.tokenPolicies = make(map[string]map[capabilityUsageKey]time.Time)
+	a.lock.Unlock()
+
+	a.storageLock.Lock()
+	defer a.storageLock.Unlock()
+
+	for entityID, used := range capabilities {
+		usage, err := a.load(ctx, entityID)
+		if err != nil {
+			return err
+		}
+		if usage == nil {
+			usage = &entityAccessUsage{TrackingSince: time.Now()}
+		}
+		usage.Capabilities = mergeCapabilityUsage(usage.Capabilities, used)
+		usage.TokenPolicies = mergeCapabilityUsage(usage.TokenPolicies, tokenPolicies[entityID])
+
+		entry, err := logical.StorageEntryJSON("entity/"+entityID, usage)
+		if err != nil {
+			return err
+		}
+		if err := a.view.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *accessAdvisor) load(ctx context.Context, entityID string) (*entityAccessUsage, error) {
+	entry, err := a.view.Get(ctx, "entity/"+entityID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	var usage entityAccessUsage
+	if err := entry.DecodeJSON(&usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func mergeCapabilityUsage(stored []*capabilityUsage, used map[capabilityUsageKey]time.Time) []*capabilityUsage {
+	byKey := make(map[capabilityUsageKey]*capabilityUsage, len(stored))
+	for _, u := range stored {
+		byKey[u.capabilityUsageKey] = u
+	}
+	for key, lastUsed := range used {
+		if u, ok := byKey[key]; ok {
+			if lastUsed.After(u.LastUsed) {
+				u.LastUsed = lastUsed
+			}
+			continue
+		}
+		u := &capabilityUsage{capabilityUsageKey: key, LastUsed: lastUsed}
+		byKey[key] = u
+		stored = append(stored, u)
+	}
+	return stored
+}
+
+// AccessAdvisorCapability is a capability an entity holds through a rule of
+// one of its policies.
+type AccessAdvisorCapability struct {
+	Policy        string     `json:"policy"`
+	NamespacePath string     `json:"namespace_path"`
+	Rule          string     `json:"rule"`
+	Capability    string     `json:"capability"`
+	LastUsed      *time.Time `json:"last_used"`
+	Unused        bool       `json:"unused"`
+}
+
+// AccessAdvisorReport lists the capabilities an entity holds, and when each
+// was last used.
+type AccessAdvisorReport struct {
+	EntityID      string                     `json:"entity_id"`
+	TrackingSince *time.Time                 `json:"tracking_since"`
+	Capabilities  []*AccessAdvisorCapability `json:"capabilities"`
+}
+
+// AccessAdvisorReport reports the capabilities granted to the entity by its
+// identity policies and by the policies of the tokens it has used. Those not
+// used within the window are flagged unused.
+func (c *Core) AccessAdvisorReport(ctx context.Context, entity *identity.Entity, window time.Duration) (*AccessAdvisorReport, error) {
+	a := c.accessAdvisor.Load()
+	if a == nil {
+		return nil, fmt.Errorf("access advisor is not running on this node")
+	}
+
+	// Merge pending usage first, so that the report is current
+	if err := a.flush(ctx); err != nil {
+		return nil, err
+	}
+	a.storageLock.Lock()
+	usage, err := a.load(ctx, entity.ID)
+	a.storageLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		usage = &entityAccessUsage{}
+	}
+
+	entityNS, err := NamespaceByID(ctx, entity.NamespaceID, c)
+	if err != nil {
+		return nil, err
+	}
+	if entityNS == nil {
+		return nil, namespace.ErrNoNamespace
+	}
+	policyNames, err := c.identityStore.groupPoliciesByEntityID(entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	policyNames[entity.NamespaceID] = append(policyNames[entity.NamespaceID], entity.Policies...)
+	for _, u := range usage.TokenPolicies {
+		policyNames[u.NamespaceID] = append(policyNames[u.NamespaceID], u.Policy)
+	}
+
+	lastUsed := make(map[capabilityUsageKey]time.Time, len(usage.Capabilities))
+	for _, u := range usage.Capabilities {
+		lastUsed[u.capabilityUsageKey] = u.LastUsed
+	}
+
+	var groups []*identity.Group
+	directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	groups = append(directGroups, inheritedGroups...)
+
+	report := &AccessAdvisorReport{
+		EntityID:     entity.ID,
+		Capabilities: []*AccessAdvisorCapability{},
+	}
+	if !usage.TrackingSince.IsZero() {
+		report.TrackingSince = &usage.TrackingSince
+	}
+	cutoff := time.Now().Add(-window)
+
+	for nsID, names := range policyNames {
+		policyNS, err := NamespaceByID(ctx, nsID, c)
+		if err != nil {
+			return nil, err
+		}
+		if policyNS == nil {
+			continue
+		}
+		policyCtx := namespace.ContextWithNamespace(ctx, policyNS)
+
+		seen := make(map[string]bool, len(names))
+		for _, name := range names {
+			if seen[name] || name == "root" {
+				continue
+			}
+			seen[name] = true
+
+			policy, err := c.policyStore.GetPolicy(policyCtx, name, PolicyTypeACL)
+			if err != nil {
+				return nil, err
+			}
+			if policy == nil {
+				continue
+			}
+			if policy.Templated {
+				policy, err = parseACLPolicyWithTemplating(policy.namespace, policy.Raw, true, entity, groups)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing templated policy %q: %w", name, err)
+				}
+			}
+
+			for _, pc := range policy.Paths {
+				bitmap := pc.Permissions.CapabilitiesBitmap
+				if bitmap&DenyCapabilityInt > 0 {
+					continue
+				}
+				rule := pc.Path
+				if pc.IsPrefix {
+					rule += "*"
+				}
+				for _, capability := range capabilitiesFromBitmap(bitmap) {
+					if capability == DenyCapability {
+						continue
+					}
+					ret := &AccessAdvisorCapability{
+						Policy:        name,
+						NamespacePath: policyNS.Path,
+						Rule:          rule,
+						Capability:    capability,
+						Unused:        true,
+					}
+					if t, ok := lastUsed[capabilityUsageKey{NamespaceID: nsID, Policy: name, Rule: rule, Capability: capability}]; ok {
+						ret.LastUsed = &t
+						ret.Unused = t.Before(cutoff)
+					}
+					report.Capabilities = append(report.Capabilities, ret)
+				}
+			}
+		}
+	}
+
+	sort.Slice(report.Capabilities, func(i, j int) bool {
+		ci, cj := report.Capabilities[i], report.Capabilities[j]
+		if ci.NamespacePath != cj.NamespacePath {
+			return ci.NamespacePath < cj.NamespacePath
+		}
+		if ci.Policy != cj.Policy {
+			return ci.Policy < cj.Policy
+		}
+		if ci.Rule != cj.Rule {
+			return ci.Rule < cj.Rule
+		}
+		return ci.Capability < cj.Capability
+	})
+	return report, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// TestAccessAdvisor_Report verifies that the capabilities an entity uses are
+// recorded against the policy rules granting them, and that the others are
+// reported unused.
+func TestAccessAdvisor_Report(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `
+path "sys/mounts" {
+	capabilities = ["read", "update"]
+}
+path "sys/policy/*" {
+	capabilities = ["read"]
+}
+path "sys/policy/admin" {
+	capabilities = ["deny"]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.Name = "app"
+	if err := c.policyStore.SetPolicy(ctx, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Path:        "identity/entity",
+		Data: map[string]interface{}{
+			"name":     "app",
+			"policies": "app",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	entityID := resp.Data["id"].(string)
+
+	if err := c.tokenStore.create(ctx, &logical.TokenEntry{
+		ID:           "apptoken",
+		Path:         "auth/token/create",
+		Policies:     []string{"default"},
+		CreationTime: time.Now().Unix(),
+		EntityID:     entityID,
+		NamespaceID:  namespace.RootNamespaceID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		ClientToken: "apptoken",
+		Operation:   logical.ReadOperation,
+		Path:        "sys/mounts",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		ClientToken: root,
+		Operation:   logical.ReadOperation,
+		Path:        "identity/entity/" + entityID + "/access-advisor",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["tracking_since"] == nil {
+		t.Fatal("expected tracking_since to be set")
+	}
+
+	expected := map[string]bool{
+		"sys/mounts read":   false,
+		"sys/mounts update": true,
+		"sys/policy/* read": true,
+	}
+	var sawDefault bool
+	for _, capability := range resp.Data["capabilities"].([]map[string]interface{}) {
+		switch capability["policy"] {
+		case "default":
+			sawDefault = true
+			continue
+		case "app":
+		default:
+			t.Fatalf("unexpected policy: %#v", capability)
+		}
+
+		key := capability["rule"].(string) + " " + capability["capability"].(string)
+		unused, ok := expected[key]
+		if !ok {
+			t.Fatalf("unexpected capability %q", key)
+		}
+		if capability["unused"] != unused {
+			t.Fatalf("expected %q to be reported unused=%t, got %#v", key, unused, capability)
+		}
+		if !unused && capability["last_used"] == nil {
+			t.Fatalf("expected last_used of %q to be set", key)
+		}
+		delete(expected, key)
+	}
+	if len(expected) > 0 {
+		t.Fatalf("capabilities missing from report: %v", expected)
+	}
+	if !sawDefault {
+		t.Fatal("expected the policies of the entity's tokens to be reported")
+	}
+}
@@ -61,6 +61,9 @@ type ACLResults struct {
 	CapabilitiesBitmap  uint32
 	GrantingPolicies    []logical.PolicyInfo
 	SubscribeEventTypes []string
+
+	// permissions are those of the rule matching the path, if any
+	permissions *ACLPermissions
 }
 
 type SentinelResults struct {
@@ -141,6 +144,10 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 				// Store this policy name as the policy that permits these
 				// capabilities
 				clonedPerms.GrantingPoliciesMap = addGrantingPoliciesToMap(nil, policy, clonedPerms.CapabilitiesBitmap)
+				clonedPerms.rulePath = pc.Path
+				if pc.IsPrefix {
+					clonedPerms.rulePath += "*"
+				}
 				switch {
 				case pc.HasSegmentWildcards:
 					a.segmentWildcardPaths[pc.Path] = clonedPerms
@@ -156,7 +163,11 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 			switch {
 			case existingPerms.CapabilitiesBitmap&DenyCapabilityInt > 0:
 				// If we are explicitly denied in the existing capability set,
-				// don't save anything else
+				// don't save anything else, other than a further policy
+				// denying it
+				if pc.Permissions.CapabilitiesBitmap&DenyCapabilityInt > 0 {
+					existingPerms.GrantingPoliciesMap = addGrantingPoliciesToMap(existingPerms.GrantingPoliciesMap, policy, DenyCapabilityInt)
+				}
 				continue
 
 			case pc.Permissions.CapabilitiesBitmap&DenyCapabilityInt > 0:
@@ -164,6 +175,7 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 				existingPerms.CapabilitiesBitmap = DenyCapabilityInt
 				existingPerms.AllowedParameters = nil
 				existingPerms.DeniedParameters = nil
+				existingPerms.GrantingPoliciesMap = addGrantingPoliciesToMap(nil, policy, DenyCapabilityInt)
 				goto INSERT
 
 			default:
@@ -306,9 +318,12 @@ func (a *ACL) CapabilitiesAndSubscribeEventTypes(ctx context.Context, path strin
 	if res.IsRoot {
 		return []string{RootCapability}, []string{"*"}
 	}
-	subscribeEventTypes = res.SubscribeEventTypes
-	capabilities := res.CapabilitiesBitmap
+	return capabilitiesFromBitmap(res.CapabilitiesBitmap), res.SubscribeEventTypes
+}
 
+// capabilitiesFromBitmap returns the names of the capabilities in the
+// bitmap, or only "deny" if it is explicitly set or no capability is.
+func capabilitiesFromBitmap(capabilities uint32) (pathCapabilities []string) {
 	if capabilities&SudoCapabilityInt > 0 {
 		pathCapabilities = append(pathCapabilities, SudoCapability)
 	}
@@ -348,6 +363,70 @@ func (a *ACL) Capabilities(ctx context.Context, path string) []string {
 	return pathCapabilities
 }
 
+// CapabilitiesExplanation describes how the capabilities on a path were
+// derived from the policies of a token.
+type CapabilitiesExplanation struct {
+	Capabilities []string `json:"capabilities"`
+
+	// MatchedRule is the policy path rule which applies to the path, empty
+	// if no rule matches it.
+	MatchedRule string `json:"matched_rule"`
+
+	// GrantedBy maps each capability granted on the path to the policies
+	// granting it through the matched rule.
+	GrantedBy map[string][]logical.PolicyInfo `json:"granted_by"`
+
+	// DeniedBy are the policies explicitly denying the path through the
+	// matched rule.
+	DeniedBy []logical.PolicyInfo `json:"denied_by"`
+
+	Reason string `json:"reason"`
+}
+
+// ExplainCapabilities returns the capabilities on the path, along with the
+// rule and policies they are derived from.
+func (a *ACL) ExplainCapabilities(ctx context.Context, path string) *CapabilitiesExplanation {
+	req := &logical.Request{
+		Path:      path,
+		Operation: logical.ListOperation,
+	}
+
+	res := a.AllowOperation(ctx, req, true)
+	if res.IsRoot {
+		return &CapabilitiesExplanation{
+			Capabilities: []string{RootCapability},
+			GrantedBy:    map[string][]logical.PolicyInfo{RootCapability: res.GrantingPolicies},
+			Reason:       "token has the root policy",
+		}
+	}
+
+	ret := &CapabilitiesExplanation{
+		Capabilities: capabilitiesFromBitmap(res.CapabilitiesBitmap),
+		GrantedBy:    make(map[string][]logical.PolicyInfo),
+	}
+	sort.Strings(ret.Capabilities)
+
+	perms := res.permissions
+	switch {
+	case perms == nil:
+		ret.Reason = "no policy rule of the token matches the path"
+	case perms.CapabilitiesBitmap&DenyCapabilityInt > 0:
+		ret.MatchedRule = perms.rulePath
+		ret.DeniedBy = perms.GrantingPoliciesMap[DenyCapabilityInt]
+		ret.Reason = fmt.Sprintf("rule %q explicitly denies the path", perms.rulePath)
+	case ret.Capabilities[0] == DenyCapability:
+		ret.MatchedRule = perms.rulePath
+		ret.Reason = fmt.Sprintf("rule %q grants no capabilities", perms.rulePath)
+	default:
+		ret.MatchedRule = perms.rulePath
+		for _, capability := range ret.Capabilities {
+			ret.GrantedBy[capability] = perms.GrantingPoliciesMap[cap2Int[capability]]
+		}
+		ret.Reason = fmt.Sprintf("capabilities are granted by rule %q", perms.rulePath)
+	}
+	return ret
+}
+
 // AllowOperation is used to check if the given operation is permitted.
 func (a *ACL) AllowOperation(ctx context.Context, req *logical.Request, capCheckOnly bool) (ret *ACLResults) {
 	ret = new(ACLResults)
@@ -434,6 +513,7 @@ CHECK:
 	// If "deny" has been explicitly set, only deny will be in the map, so we
 	// only need to check for the existence of other values
 	ret.RootPrivs = capabilities&SudoCapabilityInt > 0
+	ret.permissions = permissions
 
 	// This is after the RootPrivs check so we can gate on it being from sudo
 	// rather than policy root
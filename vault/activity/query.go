@@ -23,6 +23,7 @@ type NamespaceRecord struct {
 	Entities        uint64         `json:"entities"`
 	NonEntityTokens uint64         `json:"non_entity_tokens"`
 	SecretSyncs     uint64         `json:"secret_syncs"`
+	ExcludedClients uint64         `json:"excluded_clients,omitempty"`
 	Mounts          []*MountRecord `json:"mounts"`
 }
 
@@ -30,11 +31,15 @@ type CountsRecord struct {
 	EntityClients    int `json:"entity_clients"`
 	NonEntityClients int `json:"non_entity_clients"`
 	SecretSyncs      int `json:"secret_syncs"`
+
+	// ExcludedClients are the clients matching the exclusion rules of the
+	// activity log, which are not billable
+	ExcludedClients int `json:"excluded_clients,omitempty"`
 }
 
 // HasCounts returns true when any of the record's fields have a non-zero value
 func (c *CountsRecord) HasCounts() bool {
-	return c.EntityClients+c.NonEntityClients+c.SecretSyncs != 0
+	return c.EntityClients+c.NonEntityClients+c.SecretSyncs+c.ExcludedClients != 0
 }
 
 type NewClientRecord struct {
@@ -47,6 +52,11 @@ type MonthRecord struct {
 	Counts     *CountsRecord             `json:"counts"`
 	Namespaces []*MonthlyNamespaceRecord `json:"namespaces"`
 	NewClients *NewClientRecord          `json:"new_clients"`
+
+	// Mounts are the clients and new clients of each mount in the month,
+	// indexed by mount rather than rolled up by namespace. They are recorded
+	// from version 2 on.
+	Mounts []*MonthlyMountRecord `json:"mounts,omitempty"`
 }
 
 type MonthlyNamespaceRecord struct {
@@ -58,9 +68,51 @@ type MonthlyNamespaceRecord struct {
 type MountRecord struct {
 	MountPath string        `json:"mount_path"`
 	Counts    *CountsRecord `json:"counts"`
+
+	// MountAccessor identifies the mount regardless of the path it is moved
+	// to. It is recorded from version 2 on, and is empty for the clients
+	// recorded without a mount.
+	MountAccessor string `json:"mount_accessor,omitempty"`
 }
 
+// MonthlyMountRecord is the activity of a mount in a month. NewClients are the
+// clients first seen in the month, within the period of the query, on the
+// mount.
+type MonthlyMountRecord struct {
+	NamespaceID   string        `json:"namespace_id"`
+	MountAccessor string        `json:"mount_accessor,omitempty"`
+	MountPath     string        `json:"mount_path"`
+	Counts        *CountsRecord `json:"counts"`
+	NewClients    *CountsRecord `json:"new_clients"`
+}
+
+const (
+	// PrecomputedQueryVersion1 queries only record the mounts by path, within
+	// the namespace rollups. Queries written before queries were versioned
+	// are of version 1.
+	PrecomputedQueryVersion1 = 1
+
+	// PrecomputedQueryVersion2 queries also record the mount accessors, and
+	// the clients and new clients of each mount in each month.
+	PrecomputedQueryVersion2 = 2
+
+	// PrecomputedQueryCurrentVersion is the version queries are written in.
+	PrecomputedQueryCurrentVersion = PrecomputedQueryVersion2
+)
+
+// MountAccessorResolver returns the accessor of the mount at the given path in
+// the given namespace, or an empty string if it is unknown.
+type MountAccessorResolver func(ctx context.Context, namespaceID, mountPath string) string
+
+// MonthResolver returns the start of the month a stored time falls in, in the
+// time zone the months are reported in.
+type MonthResolver func(t time.Time) time.Time
+
 type PrecomputedQuery struct {
+	// Version is the version of the format of the query. It is zero for the
+	// queries of version 1.
+	Version int `json:"version,omitempty"`
+
 	StartTime  time.Time
 	EndTime    time.Time
 	Namespaces []*NamespaceRecord `json:"namespaces"`
@@ -70,6 +122,15 @@ type PrecomputedQuery struct {
 type PrecomputedQueryStore struct {
 	logger log.Logger
 	view   logical.Storage
+
+	// mountAccessor resolves the accessors of the mounts of the queries of
+	// version 1 when they are upgraded.
+	mountAccessor MountAccessorResolver
+
+	// monthStart and monthEnd resolve the months the start and end times of
+	// the queries fall in. The stored times are used as they are when unset.
+	monthStart MonthResolver
+	monthEnd   MonthResolver
 }
 
 // The query store should be initialized with a view to the subdirectory
@@ -81,8 +142,43 @@ func NewPrecomputedQueryStore(logger log.Logger, view logical.Storage, retention
 	}
 }
 
+// SetMountAccessorResolver sets how the accessors of the mounts of the queries
+// of version 1, which only recorded their path, are resolved on upgrade.
+func (s *PrecomputedQueryStore) SetMountAccessorResolver(resolver MountAccessorResolver) {
+	s.mountAccessor = resolver
+}
+
+// SetMonthResolvers sets how the months the start and end times of the queries
+// fall in are resolved, for the months to be computed in another time zone than
+// UTC. The start times and end times are compared to the months queried, and
+// are reported, as the months they resolve to.
+func (s *PrecomputedQueryStore) SetMonthResolvers(start, end MonthResolver) {
+	s.monthStart = start
+	s.monthEnd = end
+}
+
+func (s *PrecomputedQueryStore) startMonth(t time.Time) time.Time {
+	if s.monthStart == nil {
+		return t
+	}
+	return s.monthStart(t)
+}
+
+func (s *PrecomputedQueryStore) endMonth(t time.Time) time.Time {
+	if s.monthEnd == nil {
+		return t
+	}
+	return timeutil.EndOfMonth(s.monthEnd(t))
+}
+
+// Put stores the query in the current version, upgrading it in place if it is
+// of an older version.
 func (s *PrecomputedQueryStore) Put(ctx context.Context, p *PrecomputedQuery) error {
-	path := fmt.Sprintf("%v/%v", p.StartTime.Unix(), p.EndTime.Unix())
+	s.upgrade(ctx, p)
+	return s.put(ctx, fmt.Sprintf("%v/%v", p.StartTime.Unix(), p.EndTime.Unix()), p)
+}
+
+func (s *PrecomputedQueryStore) put(ctx context.Context, path string, p *PrecomputedQuery) error {
 	asJson, err := json.Marshal(p)
 	if err != nil {
 		return err
@@ -159,7 +255,7 @@ func (s *PrecomputedQueryStore) getMaxEndTime(ctx context.Context, startTime tim
 		}
 		endTime := time.Unix(val, 0).UTC()
 		s.logger.Trace("end time in consideration is", "end time", endTime, "end time bound", endTimeBound)
-		if endTime.After(maxEndTime) && !endTime.After(endTimeBound) {
+		if endTime.After(maxEndTime) && !s.endMonth(endTime).After(endTimeBound) {
 			s.logger.Trace("end time has been updated")
 			maxEndTime = endTime
 		}
@@ -217,7 +313,7 @@ func (s *PrecomputedQueryStore) Get(ctx context.Context, startTime, endTime time
 
 	filteredList := make([]time.Time, 0)
 	for _, t := range startTimes {
-		if timeutil.InRange(t, startTime, endTime) {
+		if timeutil.InRange(s.startMonth(t), startTime, endTime) {
 			filteredList = append(filteredList, t)
 		}
 	}
@@ -247,7 +343,7 @@ func (s *PrecomputedQueryStore) Get(ctx context.Context, startTime, endTime time
 			continue
 		}
 		s.logger.Trace("retrieved max end time from storage", "endTime", testEndTime)
-		diff := testEndTime.Sub(testStartTime)
+		diff := s.endMonth(testEndTime).Sub(s.startMonth(testStartTime))
 		if diff >= maxTimeDifference {
 			closestStartTime = testStartTime
 			closestEndTime = testEndTime
@@ -287,9 +383,103 @@ func (s *PrecomputedQueryStore) Get(ctx context.Context, startTime, endTime time
 		return nil, err
 	}
 
+	// Queries of older versions are migrated lazily, as they are read
+	if s.upgrade(ctx, p) {
+		err = s.put(ctx, path, p)
+		if err != nil && !errors.Is(err, logical.ErrReadOnly) {
+			s.logger.Warn("failed to store upgraded query", "path", path, "error", err)
+		}
+	}
+	p.StartTime = s.startMonth(p.StartTime)
+	p.EndTime = s.endMonth(p.EndTime)
+
 	return p, nil
 }
 
+// upgrade upgrades the query to the current version, and returns whether it
+// was of an older version.
+func (s *PrecomputedQueryStore) upgrade(ctx context.Context, p *PrecomputedQuery) bool {
+	if p.Version >= PrecomputedQueryCurrentVersion {
+		return false
+	}
+
+	// Version 1 queries only recorded the path of the mounts, which is
+	// assumed to still be the path of the same mount
+	resolve := func(namespaceID string, mounts []*MountRecord) {
+		for _, mount := range mounts {
+			if mount.MountAccessor == "" && s.mountAccessor != nil {
+				mount.MountAccessor = s.mountAccessor(ctx, namespaceID, mount.MountPath)
+			}
+		}
+	}
+	for _, ns := range p.Namespaces {
+		resolve(ns.NamespaceID, ns.Mounts)
+	}
+	for _, month := range p.Months {
+		for _, ns := range month.Namespaces {
+			resolve(ns.NamespaceID, ns.Mounts)
+		}
+		if month.NewClients != nil {
+			for _, ns := range month.NewClients.Namespaces {
+				resolve(ns.NamespaceID, ns.Mounts)
+			}
+		}
+		month.Mounts = MonthlyMounts(month)
+	}
+
+	p.Version = PrecomputedQueryCurrentVersion
+	return true
+}
+
+// MonthlyMounts indexes the clients and new clients of the namespaces of the
+// month by mount.
+func MonthlyMounts(month *MonthRecord) []*MonthlyMountRecord {
+	byMount := make(map[string]*MonthlyMountRecord)
+	mounts := make([]*MonthlyMountRecord, 0)
+	mountRecord := func(namespaceID string, mount *MountRecord) *MonthlyMountRecord {
+		key := mount.MountAccessor
+		if key == "" {
+			key = namespaceID + "/" + mount.MountPath
+		}
+		record, ok := byMount[key]
+		if !ok {
+			record = &MonthlyMountRecord{
+				NamespaceID:   namespaceID,
+				MountAccessor: mount.MountAccessor,
+				MountPath:     mount.MountPath,
+				Counts:        &CountsRecord{},
+				NewClients:    &CountsRecord{},
+			}
+			byMount[key] = record
+			mounts = append(mounts, record)
+		}
+		return record
+	}
+	add := func(to, from *CountsRecord) {
+		if from == nil {
+			return
+		}
+		to.EntityClients += from.EntityClients
+		to.NonEntityClients += from.NonEntityClients
+		to.SecretSyncs += from.SecretSyncs
+		to.ExcludedClients += from.ExcludedClients
+	}
+
+	for _, ns := range month.Namespaces {
+		for _, mount := range ns.Mounts {
+			add(mountRecord(ns.NamespaceID, mount).Counts, mount.Counts)
+		}
+	}
+	if month.NewClients != nil {
+		for _, ns := range month.NewClients.Namespaces {
+			for _, mount := range ns.Mounts {
+				add(mountRecord(ns.NamespaceID, mount).NewClients, mount.Counts)
+			}
+		}
+	}
+	return mounts
+}
+
 func (s *PrecomputedQueryStore) DeleteQueriesBefore(ctx context.Context, retentionThreshold time.Time) error {
 	startTimes, err := s.listStartTimes(ctx)
 	if err != nil {
@@ -298,7 +488,7 @@ func (s *PrecomputedQueryStore) DeleteQueriesBefore(ctx context.Context, retenti
 
 	for _, t := range startTimes {
 		path := fmt.Sprintf("%v/", t.Unix())
-		if t.Before(retentionThreshold) {
+		if s.startMonth(t).Before(retentionThreshold) {
 			rawEndTimes, err := s.view.List(ctx, path)
 			if err != nil {
 				return err
@@ -5,6 +5,8 @@ package activity
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -12,6 +14,7 @@ import (
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/helper/compressutil"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -292,3 +295,105 @@ func TestQueryStore_TimeRanges(t *testing.T) {
 		})
 	}
 }
+
+// TestQueryStore_UpgradeVersion1 verifies that the queries of version 1 are
+// upgraded when read, with their mounts indexed by accessor, and rewritten in
+// the current version.
+func TestQueryStore_UpgradeVersion1(t *testing.T) {
+	tsStart := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tsEnd := timeutil.EndOfMonth(tsStart)
+
+	qs := NewTestQueryStore(t)
+	qs.SetMountAccessorResolver(func(_ context.Context, namespaceID, mountPath string) string {
+		if namespaceID == "root" && mountPath == "auth/userpass/" {
+			return "auth_userpass_1234"
+		}
+		return ""
+	})
+	ctx := context.Background()
+
+	// Queries written before queries were versioned are of version 1
+	v1 := &PrecomputedQuery{
+		StartTime: tsStart,
+		EndTime:   tsEnd,
+		Months: []*MonthRecord{
+			{
+				Timestamp: tsStart.Unix(),
+				Counts:    &CountsRecord{EntityClients: 5, NonEntityClients: 2},
+				Namespaces: []*MonthlyNamespaceRecord{
+					{
+						NamespaceID: "root",
+						Counts:      &CountsRecord{EntityClients: 5, NonEntityClients: 2},
+						Mounts: []*MountRecord{
+							{MountPath: "auth/userpass/", Counts: &CountsRecord{EntityClients: 5}},
+							{MountPath: "auth/token/", Counts: &CountsRecord{NonEntityClients: 2}},
+						},
+					},
+				},
+				NewClients: &NewClientRecord{
+					Counts: &CountsRecord{EntityClients: 3},
+					Namespaces: []*MonthlyNamespaceRecord{
+						{
+							NamespaceID: "root",
+							Counts:      &CountsRecord{EntityClients: 3},
+							Mounts: []*MountRecord{
+								{MountPath: "auth/userpass/", Counts: &CountsRecord{EntityClients: 3}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	asJson, err := json.Marshal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("%v/%v", tsStart.Unix(), tsEnd.Unix())
+	if err := qs.view.Put(ctx, &logical.StorageEntry{Key: path, Value: asJson}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := qs.Get(ctx, tsStart, tsEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != PrecomputedQueryCurrentVersion {
+		t.Fatalf("expected version %d, got %d", PrecomputedQueryCurrentVersion, result.Version)
+	}
+	expected := []*MonthlyMountRecord{
+		{
+			NamespaceID:   "root",
+			MountAccessor: "auth_userpass_1234",
+			MountPath:     "auth/userpass/",
+			Counts:        &CountsRecord{EntityClients: 5},
+			NewClients:    &CountsRecord{EntityClients: 3},
+		},
+		{
+			NamespaceID: "root",
+			MountPath:   "auth/token/",
+			Counts:      &CountsRecord{NonEntityClients: 2},
+			NewClients:  &CountsRecord{},
+		},
+	}
+	if !reflect.DeepEqual(result.Months[0].Mounts, expected) {
+		t.Fatalf("unexpected mounts, expected %v got %v", expected, result.Months[0].Mounts)
+	}
+
+	// The upgraded query was stored
+	entry, err := qs.view.Get(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _, err := compressutil.Decompress(entry.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &PrecomputedQuery{}
+	if err := json.Unmarshal(value, stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, result) {
+		t.Fatalf("unexpected stored query, expected %v got %v", result, stored)
+	}
+}
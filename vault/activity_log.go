@@ -20,7 +20,6 @@ import (
 	"unicode/utf8"
 
 	"github.com/axiomhq/hyperloglog"
-	"github.com/golang/protobuf/proto"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -86,6 +85,7 @@ const (
 	nonEntityTokenActivityType = "non-entity-token"
 	entityActivityType         = "entity"
 	secretSyncActivityType     = "secret-sync"
+	excludedActivityType       = "excluded"
 
 	// FeatureSecretSyncBilling will always be false
 	FeatureSecretSyncBilling = license.FeatureNone
@@ -151,6 +151,13 @@ type ActivityLog struct {
 	// Channel to stop background processing
 	doneCh chan struct{}
 
+	// Channel to spill clients to storage when the tracked clients exceed
+	// maxTrackedClients.
+	spillCh chan struct{}
+
+	// Channel to reschedule the end of the month when the clock is adjusted
+	clockAdjustedCh chan struct{}
+
 	// track metadata and contents of the most recent log segment
 	currentSegment segmentInfo
 
@@ -162,6 +169,15 @@ type ActivityLog struct {
 	defaultReportMonths int
 	retentionMonths     int
 
+	// dailyRetentionDays are the days the daily rollups of the clients are
+	// kept for. Zero disables daily rollups. Protected by l.
+	dailyRetentionDays int
+
+	// monthCloseWebhookURL and monthCloseEvent configure how the totals of
+	// a month are reported once its queries are finalized. Protected by l.
+	monthCloseWebhookURL string
+	monthCloseEvent      bool
+
 	// channel closed by delete worker when done
 	deleteDone chan struct{}
 
@@ -176,6 +192,47 @@ type ActivityLog struct {
 	// partialMonthClientTracker tracks active clients this month.  Protected by fragmentLock.
 	partialMonthClientTracker map[string]*activity.EntityRecord
 
+	// maxTrackedClients bounds the clients of the current month tracked in
+	// partialMonthClientTracker; the clients beyond are spilled to storage,
+	// and tracked in spilled. Zero is unbounded. Protected by fragmentLock.
+	maxTrackedClients int
+	spilled           *spilledClients
+
+	// spillLock serializes the spills of clients to storage.
+	spillLock sync.Mutex
+
+	// knownClients is the filter of the clients the active node counted this
+	// month, which performance standbys deduplicate their fragments against.
+	// knownClientsGeneration identifies the stored filter it was read from.
+	// Protected by fragmentLock.
+	knownClients           *clientBloomFilter
+	knownClientsGeneration string
+
+	// knownClientsWritten and knownClientsWrittenStart are the number of
+	// clients and the month of the filter last written by the active node.
+	// Protected by fragmentLock.
+	knownClientsWritten      int
+	knownClientsWrittenStart int64
+
+	// exclusionRules are the rules of the clients to exclude from billing.
+	// Protected by fragmentLock.
+	exclusionRules activityExclusionRules
+
+	// accessorMappings map the accessors of the auth mounts which replaced
+	// others to their mappings, for their entity clients to be identified as
+	// those of the replaced mounts. Protected by fragmentLock.
+	accessorMappings map[string]*activityAccessorMapping
+
+	// simulatedClients tracks the clients recorded by this node for the auth
+	// mounts in client count simulation, keyed by mount accessor. Protected
+	// by simulationLock.
+	simulatedClients map[string]map[string]struct{}
+	simulationLock   sync.Mutex
+
+	// monthZonesPtr are the time zones the boundaries of the months are
+	// computed in, from the configuration.
+	monthZonesPtr atomic.Pointer[activityMonthZones]
+
 	inprocessExport *atomic.Bool
 
 	// CensusReportDone is a channel used to signal tests upon successful calls
@@ -226,6 +283,9 @@ func NewActivityLog(core *Core, logger log.Logger, view *BarrierView, metrics me
 	}
 
 	clock := core.activityLogConfig.Clock
+	if clock == nil {
+		clock = core.clock
+	}
 	if clock == nil {
 		clock = timeutil.DefaultClock{}
 	}
@@ -238,6 +298,8 @@ func NewActivityLog(core *Core, logger log.Logger, view *BarrierView, metrics me
 		nodeID:                    hostname,
 		newFragmentCh:             make(chan struct{}, 1),
 		sendCh:                    make(chan struct{}, 1), // buffered so it can be triggered by fragment size
+		spillCh:                   make(chan struct{}, 1),
+		clockAdjustedCh:           make(chan struct{}, 1),
 		doneCh:                    make(chan struct{}, 1),
 		partialMonthClientTracker: make(map[string]*activity.EntityRecord),
 		CensusReportInterval:      time.Hour * 1,
@@ -271,6 +333,8 @@ func NewActivityLog(core *Core, logger log.Logger, view *BarrierView, metrics me
 		logger,
 		view.SubView(activityQueryBasePath),
 		config.RetentionMonths)
+	a.queryStore.SetMountAccessorResolver(a.precomputedQueryMountAccessor)
+	a.queryStore.SetMonthResolvers(a.monthOf, a.monthOfEnd)
 
 	return a, nil
 }
@@ -384,6 +448,12 @@ func (a *ActivityLog) saveCurrentSegmentToStorageLocked(ctx context.Context, for
 		return err
 	}
 
+	// The index is only used to look up the history of clients, so failing
+	// to update it does not fail the segment write.
+	if err := a.indexClients(ctx, a.currentSegment.startTimestamp, newEntities); err != nil {
+		a.logger.Warn("failed to index activity log clients", "error", err)
+	}
+
 	if available <= 0 {
 		if a.currentSegment.clientSequenceNumber >= activityLogMaxSegmentPerMonth {
 			// Cannot send as Warn because it will repeat too often,
@@ -440,7 +510,7 @@ func (a *ActivityLog) saveSegmentTokensInternal(ctx context.Context, currentSegm
 			a.logger.Info("storing nonzero token count")
 		}
 	}
-	tokenCount, err := proto.Marshal(a.currentSegment.tokenCount)
+	tokenCount, err := encodeActivitySegment(a.currentSegment.tokenCount)
 	if err != nil {
 		return "", err
 	}
@@ -471,7 +541,7 @@ func (a *ActivityLog) saveSegmentEntitiesInternal(ctx context.Context, currentSe
 	if len(currentSegment.currentClients.Clients) == 0 && !force {
 		return "", nil
 	}
-	clients, err := proto.Marshal(currentSegment.currentClients)
+	clients, err := encodeActivitySegment(currentSegment.currentClients)
 	if err != nil {
 		return entityPath, err
 	}
@@ -592,7 +662,7 @@ func (a *ActivityLog) WalkEntitySegments(ctx context.Context, startTime time.Tim
 		}
 
 		out := &activity.EntityActivityLog{}
-		err = proto.Unmarshal(raw.Value, out)
+		err = decodeActivitySegment(raw.Value, out)
 		if err != nil {
 			return fmt.Errorf("unable to parse segment %v%v: %w", basePath, path, err)
 		}
@@ -625,7 +695,7 @@ func (a *ActivityLog) WalkTokenSegments(ctx context.Context,
 			continue
 		}
 		out := &activity.TokenCount{}
-		err = proto.Unmarshal(raw.Value, out)
+		err = decodeActivitySegment(raw.Value, out)
 		if err != nil {
 			return fmt.Errorf("unable to parse token segment %v%v: %w", basePath, path, err)
 		}
@@ -647,7 +717,7 @@ func (a *ActivityLog) loadPriorEntitySegment(ctx context.Context, startTime time
 	}
 
 	out := &activity.EntityActivityLog{}
-	err = proto.Unmarshal(data.Value, out)
+	err = decodeActivitySegment(data.Value, out)
 	if err != nil {
 		return err
 	}
@@ -681,7 +751,7 @@ func (a *ActivityLog) loadCurrentClientSegment(ctx context.Context, startTime ti
 	}
 
 	out := &activity.EntityActivityLog{}
-	err = proto.Unmarshal(data.Value, out)
+	err = decodeActivitySegment(data.Value, out)
 	if err != nil {
 		return err
 	}
@@ -745,7 +815,7 @@ func (a *ActivityLog) loadTokenCount(ctx context.Context, startTime time.Time) e
 	}
 
 	out := &activity.TokenCount{}
-	err = proto.Unmarshal(data.Value, out)
+	err = decodeActivitySegment(data.Value, out)
 	if err != nil {
 		return err
 	}
@@ -793,7 +863,7 @@ func (a *ActivityLog) startNewCurrentLogLocked(now time.Time) {
 func (a *ActivityLog) newMonthCurrentLogLocked(currentTime time.Time) {
 	a.logger.Trace("continuing log to new month")
 	a.resetCurrentLog()
-	monthStart := timeutil.StartOfMonth(currentTime.UTC())
+	monthStart := a.startOfMonth(currentTime)
 	a.currentSegment.startTimestamp = monthStart.Unix()
 }
 
@@ -825,6 +895,7 @@ func (a *ActivityLog) resetCurrentLog() {
 
 	a.fragment = nil
 	a.partialMonthClientTracker = make(map[string]*activity.EntityRecord)
+	a.resetSpilledClientsLocked()
 
 	a.standbyFragmentsReceived = make([]*activity.LogFragment, 0)
 }
@@ -902,10 +973,11 @@ func (a *ActivityLog) refreshFromStoredLog(ctx context.Context, wg *sync.WaitGro
 	}
 
 	mostRecent := decreasingLogTimes[0]
+	mostRecentMonth, currentMonth := a.monthOf(mostRecent), a.monthOf(now)
 
 	if !a.enabled {
 		a.logger.Debug("activity log not enabled, skipping refresh from storage")
-		if !a.core.perfStandby && timeutil.IsCurrentMonth(mostRecent, now) {
+		if !a.core.perfStandby && timeutil.IsCurrentMonth(mostRecentMonth, currentMonth) {
 			a.logger.Debug("activity log is disabled, cleaning up logs for the current month")
 			go a.deleteLogWorker(ctx, mostRecent.Unix(), make(chan struct{}))
 		}
@@ -913,7 +985,7 @@ func (a *ActivityLog) refreshFromStoredLog(ctx context.Context, wg *sync.WaitGro
 		return nil
 	}
 
-	if timeutil.IsPreviousMonth(mostRecent, now) {
+	if timeutil.IsPreviousMonth(mostRecentMonth, currentMonth) {
 		// no activity logs to load for this month. if we are enabled, interpret
 		// it as having missed the rotation, so let it fall through and load
 		// if we missed generating the precomputed query, activeFragmentWorker()
@@ -925,7 +997,7 @@ func (a *ActivityLog) refreshFromStoredLog(ctx context.Context, wg *sync.WaitGro
 		// we can't do anything if the most recent log is in the future
 		a.logger.Warn("timestamp from log to load is in the future", "timestamp", mostRecent)
 		return nil
-	} else if !timeutil.IsCurrentMonth(mostRecent, now) {
+	} else if !timeutil.IsCurrentMonth(mostRecentMonth, currentMonth) {
 		// the most recent log in storage is 2+ months in the past
 
 		a.logger.Warn("most recent log in storage is 2 or more months in the past.", "timestamp", mostRecent)
@@ -992,8 +1064,15 @@ func (a *ActivityLog) SetConfigInit(config activityConfig) {
 		a.enabled = true
 	}
 
+	a.exclusionRules = config.ExclusionRules
+	a.maxTrackedClients = config.MaxTrackedClients
+	a.setMonthZones(config.TimeZones)
+
 	a.defaultReportMonths = config.DefaultReportMonths
 	a.retentionMonths = config.RetentionMonths
+	a.dailyRetentionDays = config.DailyRetentionDays
+	a.monthCloseWebhookURL = config.MonthCloseWebhookURL
+	a.monthCloseEvent = config.MonthCloseEvent
 
 	if a.retentionMonths < a.configOverrides.MinimumRetentionMonths {
 		a.retentionMonths = a.configOverrides.MinimumRetentionMonths
@@ -1009,6 +1088,8 @@ func (a *ActivityLog) SetConfig(ctx context.Context, config activityConfig) {
 	a.l.Lock()
 	defer a.l.Unlock()
 
+	a.core.invalidateResponseCache(responseCacheActivity, responseCacheActivityMonthly)
+
 	// enabled is protected by fragmentLock
 	a.fragmentLock.Lock()
 	originalEnabled := a.enabled
@@ -1025,6 +1106,15 @@ func (a *ActivityLog) SetConfig(ctx context.Context, config activityConfig) {
 		a.logger.Info("activity log enable changed", "original", originalEnabled, "current", a.enabled)
 	}
 
+	a.exclusionRules = config.ExclusionRules
+	a.maxTrackedClients = config.MaxTrackedClients
+	a.signalSpill()
+
+	if a.setMonthZones(config.TimeZones) {
+		a.logger.Info("activity log time zone changed", "time_zone", a.location().String())
+		a.clockAdjusted()
+	}
+
 	if !a.enabled && a.currentSegment.startTimestamp != 0 {
 		a.logger.Trace("deleting current segment")
 		a.deleteDone = make(chan struct{})
@@ -1056,6 +1146,9 @@ func (a *ActivityLog) SetConfig(ctx context.Context, config activityConfig) {
 
 	a.defaultReportMonths = config.DefaultReportMonths
 	a.retentionMonths = config.RetentionMonths
+	a.dailyRetentionDays = config.DailyRetentionDays
+	a.monthCloseWebhookURL = config.MonthCloseWebhookURL
+	a.monthCloseEvent = config.MonthCloseEvent
 	if a.retentionMonths < a.configOverrides.MinimumRetentionMonths {
 		a.retentionMonths = a.configOverrides.MinimumRetentionMonths
 	}
@@ -1085,7 +1178,11 @@ func (a *ActivityLog) SetConfigStandby(ctx context.Context, config activityConfi
 		a.logger.Info("activity log enable changed", "original", originalEnabled, "current", a.enabled)
 		a.resetCurrentLog()
 	}
+	a.exclusionRules = config.ExclusionRules
+	a.setMonthZones(config.TimeZones)
 	a.fragmentLock.Unlock()
+
+	a.dailyRetentionDays = config.DailyRetentionDays
 }
 
 func (a *ActivityLog) queriesAvailable(ctx context.Context) (bool, error) {
@@ -1131,6 +1228,9 @@ func (c *Core) setupActivityLogLocked(ctx context.Context, wg *sync.WaitGroup) e
 	if err != nil {
 		return err
 	}
+	if err := manager.loadAccessorMappings(manager.core.activeContext); err != nil {
+		return err
+	}
 
 	// Start the background worker, depending on type
 	// Lock already held here, can't use .PerfStandby()
@@ -1151,6 +1251,13 @@ func (c *Core) setupActivityLogLocked(ctx context.Context, wg *sync.WaitGroup) e
 			close(manager.computationWorkerDone)
 		}()
 
+		// Rewrite the segments of older versions, in the background
+		go func() {
+			if err := manager.segmentMigrationWorker(ctx); err != nil {
+				manager.logger.Warn("failed to migrate activity log segments", "error", err)
+			}
+		}()
+
 		// Catch up on garbage collection
 		// Signal when this is done so that unit tests can proceed.
 		manager.retentionDone = make(chan struct{})
@@ -1192,9 +1299,9 @@ func (a *ActivityLog) StartOfNextMonth() time.Time {
 	defer a.l.RUnlock()
 	var segmentStart time.Time
 	if a.currentSegment.startTimestamp == 0 {
-		segmentStart = a.clock.Now().UTC()
+		segmentStart = a.monthOf(a.clock.Now())
 	} else {
-		segmentStart = time.Unix(a.currentSegment.startTimestamp, 0).UTC()
+		segmentStart = a.monthOf(time.Unix(a.currentSegment.startTimestamp, 0))
 	}
 	// Basing this on the segment start will mean we trigger EOM rollover when
 	// necessary because we were down.
@@ -1217,6 +1324,10 @@ func (a *ActivityLog) perfStandbyFragmentWorker(ctx context.Context) {
 	sendFunc := func() {
 		ctx, cancel := context.WithTimeout(ctx, activityFragmentSendTimeout)
 		defer cancel()
+		if err := a.refreshKnownClients(ctx); err != nil {
+			a.logger.Warn("failed to refresh known clients, fragment not deduplicated", "error", err)
+		}
+		a.deduplicateFragment()
 		err := a.sendCurrentFragment(ctx)
 		if err != nil {
 			a.logger.Warn("activity log fragment lost", "error", err)
@@ -1275,13 +1386,15 @@ func (a *ActivityLog) perfStandbyFragmentWorker(ctx context.Context) {
 			// clear active entity set
 			a.fragmentLock.Lock()
 			a.partialMonthClientTracker = make(map[string]*activity.EntityRecord)
+			a.knownClients = nil
+			a.knownClientsGeneration = ""
 
 			a.fragmentLock.Unlock()
 
 			// Set timer for next month.
 			// The current segment *probably* hasn't been set yet (via invalidation),
 			// so don't rely on it.
-			target := timeutil.StartOfNextMonth(a.clock.Now().UTC())
+			target := timeutil.StartOfNextMonth(a.monthOf(a.clock.Now()))
 			endOfMonth.Reset(target.Sub(a.clock.Now()))
 		}
 	}
@@ -1309,6 +1422,17 @@ func (a *ActivityLog) activeFragmentWorker(ctx context.Context) {
 		if err != nil {
 			a.logger.Warn("activity log segment not saved, current fragment lost", "error", err)
 		}
+		if err := a.writeKnownClients(ctx); err != nil {
+			a.logger.Warn("known clients filter not saved", "error", err)
+		}
+	}
+
+	spillFunc := func() {
+		ctx, cancel := context.WithTimeout(ctx, activitySegmentWriteTimeout)
+		defer cancel()
+		if err := a.spillClients(ctx); err != nil {
+			a.logger.Warn("activity log clients not spilled to storage", "error", err)
+		}
 	}
 
 	// we modify the doneCh in some tests, so let's make sure we don't trip
@@ -1336,7 +1460,23 @@ func (a *ActivityLog) activeFragmentWorker(ctx context.Context) {
 			}
 			a.logger.Trace("writing segment on timer expiration")
 			writeFunc()
+		case <-a.spillCh:
+			a.logger.Trace("spilling clients over the tracked clients limit")
+			spillFunc()
+		case <-a.clockAdjustedCh:
+			if endOfMonthChannel == nil || a.configOverrides.DisableTimers {
+				continue
+			}
+			if !endOfMonth.Stop() {
+				select {
+				case <-endOfMonth.C:
+				default:
+				}
+			}
+			a.logger.Trace("rescheduling end of month on clock adjustment")
+			endOfMonth.Reset(a.StartOfNextMonth().Sub(a.clock.Now()))
 		case currentTime := <-endOfMonthChannel:
+			currentTime = timeutil.AdjustTime(a.clock, currentTime)
 			err := a.HandleEndOfMonth(ctx, currentTime.UTC())
 			if err != nil {
 				a.logger.Error("failed to perform end of month rotation", "error", err)
@@ -1358,6 +1498,14 @@ func (a *ActivityLog) activeFragmentWorker(ctx context.Context) {
 	}
 }
 
+// clockAdjusted reschedules the end of the month by the adjusted clock.
+func (a *ActivityLog) clockAdjusted() {
+	select {
+	case a.clockAdjustedCh <- struct{}{}:
+	default:
+	}
+}
+
 type ActivityIntentLog struct {
 	PreviousMonth int64 `json:"previous_month"`
 	NextMonth     int64 `json:"next_month"`
@@ -1419,7 +1567,7 @@ func (a *ActivityLog) HandleEndOfMonth(ctx context.Context, currentTime time.Tim
 // month processing, this will be the current time and should be in a different
 // month than the prevSegmentTimestamp
 func (a *ActivityLog) writeIntentLog(ctx context.Context, prevSegmentTimestamp int64, nextSegment time.Time) error {
-	nextSegmentTimestamp := timeutil.StartOfMonth(nextSegment.UTC()).Unix()
+	nextSegmentTimestamp := a.startOfMonth(nextSegment).Unix()
 
 	// Write out an intent log for the rotation with the current and new segment times.
 	intentLog := &ActivityIntentLog{
@@ -1505,6 +1653,11 @@ func (a *ActivityLog) AddActivityToFragment(clientID string, namespaceID string,
 		return
 	}
 
+	// Look up the clients spilled to storage before taking the write lock.
+	if a.spilledClient(clientID) {
+		return
+	}
+
 	// Update current fragment with new active entity
 	a.fragmentLock.Lock()
 	defer a.fragmentLock.Unlock()
@@ -1515,8 +1668,6 @@ func (a *ActivityLog) AddActivityToFragment(clientID string, namespaceID string,
 		return
 	}
 
-	a.createCurrentFragment()
-
 	clientRecord := &activity.EntityRecord{
 		ClientID:      clientID,
 		NamespaceID:   namespaceID,
@@ -1533,8 +1684,20 @@ func (a *ActivityLog) AddActivityToFragment(clientID string, namespaceID string,
 		clientRecord.NonEntity = true
 	}
 
+	// Performance standbys do not forward the clients the active node
+	// already counted this month.
+	if a.core.perfStandby && a.knownClient(clientID) {
+		a.partialMonthClientTracker[clientRecord.ClientID] = clientRecord
+		a.metrics.IncrCounterWithLabels([]string{"core", "activity", "fragment_deduplicated"},
+			1, []metricsutil.Label{})
+		return
+	}
+
+	a.createCurrentFragment()
+
 	a.fragment.Clients = append(a.fragment.Clients, clientRecord)
 	a.partialMonthClientTracker[clientRecord.ClientID] = clientRecord
+	a.signalSpill()
 }
 
 // Create the current fragment if it doesn't already exist.
@@ -1567,6 +1730,7 @@ func (a *ActivityLog) receivedFragment(fragment *activity.LogFragment) {
 	for _, e := range fragment.Clients {
 		a.partialMonthClientTracker[e.ClientID] = e
 	}
+	a.signalSpill()
 
 	a.standbyFragmentsReceived = append(a.standbyFragmentsReceived, fragment)
 
@@ -1580,6 +1744,7 @@ type ResponseCounts struct {
 	NonEntityClients int `json:"non_entity_clients" mapstructure:"non_entity_clients"`
 	Clients          int `json:"clients"`
 	SecretSyncs      int `json:"secret_syncs" mapstructure:"secret_syncs"`
+	ExcludedClients  int `json:"excluded_clients" mapstructure:"excluded_clients"`
 }
 
 // Add adds the new record's counts to the existing record
@@ -1593,6 +1758,7 @@ func (r *ResponseCounts) Add(newRecord *ResponseCounts) {
 	r.NonEntityClients += newRecord.NonEntityClients
 	r.NonEntityTokens += newRecord.NonEntityTokens
 	r.SecretSyncs += newRecord.SecretSyncs
+	r.ExcludedClients += newRecord.ExcludedClients
 }
 
 type ResponseNamespace struct {
@@ -1651,6 +1817,7 @@ func (c *Core) ActivityLogInjectResponse(ctx context.Context, pq *activity.Preco
 	if a == nil {
 		return errors.New("nil activity log")
 	}
+	c.invalidateResponseCache(responseCacheActivity, responseCacheActivityMonthly)
 	return a.queryStore.Put(ctx, pq)
 }
 
@@ -1676,21 +1843,23 @@ func (a *ActivityLog) handleQuery(ctx context.Context, startTime, endTime time.T
 	var computePartial bool
 
 	// Change the start time to the beginning of the month, and the end time to be the end
-	// of the month.
-	startTime = timeutil.StartOfMonth(startTime)
-	endTime = timeutil.EndOfMonth(endTime)
+	// of the month, in the time zone the months are reported in.
+	loc := a.location()
+	startTime = timeutil.StartOfMonth(startTime.In(loc))
+	endTime = timeutil.EndOfMonth(endTime.In(loc))
 
 	// If the endTime of the query is the current month, request data from the queryStore
 	// with the endTime equal to the end of the last month, and add in the current month
 	// data.
+	thisMonth := a.currentMonth()
 	precomputedQueryEndTime := endTime
-	if timeutil.IsCurrentMonth(endTime, a.clock.Now().UTC()) {
-		precomputedQueryEndTime = timeutil.EndOfMonth(timeutil.MonthsPreviousTo(1, timeutil.StartOfMonth(endTime)))
+	if timeutil.IsCurrentMonth(endTime, thisMonth) {
+		precomputedQueryEndTime = timeutil.EndOfMonth(timeutil.MonthsPreviousToInZone(1, timeutil.StartOfMonth(endTime)))
 		computePartial = true
 	}
 
 	pq := &activity.PrecomputedQuery{}
-	if startTime.After(precomputedQueryEndTime) && timeutil.IsCurrentMonth(startTime, a.clock.Now().UTC()) {
+	if startTime.After(precomputedQueryEndTime) && timeutil.IsCurrentMonth(startTime, thisMonth) {
 		// We're only calculating the partial month client count. Skip the precomputation
 		// get call.
 		pq = &activity.PrecomputedQuery{
@@ -1735,8 +1904,11 @@ func (a *ActivityLog) handleQuery(ctx context.Context, startTime, endTime time.T
 		// Traverse through current month's activitylog data and group clients
 		// into months and namespaces
 		a.fragmentLock.RLock()
-		partialByMonth, partialByNamespace = a.populateNamespaceAndMonthlyBreakdowns()
+		partialByMonth, partialByNamespace, err = a.populateNamespaceAndMonthlyBreakdowns(ctx)
 		a.fragmentLock.RUnlock()
+		if err != nil {
+			return nil, err
+		}
 
 		// Convert the byNamespace breakdowns into structs that are
 		// consumable by the /activity endpoint, so as to reuse code between these two
@@ -1821,6 +1993,16 @@ func (a *ActivityLog) handleQuery(ctx context.Context, startTime, endTime time.T
 	responseData["by_namespace"] = byNamespaceResponse
 	totalCounts.Add(totalCurrentCounts)
 	totalCounts.DistinctEntities = distinctEntitiesResponse
+
+	// Add the externally supplied counts of the period, such as those of
+	// clusters merged into this one
+	baselines, err := a.addBaselines(ctx, totalCounts, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(baselines) > 0 {
+		responseData["baselines"] = baselines
+	}
 	responseData["total"] = totalCounts
 
 	// Create and populate the month response structs based on the monthly breakdown.
@@ -1845,9 +2027,10 @@ func (a *ActivityLog) modifyResponseMonths(months []*ResponseMonth, start time.T
 	if len(months) == 0 {
 		return months
 	}
-	start = timeutil.StartOfMonth(start)
-	end = timeutil.EndOfMonth(end)
-	if timeutil.IsCurrentMonth(end, a.clock.Now().UTC()) {
+	loc := a.location()
+	start = timeutil.StartOfMonth(start.In(loc))
+	end = timeutil.EndOfMonth(end.In(loc))
+	if timeutil.IsCurrentMonth(end, a.currentMonth()) {
 		end = timeutil.EndOfMonth(timeutil.StartOfMonth(end).AddDate(0, -1, 0))
 	}
 	modifiedResponseMonths := make([]*ResponseMonth, 0)
@@ -1855,8 +2038,9 @@ func (a *ActivityLog) modifyResponseMonths(months []*ResponseMonth, start time.T
 	if err != nil {
 		return months
 	}
+	firstMonth = firstMonth.In(loc)
 	for start.Before(firstMonth) && !timeutil.IsCurrentMonth(start, firstMonth) {
-		monthPlaceholder := &ResponseMonth{Timestamp: start.UTC().Format(time.RFC3339)}
+		monthPlaceholder := &ResponseMonth{Timestamp: start.Format(time.RFC3339)}
 		modifiedResponseMonths = append(modifiedResponseMonths, monthPlaceholder)
 		start = timeutil.StartOfMonth(start.AddDate(0, 1, 0))
 	}
@@ -1865,10 +2049,10 @@ func (a *ActivityLog) modifyResponseMonths(months []*ResponseMonth, start time.T
 	if err != nil {
 		return modifiedResponseMonths
 	}
-	lastMonth := timeutil.EndOfMonth(lastMonthStart)
+	lastMonth := timeutil.EndOfMonth(lastMonthStart.In(loc))
 	for lastMonth.Before(end) && !timeutil.IsCurrentMonth(end, lastMonth) {
 		lastMonth = timeutil.StartOfMonth(lastMonth).AddDate(0, 1, 0)
-		monthPlaceholder := &ResponseMonth{Timestamp: lastMonth.UTC().Format(time.RFC3339)}
+		monthPlaceholder := &ResponseMonth{Timestamp: lastMonth.Format(time.RFC3339)}
 		modifiedResponseMonths = append(modifiedResponseMonths, monthPlaceholder)
 
 		// reset lastMonth to be the end of the month so we can make an apt comparison
@@ -1891,6 +2075,32 @@ type activityConfig struct {
 	Enabled string `json:"enabled"`
 
 	CensusReportInterval time.Duration `json:"census_report_interval"`
+
+	// ExclusionRules mark the matching clients as excluded: they are tracked
+	// apart, and are not billable.
+	ExclusionRules activityExclusionRules `json:"exclusion_rules"`
+
+	// MaxTrackedClients bounds the clients of the current month the active
+	// node tracks in memory; the clients beyond are spilled to storage. The
+	// zero value is unbounded.
+	MaxTrackedClients int `json:"max_tracked_clients"`
+
+	// DailyRetentionDays are the days the daily rollups of the clients are
+	// kept for. The zero value disables daily rollups.
+	DailyRetentionDays int `json:"daily_retention_days,omitempty"`
+
+	// MonthCloseWebhookURL is POSTed the totals of each month, per namespace,
+	// once its queries are finalized.
+	MonthCloseWebhookURL string `json:"month_close_webhook_url,omitempty"`
+
+	// MonthCloseEvent sends the totals of each month as events once its
+	// queries are finalized.
+	MonthCloseEvent bool `json:"month_close_event,omitempty"`
+
+	// TimeZones are the time zones the boundaries of the months are computed
+	// in, in the order they were set. Months are computed in UTC before the
+	// first one, and when unset.
+	TimeZones []activityTimeZone `json:"time_zones,omitempty"`
 }
 
 func defaultActivityConfig() activityConfig {
@@ -1962,6 +2172,23 @@ func (a *ActivityLog) HandleTokenUsage(ctx context.Context, entry *logical.Token
 		mountAccessor = mountEntry.Accessor
 	}
 
+	// Clients of auth mounts being simulated are recorded apart, and are not
+	// counted
+	if mountEntry != nil && mountEntry.Config.clientCountSimulationActive(a.clock.Now()) {
+		return a.recordSimulatedClient(ctx, mountEntry.Accessor, clientID)
+	}
+
+	if a.clientExcluded(entry, mountAccessor) {
+		a.AddActivityToFragment(clientID, entry.NamespaceID, entry.CreationTime, excludedActivityType, mountAccessor)
+		return nil
+	}
+
+	// Entities of auth mounts which replaced others are identified as those of
+	// the replaced mounts, not to be counted again
+	if !isTWE && entry.EntityID != "" {
+		clientID = a.reidentifyClient(clientID, mountAccessor)
+	}
+
 	// Parse an entry's client ID and add it to the activity log
 	a.AddClientToFragment(clientID, entry.NamespaceID, entry.CreationTime, isTWE, mountAccessor)
 	return nil
@@ -2043,6 +2270,7 @@ func (p *processCounts) toCountsRecord() *activity.CountsRecord {
 		EntityClients:    p.countByType(entityActivityType),
 		NonEntityClients: p.countByType(nonEntityTokenActivityType),
 		SecretSyncs:      p.countByType(secretSyncActivityType),
+		ExcludedClients:  p.countByType(excludedActivityType),
 	}
 }
 
@@ -2206,16 +2434,16 @@ func processClientRecord(e *activity.EntityRecord, byNamespace summaryByNamespac
 func (a *ActivityLog) handleEntitySegment(l *activity.EntityActivityLog, segmentTime time.Time, hll *hyperloglog.Sketch, opts pqOptions) error {
 	for _, e := range l.Clients {
 
-		processClientRecord(e, opts.byNamespace, opts.byMonth, segmentTime)
+		processClientRecord(e, opts.byNamespace, opts.byMonth, a.startOfMonth(segmentTime))
 		hll.Insert([]byte(e.ClientID))
 
 		// step forward in time through the months to check if the client is
 		// present. If it is, delete it. This is because the client should only
 		// be reported as new in the earliest month that it was seen
-		finalMonth := timeutil.StartOfMonth(opts.activePeriodEnd).UTC()
-		for currMonth := timeutil.StartOfMonth(segmentTime).UTC(); currMonth.Before(finalMonth); currMonth = timeutil.StartOfNextMonth(currMonth).UTC() {
+		finalMonth := a.startOfMonth(opts.activePeriodEnd)
+		for currMonth := a.startOfMonth(segmentTime); currMonth.Before(finalMonth); currMonth = a.startOfNextMonth(currMonth) {
 			// Invalidate the client from being a new client in the next month
-			next := timeutil.StartOfNextMonth(currMonth).UTC().Unix()
+			next := a.startOfNextMonth(currMonth).Unix()
 			if _, present := opts.byMonth[next]; present {
 				// delete from the new clients map for the next month
 				// this will handle deleting from the per-namespace and per-mount maps of NewClients
@@ -2239,6 +2467,7 @@ func (a *ActivityLog) breakdownTokenSegment(l *activity.TokenCount, byNamespace
 
 func (a *ActivityLog) writePrecomputedQuery(ctx context.Context, segmentTime time.Time, opts pqOptions) error {
 	pq := &activity.PrecomputedQuery{
+		Version:   activity.PrecomputedQueryCurrentVersion,
 		StartTime: segmentTime,
 		EndTime:   opts.endTime,
 	}
@@ -2251,6 +2480,7 @@ func (a *ActivityLog) writePrecomputedQuery(ctx context.Context, segmentTime tim
 	if err != nil {
 		a.logger.Warn("failed to store precomputed query", "error", err)
 	}
+	a.core.invalidateResponseCache(responseCacheActivity, responseCacheActivityMonthly)
 	return nil
 }
 
@@ -2427,7 +2657,7 @@ func (a *ActivityLog) precomputedQueryWorker(ctx context.Context) error {
 	currentMonth := a.currentSegment.startTimestamp
 	// Base retention period on the month we are generating (even in the past)--- a.clock.Now()
 	// would work but this will be easier to control in tests.
-	retentionWindow := timeutil.MonthsPreviousTo(a.retentionMonths, time.Unix(intent.NextMonth, 0).UTC())
+	retentionWindow := timeutil.MonthsPreviousToInZone(a.retentionMonths, a.monthOf(time.Unix(intent.NextMonth, 0)))
 	a.l.RUnlock()
 	if currentMonth != 0 && intent.NextMonth != currentMonth {
 		a.logger.Warn("intent log does not match current segment",
@@ -2457,8 +2687,10 @@ func (a *ActivityLog) precomputedQueryWorker(ctx context.Context) error {
 	byNamespace := make(map[string]*processByNamespace)
 	byMonth := make(map[int64]*processMonth)
 
-	endTime := timeutil.EndOfMonth(time.Unix(lastMonth, 0).UTC())
-	activePeriodStart := timeutil.MonthsPreviousTo(a.defaultReportMonths, endTime)
+	// The month ends when the next month starts, which may be in another
+	// time zone
+	endTime := a.startOfNextMonth(time.Unix(lastMonth, 0)).Add(-time.Second)
+	activePeriodStart := a.startOfMonthsPrevious(a.defaultReportMonths, time.Unix(lastMonth, 0)).UTC()
 	// If not enough data, report as much as we have in the window
 	if activePeriodStart.Before(times[len(times)-1]) {
 		activePeriodStart = times[len(times)-1]
@@ -2475,9 +2707,13 @@ func (a *ActivityLog) precomputedQueryWorker(ctx context.Context) error {
 	for _, startTime := range times {
 		// Do not work back further than the current retention window,
 		// which will just get deleted anyway.
-		if startTime.Before(retentionWindow) {
+		if a.monthOf(startTime).Before(retentionWindow) {
 			break
 		}
+		// Precomputing can wait for memory pressure to be relieved
+		if err := a.core.waitForMemoryPressureRelief(ctx); err != nil {
+			return err
+		}
 		reader, err := a.NewSegmentFileReader(ctx, startTime)
 		if err != nil {
 			return err
@@ -2488,6 +2724,16 @@ func (a *ActivityLog) precomputedQueryWorker(ctx context.Context) error {
 		}
 	}
 
+	// Roll up the clients of the month by day, alongside the queries
+	if err := a.writeDailyRollup(ctx, time.Unix(lastMonth, 0)); err != nil {
+		a.logger.Warn("failed to write daily rollup", "month", time.Unix(lastMonth, 0).UTC(), "error", err)
+	}
+
+	// Report the totals of the month now that its queries are final
+	if err := a.notifyMonthClosed(ctx, time.Unix(lastMonth, 0), endTime); err != nil {
+		a.logger.Error("failed to report the closed month", "month", time.Unix(lastMonth, 0).UTC(), "error", err)
+	}
+
 	// delete the intent log
 	a.view.Delete(ctx, activityIntentLogKey)
 
@@ -2527,7 +2773,7 @@ func (a *ActivityLog) retentionWorker(ctx context.Context, currentTime time.Time
 	}()
 
 	// everything >= the threshold is OK
-	retentionThreshold := timeutil.MonthsPreviousTo(retentionMonths, currentTime)
+	retentionThreshold := timeutil.MonthsPreviousToInZone(retentionMonths, a.monthOf(currentTime))
 
 	available, err := a.availableLogs(ctx)
 	if err != nil {
@@ -2536,12 +2782,16 @@ func (a *ActivityLog) retentionWorker(ctx context.Context, currentTime time.Time
 	}
 	for _, t := range available {
 		// One at a time seems OK
-		if t.Before(retentionThreshold) {
+		if a.monthOf(t).Before(retentionThreshold) {
 			a.logger.Trace("deleting segments", "startTime", t)
 			a.deleteLogWorker(ctx, t.Unix(), make(chan struct{}))
 		}
 	}
 
+	if err := a.deleteDailyRollups(ctx, currentTime); err != nil {
+		a.logger.Warn("deletion of daily rollups failed", "error", err)
+	}
+
 	if a.queryStore != nil {
 		err = a.queryStore.DeleteQueriesBefore(ctx, retentionThreshold)
 		if err != nil {
@@ -2564,6 +2814,9 @@ func (a *ActivityLog) PartialMonthMetrics(ctx context.Context) ([]metricsutil.Ga
 		return []metricsutil.GaugeLabelValues{}, nil
 	}
 	count := len(a.partialMonthClientTracker)
+	if a.spilled != nil {
+		count += a.spilled.count
+	}
 
 	return []metricsutil.GaugeLabelValues{
 		{
@@ -2585,14 +2838,17 @@ func (c *Core) activeEntityGaugeCollector(ctx context.Context) ([]metricsutil.Ga
 
 // populateNamespaceAndMonthlyBreakdowns traverses the partial month data
 // stored in memory and groups them by months and namespaces.
-func (a *ActivityLog) populateNamespaceAndMonthlyBreakdowns() (map[int64]*processMonth, map[string]*processByNamespace) {
+func (a *ActivityLog) populateNamespaceAndMonthlyBreakdowns(ctx context.Context) (map[int64]*processMonth, map[string]*processByNamespace, error) {
 	// Parse the monthly clients and prepare the breakdowns.
 	byNamespace := make(map[string]*processByNamespace)
 	byMonth := make(map[int64]*processMonth)
 	for _, e := range a.partialMonthClientTracker {
-		processClientRecord(e, byNamespace, byMonth, a.clock.Now())
+		processClientRecord(e, byNamespace, byMonth, a.startOfMonth(a.clock.Now()))
+	}
+	if err := a.addSpilledClientBreakdowns(ctx, byMonth, byNamespace); err != nil {
+		return nil, nil, err
 	}
-	return byMonth, byNamespace
+	return byMonth, byNamespace, nil
 }
 
 // transformMonthBreakdowns converts a map of unix timestamp -> processMonth to
@@ -2606,8 +2862,9 @@ func (a *ActivityLog) transformMonthBreakdowns(byMonth map[int64]*processMonth)
 			mountRecord := make([]*activity.MountRecord, 0, len(nsMap[nsID].Mounts))
 			for mountAccessor, mountData := range nsMap[nsID].Mounts {
 				mountRecord = append(mountRecord, &activity.MountRecord{
-					MountPath: a.mountAccessorToMountPath(mountAccessor),
-					Counts:    mountData.Counts.toCountsRecord(),
+					MountPath:     a.mountAccessorToMountPath(mountAccessor),
+					MountAccessor: mountAccessor,
+					Counts:        mountData.Counts.toCountsRecord(),
 				})
 			}
 
@@ -2628,12 +2885,14 @@ func (a *ActivityLog) transformMonthBreakdowns(byMonth map[int64]*processMonth)
 		}
 
 		// Process all the months
-		monthly = append(monthly, &activity.MonthRecord{
+		month := &activity.MonthRecord{
 			Timestamp:  timestamp,
 			Counts:     monthData.Counts.toCountsRecord(),
 			Namespaces: processByNamespaces(monthData.Namespaces),
 			NewClients: newClientRecord,
-		})
+		}
+		month.Mounts = activity.MonthlyMounts(month)
+		monthly = append(monthly, month)
 	}
 	return monthly
 }
@@ -2697,7 +2956,7 @@ func (a *ActivityLog) prepareMonthsResponseForQuery(ctx context.Context, byMonth
 		}
 
 		monthResponse := &ResponseMonth{
-			Timestamp: time.Unix(monthsRecord.Timestamp, 0).UTC().Format(time.RFC3339),
+			Timestamp: a.monthOf(time.Unix(monthsRecord.Timestamp, 0)).Format(time.RFC3339),
 		}
 		if monthsRecord.Counts.HasCounts() {
 			nsResponse, err := a.prepareNamespaceResponse(ctx, monthsRecord.Namespaces)
@@ -2773,7 +3032,10 @@ func (a *ActivityLog) partialMonthClientCount(ctx context.Context) (map[string]i
 
 	// Traverse through current month's activitylog data and group clients
 	// into months and namespaces
-	byMonth, byNamespace := a.populateNamespaceAndMonthlyBreakdowns()
+	byMonth, byNamespace, err := a.populateNamespaceAndMonthlyBreakdowns(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert the byNamespace breakdowns into structs that are
 	// consumable by the /activity endpoint, so as to reuse code between these two
@@ -2799,6 +3061,7 @@ func (a *ActivityLog) partialMonthClientCount(ctx context.Context) (map[string]i
 	responseData["non_entity_clients"] = totalCounts.NonEntityClients
 	responseData["clients"] = totalCounts.Clients
 	responseData["secret_syncs"] = totalCounts.SecretSyncs
+	responseData["excluded_clients"] = totalCounts.ExcludedClients
 
 	// The partialMonthClientCount should not have more than one month worth of data.
 	// If it does, something has gone wrong and we should warn that the activity log data
@@ -2838,6 +3101,18 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 	}
 	defer a.inprocessExport.Store(false)
 
+	return a.exportSegments(ctx, rw, format, startTime, endTime, activityExportFilter{})
+}
+
+// exportSegments writes the clients of the segments between the start and
+// end months which match the filter to rw. It only reads from storage, so it
+// can run on standbys.
+func (a *ActivityLog) exportSegments(ctx context.Context, rw http.ResponseWriter, format string, startTime, endTime time.Time, filter activityExportFilter) error {
+	match, err := filter.matcher(ctx, a.core)
+	if err != nil {
+		return err
+	}
+
 	// Find the months with activity log data that are between the start and end
 	// months. We want to walk this in cronological order so the oldest instance of a
 	// client usage is recorded, not the most recent.
@@ -2886,7 +3161,8 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 		return fmt.Errorf("invalid format: %s", format)
 	}
 
-	a.logger.Info("starting activity log export", "start_time", startTime, "end_time", endTime, "format", format)
+	a.logger.Info("starting activity log export", "start_time", startTime, "end_time", endTime, "format", format,
+		"namespace", filter.NamespacePath, "client_types", filter.ClientTypes, "mount_accessors", filter.MountAccessors)
 
 	dedupedIds := make(map[string]struct{})
 
@@ -2895,9 +3171,18 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 			if _, ok := dedupedIds[e.ClientID]; ok {
 				continue
 			}
+			// Filter before deduplicating, so that the earliest activity
+			// of a client matching the filter is exported
+			matches, err := match(e)
+			if err != nil {
+				return err
+			}
+			if !matches {
+				continue
+			}
 
 			dedupedIds[e.ClientID] = struct{}{}
-			err := encoder.Encode(e)
+			err = encoder.Encode(e)
 			if err != nil {
 				return err
 			}
@@ -2927,6 +3212,35 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 	return nil
 }
 
+// activityExportInput is the input of the offloaded activity export.
+type activityExportInput struct {
+	Format    string               `json:"format"`
+	StartTime time.Time            `json:"start_time"`
+	EndTime   time.Time            `json:"end_time"`
+	Filter    activityExportFilter `json:"filter"`
+}
+
+// offloadActivityExport renders an activity export. The activity log is not
+// set up on standbys, so they read the segments from storage directly.
+func offloadActivityExport(ctx context.Context, c *Core, input json.RawMessage, rw http.ResponseWriter) error {
+	var in activityExportInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return err
+	}
+
+	c.activityLogLock.RLock()
+	a := c.activityLog
+	c.activityLogLock.RUnlock()
+	if a == nil {
+		a = &ActivityLog{
+			core:   c,
+			logger: c.baseLogger.Named("activity"),
+			view:   c.systemBarrierView.SubView(activitySubPath),
+		}
+	}
+	return a.exportSegments(ctx, rw, in.Format, in.StartTime, in.EndTime, in.Filter)
+}
+
 type encoder interface {
 	Encode(*activity.EntityRecord) error
 	Flush()
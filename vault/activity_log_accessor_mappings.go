@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// activityAccessorMappingsBasePath is where the mappings of the accessors of
+// replaced auth mounts are stored, by old accessor.
+const activityAccessorMappingsBasePath = "accessormappings/"
+
+// activityAccessorMapping maps the accessor of an auth mount which was
+// remounted or recreated to the accessor of the mount replacing it. Until the
+// mapping expires, an entity logging in through the new mount is counted as
+// the entity its alias had on the old mount, if any, for the same humans not
+// to be counted twice in the billing period.
+type activityAccessorMapping struct {
+	OldAccessor string `json:"old_accessor"`
+	NewAccessor string `json:"new_accessor"`
+
+	ExpirationTime time.Time `json:"expiration_time"`
+
+	// CreatedBy is the display name of the token which registered the mapping.
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+func (m *activityAccessorMapping) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"old_accessor":    m.OldAccessor,
+		"new_accessor":    m.NewAccessor,
+		"expiration_time": m.ExpirationTime.Format(time.RFC3339),
+		"created_by":      m.CreatedBy,
+		"created_time":    m.CreatedTime.Format(time.RFC3339),
+	}
+}
+
+func (a *ActivityLog) getAccessorMapping(ctx context.Context, oldAccessor string) (*activityAccessorMapping, error) {
+	entry, err := a.view.Get(ctx, activityAccessorMappingsBasePath+oldAccessor)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	var mapping activityAccessorMapping
+	if err := entry.DecodeJSON(&mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode accessor mapping %q: %w", oldAccessor, err)
+	}
+	return &mapping, nil
+}
+
+// listAccessorMappings returns the accessor mappings, sorted by old accessor.
+func (a *ActivityLog) listAccessorMappings(ctx context.Context) ([]*activityAccessorMapping, error) {
+	keys, err := a.view.List(ctx, activityAccessorMappingsBasePath)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	mappings := make([]*activityAccessorMapping, 0, len(keys))
+	for _, key := range keys {
+		mapping, err := a.getAccessorMapping(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if mapping != nil {
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings, nil
+}
+
+// putAccessorMapping stores the mapping, and applies it to the clients seen
+// from then on.
+func (a *ActivityLog) putAccessorMapping(ctx context.Context, mapping *activityAccessorMapping) error {
+	entry, err := logical.StorageEntryJSON(activityAccessorMappingsBasePath+mapping.OldAccessor, mapping)
+	if err != nil {
+		return err
+	}
+	if err := a.view.Put(ctx, entry); err != nil {
+		return err
+	}
+	return a.loadAccessorMappings(ctx)
+}
+
+func (a *ActivityLog) deleteAccessorMapping(ctx context.Context, oldAccessor string) error {
+	if err := a.view.Delete(ctx, activityAccessorMappingsBasePath+oldAccessor); err != nil {
+		return err
+	}
+	return a.loadAccessorMappings(ctx)
+}
+
+// loadAccessorMappings loads the stored accessor mappings, indexed by new
+// accessor.
+func (a *ActivityLog) loadAccessorMappings(ctx context.Context) error {
+	mappings, err := a.listAccessorMappings(ctx)
+	if err != nil {
+		return err
+	}
+	byNewAccessor := make(map[string]*activityAccessorMapping, len(mappings))
+	for _, mapping := range mappings {
+		byNewAccessor[mapping.NewAccessor] = mapping
+	}
+
+	a.fragmentLock.Lock()
+	a.accessorMappings = byNewAccessor
+	a.fragmentLock.Unlock()
+	return nil
+}
+
+// reidentifyClient returns the ID of the entity the given entity had on the
+// auth mount replaced by the one of the given accessor, if a mapping of the
+// accessor applies, and the given entity ID otherwise.
+func (a *ActivityLog) reidentifyClient(entityID, mountAccessor string) string {
+	a.fragmentLock.RLock()
+	mapping, ok := a.accessorMappings[mountAccessor]
+	a.fragmentLock.RUnlock()
+	if !ok || !a.clock.Now().Before(mapping.ExpirationTime) {
+		return entityID
+	}
+
+	identityStore := a.core.identityStore
+	if identityStore == nil {
+		return entityID
+	}
+	entity, err := identityStore.MemDBEntityByID(entityID, false)
+	if err != nil || entity == nil {
+		return entityID
+	}
+	for _, alias := range entity.Aliases {
+		if alias.MountAccessor != mountAccessor {
+			continue
+		}
+		oldAlias, err := identityStore.MemDBAliasByFactors(mapping.OldAccessor, alias.Name, false, false)
+		if err != nil {
+			a.logger.Debug("failed to look up alias of replaced auth mount", "accessor", mapping.OldAccessor, "error", err)
+			return entityID
+		}
+		if oldAlias != nil && oldAlias.CanonicalID != "" {
+			return oldAlias.CanonicalID
+		}
+		break
+	}
+	return entityID
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// activityBaselinesBasePath is where the client count baselines are stored,
+// by name.
+const activityBaselinesBasePath = "baselines/"
+
+// activityBaseline is a client count supplied from outside of the activity
+// log, such as the count of a cluster merged into this one, which is added to
+// the totals reported for the periods it overlaps.
+type activityBaseline struct {
+	Name string `json:"name"`
+
+	// Source and Description record the provenance of the counts.
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+
+	EntityClients    int `json:"entity_clients"`
+	NonEntityClients int `json:"non_entity_clients"`
+	SecretSyncs      int `json:"secret_syncs"`
+
+	// CreatedBy is the display name of the token which recorded the counts.
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+// counts returns the counts of the baseline, to be added to reported totals.
+func (b *activityBaseline) counts() *ResponseCounts {
+	return &ResponseCounts{
+		DistinctEntities: b.EntityClients,
+		EntityClients:    b.EntityClients,
+		NonEntityTokens:  b.NonEntityClients,
+		NonEntityClients: b.NonEntityClients,
+		SecretSyncs:      b.SecretSyncs,
+		Clients:          b.EntityClients + b.NonEntityClients + b.SecretSyncs,
+	}
+}
+
+// overlaps returns whether the period of the baseline overlaps the period
+// from start to end.
+func (b *activityBaseline) overlaps(start, end time.Time) bool {
+	return b.StartTime.Before(end) && b.EndTime.After(start)
+}
+
+// provenance returns the description of the baseline reported along with the
+// totals it is added to.
+func (b *activityBaseline) provenance() map[string]interface{} {
+	return map[string]interface{}{
+		"name":               b.Name,
+		"source":             b.Source,
+		"description":        b.Description,
+		"start_time":         b.StartTime.Format(time.RFC3339),
+		"end_time":           b.EndTime.Format(time.RFC3339),
+		"entity_clients":     b.EntityClients,
+		"non_entity_clients": b.NonEntityClients,
+		"secret_syncs":       b.SecretSyncs,
+		"created_by":         b.CreatedBy,
+		"created_time":       b.CreatedTime.Format(time.RFC3339),
+	}
+}
+
+func (a *ActivityLog) putBaseline(ctx context.Context, baseline *activityBaseline) error {
+	entry, err := logical.StorageEntryJSON(activityBaselinesBasePath+baseline.Name, baseline)
+	if err != nil {
+		return err
+	}
+	return a.view.Put(ctx, entry)
+}
+
+func (a *ActivityLog) getBaseline(ctx context.Context, name string) (*activityBaseline, error) {
+	entry, err := a.view.Get(ctx, activityBaselinesBasePath+name)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	var baseline activityBaseline
+	if err := entry.DecodeJSON(&baseline); err != nil {
+		return nil, fmt.Errorf("failed to decode client count baseline %q: %w", name, err)
+	}
+	return &baseline, nil
+}
+
+func (a *ActivityLog) deleteBaseline(ctx context.Context, name string) error {
+	return a.view.Delete(ctx, activityBaselinesBasePath+name)
+}
+
+// listBaselines returns the baselines, sorted by start time.
+func (a *ActivityLog) listBaselines(ctx context.Context) ([]*activityBaseline, error) {
+	names, err := a.view.List(ctx, activityBaselinesBasePath)
+	if err != nil {
+		return nil, err
+	}
+	baselines := make([]*activityBaseline, 0, len(names))
+	for _, name := range names {
+		baseline, err := a.getBaseline(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if baseline != nil {
+			baselines = append(baselines, baseline)
+		}
+	}
+	sort.Slice(baselines, func(i, j int) bool {
+		return baselines[i].StartTime.Before(baselines[j].StartTime)
+	})
+	return baselines, nil
+}
+
+// addBaselines adds the counts of the baselines overlapping the period from
+// start to end to the totals, and returns their provenance.
+func (a *ActivityLog) addBaselines(ctx context.Context, totals *ResponseCounts, start, end time.Time) ([]map[string]interface{}, error) {
+	baselines, err := a.listBaselines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var provenance []map[string]interface{}
+	for _, baseline := range baselines {
+		if !baseline.overlaps(start, end) {
+			continue
+		}
+		totals.Add(baseline.counts())
+		provenance = append(provenance, baseline.provenance())
+	}
+	return provenance, nil
+}
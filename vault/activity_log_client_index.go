@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/activity"
+)
+
+const (
+	// activityClientIndexBasePath is where the index of the months each
+	// client was counted in is stored, under a shard of the client ID hash.
+	activityClientIndexBasePath = "clientindex/"
+
+	// activityClientIndexShardChars is the number of hex characters of the
+	// client ID hash naming the shard.
+	activityClientIndexShardChars = 4
+)
+
+// clientIndexEntry records a month a client was counted in, with the
+// namespace and mount it was first seen on that month.
+type clientIndexEntry struct {
+	Month         int64  `json:"month"`
+	Timestamp     int64  `json:"timestamp"`
+	NamespaceID   string `json:"namespace_id"`
+	MountAccessor string `json:"mount_accessor"`
+	ClientType    string `json:"client_type"`
+}
+
+func activityClientIndexPath(clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return activityClientIndexBasePath + hex.EncodeToString(sum[:])[:activityClientIndexShardChars]
+}
+
+func (a *ActivityLog) readClientIndexShard(ctx context.Context, path string) (map[string][]*clientIndexEntry, error) {
+	shard := make(map[string][]*clientIndexEntry)
+	entry, err := a.view.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return shard, nil
+	}
+	if err := entry.DecodeJSON(&shard); err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// indexClients adds the month to the index entries of the clients written
+// to a segment of the month, and drops the months out of the retention
+// period from the shards it rewrites. Must be called with l held.
+func (a *ActivityLog) indexClients(ctx context.Context, month int64, clients map[string]*activity.EntityRecord) error {
+	if len(clients) == 0 {
+		return nil
+	}
+	retentionThreshold := a.startOfMonthsPrevious(a.retentionMonths, a.clock.Now()).Unix()
+
+	byShard := make(map[string][]*activity.EntityRecord)
+	for _, client := range clients {
+		path := activityClientIndexPath(client.ClientID)
+		byShard[path] = append(byShard[path], client)
+	}
+
+	for path, records := range byShard {
+		shard, err := a.readClientIndexShard(ctx, path)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			entries := shard[record.ClientID]
+			seen := false
+			for _, entry := range entries {
+				if entry.Month == month {
+					seen = true
+					break
+				}
+			}
+			if !seen {
+				shard[record.ClientID] = append(entries, &clientIndexEntry{
+					Month:         month,
+					Timestamp:     record.Timestamp,
+					NamespaceID:   record.NamespaceID,
+					MountAccessor: record.MountAccessor,
+					ClientType:    getClientType(record),
+				})
+			}
+		}
+		for clientID, entries := range shard {
+			retained := entries[:0]
+			for _, entry := range entries {
+				if entry.Month >= retentionThreshold {
+					retained = append(retained, entry)
+				}
+			}
+			if len(retained) == 0 {
+				delete(shard, clientID)
+			} else {
+				shard[clientID] = retained
+			}
+		}
+
+		if len(shard) == 0 {
+			if err := a.view.Delete(ctx, path); err != nil {
+				return err
+			}
+			continue
+		}
+		entry, err := logical.StorageEntryJSON(path, shard)
+		if err != nil {
+			return err
+		}
+		if err := a.view.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clientActivityHistory returns the months the client was counted in,
+// within the retention period, oldest first.
+func (a *ActivityLog) clientActivityHistory(ctx context.Context, clientID string) ([]*clientIndexEntry, error) {
+	shard, err := a.readClientIndexShard(ctx, activityClientIndexPath(clientID))
+	if err != nil {
+		return nil, err
+	}
+
+	a.l.RLock()
+	retentionMonths := a.retentionMonths
+	a.l.RUnlock()
+	retentionThreshold := a.startOfMonthsPrevious(retentionMonths, a.clock.Now()).Unix()
+
+	var history []*clientIndexEntry
+	for _, entry := range shard[clientID] {
+		if entry.Month >= retentionThreshold {
+			history = append(history, entry)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Month < history[j].Month
+	})
+	return history, nil
+}
@@ -0,0 +1,325 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/activity"
+)
+
+const (
+	// activityDailyBasePath is where the daily rollups of the months are
+	// stored, by the start of the month.
+	activityDailyBasePath = "daily/"
+
+	// activityDailyMaxRetentionDays caps the days daily rollups are kept for.
+	activityDailyMaxRetentionDays = 366
+)
+
+var errDailyRollupsDisabled = errors.New("daily rollups are disabled; set daily_retention_days in the activity log configuration")
+
+// activityDay is the clients first seen in a month on a day, by namespace.
+type activityDay struct {
+	Timestamp  int64                             `json:"timestamp"`
+	NewClients map[string]*activity.CountsRecord `json:"new_clients"`
+}
+
+// activityDailyRollup is the days of a month, which are written alongside the
+// precomputed queries when the month ends.
+type activityDailyRollup struct {
+	Days []*activityDay `json:"days"`
+}
+
+// ResponseDay is the activity of a day. NewClients are the clients first seen
+// in the month on the day, and Counts are the distinct clients seen in the
+// month up to and including the day.
+type ResponseDay struct {
+	Timestamp  string          `json:"timestamp"`
+	Counts     *ResponseCounts `json:"counts"`
+	NewClients *ResponseCounts `json:"new_clients" mapstructure:"new_clients"`
+}
+
+// addFirstSeen records the client in firstSeen, unless it was seen earlier.
+func addFirstSeen(firstSeen map[string]*activity.EntityRecord, e *activity.EntityRecord) {
+	if seen, ok := firstSeen[e.ClientID]; ok && seen.Timestamp <= e.Timestamp {
+		return
+	}
+	firstSeen[e.ClientID] = e
+}
+
+// rollupActivityDays groups the clients by the day, in loc, they were first
+// seen on.
+func rollupActivityDays(firstSeen map[string]*activity.EntityRecord, loc *time.Location) []*activityDay {
+	byDay := make(map[int64]map[string]*processCounts)
+	for _, e := range firstSeen {
+		day := timeutil.StartOfDay(time.Unix(e.Timestamp, 0).In(loc)).Unix()
+		if _, ok := byDay[day]; !ok {
+			byDay[day] = make(map[string]*processCounts)
+		}
+		if _, ok := byDay[day][e.NamespaceID]; !ok {
+			byDay[day][e.NamespaceID] = newProcessCounts()
+		}
+		byDay[day][e.NamespaceID].add(e)
+	}
+
+	days := make([]*activityDay, 0, len(byDay))
+	for day, byNamespace := range byDay {
+		newClients := make(map[string]*activity.CountsRecord, len(byNamespace))
+		for nsID, counts := range byNamespace {
+			newClients[nsID] = counts.toCountsRecord()
+		}
+		days = append(days, &activityDay{
+			Timestamp:  day,
+			NewClients: newClients,
+		})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Timestamp < days[j].Timestamp
+	})
+	return days
+}
+
+// segmentDays rolls up the clients of the stored segments of the month by
+// day.
+func (a *ActivityLog) segmentDays(ctx context.Context, month time.Time) ([]*activityDay, error) {
+	reader, err := a.NewSegmentFileReader(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	firstSeen := make(map[string]*activity.EntityRecord)
+	for {
+		entity, err := reader.ReadEntity(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entity.Clients {
+			addFirstSeen(firstSeen, e)
+		}
+	}
+	return rollupActivityDays(firstSeen, a.monthZones().at(month)), nil
+}
+
+// currentMonthDays rolls up the clients of the current month by day.
+func (a *ActivityLog) currentMonthDays(ctx context.Context) ([]*activityDay, error) {
+	a.fragmentLock.RLock()
+	defer a.fragmentLock.RUnlock()
+
+	firstSeen := make(map[string]*activity.EntityRecord, len(a.partialMonthClientTracker))
+	for _, e := range a.partialMonthClientTracker {
+		addFirstSeen(firstSeen, e)
+	}
+	err := a.walkSpilledClients(ctx, func(e *activity.EntityRecord) {
+		addFirstSeen(firstSeen, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollupActivityDays(firstSeen, a.monthZones().at(a.clock.Now())), nil
+}
+
+// writeDailyRollup stores the daily rollup of the month of the segment
+// starting at month, if daily rollups are enabled.
+func (a *ActivityLog) writeDailyRollup(ctx context.Context, month time.Time) error {
+	a.l.RLock()
+	retentionDays := a.dailyRetentionDays
+	a.l.RUnlock()
+	if retentionDays == 0 {
+		return nil
+	}
+
+	days, err := a.segmentDays(ctx, month)
+	if err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%d", activityDailyBasePath, month.Unix()), &activityDailyRollup{Days: days})
+	if err != nil {
+		return err
+	}
+	return a.view.Put(ctx, entry)
+}
+
+// listDailyRollups returns the months of the stored daily rollups.
+func (a *ActivityLog) listDailyRollups(ctx context.Context) ([]time.Time, error) {
+	keys, err := a.view.List(ctx, activityDailyBasePath)
+	if err != nil {
+		return nil, err
+	}
+	months := make([]time.Time, 0, len(keys))
+	for _, key := range keys {
+		month, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			a.logger.Warn("could not parse daily rollup month", "key", key)
+			continue
+		}
+		months = append(months, time.Unix(month, 0))
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+	return months, nil
+}
+
+func (a *ActivityLog) readDailyRollup(ctx context.Context, month time.Time) (*activityDailyRollup, error) {
+	entry, err := a.view.Get(ctx, fmt.Sprintf("%s%d", activityDailyBasePath, month.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	rollup := &activityDailyRollup{}
+	if err := entry.DecodeJSON(rollup); err != nil {
+		return nil, err
+	}
+	return rollup, nil
+}
+
+// deleteDailyRollups deletes the daily rollups of the months which ended
+// before the daily retention window, or all of them if daily rollups are
+// disabled.
+func (a *ActivityLog) deleteDailyRollups(ctx context.Context, currentTime time.Time) error {
+	a.l.RLock()
+	retentionDays := a.dailyRetentionDays
+	a.l.RUnlock()
+	cutoff := timeutil.StartOfDay(currentTime.In(a.location())).AddDate(0, 0, -retentionDays)
+
+	months, err := a.listDailyRollups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, month := range months {
+		if retentionDays > 0 && a.startOfNextMonth(month).After(cutoff) {
+			continue
+		}
+		a.logger.Trace("deleting daily rollup", "month", month)
+		if err := a.view.Delete(ctx, fmt.Sprintf("%s%d", activityDailyBasePath, month.Unix())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dailyActivity reports the activity of each day between the start and end
+// times within the daily retention window, from the daily rollups of the past
+// months and from the clients of the current month. Only the clients of the
+// namespaces the query namespace includes are counted.
+func (a *ActivityLog) dailyActivity(ctx context.Context, startTime, endTime time.Time) ([]*ResponseDay, error) {
+	a.l.RLock()
+	retentionDays := a.dailyRetentionDays
+	a.l.RUnlock()
+	if retentionDays == 0 {
+		return nil, errDailyRollupsDisabled
+	}
+
+	queryNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	included := make(map[string]bool)
+	includes := func(nsID string) (bool, error) {
+		in, ok := included[nsID]
+		if !ok {
+			ns, err := NamespaceByID(ctx, nsID, a.core)
+			if err != nil {
+				return false, err
+			}
+			in = a.includeInResponse(queryNS, ns)
+			included[nsID] = in
+		}
+		return in, nil
+	}
+
+	now := a.clock.Now()
+	loc := a.location()
+	windowStart := timeutil.StartOfDay(now.In(loc)).AddDate(0, 0, -retentionDays+1)
+	if startTime.Before(windowStart) {
+		startTime = windowStart
+	}
+	if endTime.After(now) {
+		endTime = now
+	}
+
+	months, err := a.listDailyRollups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currentMonth := a.startOfMonth(now)
+	if len(months) == 0 || months[len(months)-1].Before(currentMonth) {
+		months = append(months, currentMonth)
+	}
+
+	days := make([]*ResponseDay, 0)
+	for _, month := range months {
+		monthStart, monthEnd := a.startOfMonth(month), a.startOfNextMonth(month)
+		if !monthEnd.After(startTime) || monthStart.After(endTime) {
+			continue
+		}
+
+		var monthDays []*activityDay
+		if monthStart.Equal(currentMonth) {
+			monthDays, err = a.currentMonthDays(ctx)
+		} else {
+			var rollup *activityDailyRollup
+			rollup, err = a.readDailyRollup(ctx, month)
+			if rollup != nil {
+				monthDays = rollup.Days
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		byDay := make(map[int64]*ResponseCounts, len(monthDays))
+		for _, day := range monthDays {
+			newClients := &ResponseCounts{}
+			for nsID, counts := range day.NewClients {
+				in, err := includes(nsID)
+				if err != nil {
+					return nil, err
+				}
+				if in {
+					newClients.Add(a.countsRecordToCountsResponse(counts, false))
+				}
+			}
+			byDay[day.Timestamp] = newClients
+		}
+
+		// Report every day of the month up to the end time, for the new
+		// clients of each to add up to the clients of the month so far
+		monthClients := &ResponseCounts{}
+		dayLoc := a.monthZones().at(monthStart)
+		for day := monthStart.In(dayLoc); day.Before(monthEnd) && !day.After(endTime); day = day.AddDate(0, 0, 1) {
+			newClients, ok := byDay[day.Unix()]
+			if !ok {
+				newClients = &ResponseCounts{}
+			}
+			monthClients.Add(newClients)
+			if day.Before(timeutil.StartOfDay(startTime.In(dayLoc))) {
+				continue
+			}
+
+			counts := &ResponseCounts{}
+			counts.Add(monthClients)
+			days = append(days, &ResponseDay{
+				Timestamp:  day.In(loc).Format(time.RFC3339),
+				Counts:     counts,
+				NewClients: newClients,
+			})
+		}
+	}
+	return days, nil
+}
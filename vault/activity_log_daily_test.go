@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault/activity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRollupActivityDays verifies that the clients are counted once, on the
+// day they were first seen, by namespace.
+func TestRollupActivityDays(t *testing.T) {
+	dayOne := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	dayTwo := dayOne.AddDate(0, 0, 1)
+
+	firstSeen := make(map[string]*activity.EntityRecord)
+	for _, e := range []*activity.EntityRecord{
+		{ClientID: "1", NamespaceID: namespace.RootNamespaceID, Timestamp: dayTwo.Add(time.Hour).Unix()},
+		{ClientID: "1", NamespaceID: namespace.RootNamespaceID, Timestamp: dayOne.Add(time.Hour).Unix()},
+		{ClientID: "2", NamespaceID: namespace.RootNamespaceID, Timestamp: dayOne.Add(2 * time.Hour).Unix(), NonEntity: true},
+		{ClientID: "3", NamespaceID: "ns1", Timestamp: dayTwo.Add(time.Hour).Unix()},
+	} {
+		addFirstSeen(firstSeen, e)
+	}
+
+	days := rollupActivityDays(firstSeen, time.UTC)
+	require.Len(t, days, 2)
+
+	require.Equal(t, dayOne.Unix(), days[0].Timestamp)
+	require.Equal(t, map[string]*activity.CountsRecord{
+		namespace.RootNamespaceID: {EntityClients: 1, NonEntityClients: 1},
+	}, days[0].NewClients)
+
+	require.Equal(t, dayTwo.Unix(), days[1].Timestamp)
+	require.Equal(t, map[string]*activity.CountsRecord{
+		"ns1": {EntityClients: 1},
+	}, days[1].NewClients)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// activityExclusionRules select the clients to exclude from billing, such as
+// internal or test workloads sharing a production cluster. A client matching
+// any of the rules is excluded.
+type activityExclusionRules struct {
+	// MountAccessors excludes the clients of the tokens issued by these auth
+	// mounts.
+	MountAccessors []string `json:"mount_accessors,omitempty"`
+
+	// EntityMetadata excludes the entities having any of these metadata
+	// key/value pairs.
+	EntityMetadata map[string]string `json:"entity_metadata,omitempty"`
+
+	// Policies excludes the clients of the tokens having any of these
+	// policies.
+	Policies []string `json:"policies,omitempty"`
+}
+
+func (r activityExclusionRules) empty() bool {
+	return len(r.MountAccessors) == 0 && len(r.EntityMetadata) == 0 && len(r.Policies) == 0
+}
+
+// clientExcluded returns whether the client of the token matches the
+// exclusion rules of the activity log.
+func (a *ActivityLog) clientExcluded(entry *logical.TokenEntry, mountAccessor string) bool {
+	a.fragmentLock.RLock()
+	rules := a.exclusionRules
+	a.fragmentLock.RUnlock()
+
+	if rules.empty() {
+		return false
+	}
+
+	if mountAccessor != "" && strutil.StrListContains(rules.MountAccessors, mountAccessor) {
+		return true
+	}
+
+	for _, policy := range entry.Policies {
+		if strutil.StrListContains(rules.Policies, policy) {
+			return true
+		}
+	}
+
+	if len(rules.EntityMetadata) == 0 || entry.EntityID == "" || a.core.identityStore == nil {
+		return false
+	}
+	entity, err := a.core.identityStore.MemDBEntityByID(entry.EntityID, false)
+	if err != nil {
+		a.logger.Warn("failed to look up entity for exclusion rules", "entity_id", entry.EntityID, "error", err)
+		return false
+	}
+	if entity == nil {
+		return false
+	}
+	for key, value := range rules.EntityMetadata {
+		if entityValue, ok := entity.Metadata[key]; ok && entityValue == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault/activity"
+)
+
+// activityExportClientTypes maps the client types the export can be filtered
+// by to the activity types of the clients.
+var activityExportClientTypes = map[string]string{
+	"entity":      entityActivityType,
+	"non-entity":  nonEntityTokenActivityType,
+	"acme":        ACMEActivityType,
+	"secret-sync": secretSyncActivityType,
+	"excluded":    excludedActivityType,
+}
+
+// activityExportFilter selects the clients an export includes. The filters
+// are applied as the segments are read, so that the clients which do not
+// match are never sent. A client matches if it matches every filter set.
+type activityExportFilter struct {
+	// NamespacePath selects the clients of the namespace and its children.
+	NamespacePath string `json:"namespace_path,omitempty"`
+
+	// ClientTypes selects the clients of these activity types.
+	ClientTypes []string `json:"client_types,omitempty"`
+
+	// MountAccessors selects the clients seen on these mounts.
+	MountAccessors []string `json:"mount_accessors,omitempty"`
+}
+
+// parseActivityExportClientTypes returns the activity types of the client
+// types the export is filtered by.
+func parseActivityExportClientTypes(clientTypes []string) ([]string, error) {
+	activityTypes := make([]string, 0, len(clientTypes))
+	for _, clientType := range clientTypes {
+		activityType, ok := activityExportClientTypes[strings.ToLower(strings.TrimSpace(clientType))]
+		if !ok {
+			valid := make([]string, 0, len(activityExportClientTypes))
+			for name := range activityExportClientTypes {
+				valid = append(valid, name)
+			}
+			sort.Strings(valid)
+			return nil, fmt.Errorf("invalid client type %q, must be one of: %s", clientType, strings.Join(valid, ", "))
+		}
+		activityTypes = append(activityTypes, activityType)
+	}
+	return activityTypes, nil
+}
+
+// matcher returns a function reporting whether a client matches the filter.
+// The namespaces of the clients are looked up once per export.
+func (f activityExportFilter) matcher(ctx context.Context, c *Core) (func(*activity.EntityRecord) (bool, error), error) {
+	var subtree *namespace.Namespace
+	if f.NamespacePath != "" {
+		subtree = c.namespaceByPath(namespace.Canonicalize(f.NamespacePath))
+		if subtree == nil {
+			return nil, fmt.Errorf("namespace %q not found", f.NamespacePath)
+		}
+	}
+	inSubtree := make(map[string]bool)
+
+	return func(e *activity.EntityRecord) (bool, error) {
+		if len(f.ClientTypes) > 0 && !strutil.StrListContains(f.ClientTypes, getClientType(e)) {
+			return false, nil
+		}
+		if len(f.MountAccessors) > 0 && !strutil.StrListContains(f.MountAccessors, e.MountAccessor) {
+			return false, nil
+		}
+		if subtree == nil || subtree.ID == namespace.RootNamespaceID {
+			return true, nil
+		}
+
+		in, ok := inSubtree[e.NamespaceID]
+		if !ok {
+			ns, err := NamespaceByID(ctx, e.NamespaceID, c)
+			if err != nil {
+				return false, err
+			}
+			// The clients of deleted namespaces are only in the root subtree
+			in = ns != nil && ns.HasParent(subtree)
+			inSubtree[e.NamespaceID] = in
+		}
+		return in, nil
+	}, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault/activity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestActivityExportFilter verifies that the clients of an export are
+// selected by client type and mount accessor.
+func TestActivityExportFilter(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	_, err := parseActivityExportClientTypes([]string{"entity", "bogus"})
+	require.Error(t, err)
+
+	clientTypes, err := parseActivityExportClientTypes([]string{"entity", "ACME"})
+	require.NoError(t, err)
+	require.Equal(t, []string{entityActivityType, ACMEActivityType}, clientTypes)
+
+	match, err := activityExportFilter{
+		NamespacePath:  "root",
+		ClientTypes:    clientTypes,
+		MountAccessors: []string{"auth_userpass_1"},
+	}.matcher(ctx, c)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		record  *activity.EntityRecord
+		matches bool
+	}{
+		{&activity.EntityRecord{ClientID: "1", NamespaceID: namespace.RootNamespaceID, MountAccessor: "auth_userpass_1"}, true},
+		{&activity.EntityRecord{ClientID: "2", NamespaceID: namespace.RootNamespaceID, MountAccessor: "auth_userpass_1", ClientType: ACMEActivityType}, true},
+		{&activity.EntityRecord{ClientID: "3", NamespaceID: namespace.RootNamespaceID, MountAccessor: "auth_userpass_1", NonEntity: true}, false},
+		{&activity.EntityRecord{ClientID: "4", NamespaceID: namespace.RootNamespaceID, MountAccessor: "auth_userpass_2"}, false},
+	} {
+		matches, err := match(tc.record)
+		require.NoError(t, err)
+		require.Equal(t, tc.matches, matches, "client %s", tc.record.ClientID)
+	}
+
+	// An empty filter matches every client
+	match, err = activityExportFilter{}.matcher(ctx, c)
+	require.NoError(t, err)
+	matches, err := match(&activity.EntityRecord{ClientID: "5", NamespaceID: "deleted", NonEntity: true})
+	require.NoError(t, err)
+	require.True(t, matches)
+}
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/hashicorp/vault/helper/timeutil"
+)
+
+const (
+	// activityForecastDefaultHistoryMonths is the default number of complete
+	// months the forecast is fitted on.
+	activityForecastDefaultHistoryMonths = 12
+
+	// activityForecastMinMonths is the minimum number of months with client
+	// counts needed to fit a forecast.
+	activityForecastMinMonths = 3
+
+	activityForecastConfidenceLevel = 0.95
+)
+
+// errInsufficientActivityHistory is returned when there are not enough
+// months of client counts to fit a forecast.
+var errInsufficientActivityHistory = errors.New("not enough months of client counts to forecast usage")
+
+// tQuantiles975 are the 97.5% quantiles of Student's t-distribution by
+// degrees of freedom, for two-sided 95% intervals.
+var tQuantiles975 = []float64{
+	0, 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tQuantile975(degreesOfFreedom int) float64 {
+	if degreesOfFreedom < len(tQuantiles975) {
+		return tQuantiles975[degreesOfFreedom]
+	}
+	return 1.960
+}
+
+// linearFit is an ordinary least squares fit of y = intercept + slope * x.
+type linearFit struct {
+	intercept float64
+	slope     float64
+
+	n     int
+	meanX float64
+	sxx   float64
+	// stdErr is the residual standard error of the fit.
+	stdErr float64
+}
+
+func fitLinear(xs, ys []float64) *linearFit {
+	f := &linearFit{n: len(xs)}
+	var meanY float64
+	for i := range xs {
+		f.meanX += xs[i]
+		meanY += ys[i]
+	}
+	f.meanX /= float64(f.n)
+	meanY /= float64(f.n)
+
+	var sxy float64
+	for i := range xs {
+		f.sxx += (xs[i] - f.meanX) * (xs[i] - f.meanX)
+		sxy += (xs[i] - f.meanX) * (ys[i] - meanY)
+	}
+	if f.sxx > 0 {
+		f.slope = sxy / f.sxx
+	}
+	f.intercept = meanY - f.slope*f.meanX
+
+	if f.n > 2 {
+		var sse float64
+		for i := range xs {
+			residual := ys[i] - f.predict(xs[i])
+			sse += residual * residual
+		}
+		f.stdErr = math.Sqrt(sse / float64(f.n-2))
+	}
+	return f
+}
+
+func (f *linearFit) predict(x float64) float64 {
+	return f.intercept + f.slope*x
+}
+
+// predictionInterval returns the half-width of the 95% prediction interval
+// of a new observation at x.
+func (f *linearFit) predictionInterval(x float64) float64 {
+	leverage := 1 / float64(f.n)
+	if f.sxx > 0 {
+		leverage += (x - f.meanX) * (x - f.meanX) / f.sxx
+	}
+	return tQuantile975(f.n-2) * f.stdErr * math.Sqrt(1+leverage)
+}
+
+// activityProjection is a projected value with its confidence interval.
+type activityProjection struct {
+	Estimate int `json:"estimate" mapstructure:"estimate"`
+	Lower    int `json:"lower" mapstructure:"lower"`
+	Upper    int `json:"upper" mapstructure:"upper"`
+}
+
+func newActivityProjection(estimate, halfWidth float64) *activityProjection {
+	return &activityProjection{
+		Estimate: int(math.Round(math.Max(estimate, 0))),
+		Lower:    int(math.Round(math.Max(estimate-halfWidth, 0))),
+		Upper:    int(math.Round(math.Max(estimate+halfWidth, 0))),
+	}
+}
+
+// forecastUsage fits the growth of the client counts of the last complete
+// months, and projects the clients of the contract period ending at
+// contractEnd. The months of the contract without client counts yet are
+// projected from the fitted number of new clients per month, which adds to
+// the clients already counted in the contract period.
+func (a *ActivityLog) forecastUsage(ctx context.Context, now, contractStart, contractEnd time.Time, historyMonths int) (map[string]interface{}, error) {
+	historyStart := timeutil.MonthsPreviousToInZone(historyMonths, now)
+	historyEnd := timeutil.EndOfMonth(timeutil.StartOfPreviousMonth(now))
+
+	results, err := a.handleQuery(ctx, historyStart, historyEnd, 0)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return nil, errInsufficientActivityHistory
+	}
+	months, _ := results["months"].([]*ResponseMonth)
+
+	var xs, clients, newXs, newClients []float64
+	first := true
+	for _, month := range months {
+		if month.Counts == nil {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339, month.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		x := float64(monthsBetween(historyStart, timestamp))
+		xs = append(xs, x)
+		clients = append(clients, float64(month.Counts.Clients))
+
+		// All the clients of the first month with counts are new in the
+		// query, so only the following months tell the rate of new clients.
+		if first {
+			first = false
+			continue
+		}
+		newXs = append(newXs, x)
+		if month.NewClients != nil && month.NewClients.Counts != nil {
+			newClients = append(newClients, float64(month.NewClients.Counts.Clients))
+		} else {
+			newClients = append(newClients, 0)
+		}
+	}
+	if len(xs) < activityForecastMinMonths {
+		return nil, errInsufficientActivityHistory
+	}
+	clientsFit := fitLinear(xs, clients)
+	newClientsFit := fitLinear(newXs, newClients)
+
+	// The clients already counted in the contract period.
+	clientsToDate := 0
+	if contractStart.Before(historyEnd) {
+		results, err := a.handleQuery(ctx, timeutil.StartOfMonth(contractStart), historyEnd, 0)
+		if err != nil {
+			return nil, err
+		}
+		if total, ok := results["total"].(*ResponseCounts); ok && total != nil {
+			clientsToDate = total.Clients
+		}
+	}
+
+	// Project the new clients of each remaining month of the contract.
+	var estimate, lower, upper float64
+	projectedMonths := make([]map[string]interface{}, 0)
+	firstProjected := timeutil.StartOfMonth(now)
+	if contractStart.After(firstProjected) {
+		firstProjected = timeutil.StartOfMonth(contractStart)
+	}
+	for month := firstProjected; !month.After(contractEnd); month = month.AddDate(0, 1, 0) {
+		x := float64(monthsBetween(historyStart, month))
+		projection := newActivityProjection(newClientsFit.predict(x), newClientsFit.predictionInterval(x))
+		estimate += float64(projection.Estimate)
+		lower += float64(projection.Lower)
+		upper += float64(projection.Upper)
+
+		monthly := newActivityProjection(clientsFit.predict(x), clientsFit.predictionInterval(x))
+		projectedMonths = append(projectedMonths, map[string]interface{}{
+			"timestamp":             month.Format(time.RFC3339),
+			"projected_clients":     monthly,
+			"projected_new_clients": projection,
+		})
+	}
+
+	return map[string]interface{}{
+		"history_start_time":       historyStart.Format(time.RFC3339),
+		"history_end_time":         historyEnd.Format(time.RFC3339),
+		"history_months":           len(xs),
+		"contract_start_time":      contractStart.Format(time.RFC3339),
+		"contract_end_time":        contractEnd.Format(time.RFC3339),
+		"confidence_level":         activityForecastConfidenceLevel,
+		"monthly_client_growth":    clientsFit.slope,
+		"monthly_new_clients":      math.Max(newClientsFit.predict(float64(monthsBetween(historyStart, firstProjected))), 0),
+		"contract_clients_to_date": clientsToDate,
+		"projected_contract_clients": &activityProjection{
+			Estimate: clientsToDate + int(estimate),
+			Lower:    clientsToDate + int(lower),
+			Upper:    clientsToDate + int(upper),
+		},
+		"months": projectedMonths,
+	}, nil
+}
+
+// monthsBetween returns the number of calendar months from the month of
+// start to the month of end.
+func monthsBetween(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+}
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// activityKnownClientsBasePath is where the active node stores the bloom
+	// filter of the clients of the current month, for performance standbys
+	// to deduplicate their fragments against.
+	activityKnownClientsBasePath  = "knownclients/"
+	activityKnownClientsHeaderKey = activityKnownClientsBasePath + "header"
+
+	// activityKnownClientsFalsePositiveRate is the target false positive rate
+	// of the filter. A false positive drops a new client observed only by a
+	// standby until the next month, so the rate is kept low.
+	activityKnownClientsFalsePositiveRate = 0.0001
+
+	// activityKnownClientsChunkSize is the size of the storage entries the
+	// filter is split into, below the storage entry limit.
+	activityKnownClientsChunkSize = 256 * 1024
+)
+
+// clientBloomFilter is a bloom filter of client IDs.
+type clientBloomFilter struct {
+	bits      []byte
+	numHashes uint32
+}
+
+// newClientBloomFilter sizes a filter for the number of clients at the false
+// positive rate.
+func newClientBloomFilter(clients int, falsePositiveRate float64) *clientBloomFilter {
+	if clients < 1 {
+		clients = 1
+	}
+	numBits := math.Ceil(-float64(clients) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	numBytes := int(math.Max(math.Ceil(numBits/8), 128))
+	numHashes := uint32(math.Max(math.Round(float64(numBytes*8)/float64(clients)*math.Ln2), 1))
+	return &clientBloomFilter{
+		bits:      make([]byte, numBytes),
+		numHashes: numHashes,
+	}
+}
+
+// positions returns the bits of the client ID, with double hashing.
+func (f *clientBloomFilter) positions(clientID string) []uint64 {
+	sum := sha256.Sum256([]byte(clientID))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16])
+	numBits := uint64(len(f.bits)) * 8
+
+	positions := make([]uint64, f.numHashes)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % numBits
+	}
+	return positions
+}
+
+func (f *clientBloomFilter) add(clientID string) {
+	for _, pos := range f.positions(clientID) {
+		f.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// mayContain returns whether the client may have been added to the filter;
+// it is certain that a client was not added if false.
+func (f *clientBloomFilter) mayContain(clientID string) bool {
+	for _, pos := range f.positions(clientID) {
+		if f.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// knownClientsHeader describes the stored filter. The chunks are written
+// under a new generation each time, before the header, so that a standby
+// never reads the chunks of different filters together.
+type knownClientsHeader struct {
+	StartTimestamp int64  `json:"start_timestamp"`
+	Generation     string `json:"generation"`
+	NumHashes      uint32 `json:"num_hashes"`
+	NumBytes       int    `json:"num_bytes"`
+	Clients        int    `json:"clients"`
+}
+
+func knownClientsChunkPath(generation string, index int) string {
+	return fmt.Sprintf("%s%s/%d", activityKnownClientsBasePath, generation, index)
+}
+
+// writeKnownClients stores the filter of the clients of the current month,
+// if new clients were seen since it was last written. It runs on the active
+// node only.
+func (a *ActivityLog) writeKnownClients(ctx context.Context) error {
+	a.l.RLock()
+	startTimestamp := a.currentSegment.startTimestamp
+	a.l.RUnlock()
+	if startTimestamp == 0 {
+		return nil
+	}
+
+	a.fragmentLock.RLock()
+	clients := len(a.partialMonthClientTracker)
+	if !a.enabled || (clients == a.knownClientsWritten && startTimestamp == a.knownClientsWrittenStart) {
+		a.fragmentLock.RUnlock()
+		return nil
+	}
+	// Leave room for the clients seen until the next write.
+	filter := newClientBloomFilter(clients*2, activityKnownClientsFalsePositiveRate)
+	for clientID := range a.partialMonthClientTracker {
+		filter.add(clientID)
+	}
+	a.fragmentLock.RUnlock()
+
+	generation, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	for i := 0; i*activityKnownClientsChunkSize < len(filter.bits); i++ {
+		end := (i + 1) * activityKnownClientsChunkSize
+		if end > len(filter.bits) {
+			end = len(filter.bits)
+		}
+		err := a.view.Put(ctx, &logical.StorageEntry{
+			Key:   knownClientsChunkPath(generation, i),
+			Value: filter.bits[i*activityKnownClientsChunkSize : end],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	previous, err := a.readKnownClientsHeader(ctx)
+	if err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(activityKnownClientsHeaderKey, &knownClientsHeader{
+		StartTimestamp: startTimestamp,
+		Generation:     generation,
+		NumHashes:      filter.numHashes,
+		NumBytes:       len(filter.bits),
+		Clients:        clients,
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.view.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	a.fragmentLock.Lock()
+	a.knownClientsWritten = clients
+	a.knownClientsWrittenStart = startTimestamp
+	a.fragmentLock.Unlock()
+
+	if previous != nil {
+		if err := logical.ClearView(ctx, a.view.SubView(activityKnownClientsBasePath+previous.Generation+"/")); err != nil {
+			a.logger.Warn("failed to delete previous known clients filter", "error", err)
+		}
+	}
+	return nil
+}
+
+func (a *ActivityLog) readKnownClientsHeader(ctx context.Context) (*knownClientsHeader, error) {
+	entry, err := a.view.Get(ctx, activityKnownClientsHeaderKey)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	var header knownClientsHeader
+	if err := entry.DecodeJSON(&header); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// refreshKnownClients loads the filter of the clients of the current month
+// written by the active node. It runs on performance standbys only.
+func (a *ActivityLog) refreshKnownClients(ctx context.Context) error {
+	header, err := a.readKnownClientsHeader(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.l.RLock()
+	startTimestamp := a.currentSegment.startTimestamp
+	a.l.RUnlock()
+
+	// A filter of another month would deduplicate clients which are new
+	// this month.
+	if header == nil || header.StartTimestamp != startTimestamp || header.NumHashes == 0 {
+		a.fragmentLock.Lock()
+		a.knownClients = nil
+		a.knownClientsGeneration = ""
+		a.fragmentLock.Unlock()
+		return nil
+	}
+
+	a.fragmentLock.RLock()
+	current := a.knownClientsGeneration
+	a.fragmentLock.RUnlock()
+	if header.Generation == current {
+		return nil
+	}
+
+	filter := &clientBloomFilter{
+		bits:      make([]byte, 0, header.NumBytes),
+		numHashes: header.NumHashes,
+	}
+	for i := 0; len(filter.bits) < header.NumBytes; i++ {
+		entry, err := a.view.Get(ctx, knownClientsChunkPath(header.Generation, i))
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			// The filter was replaced while being read; the next refresh
+			// reads the new one.
+			return nil
+		}
+		filter.bits = append(filter.bits, entry.Value...)
+	}
+	if len(filter.bits) != header.NumBytes {
+		return fmt.Errorf("known clients filter has %d bytes, expected %d", len(filter.bits), header.NumBytes)
+	}
+
+	a.fragmentLock.Lock()
+	a.knownClients = filter
+	a.knownClientsGeneration = header.Generation
+	a.fragmentLock.Unlock()
+	return nil
+}
+
+// knownClient returns whether the client is known to have been counted this
+// month by the active node. Must be called with fragmentLock held.
+func (a *ActivityLog) knownClient(clientID string) bool {
+	return a.knownClients != nil && a.knownClients.mayContain(clientID)
+}
+
+// deduplicateFragment removes from the current fragment the clients the
+// active node already counted this month, before it is forwarded.
+func (a *ActivityLog) deduplicateFragment() {
+	a.fragmentLock.Lock()
+	defer a.fragmentLock.Unlock()
+
+	if a.fragment == nil || a.knownClients == nil {
+		return
+	}
+
+	clients := a.fragment.Clients[:0]
+	for _, client := range a.fragment.Clients {
+		if !a.knownClient(client.ClientID) {
+			clients = append(clients, client)
+		}
+	}
+	deduplicated := len(a.fragment.Clients) - len(clients)
+	a.fragment.Clients = clients
+
+	if deduplicated > 0 {
+		a.metrics.IncrCounterWithLabels([]string{"core", "activity", "fragment_deduplicated"},
+			float32(deduplicated), []metricsutil.Label{})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// activityMonthClosedEventType is the type of the event sent in the root
+	// namespace with the totals of a month, when its queries are finalized.
+	activityMonthClosedEventType = "activity/month-closed"
+
+	// activityNamespaceMonthClosedEventType is the type of the events sent in
+	// each namespace with its clients in a month, when its queries are
+	// finalized.
+	activityNamespaceMonthClosedEventType = "activity/namespace-month-closed"
+
+	activityMonthCloseWebhookTimeout  = 10 * time.Second
+	activityMonthCloseWebhookAttempts = 3
+)
+
+// activityMonthClose is the report of a month sent once its precomputed
+// queries are finalized.
+type activityMonthClose struct {
+	Month       string               `json:"month"`
+	StartTime   string               `json:"start_time"`
+	EndTime     string               `json:"end_time"`
+	Total       *ResponseCounts      `json:"total"`
+	ByNamespace []*ResponseNamespace `json:"by_namespace"`
+}
+
+// notifyMonthClosed reports the totals of the month of the segment starting at
+// month, per namespace, to the configured webhook and as events, once the
+// precomputed queries of the month are written.
+func (a *ActivityLog) notifyMonthClosed(ctx context.Context, month, endTime time.Time) error {
+	a.l.RLock()
+	webhookURL, sendEvent := a.monthCloseWebhookURL, a.monthCloseEvent
+	a.l.RUnlock()
+	if webhookURL == "" && !sendEvent {
+		return nil
+	}
+
+	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	pq, err := a.queryStore.Get(ctx, a.monthOf(month), a.monthOfEnd(endTime))
+	if err != nil {
+		return err
+	}
+	if pq == nil {
+		return fmt.Errorf("no precomputed query for the month")
+	}
+	total, byNamespace, err := a.calculateByNamespaceResponseForQuery(ctx, pq.Namespaces)
+	if err != nil {
+		return err
+	}
+	a.sortALResponseNamespaces(byNamespace)
+
+	report := &activityMonthClose{
+		Month:       a.monthOf(month).Format(time.RFC3339),
+		StartTime:   pq.StartTime.Format(time.RFC3339),
+		EndTime:     pq.EndTime.Format(time.RFC3339),
+		Total:       total,
+		ByNamespace: byNamespace,
+	}
+	if sendEvent {
+		a.sendMonthClosedEvents(ctx, report)
+	}
+	if webhookURL != "" {
+		return a.sendMonthCloseWebhook(ctx, webhookURL, report)
+	}
+	return nil
+}
+
+func activityCountsMetadata(month string, counts *ResponseCounts) map[string]*structpb.Value {
+	return map[string]*structpb.Value{
+		"month":              structpb.NewStringValue(month),
+		"clients":            structpb.NewStringValue(strconv.Itoa(counts.Clients)),
+		"entity_clients":     structpb.NewStringValue(strconv.Itoa(counts.EntityClients)),
+		"non_entity_clients": structpb.NewStringValue(strconv.Itoa(counts.NonEntityClients)),
+		"secret_syncs":       structpb.NewStringValue(strconv.Itoa(counts.SecretSyncs)),
+		"excluded_clients":   structpb.NewStringValue(strconv.Itoa(counts.ExcludedClients)),
+	}
+}
+
+// sendMonthClosedEvents sends the totals of the month in the root namespace,
+// and the clients of each namespace in the namespace.
+func (a *ActivityLog) sendMonthClosedEvents(ctx context.Context, report *activityMonthClose) {
+	events := a.core.events
+	if events == nil {
+		return
+	}
+
+	send := func(ns *namespace.Namespace, eventType logical.EventType, metadata map[string]*structpb.Value) {
+		ev, err := logical.NewEvent()
+		if err != nil {
+			a.logger.Error("failed to create month closed event", "error", err)
+			return
+		}
+		ev.Metadata = &structpb.Struct{Fields: metadata}
+		if err := events.SendEventInternal(ctx, ns, nil, eventType, ev); err != nil {
+			a.logger.Debug("failed to send month closed event", "namespace", ns.Path, "error", err)
+		}
+	}
+
+	send(namespace.RootNamespace, activityMonthClosedEventType, activityCountsMetadata(report.Month, report.Total))
+	for _, nsResponse := range report.ByNamespace {
+		ns, err := NamespaceByID(ctx, nsResponse.NamespaceID, a.core)
+		if err != nil || ns == nil {
+			// The clients of deleted namespaces are only in the totals
+			continue
+		}
+		metadata := activityCountsMetadata(report.Month, &nsResponse.Counts)
+		metadata["namespace_path"] = structpb.NewStringValue(nsResponse.NamespacePath)
+		send(ns, activityNamespaceMonthClosedEventType, metadata)
+	}
+}
+
+// sendMonthCloseWebhook POSTs the report to the webhook, retrying a few times,
+// as the report of a month is only sent once.
+func (a *ActivityLog) sendMonthCloseWebhook(ctx context.Context, webhookURL string, report *activityMonthClose) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	client := cleanhttp.DefaultClient()
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = a.postMonthCloseWebhook(ctx, client, webhookURL, body)
+		if err == nil {
+			return nil
+		}
+		if attempt == activityMonthCloseWebhookAttempts {
+			return err
+		}
+		a.logger.Warn("failed to send month close webhook, retrying", "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (a *ActivityLog) postMonthCloseWebhook(ctx context.Context, client *http.Client, webhookURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, activityMonthCloseWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestActivityLog_MonthCloseWebhook verifies that the report of a month is
+// retried until the webhook accepts it.
+func TestActivityLog_MonthCloseWebhook(t *testing.T) {
+	var attempts int32
+	received := make(chan *activityMonthClose, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		report := &activityMonthClose{}
+		if err := json.NewDecoder(r.Body).Decode(report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- report
+	}))
+	defer server.Close()
+
+	a := &ActivityLog{logger: hclog.NewNullLogger()}
+	report := &activityMonthClose{
+		Month: "2024-03-01T00:00:00Z",
+		Total: &ResponseCounts{Clients: 3, EntityClients: 2, NonEntityClients: 1},
+		ByNamespace: []*ResponseNamespace{
+			{NamespaceID: "root", NamespacePath: "", Counts: ResponseCounts{Clients: 3}},
+		},
+	}
+	require.NoError(t, a.sendMonthCloseWebhook(context.Background(), server.URL, report))
+	require.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	got := <-received
+	require.Equal(t, report.Month, got.Month)
+	require.Equal(t, report.Total, got.Total)
+	require.Len(t, got.ByNamespace, 1)
+	require.Equal(t, 3, got.ByNamespace[0].Counts.Clients)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/helper/timeutil"
+)
+
+// ResponseMountHistory is the activity of a mount in each month of a period.
+type ResponseMountHistory struct {
+	NamespaceID   string `json:"namespace_id" mapstructure:"namespace_id"`
+	NamespacePath string `json:"namespace_path" mapstructure:"namespace_path"`
+	MountAccessor string `json:"mount_accessor" mapstructure:"mount_accessor"`
+	MountPath     string `json:"mount_path" mapstructure:"mount_path"`
+
+	// NewClients are the clients first seen in the period on the mount.
+	NewClients *ResponseCounts       `json:"new_clients" mapstructure:"new_clients"`
+	Months     []*ResponseMountMonth `json:"months"`
+}
+
+type ResponseMountMonth struct {
+	Timestamp  string          `json:"timestamp"`
+	Counts     *ResponseCounts `json:"counts"`
+	NewClients *ResponseCounts `json:"new_clients" mapstructure:"new_clients"`
+}
+
+// mountActivityHistory reports the clients and new clients of each mount, or
+// of the mount of the given accessor only, in each complete month of the
+// period. It is answered from the precomputed queries, which record the
+// activity of each mount in each month from version 2 on; the current month
+// is not reported, as it is not precomputed yet.
+func (a *ActivityLog) mountActivityHistory(ctx context.Context, startTime, endTime time.Time, mountAccessor string) (map[string]interface{}, error) {
+	loc := a.location()
+	startTime = timeutil.StartOfMonth(startTime.In(loc))
+	endTime = timeutil.EndOfMonth(endTime.In(loc))
+	if timeutil.IsCurrentMonth(endTime, a.currentMonth()) {
+		endTime = timeutil.EndOfMonth(timeutil.MonthsPreviousToInZone(1, timeutil.StartOfMonth(endTime)))
+	}
+	if endTime.Before(startTime) {
+		return nil, nil
+	}
+
+	pq, err := a.queryStore.Get(ctx, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if pq == nil {
+		return nil, nil
+	}
+
+	byMount := make(map[string]*ResponseMountHistory)
+	mounts := make([]*ResponseMountHistory, 0)
+	for _, month := range pq.Months {
+		for _, mount := range month.Mounts {
+			if mountAccessor != "" && mount.MountAccessor != mountAccessor {
+				continue
+			}
+			if !mount.Counts.HasCounts() && !mount.NewClients.HasCounts() {
+				continue
+			}
+
+			key := mount.MountAccessor
+			if key == "" {
+				key = mount.NamespaceID + "/" + mount.MountPath
+			}
+			history, ok := byMount[key]
+			if !ok {
+				// Report the mounts which still exist at their current path
+				mountPath := mount.MountPath
+				if mount.MountAccessor != "" {
+					mountPath = a.mountAccessorToMountPath(mount.MountAccessor)
+				}
+				history = &ResponseMountHistory{
+					NamespaceID:   mount.NamespaceID,
+					NamespacePath: a.namespaceToLabel(ctx, mount.NamespaceID),
+					MountAccessor: mount.MountAccessor,
+					MountPath:     mountPath,
+					NewClients:    &ResponseCounts{},
+					Months:        make([]*ResponseMountMonth, 0),
+				}
+				byMount[key] = history
+				mounts = append(mounts, history)
+			}
+
+			newClients := a.countsRecordToCountsResponse(mount.NewClients, false)
+			history.NewClients.Add(newClients)
+			history.Months = append(history.Months, &ResponseMountMonth{
+				Timestamp:  a.monthOf(time.Unix(month.Timestamp, 0)).Format(time.RFC3339),
+				Counts:     a.countsRecordToCountsResponse(mount.Counts, false),
+				NewClients: newClients,
+			})
+		}
+	}
+
+	for _, history := range mounts {
+		sort.Slice(history.Months, func(i, j int) bool {
+			return history.Months[i].Timestamp < history.Months[j].Timestamp
+		})
+	}
+	// Sort the mounts in descending order of new clients
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return mounts[i].NewClients.Clients > mounts[j].NewClients.Clients
+	})
+
+	return map[string]interface{}{
+		"start_time": pq.StartTime.Format(time.RFC3339),
+		"end_time":   pq.EndTime.Format(time.RFC3339),
+		"mounts":     mounts,
+	}, nil
+}
@@ -0,0 +1,335 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	usageReportFormatHTML = "html"
+	usageReportFormatPDF  = "pdf"
+
+	// usageReportTopGrowth is the number of top growth contributors listed
+	// in usage reports.
+	usageReportTopGrowth = 10
+)
+
+// usageReport is a human-readable summary of the client counts of a period,
+// for sharing with people without access to Vault.
+type usageReport struct {
+	StartTime   time.Time
+	EndTime     time.Time
+	GeneratedAt time.Time
+	Total       *ResponseCounts
+	Namespaces  []*ResponseNamespace
+	Months      []*usageReportMonth
+	TopGrowth   []*usageReportGrowth
+}
+
+type usageReportMonth struct {
+	Month      string
+	Clients    int
+	NewClients int
+	// Change is the change of the clients from the previous month, in
+	// percent, or empty for the first month.
+	Change string
+}
+
+type usageReportGrowth struct {
+	NamespacePath string
+	MountPath     string
+	NewClients    int
+}
+
+// buildUsageReport computes the usage report of the period from the
+// activity log.
+func (a *ActivityLog) buildUsageReport(ctx context.Context, startTime, endTime time.Time) (*usageReport, error) {
+	results, err := a.handleQuery(ctx, startTime, endTime, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &usageReport{
+		StartTime:   startTime,
+		EndTime:     endTime,
+		GeneratedAt: a.clock.Now().UTC(),
+		Total:       &ResponseCounts{},
+	}
+	if results == nil {
+		return report, nil
+	}
+	if total, ok := results["total"].(*ResponseCounts); ok {
+		report.Total = total
+	}
+	if namespaces, ok := results["by_namespace"].([]*ResponseNamespace); ok {
+		report.Namespaces = namespaces
+	}
+	months, _ := results["months"].([]*ResponseMonth)
+
+	growth := make(map[[2]string]int)
+	previous := -1
+	for _, month := range months {
+		timestamp, err := time.Parse(time.RFC3339, month.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		m := &usageReportMonth{Month: timestamp.Format("January 2006")}
+		if month.Counts != nil {
+			m.Clients = month.Counts.Clients
+		}
+		if month.NewClients != nil {
+			if month.NewClients.Counts != nil {
+				m.NewClients = month.NewClients.Counts.Clients
+			}
+			for _, ns := range month.NewClients.Namespaces {
+				for _, mount := range ns.Mounts {
+					if mount.Counts != nil {
+						growth[[2]string{ns.NamespacePath, mount.MountPath}] += mount.Counts.Clients
+					}
+				}
+			}
+		}
+		if previous > 0 {
+			m.Change = fmt.Sprintf("%+.1f%%", float64(m.Clients-previous)*100/float64(previous))
+		}
+		previous = m.Clients
+		report.Months = append(report.Months, m)
+	}
+
+	for key, newClients := range growth {
+		if newClients == 0 {
+			continue
+		}
+		report.TopGrowth = append(report.TopGrowth, &usageReportGrowth{
+			NamespacePath: key[0],
+			MountPath:     key[1],
+			NewClients:    newClients,
+		})
+	}
+	sort.Slice(report.TopGrowth, func(i, j int) bool {
+		if report.TopGrowth[i].NewClients != report.TopGrowth[j].NewClients {
+			return report.TopGrowth[i].NewClients > report.TopGrowth[j].NewClients
+		}
+		if report.TopGrowth[i].NamespacePath != report.TopGrowth[j].NamespacePath {
+			return report.TopGrowth[i].NamespacePath < report.TopGrowth[j].NamespacePath
+		}
+		return report.TopGrowth[i].MountPath < report.TopGrowth[j].MountPath
+	})
+	if len(report.TopGrowth) > usageReportTopGrowth {
+		report.TopGrowth = report.TopGrowth[:usageReportTopGrowth]
+	}
+
+	return report, nil
+}
+
+var usageReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	"ns": func(path string) string {
+		if path == "" {
+			return "root"
+		}
+		return path
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Vault usage report {{date .StartTime}} to {{date .EndTime}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>Vault usage report</h1>
+<p>Period: {{date .StartTime}} to {{date .EndTime}}. Generated on {{date .GeneratedAt}}.</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Clients</th><td>{{.Total.Clients}}</td></tr>
+<tr><th>Entity clients</th><td>{{.Total.EntityClients}}</td></tr>
+<tr><th>Non-entity clients</th><td>{{.Total.NonEntityClients}}</td></tr>
+<tr><th>Secret syncs</th><td>{{.Total.SecretSyncs}}</td></tr>
+<tr><th>Excluded clients</th><td>{{.Total.ExcludedClients}}</td></tr>
+</table>
+
+<h2>Monthly trend</h2>
+<table>
+<tr><th>Month</th><th>Clients</th><th>New clients</th><th>Change</th></tr>
+{{- range .Months}}
+<tr><td>{{.Month}}</td><td>{{.Clients}}</td><td>{{.NewClients}}</td><td>{{.Change}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Top growth contributors</h2>
+<table>
+<tr><th>Namespace</th><th>Mount</th><th>New clients</th></tr>
+{{- range .TopGrowth}}
+<tr><td>{{ns .NamespacePath}}</td><td>{{.MountPath}}</td><td>{{.NewClients}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Namespaces</h2>
+{{- range .Namespaces}}
+<h3>{{ns .NamespacePath}}</h3>
+<table>
+<tr><th>Mount</th><th>Clients</th><th>Entity clients</th><th>Non-entity clients</th><th>Secret syncs</th></tr>
+<tr><td><em>total</em></td><td>{{.Counts.Clients}}</td><td>{{.Counts.EntityClients}}</td><td>{{.Counts.NonEntityClients}}</td><td>{{.Counts.SecretSyncs}}</td></tr>
+{{- range .Mounts}}{{if .Counts}}
+<tr><td>{{.MountPath}}</td><td>{{.Counts.Clients}}</td><td>{{.Counts.EntityClients}}</td><td>{{.Counts.NonEntityClients}}</td><td>{{.Counts.SecretSyncs}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML renders the report as a standalone HTML document.
+func (r *usageReport) renderHTML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := usageReportTemplate.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lines renders the report as plain text lines, for the PDF output.
+func (r *usageReport) lines() []string {
+	nsName := func(path string) string {
+		if path == "" {
+			return "root"
+		}
+		return path
+	}
+
+	lines := []string{
+		"Vault usage report",
+		"",
+		fmt.Sprintf("Period: %s to %s. Generated on %s.", r.StartTime.Format("2006-01-02"), r.EndTime.Format("2006-01-02"), r.GeneratedAt.Format("2006-01-02")),
+		"",
+		"Summary",
+		fmt.Sprintf("  Clients: %d", r.Total.Clients),
+		fmt.Sprintf("  Entity clients: %d", r.Total.EntityClients),
+		fmt.Sprintf("  Non-entity clients: %d", r.Total.NonEntityClients),
+		fmt.Sprintf("  Secret syncs: %d", r.Total.SecretSyncs),
+		fmt.Sprintf("  Excluded clients: %d", r.Total.ExcludedClients),
+		"",
+		"Monthly trend",
+		fmt.Sprintf("  %-16s %10s %12s %10s", "Month", "Clients", "New clients", "Change"),
+	}
+	for _, m := range r.Months {
+		lines = append(lines, fmt.Sprintf("  %-16s %10d %12d %10s", m.Month, m.Clients, m.NewClients, m.Change))
+	}
+
+	lines = append(lines, "", "Top growth contributors")
+	for _, g := range r.TopGrowth {
+		lines = append(lines, fmt.Sprintf("  %s %s: %d new clients", nsName(g.NamespacePath), g.MountPath, g.NewClients))
+	}
+
+	lines = append(lines, "", "Namespaces")
+	for _, ns := range r.Namespaces {
+		lines = append(lines, fmt.Sprintf("  %s: %d clients", nsName(ns.NamespacePath), ns.Counts.Clients))
+		for _, mount := range ns.Mounts {
+			clients := 0
+			if mount.Counts != nil {
+				clients = mount.Counts.Clients
+			}
+			lines = append(lines, fmt.Sprintf("    %s: %d clients", mount.MountPath, clients))
+		}
+	}
+	return lines
+}
+
+// renderPDF renders the report as a text-only PDF document.
+func (r *usageReport) renderPDF() []byte {
+	return renderTextPDF(r.lines())
+}
+
+const (
+	pdfLinesPerPage = 60
+	pdfFontSize     = 10
+	pdfLineHeight   = 12
+	pdfMarginLeft   = 50
+	pdfPageHeight   = 842 // A4
+	pdfPageWidth    = 595
+)
+
+// renderTextPDF writes a minimal PDF document showing the lines in a
+// monospaced font, paginated.
+func renderTextPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and a content
+	// stream per page.
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	)
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMarginLeft, pdfPageHeight-pdfMarginLeft)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a line for a PDF string literal; characters outside of
+// printable ASCII are replaced, as the standard fonts do not cover them.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/activity"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// activitySegmentVersionMarker starts versioned segments. It cannot start
+	// a bare protobuf encoding, as field number 0 is invalid, so legacy
+	// segments are told apart from versioned ones.
+	activitySegmentVersionMarker byte = 0x00
+
+	// activitySegmentVersionLegacy is the version of the segments written
+	// before segments were versioned, as bare protobuf encodings.
+	activitySegmentVersionLegacy = 0
+
+	// activitySegmentVersion1 prefixes the protobuf encoding with the marker
+	// and the version.
+	activitySegmentVersion1 = 1
+
+	// activitySegmentCurrentVersion is the version segments are written in.
+	activitySegmentCurrentVersion = activitySegmentVersion1
+
+	// activitySegmentMigrationKey stores the version all the segments were
+	// rewritten in by the background migration.
+	activitySegmentMigrationKey = "segment-migration"
+)
+
+// activitySegmentUpgrades upgrade a decoded segment from the version of the
+// key to the next one. Changes to the segment messages which need more than
+// the protobuf compatibility rules, such as populating a new field from
+// existing ones, add an upgrade and bump activitySegmentCurrentVersion.
+// Upgrades run when segments are read, and the background migration
+// rewrites the segments in the current version.
+var activitySegmentUpgrades = map[int]func(proto.Message) error{
+	// Only the encoding changed.
+	activitySegmentVersionLegacy: func(proto.Message) error { return nil },
+}
+
+// activitySegmentMigration is the progress of the background migration.
+type activitySegmentMigration struct {
+	Version int `json:"version"`
+}
+
+// encodeActivitySegment encodes a segment in the current version.
+func encodeActivitySegment(msg proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{activitySegmentVersionMarker, activitySegmentCurrentVersion}, data...), nil
+}
+
+// activitySegmentVersion returns the version a segment is encoded in.
+func activitySegmentVersion(raw []byte) int {
+	if len(raw) >= 2 && raw[0] == activitySegmentVersionMarker {
+		return int(raw[1])
+	}
+	return activitySegmentVersionLegacy
+}
+
+// decodeActivitySegment decodes a segment of any version up to the current
+// one, and upgrades it to the current version.
+func decodeActivitySegment(raw []byte, out proto.Message) error {
+	version := activitySegmentVersion(raw)
+	if version > activitySegmentCurrentVersion {
+		return fmt.Errorf("segment version %d is newer than the supported version %d", version, activitySegmentCurrentVersion)
+	}
+	if version != activitySegmentVersionLegacy {
+		raw = raw[2:]
+	}
+	if err := proto.Unmarshal(raw, out); err != nil {
+		return err
+	}
+	for ; version < activitySegmentCurrentVersion; version++ {
+		upgrade, ok := activitySegmentUpgrades[version]
+		if !ok {
+			return fmt.Errorf("no upgrade of segment version %d", version)
+		}
+		if err := upgrade(out); err != nil {
+			return fmt.Errorf("failed to upgrade segment from version %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// segmentMigrationWorker rewrites the segments of older versions in the
+// current version, so that the upgrades of old versions can eventually be
+// removed. It runs on the active node only, and records its completion so
+// that it runs once per version; on failure, it runs again on the next
+// startup.
+func (a *ActivityLog) segmentMigrationWorker(ctx context.Context) error {
+	entry, err := a.view.Get(ctx, activitySegmentMigrationKey)
+	if err != nil {
+		return err
+	}
+	var migration activitySegmentMigration
+	if entry != nil {
+		if err := entry.DecodeJSON(&migration); err != nil {
+			return err
+		}
+	}
+	if migration.Version >= activitySegmentCurrentVersion {
+		return nil
+	}
+
+	a.l.RLock()
+	retentionMonths := a.retentionMonths
+	a.l.RUnlock()
+	// Segments out of the retention period are about to be deleted, and
+	// rewriting them could race with their deletion.
+	cutoff := a.startOfMonthsPrevious(retentionMonths, a.clock.Now()).Unix()
+
+	rewritten := 0
+	for _, basePath := range []string{activityEntityBasePath, activityTokenBasePath} {
+		months, err := a.view.List(ctx, basePath)
+		if err != nil {
+			return err
+		}
+		for _, month := range months {
+			var start int64
+			if _, err := fmt.Sscanf(strings.TrimSuffix(month, "/"), "%d", &start); err != nil || start < cutoff {
+				continue
+			}
+			segments, err := a.view.List(ctx, basePath+month)
+			if err != nil {
+				return err
+			}
+			for _, segment := range segments {
+				select {
+				case <-a.doneCh:
+					return nil
+				default:
+				}
+				ok, err := a.migrateSegment(ctx, basePath+month+segment, basePath == activityEntityBasePath)
+				if err != nil {
+					return err
+				}
+				if ok {
+					rewritten++
+				}
+			}
+		}
+	}
+
+	entry, err = logical.StorageEntryJSON(activitySegmentMigrationKey, &activitySegmentMigration{
+		Version: activitySegmentCurrentVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.view.Put(ctx, entry); err != nil {
+		return err
+	}
+	a.logger.Info("migrated activity log segments", "version", activitySegmentCurrentVersion, "rewritten", rewritten)
+	return nil
+}
+
+// migrateSegment rewrites a segment in the current version, and returns
+// whether it was rewritten.
+func (a *ActivityLog) migrateSegment(ctx context.Context, path string, entities bool) (bool, error) {
+	// Hold off segment writes, so that the current segment is not
+	// overwritten with its previous content.
+	a.l.RLock()
+	defer a.l.RUnlock()
+
+	raw, err := a.view.Get(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	if raw == nil || activitySegmentVersion(raw.Value) == activitySegmentCurrentVersion {
+		return false, nil
+	}
+
+	var out proto.Message = &activity.TokenCount{}
+	if entities {
+		out = &activity.EntityActivityLog{}
+	}
+	if err := decodeActivitySegment(raw.Value, out); err != nil {
+		return false, fmt.Errorf("unable to parse segment %v: %w", path, err)
+	}
+	value, err := encodeActivitySegment(out)
+	if err != nil {
+		return false, err
+	}
+	return true, a.view.Put(ctx, &logical.StorageEntry{
+		Key:   path,
+		Value: value,
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// activitySimulationBasePath is where the clients recorded for auth mounts
+// in client count simulation are stored, under the mount accessor.
+const activitySimulationBasePath = "simulation/"
+
+// simulatedClient is the storage entry of a client recorded during a client
+// count simulation.
+type simulatedClient struct {
+	ClientID string `json:"client_id"`
+}
+
+// recordSimulatedClient records a client of an auth mount in client count
+// simulation. The client is not counted toward the activity log.
+func (a *ActivityLog) recordSimulatedClient(ctx context.Context, mountAccessor, clientID string) error {
+	a.simulationLock.Lock()
+	defer a.simulationLock.Unlock()
+
+	if a.simulatedClients == nil {
+		a.simulatedClients = make(map[string]map[string]struct{})
+	}
+	clients, ok := a.simulatedClients[mountAccessor]
+	if !ok {
+		clients = make(map[string]struct{})
+		a.simulatedClients[mountAccessor] = clients
+	}
+	if _, ok := clients[clientID]; ok {
+		return nil
+	}
+	clients[clientID] = struct{}{}
+
+	// Performance standbys cannot write to storage; the clients they see are
+	// only reported by them.
+	if a.core.perfStandby {
+		return nil
+	}
+
+	entry, err := logical.StorageEntryJSON(simulatedClientPath(mountAccessor, clientID), &simulatedClient{
+		ClientID: clientID,
+	})
+	if err != nil {
+		return err
+	}
+	return a.view.Put(ctx, entry)
+}
+
+// simulatedClientCounts returns the number of distinct clients recorded for
+// the auth mount, and how many of them have not been counted this month,
+// which is the projected increase of the client count.
+func (a *ActivityLog) simulatedClientCounts(ctx context.Context, mountAccessor string) (int, int, error) {
+	clients := make(map[string]struct{})
+
+	view := a.view.SubView(activitySimulationBasePath + mountAccessor + "/")
+	keys, err := logical.CollectKeys(ctx, view)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, key := range keys {
+		entry, err := view.Get(ctx, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		if entry == nil {
+			continue
+		}
+		var client simulatedClient
+		if err := entry.DecodeJSON(&client); err != nil {
+			return 0, 0, err
+		}
+		clients[client.ClientID] = struct{}{}
+	}
+
+	a.simulationLock.Lock()
+	for clientID := range a.simulatedClients[mountAccessor] {
+		clients[clientID] = struct{}{}
+	}
+	a.simulationLock.Unlock()
+
+	newClients := 0
+	a.fragmentLock.RLock()
+	for clientID := range clients {
+		if _, ok := a.partialMonthClientTracker[clientID]; !ok {
+			newClients++
+		}
+	}
+	a.fragmentLock.RUnlock()
+
+	return len(clients), newClients, nil
+}
+
+// clearSimulatedClients removes the clients recorded for the auth mount, so
+// that a new simulation starts from scratch.
+func (a *ActivityLog) clearSimulatedClients(ctx context.Context, mountAccessor string) error {
+	a.simulationLock.Lock()
+	defer a.simulationLock.Unlock()
+
+	delete(a.simulatedClients, mountAccessor)
+	return logical.ClearView(ctx, a.view.SubView(activitySimulationBasePath+mountAccessor+"/"))
+}
+
+// simulatedClientPath hashes the client ID into the storage key, as the IDs
+// of clients without entities may contain slashes.
+func simulatedClientPath(mountAccessor, clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return activitySimulationBasePath + mountAccessor + "/" + hex.EncodeToString(sum[:])
+}
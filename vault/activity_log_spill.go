@@ -0,0 +1,263 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/activity"
+)
+
+const (
+	// activitySpillBasePath is where the active node spills the clients of
+	// the current month it tracks beyond the configured limit, under the
+	// start time of the month and a shard of the client ID hash.
+	activitySpillBasePath = "spill/"
+
+	// activitySpillShardChars is the number of hex characters of the client
+	// ID hash naming the shard, which keeps shards below the storage entry
+	// limit up to hundreds of millions of clients.
+	activitySpillShardChars = 4
+
+	// activitySpillFalsePositiveRate is the false positive rate of the
+	// filters of spilled clients; a false positive costs a storage read.
+	activitySpillFalsePositiveRate = 0.001
+)
+
+// spilledClients tracks the clients of the current month moved from memory
+// to storage. Each spill adds a filter sized for the clients it moves, so
+// that the filters do not saturate as the spilled clients grow.
+type spilledClients struct {
+	startTimestamp int64
+	filters        []*clientBloomFilter
+	count          int
+}
+
+func (s *spilledClients) mayContain(clientID string) bool {
+	for _, filter := range s.filters {
+		if filter.mayContain(clientID) {
+			return true
+		}
+	}
+	return false
+}
+
+func activitySpillShard(clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return hex.EncodeToString(sum[:])[:activitySpillShardChars]
+}
+
+func activitySpillShardPath(startTimestamp int64, shard string) string {
+	return fmt.Sprintf("%s%d/%s", activitySpillBasePath, startTimestamp, shard)
+}
+
+// signalSpill asks the active fragment worker to spill clients, if the
+// tracked clients exceed the limit. Must be called with fragmentLock held.
+func (a *ActivityLog) signalSpill() {
+	if a.maxTrackedClients <= 0 || len(a.partialMonthClientTracker) <= a.maxTrackedClients {
+		return
+	}
+	select {
+	case a.spillCh <- struct{}{}:
+	default:
+	}
+}
+
+// spillClients moves clients of the current month from memory to storage,
+// down to half of the limit, so spills do not happen on every new client.
+// The clients moved are already in the fragments and segments; the shards
+// only serve to tell whether a client was seen this month. It runs on the
+// active node only.
+func (a *ActivityLog) spillClients(ctx context.Context) error {
+	if a.core.perfStandby {
+		return nil
+	}
+
+	a.spillLock.Lock()
+	defer a.spillLock.Unlock()
+
+	a.l.RLock()
+	startTimestamp := a.currentSegment.startTimestamp
+	a.l.RUnlock()
+	if startTimestamp == 0 {
+		return nil
+	}
+
+	a.fragmentLock.RLock()
+	limit := a.maxTrackedClients
+	if !a.enabled || limit <= 0 || len(a.partialMonthClientTracker) <= limit {
+		a.fragmentLock.RUnlock()
+		return nil
+	}
+	toSpill := len(a.partialMonthClientTracker) - limit/2
+	byShard := make(map[string][]*activity.EntityRecord)
+	spilled := make([]*activity.EntityRecord, 0, toSpill)
+	for _, record := range a.partialMonthClientTracker {
+		if len(spilled) == toSpill {
+			break
+		}
+		shard := activitySpillShard(record.ClientID)
+		byShard[shard] = append(byShard[shard], record)
+		spilled = append(spilled, record)
+	}
+	a.fragmentLock.RUnlock()
+
+	defer a.metrics.MeasureSinceWithLabels([]string{"core", "activity", "spill"},
+		a.clock.Now(), []metricsutil.Label{})
+
+	for shard, records := range byShard {
+		stored, err := a.readSpillShard(ctx, startTimestamp, shard)
+		if err != nil {
+			return err
+		}
+		stored.Clients = append(stored.Clients, records...)
+		value, err := encodeActivitySegment(stored)
+		if err != nil {
+			return err
+		}
+		err = a.view.Put(ctx, &logical.StorageEntry{
+			Key:   activitySpillShardPath(startTimestamp, shard),
+			Value: value,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	filter := newClientBloomFilter(len(spilled), activitySpillFalsePositiveRate)
+	for _, record := range spilled {
+		filter.add(record.ClientID)
+	}
+
+	a.fragmentLock.Lock()
+	if a.spilled == nil || a.spilled.startTimestamp != startTimestamp {
+		a.spilled = &spilledClients{startTimestamp: startTimestamp}
+	}
+	a.spilled.filters = append(a.spilled.filters, filter)
+	a.spilled.count += len(spilled)
+	for _, record := range spilled {
+		// The record may have been replaced since, if the month rotated.
+		if a.partialMonthClientTracker[record.ClientID] == record {
+			delete(a.partialMonthClientTracker, record.ClientID)
+		}
+	}
+	a.fragmentLock.Unlock()
+
+	a.metrics.IncrCounterWithLabels([]string{"core", "activity", "clients_spilled"},
+		float32(len(spilled)), []metricsutil.Label{})
+	a.logger.Debug("spilled activity log clients to storage", "clients", len(spilled), "shards", len(byShard))
+	return nil
+}
+
+func (a *ActivityLog) readSpillShard(ctx context.Context, startTimestamp int64, shard string) (*activity.EntityActivityLog, error) {
+	out := &activity.EntityActivityLog{}
+	raw, err := a.view.Get(ctx, activitySpillShardPath(startTimestamp, shard))
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return out, nil
+	}
+	if err := decodeActivitySegment(raw.Value, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// spilledClient returns whether the client was spilled to storage this
+// month. Must not be called with fragmentLock held, as it reads storage.
+func (a *ActivityLog) spilledClient(clientID string) bool {
+	a.fragmentLock.RLock()
+	spilled := a.spilled
+	maybe := spilled != nil && spilled.mayContain(clientID)
+	a.fragmentLock.RUnlock()
+	if !maybe {
+		return false
+	}
+
+	a.metrics.IncrCounterWithLabels([]string{"core", "activity", "spill_lookup"},
+		1, []metricsutil.Label{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), activitySegmentWriteTimeout)
+	defer cancel()
+	shard, err := a.readSpillShard(ctx, spilled.startTimestamp, activitySpillShard(clientID))
+	if err != nil {
+		// Count the client again rather than risk not counting it.
+		a.logger.Warn("failed to look up spilled client", "error", err)
+		return false
+	}
+	for _, record := range shard.Clients {
+		if record.ClientID == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// addSpilledClientBreakdowns adds the spilled clients of the current month
+// to the breakdowns. Must be called with fragmentLock held, so that clients
+// tracked both in memory and in storage are counted once.
+func (a *ActivityLog) addSpilledClientBreakdowns(ctx context.Context, byMonth map[int64]*processMonth, byNamespace map[string]*processByNamespace) error {
+	now := a.startOfMonth(a.clock.Now())
+	return a.walkSpilledClients(ctx, func(record *activity.EntityRecord) {
+		processClientRecord(record, byNamespace, byMonth, now)
+	})
+}
+
+// walkSpilledClients calls fn with the spilled clients of the current month
+// which are not tracked in memory. Must be called with fragmentLock held.
+func (a *ActivityLog) walkSpilledClients(ctx context.Context, fn func(*activity.EntityRecord)) error {
+	if a.spilled == nil {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("%s%d/", activitySpillBasePath, a.spilled.startTimestamp)
+	shards, err := a.view.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		if strings.HasSuffix(shard, "/") {
+			continue
+		}
+		stored, err := a.readSpillShard(ctx, a.spilled.startTimestamp, shard)
+		if err != nil {
+			return err
+		}
+		for _, record := range stored.Clients {
+			if _, ok := a.partialMonthClientTracker[record.ClientID]; ok {
+				continue
+			}
+			fn(record)
+		}
+	}
+	return nil
+}
+
+// resetSpilledClientsLocked forgets the spilled clients when the month
+// rotates or the activity log is disabled, and deletes them from storage.
+// Must be called with fragmentLock held.
+func (a *ActivityLog) resetSpilledClientsLocked() {
+	if a.spilled == nil {
+		return
+	}
+	startTimestamp := a.spilled.startTimestamp
+	a.spilled = nil
+
+	if a.core.perfStandby {
+		return
+	}
+	go func() {
+		view := a.view.SubView(fmt.Sprintf("%s%d/", activitySpillBasePath, startTimestamp))
+		if err := logical.ClearView(context.Background(), view); err != nil {
+			a.logger.Warn("failed to delete spilled clients", "error", err)
+		}
+	}()
+}
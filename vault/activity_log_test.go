@@ -26,6 +26,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/constants"
+	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/timeutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -167,6 +168,520 @@ func TestActivityLog_Creation_WrappingTokens(t *testing.T) {
 	a.fragmentLock.Unlock()
 }
 
+// TestActivityLog_ClientCountSimulation verifies that the clients of an auth
+// mount in client count simulation are recorded and reported instead of
+// being counted, until the simulation ends.
+func TestActivityLog_ClientCountSimulation(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	a := c.activityLog
+	a.SetEnable(true)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/foo")
+	req.Data["type"] = "noop"
+	_, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "mounts/bar")
+	req.Data["type"] = "noop"
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "mounts/bar/tune")
+	req.Data["client_count_simulation_period"] = "1h"
+	resp, err := b.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.True(t, resp.IsError(), "expected simulation of a secrets mount to be rejected")
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/foo/tune")
+	req.Data["client_count_simulation_period"] = "1h"
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	resp, err = b.HandleRequest(ctx, logical.TestRequest(t, logical.ReadOperation, "auth/foo/tune"))
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data["client_count_simulation_end"])
+
+	te := &logical.TokenEntry{
+		Path:         "auth/foo/login",
+		EntityID:     "entity-1",
+		Policies:     []string{"default"},
+		CreationTime: time.Now().Unix(),
+		TTL:          3600,
+		NamespaceID:  namespace.RootNamespaceID,
+	}
+	id, isTWE := te.CreateClientID()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, a.HandleTokenUsage(ctx, te, id, isTWE))
+	}
+
+	a.fragmentLock.RLock()
+	require.Nil(t, a.fragment, "expected simulated client not to be counted")
+	a.fragmentLock.RUnlock()
+
+	report := func() map[string]interface{} {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, logical.TestRequest(t, logical.ReadOperation, "internal/counters/simulation"))
+		require.NoError(t, err)
+		mounts := resp.Data["mounts"].([]map[string]interface{})
+		require.Len(t, mounts, 1)
+		return mounts[0]
+	}
+
+	mount := report()
+	require.Equal(t, "auth/foo/", mount["mount_path"])
+	require.Equal(t, true, mount["simulation_active"])
+	require.Equal(t, 1, mount["simulated_clients"])
+	require.Equal(t, 1, mount["projected_new_clients"])
+
+	// Ending the simulation counts the clients again, and keeps the report.
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/foo/tune")
+	req.Data["client_count_simulation_period"] = "0"
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, a.HandleTokenUsage(ctx, te, id, isTWE))
+	checkExpectedEntitiesInMap(t, a, []string{"entity-1"})
+
+	mount = report()
+	require.Equal(t, false, mount["simulation_active"])
+	require.Equal(t, 1, mount["simulated_clients"])
+	require.Equal(t, 0, mount["projected_new_clients"])
+}
+
+// TestActivityLog_ExclusionRules verifies that the clients matching the
+// exclusion rules are tracked as excluded, and are not billable.
+func TestActivityLog_ExclusionRules(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	a := core.activityLog
+	a.SetEnable(true)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "internal/counters/config")
+	req.Storage = core.systemBarrierView
+	req.Data["enabled"] = "enable"
+	req.Data["retention_months"] = 24
+	req.Data["excluded_policies"] = "load-test"
+	_, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/config")
+	req.Storage = core.systemBarrierView
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"load-test"}, resp.Data["excluded_policies"])
+
+	excluded := &logical.TokenEntry{
+		Path:         "test",
+		EntityID:     "excluded-entity",
+		Policies:     []string{"default", "load-test"},
+		CreationTime: time.Now().Unix(),
+		NamespaceID:  namespace.RootNamespaceID,
+	}
+	counted := &logical.TokenEntry{
+		Path:         "test",
+		EntityID:     "counted-entity",
+		Policies:     []string{"default"},
+		CreationTime: time.Now().Unix(),
+		NamespaceID:  namespace.RootNamespaceID,
+	}
+	for _, te := range []*logical.TokenEntry{excluded, counted} {
+		id, isTWE := te.CreateClientID()
+		require.NoError(t, a.HandleTokenUsage(ctx, te, id, isTWE))
+	}
+
+	counts := newProcessCounts()
+	a.fragmentLock.RLock()
+	for _, client := range a.fragment.Clients {
+		counts.add(client)
+	}
+	a.fragmentLock.RUnlock()
+
+	record := counts.toCountsRecord()
+	require.Equal(t, 1, record.EntityClients)
+	require.Equal(t, 1, record.ExcludedClients)
+
+	response := a.countsRecordToCountsResponse(record, false)
+	require.Equal(t, 1, response.Clients, "expected excluded clients not to be billable")
+	require.Equal(t, 1, response.ExcludedClients)
+}
+
+// TestActivityLog_UsageReport generates usage reports in both formats, and
+// verifies their content.
+func TestActivityLog_UsageReport(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	core.activityLog.SetEnable(true)
+	ctx := namespace.RootContext(nil)
+
+	for format, contentType := range map[string]string{
+		"html": "text/html; charset=utf-8",
+		"pdf":  "application/pdf",
+	} {
+		req := logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/report")
+		req.Data["format"] = format
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, contentType, resp.Data[logical.HTTPContentType])
+		require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+		require.NotEmpty(t, resp.Data[logical.HTTPRawBody])
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/report")
+	req.Data["format"] = "docx"
+	resp, err := b.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.True(t, resp.IsError())
+
+	report := &usageReport{
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Total:     &ResponseCounts{Clients: 30, EntityClients: 30},
+		Namespaces: []*ResponseNamespace{
+			{
+				NamespacePath: "",
+				Counts:        ResponseCounts{Clients: 30, EntityClients: 30},
+				Mounts: []*ResponseMount{
+					{MountPath: "auth/userpass (prod)/", Counts: &ResponseCounts{Clients: 30, EntityClients: 30}},
+				},
+			},
+		},
+		Months: []*usageReportMonth{
+			{Month: "January 2024", Clients: 10, NewClients: 10},
+			{Month: "February 2024", Clients: 25, NewClients: 20, Change: "+150.0%"},
+		},
+		TopGrowth: []*usageReportGrowth{
+			{MountPath: "auth/userpass (prod)/", NewClients: 30},
+		},
+	}
+
+	html, err := report.renderHTML()
+	require.NoError(t, err)
+	require.Contains(t, string(html), "<td>February 2024</td><td>25</td><td>20</td><td>+150.0%</td>")
+	require.Contains(t, string(html), "<td>root</td><td>auth/userpass (prod)/</td><td>30</td>")
+
+	pdf := string(report.renderPDF())
+	require.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	require.Contains(t, pdf, `(  root auth/userpass \(prod\)/: 30 new clients) Tj`)
+}
+
+// TestActivityLog_Forecast verifies the fit of the usage forecast, and that
+// forecasts require enough history.
+func TestActivityLog_Forecast(t *testing.T) {
+	fit := fitLinear([]float64{0, 1, 2, 3}, []float64{10, 20, 30, 40})
+	require.InDelta(t, 10, fit.slope, 1e-9)
+	require.InDelta(t, 10, fit.intercept, 1e-9)
+	require.InDelta(t, 60, fit.predict(5), 1e-9)
+	require.InDelta(t, 0, fit.predictionInterval(5), 1e-9, "expected an exact fit to have no uncertainty")
+
+	fit = fitLinear([]float64{0, 1, 2, 3}, []float64{10, 25, 25, 40})
+	require.Greater(t, fit.predictionInterval(10), fit.predictionInterval(2), "expected the interval to widen away from the data")
+
+	projection := newActivityProjection(5, 10)
+	require.Equal(t, &activityProjection{Estimate: 5, Lower: 0, Upper: 15}, projection)
+
+	require.Equal(t, 14, monthsBetween(time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)))
+
+	core, b, _ := testCoreSystemBackend(t)
+	core.activityLog.SetEnable(true)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/forecast")
+	req.Data["history_months"] = 2
+	resp, err := b.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.True(t, resp.IsError())
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/forecast")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected an error without client counts")
+	require.Contains(t, resp.Error().Error(), errInsufficientActivityHistory.Error())
+}
+
+// TestActivityLog_Baselines verifies that client count baselines are
+// validated and stored, and that their counts are added to the totals of the
+// periods they overlap, with their provenance.
+func TestActivityLog_Baselines(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	a := core.activityLog
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "internal/counters/baselines/merged")
+	req.Data["start_time"] = "2024-01-01T00:00:00Z"
+	req.Data["end_time"] = "2024-07-01T00:00:00Z"
+	req.Data["entity_clients"] = 100
+	req.Data["non_entity_clients"] = 20
+	_, err := b.HandleRequest(ctx, req)
+	require.Error(t, err, "expected the source to be required")
+
+	req.Data["source"] = "cluster-b"
+	req.DisplayName = "token-admin"
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ListOperation, "internal/counters/baselines")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"merged"}, resp.Data["keys"])
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/baselines/merged")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "cluster-b", resp.Data["source"])
+	require.Equal(t, "token-admin", resp.Data["created_by"])
+
+	totals := &ResponseCounts{EntityClients: 5, DistinctEntities: 5, Clients: 5}
+	provenance, err := a.addBaselines(ctx, totals, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, provenance, 1)
+	require.Equal(t, "merged", provenance[0]["name"])
+	require.Equal(t, &ResponseCounts{EntityClients: 105, DistinctEntities: 105, NonEntityTokens: 20, NonEntityClients: 20, Clients: 125}, totals)
+
+	totals = &ResponseCounts{}
+	provenance, err = a.addBaselines(ctx, totals, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, provenance)
+	require.Equal(t, &ResponseCounts{}, totals)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "internal/counters/baselines/merged")
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	baselines, err := a.listBaselines(ctx)
+	require.NoError(t, err)
+	require.Empty(t, baselines)
+}
+
+// TestActivityLog_AccessorMappings verifies that entities logging in through
+// an auth mount which replaced another are counted as the entities of their
+// aliases on the replaced mount, until the mapping expires.
+func TestActivityLog_AccessorMappings(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	a := core.activityLog
+	ctx := namespace.RootContext(nil)
+	newAccessor := core.router.MatchingMountEntry(ctx, "auth/token/").Accessor
+
+	for _, entity := range []*identity.Entity{
+		{
+			ID:          "old-entity",
+			NamespaceID: namespace.RootNamespaceID,
+			Aliases: []*identity.Alias{{
+				ID:            "old-alias",
+				CanonicalID:   "old-entity",
+				MountAccessor: "auth_userpass_old",
+				Name:          "alice",
+			}},
+		},
+		{
+			ID:          "new-entity",
+			NamespaceID: namespace.RootNamespaceID,
+			Aliases: []*identity.Alias{{
+				ID:            "new-alias",
+				CanonicalID:   "new-entity",
+				MountAccessor: newAccessor,
+				Name:          "alice",
+			}},
+		},
+	} {
+		require.NoError(t, core.identityStore.upsertEntity(ctx, entity, nil, false))
+	}
+	require.Equal(t, "new-entity", a.reidentifyClient("new-entity", newAccessor))
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "internal/counters/accessor-mappings/auth_userpass_old")
+	req.Data["new_accessor"] = "auth_missing"
+	req.Data["expiration_time"] = time.Now().Add(time.Hour).Format(time.RFC3339)
+	_, err := b.HandleRequest(ctx, req)
+	require.Error(t, err, "expected the new accessor to be validated")
+
+	req.Data["new_accessor"] = newAccessor
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ListOperation, "internal/counters/accessor-mappings")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth_userpass_old"}, resp.Data["keys"])
+
+	require.Equal(t, "old-entity", a.reidentifyClient("new-entity", newAccessor))
+	require.Equal(t, "other-entity", a.reidentifyClient("other-entity", newAccessor))
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "internal/counters/accessor-mappings/auth_userpass_old")
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "new-entity", a.reidentifyClient("new-entity", newAccessor))
+}
+
+// TestActivityLog_KnownClients verifies that the filter of known clients
+// written by the active node deduplicates fragments once read back.
+func TestActivityLog_KnownClients(t *testing.T) {
+	filter := newClientBloomFilter(1000, activityKnownClientsFalsePositiveRate)
+	for i := 0; i < 1000; i++ {
+		filter.add(fmt.Sprintf("client-%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		require.True(t, filter.mayContain(fmt.Sprintf("client-%d", i)))
+	}
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if filter.mayContain(fmt.Sprintf("client-%d", i)) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 10)
+
+	core, _, _ := TestCoreUnsealed(t)
+	a := core.activityLog
+	a.SetEnable(true)
+	a.SetStartTimestamp(time.Now().Unix()) // set a nonzero segment
+	ctx := context.Background()
+
+	a.AddEntityToFragment("known-entity", namespace.RootNamespaceID, time.Now().Unix())
+	require.NoError(t, a.writeKnownClients(ctx))
+	header, err := a.readKnownClientsHeader(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, header.Clients)
+
+	// Nothing new, so the filter is not rewritten.
+	require.NoError(t, a.writeKnownClients(ctx))
+	unchanged, err := a.readKnownClientsHeader(ctx)
+	require.NoError(t, err)
+	require.Equal(t, header.Generation, unchanged.Generation)
+
+	require.NoError(t, a.refreshKnownClients(ctx))
+	a.fragmentLock.RLock()
+	require.NotNil(t, a.knownClients)
+	require.Equal(t, header.Generation, a.knownClientsGeneration)
+	a.fragmentLock.RUnlock()
+
+	a.AddEntityToFragment("new-entity", namespace.RootNamespaceID, time.Now().Unix())
+	a.deduplicateFragment()
+
+	a.fragmentLock.RLock()
+	defer a.fragmentLock.RUnlock()
+	require.Len(t, a.fragment.Clients, 1)
+	require.Equal(t, "new-entity", a.fragment.Clients[0].ClientID)
+}
+
+// TestActivityLog_SpillClients verifies that the clients over the tracked
+// clients limit are spilled to storage, and are still deduplicated and
+// counted.
+func TestActivityLog_SpillClients(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	a := core.activityLog
+	a.SetEnable(true)
+	a.SetStartTimestamp(time.Now().Unix()) // set a nonzero segment
+	ctx := context.Background()
+
+	a.fragmentLock.Lock()
+	a.maxTrackedClients = 4
+	a.fragmentLock.Unlock()
+
+	for i := 0; i < 10; i++ {
+		a.AddEntityToFragment(fmt.Sprintf("entity-%d", i), namespace.RootNamespaceID, time.Now().Unix())
+	}
+	require.NoError(t, a.spillClients(ctx))
+
+	a.fragmentLock.RLock()
+	require.LessOrEqual(t, len(a.partialMonthClientTracker), 4)
+	require.NotNil(t, a.spilled)
+	require.Equal(t, 10, len(a.partialMonthClientTracker)+a.spilled.count)
+	fragmentClients := len(a.fragment.Clients)
+	a.fragmentLock.RUnlock()
+	require.Equal(t, 10, fragmentClients)
+
+	// Spilled clients are still known this month.
+	for i := 0; i < 10; i++ {
+		a.AddEntityToFragment(fmt.Sprintf("entity-%d", i), namespace.RootNamespaceID, time.Now().Unix())
+	}
+	a.fragmentLock.RLock()
+	require.Len(t, a.fragment.Clients, 10)
+	a.fragmentLock.RUnlock()
+
+	counts, err := a.partialMonthClientCount(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 10, counts["clients"])
+
+	metrics, err := a.PartialMonthMetrics(ctx)
+	require.NoError(t, err)
+	require.Equal(t, float32(10), metrics[0].Value)
+}
+
+// TestActivityLog_SegmentVersioning verifies that segments of all versions
+// are read, and that the migration rewrites legacy segments.
+func TestActivityLog_SegmentVersioning(t *testing.T) {
+	segment := &activity.EntityActivityLog{
+		Clients: []*activity.EntityRecord{{ClientID: "client", NamespaceID: namespace.RootNamespaceID}},
+	}
+	legacy, err := proto.Marshal(segment)
+	require.NoError(t, err)
+	versioned, err := encodeActivitySegment(segment)
+	require.NoError(t, err)
+	require.Equal(t, activitySegmentVersionLegacy, activitySegmentVersion(legacy))
+	require.Equal(t, activitySegmentCurrentVersion, activitySegmentVersion(versioned))
+
+	for _, raw := range [][]byte{legacy, versioned} {
+		out := &activity.EntityActivityLog{}
+		require.NoError(t, decodeActivitySegment(raw, out))
+		require.Equal(t, "client", out.Clients[0].ClientID)
+	}
+
+	future := append([]byte{activitySegmentVersionMarker, activitySegmentCurrentVersion + 1}, legacy...)
+	require.Error(t, decodeActivitySegment(future, &activity.EntityActivityLog{}))
+
+	core, _, _ := TestCoreUnsealed(t)
+	a := core.activityLog
+	ctx := context.Background()
+
+	path := fmt.Sprintf("%s%d/0", activityEntityBasePath, timeutil.StartOfMonth(time.Now().UTC()).Unix())
+	require.NoError(t, a.view.Delete(ctx, activitySegmentMigrationKey))
+	require.NoError(t, a.view.Put(ctx, &logical.StorageEntry{Key: path, Value: legacy}))
+	require.NoError(t, a.segmentMigrationWorker(ctx))
+
+	entry, err := a.view.Get(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, versioned, entry.Value)
+
+	entry, err = a.view.Get(ctx, activitySegmentMigrationKey)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+}
+
+// TestActivityLog_ClientIndex verifies that the clients written to segments
+// are indexed, and that their history is reported.
+func TestActivityLog_ClientIndex(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	a := core.activityLog
+	a.SetEnable(true)
+	a.SetStartTimestamp(timeutil.StartOfMonth(time.Now().UTC()).Unix())
+	ctx := namespace.RootContext(nil)
+
+	a.AddClientToFragment("indexed-entity", namespace.RootNamespaceID, time.Now().Unix(), false, "auth_userpass_1234")
+	require.NoError(t, a.saveCurrentSegmentToStorage(ctx, false))
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/client/indexed-entity")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "indexed-entity", resp.Data["client_id"])
+	months := resp.Data["months"].([]map[string]interface{})
+	require.Len(t, months, 1)
+	require.Equal(t, "auth_userpass_1234", months[0]["mount_accessor"])
+	require.Equal(t, entityActivityType, months[0]["client_type"])
+	require.Equal(t, "root", months[0]["namespace_path"])
+
+	// Writing the segment again does not duplicate the month.
+	require.NoError(t, a.indexClients(ctx, a.GetStartTimestamp(), map[string]*activity.EntityRecord{
+		"indexed-entity": {ClientID: "indexed-entity", NamespaceID: namespace.RootNamespaceID},
+	}))
+	history, err := a.clientActivityHistory(ctx, "indexed-entity")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/client/unknown")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
 func checkExpectedEntitiesInMap(t *testing.T, a *ActivityLog, entityIDs []string) {
 	t.Helper()
 
@@ -315,7 +830,7 @@ func TestActivityLog_SaveTokensToStorage(t *testing.T) {
 
 	out := &activity.TokenCount{}
 	protoSegment := readSegmentFromStorage(t, core, path)
-	err = proto.Unmarshal(protoSegment.Value, out)
+	err = decodeActivitySegment(protoSegment.Value, out)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -347,7 +862,7 @@ func TestActivityLog_SaveTokensToStorage(t *testing.T) {
 
 	protoSegment = readSegmentFromStorage(t, core, path)
 	out = &activity.TokenCount{}
-	err = proto.Unmarshal(protoSegment.Value, out)
+	err = decodeActivitySegment(protoSegment.Value, out)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -419,7 +934,7 @@ func TestActivityLog_SaveTokensToStorageDoesNotUpdateTokenCount(t *testing.T) {
 
 	e := readSegmentFromStorage(t, core, clientPath)
 	out := &activity.EntityActivityLog{}
-	err = proto.Unmarshal(e.Value, out)
+	err = decodeActivitySegment(e.Value, out)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -479,7 +994,7 @@ func TestActivityLog_SaveEntitiesToStorage(t *testing.T) {
 
 	protoSegment := readSegmentFromStorage(t, core, path)
 	out := &activity.EntityActivityLog{}
-	err = proto.Unmarshal(protoSegment.Value, out)
+	err = decodeActivitySegment(protoSegment.Value, out)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -494,7 +1009,7 @@ func TestActivityLog_SaveEntitiesToStorage(t *testing.T) {
 
 	protoSegment = readSegmentFromStorage(t, core, path)
 	out = &activity.EntityActivityLog{}
-	err = proto.Unmarshal(protoSegment.Value, out)
+	err = decodeActivitySegment(protoSegment.Value, out)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -712,7 +1227,7 @@ func TestActivityLog_MultipleFragmentsAndSegments(t *testing.T) {
 
 	protoSegment0 := readSegmentFromStorage(t, core, path0)
 	entityLog0 := activity.EntityActivityLog{}
-	err = proto.Unmarshal(protoSegment0.Value, &entityLog0)
+	err = decodeActivitySegment(protoSegment0.Value, &entityLog0)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -782,7 +1297,7 @@ func TestActivityLog_MultipleFragmentsAndSegments(t *testing.T) {
 	}
 
 	protoSegment0 = readSegmentFromStorage(t, core, path0)
-	err = proto.Unmarshal(protoSegment0.Value, &entityLog0)
+	err = decodeActivitySegment(protoSegment0.Value, &entityLog0)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -793,7 +1308,7 @@ func TestActivityLog_MultipleFragmentsAndSegments(t *testing.T) {
 
 	protoSegment1 := readSegmentFromStorage(t, core, path1)
 	entityLog1 := activity.EntityActivityLog{}
-	err = proto.Unmarshal(protoSegment1.Value, &entityLog1)
+	err = decodeActivitySegment(protoSegment1.Value, &entityLog1)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -804,7 +1319,7 @@ func TestActivityLog_MultipleFragmentsAndSegments(t *testing.T) {
 
 	protoSegment2 := readSegmentFromStorage(t, core, path2)
 	entityLog2 := activity.EntityActivityLog{}
-	err = proto.Unmarshal(protoSegment2.Value, &entityLog2)
+	err = decodeActivitySegment(protoSegment2.Value, &entityLog2)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -837,7 +1352,7 @@ func TestActivityLog_MultipleFragmentsAndSegments(t *testing.T) {
 	}
 	tokenSegment := readSegmentFromStorage(t, core, tokenPath)
 	tokenCount := activity.TokenCount{}
-	err = proto.Unmarshal(tokenSegment.Value, &tokenCount)
+	err = decodeActivitySegment(tokenSegment.Value, &tokenCount)
 	if err != nil {
 		t.Fatalf("could not unmarshal protobuf: %v", err)
 	}
@@ -2242,7 +2757,7 @@ func TestActivityLog_EndOfMonth(t *testing.T) {
 	path := fmt.Sprintf("%ventity/%v/0", ActivityLogPrefix, segment0)
 	protoSegment := readSegmentFromStorage(t, core, path)
 	out := &activity.EntityActivityLog{}
-	err := proto.Unmarshal(protoSegment.Value, out)
+	err := decodeActivitySegment(protoSegment.Value, out)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2303,7 +2818,7 @@ func TestActivityLog_EndOfMonth(t *testing.T) {
 		path := fmt.Sprintf("%ventity/%v/0", ActivityLogPrefix, tc.SegmentTimestamp)
 		protoSegment := readSegmentFromStorage(t, core, path)
 		out := &activity.EntityActivityLog{}
-		err = proto.Unmarshal(protoSegment.Value, out)
+		err = decodeActivitySegment(protoSegment.Value, out)
 		if err != nil {
 			t.Fatalf("could not unmarshal protobuf: %v", err)
 		}
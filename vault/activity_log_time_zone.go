@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/timeutil"
+)
+
+// activityTimeZone is a time zone the activity log computes the boundaries of
+// the months in, from Since on. Time zones are changed at the start of a
+// month, so that no month is split across time zones.
+type activityTimeZone struct {
+	Name  string `json:"name"`
+	Since int64  `json:"since"`
+}
+
+// activityMonthZones are the time zones the months were computed in, in the
+// order they were set. Months are computed in UTC before the first one.
+type activityMonthZones struct {
+	history   []activityTimeZone
+	locations []*time.Location
+}
+
+func newActivityMonthZones(history []activityTimeZone) (*activityMonthZones, error) {
+	z := &activityMonthZones{
+		history:   history,
+		locations: make([]*time.Location, 0, len(history)),
+	}
+	for _, zone := range history {
+		loc, err := time.LoadLocation(zone.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load time zone %q: %w", zone.Name, err)
+		}
+		z.locations = append(z.locations, loc)
+	}
+	return z, nil
+}
+
+// location returns the time zone the months are reported in.
+func (z *activityMonthZones) location() *time.Location {
+	if len(z.locations) == 0 {
+		return time.UTC
+	}
+	return z.locations[len(z.locations)-1]
+}
+
+// at returns the time zone the month of t was computed in.
+func (z *activityMonthZones) at(t time.Time) *time.Location {
+	loc := time.UTC
+	for i, zone := range z.history {
+		if t.Unix() < zone.Since {
+			break
+		}
+		loc = z.locations[i]
+	}
+	return loc
+}
+
+// monthOf returns the start, in loc, of the month starting at t, such as the
+// start of a segment, in the time zone the month was computed in.
+func (z *activityMonthZones) monthOf(t time.Time, loc *time.Location) time.Time {
+	year, month, _ := t.In(z.at(t)).Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+}
+
+// monthOfEnd returns the start, in loc, of the month ending at t, such as the
+// end of a precomputed query. The month ended in the time zone the next month
+// was computed in.
+func (z *activityMonthZones) monthOfEnd(t time.Time, loc *time.Location) time.Time {
+	year, month, _ := t.In(z.at(t.Add(time.Second))).Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+}
+
+// startOf returns the instant the given month started at, in the time zone it
+// was computed in.
+func (z *activityMonthZones) startOf(month time.Time) time.Time {
+	year, m, _ := month.Date()
+	for i := len(z.history) - 1; i >= 0; i-- {
+		start := time.Date(year, m, 1, 0, 0, 0, 0, z.locations[i])
+		if start.Unix() >= z.history[i].Since {
+			return start
+		}
+	}
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (a *ActivityLog) monthZones() *activityMonthZones {
+	if z := a.monthZonesPtr.Load(); z != nil {
+		return z
+	}
+	return &activityMonthZones{}
+}
+
+// setMonthZones sets the time zones of the months from the configuration.
+// It returns whether the time zone the months are reported in changed.
+func (a *ActivityLog) setMonthZones(history []activityTimeZone) bool {
+	z, err := newActivityMonthZones(history)
+	if err != nil {
+		// Time zones are validated when configured, so the time zone
+		// database of this node must be missing it
+		a.logger.Error("failed to load the time zones of the activity log, keeping the current ones", "error", err)
+		return false
+	}
+	previous := a.monthZones().location()
+	a.monthZonesPtr.Store(z)
+	return previous.String() != z.location().String()
+}
+
+// location returns the time zone the months are reported in.
+func (a *ActivityLog) location() *time.Location {
+	return a.monthZones().location()
+}
+
+// now returns the current time, in the time zone the months are reported in.
+func (a *ActivityLog) now() time.Time {
+	return a.clock.Now().In(a.location())
+}
+
+// currentMonth returns the start of the current month, in the time zone the
+// months are reported in.
+func (a *ActivityLog) currentMonth() time.Time {
+	return a.monthOf(a.clock.Now())
+}
+
+// monthOf returns the start, in the time zone the months are reported in, of
+// the month starting at t, such as the start of a segment.
+func (a *ActivityLog) monthOf(t time.Time) time.Time {
+	z := a.monthZones()
+	return z.monthOf(t, z.location())
+}
+
+// monthOfEnd returns the start, in the time zone the months are reported in,
+// of the month ending at t, such as the end of a precomputed query.
+func (a *ActivityLog) monthOfEnd(t time.Time) time.Time {
+	z := a.monthZones()
+	return z.monthOfEnd(t, z.location())
+}
+
+// startOfMonth returns the instant the month of t started at, such as the
+// start of the segment of a new month, or the key of a month in a breakdown.
+func (a *ActivityLog) startOfMonth(t time.Time) time.Time {
+	z := a.monthZones()
+	return z.startOf(z.monthOf(t, time.UTC))
+}
+
+// startOfNextMonth returns the instant the month after the month of t starts
+// at.
+func (a *ActivityLog) startOfNextMonth(t time.Time) time.Time {
+	z := a.monthZones()
+	return z.startOf(timeutil.StartOfNextMonth(z.monthOf(t, time.UTC)))
+}
+
+// startOfMonthsPrevious returns the instant the month the given number of
+// months before the month of t started at.
+func (a *ActivityLog) startOfMonthsPrevious(months int, t time.Time) time.Time {
+	z := a.monthZones()
+	return z.startOf(timeutil.MonthsPreviousTo(months, z.monthOf(t, time.UTC)))
+}
+
+// withTimeZone returns the time zones of the configuration, with the months
+// computed in the given time zone from the next month on. If the activity log
+// is not recording a month, the time zone applies immediately.
+func (a *ActivityLog) withTimeZone(history []activityTimeZone, loc *time.Location) []activityTimeZone {
+	now := a.clock.Now()
+
+	// Drop the time zones which do not apply yet, as they are replaced
+	applied := make([]activityTimeZone, 0, len(history)+1)
+	for _, zone := range history {
+		if zone.Since <= now.Unix() {
+			applied = append(applied, zone)
+		}
+	}
+	z, err := newActivityMonthZones(applied)
+	if err != nil {
+		z = a.monthZones()
+	}
+	if z.location().String() == loc.String() {
+		return applied
+	}
+
+	a.l.RLock()
+	currentSegment := a.currentSegment.startTimestamp
+	a.l.RUnlock()
+	since := now
+	if currentSegment != 0 {
+		since = timeutil.StartOfNextMonth(z.monthOf(time.Unix(currentSegment, 0), loc))
+	}
+	return append(applied, activityTimeZone{
+		Name:  loc.String(),
+		Since: since.Unix(),
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestActivityMonthZones verifies that the months recorded before a change of
+// time zone keep their boundaries, and that the months after it are computed
+// in the new time zone.
+func TestActivityMonthZones(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	// Switch from UTC to New York at the start of April in New York
+	since := time.Date(2024, time.April, 1, 0, 0, 0, 0, newYork)
+	z, err := newActivityMonthZones([]activityTimeZone{{Name: newYork.String(), Since: since.Unix()}})
+	require.NoError(t, err)
+	require.Equal(t, newYork.String(), z.location().String())
+
+	// March started in UTC, and is reported as March in New York
+	march := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, newYork), z.monthOf(march, newYork))
+	require.Equal(t, march.Unix(), z.startOf(march).Unix())
+
+	// March ended when April started in New York
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, newYork), z.monthOfEnd(since.Add(-time.Second), newYork))
+
+	// April started in New York
+	require.Equal(t, time.Date(2024, time.April, 1, 0, 0, 0, 0, newYork), z.monthOf(since, newYork))
+	require.Equal(t, since.Unix(), z.startOf(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)).Unix())
+
+	// Without time zones, months are computed in UTC
+	z, err = newActivityMonthZones(nil)
+	require.NoError(t, err)
+	require.Equal(t, time.UTC.String(), z.location().String())
+	require.Equal(t, march, z.monthOf(march.Add(time.Hour), time.UTC))
+
+	_, err = newActivityMonthZones([]activityTimeZone{{Name: "Not/AZone"}})
+	require.Error(t, err)
+}
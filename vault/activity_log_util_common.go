@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/axiomhq/hyperloglog"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/timeutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault/activity"
@@ -75,7 +76,7 @@ func (a *ActivityLog) StoreHyperlogLog(ctx context.Context, startTime time.Time,
 }
 
 func (a *ActivityLog) computeCurrentMonthForBillingPeriodInternal(ctx context.Context, byMonth map[int64]*processMonth, hllGetFunc HLLGetter, startTime time.Time, endTime time.Time) (*activity.MonthRecord, error) {
-	if timeutil.IsCurrentMonth(startTime, a.clock.Now().UTC()) {
+	if timeutil.IsCurrentMonth(startTime, a.currentMonth()) {
 		monthlyComputation := a.transformMonthBreakdowns(byMonth)
 		if len(monthlyComputation) > 1 {
 			a.logger.Warn("monthly in-memory activitylog computation returned multiple months of data", "months returned", len(byMonth))
@@ -89,10 +90,13 @@ func (a *ActivityLog) computeCurrentMonthForBillingPeriodInternal(ctx context.Co
 
 	// hllMonthlyTimestamp is the start time of the month corresponding to which a hyperloglog of that month's
 	// client data is stored. The path at which the hyperloglog for a month is stored containes this timestamp.
+	// The months are stepped through in the time zone they are reported in, and the hyperloglog of each is
+	// looked up by the instant it started at, in the time zone it was computed in.
+	zones := a.monthZones()
 	hllMonthlyTimestamp := timeutil.StartOfMonth(startTime)
 	billingPeriodHLL := hyperloglog.New()
 	for hllMonthlyTimestamp.Before(timeutil.StartOfMonth(endTime)) {
-		monthSketch, err := hllGetFunc(ctx, hllMonthlyTimestamp)
+		monthSketch, err := hllGetFunc(ctx, zones.startOf(hllMonthlyTimestamp))
 		// If there's an error with the hyperloglog fetch, we should still deduplicate on
 		// the hlls that we have so we will warn that we couldn't find a hll for the month
 		// and continue.
@@ -115,7 +119,7 @@ func (a *ActivityLog) computeCurrentMonthForBillingPeriodInternal(ctx context.Co
 		return nil, errors.New(fmt.Sprintf("multiple months of data found in partial month's client count breakdowns: %+v\n", byMonth))
 	}
 
-	activityTypes := []string{entityActivityType, nonEntityTokenActivityType, secretSyncActivityType}
+	activityTypes := []string{entityActivityType, nonEntityTokenActivityType, secretSyncActivityType, excludedActivityType}
 
 	// Now we will add the clients for the current month to a copy of the billing period's hll to
 	// see how the cardinality grows.
@@ -154,16 +158,18 @@ func (a *ActivityLog) computeCurrentMonthForBillingPeriodInternal(ctx context.Co
 	}
 
 	return &activity.MonthRecord{
-		Timestamp: timeutil.StartOfMonth(endTime).UTC().Unix(),
+		Timestamp: zones.startOf(timeutil.StartOfMonth(endTime)).Unix(),
 		NewClients: &activity.NewClientRecord{Counts: &activity.CountsRecord{
 			EntityClients:    currentMonthNewByType[entityActivityType],
 			NonEntityClients: currentMonthNewByType[nonEntityTokenActivityType],
 			SecretSyncs:      currentMonthNewByType[secretSyncActivityType],
+			ExcludedClients:  currentMonthNewByType[excludedActivityType],
 		}},
 		Counts: &activity.CountsRecord{
 			EntityClients:    totalByType[entityActivityType],
 			NonEntityClients: totalByType[nonEntityTokenActivityType],
 			SecretSyncs:      totalByType[secretSyncActivityType],
+			ExcludedClients:  totalByType[excludedActivityType],
 		},
 	}, nil
 }
@@ -188,6 +194,7 @@ func (a *ActivityLog) transformALNamespaceBreakdowns(nsData map[string]*processB
 			Entities:        uint64(ns.Counts.countByType(entityActivityType)),
 			NonEntityTokens: uint64(ns.Counts.countByType(nonEntityTokenActivityType)),
 			SecretSyncs:     uint64(ns.Counts.countByType(secretSyncActivityType)),
+			ExcludedClients: uint64(ns.Counts.countByType(excludedActivityType)),
 			Mounts:          a.transformActivityLogMounts(ns.Mounts),
 		}
 		byNamespace = append(byNamespace, &nsRecord)
@@ -216,8 +223,9 @@ func (a *ActivityLog) transformActivityLogMounts(mts map[string]*processMount) [
 	mounts := make([]*activity.MountRecord, 0)
 	for mountAccessor, mountCounts := range mts {
 		mount := activity.MountRecord{
-			MountPath: a.mountAccessorToMountPath(mountAccessor),
-			Counts:    mountCounts.Counts.toCountsRecord(),
+			MountPath:     a.mountAccessorToMountPath(mountAccessor),
+			MountAccessor: mountAccessor,
+			Counts:        mountCounts.Counts.toCountsRecord(),
 		}
 		mounts = append(mounts, &mount)
 	}
@@ -289,6 +297,37 @@ func (a *ActivityLog) mountAccessorToMountPath(mountAccessor string) string {
 	return displayPath
 }
 
+// precomputedQueryMountAccessor resolves the accessor of a mount recorded by
+// path, as returned by mountAccessorToMountPath, in a precomputed query of
+// version 1. The mount currently at the path is assumed to be the mount the
+// clients were recorded on.
+func (a *ActivityLog) precomputedQueryMountAccessor(ctx context.Context, namespaceID, mountPath string) string {
+	if mountPath == noMountAccessor {
+		return ""
+	}
+	var accessor string
+	if _, err := fmt.Sscanf(mountPath, deletedMountFmt, &accessor); err == nil {
+		return accessor
+	}
+
+	ns, err := NamespaceByID(ctx, namespaceID, a.core)
+	if err != nil || ns == nil {
+		return ""
+	}
+	entry := a.core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, ns), mountPath)
+	if entry == nil {
+		return ""
+	}
+	entryPath := entry.Path
+	if entry.Table == credentialTableType {
+		entryPath = credentialRoutePrefix + entryPath
+	}
+	if strings.TrimSuffix(entryPath, "/") != strings.TrimSuffix(mountPath, "/") {
+		return ""
+	}
+	return entry.Accessor
+}
+
 type singleTypeSegmentReader struct {
 	basePath         string
 	startTime        time.Time
@@ -353,7 +392,7 @@ func (s *singleTypeSegmentReader) nextValue(ctx context.Context, out proto.Messa
 			s.a.logger.Warn("expected log segment file has been deleted", "startTime", s.startTime, "segmentPath", path)
 		}
 	}
-	err := proto.Unmarshal(raw.Value, out)
+	err := decodeActivitySegment(raw.Value, out)
 	if err != nil {
 		return fmt.Errorf("unable to parse segment file %v%v: %w", s.basePath, path, err)
 	}
@@ -392,6 +431,7 @@ func (a *ActivityLog) countsRecordToCountsResponse(record *activity.CountsRecord
 		NonEntityClients: record.NonEntityClients,
 		Clients:          record.EntityClients + record.NonEntityClients + record.SecretSyncs,
 		SecretSyncs:      record.SecretSyncs,
+		ExcludedClients:  record.ExcludedClients,
 	}
 	if includeDeprecated {
 		response.NonEntityTokens = response.NonEntityClients
@@ -411,5 +451,6 @@ func (a *ActivityLog) namespaceRecordToCountsResponse(record *activity.Namespace
 		NonEntityClients: int(record.NonEntityTokens),
 		Clients:          int(record.Entities + record.NonEntityTokens + record.SecretSyncs),
 		SecretSyncs:      int(record.SecretSyncs),
+		ExcludedClients:  int(record.ExcludedClients),
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -91,6 +92,19 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 		entry.Options["fallback"] = strconv.FormatBool(fallback)
 	}
 
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entry.NamespaceID = ns.ID
+	entry.namespace = ns
+
+	// Devices enabled within a namespace only receive that namespace's
+	// entries, so they can't serve as the catch-all fallback device.
+	if ns.ID != namespace.RootNamespaceID && entry.Options["fallback"] == "true" {
+		return fmt.Errorf("unable to enable audit device '%s', fallback devices can only be enabled in the root namespace", entry.Path)
+	}
+
 	// Update the audit table
 	c.auditLock.Lock()
 	defer c.auditLock.Unlock()
@@ -100,6 +114,9 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 		switch {
 		case entry.Options["fallback"] == "true" && ent.Options["fallback"] == "true":
 			return fmt.Errorf("unable to enable audit device '%s', a fallback device already exists '%s'", entry.Path, ent.Path)
+		// Paths only need to be unique within a namespace
+		case ent.NamespaceID != "" && ent.NamespaceID != ns.ID:
+			continue
 		// Existing is sql/mysql/ new is sql/ or
 		// existing is sql/ and new is sql/mysql/
 		case strings.HasPrefix(ent.Path, entry.Path):
@@ -161,13 +178,6 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 	newTable := c.audit.shallowClone()
 	newTable.Entries = append(newTable.Entries, entry)
 
-	ns, err := namespace.FromContext(ctx)
-	if err != nil {
-		return err
-	}
-	entry.NamespaceID = ns.ID
-	entry.namespace = ns
-
 	if updateStorage {
 		if err := c.persistAudit(ctx, newTable, entry.Local); err != nil {
 			return errors.New("failed to update audit table")
@@ -177,7 +187,7 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 	c.audit = newTable
 
 	// Register the backend
-	err = c.auditBroker.Register(entry.Path, backend, entry.Local)
+	err = c.auditBroker.Register(entry.auditDeviceName(), backend, entry.Local)
 	if err != nil {
 		return fmt.Errorf("failed to register %q audit backend %q: %w", entry.Type, entry.Path, err)
 	}
@@ -238,7 +248,7 @@ func (c *Core) disableAudit(ctx context.Context, path string, updateStorage bool
 
 	// Unmount the backend, any returned error can be ignored since the
 	// Backend will already have been removed from the AuditBroker's map.
-	err = c.auditBroker.Deregister(ctx, path)
+	err = c.auditBroker.Deregister(ctx, entry.auditDeviceName())
 	if err != nil {
 		return existed, fmt.Errorf("failed to deregister %q audit backend %q: %w", entry.Type, entry.Path, err)
 	}
@@ -451,7 +461,7 @@ func (c *Core) setupAudits(ctx context.Context) error {
 		}
 
 		// Mount the backend
-		err = broker.Register(entry.Path, backend, entry.Local)
+		err = broker.Register(entry.auditDeviceName(), backend, entry.Local)
 		if err != nil {
 			c.logger.Error("failed to setup audit backed", "path", entry.Path, "type", entry.Type, "error", err)
 			continue
@@ -481,7 +491,7 @@ func (c *Core) teardownAudits() error {
 		for _, entry := range c.audit.Entries {
 			c.removeAuditReloadFunc(entry)
 			removeAuditPathChecker(c, entry)
-			err := c.auditBroker.Deregister(ctx, entry.Path)
+			err := c.auditBroker.Deregister(ctx, entry.auditDeviceName())
 			if err != nil {
 				c.logger.Error("unable to deregister audit during teardown", "path", entry.Path, "type", entry.Type, "error", err)
 			}
@@ -529,12 +539,16 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 	}
 	auditLogger := c.baseLogger.Named("audit")
 
+	if ns := entry.namespace; ns != nil && ns.ID != namespace.RootNamespaceID {
+		conf = namespaceAuditConfig(conf, ns)
+	}
+
 	be, err := f(
 		ctx, &audit.BackendConfig{
 			SaltView:   view,
 			SaltConfig: saltConfig,
 			Config:     conf,
-			MountPath:  entry.Path,
+			MountPath:  entry.auditDeviceName(),
 			Logger:     auditLogger,
 		},
 		c.auditedHeaders)
@@ -578,6 +592,35 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 	return be, err
 }
 
+// auditDeviceName returns the name the audit device for entry is registered
+// with in the audit broker. Devices in the root namespace are named by their
+// path; devices enabled within a namespace are qualified by the namespace
+// path, as audit device paths only need to be unique within a namespace.
+func (e *MountEntry) auditDeviceName() string {
+	if e.namespace == nil || e.namespace.ID == namespace.RootNamespaceID {
+		return e.Path
+	}
+	return e.namespace.Path + e.Path
+}
+
+// namespaceAuditConfig returns a copy of conf with a filter restricting the
+// audit device to entries for requests made in ns or its child namespaces.
+// Any filter configured on the device is still applied.
+func namespaceAuditConfig(conf map[string]string, ns *namespace.Namespace) map[string]string {
+	nsConf := make(map[string]string, len(conf)+1)
+	for k, v := range conf {
+		nsConf[k] = v
+	}
+
+	nsFilter := fmt.Sprintf("namespace matches %q", "^"+regexp.QuoteMeta(ns.Path))
+	if filter := strings.TrimSpace(conf["filter"]); filter != "" {
+		nsFilter = fmt.Sprintf("(%s) and (%s)", filter, nsFilter)
+	}
+	nsConf["filter"] = nsFilter
+
+	return nsConf
+}
+
 // defaultAuditTable creates a default audit table
 func defaultAuditTable() *MountTable {
 	table := &MountTable{
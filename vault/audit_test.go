@@ -290,6 +290,77 @@ func TestCore_DisableAudit(t *testing.T) {
 	}
 }
 
+// TestCore_EnableAudit_Namespace verifies that audit devices enabled within a
+// namespace are registered separately from devices at the same path in other
+// namespaces, and only receive entries for their namespace's subtree.
+func TestCore_EnableAudit_Namespace(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	configs := make(map[string]map[string]string)
+	c.auditBackends["noop"] = func(ctx context.Context, config *audit.BackendConfig, headersConfig audit.HeaderFormatter) (audit.Backend, error) {
+		configs[config.MountPath] = config.Config
+		return corehelpers.NoopAuditFactory(nil)(ctx, config, headersConfig)
+	}
+
+	ns1 := &namespace.Namespace{ID: "ns1id", Path: "ns1/"}
+	ns1Ctx := namespace.ContextWithNamespace(context.Background(), ns1)
+
+	err := c.enableAudit(namespace.RootContext(context.Background()), &MountEntry{
+		Table: auditTableType,
+		Path:  "foo",
+		Type:  "noop",
+	}, true)
+	require.NoError(t, err)
+
+	err = c.enableAudit(ns1Ctx, &MountEntry{
+		Table: auditTableType,
+		Path:  "foo",
+		Type:  "noop",
+	}, true)
+	require.NoError(t, err)
+
+	require.True(t, c.auditBroker.IsRegistered("foo/"))
+	require.True(t, c.auditBroker.IsRegistered("ns1/foo/"))
+
+	// The root device is unfiltered, the namespace device is restricted to
+	// its subtree
+	require.Empty(t, configs["foo/"]["filter"])
+	filter := configs["ns1/foo/"]["filter"]
+	require.Equal(t, `namespace matches "^ns1/"`, filter)
+	_, err = audit.NewEntryFilter(filter)
+	require.NoError(t, err)
+
+	// Fallback devices must be global
+	err = c.enableAudit(ns1Ctx, &MountEntry{
+		Table:   auditTableType,
+		Path:    "bar",
+		Type:    "noop",
+		Options: map[string]string{"fallback": "true"},
+	}, true)
+	require.Error(t, err)
+
+	existed, err := c.disableAudit(ns1Ctx, "foo", true)
+	require.True(t, existed)
+	require.NoError(t, err)
+	require.False(t, c.auditBroker.IsRegistered("ns1/foo/"))
+	require.True(t, c.auditBroker.IsRegistered("foo/"))
+}
+
+func TestNamespaceAuditConfig(t *testing.T) {
+	ns := &namespace.Namespace{ID: "ns1id", Path: "ns1/child/"}
+	conf := map[string]string{
+		"file_path": "stdout",
+		"filter":    `mount_type == "kv"`,
+	}
+
+	nsConf := namespaceAuditConfig(conf, ns)
+	require.Equal(t, `(mount_type == "kv") and (namespace matches "^ns1/child/")`, nsConf["filter"])
+	require.Equal(t, "stdout", nsConf["file_path"])
+
+	// The original configuration is left untouched
+	require.Equal(t, `mount_type == "kv"`, conf["filter"])
+}
+
 func TestCore_DefaultAuditTable(t *testing.T) {
 	c, keys, _ := TestCoreUnsealed(t)
 	verifyDefaultAuditTable(t, c.audit)
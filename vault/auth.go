@@ -151,8 +151,13 @@ func (c *Core) enableCredentialInternal(ctx context.Context, entry *MountEntry,
 	// Sync values to the cache
 	entry.SyncCache()
 
+	storage, err := c.mountKeyStorage(ctx, entry, c.barrier, updateStorage)
+	if err != nil {
+		return err
+	}
+
 	viewPath := entry.ViewPath()
-	view := NewBarrierView(c.barrier, viewPath)
+	view := NewBarrierView(storage, viewPath)
 
 	// Singleton mounts cannot be filtered on a per-secondary basis
 	// from replication
@@ -337,6 +342,13 @@ func (c *Core) disableCredentialInternal(ctx context.Context, path string, updat
 			return err
 		}
 
+	case entry.MountKey && (entry.Local || !c.IsPerfSecondary()):
+		// Destroying the key of the mount erases its data
+		if err := c.eraseMountKey(ctx, entry); err != nil {
+			c.logger.Error("failed to erase the data of the mount being unmounted", "error", err, "path", path)
+			return err
+		}
+
 	case entry.Local, !c.IsPerfSecondary():
 		// Have writable storage, remove the whole thing
 		if err := logical.ClearViewWithLogging(ctx, view, c.logger.Named("auth.deletion").With("namespace", ns.ID, "path", path)); err != nil {
@@ -371,6 +383,13 @@ func (c *Core) disableCredentialInternal(ctx context.Context, path string, updat
 		}
 	}
 
+	if updateStorage && c.TokenAnomalyConfig(entry.Accessor) != nil {
+		if err := c.deleteTokenAnomalyConfig(ctx, entry.Accessor); err != nil {
+			c.logger.Error("failed to remove token anomaly config after disabling auth", "path", path, "error", err)
+			return err
+		}
+	}
+
 	if c.logger.IsInfo() {
 		c.logger.Info("disabled credential backend", "path", path)
 	}
@@ -688,6 +707,9 @@ func (c *Core) persistAuth(ctx context.Context, table *MountTable, local *bool)
 		return fmt.Errorf("invalid table type given, not persisting")
 	}
 
+	// The OpenAPI document is generated from the mounts
+	c.invalidateResponseCache(responseCacheOpenAPI)
+
 	nonLocalAuth := &MountTable{
 		Type: credentialTableType,
 	}
@@ -783,7 +805,12 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			addFilterablePath(c, viewPath)
 		}
 
-		view := NewBarrierView(c.barrier, viewPath)
+		storage, err := c.mountKeyStorage(ctx, entry, c.barrier, false)
+		if err != nil {
+			c.logger.Error("failed to set up the mount key", "path", entry.Path, "error", err)
+			return errLoadAuthFailed
+		}
+		view := NewBarrierView(storage, viewPath)
 
 		// Determining the replicated state of the mount
 		nilMount, err := preprocessMount(c, entry, view)
@@ -803,6 +830,13 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 		// Initialize the backend
 		sysView := c.mountEntrySysView(entry)
 
+		// Sealed mounts keep no backend until they are unsealed
+		if entry.Sealed {
+			c.logger.Warn("not starting the backend of sealed auth mount", "path", entry.Path)
+			view.setReadOnlyErr(origViewReadOnlyErr)
+			goto ROUTER_MOUNT
+		}
+
 		backend, err = c.newCredentialBackend(ctx, entry, sysView, view)
 		if err != nil {
 			c.logger.Error("failed to create credential entry", "path", entry.Path, "error", err)
@@ -896,7 +930,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 		NamespaceByID(ctx, entry.NamespaceID, c)
 
 		// Initialize
-		if !nilMount {
+		if !nilMount && !entry.Sealed {
 			// Bind locally
 			localEntry := entry
 			c.postUnsealFuncs = append(c.postUnsealFuncs, func() {
@@ -54,10 +54,18 @@ func (v *BarrierView) Prefix() string {
 }
 
 func (v *BarrierView) List(ctx context.Context, prefix string) ([]string, error) {
+	if logical.RequestDeadlineExceeded(ctx) {
+		return nil, logical.ErrRequestDeadlineExceeded
+	}
+	defer enterInFlightPhase(ctx, inFlightPhaseStorage)()
 	return v.storage.List(ctx, prefix)
 }
 
 func (v *BarrierView) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	if logical.RequestDeadlineExceeded(ctx) {
+		return nil, logical.ErrRequestDeadlineExceeded
+	}
+	defer enterInFlightPhase(ctx, inFlightPhaseStorage)()
 	return v.storage.Get(ctx, key)
 }
 
@@ -66,6 +74,9 @@ func (v *BarrierView) Put(ctx context.Context, entry *logical.StorageEntry) erro
 	if entry == nil {
 		return errors.New("cannot write nil entry")
 	}
+	if logical.RequestDeadlineExceeded(ctx) {
+		return logical.ErrRequestDeadlineExceeded
+	}
 
 	expandedKey := v.storage.ExpandKey(entry.Key)
 
@@ -76,11 +87,15 @@ func (v *BarrierView) Put(ctx context.Context, entry *logical.StorageEntry) erro
 		}
 	}
 
+	defer enterInFlightPhase(ctx, inFlightPhaseStorage)()
 	return v.storage.Put(ctx, entry)
 }
 
 // logical.Storage impl.
 func (v *BarrierView) Delete(ctx context.Context, key string) error {
+	if logical.RequestDeadlineExceeded(ctx) {
+		return logical.ErrRequestDeadlineExceeded
+	}
 	expandedKey := v.storage.ExpandKey(key)
 
 	roErr := v.getReadOnlyErr()
@@ -90,6 +105,7 @@ func (v *BarrierView) Delete(ctx context.Context, key string) error {
 		}
 	}
 
+	defer enterInFlightPhase(ctx, inFlightPhaseStorage)()
 	return v.storage.Delete(ctx, key)
 }
 
@@ -25,25 +25,71 @@ func (c *Core) CapabilitiesAndSubscribeEventTypes(ctx context.Context, token, pa
 		return nil, nil, &logical.StatusBadRequest{Err: "missing path"}
 	}
 
+	acl, err := c.tokenACL(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if acl == nil {
+		return []string{DenyCapability}, nil, nil
+	}
+
+	capabilities, eventTypes := acl.CapabilitiesAndSubscribeEventTypes(ctx, path)
+	sort.Strings(capabilities)
+	return capabilities, eventTypes, nil
+}
+
+// ExplainCapabilities returns the capabilities of the given token on each of
+// the given paths, along with the policies and rules they are derived from.
+func (c *Core) ExplainCapabilities(ctx context.Context, token string, paths []string) (map[string]*CapabilitiesExplanation, error) {
+	for _, path := range paths {
+		if path == "" {
+			return nil, &logical.StatusBadRequest{Err: "missing path"}
+		}
+	}
+
+	acl, err := c.tokenACL(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*CapabilitiesExplanation, len(paths))
+	for _, path := range paths {
+		if acl == nil {
+			ret[path] = &CapabilitiesExplanation{
+				Capabilities: []string{DenyCapability},
+				GrantedBy:    map[string][]logical.PolicyInfo{},
+				Reason:       "token has no policies",
+			}
+			continue
+		}
+		ret[path] = acl.ExplainCapabilities(ctx, path)
+	}
+	return ret, nil
+}
+
+// tokenACL returns the ACL of the given token, built from its policies,
+// inline policy and those derived from its entity. The ACL is nil if the
+// token has no policies.
+func (c *Core) tokenACL(ctx context.Context, token string) (*ACL, error) {
 	if token == "" {
-		return nil, nil, &logical.StatusBadRequest{Err: "missing token"}
+		return nil, &logical.StatusBadRequest{Err: "missing token"}
 	}
 
 	te, err := c.tokenStore.Lookup(ctx, token)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if te == nil {
-		return nil, nil, &logical.StatusBadRequest{Err: "invalid token"}
+		return nil, &logical.StatusBadRequest{Err: "invalid token"}
 	}
 
 	var tokenNS *namespace.Namespace
 	tokenNS, err = NamespaceByID(ctx, te.NamespaceID, c)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if tokenNS == nil {
-		return nil, nil, namespace.ErrNoNamespace
+		return nil, namespace.ErrNoNamespace
 	}
 
 	var policyCount int
@@ -53,15 +99,15 @@ func (c *Core) CapabilitiesAndSubscribeEventTypes(ctx context.Context, token, pa
 
 	entity, identityPolicies, err := c.fetchEntityAndDerivedPolicies(ctx, tokenNS, te.EntityID, te.NoIdentityPolicies)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if entity != nil && entity.Disabled {
 		c.logger.Warn("permission denied as the entity on the token is disabled")
-		return nil, nil, logical.ErrPermissionDenied
+		return nil, logical.ErrPermissionDenied
 	}
 	if te.EntityID != "" && entity == nil {
 		c.logger.Warn("permission denied as the entity on the token is invalid")
-		return nil, nil, logical.ErrPermissionDenied
+		return nil, logical.ErrPermissionDenied
 	}
 
 	for nsID, nsPolicies := range identityPolicies {
@@ -74,25 +120,18 @@ func (c *Core) CapabilitiesAndSubscribeEventTypes(ctx context.Context, token, pa
 	if te.InlinePolicy != "" {
 		inlinePolicy, err := ParseACLPolicy(tokenNS, te.InlinePolicy)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		policies = append(policies, inlinePolicy)
 		policyCount++
 	}
 
 	if policyCount == 0 {
-		return []string{DenyCapability}, nil, nil
+		return nil, nil
 	}
 
 	// Construct the corresponding ACL object. ACL construction should be
 	// performed on the token's namespace.
 	tokenCtx := namespace.ContextWithNamespace(ctx, tokenNS)
-	acl, err := c.policyStore.ACL(tokenCtx, entity, policyNames, policies...)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	capabilities, eventTypes := acl.CapabilitiesAndSubscribeEventTypes(ctx, path)
-	sort.Strings(capabilities)
-	return capabilities, eventTypes, nil
+	return c.policyStore.ACL(tokenCtx, entity, policyNames, policies...)
 }
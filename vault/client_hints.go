@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const clientHintsConfigPath = "client-hints"
+
+// ClientHintsConfig holds the client behavior defaults which are advertised
+// to API clients at sys/internal/client-hints. Clients may use them to
+// configure request hedging and per-endpoint circuit breaking without each
+// consumer having to tune them independently.
+type ClientHintsConfig struct {
+	HedgingEnabled                 bool `json:"hedging_enabled"`
+	HedgeDelayMillis               int  `json:"hedge_delay_ms"`
+	MaxHedgedRequests              int  `json:"max_hedged_requests"`
+	CircuitBreakerEnabled          bool `json:"circuit_breaker_enabled"`
+	CircuitBreakerFailureThreshold int  `json:"circuit_breaker_failure_threshold"`
+	CircuitBreakerOpenDuration     int  `json:"circuit_breaker_open_duration"`
+}
+
+// defaultClientHintsConfig returns the hints advertised when no configuration
+// has been written. Hedging and circuit breaking are opt-in, but sensible
+// parameters are provided so that enabling them is a single flag flip.
+func defaultClientHintsConfig() *ClientHintsConfig {
+	return &ClientHintsConfig{
+		HedgeDelayMillis:               500,
+		MaxHedgedRequests:              1,
+		CircuitBreakerFailureThreshold: 5,
+		CircuitBreakerOpenDuration:     30,
+	}
+}
+
+// ClientHints returns the currently advertised client hints.
+func (c *Core) ClientHints() *ClientHintsConfig {
+	if hints := c.clientHints.Load(); hints != nil {
+		return hints
+	}
+	return defaultClientHintsConfig()
+}
+
+func (c *Core) saveClientHintsConfig(ctx context.Context, hints *ClientHintsConfig) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	entry, err := logical.StorageEntryJSON(clientHintsConfigPath, hints)
+	if err != nil {
+		return fmt.Errorf("failed to create client hints config entry: %w", err)
+	}
+
+	if err := view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save client hints config: %w", err)
+	}
+
+	c.clientHints.Store(hints)
+	return nil
+}
+
+func (c *Core) deleteClientHintsConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	if err := view.Delete(ctx, clientHintsConfigPath); err != nil {
+		return fmt.Errorf("failed to delete client hints config: %w", err)
+	}
+
+	c.clientHints.Store(nil)
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadClientHintsConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	out, err := view.Get(ctx, clientHintsConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read client hints config: %w", err)
+	}
+	if out == nil {
+		c.clientHints.Store(nil)
+		return nil
+	}
+
+	hints := defaultClientHintsConfig()
+	if err := out.DecodeJSON(hints); err != nil {
+		return err
+	}
+
+	c.clientHints.Store(hints)
+	return nil
+}
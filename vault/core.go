@@ -49,6 +49,7 @@ import (
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/osutil"
+	"github.com/hashicorp/vault/helper/timeutil"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/plugins/event"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
@@ -333,6 +334,9 @@ type Core struct {
 	manualStepDownCh     chan struct{}
 	keepHALockOnStepDown *uint32
 	heldHALock           physical.Lock
+	// haFaults holds the faults injected in leadership transfers by tests,
+	// in testonly builds
+	haFaults haFaults
 
 	// shutdownDoneCh is used to notify when core.Shutdown() completes.
 	// core.Shutdown() is typically issued in a goroutine to allow Vault to
@@ -506,6 +510,9 @@ type Core struct {
 	clusterLeaderParams *atomic.Value
 	// Info on cluster members
 	clusterPeerClusterAddrsCache *cache.Cache
+	// Results of mutating requests made with an idempotency key, kept so
+	// that they can be replayed when a client retries the request
+	idempotencyCache *cache.Cache
 	// The context for the client
 	rpcClientConnContext context.Context
 	// The function for canceling the client connection
@@ -520,6 +527,58 @@ type Core struct {
 	// CORS Information
 	corsConfig *CORSConfig
 
+	// clientHints holds the client behavior defaults advertised at
+	// sys/internal/client-hints; nil means the defaults are in use
+	clientHints atomic.Pointer[ClientHintsConfig]
+
+	// fairShareConfig holds the per-namespace request scheduling
+	// configuration; fairShareScheduler is nil unless it is enabled
+	fairShareConfig    atomic.Pointer[FairShareConfig]
+	fairShareScheduler atomic.Pointer[fairShareScheduler]
+
+	// loginEnrichmentConfig holds the configuration of login attempt
+	// enrichment; loginEnricher, if set by tests, is used instead of the
+	// configured enrichment service
+	loginEnrichmentConfig atomic.Pointer[LoginEnrichmentConfig]
+	loginEnricher         LoginEnricher
+
+	// tokenAnomalyConfigs maps auth mount accessors to their token anomaly
+	// detection configuration; tokenUsageProfiles holds the usage history
+	// of tokens issued by those mounts, keyed by token accessor
+	tokenAnomalyConfigs     atomic.Pointer[map[string]*TokenAnomalyConfig]
+	tokenAnomalyConfigsLock sync.Mutex
+	tokenUsageProfiles      *cache.Cache
+
+	// accessAdvisor gathers the capabilities entities use, while the node
+	// is active
+	accessAdvisor atomic.Pointer[accessAdvisor]
+
+	// storageIntegrity samples storage entries and checks their integrity,
+	// while the node is active
+	storageIntegrity atomic.Pointer[storageIntegrityScrubber]
+
+	// requestMeter attributes a cost to the requests handled while the node
+	// is active, for chargeback
+	requestMeter atomic.Pointer[requestMeter]
+
+	// deception holds the configured honeytokens and decoy paths
+	deception     atomic.Pointer[deceptionState]
+	deceptionLock sync.Mutex
+
+	// draining is set once the node starts draining before shutdown
+	draining atomic.Bool
+
+	// offloadNext rotates the standbys to which tasks are offloaded
+	offloadNext atomic.Uint64
+
+	// entityRevocations holds the last revocation of the credentials of each
+	// disabled entity, keyed by entity ID
+	entityRevocations sync.Map
+
+	// pathRewrites holds the configured path rewrite rules
+	pathRewrites     atomic.Pointer[pathRewriteState]
+	pathRewritesLock sync.Mutex
+
 	// replicationState keeps the current replication state cached for quick
 	// lookup; activeNodeReplicationState stores the active value on standbys
 	replicationState           *uint32
@@ -597,6 +656,11 @@ type Core struct {
 	allLoggers     []log.Logger
 	allLoggersLock sync.RWMutex
 
+	// loggerOverrides are the runtime overrides of loggers set through
+	// sys/loggers, by logger name
+	loggerOverrides     map[string]*loggerOverride
+	loggerOverridesLock sync.Mutex
+
 	// Can be toggled atomically to cause the core to never try to become
 	// active, or give up active as soon as it gets it
 	neverBecomeActive *uint32
@@ -643,6 +707,10 @@ type Core struct {
 	// it is protected by activityLogLock
 	activityLogConfig ActivityLogCoreConfig
 
+	// clock is the clock of the lease expirations and the activity log, which
+	// tests can adjust
+	clock timeutil.Clock
+
 	// activeTime is set on active nodes indicating the time at which this node
 	// became active.
 	activeTime time.Time
@@ -667,6 +735,9 @@ type Core struct {
 	enableResponseHeaderHostname   bool
 	enableResponseHeaderRaftNodeID bool
 
+	// enableBuiltinAuditorPolicy serves the built-in auditor policy
+	enableBuiltinAuditorPolicy bool
+
 	// disableSSCTokens is used to disable server side consistent token creation/usage
 	disableSSCTokens bool
 
@@ -690,6 +761,30 @@ type Core struct {
 	pendingRemovalMountsAllowed bool
 	expirationRevokeRetryBase   time.Duration
 
+	// deprecationUsage counts the requests served by deprecated builtins and
+	// endpoints
+	deprecationUsage *deprecationUsageTracker
+
+	// requestJournal persists the in-flight requests for post-mortems, if
+	// enabled
+	requestJournal *requestJournal
+
+	// pprofWatchdog captures pprof dumps when resource thresholds are
+	// exceeded, if enabled
+	pprofWatchdog *pprofWatchdog
+
+	// memoryPressure watches the memory usage against the soft memory limit,
+	// if one is set
+	memoryPressure *memoryPressureMonitor
+
+	// tidyScheduler runs the tidy operations of core within the concurrency
+	// limit and the IO budget
+	tidyScheduler *tidyScheduler
+
+	// responseCache caches the responses of expensive computed reads, if
+	// enabled for any of them
+	responseCache *responseCache
+
 	events *eventbus.EventBus
 
 	// writeForwardedPaths are a set of storage paths which are GRPC forwarded
@@ -792,12 +887,33 @@ type CoreConfig struct {
 	// If any role based quota (LCQ or RLQ) is enabled, don't track lease counts by role
 	ImpreciseLeaseRoleTracking bool
 
+	// RequestJournalPath is the local file the in-flight requests are
+	// persisted to on shutdown, on panic, and periodically, if set.
+	RequestJournalPath string
+
+	// PprofWatchdog enables the automatic capture of pprof dumps, if set.
+	PprofWatchdog *PprofWatchdogConfig
+
+	// TidyMaxConcurrency is the number of tidy operations which can run at
+	// once, defaulting to one, and TidyIORateLimit the storage operations
+	// per second they are allowed together, unlimited if zero.
+	TidyMaxConcurrency int
+	TidyIORateLimit    float64
+
+	// ResponseCacheTTLs enables the caching of the responses of expensive
+	// reads, by path template, for the TTL.
+	ResponseCacheTTLs map[string]time.Duration
+
 	// Disables the trace display for Sentinel checks
 	DisableSentinelTrace bool
 
 	// Disables the LRU cache on the physical backend
 	DisableCache bool
 
+	// Disables the coalescing of identical concurrent reads of the physical
+	// backend
+	DisableReadCoalescing bool
+
 	// Disables mlock syscall
 	DisableMlock bool
 
@@ -864,6 +980,10 @@ type CoreConfig struct {
 	// Activity log controls
 	ActivityLogConfig ActivityLogCoreConfig
 
+	// Clock is used to support manipulating time in tests. It defaults to
+	// the wall clock, or to an adjustable clock in testonly builds.
+	Clock timeutil.Clock
+
 	// number of workers to use for lease revocation in the expiration manager
 	NumExpirationWorkers int
 
@@ -874,6 +994,10 @@ type CoreConfig struct {
 	EnableResponseHeaderHostname   bool
 	EnableResponseHeaderRaftNodeID bool
 
+	// EnableBuiltinAuditorPolicy serves the built-in auditor policy in every
+	// namespace where no policy of that name is written
+	EnableBuiltinAuditorPolicy bool
+
 	// DisableSSCTokens is used to disable the use of server side consistent tokens
 	DisableSSCTokens bool
 
@@ -1036,6 +1160,8 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		clusterName:                    conf.ClusterName,
 		clusterNetworkLayer:            conf.ClusterNetworkLayer,
 		clusterPeerClusterAddrsCache:   cache.New(3*clusterHeartbeatInterval, time.Second),
+		idempotencyCache:               cache.New(idempotencyKeyTTL, time.Minute),
+		tokenUsageProfiles:             cache.New(tokenUsageProfileTTL, time.Hour),
 		enableMlock:                    !conf.DisableMlock,
 		rawEnabled:                     conf.EnableRaw,
 		introspectionEnabled:           conf.EnableIntrospection,
@@ -1050,6 +1176,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		disablePerfStandby:             true,
 		activeContextCancelFunc:        new(atomic.Value),
 		allLoggers:                     conf.AllLoggers,
+		loggerOverrides:                make(map[string]*loggerOverride),
 		builtinRegistry:                conf.BuiltinRegistry,
 		neverBecomeActive:              new(uint32),
 		clusterLeaderParams:            new(atomic.Value),
@@ -1063,18 +1190,21 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		raftJoinDoneCh:                 make(chan struct{}),
 		clusterHeartbeatInterval:       clusterHeartbeatInterval,
 		activityLogConfig:              conf.ActivityLogConfig,
+		clock:                          conf.Clock,
 		keyRotateGracePeriod:           new(int64),
 		numExpirationWorkers:           conf.NumExpirationWorkers,
 		raftFollowerStates:             raft.NewFollowerStates(),
 		disableAutopilot:               conf.DisableAutopilot,
 		enableResponseHeaderHostname:   conf.EnableResponseHeaderHostname,
 		enableResponseHeaderRaftNodeID: conf.EnableResponseHeaderRaftNodeID,
+		enableBuiltinAuditorPolicy:     conf.EnableBuiltinAuditorPolicy,
 		mountMigrationTracker:          &sync.Map{},
 		disableSSCTokens:               conf.DisableSSCTokens,
 		effectiveSDKVersion:            effectiveSDKVersion,
 		userFailedLoginInfo:            make(map[FailedLoginUser]*FailedLoginInfo),
 		experiments:                    conf.Experiments,
 		pendingRemovalMountsAllowed:    conf.PendingRemovalMountsAllowed,
+		deprecationUsage:               newDeprecationUsageTracker(),
 		expirationRevokeRetryBase:      conf.ExpirationRevokeRetryBase,
 		rollbackMountPathMetrics:       conf.MetricSink.TelemetryConsts.RollbackMetricsIncludeMountPoint,
 		numRollbackWorkers:             conf.NumRollbackWorkers,
@@ -1104,6 +1234,47 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		InFlightReqCount: uberAtomic.NewUint64(0),
 	}
 
+	if conf.RequestJournalPath != "" {
+		requestJournalLogger := c.baseLogger.Named("request-journal")
+		c.allLoggers = append(c.allLoggers, requestJournalLogger)
+		journal, err := newRequestJournal(conf.RequestJournalPath, requestJournalLogger)
+		if err != nil {
+			return nil, err
+		}
+		c.requestJournal = journal
+		go c.runRequestJournal(journal)
+	}
+
+	if conf.PprofWatchdog != nil {
+		pprofWatchdogLogger := c.baseLogger.Named("pprof-watchdog")
+		c.allLoggers = append(c.allLoggers, pprofWatchdogLogger)
+		watchdog, err := newPprofWatchdog(conf.PprofWatchdog, pprofWatchdogLogger)
+		if err != nil {
+			return nil, err
+		}
+		c.pprofWatchdog = watchdog
+		go watchdog.run()
+	}
+
+	if softMemoryLimit() > 0 {
+		memoryPressureLogger := c.baseLogger.Named("memory-pressure")
+		c.allLoggers = append(c.allLoggers, memoryPressureLogger)
+		c.memoryPressure = newMemoryPressureMonitor(memoryPressureLogger)
+		go c.runMemoryPressureMonitor(c.memoryPressure)
+	}
+
+	tidyLogger := c.baseLogger.Named("tidy")
+	c.allLoggers = append(c.allLoggers, tidyLogger)
+	c.tidyScheduler = newTidyScheduler(conf.TidyMaxConcurrency, conf.TidyIORateLimit, tidyLogger)
+
+	if len(conf.ResponseCacheTTLs) > 0 {
+		responseCache, err := newResponseCache(conf.ResponseCacheTTLs)
+		if err != nil {
+			return nil, err
+		}
+		c.responseCache = responseCache
+	}
+
 	c.SetConfig(conf.RawConfig)
 
 	atomic.StoreUint32(c.replicationState, uint32(consts.ReplicationDRDisabled|consts.ReplicationPerformanceDisabled))
@@ -1196,11 +1367,16 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		}
 	}
 
+	if c.clock == nil {
+		c.clock = newCoreClock()
+	}
+
 	// Construct a new AES-GCM barrier
-	c.barrier, err = NewAESGCMBarrier(c.physical)
+	barrier, err := NewAESGCMBarrier(c.physical)
 	if err != nil {
 		return nil, fmt.Errorf("barrier setup failed: %w", err)
 	}
+	c.barrier = barrier
 
 	err = c.entCheckStoredLicense(conf)
 	if err != nil {
@@ -1510,6 +1686,13 @@ func (c *Core) ShutdownCoreError(err error) {
 // happens as quickly as possible.
 func (c *Core) Shutdown() error {
 	c.logger.Debug("shutdown called")
+	c.persistRequestJournal(requestJournalReasonShutdown, nil)
+	if c.pprofWatchdog != nil {
+		c.pprofWatchdog.stop()
+	}
+	if c.memoryPressure != nil {
+		c.memoryPressure.stop()
+	}
 	err := c.sealInternal()
 
 	c.stateLock.Lock()
@@ -2567,6 +2750,12 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 			return c.setupManagedKeyRegistry()
 		},
 		c.loadCORSConfig,
+		c.loadClientHintsConfig,
+		c.loadFairShareConfig,
+		c.loadLoginEnrichmentConfig,
+		c.loadTokenAnomalyConfigs,
+		c.loadDeception,
+		c.loadPathRewrites,
 		c.loadCredentials,
 		func(_ context.Context) error {
 			return c.entSetupFilteredPaths()
@@ -2600,6 +2789,7 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 		setupFunctions = append(setupFunctions, func(_ context.Context) error {
 			return c.setupExpiration(expireLeaseStrategyFairsharing)
 		})
+		setupFunctions = append(setupFunctions, c.setupEntityTokenInventory)
 		setupFunctions = append(setupFunctions, c.loadAudits)
 		setupFunctions = append(setupFunctions, c.setupAuditedHeadersConfig)
 		setupFunctions = append(setupFunctions, c.setupAudits)
@@ -2612,6 +2802,9 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 			return nil
 		})
 		setupFunctions = append(setupFunctions, c.loadLoginMFAConfigs)
+		setupFunctions = append(setupFunctions, c.setupAccessAdvisor)
+		setupFunctions = append(setupFunctions, c.setupStorageIntegrity)
+		setupFunctions = append(setupFunctions, c.setupRequestMeter)
 	}
 
 	return setupFunctions
@@ -2843,6 +3036,9 @@ func (c *Core) preSeal() error {
 		result = multierror.Append(result, fmt.Errorf("error stopping expiration: %w", err))
 	}
 	c.stopActivityLog()
+	c.stopAccessAdvisor()
+	c.stopStorageIntegrity()
+	c.stopRequestMeter()
 	// Clean up census on seal
 	if err := c.teardownCensusManager(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error tearing down reporting agent: %w", err))
@@ -4005,9 +4201,23 @@ type InFlightReqData struct {
 	ReqPath          string    `json:"request_path"`
 	Method           string    `json:"request_method"`
 	ClientID         string    `json:"client_id"`
+	EntityID         string    `json:"entity_id,omitempty"`
+	Namespace        string    `json:"namespace,omitempty"`
+	TraceID          string    `json:"trace_id,omitempty"`
+	SpanID           string    `json:"span_id,omitempty"`
+
+	// Phase and ElapsedMs are set when the data is loaded
+	Phase     string `json:"phase,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+
+	// CancelFunc cancels the context of the request, if set
+	CancelFunc context.CancelFunc `json:"-"`
+
+	phase *inFlightPhase
 }
 
 func (c *Core) StoreInFlightReqData(reqID string, data InFlightReqData) {
+	data.phase = newInFlightPhase()
 	c.inFlightReqData.InFlightReqMap.Store(reqID, data)
 	c.inFlightReqData.InFlightReqCount.Inc()
 }
@@ -4026,12 +4236,15 @@ func (c *Core) FinalizeInFlightReqData(reqID string, statusCode int) {
 }
 
 // LoadInFlightReqData creates a snapshot map of the current
-// in-flight requests
+// in-flight requests, with their current phase and elapsed time
 func (c *Core) LoadInFlightReqData() map[string]InFlightReqData {
 	currentInFlightReqMap := make(map[string]InFlightReqData)
+	now := time.Now()
 	c.inFlightReqData.InFlightReqMap.Range(func(key, value interface{}) bool {
 		// there is only one writer to this map, so skip checking for errors
 		v := value.(InFlightReqData)
+		v.Phase = v.phase.get()
+		v.ElapsedMs = now.Sub(v.StartTime).Milliseconds()
 		currentInFlightReqMap[key.(string)] = v
 		return true
 	})
@@ -4040,8 +4253,8 @@ func (c *Core) LoadInFlightReqData() map[string]InFlightReqData {
 }
 
 // UpdateInFlightReqData updates the data for a specific reqID with
-// the clientID
-func (c *Core) UpdateInFlightReqData(reqID, clientID string) {
+// the clientID, entityID, and namespace path
+func (c *Core) UpdateInFlightReqData(reqID, clientID, entityID, namespacePath string) {
 	v, ok := c.inFlightReqData.InFlightReqMap.Load(reqID)
 	if !ok {
 		c.Logger().Trace("failed to retrieve request with ID", "request_id", reqID)
@@ -4051,6 +4264,8 @@ func (c *Core) UpdateInFlightReqData(reqID, clientID string) {
 	// there is only one writer to this map, so skip checking for errors
 	reqData := v.(InFlightReqData)
 	reqData.ClientID = clientID
+	reqData.EntityID = entityID
+	reqData.Namespace = namespacePath
 	c.inFlightReqData.InFlightReqMap.Store(reqID, reqData)
 }
 
@@ -4065,12 +4280,18 @@ func (c *Core) LogCompletedRequests(reqID string, statusCode int) {
 
 	// there is only one writer to this map, so skip checking for errors
 	reqData := v.(InFlightReqData)
-	c.logger.Log(logLevel, "completed_request",
+	args := []interface{}{
 		"start_time", reqData.StartTime.Format(time.RFC3339),
 		"duration", fmt.Sprintf("%dms", time.Now().Sub(reqData.StartTime).Milliseconds()),
 		"client_id", reqData.ClientID,
 		"client_address", reqData.ClientRemoteAddr, "status_code", statusCode, "request_path", reqData.ReqPath,
-		"request_method", reqData.Method)
+		"request_method", reqData.Method,
+	}
+	// Correlate the log with the trace of the request, if it is traced
+	if reqData.TraceID != "" {
+		args = append(args, "trace_id", reqData.TraceID, "span_id", reqData.SpanID)
+	}
+	c.logger.Log(logLevel, "completed_request", args...)
 }
 
 func (c *Core) ReloadLogRequestsLevel() {
@@ -44,13 +44,19 @@ func coreInit(c *Core, conf *CoreConfig) error {
 	sealUnwrapperLogger := conf.Logger.Named("storage.sealunwrapper")
 	c.allLoggers = append(c.allLoggers, sealUnwrapperLogger)
 	c.sealUnwrapper = NewSealUnwrapper(phys, sealUnwrapperLogger)
+	// Coalesce identical concurrent reads which miss the cache, such as
+	// those of the mount tables and policies when clients reconnect en masse
+	unwrapped := c.sealUnwrapper
+	if !conf.DisableReadCoalescing {
+		unwrapped = physical.NewCoalescing(unwrapped, c.MetricSink().Sink)
+	}
 	// Wrap the physical backend in a cache layer if enabled
 	cacheLogger := c.baseLogger.Named("storage.cache")
 	c.allLoggers = append(c.allLoggers, cacheLogger)
 	if txnOK {
-		c.physical = physical.NewTransactionalCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical = physical.NewTransactionalCache(unwrapped, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
 	} else {
-		c.physical = physical.NewCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical = physical.NewCache(unwrapped, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
 	}
 	c.physicalCache = c.physical.(physical.ToggleablePurgemonster)
 
@@ -191,6 +197,10 @@ func (c *Core) namespaceByPath(path string) *namespace.Namespace {
 	return namespace.RootNamespace
 }
 
+func (c *Core) createNamespace(ctx context.Context, path string) (*namespace.Namespace, error) {
+	return nil, fmt.Errorf("namespaces are not supported")
+}
+
 func (c *Core) AllowForwardingViaHeader() bool {
 	return false
 }
@@ -7,10 +7,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -20,6 +23,10 @@ const (
 	CORSEnabled
 )
 
+// DefaultCORSMaxAge is the number of seconds browsers may cache the response
+// to a preflight request for, unless a CORS policy sets another.
+const DefaultCORSMaxAge = 300
+
 var StdAllowedHeaders = []string{
 	"Content-Type",
 	"X-Requested-With",
@@ -31,6 +38,8 @@ var StdAllowedHeaders = []string{
 	"X-Vault-Policy-Override",
 	"Authorization",
 	consts.AuthHeaderName,
+	consts.IdempotencyKeyHeaderName,
+	consts.RequestTimeoutHeaderName,
 }
 
 // CORSConfig stores the state of the CORS configuration.
@@ -40,6 +49,59 @@ type CORSConfig struct {
 	Enabled        *uint32  `json:"enabled"`
 	AllowedOrigins []string `json:"allowed_origins,omitempty"`
 	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	MaxAge         int      `json:"max_age,omitempty"`
+
+	// NamespacePolicies are the CORS policies of namespaces, keyed by the
+	// canonical path of the namespace. They take precedence over the CORS
+	// policies of listeners and over the global configuration.
+	NamespacePolicies map[string]*CORSPolicy `json:"namespace_policies,omitempty"`
+}
+
+// CORSPolicy is the CORS policy of a listener or of a namespace: the origins
+// allowed to make cross-origin requests, the headers they may send, and how
+// long browsers may cache preflight responses for, in seconds.
+type CORSPolicy struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	MaxAge         int      `json:"max_age,omitempty"`
+}
+
+// NewCORSPolicy validates the origins of a CORS policy, and returns it with
+// the standard headers Vault accepts added to the allowed headers.
+func NewCORSPolicy(origins, headers []string, maxAge int) (*CORSPolicy, error) {
+	if len(origins) == 0 {
+		return nil, errors.New("at least one origin or the wildcard must be provided")
+	}
+	if strutil.StrListContains(origins, "*") && len(origins) > 1 {
+		return nil, errors.New("to allow all origins the '*' must be the only value for allowed_origins")
+	}
+	if maxAge < 0 {
+		return nil, errors.New("max_age cannot be negative")
+	}
+
+	return &CORSPolicy{
+		AllowedOrigins: origins,
+		AllowedHeaders: append(append([]string{}, StdAllowedHeaders...), headers...),
+		MaxAge:         maxAge,
+	}, nil
+}
+
+// IsValidOrigin determines if the origin is allowed to make cross-origin
+// requests by the policy.
+func (p *CORSPolicy) IsValidOrigin(origin string) bool {
+	if len(p.AllowedOrigins) == 1 && p.AllowedOrigins[0] == "*" {
+		return true
+	}
+	return strutil.StrListContains(p.AllowedOrigins, origin)
+}
+
+// MaxAgeOrDefault returns the max age of the policy, or DefaultCORSMaxAge if
+// the policy does not set one.
+func (p *CORSPolicy) MaxAgeOrDefault() int {
+	if p.MaxAge > 0 {
+		return p.MaxAge
+	}
+	return DefaultCORSMaxAge
 }
 
 func (c *Core) saveCORSConfig(ctx context.Context) error {
@@ -52,6 +114,8 @@ func (c *Core) saveCORSConfig(ctx context.Context) error {
 	c.corsConfig.RLock()
 	localConfig.AllowedOrigins = c.corsConfig.AllowedOrigins
 	localConfig.AllowedHeaders = c.corsConfig.AllowedHeaders
+	localConfig.MaxAge = c.corsConfig.MaxAge
+	localConfig.NamespacePolicies = c.corsConfig.NamespacePolicies
 	c.corsConfig.RUnlock()
 
 	entry, err := logical.StorageEntryJSON("cors", localConfig)
@@ -99,25 +163,24 @@ func (c *Core) loadCORSConfig(ctx context.Context) error {
 // Enable takes either a '*' or a comma-separated list of URLs that can make
 // cross-origin requests to Vault.
 func (c *CORSConfig) Enable(ctx context.Context, urls []string, headers []string) error {
-	if len(urls) == 0 {
-		return errors.New("at least one origin or the wildcard must be provided")
-	}
+	return c.EnableWithMaxAge(ctx, urls, headers, 0)
+}
 
-	if strutil.StrListContains(urls, "*") && len(urls) > 1 {
-		return errors.New("to allow all origins the '*' must be the only value for allowed_origins")
+// EnableWithMaxAge enables CORS like Enable, with the number of seconds
+// browsers may cache preflight responses for. Zero uses DefaultCORSMaxAge.
+func (c *CORSConfig) EnableWithMaxAge(ctx context.Context, urls []string, headers []string, maxAge int) error {
+	// Start with the standard headers to Vault accepts, and allow the user to
+	// add additional headers to the list of headers allowed on cross-origin
+	// requests.
+	policy, err := NewCORSPolicy(urls, headers, maxAge)
+	if err != nil {
+		return err
 	}
 
 	c.Lock()
-	c.AllowedOrigins = urls
-
-	// Start with the standard headers to Vault accepts.
-	c.AllowedHeaders = append([]string{}, StdAllowedHeaders...)
-
-	// Allow the user to add additional headers to the list of
-	// headers allowed on cross-origin requests.
-	if len(headers) > 0 {
-		c.AllowedHeaders = append(c.AllowedHeaders, headers...)
-	}
+	c.AllowedOrigins = policy.AllowedOrigins
+	c.AllowedHeaders = policy.AllowedHeaders
+	c.MaxAge = policy.MaxAge
 	c.Unlock()
 
 	atomic.StoreUint32(c.Enabled, CORSEnabled)
@@ -137,6 +200,7 @@ func (c *CORSConfig) Disable(ctx context.Context) error {
 
 	c.AllowedOrigins = nil
 	c.AllowedHeaders = nil
+	c.MaxAge = 0
 
 	c.Unlock()
 
@@ -164,3 +228,88 @@ func (c *CORSConfig) IsValidOrigin(origin string) bool {
 
 	return strutil.StrListContains(c.AllowedOrigins, origin)
 }
+
+// Policy returns the global CORS policy, or nil if CORS is not enabled.
+func (c *CORSConfig) Policy() *CORSPolicy {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+	return &CORSPolicy{
+		AllowedOrigins: c.AllowedOrigins,
+		AllowedHeaders: c.AllowedHeaders,
+		MaxAge:         c.MaxAge,
+	}
+}
+
+// NamespacePolicy returns the CORS policy of the most specific namespace
+// containing the request path, which is the path of the request from the
+// root namespace, including the namespace of the request. It returns nil if
+// none of the namespaces of the request path have a CORS policy.
+func (c *CORSConfig) NamespacePolicy(requestPath string) *CORSPolicy {
+	c.RLock()
+	defer c.RUnlock()
+
+	var longest string
+	var policy *CORSPolicy
+	for nsPath, p := range c.NamespacePolicies {
+		if strings.HasPrefix(requestPath, nsPath) && len(nsPath) > len(longest) {
+			longest = nsPath
+			policy = p
+		}
+	}
+	return policy
+}
+
+// NamespacePolicyByPath returns the CORS policy of the namespace, or nil if it
+// has none.
+func (c *CORSConfig) NamespacePolicyByPath(nsPath string) *CORSPolicy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.NamespacePolicies[namespace.Canonicalize(nsPath)]
+}
+
+// NamespacePolicyPaths returns the sorted paths of the namespaces which have a
+// CORS policy.
+func (c *CORSConfig) NamespacePolicyPaths() []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	paths := make([]string, 0, len(c.NamespacePolicies))
+	for nsPath := range c.NamespacePolicies {
+		paths = append(paths, nsPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SetNamespacePolicy sets the CORS policy of the namespace, or removes it if
+// policy is nil.
+func (c *CORSConfig) SetNamespacePolicy(ctx context.Context, nsPath string, policy *CORSPolicy) error {
+	nsPath = namespace.Canonicalize(nsPath)
+	if nsPath == "" {
+		return errors.New("the CORS policy of the root namespace is the global CORS configuration")
+	}
+
+	c.Lock()
+	// Copy the policies, as saving the configuration reads them without the
+	// lock held
+	policies := make(map[string]*CORSPolicy, len(c.NamespacePolicies)+1)
+	for k, v := range c.NamespacePolicies {
+		policies[k] = v
+	}
+	if policy == nil {
+		delete(policies, nsPath)
+	} else {
+		policies[nsPath] = policy
+	}
+	if len(policies) == 0 {
+		policies = nil
+	}
+	c.NamespacePolicies = policies
+	c.Unlock()
+
+	return c.core.saveCORSConfig(ctx)
+}
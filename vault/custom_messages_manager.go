@@ -17,4 +17,7 @@ type CustomMessagesManager interface {
 	ReadMessage(context.Context, string) (*uicustommessages.Message, error)
 	UpdateMessage(context.Context, uicustommessages.Message) (*uicustommessages.Message, error)
 	DeleteMessage(context.Context, string) error
+	PendingAcknowledgments(context.Context, string) ([]uicustommessages.Message, error)
+	Acknowledge(context.Context, string, string, int) error
+	ReadAcknowledgments(context.Context, string) ([]uicustommessages.Acknowledgment, error)
 }
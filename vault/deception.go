@@ -0,0 +1,354 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/base62"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	deceptionSubPath        = "deception/"
+	deceptionConfigPath     = "config"
+	deceptionTokensPrefix   = "tokens/"
+	deceptionPathsPrefix    = "paths/"
+	deceptionWebhookTimeout = 10 * time.Second
+
+	deceptionSeverityHigh = "high"
+)
+
+// DeceptionConfig configures how deception triggers are reported in
+// addition to audit entries.
+type DeceptionConfig struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// honeytokenEntry is a decoy token. Only a hash of the token is stored, as
+// it is never used to authenticate.
+type honeytokenEntry struct {
+	Name         string    `json:"name"`
+	TokenHash    string    `json:"token_hash"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// decoyPathEntry is a path, relative to the root namespace, whose access
+// triggers an alert. A path ending in '/' matches every path below it.
+type decoyPathEntry struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// deceptionState is the in-memory view of the configured decoys.
+type deceptionState struct {
+	config      *DeceptionConfig
+	honeytokens map[string]*honeytokenEntry
+	decoyPaths  map[string]*decoyPathEntry
+
+	// honeytokensByHash indexes honeytokens by token hash, so that the token
+	// of every request is matched without scanning all the honeytokens.
+	honeytokensByHash map[string]*honeytokenEntry
+}
+
+// honeytokenHash returns the hash honeytokens are stored and looked up by.
+func honeytokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// indexHoneytokens rebuilds the index of the honeytokens by token hash.
+func (s *deceptionState) indexHoneytokens() {
+	s.honeytokensByHash = make(map[string]*honeytokenEntry, len(s.honeytokens))
+	for _, ht := range s.honeytokens {
+		s.honeytokensByHash[ht.TokenHash] = ht
+	}
+}
+
+func (s *deceptionState) empty() bool {
+	return len(s.honeytokens) == 0 && len(s.decoyPaths) == 0
+}
+
+// match returns the trigger caused by a request with the given client token
+// and absolute path, if any.
+func (s *deceptionState) match(tokens []string, path string) *logical.DeceptionTrigger {
+	if len(s.honeytokens) > 0 {
+		for _, token := range tokens {
+			if token == "" {
+				continue
+			}
+			if ht, ok := s.honeytokensByHash[honeytokenHash(token)]; ok {
+				return &logical.DeceptionTrigger{
+					Type:     logical.DeceptionTriggerHoneytoken,
+					Name:     ht.Name,
+					Severity: deceptionSeverityHigh,
+				}
+			}
+		}
+	}
+
+	for _, dp := range s.decoyPaths {
+		if path == dp.Path || (strings.HasSuffix(dp.Path, "/") && strings.HasPrefix(path, dp.Path)) {
+			return &logical.DeceptionTrigger{
+				Type:     logical.DeceptionTriggerDecoyPath,
+				Name:     dp.Name,
+				Severity: deceptionSeverityHigh,
+			}
+		}
+	}
+	return nil
+}
+
+// checkDeception flags requests which use a honeytoken or access a decoy
+// path. The trigger is set on the request so that it is recorded in audit
+// entries, and is reported to the configured webhook. The request itself is
+// handled as usual: honeytokens do not exist in the token store and are
+// rejected as any other invalid token would be.
+func (c *Core) checkDeception(ctx context.Context, req *logical.Request) {
+	state := c.deception.Load()
+	if state == nil || state.empty() {
+		return
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	tokens := []string{req.ClientToken}
+	if token, ok := req.Data["token"].(string); ok {
+		tokens = append(tokens, token)
+	}
+
+	trigger := state.match(tokens, ns.Path+req.Path)
+	if trigger == nil {
+		return
+	}
+	req.DeceptionTrigger = trigger
+
+	var remoteAddr string
+	if req.Connection != nil {
+		remoteAddr = req.Connection.RemoteAddr
+	}
+
+	metrics.IncrCounterWithLabels([]string{"core", "deception", "triggered"}, 1, []metrics.Label{
+		{Name: "type", Value: trigger.Type},
+		{Name: "name", Value: trigger.Name},
+	})
+	c.logger.Error("deception triggered", "type", trigger.Type, "name", trigger.Name,
+		"path", ns.Path+req.Path, "operation", req.Operation, "remote_address", remoteAddr)
+
+	if state.config != nil && state.config.WebhookURL != "" {
+		go c.sendDeceptionWebhook(state.config.WebhookURL, map[string]interface{}{
+			"type":           trigger.Type,
+			"name":           trigger.Name,
+			"severity":       trigger.Severity,
+			"path":           req.Path,
+			"namespace":      ns.Path,
+			"operation":      req.Operation,
+			"request_id":     req.ID,
+			"remote_address": remoteAddr,
+			"time":           time.Now().UTC().Format(time.RFC3339Nano),
+		})
+	}
+}
+
+func (c *Core) sendDeceptionWebhook(url string, payload map[string]interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Error("failed to encode deception webhook payload", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deceptionWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		c.logger.Error("failed to create deception webhook request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		c.logger.Error("failed to send deception webhook", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		c.logger.Error("deception webhook returned an unexpected status", "status", resp.StatusCode)
+	}
+}
+
+// newHoneytoken returns a decoy token which is indistinguishable in format
+// from a service token.
+func newHoneytoken() (string, error) {
+	id, err := base62.Random(TokenLength)
+	if err != nil {
+		return "", err
+	}
+	return consts.ServiceTokenPrefix + id, nil
+}
+
+func (c *Core) deceptionView() *BarrierView {
+	return c.systemBarrierView.SubView(deceptionSubPath)
+}
+
+// updateDeceptionState applies f to a copy of the current deception state.
+func (c *Core) updateDeceptionState(f func(*deceptionState)) {
+	c.deceptionLock.Lock()
+	defer c.deceptionLock.Unlock()
+
+	state := &deceptionState{
+		honeytokens: make(map[string]*honeytokenEntry),
+		decoyPaths:  make(map[string]*decoyPathEntry),
+	}
+	if current := c.deception.Load(); current != nil {
+		state.config = current.config
+		for k, v := range current.honeytokens {
+			state.honeytokens[k] = v
+		}
+		for k, v := range current.decoyPaths {
+			state.decoyPaths[k] = v
+		}
+	}
+	f(state)
+	state.indexHoneytokens()
+	c.deception.Store(state)
+}
+
+func (c *Core) saveDeceptionConfig(ctx context.Context, config *DeceptionConfig) error {
+	entry, err := logical.StorageEntryJSON(deceptionConfigPath, config)
+	if err != nil {
+		return fmt.Errorf("failed to create deception config entry: %w", err)
+	}
+	if err := c.deceptionView().Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save deception config: %w", err)
+	}
+
+	c.updateDeceptionState(func(s *deceptionState) { s.config = config })
+	return nil
+}
+
+func (c *Core) saveHoneytoken(ctx context.Context, ht *honeytokenEntry) error {
+	entry, err := logical.StorageEntryJSON(deceptionTokensPrefix+ht.Name, ht)
+	if err != nil {
+		return fmt.Errorf("failed to create honeytoken entry: %w", err)
+	}
+	if err := c.deceptionView().Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save honeytoken: %w", err)
+	}
+
+	c.updateDeceptionState(func(s *deceptionState) { s.honeytokens[ht.Name] = ht })
+	return nil
+}
+
+func (c *Core) deleteHoneytoken(ctx context.Context, name string) error {
+	if err := c.deceptionView().Delete(ctx, deceptionTokensPrefix+name); err != nil {
+		return fmt.Errorf("failed to delete honeytoken: %w", err)
+	}
+
+	c.updateDeceptionState(func(s *deceptionState) { delete(s.honeytokens, name) })
+	return nil
+}
+
+func (c *Core) saveDecoyPath(ctx context.Context, dp *decoyPathEntry) error {
+	entry, err := logical.StorageEntryJSON(deceptionPathsPrefix+dp.Name, dp)
+	if err != nil {
+		return fmt.Errorf("failed to create decoy path entry: %w", err)
+	}
+	if err := c.deceptionView().Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save decoy path: %w", err)
+	}
+
+	c.updateDeceptionState(func(s *deceptionState) { s.decoyPaths[dp.Name] = dp })
+	return nil
+}
+
+func (c *Core) deleteDecoyPath(ctx context.Context, name string) error {
+	if err := c.deceptionView().Delete(ctx, deceptionPathsPrefix+name); err != nil {
+		return fmt.Errorf("failed to delete decoy path: %w", err)
+	}
+
+	c.updateDeceptionState(func(s *deceptionState) { delete(s.decoyPaths, name) })
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadDeception(ctx context.Context) error {
+	view := c.deceptionView()
+
+	state := &deceptionState{
+		honeytokens: make(map[string]*honeytokenEntry),
+		decoyPaths:  make(map[string]*decoyPathEntry),
+	}
+
+	out, err := view.Get(ctx, deceptionConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read deception config: %w", err)
+	}
+	if out != nil {
+		state.config = &DeceptionConfig{}
+		if err := out.DecodeJSON(state.config); err != nil {
+			return err
+		}
+	}
+
+	names, err := view.List(ctx, deceptionTokensPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list honeytokens: %w", err)
+	}
+	for _, name := range names {
+		out, err := view.Get(ctx, deceptionTokensPrefix+name)
+		if err != nil {
+			return fmt.Errorf("failed to read honeytoken: %w", err)
+		}
+		if out == nil {
+			continue
+		}
+		var ht honeytokenEntry
+		if err := out.DecodeJSON(&ht); err != nil {
+			return err
+		}
+		state.honeytokens[ht.Name] = &ht
+	}
+
+	names, err = view.List(ctx, deceptionPathsPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list decoy paths: %w", err)
+	}
+	for _, name := range names {
+		out, err := view.Get(ctx, deceptionPathsPrefix+name)
+		if err != nil {
+			return fmt.Errorf("failed to read decoy path: %w", err)
+		}
+		if out == nil {
+			continue
+		}
+		var dp decoyPathEntry
+		if err := out.DecodeJSON(&dp); err != nil {
+			return err
+		}
+		state.decoyPaths[dp.Name] = &dp
+	}
+
+	state.indexHoneytokens()
+	c.deception.Store(state)
+	return nil
+}
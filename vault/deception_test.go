@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_Deception verifies that using a honeytoken or accessing a decoy
+// path is reported to the webhook, while the request is otherwise handled
+// as usual.
+func TestCore_Deception(t *testing.T) {
+	triggers := make(chan map[string]interface{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		triggers <- payload
+	}))
+	defer srv.Close()
+
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path, token string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+	nextTrigger := func() map[string]interface{} {
+		select {
+		case payload := <-triggers:
+			return payload
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not called")
+			return nil
+		}
+	}
+
+	_, err := request(logical.UpdateOperation, "sys/deception/config", root, map[string]interface{}{
+		"webhook_url": srv.URL,
+	})
+	require.NoError(t, err)
+
+	resp, err := request(logical.UpdateOperation, "sys/deception/tokens/breakglass", root, nil)
+	require.NoError(t, err)
+	honeytoken := resp.Data["token"].(string)
+	require.NotEmpty(t, honeytoken)
+
+	resp, err = request(logical.UpdateOperation, "sys/deception/tokens/breakglass", root, nil)
+	require.True(t, err != nil || resp.IsError())
+
+	// The honeytoken cannot be used to authenticate
+	_, err = request(logical.ReadOperation, "auth/token/lookup-self", honeytoken, nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	payload := nextTrigger()
+	require.Equal(t, logical.DeceptionTriggerHoneytoken, payload["type"])
+	require.Equal(t, "breakglass", payload["name"])
+
+	_, err = request(logical.UpdateOperation, "sys/deception/paths/admin", root, map[string]interface{}{
+		"path": "cubbyhole/admin/",
+	})
+	require.NoError(t, err)
+
+	// Requests to other paths are not reported
+	_, err = request(logical.UpdateOperation, "cubbyhole/other", root, map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+
+	// Decoy paths remain usable
+	_, err = request(logical.UpdateOperation, "cubbyhole/admin/creds", root, map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+	payload = nextTrigger()
+	require.Equal(t, logical.DeceptionTriggerDecoyPath, payload["type"])
+	require.Equal(t, "admin", payload["name"])
+	require.Equal(t, "cubbyhole/admin/creds", payload["path"])
+
+	// Decoys survive a reload
+	require.NoError(t, c.loadDeception(ctx))
+	state := c.deception.Load()
+	require.Contains(t, state.honeytokens, "breakglass")
+	require.Contains(t, state.honeytokensByHash, honeytokenHash(honeytoken))
+	require.Contains(t, state.decoyPaths, "admin")
+	require.Equal(t, srv.URL, state.config.WebhookURL)
+
+	resp, err = request(logical.ListOperation, "sys/deception/paths/", root, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin"}, resp.Data["keys"])
+
+	_, err = request(logical.DeleteOperation, "sys/deception/paths/admin", root, nil)
+	require.NoError(t, err)
+	_, err = request(logical.DeleteOperation, "sys/deception/tokens/breakglass", root, nil)
+	require.NoError(t, err)
+	require.True(t, c.deception.Load().empty())
+	require.Empty(t, c.deception.Load().honeytokensByHash)
+
+	select {
+	case payload := <-triggers:
+		t.Fatalf("unexpected trigger: %v", payload)
+	default:
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	deprecationUsageKindBuiltin  = "builtin"
+	deprecationUsageKindEndpoint = "endpoint"
+
+	// deprecationUsageMaxEntities is the number of distinct entities recorded
+	// per usage, past which the usage only counts the requests.
+	deprecationUsageMaxEntities = 100
+)
+
+// deprecationUsageTracker counts the requests served by deprecated builtins
+// and deprecated endpoints, per namespace and mount, so that operators know
+// who breaks before pending removal builtins are denied. Usage is tracked in
+// memory by each node, until it restarts or the usage is reset.
+type deprecationUsageTracker struct {
+	l     sync.Mutex
+	usage map[deprecationUsageKey]*deprecationUsage
+
+	// statuses caches the deprecation status of the builtin of each mount,
+	// by mount UUID and running version, as looking it up reads the catalog.
+	statuses sync.Map
+}
+
+type deprecationUsageKey struct {
+	kind          string
+	namespaceID   string
+	mountAccessor string
+	endpoint      string
+}
+
+type deprecationUsage struct {
+	namespacePath string
+	mountPath     string
+	mountType     string
+	status        string
+	replacement   string
+	sunset        time.Time
+	count         uint64
+	firstSeen     time.Time
+	lastSeen      time.Time
+	entities      map[string]uint64
+	noEntityCount uint64
+}
+
+func newDeprecationUsageTracker() *deprecationUsageTracker {
+	return &deprecationUsageTracker{
+		usage: make(map[deprecationUsageKey]*deprecationUsage),
+	}
+}
+
+func (t *deprecationUsageTracker) record(key deprecationUsageKey, ns *namespace.Namespace, entry *MountEntry, status string, req *logical.Request, now time.Time) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	usage, ok := t.usage[key]
+	if !ok {
+		usage = &deprecationUsage{
+			namespacePath: ns.Path,
+			mountPath:     entry.Path,
+			mountType:     entry.Type,
+			status:        status,
+			firstSeen:     now,
+			entities:      make(map[string]uint64),
+		}
+		if key.kind == deprecationUsageKindEndpoint {
+			usage.replacement, usage.sunset = req.DeprecationHints()
+		}
+		if entry.Table == credentialTableType {
+			usage.mountPath = credentialRoutePrefix + entry.Path
+		}
+		t.usage[key] = usage
+	}
+	usage.count++
+	usage.lastSeen = now
+	_, seen := usage.entities[req.EntityID]
+	switch {
+	case req.EntityID == "":
+		usage.noEntityCount++
+	case seen || len(usage.entities) < deprecationUsageMaxEntities:
+		usage.entities[req.EntityID]++
+	}
+}
+
+func (t *deprecationUsageTracker) reset() {
+	t.l.Lock()
+	defer t.l.Unlock()
+	t.usage = make(map[deprecationUsageKey]*deprecationUsage)
+}
+
+// builtinDeprecationStatus returns the deprecation status of the builtin of
+// the mount, if it is deprecated.
+func (c *Core) builtinDeprecationStatus(ctx context.Context, entry *MountEntry) (consts.DeprecationStatus, bool) {
+	cacheKey := entry.UUID + "/" + entry.RunningVersion
+	if cached, ok := c.deprecationUsage.statuses.Load(cacheKey); ok {
+		status := cached.(consts.DeprecationStatus)
+		return status, status != consts.Supported
+	}
+
+	var status consts.DeprecationStatus = consts.Supported
+	if c.builtinRegistry != nil && !entry.IsExternalPlugin() {
+		t := entry.Type
+		if alias, ok := mountAliases[t]; ok {
+			t = alias
+		}
+		if s, ok := c.builtinRegistry.DeprecationStatus(t, c.builtinTypeFromMountEntry(ctx, entry)); ok {
+			status = s
+		}
+	}
+	c.deprecationUsage.statuses.Store(cacheKey, status)
+	return status, status != consts.Supported
+}
+
+// recordDeprecationUsage counts the request if it was served by a deprecated
+// builtin or a deprecated endpoint.
+func (c *Core) recordDeprecationUsage(ctx context.Context, req *logical.Request, entry *MountEntry) {
+	if c.deprecationUsage == nil || entry == nil {
+		return
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+
+	if status, ok := c.builtinDeprecationStatus(ctx, entry); ok {
+		c.deprecationUsage.record(deprecationUsageKey{
+			kind:          deprecationUsageKindBuiltin,
+			namespaceID:   ns.ID,
+			mountAccessor: entry.Accessor,
+		}, ns, entry, status.String(), req, now)
+		c.metricSink.IncrCounterWithLabels([]string{"core", "deprecation", "builtin_usage"}, 1, []metrics.Label{
+			metricsutil.NamespaceLabel(ns),
+			{"mount_type", entry.Type},
+			{"status", status.String()},
+		})
+	}
+
+	if pattern := req.DeprecatedEndpoint(); pattern != "" {
+		c.deprecationUsage.record(deprecationUsageKey{
+			kind:          deprecationUsageKindEndpoint,
+			namespaceID:   ns.ID,
+			mountAccessor: entry.Accessor,
+			endpoint:      pattern,
+		}, ns, entry, consts.DeprecationStatus(consts.Deprecated).String(), req, now)
+		c.metricSink.IncrCounterWithLabels([]string{"core", "deprecation", "endpoint_usage"}, 1, []metrics.Label{
+			metricsutil.NamespaceLabel(ns),
+			{"mount_type", entry.Type},
+		})
+	}
+}
+
+// deprecationUsageReport returns the usage of deprecated builtins and
+// endpoints in the namespace and its children, most used first.
+func (c *Core) deprecationUsageReport(ns *namespace.Namespace) []map[string]interface{} {
+	t := c.deprecationUsage
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	type reportEntry struct {
+		key   deprecationUsageKey
+		usage *deprecationUsage
+	}
+	var entries []reportEntry
+	for key, usage := range t.usage {
+		if !strings.HasPrefix(usage.namespacePath, ns.Path) {
+			continue
+		}
+		entries = append(entries, reportEntry{key: key, usage: usage})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].usage.count != entries[j].usage.count {
+			return entries[i].usage.count > entries[j].usage.count
+		}
+		return entries[i].usage.lastSeen.After(entries[j].usage.lastSeen)
+	})
+
+	report := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		entities := make([]string, 0, len(e.usage.entities))
+		entityCounts := make(map[string]uint64, len(e.usage.entities))
+		for entityID, count := range e.usage.entities {
+			entities = append(entities, entityID)
+			entityCounts[entityID] = count
+		}
+		sort.Strings(entities)
+
+		item := map[string]interface{}{
+			"kind":                 e.key.kind,
+			"namespace_id":         e.key.namespaceID,
+			"namespace_path":       e.usage.namespacePath,
+			"mount_accessor":       e.key.mountAccessor,
+			"mount_path":           e.usage.mountPath,
+			"mount_type":           e.usage.mountType,
+			"deprecation_status":   e.usage.status,
+			"count":                e.usage.count,
+			"no_entity_count":      e.usage.noEntityCount,
+			"entity_ids":           entities,
+			"entity_counts":        entityCounts,
+			"entity_ids_truncated": len(entities) >= deprecationUsageMaxEntities,
+			"first_seen":           e.usage.firstSeen.UTC().Format(time.RFC3339),
+			"last_seen":            e.usage.lastSeen.UTC().Format(time.RFC3339),
+		}
+		if e.key.endpoint != "" {
+			item["endpoint"] = e.key.endpoint
+		}
+		if e.usage.replacement != "" {
+			item["replacement"] = e.usage.replacement
+		}
+		if !e.usage.sunset.IsZero() {
+			item["sunset"] = e.usage.sunset.UTC().Format(time.RFC3339)
+		}
+		report = append(report, item)
+	}
+	return report
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationUsage(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	read := func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		return &logical.Response{Data: map[string]interface{}{"ok": true}}, nil
+	}
+	c.logicalBackends["legacy"] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
+		b := &framework.Backend{
+			BackendType: logical.TypeLogical,
+			Paths: []*framework.Path{
+				{
+					Pattern:    "old",
+					Deprecated: true,
+					Operations: map[logical.Operation]framework.OperationHandler{
+						logical.ReadOperation: &framework.PathOperation{Callback: read},
+					},
+				},
+				{
+					Pattern: "new",
+					Operations: map[logical.Operation]framework.OperationHandler{
+						logical.ReadOperation: &framework.PathOperation{Callback: read},
+					},
+				},
+			},
+		}
+		if err := b.Setup(ctx, config); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	for _, path := range []string{"legacy/", "pending/"} {
+		require.NoError(t, c.mount(ctx, &MountEntry{
+			Table: mountTableType,
+			Path:  path,
+			Type:  "legacy",
+		}))
+	}
+
+	// Treat the builtin of the second mount as pending removal
+	pending := c.router.MatchingMountEntry(ctx, "pending/")
+	require.NotNil(t, pending)
+	c.deprecationUsage.statuses.Store(pending.UUID+"/"+pending.RunningVersion, consts.DeprecationStatus(consts.PendingRemoval))
+
+	for _, path := range []string{"legacy/old", "legacy/old", "legacy/new", "pending/new"} {
+		req := logical.TestRequest(t, logical.ReadOperation, path)
+		req.ClientToken = root
+		_, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/deprecations/usage")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	usage := resp.Data["usage"].([]map[string]interface{})
+	require.Len(t, usage, 2)
+
+	require.Equal(t, deprecationUsageKindEndpoint, usage[0]["kind"])
+	require.Equal(t, "old", usage[0]["endpoint"])
+	require.Equal(t, "legacy/", usage[0]["mount_path"])
+	require.Equal(t, uint64(2), usage[0]["count"])
+	require.Equal(t, uint64(2), usage[0]["no_entity_count"])
+
+	require.Equal(t, deprecationUsageKindBuiltin, usage[1]["kind"])
+	require.Equal(t, "pending/", usage[1]["mount_path"])
+	require.Equal(t, "pending removal", usage[1]["deprecation_status"])
+	require.Equal(t, uint64(1), usage[1]["count"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/deprecations/usage")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, c.deprecationUsageReport(namespace.RootNamespace))
+}
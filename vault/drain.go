@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"time"
+)
+
+// drainProgressInterval is how often the progress of a drain is logged.
+var drainProgressInterval = 5 * time.Second
+
+// Draining returns whether the node is being drained before shutting down.
+func (c *Core) Draining() bool {
+	return c.draining.Load()
+}
+
+// Drain prepares the node to shut down without disrupting clients. It waits
+// for the queued lease revocations to complete, then steps the node down if
+// it is the HA active node so that another node takes over, and finally
+// waits for the in-flight requests to complete. Requests which find the node
+// stepped down are forwarded to the new active node. The progress is logged
+// periodically. Drain returns when the node is drained or when ctx is done,
+// whichever happens first; the node must be shut down afterwards.
+func (c *Core) Drain(ctx context.Context) error {
+	c.draining.Store(true)
+	c.logger.Info("draining node before shutdown")
+
+	ticker := time.NewTicker(drainProgressInterval)
+	defer ticker.Stop()
+
+	stepDownSent := false
+	for {
+		pending := c.pendingLeaseRevocations()
+		inFlight := c.inFlightReqData.InFlightReqCount.Load()
+		standby, _ := c.Standby()
+
+		// The active role is only handed off if there is another node to
+		// take it over, otherwise this node would immediately take it back.
+		handOff := !standby && !c.Sealed() && c.ha != nil && len(c.GetHAPeerNodesCached()) > 0
+
+		switch {
+		case pending > 0:
+		case handOff && !stepDownSent:
+			c.logger.Info("stepping down to hand off the active role before shutdown")
+			c.stateLock.RLock()
+			select {
+			case c.manualStepDownCh <- struct{}{}:
+			default:
+			}
+			c.stateLock.RUnlock()
+			stepDownSent = true
+			continue
+		case handOff:
+		case inFlight == 0:
+			c.logger.Info("node drained")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Warn("drain timed out, shutting down", "pending_lease_revocations", pending,
+				"in_flight_requests", inFlight, "active", handOff)
+			return ctx.Err()
+		case <-ticker.C:
+			c.logger.Info("draining", "pending_lease_revocations", pending,
+				"in_flight_requests", inFlight, "active", handOff)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// pendingLeaseRevocations returns the number of lease revocations which are
+// queued or in progress.
+func (c *Core) pendingLeaseRevocations() int {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	if c.expiration == nil || c.standby {
+		return 0
+	}
+	pending := c.expiration.jobManager.GetPendingJobCount()
+	for _, workers := range c.expiration.jobManager.GetWorkerCounts() {
+		pending += workers
+	}
+	return pending
+}
@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_Drain verifies that draining waits for the in-flight requests to
+// complete, up to the deadline of the context.
+func TestCore_Drain(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	require.False(t, c.Draining())
+
+	c.StoreInFlightReqData("req", InFlightReqData{StartTime: time.Now()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.Drain(ctx), context.DeadlineExceeded)
+	require.True(t, c.Draining())
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		c.FinalizeInFlightReqData("req", 200)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.Drain(ctx))
+}
@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// entityRevocation records the revocation of the credentials of a disabled
+// entity, to report its progress.
+type entityRevocation struct {
+	entityID      string
+	startTime     time.Time
+	tokensRevoked int
+	tokenErrors   []string
+	leases        []string
+}
+
+// EntityRevocationStatus is the progress of the revocation of the
+// credentials of a disabled entity.
+type EntityRevocationStatus struct {
+	EntityID          string
+	StartTime         time.Time
+	TokensRevoked     int
+	TokenErrors       []string
+	LeasesTotal       int
+	LeasesPending     int
+	LeasesRevoked     int
+	LeasesIrrevocable int
+	Complete          bool
+}
+
+// RevokeEntityCredentials revokes the tokens of the entity synchronously, and
+// the leases of the secrets issued to them with priority over the other
+// revocations. The leases of all the tokens are prioritized before any token
+// is revoked, so that those of their child tokens are prioritized as well.
+func (c *Core) RevokeEntityCredentials(ctx context.Context, entity *identity.Entity) (*EntityRevocationStatus, error) {
+	defer metrics.MeasureSince([]string{"identity", "entity", "revoke_credentials"}, time.Now())
+
+	entityNS, err := NamespaceByID(ctx, entity.NamespaceID, c)
+	if err != nil {
+		return nil, err
+	}
+	if entityNS == nil {
+		return nil, namespace.ErrNoNamespace
+	}
+	nsCtx := namespace.ContextWithNamespace(ctx, entityNS)
+
+	accessors, err := c.tokenStore.accessorView(entityNS).List(nsCtx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list token accessors: %w", err)
+	}
+
+	rev := &entityRevocation{
+		entityID:  entity.ID,
+		startTime: time.Now().UTC(),
+	}
+
+	var tokens []*logical.TokenEntry
+	for _, accessor := range accessors {
+		aEntry, err := c.tokenStore.lookupByAccessor(nsCtx, accessor, true, false)
+		if err != nil || aEntry == nil || aEntry.TokenID == "" {
+			continue
+		}
+		te, err := c.tokenStore.lookupInternal(nsCtx, aEntry.TokenID, false, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up token: %w", err)
+		}
+		if te == nil || te.EntityID != entity.ID || te.Type == logical.TokenTypeBatch {
+			continue
+		}
+
+		tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+		if err != nil {
+			return nil, err
+		}
+		if tokenNS == nil {
+			continue
+		}
+		leases, err := c.expiration.lookupLeasesByToken(namespace.ContextWithNamespace(ctx, tokenNS), te)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the leases of token %q: %w", te.Accessor, err)
+		}
+		c.expiration.prioritizeRevocations(leases)
+		rev.leases = append(rev.leases, leases...)
+		tokens = append(tokens, te)
+	}
+
+	for _, te := range tokens {
+		if err := c.revokeEntityToken(te); err != nil {
+			c.logger.Error("failed to revoke token of disabled entity", "entity_id", entity.ID, "accessor", te.Accessor, "error", err)
+			rev.tokenErrors = append(rev.tokenErrors, fmt.Sprintf("token with accessor %q: %s", te.Accessor, err))
+			continue
+		}
+		rev.tokensRevoked++
+	}
+
+	c.logger.Info("revoked credentials of disabled entity", "entity_id", entity.ID,
+		"tokens", rev.tokensRevoked, "leases", len(rev.leases))
+	c.entityRevocations.Store(entity.ID, rev)
+	return c.expiration.entityRevocationStatus(rev), nil
+}
+
+// revokeEntityToken revokes the token and its children, as the revocation
+// endpoints of the token store do. Its leases are revoked asynchronously.
+func (c *Core) revokeEntityToken(te *logical.TokenEntry) error {
+	tokenNS, err := NamespaceByID(c.activeContext, te.NamespaceID, c)
+	if err != nil {
+		return err
+	}
+	if tokenNS == nil {
+		return namespace.ErrNoNamespace
+	}
+
+	revokeCtx := namespace.ContextWithNamespace(c.activeContext, tokenNS)
+	leaseID, err := c.expiration.CreateOrFetchRevocationLeaseByToken(revokeCtx, te)
+	if err != nil {
+		return err
+	}
+	return c.expiration.Revoke(revokeCtx, leaseID)
+}
+
+// EntityRevocationStatus returns the progress of the last revocation of the
+// credentials of the entity, or nil if there is none.
+func (c *Core) EntityRevocationStatus(entityID string) *EntityRevocationStatus {
+	raw, ok := c.entityRevocations.Load(entityID)
+	if !ok || c.expiration == nil {
+		return nil
+	}
+	return c.expiration.entityRevocationStatus(raw.(*entityRevocation))
+}
+
+// entityRevocationStatus counts the leases of the revocation which are still
+// pending, and those which could not be revoked.
+func (m *ExpirationManager) entityRevocationStatus(rev *entityRevocation) *EntityRevocationStatus {
+	status := &EntityRevocationStatus{
+		EntityID:      rev.entityID,
+		StartTime:     rev.startTime,
+		TokensRevoked: rev.tokensRevoked,
+		TokenErrors:   rev.tokenErrors,
+		LeasesTotal:   len(rev.leases),
+	}
+	for _, leaseID := range rev.leases {
+		if _, ok := m.irrevocable.Load(leaseID); ok {
+			status.LeasesIrrevocable++
+		} else if _, ok := m.pending.Load(leaseID); ok {
+			status.LeasesPending++
+		} else {
+			status.LeasesRevoked++
+		}
+	}
+	status.Complete = status.LeasesPending == 0 && len(status.TokenErrors) == 0
+	return status
+}
@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// entityTokensPrefix is the prefix used to index the tokens issued to
+	// entities by the auth mounts which limit their number, by mount accessor,
+	// entity ID and salted token accessor
+	entityTokensPrefix = "entity-tokens/"
+
+	// entityTokenLimitDeny makes logins fail when the entity has reached the
+	// limit of tokens of the auth mount.
+	entityTokenLimitDeny = "deny"
+
+	// entityTokenLimitEvictOldest makes logins revoke the oldest tokens of
+	// the entity to stay within the limit of tokens of the auth mount.
+	entityTokenLimitEvictOldest = "evict_oldest"
+)
+
+// entityTokenIndexEntry is stored for each token issued to an entity by an
+// auth mount limiting their number. Entries of tokens which are no longer
+// valid are removed on the next login of the entity.
+type entityTokenIndexEntry struct {
+	IssueTime time.Time `json:"issue_time"`
+}
+
+// entityToken is a valid token indexed for an entity.
+type entityToken struct {
+	key       string
+	te        *logical.TokenEntry
+	issueTime time.Time
+}
+
+// entityTokenLimitKey returns the prefix of the index of the tokens issued to
+// the entity by the auth mount.
+func entityTokenLimitKey(mountAccessor, entityID string) string {
+	return mountAccessor + "/" + entityID + "/"
+}
+
+// reserveEntityToken makes room for a new token issued to the entity by the
+// auth mount, according to the limit of tokens of the mount: it fails with
+// logical.ErrPermissionDenied if the entity has reached the limit, or revokes
+// its oldest tokens if the mount evicts them. It returns with a lock held on
+// the tokens of the entity, so that the new token is indexed with
+// indexEntityToken before other logins of the entity are checked; the
+// returned function releases it.
+func (ts *TokenStore) reserveEntityToken(ctx context.Context, mountEntry *MountEntry, entityID string) (func(), error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := entityTokenLimitKey(mountEntry.Accessor, entityID)
+	lock := locksutil.LockForKey(ts.entityTokenLocks, prefix)
+	lock.Lock()
+
+	tokens, err := ts.entityTokens(ctx, ns, prefix)
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+
+	limit := mountEntry.Config.MaxEntityTokens
+	for len(tokens) >= limit {
+		if mountEntry.Config.EntityTokenLimitAction != entityTokenLimitEvictOldest {
+			lock.Unlock()
+			return nil, fmt.Errorf("entity has reached the limit of %d tokens issued by this auth method: %w", limit, logical.ErrPermissionDenied)
+		}
+
+		oldest := tokens[0]
+		if err := ts.core.revokeEntityToken(oldest.te); err != nil {
+			lock.Unlock()
+			return nil, fmt.Errorf("failed to evict the oldest token of the entity: %w", err)
+		}
+		if err := ts.entityTokensView(ns).Delete(ctx, oldest.key); err != nil {
+			lock.Unlock()
+			return nil, err
+		}
+		ts.logger.Info("evicted oldest token of entity at its limit of tokens", "entity_id", entityID,
+			"mount_accessor", mountEntry.Accessor, "accessor", oldest.te.Accessor)
+		tokens = tokens[1:]
+	}
+
+	return lock.Unlock, nil
+}
+
+// indexEntityToken records the token issued to its entity by the auth mount,
+// so that it counts toward the limit of tokens of the mount.
+func (ts *TokenStore) indexEntityToken(ctx context.Context, mountEntry *MountEntry, te *logical.TokenEntry) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	saltedAccessor, err := ts.SaltID(ctx, te.Accessor)
+	if err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(entityTokenLimitKey(mountEntry.Accessor, te.EntityID)+saltedAccessor, &entityTokenIndexEntry{
+		IssueTime: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return ts.entityTokensView(ns).Put(ctx, entry)
+}
+
+// entityTokens returns the valid tokens indexed under the prefix, oldest
+// first, and removes the entries of the tokens which are no longer valid.
+func (ts *TokenStore) entityTokens(ctx context.Context, ns *namespace.Namespace, prefix string) ([]*entityToken, error) {
+	view := ts.entityTokensView(ns)
+	keys, err := view.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tokens of the entity: %w", err)
+	}
+
+	var tokens []*entityToken
+	for _, saltedAccessor := range keys {
+		key := prefix + saltedAccessor
+
+		var index entityTokenIndexEntry
+		raw, err := view.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		if err := jsonutil.DecodeJSON(raw.Value, &index); err != nil {
+			return nil, err
+		}
+
+		var te *logical.TokenEntry
+		aEntry, err := ts.lookupByAccessor(ctx, saltedAccessor, true, false)
+		if err != nil {
+			return nil, err
+		}
+		if aEntry != nil && aEntry.TokenID != "" {
+			te, err = ts.Lookup(ctx, aEntry.TokenID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if te == nil {
+			if err := view.Delete(ctx, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tokens = append(tokens, &entityToken{key: key, te: te, issueTime: index.IssueTime})
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].issueTime.Before(tokens[j].issueTime)
+	})
+	return tokens, nil
+}
+
+// entityTokenLimitAction returns the action taken on the logins of an entity
+// which has reached the limit of tokens of the auth mount.
+func (c *MountConfig) entityTokenLimitAction() string {
+	if c.EntityTokenLimitAction == "" {
+		return entityTokenLimitDeny
+	}
+	return c.EntityTokenLimitAction
+}
+
+// validateEntityTokenLimitAction checks the action taken when an entity
+// reaches the limit of tokens of an auth mount.
+func validateEntityTokenLimitAction(action string) error {
+	switch action {
+	case "", entityTokenLimitDeny, entityTokenLimitEvictOldest:
+		return nil
+	default:
+		return fmt.Errorf("invalid entity token limit action %q, must be %q or %q", action, entityTokenLimitDeny, entityTokenLimitEvictOldest)
+	}
+}
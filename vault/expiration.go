@@ -28,6 +28,7 @@ import (
 	"github.com/hashicorp/vault/helper/locking"
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/timeutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
@@ -71,6 +72,10 @@ const (
 
 	fairshareWorkersOverrideVar = "VAULT_LEASE_REVOCATION_WORKERS"
 
+	// priorityRevocationQueueID is the job queue of the prioritized lease
+	// revocations, which are served ahead of those of the mounts
+	priorityRevocationQueueID = "priority"
+
 	// limit irrevocable error messages to 240 characters to be respectful of
 	// storage/memory
 	maxIrrevocableErrorLength = 240
@@ -106,16 +111,24 @@ type ExpirationManager struct {
 	tokenStore *TokenStore
 	logger     log.Logger
 
-	// Although the data structure itself is atomic,
-	// pendingLock should be held to ensure lease modifications
-	// are atomic (with respect to storage, expiration time,
-	// and particularly the lease count.)
+	// clock is the clock by which leases are issued, renewed, and expired
+	clock timeutil.Clock
+
+	// Although the data structure itself is atomic, the pending
+	// state of a lease should be locked with lockPendingShard to
+	// ensure lease modifications are atomic (with respect to storage,
+	// expiration time, and particularly the lease count.) It holds
+	// pendingLock for reading and the lock of the shard of the mount
+	// of the lease, so that churn on one mount, such as a revocation
+	// storm, does not block renewals on the others. pendingLock is
+	// held for writing to reset the state of all the leases.
 	// The nonexpiring map holds entries for root tokens with
 	// TTL zero, which we want to count but have no timer associated.
-	pending     sync.Map
-	nonexpiring sync.Map
-	leaseCount  int
-	pendingLock locking.RWMutex
+	pending       sync.Map
+	nonexpiring   sync.Map
+	leaseCount    int64
+	pendingLock   locking.RWMutex
+	pendingShards sync.Map
 
 	// A sync.Lock for every active leaseID
 	lockPerLease sync.Map
@@ -124,13 +137,14 @@ type ExpirationManager struct {
 	irrevocable sync.Map
 
 	// Track count for metrics reporting
-	// This value is protected by pendingLock
-	irrevocableLeaseCount int
+	// This value is updated atomically, with the pending state locked
+	irrevocableLeaseCount int64
 
 	// The uniquePolicies map holds policy sets, so they can
 	// be deduplicated. It is periodically emptied to prevent
 	// unbounded growth.
 	uniquePolicies      map[string][]string
+	uniquePoliciesLock  sync.Mutex
 	emptyUniquePolicies *time.Ticker
 
 	tidyLock *int32
@@ -157,6 +171,10 @@ type ExpirationManager struct {
 
 	jobManager      *fairshare.JobManager
 	revokeRetryBase time.Duration
+
+	// prioritized holds the leases whose revocation jobs are queued ahead of
+	// the others, such as those of the tokens of disabled entities
+	prioritized sync.Map
 }
 
 type ExpireLeaseStrategy func(context.Context, *ExpirationManager, string, *namespace.Namespace)
@@ -240,17 +258,24 @@ func (r *revocationJob) Execute() error {
 func (r *revocationJob) OnFailure(err error) {
 	r.m.core.metricSink.IncrCounterWithLabels([]string{"expire", "lease_expiration", "error"}, 1, []metrics.Label{metricsutil.NamespaceLabel(r.ns)})
 
-	r.m.pendingLock.Lock()
+	unlock := r.m.lockPendingShard(r.ns, r.leaseID)
 	pendingRaw, ok := r.m.pending.Load(r.leaseID)
-	r.m.pendingLock.Unlock()
+	unlock()
 	if !ok {
 		r.m.logger.Warn("failed to find lease in pending map for revocation retry", "lease_id", r.leaseID)
 		return
 	}
 
 	pending := pendingRaw.(pendingInfo)
-	pending.revokesAttempted++
-	newTimer := r.revokeExponentialBackoff(pending.revokesAttempted)
+	var newTimer time.Duration
+	if errors.Is(err, logical.ErrMountSealed) {
+		// The revocation is retried until the mount is unsealed, rather
+		// than the lease becoming irrevocable
+		newTimer = r.revokeExponentialBackoff(maxRevokeAttempts - 1)
+	} else {
+		pending.revokesAttempted++
+		newTimer = r.revokeExponentialBackoff(pending.revokesAttempted)
+	}
 
 	if pending.revokesAttempted >= maxRevokeAttempts || errIsUnrecoverable(err) {
 		reason := "unrecoverable error"
@@ -270,9 +295,9 @@ func (r *revocationJob) OnFailure(err error) {
 			return
 		}
 
-		r.m.pendingLock.Lock()
+		unlock := r.m.lockPendingShard(r.ns, r.leaseID)
 		r.m.markLeaseIrrevocable(r.nsCtx, le, err)
-		r.m.pendingLock.Unlock()
+		unlock()
 		return
 	} else {
 		r.m.logger.Error("failed to revoke lease", "lease_id", r.leaseID, "error", err,
@@ -280,9 +305,9 @@ func (r *revocationJob) OnFailure(err error) {
 	}
 
 	pending.timer.Reset(newTimer)
-	r.m.pendingLock.Lock()
+	unlock = r.m.lockPendingShard(r.ns, r.leaseID)
 	r.m.pending.Store(r.leaseID, pending)
-	r.m.pendingLock.Unlock()
+	unlock()
 }
 
 func expireLeaseStrategyFairsharing(ctx context.Context, m *ExpirationManager, leaseID string, ns *namespace.Namespace) {
@@ -296,9 +321,21 @@ func expireLeaseStrategyFairsharing(ctx context.Context, m *ExpirationManager, l
 		return
 	}
 
+	if _, ok := m.prioritized.Load(leaseID); ok {
+		m.jobManager.AddPriorityJob(job, priorityRevocationQueueID)
+		return
+	}
 	m.jobManager.AddJob(job, mountAccessor)
 }
 
+// prioritizeRevocations queues the revocation jobs of the leases ahead of
+// the others once they expire.
+func (m *ExpirationManager) prioritizeRevocations(leaseIDs []string) {
+	for _, leaseID := range leaseIDs {
+		m.prioritized.Store(leaseID, struct{}{})
+	}
+}
+
 func (r *revocationJob) revokeExponentialBackoff(attempt uint8) time.Duration {
 	exp := (1 << attempt) * r.m.revokeRetryBase
 	randomDelta := 0.5 * float64(exp)
@@ -345,8 +382,8 @@ func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, log
 		pending:     sync.Map{},
 		pendingLock: &locking.SyncRWMutex{},
 		nonexpiring: sync.Map{},
-		leaseCount:  0,
 		tidyLock:    new(int32),
+		clock:       c.clock,
 
 		lockPerLease: sync.Map{},
 
@@ -372,6 +409,9 @@ func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, log
 	if exp.revokeRetryBase == 0 {
 		exp.revokeRetryBase = revokeRetryBase
 	}
+	if exp.clock == nil {
+		exp.clock = timeutil.DefaultClock{}
+	}
 	*exp.restoreMode = 1
 
 	if exp.logger == nil {
@@ -486,8 +526,8 @@ func (m *ExpirationManager) invalidate(key string) {
 			return
 		}
 
-		m.pendingLock.Lock()
-		defer m.pendingLock.Unlock()
+		unlock := m.lockPendingShard(leaseNS, leaseID)
+		defer unlock()
 		info, ok := m.pending.Load(leaseID)
 		switch {
 		case ok:
@@ -497,7 +537,7 @@ func (m *ExpirationManager) invalidate(key string) {
 				pending := info.(pendingInfo)
 				pending.timer.Stop()
 				m.pending.Delete(leaseID)
-				m.leaseCount--
+				atomic.AddInt64(&m.leaseCount, -1)
 
 				// Avoid nil pointer dereference. Without cachedLeaseInfo we do not have enough information to
 				// accurately update quota lease information.
@@ -523,9 +563,9 @@ func (m *ExpirationManager) invalidate(key string) {
 				if info, ok := m.irrevocable.Load(leaseID); ok {
 					ile := info.(*leaseEntry)
 					m.irrevocable.Delete(leaseID)
-					m.irrevocableLeaseCount--
+					atomic.AddInt64(&m.irrevocableLeaseCount, -1)
 
-					m.leaseCount--
+					atomic.AddInt64(&m.leaseCount, -1)
 					// Note that the leaseEntry should never be nil under normal operation.
 					if ile != nil {
 						leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: leaseID, Role: ile.LoginRole}
@@ -550,6 +590,11 @@ func (m *ExpirationManager) invalidate(key string) {
 // not required to use the API that invokes this. This is only intended to
 // clean up the corrupt storage due to bugs.
 func (m *ExpirationManager) Tidy(ctx context.Context) error {
+	return m.tidy(ctx, nil)
+}
+
+// tidy is Tidy, reporting its progress to the tidy operation if not nil.
+func (m *ExpirationManager) tidy(ctx context.Context, op *tidyOperation) error {
 	if m.inRestoreMode() {
 		return errors.New("cannot run tidy while restoring leases")
 	}
@@ -572,8 +617,16 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	// Create a cache to keep track of looked up tokens
 	tokenCache := make(map[string]bool)
 	var countLease, revokedCount, deletedCountInvalidToken, deletedCountEmptyToken int64
+	var stepErr error
 
 	tidyFunc := func(leaseID string) {
+		if stepErr != nil {
+			return
+		}
+		if stepErr = m.core.tidyStep(ctx, op); stepErr != nil {
+			return
+		}
+
 		countLease++
 		if countLease%500 == 0 {
 			logger.Info("tidying leases", "progress", countLease)
@@ -657,6 +710,9 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	if err := logical.ScanView(m.quitContext, leaseView, tidyFunc); err != nil {
 		return err
 	}
+	if stepErr != nil {
+		return stepErr
+	}
 
 	logger.Info("number of leases scanned", "count", countLease)
 	logger.Info("number of leases which had empty tokens", "count", deletedCountEmptyToken)
@@ -881,11 +937,15 @@ func (m *ExpirationManager) Stop() error {
 	m.expireFunc.Store(&newStrategy)
 	oldPending := &m.pending
 	m.pending, m.nonexpiring, m.irrevocable = sync.Map{}, sync.Map{}, sync.Map{}
-	m.leaseCount = 0
-	m.uniquePolicies = make(map[string][]string)
-	m.irrevocableLeaseCount = 0
+	m.pendingShards = sync.Map{}
+	atomic.StoreInt64(&m.leaseCount, 0)
+	atomic.StoreInt64(&m.irrevocableLeaseCount, 0)
 	m.pendingLock.Unlock()
 
+	m.uniquePoliciesLock.Lock()
+	m.uniquePolicies = make(map[string][]string)
+	m.uniquePoliciesLock.Unlock()
+
 	go oldPending.Range(func(key, value interface{}) bool {
 		info := value.(pendingInfo)
 		// We need to stop the timers to prevent memory leaks.
@@ -939,7 +999,7 @@ func (m *ExpirationManager) lazyRevokeInternal(ctx context.Context, leaseID stri
 		return nil
 	}
 
-	le.ExpireTime = time.Now()
+	le.ExpireTime = m.clock.Now()
 	if err := m.persistEntry(ctx, le); err != nil {
 		return err
 	}
@@ -954,7 +1014,7 @@ func (m *ExpirationManager) attemptIrrevocableLeasesRevoke() {
 		leaseID := k.(string)
 		le := v.(*leaseEntry)
 
-		if le.ExpireTime.Add(time.Hour).Before(time.Now()) {
+		if le.ExpireTime.Add(time.Hour).Before(m.clock.Now()) {
 			// if we get an error (or no namespace) note it, but continue attempting
 			// to revoke other leases
 			leaseNS, err := m.getNamespaceFromLeaseID(m.core.activeContext, leaseID)
@@ -1020,13 +1080,33 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 		}
 	}
 
+	if err := m.removeLease(ctx, le); err != nil {
+		return err
+	}
+
+	if m.logger.IsInfo() && !skipToken && m.logLeaseExpirations {
+		m.logger.Info("revoked lease", "lease_id", leaseID)
+	}
+	if m.logger.IsWarn() && !skipToken && le.isIncorrectlyNonExpiring() {
+		var accessor string
+		if le.Auth != nil {
+			accessor = le.Auth.Accessor
+		}
+		m.logger.Warn("finished revoking incorrectly non-expiring lease", "leaseID", le.LeaseID, "accessor", accessor)
+	}
+	return nil
+}
+
+// removeLease deletes the lease entry, its secondary index, and its pending
+// state, once revoked. The lease lock must be held.
+func (m *ExpirationManager) removeLease(ctx context.Context, le *leaseEntry) error {
 	// Delete the entry
 	if err := m.deleteEntry(ctx, le); err != nil {
 		return err
 	}
 
 	// Lease has been removed, also remove the in-memory lock.
-	m.deleteLockForLease(leaseID)
+	m.deleteLockForLease(le.LeaseID)
 
 	// Delete the secondary index, but only if it's a leased secret (not auth)
 	if le.Secret != nil {
@@ -1056,26 +1136,17 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 	}
 
 	// Clear the expiration handler
-	m.pendingLock.Lock()
-	m.removeFromPending(ctx, leaseID, true)
-	m.nonexpiring.Delete(leaseID)
+	unlock := m.lockPendingShard(le.namespace, le.LeaseID)
+	m.removeFromPending(ctx, le.LeaseID, true)
+	m.nonexpiring.Delete(le.LeaseID)
+	m.prioritized.Delete(le.LeaseID)
 
 	if _, ok := m.irrevocable.Load(le.LeaseID); ok {
-		m.irrevocable.Delete(leaseID)
-		m.irrevocableLeaseCount--
+		m.irrevocable.Delete(le.LeaseID)
+		atomic.AddInt64(&m.irrevocableLeaseCount, -1)
 	}
-	m.pendingLock.Unlock()
+	unlock()
 
-	if m.logger.IsInfo() && !skipToken && m.logLeaseExpirations {
-		m.logger.Info("revoked lease", "lease_id", leaseID)
-	}
-	if m.logger.IsWarn() && !skipToken && le.isIncorrectlyNonExpiring() {
-		var accessor string
-		if le.Auth != nil {
-			accessor = le.Auth.Accessor
-		}
-		m.logger.Warn("finished revoking incorrectly non-expiring lease", "leaseID", le.LeaseID, "accessor", accessor)
-	}
 	return nil
 }
 
@@ -1283,8 +1354,8 @@ func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment
 	// Update the lease entry
 	le.Data = resp.Data
 	le.Secret = resp.Secret
-	le.ExpireTime = resp.Secret.ExpirationTime()
-	le.LastRenewalTime = time.Now()
+	le.ExpireTime = m.expirationTime(&resp.Secret.LeaseOptions)
+	le.LastRenewalTime = m.clock.Now()
 
 	// If the token it's associated with is a batch token, constrain lease
 	// times
@@ -1428,8 +1499,8 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 
 	// Update the lease entry
 	le.Auth = resp.Auth
-	le.ExpireTime = resp.Auth.ExpirationTime()
-	le.LastRenewalTime = time.Now()
+	le.ExpireTime = m.expirationTime(&resp.Auth.LeaseOptions)
+	le.LastRenewalTime = m.clock.Now()
 
 	if err := m.persistEntry(ctx, le); err != nil {
 		return nil, err
@@ -1440,6 +1511,61 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 	return retResp, nil
 }
 
+// ReduceTokenTTL shortens the lease of a token so that it expires within
+// ttl, and makes it non-renewable so that it cannot be extended past that
+// point. Leases which already expire sooner are left unchanged.
+func (m *ExpirationManager) ReduceTokenTTL(ctx context.Context, te *logical.TokenEntry, ttl time.Duration) error {
+	defer metrics.MeasureSince([]string{"expire", "reduce-token-ttl"}, time.Now())
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, m.core)
+	if err != nil {
+		return err
+	}
+	if tokenNS == nil {
+		return namespace.ErrNoNamespace
+	}
+	ctx = namespace.ContextWithNamespace(ctx, tokenNS)
+
+	saltedID, err := m.tokenStore.SaltID(ctx, te.ID)
+	if err != nil {
+		return err
+	}
+
+	leaseID := path.Join(te.Path, saltedID)
+	if tokenNS.ID != namespace.RootNamespaceID {
+		leaseID = fmt.Sprintf("%s.%s", leaseID, tokenNS.ID)
+	}
+
+	leaseLock := m.lockForLeaseID(leaseID)
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	if le == nil || le.Auth == nil {
+		return nil
+	}
+
+	now := m.clock.Now()
+	expireTime := now.Add(ttl)
+	if !le.ExpireTime.IsZero() && le.ExpireTime.Before(expireTime) {
+		return nil
+	}
+
+	le.Auth.TTL = ttl
+	le.Auth.Renewable = false
+	le.ExpireTime = expireTime
+	le.LastRenewalTime = now
+
+	if err := m.persistEntry(ctx, le); err != nil {
+		return err
+	}
+	m.updatePending(le)
+	return nil
+}
+
 // Register is used to take a request and response with an associated
 // lease. The secret gets assigned a LeaseID and the management of
 // the lease is assumed by the expiration manager.
@@ -1486,8 +1612,8 @@ func (m *ExpirationManager) Register(ctx context.Context, req *logical.Request,
 		Data:            resp.Data,
 		Secret:          resp.Secret,
 		LoginRole:       loginRole,
-		IssueTime:       time.Now(),
-		ExpireTime:      resp.Secret.ExpirationTime(),
+		IssueTime:       m.clock.Now(),
+		ExpireTime:      m.expirationTime(&resp.Secret.LeaseOptions),
 		namespace:       ns,
 		Version:         1,
 	}
@@ -1570,7 +1696,7 @@ func (m *ExpirationManager) Register(ctx context.Context, req *logical.Request,
 	// ticking, so we'll end up always returning 299 instead of 300 or
 	// 26399 instead of 26400, say, even if it's just a few
 	// microseconds. This provides a nicer UX.
-	resp.Secret.TTL = le.ExpireTime.Sub(time.Now()).Round(time.Second)
+	resp.Secret.TTL = le.ExpireTime.Sub(m.clock.Now()).Round(time.Second)
 
 	// Done
 	return le.LeaseID, nil
@@ -1588,7 +1714,7 @@ func (m *ExpirationManager) RegisterAuth(ctx context.Context, te *logical.TokenE
 		return fmt.Errorf("failing explicitly on RegisterAuth")
 	}
 
-	authExpirationTime := auth.ExpirationTime()
+	authExpirationTime := m.expirationTime(&auth.LeaseOptions)
 
 	if te.TTL == 0 && authExpirationTime.IsZero() && (len(te.Policies) != 1 || te.Policies[0] != "root") {
 		return errors.New("refusing to register a lease for a non-root token with no TTL")
@@ -1632,7 +1758,7 @@ func (m *ExpirationManager) RegisterAuth(ctx context.Context, te *logical.TokenE
 		Auth:        auth,
 		Path:        te.Path,
 		LoginRole:   loginRole,
-		IssueTime:   time.Now(),
+		IssueTime:   m.clock.Now(),
 		ExpireTime:  authExpirationTime,
 		namespace:   tokenNS,
 		Version:     1,
@@ -1760,11 +1886,12 @@ func (m *ExpirationManager) inMemoryLeaseInfo(le *leaseEntry) *leaseEntry {
 	//   auth method -- derived from lease.Path
 	if le.Auth != nil {
 		// Ensure that list of policies is not copied more than
-		// once. This method is called with pendingLock held.
+		// once.
 
 		// We could use hashstructure here to generate a key, but that
 		// seems like it would be substantially slower?
 		key := strings.Join(le.Auth.Policies, "\n")
+		m.uniquePoliciesLock.Lock()
 		uniq, ok := m.uniquePolicies[key]
 		if ok {
 			ret.Auth.Policies = uniq
@@ -1772,6 +1899,7 @@ func (m *ExpirationManager) inMemoryLeaseInfo(le *leaseEntry) *leaseEntry {
 			m.uniquePolicies[key] = le.Auth.Policies
 			ret.Auth.Policies = le.Auth.Policies
 		}
+		m.uniquePoliciesLock.Unlock()
 		ret.Path = le.Path
 	}
 	if le.isIrrevocable() {
@@ -1792,9 +1920,9 @@ func (m *ExpirationManager) uniquePoliciesGc() {
 		// If the maximum lease is a month, and we blow away the unique
 		// policy cache every week, the pessimal case is 4x larger space
 		// utilization than keeping the cache indefinitely.
-		m.pendingLock.Lock()
+		m.uniquePoliciesLock.Lock()
 		m.uniquePolicies = make(map[string][]string)
-		m.pendingLock.Unlock()
+		m.uniquePoliciesLock.Unlock()
 	}
 }
 
@@ -1822,16 +1950,73 @@ func (m *ExpirationManager) deleteLockForLease(id string) {
 	m.lockPerLease.Delete(id)
 }
 
-// updatePending is used to update a pending invocation for a lease
-func (m *ExpirationManager) updatePending(le *leaseEntry) {
+// lockPendingShard locks the pending state of the lease for modification. It
+// holds pendingLock for reading and the lock of the shard of the mount of the
+// lease, and returns the function releasing both.
+func (m *ExpirationManager) lockPendingShard(ns *namespace.Namespace, leaseID string) func() {
+	m.pendingLock.RLock()
+	shard := m.pendingShard(ns, leaseID)
+	shard.Lock()
+	return func() {
+		shard.Unlock()
+		m.pendingLock.RUnlock()
+	}
+}
+
+// pendingShard returns the lock of the shard of the mount of the lease,
+// creating it if needed. Leases whose mount is gone share a shard.
+func (m *ExpirationManager) pendingShard(ns *namespace.Namespace, leaseID string) locking.RWMutex {
+	if ns == nil {
+		ns = namespace.RootNamespace
+	}
+	mount := m.router.MatchingMount(namespace.ContextWithNamespace(context.Background(), ns), leaseID)
+	if shard, ok := m.pendingShards.Load(mount); ok {
+		return shard.(locking.RWMutex)
+	}
+
+	var shard locking.RWMutex = &locking.SyncRWMutex{}
+	if m.DetectDeadlocks() {
+		shard = &locking.DeadlockRWMutex{}
+	}
+	actual, _ := m.pendingShards.LoadOrStore(mount, shard)
+	return actual.(locking.RWMutex)
+}
+
+// rescheduleTimers reschedules the expiration of the pending leases by the
+// clock, once it is adjusted.
+func (m *ExpirationManager) rescheduleTimers() {
 	m.pendingLock.Lock()
 	defer m.pendingLock.Unlock()
 
+	now := m.clock.Now()
+	m.pending.Range(func(_, value interface{}) bool {
+		info := value.(pendingInfo)
+		if info.cachedLeaseInfo != nil {
+			info.timer.Reset(info.cachedLeaseInfo.ExpireTime.Sub(now))
+		}
+		return true
+	})
+}
+
+// expirationTime returns when a lease with the given options expires, by
+// the clock.
+func (m *ExpirationManager) expirationTime(l *logical.LeaseOptions) time.Time {
+	if !l.LeaseEnabled() {
+		return time.Time{}
+	}
+	return m.clock.Now().Add(l.LeaseTotal())
+}
+
+// updatePending is used to update a pending invocation for a lease
+func (m *ExpirationManager) updatePending(le *leaseEntry) {
+	unlock := m.lockPendingShard(le.namespace, le.LeaseID)
+	defer unlock()
+
 	m.updatePendingInternal(le)
 }
 
 // updatePendingInternal is the locked version of updatePending; do not call
-// this without a write lock on m.pending
+// this without the pending state of the lease locked
 func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 	// Check for an existing timer
 	info, leaseInPending := m.pending.Load(le.LeaseID)
@@ -1851,7 +2036,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 		if leaseInPending {
 			info.(pendingInfo).timer.Stop()
 			m.pending.Delete(le.LeaseID)
-			m.leaseCount--
+			atomic.AddInt64(&m.leaseCount, -1)
 			// Avoid nil pointer dereference. Without cachedLeaseInfo we do not have enough information to
 			// accurately update quota lease information.
 			// Note that cachedLeaseInfo should never be nil under normal operation.
@@ -1866,7 +2051,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 		return
 	}
 
-	leaseTotal := le.ExpireTime.Sub(time.Now())
+	leaseTotal := le.ExpireTime.Sub(m.clock.Now())
 	leaseCreated := false
 
 	if le.isIrrevocable() {
@@ -1885,7 +2070,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 		// Increment count if the lease was not present in the irrevocable map
 		// prior to being added to it above
 		if !leaseInIrrevocable {
-			m.irrevocableLeaseCount++
+			atomic.AddInt64(&m.irrevocableLeaseCount, 1)
 		}
 	} else {
 		// Create entry if it does not exist or reset if it does
@@ -1912,7 +2097,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 		m.pending.Store(le.LeaseID, pending)
 	}
 	if leaseCreated {
-		m.leaseCount++
+		atomic.AddInt64(&m.leaseCount, 1)
 
 		// If we're in restore mode, Vault is still starting. While we may get leases created, it is likely
 		// 'catching up' on old creates. There will be a core.quotasHandleLeases call to register these leases in
@@ -2294,7 +2479,7 @@ func (m *ExpirationManager) CreateOrFetchRevocationLeaseByToken(ctx context.Cont
 		}
 
 		// Create a lease entry
-		now := time.Now()
+		now := m.clock.Now()
 		le = &leaseEntry{
 			LeaseID:     leaseID,
 			ClientToken: auth.ClientToken,
@@ -2383,11 +2568,8 @@ func (m *ExpirationManager) lookupLeasesByToken(ctx context.Context, te *logical
 
 // emitMetrics is invoked periodically to emit statistics
 func (m *ExpirationManager) emitMetrics() {
-	// All updates of these values are with the pendingLock held.
-	m.pendingLock.RLock()
-	allLeases := m.leaseCount
-	irrevocableLeases := m.irrevocableLeaseCount
-	m.pendingLock.RUnlock()
+	allLeases := atomic.LoadInt64(&m.leaseCount)
+	irrevocableLeases := atomic.LoadInt64(&m.irrevocableLeaseCount)
 
 	metrics.SetGauge([]string{"expire", "num_leases"}, float32(allLeases))
 
@@ -2408,7 +2590,7 @@ func (m *ExpirationManager) leaseAggregationMetrics(ctx context.Context, consts
 	leaseEpsilon := consts.LeaseMetricsEpsilon
 	nsLabel := consts.LeaseMetricsNameSpaceLabels
 
-	rollingWindow := time.Now().Add(time.Duration(consts.NumLeaseMetricsTimeBuckets) * leaseEpsilon)
+	rollingWindow := m.clock.Now().Add(time.Duration(consts.NumLeaseMetricsTimeBuckets) * leaseEpsilon)
 
 	err := m.walkLeases(func(entryID string, expireTime time.Time) bool {
 		select {
@@ -2528,7 +2710,7 @@ func (m *ExpirationManager) walkLeases(walkFn leaseWalkFunction) error {
 	return nil
 }
 
-// must be called with m.pendingLock held
+// must be called with the pending state of the lease locked
 // set decrementCounters true to decrement the lease count metric and quota
 func (m *ExpirationManager) removeFromPending(ctx context.Context, leaseID string, decrementCounters bool) {
 	if info, ok := m.pending.Load(leaseID); ok {
@@ -2536,7 +2718,7 @@ func (m *ExpirationManager) removeFromPending(ctx context.Context, leaseID strin
 		pending.timer.Stop()
 		m.pending.Delete(leaseID)
 		if decrementCounters {
-			m.leaseCount--
+			atomic.AddInt64(&m.leaseCount, -1)
 			// Avoid nil pointer dereference. Without cachedLeaseInfo we do not have enough information to
 			// accurately update quota lease information.
 			// Note that cachedLeaseInfo should never be nil under normal operation.
@@ -2554,7 +2736,7 @@ func (m *ExpirationManager) removeFromPending(ctx context.Context, leaseID strin
 // Marks a pending lease as irrevocable. Because the lease is being moved from
 // pending to irrevocable, no total lease count metrics/quotas updates are needed.
 // However, irrevocable lease count will need to be incremented
-// note: must be called with pending lock held
+// note: must be called with the pending state of the lease locked
 func (m *ExpirationManager) markLeaseIrrevocable(ctx context.Context, le *leaseEntry, err error) {
 	if le == nil {
 		m.logger.Warn("attempted to mark nil lease as irrevocable")
@@ -2580,7 +2762,7 @@ func (m *ExpirationManager) markLeaseIrrevocable(ctx context.Context, le *leaseE
 	m.persistEntry(ctx, le)
 
 	m.irrevocable.Store(le.LeaseID, m.inMemoryLeaseInfo(le))
-	m.irrevocableLeaseCount++
+	atomic.AddInt64(&m.irrevocableLeaseCount, 1)
 	m.removeFromPending(ctx, le.LeaseID, false)
 	m.nonexpiring.Delete(le.LeaseID)
 }
@@ -319,7 +319,7 @@ func TestExpiration_TotalLeaseCount(t *testing.T) {
 	}
 
 	exp.pendingLock.RLock()
-	count := exp.leaseCount
+	count := int(atomic.LoadInt64(&exp.leaseCount))
 	exp.pendingLock.RUnlock()
 
 	if count != expectedCount {
@@ -416,7 +416,7 @@ func TestExpiration_TotalLeaseCount_WithRoles(t *testing.T) {
 	}
 
 	exp.pendingLock.RLock()
-	count := exp.leaseCount
+	count := int(atomic.LoadInt64(&exp.leaseCount))
 	exp.pendingLock.RUnlock()
 
 	if count != expectedCount {
@@ -851,8 +851,8 @@ func TestExpiration_Restore(t *testing.T) {
 		}
 	}
 
-	if exp.leaseCount != len(paths) {
-		t.Fatalf("expected %v leases, got %v", len(paths), exp.leaseCount)
+	if leaseCount := atomic.LoadInt64(&exp.leaseCount); leaseCount != int64(len(paths)) {
+		t.Fatalf("expected %v leases, got %v", len(paths), leaseCount)
 	}
 
 	// Stop everything
@@ -861,8 +861,8 @@ func TestExpiration_Restore(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if exp.leaseCount != 0 {
-		t.Fatalf("expected %v leases, got %v", 0, exp.leaseCount)
+	if leaseCount := atomic.LoadInt64(&exp.leaseCount); leaseCount != 0 {
+		t.Fatalf("expected %v leases, got %v", 0, leaseCount)
 	}
 
 	// Restore
@@ -892,8 +892,8 @@ func TestExpiration_Restore(t *testing.T) {
 		}
 	}
 
-	if exp.leaseCount != 0 {
-		t.Fatalf("expected %v leases, got %v", 0, exp.leaseCount)
+	if leaseCount := atomic.LoadInt64(&exp.leaseCount); leaseCount != 0 {
+		t.Fatalf("expected %v leases, got %v", 0, leaseCount)
 	}
 }
 
@@ -1681,8 +1681,8 @@ func TestExpiration_RenewToken_period(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if exp.leaseCount != 1 {
-		t.Fatalf("expected %v leases, got %v", 1, exp.leaseCount)
+	if leaseCount := atomic.LoadInt64(&exp.leaseCount); leaseCount != 1 {
+		t.Fatalf("expected %v leases, got %v", 1, leaseCount)
 	}
 
 	// Renew the token
@@ -1704,8 +1704,8 @@ func TestExpiration_RenewToken_period(t *testing.T) {
 		t.Fatalf("expected TTL to be less than 1 minute, got: %s", out.Auth.TTL)
 	}
 
-	if exp.leaseCount != 1 {
-		t.Fatalf("expected %v leases, got %v", 1, exp.leaseCount)
+	if leaseCount := atomic.LoadInt64(&exp.leaseCount); leaseCount != 1 {
+		t.Fatalf("expected %v leases, got %v", 1, leaseCount)
 	}
 }
 
@@ -3047,7 +3047,7 @@ func TestExpiration_MarkIrrevocable(t *testing.T) {
 	}
 
 	exp.pendingLock.RLock()
-	irrevocableLeaseCount := exp.irrevocableLeaseCount
+	irrevocableLeaseCount := atomic.LoadInt64(&exp.irrevocableLeaseCount)
 	exp.pendingLock.RUnlock()
 
 	if irrevocableLeaseCount != 1 {
@@ -3164,7 +3164,7 @@ func TestExpiration_StopClearsIrrevocableCache(t *testing.T) {
 	}
 
 	exp.pendingLock.RLock()
-	irrevocableLeaseCount := exp.irrevocableLeaseCount
+	irrevocableLeaseCount := atomic.LoadInt64(&exp.irrevocableLeaseCount)
 	exp.pendingLock.RUnlock()
 
 	if irrevocableLeaseCount != 0 {
@@ -3321,7 +3321,7 @@ func TestExpiration_getIrrevocableLeaseCounts(t *testing.T) {
 	}
 
 	exp.pendingLock.RLock()
-	irrevocableLeaseCount := exp.irrevocableLeaseCount
+	irrevocableLeaseCount := int(atomic.LoadInt64(&exp.irrevocableLeaseCount))
 	exp.pendingLock.RUnlock()
 
 	if irrevocableLeaseCount != len(backends)*expectedPerMount {
@@ -3500,3 +3500,55 @@ func TestExpiration_listIrrevocableLeases_includeAll(t *testing.T) {
 		t.Errorf("bad lease count. expected %d, got %d", expectedNumLeases, numLeases)
 	}
 }
+
+// TestExpiration_pendingShards verifies that the pending state of the leases
+// of a mount being locked does not block updates of the leases of another
+// mount, while it does block those of the same mount.
+func TestExpiration_pendingShards(t *testing.T) {
+	exp := mockExpiration(t)
+
+	newLease := func(leaseID string) *leaseEntry {
+		return &leaseEntry{
+			LeaseID:    leaseID,
+			Path:       leaseID,
+			namespace:  namespace.RootNamespace,
+			IssueTime:  time.Now(),
+			ExpireTime: time.Now().Add(time.Hour),
+		}
+	}
+	update := func(le *leaseEntry) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			exp.updatePending(le)
+			close(done)
+		}()
+		return done
+	}
+
+	unlock := exp.lockPendingShard(namespace.RootNamespace, "secret/foo/1")
+
+	select {
+	case <-update(newLease("cubbyhole/foo/1")):
+	case <-time.After(10 * time.Second):
+		unlock()
+		t.Fatal("expected the lease of another mount to be updated")
+	}
+
+	sameMount := update(newLease("secret/foo/2"))
+	select {
+	case <-sameMount:
+		t.Fatal("expected the lease of the locked mount to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-sameMount:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the lease of the mount to be updated once unlocked")
+	}
+
+	if _, ok := exp.pending.Load("secret/foo/2"); !ok {
+		t.Fatal("expected the lease to be pending")
+	}
+}
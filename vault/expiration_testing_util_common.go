@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/rand"
 	"path"
+	"sync/atomic"
 	"time"
 
 	uuid "github.com/hashicorp/go-uuid"
@@ -128,7 +129,5 @@ func mountNoopBackends(c *Core, backends []*backend) (map[string]string, error)
 }
 
 func (c *Core) FetchLeaseCountToRevoke() int {
-	c.expiration.pendingLock.RLock()
-	defer c.expiration.pendingLock.RUnlock()
-	return c.expiration.leaseCount
+	return int(atomic.LoadInt64(&c.expiration.leaseCount))
 }
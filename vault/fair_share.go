@@ -0,0 +1,263 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const fairShareConfigPath = "fair-share"
+
+// FairShareConfig configures per-namespace fair-share scheduling of request
+// handling. When enabled, at most MaxConcurrentRequests requests are handled
+// concurrently. Once that limit is reached, queued requests are admitted in
+// the order which keeps each namespace's share of in-flight requests closest
+// to its configured weight, so that a surge of traffic in one namespace does
+// not starve the others.
+type FairShareConfig struct {
+	Enabled               bool           `json:"enabled"`
+	MaxConcurrentRequests int            `json:"max_concurrent_requests"`
+	DefaultShares         int            `json:"default_shares"`
+	NamespaceShares       map[string]int `json:"namespace_shares"`
+}
+
+func defaultFairShareConfig() *FairShareConfig {
+	return &FairShareConfig{
+		MaxConcurrentRequests: 1024,
+		DefaultShares:         1,
+		NamespaceShares:       map[string]int{},
+	}
+}
+
+// fairShareScheduler is a weighted fair concurrency limiter keyed by
+// namespace path.
+type fairShareScheduler struct {
+	l             sync.Mutex
+	capacity      int
+	defaultShares int
+	shares        map[string]int
+	inFlight      int
+	tenants       map[string]*fairShareTenant
+}
+
+type fairShareTenant struct {
+	inFlight int
+	waiters  *list.List
+}
+
+type fairShareWaiter struct {
+	ready    chan struct{}
+	admitted bool
+}
+
+func newFairShareScheduler(config *FairShareConfig) *fairShareScheduler {
+	shares := make(map[string]int, len(config.NamespaceShares))
+	for ns, weight := range config.NamespaceShares {
+		shares[normalizeFairShareNamespace(ns)] = weight
+	}
+	return &fairShareScheduler{
+		capacity:      config.MaxConcurrentRequests,
+		defaultShares: config.DefaultShares,
+		shares:        shares,
+		tenants:       make(map[string]*fairShareTenant),
+	}
+}
+
+// normalizeFairShareNamespace returns the namespace path in the form used by
+// namespace.Namespace, i.e. with a trailing slash, or empty for the root.
+func normalizeFairShareNamespace(ns string) string {
+	if ns == "" || ns == "/" || ns == "root" {
+		return ""
+	}
+	if ns[len(ns)-1] != '/' {
+		ns += "/"
+	}
+	return ns
+}
+
+func (s *fairShareScheduler) sharesFor(ns string) int {
+	if weight, ok := s.shares[ns]; ok && weight > 0 {
+		return weight
+	}
+	return s.defaultShares
+}
+
+func (s *fairShareScheduler) tenant(ns string) *fairShareTenant {
+	t, ok := s.tenants[ns]
+	if !ok {
+		t = &fairShareTenant{waiters: list.New()}
+		s.tenants[ns] = t
+	}
+	return t
+}
+
+func (s *fairShareScheduler) queued() bool {
+	for _, t := range s.tenants {
+		if t.waiters.Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Acquire blocks until a request for namespace ns may be handled, or ctx is
+// done. On success, the returned function must be called once the request
+// has been handled.
+func (s *fairShareScheduler) Acquire(ctx context.Context, ns string) (func(), error) {
+	release := func() { s.release(ns) }
+
+	s.l.Lock()
+	t := s.tenant(ns)
+	if s.inFlight < s.capacity && !s.queued() {
+		s.inFlight++
+		t.inFlight++
+		s.l.Unlock()
+		return release, nil
+	}
+
+	w := &fairShareWaiter{ready: make(chan struct{})}
+	elem := t.waiters.PushBack(w)
+	s.l.Unlock()
+
+	defer metrics.MeasureSince([]string{"core", "fair_share", "queue_time"}, time.Now())
+
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	s.l.Lock()
+	if w.admitted {
+		// Admitted concurrently with the context being canceled; give the
+		// slot back so another waiter can use it.
+		s.l.Unlock()
+		s.release(ns)
+	} else {
+		t.waiters.Remove(elem)
+		if t.inFlight == 0 && t.waiters.Len() == 0 {
+			delete(s.tenants, ns)
+		}
+		s.l.Unlock()
+	}
+	return nil, fmt.Errorf("%w: request canceled while queued for scheduling", ctx.Err())
+}
+
+func (s *fairShareScheduler) release(ns string) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.inFlight--
+	t := s.tenants[ns]
+	t.inFlight--
+	if t.inFlight == 0 && t.waiters.Len() == 0 {
+		delete(s.tenants, ns)
+	}
+
+	s.dispatch()
+}
+
+// dispatch admits queued requests while there is capacity, each time picking
+// the namespace with the lowest ratio of in-flight requests to shares. This
+// should only be called with the lock held.
+func (s *fairShareScheduler) dispatch() {
+	for s.inFlight < s.capacity {
+		var nextNS string
+		var next *fairShareTenant
+		for ns, t := range s.tenants {
+			if t.waiters.Len() == 0 {
+				continue
+			}
+			// Compare t.inFlight/shares(t) < next.inFlight/shares(next)
+			// without dividing.
+			if next == nil || t.inFlight*s.sharesFor(nextNS) < next.inFlight*s.sharesFor(ns) {
+				nextNS, next = ns, t
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		w := next.waiters.Remove(next.waiters.Front()).(*fairShareWaiter)
+		w.admitted = true
+		s.inFlight++
+		next.inFlight++
+		close(w.ready)
+	}
+}
+
+// FairShareConfig returns the current fair-share scheduling configuration.
+func (c *Core) FairShareConfig() *FairShareConfig {
+	if config := c.fairShareConfig.Load(); config != nil {
+		return config
+	}
+	return defaultFairShareConfig()
+}
+
+// setFairShareConfig applies config, replacing the active scheduler.
+// Requests admitted by a previous scheduler release their slot to it.
+func (c *Core) setFairShareConfig(config *FairShareConfig) {
+	c.fairShareConfig.Store(config)
+	if config == nil || !config.Enabled {
+		c.fairShareScheduler.Store(nil)
+		return
+	}
+	c.fairShareScheduler.Store(newFairShareScheduler(config))
+}
+
+func (c *Core) saveFairShareConfig(ctx context.Context, config *FairShareConfig) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	entry, err := logical.StorageEntryJSON(fairShareConfigPath, config)
+	if err != nil {
+		return fmt.Errorf("failed to create fair share config entry: %w", err)
+	}
+
+	if err := view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save fair share config: %w", err)
+	}
+
+	c.setFairShareConfig(config)
+	return nil
+}
+
+func (c *Core) deleteFairShareConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	if err := view.Delete(ctx, fairShareConfigPath); err != nil {
+		return fmt.Errorf("failed to delete fair share config: %w", err)
+	}
+
+	c.setFairShareConfig(nil)
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadFairShareConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	out, err := view.Get(ctx, fairShareConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read fair share config: %w", err)
+	}
+	if out == nil {
+		c.setFairShareConfig(nil)
+		return nil
+	}
+
+	config := defaultFairShareConfig()
+	if err := out.DecodeJSON(config); err != nil {
+		return err
+	}
+
+	c.setFairShareConfig(config)
+	return nil
+}
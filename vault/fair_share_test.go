@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (s *fairShareScheduler) queueLen(ns string) int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if t, ok := s.tenants[ns]; ok {
+		return t.waiters.Len()
+	}
+	return 0
+}
+
+// TestFairShareScheduler verifies that once the scheduler is at capacity,
+// queued requests are admitted according to their namespace's shares rather
+// than in arrival order.
+func TestFairShareScheduler(t *testing.T) {
+	s := newFairShareScheduler(&FairShareConfig{
+		Enabled:               true,
+		MaxConcurrentRequests: 2,
+		DefaultShares:         1,
+		NamespaceShares: map[string]int{
+			"tenant-a": 2,
+		},
+	})
+	ctx := context.Background()
+
+	// The noisy tenant takes all capacity
+	var noisy []func()
+	for i := 0; i < 2; i++ {
+		release, err := s.Acquire(ctx, "noisy/")
+		require.NoError(t, err)
+		noisy = append(noisy, release)
+	}
+
+	admitted := make(chan string, 10)
+	enqueue := func(ns string) {
+		go func() {
+			// Admitted requests are never released, so that each release
+			// below admits exactly one queued request.
+			if _, err := s.Acquire(ctx, ns); err != nil {
+				return
+			}
+			admitted <- ns
+		}()
+	}
+
+	// The noisy tenant queues more work before the other tenant arrives
+	for i := 0; i < 3; i++ {
+		enqueue("noisy/")
+	}
+	require.Eventually(t, func() bool { return s.queueLen("noisy/") == 3 }, time.Second, time.Millisecond)
+	enqueue("tenant-a/")
+	require.Eventually(t, func() bool { return s.queueLen("tenant-a/") == 1 }, time.Second, time.Millisecond)
+
+	// Freeing a slot admits the other tenant first, despite it arriving last
+	noisy[0]()
+	select {
+	case ns := <-admitted:
+		require.Equal(t, "tenant-a/", ns)
+	case <-time.After(time.Second):
+		t.Fatal("no request admitted")
+	}
+
+	// A canceled waiter leaves the queue without consuming capacity
+	cancelCtx, cancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(cancelCtx, "tenant-b/")
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return s.queueLen("tenant-b/") == 1 }, time.Second, time.Millisecond)
+	cancel()
+	require.True(t, errors.Is(<-errCh, context.Canceled))
+	require.Equal(t, 0, s.queueLen("tenant-b/"))
+
+	noisy[1]()
+	select {
+	case ns := <-admitted:
+		require.Equal(t, "noisy/", ns)
+	case <-time.After(time.Second):
+		t.Fatal("no request admitted")
+	}
+}
@@ -47,6 +47,16 @@ const (
 	leaderPrefixCleanDelay = 200 * time.Millisecond
 )
 
+// The steps of leadership transfers at which faults can be injected in
+// testonly builds.
+const (
+	haFaultStepDownStateLock = "step-down-state-lock"
+	haFaultPreSeal           = "pre-seal"
+	haFaultClearLeader       = "clear-leader"
+	haFaultHAUnlock          = "ha-unlock"
+	haFaultPostUnseal        = "post-unseal"
+)
+
 var (
 	addEnterpriseHaActors func(*Core, *run.Group) chan func()            = addEnterpriseHaActorsNoop
 	interruptPerfStandby  func(chan func(), chan struct{}) chan struct{} = interruptPerfStandbyNoop
@@ -660,7 +670,10 @@ func (c *Core) waitForLeadership(newLeaderCh chan func(), manualStepDownCh, stop
 		}
 
 		// Attempt the post-unseal process
-		err = c.postUnseal(activeCtx, activeCtxCancel, standardUnsealStrategy{})
+		err = c.injectHAFault(haFaultPostUnseal)
+		if err == nil {
+			err = c.postUnseal(activeCtx, activeCtxCancel, standardUnsealStrategy{})
+		}
 		if err == nil {
 			c.standby = false
 			c.activeTime = time.Now()
@@ -704,7 +717,9 @@ func (c *Core) waitForLeadership(newLeaderCh chan func(), manualStepDownCh, stop
 				}
 			}()
 
-			// Grab lock if we are not stopped
+			// Grab lock if we are not stopped. Only the delay of a fault
+			// injected there applies, as the lock cannot fail to be grabbed.
+			_ = c.injectHAFault(haFaultStepDownStateLock)
 			l := newLockGrabber(c.stateLock.Lock, c.stateLock.Unlock, stopCh)
 			go l.grab()
 			stopped := l.lockOrStop()
@@ -721,17 +736,23 @@ func (c *Core) waitForLeadership(newLeaderCh chan func(), manualStepDownCh, stop
 			c.metricSink.SetGaugeWithLabels([]string{"core", "active"}, 0, nil)
 
 			// Seal
-			if err := c.preSeal(); err != nil {
+			if err := c.injectHAFault(haFaultPreSeal); err != nil {
+				c.logger.Error("pre-seal teardown failed", "error", err)
+			} else if err := c.preSeal(); err != nil {
 				c.logger.Error("pre-seal teardown failed", "error", err)
 			}
 
 			// If we are not meant to keep the HA lock, clear it
 			if atomic.LoadUint32(c.keepHALockOnStepDown) == 0 {
-				if err := c.clearLeader(uuid); err != nil {
+				if err := c.injectHAFault(haFaultClearLeader); err != nil {
+					c.logger.Error("clearing leader advertisement failed", "error", err)
+				} else if err := c.clearLeader(uuid); err != nil {
 					c.logger.Error("clearing leader advertisement failed", "error", err)
 				}
 
-				if err := c.heldHALock.Unlock(); err != nil {
+				if err := c.injectHAFault(haFaultHAUnlock); err != nil {
+					c.logger.Error("unlocking HA lock failed", "error", err)
+				} else if err := c.heldHALock.Unlock(); err != nil {
 					c.logger.Error("unlocking HA lock failed", "error", err)
 				}
 				c.heldHALock = nil
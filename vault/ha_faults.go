@@ -0,0 +1,14 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !testonly
+
+package vault
+
+import "github.com/hashicorp/vault/sdk/framework"
+
+type haFaults struct{}
+
+func (c *Core) injectHAFault(point string) error { return nil }
+
+func (b *SystemBackend) haFaultsPath() *framework.Path { return nil }
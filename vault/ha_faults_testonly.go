@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const haFaultsHelpText = "Inject delays and failures in the leadership transfers of the core, for testing purposes"
+
+var haFaultPoints = []string{
+	haFaultStepDownStateLock,
+	haFaultPreSeal,
+	haFaultClearLeader,
+	haFaultHAUnlock,
+	haFaultPostUnseal,
+}
+
+// haFaults holds the faults injected at the steps of leadership transfers.
+type haFaults struct {
+	l      sync.Mutex
+	faults map[string]*haFault
+}
+
+// haFault delays a step of leadership transfers, and fails it if fail is set.
+// It is removed once it was injected remaining times, if remaining is set.
+type haFault struct {
+	delay     time.Duration
+	fail      bool
+	remaining int
+}
+
+// injectHAFault applies the fault injected at the given step, if any.
+func (c *Core) injectHAFault(point string) error {
+	c.haFaults.l.Lock()
+	fault, ok := c.haFaults.faults[point]
+	if !ok {
+		c.haFaults.l.Unlock()
+		return nil
+	}
+	delay, fail := fault.delay, fault.fail
+	if fault.remaining > 0 {
+		fault.remaining--
+		if fault.remaining == 0 {
+			delete(c.haFaults.faults, point)
+		}
+	}
+	c.haFaults.l.Unlock()
+
+	c.logger.Warn("injecting leadership transfer fault", "point", point, "delay", delay, "fail", fail)
+	time.Sleep(delay)
+	if fail {
+		return fmt.Errorf("injected fault at %s", point)
+	}
+	return nil
+}
+
+func (b *SystemBackend) haFaultsPath() *framework.Path {
+	return &framework.Path{
+		Pattern:         "internal/testing/ha-faults$",
+		HelpDescription: haFaultsHelpText,
+		HelpSynopsis:    haFaultsHelpText,
+		Fields: map[string]*framework.FieldSchema{
+			"point": {
+				Type:        framework.TypeString,
+				Description: fmt.Sprintf("Step of leadership transfers to inject the fault at, one of %s", strings.Join(haFaultPoints, ", ")),
+			},
+			"delay": {
+				Type:        framework.TypeString,
+				Description: "Duration to delay the step by, such as 500ms",
+			},
+			"fail": {
+				Type:        framework.TypeBool,
+				Description: "Fail the step, after the delay",
+			},
+			"count": {
+				Type:        framework.TypeInt,
+				Description: "Number of times to inject the fault, unlimited if 0",
+			},
+			"step_down": {
+				Type:        framework.TypeBool,
+				Description: "Step the active node down once the fault is injected",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleHAFaultsRead,
+				Summary:  "List the injected faults",
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleHAFaultsUpdate,
+				Summary:  "Inject a fault, and optionally step down",
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.handleHAFaultsDelete,
+				Summary:  "Remove the injected faults",
+			},
+		},
+	}
+}
+
+func (b *SystemBackend) handleHAFaultsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	faults := &b.Core.haFaults
+	faults.l.Lock()
+	defer faults.l.Unlock()
+
+	points := make([]string, 0, len(faults.faults))
+	for point := range faults.faults {
+		points = append(points, point)
+	}
+	sort.Strings(points)
+
+	data := make(map[string]interface{}, len(points))
+	for _, point := range points {
+		fault := faults.faults[point]
+		data[point] = map[string]interface{}{
+			"delay": fault.delay.String(),
+			"fail":  fault.fail,
+			"count": fault.remaining,
+		}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"faults": data,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleHAFaultsUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	point := d.Get("point").(string)
+	if point != "" {
+		valid := false
+		for _, p := range haFaultPoints {
+			valid = valid || p == point
+		}
+		if !valid {
+			return logical.ErrorResponse("unknown point %q, expected one of %s", point, strings.Join(haFaultPoints, ", ")), logical.ErrInvalidRequest
+		}
+
+		var delay time.Duration
+		if raw := d.Get("delay").(string); raw != "" {
+			var err error
+			delay, err = parseutil.ParseDurationSecond(raw)
+			if err != nil {
+				return logical.ErrorResponse("invalid delay: %s", err), logical.ErrInvalidRequest
+			}
+		}
+		count := d.Get("count").(int)
+		if count < 0 {
+			return logical.ErrorResponse("count cannot be negative"), logical.ErrInvalidRequest
+		}
+
+		faults := &b.Core.haFaults
+		faults.l.Lock()
+		if faults.faults == nil {
+			faults.faults = make(map[string]*haFault)
+		}
+		faults.faults[point] = &haFault{
+			delay:     delay,
+			fail:      d.Get("fail").(bool),
+			remaining: count,
+		}
+		faults.l.Unlock()
+	}
+
+	if d.Get("step_down").(bool) {
+		select {
+		case b.Core.manualStepDownCh <- struct{}{}:
+		default:
+			b.logger.Warn("manual step-down operation already queued")
+		}
+	} else if point == "" {
+		return logical.ErrorResponse("one of point and step_down is required"), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleHAFaultsDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	faults := &b.Core.haFaults
+	faults.l.Lock()
+	defer faults.l.Unlock()
+	faults.faults = nil
+	return nil, nil
+}
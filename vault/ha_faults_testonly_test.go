@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_haFaults verifies that a fault injected in leadership
+// transfers fails its step the given number of times, and that faults can be
+// listed and removed.
+func TestSystemBackend_haFaults(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/internal/testing/ha-faults")
+	req.ClientToken = root
+	req.Data["point"] = "unknown"
+	_, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+
+	req.Data["point"] = haFaultPreSeal
+	req.Data["delay"] = "10ms"
+	req.Data["fail"] = true
+	req.Data["count"] = 2
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/testing/ha-faults")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		haFaultPreSeal: map[string]interface{}{
+			"delay": "10ms",
+			"fail":  true,
+			"count": 2,
+		},
+	}, resp.Data["faults"])
+
+	require.Error(t, c.injectHAFault(haFaultPreSeal))
+	require.NoError(t, c.injectHAFault(haFaultClearLeader))
+	require.Error(t, c.injectHAFault(haFaultPreSeal))
+	require.NoError(t, c.injectHAFault(haFaultPreSeal))
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/internal/testing/ha-faults")
+	req.ClientToken = root
+	req.Data["point"] = haFaultPostUnseal
+	req.Data["fail"] = true
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/internal/testing/ha-faults")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.injectHAFault(haFaultPostUnseal))
+}
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/copystructure"
+)
+
+const (
+	// idempotencyKeyTTL is how long the result of a request made with an
+	// idempotency key is retained for replay.
+	idempotencyKeyTTL = 10 * time.Minute
+
+	// maxIdempotencyKeyLength bounds the size of client supplied keys.
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotentResult is the recorded outcome of a mutating request made with
+// an idempotency key. While the original request is in flight, done is open
+// and completed is false.
+type idempotentResult struct {
+	fingerprint string
+	done        chan struct{}
+	completed   bool
+	resp        *logical.Response
+	auth        *logical.Auth
+}
+
+type requestHandlerFunc func(context.Context, *logical.Request) (*logical.Response, *logical.Auth, error)
+
+// handleIdempotentRequest invokes handler for req. If req is a mutating
+// request carrying an idempotency key, the successful result is recorded and
+// replayed for any retry of the same request within idempotencyKeyTTL,
+// rather than performing the mutation again. This prevents retries after a
+// client-side timeout from, e.g., generating a second set of dynamic
+// credentials or a second token.
+//
+// Results are scoped to the namespace, client token, operation and path of
+// the request, so a key cannot be used to observe another client's result.
+// Reusing a key with a different request body is rejected. A retry is
+// authorized and audited like the original request before the result is
+// replayed, so a token revoked since cannot obtain it. Results are only kept
+// in the memory of the node which handled the request: they are neither
+// shared with standbys nor kept across a failover.
+func (c *Core) handleIdempotentRequest(ctx context.Context, req *logical.Request, login bool, handler requestHandlerFunc) (*logical.Response, *logical.Auth, error) {
+	idempotencyKey := http.Header(req.Headers).Get(consts.IdempotencyKeyHeaderName)
+	if idempotencyKey == "" || c.idempotencyCache == nil {
+		return handler(ctx, req)
+	}
+
+	switch req.Operation {
+	case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation, logical.DeleteOperation:
+	default:
+		// Other operations are already safe to retry
+		return handler(ctx, req)
+	}
+
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return logical.ErrorResponse("idempotency key must be at most %d characters", maxIdempotencyKeyLength), nil, logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cacheKey := idempotencyCacheKey(ns.ID, req.ClientToken, string(req.Operation), req.Path, idempotencyKey)
+	fingerprint, err := idempotencyFingerprint(req.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fingerprint request: %w", err)
+	}
+
+	pending := &idempotentResult{
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+	}
+	if err := c.idempotencyCache.Add(cacheKey, pending, idempotencyKeyTTL); err != nil {
+		raw, ok := c.idempotencyCache.Get(cacheKey)
+		if !ok {
+			// The entry expired between the two calls; treat this as a
+			// conflict and let the client retry rather than racing.
+			return nil, nil, logical.CodedError(http.StatusConflict, "a request with this idempotency key is already in progress")
+		}
+		result := raw.(*idempotentResult)
+		if result.fingerprint != fingerprint {
+			return nil, nil, logical.CodedError(http.StatusUnprocessableEntity, "idempotency key was already used for a request with different parameters")
+		}
+		if auth, err := c.authorizeIdempotentReplay(ctx, req, login); err != nil {
+			return nil, auth, err
+		}
+		return replayIdempotentResult(result)
+	}
+
+	resp, auth, err := handler(ctx, req)
+
+	// Only successful results are recorded; failures may be transient, so the
+	// key is released to allow the client to retry.
+	if err != nil || (resp != nil && resp.IsError()) {
+		c.idempotencyCache.Delete(cacheKey)
+		close(pending.done)
+		return resp, auth, err
+	}
+
+	result := &idempotentResult{
+		fingerprint: fingerprint,
+		done:        pending.done,
+		completed:   true,
+	}
+	if result.resp, err = copyResponse(resp); err == nil {
+		result.auth, err = copyAuth(auth)
+	}
+	if err != nil {
+		c.logger.Warn("failed to record idempotent request result", "path", req.Path, "error", err)
+		c.idempotencyCache.Delete(cacheKey)
+	} else {
+		c.idempotencyCache.Set(cacheKey, result, idempotencyKeyTTL)
+	}
+	close(pending.done)
+
+	return resp, auth, nil
+}
+
+// authorizeIdempotentReplay checks the token of a retry against the ACL of
+// the request path, as the original request was, and creates an audit trail
+// of the request. The replayed response is audited by the caller.
+func (c *Core) authorizeIdempotentReplay(ctx context.Context, req *logical.Request, login bool) (*logical.Auth, error) {
+	var nonHMACReqDataKeys []string
+	if entry := c.router.MatchingMountEntry(ctx, req.Path); entry != nil {
+		req.MountType = entry.Type
+		if rawVals, ok := entry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
+			nonHMACReqDataKeys = rawVals.([]string)
+		}
+	}
+
+	if login {
+		req.Unauthenticated = true
+	}
+	auth, _, ctErr := c.CheckToken(ctx, req, login)
+	if ctErr == logical.ErrPerfStandbyPleaseForward {
+		return nil, ctErr
+	}
+	if ctErr == nil && auth != nil {
+		req.DisplayName = auth.DisplayName
+	}
+
+	logInput := &logical.LogInput{
+		Auth:               auth,
+		Request:            req,
+		OuterErr:           ctErr,
+		NonHMACReqDataKeys: nonHMACReqDataKeys,
+	}
+	if err := c.auditBroker.LogRequest(ctx, logInput); err != nil {
+		c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+		return nil, ErrInternalError
+	}
+
+	switch {
+	case ctErr == nil:
+		return auth, nil
+	case ctErr == ErrInternalError, errwrap.Contains(ctErr, ErrInternalError.Error()):
+		return auth, ErrInternalError
+	case ctErr == logical.ErrPermissionDenied, errwrap.Contains(ctErr, logical.ErrPermissionDenied.Error()):
+		return auth, logical.ErrPermissionDenied
+	default:
+		return auth, multierror.Append(logical.ErrInvalidRequest, ctErr)
+	}
+}
+
+// replayIdempotentResult returns a copy of the recorded result, if the
+// request that produced it has completed.
+func replayIdempotentResult(result *idempotentResult) (*logical.Response, *logical.Auth, error) {
+	select {
+	case <-result.done:
+	default:
+		return nil, nil, logical.CodedError(http.StatusConflict, "a request with this idempotency key is already in progress")
+	}
+
+	// The original request failed and its entry has since been removed; the
+	// client should simply retry.
+	if !result.completed {
+		return nil, nil, logical.CodedError(http.StatusConflict, "a request with this idempotency key is already in progress")
+	}
+
+	resp, err := copyResponse(result.resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth, err := copyAuth(result.auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp == nil {
+		resp = &logical.Response{}
+	}
+	if resp.Headers == nil {
+		resp.Headers = make(map[string][]string, 1)
+	}
+	resp.Headers[consts.IdempotentReplayedHeaderName] = []string{"true"}
+
+	return resp, auth, nil
+}
+
+func idempotencyCacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func idempotencyFingerprint(data map[string]interface{}) (string, error) {
+	// encoding/json sorts map keys, so equal data yields an equal encoding
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func copyResponse(resp *logical.Response) (*logical.Response, error) {
+	if resp == nil {
+		return nil, nil
+	}
+	cp, err := copystructure.Copy(resp)
+	if err != nil {
+		return nil, err
+	}
+	return cp.(*logical.Response), nil
+}
+
+func copyAuth(auth *logical.Auth) (*logical.Auth, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	cp, err := copystructure.Copy(auth)
+	if err != nil {
+		return nil, err
+	}
+	return cp.(*logical.Auth), nil
+}
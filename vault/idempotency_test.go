@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_IdempotencyKey verifies that retrying a mutating request with the
+// same idempotency key replays the original result instead of performing the
+// mutation again.
+func TestCore_IdempotencyKey(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	createToken := func(key string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+		req.ClientToken = root
+		req.Data = data
+		if key != "" {
+			req.Headers = map[string][]string{
+				consts.IdempotencyKeyHeaderName: {key},
+			}
+		}
+		return c.HandleRequest(ctx, req)
+	}
+
+	first, err := createToken("key-1", map[string]interface{}{"ttl": "1h"})
+	require.NoError(t, err)
+	require.NotNil(t, first.Auth)
+	require.Empty(t, first.Headers[consts.IdempotentReplayedHeaderName])
+
+	// A retry returns the same token
+	retry, err := createToken("key-1", map[string]interface{}{"ttl": "1h"})
+	require.NoError(t, err)
+	require.NotNil(t, retry.Auth)
+	require.Equal(t, first.Auth.ClientToken, retry.Auth.ClientToken)
+	require.Equal(t, []string{"true"}, retry.Headers[consts.IdempotentReplayedHeaderName])
+
+	// A different key performs the mutation again
+	other, err := createToken("key-2", map[string]interface{}{"ttl": "1h"})
+	require.NoError(t, err)
+	require.NotEqual(t, first.Auth.ClientToken, other.Auth.ClientToken)
+
+	// Requests without a key are unaffected
+	unkeyed, err := createToken("", map[string]interface{}{"ttl": "1h"})
+	require.NoError(t, err)
+	require.NotEqual(t, first.Auth.ClientToken, unkeyed.Auth.ClientToken)
+
+	// Reusing a key with different parameters is rejected
+	_, err = createToken("key-1", map[string]interface{}{"ttl": "2h"})
+	require.Error(t, err)
+	codedErr, ok := err.(logical.HTTPCodedError)
+	require.True(t, ok, "expected a coded error, got %T", err)
+	require.Equal(t, http.StatusUnprocessableEntity, codedErr.Code())
+}
+
+// TestCore_IdempotencyKey_RevokedToken verifies that the result of a request
+// is not replayed to a retry made with a token revoked since.
+func TestCore_IdempotencyKey_RevokedToken(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{"ttl": "1h"}
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	token := resp.Auth.ClientToken
+
+	createToken := func() (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+		req.ClientToken = token
+		req.Data = map[string]interface{}{"ttl": "1h"}
+		req.Headers = map[string][]string{
+			consts.IdempotencyKeyHeaderName: {"key-1"},
+		}
+		return c.HandleRequest(ctx, req)
+	}
+
+	first, err := createToken()
+	require.NoError(t, err)
+	require.NotNil(t, first.Auth)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/revoke-orphan")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{"token": token}
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	retry, err := createToken()
+	require.Error(t, err)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	if retry != nil && retry.Auth != nil {
+		t.Fatalf("expected the result not to be replayed, got %#v", retry.Auth)
+	}
+}
@@ -66,6 +66,9 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 		entityCreator: core,
 		mountLister:   core,
 		mfaBackend:    core.loginMFABackend,
+
+		accessAdvisorReporter: core,
+		entityRevoker:         core,
 	}
 
 	// Create a memdb instance, which by default, operates on lower cased
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	memdb "github.com/hashicorp/go-memdb"
@@ -46,7 +47,11 @@ vault <command> <path> metadata=key1=value1 metadata=key2=value2
 		},
 		"disabled": {
 			Type:        framework.TypeBool,
-			Description: "If set true, tokens tied to this identity will not be able to be used (but will not be revoked).",
+			Description: "If set true, tokens tied to this identity will not be able to be used (but will not be revoked, unless 'revoke' is set).",
+		},
+		"revoke": {
+			Type:        framework.TypeBool,
+			Description: "If set true along with 'disabled', synchronously revokes the tokens of the entity, and revokes the leases of the secrets issued to them ahead of other revocations.",
 		},
 	}
 }
@@ -144,6 +149,61 @@ func entityPaths(i *IdentityStore) []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-id"][0]),
 			HelpDescription: strings.TrimSpace(entityHelp["entity-id"][1]),
 		},
+		{
+			Pattern: "entity/" + framework.GenericNameRegex("id") + "/access-advisor$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "entity",
+				OperationVerb:   "read",
+				OperationSuffix: "access-advisor",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the entity.",
+				},
+				"window": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultAccessAdvisorWindow.Seconds()),
+					Description: "Period a capability must not have been used for to be reported as unused.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathEntityAccessAdvisorRead(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-access-advisor"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-access-advisor"][1]),
+		},
+		{
+			Pattern: "entity/id/" + framework.GenericNameRegex("id") + "/revocation$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "entity",
+				OperationVerb:   "read",
+				OperationSuffix: "revocation-status",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the entity.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathEntityRevocationRead(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-revocation"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-revocation"][1]),
+		},
 		{
 			Pattern: "entity/batch-delete",
 
@@ -310,7 +370,12 @@ func (i *IdentityStore) pathEntityMergeID() framework.OperationFunc {
 func (i *IdentityStore) handleEntityUpdateCommon() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		i.lock.Lock()
-		defer i.lock.Unlock()
+		locked := true
+		defer func() {
+			if locked {
+				i.lock.Unlock()
+			}
+		}()
 
 		entity := new(identity.Entity)
 		var err error
@@ -365,6 +430,10 @@ func (i *IdentityStore) handleEntityUpdateCommon() framework.OperationFunc {
 		if ok {
 			entity.Disabled = disabledRaw.(bool)
 		}
+		revoke := d.Get("revoke").(bool)
+		if revoke && (!entity.Disabled || entity.ID == "") {
+			return logical.ErrorResponse("revoke can only be set when disabling an existing entity"), nil
+		}
 
 		// Get entity metadata
 		metadata, ok, err := d.GetOkErr("metadata")
@@ -389,6 +458,21 @@ func (i *IdentityStore) handleEntityUpdateCommon() framework.OperationFunc {
 			return nil, err
 		}
 
+		// The tokens are revoked without holding the lock, as it may take a
+		// while and does not change the entity
+		if revoke {
+			i.lock.Unlock()
+			locked = false
+
+			status, err := i.entityRevoker.RevokeEntityCredentials(ctx, entity)
+			if err != nil {
+				return nil, err
+			}
+			return &logical.Response{
+				Data: entityRevocationStatusResponse(status),
+			}, nil
+		}
+
 		// If this operation was an update to an existing entity, return 204
 		if !newEntity {
 			return nil, nil
@@ -455,6 +539,118 @@ func (i *IdentityStore) pathEntityIDRead() framework.OperationFunc {
 	}
 }
 
+// pathEntityAccessAdvisorRead reports the capabilities an entity holds and
+// when each was last used, flagging those unused within the window.
+func (i *IdentityStore) pathEntityAccessAdvisorRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entityID := d.Get("id").(string)
+		if entityID == "" {
+			return logical.ErrorResponse("missing entity id"), nil
+		}
+		window := time.Duration(d.Get("window").(int)) * time.Second
+		if window <= 0 {
+			return logical.ErrorResponse("window must be positive"), nil
+		}
+
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entity, err := i.MemDBEntityByID(entityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil || entity.NamespaceID != ns.ID {
+			return nil, nil
+		}
+
+		report, err := i.accessAdvisorReporter.AccessAdvisorReport(ctx, entity, window)
+		if err != nil {
+			return nil, err
+		}
+
+		capabilities := make([]map[string]interface{}, 0, len(report.Capabilities))
+		unused := 0
+		for _, c := range report.Capabilities {
+			capability := map[string]interface{}{
+				"policy":         c.Policy,
+				"namespace_path": c.NamespacePath,
+				"rule":           c.Rule,
+				"capability":     c.Capability,
+				"last_used":      nil,
+				"unused":         c.Unused,
+			}
+			if c.LastUsed != nil {
+				capability["last_used"] = c.LastUsed.Format(time.RFC3339)
+			}
+			if c.Unused {
+				unused++
+			}
+			capabilities = append(capabilities, capability)
+		}
+
+		var trackingSince interface{}
+		if report.TrackingSince != nil {
+			trackingSince = report.TrackingSince.Format(time.RFC3339)
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"entity_id":      entity.ID,
+				"tracking_since": trackingSince,
+				"window":         int64(window.Seconds()),
+				"capabilities":   capabilities,
+				"unused_count":   unused,
+			},
+		}, nil
+	}
+}
+
+// pathEntityRevocationRead returns the progress of the last revocation of the
+// credentials of the entity
+func (i *IdentityStore) pathEntityRevocationRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entityID := d.Get("id").(string)
+		if entityID == "" {
+			return logical.ErrorResponse("missing entity id"), nil
+		}
+
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entity, err := i.MemDBEntityByID(entityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil || entity.NamespaceID != ns.ID {
+			return nil, nil
+		}
+
+		status := i.entityRevoker.EntityRevocationStatus(entity.ID)
+		if status == nil {
+			return nil, nil
+		}
+		return &logical.Response{
+			Data: entityRevocationStatusResponse(status),
+		}, nil
+	}
+}
+
+func entityRevocationStatusResponse(status *EntityRevocationStatus) map[string]interface{} {
+	return map[string]interface{}{
+		"entity_id":          status.EntityID,
+		"start_time":         status.StartTime.Format(time.RFC3339),
+		"tokens_revoked":     status.TokensRevoked,
+		"token_errors":       status.TokenErrors,
+		"leases_total":       status.LeasesTotal,
+		"leases_pending":     status.LeasesPending,
+		"leases_revoked":     status.LeasesRevoked,
+		"leases_irrevocable": status.LeasesIrrevocable,
+		"complete":           status.Complete,
+	}
+}
+
 func (i *IdentityStore) handleEntityReadCommon(ctx context.Context, entity *identity.Entity) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -1167,6 +1363,22 @@ var entityHelp = map[string][2]string{
 		"Update, read or delete an entity using entity ID",
 		"",
 	},
+	"entity-access-advisor": {
+		"Report the capabilities an entity holds but does not use",
+		`Lists the capabilities granted to the entity, by its identity policies and by
+the policies of the tokens it has used, with the time each was last used. Those
+not used within 'window' are flagged unused. Usage is only tracked while this
+feature exists on the active node, from 'tracking_since' onward.`,
+	},
+	"entity-revocation": {
+		"Report the progress of the revocation of the credentials of an entity",
+		`Reports the progress of the last revocation of the tokens and leases of the
+entity, requested by disabling it with 'revoke' set. The tokens are revoked
+when the entity is disabled; the leases of the secrets issued to them are
+revoked ahead of other lease revocations, and are counted here until they are
+revoked, or flagged irrevocable. The progress is tracked in memory by the
+active node, so it is not reported after a restart or a leadership change.`,
+	},
 	"entity-name": {
 		"Update, read or delete an entity using entity name",
 		"",
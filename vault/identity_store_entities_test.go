@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-uuid"
 	credGithub "github.com/hashicorp/vault/builtin/credential/github"
@@ -1311,3 +1312,81 @@ func TestIdentityStore_MergeEntitiesByID_DuplicateFromEntityIDs(t *testing.T) {
 		t.Fatalf("invalid number of entity policies; expected: 2, actualL: %d", len(entity1Lookup.Policies))
 	}
 }
+
+// TestIdentityStore_EntityDisableRevoke verifies that disabling an entity
+// with revoke set revokes its tokens, and that the progress of the revocation
+// is reported.
+func TestIdentityStore_EntityDisableRevoke(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name": "compromised",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	entityID := resp.Data["id"].(string)
+
+	te := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: []string{"default"},
+		TTL:      time.Hour,
+		EntityID: entityID,
+	}
+	testMakeTokenDirectly(t, c.tokenStore, te)
+	other := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: []string{"default"},
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, c.tokenStore, other)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityID,
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"revoke": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected revoke to require disabling the entity, got resp: %#v, err: %v", resp, err)
+	}
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityID,
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"disabled": true,
+			"revoke":   true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["tokens_revoked"] != 1 {
+		t.Fatalf("expected 1 token to be revoked, got %#v", resp.Data)
+	}
+
+	if out, err := c.tokenStore.Lookup(ctx, te.ID); err != nil || out != nil {
+		t.Fatalf("expected the token of the entity to be revoked, got %#v, err: %v", out, err)
+	}
+	if out, err := c.tokenStore.Lookup(ctx, other.ID); err != nil || out == nil {
+		t.Fatalf("expected the other token to remain, got %#v, err: %v", out, err)
+	}
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityID + "/revocation",
+		Operation: logical.ReadOperation,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["complete"] != true || resp.Data["tokens_revoked"] != 1 {
+		t.Fatalf("unexpected revocation status: %#v", resp.Data)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"regexp"
 	"sync"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -104,6 +105,9 @@ type IdentityStore struct {
 	entityCreator EntityCreator
 	mountLister   MountLister
 	mfaBackend    *LoginMFABackend
+
+	accessAdvisorReporter AccessAdvisorReporter
+	entityRevoker         EntityRevoker
 }
 
 type groupDiff struct {
@@ -161,3 +165,16 @@ type MountLister interface {
 }
 
 var _ MountLister = &Core{}
+
+type AccessAdvisorReporter interface {
+	AccessAdvisorReport(ctx context.Context, entity *identity.Entity, window time.Duration) (*AccessAdvisorReport, error)
+}
+
+var _ AccessAdvisorReporter = &Core{}
+
+type EntityRevoker interface {
+	RevokeEntityCredentials(ctx context.Context, entity *identity.Entity) (*EntityRevocationStatus, error)
+	EntityRevocationStatus(entityID string) *EntityRevocationStatus
+}
+
+var _ EntityRevoker = &Core{}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Phases of an in-flight request, as reported by sys/in-flight-req.
+const (
+	inFlightPhaseRouting = "routing"
+	inFlightPhaseBackend = "backend"
+	inFlightPhaseStorage = "storage"
+)
+
+// inFlightPhase tracks the phase an in-flight request is in. It is shared by
+// the in-flight request data and the context of the request, so that the
+// router and storage views can update it without looking the request up.
+type inFlightPhase struct {
+	phase atomic.Value
+}
+
+func newInFlightPhase() *inFlightPhase {
+	p := &inFlightPhase{}
+	p.phase.Store(inFlightPhaseRouting)
+	return p
+}
+
+func (p *inFlightPhase) get() string {
+	if p == nil {
+		return ""
+	}
+	return p.phase.Load().(string)
+}
+
+type ctxKeyInFlightPhase struct{}
+
+// contextWithInFlightPhase attaches the phase tracker of the in-flight request
+// to the context, if the request is tracked.
+func (c *Core) contextWithInFlightPhase(ctx context.Context, reqID string) context.Context {
+	v, ok := c.inFlightReqData.InFlightReqMap.Load(reqID)
+	if !ok {
+		return ctx
+	}
+	p := v.(InFlightReqData).phase
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKeyInFlightPhase{}, p)
+}
+
+func noopRestoreInFlightPhase() {}
+
+// enterInFlightPhase sets the phase of the in-flight request of the context,
+// if any, and returns a function restoring the previous phase.
+func enterInFlightPhase(ctx context.Context, phase string) func() {
+	p, ok := ctx.Value(ctxKeyInFlightPhase{}).(*inFlightPhase)
+	if !ok {
+		return noopRestoreInFlightPhase
+	}
+	previous := p.phase.Swap(phase)
+	return func() {
+		p.phase.Store(previous)
+	}
+}
+
+// CancelInFlightRequest cancels the context of the in-flight request with the
+// given ID, so that a request stuck in a backend or in storage returns. It
+// returns false if no such request is in flight on this node.
+func (c *Core) CancelInFlightRequest(reqID string) bool {
+	v, ok := c.inFlightReqData.InFlightReqMap.Load(reqID)
+	if !ok {
+		return false
+	}
+	data := v.(InFlightReqData)
+	if data.CancelFunc == nil {
+		return false
+	}
+
+	c.logger.Warn("canceling in-flight request", "request_id", reqID, "request_path", data.ReqPath,
+		"client_id", data.ClientID, "phase", data.phase.get(), "start_time", data.StartTime)
+	data.CancelFunc()
+	return true
+}
@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInFlightRequests_PhaseAndCancel verifies that the phase of an in-flight
+// request follows its context through the backend and storage, and that the
+// request can be canceled through sys/in-flight-req/cancel.
+func TestInFlightRequests_PhaseAndCancel(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.StoreInFlightReqData("stuck", InFlightReqData{
+		StartTime:  time.Now().Add(-time.Minute),
+		ReqPath:    "/v1/secret/foo",
+		CancelFunc: cancel,
+	})
+	phase := func() string {
+		return c.LoadInFlightReqData()["stuck"].Phase
+	}
+	require.Equal(t, inFlightPhaseRouting, phase())
+	require.GreaterOrEqual(t, c.LoadInFlightReqData()["stuck"].ElapsedMs, time.Minute.Milliseconds())
+
+	ctx := c.contextWithInFlightPhase(reqCtx, "stuck")
+	restoreBackend := enterInFlightPhase(ctx, inFlightPhaseBackend)
+	require.Equal(t, inFlightPhaseBackend, phase())
+	restoreStorage := enterInFlightPhase(ctx, inFlightPhaseStorage)
+	require.Equal(t, inFlightPhaseStorage, phase())
+	restoreStorage()
+	require.Equal(t, inFlightPhaseBackend, phase())
+	restoreBackend()
+	require.Equal(t, inFlightPhaseRouting, phase())
+
+	// Requests that are not tracked are not affected
+	enterInFlightPhase(context.Background(), inFlightPhaseStorage)()
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/in-flight-req/cancel")
+	req.ClientToken = root
+	req.Data["request_id"] = "unknown"
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.Data[logical.HTTPStatusCode])
+
+	req.Data["request_id"] = "stuck"
+	resp, err = c.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.ErrorIs(t, reqCtx.Err(), context.Canceled)
+}
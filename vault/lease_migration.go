@@ -0,0 +1,327 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	leaseBundleVersion = 1
+
+	// leaseBundleAAD binds the ciphertext to its purpose, so that a bundle
+	// cannot be passed off as another kind of encrypted blob.
+	leaseBundleAAD = "vault-lease-bundle"
+
+	leaseImportTokenPath = "sys/leases/import"
+)
+
+// leaseMigrationExcludedTypes are the types of mounts whose leases and
+// state cannot be moved to another cluster.
+var leaseMigrationExcludedTypes = []string{
+	mountTypeSystem, mountTypeNSSystem, mountTypeCubbyhole, mountTypeNSCubbyhole, mountTypeIdentity,
+}
+
+// leaseBundle holds the leases of a secrets engine mount, along with the
+// storage of the mount, so that the mount can be moved to another cluster
+// with its dynamic credentials remaining revocable there.
+type leaseBundle struct {
+	Version    int                     `json:"version"`
+	MountPath  string                  `json:"mount_path"`
+	MountType  string                  `json:"mount_type"`
+	ExportTime time.Time               `json:"export_time"`
+	Storage    []*logical.StorageEntry `json:"storage"`
+	Leases     []*leaseEntry           `json:"leases"`
+}
+
+// encryptLeaseBundle encodes and encrypts the bundle with AES-GCM, using the
+// base64-encoded 256-bit key.
+func encryptLeaseBundle(bundle *leaseBundle, key string) (string, error) {
+	aead, err := leaseBundleAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(bundle)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	ciphertext := aead.Seal(nonce, nonce, plaintext, []byte(leaseBundleAAD))
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptLeaseBundle reverses encryptLeaseBundle.
+func decryptLeaseBundle(encoded, key string) (*leaseBundle, error) {
+	aead, err := leaseBundleAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle encoding: %w", err)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("invalid bundle")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(leaseBundleAAD))
+	if err != nil {
+		return nil, errors.New("failed to decrypt the bundle, the key may be wrong")
+	}
+
+	var bundle leaseBundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if bundle.Version != leaseBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	return &bundle, nil
+}
+
+func leaseBundleAEAD(key string) (cipher.AEAD, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(rawKey) != 32 {
+		return nil, errors.New("key must be a base64-encoded 256-bit key")
+	}
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// migratableMountEntry returns the entry of the secrets engine mounted at the
+// path in the namespace of the context, if its leases can be migrated.
+func (c *Core) migratableMountEntry(ctx context.Context, path string) (*MountEntry, error) {
+	path = sanitizePath(path)
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		return nil, errors.New("the leases of auth methods cannot be migrated")
+	}
+	entry := c.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.Path != path {
+		return nil, fmt.Errorf("no secrets engine mounted at %q", path)
+	}
+	if strutil.StrListContains(leaseMigrationExcludedTypes, entry.Type) {
+		return nil, fmt.Errorf("the leases of %q mounts cannot be migrated", entry.Type)
+	}
+	return entry, nil
+}
+
+// exportMountLeases bundles the leases and the storage of the secrets engine
+// mounted at the path. If detach is set, the exported leases are then removed
+// from this cluster without being revoked, so that they are only revoked by
+// the cluster the bundle is imported in.
+func (c *Core) exportMountLeases(ctx context.Context, path string, detach bool) (*leaseBundle, error) {
+	m := c.expiration
+	if m.inRestoreMode() {
+		return nil, ErrInRestoreMode
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.migratableMountEntry(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &leaseBundle{
+		Version:    leaseBundleVersion,
+		MountPath:  entry.Path,
+		MountType:  entry.Type,
+		ExportTime: time.Now().UTC(),
+	}
+
+	storage := c.router.MatchingStorageByAPIPath(ctx, entry.Path)
+	if storage == nil {
+		return nil, fmt.Errorf("no storage for %q", entry.Path)
+	}
+	keys, err := logical.CollectKeys(ctx, storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the storage of the mount: %w", err)
+	}
+	for _, key := range keys {
+		se, err := storage.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", key, err)
+		}
+		if se != nil {
+			bundle.Storage = append(bundle.Storage, se)
+		}
+	}
+
+	leaseIDs, err := logical.CollectKeys(ctx, m.leaseView(ns).SubView(entry.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for leases: %w", err)
+	}
+	for _, suffix := range leaseIDs {
+		leaseID := entry.Path + suffix
+		if _, nsID := namespace.SplitIDFromString(leaseID); nsID != "" && nsID != ns.ID {
+			continue
+		}
+		le, err := m.loadEntry(ctx, leaseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load lease %q: %w", leaseID, err)
+		}
+		if le == nil || le.Secret == nil {
+			continue
+		}
+		bundle.Leases = append(bundle.Leases, le)
+	}
+
+	if detach {
+		for _, le := range bundle.Leases {
+			if err := m.detachLease(ctx, le.LeaseID); err != nil {
+				return nil, fmt.Errorf("failed to detach lease %q: %w", le.LeaseID, err)
+			}
+		}
+		c.logger.Info("detached exported leases", "path", entry.Path, "count", len(bundle.Leases))
+	}
+
+	return bundle, nil
+}
+
+// importMountLeases restores the bundle into the secrets engine mounted at
+// the path, which must be of the same type as the exported one and have no
+// data yet. The imported leases are owned by a new orphan token, whose
+// accessor is returned, so that they can be revoked together.
+func (c *Core) importMountLeases(ctx context.Context, path string, bundle *leaseBundle) (string, error) {
+	m := c.expiration
+	if m.inRestoreMode() {
+		return "", ErrInRestoreMode
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	entry, err := c.migratableMountEntry(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if entry.Type != bundle.MountType {
+		return "", fmt.Errorf("the bundle is of a %q mount, not %q", bundle.MountType, entry.Type)
+	}
+
+	storage := c.router.MatchingStorageByAPIPath(ctx, entry.Path)
+	if storage == nil {
+		return "", fmt.Errorf("no storage for %q", entry.Path)
+	}
+	existing, err := storage.List(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return "", fmt.Errorf("the mount at %q already has data", entry.Path)
+	}
+	for _, se := range bundle.Storage {
+		if err := storage.Put(ctx, se); err != nil {
+			return "", fmt.Errorf("failed to write %q: %w", se.Key, err)
+		}
+	}
+	// Have the backend load the imported state
+	if err := c.reloadBackendCommon(ctx, entry, false); err != nil {
+		return "", fmt.Errorf("failed to reload the mount: %w", err)
+	}
+
+	if len(bundle.Leases) == 0 {
+		return "", nil
+	}
+
+	// The token has to outlive the leases, which can be renewed up to the max
+	// TTL of the mount
+	ttl := entry.Config.MaxLeaseTTL
+	if ttl == 0 {
+		ttl = c.maxLeaseTTL
+	}
+	auth := &logical.Auth{
+		DisplayName:   "lease-import",
+		TokenPolicies: []string{"default"},
+		Policies:      []string{"default"},
+		TokenType:     logical.TokenTypeService,
+		Metadata: map[string]string{
+			"source_mount_path": bundle.MountPath,
+			"mount_path":        entry.Path,
+		},
+		LeaseOptions: logical.LeaseOptions{
+			TTL: ttl,
+		},
+	}
+	if err := c.RegisterAuth(ctx, ttl, leaseImportTokenPath, auth, ""); err != nil {
+		return "", fmt.Errorf("failed to create the token owning the imported leases: %w", err)
+	}
+
+	for _, le := range bundle.Leases {
+		leaseID, _ := namespace.SplitIDFromString(le.LeaseID)
+		le.LeaseID = entry.Path + strings.TrimPrefix(leaseID, bundle.MountPath)
+		if ns.ID != namespace.RootNamespaceID {
+			le.LeaseID = fmt.Sprintf("%s.%s", le.LeaseID, ns.ID)
+		}
+		le.Path = entry.Path + strings.TrimPrefix(le.Path, bundle.MountPath)
+		le.ClientToken = auth.ClientToken
+		le.ClientTokenType = logical.TokenTypeService
+		le.Version = 1
+		le.namespace = ns
+
+		if err := m.importLease(ctx, le); err != nil {
+			return auth.Accessor, fmt.Errorf("failed to import lease %q: %w", le.LeaseID, err)
+		}
+	}
+	c.logger.Info("imported leases", "path", entry.Path, "source_path", bundle.MountPath, "count", len(bundle.Leases))
+
+	return auth.Accessor, nil
+}
+
+// importLease persists and indexes an imported lease, and sets up its
+// revocation timer.
+func (m *ExpirationManager) importLease(ctx context.Context, le *leaseEntry) error {
+	leaseLock := m.lockForLeaseID(le.LeaseID)
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+
+	if err := m.persistEntry(ctx, le); err != nil {
+		return err
+	}
+	if err := m.createIndexByToken(ctx, le, le.ClientToken); err != nil {
+		return err
+	}
+	m.updatePending(le)
+	return nil
+}
+
+// detachLease removes the lease from this cluster without revoking it with
+// its backend.
+func (m *ExpirationManager) detachLease(ctx context.Context, leaseID string) error {
+	leaseLock := m.lockForLeaseID(leaseID)
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	if le == nil {
+		return nil
+	}
+	return m.removeLease(ctx, le)
+}
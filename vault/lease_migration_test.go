@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLeaseMigration verifies that the leases and the storage of a mount can
+// be exported, detaching them without revocation, and imported in another
+// mount, with the lease IDs rewritten and owned by a new token.
+func TestLeaseMigration(t *testing.T) {
+	noop := &NoopBackend{}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	for _, path := range []string{"source", "target"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/"+path)
+		req.Data["type"] = "noop"
+		req.ClientToken = root
+		_, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+	}
+
+	storage := c.router.MatchingStorageByAPIPath(ctx, "source/")
+	require.NoError(t, storage.Put(ctx, &logical.StorageEntry{Key: "config/connection", Value: []byte("conn")}))
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "source/creds/role",
+		ClientToken: "foobar",
+	}
+	req.SetTokenEntry(&logical.TokenEntry{ID: "foobar", NamespaceID: namespace.RootNamespaceID})
+	leaseID, err := c.expiration.Register(ctx, req, &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{TTL: time.Hour},
+		},
+		Data: map[string]interface{}{"username": "foo"},
+	}, "")
+	require.NoError(t, err)
+
+	rawKey := make([]byte, 32)
+	_, err = rand.Read(rawKey)
+	require.NoError(t, err)
+	key := base64.StdEncoding.EncodeToString(rawKey)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/leases/export")
+	req.Data["mount"] = "source"
+	req.Data["key"] = key
+	req.Data["detach"] = true
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Data["lease_count"])
+	bundle := resp.Data["bundle"].(string)
+
+	le, err := c.expiration.loadEntry(ctx, leaseID)
+	require.NoError(t, err)
+	require.Nil(t, le, "expected the lease to be detached")
+	for _, r := range noop.Requests {
+		require.NotEqual(t, logical.RevokeOperation, r.Operation, "expected the lease not to be revoked")
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/leases/import")
+	req.Data["mount"] = "target"
+	req.Data["key"] = base64.StdEncoding.EncodeToString(make([]byte, 32))
+	req.Data["bundle"] = bundle
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.Error(t, err, "expected the import with the wrong key to fail")
+
+	req.Data["key"] = key
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data["token_accessor"])
+
+	se, err := c.router.MatchingStorageByAPIPath(ctx, "target/").Get(ctx, "config/connection")
+	require.NoError(t, err)
+	require.NotNil(t, se)
+
+	importedID := "target/" + strings.TrimPrefix(leaseID, "source/")
+	le, err = c.expiration.loadEntry(ctx, importedID)
+	require.NoError(t, err)
+	require.NotNil(t, le)
+	require.Equal(t, "foo", le.Data["username"])
+	_, ok := c.expiration.pending.Load(importedID)
+	require.True(t, ok, "expected the imported lease to be pending")
+
+	// The mount has data now
+	_, err = c.HandleRequest(ctx, req)
+	require.Error(t, err)
+}
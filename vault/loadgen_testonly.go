@@ -0,0 +1,408 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/time/rate"
+)
+
+const (
+	loadgenHelpText = "Drive a mix of requests against the core and report their latencies, for benchmarking purposes"
+
+	loadgenMaxDuration = 5 * time.Minute
+	loadgenMaxWorkers  = 256
+)
+
+// The operations of the load generator.
+const (
+	loadgenOpLogin          = "login"
+	loadgenOpKVRead         = "kv_read"
+	loadgenOpKVWrite        = "kv_write"
+	loadgenOpTransitEncrypt = "transit_encrypt"
+	loadgenOpTransitDecrypt = "transit_decrypt"
+)
+
+var loadgenOps = []string{
+	loadgenOpLogin,
+	loadgenOpKVRead,
+	loadgenOpKVWrite,
+	loadgenOpTransitEncrypt,
+	loadgenOpTransitDecrypt,
+}
+
+func (b *SystemBackend) loadgenPath() *framework.Path {
+	return &framework.Path{
+		Pattern:         "internal/testing/loadgen$",
+		HelpDescription: loadgenHelpText,
+		HelpSynopsis:    loadgenHelpText,
+		Fields: map[string]*framework.FieldSchema{
+			"duration": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Duration of the run, at most 5m",
+				Default:     10,
+			},
+			"rate": {
+				Type:        framework.TypeInt,
+				Description: "Number of operations per second, unlimited if 0",
+			},
+			"workers": {
+				Type:        framework.TypeInt,
+				Description: "Number of concurrent workers",
+				Default:     4,
+			},
+			"mix": {
+				Type:        framework.TypeMap,
+				Description: fmt.Sprintf("Relative weights of the operations, among %s", strings.Join(loadgenOps, ", ")),
+			},
+			"kv_path": {
+				Type:        framework.TypeString,
+				Description: "Path of the KV mount",
+				Default:     "secret",
+			},
+			"kv_version": {
+				Type:        framework.TypeInt,
+				Description: "Version of the KV mount, 1 or 2",
+				Default:     1,
+			},
+			"kv_keys": {
+				Type:        framework.TypeInt,
+				Description: "Number of keys read and written",
+				Default:     100,
+			},
+			"transit_path": {
+				Type:        framework.TypeString,
+				Description: "Path of the transit mount",
+				Default:     "transit",
+			},
+			"login_path": {
+				Type:        framework.TypeString,
+				Description: "Path of the login operation, such as auth/userpass/login/user",
+			},
+			"login_data": {
+				Type:        framework.TypeMap,
+				Description: "Data of the login operation",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleLoadgenUpdate,
+				Summary:  "Run the load generator and report the latencies of the operations",
+			},
+		},
+	}
+}
+
+// loadgenConfig is the configuration of a run of the load generator.
+type loadgenConfig struct {
+	duration time.Duration
+	rate     int
+	workers  int
+	weights  map[string]int
+
+	kvPath      string
+	kvVersion   int
+	kvKeys      int
+	transitPath string
+	loginPath   string
+	loginData   map[string]interface{}
+}
+
+// loadgen drives requests against the core with the token of the caller.
+type loadgen struct {
+	core   *Core
+	config *loadgenConfig
+	token  string
+
+	// ciphertext is decrypted by the transit_decrypt operations
+	ciphertext string
+
+	l         sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func (b *SystemBackend) handleLoadgenUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := parseLoadgenConfig(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	g := &loadgen{
+		core:      b.Core,
+		config:    config,
+		token:     req.ClientToken,
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+	if err := g.setup(ctx); err != nil {
+		return logical.ErrorResponse("failed to set up the run: %s", err), logical.ErrInvalidRequest
+	}
+
+	start := time.Now()
+	g.run(ctx)
+	return &logical.Response{
+		Data: g.report(time.Since(start)),
+	}, nil
+}
+
+func parseLoadgenConfig(d *framework.FieldData) (*loadgenConfig, error) {
+	config := &loadgenConfig{
+		duration:    time.Duration(d.Get("duration").(int)) * time.Second,
+		rate:        d.Get("rate").(int),
+		workers:     d.Get("workers").(int),
+		weights:     make(map[string]int),
+		kvPath:      strings.Trim(d.Get("kv_path").(string), "/"),
+		kvVersion:   d.Get("kv_version").(int),
+		kvKeys:      d.Get("kv_keys").(int),
+		transitPath: strings.Trim(d.Get("transit_path").(string), "/"),
+		loginPath:   strings.Trim(d.Get("login_path").(string), "/"),
+		loginData:   d.Get("login_data").(map[string]interface{}),
+	}
+	switch {
+	case config.duration <= 0 || config.duration > loadgenMaxDuration:
+		return nil, fmt.Errorf("duration must be positive and at most %s", loadgenMaxDuration)
+	case config.rate < 0:
+		return nil, fmt.Errorf("rate cannot be negative")
+	case config.workers <= 0 || config.workers > loadgenMaxWorkers:
+		return nil, fmt.Errorf("workers must be positive and at most %d", loadgenMaxWorkers)
+	case config.kvVersion != 1 && config.kvVersion != 2:
+		return nil, fmt.Errorf("kv_version must be 1 or 2")
+	case config.kvKeys <= 0:
+		return nil, fmt.Errorf("kv_keys must be positive")
+	}
+
+	mix := d.Get("mix").(map[string]interface{})
+	if len(mix) == 0 {
+		mix = map[string]interface{}{loadgenOpKVRead: 1}
+	}
+	for op, raw := range mix {
+		if !strutil.StrListContains(loadgenOps, op) {
+			return nil, fmt.Errorf("unknown operation %q in mix, expected one of %s", op, strings.Join(loadgenOps, ", "))
+		}
+		weight, err := parseutil.SafeParseInt(raw)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight of %q in mix", op)
+		}
+		if weight > 0 {
+			config.weights[op] = weight
+		}
+	}
+	if len(config.weights) == 0 {
+		return nil, fmt.Errorf("mix must have a positive weight")
+	}
+	if config.weights[loadgenOpLogin] > 0 && config.loginPath == "" {
+		return nil, fmt.Errorf("login_path is required for the login operation")
+	}
+	return config, nil
+}
+
+// setup writes the keys read by the run, and creates the transit key and the
+// ciphertext its operations use.
+func (g *loadgen) setup(ctx context.Context) error {
+	if g.config.weights[loadgenOpKVRead] > 0 {
+		for i := 0; i < g.config.kvKeys; i++ {
+			if _, err := g.do(ctx, g.kvWriteRequest(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if g.config.weights[loadgenOpTransitEncrypt] > 0 || g.config.weights[loadgenOpTransitDecrypt] > 0 {
+		if _, err := g.do(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      g.config.transitPath + "/keys/loadgen",
+		}); err != nil {
+			return err
+		}
+		resp, err := g.do(ctx, g.transitEncryptRequest())
+		if err != nil {
+			return err
+		}
+		g.ciphertext, _ = resp.Data["ciphertext"].(string)
+	}
+	return nil
+}
+
+// do handles the request with the token of the caller, under the state lock
+// held by the request of the caller.
+func (g *loadgen) do(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if req.Path != g.config.loginPath {
+		req.ClientToken = g.token
+	}
+	resp, err := g.core.switchedLockHandleRequest(ctx, req, false)
+	if err == nil && resp.IsError() {
+		err = resp.Error()
+	}
+	return resp, err
+}
+
+func (g *loadgen) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, g.config.duration)
+	defer cancel()
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if g.config.rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(g.config.rate), 1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < g.config.workers; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for limiter.Wait(ctx) == nil {
+				op := g.pick(rnd)
+				start := time.Now()
+				_, err := g.do(ctx, g.request(op, rnd))
+				g.record(op, time.Since(start), err)
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+}
+
+// pick returns an operation, at random by the weights of the mix.
+func (g *loadgen) pick(rnd *rand.Rand) string {
+	total := 0
+	for _, weight := range g.config.weights {
+		total += weight
+	}
+	n := rnd.Intn(total)
+	for _, op := range loadgenOps {
+		n -= g.config.weights[op]
+		if n < 0 {
+			return op
+		}
+	}
+	return loadgenOps[len(loadgenOps)-1]
+}
+
+func (g *loadgen) request(op string, rnd *rand.Rand) *logical.Request {
+	switch op {
+	case loadgenOpLogin:
+		data := make(map[string]interface{}, len(g.config.loginData))
+		for k, v := range g.config.loginData {
+			data[k] = v
+		}
+		return &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      g.config.loginPath,
+			Data:      data,
+		}
+	case loadgenOpKVRead:
+		return &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      g.kvKeyPath(rnd.Intn(g.config.kvKeys)),
+		}
+	case loadgenOpKVWrite:
+		return g.kvWriteRequest(rnd.Intn(g.config.kvKeys))
+	case loadgenOpTransitEncrypt:
+		return g.transitEncryptRequest()
+	default:
+		return &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      g.config.transitPath + "/decrypt/loadgen",
+			Data: map[string]interface{}{
+				"ciphertext": g.ciphertext,
+			},
+		}
+	}
+}
+
+func (g *loadgen) kvKeyPath(i int) string {
+	if g.config.kvVersion == 2 {
+		return fmt.Sprintf("%s/data/loadgen/%d", g.config.kvPath, i)
+	}
+	return fmt.Sprintf("%s/loadgen/%d", g.config.kvPath, i)
+}
+
+func (g *loadgen) kvWriteRequest(i int) *logical.Request {
+	data := map[string]interface{}{
+		"value": fmt.Sprintf("loadgen-%d", i),
+	}
+	if g.config.kvVersion == 2 {
+		data = map[string]interface{}{
+			"data": data,
+		}
+	}
+	return &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      g.kvKeyPath(i),
+		Data:      data,
+	}
+}
+
+func (g *loadgen) transitEncryptRequest() *logical.Request {
+	return &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      g.config.transitPath + "/encrypt/loadgen",
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte("loadgen")),
+		},
+	}
+}
+
+// record records the latency of an operation, unless the run ended while it
+// was handled.
+func (g *loadgen) record(op string, latency time.Duration, err error) {
+	g.l.Lock()
+	defer g.l.Unlock()
+	if err != nil {
+		if err == context.DeadlineExceeded || err == context.Canceled {
+			return
+		}
+		g.errors[op]++
+	}
+	g.latencies[op] = append(g.latencies[op], latency)
+}
+
+func (g *loadgen) report(elapsed time.Duration) map[string]interface{} {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	total, totalErrors := 0, 0
+	ops := make(map[string]interface{}, len(g.latencies))
+	for op, latencies := range g.latencies {
+		sort.Slice(latencies, func(i, j int) bool {
+			return latencies[i] < latencies[j]
+		})
+		percentile := func(p float64) float64 {
+			return float64(latencies[int(p*float64(len(latencies)-1))]) / float64(time.Millisecond)
+		}
+		ops[op] = map[string]interface{}{
+			"count":  len(latencies),
+			"errors": g.errors[op],
+			"p50_ms": percentile(0.5),
+			"p90_ms": percentile(0.9),
+			"p99_ms": percentile(0.99),
+			"max_ms": percentile(1),
+		}
+		total += len(latencies)
+		totalErrors += g.errors[op]
+	}
+
+	return map[string]interface{}{
+		"duration":       elapsed.String(),
+		"operations":     total,
+		"errors":         totalErrors,
+		"ops_per_second": float64(total) / elapsed.Seconds(),
+		"by_operation":   ops,
+	}
+}
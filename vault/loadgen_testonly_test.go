@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_loadgen verifies that the load generator drives the mix
+// of operations against a KV mount and reports their latencies.
+func TestSystemBackend_loadgen(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/loadgen-kv")
+	req.ClientToken = root
+	req.Data["type"] = "kv"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/internal/testing/loadgen")
+	req.ClientToken = root
+	req.Data["mix"] = map[string]interface{}{"unknown": 1}
+	_, err = c.HandleRequest(ctx, req)
+	require.Error(t, err)
+
+	req.Data["duration"] = "1s"
+	req.Data["rate"] = 100
+	req.Data["kv_path"] = "loadgen-kv"
+	req.Data["kv_keys"] = 10
+	req.Data["mix"] = map[string]interface{}{
+		"kv_read":  3,
+		"kv_write": 1,
+	}
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 0, resp.Data["errors"])
+	require.Greater(t, resp.Data["operations"], 0)
+	require.LessOrEqual(t, resp.Data["operations"], 110)
+
+	ops := resp.Data["by_operation"].(map[string]interface{})
+	require.Contains(t, ops, "kv_read")
+	require.NotContains(t, ops, "login")
+}
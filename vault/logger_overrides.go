@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+// loggerOverrideMaxDuration is the longest a logger override can last before
+// it reverts, so that a forgotten debug session does not flood the logs.
+const loggerOverrideMaxDuration = 7 * 24 * time.Hour
+
+var errLoggerSinksUnsupported = errors.New("the logger does not support sinks")
+
+// loggerOverride is a runtime change of the level of a logger, or a sink its
+// logs are routed to, which reverts when it expires.
+type loggerOverride struct {
+	name      string
+	level     log.Level
+	sinkFile  string
+	expiresAt time.Time
+
+	sink  *loggerSink
+	timer *time.Timer
+}
+
+// loggerSink writes the logs of a logger and its sub-loggers at or above a
+// level to a file, in addition to the main log.
+type loggerSink struct {
+	name    string
+	level   log.Level
+	adapter log.SinkAdapter
+	file    *os.File
+}
+
+func (s *loggerSink) Accept(name string, level log.Level, msg string, args ...interface{}) {
+	if level < s.level || (name != s.name && !strings.HasPrefix(name, s.name+".")) {
+		return
+	}
+	s.adapter.Accept(name, level, msg, args...)
+}
+
+// resolveLoggerName returns the name of the logger of the mount when name is
+// the path of a mount, and name otherwise. Logger names never contain a
+// slash, unlike mount paths.
+func (c *Core) resolveLoggerName(ctx context.Context, name string) (string, error) {
+	if !strings.Contains(name, "/") {
+		return name, nil
+	}
+	entry := c.router.MatchingMountEntry(ctx, name)
+	if entry == nil {
+		return "", fmt.Errorf("no mount found at %q", name)
+	}
+	if entry.Table == credentialTableType {
+		return fmt.Sprintf("auth.%s.%s", entry.Type, entry.Accessor), nil
+	}
+	return fmt.Sprintf("secrets.%s.%s", entry.Type, entry.Accessor), nil
+}
+
+// loggerSinkDir returns the directory sink files are written to, which is the
+// directory of the log file of the server.
+func loggerSinkDir(conf *server.Config) (string, error) {
+	if conf == nil || conf.LogFile == "" {
+		return "", errors.New("log sinks require log_file to be configured")
+	}
+	if strings.HasSuffix(conf.LogFile, string(os.PathSeparator)) {
+		return conf.LogFile, nil
+	}
+	if info, err := os.Stat(conf.LogFile); err == nil && info.IsDir() {
+		return conf.LogFile, nil
+	}
+	return filepath.Dir(conf.LogFile), nil
+}
+
+// openLoggerSink opens the sink file, which is a plain file name in the sink
+// directory, and registers a sink of the logs of the logger at the level.
+func (c *Core) openLoggerSink(name string, level log.Level, sinkFile string) (*loggerSink, error) {
+	intercept, ok := c.baseLogger.(log.InterceptLogger)
+	if !ok {
+		return nil, errLoggerSinksUnsupported
+	}
+	if sinkFile != filepath.Base(sinkFile) || sinkFile == "." || sinkFile == ".." {
+		return nil, fmt.Errorf("sink %q must be a file name", sinkFile)
+	}
+	conf, _ := c.rawConfig.Load().(*server.Config)
+	dir, err := loggerSinkDir(conf)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, sinkFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink: %w", err)
+	}
+
+	sink := &loggerSink{
+		name:  name,
+		level: level,
+		adapter: log.NewSinkAdapter(&log.LoggerOptions{
+			Output:     f,
+			Level:      log.Trace,
+			JSONFormat: conf.LogFormat == logging.JSONFormat.String(),
+		}),
+		file: f,
+	}
+	intercept.RegisterSink(sink)
+	return sink, nil
+}
+
+func (c *Core) closeLoggerSink(sink *loggerSink) {
+	if intercept, ok := c.baseLogger.(log.InterceptLogger); ok {
+		intercept.DeregisterSink(sink)
+	}
+	if err := sink.file.Close(); err != nil {
+		c.logger.Warn("failed to close logger sink", "logger", sink.name, "error", err)
+	}
+}
+
+// SetLoggerOverride sets the level of the named logger, or when sinkFile is
+// set, routes its logs at the level to the sink file and leaves its level in
+// the main log unchanged. The override replaces any previous override of the
+// logger, and reverts after the duration, if not zero.
+func (c *Core) SetLoggerOverride(name string, level log.Level, sinkFile string, duration time.Duration) error {
+	if duration > loggerOverrideMaxDuration {
+		return fmt.Errorf("duration cannot exceed %s", loggerOverrideMaxDuration)
+	}
+
+	if !c.hasLogger(name) {
+		return fmt.Errorf("logger %q not found", name)
+	}
+
+	c.loggerOverridesLock.Lock()
+	defer c.loggerOverridesLock.Unlock()
+
+	override := &loggerOverride{
+		name:     name,
+		level:    level,
+		sinkFile: sinkFile,
+	}
+	if sinkFile != "" {
+		sink, err := c.openLoggerSink(name, level, sinkFile)
+		if err != nil {
+			return err
+		}
+		override.sink = sink
+	}
+
+	if previous, ok := c.loggerOverrides[name]; ok {
+		c.clearLoggerOverrideLocked(previous)
+	}
+	if sinkFile == "" {
+		c.SetLogLevelByName(name, level)
+	}
+
+	if duration > 0 {
+		override.expiresAt = time.Now().Add(duration)
+		override.timer = time.AfterFunc(duration, func() {
+			c.revertLoggerOverride(name, override)
+		})
+	}
+	c.loggerOverrides[name] = override
+	return nil
+}
+
+// revertLoggerOverride reverts the override when it expires, unless it was
+// replaced since.
+func (c *Core) revertLoggerOverride(name string, override *loggerOverride) {
+	c.loggerOverridesLock.Lock()
+	defer c.loggerOverridesLock.Unlock()
+
+	if c.loggerOverrides[name] != override {
+		return
+	}
+	c.clearLoggerOverrideLocked(override)
+	delete(c.loggerOverrides, name)
+	if override.sink == nil {
+		if level, err := logging.ParseLogLevel(c.logLevel); err == nil {
+			c.SetLogLevelByName(name, level)
+		}
+	}
+	c.logger.Info("logger override expired", "logger", name)
+}
+
+// ClearLoggerOverrides forgets the overrides of the named logger, or of all
+// the loggers if name is empty, and closes their sinks. Levels are left for
+// the caller to reset.
+func (c *Core) ClearLoggerOverrides(name string) {
+	c.loggerOverridesLock.Lock()
+	defer c.loggerOverridesLock.Unlock()
+
+	for overrideName, override := range c.loggerOverrides {
+		if name == "" || name == overrideName {
+			c.clearLoggerOverrideLocked(override)
+			delete(c.loggerOverrides, overrideName)
+		}
+	}
+}
+
+func (c *Core) clearLoggerOverrideLocked(override *loggerOverride) {
+	if override.timer != nil {
+		override.timer.Stop()
+	}
+	if override.sink != nil {
+		c.closeLoggerSink(override.sink)
+	}
+}
+
+func (c *Core) hasLogger(name string) bool {
+	c.allLoggersLock.RLock()
+	defer c.allLoggersLock.RUnlock()
+	for _, logger := range c.allLoggers {
+		if logger.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loggerOverridesInfo returns the active logger overrides by logger name.
+func (c *Core) loggerOverridesInfo() ([]string, map[string]interface{}) {
+	c.loggerOverridesLock.Lock()
+	defer c.loggerOverridesLock.Unlock()
+
+	names := make([]string, 0, len(c.loggerOverrides))
+	info := make(map[string]interface{}, len(c.loggerOverrides))
+	for name, override := range c.loggerOverrides {
+		names = append(names, name)
+		entry := map[string]interface{}{
+			"level": strings.ToLower(override.level.String()),
+		}
+		if override.sinkFile != "" {
+			entry["sink"] = override.sinkFile
+		}
+		if !override.expiresAt.IsZero() {
+			entry["expires_at"] = override.expiresAt.UTC().Format(time.RFC3339)
+		}
+		info[name] = entry
+	}
+	sort.Strings(names)
+	return names, info
+}
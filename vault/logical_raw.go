@@ -298,6 +298,8 @@ func rawPaths(prefix string, r *RawBackend) []*framework.Path {
 		{
 			Pattern: prefix + "raw/" + framework.MatchAllRegex("path"),
 
+			Sensitivity: framework.SensitivityCritical,
+
 			Fields: map[string]*framework.FieldSchema{
 				"path": {
 					Type: framework.TypeString,
@@ -4,6 +4,7 @@
 package vault
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -22,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	semver "github.com/hashicorp/go-version"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/vault/helper/experiments"
 	"github.com/hashicorp/vault/helper/hostutil"
 	"github.com/hashicorp/vault/helper/identity"
@@ -60,6 +63,10 @@ import (
 const (
 	maxBytes    = 128 * 1024
 	globalScope = "global"
+
+	// openAPICacheSize is the number of generated OpenAPI documents that are
+	// kept cached
+	openAPICacheSize = 64
 )
 
 func systemBackendMemDBSchema() *memdb.DBSchema {
@@ -92,12 +99,15 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 		return nil
 	}
 
+	openAPICache, _ := lru.New2Q(openAPICacheSize)
+
 	b := &SystemBackend{
-		Core:        core,
-		db:          db,
-		logger:      logger,
-		mfaBackend:  NewPolicyMFABackend(core, logger),
-		syncBackend: syncBackend,
+		Core:         core,
+		db:           db,
+		logger:       logger,
+		mfaBackend:   NewPolicyMFABackend(core, logger),
+		syncBackend:  syncBackend,
+		openAPICache: openAPICache,
 	}
 
 	b.Backend = &framework.Backend{
@@ -120,6 +130,17 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"replication/performance/reindex",
 				"rotate",
 				"config/cors",
+				"config/cors/namespaces",
+				"config/cors/namespaces/*",
+				"config/client-hints",
+				"deprecations/usage",
+				"diagnostics/last-crash",
+				"in-flight-req/cancel",
+				"config/fair-share",
+				"config/login-enrichment",
+				"config/token-anomaly/*",
+				"deception/*",
+				"config/path-rewrites/*",
 				"config/auditing/*",
 				"config/ui/headers/*",
 				"plugins/catalog/*",
@@ -129,9 +150,21 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"leases/export",
+				"leases/import",
+				"seal-mount",
+				"unseal-mount",
+				"mount-erasures",
+				"mount-erasures/*",
 				"storage/raft/snapshot-auto/config/*",
+				"storage/integrity/config",
+				"storage/integrity/scrub",
+				"storage/integrity/quarantine",
 				"leases",
 				"internal/inspect/*",
+				"internal/testing/clock",
+				"internal/testing/ha-faults",
+				"internal/testing/loadgen",
 				// sys/seal and sys/step-down actually have their sudo requirement enforced through hardcoding
 				// PolicyCheckOpts.RootPrivsRequired in dedicated calls to Core.performPolicyChecks, but we still need
 				// to declare them here so that the generated OpenAPI spec gets their sudo status correct.
@@ -144,8 +177,11 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"wrapping/pubkey",
 				"replication/status",
 				"internal/specs/openapi",
+				"internal/specs/clients",
+				"internal/client-hints",
 				"internal/ui/authenticated-messages",
 				"internal/ui/unauthenticated-messages",
+				"internal/ui/branding",
 				"internal/ui/mounts",
 				"internal/ui/mounts/*",
 				"internal/ui/namespaces",
@@ -207,8 +243,21 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.auditPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.mountPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.authPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountSealPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountErasurePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.pathCatalogPaths()...)
+	if clockPath := b.testingClockPath(); clockPath != nil {
+		b.Backend.Paths = append(b.Backend.Paths, clockPath)
+	}
+	if haFaultsPath := b.haFaultsPath(); haFaultsPath != nil {
+		b.Backend.Paths = append(b.Backend.Paths, haFaultsPath)
+	}
+	if loadgenPath := b.loadgenPath(); loadgenPath != nil {
+		b.Backend.Paths = append(b.Backend.Paths, loadgenPath)
+	}
 	b.Backend.Paths = append(b.Backend.Paths, b.lockedUserPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.leasePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.leaseMigrationPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.policyPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.wrappingPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.toolsPaths()...)
@@ -219,8 +268,17 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.metricsPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.monitorPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestCancelPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.lastCrashPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.fairSharePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.loginEnrichmentPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.uiBrandingPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.tokenAnomalyPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.deceptionPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.pathRewritePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.storageIntegrityPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.loginMFAPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.experimentPaths()...)
@@ -270,6 +328,98 @@ type SystemBackend struct {
 	logger      log.Logger
 	mfaBackend  *PolicyMFABackend
 	syncBackend *SecretsSyncBackend
+
+	// openAPICache caches the generated OpenAPI documents by the hash of
+	// everything they are generated from, which is also their ETag.
+	openAPICache *lru.TwoQueueCache
+
+	// monitorStreams is the number of sys/monitor streams in progress.
+	monitorStreams atomic.Int32
+}
+
+// handleClientHintsConfigRead returns the client hints configuration
+func (b *SystemBackend) handleClientHintsConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: clientHintsResponseData(b.Core.ClientHints()),
+	}, nil
+}
+
+// handleDeprecationUsageRead returns the usage of deprecated builtins and
+// endpoints in the namespace of the request and its children, as counted by
+// this node.
+func (b *SystemBackend) handleDeprecationUsageRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"usage": b.Core.deprecationUsageReport(ns),
+		},
+	}, nil
+}
+
+// handleDeprecationUsageDelete resets the usage of deprecated builtins and
+// endpoints counted by this node.
+func (b *SystemBackend) handleDeprecationUsageDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.deprecationUsage.reset()
+	return nil, nil
+}
+
+// handleClientHintsConfigUpdate updates the client hints advertised to API
+// clients. Fields which are not provided retain their current value.
+func (b *SystemBackend) handleClientHintsConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	hints := *b.Core.ClientHints()
+
+	if v, ok := d.GetOk("hedging_enabled"); ok {
+		hints.HedgingEnabled = v.(bool)
+	}
+	if v, ok := d.GetOk("hedge_delay_ms"); ok {
+		hints.HedgeDelayMillis = v.(int)
+	}
+	if v, ok := d.GetOk("max_hedged_requests"); ok {
+		hints.MaxHedgedRequests = v.(int)
+	}
+	if v, ok := d.GetOk("circuit_breaker_enabled"); ok {
+		hints.CircuitBreakerEnabled = v.(bool)
+	}
+	if v, ok := d.GetOk("circuit_breaker_failure_threshold"); ok {
+		hints.CircuitBreakerFailureThreshold = v.(int)
+	}
+	if v, ok := d.GetOk("circuit_breaker_open_duration"); ok {
+		hints.CircuitBreakerOpenDuration = v.(int)
+	}
+
+	switch {
+	case hints.HedgeDelayMillis < 0:
+		return logical.ErrorResponse("hedge_delay_ms cannot be negative"), nil
+	case hints.MaxHedgedRequests < 0:
+		return logical.ErrorResponse("max_hedged_requests cannot be negative"), nil
+	case hints.HedgingEnabled && (hints.HedgeDelayMillis == 0 || hints.MaxHedgedRequests == 0):
+		return logical.ErrorResponse("hedge_delay_ms and max_hedged_requests must be greater than zero when hedging is enabled"), nil
+	case hints.CircuitBreakerFailureThreshold < 0:
+		return logical.ErrorResponse("circuit_breaker_failure_threshold cannot be negative"), nil
+	case hints.CircuitBreakerEnabled && (hints.CircuitBreakerFailureThreshold == 0 || hints.CircuitBreakerOpenDuration <= 0):
+		return logical.ErrorResponse("circuit_breaker_failure_threshold and circuit_breaker_open_duration must be greater than zero when circuit breaking is enabled"), nil
+	}
+
+	return nil, b.Core.saveClientHintsConfig(ctx, &hints)
+}
+
+// handleClientHintsConfigDelete resets the client hints to their defaults
+func (b *SystemBackend) handleClientHintsConfigDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, b.Core.deleteClientHintsConfig(ctx)
+}
+
+func clientHintsResponseData(hints *ClientHintsConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"hedging_enabled":                   hints.HedgingEnabled,
+		"hedge_delay_ms":                    hints.HedgeDelayMillis,
+		"max_hedged_requests":               hints.MaxHedgedRequests,
+		"circuit_breaker_enabled":           hints.CircuitBreakerEnabled,
+		"circuit_breaker_failure_threshold": hints.CircuitBreakerFailureThreshold,
+		"circuit_breaker_open_duration":     hints.CircuitBreakerOpenDuration,
+	}
 }
 
 // handleConfigStateSanitized returns the current configuration state. The configuration
@@ -308,10 +458,10 @@ func (b *SystemBackend) handleCORSRead(ctx context.Context, req *logical.Request
 	}
 
 	if enabled {
-		corsConf.RLock()
-		resp.Data["allowed_origins"] = corsConf.AllowedOrigins
-		resp.Data["allowed_headers"] = corsConf.AllowedHeaders
-		corsConf.RUnlock()
+		policy := corsConf.Policy()
+		resp.Data["allowed_origins"] = policy.AllowedOrigins
+		resp.Data["allowed_headers"] = policy.AllowedHeaders
+		resp.Data["max_age"] = policy.MaxAgeOrDefault()
 	}
 
 	return resp, nil
@@ -322,8 +472,9 @@ func (b *SystemBackend) handleCORSRead(ctx context.Context, req *logical.Request
 func (b *SystemBackend) handleCORSUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	origins := d.Get("allowed_origins").([]string)
 	headers := d.Get("allowed_headers").([]string)
+	maxAge := d.Get("max_age").(int)
 
-	return nil, b.Core.corsConfig.Enable(ctx, origins, headers)
+	return nil, b.Core.corsConfig.EnableWithMaxAge(ctx, origins, headers, maxAge)
 }
 
 // handleCORSDelete sets the CORS enabled flag to false and clears the list of
@@ -332,26 +483,142 @@ func (b *SystemBackend) handleCORSDelete(ctx context.Context, req *logical.Reque
 	return nil, b.Core.corsConfig.Disable(ctx)
 }
 
+// handleCORSNamespaceList returns the paths of the namespaces which have a
+// CORS policy.
+func (b *SystemBackend) handleCORSNamespaceList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	paths := b.Core.corsConfig.NamespacePolicyPaths()
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	return logical.ListResponse(paths), nil
+}
+
+// handleCORSNamespaceRead returns the CORS policy of a namespace.
+func (b *SystemBackend) handleCORSNamespaceRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nsPath := namespace.Canonicalize(d.Get("namespace").(string))
+	policy := b.Core.corsConfig.NamespacePolicyByPath(nsPath)
+	if policy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"namespace":       nsPath,
+			"allowed_origins": policy.AllowedOrigins,
+			"allowed_headers": policy.AllowedHeaders,
+			"max_age":         policy.MaxAgeOrDefault(),
+		},
+	}, nil
+}
+
+// handleCORSNamespaceUpdate sets the CORS policy of a namespace, which takes
+// precedence over the CORS policies of listeners and the global CORS
+// configuration for requests to the namespace and its children.
+func (b *SystemBackend) handleCORSNamespaceUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nsPath := namespace.Canonicalize(d.Get("namespace").(string))
+	if nsPath == "" {
+		return logical.ErrorResponse("the CORS policy of the root namespace is configured at sys/config/cors"), logical.ErrInvalidRequest
+	}
+
+	policy, err := NewCORSPolicy(d.Get("allowed_origins").([]string), d.Get("allowed_headers").([]string), d.Get("max_age").(int))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.corsConfig.SetNamespacePolicy(ctx, nsPath, policy)
+}
+
+// handleCORSNamespaceDelete removes the CORS policy of a namespace.
+func (b *SystemBackend) handleCORSNamespaceDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nsPath := namespace.Canonicalize(d.Get("namespace").(string))
+	if b.Core.corsConfig.NamespacePolicyByPath(nsPath) == nil {
+		return nil, nil
+	}
+	return nil, b.Core.corsConfig.SetNamespacePolicy(ctx, nsPath, nil)
+}
+
 func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	go func() {
-		tidyCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
-		err := b.Core.expiration.Tidy(tidyCtx)
+	tidyCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
+	op, err := b.Core.tidyScheduler.start(tidyCtx, tidyKindLeases, func(ctx context.Context, op *tidyOperation) error {
+		err := b.Core.expiration.tidy(ctx, op)
 		if err != nil {
 			b.Backend.Logger().Error("failed to tidy leases", "error", err)
-			return
 		}
-	}()
+		return err
+	})
+	return tidyStartedResponse(req, ns, op, err)
+}
 
+// tidyStartedResponse returns the response to a request starting a tidy
+// operation with the tidy scheduler.
+func tidyStartedResponse(req *logical.Request, ns *namespace.Namespace, op *tidyOperation, err error) (*logical.Response, error) {
 	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
+	switch {
+	case errors.Is(err, errTidyInProgress):
+		resp.AddWarning("Tidy operation already in progress.")
+		if op.Namespace == ns.Path {
+			resp.Data = map[string]interface{}{"tidy_id": op.ID}
+		}
+		return resp, nil
+	case err != nil:
+		return nil, err
+	}
+
+	resp.Data = map[string]interface{}{"tidy_id": op.ID}
+	resp.AddWarning("Tidy operation successfully started. Its progress can be read at sys/tidy/operations/" + op.ID + ", and any information from the operation will be printed to Vault's server logs.")
 	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
 }
 
+func (b *SystemBackend) handleTidyOperationsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := b.Core.tidyScheduler.list(ns)
+	keys := make([]string, 0, len(ops))
+	keyInfo := make(map[string]interface{}, len(ops))
+	for _, op := range ops {
+		keys = append(keys, op.ID)
+		keyInfo[op.ID] = op.status()
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleTidyOperationRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	op := b.Core.tidyScheduler.get(ns, d.Get("id").(string))
+	if op == nil {
+		return nil, nil
+	}
+	return &logical.Response{Data: op.status()}, nil
+}
+
+// handleTidyOperationCancel cancels a queued or running tidy operation. The
+// operation stops at its next entry.
+func (b *SystemBackend) handleTidyOperationCancel(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	op := b.Core.tidyScheduler.get(ns, d.Get("id").(string))
+	if op == nil {
+		return nil, nil
+	}
+	op.cancelOperation()
+	return nil, nil
+}
+
 func (b *SystemBackend) handleLeaseCount(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	typeRaw, ok := d.GetOk("type")
 	if !ok || strings.ToLower(typeRaw.(string)) != "irrevocable" {
@@ -1259,6 +1526,14 @@ func (b *SystemBackend) handleCapabilities(ctx context.Context, req *logical.Req
 		ret.Data["capabilities"] = ret.Data[paths[0]]
 	}
 
+	if strings.HasSuffix(req.Path, "capabilities-self") && d.Get("explain").(bool) {
+		explanations, err := b.Core.ExplainCapabilities(ctx, token, paths)
+		if err != nil {
+			return nil, err
+		}
+		ret.Data["explanations"] = explanations
+	}
+
 	return ret, nil
 }
 
@@ -1370,11 +1645,26 @@ func (b *SystemBackend) mountInfo(ctx context.Context, entry *MountEntry) map[st
 		"running_plugin_version":  entry.RunningVersion,
 		"running_sha256":          entry.RunningSha256,
 	}
+	if entry.Sealed {
+		info["sealed"] = true
+	}
+	if entry.MountKey {
+		info["mount_key"] = true
+	}
 	entryConfig := map[string]interface{}{
 		"default_lease_ttl": int64(entry.Config.DefaultLeaseTTL.Seconds()),
 		"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
 		"force_no_cache":    entry.Config.ForceNoCache,
 	}
+	if entry.Config.StrictFieldValidation {
+		entryConfig["strict_field_validation"] = true
+	}
+	if entry.Config.CompatibilityLevel != "" {
+		entryConfig["compatibility_level"] = entry.Config.CompatibilityLevel
+	}
+	if rawVal, ok := entry.synthesizedConfigCache.Load("custom_response_headers"); ok {
+		entryConfig["custom_response_headers"] = rawVal.(map[string]string)
+	}
 	if rawVal, ok := entry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
 		entryConfig["audit_non_hmac_request_keys"] = rawVal.([]string)
 	}
@@ -1400,6 +1690,25 @@ func (b *SystemBackend) mountInfo(ctx context.Context, entry *MountEntry) map[st
 	}
 	if entry.Table == credentialTableType {
 		entryConfig["token_type"] = entry.Config.TokenType.String()
+		if entry.Config.MaxChildTokenDepth != nil {
+			entryConfig["max_child_token_depth"] = *entry.Config.MaxChildTokenDepth
+		}
+		if entry.Config.MaxEntityTokens > 0 {
+			entryConfig["max_entity_tokens"] = entry.Config.MaxEntityTokens
+			entryConfig["entity_token_limit_action"] = entry.Config.entityTokenLimitAction()
+		}
+		if len(entry.Config.LoginResponseEntityMetadata) > 0 {
+			entryConfig["login_response_entity_metadata"] = entry.Config.LoginResponseEntityMetadata
+		}
+		if entry.Config.LoginResponseGroupNames {
+			entryConfig["login_response_group_names"] = true
+		}
+		if entry.Config.LoginResponseNamespace {
+			entryConfig["login_response_namespace"] = true
+		}
+		if len(entry.Config.LoginResponseRoles) > 0 {
+			entryConfig["login_response_roles"] = entry.Config.LoginResponseRoles
+		}
 	}
 	if entry.Config.UserLockoutConfig != nil {
 		userLockoutConfig := map[string]interface{}{
@@ -1478,6 +1787,7 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 	description := data.Get("description").(string)
 	pluginName := data.Get("plugin_name").(string)
 	sealWrap := data.Get("seal_wrap").(bool)
+	mountKey := data.Get("mount_key").(bool)
 	externalEntropyAccess := data.Get("external_entropy_access").(bool)
 	options := data.Get("options").(map[string]string)
 
@@ -1618,6 +1928,13 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 	if len(apiConfig.DelegatedAuthAccessors) > 0 {
 		config.DelegatedAuthAccessors = apiConfig.DelegatedAuthAccessors
 	}
+	if len(apiConfig.CustomResponseHeaders) > 0 {
+		headers, err := validateMountResponseHeaders(apiConfig.CustomResponseHeaders)
+		if err != nil {
+			return logical.ErrorResponse("invalid custom_response_headers: %s", err), logical.ErrInvalidRequest
+		}
+		config.CustomResponseHeaders = headers
+	}
 
 	storage := b.Core.router.MatchingStorageByAPIPath(ctx, mountPathIdentity)
 	if storage == nil {
@@ -1665,6 +1982,7 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 		Local:                 local,
 		SealWrap:              sealWrap,
 		ExternalEntropyAccess: externalEntropyAccess,
+		MountKey:              mountKey,
 		Options:               options,
 		Version:               pluginVersion,
 	}
@@ -2093,6 +2411,18 @@ func (b *SystemBackend) handleTuneReadCommon(ctx context.Context, path string) (
 		},
 	}
 
+	if mountEntry.Config.StrictFieldValidation {
+		resp.Data["strict_field_validation"] = true
+	}
+
+	if mountEntry.Config.CompatibilityLevel != "" {
+		resp.Data["compatibility_level"] = mountEntry.Config.CompatibilityLevel
+	}
+
+	if rawVal, ok := mountEntry.synthesizedConfigCache.Load("custom_response_headers"); ok {
+		resp.Data["custom_response_headers"] = rawVal.(map[string]string)
+	}
+
 	// not tunable so doesn't need to be stored/loaded through synthesizedConfigCache
 	if mountEntry.ExternalEntropyAccess {
 		resp.Data["external_entropy_access"] = true
@@ -2101,6 +2431,32 @@ func (b *SystemBackend) handleTuneReadCommon(ctx context.Context, path string) (
 	isAuth := mountEntry.Table == credentialTableType
 	if isAuth {
 		resp.Data["token_type"] = mountEntry.Config.TokenType.String()
+
+		if !mountEntry.Config.ClientCountSimulationEnd.IsZero() {
+			resp.Data["client_count_simulation_end"] = mountEntry.Config.ClientCountSimulationEnd.Format(time.RFC3339)
+		}
+
+		if mountEntry.Config.MaxChildTokenDepth != nil {
+			resp.Data["max_child_token_depth"] = *mountEntry.Config.MaxChildTokenDepth
+		}
+
+		if mountEntry.Config.MaxEntityTokens > 0 {
+			resp.Data["max_entity_tokens"] = mountEntry.Config.MaxEntityTokens
+			resp.Data["entity_token_limit_action"] = mountEntry.Config.entityTokenLimitAction()
+		}
+
+		if len(mountEntry.Config.LoginResponseEntityMetadata) > 0 {
+			resp.Data["login_response_entity_metadata"] = mountEntry.Config.LoginResponseEntityMetadata
+		}
+		if mountEntry.Config.LoginResponseGroupNames {
+			resp.Data["login_response_group_names"] = true
+		}
+		if mountEntry.Config.LoginResponseNamespace {
+			resp.Data["login_response_namespace"] = true
+		}
+		if len(mountEntry.Config.LoginResponseRoles) > 0 {
+			resp.Data["login_response_roles"] = mountEntry.Config.LoginResponseRoles
+		}
 	}
 
 	if rawVal, ok := mountEntry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
@@ -2621,6 +2977,263 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
+	if rawVal, ok := data.GetOk("client_count_simulation_period"); ok {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'client_count_simulation_period' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		if mountEntry.Type == mountTypeToken || mountEntry.Type == mountTypeNSToken {
+			return logical.ErrorResponse("'client_count_simulation_period' cannot be set for 'token' or 'ns_token' auth mounts"), logical.ErrInvalidRequest
+		}
+
+		period, err := parseutil.ParseDurationSecond(rawVal)
+		if err != nil {
+			return logical.ErrorResponse("invalid value for 'client_count_simulation_period': %v", err), logical.ErrInvalidRequest
+		}
+		if period < 0 {
+			return logical.ErrorResponse("'client_count_simulation_period' cannot be negative"), logical.ErrInvalidRequest
+		}
+
+		// A period of zero ends a running simulation, keeping the clients it
+		// recorded available for reporting.
+		now := time.Now().UTC()
+		oldVal := mountEntry.Config.ClientCountSimulationEnd
+		switch {
+		case period > 0:
+			mountEntry.Config.ClientCountSimulationEnd = now.Add(period)
+		case mountEntry.Config.clientCountSimulationActive(now):
+			mountEntry.Config.ClientCountSimulationEnd = now
+		}
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.ClientCountSimulationEnd = oldVal
+			return handleError(err)
+		}
+
+		// A new simulation starts without the clients of the previous one
+		if period > 0 {
+			b.Core.activityLogLock.RLock()
+			activityLog := b.Core.activityLog
+			b.Core.activityLogLock.RUnlock()
+			if activityLog != nil {
+				if err := activityLog.clearSimulatedClients(ctx, mountEntry.Accessor); err != nil {
+					return handleError(err)
+				}
+			}
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of client_count_simulation_period successful", "path", path, "client_count_simulation_end", mountEntry.Config.ClientCountSimulationEnd)
+		}
+	}
+
+	if rawVal, ok := data.GetOk("max_child_token_depth"); ok {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'max_child_token_depth' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		depth := rawVal.(int)
+		if depth < -1 {
+			return logical.ErrorResponse("'max_child_token_depth' must be -1 or greater"), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.MaxChildTokenDepth
+		if depth == -1 {
+			mountEntry.Config.MaxChildTokenDepth = nil
+		} else {
+			mountEntry.Config.MaxChildTokenDepth = &depth
+		}
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.MaxChildTokenDepth = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of max_child_token_depth successful", "path", path, "max_child_token_depth", depth)
+		}
+	}
+
+	maxEntityTokensRaw, maxEntityTokensOk := data.GetOk("max_entity_tokens")
+	limitActionRaw, limitActionOk := data.GetOk("entity_token_limit_action")
+	if maxEntityTokensOk || limitActionOk {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'max_entity_tokens' and 'entity_token_limit_action' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		if mountEntry.Type == mountTypeToken || mountEntry.Type == mountTypeNSToken {
+			return logical.ErrorResponse("'max_entity_tokens' and 'entity_token_limit_action' cannot be set for 'token' or 'ns_token' auth mounts"), logical.ErrInvalidRequest
+		}
+
+		maxEntityTokens := mountEntry.Config.MaxEntityTokens
+		if maxEntityTokensOk {
+			maxEntityTokens = maxEntityTokensRaw.(int)
+			if maxEntityTokens < 0 {
+				return logical.ErrorResponse("'max_entity_tokens' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+		limitAction := mountEntry.Config.EntityTokenLimitAction
+		if limitActionOk {
+			limitAction = limitActionRaw.(string)
+			if err := validateEntityTokenLimitAction(limitAction); err != nil {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
+		}
+
+		oldMax, oldAction := mountEntry.Config.MaxEntityTokens, mountEntry.Config.EntityTokenLimitAction
+		mountEntry.Config.MaxEntityTokens = maxEntityTokens
+		mountEntry.Config.EntityTokenLimitAction = limitAction
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.MaxEntityTokens = oldMax
+			mountEntry.Config.EntityTokenLimitAction = oldAction
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of entity token limit successful", "path", path,
+				"max_entity_tokens", maxEntityTokens, "entity_token_limit_action", mountEntry.Config.entityTokenLimitAction())
+		}
+	}
+
+	entityMetadataRaw, entityMetadataOk := data.GetOk("login_response_entity_metadata")
+	groupNamesRaw, groupNamesOk := data.GetOk("login_response_group_names")
+	namespaceRaw, namespaceOk := data.GetOk("login_response_namespace")
+	rolesRaw, rolesOk := data.GetOk("login_response_roles")
+	if entityMetadataOk || groupNamesOk || namespaceOk || rolesOk {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'login_response_entity_metadata', 'login_response_group_names', 'login_response_namespace' and 'login_response_roles' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		if mountEntry.Type == mountTypeToken || mountEntry.Type == mountTypeNSToken {
+			return logical.ErrorResponse("'login_response_entity_metadata', 'login_response_group_names', 'login_response_namespace' and 'login_response_roles' cannot be set for 'token' or 'ns_token' auth mounts"), logical.ErrInvalidRequest
+		}
+
+		entityMetadata := mountEntry.Config.LoginResponseEntityMetadata
+		if entityMetadataOk {
+			entityMetadata = strutil.RemoveDuplicates(entityMetadataRaw.([]string), false)
+			if len(entityMetadata) == 0 {
+				entityMetadata = nil
+			}
+		}
+		groupNames := mountEntry.Config.LoginResponseGroupNames
+		if groupNamesOk {
+			groupNames = groupNamesRaw.(bool)
+		}
+		includeNamespace := mountEntry.Config.LoginResponseNamespace
+		if namespaceOk {
+			includeNamespace = namespaceRaw.(bool)
+		}
+		roles := mountEntry.Config.LoginResponseRoles
+		if rolesOk {
+			var err error
+			roles, err = parseLoginResponseRoles(rolesRaw.(map[string]interface{}))
+			if err != nil {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
+		}
+
+		oldEntityMetadata, oldGroupNames, oldNamespace := mountEntry.Config.LoginResponseEntityMetadata, mountEntry.Config.LoginResponseGroupNames, mountEntry.Config.LoginResponseNamespace
+		oldRoles := mountEntry.Config.LoginResponseRoles
+		mountEntry.Config.LoginResponseEntityMetadata = entityMetadata
+		mountEntry.Config.LoginResponseGroupNames = groupNames
+		mountEntry.Config.LoginResponseNamespace = includeNamespace
+		mountEntry.Config.LoginResponseRoles = roles
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.LoginResponseEntityMetadata = oldEntityMetadata
+			mountEntry.Config.LoginResponseGroupNames = oldGroupNames
+			mountEntry.Config.LoginResponseNamespace = oldNamespace
+			mountEntry.Config.LoginResponseRoles = oldRoles
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of login response shaping successful", "path", path,
+				"login_response_entity_metadata", entityMetadata, "login_response_group_names", groupNames,
+				"login_response_namespace", includeNamespace, "login_response_roles", len(roles))
+		}
+	}
+
+	if rawVal, ok := data.GetOk("strict_field_validation"); ok {
+		oldVal := mountEntry.Config.StrictFieldValidation
+		mountEntry.Config.StrictFieldValidation = rawVal.(bool)
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, "auth/"):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.StrictFieldValidation = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of strict_field_validation successful", "path", path, "strict_field_validation", mountEntry.Config.StrictFieldValidation)
+		}
+	}
+
+	if rawVal, ok := data.GetOk("compatibility_level"); ok {
+		level := rawVal.(string)
+		if level != "" {
+			if _, err := semver.NewVersion(level); err != nil {
+				return logical.ErrorResponse("compatibility_level must be a version, e.g. '1.2': %s", err), logical.ErrInvalidRequest
+			}
+		}
+
+		oldVal := mountEntry.Config.CompatibilityLevel
+		mountEntry.Config.CompatibilityLevel = level
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, "auth/"):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.CompatibilityLevel = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of compatibility_level successful", "path", path, "compatibility_level", mountEntry.Config.CompatibilityLevel)
+		}
+	}
+
+	if rawVal, ok := data.GetOk("custom_response_headers"); ok {
+		headers, err := validateMountResponseHeaders(rawVal.(map[string]string))
+		if err != nil {
+			return logical.ErrorResponse("invalid custom_response_headers: %s", err), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.CustomResponseHeaders
+		mountEntry.Config.CustomResponseHeaders = headers
+
+		// Update the mount table
+		switch {
+		case strings.HasPrefix(path, "auth/"):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.CustomResponseHeaders = oldVal
+			return handleError(err)
+		}
+
+		mountEntry.SyncCache()
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of custom_response_headers successful", "path", path)
+		}
+	}
+
 	if rawVal, ok := data.GetOk("passthrough_request_headers"); ok {
 		headers := rawVal.([]string)
 
@@ -3130,6 +3743,7 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 	description := data.Get("description").(string)
 	pluginName := data.Get("plugin_name").(string)
 	sealWrap := data.Get("seal_wrap").(bool)
+	mountKey := data.Get("mount_key").(bool)
 	externalEntropyAccess := data.Get("external_entropy_access").(bool)
 	options := data.Get("options").(map[string]string)
 
@@ -3256,6 +3870,13 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 	if len(apiConfig.AllowedManagedKeys) > 0 {
 		config.AllowedManagedKeys = apiConfig.AllowedManagedKeys
 	}
+	if len(apiConfig.CustomResponseHeaders) > 0 {
+		headers, err := validateMountResponseHeaders(apiConfig.CustomResponseHeaders)
+		if err != nil {
+			return logical.ErrorResponse("invalid custom_response_headers: %s", err), logical.ErrInvalidRequest
+		}
+		config.CustomResponseHeaders = headers
+	}
 
 	storage := b.Core.router.MatchingStorageByAPIPath(ctx, mountPathIdentity)
 	if storage == nil {
@@ -3299,6 +3920,7 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 		Local:                 local,
 		SealWrap:              sealWrap,
 		ExternalEntropyAccess: externalEntropyAccess,
+		MountKey:              mountKey,
 		Options:               options,
 		Version:               pluginVersion,
 	}
@@ -3824,6 +4446,11 @@ func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *l
 
 // handleAuditTable handles the "audit" endpoint to provide the audit table
 func (b *SystemBackend) handleAuditTable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	b.Core.auditLock.RLock()
 	defer b.Core.auditLock.RUnlock()
 
@@ -3831,6 +4458,14 @@ func (b *SystemBackend) handleAuditTable(ctx context.Context, req *logical.Reque
 		Data: make(map[string]interface{}),
 	}
 	for _, entry := range b.Core.audit.Entries {
+		// Only list the devices managed within the request's namespace
+		entryNSID := entry.NamespaceID
+		if entryNSID == "" {
+			entryNSID = namespace.RootNamespaceID
+		}
+		if entryNSID != ns.ID {
+			continue
+		}
 		info := map[string]interface{}{
 			"path":        entry.Path,
 			"type":        entry.Type,
@@ -3854,6 +4489,14 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 
 	path = sanitizePath(path)
 
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		path = ns.Path + path
+	}
+
 	hash, err := b.Core.auditBroker.GetHash(ctx, path, input)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
@@ -4352,6 +4995,58 @@ func (b *SystemBackend) handleInFlightRequestData(_ context.Context, req *logica
 	return resp, nil
 }
 
+// handleInFlightRequestCancel cancels the context of an in-flight request.
+func (b *SystemBackend) handleInFlightRequestCancel(_ context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	reqID := data.Get("request_id").(string)
+	if reqID == "" {
+		return logical.ErrorResponse("missing request_id"), logical.ErrInvalidRequest
+	}
+	if !b.Core.CancelInFlightRequest(reqID) {
+		return logical.RespondWithStatusCode(logical.ErrorResponse("no in-flight request %q on this node", reqID), req, http.StatusNotFound)
+	}
+	return nil, nil
+}
+
+// handleLastCrashRead returns the request journal of the previous run, if it
+// did not shut down cleanly.
+func (b *SystemBackend) handleLastCrashRead(_ context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	j := b.Core.requestJournal
+	if j == nil {
+		return logical.ErrorResponse("the request journal is not enabled, set request_journal_path in the server configuration"), nil
+	}
+	if j.lastCrash == nil {
+		return nil, nil
+	}
+
+	requests := make([]map[string]interface{}, 0, len(j.lastCrash.Requests))
+	for _, r := range j.lastCrash.Requests {
+		requests = append(requests, map[string]interface{}{
+			"request_id":     r.RequestID,
+			"method":         r.Method,
+			"path":           r.Path,
+			"namespace":      r.Namespace,
+			"entity_id":      r.EntityID,
+			"client_id":      r.ClientID,
+			"client_address": r.ClientAddress,
+			"start_time":     r.StartTime.Format(time.RFC3339Nano),
+			"elapsed_ms":     r.ElapsedMs,
+			"phase":          r.Phase,
+		})
+	}
+
+	data := map[string]interface{}{
+		"reason":     j.lastCrash.Reason,
+		"written_at": j.lastCrash.WrittenAt.Format(time.RFC3339Nano),
+		"started_at": j.lastCrash.StartedAt.Format(time.RFC3339Nano),
+		"version":    j.lastCrash.Version,
+		"requests":   requests,
+	}
+	if j.lastCrash.Panic != "" {
+		data["panic"] = j.lastCrash.Panic
+	}
+	return &logical.Response{Data: data}, nil
+}
+
 func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	ll := data.Get("log_level").(string)
 	w := req.ResponseWriter
@@ -4373,6 +5068,24 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("unknown log format"), nil
 	}
 
+	framing := strings.ToLower(data.Get("framing").(string))
+	switch framing {
+	case monitorFramingNone, monitorFramingJSONL:
+	default:
+		return logical.ErrorResponse("unknown framing"), nil
+	}
+	jsonl := framing == monitorFramingJSONL
+
+	heartbeatInterval := time.Duration(data.Get("heartbeat_interval").(int)) * time.Second
+	if heartbeatInterval < 0 {
+		return logical.ErrorResponse("heartbeat_interval cannot be negative"), nil
+	}
+
+	filter := &monitor.Filter{
+		Subsystems: data.Get("subsystems").([]string),
+		Namespaces: data.Get("namespaces").([]string),
+	}
+
 	flusher, ok := w.ResponseWriter.(http.Flusher)
 	if !ok {
 		// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
@@ -4387,13 +5100,31 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		}
 	}
 
-	isJson := b.Core.LogFormat() == "json" || lf == "json"
+	// Each stream registers its own sink on the logger, so limit their number
+	// to bound the cost of logging under load
+	streams := b.monitorStreams.Add(1)
+	defer b.monitorStreams.Add(-1)
+	if streams > maxMonitorStreams {
+		return logical.RespondWithStatusCode(
+			logical.ErrorResponse("too many concurrent monitor streams, the limit is %d", maxMonitorStreams),
+			req, http.StatusTooManyRequests)
+	}
+
+	// JSONL framing wraps the log messages in JSON, which requires them to be
+	// JSON themselves
+	isJson := b.Core.LogFormat() == "json" || lf == "json" || jsonl
 	logger := b.Core.Logger().(log.InterceptLogger)
 
-	mon, err := monitor.NewMonitor(512, logger, &log.LoggerOptions{
+	monitorOpts := &monitor.Options{Filter: filter}
+	if jsonl {
+		monitorOpts.DroppedMessage = func(count uint32) []byte {
+			return monitorFrame(monitorFrameDropped, map[string]interface{}{"count": count})
+		}
+	}
+	mon, err := monitor.NewMonitorWithOptions(512, logger, &log.LoggerOptions{
 		Level:      logLevel,
 		JSONFormat: isJson,
-	})
+	}, monitorOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -4405,6 +5136,9 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("error trying to start a monitor that's already been started")
 	}
 
+	if jsonl {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
 	w.WriteHeader(http.StatusOK)
 
 	// 0 byte write is needed before the Flush call so that if we are using
@@ -4414,11 +5148,32 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("error seeding flusher: %w", err)
 	}
 
+	if jsonl {
+		_, err = w.Write(monitorFrame(monitorFrameStart, map[string]interface{}{
+			"log_level":      logLevel.String(),
+			"subsystems":     filter.Subsystems,
+			"namespaces":     filter.Namespaces,
+			"active_streams": streams,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("error streaming monitor output: %w", err)
+		}
+	}
+
 	flusher.Flush()
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	// Heartbeats let clients of JSONL streams tell a quiet stream from a
+	// broken connection
+	var heartbeatCh <-chan time.Time
+	if jsonl && heartbeatInterval > 0 {
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+		heartbeatCh = heartbeat.C
+	}
+
 	// Stream logs until the connection is closed.
 	for {
 		select {
@@ -4429,14 +5184,32 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 				// We still return the error, but this will be ignored upstream
 				// due to the fact that we've already sent a response by
 				// writing the header and flushing the writer above.
-				_, err = fmt.Fprint(w, "core received sealed state change, ending monitor session")
+				msg := []byte("core received sealed state change, ending monitor session")
+				if jsonl {
+					msg = monitorFrame(monitorFrameSealed, nil)
+				}
+				_, err = w.Write(msg)
 				if err != nil {
 					return nil, fmt.Errorf("error checking seal state: %w", err)
 				}
 			}
+		case t := <-heartbeatCh:
+			_, err = w.Write(monitorFrame(monitorFrameHeartbeat, map[string]interface{}{
+				"time": t.UTC().Format(time.RFC3339Nano),
+			}))
+			if err != nil {
+				return nil, fmt.Errorf("error streaming monitor output: %w", err)
+			}
+
+			flusher.Flush()
 		case <-ctx.Done():
 			return nil, nil
 		case l := <-logCh:
+			// Dropped message frames are already framed by the monitor
+			if jsonl && !bytes.HasPrefix(l, monitorFramePrefix) {
+				l = monitorLogFrame(l)
+			}
+
 			// We still return the error, but this will be ignored upstream
 			// due to the fact that we've already sent a response by
 			// writing the header and flushing the writer above.
@@ -4450,6 +5223,46 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 	}
 }
 
+const (
+	// maxMonitorStreams is the maximum number of concurrent sys/monitor
+	// streams on a node.
+	maxMonitorStreams = 32
+
+	monitorFramingNone  = "none"
+	monitorFramingJSONL = "jsonl"
+
+	monitorFrameStart     = "start"
+	monitorFrameLog       = "log"
+	monitorFrameDropped   = "dropped"
+	monitorFrameHeartbeat = "heartbeat"
+	monitorFrameSealed    = "sealed"
+)
+
+// monitorFramePrefix starts all the JSONL frames of sys/monitor, and none of
+// the log messages, which are JSON objects keyed by "@level" first.
+var monitorFramePrefix = []byte(`{"type":`)
+
+// monitorJSONLFrame is a line of a sys/monitor stream using JSONL framing.
+type monitorJSONLFrame struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+func monitorFrame(frameType string, data interface{}) []byte {
+	frame, _ := json.Marshal(&monitorJSONLFrame{Type: frameType, Data: data})
+	return append(frame, '\n')
+}
+
+// monitorLogFrame frames a log message formatted as JSON. Messages which are
+// not valid JSON are framed as strings.
+func monitorLogFrame(l []byte) []byte {
+	l = bytes.TrimSpace(l)
+	if json.Valid(l) {
+		return monitorFrame(monitorFrameLog, json.RawMessage(l))
+	}
+	return monitorFrame(monitorFrameLog, string(l))
+}
+
 // handleHostInfo collects and returns host-related information, which includes
 // system information, cpu, disk, and memory usage. Any capture-related errors
 // returned by the collection method will be returned as response warnings.
@@ -4888,12 +5701,24 @@ func (b *SystemBackend) pathInternalUICustomMessagesCommon(ctx context.Context,
 			"end_time":      endTimeFormatted,
 			"link":          linkFormatted,
 			"options":       message.Options,
+
+			"require_acknowledgment": message.RequireAcknowledgment,
+			"version":                message.Version,
 		}
 	}
 
 	return logical.ListResponseWithInfo(keys, keyInfo), nil
 }
 
+// pathInternalClientHintsRead returns the client behavior defaults advertised
+// to API clients. This is an unauthenticated endpoint so that clients can
+// configure themselves before logging in.
+func (b *SystemBackend) pathInternalClientHintsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: clientHintsResponseData(b.Core.ClientHints()),
+	}, nil
+}
+
 func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -5243,6 +6068,23 @@ func (b *SystemBackend) pathInternalUIResultantACL(ctx context.Context, req *log
 	acl.exactRules.Walk(exactWalkFn)
 	acl.prefixRules.Walk(globWalkFn)
 
+	if d.Get("sensitivity").(bool) {
+		catalog, err := b.pathCatalog(ctx, req, false)
+		if err != nil {
+			return nil, err
+		}
+		for path, res := range exact {
+			if sensitivity := catalogSensitivity(catalog, path, false); sensitivity != "" {
+				res.(map[string]interface{})["sensitivity"] = string(sensitivity)
+			}
+		}
+		for path, res := range glob {
+			if sensitivity := catalogSensitivity(catalog, path, true); sensitivity != "" {
+				res.(map[string]interface{})["sensitivity"] = string(sensitivity)
+			}
+		}
+	}
+
 	resp.Data["exact_paths"] = exact
 	resp.Data["glob_paths"] = glob
 
@@ -5262,10 +6104,25 @@ func (b *SystemBackend) pathInternalUIVersion(ctx context.Context, req *logical.
 }
 
 func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	buf, cacheKey, err := b.openAPIDocument(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+	return openAPIResponse(buf, cacheKey), nil
+}
+
+// openAPIDocument generates the OpenAPI document of the paths of the mounts
+// visible to the caller, and returns it encoded along with its cache key.
+func (b *SystemBackend) openAPIDocument(ctx context.Context, req *logical.Request, d *framework.FieldData) ([]byte, string, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Limit output to authorized paths
 	resp, err := b.pathInternalUIMountsRead(ctx, req, d)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	context := d.Get("context").(string)
@@ -5286,6 +6143,20 @@ func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Re
 	// each of those APIs.
 	genericMountPaths, _ := d.Get("generic_mount_paths").(bool)
 
+	// Namespace paths prefix the paths with the path of the namespace of the
+	// request, so that they can be called without the namespace header.
+	var namespacePrefix string
+	if namespacePaths, _ := d.Get("namespace_paths").(bool); namespacePaths {
+		namespacePrefix = ns.Path
+	}
+
+	// The document only depends on the mounts the caller can see and on the
+	// parameters, so callers with access to the same mounts share it.
+	cacheKey := b.openAPICacheKey(ctx, ns, resp.Data, context, genericMountPaths, namespacePrefix)
+	if cached, ok := b.openAPICache.Get(cacheKey); ok {
+		return cached.([]byte), cacheKey, nil
+	}
+
 	procMountGroup := func(group, mountPrefix string) error {
 		for mount, entry := range resp.Data[group].(map[string]interface{}) {
 
@@ -5387,7 +6258,7 @@ func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Re
 					})
 				}
 
-				doc.Paths["/"+mountPrefix+mountForOpenAPI+path] = obj
+				doc.Paths["/"+namespacePrefix+mountPrefix+mountForOpenAPI+path] = obj
 			}
 
 			// Merge backend schema components
@@ -5399,10 +6270,10 @@ func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Re
 	}
 
 	if err := procMountGroup("secret", ""); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if err := procMountGroup("auth", "auth/"); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	doc.CreateOperationIDs(context)
@@ -5417,18 +6288,52 @@ func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Re
 
 	buf, err := json.Marshal(doc)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	b.openAPICache.Add(cacheKey, buf)
+
+	return buf, cacheKey, nil
+}
+
+// openAPICacheKey hashes what the OpenAPI document is generated from: the
+// Vault version, the namespace, the parameters and the mounts visible to the
+// caller, along with the plugin versions serving them.
+func (b *SystemBackend) openAPICacheKey(ctx context.Context, ns *namespace.Namespace, mounts map[string]interface{}, operationContext string, genericMountPaths bool, namespacePrefix string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%t\n%s\n", version.GetVersion().FullVersionNumber(false), ns.ID, operationContext, genericMountPaths, namespacePrefix)
+
+	for _, group := range []string{"secret", "auth"} {
+		groupMounts, _ := mounts[group].(map[string]interface{})
+		paths := make([]string, 0, len(groupMounts))
+		for path := range groupMounts {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		prefix := ""
+		if group == "auth" {
+			prefix = "auth/"
+		}
+		for _, path := range paths {
+			fmt.Fprintf(h, "%s\n%s", group, path)
+			if entry := b.Core.router.MatchingMountEntry(ctx, prefix+path); entry != nil {
+				fmt.Fprintf(h, "\n%s\n%s\n%s\n%s", entry.UUID, entry.Type, entry.RunningVersion, entry.RunningSha256)
+			}
+			h.Write([]byte{'\n'})
+		}
 	}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	resp = &logical.Response{
+func openAPIResponse(buf []byte, cacheKey string) *logical.Response {
+	return &logical.Response{
 		Data: map[string]interface{}{
 			logical.HTTPStatusCode:  200,
 			logical.HTTPRawBody:     buf,
 			logical.HTTPContentType: "application/json",
+			logical.HTTPETagHeader:  `"` + cacheKey + `"`,
 		},
 	}
-
-	return resp, nil
 }
 
 type SealStatusResponse struct {
@@ -5898,11 +6803,16 @@ func (b *SystemBackend) handleLoggersDelete(ctx context.Context, req *logical.Re
 		return logical.ErrorResponse(fmt.Sprintf("log level from config is invalid: %s", err.Error())), nil
 	}
 
+	b.Core.ClearLoggerOverrides("")
 	b.Core.SetLogLevel(level)
 
 	return nil, nil
 }
 
+func (b *SystemBackend) handleLoggerOverridesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponseWithInfo(b.Core.loggerOverridesInfo()), nil
+}
+
 func (b *SystemBackend) handleLoggersByNameRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	nameRaw, nameOk := d.GetOk("name")
 	if !nameOk {
@@ -5914,6 +6824,11 @@ func (b *SystemBackend) handleLoggersByNameRead(ctx context.Context, req *logica
 		return logical.ErrorResponse("name is empty"), nil
 	}
 
+	name, err := b.Core.resolveLoggerName(ctx, name)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	b.Core.allLoggersLock.RLock()
 	defer b.Core.allLoggersLock.RUnlock()
 
@@ -5976,9 +6891,19 @@ func (b *SystemBackend) handleLoggersByNameWrite(ctx context.Context, req *logic
 		return logical.ErrorResponse(fmt.Sprintf("invalid level provided: %s", err.Error())), nil
 	}
 
-	success := b.Core.SetLogLevelByName(name, level)
-	if !success {
-		return logical.ErrorResponse(fmt.Sprintf("logger %q not found", name)), nil
+	name, err = b.Core.resolveLoggerName(ctx, name)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	sink := d.Get("sink").(string)
+	duration := time.Duration(d.Get("duration").(int)) * time.Second
+	if duration < 0 {
+		return logical.ErrorResponse("duration cannot be negative"), nil
+	}
+
+	if err := b.Core.SetLoggerOverride(name, level, sink, duration); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
 	}
 
 	return nil, nil
@@ -6000,6 +6925,12 @@ func (b *SystemBackend) handleLoggersByNameDelete(ctx context.Context, req *logi
 		return logical.ErrorResponse("name is empty"), nil
 	}
 
+	name, err = b.Core.resolveLoggerName(ctx, name)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	b.Core.ClearLoggerOverrides(name)
 	success := b.Core.SetLogLevelByName(name, level)
 	if !success {
 		return logical.ErrorResponse(fmt.Sprintf("logger %q not found", name)), nil
@@ -6126,6 +7057,23 @@ The path responds to the following HTTP methods.
         Sets the license for the server
 	`,
 	},
+	"config/client-hints": {
+		"Configures or returns the client hints advertised to API clients.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the client hints configuration.
+
+    POST /
+        Sets the hedging and circuit breaker defaults advertised to API
+        clients at sys/internal/client-hints.
+
+    DELETE /
+        Resets the client hints to their defaults.
+		`,
+	},
+
 	"config/cors": {
 		"Configures or returns the current configuration of CORS settings.",
 		`
@@ -6139,6 +7087,34 @@ This path responds to the following HTTP methods.
 
     DELETE /
         Clears the CORS configuration and disables acceptance of CORS requests.
+
+The CORS policies of namespaces, configured at sys/config/cors/namespaces,
+and of listeners take precedence over this configuration.
+		`,
+	},
+	"config/cors/namespaces": {
+		"Configures or returns the CORS policies of namespaces.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        Returns the paths of the namespaces which have a CORS policy.
+
+    GET /<namespace>
+        Returns the CORS policy of the namespace.
+
+    POST /<namespace>
+        Sets the origins, headers and preflight max age of the CORS policy of
+        the namespace.
+
+    DELETE /<namespace>
+        Removes the CORS policy of the namespace.
+
+The CORS policy of a namespace applies to requests to the namespace and its
+children, and takes precedence over the CORS policy of the listener and the
+global CORS configuration. Browsers do not send the X-Vault-Namespace header
+on preflight requests, so cross-origin clients of a namespace should include
+the namespace in the request path.
 		`,
 	},
 	"config/group-policy-application": {
@@ -6307,6 +7283,10 @@ in the plugin catalog.`,
 		`Whether to turn on seal wrapping for the mount.`,
 	},
 
+	"mount_key": {
+		`Whether to encrypt the data of the mount with a key of its own, which is destroyed when the mount is disabled.`,
+	},
+
 	"external_entropy_access": {
 		`Whether to give the mount access to Vault's external entropy.`,
 	},
@@ -6681,7 +7661,9 @@ Enable a new audit backend or disable an existing backend.
 	"capabilities_self": {
 		"Fetches the capabilities of the given token on the given path.",
 		`Returns the capabilities of the client token on the path.
-		The path will be searched for a path match in all the policies associated with the client token.`,
+		The path will be searched for a path match in all the policies associated with the client token.
+		If 'explain' is set, the policy rule matching each path and the policies granting or denying
+		its capabilities are returned under 'explanations'.`,
 	},
 
 	"capabilities_accessor": {
@@ -6699,6 +7681,20 @@ necessary, and is only required if upgrade notes or support personnel suggest
 it.`,
 	},
 
+	"tidy_operations": {
+		"Lists the tidy operations.",
+		`Lists the tidy operations of the namespace which are queued, running, or
+recently finished, with their progress. Tidy operations are run by a scheduler
+which limits how many of them run at once, and holds them to a shared budget
+of storage operations per second.`,
+	},
+
+	"tidy_operation": {
+		"Reads or cancels a tidy operation.",
+		`Reads the progress of a tidy operation. Deleting a queued or running tidy
+operation cancels it, stopping it before its next entry.`,
+	},
+
 	"wrap": {
 		"Response-wraps an arbitrary JSON object.",
 		`Round trips the given input data into a response-wrapped token.`,
@@ -6886,6 +7882,72 @@ This path responds to the following HTTP methods.
 		"The name of the key used to sign plugin identity tokens. Defaults to the default key.",
 		"",
 	},
+	"strict_field_validation": {
+		`Whether requests to the mount fail when they have fields which are not in
+the schema of the path or which cannot be converted to their type, instead of
+ignoring them.`,
+		"",
+	},
+	"compatibility_level": {
+		`The compatibility level, a version of the backend, which the responses of
+the mount are pinned to. Response fields added or renamed by the backend after
+that level are omitted or returned under their previous name. An empty value
+unpins the mount.`,
+		"",
+	},
+	"custom_response_headers": {
+		`Headers set on the responses of the mount, as a map of header names to
+values. Values may use identity templates, such as
+{{identity.entity.metadata.team}}, and the {{namespace.id}} and
+{{namespace.path}} templates of the namespace of the request. Headers whose
+templates cannot be resolved for a request are not set. An empty map removes
+the headers.`,
+		"",
+	},
+	"max_child_token_depth": {
+		`The number of levels of child tokens which the tokens issued by an auth
+mount may create below them. Zero forbids child tokens, and -1 removes the
+restriction. The restriction is fixed on each token when it is issued.`,
+		"",
+	},
+	"max_entity_tokens": {
+		`The number of valid service tokens which an auth mount may issue to each
+entity. Zero removes the limit.`,
+		"",
+	},
+	"entity_token_limit_action": {
+		`What happens on the logins of an entity which has reached the limit of
+tokens of the auth mount: "deny", the default, makes them fail, and
+"evict_oldest" revokes the oldest tokens of the entity.`,
+		"",
+	},
+	"login_response_entity_metadata": {
+		`The keys of the entity metadata included in the login responses of an
+auth mount, or "*" to include all of it.`,
+		"",
+	},
+	"login_response_group_names": {
+		`Whether the login responses of an auth mount include the names of the
+groups of the entity in the namespace of the login.`,
+		"",
+	},
+	"login_response_namespace": {
+		`Whether the login responses of an auth mount include the ID and path of
+the namespace of the login.`,
+		"",
+	},
+	"login_response_roles": {
+		`The identity details included in the login responses to given roles of an
+auth mount, by role name, overriding those of the mount. Each role takes
+"entity_metadata", "group_names" and "namespace".`,
+		"",
+	},
+	"client_count_simulation_period": {
+		`The period for which the clients of the tokens issued by an auth mount are
+recorded without being counted toward the client count. A new period restarts
+the simulation from scratch, and 0 ends a running simulation.`,
+		"",
+	},
 	"leases": {
 		`View or list lease metadata.`,
 		`
@@ -6976,6 +8038,26 @@ This path responds to the following HTTP methods.
 		"Write, Read, and Delete data directly in the Storage backend.",
 		"",
 	},
+	"internal-client-hints": {
+		"Client behavior defaults advertised to API clients. Internal API; its location, inputs, and outputs may change.",
+		"",
+	},
+	"deprecations/usage": {
+		"Report the usage of deprecated builtins and endpoints.",
+		`Report the requests served by deprecated builtins and by deprecated
+		endpoints, per namespace and mount, with the number of requests each entity
+		made, and for endpoints, what replaces them and when they are removed. Use
+		it to know which clients break before denying pending removal builtins or
+		removing endpoints. Usage is counted in memory by each node, so read it
+		from every node; a delete resets it.`,
+	},
+	"internal-specs-clients": {
+		"Generate a manifest of the endpoints of all mounted paths, for generating typed clients.",
+		`Generate a machine-readable manifest of the endpoints of the mounts visible to
+		the client token, with their operations, parameters, and deprecations. The
+		manifest is derived from the OpenAPI document, and is consumed by the code
+		generator of the api package to produce typed clients of the cluster.`,
+	},
 	"internal-ui-feature-flags": {
 		"Enabled feature flags. Internal API; its location, inputs, and outputs may change.",
 		"",
@@ -6998,12 +8080,40 @@ This path responds to the following HTTP methods.
 		"Export the metrics aggregated for telemetry purpose.",
 		"",
 	},
+	"diagnostics/last-crash": {
+		"Report the requests in flight when the previous run of this node died.",
+		`Report the request journal of the previous run of this node, if it panicked,
+		was killed, or shut down with requests in flight, with the method, path,
+		namespace, entity, and elapsed time of each request. The journal is
+		persisted to request_journal_path on shutdown, when a request handler
+		panics, and every few seconds, and is local to each node. Panics of
+		background workers are reported as unclean exits.`,
+	},
 	"in-flight-req": {
 		"reports in-flight requests",
 		`
 This path responds to the following HTTP methods.
 		GET /
-			Returns a map of in-flight requests.
+			Returns a map of in-flight requests, with their namespace, entity,
+			elapsed time and current phase: routing, backend or storage.
+		`,
+	},
+	"in-flight-req-cancel": {
+		"Cancel an in-flight request.",
+		`
+Cancels the context of an in-flight request of this node, identified by its key
+in the in-flight requests map, so that a request stuck in a backend or in
+storage returns. Use it to unstick an active node without restarting it. The
+backend or storage may not observe the cancellation immediately.
+		`,
+	},
+	"monitor": {
+		"Stream the logs of this node.",
+		`
+Streams the logs of this node at the requested level, without changing the
+level of the logger. Logs can be filtered by subsystem and by namespace, and
+streamed as JSON lines with heartbeats, for tools consuming the stream. Several
+streams, each with their own filters, can be open at once.
 		`,
 	},
 	"internal-counters-requests": {
@@ -7040,10 +8150,101 @@ This path responds to the following HTTP methods.
 		"Export the historical activity of clients.",
 		"Export the historical activity of clients.",
 	},
+	"activity-forecast": {
+		"Project the client count at the end of the contract period.",
+		`
+Fits a linear trend to the client counts of the last complete months, and
+projects the clients of the contract period: the clients already counted
+since the start of the contract, plus the new clients projected for each
+remaining month. Projections come with 95% prediction intervals, which
+widen as the fitted history gets noisier or shorter.
+		`,
+	},
+	"activity-client": {
+		"Report the activity history of a client.",
+		`
+Reports the months a client was counted in, within the retention period,
+with the namespace and mount it was first seen on each month, to explain
+why a client such as an entity is counted. The history is indexed when the
+clients are written to the activity log segments, so the clients of the
+current month appear once their segment is written.
+		`,
+	},
+	"activity-mounts": {
+		"Report the historical client counts of each mount.",
+		`
+Reports, for each complete month of the period, the clients and new clients
+of each mount, along with the new clients of the mount over the period. New
+clients are the clients first seen in the period, in that month, on the
+mount. Mounts are identified by accessor, so they are reported under their
+current path even if they were moved. The months precomputed before mounts
+were recorded by accessor assume that the mount currently at the recorded
+path is the same mount.
+		`,
+	},
+	"cost-config": {
+		"Control the accounting of request costs.",
+		`
+Enables or disables the accounting of request costs, and sets how the cost of
+a request is computed: the weight of its operation, plus a weight for every
+unit of its payload. Costs are attributed to the namespace and entity of the
+request. Changes apply to the requests made afterwards.
+		`,
+	},
+	"cost-export": {
+		"Export the monthly request costs for chargeback.",
+		`
+Exports, for each month of the period, the number of requests and their cost
+per namespace and entity, along with the client count of the namespace that
+month, as a CSV file or a JSON file with an object per line. Requests made
+without an entity have an empty entity ID. Costs are accounted by the active
+node.
+		`,
+	},
+	"activity-report": {
+		"Generate a usage report of the historical count of clients.",
+		`
+Generates a usage report of the period as an HTML or PDF document, for
+sharing with people without access to Vault. The report contains the total
+client counts, the clients and new clients of each month with the change
+from the previous month, the mounts contributing the most new clients, and
+the client counts of each namespace and mount.
+		`,
+	},
+	"activity-simulation": {
+		"Report the projected client count of auth mounts in client count simulation.",
+		`
+For each auth mount with a client count simulation, reports the number of
+distinct clients recorded during the simulation, and how many of them have
+not been counted this month. The latter is the projected increase of the
+client count had the clients of the mount been counted.
+		`,
+	},
 	"activity-monthly": {
 		"Count of active clients so far this month.",
 		"Count of active clients so far this month.",
 	},
+	"activity-accessor-mappings": {
+		"Map the accessors of replaced auth mounts to the mounts replacing them.",
+		`
+Maps the accessor of an auth mount which was remounted or recreated to the
+accessor of the mount replacing it. Until the mapping expires, by default at
+the end of the current billing period, an entity logging in through the new
+mount is counted as the entity of its alias on the old mount, if there is one,
+so that the same users are not counted as new clients.
+		`,
+	},
+	"activity-baselines": {
+		"Record client counts supplied from outside of this cluster.",
+		`
+Records baseline client counts supplied from outside of the activity log of
+this cluster, such as the counts of a cluster merged into this one, for billing
+to remain accurate after a migration. The counts of the baselines overlapping
+the queried period are added to the totals of the client count queries, which
+list the baselines along with their source, description, and the token which
+recorded them.
+		`,
+	},
 	"activity-config": {
 		"Control the collection and reporting of client counts.",
 		"Control the collection and reporting of client counts.",
@@ -5,14 +5,17 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/timeutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -47,13 +50,18 @@ func (b *SystemBackend) activityQueryPath() *framework.Path {
 				Default:     0,
 				Description: "Limit query output by namespaces",
 			},
+			"granularity": {
+				Type:        framework.TypeString,
+				Description: "Granularity of the breakdown of the clients over time. Either \"month\" or \"day\", which also reports the clients of each day within the daily retention window.",
+				Default:     "month",
+			},
 		},
 		HelpSynopsis:    strings.TrimSpace(sysHelp["activity-query"][0]),
 		HelpDescription: strings.TrimSpace(sysHelp["activity-query"][1]),
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
-				Callback: b.handleClientMetricQuery,
+				Callback: b.Core.cachedRead(responseCacheActivity, b.handleClientMetricQuery),
 				Summary:  "Report the client count metrics, for this namespace and all child namespaces.",
 			},
 		},
@@ -75,7 +83,7 @@ func (b *SystemBackend) monthlyActivityCountPath() *framework.Path {
 		HelpDescription: strings.TrimSpace(sysHelp["activity-monthly"][1]),
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
-				Callback: b.handleMonthlyActivityCount,
+				Callback: b.Core.cachedRead(responseCacheActivityMonthly, b.handleMonthlyActivityCount),
 				Summary:  "Report the number of clients for this month, for this namespace and all child namespaces.",
 			},
 		},
@@ -117,6 +125,38 @@ func (b *SystemBackend) rootActivityPaths() []*framework.Path {
 					Default:     "default",
 					Description: "Enable or disable collection of client count: enable, disable, or default.",
 				},
+				"excluded_mount_accessors": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Accessors of the auth mounts whose clients are excluded from billing.",
+				},
+				"excluded_entity_metadata": {
+					Type:        framework.TypeKVPairs,
+					Description: "Entity metadata key/value pairs; entities with any of them are excluded from billing.",
+				},
+				"excluded_policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Policies whose tokens' clients are excluded from billing.",
+				},
+				"max_tracked_clients": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of clients of the current month tracked in memory; the clients beyond are spilled to storage. 0 is unbounded.",
+				},
+				"daily_retention_days": {
+					Type:        framework.TypeInt,
+					Description: "Number of days the daily rollups of the clients are kept for. 0 disables daily rollups.",
+				},
+				"month_close_webhook_url": {
+					Type:        framework.TypeString,
+					Description: "URL POSTed the totals of each month, per namespace, once its queries are finalized.",
+				},
+				"month_close_event": {
+					Type:        framework.TypeBool,
+					Description: "Send the totals of each month, per namespace, as events once its queries are finalized.",
+				},
+				"time_zone": {
+					Type:        framework.TypeString,
+					Description: "IANA time zone, such as America/New_York, the boundaries of the months are computed in. Takes effect from the next month. Defaults to UTC.",
+				},
 			},
 			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-config"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["activity-config"][1]),
@@ -160,6 +200,18 @@ func (b *SystemBackend) rootActivityPaths() []*framework.Path {
 					Description: "Format of the file. Either a CSV or a JSON file with an object per line.",
 					Default:     "json",
 				},
+				"namespace": {
+					Type:        framework.TypeString,
+					Description: "Path of the namespace whose clients, and the clients of its child namespaces, are exported. Defaults to all the namespaces.",
+				},
+				"client_type": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Types of the clients to export: entity, non-entity, acme, secret-sync or excluded. Defaults to all the types.",
+				},
+				"mount_accessor": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Accessors of the mounts whose clients are exported. Defaults to all the mounts.",
+				},
 			},
 
 			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-export"][0]),
@@ -172,13 +224,323 @@ func (b *SystemBackend) rootActivityPaths() []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "internal/counters/activity/forecast$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationVerb:   "forecast",
+				OperationSuffix: "usage",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"history_months": {
+					Type:        framework.TypeInt,
+					Description: "Number of complete months of client counts to fit the forecast on.",
+					Default:     activityForecastDefaultHistoryMonths,
+				},
+				"contract_start_time": {
+					Type:        framework.TypeTime,
+					Description: "Start of the contract period. Defaults to the billing start time, or the start of the default reporting period.",
+				},
+				"contract_end_time": {
+					Type:        framework.TypeTime,
+					Description: "End of the contract period. Defaults to a year after its start.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-forecast"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-forecast"][1]),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleActivityForecast,
+					Summary:  "Project the client count at the end of the contract period.",
+				},
+			},
+		},
+		{
+			Pattern: "internal/counters/activity/report$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationVerb:   "generate",
+				OperationSuffix: "usage-report",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"start_time": {
+					Type:        framework.TypeTime,
+					Description: "Start of report interval",
+				},
+				"end_time": {
+					Type:        framework.TypeTime,
+					Description: "End of report interval",
+				},
+				"format": {
+					Type:        framework.TypeString,
+					Description: "Format of the report. Either \"html\" or \"pdf\".",
+					Default:     usageReportFormatHTML,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-report"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-report"][1]),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleActivityReport,
+					Summary:  "Generate a usage report of the client counts, for sharing outside of Vault.",
+				},
+			},
+		},
 	}
+	paths = append(paths, b.activitySimulationPath(), b.activityClientPath(), b.activityMountsPath())
+	paths = append(paths, b.activityBaselinePaths()...)
+	paths = append(paths, b.activityAccessorMappingPaths()...)
+	paths = append(paths, b.meteringPaths()...)
 	if writePath := b.activityWritePath(); writePath != nil {
 		paths = append(paths, writePath)
 	}
 	return paths
 }
 
+// activitySimulationPath is available only in the root namespace
+func (b *SystemBackend) activitySimulationPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "internal/counters/simulation$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "internal-client-activity",
+			OperationVerb:   "report",
+			OperationSuffix: "simulated-counts",
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["activity-simulation"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["activity-simulation"][1]),
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleActivitySimulationRead,
+				Summary:  "Report the projected client count of auth mounts in client count simulation.",
+			},
+		},
+	}
+}
+
+// activityClientPath is available only in the root namespace
+func (b *SystemBackend) activityClientPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "internal/counters/activity/client/(?P<client_id>.+)$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "internal-client-activity",
+			OperationVerb:   "read",
+			OperationSuffix: "client-history",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"client_id": {
+				Type:        framework.TypeString,
+				Description: "ID of the client, such as an entity ID.",
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["activity-client"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["activity-client"][1]),
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleActivityClientRead,
+				Summary:  "Report the months a client was counted in, and the namespaces and mounts it was seen on.",
+			},
+		},
+	}
+}
+
+// activityMountsPath is available only in the root namespace
+func (b *SystemBackend) activityMountsPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "internal/counters/activity/mounts$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "internal-client-activity",
+			OperationVerb:   "report",
+			OperationSuffix: "mount-history",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"start_time": {
+				Type:        framework.TypeTime,
+				Description: "Start of query interval",
+			},
+			"end_time": {
+				Type:        framework.TypeTime,
+				Description: "End of query interval",
+			},
+			"mount_accessor": {
+				Type:        framework.TypeString,
+				Description: "Accessor of the mount to report on. Defaults to all the mounts.",
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["activity-mounts"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["activity-mounts"][1]),
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleActivityMountsRead,
+				Summary:  "Report the clients and new clients of each mount in each month.",
+			},
+		},
+	}
+}
+
+// activityBaselinePaths are available only in the root namespace
+func (b *SystemBackend) activityBaselinePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "internal/counters/baselines/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationVerb:   "list",
+				OperationSuffix: "baselines",
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-baselines"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-baselines"][1]),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleActivityBaselineList,
+					Summary:  "List the externally supplied client count baselines.",
+				},
+			},
+		},
+		{
+			Pattern: "internal/counters/baselines/" + framework.GenericNameRegex("name") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationSuffix: "baseline",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the baseline.",
+				},
+				"source": {
+					Type:        framework.TypeString,
+					Description: "Where the counts come from, such as the cluster merged into this one.",
+				},
+				"description": {
+					Type:        framework.TypeString,
+					Description: "Description of the counts.",
+				},
+				"start_time": {
+					Type:        framework.TypeTime,
+					Description: "Start of the period the counts apply to.",
+				},
+				"end_time": {
+					Type:        framework.TypeTime,
+					Description: "End of the period the counts apply to.",
+				},
+				"entity_clients": {
+					Type:        framework.TypeInt,
+					Description: "Number of entity clients.",
+				},
+				"non_entity_clients": {
+					Type:        framework.TypeInt,
+					Description: "Number of non-entity clients.",
+				},
+				"secret_syncs": {
+					Type:        framework.TypeInt,
+					Description: "Number of secret sync clients.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-baselines"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-baselines"][1]),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleActivityBaselineRead,
+					Summary:  "Read an externally supplied client count baseline.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleActivityBaselineUpdate,
+					Summary:  "Record client counts supplied from outside of this cluster, added to the reported totals.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleActivityBaselineDelete,
+					Summary:  "Delete an externally supplied client count baseline.",
+				},
+			},
+		},
+	}
+}
+
+// activityAccessorMappingPaths are available only in the root namespace
+func (b *SystemBackend) activityAccessorMappingPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "internal/counters/accessor-mappings/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationVerb:   "list",
+				OperationSuffix: "accessor-mappings",
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-accessor-mappings"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-accessor-mappings"][1]),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleActivityAccessorMappingList,
+					Summary:  "List the accessors of the auth mounts mapped to the mounts replacing them.",
+				},
+			},
+		},
+		{
+			Pattern: "internal/counters/accessor-mappings/" + framework.GenericNameRegex("old_accessor") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-client-activity",
+				OperationSuffix: "accessor-mapping",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"old_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth mount which was replaced.",
+				},
+				"new_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth mount replacing it.",
+				},
+				"expiration_time": {
+					Type:        framework.TypeTime,
+					Description: "Time until which the mapping applies. Defaults to the end of the current billing period.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-accessor-mappings"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-accessor-mappings"][1]),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleActivityAccessorMappingRead,
+					Summary:  "Read the mapping of the accessor of a replaced auth mount.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleActivityAccessorMappingUpdate,
+					Summary:  "Map the accessor of a replaced auth mount to the mount replacing it, for its clients not to be counted again.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleActivityAccessorMappingDelete,
+					Summary:  "Delete the mapping of the accessor of a replaced auth mount.",
+				},
+			},
+		},
+	}
+}
+
 func parseStartEndTimes(a *ActivityLog, d *framework.FieldData) (time.Time, time.Time, error) {
 	startTime := d.Get("start_time").(time.Time)
 	endTime := d.Get("end_time").(time.Time)
@@ -187,17 +549,18 @@ func parseStartEndTimes(a *ActivityLog, d *framework.FieldData) (time.Time, time
 	// otherwise we want to give the latest N months, so go back to the start
 	// of the previous month
 	//
-	// Also convert any user inputs to UTC to avoid
-	// problems later.
+	// Also convert any user inputs to the time zone the months are
+	// computed in to avoid problems later.
+	loc := a.location()
 	if endTime.IsZero() {
-		endTime = timeutil.EndOfMonth(timeutil.StartOfPreviousMonth(time.Now().UTC()))
+		endTime = timeutil.EndOfMonth(timeutil.StartOfPreviousMonth(time.Now().In(loc)))
 	} else {
-		endTime = endTime.UTC()
+		endTime = endTime.In(loc)
 	}
 	if startTime.IsZero() {
 		startTime = a.DefaultStartTime(endTime)
 	} else {
-		startTime = startTime.UTC()
+		startTime = startTime.In(loc)
 	}
 	if startTime.After(endTime) {
 		return time.Time{}, time.Time{}, fmt.Errorf("start_time is later than end_time")
@@ -220,6 +583,19 @@ func (b *SystemBackend) handleClientExport(ctx context.Context, req *logical.Req
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
+	clientTypes, err := parseActivityExportClientTypes(d.Get("client_type").([]string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	filter := activityExportFilter{
+		NamespacePath:  d.Get("namespace").(string),
+		ClientTypes:    clientTypes,
+		MountAccessors: d.Get("mount_accessor").([]string),
+	}
+	if filter.NamespacePath != "" && b.Core.namespaceByPath(namespace.Canonicalize(filter.NamespacePath)) == nil {
+		return logical.ErrorResponse("namespace %q not found", filter.NamespacePath), logical.ErrInvalidRequest
+	}
+
 	// This is to avoid the default 90s context timeout.
 	timeout := 10 * time.Minute
 	if durationRaw := os.Getenv("VAULT_ACTIVITY_EXPORT_DURATION"); durationRaw != "" {
@@ -232,7 +608,19 @@ func (b *SystemBackend) handleClientExport(ctx context.Context, req *logical.Req
 	runCtx, cancelFunc := context.WithTimeout(b.Core.activeContext, timeout)
 	defer cancelFunc()
 
-	err = a.writeExport(runCtx, req.ResponseWriter, d.Get("format").(string), startTime, endTime)
+	// For capacity reasons only allow a single export at a time, even though
+	// exports are offloaded to standbys when possible.
+	if !a.inprocessExport.CAS(false, true) {
+		return nil, fmt.Errorf("existing export in progress")
+	}
+	defer a.inprocessExport.Store(false)
+
+	err = b.Core.runOffloadableTask(runCtx, offloadTaskActivityExport, &activityExportInput{
+		Format:    d.Get("format").(string),
+		StartTime: startTime,
+		EndTime:   endTime,
+		Filter:    filter,
+	}, req.ResponseWriter)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +639,7 @@ func (b *SystemBackend) handleClientMetricQuery(ctx context.Context, req *logica
 
 	if d.Get("current_billing_period").(bool) {
 		startTime = b.Core.BillingStart()
-		endTime = time.Now().UTC()
+		endTime = time.Now().In(a.location())
 	} else {
 		var err error
 		startTime, endTime, err = parseStartEndTimes(a, d)
@@ -265,10 +653,34 @@ func (b *SystemBackend) handleClientMetricQuery(ctx context.Context, req *logica
 		limitNamespaces = limitNamespacesRaw.(int)
 	}
 
+	granularity := d.Get("granularity").(string)
+	switch granularity {
+	case "month", "day":
+	default:
+		return logical.ErrorResponse("granularity must be one of \"month\", \"day\""), logical.ErrInvalidRequest
+	}
+
 	results, err := a.handleQuery(ctx, startTime, endTime, limitNamespaces)
 	if err != nil {
 		return nil, err
 	}
+
+	if granularity == "day" {
+		days, err := a.dailyActivity(ctx, startTime, endTime)
+		if errors.Is(err, errDailyRollupsDisabled) {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		if err != nil {
+			return nil, err
+		}
+		if results == nil && len(days) > 0 {
+			results = make(map[string]interface{})
+		}
+		if results != nil {
+			results["days"] = days
+		}
+	}
+
 	if results == nil {
 		resp204, err := logical.RespondWithStatusCode(nil, req, http.StatusNoContent)
 		return resp204, err
@@ -279,6 +691,161 @@ func (b *SystemBackend) handleClientMetricQuery(ctx context.Context, req *logica
 	}, nil
 }
 
+func (b *SystemBackend) handleActivityForecast(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	historyMonths := d.Get("history_months").(int)
+	if historyMonths < activityForecastMinMonths {
+		return logical.ErrorResponse("history_months must be at least %d", activityForecastMinMonths), logical.ErrInvalidRequest
+	}
+
+	loc := a.location()
+	now := time.Now().In(loc)
+	contractStart := d.Get("contract_start_time").(time.Time).In(loc)
+	if contractStart.IsZero() {
+		contractStart = b.Core.BillingStart().In(loc)
+	}
+	if contractStart.IsZero() {
+		contractStart = a.DefaultStartTime(now)
+	}
+	contractEnd := d.Get("contract_end_time").(time.Time).In(loc)
+	if contractEnd.IsZero() {
+		contractEnd = timeutil.EndOfMonth(contractStart.AddDate(0, 11, 0))
+	}
+	if !contractEnd.After(now) {
+		return logical.ErrorResponse("contract_end_time must be in the future"), logical.ErrInvalidRequest
+	}
+	if !contractStart.Before(contractEnd) {
+		return logical.ErrorResponse("contract_start_time is later than contract_end_time"), logical.ErrInvalidRequest
+	}
+
+	results, err := a.forecastUsage(ctx, now, contractStart, contractEnd, historyMonths)
+	if errors.Is(err, errInsufficientActivityHistory) {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: results,
+	}, nil
+}
+
+func (b *SystemBackend) handleActivityClientRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	clientID := d.Get("client_id").(string)
+	history, err := a.clientActivityHistory(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, nil
+	}
+
+	months := make([]map[string]interface{}, 0, len(history))
+	for _, entry := range history {
+		months = append(months, map[string]interface{}{
+			"month":          a.monthOf(time.Unix(entry.Month, 0)).Format(time.RFC3339),
+			"first_seen":     time.Unix(entry.Timestamp, 0).In(a.location()).Format(time.RFC3339),
+			"namespace_id":   entry.NamespaceID,
+			"namespace_path": a.namespaceToLabel(ctx, entry.NamespaceID),
+			"mount_accessor": entry.MountAccessor,
+			"mount_path":     a.mountAccessorToMountPath(entry.MountAccessor),
+			"client_type":    entry.ClientType,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"client_id": clientID,
+			"months":    months,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleActivityMountsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	startTime, endTime, err := parseStartEndTimes(a, d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	results, err := a.mountActivityHistory(ctx, startTime, endTime, d.Get("mount_accessor").(string))
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return logical.RespondWithStatusCode(nil, req, http.StatusNoContent)
+	}
+
+	return &logical.Response{
+		Data: results,
+	}, nil
+}
+
+func (b *SystemBackend) handleActivityReport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	format := d.Get("format").(string)
+	if format != usageReportFormatHTML && format != usageReportFormatPDF {
+		return logical.ErrorResponse("invalid format %q, must be \"html\" or \"pdf\"", format), logical.ErrInvalidRequest
+	}
+
+	startTime, endTime, err := parseStartEndTimes(a, d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	report, err := a.buildUsageReport(ctx, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	contentType := "text/html; charset=utf-8"
+	switch format {
+	case usageReportFormatPDF:
+		body = report.renderPDF()
+		contentType = "application/pdf"
+	default:
+		body, err = report.renderHTML()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
 func (b *SystemBackend) handleMonthlyActivityCount(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	b.Core.activityLogLock.RLock()
 	a := b.Core.activityLog
@@ -300,6 +867,50 @@ func (b *SystemBackend) handleMonthlyActivityCount(ctx context.Context, req *log
 	}, nil
 }
 
+func (b *SystemBackend) handleActivitySimulationRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	b.Core.authLock.RLock()
+	var entries []*MountEntry
+	if b.Core.auth != nil {
+		for _, entry := range b.Core.auth.Entries {
+			if !entry.Config.ClientCountSimulationEnd.IsZero() {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	b.Core.authLock.RUnlock()
+
+	now := time.Now()
+	mounts := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		simulated, newClients, err := a.simulatedClientCounts(ctx, entry.Accessor)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, map[string]interface{}{
+			"mount_accessor":        entry.Accessor,
+			"mount_path":            entry.APIPath(),
+			"mount_type":            entry.Type,
+			"simulation_end":        entry.Config.ClientCountSimulationEnd.Format(time.RFC3339),
+			"simulation_active":     entry.Config.clientCountSimulationActive(now),
+			"simulated_clients":     simulated,
+			"projected_new_clients": newClients,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"mounts": mounts,
+		},
+	}, nil
+}
+
 func (b *SystemBackend) handleActivityConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	b.Core.activityLogLock.RLock()
 	a := b.Core.activityLog
@@ -322,7 +933,7 @@ func (b *SystemBackend) handleActivityConfigRead(ctx context.Context, req *logic
 		config.Enabled = activityLogEnabledDefaultValue
 	}
 
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: map[string]interface{}{
 			"default_report_months":    config.DefaultReportMonths,
 			"retention_months":         config.RetentionMonths,
@@ -332,7 +943,36 @@ func (b *SystemBackend) handleActivityConfigRead(ctx context.Context, req *logic
 			"billing_start_timestamp":  b.Core.BillingStart(),
 			"minimum_retention_months": a.configOverrides.MinimumRetentionMonths,
 		},
-	}, nil
+	}
+	if len(config.ExclusionRules.MountAccessors) > 0 {
+		resp.Data["excluded_mount_accessors"] = config.ExclusionRules.MountAccessors
+	}
+	if len(config.ExclusionRules.EntityMetadata) > 0 {
+		resp.Data["excluded_entity_metadata"] = config.ExclusionRules.EntityMetadata
+	}
+	if len(config.ExclusionRules.Policies) > 0 {
+		resp.Data["excluded_policies"] = config.ExclusionRules.Policies
+	}
+	if config.MaxTrackedClients > 0 {
+		resp.Data["max_tracked_clients"] = config.MaxTrackedClients
+	}
+	if config.DailyRetentionDays > 0 {
+		resp.Data["daily_retention_days"] = config.DailyRetentionDays
+	}
+	if config.MonthCloseWebhookURL != "" {
+		resp.Data["month_close_webhook_url"] = config.MonthCloseWebhookURL
+	}
+	resp.Data["month_close_event"] = config.MonthCloseEvent
+	resp.Data["time_zone"] = "UTC"
+	if len(config.TimeZones) > 0 {
+		timeZone := config.TimeZones[len(config.TimeZones)-1]
+		resp.Data["time_zone"] = timeZone.Name
+		if since := time.Unix(timeZone.Since, 0); since.After(a.clock.Now()) {
+			resp.Data["time_zone_effective_time"] = since.UTC().Format(time.RFC3339)
+		}
+	}
+
+	return resp, nil
 }
 
 func (b *SystemBackend) handleActivityConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -404,6 +1044,86 @@ func (b *SystemBackend) handleActivityConfigUpdate(ctx context.Context, req *log
 		}
 	}
 
+	{
+		// Parse the exclusion rules
+		if accessorsRaw, ok := d.GetOk("excluded_mount_accessors"); ok {
+			config.ExclusionRules.MountAccessors = accessorsRaw.([]string)
+		}
+		if metadataRaw, ok := d.GetOk("excluded_entity_metadata"); ok {
+			config.ExclusionRules.EntityMetadata = metadataRaw.(map[string]string)
+		}
+		if policiesRaw, ok := d.GetOk("excluded_policies"); ok {
+			config.ExclusionRules.Policies = policiesRaw.([]string)
+		}
+	}
+
+	{
+		// Parse the in-memory tracking limit
+		if maxTrackedClientsRaw, ok := d.GetOk("max_tracked_clients"); ok {
+			config.MaxTrackedClients = maxTrackedClientsRaw.(int)
+		}
+
+		if config.MaxTrackedClients < 0 {
+			return logical.ErrorResponse("max_tracked_clients must be greater than or equal to 0"), logical.ErrInvalidRequest
+		}
+	}
+
+	{
+		// Parse the daily rollup retention
+		if dailyRetentionDaysRaw, ok := d.GetOk("daily_retention_days"); ok {
+			config.DailyRetentionDays = dailyRetentionDaysRaw.(int)
+		}
+
+		if config.DailyRetentionDays < 0 {
+			return logical.ErrorResponse("daily_retention_days must be greater than or equal to 0"), logical.ErrInvalidRequest
+		}
+
+		if config.DailyRetentionDays > activityDailyMaxRetentionDays {
+			config.DailyRetentionDays = activityDailyMaxRetentionDays
+			warnings = append(warnings, fmt.Sprintf("daily_retention_days cannot be greater than %d; capped to %d.", activityDailyMaxRetentionDays, activityDailyMaxRetentionDays))
+		}
+	}
+
+	{
+		// Parse the month close notifications
+		if webhookURLRaw, ok := d.GetOk("month_close_webhook_url"); ok {
+			config.MonthCloseWebhookURL = strings.TrimSpace(webhookURLRaw.(string))
+		}
+		if config.MonthCloseWebhookURL != "" {
+			u, err := url.Parse(config.MonthCloseWebhookURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return logical.ErrorResponse("month_close_webhook_url must be an absolute http or https URL"), logical.ErrInvalidRequest
+			}
+		}
+		if monthCloseEventRaw, ok := d.GetOk("month_close_event"); ok {
+			config.MonthCloseEvent = monthCloseEventRaw.(bool)
+		}
+	}
+
+	{
+		// Parse the time zone of the months. Months already recorded keep
+		// their boundaries, so the time zone takes effect from the next month.
+		if timeZoneRaw, ok := d.GetOk("time_zone"); ok {
+			timeZone := strings.TrimSpace(timeZoneRaw.(string))
+			if timeZone == "" {
+				timeZone = "UTC"
+			}
+			if timeZone == "Local" {
+				return logical.ErrorResponse("time_zone must be an IANA time zone name"), logical.ErrInvalidRequest
+			}
+			loc, err := time.LoadLocation(timeZone)
+			if err != nil {
+				return logical.ErrorResponse("invalid time_zone %q: %s", timeZone, err), logical.ErrInvalidRequest
+			}
+			config.TimeZones = a.withTimeZone(config.TimeZones, loc)
+			if len(config.TimeZones) > 0 {
+				if since := time.Unix(config.TimeZones[len(config.TimeZones)-1].Since, 0); since.After(a.clock.Now()) {
+					warnings = append(warnings, fmt.Sprintf("time_zone takes effect from the start of the next month, at %s", since.UTC().Format(time.RFC3339)))
+				}
+			}
+		}
+	}
+
 	a.core.activityLogLock.RLock()
 	minimumRetentionMonths := a.configOverrides.MinimumRetentionMonths
 	a.core.activityLogLock.RUnlock()
@@ -441,3 +1161,177 @@ func (b *SystemBackend) handleActivityConfigUpdate(ctx context.Context, req *log
 
 	return nil, nil
 }
+
+func (b *SystemBackend) activityLogForBaselines() (*ActivityLog, *logical.Response) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return nil, logical.ErrorResponse("no activity log present")
+	}
+	return a, nil
+}
+
+func (b *SystemBackend) handleActivityBaselineList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	baselines, err := a.listBaselines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(baselines))
+	for _, baseline := range baselines {
+		names = append(names, baseline.Name)
+	}
+	return logical.ListResponse(names), nil
+}
+
+func (b *SystemBackend) handleActivityBaselineRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	baseline, err := a.getBaseline(ctx, d.Get("name").(string))
+	if err != nil || baseline == nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: baseline.provenance(),
+	}, nil
+}
+
+func (b *SystemBackend) handleActivityBaselineUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+
+	baseline := &activityBaseline{
+		Name:             d.Get("name").(string),
+		Source:           d.Get("source").(string),
+		Description:      d.Get("description").(string),
+		StartTime:        d.Get("start_time").(time.Time).UTC(),
+		EndTime:          d.Get("end_time").(time.Time).UTC(),
+		EntityClients:    d.Get("entity_clients").(int),
+		NonEntityClients: d.Get("non_entity_clients").(int),
+		SecretSyncs:      d.Get("secret_syncs").(int),
+		CreatedBy:        req.DisplayName,
+		CreatedTime:      time.Now().UTC(),
+	}
+	switch {
+	case baseline.Source == "":
+		return logical.ErrorResponse("source is required to record the provenance of the counts"), logical.ErrInvalidRequest
+	case baseline.StartTime.IsZero() || baseline.EndTime.IsZero():
+		return logical.ErrorResponse("start_time and end_time are required"), logical.ErrInvalidRequest
+	case !baseline.StartTime.Before(baseline.EndTime):
+		return logical.ErrorResponse("start_time must be earlier than end_time"), logical.ErrInvalidRequest
+	case baseline.EntityClients < 0 || baseline.NonEntityClients < 0 || baseline.SecretSyncs < 0:
+		return logical.ErrorResponse("counts cannot be negative"), logical.ErrInvalidRequest
+	}
+
+	if err := a.putBaseline(ctx, baseline); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleActivityBaselineDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	return nil, a.deleteBaseline(ctx, d.Get("name").(string))
+}
+
+func (b *SystemBackend) handleActivityAccessorMappingList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	mappings, err := a.listAccessorMappings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accessors := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		accessors = append(accessors, mapping.OldAccessor)
+	}
+	return logical.ListResponse(accessors), nil
+}
+
+func (b *SystemBackend) handleActivityAccessorMappingRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	mapping, err := a.getAccessorMapping(ctx, d.Get("old_accessor").(string))
+	if err != nil || mapping == nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: mapping.toMap(),
+	}, nil
+}
+
+func (b *SystemBackend) handleActivityAccessorMappingUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+
+	now := time.Now().UTC()
+	mapping := &activityAccessorMapping{
+		OldAccessor:    d.Get("old_accessor").(string),
+		NewAccessor:    d.Get("new_accessor").(string),
+		ExpirationTime: d.Get("expiration_time").(time.Time).UTC(),
+		CreatedBy:      req.DisplayName,
+		CreatedTime:    now,
+	}
+	if mapping.ExpirationTime.IsZero() {
+		// The mapping applies for the remainder of the billing period
+		if billingStart := b.Core.BillingStart(); !billingStart.IsZero() {
+			mapping.ExpirationTime = billingStart.UTC()
+			for !mapping.ExpirationTime.After(now) {
+				mapping.ExpirationTime = mapping.ExpirationTime.AddDate(1, 0, 0)
+			}
+		}
+	}
+	switch {
+	case mapping.NewAccessor == "":
+		return logical.ErrorResponse("new_accessor is required"), logical.ErrInvalidRequest
+	case mapping.NewAccessor == mapping.OldAccessor:
+		return logical.ErrorResponse("new_accessor must differ from the old accessor"), logical.ErrInvalidRequest
+	case mapping.ExpirationTime.IsZero():
+		return logical.ErrorResponse("expiration_time is required when no billing period is configured"), logical.ErrInvalidRequest
+	case !mapping.ExpirationTime.After(now):
+		return logical.ErrorResponse("expiration_time must be in the future"), logical.ErrInvalidRequest
+	}
+	if entry := b.Core.router.MatchingMountByAccessor(mapping.NewAccessor); entry == nil || entry.Table != credentialTableType {
+		return logical.ErrorResponse("no auth mount found for new_accessor %q", mapping.NewAccessor), logical.ErrInvalidRequest
+	}
+
+	mappings, err := a.listAccessorMappings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range mappings {
+		if other.OldAccessor != mapping.OldAccessor && other.NewAccessor == mapping.NewAccessor {
+			return logical.ErrorResponse("accessor %q is already mapped to %q", other.OldAccessor, mapping.NewAccessor), logical.ErrInvalidRequest
+		}
+	}
+
+	if err := a.putAccessorMapping(ctx, mapping); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleActivityAccessorMappingDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	a, errResp := b.activityLogForBaselines()
+	if a == nil {
+		return errResp, nil
+	}
+	return nil, a.deleteAccessorMapping(ctx, d.Get("old_accessor").(string))
+}
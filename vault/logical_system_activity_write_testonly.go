@@ -36,6 +36,20 @@ func (b *SystemBackend) activityWritePath() *framework.Path {
 				Type:        framework.TypeString,
 				Description: "JSON input for generating mock data",
 			},
+			"create_missing": {
+				Type:        framework.TypeBool,
+				Description: "Create the namespaces and mounts of the input which do not exist",
+			},
+			"secrets_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the secrets engines created for the input",
+				Default:     "kv",
+			},
+			"auth_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the auth methods created for the input",
+				Default:     "userpass",
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
@@ -56,6 +70,9 @@ func (b *SystemBackend) handleActivityWriteData(ctx context.Context, request *lo
 	if len(input.Write) == 0 {
 		return logical.ErrorResponse("Missing required \"write\" values"), logical.ErrInvalidRequest
 	}
+	if err := clientcountutil.ExpandPreset(input); err != nil {
+		return logical.ErrorResponse("Invalid preset: %s", err), logical.ErrInvalidRequest
+	}
 	if len(input.Data) == 0 {
 		return logical.ErrorResponse("Missing required \"data\" values"), logical.ErrInvalidRequest
 	}
@@ -72,10 +89,16 @@ func (b *SystemBackend) handleActivityWriteData(ctx context.Context, request *lo
 		}
 	}
 	generated := newMultipleMonthsActivityClients(numMonths + 1)
+	if data.Get("create_missing").(bool) {
+		generated.create = &activityWriteCreateOptions{
+			secretsType: data.Get("secrets_type").(string),
+			authType:    data.Get("auth_type").(string),
+		}
+	}
 	for _, month := range input.Data {
 		err := generated.processMonth(ctx, b.Core, month)
 		if err != nil {
-			return logical.ErrorResponse("failed to process data for month %d", month.GetMonthsAgo()), err
+			return logical.ErrorResponse("failed to process data for month %d: %s", month.GetMonthsAgo(), err), err
 		}
 	}
 
@@ -89,7 +112,8 @@ func (b *SystemBackend) handleActivityWriteData(ctx context.Context, request *lo
 	}
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"paths": paths,
+			"paths":  paths,
+			"months": generated.summary(),
 		},
 	}, nil
 }
@@ -103,14 +127,30 @@ type singleMonthActivityClients struct {
 	predefinedSegments map[int][]int
 	// generationParameters holds the generation request
 	generationParameters *generation.Data
+	// segmentsWritten is the number of segments of clients written to storage
+	segmentsWritten int
 }
 
 // multipleMonthsActivityClients holds multiple month's data
 type multipleMonthsActivityClients struct {
 	// months are in order, with month 0 being the current month and index 1 being 1 month ago
 	months []*singleMonthActivityClients
+	// create is set for the missing namespaces and mounts of the clients to
+	// be created rather than fail their processing
+	create *activityWriteCreateOptions
+}
+
+// activityWriteCreateOptions holds the types of the mounts created for the
+// clients of the mock input
+type activityWriteCreateOptions struct {
+	secretsType string
+	authType    string
 }
 
+// activityWriteDefaultMount is the path of the mount created for the clients
+// of a namespace without any mount
+const activityWriteDefaultMount = "activity-mock/"
+
 func (s *singleMonthActivityClients) addEntityRecord(record *activity.EntityRecord, segmentIndex *int) {
 	s.clients = append(s.clients, record)
 	if segmentIndex != nil {
@@ -225,59 +265,110 @@ func (m *multipleMonthsActivityClients) processMonth(ctx context.Context, core *
 		}
 	}
 	m.months[month.GetMonthsAgo()].generationParameters = month
-	add := func(c []*generation.Client, segmentIndex *int) error {
-		for _, clients := range c {
-			mountAccessor := defaultMountAccessorRootNS
 
-			if clients.Namespace == "" {
-				clients.Namespace = namespace.RootNamespaceID
+	// locate returns the ID of the namespace and the accessor of the mount
+	// that clients are seen in, defaulting to the first mount of the namespace
+	locate := func(nsPath, mountPath string) (string, string, error) {
+		mountAccessor := defaultMountAccessorRootNS
+		if nsPath == "" {
+			nsPath = namespace.RootNamespaceID
+		}
+		if nsPath != namespace.RootNamespaceID && !strings.HasSuffix(nsPath, "/") {
+			nsPath += "/"
+		}
+		// verify that the namespace exists
+		ns := core.namespaceByPath(nsPath)
+		if ns.ID == namespace.RootNamespaceID && nsPath != namespace.RootNamespaceID {
+			if m.create == nil {
+				return "", "", fmt.Errorf("unable to find namespace %s", nsPath)
 			}
-			if clients.ClientType == "" {
-				clients.ClientType = entityActivityType
+			ns, err = core.createNamespace(ctx, nsPath)
+			if err != nil {
+				return "", "", fmt.Errorf("unable to create namespace %s: %w", nsPath, err)
 			}
+		}
+		nctx := namespace.ContextWithNamespace(ctx, ns)
 
-			if clients.Namespace != namespace.RootNamespaceID && !strings.HasSuffix(clients.Namespace, "/") {
-				clients.Namespace += "/"
+		// verify that the mount exists
+		if mountPath != "" {
+			if !strings.HasSuffix(mountPath, "/") {
+				mountPath += "/"
 			}
-			// verify that the namespace exists
-			ns := core.namespaceByPath(clients.Namespace)
-			if ns.ID == namespace.RootNamespaceID && clients.Namespace != namespace.RootNamespaceID {
-				return fmt.Errorf("unable to find namespace %s", clients.Namespace)
+			mountEntry := core.router.MatchingMountEntry(nctx, mountPath)
+			if mountEntry == nil {
+				if m.create == nil {
+					return "", "", fmt.Errorf("unable to find matching mount in namespace %s", ns.Path)
+				}
+				mountEntry, err = m.create.mount(nctx, core, mountPath)
+				if err != nil {
+					return "", "", err
+				}
+				mounts = append(mounts, mountEntry)
 			}
-			clients.Namespace = ns.ID
+			mountAccessor = mountEntry.Accessor
+		}
 
-			// verify that the mount exists
-			if clients.Mount != "" {
-				if !strings.HasSuffix(clients.Mount, "/") {
-					clients.Mount += "/"
+		if ns.ID != namespace.RootNamespaceID && mountPath == "" {
+			// if we're not using the root namespace, find a mount on the namespace that we are using
+			found := false
+			for _, mount := range mounts {
+				if mount.NamespaceID == ns.ID {
+					mountAccessor = mount.Accessor
+					found = true
+					break
 				}
-				nctx := namespace.ContextWithNamespace(ctx, ns)
-				mountEntry := core.router.MatchingMountEntry(nctx, clients.Mount)
-				if mountEntry == nil {
-					return fmt.Errorf("unable to find matching mount in namespace %s", ns.Path)
+			}
+			if !found {
+				if m.create == nil {
+					return "", "", fmt.Errorf("unable to find matching mount in namespace %s", ns.Path)
 				}
+				mountEntry, err := m.create.mount(nctx, core, activityWriteDefaultMount)
+				if err != nil {
+					return "", "", err
+				}
+				mounts = append(mounts, mountEntry)
 				mountAccessor = mountEntry.Accessor
 			}
+		}
+		return ns.ID, mountAccessor, nil
+	}
 
-			if clients.Namespace != namespace.RootNamespaceID && clients.Mount == "" {
-				// if we're not using the root namespace, find a mount on the namespace that we are using
-				found := false
-				for _, mount := range mounts {
-					if mount.NamespaceID == ns.ID {
-						mountAccessor = mount.Accessor
-						found = true
-						break
-					}
-				}
-				if !found {
-					return fmt.Errorf("unable to find matching mount in namespace %s", ns.Path)
-				}
+	add := func(c []*generation.Client, segmentIndex *int) error {
+		for _, clients := range c {
+			if clients.ClientType == "" {
+				clients.ClientType = entityActivityType
+			}
+			nsID, mountAccessor, err := locate(clients.Namespace, clients.Mount)
+			if err != nil {
+				return err
 			}
+			clients.Namespace = nsID
 
+			addingTo := m.months[month.GetMonthsAgo()]
+			seen := len(addingTo.clients)
 			err = m.addClientToMonth(month.GetMonthsAgo(), clients, mountAccessor, segmentIndex)
 			if err != nil {
 				return err
 			}
+
+			// record the new clients in the other namespaces and mounts they
+			// are seen in, with the same IDs
+			newClients := addingTo.clients[seen:]
+			for _, overlap := range clients.GetOverlaps() {
+				nsID, mountAccessor, err := locate(overlap.Namespace, overlap.Mount)
+				if err != nil {
+					return err
+				}
+				for _, client := range newClients {
+					addingTo.addEntityRecord(&activity.EntityRecord{
+						ClientID:      client.ClientID,
+						NamespaceID:   nsID,
+						MountAccessor: mountAccessor,
+						NonEntity:     client.NonEntity,
+						ClientType:    client.ClientType,
+					}, segmentIndex)
+				}
+			}
 		}
 		return nil
 	}
@@ -300,6 +391,30 @@ func (m *multipleMonthsActivityClients) processMonth(ctx context.Context, core *
 	return nil
 }
 
+// mount creates the mount at the path in the namespace of the context, an auth
+// method if the path starts with auth/, and a secrets engine otherwise
+func (o *activityWriteCreateOptions) mount(ctx context.Context, core *Core, path string) (*MountEntry, error) {
+	entry := &MountEntry{
+		Table:       mountTableType,
+		Path:        path,
+		Type:        o.secretsType,
+		Description: "created for mock activity data",
+	}
+	var err error
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		entry.Table = credentialTableType
+		entry.Path = strings.TrimPrefix(path, credentialRoutePrefix)
+		entry.Type = o.authType
+		err = core.enableCredential(ctx, entry)
+	} else {
+		err = core.mount(ctx, entry)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create mount %s: %w", path, err)
+	}
+	return entry, nil
+}
+
 func (m *multipleMonthsActivityClients) addClientToMonth(monthsAgo int32, c *generation.Client, mountAccessor string, segmentIndex *int) error {
 	if c.Repeated || c.RepeatedFromMonth > 0 {
 		return m.addRepeatedClients(monthsAgo, c, mountAccessor, segmentIndex)
@@ -388,6 +503,7 @@ func (m *multipleMonthsActivityClients) write(ctx context.Context, opts map[gene
 					return nil, err
 				}
 				paths = append(paths, entityPath)
+				month.segmentsWritten++
 			}
 		}
 
@@ -415,6 +531,37 @@ func (m *multipleMonthsActivityClients) write(ctx context.Context, opts map[gene
 	return paths, nil
 }
 
+// summary returns the number of distinct entities, non-entity clients and
+// namespaces of each month of generated data, and the number of segments
+// written for it, from the current month
+func (m *multipleMonthsActivityClients) summary() []map[string]interface{} {
+	var summary []map[string]interface{}
+	for i, month := range m.months {
+		if month.generationParameters == nil {
+			continue
+		}
+		entities := make(map[string]struct{})
+		nonEntities := make(map[string]struct{})
+		namespaces := make(map[string]struct{})
+		for _, client := range month.clients {
+			if client.NonEntity {
+				nonEntities[client.ClientID] = struct{}{}
+			} else {
+				entities[client.ClientID] = struct{}{}
+			}
+			namespaces[client.NamespaceID] = struct{}{}
+		}
+		summary = append(summary, map[string]interface{}{
+			"months_ago":        i,
+			"entities":          len(entities),
+			"non_entity_tokens": len(nonEntities),
+			"namespaces":        len(namespaces),
+			"segments":          month.segmentsWritten,
+		})
+	}
+	return summary
+}
+
 func (m *multipleMonthsActivityClients) latestTimestamp(now time.Time, includeCurrentMonth bool) time.Time {
 	for i, month := range m.months {
 		if month.generationParameters != nil && (i != 0 || includeCurrentMonth) {
@@ -19,7 +19,6 @@ import (
 	"github.com/hashicorp/vault/vault/activity"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/protojson"
-	"google.golang.org/protobuf/proto"
 )
 
 // TestSystemBackend_handleActivityWriteData calls the activity log write endpoint and confirms that the inputs are
@@ -83,6 +82,23 @@ func TestSystemBackend_handleActivityWriteData(t *testing.T) {
 			input:     map[string]interface{}{"input": `{"write":["WRITE_ENTITIES"],"data":[{"current_month":true,"num_segments":3,"all":{"clients":[{"count":5}]}}]}`},
 			wantPaths: 3,
 		},
+		{
+			name:      "preset succeeds",
+			operation: logical.UpdateOperation,
+			input:     map[string]interface{}{"input": `{"write":["WRITE_PRECOMPUTED_QUERIES"],"preset":"steady-growth-12mo"}`},
+		},
+		{
+			name:      "unknown preset fails",
+			operation: logical.UpdateOperation,
+			input:     map[string]interface{}{"input": `{"write":["WRITE_PRECOMPUTED_QUERIES"],"preset":"unknown"}`},
+			wantError: logical.ErrInvalidRequest,
+		},
+		{
+			name:      "preset and data fails",
+			operation: logical.UpdateOperation,
+			input:     map[string]interface{}{"input": `{"write":["WRITE_PRECOMPUTED_QUERIES"],"preset":"churn-heavy","data":[{"current_month":true,"all":{"clients":[{"count":5}]}}]}`},
+			wantError: logical.ErrInvalidRequest,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -101,6 +117,36 @@ func TestSystemBackend_handleActivityWriteData(t *testing.T) {
 	}
 }
 
+// TestSystemBackend_handleActivityWriteData_summary verifies that the activity
+// log write endpoint returns the number of distinct clients, namespaces and
+// written segments of each month
+func TestSystemBackend_handleActivityWriteData_summary(t *testing.T) {
+	b := testSystemBackend(t)
+	req := logical.TestRequest(t, logical.UpdateOperation, "internal/counters/activity/write")
+	req.Data = map[string]interface{}{"input": `{"write":["WRITE_ENTITIES"],"data":[
+		{"months_ago":1,"all":{"clients":[{"count":5},{"count":2,"client_type":"non-entity-token"}]}},
+		{"current_month":true,"num_segments":2,"all":{"clients":[{"count":3,"repeated":true},{"count":1}]}}
+	]}`}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"months_ago":        0,
+			"entities":          4,
+			"non_entity_tokens": 0,
+			"namespaces":        1,
+			"segments":          2,
+		},
+		{
+			"months_ago":        1,
+			"entities":          5,
+			"non_entity_tokens": 2,
+			"namespaces":        1,
+			"segments":          1,
+		},
+	}, resp.Data["months"])
+}
+
 // Test_singleMonthActivityClients_addNewClients verifies that new clients are
 // created correctly, adhering to the requested parameters. The clients should
 // use the inputted mount and a generated ID if one is not supplied. The new
@@ -291,6 +337,67 @@ func Test_multipleMonthsActivityClients_processMonth(t *testing.T) {
 	}
 }
 
+// Test_multipleMonthsActivityClients_processMonth_createMissing verifies that
+// the missing mounts of the clients are created when requested, as auth
+// methods or secrets engines by their paths
+func Test_multipleMonthsActivityClients_processMonth_createMissing(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	data := &generation.Data{
+		Clients: &generation.Data_All{All: &generation.Clients{Clients: []*generation.Client{
+			{Mount: "auth/mock-auth"},
+			{Mount: "mock-secrets/"},
+			{Mount: "auth/mock-auth/"},
+		}}},
+	}
+	m := newMultipleMonthsActivityClients(1)
+	m.create = &activityWriteCreateOptions{secretsType: "kv", authType: "noop"}
+	require.NoError(t, m.processMonth(context.Background(), core, data))
+	require.Len(t, m.months[0].clients, 3)
+
+	ctx := namespace.RootContext(nil)
+	authMount := core.router.MatchingMountEntry(ctx, "auth/mock-auth/")
+	require.NotNil(t, authMount)
+	require.Equal(t, credentialTableType, authMount.Table)
+	secretsMount := core.router.MatchingMountEntry(ctx, "mock-secrets/")
+	require.NotNil(t, secretsMount)
+	require.Equal(t, "kv", secretsMount.Type)
+
+	require.Equal(t, authMount.Accessor, m.months[0].clients[0].MountAccessor)
+	require.Equal(t, secretsMount.Accessor, m.months[0].clients[1].MountAccessor)
+	require.Equal(t, authMount.Accessor, m.months[0].clients[2].MountAccessor)
+}
+
+// Test_multipleMonthsActivityClients_processMonth_overlaps verifies that new
+// clients with overlaps are also recorded in the other mounts, with the same
+// IDs, and that the summary counts them once
+func Test_multipleMonthsActivityClients_processMonth_overlaps(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	data := &generation.Data{
+		Clients: &generation.Data_All{All: &generation.Clients{Clients: []*generation.Client{{
+			Count:    2,
+			Mount:    "cubbyhole/",
+			Overlaps: []*generation.ClientLocation{{Mount: "identity/"}},
+		}}}},
+	}
+	m := newMultipleMonthsActivityClients(1)
+	require.NoError(t, m.processMonth(context.Background(), core, data))
+
+	clients := m.months[0].clients
+	require.Len(t, clients, 4)
+	identity := core.router.MatchingMountEntry(namespace.RootContext(nil), "identity/")
+	for i, client := range clients[:2] {
+		overlap := clients[i+2]
+		require.Equal(t, client.ClientID, overlap.ClientID)
+		require.NotEqual(t, client.MountAccessor, overlap.MountAccessor)
+		require.Equal(t, identity.Accessor, overlap.MountAccessor)
+	}
+	require.Equal(t, 2, m.summary()[0]["entities"])
+
+	data.GetAll().Clients[0].Overlaps = []*generation.ClientLocation{{Mount: "missing/"}}
+	m = newMultipleMonthsActivityClients(1)
+	require.Error(t, m.processMonth(context.Background(), core, data))
+}
+
 // Test_multipleMonthsActivityClients_processMonth_segmented verifies that segments
 // are filled correctly when a month is processed with segmented data. The clients
 // should be in the clients array, and should also be in the predefinedSegments map
@@ -557,7 +664,7 @@ func Test_handleActivityWriteData(t *testing.T) {
 					continue
 				}
 				activities := &activity.EntityActivityLog{}
-				err = proto.Unmarshal(entry.Value, activities)
+				err = decodeActivitySegment(entry.Value, activities)
 				require.NoError(t, err)
 				segments[segmentNum] = activities.Clients
 			}
@@ -0,0 +1,21 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !testonly
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+)
+
+// TestSystemBackend_APICompatibility verifies that no path, operation or
+// field of the system backend recorded in the golden file was removed or
+// changed type. Testonly builds are excluded, as their additional paths are
+// not part of the API.
+func TestSystemBackend_APICompatibility(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	framework.TestBackendAPICompatibility(t, c.systemBackend.Backend, "testdata/system_backend_api.json")
+}
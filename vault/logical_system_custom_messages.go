@@ -94,6 +94,11 @@ func (b *SystemBackend) uiCustomMessagePaths() []*framework.Path {
 					Type:     framework.TypeMap,
 					Required: false,
 				},
+				"require_acknowledgment": {
+					Type:        framework.TypeBool,
+					Required:    false,
+					Description: "If set, users of interactive auth methods must acknowledge the message before they are issued a token. Only valid for unauthenticated messages.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -112,6 +117,50 @@ func (b *SystemBackend) uiCustomMessagePaths() []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "config/ui/custom-messages/" + framework.GenericNameRegex("id") + "/acknowledgments$",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The unique identifier for the custom message",
+				},
+			},
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "ui-config",
+				OperationSuffix: "custom-message-acknowledgments",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleReadCustomMessageAcknowledgments,
+					Summary:  "Read the acknowledgments of a custom message",
+
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"version": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"acknowledgments": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+			},
+		},
 		{
 			Pattern: "config/ui/custom-messages/" + framework.MatchAllRegex("id"),
 			Fields: map[string]*framework.FieldSchema{
@@ -153,6 +202,11 @@ func (b *SystemBackend) uiCustomMessagePaths() []*framework.Path {
 					Type:     framework.TypeMap,
 					Required: false,
 				},
+				"require_acknowledgment": {
+					Type:        framework.TypeBool,
+					Required:    false,
+					Description: "If set, users of interactive auth methods must acknowledge the message before they are issued a token. Only valid for unauthenticated messages.",
+				},
 			},
 			DisplayAttrs: &framework.DisplayAttributes{
 				OperationPrefix: "ui-config",
@@ -299,6 +353,7 @@ func (b *SystemBackend) handleListCustomMessages(ctx context.Context, req *logic
 		m["start_time"] = message.StartTime
 		m["end_time"] = message.EndTime
 		m["active"] = message.Active()
+		m["require_acknowledgment"] = message.RequireAcknowledgment
 
 		keyInfos[message.ID] = m
 		keys[i] = message.ID
@@ -438,15 +493,21 @@ func (b *SystemBackend) handleCreateCustomMessages(ctx context.Context, req *log
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
+	requireAcknowledgment, err := parameterValidateOrUseDefault[bool]("require_acknowledgment", d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	message := &uicustommessages.Message{
-		Title:         title,
-		Authenticated: authenticated,
-		Type:          messageType,
-		Message:       messageValue,
-		StartTime:     startTime,
-		EndTime:       endTime,
-		Link:          link,
-		Options:       options,
+		Title:                 title,
+		Authenticated:         authenticated,
+		Type:                  messageType,
+		Message:               messageValue,
+		StartTime:             startTime,
+		EndTime:               endTime,
+		Link:                  link,
+		Options:               options,
+		RequireAcknowledgment: requireAcknowledgment,
 	}
 
 	message, err = b.Core.customMessageManager.AddMessage(ctx, *message)
@@ -470,6 +531,9 @@ func (b *SystemBackend) handleCreateCustomMessages(ctx context.Context, req *log
 			"link":          message.Link,
 			"options":       message.Options,
 			"active":        message.Active(),
+
+			"require_acknowledgment": message.RequireAcknowledgment,
+			"version":                message.Version,
 		},
 	}, nil
 }
@@ -558,6 +622,9 @@ func (b *SystemBackend) handleReadCustomMessage(ctx context.Context, req *logica
 			"options":       message.Options,
 			"active":        message.Active(),
 			"title":         message.Title,
+
+			"require_acknowledgment": message.RequireAcknowledgment,
+			"version":                message.Version,
 		},
 	}, nil
 }
@@ -625,16 +692,22 @@ func (b *SystemBackend) handleUpdateCustomMessage(ctx context.Context, req *logi
 		endTime = &value
 	}
 
+	requireAcknowledgment, err := parameterValidateOrUseDefault[bool]("require_acknowledgment", d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	message := &uicustommessages.Message{
-		ID:            messageID,
-		Title:         title,
-		Authenticated: authenticated,
-		Type:          messageType,
-		Message:       messageValue,
-		Link:          link,
-		Options:       options,
-		StartTime:     startTime,
-		EndTime:       endTime,
+		ID:                    messageID,
+		Title:                 title,
+		Authenticated:         authenticated,
+		Type:                  messageType,
+		Message:               messageValue,
+		Link:                  link,
+		Options:               options,
+		StartTime:             startTime,
+		EndTime:               endTime,
+		RequireAcknowledgment: requireAcknowledgment,
 	}
 
 	message, err = b.Core.customMessageManager.UpdateMessage(ctx, *message)
@@ -658,6 +731,9 @@ func (b *SystemBackend) handleUpdateCustomMessage(ctx context.Context, req *logi
 			"end_time":      endTimeResponse,
 			"type":          message.Type,
 			"authenticated": message.Authenticated,
+
+			"require_acknowledgment": message.RequireAcknowledgment,
+			"version":                message.Version,
 		},
 	}, nil
 }
@@ -677,6 +753,49 @@ func (b *SystemBackend) handleDeleteCustomMessage(ctx context.Context, req *logi
 	return nil, nil
 }
 
+// handleReadCustomMessageAcknowledgments is the operation callback for the
+// READ operation of the custom message acknowledgments endpoint. It returns
+// the latest acknowledgment of each entity which acknowledged the message, and
+// whether it is of the current version of the message.
+func (b *SystemBackend) handleReadCustomMessageAcknowledgments(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	id, err := parameterValidateOrReportMissing[string]("id", d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Reading the message ensures it exists in the namespace of the request
+	message, err := b.Core.customMessageManager.ReadMessage(ctx, id)
+	switch {
+	case errors.Is(err, logical.ErrNotFound):
+		return nil, err
+	case err != nil:
+		return logical.ErrorResponse("failed to retrieve custom message: %s", err), nil
+	}
+
+	acks, err := b.Core.customMessageManager.ReadAcknowledgments(ctx, id)
+	if err != nil {
+		return logical.ErrorResponse("failed to retrieve custom message acknowledgments: %s", err), nil
+	}
+
+	acksResponse := make([]map[string]any, 0, len(acks))
+	for _, ack := range acks {
+		acksResponse = append(acksResponse, map[string]any{
+			"entity_id":       ack.EntityID,
+			"version":         ack.Version,
+			"acknowledged_at": ack.Time.Format(time.RFC3339Nano),
+			"current":         ack.Version >= message.Version,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]any{
+			"id":              id,
+			"version":         message.Version,
+			"acknowledgments": acksResponse,
+		},
+	}, nil
+}
+
 // handleCustomMessageExistenceCheck is the function that fills the
 // framework.Path ExistenceCheck role for custom messages.
 func (b *SystemBackend) handleCustomMessageExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
@@ -0,0 +1,454 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// deceptionPaths returns paths that manage honeytokens and decoy paths
+func (b *SystemBackend) deceptionPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "deception/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deception",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"webhook_url": {
+					Type:        framework.TypeString,
+					Description: "URL which is sent a POST request with the details of every deception trigger.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleDeceptionConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"webhook_url": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleDeceptionConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(deceptionHelp["deception-config"][0]),
+			HelpDescription: strings.TrimSpace(deceptionHelp["deception-config"][1]),
+		},
+		{
+			Pattern: "deception/tokens/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deception",
+				OperationSuffix: "honeytokens",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleHoneytokenList(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(deceptionHelp["deception-tokens"][0]),
+			HelpDescription: strings.TrimSpace(deceptionHelp["deception-tokens"][1]),
+		},
+		{
+			Pattern: "deception/tokens/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deception",
+				OperationSuffix: "honeytoken",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the honeytoken.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleHoneytokenRead(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"creation_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleHoneytokenCreate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "create",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"token": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleHoneytokenDelete(),
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(deceptionHelp["deception-token"][0]),
+			HelpDescription: strings.TrimSpace(deceptionHelp["deception-token"][1]),
+		},
+		{
+			Pattern: "deception/paths/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deception",
+				OperationSuffix: "decoy-paths",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleDecoyPathList(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(deceptionHelp["deception-paths"][0]),
+			HelpDescription: strings.TrimSpace(deceptionHelp["deception-paths"][1]),
+		},
+		{
+			Pattern: "deception/paths/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deception",
+				OperationSuffix: "decoy-path",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the decoy path.",
+				},
+				"path": {
+					Type:        framework.TypeString,
+					Description: "Path, relative to the namespace of the request, whose access triggers an alert, e.g. 'secret/data/admin'. A path ending in '/' matches every path below it.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleDecoyPathRead(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"creation_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleDecoyPathWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "write",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleDecoyPathDelete(),
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(deceptionHelp["deception-path"][0]),
+			HelpDescription: strings.TrimSpace(deceptionHelp["deception-path"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) deceptionState() *deceptionState {
+	if state := b.Core.deception.Load(); state != nil {
+		return state
+	}
+	return &deceptionState{}
+}
+
+func (b *SystemBackend) handleDeceptionConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config := b.deceptionState().config
+		if config == nil {
+			config = &DeceptionConfig{}
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"webhook_url": config.WebhookURL,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleDeceptionConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config := &DeceptionConfig{}
+		if current := b.deceptionState().config; current != nil {
+			*config = *current
+		}
+
+		if v, ok := d.GetOk("webhook_url"); ok {
+			config.WebhookURL = v.(string)
+		}
+		if config.WebhookURL != "" {
+			u, err := url.Parse(config.WebhookURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return logical.ErrorResponse("webhook_url must be an absolute http or https URL"), nil
+			}
+		}
+
+		return nil, b.Core.saveDeceptionConfig(ctx, config)
+	}
+}
+
+func (b *SystemBackend) handleHoneytokenList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		var keys []string
+		for name := range b.deceptionState().honeytokens {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		return logical.ListResponse(keys), nil
+	}
+}
+
+func (b *SystemBackend) handleHoneytokenRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ht, ok := b.deceptionState().honeytokens[d.Get("name").(string)]
+		if !ok {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name":          ht.Name,
+				"creation_time": ht.CreationTime.Format(time.RFC3339Nano),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleHoneytokenCreate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		if _, ok := b.deceptionState().honeytokens[name]; ok {
+			return logical.ErrorResponse("honeytoken %q already exists", name), nil
+		}
+
+		token, err := newHoneytoken()
+		if err != nil {
+			return nil, err
+		}
+
+		ht := &honeytokenEntry{
+			Name:         name,
+			TokenHash:    honeytokenHash(token),
+			CreationTime: time.Now().UTC(),
+		}
+		if err := b.Core.saveHoneytoken(ctx, ht); err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name":  name,
+				"token": token,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleHoneytokenDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		return nil, b.Core.deleteHoneytoken(ctx, d.Get("name").(string))
+	}
+}
+
+func (b *SystemBackend) handleDecoyPathList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		var keys []string
+		for name := range b.deceptionState().decoyPaths {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		return logical.ListResponse(keys), nil
+	}
+}
+
+func (b *SystemBackend) handleDecoyPathRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		dp, ok := b.deceptionState().decoyPaths[d.Get("name").(string)]
+		if !ok {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name":          dp.Name,
+				"path":          dp.Path,
+				"creation_time": dp.CreationTime.Format(time.RFC3339Nano),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleDecoyPathWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		path := strings.TrimPrefix(d.Get("path").(string), "/")
+		if path == "" {
+			return logical.ErrorResponse("path is required"), nil
+		}
+
+		dp := &decoyPathEntry{
+			Name:         d.Get("name").(string),
+			Path:         ns.Path + path,
+			CreationTime: time.Now().UTC(),
+		}
+		return nil, b.Core.saveDecoyPath(ctx, dp)
+	}
+}
+
+func (b *SystemBackend) handleDecoyPathDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		return nil, b.Core.deleteDecoyPath(ctx, d.Get("name").(string))
+	}
+}
+
+var deceptionHelp = map[string][2]string{
+	"deception-config": {
+		"Configure how deception triggers are reported.",
+		`Every use of a honeytoken and every request to a decoy path is recorded with
+a 'deception_trigger' in the audit log, counted in the
+'core.deception.triggered' metric and logged as an error. If 'webhook_url' is
+set, the details of each trigger are also POSTed to it as JSON.`,
+	},
+	"deception-tokens": {
+		"List honeytokens.",
+		`Lists the names of all honeytokens.`,
+	},
+	"deception-token": {
+		"Create, read or delete a honeytoken.",
+		`Honeytokens are decoy tokens which look like service tokens but cannot be
+used to authenticate. Since no legitimate client has a honeytoken, any use of
+one indicates that stolen credentials are being tried, and triggers an alert.
+The token is only returned when the honeytoken is created; Vault stores only a
+hash of it.`,
+	},
+	"deception-paths": {
+		"List decoy paths.",
+		`Lists the names of all decoy paths.`,
+	},
+	"deception-path": {
+		"Create, read or delete a decoy path.",
+		`Any request to a decoy path triggers an alert, regardless of whether it is
+authorized. The request itself is handled as usual, so a decoy path can hold
+decoy data, e.g. a KV secret with enticing but fake credentials.`,
+	},
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// fairSharePaths returns paths that configure fair-share request scheduling
+func (b *SystemBackend) fairSharePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/fair-share$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "fair-share",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"enabled": {
+					Type:        framework.TypeBool,
+					Description: "If set, requests are scheduled fairly across namespaces once max_concurrent_requests is reached.",
+				},
+				"max_concurrent_requests": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of requests handled concurrently before requests are queued.",
+				},
+				"default_shares": {
+					Type:        framework.TypeInt,
+					Description: "Weight of namespaces which have no explicit entry in namespace_shares.",
+				},
+				"namespace_shares": {
+					Type:        framework.TypeMap,
+					Description: "Map of namespace paths to their weight. A namespace with twice the shares of another is given twice the concurrent requests under contention.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleFairShareConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"enabled": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"max_concurrent_requests": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"default_shares": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"namespace_shares": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleFairShareConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleFairShareConfigDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(fairShareHelp["fair-share-config"][0]),
+			HelpDescription: strings.TrimSpace(fairShareHelp["fair-share-config"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleFairShareConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config := b.Core.FairShareConfig()
+
+		shares := make(map[string]interface{}, len(config.NamespaceShares))
+		for ns, weight := range config.NamespaceShares {
+			shares[ns] = weight
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"enabled":                 config.Enabled,
+				"max_concurrent_requests": config.MaxConcurrentRequests,
+				"default_shares":          config.DefaultShares,
+				"namespace_shares":        shares,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleFairShareConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		current := b.Core.FairShareConfig()
+		config := &FairShareConfig{
+			Enabled:               current.Enabled,
+			MaxConcurrentRequests: current.MaxConcurrentRequests,
+			DefaultShares:         current.DefaultShares,
+			NamespaceShares:       current.NamespaceShares,
+		}
+
+		if v, ok := d.GetOk("enabled"); ok {
+			config.Enabled = v.(bool)
+		}
+		if v, ok := d.GetOk("max_concurrent_requests"); ok {
+			config.MaxConcurrentRequests = v.(int)
+		}
+		if v, ok := d.GetOk("default_shares"); ok {
+			config.DefaultShares = v.(int)
+		}
+		if v, ok := d.GetOk("namespace_shares"); ok {
+			shares := make(map[string]int)
+			for ns, raw := range v.(map[string]interface{}) {
+				weight, err := parseutil.SafeParseInt(raw)
+				if err != nil {
+					return logical.ErrorResponse("invalid shares for namespace %q: %s", ns, err), nil
+				}
+				if weight <= 0 {
+					return logical.ErrorResponse("shares for namespace %q must be greater than zero", ns), nil
+				}
+				shares[normalizeFairShareNamespace(ns)] = weight
+			}
+			config.NamespaceShares = shares
+		}
+
+		if config.MaxConcurrentRequests <= 0 {
+			return logical.ErrorResponse("max_concurrent_requests must be greater than zero"), nil
+		}
+		if config.DefaultShares <= 0 {
+			return logical.ErrorResponse("default_shares must be greater than zero"), nil
+		}
+
+		return nil, b.Core.saveFairShareConfig(ctx, config)
+	}
+}
+
+func (b *SystemBackend) handleFairShareConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		return nil, b.Core.deleteFairShareConfig(ctx)
+	}
+}
+
+var fairShareHelp = map[string][2]string{
+	"fair-share-config": {
+		"Configure fair-share scheduling of requests across namespaces.",
+		`When enabled, Vault handles at most 'max_concurrent_requests' requests at a
+time. Additional requests are queued, and admitted so that each namespace's
+share of in-flight requests tracks its configured weight. This prevents a
+traffic surge in one namespace from increasing latency for every other
+namespace on the cluster. Namespaces without an entry in 'namespace_shares'
+are weighted by 'default_shares'.`,
+	},
+}
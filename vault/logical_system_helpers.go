@@ -140,7 +140,9 @@ var (
 			"namespaces/?$": {operations: []logical.Operation{logical.ListOperation}},
 			"namespaces/api-lock/lock" + framework.OptionalParamRegex("path"):   {parameters: []string{"path"}, operations: []logical.Operation{logical.UpdateOperation}},
 			"namespaces/api-lock/unlock" + framework.OptionalParamRegex("path"): {parameters: []string{"path"}, operations: []logical.Operation{logical.UpdateOperation}},
-			"namespaces/(?P<path>.+?)": {parameters: []string{"path"}, operations: []logical.Operation{logical.DeleteOperation, logical.PatchOperation, logical.ReadOperation, logical.UpdateOperation}},
+			"namespaces/(?P<path>.+?)/rename":                                   {parameters: []string{"path"}, operations: []logical.Operation{logical.UpdateOperation}},
+			"namespaces/(?P<path>.+?)/move":                                     {parameters: []string{"path"}, operations: []logical.Operation{logical.UpdateOperation}},
+			"namespaces/(?P<path>.+?)":                                          {parameters: []string{"path"}, operations: []logical.Operation{logical.DeleteOperation, logical.PatchOperation, logical.ReadOperation, logical.UpdateOperation}},
 		})...)
 
 		// replication paths
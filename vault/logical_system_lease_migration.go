@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// leaseMigrationPaths returns paths that export and import the leases of a
+// secrets engine mount, to move it to another cluster
+func (b *SystemBackend) leaseMigrationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "leases/export$",
+
+			Sensitivity: framework.SensitivityCritical,
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "export",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Path of the secrets engine mount whose leases to export, e.g. 'database'.",
+				},
+				"key": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Base64-encoded 256-bit key the bundle is encrypted with.",
+				},
+				"detach": {
+					Type:        framework.TypeBool,
+					Description: "If set, the exported leases are removed from this cluster without being revoked.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLeasesExport,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"bundle": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"lease_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"storage_entry_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(leaseMigrationHelp["leases-export"][0]),
+			HelpDescription: strings.TrimSpace(leaseMigrationHelp["leases-export"][1]),
+		},
+		{
+			Pattern: "leases/import$",
+
+			Sensitivity: framework.SensitivityCritical,
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "import",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Path of the secrets engine mount to import the leases in, e.g. 'database'.",
+				},
+				"key": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Base64-encoded 256-bit key the bundle was encrypted with.",
+				},
+				"bundle": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Bundle returned by the export.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLeasesImport,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"storage_entry_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"token_accessor": {
+									Type: framework.TypeString,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(leaseMigrationHelp["leases-import"][0]),
+			HelpDescription: strings.TrimSpace(leaseMigrationHelp["leases-import"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleLeasesExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	mount := d.Get("mount").(string)
+	key := d.Get("key").(string)
+	if mount == "" || key == "" {
+		return logical.ErrorResponse("mount and key are required"), logical.ErrInvalidRequest
+	}
+	// Check the key before anything gets detached
+	if _, err := leaseBundleAEAD(key); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	bundle, err := b.Core.exportMountLeases(ctx, mount, d.Get("detach").(bool))
+	if err != nil {
+		return handleError(err)
+	}
+	encrypted, err := encryptLeaseBundle(bundle, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"bundle":              encrypted,
+			"lease_count":         len(bundle.Leases),
+			"storage_entry_count": len(bundle.Storage),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleLeasesImport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	mount := d.Get("mount").(string)
+	key := d.Get("key").(string)
+	encrypted := d.Get("bundle").(string)
+	if mount == "" || key == "" || encrypted == "" {
+		return logical.ErrorResponse("mount, key and bundle are required"), logical.ErrInvalidRequest
+	}
+
+	bundle, err := decryptLeaseBundle(encrypted, key)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	accessor, err := b.Core.importMountLeases(ctx, mount, bundle)
+	if err != nil {
+		return handleError(err)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"lease_count":         len(bundle.Leases),
+			"storage_entry_count": len(bundle.Storage),
+		},
+	}
+	if accessor != "" {
+		resp.Data["token_accessor"] = accessor
+	}
+	return resp, nil
+}
+
+var leaseMigrationHelp = map[string][2]string{
+	"leases-export": {
+		"Export the leases and the state of a secrets engine mount.",
+		`
+Bundles the leases of the secrets engine mounted at the given path, along
+with the storage of the mount, encrypted with the given key. Importing the
+bundle in another cluster moves the mount there, with its dynamic credentials
+remaining revocable.
+
+If detach is set, the exported leases are removed from this cluster without
+being revoked, so that they are only revoked by the cluster the bundle is
+imported in. The mount can then be disabled without revoking them.
+		`,
+	},
+	"leases-import": {
+		"Import the leases and the state of a secrets engine mount.",
+		`
+Restores an exported bundle in the secrets engine mounted at the given path,
+which must be of the same type as the exported mount and have no data yet.
+The lease IDs are rewritten for the path. The imported leases are owned by
+a new orphan token, whose accessor is returned: revoking it revokes them.
+		`,
+	},
+}
@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// loginEnrichmentPaths returns paths that configure enrichment of login
+// attempts with network intelligence
+func (b *SystemBackend) loginEnrichmentPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/login-enrichment$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "login-enrichment",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"enabled": {
+					Type:        framework.TypeBool,
+					Description: "If set, login attempts are enriched and checked against the configured block rules.",
+				},
+				"service_url": {
+					Type:        framework.TypeString,
+					Description: "URL of the enrichment service login attempts are POSTed to.",
+				},
+				"timeout": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Maximum time to wait for a login attempt to be enriched.",
+				},
+				"fail_closed": {
+					Type:        framework.TypeBool,
+					Description: "If set, login attempts which could not be enriched are blocked.",
+				},
+				"blocked_countries": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "ISO 3166-1 alpha-2 codes of countries logins are blocked from.",
+				},
+				"blocked_asns": {
+					Type:        framework.TypeCommaIntSlice,
+					Description: "Autonomous system numbers logins are blocked from.",
+				},
+				"max_risk_score": {
+					Type:        framework.TypeFloat,
+					Description: "Logins with a higher risk score are blocked. Zero disables the check.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLoginEnrichmentConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"enabled": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"service_url": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"timeout": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"fail_closed": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"blocked_countries": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"blocked_asns": {
+									Type:     framework.TypeCommaIntSlice,
+									Required: true,
+								},
+								"max_risk_score": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLoginEnrichmentConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleLoginEnrichmentConfigDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(loginEnrichmentHelp["login-enrichment-config"][0]),
+			HelpDescription: strings.TrimSpace(loginEnrichmentHelp["login-enrichment-config"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleLoginEnrichmentConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config := b.Core.LoginEnrichmentConfig()
+
+		blockedCountries := config.BlockedCountries
+		if blockedCountries == nil {
+			blockedCountries = []string{}
+		}
+		blockedASNs := config.BlockedASNs
+		if blockedASNs == nil {
+			blockedASNs = []int{}
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"enabled":           config.Enabled,
+				"service_url":       config.ServiceURL,
+				"timeout":           int64(config.Timeout.Seconds()),
+				"fail_closed":       config.FailClosed,
+				"blocked_countries": blockedCountries,
+				"blocked_asns":      blockedASNs,
+				"max_risk_score":    config.MaxRiskScore,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleLoginEnrichmentConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config := *b.Core.LoginEnrichmentConfig()
+
+		if v, ok := d.GetOk("enabled"); ok {
+			config.Enabled = v.(bool)
+		}
+		if v, ok := d.GetOk("service_url"); ok {
+			config.ServiceURL = v.(string)
+		}
+		if v, ok := d.GetOk("timeout"); ok {
+			config.Timeout = time.Duration(v.(int)) * time.Second
+		}
+		if v, ok := d.GetOk("fail_closed"); ok {
+			config.FailClosed = v.(bool)
+		}
+		if v, ok := d.GetOk("blocked_countries"); ok {
+			config.BlockedCountries = v.([]string)
+		}
+		if v, ok := d.GetOk("blocked_asns"); ok {
+			config.BlockedASNs = v.([]int)
+		}
+		if v, ok := d.GetOk("max_risk_score"); ok {
+			config.MaxRiskScore = v.(float64)
+		}
+
+		if config.Timeout <= 0 {
+			return logical.ErrorResponse("timeout must be greater than zero"), nil
+		}
+		if config.MaxRiskScore < 0 {
+			return logical.ErrorResponse("max_risk_score must not be negative"), nil
+		}
+		if config.ServiceURL != "" {
+			u, err := url.Parse(config.ServiceURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return logical.ErrorResponse("service_url must be an absolute http or https URL"), nil
+			}
+		}
+		if config.Enabled && config.ServiceURL == "" && b.Core.loginEnricher == nil {
+			return logical.ErrorResponse("service_url is required to enable login enrichment"), nil
+		}
+
+		return nil, b.Core.saveLoginEnrichmentConfig(ctx, &config)
+	}
+}
+
+func (b *SystemBackend) handleLoginEnrichmentConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		return nil, b.Core.deleteLoginEnrichmentConfig(ctx)
+	}
+}
+
+var loginEnrichmentHelp = map[string][2]string{
+	"login-enrichment-config": {
+		"Configure enrichment of login attempts with network intelligence.",
+		`When enabled, every login attempt is enriched with the ASN, country and risk
+score of the address it originates from, by POSTing it to 'service_url'.
+Attempts from 'blocked_countries' or 'blocked_asns', or with a risk score
+above 'max_risk_score', are rejected. If 'fail_closed' is set, attempts which
+could not be enriched are rejected as well. The enrichment and decision are
+recorded in the audit entries of the login request.`,
+	},
+}
@@ -0,0 +1,248 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// meteringPaths are available only in the root namespace
+func (b *SystemBackend) meteringPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "internal/counters/cost/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-request-cost",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"enabled": {
+					Type:        framework.TypeBool,
+					Description: "Enable or disable the accounting of request costs.",
+				},
+				"operation_weights": {
+					Type:        framework.TypeMap,
+					Description: "Cost of a request by operation (read, list, create, update, patch, delete, login), before its payload is accounted for. Operations not listed cost 1.",
+				},
+				"bytes_per_unit": {
+					Type:        framework.TypeInt,
+					Description: "Size of a payload unit in bytes. 0 disables the accounting of payloads.",
+				},
+				"unit_weight": {
+					Type:        framework.TypeFloat,
+					Description: "Cost added for every payload unit of a request, rounded up.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["cost-config"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["cost-config"][1]),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMeteringConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Read the request cost accounting configuration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMeteringConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Enable or disable the accounting of request costs, or set the cost weights.",
+				},
+			},
+		},
+		{
+			Pattern: "internal/counters/cost/export$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-request-cost",
+				OperationVerb:   "export",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"start_time": {
+					Type:        framework.TypeTime,
+					Description: "Start of export interval",
+				},
+				"end_time": {
+					Type:        framework.TypeTime,
+					Description: "End of export interval",
+				},
+				"format": {
+					Type:        framework.TypeString,
+					Description: "Format of the file. Either a CSV or a JSON file with an object per line.",
+					Default:     "json",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["cost-export"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["cost-export"][1]),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMeteringExport,
+					Summary:  "Export the monthly request costs per namespace and entity, with the client counts of the namespaces.",
+				},
+			},
+		},
+	}
+}
+
+func (b *SystemBackend) handleMeteringConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.requestMeter.Load()
+	if m == nil {
+		return logical.ErrorResponse("metering is not running on this node"), nil
+	}
+	config := m.getConfig()
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":           config.Enabled,
+			"operation_weights": config.OperationWeights,
+			"bytes_per_unit":    config.BytesPerUnit,
+			"unit_weight":       config.UnitWeight,
+		},
+	}, nil
+}
+
+// handleMeteringConfigUpdate updates the request cost accounting
+// configuration. Fields which are not provided retain their current value;
+// operation_weights replaces all the weights.
+func (b *SystemBackend) handleMeteringConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.requestMeter.Load()
+	if m == nil {
+		return logical.ErrorResponse("metering is not running on this node"), nil
+	}
+
+	current := m.getConfig()
+	config := &meteringConfig{
+		Enabled:          current.Enabled,
+		OperationWeights: current.OperationWeights,
+		BytesPerUnit:     current.BytesPerUnit,
+		UnitWeight:       current.UnitWeight,
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if weightsRaw, ok := d.GetOk("operation_weights"); ok {
+		weights := make(map[string]float64)
+		for op, raw := range weightsRaw.(map[string]interface{}) {
+			var weight float64
+			switch v := raw.(type) {
+			case float64:
+				weight = v
+			case int:
+				weight = float64(v)
+			case json.Number:
+				f, err := v.Float64()
+				if err != nil {
+					return logical.ErrorResponse("invalid weight of operation %q: %s", op, err), logical.ErrInvalidRequest
+				}
+				weight = f
+			default:
+				return logical.ErrorResponse("invalid weight of operation %q", op), logical.ErrInvalidRequest
+			}
+			if weight < 0 {
+				return logical.ErrorResponse("weight of operation %q cannot be negative", op), logical.ErrInvalidRequest
+			}
+			weights[op] = weight
+		}
+		config.OperationWeights = weights
+	}
+	if bytesRaw, ok := d.GetOk("bytes_per_unit"); ok {
+		config.BytesPerUnit = bytesRaw.(int)
+		if config.BytesPerUnit < 0 {
+			return logical.ErrorResponse("bytes_per_unit cannot be negative"), logical.ErrInvalidRequest
+		}
+	}
+	if unitRaw, ok := d.GetOk("unit_weight"); ok {
+		config.UnitWeight = unitRaw.(float64)
+		if config.UnitWeight < 0 {
+			return logical.ErrorResponse("unit_weight cannot be negative"), logical.ErrInvalidRequest
+		}
+	}
+
+	if err := m.setConfig(ctx, config); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleMeteringExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.activityLogLock.RLock()
+	a := b.Core.activityLog
+	b.Core.activityLogLock.RUnlock()
+	if a == nil {
+		return logical.ErrorResponse("no activity log present"), nil
+	}
+
+	format := d.Get("format").(string)
+	if format != "json" && format != "csv" {
+		return logical.ErrorResponse("invalid format %q, must be \"json\" or \"csv\"", format), logical.ErrInvalidRequest
+	}
+
+	startTime, endTime, err := parseStartEndTimes(a, d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := b.Core.chargebackRecords(ctx, ns, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/json"
+	switch format {
+	case "csv":
+		contentType = "text/csv"
+		w := csv.NewWriter(&buf)
+		if err := w.Write(chargebackCSVHeader); err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if err := w.Write(record.csv()); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	default:
+		enc := json.NewEncoder(&buf)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     buf.Bytes(),
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
@@ -0,0 +1,225 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// mountErasurePaths returns paths that verify the cryptographic erasure of
+// the data of disabled mounts which had a key of their own
+func (b *SystemBackend) mountErasurePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mount-erasures/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-erasures",
+				OperationVerb:   "list",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleMountErasuresList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(mountErasureHelp["mount-erasures"][0]),
+			HelpDescription: strings.TrimSpace(mountErasureHelp["mount-erasures"][1]),
+		},
+		{
+			Pattern: "mount-erasures/(?P<uuid>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-erasures",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"uuid": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "UUID of the erased mount.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountErasureRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "verify",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"type": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"accessor": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"key_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"erased_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"key_destroyed": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"remaining_entries": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"unrecoverable": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountErasureDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "purge",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(mountErasureHelp["mount-erasure"][0]),
+			HelpDescription: strings.TrimSpace(mountErasureHelp["mount-erasure"][1]),
+		},
+	}
+}
+
+// mountErasure loads the erasure record of the mount, if it was in the
+// namespace of the request.
+func (b *SystemBackend) mountErasure(ctx context.Context, mountUUID string) (*mountErasure, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	erasure, err := b.Core.loadMountErasure(ctx, mountUUID)
+	if err != nil {
+		return nil, err
+	}
+	if erasure == nil || erasure.NamespaceID != ns.ID {
+		return nil, nil
+	}
+	return erasure, nil
+}
+
+func (b *SystemBackend) handleMountErasuresList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	uuids, err := b.Core.barrier.List(ctx, mountErasurePath)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, mountUUID := range uuids {
+		erasure, err := b.mountErasure(ctx, mountUUID)
+		if err != nil {
+			return nil, err
+		}
+		if erasure != nil {
+			keys = append(keys, mountUUID)
+		}
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func (b *SystemBackend) handleMountErasureRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	erasure, err := b.mountErasure(ctx, d.Get("uuid").(string))
+	if err != nil {
+		return nil, err
+	}
+	if erasure == nil {
+		return nil, nil
+	}
+
+	keyDestroyed, remaining, err := b.Core.verifyMountErasure(ctx, erasure)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"path":              erasure.Path,
+			"type":              erasure.Type,
+			"accessor":          erasure.Accessor,
+			"key_id":            erasure.KeyID,
+			"erased_time":       erasure.ErasedTime,
+			"key_destroyed":     keyDestroyed,
+			"remaining_entries": remaining,
+			"unrecoverable":     keyDestroyed,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleMountErasureDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	erasure, err := b.mountErasure(ctx, d.Get("uuid").(string))
+	if err != nil {
+		return nil, err
+	}
+	if erasure == nil {
+		return nil, nil
+	}
+	if err := b.Core.purgeMountErasure(ctx, erasure); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+var mountErasureHelp = map[string][2]string{
+	"mount-erasures": {
+		"List the mounts whose data was erased by destroying their key.",
+		`
+Lists the UUIDs of the disabled mounts of the namespace which were enabled
+with mount_key set. The data of such mounts is encrypted with a key of their
+own, which is destroyed when they are disabled instead of deleting the data.
+		`,
+	},
+	"mount-erasure": {
+		"Verify or purge the erased data of a disabled mount.",
+		`
+Reading verifies that the key of the disabled mount is gone from storage,
+in which case its remaining entries are unrecoverable, and reports how many
+are left. Deleting removes the remaining entries along with the record of the
+erasure.
+
+Storage snapshots and backups taken before the mount was disabled still hold
+the key.
+		`,
+	},
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// mountSealPaths returns paths that seal and unseal individual mounts, to
+// isolate a secrets engine or auth method without sealing the whole cluster
+func (b *SystemBackend) mountSealPaths() []*framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		"path": {
+			Type:        framework.TypeString,
+			Required:    true,
+			Description: "Path of the mount, prefixed with 'auth/' for auth methods.",
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "seal-mount$",
+
+			Sensitivity: framework.SensitivityHigh,
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationVerb:   "seal",
+				OperationSuffix: "mount",
+			},
+
+			Fields: fields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountSeal(true),
+					Summary:  "Seal a mount, rejecting all the operations on it.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(mountSealHelp["seal-mount"][0]),
+			HelpDescription: strings.TrimSpace(mountSealHelp["seal-mount"][1]),
+		},
+		{
+			Pattern: "unseal-mount$",
+
+			Sensitivity: framework.SensitivityHigh,
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationVerb:   "unseal",
+				OperationSuffix: "mount",
+			},
+
+			Fields: fields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountSeal(false),
+					Summary:  "Unseal a sealed mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(mountSealHelp["unseal-mount"][0]),
+			HelpDescription: strings.TrimSpace(mountSealHelp["unseal-mount"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleMountSeal(sealed bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		path := d.Get("path").(string)
+		if path == "" {
+			return logical.ErrorResponse("path is required"), logical.ErrInvalidRequest
+		}
+		if err := b.Core.setMountSealed(ctx, path, sealed); err != nil {
+			return handleError(err)
+		}
+		return nil, nil
+	}
+}
+
+var mountSealHelp = map[string][2]string{
+	"seal-mount": {
+		"Seal an individual mount.",
+		`
+Seals the secrets engine or auth method mounted at the given path, without
+affecting the rest of the cluster. All the operations on a sealed mount are
+rejected, and its backend is stopped, dropping the credentials and keys it
+holds in memory. Its storage is left untouched.
+
+The mount stays sealed across restarts until it is unsealed. Revocations of
+its leases are retried until then. Unseal the mount before disabling it.
+		`,
+	},
+	"unseal-mount": {
+		"Unseal a sealed mount.",
+		`
+Starts the backend of the sealed mount at the given path again, and lets the
+operations on it through.
+		`,
+	},
+}
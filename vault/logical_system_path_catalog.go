@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// openAPIParamRe matches the path parameters of OpenAPI paths.
+var openAPIParamRe = regexp.MustCompile(`\{[^}]+\}`)
+
+// pathCatalogPaths returns the path listing the paths of the mounts visible
+// to the caller, with the operations they support and their sensitivity, for
+// policy authoring tools
+func (b *SystemBackend) pathCatalogPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "paths/catalog$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "paths",
+				OperationVerb:   "read",
+				OperationSuffix: "catalog",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"generic_mount_paths": {
+					Type:        framework.TypeBool,
+					Description: "Use generic mount paths, such as {secret_mount_path}, instead of the actual ones.",
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePathCatalogRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"paths": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(pathCatalogHelp["paths-catalog"][0]),
+			HelpDescription: strings.TrimSpace(pathCatalogHelp["paths-catalog"][1]),
+		},
+	}
+}
+
+// pathCatalogEntry describes a path of the catalog.
+type pathCatalogEntry struct {
+	path string
+	item *framework.OASPathItem
+
+	// literal is the part of the path before its first parameter, and re
+	// matches the instances of the path, for policy paths to be compared
+	// with it.
+	literal string
+	re      *regexp.Regexp
+}
+
+// pathCatalog returns the paths of the mounts visible to the caller, from
+// their OpenAPI document.
+func (b *SystemBackend) pathCatalog(ctx context.Context, req *logical.Request, genericMountPaths bool) ([]*pathCatalogEntry, error) {
+	d := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"generic_mount_paths": genericMountPaths,
+		},
+		Schema: map[string]*framework.FieldSchema{
+			"context":             {Type: framework.TypeString},
+			"generic_mount_paths": {Type: framework.TypeBool},
+			"namespace_paths":     {Type: framework.TypeBool},
+		},
+	}
+	buf, _, err := b.openAPIDocument(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+	var doc framework.OASDocument
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*pathCatalogEntry, 0, len(doc.Paths))
+	for path, item := range doc.Paths {
+		entries = append(entries, newPathCatalogEntry(strings.TrimPrefix(path, "/"), item))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].path < entries[j].path
+	})
+	return entries, nil
+}
+
+func newPathCatalogEntry(path string, item *framework.OASPathItem) *pathCatalogEntry {
+	literal := path
+	if loc := openAPIParamRe.FindStringIndex(path); loc != nil {
+		literal = path[:loc[0]]
+	}
+
+	// Parameters can span segments, such as the paths of KV secrets
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i, part := range openAPIParamRe.Split(path, -1) {
+		if i > 0 {
+			pattern.WriteString(".+")
+		}
+		pattern.WriteString(regexp.QuoteMeta(part))
+	}
+	pattern.WriteString("$")
+
+	return &pathCatalogEntry{
+		path:    path,
+		item:    item,
+		literal: literal,
+		re:      regexp.MustCompile(pattern.String()),
+	}
+}
+
+// catalogSensitivity returns the highest sensitivity of the paths of the
+// catalog which a policy path grants access to: the paths it matches, or for
+// a glob, the paths it is a prefix of.
+func catalogSensitivity(catalog []*pathCatalogEntry, policyPath string, glob bool) framework.PathSensitivity {
+	var sensitivity framework.PathSensitivity
+	for _, entry := range catalog {
+		covered := entry.re.MatchString(policyPath)
+		if glob && !covered {
+			covered = strings.HasPrefix(entry.literal, policyPath)
+		}
+		if covered && entry.item.Sensitivity.Rank() > sensitivity.Rank() {
+			sensitivity = entry.item.Sensitivity
+		}
+	}
+	return sensitivity
+}
+
+func (b *SystemBackend) handlePathCatalogRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	catalog, err := b.pathCatalog(ctx, req, d.Get("generic_mount_paths").(bool))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]interface{}, len(catalog))
+	for _, entry := range catalog {
+		item := entry.item
+		operations := make(map[string]interface{})
+		describe := func(capabilities []string, op *framework.OASOperation) {
+			if op == nil {
+				return
+			}
+			description := op.Summary
+			if description == "" {
+				description = op.Description
+			}
+			for _, capability := range capabilities {
+				operations[capability] = description
+			}
+		}
+		if strings.HasSuffix(entry.path, "/") {
+			describe([]string{ListCapability}, item.Get)
+		} else {
+			describe([]string{ReadCapability}, item.Get)
+		}
+		if item.CreateSupported {
+			describe([]string{CreateCapability, UpdateCapability}, item.Post)
+		} else {
+			describe([]string{UpdateCapability}, item.Post)
+		}
+		describe([]string{DeleteCapability}, item.Delete)
+
+		info := map[string]interface{}{
+			"policy_path":     openAPIParamRe.ReplaceAllString(entry.path, "+"),
+			"description":     item.Description,
+			"sudo":            item.Sudo,
+			"unauthenticated": item.Unauthenticated,
+			"operations":      operations,
+		}
+		if item.Sensitivity != "" {
+			info["sensitivity"] = string(item.Sensitivity)
+		}
+		paths[entry.path] = info
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"paths": paths,
+		},
+	}, nil
+}
+
+var pathCatalogHelp = map[string][2]string{
+	"paths-catalog": {
+		"List the paths of the mounts visible to the caller, for policy authoring.",
+		`
+Lists the paths of the secrets engines and auth methods the caller can see,
+with the policy path matching each of them, the capabilities their operations
+require along with what the operations do, whether they require sudo, and the
+sensitivity classification the backend declares for them.
+		`,
+	},
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCatalogSensitivity verifies that policy paths are given the highest
+// sensitivity of the catalog paths they grant access to.
+func TestCatalogSensitivity(t *testing.T) {
+	catalog := []*pathCatalogEntry{
+		newPathCatalogEntry("secret/config", &framework.OASPathItem{Sensitivity: framework.SensitivityModerate}),
+		newPathCatalogEntry("secret/data/{path}", &framework.OASPathItem{Sensitivity: framework.SensitivityHigh}),
+		newPathCatalogEntry("secret/rotate-root", &framework.OASPathItem{Sensitivity: framework.SensitivityCritical}),
+		newPathCatalogEntry("secret/metadata/{path}", &framework.OASPathItem{}),
+	}
+
+	for _, tc := range []struct {
+		path     string
+		glob     bool
+		expected framework.PathSensitivity
+	}{
+		{"secret/config", false, framework.SensitivityModerate},
+		{"secret/data/foo/bar", false, framework.SensitivityHigh},
+		{"secret/data/foo", true, framework.SensitivityHigh},
+		{"secret/data/", true, framework.SensitivityHigh},
+		{"secret/", true, framework.SensitivityCritical},
+		{"secret/metadata/foo", false, ""},
+		{"other/", true, ""},
+	} {
+		require.Equal(t, tc.expected, catalogSensitivity(catalog, tc.path, tc.glob), tc.path)
+	}
+}
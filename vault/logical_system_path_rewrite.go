@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pathRewritePaths returns paths that manage path rewrite rules
+func (b *SystemBackend) pathRewritePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/path-rewrites/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "path-rewrites",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handlePathRewriteList(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "rules",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(pathRewriteHelp["path-rewrites"][0]),
+			HelpDescription: strings.TrimSpace(pathRewriteHelp["path-rewrites"][1]),
+		},
+		{
+			Pattern: "config/path-rewrites/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "path-rewrites",
+				OperationSuffix: "rule",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the rewrite rule.",
+				},
+				"from": {
+					Type:        framework.TypeString,
+					Description: "Path, relative to the namespace of the request, which is rewritten, e.g. 'secret/'. A path ending in '/' rewrites every path below it.",
+				},
+				"to": {
+					Type:        framework.TypeString,
+					Description: "Path, relative to the same namespace, that requests are rewritten to, e.g. 'kv/data/'. Must end in '/' if and only if 'from' does.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePathRewriteRead(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"from": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"to": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"creation_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handlePathRewriteWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "write",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handlePathRewriteDelete(),
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(pathRewriteHelp["path-rewrite"][0]),
+			HelpDescription: strings.TrimSpace(pathRewriteHelp["path-rewrite"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) pathRewriteRules() map[string]*pathRewriteRule {
+	if state := b.Core.pathRewrites.Load(); state != nil {
+		return state.rules
+	}
+	return nil
+}
+
+func (b *SystemBackend) handlePathRewriteList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+		for _, rule := range b.pathRewriteRules() {
+			if rule.NamespaceID == ns.ID {
+				keys = append(keys, rule.Name)
+			}
+		}
+		sort.Strings(keys)
+		return logical.ListResponse(keys), nil
+	}
+}
+
+func (b *SystemBackend) handlePathRewriteRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rule, ok := b.pathRewriteRules()[pathRewriteRuleKey(ns.ID, d.Get("name").(string))]
+		if !ok {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name":          rule.Name,
+				"from":          rule.From,
+				"to":            rule.To,
+				"creation_time": rule.CreationTime.Format(time.RFC3339Nano),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handlePathRewriteWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		name := d.Get("name").(string)
+		rule := &pathRewriteRule{
+			Name:         name,
+			Namespace:    ns.Path,
+			NamespaceID:  ns.ID,
+			From:         strings.TrimPrefix(d.Get("from").(string), "/"),
+			To:           strings.TrimPrefix(d.Get("to").(string), "/"),
+			CreationTime: time.Now().UTC(),
+		}
+		if err := validatePathRewriteRule(rule); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		for _, other := range b.pathRewriteRules() {
+			if other.Name != rule.Name && other.NamespaceID == rule.NamespaceID && other.From == rule.From {
+				return logical.ErrorResponse("path rewrite rule %q already rewrites %q", other.Name, rule.From), nil
+			}
+		}
+
+		return nil, b.Core.savePathRewriteRule(ctx, rule, b.pathRewriteRules()[pathRewriteRuleKey(ns.ID, name)])
+	}
+}
+
+func (b *SystemBackend) handlePathRewriteDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rule, ok := b.pathRewriteRules()[pathRewriteRuleKey(ns.ID, d.Get("name").(string))]
+		if !ok {
+			return nil, nil
+		}
+		return nil, b.Core.deletePathRewriteRule(ctx, rule)
+	}
+}
+
+var pathRewriteHelp = map[string][2]string{
+	"path-rewrites": {
+		"List path rewrite rules.",
+		`Lists the names of the path rewrite rules of the namespace.`,
+	},
+	"path-rewrite": {
+		"Create, read or delete a path rewrite rule.",
+		`Path rewrite rules allow a mount to be moved or renamed without breaking
+clients which still use its old path, e.g. rewriting 'secret/' to 'kv/data/'.
+Rules are applied before ACLs are checked and the request is routed, so
+policies must grant access to the rewritten path. When several rules match a
+path, the one with the longest 'from' is applied; rules are never applied to
+the result of another rule. Requests which are rewritten record the rule and
+the original path in the 'path_rewrite' field of their audit entries. Paths
+under sys/ cannot be rewritten.`,
+	},
+}
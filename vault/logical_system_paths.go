@@ -33,6 +33,10 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 					Type:        framework.TypeCommaStringSlice,
 					Description: "A comma-separated string or array of strings indicating headers that are allowed on cross-origin requests.",
 				},
+				"max_age": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long browsers may cache the response to a preflight request for. Defaults to 300 seconds.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -59,6 +63,10 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 									Type:     framework.TypeCommaStringSlice,
 									Required: false,
 								},
+								"max_age": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -95,6 +103,250 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors"][1]),
 		},
 
+		{
+			Pattern: "config/cors/namespaces/(?P<namespace>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "cors-namespace",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"namespace": {
+					Type:        framework.TypeString,
+					Description: "The path of the namespace.",
+				},
+				"allowed_origins": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "A comma-separated string or array of strings indicating origins that may make cross-origin requests to the namespace.",
+				},
+				"allowed_headers": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "A comma-separated string or array of strings indicating headers that are allowed on cross-origin requests to the namespace.",
+				},
+				"max_age": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long browsers may cache the response to a preflight request for. Defaults to 300 seconds.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleCORSNamespaceRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the CORS policy of a namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"namespace": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"allowed_origins": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"allowed_headers": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"max_age": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleCORSNamespaceUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure the CORS policy of a namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleCORSNamespaceDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Remove the CORS policy of a namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpDescription: strings.TrimSpace(sysHelp["config/cors/namespaces"][0]),
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors/namespaces"][1]),
+		},
+
+		{
+			Pattern: "config/cors/namespaces/?$",
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleCORSNamespaceList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationPrefix: "cors-namespaces",
+						OperationVerb:   "list",
+					},
+					Summary: "Return the namespaces which have a CORS policy.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:        framework.TypeCommaStringSlice,
+									Description: "The paths of the namespaces which have a CORS policy. Omitted if the list is empty",
+									Required:    false,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpDescription: strings.TrimSpace(sysHelp["config/cors/namespaces"][0]),
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors/namespaces"][1]),
+		},
+
+		{
+			Pattern: "config/client-hints$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "client-hints",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"hedging_enabled": {
+					Type:        framework.TypeBool,
+					Description: "Whether clients should hedge slow idempotent reads by sending additional parallel attempts.",
+				},
+				"hedge_delay_ms": {
+					Type:        framework.TypeInt,
+					Description: "Time in milliseconds after which a hedged attempt is sent if the previous attempts have not completed.",
+				},
+				"max_hedged_requests": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of hedged attempts sent in addition to the original request.",
+				},
+				"circuit_breaker_enabled": {
+					Type:        framework.TypeBool,
+					Description: "Whether clients should stop sending requests to endpoints which keep failing.",
+				},
+				"circuit_breaker_failure_threshold": {
+					Type:        framework.TypeInt,
+					Description: "Number of consecutive failures after which a client opens the circuit for an endpoint.",
+				},
+				"circuit_breaker_open_duration": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time for which a client rejects requests to an endpoint once its circuit has been opened.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleClientHintsConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the client hints advertised to API clients.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      clientHintsResponseFields(),
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleClientHintsConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure the client hints advertised to API clients.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleClientHintsConfigDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Reset the client hints to their defaults.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/client-hints"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config/client-hints"][1]),
+		},
+
+		{
+			Pattern: "deprecations/usage$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deprecations",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleDeprecationUsageRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "usage",
+					},
+					Summary: "Report the usage of deprecated builtins and endpoints.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"usage": {
+									Type:        framework.TypeSlice,
+									Description: "Usage of deprecated builtins and endpoints per namespace and mount, most used first.",
+									Required:    true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleDeprecationUsageDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "reset",
+						OperationSuffix: "usage",
+					},
+					Summary: "Reset the usage of deprecated builtins and endpoints.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["deprecations/usage"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["deprecations/usage"][1]),
+		},
+
 		{
 			Pattern: "config/state/sanitized$",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -676,6 +928,27 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "loggers/overrides/?$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "loggers",
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleLoggerOverridesList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "list",
+						OperationSuffix: "overrides",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "List the loggers with a level or sink set at runtime, and when they revert.",
+				},
+			},
+		},
 		{
 			Pattern: "loggers/" + framework.MatchAllRegex("name"),
 			DisplayAttrs: &framework.DisplayAttributes{
@@ -684,13 +957,22 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 			Fields: map[string]*framework.FieldSchema{
 				"name": {
 					Type:        framework.TypeString,
-					Description: "The name of the logger to be modified.",
+					Description: "The name of the logger to be modified, or the path of a mount to modify the logger of its plugin.",
 				},
 				"level": {
 					Type: framework.TypeString,
 					Description: "Log verbosity level. Supported values (in order of detail) are " +
 						"\"trace\", \"debug\", \"info\", \"warn\", and \"error\".",
 				},
+				"sink": {
+					Type: framework.TypeString,
+					Description: "Name of a file, in the directory of the log file, to write the logs of the logger " +
+						"at the level to. The level of the logger in the main log is left unchanged.",
+				},
+				"duration": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time after which the level or sink reverts. If not set, it lasts until reverted.",
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
@@ -2596,17 +2878,23 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 					Query:       true,
 					Default:     false,
 				},
+				"namespace_paths": {
+					Type:        framework.TypeBool,
+					Description: "Prefix the paths with the path of the namespace of the request",
+					Query:       true,
+					Default:     false,
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
-					Callback: b.pathInternalOpenAPI,
+					Callback: b.Core.cachedRead(responseCacheOpenAPI, b.pathInternalOpenAPI),
 					DisplayAttrs: &framework.DisplayAttributes{
 						OperationSuffix: "open-api-document",
 					},
 				},
 				logical.UpdateOperation: &framework.PathOperation{
-					Callback: b.pathInternalOpenAPI,
+					Callback: b.Core.cachedRead(responseCacheOpenAPI, b.pathInternalOpenAPI),
 					DisplayAttrs: &framework.DisplayAttributes{
 						OperationSuffix: "open-api-document-with-parameters",
 					},
@@ -2615,6 +2903,46 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 
 			HelpSynopsis: "Generate an OpenAPI 3 document of all mounted paths.",
 		},
+		{
+			Pattern: "internal/specs/clients",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal",
+				OperationVerb:   "generate",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"context": {
+					Type:        framework.TypeString,
+					Description: "Context string appended to every operationId",
+					Query:       true,
+				},
+				"generic_mount_paths": {
+					Type:        framework.TypeBool,
+					Description: "Use generic mount paths",
+					Query:       true,
+					Default:     false,
+				},
+				"namespace_paths": {
+					Type:        framework.TypeBool,
+					Description: "Prefix the paths with the path of the namespace of the request",
+					Query:       true,
+					Default:     false,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalSpecsClients,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "client-manifest",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-specs-clients"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["internal-specs-clients"][1]),
+		},
 		{
 			Pattern: "internal/ui/authenticated-messages",
 
@@ -2647,24 +2975,49 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 			},
 		},
 		{
-			Pattern: "internal/ui/feature-flags",
+			Pattern: "internal/client-hints$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
-				OperationPrefix: "internal-ui",
-				OperationVerb:   "list",
-				OperationSuffix: "enabled-feature-flags",
+				OperationPrefix: "internal",
+				OperationVerb:   "read",
+				OperationSuffix: "client-hints",
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
-					// callback is absent because this is an unauthenticated method
-					Summary: "Lists enabled feature flags.",
+					Callback: b.pathInternalClientHintsRead,
+					Summary:  "Return the client behavior defaults advertised to API clients.",
 					Responses: map[int][]framework.Response{
 						http.StatusOK: {{
 							Description: "OK",
-							Fields: map[string]*framework.FieldSchema{
-								"feature_flags": {
-									Type:     framework.TypeCommaStringSlice,
+							Fields:      clientHintsResponseFields(),
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-client-hints"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["internal-client-hints"][1]),
+		},
+		{
+			Pattern: "internal/ui/feature-flags",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-ui",
+				OperationVerb:   "list",
+				OperationSuffix: "enabled-feature-flags",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					// callback is absent because this is an unauthenticated method
+					Summary: "Lists enabled feature flags.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"feature_flags": {
+									Type:     framework.TypeCommaStringSlice,
 									Required: true,
 								},
 							},
@@ -2828,6 +3181,13 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 				OperationVerb:   "read",
 				OperationSuffix: "resultant-acl",
 			},
+			Fields: map[string]*framework.FieldSchema{
+				"sensitivity": {
+					Type:        framework.TypeBool,
+					Description: "Include the highest sensitivity of the paths each ACL path grants access to, as declared by the backends.",
+					Query:       true,
+				},
+			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
 					Callback: b.pathInternalUIResultantACL,
@@ -3127,6 +3487,10 @@ func (b *SystemBackend) capabilitiesPaths() []*framework.Path {
 					Type:        framework.TypeCommaStringSlice,
 					Description: "Paths on which capabilities are being queried.",
 				},
+				"explain": {
+					Type:        framework.TypeBool,
+					Description: "If set, also return for each path the policy rule and policies its capabilities are derived from.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -3425,6 +3789,74 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["tidy_leases"][1]),
 		},
 
+		{
+			Pattern: "tidy/operations/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "tidy",
+				OperationSuffix: "operations",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleTidyOperationsList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["tidy_operations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["tidy_operations"][1]),
+		},
+
+		{
+			Pattern: "tidy/operations/(?P<id>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "tidy",
+				OperationSuffix: "operation",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the tidy operation.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTidyOperationRead,
+					Summary:  "Read the progress of a tidy operation.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleTidyOperationCancel,
+					Summary:  "Cancel a tidy operation.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["tidy_operation"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["tidy_operation"][1]),
+		},
+
 		{
 			Pattern: "leases/count$",
 
@@ -3662,6 +4094,28 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 				Query:       true,
 				Default:     "standard",
 			},
+			"subsystems": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Names of the subsystems to stream the logs of, such as \"core\" or \"secrets.kv\", including their sub-loggers. All subsystems are streamed by default.",
+				Query:       true,
+			},
+			"namespaces": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Paths or IDs of the namespaces to stream the logs about, including their child namespaces. Only logs with a namespace are streamed when set.",
+				Query:       true,
+			},
+			"framing": {
+				Type:        framework.TypeString,
+				Description: "Framing of the stream. Supported values are \"none\", streaming the logs as they are formatted, and \"jsonl\", streaming a JSON object per line, with log, heartbeat, and dropped log frames.",
+				Query:       true,
+				Default:     monitorFramingNone,
+			},
+			"heartbeat_interval": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Interval of the heartbeat frames of streams using the \"jsonl\" framing. Set to 0 to disable heartbeats.",
+				Query:       true,
+				Default:     10,
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -3678,6 +4132,40 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) inFlightRequestCancelPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "in-flight-req/cancel$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationVerb:   "cancel",
+			OperationSuffix: "in-flight-request",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"request_id": {
+				Type:        framework.TypeString,
+				Description: "ID of the in-flight request, as keyed in sys/in-flight-req.",
+				Required:    true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleInFlightRequestCancel,
+				Summary:  strings.TrimSpace(sysHelp["in-flight-req-cancel"][0]),
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["in-flight-req-cancel"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["in-flight-req-cancel"][1]),
+	}
+}
+
 func (b *SystemBackend) inFlightRequestPath() *framework.Path {
 	return &framework.Path{
 		Pattern: "in-flight-req",
@@ -3703,6 +4191,68 @@ func (b *SystemBackend) inFlightRequestPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) lastCrashPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "diagnostics/last-crash$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "diagnostics",
+			OperationVerb:   "read",
+			OperationSuffix: "last-crash",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback:    b.handleLastCrashRead,
+				Summary:     strings.TrimSpace(sysHelp["diagnostics/last-crash"][0]),
+				Description: strings.TrimSpace(sysHelp["diagnostics/last-crash"][1]),
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"reason": {
+								Type:        framework.TypeString,
+								Description: "Why the journal was last written: shutdown, panic, or unclean_exit when the process died without notice.",
+								Required:    true,
+							},
+							"panic": {
+								Type:        framework.TypeString,
+								Description: "The value of the panic, if the process panicked.",
+							},
+							"written_at": {
+								Type:        framework.TypeTime,
+								Description: "When the journal was last written.",
+								Required:    true,
+							},
+							"started_at": {
+								Type:        framework.TypeTime,
+								Description: "When the previous run started.",
+								Required:    true,
+							},
+							"version": {
+								Type:        framework.TypeString,
+								Description: "The version of the previous run.",
+								Required:    true,
+							},
+							"requests": {
+								Type:        framework.TypeSlice,
+								Description: "The requests in flight when the journal was last written, longest running first.",
+								Required:    true,
+							},
+						},
+					}},
+					http.StatusNoContent: {{
+						Description: "No journal of a previous run was found.",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["diagnostics/last-crash"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["diagnostics/last-crash"][1]),
+	}
+}
+
 func (b *SystemBackend) hostInfoPath() *framework.Path {
 	return &framework.Path{
 		Pattern: "host-info/?",
@@ -3846,6 +4396,50 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 					Description: strings.TrimSpace(sysHelp["identity_token_key"][0]),
 					Required:    false,
 				},
+				"client_count_simulation_period": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["client_count_simulation_period"][0]),
+				},
+				"strict_field_validation": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["strict_field_validation"][0]),
+				},
+				"compatibility_level": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["compatibility_level"][0]),
+				},
+				"custom_response_headers": {
+					Type:        framework.TypeKVPairs,
+					Description: strings.TrimSpace(sysHelp["custom_response_headers"][0]),
+				},
+				"max_child_token_depth": {
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["max_child_token_depth"][0]),
+				},
+				"max_entity_tokens": {
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["max_entity_tokens"][0]),
+				},
+				"entity_token_limit_action": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["entity_token_limit_action"][0]),
+				},
+				"login_response_entity_metadata": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["login_response_entity_metadata"][0]),
+				},
+				"login_response_group_names": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["login_response_group_names"][0]),
+				},
+				"login_response_namespace": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["login_response_namespace"][0]),
+				},
+				"login_response_roles": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["login_response_roles"][0]),
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
@@ -3936,6 +4530,50 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"strict_field_validation": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"compatibility_level": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"custom_response_headers": {
+									Type:     framework.TypeKVPairs,
+									Required: false,
+								},
+								"client_count_simulation_end": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"max_child_token_depth": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
+								"max_entity_tokens": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
+								"entity_token_limit_action": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"login_response_entity_metadata": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
+								"login_response_group_names": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"login_response_namespace": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"login_response_roles": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -3992,6 +4630,11 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 					Default:     false,
 					Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
 				},
+				"mount_key": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_key"][0]),
+				},
 				"external_entropy_access": {
 					Type:        framework.TypeBool,
 					Default:     false,
@@ -4706,6 +5349,50 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["identity_token_key"][0]),
 				},
+				"client_count_simulation_period": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["client_count_simulation_period"][0]),
+				},
+				"strict_field_validation": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["strict_field_validation"][0]),
+				},
+				"compatibility_level": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["compatibility_level"][0]),
+				},
+				"custom_response_headers": {
+					Type:        framework.TypeKVPairs,
+					Description: strings.TrimSpace(sysHelp["custom_response_headers"][0]),
+				},
+				"max_child_token_depth": {
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["max_child_token_depth"][0]),
+				},
+				"max_entity_tokens": {
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["max_entity_tokens"][0]),
+				},
+				"entity_token_limit_action": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["entity_token_limit_action"][0]),
+				},
+				"login_response_entity_metadata": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["login_response_entity_metadata"][0]),
+				},
+				"login_response_group_names": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["login_response_group_names"][0]),
+				},
+				"login_response_namespace": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["login_response_namespace"][0]),
+				},
+				"login_response_roles": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["login_response_roles"][0]),
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -4808,6 +5495,50 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"strict_field_validation": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"compatibility_level": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"custom_response_headers": {
+									Type:     framework.TypeKVPairs,
+									Required: false,
+								},
+								"client_count_simulation_end": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"max_child_token_depth": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
+								"max_entity_tokens": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
+								"entity_token_limit_action": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"login_response_entity_metadata": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
+								"login_response_group_names": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"login_response_namespace": {
+									Type:     framework.TypeBool,
+									Required: false,
+								},
+								"login_response_roles": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -4864,6 +5595,11 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 					Default:     false,
 					Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
 				},
+				"mount_key": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_key"][0]),
+				},
 				"external_entropy_access": {
 					Type:        framework.TypeBool,
 					Default:     false,
@@ -5295,3 +6031,32 @@ func (b *SystemBackend) wellKnownPaths() []*framework.Path {
 		},
 	}
 }
+
+func clientHintsResponseFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"hedging_enabled": {
+			Type:     framework.TypeBool,
+			Required: true,
+		},
+		"hedge_delay_ms": {
+			Type:     framework.TypeInt,
+			Required: true,
+		},
+		"max_hedged_requests": {
+			Type:     framework.TypeInt,
+			Required: true,
+		},
+		"circuit_breaker_enabled": {
+			Type:     framework.TypeBool,
+			Required: true,
+		},
+		"circuit_breaker_failure_threshold": {
+			Type:     framework.TypeInt,
+			Required: true,
+		},
+		"circuit_breaker_open_duration": {
+			Type:     framework.TypeDurationSecond,
+			Required: true,
+		},
+	}
+}
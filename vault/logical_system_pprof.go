@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -266,7 +268,192 @@ render pages.`,
 				},
 			},
 		},
+		{
+			Pattern: "pprof/auto/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "pprof",
+				OperationVerb:   "list",
+				OperationSuffix: "automatic-dumps",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handlePprofAutoList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Lists the pprof dumps captured automatically by the watchdog.",
+					Description: `Lists the pprof dumps captured automatically when the goroutine count, the
+heap, or the GC pauses exceeded their configured thresholds, most recent first.`,
+				},
+			},
+		},
+		{
+			Pattern: "pprof/auto/" + framework.GenericNameRegex("id") + "/" + framework.GenericNameRegex("profile") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "pprof",
+				OperationVerb:   "read",
+				OperationSuffix: "automatic-dump-profile",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the dump.",
+				},
+				"profile": {
+					Type:        framework.TypeString,
+					Description: "The name of the profile, such as goroutine or heap.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePprofAutoProfileRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary:     "Returns a pprof-formatted profile of a dump captured by the watchdog.",
+					Description: "Returns a pprof-formatted profile of a dump captured automatically by the watchdog.",
+				},
+			},
+		},
+		{
+			Pattern: "pprof/auto/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "pprof",
+				OperationSuffix: "automatic-dump",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the dump.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePprofAutoRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary:     "Returns the trigger and the profiles of a dump captured by the watchdog.",
+					Description: "Returns the trigger and the profiles of a dump captured automatically by the watchdog.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handlePprofAutoDelete,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary:     "Deletes a dump captured by the watchdog.",
+					Description: "Deletes a dump captured automatically by the watchdog.",
+				},
+			},
+		},
+	}
+}
+
+func pprofDumpInfo(dump *pprofDump) map[string]interface{} {
+	return map[string]interface{}{
+		"trigger":     dump.Trigger,
+		"value":       dump.Value,
+		"threshold":   dump.Threshold,
+		"captured_at": dump.CapturedAt.Format(time.RFC3339Nano),
+		"profiles":    dump.Profiles,
+	}
+}
+
+func (b *SystemBackend) handlePprofAutoList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	w := b.Core.pprofWatchdog
+	if w == nil {
+		return logical.ErrorResponse("the pprof watchdog is not enabled"), nil
+	}
+
+	dumps, err := w.list()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(dumps))
+	keyInfo := make(map[string]interface{}, len(dumps))
+	for _, dump := range dumps {
+		keys = append(keys, dump.ID)
+		keyInfo[dump.ID] = pprofDumpInfo(dump)
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handlePprofAutoRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	w := b.Core.pprofWatchdog
+	if w == nil {
+		return logical.ErrorResponse("the pprof watchdog is not enabled"), nil
 	}
+
+	id := d.Get("id").(string)
+	if !pprofWatchdogDumpIDRe.MatchString(id) {
+		return nil, nil
+	}
+	dump, err := w.dump(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{Data: pprofDumpInfo(dump)}, nil
+}
+
+func (b *SystemBackend) handlePprofAutoProfileRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	w := b.Core.pprofWatchdog
+	if w == nil {
+		return logical.ErrorResponse("the pprof watchdog is not enabled"), nil
+	}
+
+	profile, err := w.readProfile(d.Get("id").(string), d.Get("profile").(string))
+	if errors.Is(err, errPprofDumpNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/octet-stream",
+			logical.HTTPRawBody:     profile,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handlePprofAutoDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	w := b.Core.pprofWatchdog
+	if w == nil {
+		return logical.ErrorResponse("the pprof watchdog is not enabled"), nil
+	}
+
+	return nil, w.delete(d.Get("id").(string))
 }
 
 func (b *SystemBackend) handlePprofIndex(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -0,0 +1,211 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/version"
+)
+
+// clientManifestVersion is the version of the format of the client manifest,
+// bumped on changes which are not backwards compatible for its consumers.
+const clientManifestVersion = 1
+
+// clientManifest describes the endpoints of the mounts visible to the caller,
+// for generating typed clients of a cluster. It is derived from the OpenAPI
+// document, keeping what clients need to call the endpoints.
+type clientManifest struct {
+	ManifestVersion int                       `json:"manifest_version"`
+	VaultVersion    string                    `json:"vault_version"`
+	Namespace       string                    `json:"namespace"`
+	Endpoints       []*clientManifestEndpoint `json:"endpoints"`
+}
+
+type clientManifestEndpoint struct {
+	Path            string                     `json:"path"`
+	Sudo            bool                       `json:"sudo,omitempty"`
+	Unauthenticated bool                       `json:"unauthenticated,omitempty"`
+	Operations      []*clientManifestOperation `json:"operations"`
+}
+
+type clientManifestOperation struct {
+	Method      string                     `json:"method"`
+	OperationID string                     `json:"operation_id"`
+	Summary     string                     `json:"summary,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []*clientManifestParameter `json:"parameters,omitempty"`
+}
+
+type clientManifestParameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Type        string `json:"type"`
+	ItemType    string `json:"item_type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// pathInternalSpecsClients returns the client manifest of the mounts visible
+// to the caller, cached along with the OpenAPI document it is derived from.
+func (b *SystemBackend) pathInternalSpecsClients(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, cacheKey, err := b.openAPIDocument(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestKey := "clients/" + cacheKey
+	if cached, ok := b.openAPICache.Get(manifestKey); ok {
+		return openAPIResponse(cached.([]byte), manifestKey), nil
+	}
+
+	var doc framework.OASDocument
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(buildClientManifest(&doc, ns.Path))
+	if err != nil {
+		return nil, err
+	}
+	b.openAPICache.Add(manifestKey, manifest)
+
+	return openAPIResponse(manifest, manifestKey), nil
+}
+
+// buildClientManifest derives the client manifest from the OpenAPI document,
+// skipping the unpublished paths, which have no operations.
+func buildClientManifest(doc *framework.OASDocument, namespacePath string) *clientManifest {
+	manifest := &clientManifest{
+		ManifestVersion: clientManifestVersion,
+		VaultVersion:    version.GetVersion().Version,
+		Namespace:       namespacePath,
+		Endpoints:       make([]*clientManifestEndpoint, 0, len(doc.Paths)),
+	}
+
+	for path, item := range doc.Paths {
+		endpoint := &clientManifestEndpoint{
+			Path:            path,
+			Sudo:            item.Sudo,
+			Unauthenticated: item.Unauthenticated,
+		}
+		for _, op := range []struct {
+			method    string
+			operation *framework.OASOperation
+		}{
+			{"GET", item.Get},
+			{"POST", item.Post},
+			{"DELETE", item.Delete},
+		} {
+			if op.operation == nil {
+				continue
+			}
+			endpoint.Operations = append(endpoint.Operations, &clientManifestOperation{
+				Method:      op.method,
+				OperationID: op.operation.OperationID,
+				Summary:     op.operation.Summary,
+				Deprecated:  op.operation.Deprecated,
+				Parameters:  clientManifestParameters(doc, item, op.operation),
+			})
+		}
+		if len(endpoint.Operations) > 0 {
+			manifest.Endpoints = append(manifest.Endpoints, endpoint)
+		}
+	}
+
+	sort.Slice(manifest.Endpoints, func(i, j int) bool {
+		return manifest.Endpoints[i].Path < manifest.Endpoints[j].Path
+	})
+	return manifest
+}
+
+// clientManifestParameters returns the path parameters of the operation,
+// followed by its query parameters and the properties of its request body,
+// each group sorted by name.
+func clientManifestParameters(doc *framework.OASDocument, item *framework.OASPathItem, operation *framework.OASOperation) []*clientManifestParameter {
+	var params []*clientManifestParameter
+	for _, param := range append(append([]framework.OASParameter{}, item.Parameters...), operation.Parameters...) {
+		p := &clientManifestParameter{
+			Name:        param.Name,
+			In:          param.In,
+			Description: param.Description,
+			Required:    param.Required,
+			Deprecated:  param.Deprecated,
+		}
+		setClientManifestParameterType(p, param.Schema)
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].In != params[j].In {
+			return params[i].In == "path"
+		}
+		return params[i].Name < params[j].Name
+	})
+
+	body := requestBodySchema(doc, operation.RequestBody)
+	if body == nil {
+		return params
+	}
+	required := make(map[string]bool, len(body.Required))
+	for _, name := range body.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(body.Properties))
+	for name := range body.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema := body.Properties[name]
+		p := &clientManifestParameter{
+			Name:        name,
+			In:          "body",
+			Description: schema.Description,
+			Required:    required[name],
+			Deprecated:  schema.Deprecated,
+		}
+		setClientManifestParameterType(p, schema)
+		params = append(params, p)
+	}
+	return params
+}
+
+// requestBodySchema returns the JSON schema of the request body, resolving
+// references to the components of the document.
+func requestBodySchema(doc *framework.OASDocument, body *framework.OASRequestBody) *framework.OASSchema {
+	if body == nil {
+		return nil
+	}
+	media, ok := body.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	schema := media.Schema
+	if schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func setClientManifestParameterType(p *clientManifestParameter, schema *framework.OASSchema) {
+	p.Type = "string"
+	if schema == nil || schema.Type == "" {
+		return
+	}
+	p.Type = schema.Type
+	if schema.Type == "array" && schema.Items != nil && schema.Items.Type != "" {
+		p.ItemType = schema.Items.Type
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// storageIntegrityPaths returns paths that report and configure the storage
+// integrity scrubber
+func (b *SystemBackend) storageIntegrityPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "storage/integrity$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "storage-integrity",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageIntegrityRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "report",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"last_scrub_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"scrubs": {
+									Type:     framework.TypeInt64,
+									Required: true,
+								},
+								"entries_checked": {
+									Type:     framework.TypeInt64,
+									Required: true,
+								},
+								"corrupt_entries": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(storageIntegrityHelp["storage-integrity"][0]),
+			HelpDescription: strings.TrimSpace(storageIntegrityHelp["storage-integrity"][1]),
+		},
+
+		{
+			Pattern: "storage/integrity/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "storage-integrity",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"enabled": {
+					Type:        framework.TypeBool,
+					Default:     true,
+					Description: "If set, storage entries are sampled and checked in the background.",
+				},
+				"interval": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultStorageIntegrityInterval.Seconds()),
+					Description: "How often storage entries are sampled and checked.",
+				},
+				"sample_size": {
+					Type:        framework.TypeInt,
+					Default:     defaultStorageIntegritySampleSize,
+					Description: "Number of storage entries sampled and checked each time.",
+				},
+				"auto_quarantine": {
+					Type:        framework.TypeBool,
+					Description: "If set, corrupt storage entries are quarantined as soon as they are found.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageIntegrityConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"enabled": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"interval": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"sample_size": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"auto_quarantine": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageIntegrityConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(storageIntegrityHelp["storage-integrity-config"][0]),
+			HelpDescription: strings.TrimSpace(storageIntegrityHelp["storage-integrity-config"][1]),
+		},
+
+		{
+			Pattern: "storage/integrity/scrub$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "storage-integrity",
+				OperationVerb:   "scrub",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"sample_size": {
+					Type:        framework.TypeInt,
+					Description: "Number of storage entries to sample and check. Defaults to the configured sample size.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageIntegrityScrub(),
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"entries_checked": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"corrupt_entries": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(storageIntegrityHelp["storage-integrity-scrub"][0]),
+			HelpDescription: strings.TrimSpace(storageIntegrityHelp["storage-integrity-scrub"][1]),
+		},
+
+		{
+			Pattern: "storage/integrity/quarantine$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "storage-integrity",
+				OperationVerb:   "quarantine",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"key": {
+					Type:        framework.TypeString,
+					Description: "Storage key of the corrupt entry to quarantine.",
+					Required:    true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageIntegrityQuarantine(),
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(storageIntegrityHelp["storage-integrity-quarantine"][0]),
+			HelpDescription: strings.TrimSpace(storageIntegrityHelp["storage-integrity-quarantine"][1]),
+		},
+	}
+}
+
+func corruptStorageEntriesResponse(entries []corruptStorageEntry) []map[string]interface{} {
+	resp := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		data := map[string]interface{}{
+			"key":           entry.Key,
+			"error":         entry.Error,
+			"detected_time": entry.DetectedTime.Format(time.RFC3339),
+			"quarantined":   entry.Quarantined,
+		}
+		if entry.Quarantined {
+			data["quarantine_key"] = storageIntegrityQuarantinePrefix + entry.Key
+			data["quarantined_time"] = entry.QuarantinedTime.Format(time.RFC3339)
+		}
+		resp = append(resp, data)
+	}
+	return resp
+}
+
+func (b *SystemBackend) handleStorageIntegrityRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		s := b.Core.storageIntegrity.Load()
+		if s == nil {
+			return nil, errStorageIntegrityNotRunning
+		}
+
+		report, corrupt := s.getReport()
+		data := map[string]interface{}{
+			"scrubs":          report.Scrubs,
+			"entries_checked": report.EntriesChecked,
+			"corrupt_entries": corruptStorageEntriesResponse(corrupt),
+		}
+		if !report.LastScrubTime.IsZero() {
+			data["last_scrub_time"] = report.LastScrubTime.Format(time.RFC3339)
+		}
+		return &logical.Response{Data: data}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageIntegrityConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		s := b.Core.storageIntegrity.Load()
+		if s == nil {
+			return nil, errStorageIntegrityNotRunning
+		}
+
+		config := s.getConfig()
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"enabled":         config.Enabled,
+				"interval":        int64(config.Interval.Seconds()),
+				"sample_size":     config.SampleSize,
+				"auto_quarantine": config.AutoQuarantine,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageIntegrityConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		s := b.Core.storageIntegrity.Load()
+		if s == nil {
+			return nil, errStorageIntegrityNotRunning
+		}
+
+		config := s.getConfig()
+		if v, ok := d.GetOk("enabled"); ok {
+			config.Enabled = v.(bool)
+		}
+		if v, ok := d.GetOk("interval"); ok {
+			config.Interval = time.Duration(v.(int)) * time.Second
+		}
+		if v, ok := d.GetOk("sample_size"); ok {
+			config.SampleSize = v.(int)
+		}
+		if v, ok := d.GetOk("auto_quarantine"); ok {
+			config.AutoQuarantine = v.(bool)
+		}
+
+		switch {
+		case config.Interval < time.Minute:
+			return logical.ErrorResponse("interval must be at least one minute"), nil
+		case config.SampleSize <= 0 || config.SampleSize > storageIntegrityMaxSampleSize:
+			return logical.ErrorResponse(fmt.Sprintf("sample_size must be between 1 and %d", storageIntegrityMaxSampleSize)), nil
+		}
+
+		return nil, s.setConfig(ctx, &config)
+	}
+}
+
+func (b *SystemBackend) handleStorageIntegrityScrub() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		s := b.Core.storageIntegrity.Load()
+		if s == nil {
+			return nil, errStorageIntegrityNotRunning
+		}
+
+		sampleSize := d.Get("sample_size").(int)
+		if sampleSize < 0 || sampleSize > storageIntegrityMaxSampleSize {
+			return logical.ErrorResponse(fmt.Sprintf("sample_size must be between 1 and %d", storageIntegrityMaxSampleSize)), nil
+		}
+
+		result, err := s.scrub(ctx, sampleSize)
+		if err != nil {
+			return nil, err
+		}
+
+		corrupt := make([]corruptStorageEntry, 0, len(result.CorruptEntries))
+		for _, entry := range result.CorruptEntries {
+			corrupt = append(corrupt, *entry)
+		}
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"entries_checked": result.EntriesChecked,
+				"corrupt_entries": corruptStorageEntriesResponse(corrupt),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageIntegrityQuarantine() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		s := b.Core.storageIntegrity.Load()
+		if s == nil {
+			return nil, errStorageIntegrityNotRunning
+		}
+
+		key := d.Get("key").(string)
+		switch {
+		case key == "":
+			return logical.ErrorResponse("missing key"), logical.ErrInvalidRequest
+		case strings.HasPrefix(key, storageIntegrityQuarantinePrefix):
+			return logical.ErrorResponse("key is already quarantined"), logical.ErrInvalidRequest
+		case !hasStorageIntegrityPrefix(key):
+			return logical.ErrorResponse(fmt.Sprintf("only keys under %s can be quarantined", strings.Join(storageIntegrityPrefixes, ", "))), logical.ErrInvalidRequest
+		}
+
+		err := s.quarantine(ctx, key)
+		if errors.Is(err, errStorageEntryNotCorrupt) {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+}
+
+// hasStorageIntegrityPrefix returns whether the key is under one of the
+// storage prefixes checked by the scrubber, as only their entries are known
+// to be encrypted by the barrier.
+func hasStorageIntegrityPrefix(key string) bool {
+	for _, prefix := range storageIntegrityPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			_, plaintext := storageIntegrityPlaintextKeys[key]
+			return !plaintext
+		}
+	}
+	return false
+}
+
+var storageIntegrityHelp = map[string][2]string{
+	"storage-integrity": {
+		"Report the corrupt storage entries found by the storage integrity scrubber.",
+		`The storage integrity scrubber runs on the active node. It samples storage
+entries in the background and checks that they decrypt, which also verifies
+their integrity, as the barrier encrypts entries with AES-GCM. This endpoint
+reports how many entries were checked and the corrupt entries found, so that
+silent corruption of the storage is caught before a failover exposes it.`,
+	},
+	"storage-integrity-config": {
+		"Configure the storage integrity scrubber.",
+		`Every 'interval', the scrubber samples 'sample_size' storage entries at random
+and checks them. If 'auto_quarantine' is set, the corrupt entries found are
+quarantined right away.`,
+	},
+	"storage-integrity-scrub": {
+		"Sample and check storage entries now.",
+		`Samples storage entries at random and checks them, as the scrubber does in
+the background, and returns the corrupt entries found.`,
+	},
+	"storage-integrity-quarantine": {
+		"Quarantine a corrupt storage entry.",
+		`Moves the corrupt storage entry at 'key' under the
+core/storage-integrity/quarantine/ storage prefix, where Vault no longer reads
+it. The entry is checked again first, and is not quarantined if it is no
+longer corrupt.`,
+	},
+}
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
 	"github.com/hashicorp/go-uuid"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/builtinplugins"
 	"github.com/hashicorp/vault/helper/experiments"
 	"github.com/hashicorp/vault/helper/identity"
@@ -158,6 +160,67 @@ func TestSystemConfigCORS(t *testing.T) {
 	}
 }
 
+func TestSystemConfigClientHints(t *testing.T) {
+	b := testSystemBackend(t)
+	paths := b.(*SystemBackend).configPaths()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "")
+	b.(*SystemBackend).Core.systemBarrierView = view
+
+	// Defaults are advertised before anything has been configured
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/client-hints")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["hedging_enabled"])
+	require.Equal(t, 500, resp.Data["hedge_delay_ms"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/client-hints")
+	req.Data["hedging_enabled"] = true
+	req.Data["hedge_delay_ms"] = 250
+	req.Data["circuit_breaker_enabled"] = true
+	req.Data["circuit_breaker_open_duration"] = "1m"
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 1, req.Operation), resp, true)
+
+	expected := map[string]interface{}{
+		"hedging_enabled":                   true,
+		"hedge_delay_ms":                    250,
+		"max_hedged_requests":               1,
+		"circuit_breaker_enabled":           true,
+		"circuit_breaker_failure_threshold": 5,
+		"circuit_breaker_open_duration":     60,
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/client-hints")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 1, req.Operation), resp, true)
+	require.Equal(t, expected, resp.Data)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/client-hints")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, expected, resp.Data)
+
+	// Invalid combinations are rejected
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/client-hints")
+	req.Data["max_hedged_requests"] = 0
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "config/client-hints")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 1, req.Operation), resp, true)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/client-hints")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, clientHintsResponseData(defaultClientHintsConfig()), resp.Data)
+}
+
 func TestSystemBackend_mounts(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.ReadOperation, "mounts")
@@ -907,6 +970,81 @@ func TestSystemBackend_PathCapabilities(t *testing.T) {
 	nonRootCheckFunc(t, resp)
 }
 
+// TestSystemBackend_CapabilitiesSelfExplain verifies that capabilities-self
+// explains the rule and policies each path's capabilities are derived from.
+func TestSystemBackend_CapabilitiesSelfExplain(t *testing.T) {
+	core, b, rootToken := testCoreSystemBackend(t)
+
+	for name, rules := range map[string]string{
+		"test": capabilitiesPolicy,
+		"deny": `path "foo/bar/secret" { capabilities = ["deny"] }`,
+		"read": `path "bar/baz" { capabilities = ["read"] }`,
+	} {
+		policy, err := ParseACLPolicy(namespace.RootNamespace, rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy.Name = name
+		if err := core.policyStore.SetPolicy(namespace.RootContext(nil), policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testMakeServiceTokenViaBackend(t, core.tokenStore, rootToken, "tokenid", "", []string{"test", "deny", "read"})
+
+	req := &logical.Request{
+		ClientToken: "tokenid",
+		Path:        "capabilities-self",
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"paths":   []string{"foo/bar/sample", "foo/bar/secret", "bar/baz", "other"},
+			"explain": true,
+		},
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	explanations := resp.Data["explanations"].(map[string]*CapabilitiesExplanation)
+
+	policyNames := func(policies []logical.PolicyInfo) []string {
+		var names []string
+		for _, p := range policies {
+			names = append(names, p.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	e := explanations["foo/bar/sample"]
+	if e.MatchedRule != "foo/bar*" || !reflect.DeepEqual(e.Capabilities, []string{"create", "sudo", "update"}) {
+		t.Fatalf("unexpected explanation: %#v", e)
+	}
+	if names := policyNames(e.GrantedBy["sudo"]); !reflect.DeepEqual(names, []string{"test"}) {
+		t.Fatalf("expected sudo to be granted by test, got %v", names)
+	}
+
+	e = explanations["foo/bar/secret"]
+	if e.MatchedRule != "foo/bar/secret" || !reflect.DeepEqual(e.Capabilities, []string{"deny"}) {
+		t.Fatalf("unexpected explanation: %#v", e)
+	}
+	if names := policyNames(e.DeniedBy); !reflect.DeepEqual(names, []string{"deny"}) {
+		t.Fatalf("expected path to be denied by deny, got %v", names)
+	}
+
+	e = explanations["bar/baz"]
+	if names := policyNames(e.GrantedBy["read"]); !reflect.DeepEqual(names, []string{"read", "test"}) {
+		t.Fatalf("expected read to be granted by read and test, got %v", names)
+	}
+	if names := policyNames(e.GrantedBy["delete"]); !reflect.DeepEqual(names, []string{"test"}) {
+		t.Fatalf("expected delete to be granted by test, got %v", names)
+	}
+
+	e = explanations["other"]
+	if e.MatchedRule != "" || !reflect.DeepEqual(e.Capabilities, []string{"deny"}) || e.Reason == "" {
+		t.Fatalf("unexpected explanation: %#v", e)
+	}
+}
+
 func TestSystemBackend_Capabilities_BC(t *testing.T) {
 	testCapabilities(t, "capabilities")
 	testCapabilities(t, "capabilities-self")
@@ -4675,6 +4813,95 @@ func TestSystemBackend_OpenAPI(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_OpenAPICache(t *testing.T) {
+	coreConfig := &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": LeasedPassthroughBackendFactory,
+		},
+	}
+	c, _, rootToken := TestCoreUnsealedWithConfig(t, coreConfig)
+	b := c.systemBackend
+
+	readSpec := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, logical.ReadOperation, "internal/specs/openapi")
+		for k, v := range data {
+			req.Data[k] = v
+		}
+		req.ClientToken = rootToken
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Data[logical.HTTPETagHeader])
+		return resp
+	}
+
+	first := readSpec(nil)
+	second := readSpec(nil)
+	require.Equal(t, first.Data[logical.HTTPETagHeader], second.Data[logical.HTTPETagHeader])
+	require.Equal(t, first.Data[logical.HTTPRawBody], second.Data[logical.HTTPRawBody])
+
+	// The parameters are part of the ETag
+	generic := readSpec(map[string]interface{}{"generic_mount_paths": true})
+	require.NotEqual(t, first.Data[logical.HTTPETagHeader], generic.Data[logical.HTTPETagHeader])
+
+	// A new mount changes the document
+	req := logical.TestRequest(t, logical.UpdateOperation, "mounts/custom/kv/")
+	req.Data["type"] = "kv"
+	_, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+
+	mounted := readSpec(nil)
+	require.NotEqual(t, first.Data[logical.HTTPETagHeader], mounted.Data[logical.HTTPETagHeader])
+
+	var oapi map[string]interface{}
+	require.NoError(t, jsonutil.DecodeJSON(mounted.Data[logical.HTTPRawBody].([]byte), &oapi))
+	doc, err := framework.NewOASDocumentFromMap(oapi)
+	require.NoError(t, err)
+	require.Contains(t, doc.Paths, "/custom/kv/^.*$")
+}
+
+func TestSystemBackend_SpecsClients(t *testing.T) {
+	c, _, rootToken := TestCoreUnsealed(t)
+	b := c.systemBackend
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/specs/clients")
+	req.ClientToken = rootToken
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data[logical.HTTPETagHeader])
+
+	var manifest clientManifest
+	require.NoError(t, jsonutil.DecodeJSON(resp.Data[logical.HTTPRawBody].([]byte), &manifest))
+	require.Equal(t, clientManifestVersion, manifest.ManifestVersion)
+	require.NotEmpty(t, manifest.Endpoints)
+
+	var policy *clientManifestEndpoint
+	for _, endpoint := range manifest.Endpoints {
+		if endpoint.Path == "/sys/policy/{name}" {
+			policy = endpoint
+		}
+	}
+	require.NotNil(t, policy)
+
+	methods := make(map[string]*clientManifestOperation)
+	for _, op := range policy.Operations {
+		methods[op.Method] = op
+	}
+	require.Contains(t, methods, "GET")
+	require.Contains(t, methods, "POST")
+	require.Contains(t, methods, "DELETE")
+
+	params := make(map[string]*clientManifestParameter)
+	for _, param := range methods["POST"].Parameters {
+		params[param.Name] = param
+	}
+	require.Contains(t, params, "name")
+	require.Equal(t, "path", params["name"].In)
+	require.True(t, params["name"].Required)
+	require.Contains(t, params, "policy")
+	require.Equal(t, "body", params["policy"].In)
+}
+
 func TestSystemBackend_PathWildcardPreflight(t *testing.T) {
 	core, b, _ := testCoreSystemBackend(t)
 
@@ -6815,6 +7042,18 @@ func (m *testingCustomMessageManager) DeleteMessage(_ context.Context, _ string)
 	return nil
 }
 
+func (m *testingCustomMessageManager) PendingAcknowledgments(_ context.Context, _ string) ([]uicustommessages.Message, error) {
+	return nil, nil
+}
+
+func (m *testingCustomMessageManager) Acknowledge(_ context.Context, _, _ string, _ int) error {
+	return nil
+}
+
+func (m *testingCustomMessageManager) ReadAcknowledgments(_ context.Context, _ string) ([]uicustommessages.Acknowledgment, error) {
+	return nil, nil
+}
+
 // TestPathInternalUIUnauthenticatedMessages verifies the correct behaviour of
 // the pathInternalUIUnauthenticatedMessages method, which is to call the
 // FindMessages method of the Core.customMessagesManager field with a FindFilter
@@ -7073,3 +7312,91 @@ func TestWellKnownSysApi(t *testing.T) {
 	require.NoError(t, err, "failed get well-known request")
 	require.Nil(t, resp, "response from unknown should have been nil was %v", resp)
 }
+
+func TestSystemBackend_LoggerOverrides(t *testing.T) {
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		Logger:   hclog.NewInterceptLogger(&hclog.LoggerOptions{Level: hclog.Info}),
+		LogLevel: "info",
+	})
+	b := c.systemBackend
+	ctx := namespace.RootContext(nil)
+
+	entry := c.router.MatchingMountEntry(ctx, "cubbyhole/")
+	require.NotNil(t, entry)
+	mountLogger := fmt.Sprintf("secrets.cubbyhole.%s", entry.Accessor)
+
+	// Mount paths resolve to the logger of the mount
+	req := logical.TestRequest(t, logical.UpdateOperation, "loggers/cubbyhole/")
+	req.Data["level"] = "debug"
+	req.Data["duration"] = "1h"
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp != nil && resp.IsError(), "unexpected error: %#v", resp)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "loggers/cubbyhole/")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "debug", resp.Data[mountLogger])
+
+	req = logical.TestRequest(t, logical.ListOperation, "loggers/overrides")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{mountLogger}, resp.Data["keys"])
+	info := resp.Data["key_info"].(map[string]interface{})[mountLogger].(map[string]interface{})
+	require.Equal(t, "debug", info["level"])
+	require.NotEmpty(t, info["expires_at"])
+
+	// Overrides revert to the configured level when they expire
+	require.NoError(t, c.SetLoggerOverride("token", hclog.Trace, "", 50*time.Millisecond))
+	require.Eventually(t, func() bool {
+		names, _ := c.loggerOverridesInfo()
+		return len(names) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "loggers/token")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, c.logLevel, resp.Data["token"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "loggers/token")
+	req.Data["level"] = "debug"
+	req.Data["duration"] = "720h"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected error for a duration over the maximum")
+
+	// Sinks receive the logs of the logger at the level of the override,
+	// without changing its level in the main log
+	dir := t.TempDir()
+	c.SetConfig(&server.Config{SharedConfig: &configutil.SharedConfig{LogFile: filepath.Join(dir, "vault.log")}})
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "loggers/core")
+	req.Data["level"] = "debug"
+	req.Data["sink"] = "core-debug.log"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp != nil && resp.IsError(), "unexpected error: %#v", resp)
+	require.False(t, c.logger.IsDebug())
+
+	c.logger.Debug("routed to the sink")
+	c.tokenStore.logger.Debug("not routed to the sink")
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "loggers/core")
+	req.Data["level"] = "debug"
+	req.Data["sink"] = "../core-debug.log"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError(), "expected error for a sink outside the log directory")
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "loggers")
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	names, _ := c.loggerOverridesInfo()
+	require.Empty(t, names)
+
+	contents, err := os.ReadFile(filepath.Join(dir, "core-debug.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "routed to the sink")
+	require.NotContains(t, string(contents), "not routed to the sink")
+}
@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// tokenAnomalyPaths returns paths that configure per auth mount detection of
+// anomalous token usage
+func (b *SystemBackend) tokenAnomalyPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/token-anomaly/(?P<path>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "token-anomaly",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "Path of the auth mount, e.g. 'userpass'.",
+				},
+				"detect_new_network": {
+					Type:        framework.TypeBool,
+					Default:     true,
+					Description: "If set, flag tokens used from a network (a /24 for IPv4, a /64 for IPv6) they were not used from before.",
+				},
+				"detect_new_user_agent": {
+					Type:        framework.TypeBool,
+					Default:     true,
+					Description: "If set, flag tokens used with a User-Agent they were not used with before.",
+				},
+				"rate_multiplier": {
+					Type:        framework.TypeFloat,
+					Default:     10,
+					Description: "Flag tokens whose requests per minute exceed their historical average by this factor. Zero disables the check.",
+				},
+				"rate_min_requests": {
+					Type:        framework.TypeInt,
+					Default:     100,
+					Description: "Minimum number of requests per minute before a token's request rate is flagged.",
+				},
+				"reduce_ttl_to": {
+					Type:        framework.TypeDurationSecond,
+					Description: "If set, flagged tokens have their TTL reduced to at most this duration and can no longer be renewed.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTokenAnomalyConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"detect_new_network": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"detect_new_user_agent": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"rate_multiplier": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+								"rate_min_requests": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"reduce_ttl_to": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleTokenAnomalyConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleTokenAnomalyConfigDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenAnomalyHelp["token-anomaly-config"][0]),
+			HelpDescription: strings.TrimSpace(tokenAnomalyHelp["token-anomaly-config"][1]),
+		},
+	}
+}
+
+// tokenAnomalyMountEntry returns the auth mount the path field refers to.
+func (b *SystemBackend) tokenAnomalyMountEntry(ctx context.Context, d *framework.FieldData) (*MountEntry, error) {
+	path := sanitizePath(credentialRoutePrefix + d.Get("path").(string))
+
+	entry := b.Core.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.Table != credentialTableType || credentialRoutePrefix+entry.Path != path {
+		return nil, fmt.Errorf("no auth mount at %q", path)
+	}
+	return entry, nil
+}
+
+func (b *SystemBackend) handleTokenAnomalyConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.tokenAnomalyMountEntry(ctx, d)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		config := b.Core.TokenAnomalyConfig(entry.Accessor)
+		if config == nil {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"detect_new_network":    config.DetectNewNetwork,
+				"detect_new_user_agent": config.DetectNewUserAgent,
+				"rate_multiplier":       config.RateMultiplier,
+				"rate_min_requests":     config.RateMinRequests,
+				"reduce_ttl_to":         int64(config.ReduceTTLTo.Seconds()),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleTokenAnomalyConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.tokenAnomalyMountEntry(ctx, d)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		config := defaultTokenAnomalyConfig()
+		if current := b.Core.TokenAnomalyConfig(entry.Accessor); current != nil {
+			*config = *current
+		}
+
+		if v, ok := d.GetOk("detect_new_network"); ok {
+			config.DetectNewNetwork = v.(bool)
+		}
+		if v, ok := d.GetOk("detect_new_user_agent"); ok {
+			config.DetectNewUserAgent = v.(bool)
+		}
+		if v, ok := d.GetOk("rate_multiplier"); ok {
+			config.RateMultiplier = v.(float64)
+		}
+		if v, ok := d.GetOk("rate_min_requests"); ok {
+			config.RateMinRequests = v.(int)
+		}
+		if v, ok := d.GetOk("reduce_ttl_to"); ok {
+			config.ReduceTTLTo = time.Duration(v.(int)) * time.Second
+		}
+
+		switch {
+		case config.RateMultiplier != 0 && config.RateMultiplier <= 1:
+			return logical.ErrorResponse("rate_multiplier must be greater than 1, or zero to disable the check"), nil
+		case config.RateMinRequests < 0:
+			return logical.ErrorResponse("rate_min_requests must not be negative"), nil
+		case config.ReduceTTLTo < 0:
+			return logical.ErrorResponse("reduce_ttl_to must not be negative"), nil
+		}
+
+		return nil, b.Core.saveTokenAnomalyConfig(ctx, entry.Accessor, config)
+	}
+}
+
+func (b *SystemBackend) handleTokenAnomalyConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.tokenAnomalyMountEntry(ctx, d)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return nil, b.Core.deleteTokenAnomalyConfig(ctx, entry.Accessor)
+	}
+}
+
+var tokenAnomalyHelp = map[string][2]string{
+	"token-anomaly-config": {
+		"Configure detection of anomalous usage of tokens issued by an auth mount.",
+		`Once configured for an auth mount, every use of a token issued by that mount
+is compared to the token's history. A token is flagged when it is used from a
+network or with a User-Agent it was not used with before, or when its request
+rate exceeds 'rate_multiplier' times its historical average. Flagged usage is
+logged, counted in the 'core.token_anomaly.detected' metric and sent as a
+'token/anomaly-detected' event. If 'reduce_ttl_to' is set, the token's TTL is
+also reduced to at most that duration, and it can no longer be renewed.
+
+Token history is kept in memory on each node, and is lost on restart.`,
+	},
+}
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// uiBrandingPaths returns paths that configure the branding and feature flags
+// of the UI per namespace, and the unauthenticated path the UI reads them from
+func (b *SystemBackend) uiBrandingPaths() []*framework.Path {
+	brandingFields := map[string]*framework.FieldSchema{
+		"logo": {
+			Type:     framework.TypeString,
+			Required: true,
+		},
+		"primary_color": {
+			Type:     framework.TypeString,
+			Required: true,
+		},
+		"login_message": {
+			Type:     framework.TypeString,
+			Required: true,
+		},
+		"feature_flags": {
+			Type:     framework.TypeCommaStringSlice,
+			Required: true,
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "config/ui/branding$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "ui-branding",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"logo": {
+					Type:        framework.TypeString,
+					Description: "The logo shown in the UI, as an https URL or a base64 encoded data URL of an image.",
+				},
+				"primary_color": {
+					Type:        framework.TypeString,
+					Description: "The primary color of the UI, as a hex color such as #1563ff.",
+				},
+				"login_message": {
+					Type:        framework.TypeString,
+					Description: "A plain text message shown on the login page of the UI.",
+				},
+				"feature_flags": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The UI features enabled for the namespace.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleUIBrandingConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the UI branding configured in the namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      brandingFields,
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleUIBrandingConfigUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure the UI branding of the namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleUIBrandingConfigDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Remove the UI branding of the namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(uiBrandingHelp["ui-branding-config"][0]),
+			HelpDescription: strings.TrimSpace(uiBrandingHelp["ui-branding-config"][1]),
+		},
+		{
+			Pattern: "internal/ui/branding$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal-ui",
+				OperationVerb:   "read",
+				OperationSuffix: "branding",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleInternalUIBrandingRead(),
+					Summary:  "Return the UI branding and feature flags in effect in the namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      brandingFields,
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(uiBrandingHelp["internal-ui-branding"][0]),
+			HelpDescription: strings.TrimSpace(uiBrandingHelp["internal-ui-branding"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleUIBrandingConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		branding, err := b.Core.UIBranding(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		if branding == nil {
+			branding = new(UIBranding)
+		}
+
+		return &logical.Response{
+			Data: uiBrandingResponseData(branding),
+		}, nil
+	}
+}
+
+// handleUIBrandingConfigUpdate updates the branding of the namespace. Fields
+// which are not provided retain their current value, and fields set to an
+// empty value are inherited from the parent namespace.
+func (b *SystemBackend) handleUIBrandingConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		branding, err := b.Core.UIBranding(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		if branding == nil {
+			branding = new(UIBranding)
+		}
+
+		if v, ok := d.GetOk("logo"); ok {
+			branding.Logo = v.(string)
+		}
+		if v, ok := d.GetOk("primary_color"); ok {
+			branding.PrimaryColor = v.(string)
+		}
+		if v, ok := d.GetOk("login_message"); ok {
+			branding.LoginMessage = v.(string)
+		}
+		if v, ok := d.GetOk("feature_flags"); ok {
+			branding.FeatureFlags = v.([]string)
+		}
+
+		if err := branding.validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		return nil, b.Core.SetUIBranding(ctx, ns, branding)
+	}
+}
+
+func (b *SystemBackend) handleUIBrandingConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, b.Core.DeleteUIBranding(ctx, ns)
+	}
+}
+
+// handleInternalUIBrandingRead returns the branding in effect in the namespace
+// of the request. It is unauthenticated, as the UI shows the branding on its
+// login page.
+func (b *SystemBackend) handleInternalUIBrandingRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		branding, err := b.Core.EffectiveUIBranding(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: uiBrandingResponseData(branding),
+		}, nil
+	}
+}
+
+func uiBrandingResponseData(branding *UIBranding) map[string]interface{} {
+	featureFlags := branding.FeatureFlags
+	if featureFlags == nil {
+		featureFlags = []string{}
+	}
+
+	return map[string]interface{}{
+		"logo":          branding.Logo,
+		"primary_color": branding.PrimaryColor,
+		"login_message": branding.LoginMessage,
+		"feature_flags": featureFlags,
+	}
+}
+
+var uiBrandingHelp = map[string][2]string{
+	"ui-branding-config": {
+		"Configure the branding and feature flags of the UI in the namespace.",
+		`The logo, primary color and login message configured here are shown by the
+UI to the users of the namespace, and the feature flags enable UI features for
+them. Settings which are not configured in a namespace are inherited from its
+closest ancestor which configures them, so child namespaces only need to
+configure what they change. Setting a field to an empty value inherits it
+again. Reading returns the settings configured in the namespace itself; the
+settings in effect are returned by sys/internal/ui/branding.`,
+	},
+	"internal-ui-branding": {
+		"Return the UI branding and feature flags in effect in the namespace.",
+		`This path is unauthenticated, so the UI can show the branding of a namespace
+on its login page. It returns the settings configured in the namespace, with
+the settings it does not configure inherited from its closest ancestor which
+configures them.`,
+	},
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemConfigUIBranding(t *testing.T) {
+	b := testSystemBackend(t)
+	paths := b.(*SystemBackend).uiBrandingPaths()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "")
+	b.(*SystemBackend).Core.systemBarrierView = view
+
+	empty := map[string]interface{}{
+		"logo":          "",
+		"primary_color": "",
+		"login_message": "",
+		"feature_flags": []string{},
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/ui/branding")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, empty, resp.Data)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/ui/branding")
+	req.Data["logo"] = "https://example.com/logo.svg"
+	req.Data["primary_color"] = "#1563ff"
+	req.Data["feature_flags"] = "beta-dashboard,audit-viewer,beta-dashboard"
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 0, req.Operation), resp, true)
+
+	// Fields which are not provided retain their value
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/ui/branding")
+	req.Data["login_message"] = "Welcome to the platform"
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"logo":          "https://example.com/logo.svg",
+		"primary_color": "#1563ff",
+		"login_message": "Welcome to the platform",
+		"feature_flags": []string{"audit-viewer", "beta-dashboard"},
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/ui/branding")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 0, req.Operation), resp, true)
+	require.Equal(t, expected, resp.Data)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/ui/branding")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 1, req.Operation), resp, true)
+	require.Equal(t, expected, resp.Data)
+
+	for field, value := range map[string]interface{}{
+		"logo":          "http://example.com/logo.svg",
+		"primary_color": "blue",
+		"feature_flags": "not a flag",
+	} {
+		req = logical.TestRequest(t, logical.UpdateOperation, "config/ui/branding")
+		req.Data[field] = value
+		resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+		require.NoError(t, err)
+		require.True(t, resp.IsError(), field)
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "config/ui/branding")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	schema.ValidateResponse(t, schema.FindResponseSchema(t, paths, 0, req.Operation), resp, true)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/ui/branding")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, empty, resp.Data)
+}
+
+func TestParentNamespacePath(t *testing.T) {
+	require.Equal(t, "", parentNamespacePath(""))
+	require.Equal(t, "", parentNamespacePath("team1/"))
+	require.Equal(t, "team1/", parentNamespacePath("team1/dev/"))
+}
@@ -0,0 +1,284 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	loginEnrichmentConfigPath = "login-enrichment"
+
+	defaultLoginEnrichmentTimeout = 2 * time.Second
+
+	// maxLoginEnrichmentResponseSize limits how much of an enrichment
+	// service response is read.
+	maxLoginEnrichmentResponseSize = 64 * 1024
+)
+
+// LoginEnrichmentConfig configures enrichment of login attempts with
+// network intelligence about their origin, and which attempts are blocked
+// as a result.
+type LoginEnrichmentConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// ServiceURL is the endpoint of the enrichment service.
+	ServiceURL string        `json:"service_url"`
+	Timeout    time.Duration `json:"timeout"`
+
+	// FailClosed blocks login attempts which could not be enriched.
+	FailClosed bool `json:"fail_closed"`
+
+	BlockedCountries []string `json:"blocked_countries"`
+	BlockedASNs      []int    `json:"blocked_asns"`
+
+	// MaxRiskScore blocks login attempts with a higher risk score. Zero
+	// disables the check.
+	MaxRiskScore float64 `json:"max_risk_score"`
+
+	// enricher calls the enrichment service. It is built when the
+	// configuration is stored, so that its HTTP client is shared by all the
+	// login attempts.
+	enricher *httpLoginEnricher
+}
+
+// setupEnricher builds the client of the enrichment service of the
+// configuration.
+func (config *LoginEnrichmentConfig) setupEnricher() {
+	config.enricher = nil
+	if config.ServiceURL != "" {
+		config.enricher = newHTTPLoginEnricher(config.ServiceURL, config.Timeout)
+	}
+}
+
+func defaultLoginEnrichmentConfig() *LoginEnrichmentConfig {
+	return &LoginEnrichmentConfig{
+		Timeout: defaultLoginEnrichmentTimeout,
+	}
+}
+
+// LoginAttempt describes a login attempt to be enriched.
+type LoginAttempt struct {
+	RemoteAddress string `json:"remote_address"`
+	Path          string `json:"path"`
+	MountPoint    string `json:"mount_point"`
+	MountType     string `json:"mount_type"`
+	Namespace     string `json:"namespace"`
+}
+
+// LoginEnricher looks up network intelligence about the origin of a login
+// attempt. Only the informational fields of the returned LoginEnrichment are
+// used; the decision to block the attempt is made by the core.
+type LoginEnricher interface {
+	Enrich(context.Context, *LoginAttempt) (*logical.LoginEnrichment, error)
+}
+
+// httpLoginEnricher enriches login attempts by POSTing them as JSON to an
+// external service, which responds with a JSON encoded LoginEnrichment.
+type httpLoginEnricher struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPLoginEnricher(url string, timeout time.Duration) *httpLoginEnricher {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = timeout
+	return &httpLoginEnricher{
+		url:    url,
+		client: client,
+	}
+}
+
+func (e *httpLoginEnricher) Enrich(ctx context.Context, attempt *LoginAttempt) (*logical.LoginEnrichment, error) {
+	body, err := json.Marshal(attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("enrichment service returned status %d", resp.StatusCode)
+	}
+
+	var enrichment logical.LoginEnrichment
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxLoginEnrichmentResponseSize)).Decode(&enrichment); err != nil {
+		return nil, fmt.Errorf("failed to decode enrichment service response: %w", err)
+	}
+	return &enrichment, nil
+}
+
+// evaluate decides whether a login attempt with the given enrichment is
+// blocked, recording the decision in the enrichment.
+func (config *LoginEnrichmentConfig) evaluate(enrichment *logical.LoginEnrichment) {
+	enrichment.Blocked = false
+	enrichment.Reason = ""
+
+	for _, country := range config.BlockedCountries {
+		if enrichment.Country != "" && strings.EqualFold(country, enrichment.Country) {
+			enrichment.Blocked = true
+			enrichment.Reason = fmt.Sprintf("logins from country %q are blocked", enrichment.Country)
+			return
+		}
+	}
+	for _, asn := range config.BlockedASNs {
+		if enrichment.ASN != 0 && asn == enrichment.ASN {
+			enrichment.Blocked = true
+			enrichment.Reason = fmt.Sprintf("logins from ASN %d are blocked", enrichment.ASN)
+			return
+		}
+	}
+	if config.MaxRiskScore > 0 && enrichment.RiskScore > config.MaxRiskScore {
+		enrichment.Blocked = true
+		enrichment.Reason = fmt.Sprintf("risk score %g exceeds the maximum of %g", enrichment.RiskScore, config.MaxRiskScore)
+	}
+}
+
+// enrichLogin enriches the login attempt req to the mount entry, if login
+// enrichment is enabled. The result is set on the request so that it is
+// recorded in audit entries.
+func (c *Core) enrichLogin(ctx context.Context, req *logical.Request, entry *MountEntry) {
+	config := c.loginEnrichmentConfig.Load()
+	if config == nil || !config.Enabled {
+		return
+	}
+	if req.Connection == nil || req.Connection.RemoteAddr == "" {
+		return
+	}
+
+	enricher := c.loginEnricher
+	if enricher == nil {
+		if config.enricher == nil {
+			return
+		}
+		enricher = config.enricher
+	}
+
+	attempt := &LoginAttempt{
+		RemoteAddress: req.Connection.RemoteAddr,
+		Path:          req.Path,
+	}
+	if entry != nil {
+		attempt.MountPoint = entry.Path
+		attempt.MountType = entry.Type
+	}
+	if ns, err := namespace.FromContext(ctx); err == nil {
+		attempt.Namespace = ns.Path
+	}
+
+	enrichCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	decision := "allowed"
+	enrichment, err := enricher.Enrich(enrichCtx, attempt)
+	switch {
+	case err != nil:
+		c.logger.Warn("failed to enrich login attempt", "path", req.Path, "error", err)
+		enrichment = &logical.LoginEnrichment{
+			Blocked: config.FailClosed,
+			Reason:  "login attempt could not be enriched",
+		}
+		decision = "error"
+	case enrichment == nil:
+		enrichment = &logical.LoginEnrichment{}
+		fallthrough
+	default:
+		config.evaluate(enrichment)
+		if enrichment.Blocked {
+			decision = "blocked"
+		}
+	}
+
+	metrics.IncrCounterWithLabels([]string{"core", "login_enrichment", "decision"}, 1, []metrics.Label{
+		{Name: "decision", Value: decision},
+		{Name: "mount_point", Value: attempt.MountPoint},
+		{Name: "namespace", Value: attempt.Namespace},
+	})
+
+	req.LoginEnrichment = enrichment
+}
+
+// LoginEnrichmentConfig returns the current login enrichment configuration.
+func (c *Core) LoginEnrichmentConfig() *LoginEnrichmentConfig {
+	if config := c.loginEnrichmentConfig.Load(); config != nil {
+		return config
+	}
+	return defaultLoginEnrichmentConfig()
+}
+
+func (c *Core) saveLoginEnrichmentConfig(ctx context.Context, config *LoginEnrichmentConfig) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	entry, err := logical.StorageEntryJSON(loginEnrichmentConfigPath, config)
+	if err != nil {
+		return fmt.Errorf("failed to create login enrichment config entry: %w", err)
+	}
+
+	if err := view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save login enrichment config: %w", err)
+	}
+
+	config.setupEnricher()
+	c.loginEnrichmentConfig.Store(config)
+	return nil
+}
+
+func (c *Core) deleteLoginEnrichmentConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	if err := view.Delete(ctx, loginEnrichmentConfigPath); err != nil {
+		return fmt.Errorf("failed to delete login enrichment config: %w", err)
+	}
+
+	c.loginEnrichmentConfig.Store(nil)
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadLoginEnrichmentConfig(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/")
+
+	out, err := view.Get(ctx, loginEnrichmentConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read login enrichment config: %w", err)
+	}
+	if out == nil {
+		c.loginEnrichmentConfig.Store(nil)
+		return nil
+	}
+
+	config := defaultLoginEnrichmentConfig()
+	if err := out.DecodeJSON(config); err != nil {
+		return err
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultLoginEnrichmentTimeout
+	}
+
+	config.setupEnricher()
+	c.loginEnrichmentConfig.Store(config)
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoginEnrichmentConfig_Evaluate verifies the block rules applied to
+// enriched login attempts.
+func TestLoginEnrichmentConfig_Evaluate(t *testing.T) {
+	config := &LoginEnrichmentConfig{
+		BlockedCountries: []string{"xx"},
+		BlockedASNs:      []int{64512},
+		MaxRiskScore:     50,
+	}
+
+	tests := map[string]struct {
+		enrichment *logical.LoginEnrichment
+		blocked    bool
+	}{
+		"allowed":        {&logical.LoginEnrichment{Country: "DE", ASN: 3320, RiskScore: 10}, false},
+		"country":        {&logical.LoginEnrichment{Country: "XX"}, true},
+		"asn":            {&logical.LoginEnrichment{ASN: 64512}, true},
+		"risk score":     {&logical.LoginEnrichment{RiskScore: 50.5}, true},
+		"max risk score": {&logical.LoginEnrichment{RiskScore: 50}, false},
+		"empty":          {&logical.LoginEnrichment{}, false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config.evaluate(tc.enrichment)
+			require.Equal(t, tc.blocked, tc.enrichment.Blocked)
+			require.Equal(t, tc.blocked, tc.enrichment.Reason != "")
+		})
+	}
+}
+
+// TestCore_EnrichLogin verifies that login attempts are enriched by the
+// configured service, and that failures are handled according to
+// fail_closed.
+func TestCore_EnrichLogin(t *testing.T) {
+	var attempt LoginAttempt
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&attempt))
+		if attempt.RemoteAddress == "192.0.2.1" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(&logical.LoginEnrichment{
+			ASN:       64512,
+			Country:   "DE",
+			RiskScore: 10,
+			Tags:      []string{"hosting"},
+			// The service cannot make the decision
+			Blocked: true,
+		})
+	}))
+	defer srv.Close()
+
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	entry := c.router.MatchingMountEntry(ctx, "auth/token/")
+
+	newReq := func(remoteAddr string) *logical.Request {
+		return &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "auth/token/login",
+			Connection: &logical.Connection{RemoteAddr: remoteAddr},
+		}
+	}
+
+	// Disabled by default
+	req := newReq("198.51.100.1")
+	c.enrichLogin(ctx, req, entry)
+	require.Nil(t, req.LoginEnrichment)
+
+	config := &LoginEnrichmentConfig{
+		Enabled:    true,
+		ServiceURL: srv.URL,
+		Timeout:    time.Second,
+	}
+	require.NoError(t, c.saveLoginEnrichmentConfig(ctx, config))
+
+	req = newReq("198.51.100.1")
+	c.enrichLogin(ctx, req, entry)
+	require.NotNil(t, req.LoginEnrichment)
+	require.False(t, req.LoginEnrichment.Blocked)
+	require.Equal(t, 64512, req.LoginEnrichment.ASN)
+	require.Equal(t, []string{"hosting"}, req.LoginEnrichment.Tags)
+	require.Equal(t, "198.51.100.1", attempt.RemoteAddress)
+	require.Equal(t, "auth/token/", attempt.MountPoint)
+
+	config.BlockedASNs = []int{64512}
+	require.NoError(t, c.saveLoginEnrichmentConfig(ctx, config))
+	req = newReq("198.51.100.1")
+	c.enrichLogin(ctx, req, entry)
+	require.True(t, req.LoginEnrichment.Blocked)
+
+	// Service failures are allowed unless failing closed
+	req = newReq("192.0.2.1")
+	c.enrichLogin(ctx, req, entry)
+	require.False(t, req.LoginEnrichment.Blocked)
+
+	config.FailClosed = true
+	require.NoError(t, c.saveLoginEnrichmentConfig(ctx, config))
+	req = newReq("192.0.2.1")
+	c.enrichLogin(ctx, req, entry)
+	require.True(t, req.LoginEnrichment.Blocked)
+
+	// The configuration survives a reload, with a new client of the service
+	require.NoError(t, c.loadLoginEnrichmentConfig(ctx))
+	want, got := *config, *c.LoginEnrichmentConfig()
+	require.NotNil(t, got.enricher)
+	require.Equal(t, srv.URL, got.enricher.url)
+	want.enricher, got.enricher = nil, nil
+	require.Equal(t, want, got)
+}
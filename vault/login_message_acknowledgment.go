@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// MessageAcknowledgmentHeaderName is the header login requests acknowledge
+// the login messages which require it with, as a comma separated list of
+// <message ID>:<version> pairs.
+const MessageAcknowledgmentHeaderName = "X-Vault-Acknowledge-Messages"
+
+// interactiveAuthMethods are the types of the auth methods people log in
+// with, as opposed to machines. Only their logins must acknowledge the login
+// messages which require it.
+var interactiveAuthMethods = map[string]struct{}{
+	"github":   {},
+	"ldap":     {},
+	"oidc":     {},
+	"okta":     {},
+	"radius":   {},
+	"userpass": {},
+}
+
+// parseMessageAcknowledgments parses the message versions acknowledged by the
+// acknowledgment header values, keyed by message ID. Invalid pairs are
+// ignored, as they acknowledge nothing.
+func parseMessageAcknowledgments(values []string) map[string]int {
+	acked := make(map[string]int)
+	for _, value := range values {
+		for _, pair := range strings.Split(value, ",") {
+			id, rawVersion, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok {
+				continue
+			}
+			version, err := strconv.Atoi(rawVersion)
+			if err != nil {
+				continue
+			}
+			acked[id] = version
+		}
+	}
+	return acked
+}
+
+// enforceMessageAcknowledgments checks that an interactive login acknowledges
+// the current version of each active login message which requires it, and
+// records the acknowledgments of the entity. It returns a permission denied
+// error naming the messages which are left to acknowledge, if any, so that no
+// token is issued until they are.
+func (c *Core) enforceMessageAcknowledgments(ctx context.Context, req *logical.Request, entityID string) error {
+	if entityID == "" || c.customMessageManager == nil {
+		return nil
+	}
+	if _, ok := interactiveAuthMethods[req.MountType]; !ok {
+		return nil
+	}
+
+	pending, err := c.customMessageManager.PendingAcknowledgments(ctx, entityID)
+	if err != nil {
+		c.logger.Error("failed to find the login messages pending acknowledgment", "error", err)
+		return ErrInternalError
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	acked := parseMessageAcknowledgments(http.Header(req.Headers).Values(MessageAcknowledgmentHeaderName))
+	var missing []string
+	for _, message := range pending {
+		if version, ok := acked[message.ID]; ok && version == message.Version {
+			if err := c.customMessageManager.Acknowledge(ctx, message.ID, entityID, message.Version); err != nil {
+				c.logger.Error("failed to record login message acknowledgment", "message_id", message.ID, "error", err)
+				return ErrInternalError
+			}
+			continue
+		}
+		missing = append(missing, fmt.Sprintf("%s:%d", message.ID, message.Version))
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: the login messages %s must be acknowledged with the %s header",
+			logical.ErrPermissionDenied, strings.Join(missing, ","), MessageAcknowledgmentHeaderName)
+	}
+	return nil
+}
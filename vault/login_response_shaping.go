@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// loginResponseAllEntityMetadata selects all of the entity metadata for
+// inclusion in login responses.
+const loginResponseAllEntityMetadata = "*"
+
+// LoginResponseShaping selects the identity details included in the login
+// responses to a role of an auth mount: the given keys of the metadata of the
+// entity, or all of them with "*", the names of its groups, and the namespace
+// of the login.
+type LoginResponseShaping struct {
+	EntityMetadata []string `json:"entity_metadata,omitempty" mapstructure:"entity_metadata"`
+	GroupNames     bool     `json:"group_names,omitempty" mapstructure:"group_names"`
+	Namespace      bool     `json:"namespace,omitempty" mapstructure:"namespace"`
+}
+
+// loginResponseShaping returns the shaping of the login responses to the role
+// of the auth mount, which defaults to the shaping of the mount.
+func (c *MountConfig) loginResponseShaping(role string) *LoginResponseShaping {
+	if shaping, ok := c.LoginResponseRoles[role]; ok && role != "" {
+		return shaping
+	}
+	return &LoginResponseShaping{
+		EntityMetadata: c.LoginResponseEntityMetadata,
+		GroupNames:     c.LoginResponseGroupNames,
+		Namespace:      c.LoginResponseNamespace,
+	}
+}
+
+// parseLoginResponseRoles parses the login_response_roles tune parameter,
+// which maps role names to their login response shaping.
+func parseLoginResponseRoles(raw map[string]interface{}) (map[string]*LoginResponseShaping, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	roles := make(map[string]*LoginResponseShaping, len(raw))
+	for role, rawShaping := range raw {
+		if role == "" {
+			return nil, fmt.Errorf("role names of 'login_response_roles' cannot be empty")
+		}
+
+		shaping := &LoginResponseShaping{}
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			Result:           shaping,
+			WeaklyTypedInput: true,
+			ErrorUnused:      true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(rawShaping); err != nil {
+			return nil, fmt.Errorf("invalid login response shaping of role %q: %w", role, err)
+		}
+		shaping.EntityMetadata = strutil.RemoveDuplicates(shaping.EntityMetadata, false)
+		if len(shaping.EntityMetadata) == 0 {
+			shaping.EntityMetadata = nil
+		}
+		roles[role] = shaping
+	}
+	return roles, nil
+}
+
+// loginRole returns the role of a login, as recorded in the metadata of its
+// auth by the auth methods which do, or as resolved by the auth method.
+func (c *Core) loginRole(ctx context.Context, req *logical.Request, auth *logical.Auth) string {
+	for _, key := range []string{"role", "role_name"} {
+		if role := auth.Metadata[key]; role != "" {
+			return role
+		}
+	}
+	return c.DetermineRoleFromLoginRequest(ctx, req.MountPoint, req.Data)
+}
+
+// shapeLoginResponse includes the identity details selected by the auth
+// mount of a login, for the role of the login, in its auth response: the
+// entity metadata, the names of the groups of the entity in the namespace of
+// the login, and the namespace itself. The entity may be nil, in which case
+// only the namespace is included.
+func (c *Core) shapeLoginResponse(mountEntry *MountEntry, role string, ns *namespace.Namespace, entity *identity.Entity, auth *logical.Auth) error {
+	shaping := mountEntry.Config.loginResponseShaping(role)
+
+	if shaping.Namespace {
+		auth.NamespaceID = ns.ID
+		auth.NamespacePath = ns.Path
+	}
+
+	if entity == nil {
+		return nil
+	}
+
+	if len(shaping.EntityMetadata) > 0 && len(entity.Metadata) > 0 {
+		all := strutil.StrListContains(shaping.EntityMetadata, loginResponseAllEntityMetadata)
+		metadata := make(map[string]string)
+		for key, value := range entity.Metadata {
+			if all || strutil.StrListContains(shaping.EntityMetadata, key) {
+				metadata[key] = value
+			}
+		}
+		if len(metadata) > 0 {
+			auth.EntityMetadata = metadata
+		}
+	}
+
+	if shaping.GroupNames && c.identityStore != nil {
+		directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		for _, group := range append(directGroups, inheritedGroups...) {
+			if group.NamespaceID == ns.ID {
+				names = append(names, group.Name)
+			}
+		}
+		sort.Strings(names)
+		auth.GroupNames = names
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	memoryPressureCheckInterval = time.Second
+
+	// memoryPressureHighRatio and memoryPressureLowRatio are the ratios of
+	// the memory usage to the soft memory limit above which memory pressure
+	// begins, and below which it ends. The gap avoids flapping.
+	memoryPressureHighRatio = 0.90
+	memoryPressureLowRatio  = 0.80
+
+	memoryPressureHighEventType     = "core/memory-pressure-high"
+	memoryPressureRelievedEventType = "core/memory-pressure-relieved"
+)
+
+// memoryPressureMonitor watches the memory usage of the process against the
+// soft memory limit of the Go runtime. Under memory pressure, core sheds the
+// physical cache, and non-critical background workers, such as the activity
+// log precompute and the tidy jobs, pause until the pressure is relieved,
+// rather than the process getting OOM-killed.
+type memoryPressureMonitor struct {
+	logger log.Logger
+
+	// limit and usage return the soft memory limit and the memory usage
+	// counted against it, in bytes. They are replaced in tests.
+	limit func() int64
+	usage func() uint64
+
+	l        sync.Mutex
+	pressure bool
+	since    time.Time
+	// reliefCh is closed when the current memory pressure is relieved.
+	reliefCh chan struct{}
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newMemoryPressureMonitor(logger log.Logger) *memoryPressureMonitor {
+	return &memoryPressureMonitor{
+		logger: logger,
+		limit:  softMemoryLimit,
+		usage:  runtimeMemoryUsage,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// softMemoryLimit returns the soft memory limit of the Go runtime, set with
+// GOMEMLIMIT or the memory_limit server option, or zero if there is none.
+func softMemoryLimit() int64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
+
+// runtimeMemoryUsage returns the memory mapped by the Go runtime and not
+// released to the OS, which is what the soft memory limit applies to.
+func runtimeMemoryUsage() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// underPressure returns a channel closed when the memory pressure is
+// relieved, or nil if there is no memory pressure.
+func (m *memoryPressureMonitor) underPressure() <-chan struct{} {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if !m.pressure {
+		return nil
+	}
+	return m.reliefCh
+}
+
+func (m *memoryPressureMonitor) stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
+// runMemoryPressureMonitor checks the memory pressure periodically, until
+// the monitor is stopped.
+func (c *Core) runMemoryPressureMonitor(m *memoryPressureMonitor) {
+	ticker := time.NewTicker(memoryPressureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.checkMemoryPressure(m)
+		case <-m.stopCh:
+			// Do not leave workers waiting on a monitor which is gone
+			m.l.Lock()
+			if m.pressure {
+				m.pressure = false
+				close(m.reliefCh)
+			}
+			m.l.Unlock()
+			return
+		}
+	}
+}
+
+// checkMemoryPressure updates the memory pressure state, and acts on its
+// transitions.
+func (c *Core) checkMemoryPressure(m *memoryPressureMonitor) {
+	limit := m.limit()
+	if limit <= 0 {
+		return
+	}
+	usage := m.usage()
+	ratio := float64(usage) / float64(limit)
+	c.metricSink.SetGauge([]string{"core", "memory_pressure", "usage_ratio"}, float32(ratio))
+
+	m.l.Lock()
+	var began, relieved bool
+	var duration time.Duration
+	switch {
+	case !m.pressure && ratio >= memoryPressureHighRatio:
+		m.pressure = true
+		m.since = time.Now()
+		m.reliefCh = make(chan struct{})
+		began = true
+	case m.pressure && ratio < memoryPressureLowRatio:
+		m.pressure = false
+		duration = time.Since(m.since)
+		close(m.reliefCh)
+		relieved = true
+	}
+	m.l.Unlock()
+
+	switch {
+	case began:
+		m.logger.Warn("memory pressure is high, shedding caches and pausing background workers",
+			"usage", usage, "limit", limit)
+		c.metricSink.IncrCounterWithLabels([]string{"core", "memory_pressure", "high"}, 1, nil)
+		if c.physicalCache != nil {
+			c.physicalCache.Purge(context.Background())
+		}
+		// Return the memory of the purged caches to the OS now, rather than
+		// when the scavenger gets to it
+		debug.FreeOSMemory()
+		c.sendMemoryPressureEvent(memoryPressureHighEventType, usage, limit, 0)
+	case relieved:
+		m.logger.Info("memory pressure is relieved, resuming background workers",
+			"usage", usage, "limit", limit, "duration", duration)
+		c.sendMemoryPressureEvent(memoryPressureRelievedEventType, usage, limit, duration)
+	}
+}
+
+func (c *Core) sendMemoryPressureEvent(eventType logical.EventType, usage uint64, limit int64, duration time.Duration) {
+	if c.events == nil {
+		return
+	}
+	ev, err := logical.NewEvent()
+	if err != nil {
+		c.logger.Error("failed to create memory pressure event", "error", err)
+		return
+	}
+	ev.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+		"usage_bytes": structpb.NewStringValue(strconv.FormatUint(usage, 10)),
+		"limit_bytes": structpb.NewStringValue(strconv.FormatInt(limit, 10)),
+	}}
+	if duration > 0 {
+		ev.Metadata.Fields["duration"] = structpb.NewStringValue(duration.String())
+	}
+	if err := c.events.SendEventInternal(context.Background(), namespace.RootNamespace, nil, eventType, ev); err != nil {
+		c.logger.Debug("failed to send memory pressure event", "error", err)
+	}
+}
+
+// waitForMemoryPressureRelief blocks non-critical background work while the
+// memory is under pressure, until the pressure is relieved or the context is
+// done.
+func (c *Core) waitForMemoryPressureRelief(ctx context.Context) error {
+	if c.memoryPressure == nil {
+		return nil
+	}
+	reliefCh := c.memoryPressure.underPressure()
+	if reliefCh == nil {
+		return nil
+	}
+	c.memoryPressure.logger.Debug("pausing background work under memory pressure")
+	select {
+	case <-reliefCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryPressure verifies that background work pauses once the memory
+// usage crosses the high ratio of the soft memory limit, and resumes only
+// once it drops below the low ratio.
+func TestMemoryPressure(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	var usage uint64
+	m := newMemoryPressureMonitor(hclog.NewNullLogger())
+	m.limit = func() int64 { return 1000 }
+	m.usage = func() uint64 { return usage }
+	c.memoryPressure = m
+
+	ctx := context.Background()
+	require.NoError(t, c.waitForMemoryPressureRelief(ctx))
+
+	usage = 950
+	c.checkMemoryPressure(m)
+	require.NotNil(t, m.underPressure())
+
+	// A cancelled context stops the wait
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, c.waitForMemoryPressureRelief(cancelledCtx), context.Canceled)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.waitForMemoryPressureRelief(ctx)
+	}()
+
+	// Between the ratios the pressure is not relieved yet
+	usage = 850
+	c.checkMemoryPressure(m)
+	select {
+	case <-errCh:
+		t.Fatal("expected the wait to block under memory pressure")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	usage = 700
+	c.checkMemoryPressure(m)
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the wait to return once the memory pressure is relieved")
+	}
+	require.Nil(t, m.underPressure())
+}
@@ -334,7 +334,9 @@ type MountEntry struct {
 	Local                 bool              `json:"local"`                             // Local mounts are not replicated or affected by replication
 	SealWrap              bool              `json:"seal_wrap"`                         // Whether to wrap CSPs
 	ExternalEntropyAccess bool              `json:"external_entropy_access,omitempty"` // Whether to allow external entropy source access
+	MountKey              bool              `json:"mount_key,omitempty"`               // Whether the data is encrypted with a key of its own, destroyed on unmount
 	Tainted               bool              `json:"tainted,omitempty"`                 // Set as a Write-Ahead flag for unmount/remount
+	Sealed                bool              `json:"sealed,omitempty"`                  // Set when the mount is sealed, rejecting all requests and keeping no backend
 	MountState            string            `json:"mount_state,omitempty"`             // The current mount state.  The only non-empty mount state right now is "unmounting"
 	NamespaceID           string            `json:"namespace_id"`
 
@@ -369,6 +371,51 @@ type MountConfig struct {
 	DelegatedAuthAccessors    []string              `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
 	IdentityTokenKey          string                `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
 
+	// StrictFieldValidation makes the requests to the mount fail when they
+	// have fields which are not in the schema of the path or which cannot be
+	// converted to their type, instead of ignoring them.
+	StrictFieldValidation bool `json:"strict_field_validation,omitempty" mapstructure:"strict_field_validation"`
+
+	// CompatibilityLevel pins the responses of the mount to how they were at
+	// a compatibility level, a version, of its backend, so that backend
+	// upgrades which change them can be adopted on the operator's schedule.
+	CompatibilityLevel string `json:"compatibility_level,omitempty" mapstructure:"compatibility_level"`
+
+	// CustomResponseHeaders are headers set on the responses of the mount,
+	// keyed by their canonical name. Their values may use identity and
+	// namespace templates.
+	CustomResponseHeaders map[string]string `json:"custom_response_headers,omitempty" mapstructure:"custom_response_headers"`
+
+	// MaxChildTokenDepth restricts the number of levels of child tokens which
+	// the tokens issued by an auth mount may create below them. Nil means
+	// unrestricted.
+	MaxChildTokenDepth *int `json:"max_child_token_depth,omitempty" mapstructure:"max_child_token_depth"`
+
+	// MaxEntityTokens limits the number of valid service tokens issued by an
+	// auth mount to each entity; zero means unlimited. EntityTokenLimitAction
+	// is what happens on the logins of an entity which has reached the
+	// limit: they are denied, or they evict the oldest token of the entity.
+	MaxEntityTokens        int    `json:"max_entity_tokens,omitempty" mapstructure:"max_entity_tokens"`
+	EntityTokenLimitAction string `json:"entity_token_limit_action,omitempty" mapstructure:"entity_token_limit_action"`
+
+	// LoginResponseEntityMetadata, LoginResponseGroupNames and
+	// LoginResponseNamespace select the identity details included in the
+	// login responses of an auth mount: the given keys of the metadata of the
+	// entity, or all of them with "*", the names of its groups, and the
+	// namespace of the login.
+	LoginResponseEntityMetadata []string `json:"login_response_entity_metadata,omitempty" mapstructure:"login_response_entity_metadata"`
+	LoginResponseGroupNames     bool     `json:"login_response_group_names,omitempty" mapstructure:"login_response_group_names"`
+	LoginResponseNamespace      bool     `json:"login_response_namespace,omitempty" mapstructure:"login_response_namespace"`
+
+	// LoginResponseRoles overrides the identity details included in the
+	// login responses of an auth mount for the logins to the given roles.
+	LoginResponseRoles map[string]*LoginResponseShaping `json:"login_response_roles,omitempty" mapstructure:"login_response_roles"`
+
+	// ClientCountSimulationEnd is the end of the client count simulation of
+	// an auth mount. Until then, the clients of the tokens issued by the
+	// mount are recorded for reporting instead of being counted.
+	ClientCountSimulationEnd time.Time `json:"client_count_simulation_end,omitempty" mapstructure:"client_count_simulation_end"`
+
 	// PluginName is the name of the plugin registered in the catalog.
 	//
 	// Deprecated: MountEntry.Type should be used instead for Vault 1.0.0 and beyond.
@@ -409,6 +456,7 @@ type APIMountConfig struct {
 	PluginVersion             string                `json:"plugin_version,omitempty" mapstructure:"plugin_version"`
 	DelegatedAuthAccessors    []string              `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
 	IdentityTokenKey          string                `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	CustomResponseHeaders     map[string]string     `json:"custom_response_headers,omitempty" mapstructure:"custom_response_headers"`
 
 	// PluginName is the name of the plugin registered in the catalog.
 	//
@@ -460,6 +508,12 @@ func (e *MountEntry) Namespace() *namespace.Namespace {
 }
 
 // APIPath returns the full API Path for the given mount entry
+// clientCountSimulationActive returns whether the client count simulation of
+// the mount is running at the given time.
+func (c *MountConfig) clientCountSimulationActive(now time.Time) bool {
+	return !c.ClientCountSimulationEnd.IsZero() && now.Before(c.ClientCountSimulationEnd)
+}
+
 func (e *MountEntry) APIPath() string {
 	path := e.Path
 	if e.Table == credentialTableType {
@@ -522,6 +576,12 @@ func (e *MountEntry) SyncCache() {
 	} else {
 		e.synthesizedConfigCache.Store("identity_token_key", e.Config.IdentityTokenKey)
 	}
+
+	if len(e.Config.CustomResponseHeaders) == 0 {
+		e.synthesizedConfigCache.Delete("custom_response_headers")
+	} else {
+		e.synthesizedConfigCache.Store("custom_response_headers", e.Config.CustomResponseHeaders)
+	}
 }
 
 func (entry *MountEntry) Deserialize() map[string]interface{} {
@@ -679,9 +739,13 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry, updateStora
 	if err != nil {
 		return fmt.Errorf("error creating forwarded writer: %v", err)
 	}
+	storage, err := c.mountKeyStorage(ctx, entry, forwarded, updateStorage)
+	if err != nil {
+		return err
+	}
 
 	viewPath := entry.ViewPath()
-	view := NewBarrierView(forwarded, viewPath)
+	view := NewBarrierView(storage, viewPath)
 
 	// Singleton mounts cannot be filtered manually on a per-secondary basis
 	// from replication.
@@ -923,6 +987,13 @@ func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage b
 			return err
 		}
 
+	case entry.MountKey && (entry.Local || !c.IsPerfSecondary()):
+		// Destroying the key of the mount erases its data
+		if err := c.eraseMountKey(ctx, entry); err != nil {
+			c.logger.Error("failed to erase the data of the mount being unmounted", "error", err, "path", path)
+			return err
+		}
+
 	case entry.Local, !c.IsPerfSecondary():
 		// Have writable storage, remove the whole thing
 		if err := logical.ClearViewWithLogging(ctx, view, c.logger.Named("secrets.deletion").With("namespace", ns.ID, "path", path)); err != nil {
@@ -1428,6 +1499,9 @@ func (c *Core) persistMounts(ctx context.Context, table *MountTable, local *bool
 		return fmt.Errorf("invalid table type given, not persisting")
 	}
 
+	// The OpenAPI document is generated from the mounts
+	c.invalidateResponseCache(responseCacheOpenAPI)
+
 	nonLocalMounts := &MountTable{
 		Type: mountTableType,
 	}
@@ -1525,9 +1599,14 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("error creating forwarded writer: %v", err)
 		}
+		storage, err := c.mountKeyStorage(ctx, entry, forwarded, false)
+		if err != nil {
+			c.logger.Error("failed to set up the mount key", "path", entry.Path, "error", err)
+			return errLoadMountsFailed
+		}
 
 		// Create a barrier storage view using the UUID
-		view := NewBarrierView(forwarded, barrierPath)
+		view := NewBarrierView(storage, barrierPath)
 
 		// Singleton mounts cannot be filtered manually on a per-secondary basis
 		// from replication
@@ -1554,6 +1633,12 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		var backend logical.Backend
 		// Create the new backend
 		sysView := c.mountEntrySysView(entry)
+		// Sealed mounts keep no backend until they are unsealed
+		if entry.Sealed {
+			c.logger.Warn("not starting the backend of sealed mount", "path", entry.Path)
+			view.setReadOnlyErr(origReadOnlyErr)
+			goto ROUTER_MOUNT
+		}
 		backend, err = c.newLogicalBackend(ctx, entry, sysView, view)
 		if err != nil {
 			c.logger.Error("failed to create mount entry", "path", entry.Path, "error", err)
@@ -1617,7 +1702,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		}
 
 		// Initialize
-		if !nilMount {
+		if !nilMount && !entry.Sealed {
 			// Bind locally
 			localEntry := entry
 			c.postUnsealFuncs = append(c.postUnsealFuncs, func() {
@@ -0,0 +1,276 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/kdf"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// mountKeyPath is the barrier path of the keys of the mounts whose data
+	// is encrypted with a key of their own.
+	mountKeyPath = "core/mount-keys/"
+
+	// mountErasurePath is the barrier path of the records of the mounts
+	// whose data was erased by destroying their key.
+	mountErasurePath = "core/mount-erasures/"
+
+	mountKeyCiphertextVersion byte = 1
+)
+
+// mountKey is the root of the key hierarchy of a mount: the key its data is
+// encrypted with is derived from the seed. The mountKey itself is protected
+// by the barrier, so that destroying it makes the data of the mount
+// unrecoverable.
+type mountKey struct {
+	ID           string    `json:"id"`
+	Seed         []byte    `json:"seed"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// mountErasure records the cryptographic erasure of the data of a mount.
+type mountErasure struct {
+	UUID        string    `json:"uuid"`
+	Path        string    `json:"path"`
+	NamespaceID string    `json:"namespace_id"`
+	Type        string    `json:"type"`
+	Accessor    string    `json:"accessor"`
+	ViewPath    string    `json:"view_path"`
+	KeyID       string    `json:"key_id"`
+	ErasedTime  time.Time `json:"erased_time"`
+}
+
+// aead derives the encryption key of the mount with the given UUID.
+func (k *mountKey) aead(mountUUID string) (cipher.AEAD, error) {
+	key, err := kdf.CounterMode(kdf.HMACSHA256PRF, kdf.HMACSHA256PRFLen, k.Seed, []byte(mountUUID), 256)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// mountKeyStorage encrypts the values written to the storage of a mount with
+// the key of the mount, on top of the barrier encryption. The storage key of
+// each entry is authenticated along with its value, so that the entries
+// cannot be swapped.
+type mountKeyStorage struct {
+	underlying logical.Storage
+	aead       cipher.AEAD
+}
+
+var _ logical.Storage = (*mountKeyStorage)(nil)
+
+func (s *mountKeyStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	return s.underlying.List(ctx, prefix)
+}
+
+func (s *mountKeyStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	entry, err := s.underlying.Get(ctx, key)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(entry.Value) < 1+nonceSize || entry.Value[0] != mountKeyCiphertextVersion {
+		return nil, fmt.Errorf("invalid mount key ciphertext for %q", key)
+	}
+	nonce := entry.Value[1 : 1+nonceSize]
+	value, err := s.aead.Open(nil, nonce, entry.Value[1+nonceSize:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %q with the mount key: %w", key, err)
+	}
+
+	decrypted := *entry
+	decrypted.Value = value
+	return &decrypted, nil
+}
+
+func (s *mountKeyStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if entry == nil {
+		return errors.New("cannot write nil entry")
+	}
+
+	nonceSize := s.aead.NonceSize()
+	value := make([]byte, 1+nonceSize, 1+nonceSize+len(entry.Value)+s.aead.Overhead())
+	value[0] = mountKeyCiphertextVersion
+	if _, err := rand.Read(value[1:]); err != nil {
+		return err
+	}
+	value = s.aead.Seal(value, value[1:], entry.Value, []byte(entry.Key))
+
+	encrypted := *entry
+	encrypted.Value = value
+	return s.underlying.Put(ctx, &encrypted)
+}
+
+func (s *mountKeyStorage) Delete(ctx context.Context, key string) error {
+	return s.underlying.Delete(ctx, key)
+}
+
+// mountKeyStorage wraps the storage of the mount with the encryption of its
+// mount key, if the mount has one. If create is set, a missing key is
+// generated, which is only done when the mount is created.
+func (c *Core) mountKeyStorage(ctx context.Context, entry *MountEntry, underlying logical.Storage, create bool) (logical.Storage, error) {
+	if !entry.MountKey {
+		return underlying, nil
+	}
+
+	key, err := c.loadMountKey(ctx, entry.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		if !create {
+			return nil, fmt.Errorf("the key of the mount at %q is missing", entry.Path)
+		}
+		if key, err = c.createMountKey(ctx, entry.UUID); err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := key.aead(entry.UUID)
+	if err != nil {
+		return nil, err
+	}
+	return &mountKeyStorage{
+		underlying: underlying,
+		aead:       aead,
+	}, nil
+}
+
+func (c *Core) loadMountKey(ctx context.Context, mountUUID string) (*mountKey, error) {
+	se, err := c.barrier.Get(ctx, mountKeyPath+mountUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the mount key: %w", err)
+	}
+	if se == nil {
+		return nil, nil
+	}
+	var key mountKey
+	if err := json.Unmarshal(se.Value, &key); err != nil {
+		return nil, fmt.Errorf("failed to decode the mount key: %w", err)
+	}
+	return &key, nil
+}
+
+func (c *Core) createMountKey(ctx context.Context, mountUUID string) (*mountKey, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	key := &mountKey{
+		ID:           id,
+		Seed:         make([]byte, 32),
+		CreationTime: time.Now().UTC(),
+	}
+	if _, err := rand.Read(key.Seed); err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:      mountKeyPath + mountUUID,
+		Value:    value,
+		SealWrap: true,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to persist the mount key: %w", err)
+	}
+	return key, nil
+}
+
+// eraseMountKey destroys the key of the mount being disabled, which makes its
+// data unrecoverable without deleting it. The erasure is recorded first, so
+// that it can be verified later.
+func (c *Core) eraseMountKey(ctx context.Context, entry *MountEntry) error {
+	key, err := c.loadMountKey(ctx, entry.UUID)
+	if err != nil {
+		return err
+	}
+	erasure := &mountErasure{
+		UUID:        entry.UUID,
+		Path:        entry.Path,
+		NamespaceID: entry.NamespaceID,
+		Type:        entry.Type,
+		Accessor:    entry.Accessor,
+		ViewPath:    entry.ViewPath(),
+		ErasedTime:  time.Now().UTC(),
+	}
+	if key != nil {
+		erasure.KeyID = key.ID
+	}
+
+	value, err := json.Marshal(erasure)
+	if err != nil {
+		return err
+	}
+	if err := c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   mountErasurePath + entry.UUID,
+		Value: value,
+	}); err != nil {
+		return fmt.Errorf("failed to record the erasure of the mount: %w", err)
+	}
+	if err := c.barrier.Delete(ctx, mountKeyPath+entry.UUID); err != nil {
+		return fmt.Errorf("failed to destroy the mount key: %w", err)
+	}
+
+	c.logger.Info("erased mount data by destroying its key", "path", entry.Path, "uuid", entry.UUID, "key_id", erasure.KeyID)
+	return nil
+}
+
+func (c *Core) loadMountErasure(ctx context.Context, mountUUID string) (*mountErasure, error) {
+	se, err := c.barrier.Get(ctx, mountErasurePath+mountUUID)
+	if err != nil {
+		return nil, err
+	}
+	if se == nil {
+		return nil, nil
+	}
+	var erasure mountErasure
+	if err := json.Unmarshal(se.Value, &erasure); err != nil {
+		return nil, err
+	}
+	return &erasure, nil
+}
+
+// verifyMountErasure checks that the key of the erased mount is gone from
+// storage, and counts the entries of the mount left, which cannot be
+// decrypted anymore.
+func (c *Core) verifyMountErasure(ctx context.Context, erasure *mountErasure) (bool, int, error) {
+	se, err := c.barrier.Get(ctx, mountKeyPath+erasure.UUID)
+	if err != nil {
+		return false, 0, err
+	}
+	keys, err := logical.CollectKeys(ctx, NewBarrierView(c.barrier, erasure.ViewPath))
+	if err != nil {
+		return false, 0, err
+	}
+	return se == nil, len(keys), nil
+}
+
+// purgeMountErasure deletes the leftover entries of the erased mount, along
+// with the record of its erasure.
+func (c *Core) purgeMountErasure(ctx context.Context, erasure *mountErasure) error {
+	if err := logical.ClearViewWithLogging(ctx, NewBarrierView(c.barrier, erasure.ViewPath), c.logger.Named("secrets.deletion").With("uuid", erasure.UUID)); err != nil {
+		return err
+	}
+	return c.barrier.Delete(ctx, mountErasurePath+erasure.UUID)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMountKey verifies that the data of a mount enabled with a key of its
+// own is encrypted with it, and is erased by destroying the key when the
+// mount is disabled.
+func TestMountKey(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{}, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/erasable")
+	req.Data["type"] = "noop"
+	req.Data["mount_key"] = true
+	req.ClientToken = root
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	entry := c.router.MatchingMountEntry(ctx, "erasable/")
+	require.True(t, entry.MountKey)
+
+	value := []byte("secret value")
+	view := c.router.MatchingStorageByAPIPath(ctx, "erasable/")
+	require.NoError(t, view.Put(ctx, &logical.StorageEntry{Key: "foo", Value: value}))
+	se, err := view.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, value, se.Value)
+
+	raw, err := c.barrier.Get(ctx, entry.ViewPath()+"foo")
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(raw.Value, value), "expected the value to be encrypted with the mount key")
+
+	// Entries cannot be swapped
+	require.NoError(t, c.barrier.Put(ctx, &logical.StorageEntry{Key: entry.ViewPath() + "bar", Value: raw.Value}))
+	_, err = view.Get(ctx, "bar")
+	require.Error(t, err)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/mounts/erasable")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/mount-erasures/"+entry.UUID)
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["key_destroyed"])
+	require.Equal(t, true, resp.Data["unrecoverable"])
+	require.Equal(t, 2, resp.Data["remaining_entries"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/mount-erasures/"+entry.UUID)
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	keys, err := logical.CollectKeys(ctx, NewBarrierView(c.barrier, entry.ViewPath()))
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	mountHeaderNamespaceIDTemplate   = "{{namespace.id}}"
+	mountHeaderNamespacePathTemplate = "{{namespace.path}}"
+)
+
+// mountHeaderTemplateRe matches the templates of custom response headers.
+var mountHeaderTemplateRe = regexp.MustCompile(`{{([^}]*)}}`)
+
+// reservedMountResponseHeaders are the headers which mounts cannot set, as
+// they are set by Vault or change how clients handle the response.
+var reservedMountResponseHeaders = map[string]struct{}{
+	"Cache-Control":             {},
+	"Content-Encoding":          {},
+	"Content-Length":            {},
+	"Content-Type":              {},
+	"Location":                  {},
+	"Retry-After":               {},
+	"Set-Cookie":                {},
+	"Strict-Transport-Security": {},
+	"Transfer-Encoding":         {},
+	"Vary":                      {},
+	"Www-Authenticate":          {},
+}
+
+// validateMountResponseHeaders validates the custom response headers of a
+// mount, and returns them keyed by their canonical name.
+func validateMountResponseHeaders(headers map[string]string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	validated := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if _, ok := reservedMountResponseHeaders[name]; ok || strings.HasPrefix(name, "X-Vault-") {
+			return nil, fmt.Errorf("header %q is reserved", name)
+		}
+		if _, ok := validated[name]; ok {
+			return nil, fmt.Errorf("header %q is set more than once", name)
+		}
+
+		// Check the templating, with the namespace templates resolved as
+		// identitytpl doesn't know of them
+		templated := populateNamespaceTemplates(value, namespace.RootNamespace)
+		_, _, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:              identitytpl.ACLTemplating,
+			ValidityCheckOnly: true,
+			String:            templated,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in the value of header %q: %w", name, err)
+		}
+		for _, match := range mountHeaderTemplateRe.FindAllStringSubmatch(templated, -1) {
+			key := strings.TrimSpace(match[1])
+			if !strings.HasPrefix(key, "identity.entity.") && !strings.HasPrefix(key, "identity.groups.") && !strings.HasPrefix(key, "time.") {
+				return nil, fmt.Errorf("unknown template %q in the value of header %q", key, name)
+			}
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("invalid value for header %q", name)
+		}
+		validated[name] = value
+	}
+	return validated, nil
+}
+
+func populateNamespaceTemplates(value string, ns *namespace.Namespace) string {
+	if !strings.Contains(value, "{{namespace.") {
+		return value
+	}
+	return strings.NewReplacer(
+		mountHeaderNamespaceIDTemplate, ns.ID,
+		mountHeaderNamespacePathTemplate, ns.Path,
+	).Replace(value)
+}
+
+// templatesIdentity returns whether the value uses identity templates.
+func templatesIdentity(value string) bool {
+	for _, match := range mountHeaderTemplateRe.FindAllStringSubmatch(value, -1) {
+		if strings.HasPrefix(strings.TrimSpace(match[1]), "identity.") {
+			return true
+		}
+	}
+	return false
+}
+
+// addMountResponseHeaders sets the custom response headers of the mount on
+// the response, templated with the namespace of the request and the entity of
+// the request. Headers whose templates cannot be resolved, such as identity
+// templates for requests without an entity, are not set.
+func (c *Core) addMountResponseHeaders(ctx context.Context, entry *MountEntry, entityID string, resp *logical.Response) {
+	if entry == nil || resp == nil {
+		return
+	}
+	rawVal, ok := entry.synthesizedConfigCache.Load("custom_response_headers")
+	if !ok {
+		return
+	}
+	headers := rawVal.(map[string]string)
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		ns = entry.Namespace()
+	}
+
+	// Only look the entity up if a header needs it
+	var entity *identity.Entity
+	var groups []*identity.Group
+	lookedUp := false
+	lookup := func() {
+		lookedUp = true
+		if entityID == "" || c.identityStore == nil {
+			return
+		}
+		entity, err = c.identityStore.MemDBEntityByID(entityID, false)
+		if err != nil || entity == nil {
+			return
+		}
+		directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+		if err != nil {
+			return
+		}
+		groups = append(directGroups, inheritedGroups...)
+	}
+
+	for name, template := range headers {
+		value := populateNamespaceTemplates(template, ns)
+		if strings.Contains(value, "{{") {
+			if templatesIdentity(value) {
+				if !lookedUp {
+					lookup()
+				}
+				if entity == nil {
+					continue
+				}
+			}
+			_, value, err = identitytpl.PopulateString(identitytpl.PopulateStringInput{
+				Mode:        identitytpl.ACLTemplating,
+				String:      value,
+				Entity:      identity.ToSDKEntity(entity),
+				Groups:      identity.ToSDKGroups(groups),
+				NamespaceID: ns.ID,
+			})
+			if err != nil {
+				continue
+			}
+		}
+		// Identity metadata may hold characters which cannot be sent
+		if !httpguts.ValidHeaderFieldValue(value) {
+			continue
+		}
+
+		if resp.Headers == nil {
+			resp.Headers = make(map[string][]string)
+		}
+		resp.Headers[name] = []string{value}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMountResponseHeaders(t *testing.T) {
+	headers, err := validateMountResponseHeaders(map[string]string{
+		"x-team":      "{{identity.entity.metadata.team}}",
+		"X-Namespace": "{{namespace.path}}",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"X-Team":      "{{identity.entity.metadata.team}}",
+		"X-Namespace": "{{namespace.path}}",
+	}, headers)
+
+	for name, invalid := range map[string]map[string]string{
+		"reserved":         {"content-type": "text/plain"},
+		"vault":            {"X-Vault-Token": "nope"},
+		"bad name":         {"X Team": "blue"},
+		"bad template":     {"X-Team": "{{identity.entity.nope}}"},
+		"bad namespace":    {"X-Namespace": "{{namespace.name}}"},
+		"duplicate":        {"x-team": "a", "X-TEAM": "b"},
+		"newline in value": {"X-Team": "blue\r\nSet-Cookie: a=b"},
+	} {
+		_, err := validateMountResponseHeaders(invalid)
+		require.Error(t, err, name)
+	}
+}
+
+// TestCore_MountResponseHeaders verifies that the custom response headers of
+// a mount are templated with the entity and namespace of each request.
+func TestCore_MountResponseHeaders(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "identity/entity")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"name":     "alice",
+		"metadata": map[string]string{"team": "blue"},
+	}
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	entityID := resp.Data["id"].(string)
+
+	te := &logical.TokenEntry{
+		ID:       "entity-token",
+		Path:     "auth/token/create",
+		Policies: []string{"root"},
+		EntityID: entityID,
+		TTL:      3600,
+	}
+	testMakeTokenDirectly(t, c.tokenStore, te)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/secret/tune")
+	req.ClientToken = root
+	req.Data["custom_response_headers"] = map[string]interface{}{
+		"x-team":      "{{identity.entity.metadata.team}}",
+		"X-Namespace": "ns-{{namespace.id}}",
+	}
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/mounts/secret/tune")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"X-Team":      "{{identity.entity.metadata.team}}",
+		"X-Namespace": "ns-{{namespace.id}}",
+	}, resp.Data["custom_response_headers"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.ClientToken = root
+	req.Data["value"] = "bar"
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = te.ID
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"blue"}, resp.Headers["X-Team"])
+	require.Equal(t, []string{"ns-root"}, resp.Headers["X-Namespace"])
+
+	// The root token has no entity, so the identity header is not set
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotContains(t, resp.Headers, "X-Team")
+	require.Equal(t, []string{"ns-root"}, resp.Headers["X-Namespace"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/secret/tune")
+	req.ClientToken = root
+	req.Data["custom_response_headers"] = map[string]interface{}{"Set-Cookie": "a=b"}
+	_, err = c.HandleRequest(ctx, req)
+	require.Error(t, err)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+)
+
+// setMountSealed seals or unseals the mount at the path, which is an auth
+// mount if prefixed with the credential route prefix. Sealed mounts reject
+// all operations and have their backend cleaned up, dropping whatever it
+// holds in memory, until they are unsealed.
+func (c *Core) setMountSealed(ctx context.Context, path string, sealed bool) error {
+	path = sanitizePath(path)
+	isAuth := strings.HasPrefix(path, credentialRoutePrefix)
+	if isAuth {
+		c.authLock.Lock()
+		defer c.authLock.Unlock()
+	} else {
+		c.mountsLock.Lock()
+		defer c.mountsLock.Unlock()
+	}
+
+	entry := c.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.APIPathNoNamespace() != path {
+		return fmt.Errorf("no mount at %q", path)
+	}
+	if strutil.StrListContains(singletonMounts, entry.Type) {
+		return fmt.Errorf("cannot seal %q mounts", entry.Type)
+	}
+	if entry.Sealed == sealed {
+		return nil
+	}
+
+	entry.Sealed = sealed
+	var err error
+	if isAuth {
+		err = c.persistAuth(ctx, c.auth, &entry.Local)
+	} else {
+		err = c.persistMounts(ctx, c.mounts, &entry.Local)
+	}
+	if err != nil {
+		entry.Sealed = !sealed
+		return fmt.Errorf("failed to update the mount table: %w", err)
+	}
+
+	if sealed {
+		if err := c.router.SealMount(ctx, path); err != nil {
+			return err
+		}
+		c.logger.Warn("sealed mount", "path", path, "namespace", entry.Namespace().Path)
+		return nil
+	}
+
+	// Start the backend again before letting requests through
+	if err := c.reloadBackendCommon(ctx, entry, isAuth); err != nil {
+		return fmt.Errorf("failed to start the backend of the mount: %w", err)
+	}
+	if err := c.router.UnsealMount(ctx, path); err != nil {
+		return err
+	}
+	c.logger.Info("unsealed mount", "path", path, "namespace", entry.Namespace().Path)
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMountSeal verifies that a sealed mount rejects all operations and has
+// no backend running, until it is unsealed.
+func TestMountSeal(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{}, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/isolated")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	read := func() error {
+		req := logical.TestRequest(t, logical.ReadOperation, "isolated/foo")
+		req.ClientToken = root
+		_, err := c.HandleRequest(ctx, req)
+		return err
+	}
+	require.NoError(t, read())
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/seal-mount")
+	req.Data["path"] = "isolated"
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, read(), logical.ErrMountSealed)
+	require.Nil(t, c.router.MatchingBackend(ctx, "isolated/"))
+	require.True(t, c.router.MatchingMountEntry(ctx, "isolated/").Sealed)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/unseal-mount")
+	req.Data["path"] = "isolated"
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, read())
+	require.NotNil(t, c.router.MatchingBackend(ctx, "isolated/"))
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/seal-mount")
+	req.Data["path"] = "auth/token"
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.Error(t, err)
+}
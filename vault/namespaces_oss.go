@@ -15,6 +15,11 @@ func (c *Core) NamespaceByID(ctx context.Context, nsID string) (*namespace.Names
 	return namespaceByID(ctx, nsID, c)
 }
 
+// NamespaceByPath returns the namespace the path starts with.
+func (c *Core) NamespaceByPath(path string) *namespace.Namespace {
+	return c.namespaceByPath(path)
+}
+
 func (c *Core) ListNamespaces(includePath bool) []*namespace.Namespace {
 	return []*namespace.Namespace{namespace.RootNamespace}
 }
@@ -0,0 +1,296 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"golang.org/x/net/http2"
+)
+
+const (
+	offloadTaskActivityExport = "activity-export"
+
+	// offloadPathPrefix is the path under which standbys serve the tasks
+	// delegated by the active node.
+	offloadPathPrefix = "/v1/offload/"
+
+	offloadDialTimeout = 5 * time.Second
+
+	// offloadMaxInputSize bounds the size of the input of a delegated task.
+	offloadMaxInputSize = 1 << 20
+)
+
+// offloadTaskFunc runs a CPU-heavy, read-only task from its JSON encoded
+// input, and writes its result to rw. It must not write to storage, as it may
+// run on a standby node.
+type offloadTaskFunc func(ctx context.Context, c *Core, input json.RawMessage, rw http.ResponseWriter) error
+
+// offloadTasks are the tasks which the active node delegates to standbys.
+var offloadTasks = map[string]offloadTaskFunc{
+	offloadTaskActivityExport: offloadActivityExport,
+}
+
+// runOffloadableTask runs the task, on a standby node if one is available so
+// as to save the CPU of the active node, or else locally. The result is
+// written to rw. A task is run locally if no standby could be reached, or if
+// the standby failed before writing any of the result; errors returned by
+// the task itself are not retried.
+func (c *Core) runOffloadableTask(ctx context.Context, task string, input interface{}, rw http.ResponseWriter) error {
+	f, ok := offloadTasks[task]
+	if !ok {
+		return fmt.Errorf("unknown task %q", task)
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	labels := []metrics.Label{{Name: "task", Value: task}}
+	if standby, _ := c.Standby(); !standby {
+		for _, addr := range c.offloadPeers() {
+			start := time.Now()
+			handled, err := c.offloadToStandby(ctx, addr, task, raw, rw)
+			if handled {
+				metrics.MeasureSinceWithLabels([]string{"core", "offload", "remote"}, start, labels)
+				return err
+			}
+			c.logger.Debug("failed to offload task to standby", "task", task, "standby", addr, "error", err)
+		}
+	}
+
+	defer metrics.MeasureSinceWithLabels([]string{"core", "offload", "local"}, time.Now(), labels)
+	return f(ctx, c, raw, rw)
+}
+
+// offloadPeers returns the cluster addresses of the standbys which have
+// recently sent heartbeats, starting from a different one on each call so
+// that the tasks are spread across them.
+func (c *Core) offloadPeers() []string {
+	peers := c.GetHAPeerNodesCached()
+	if len(peers) == 0 {
+		return nil
+	}
+
+	addrs := make([]string, len(peers))
+	next := int(c.offloadNext.Add(1) % uint64(len(peers)))
+	for i := range peers {
+		addrs[i] = peers[(next+i)%len(peers)].ClusterAddress
+	}
+	return addrs
+}
+
+// offloadToStandby runs the task on the standby at the cluster address addr.
+// It returns whether the standby handled the task, in which case the error is
+// that of the task, or of copying its result.
+func (c *Core) offloadToStandby(ctx context.Context, addr, task string, input []byte, rw http.ResponseWriter) (bool, error) {
+	clusterListener := c.getClusterListener()
+	if clusterListener == nil {
+		return false, errors.New("no cluster listener configured")
+	}
+	clusterURL, err := url.Parse(addr)
+	if err != nil {
+		return false, err
+	}
+
+	dialer := clusterListener.GetDialerFunc(ctx, consts.OffloadWorkALPN)
+	transport := &http2.Transport{
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dialer(addr, offloadDialTimeout)
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+clusterURL.Host+offloadPathPrefix+task, bytes.NewReader(input))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnprocessableEntity:
+		var taskErr struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&taskErr); err != nil {
+			return false, fmt.Errorf("failed to decode the error of the task: %w", err)
+		}
+		return true, errors.New(taskErr.Error)
+	default:
+		return false, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	for k, v := range resp.Header {
+		if k == "Content-Length" {
+			continue
+		}
+		rw.Header()[k] = v
+	}
+	if _, err := io.Copy(rw, resp.Body); err != nil {
+		return true, fmt.Errorf("failed to copy the result of the task from standby %s: %w", addr, err)
+	}
+	return true, nil
+}
+
+// offloadHandler is the cluster handler with which standbys serve the tasks
+// delegated by the active node.
+type offloadHandler struct {
+	core     *Core
+	fws      *http2.Server
+	logger   log.Logger
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newOffloadHandler(c *Core, fws *http2.Server) *offloadHandler {
+	return &offloadHandler{
+		core:   c,
+		fws:    fws,
+		logger: c.logger.Named("offload"),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// ServerLookup satisfies the ClusterHandler interface and returns the
+// cluster certificate, which the standby shares with the active node.
+func (h *offloadHandler) ServerLookup(ctx context.Context, clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	currCert := h.core.localClusterCert.Load().([]byte)
+	if len(currCert) == 0 {
+		return nil, fmt.Errorf("got offload connection but no local cert")
+	}
+
+	localCert := make([]byte, len(currCert))
+	copy(localCert, currCert)
+
+	return &tls.Certificate{
+		Certificate: [][]byte{localCert},
+		PrivateKey:  h.core.localClusterPrivateKey.Load().(*ecdsa.PrivateKey),
+		Leaf:        h.core.localClusterParsedCert.Load().(*x509.Certificate),
+	}, nil
+}
+
+// CALookup satisfies the ClusterHandler interface and returns the ha ca cert.
+func (h *offloadHandler) CALookup(ctx context.Context) ([]*x509.Certificate, error) {
+	parsedCert := h.core.localClusterParsedCert.Load().(*x509.Certificate)
+	if parsedCert == nil {
+		return nil, fmt.Errorf("offload connection client but no local cert")
+	}
+
+	return []*x509.Certificate{parsedCert}, nil
+}
+
+// Handoff serves an offload connection.
+func (h *offloadHandler) Handoff(ctx context.Context, shutdownWg *sync.WaitGroup, closeCh chan struct{}, tlsConn *tls.Conn) error {
+	shutdownWg.Add(2)
+	quitCh := make(chan struct{})
+	go func() {
+		select {
+		case <-quitCh:
+		case <-closeCh:
+		case <-h.stopCh:
+		}
+		tlsConn.Close()
+		shutdownWg.Done()
+	}()
+
+	go func() {
+		h.fws.ServeConn(tlsConn, &http2.ServeConnOpts{
+			Handler: http.HandlerFunc(h.serveTask),
+			BaseConfig: &http.Server{
+				ErrorLog: h.logger.StandardLogger(nil),
+			},
+		})
+		close(quitCh)
+		shutdownWg.Done()
+	}()
+
+	return nil
+}
+
+// Stop closes the offload connections.
+func (h *offloadHandler) Stop() error {
+	h.stopOnce.Do(func() { close(h.stopCh) })
+	return nil
+}
+
+func (h *offloadHandler) serveTask(w http.ResponseWriter, r *http.Request) {
+	task := strings.TrimPrefix(r.URL.Path, offloadPathPrefix)
+	f, ok := offloadTasks[task]
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, offloadPathPrefix) || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// The active node runs its tasks itself; a request reaching it means that
+	// it was a standby when the task was sent.
+	if standby, _ := h.core.Standby(); !standby {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	input, err := io.ReadAll(io.LimitReader(r.Body, offloadMaxInputSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	defer metrics.MeasureSinceWithLabels([]string{"core", "offload", "served"}, time.Now(), []metrics.Label{{Name: "task", Value: task}})
+
+	ow := &offloadResponseWriter{ResponseWriter: w}
+	err = f(r.Context(), h.core, input, ow)
+	switch {
+	case err == nil:
+	case !ow.written:
+		for k := range w.Header() {
+			delete(w.Header(), k)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		// Part of the result was sent; reset the stream so that the active
+		// node does not mistake it for the whole result.
+		h.logger.Error("failed to run offloaded task", "task", task, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// offloadResponseWriter records whether any of the result was written.
+type offloadResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *offloadResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *offloadResponseWriter) WriteHeader(statusCode int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+)
+
+// TestOffload_Standby verifies that the active node runs the tasks it
+// offloads on its standbys, that the errors of the tasks are returned, and
+// that the tasks run locally when there is no standby.
+func TestOffload_Standby(t *testing.T) {
+	offloadTasks["test-addr"] = func(ctx context.Context, c *Core, input json.RawMessage, rw http.ResponseWriter) error {
+		rw.Write([]byte(c.ClusterAddr()))
+		return nil
+	}
+	offloadTasks["test-error"] = func(ctx context.Context, c *Core, input json.RawMessage, rw http.ResponseWriter) error {
+		return errors.New("task failed")
+	}
+	defer func() {
+		delete(offloadTasks, "test-addr")
+		delete(offloadTasks, "test-error")
+	}()
+
+	cluster := NewTestCluster(t, nil, nil)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	active := cluster.Cores[0].Core
+	TestWaitActiveForwardingReady(t, active)
+	corehelpers.RetryUntil(t, 2*clusterTestPausePeriod, func() error {
+		if peers := active.GetHAPeerNodesCached(); len(peers) != 2 {
+			return fmt.Errorf("expected 2 peers, got %d", len(peers))
+		}
+		return nil
+	})
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		rw := httptest.NewRecorder()
+		if err := active.runOffloadableTask(context.Background(), "test-addr", nil, rw); err != nil {
+			t.Fatal(err)
+		}
+		addr := rw.Body.String()
+		if addr == active.ClusterAddr() {
+			t.Fatal("expected the task to run on a standby")
+		}
+		seen[addr] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected the tasks to be spread across both standbys, got %v", seen)
+	}
+
+	err := active.runOffloadableTask(context.Background(), "test-error", nil, httptest.NewRecorder())
+	if err == nil || err.Error() != "task failed" {
+		t.Fatalf("expected the error of the task, got %v", err)
+	}
+
+	c, _, _ := TestCoreUnsealed(t)
+	rw := httptest.NewRecorder()
+	if err := c.runOffloadableTask(context.Background(), "test-addr", nil, rw); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Body.String() != c.ClusterAddr() {
+		t.Fatalf("expected the task to run locally, got %q", rw.Body.String())
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const pathRewriteSubPath = "config/path-rewrites/"
+
+// pathRewriteRule rewrites requests to From, relative to the namespace it was
+// created in, to To. If From ends in '/', every path below it is rewritten by
+// replacing the prefix; otherwise only From itself is.
+type pathRewriteRule struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	NamespaceID  string    `json:"namespace_id"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	CreationTime time.Time `json:"creation_time"`
+
+	// key is the storage key of the rule. Rules saved before they were
+	// keyed by namespace are stored under their name alone.
+	key string
+}
+
+// pathRewriteRuleKey returns the key of the rule with the given name in the
+// namespace, so that each namespace has its own set of rule names.
+func pathRewriteRuleKey(nsID, name string) string {
+	return nsID + "/" + name
+}
+
+func (r *pathRewriteRule) rewrite(path string) (string, bool) {
+	if strings.HasSuffix(r.From, "/") {
+		if !strings.HasPrefix(path, r.From) {
+			return "", false
+		}
+		return r.To + strings.TrimPrefix(path, r.From), true
+	}
+	if path != r.From {
+		return "", false
+	}
+	return r.To, true
+}
+
+// pathRewriteState is the in-memory view of the configured rewrite rules.
+type pathRewriteState struct {
+	rules map[string]*pathRewriteRule
+
+	// ordered holds the rules with the longest From first, so that the most
+	// specific rule matching a path is applied.
+	ordered []*pathRewriteRule
+}
+
+func newPathRewriteState(rules map[string]*pathRewriteRule) *pathRewriteState {
+	state := &pathRewriteState{
+		rules:   rules,
+		ordered: make([]*pathRewriteRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		state.ordered = append(state.ordered, rule)
+	}
+	sort.Slice(state.ordered, func(i, j int) bool {
+		if len(state.ordered[i].From) != len(state.ordered[j].From) {
+			return len(state.ordered[i].From) > len(state.ordered[j].From)
+		}
+		return state.ordered[i].Name < state.ordered[j].Name
+	})
+	return state
+}
+
+// match returns the rule to apply to a request for path in the namespace with
+// ID nsID, and the rewritten path.
+func (s *pathRewriteState) match(nsID, path string) (*pathRewriteRule, string) {
+	for _, rule := range s.ordered {
+		if rule.NamespaceID != nsID {
+			continue
+		}
+		if rewritten, ok := rule.rewrite(path); ok {
+			return rule, rewritten
+		}
+	}
+	return nil, ""
+}
+
+// applyPathRewrite rewrites the path of the request according to the most
+// specific matching rewrite rule. Rules are applied once, before ACLs are
+// checked and the request is routed, so policies must grant access to the
+// rewritten path. The original path is set on the request so that it is
+// recorded in audit entries.
+func (c *Core) applyPathRewrite(ctx context.Context, req *logical.Request) {
+	state := c.pathRewrites.Load()
+	if state == nil || len(state.ordered) == 0 || req.PathRewrite != nil {
+		return
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	rule, rewritten := state.match(ns.ID, req.Path)
+	if rule == nil {
+		return
+	}
+
+	req.PathRewrite = &logical.PathRewrite{
+		Rule:         rule.Name,
+		OriginalPath: req.Path,
+	}
+	req.Path = rewritten
+
+	metrics.IncrCounterWithLabels([]string{"core", "path_rewrite", "applied"}, 1, []metrics.Label{
+		{Name: "rule", Value: rule.Name},
+	})
+}
+
+// validatePathRewriteRule checks that a rule cannot rewrite requests to or
+// from the system backend, which would allow it to make itself impossible to
+// remove.
+func validatePathRewriteRule(rule *pathRewriteRule) error {
+	if rule.From == "" || rule.To == "" {
+		return fmt.Errorf("from and to are required")
+	}
+	if rule.From == rule.To {
+		return fmt.Errorf("from and to must differ")
+	}
+	for _, p := range []string{rule.From, rule.To} {
+		if p == "sys" || strings.HasPrefix(p, "sys/") {
+			return fmt.Errorf("paths under sys/ cannot be rewritten")
+		}
+	}
+	if strings.HasSuffix(rule.From, "/") != strings.HasSuffix(rule.To, "/") {
+		return fmt.Errorf("from and to must either both or neither end in '/'")
+	}
+	return nil
+}
+
+func (c *Core) pathRewriteView() *BarrierView {
+	return c.systemBarrierView.SubView(pathRewriteSubPath)
+}
+
+// updatePathRewriteState applies f to a copy of the current rewrite rules.
+func (c *Core) updatePathRewriteState(f func(map[string]*pathRewriteRule)) {
+	c.pathRewritesLock.Lock()
+	defer c.pathRewritesLock.Unlock()
+
+	rules := make(map[string]*pathRewriteRule)
+	if current := c.pathRewrites.Load(); current != nil {
+		for k, v := range current.rules {
+			rules[k] = v
+		}
+	}
+	f(rules)
+	c.pathRewrites.Store(newPathRewriteState(rules))
+}
+
+// savePathRewriteRule stores the rule. existing is the rule of the same name
+// in the namespace, if any, whose entry is removed if it was stored under
+// another key.
+func (c *Core) savePathRewriteRule(ctx context.Context, rule *pathRewriteRule, existing *pathRewriteRule) error {
+	rule.key = pathRewriteRuleKey(rule.NamespaceID, rule.Name)
+	entry, err := logical.StorageEntryJSON(rule.key, rule)
+	if err != nil {
+		return fmt.Errorf("failed to create path rewrite rule entry: %w", err)
+	}
+	if err := c.pathRewriteView().Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save path rewrite rule: %w", err)
+	}
+	if existing != nil && existing.key != rule.key {
+		if err := c.pathRewriteView().Delete(ctx, existing.key); err != nil {
+			return fmt.Errorf("failed to delete path rewrite rule: %w", err)
+		}
+	}
+
+	c.updatePathRewriteState(func(rules map[string]*pathRewriteRule) { rules[rule.key] = rule })
+	return nil
+}
+
+func (c *Core) deletePathRewriteRule(ctx context.Context, rule *pathRewriteRule) error {
+	if err := c.pathRewriteView().Delete(ctx, rule.key); err != nil {
+		return fmt.Errorf("failed to delete path rewrite rule: %w", err)
+	}
+
+	c.updatePathRewriteState(func(rules map[string]*pathRewriteRule) {
+		delete(rules, pathRewriteRuleKey(rule.NamespaceID, rule.Name))
+	})
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadPathRewrites(ctx context.Context) error {
+	view := c.pathRewriteView()
+
+	keys, err := logical.CollectKeys(ctx, view)
+	if err != nil {
+		return fmt.Errorf("failed to list path rewrite rules: %w", err)
+	}
+
+	rules := make(map[string]*pathRewriteRule, len(keys))
+	for _, key := range keys {
+		out, err := view.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read path rewrite rule: %w", err)
+		}
+		if out == nil {
+			continue
+		}
+		var rule pathRewriteRule
+		if err := out.DecodeJSON(&rule); err != nil {
+			return err
+		}
+		rule.key = key
+		if rule.NamespaceID == "" {
+			ns := c.namespaceByPath(rule.Namespace)
+			if ns == nil || ns.Path != rule.Namespace {
+				c.logger.Warn("skipping path rewrite rule of unknown namespace", "name", rule.Name, "namespace", rule.Namespace)
+				continue
+			}
+			rule.NamespaceID = ns.ID
+		}
+		rules[pathRewriteRuleKey(rule.NamespaceID, rule.Name)] = &rule
+	}
+
+	c.pathRewrites.Store(newPathRewriteState(rules))
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_PathRewrite verifies that requests are rewritten by the most
+// specific matching rule, and that the original path is recorded on the
+// request.
+func TestCore_PathRewrite(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Request, *logical.Response) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp != nil && resp.IsError(), "bad: %#v", resp)
+		return req, resp
+	}
+	invalid := func(data map[string]interface{}) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/config/path-rewrites/invalid")
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		require.True(t, err != nil || resp.IsError(), "expected %v to be rejected", data)
+	}
+
+	invalid(map[string]interface{}{"from": "sys/", "to": "secret/"})
+	invalid(map[string]interface{}{"from": "legacy/", "to": "sys/"})
+	invalid(map[string]interface{}{"from": "legacy/", "to": "secret"})
+
+	request(logical.UpdateOperation, "sys/config/path-rewrites/legacy", map[string]interface{}{
+		"from": "legacy/",
+		"to":   "secret/",
+	})
+	request(logical.UpdateOperation, "sys/config/path-rewrites/legacy-nested", map[string]interface{}{
+		"from": "legacy/nested/",
+		"to":   "secret/moved/",
+	})
+	request(logical.UpdateOperation, "sys/config/path-rewrites/single", map[string]interface{}{
+		"from": "/old-secret",
+		"to":   "secret/single",
+	})
+
+	_, resp := request(logical.ListOperation, "sys/config/path-rewrites/", nil)
+	require.Equal(t, []string{"legacy", "legacy-nested", "single"}, resp.Data["keys"])
+
+	req, _ := request(logical.UpdateOperation, "legacy/foo", map[string]interface{}{"value": "foo"})
+	require.Equal(t, "secret/foo", req.Path)
+	require.Equal(t, &logical.PathRewrite{Rule: "legacy", OriginalPath: "legacy/foo"}, req.PathRewrite)
+
+	_, resp = request(logical.ReadOperation, "secret/foo", nil)
+	require.Equal(t, "foo", resp.Data["value"])
+
+	req, _ = request(logical.UpdateOperation, "legacy/nested/bar", map[string]interface{}{"value": "bar"})
+	require.Equal(t, "secret/moved/bar", req.Path)
+	require.Equal(t, "legacy-nested", req.PathRewrite.Rule)
+
+	request(logical.UpdateOperation, "secret/single", map[string]interface{}{"value": "single"})
+	_, resp = request(logical.ReadOperation, "old-secret", nil)
+	require.Equal(t, "single", resp.Data["value"])
+
+	// Requests to other paths are left untouched.
+	req, _ = request(logical.ReadOperation, "secret/foo", nil)
+	require.Nil(t, req.PathRewrite)
+
+	request(logical.DeleteOperation, "sys/config/path-rewrites/legacy", nil)
+	req = logical.TestRequest(t, logical.ReadOperation, "legacy/foo")
+	req.ClientToken = root
+	_, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.Nil(t, req.PathRewrite)
+
+	// Rules are loaded on unseal.
+	require.NoError(t, c.loadPathRewrites(ctx))
+	rules := c.pathRewrites.Load().rules
+	require.Len(t, rules, 2)
+	require.Equal(t, "secret/moved/", rules[pathRewriteRuleKey(namespace.RootNamespaceID, "legacy-nested")].To)
+}
+
+// TestCore_PathRewrite_Namespaces verifies that the names of rewrite rules are
+// scoped to their namespace, and that rules stored before they were keyed by
+// namespace are still loaded.
+func TestCore_PathRewrite_Namespaces(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	rootCtx := namespace.RootContext(nil)
+	ns1Ctx := namespace.ContextWithNamespace(rootCtx, &namespace.Namespace{ID: "ns1", Path: "ns1/"})
+
+	request := func(ctx context.Context, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.Data = data
+		resp, err := c.systemBackend.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp != nil && resp.IsError(), "bad: %#v", resp)
+		return resp
+	}
+
+	request(rootCtx, logical.UpdateOperation, "config/path-rewrites/legacy", map[string]interface{}{"from": "legacy/", "to": "secret/"})
+	request(ns1Ctx, logical.UpdateOperation, "config/path-rewrites/legacy", map[string]interface{}{"from": "old/", "to": "kv/"})
+
+	resp := request(ns1Ctx, logical.ListOperation, "config/path-rewrites/", nil)
+	require.Equal(t, []string{"legacy"}, resp.Data["keys"])
+	resp = request(ns1Ctx, logical.ReadOperation, "config/path-rewrites/legacy", nil)
+	require.Equal(t, "old/", resp.Data["from"])
+	resp = request(rootCtx, logical.ReadOperation, "config/path-rewrites/legacy", nil)
+	require.Equal(t, "legacy/", resp.Data["from"])
+
+	request(ns1Ctx, logical.DeleteOperation, "config/path-rewrites/legacy", nil)
+	resp = request(ns1Ctx, logical.ReadOperation, "config/path-rewrites/legacy", nil)
+	require.Nil(t, resp)
+	resp = request(rootCtx, logical.ReadOperation, "config/path-rewrites/legacy", nil)
+	require.Equal(t, "legacy/", resp.Data["from"])
+
+	// A rule stored under its name alone belongs to the namespace of its path,
+	// and is moved under the key of its namespace when it is updated.
+	entry, err := logical.StorageEntryJSON("moved", &pathRewriteRule{Name: "moved", From: "moved/", To: "secret/"})
+	require.NoError(t, err)
+	require.NoError(t, c.pathRewriteView().Put(rootCtx, entry))
+	require.NoError(t, c.loadPathRewrites(rootCtx))
+	resp = request(rootCtx, logical.ReadOperation, "config/path-rewrites/moved", nil)
+	require.Equal(t, "moved/", resp.Data["from"])
+
+	request(rootCtx, logical.UpdateOperation, "config/path-rewrites/moved", map[string]interface{}{"from": "moved/", "to": "kv/"})
+	keys, err := logical.CollectKeys(rootCtx, c.pathRewriteView())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		pathRewriteRuleKey(namespace.RootNamespaceID, "legacy"),
+		pathRewriteRuleKey(namespace.RootNamespaceID, "moved"),
+	}, keys)
+}
@@ -281,6 +281,11 @@ func (c *Core) reloadBackendCommon(ctx context.Context, entry *MountEntry, isAut
 				return err
 			}
 			re.binaryPaths.Store(binaryPathsEntry)
+			paginatedListPathsEntry, err := parseUnauthenticatedPaths(paths.PaginatedList)
+			if err != nil {
+				return err
+			}
+			re.paginatedListPaths.Store(paginatedListPathsEntry)
 		}
 	}
 
@@ -190,6 +190,10 @@ type ACLPermissions struct {
 	ControlGroup        *ControlGroup
 	GrantingPoliciesMap map[uint32][]logical.PolicyInfo
 	SubscribeEventTypes []string
+
+	// rulePath is the path of the policy rule these permissions were
+	// compiled from, with its trailing glob.
+	rulePath string
 }
 
 func (p *ACLPermissions) Clone() (*ACLPermissions, error) {
@@ -199,6 +203,7 @@ func (p *ACLPermissions) Clone() (*ACLPermissions, error) {
 		MaxWrappingTTL:      p.MaxWrappingTTL,
 		RequiredParameters:  p.RequiredParameters[:],
 		SubscribeEventTypes: p.SubscribeEventTypes[:],
+		rulePath:            p.rulePath,
 	}
 
 	switch {
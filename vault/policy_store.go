@@ -42,6 +42,9 @@ const (
 	// tokens
 	controlGroupPolicyName = "control-group"
 
+	// auditorPolicyName is the name of the built-in policy for auditors
+	auditorPolicyName = "auditor"
+
 	// responseWrappingPolicy is the policy that ensures cubbyhole response
 	// wrapping can always succeed.
 	responseWrappingPolicy = `
@@ -63,6 +66,69 @@ path "cubbyhole/control-group" {
 path "sys/wrapping/unwrap" {
     capabilities = ["update"]
 }
+`
+	// auditorPolicy is the built-in policy granting read-only access to the
+	// client counters, the audit configuration, the health of the cluster
+	// and the policies, for auditors. It is only served when enabled in the
+	// server configuration, as tokens may already reference a policy of that
+	// name, and a policy of the same name written takes precedence.
+	auditorPolicy = `
+# Read the client counters and their configuration
+path "sys/internal/counters/*" {
+    capabilities = ["read"]
+}
+
+# Read the audit devices and the audited request headers
+path "sys/audit" {
+    capabilities = ["read", "sudo"]
+}
+
+path "sys/config/auditing/request-headers" {
+    capabilities = ["read", "sudo"]
+}
+
+path "sys/config/auditing/request-headers/*" {
+    capabilities = ["read", "sudo"]
+}
+
+# Read the health of the cluster
+path "sys/health" {
+    capabilities = ["read"]
+}
+
+path "sys/ha-status" {
+    capabilities = ["read"]
+}
+
+path "sys/leader" {
+    capabilities = ["read"]
+}
+
+path "sys/seal-status" {
+    capabilities = ["read"]
+}
+
+# List and read the policies
+path "sys/policy" {
+    capabilities = ["read", "list"]
+}
+
+path "sys/policy/*" {
+    capabilities = ["read"]
+}
+
+path "sys/policies/acl" {
+    capabilities = ["list"]
+}
+
+path "sys/policies/acl/*" {
+    capabilities = ["read"]
+}
+
+# List the namespaces, to audit them in turn
+path "sys/namespaces" {
+    capabilities = ["list"]
+}
 `
 	// defaultPolicy is the "default" policy
 	defaultPolicy = `
@@ -81,6 +147,14 @@ path "auth/token/revoke-self" {
     capabilities = ["update"]
 }
 
+# Allow tokens to list and revoke the other tokens of their entity
+path "auth/token/self/inventory" {
+    capabilities = ["read"]
+}
+path "auth/token/self/inventory/revoke-others" {
+    capabilities = ["update"]
+}
+
 # Allow a token to look up its own capabilities on a path
 path "sys/capabilities-self" {
     capabilities = ["update"]
@@ -252,6 +326,10 @@ func NewPolicyStore(ctx context.Context, core *Core, baseView *BarrierView, syst
 
 	// Special-case root; doesn't exist on disk but does need to be found
 	ps.policyTypeMap.Store(ps.cacheKey(namespace.RootNamespace, "root"), PolicyTypeACL)
+	// Likewise the built-in auditor policy, unless it was written
+	if ps.builtinAuditorPolicyEnabled() {
+		ps.policyTypeMap.Store(ps.cacheKey(namespace.RootNamespace, auditorPolicyName), PolicyTypeACL)
+	}
 	return ps, nil
 }
 
@@ -573,6 +651,9 @@ func (ps *PolicyStore) switchedGetPolicy(ctx context.Context, name string, polic
 	}
 
 	if out == nil {
+		if policyType == PolicyTypeACL && name == auditorPolicyName && ps.builtinAuditorPolicyEnabled() {
+			return ps.builtinAuditorPolicy(ns, cache, index)
+		}
 		return nil, nil
 	}
 
@@ -795,6 +876,10 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 		}
 
 		ps.policyTypeMap.Delete(index)
+		if name == auditorPolicyName && ns.ID == namespace.RootNamespaceID && ps.builtinAuditorPolicyEnabled() {
+			// The built-in auditor policy applies again
+			ps.policyTypeMap.Store(index, PolicyTypeACL)
+		}
 
 	case PolicyTypeRGP:
 		if physicalDeletion {
@@ -934,6 +1019,26 @@ func (ps *PolicyStore) loadACLPolicyInternal(ctx context.Context, policyName, po
 	return ps.setPolicyInternal(ctx, policy)
 }
 
+// builtinAuditorPolicyEnabled returns whether the built-in auditor policy is
+// served where no policy of that name is written.
+func (ps *PolicyStore) builtinAuditorPolicyEnabled() bool {
+	return ps.core != nil && ps.core.enableBuiltinAuditorPolicy
+}
+
+// builtinAuditorPolicy parses the built-in auditor policy in the namespace.
+func (ps *PolicyStore) builtinAuditorPolicy(ns *namespace.Namespace, cache *lru.TwoQueueCache, index string) (*Policy, error) {
+	p, err := ParseACLPolicy(ns, auditorPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s policy: %w", auditorPolicyName, err)
+	}
+	p.Name = auditorPolicyName
+	p.Type = PolicyTypeACL
+	if cache != nil {
+		cache.Add(index, p)
+	}
+	return p, nil
+}
+
 func (ps *PolicyStore) sanitizeName(name string) string {
 	return strings.ToLower(strings.TrimSpace(name))
 }
@@ -300,6 +300,8 @@ func TestDefaultPolicy(t *testing.T) {
 		"lookup self":            {logical.ReadOperation, "auth/token/lookup-self", true},
 		"renew self":             {logical.UpdateOperation, "auth/token/renew-self", true},
 		"revoke self":            {logical.UpdateOperation, "auth/token/revoke-self", true},
+		"list self inventory":    {logical.ReadOperation, "auth/token/self/inventory", true},
+		"revoke other tokens":    {logical.UpdateOperation, "auth/token/self/inventory/revoke-others", true},
 		"check own capabilities": {logical.UpdateOperation, "sys/capabilities-self", true},
 
 		"read arbitrary path":     {logical.ReadOperation, "foo/bar", false},
@@ -321,6 +323,92 @@ func TestDefaultPolicy(t *testing.T) {
 	}
 }
 
+func TestAuditorPolicy(t *testing.T) {
+	core, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{EnableBuiltinAuditorPolicy: true})
+	ps := core.policyStore
+	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+
+	acl, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespaceID: {auditorPolicyName}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		op            logical.Operation
+		path          string
+		expectAllowed bool
+	}{
+		"read counters":        {logical.ReadOperation, "sys/internal/counters/activity", true},
+		"read audit devices":   {logical.ReadOperation, "sys/audit", true},
+		"read ha status":       {logical.ReadOperation, "sys/ha-status", true},
+		"list policies":        {logical.ListOperation, "sys/policies/acl", true},
+		"read policy":          {logical.ReadOperation, "sys/policies/acl/default", true},
+		"enable audit device":  {logical.UpdateOperation, "sys/audit/file", false},
+		"write policy":         {logical.UpdateOperation, "sys/policies/acl/foo", false},
+		"configure counters":   {logical.UpdateOperation, "sys/internal/counters/config", false},
+		"read arbitrary path":  {logical.ReadOperation, "secret/foo", false},
+		"write arbitrary path": {logical.UpdateOperation, "secret/foo", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := new(logical.Request)
+			request.Operation = tc.op
+			request.Path = tc.path
+
+			result := acl.AllowOperation(ctx, request, false)
+			if tc.expectAllowed != result.Allowed {
+				t.Fatalf("Expected %v, got %v", tc.expectAllowed, result.Allowed)
+			}
+		})
+	}
+
+	// A written auditor policy takes precedence, until deleted.
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `path "sys/health" { capabilities = ["read"] }`)
+	require.NoError(t, err)
+	policy.Name = auditorPolicyName
+	require.NoError(t, ps.SetPolicy(ctx, policy))
+	p, err := ps.GetPolicy(ctx, auditorPolicyName, PolicyTypeToken)
+	require.NoError(t, err)
+	require.Len(t, p.Paths, 1)
+
+	require.NoError(t, ps.DeletePolicy(ctx, auditorPolicyName, PolicyTypeACL))
+	p, err = ps.GetPolicy(ctx, auditorPolicyName, PolicyTypeToken)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.Greater(t, len(p.Paths), 1)
+}
+
+// TestAuditorPolicy_Disabled verifies that, unless enabled, the built-in
+// auditor policy is not served, so that tokens which already reference a
+// missing "auditor" policy do not gain access after an upgrade.
+func TestAuditorPolicy_Disabled(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ps := c.policyStore
+	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+
+	p, err := ps.GetPolicy(ctx, auditorPolicyName, PolicyTypeToken)
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"policies":          []string{auditorPolicyName},
+			"no_default_policy": true,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError())
+	token := resp.Auth.ClientToken
+
+	for _, path := range []string{"sys/audit", "sys/policies/acl", "sys/internal/counters/activity"} {
+		capabilities, err := c.Capabilities(ctx, token, path)
+		require.NoError(t, err)
+		require.Equal(t, []string{DenyCapability}, capabilities, path)
+	}
+}
+
 // TestPolicyStore_PoliciesByNamespaces tests the policiesByNamespaces function, which should return a slice of policy names for a given slice of namespaces.
 func TestPolicyStore_PoliciesByNamespaces(t *testing.T) {
 	_, ps := mockPolicyWithCore(t, false)
@@ -0,0 +1,353 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+)
+
+const (
+	defaultPprofWatchdogCheckInterval = 10 * time.Second
+	defaultPprofWatchdogCooldown      = 10 * time.Minute
+	defaultPprofWatchdogMaxDumps      = 20
+
+	pprofWatchdogTriggerGoroutines = "goroutines"
+	pprofWatchdogTriggerHeap       = "heap"
+	pprofWatchdogTriggerGCPause    = "gc_pause"
+
+	pprofWatchdogMetadataFile = "metadata.json"
+)
+
+// pprofWatchdogProfiles are the profiles captured in each dump.
+var pprofWatchdogProfiles = []string{"goroutine", "heap", "allocs", "mutex", "block", "threadcreate"}
+
+var pprofWatchdogDumpIDRe = regexp.MustCompile(`^[0-9]{8}T[0-9]{9}Z-[a-z_]+$`)
+
+var errPprofDumpNotFound = errors.New("pprof dump not found")
+
+// PprofWatchdogConfig configures the watchdog which captures pprof dumps when
+// the goroutine count, the heap, or the GC pauses exceed their thresholds.
+// A zero threshold disables its trigger.
+type PprofWatchdogConfig struct {
+	// Directory is where the dumps are stored.
+	Directory string
+
+	GoroutineThreshold int
+	HeapThreshold      uint64
+	GCPauseThreshold   time.Duration
+
+	// CheckInterval is how often the thresholds are checked, and Cooldown
+	// the minimum time between two dumps of the same trigger.
+	CheckInterval time.Duration
+	Cooldown      time.Duration
+
+	// MaxDumps and MaxAge limit the retained dumps, oldest removed first.
+	MaxDumps int
+	MaxAge   time.Duration
+}
+
+// pprofWatchdog captures pprof dumps automatically, so that transient leaks
+// are not lost before an operator can react.
+type pprofWatchdog struct {
+	config *PprofWatchdogConfig
+	logger log.Logger
+
+	// dumpLock serializes dumps and their pruning with reads of the dumps.
+	dumpLock  sync.Mutex
+	lastDumps map[string]time.Time
+
+	// lastNumGC is the GC count at the last check, to only consider the
+	// pauses of the collections since.
+	lastNumGC uint32
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// pprofDump describes a dump stored by the watchdog.
+type pprofDump struct {
+	ID         string    `json:"id"`
+	Trigger    string    `json:"trigger"`
+	Value      uint64    `json:"value"`
+	Threshold  uint64    `json:"threshold"`
+	CapturedAt time.Time `json:"captured_at"`
+	Profiles   []string  `json:"profiles"`
+}
+
+func newPprofWatchdog(config *PprofWatchdogConfig, logger log.Logger) (*pprofWatchdog, error) {
+	if config.Directory == "" {
+		return nil, errors.New("pprof watchdog requires a directory")
+	}
+	if config.GoroutineThreshold <= 0 && config.HeapThreshold == 0 && config.GCPauseThreshold <= 0 {
+		return nil, errors.New("pprof watchdog requires at least one threshold")
+	}
+	if err := os.MkdirAll(config.Directory, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create pprof watchdog directory: %w", err)
+	}
+
+	conf := *config
+	if conf.CheckInterval <= 0 {
+		conf.CheckInterval = defaultPprofWatchdogCheckInterval
+	}
+	if conf.Cooldown <= 0 {
+		conf.Cooldown = defaultPprofWatchdogCooldown
+	}
+	if conf.MaxDumps <= 0 {
+		conf.MaxDumps = defaultPprofWatchdogMaxDumps
+	}
+
+	return &pprofWatchdog{
+		config:    &conf,
+		logger:    logger,
+		lastDumps: make(map[string]time.Time),
+		stopCh:    make(chan struct{}),
+	}, nil
+}
+
+func (w *pprofWatchdog) run() {
+	ticker := time.NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.check(time.Now())
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *pprofWatchdog) stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
+}
+
+// check captures a dump for each threshold exceeded, unless one was captured
+// for it within the cooldown.
+func (w *pprofWatchdog) check(now time.Time) {
+	if w.config.GoroutineThreshold > 0 {
+		if n := runtime.NumGoroutine(); n > w.config.GoroutineThreshold {
+			w.trigger(now, pprofWatchdogTriggerGoroutines, uint64(n), uint64(w.config.GoroutineThreshold))
+		}
+	}
+
+	if w.config.HeapThreshold == 0 && w.config.GCPauseThreshold <= 0 {
+		return
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	if w.config.HeapThreshold > 0 && stats.HeapAlloc > w.config.HeapThreshold {
+		w.trigger(now, pprofWatchdogTriggerHeap, stats.HeapAlloc, w.config.HeapThreshold)
+	}
+
+	if w.config.GCPauseThreshold > 0 {
+		// PauseNs is a circular buffer of the recent pauses, of which only the
+		// ones since the last check are considered.
+		var maxPause uint64
+		gcs := stats.NumGC - w.lastNumGC
+		if gcs > uint32(len(stats.PauseNs)) {
+			gcs = uint32(len(stats.PauseNs))
+		}
+		for i := uint32(0); i < gcs; i++ {
+			pause := stats.PauseNs[(stats.NumGC-1-i)%uint32(len(stats.PauseNs))]
+			if pause > maxPause {
+				maxPause = pause
+			}
+		}
+		w.lastNumGC = stats.NumGC
+		if maxPause > uint64(w.config.GCPauseThreshold) {
+			w.trigger(now, pprofWatchdogTriggerGCPause, maxPause, uint64(w.config.GCPauseThreshold))
+		}
+	}
+}
+
+func (w *pprofWatchdog) trigger(now time.Time, trigger string, value, threshold uint64) {
+	w.dumpLock.Lock()
+	defer w.dumpLock.Unlock()
+
+	if last, ok := w.lastDumps[trigger]; ok && now.Sub(last) < w.config.Cooldown {
+		return
+	}
+	w.lastDumps[trigger] = now
+
+	dump, err := w.dumpLocked(now, trigger, value, threshold)
+	if err != nil {
+		w.logger.Error("failed to capture pprof dump", "trigger", trigger, "error", err)
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"core", "pprof_watchdog", "dump"}, 1, []metrics.Label{{"trigger", trigger}})
+	w.logger.Warn("captured pprof dump", "id", dump.ID, "trigger", trigger, "value", value, "threshold", threshold)
+
+	if err := w.pruneLocked(now); err != nil {
+		w.logger.Error("failed to prune pprof dumps", "error", err)
+	}
+}
+
+// dumpLocked writes the profiles and the metadata of a dump to its own
+// directory, which is renamed into place once complete.
+func (w *pprofWatchdog) dumpLocked(now time.Time, trigger string, value, threshold uint64) (*pprofDump, error) {
+	dump := &pprofDump{
+		ID:         fmt.Sprintf("%s%03dZ-%s", now.UTC().Format("20060102T150405"), now.Nanosecond()/int(time.Millisecond), trigger),
+		Trigger:    trigger,
+		Value:      value,
+		Threshold:  threshold,
+		CapturedAt: now.UTC(),
+	}
+
+	tmpDir, err := os.MkdirTemp(w.config.Directory, ".dump-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range pprofWatchdogProfiles {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(tmpDir, name+".pprof"), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		err = profile.WriteTo(f, 0)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s profile: %w", name, err)
+		}
+		dump.Profiles = append(dump.Profiles, name)
+	}
+
+	metadata, err := json.Marshal(dump)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, pprofWatchdogMetadataFile), metadata, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpDir, filepath.Join(w.config.Directory, dump.ID)); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// pruneLocked removes the dumps past the retention limits.
+func (w *pprofWatchdog) pruneLocked(now time.Time) error {
+	dumps, err := w.listLocked()
+	if err != nil {
+		return err
+	}
+	for i, dump := range dumps {
+		if i < w.config.MaxDumps && (w.config.MaxAge <= 0 || now.Sub(dump.CapturedAt) <= w.config.MaxAge) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(w.config.Directory, dump.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns the stored dumps, most recent first.
+func (w *pprofWatchdog) list() ([]*pprofDump, error) {
+	w.dumpLock.Lock()
+	defer w.dumpLock.Unlock()
+	return w.listLocked()
+}
+
+func (w *pprofWatchdog) listLocked() ([]*pprofDump, error) {
+	entries, err := os.ReadDir(w.config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []*pprofDump
+	for _, entry := range entries {
+		if !entry.IsDir() || !pprofWatchdogDumpIDRe.MatchString(entry.Name()) {
+			continue
+		}
+		dump, err := w.readMetadata(entry.Name())
+		if err != nil {
+			w.logger.Warn("skipping unreadable pprof dump", "id", entry.Name(), "error", err)
+			continue
+		}
+		dumps = append(dumps, dump)
+	}
+	sort.Slice(dumps, func(i, j int) bool {
+		return dumps[i].CapturedAt.After(dumps[j].CapturedAt)
+	})
+	return dumps, nil
+}
+
+// dump returns the metadata of the dump.
+func (w *pprofWatchdog) dump(id string) (*pprofDump, error) {
+	w.dumpLock.Lock()
+	defer w.dumpLock.Unlock()
+	return w.readMetadata(id)
+}
+
+func (w *pprofWatchdog) readMetadata(id string) (*pprofDump, error) {
+	raw, err := os.ReadFile(filepath.Join(w.config.Directory, id, pprofWatchdogMetadataFile))
+	if err != nil {
+		return nil, err
+	}
+	var dump pprofDump
+	if err := json.Unmarshal(raw, &dump); err != nil {
+		return nil, err
+	}
+	dump.ID = id
+	return &dump, nil
+}
+
+// readProfile returns the profile of the dump.
+func (w *pprofWatchdog) readProfile(id, profile string) ([]byte, error) {
+	if !pprofWatchdogDumpIDRe.MatchString(id) {
+		return nil, errPprofDumpNotFound
+	}
+
+	w.dumpLock.Lock()
+	defer w.dumpLock.Unlock()
+
+	dump, err := w.readMetadata(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errPprofDumpNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range dump.Profiles {
+		if name == profile {
+			return os.ReadFile(filepath.Join(w.config.Directory, id, name+".pprof"))
+		}
+	}
+	return nil, errPprofDumpNotFound
+}
+
+// delete removes the dump.
+func (w *pprofWatchdog) delete(id string) error {
+	if !pprofWatchdogDumpIDRe.MatchString(id) {
+		return nil
+	}
+
+	w.dumpLock.Lock()
+	defer w.dumpLock.Unlock()
+	return os.RemoveAll(filepath.Join(w.config.Directory, id))
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPprofWatchdog verifies that dumps are captured when a threshold is
+// exceeded, at most once per cooldown, and pruned past the retention limit.
+func TestPprofWatchdog(t *testing.T) {
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		PprofWatchdog: &PprofWatchdogConfig{
+			Directory:          t.TempDir(),
+			GoroutineThreshold: 1,
+			CheckInterval:      time.Hour,
+			Cooldown:           time.Minute,
+			MaxDumps:           2,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+	w := c.pprofWatchdog
+
+	now := time.Now()
+	w.check(now)
+	w.check(now.Add(time.Second))
+
+	req := logical.TestRequest(t, logical.ListOperation, "pprof/auto")
+	resp, err := c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	keys := resp.Data["keys"].([]string)
+	require.Len(t, keys, 1, "expected the cooldown to prevent a second dump")
+
+	info := resp.Data["key_info"].(map[string]interface{})[keys[0]].(map[string]interface{})
+	require.Equal(t, pprofWatchdogTriggerGoroutines, info["trigger"])
+	require.Equal(t, uint64(1), info["threshold"])
+	require.Contains(t, info["profiles"], "goroutine")
+
+	req = logical.TestRequest(t, logical.ReadOperation, "pprof/auto/"+keys[0]+"/goroutine")
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+	require.NotEmpty(t, resp.Data[logical.HTTPRawBody])
+
+	req = logical.TestRequest(t, logical.ReadOperation, "pprof/auto/"+keys[0]+"/unknown")
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// Only the most recent dumps are retained
+	w.check(now.Add(2 * time.Minute))
+	w.check(now.Add(4 * time.Minute))
+	dumps, err := w.list()
+	require.NoError(t, err)
+	require.Len(t, dumps, 2)
+	require.True(t, dumps[0].CapturedAt.After(dumps[1].CapturedAt))
+	require.NotEqual(t, keys[0], dumps[1].ID)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "pprof/auto/"+dumps[0].ID)
+	_, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	dumps, err = w.list()
+	require.NoError(t, err)
+	require.Len(t, dumps, 1)
+}
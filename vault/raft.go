@@ -879,20 +879,9 @@ func (c *Core) InitiateRetryJoin(ctx context.Context) error {
 	return nil
 }
 
-// getRaftChallenge is a helper function used by the raft join process for adding a
-// node to a cluster: it contacts the given node and initiates the bootstrap
-// challenge, returning the result or an error.
-func (c *Core) getRaftChallenge(leaderInfo *raft.LeaderJoinInfo) (*raftInformation, error) {
-	if leaderInfo == nil {
-		return nil, errors.New("raft leader information is nil")
-	}
-	if len(leaderInfo.LeaderAPIAddr) == 0 {
-		return nil, errors.New("raft leader address not provided")
-	}
-
-	c.logger.Info("attempting to join possible raft leader node", "leader_addr", leaderInfo.LeaderAPIAddr)
-
-	// Create an API client to interact with the leader node
+// raftLeaderAPIClient returns an API client to interact with the raft leader
+// node.
+func raftLeaderAPIClient(leaderInfo *raft.LeaderJoinInfo) (*api.Client, error) {
 	transport := cleanhttp.DefaultPooledTransport()
 
 	var err error
@@ -937,6 +926,27 @@ func (c *Core) getRaftChallenge(leaderInfo *raft.LeaderJoinInfo) (*raftInformati
 	// Clearing namespace, as this client should only ever be using the root namespace
 	apiClient.ClearNamespace()
 
+	return apiClient, nil
+}
+
+// getRaftChallenge is a helper function used by the raft join process for adding a
+// node to a cluster: it contacts the given node and initiates the bootstrap
+// challenge, returning the result or an error.
+func (c *Core) getRaftChallenge(leaderInfo *raft.LeaderJoinInfo) (*raftInformation, error) {
+	if leaderInfo == nil {
+		return nil, errors.New("raft leader information is nil")
+	}
+	if len(leaderInfo.LeaderAPIAddr) == 0 {
+		return nil, errors.New("raft leader address not provided")
+	}
+
+	c.logger.Info("attempting to join possible raft leader node", "leader_addr", leaderInfo.LeaderAPIAddr)
+
+	apiClient, err := raftLeaderAPIClient(leaderInfo)
+	if err != nil {
+		return nil, err
+	}
+
 	// Attempt to join the leader by requesting for the bootstrap challenge
 	secret, err := apiClient.Logical().Write("sys/storage/raft/bootstrap/challenge", map[string]interface{}{
 		"server_id": c.getRaftBackend().NodeID(),
@@ -1451,6 +1461,7 @@ func newDiscover() (*discover.Discover, error) {
 	}
 
 	providers["k8s"] = &discoverk8s.Provider{}
+	providers["dns"] = &dnsDiscoverProvider{}
 
 	return discover.New(
 		discover.WithProviders(providers),
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-discover"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/vault/seal"
+)
+
+// raftAutoInitInterval is the interval at which the peers are discovered
+// again until the cluster is initialized.
+const raftAutoInitInterval = 2 * time.Second
+
+// RaftAutoInit initializes the cluster when this node is the first of the raft
+// peers discovered from its retry_join configuration, by API address, and none
+// of them is initialized yet, for ephemeral clusters to form without an
+// operator. The other nodes join the cluster with retry join once it is
+// initialized. It returns nil if another node initialized the cluster.
+//
+// The seal must support stored keys, so that the nodes are unsealed without
+// any key having to be handed to them.
+func (c *Core) RaftAutoInit(ctx context.Context, apiAddr string) (*InitResult, error) {
+	raftBackend := c.getRaftBackend()
+	if raftBackend == nil {
+		return nil, errors.New("raft backend not in use")
+	}
+	if c.seal.StoredKeysSupported() != seal.StoredKeysSupportedGeneric {
+		return nil, errors.New("auto-init requires a seal supporting stored keys")
+	}
+	leaderInfos, err := raftBackend.JoinConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(leaderInfos) == 0 {
+		return nil, errors.New("auto-init requires a retry_join configuration to discover the peers")
+	}
+	disco, err := newDiscover()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auto-join discovery: %w", err)
+	}
+
+	for {
+		init, err := c.Initialized(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if core is initialized: %w", err)
+		}
+		if init {
+			return nil, nil
+		}
+
+		first, err := c.raftAutoInitFirst(leaderInfos, disco, apiAddr)
+		switch {
+		case err != nil:
+			c.logger.Warn("failed to discover the raft peers for auto-init", "retry", raftAutoInitInterval, "error", err)
+		case first:
+			c.logger.Info("initializing the cluster as the first discovered raft peer")
+			result, err := c.Initialize(ctx, &InitParams{
+				BarrierConfig: &SealConfig{
+					SecretShares:    1,
+					SecretThreshold: 1,
+					StoredShares:    1,
+				},
+				RecoveryConfig: &SealConfig{
+					SecretShares:    1,
+					SecretThreshold: 1,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := c.UnsealWithStoredKeys(ctx); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(raftAutoInitInterval):
+		}
+	}
+}
+
+// raftAutoInitFirst returns whether the node is to initialize the cluster:
+// whether none of the discovered peers is initialized, and the node has the
+// lowest API address of them.
+func (c *Core) raftAutoInitFirst(leaderInfos []*raft.LeaderJoinInfo, disco *discover.Discover, apiAddr string) (bool, error) {
+	addrs := []string{apiAddr}
+	for _, leaderInfo := range leaderInfos {
+		joinInfos, err := c.raftLeaderInfo(leaderInfo, disco)
+		if err != nil {
+			return false, err
+		}
+		for _, joinInfo := range joinInfos {
+			if joinInfo.LeaderAPIAddr == apiAddr {
+				continue
+			}
+			addrs = append(addrs, joinInfo.LeaderAPIAddr)
+
+			// Unreachable peers may still be starting, so they are only
+			// ruled out as the initializing node by their address
+			client, err := raftLeaderAPIClient(joinInfo)
+			if err != nil {
+				return false, err
+			}
+			init, err := client.Sys().InitStatus()
+			if err != nil {
+				c.logger.Debug("failed to get the initialization status of raft peer", "leader_addr", joinInfo.LeaderAPIAddr, "error", err)
+				continue
+			}
+			if init {
+				return false, nil
+			}
+		}
+	}
+	sort.Strings(addrs)
+	return addrs[0] == apiAddr, nil
+}
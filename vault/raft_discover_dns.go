@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+)
+
+// dnsDiscoverProvider is an auto-join provider discovering the raft peers from
+// the addresses a DNS name resolves to, such as the name of a headless
+// service, for environments without a cloud provider to discover them from.
+type dnsDiscoverProvider struct{}
+
+func (p *dnsDiscoverProvider) Help() string {
+	return `DNS:
+
+    provider: "dns"
+    name:     The DNS name resolving to the addresses of the nodes
+`
+}
+
+func (p *dnsDiscoverProvider) Addrs(args map[string]string, l *log.Logger) ([]string, error) {
+	if args["provider"] != "dns" {
+		return nil, fmt.Errorf("discover-dns: invalid provider %s", args["provider"])
+	}
+	name := args["name"]
+	if name == "" {
+		return nil, errors.New("discover-dns: name is required")
+	}
+
+	addrs, err := net.LookupHost(name)
+	if err != nil {
+		return nil, fmt.Errorf("discover-dns: %w", err)
+	}
+	l.Printf("[DEBUG] discover-dns: %s resolves to %v", name, addrs)
+	sort.Strings(addrs)
+	return addrs, nil
+}
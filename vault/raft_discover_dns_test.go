@@ -0,0 +1,27 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDNSDiscoverProvider verifies that raft peers are discovered from the
+// addresses DNS names resolve to.
+func TestDNSDiscoverProvider(t *testing.T) {
+	disco, err := newDiscover()
+	require.NoError(t, err)
+	l := log.New(os.Stderr, "", 0)
+
+	addrs, err := disco.Addrs("provider=dns name=localhost", l)
+	require.NoError(t, err)
+	require.Contains(t, addrs, "127.0.0.1")
+
+	_, err = disco.Addrs("provider=dns", l)
+	require.Error(t, err)
+}
@@ -222,6 +222,11 @@ func (c *Core) startForwarding(ctx context.Context) error {
 
 	clusterListener.AddHandler(consts.RequestForwardingALPN, handler)
 
+	// Tasks are offloaded to standbys with the cluster certificate
+	clusterListener.AddClient(consts.OffloadWorkALPN, &requestForwardingClusterClient{
+		core: c,
+	})
+
 	return nil
 }
 
@@ -230,6 +235,7 @@ func (c *Core) stopForwarding() {
 	if clusterListener != nil {
 		clusterListener.StopHandler(consts.RequestForwardingALPN)
 		clusterListener.StopHandler(consts.PerfStandbyALPN)
+		clusterListener.RemoveClient(consts.OffloadWorkALPN)
 	}
 	c.removeAllPerfStandbySecondaries()
 }
@@ -274,6 +280,9 @@ func (c *Core) refreshRequestForwardingConnection(ctx context.Context, clusterAd
 		core: c,
 	})
 
+	// Serve the tasks the active node offloads to standbys
+	clusterListener.AddHandler(consts.OffloadWorkALPN, newOffloadHandler(c, clusterListener.Server()))
+
 	// Set up grpc forwarding handling
 	// It's not really insecure, but we have to dial manually to get the
 	// ALPN header right. It's just "insecure" because GRPC isn't managing
@@ -330,6 +339,7 @@ func (c *Core) clearForwardingClients() {
 	clusterListener := c.getClusterListener()
 	if clusterListener != nil {
 		clusterListener.RemoveClient(consts.RequestForwardingALPN)
+		clusterListener.StopHandler(consts.OffloadWorkALPN)
 	}
 	c.clusterLeaderParams.Store((*ClusterLeaderParams)(nil))
 }
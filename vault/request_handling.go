@@ -515,6 +515,9 @@ func (c *Core) CheckToken(ctx context.Context, req *logical.Request, unauth bool
 	if authResults.ACLResults != nil && len(authResults.ACLResults.GrantingPolicies) > 0 {
 		auth.PolicyResults.GrantingPolicies = authResults.ACLResults.GrantingPolicies
 	}
+	if !unauth {
+		c.recordCapabilityUsage(te, req, authResults.ACLResults, rootPath)
+	}
 	if authResults.SentinelResults != nil && len(authResults.SentinelResults.GrantingPolicies) > 0 {
 		auth.PolicyResults.GrantingPolicies = append(auth.PolicyResults.GrantingPolicies, authResults.SentinelResults.GrantingPolicies...)
 	}
@@ -534,6 +537,20 @@ func (c *Core) CheckToken(ctx context.Context, req *logical.Request, unauth bool
 
 // HandleRequest is used to handle a new incoming request
 func (c *Core) HandleRequest(httpCtx context.Context, req *logical.Request) (resp *logical.Response, err error) {
+	// Queue for a fair share of request handling capacity before taking the
+	// state lock, so that queued requests don't hold up seal or step-down.
+	if scheduler := c.fairShareScheduler.Load(); scheduler != nil {
+		ns, err := namespace.FromContext(httpCtx)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse namespace from http context: %w", err)
+		}
+		release, err := scheduler.Acquire(httpCtx, ns.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
 	return c.switchedLockHandleRequest(httpCtx, req, true)
 }
 
@@ -554,6 +571,15 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	}
 
 	ctx, cancel := context.WithCancel(c.activeContext)
+	if deadline, ok := logical.ContextRequestDeadlineValue(httpCtx); ok {
+		// The request context derives from the active context rather than
+		// the HTTP one, so carry the client's deadline over explicitly.
+		// Backends, plugins (via gRPC) and storage then observe it.
+		var deadlineCancel context.CancelFunc
+		ctx, deadlineCancel = context.WithDeadline(ctx, deadline)
+		ctx = logical.CreateContextRequestDeadline(ctx, deadline)
+		defer deadlineCancel()
+	}
 	go func(ctx context.Context, httpCtx context.Context) {
 		select {
 		case <-ctx.Done():
@@ -572,6 +598,7 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	inFlightReqID, ok := httpCtx.Value(logical.CtxKeyInFlightRequestID{}).(string)
 	if ok {
 		ctx = context.WithValue(ctx, logical.CtxKeyInFlightRequestID{}, inFlightReqID)
+		ctx = c.contextWithInFlightPhase(ctx, inFlightReqID)
 	}
 	requestRole, ok := httpCtx.Value(logical.CtxKeyRequestRole{}).(string)
 	if ok {
@@ -589,6 +616,9 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 		ctx = logical.CreateContextRedactionSettings(ctx, redactVersion, redactAddresses, redactClusterName)
 	}
 	resp, err = c.handleCancelableRequest(ctx, req)
+	if err != nil && logical.RequestDeadlineExceeded(ctx) {
+		err = logical.ErrRequestDeadlineExceeded
+	}
 	req.SetTokenEntry(nil)
 	cancel()
 	return resp, err
@@ -612,6 +642,8 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 		return nil, err
 	}
 
+	c.applyPathRewrite(ctx, req)
+
 	// MountPoint will not always be set at this point, so we ensure the req contains it
 	// as it is depended on by some functionality (e.g. quotas)
 	req.MountPoint = c.router.MatchingMount(ctx, req.Path)
@@ -774,13 +806,15 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 		return nil, logical.CodedError(403, "namespaces feature not enabled")
 	}
 
+	c.checkDeception(ctx, req)
+
 	walState := &logical.WALState{}
 	ctx = logical.IndexStateContext(ctx, walState)
 	var auth *logical.Auth
 	if c.isLoginRequest(ctx, req) && req.ClientTokenSource != logical.ClientTokenFromInternalAuth {
-		resp, auth, err = c.handleLoginRequest(ctx, req)
+		resp, auth, err = c.handleIdempotentRequest(ctx, req, true, c.handleLoginRequest)
 	} else {
-		resp, auth, err = c.handleRequest(ctx, req)
+		resp, auth, err = c.handleIdempotentRequest(ctx, req, false, c.handleRequest)
 	}
 
 	if err == nil && c.requestResponseCallback != nil {
@@ -1008,7 +1042,7 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 	// Updating in-flight request data with client/entity ID
 	inFlightReqID, ok := ctx.Value(logical.CtxKeyInFlightRequestID{}).(string)
 	if ok && req.ClientID != "" {
-		c.UpdateInFlightReqData(inFlightReqID, req.ClientID)
+		c.UpdateInFlightReqData(inFlightReqID, req.ClientID, req.EntityID, ns.Path)
 	}
 
 	// We run this logic first because we want to decrement the use count even
@@ -1101,6 +1135,10 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
+	if te != nil {
+		c.checkTokenAnomalies(ctx, req, te)
+	}
+
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
@@ -1154,6 +1192,11 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// Route the request
 	resp, routeErr := c.doRouting(ctx, req)
+	c.recordDeprecationUsage(ctx, req, entry)
+	c.meterRequest(ctx, req, false)
+	if auth != nil {
+		c.addMountResponseHeaders(ctx, entry, auth.EntityID, resp)
+	}
 	if resp != nil {
 		// Add mount type information to the response
 		if entry != nil {
@@ -1433,7 +1476,11 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 	// Updating in-flight request data with client/entity ID
 	inFlightReqID, ok := ctx.Value(logical.CtxKeyInFlightRequestID{}).(string)
 	if ok && req.ClientID != "" {
-		c.UpdateInFlightReqData(inFlightReqID, req.ClientID)
+		var nsPath string
+		if ns, err := namespace.FromContext(ctx); err == nil {
+			nsPath = ns.Path
+		}
+		c.UpdateInFlightReqData(inFlightReqID, req.ClientID, req.EntityID, nsPath)
 	}
 
 	if ctErr != nil {
@@ -1467,6 +1514,10 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
+	// Enrich the login attempt before it is audited, so that the audit entry
+	// records the decision
+	c.enrichLogin(ctx, req, entry)
+
 	switch req.Path {
 	case "sys/replication/dr/status", "sys/replication/performance/status", "sys/replication/status":
 	default:
@@ -1490,6 +1541,10 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		return nil, nil, ErrInternalError
 	}
 
+	if req.LoginEnrichment != nil && req.LoginEnrichment.Blocked {
+		return logical.ErrorResponse("login blocked: %s", req.LoginEnrichment.Reason), nil, logical.ErrPermissionDenied
+	}
+
 	// check if user lockout feature is disabled
 	isUserLockoutDisabled, err := c.isUserLockoutDisabled(entry)
 	if err != nil {
@@ -1509,6 +1564,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 
 	// Route the request
 	resp, routeErr := c.doRouting(ctx, req)
+	c.recordDeprecationUsage(ctx, req, entry)
+	c.meterRequest(ctx, req, true)
 
 	handleInvalidCreds := func(err error) (*logical.Response, *logical.Auth, error) {
 		if !isUserLockoutDisabled {
@@ -1731,6 +1788,26 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			return nil, nil, logical.ErrPermissionDenied
 		}
 
+		// Interactive logins must acknowledge the login messages which
+		// require it before a token is issued, including through MFA
+		if entity != nil {
+			if err := c.enforceMessageAcknowledgments(ctx, req, entity.ID); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Include the identity details the auth mount selects for the role of
+		// the login in the response, before it may be cached for MFA validation
+		if mEntry != nil {
+			if reqRole == nil && len(mEntry.Config.LoginResponseRoles) > 0 {
+				role = c.loginRole(ctx, req, resp.Auth)
+			}
+			if err := c.shapeLoginResponse(mEntry, role, ns, entity, resp.Auth); err != nil {
+				c.logger.Error("failed to shape login response", "error", err)
+				return nil, nil, ErrInternalError
+			}
+		}
+
 		// The resp.Auth has been populated with the information that is required for MFA validation
 		// This is why, the MFA check is placed at this point. The resp.Auth is going to be fully cached
 		// in memory so that it would be used to return to the user upon MFA validation is completed.
@@ -1806,7 +1883,7 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		// until after they're created. This effectively zeroes out the lease count
 		// for new role-based quotas upon creation, rather than counting old leases toward
 		// the total.
-		if reqRole == nil && requiresLease && !c.impreciseLeaseRoleTracking {
+		if reqRole == nil && role == "" && requiresLease && !c.impreciseLeaseRoleTracking {
 			role = c.DetermineRoleFromLoginRequest(ctx, req.MountPoint, req.Data)
 		}
 
@@ -1856,6 +1933,11 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		return nil, nil, multierror.Append(retErr, err)
 	}
 
+	// The entity of the login is only known once the login succeeded
+	if auth != nil {
+		c.addMountResponseHeaders(ctx, entry, auth.EntityID, resp)
+	}
+
 	return resp, auth, routeErr
 }
 
@@ -2047,6 +2129,8 @@ func (c *Core) LoginCreateToken(ctx context.Context, ns *namespace.Namespace, re
 		}
 	case err == ErrInternalError:
 		return false, nil, err
+	case errors.Is(err, logical.ErrPermissionDenied):
+		return false, logical.ErrorResponse(err.Error()), logical.ErrPermissionDenied
 	default:
 		return false, logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
@@ -2373,11 +2457,33 @@ func (c *Core) RegisterAuth(ctx context.Context, tokenTTL time.Duration, path st
 		Type:           auth.TokenType,
 	}
 
+	// The auth mount may restrict the child tokens the token can create
+	if depth := c.mountMaxChildTokenDepth(ctx, path); depth != nil {
+		limitChildTokenDepth(&te, *depth)
+	}
+
 	if te.TTL == 0 && (len(te.Policies) != 1 || te.Policies[0] != "root") {
 		c.logger.Error("refusing to create a non-root zero TTL token")
 		return ErrInternalError
 	}
 
+	// The auth mount may limit the number of tokens of the entity
+	var limitingMount *MountEntry
+	if auth.TokenType == logical.TokenTypeService && te.EntityID != "" {
+		if mountEntry := c.router.MatchingMountEntry(ctx, path); mountEntry != nil && mountEntry.Config.MaxEntityTokens > 0 {
+			unlock, err := c.tokenStore.reserveEntityToken(ctx, mountEntry, te.EntityID)
+			if err != nil {
+				if errors.Is(err, logical.ErrPermissionDenied) {
+					return err
+				}
+				c.logger.Error("failed to enforce the token limit of the entity", "entity_id", te.EntityID, "error", err)
+				return ErrInternalError
+			}
+			defer unlock()
+			limitingMount = mountEntry
+		}
+	}
+
 	if err := c.tokenStore.create(ctx, &te); err != nil {
 		c.logger.Error("failed to create token", "error", err)
 		return ErrInternalError
@@ -2394,6 +2500,16 @@ func (c *Core) RegisterAuth(ctx context.Context, tokenTTL time.Duration, path st
 		// Ensure it's not marked renewable since it isn't
 		auth.Renewable = false
 	case logical.TokenTypeService:
+		if limitingMount != nil {
+			if err := c.tokenStore.indexEntityToken(ctx, limitingMount, &te); err != nil {
+				if err := c.tokenStore.revokeOrphan(ctx, te.ID); err != nil {
+					c.logger.Warn("failed to clean up token during login request", "request_path", path, "error", err)
+				}
+				c.logger.Error("failed to index token of entity during login request", "request_path", path, "error", err)
+				return ErrInternalError
+			}
+		}
+
 		// Register with the expiration manager
 		if err := c.expiration.RegisterAuth(ctx, &te, auth, role); err != nil {
 			if err := c.tokenStore.revokeOrphan(ctx, te.ID); err != nil {
@@ -4,6 +4,7 @@
 package vault
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -433,6 +434,238 @@ func TestRequestHandling_LoginMetric(t *testing.T) {
 	)
 }
 
+func TestRequestHandling_Login_EntityTokenLimit(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	handle := func(path string, clientToken string, data map[string]interface{}) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: clientToken,
+			Operation:   logical.UpdateOperation,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+	}
+	for path, data := range map[string]map[string]interface{}{
+		"sys/auth/userpass":        {"type": "userpass"},
+		"auth/userpass/users/test": {"password": "foo", "policies": "default"},
+		"sys/auth/userpass/tune":   {"max_entity_tokens": 2},
+	} {
+		if resp, err := handle(path, root, data); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v resp: %#v", path, err, resp)
+		}
+	}
+	login := func() (*logical.Response, error) {
+		return handle("auth/userpass/login/test", "", map[string]interface{}{"password": "foo"})
+	}
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		resp, err := login()
+		if err != nil || resp == nil || resp.Auth == nil {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		tokens = append(tokens, resp.Auth.ClientToken)
+	}
+
+	// The entity is at its limit, so the next login is denied
+	resp, err := login()
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got err: %v resp: %#v", err, resp)
+	}
+
+	// Revoked tokens no longer count toward the limit
+	if resp, err := handle("auth/token/revoke-self", tokens[1], nil); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	resp, err = login()
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	// Evicting the oldest token lets the next login through
+	if resp, err := handle("sys/auth/userpass/tune", root, map[string]interface{}{"entity_token_limit_action": "evict_oldest"}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	resp, err = login()
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	te, err := core.tokenStore.Lookup(namespace.RootContext(nil), tokens[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te != nil {
+		t.Fatal("expected the oldest token to be evicted")
+	}
+}
+
+func TestRequestHandling_Login_ResponseShaping(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	handle := func(path string, clientToken string, data map[string]interface{}) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: clientToken,
+			Operation:   logical.UpdateOperation,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+	}
+	mustHandle := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(path, root, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	login := func() *logical.Auth {
+		t.Helper()
+		resp, err := handle("auth/userpass/login/test", "", map[string]interface{}{"password": "foo"})
+		if err != nil || resp == nil || resp.Auth == nil {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp.Auth
+	}
+
+	mustHandle("sys/auth/userpass", map[string]interface{}{"type": "userpass"})
+	mustHandle("auth/userpass/users/test", map[string]interface{}{"password": "foo", "policies": "default"})
+
+	// Logins are not shaped by default
+	auth := login()
+	if auth.EntityMetadata != nil || auth.GroupNames != nil || auth.NamespaceID != "" {
+		t.Fatalf("expected no identity details, got %#v", auth)
+	}
+
+	mustHandle("identity/entity/id/"+auth.EntityID, map[string]interface{}{
+		"metadata": map[string]string{"team": "payments", "email": "test@example.com"},
+	})
+	mustHandle("identity/group", map[string]interface{}{
+		"name":              "engineering",
+		"member_entity_ids": []string{auth.EntityID},
+	})
+	mustHandle("sys/auth/userpass/tune", map[string]interface{}{
+		"login_response_entity_metadata": "team",
+		"login_response_group_names":     true,
+		"login_response_namespace":       true,
+	})
+
+	auth = login()
+	if !reflect.DeepEqual(auth.EntityMetadata, map[string]string{"team": "payments"}) {
+		t.Fatalf("bad entity metadata: %#v", auth.EntityMetadata)
+	}
+	if !reflect.DeepEqual(auth.GroupNames, []string{"engineering"}) {
+		t.Fatalf("bad group names: %#v", auth.GroupNames)
+	}
+	if auth.NamespaceID != namespace.RootNamespaceID {
+		t.Fatalf("bad namespace: %#v", auth)
+	}
+
+	resp, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:        "sys/auth/userpass/tune",
+		ClientToken: root,
+		Operation:   logical.ReadOperation,
+	})
+	if err != nil || resp == nil || resp.Data["login_response_group_names"] != true {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	// All of the entity metadata can be selected
+	mustHandle("sys/auth/userpass/tune", map[string]interface{}{"login_response_entity_metadata": "*"})
+	if auth = login(); len(auth.EntityMetadata) != 2 {
+		t.Fatalf("expected all of the entity metadata, got %#v", auth.EntityMetadata)
+	}
+
+	// The token auth method cannot be shaped
+	if resp, err := handle("sys/auth/token/tune", root, map[string]interface{}{"login_response_namespace": true}); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v resp: %#v", err, resp)
+	}
+}
+
+// TestRequestHandling_Login_ResponseShapingPerRole verifies that two roles of
+// one auth mount get the login response shaping configured for each of them.
+func TestRequestHandling_Login_ResponseShapingPerRole(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["approle"] = approle.Factory
+
+	handle := func(op logical.Operation, path string, clientToken string, data map[string]interface{}) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: clientToken,
+			Operation:   op,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+	}
+	mustHandle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(op, path, root, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	mustHandle(logical.UpdateOperation, "sys/auth/approle", map[string]interface{}{"type": "approle"})
+	credentials := make(map[string]map[string]interface{})
+	for _, role := range []string{"web", "batch"} {
+		mustHandle(logical.UpdateOperation, "auth/approle/role/"+role, map[string]interface{}{"token_policies": "default"})
+		roleID := mustHandle(logical.ReadOperation, "auth/approle/role/"+role+"/role-id", nil).Data["role_id"]
+		secretID := mustHandle(logical.UpdateOperation, "auth/approle/role/"+role+"/secret-id", nil).Data["secret_id"]
+		credentials[role] = map[string]interface{}{"role_id": roleID, "secret_id": secretID}
+	}
+	login := func(role string) *logical.Auth {
+		t.Helper()
+		secretID := mustHandle(logical.UpdateOperation, "auth/approle/role/"+role+"/secret-id", nil).Data["secret_id"]
+		credentials[role]["secret_id"] = secretID
+		resp, err := handle(logical.UpdateOperation, "auth/approle/login", "", credentials[role])
+		if err != nil || resp == nil || resp.Auth == nil {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp.Auth
+	}
+
+	for _, role := range []string{"web", "batch"} {
+		mustHandle(logical.UpdateOperation, "identity/entity/id/"+login(role).EntityID, map[string]interface{}{
+			"metadata": map[string]string{"team": role},
+		})
+	}
+
+	// The mount selects the namespace, which the batch role overrides to
+	// select the entity metadata instead
+	mustHandle(logical.UpdateOperation, "sys/auth/approle/tune", map[string]interface{}{
+		"login_response_namespace": true,
+		"login_response_roles": map[string]interface{}{
+			"batch": map[string]interface{}{"entity_metadata": []string{"team"}},
+		},
+	})
+
+	auth := login("web")
+	if auth.NamespaceID != namespace.RootNamespaceID || auth.EntityMetadata != nil {
+		t.Fatalf("expected the shaping of the mount for the web role, got %#v", auth)
+	}
+	auth = login("batch")
+	if auth.NamespaceID != "" || !reflect.DeepEqual(auth.EntityMetadata, map[string]string{"team": "batch"}) {
+		t.Fatalf("expected the shaping of the batch role, got %#v", auth)
+	}
+
+	resp := mustHandle(logical.ReadOperation, "sys/auth/approle/tune", nil)
+	roles, ok := resp.Data["login_response_roles"].(map[string]*LoginResponseShaping)
+	if !ok || len(roles) != 1 || roles["batch"] == nil {
+		t.Fatalf("bad login response roles: %#v", resp.Data["login_response_roles"])
+	}
+
+	// Unknown shaping fields are rejected
+	if resp, err := handle(logical.UpdateOperation, "sys/auth/approle/tune", root, map[string]interface{}{
+		"login_response_roles": map[string]interface{}{"web": map[string]interface{}{"groups": true}},
+	}); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v resp: %#v", err, resp)
+	}
+}
+
 func TestRequestHandling_SecretLeaseMetric(t *testing.T) {
 	coreConfig := &CoreConfig{
 		LogicalBackends: map[string]logical.Factory{
@@ -0,0 +1,271 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/version"
+)
+
+const (
+	// requestJournalInterval is how often the in-flight requests are
+	// persisted to the journal, bounding how stale the journal is when the
+	// process is killed without notice, such as by the OOM killer.
+	requestJournalInterval = 5 * time.Second
+
+	requestJournalReasonRunning  = "running"
+	requestJournalReasonShutdown = "shutdown"
+	requestJournalReasonPanic    = "panic"
+
+	// requestJournalReasonUncleanExit is the reason of a journal which was
+	// last written while running, meaning the process died without shutting
+	// down or recording a panic.
+	requestJournalReasonUncleanExit = "unclean_exit"
+
+	// requestJournalLastSuffix is appended to the journal path to keep the
+	// journal of the previous run, so that it outlives the next restart.
+	requestJournalLastSuffix = ".last"
+)
+
+// requestJournal persists the in-flight requests to a local file, on
+// shutdown, on panic, and periodically while running, so that the requests
+// active when the process died can be retrieved after it restarts.
+type requestJournal struct {
+	path      string
+	logger    log.Logger
+	startedAt time.Time
+
+	// writeLock serializes writes of the journal. Once stopped, the
+	// periodic writes no longer replace the final journal. Once a panic is
+	// recorded, a clean shutdown no longer replaces or removes it either.
+	writeLock sync.Mutex
+	stopped   bool
+	panicked  bool
+
+	// lastCrash is the journal of the previous run, if any.
+	lastCrash *requestJournalSnapshot
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+type requestJournalSnapshot struct {
+	Reason    string                 `json:"reason"`
+	Panic     string                 `json:"panic,omitempty"`
+	WrittenAt time.Time              `json:"written_at"`
+	StartedAt time.Time              `json:"started_at"`
+	Version   string                 `json:"version"`
+	Requests  []*requestJournalEntry `json:"requests"`
+}
+
+type requestJournalEntry struct {
+	RequestID     string    `json:"request_id"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Namespace     string    `json:"namespace,omitempty"`
+	EntityID      string    `json:"entity_id,omitempty"`
+	ClientID      string    `json:"client_id,omitempty"`
+	ClientAddress string    `json:"client_address,omitempty"`
+	StartTime     time.Time `json:"start_time"`
+	ElapsedMs     int64     `json:"elapsed_ms"`
+	Phase         string    `json:"phase,omitempty"`
+}
+
+// newRequestJournal returns a journal at the path, after moving aside the
+// journal of the previous run and loading it as the last crash.
+func newRequestJournal(path string, logger log.Logger) (*requestJournal, error) {
+	j := &requestJournal{
+		path:      path,
+		logger:    logger,
+		startedAt: time.Now(),
+		stopCh:    make(chan struct{}),
+	}
+
+	lastPath := path + requestJournalLastSuffix
+	if err := os.Rename(path, lastPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to move aside the request journal: %w", err)
+	}
+
+	raw, err := os.ReadFile(lastPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return j, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read the last request journal: %w", err)
+	}
+
+	var snapshot requestJournalSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		// A journal truncated by the crash is not a reason to not start
+		logger.Warn("failed to decode the last request journal", "path", lastPath, "error", err)
+		return j, nil
+	}
+	if snapshot.Reason == requestJournalReasonRunning {
+		snapshot.Reason = requestJournalReasonUncleanExit
+	}
+	j.lastCrash = &snapshot
+	logger.Warn("found the request journal of a previous run that did not shut down cleanly",
+		"reason", snapshot.Reason, "written_at", snapshot.WrittenAt, "in_flight_requests", len(snapshot.Requests))
+	return j, nil
+}
+
+// write persists the snapshot, replacing the journal atomically so that a
+// crash while writing leaves the previous journal intact.
+func (j *requestJournal) write(snapshot *requestJournalSnapshot) error {
+	j.writeLock.Lock()
+	defer j.writeLock.Unlock()
+
+	if j.stopped && snapshot.Reason == requestJournalReasonRunning {
+		return nil
+	}
+	if j.panicked && snapshot.Reason == requestJournalReasonShutdown {
+		return nil
+	}
+
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+	if snapshot.Reason == requestJournalReasonPanic {
+		j.panicked = true
+	}
+	return nil
+}
+
+// remove deletes the journal, as there is nothing to report of a clean
+// shutdown without in-flight requests.
+func (j *requestJournal) remove() error {
+	j.writeLock.Lock()
+	defer j.writeLock.Unlock()
+
+	if j.panicked {
+		return nil
+	}
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (j *requestJournal) stop() {
+	j.stopOnce.Do(func() {
+		j.writeLock.Lock()
+		j.stopped = true
+		j.writeLock.Unlock()
+		close(j.stopCh)
+	})
+}
+
+// requestJournalSnapshot returns the in-flight requests, longest running
+// first.
+func (c *Core) requestJournalSnapshot(reason string, startedAt time.Time) *requestJournalSnapshot {
+	now := time.Now()
+	snapshot := &requestJournalSnapshot{
+		Reason:    reason,
+		WrittenAt: now.UTC(),
+		StartedAt: startedAt.UTC(),
+		Version:   version.GetVersion().VersionNumber(),
+		Requests:  []*requestJournalEntry{},
+	}
+	for reqID, data := range c.LoadInFlightReqData() {
+		snapshot.Requests = append(snapshot.Requests, &requestJournalEntry{
+			RequestID:     reqID,
+			Method:        data.Method,
+			Path:          data.ReqPath,
+			Namespace:     data.Namespace,
+			EntityID:      data.EntityID,
+			ClientID:      data.ClientID,
+			ClientAddress: data.ClientRemoteAddr,
+			StartTime:     data.StartTime.UTC(),
+			ElapsedMs:     now.Sub(data.StartTime).Milliseconds(),
+			Phase:         data.Phase,
+		})
+	}
+	sort.Slice(snapshot.Requests, func(i, k int) bool {
+		return snapshot.Requests[i].StartTime.Before(snapshot.Requests[k].StartTime)
+	})
+	return snapshot
+}
+
+// runRequestJournal persists the in-flight requests periodically, until the
+// journal is stopped.
+func (c *Core) runRequestJournal(j *requestJournal) {
+	ticker := time.NewTicker(requestJournalInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.write(c.requestJournalSnapshot(requestJournalReasonRunning, j.startedAt)); err != nil {
+			j.logger.Error("failed to write the request journal", "error", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-j.stopCh:
+			return
+		}
+	}
+}
+
+// persistRequestJournal stops the periodic journal and persists the
+// in-flight requests with the reason. A clean shutdown without in-flight
+// requests removes the journal instead.
+func (c *Core) persistRequestJournal(reason string, panicValue interface{}) {
+	j := c.requestJournal
+	if j == nil {
+		return
+	}
+	j.stop()
+
+	snapshot := c.requestJournalSnapshot(reason, j.startedAt)
+	if panicValue != nil {
+		snapshot.Panic = fmt.Sprint(panicValue)
+	}
+	if reason == requestJournalReasonShutdown && len(snapshot.Requests) == 0 {
+		if err := j.remove(); err != nil {
+			j.logger.Error("failed to remove the request journal", "error", err)
+		}
+		return
+	}
+	if err := j.write(snapshot); err != nil {
+		j.logger.Error("failed to write the request journal", "error", err)
+	}
+}
+
+// RecordPanicInRequestJournal persists the in-flight requests with the panic,
+// if the request journal is enabled. The caller is expected to re-panic. It is
+// called where panics happen, such as by the HTTP handler, as a panic can only
+// be recovered on its own goroutine. The journal of the panic is kept until the
+// next start, even if the process survives the panic and shuts down cleanly.
+func (c *Core) RecordPanicInRequestJournal(panicValue interface{}) {
+	c.persistRequestJournal(requestJournalReasonPanic, panicValue)
+}
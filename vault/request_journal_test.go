@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestJournal verifies that the in-flight requests persisted by a run
+// are reported by the next one, and that a clean shutdown reports nothing.
+func TestRequestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.journal")
+
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{RequestJournalPath: path})
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "diagnostics/last-crash")
+	resp, err := c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	c.StoreInFlightReqData("req-1", InFlightReqData{
+		StartTime: time.Now().Add(-time.Minute),
+		ReqPath:   "/v1/secret/data/foo",
+		Method:    "GET",
+	})
+	c.UpdateInFlightReqData("req-1", "client", "entity", "ns1/")
+	c.RecordPanicInRequestJournal("boom")
+
+	// The periodic journal no longer replaces the journal of the panic
+	c.persistRequestJournal(requestJournalReasonRunning, nil)
+
+	c2, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{RequestJournalPath: path})
+	req = logical.TestRequest(t, logical.ReadOperation, "diagnostics/last-crash")
+	resp, err = c2.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, requestJournalReasonPanic, resp.Data["reason"])
+	require.Equal(t, "boom", resp.Data["panic"])
+
+	requests := resp.Data["requests"].([]map[string]interface{})
+	require.Len(t, requests, 1)
+	require.Equal(t, "req-1", requests[0]["request_id"])
+	require.Equal(t, "/v1/secret/data/foo", requests[0]["path"])
+	require.Equal(t, "entity", requests[0]["entity_id"])
+	require.Equal(t, "ns1/", requests[0]["namespace"])
+	require.GreaterOrEqual(t, requests[0]["elapsed_ms"].(int64), time.Minute.Milliseconds())
+
+	// The running journal of the second core is reported as an unclean exit
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	j, err := newRequestJournal(path, c2.logger)
+	require.NoError(t, err)
+	require.NotNil(t, j.lastCrash)
+	require.Equal(t, requestJournalReasonUncleanExit, j.lastCrash.Reason)
+
+	// A clean shutdown without in-flight requests removes the journal
+	require.NoError(t, c2.Shutdown())
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,457 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	meteringSubPath = "metering/"
+
+	meteringConfigPath  = "config"
+	meteringUsagePrefix = "usage/"
+
+	// meteringFlushInterval is how often the costs gathered in memory are
+	// merged into storage.
+	meteringFlushInterval = time.Minute
+
+	// meteringLoginOperation is the key of the weight of login requests,
+	// which are not a logical operation of their own.
+	meteringLoginOperation = "login"
+
+	defaultMeteringBytesPerUnit = 4096
+	defaultMeteringUnitWeight   = 1
+	defaultMeteringWeight       = 1
+)
+
+// defaultMeteringOperationWeights are the costs of a request by operation,
+// before its payload is accounted for. Operations not listed cost
+// defaultMeteringWeight.
+var defaultMeteringOperationWeights = map[string]float64{
+	string(logical.ReadOperation):   1,
+	string(logical.ListOperation):   1,
+	string(logical.CreateOperation): 2,
+	string(logical.UpdateOperation): 2,
+	string(logical.PatchOperation):  2,
+	string(logical.DeleteOperation): 1,
+	meteringLoginOperation:          5,
+}
+
+// meteringConfig configures how the cost of a request is computed: the
+// weight of its operation, plus unit_weight for every bytes_per_unit bytes
+// of its payload, rounded up.
+type meteringConfig struct {
+	Enabled          bool               `json:"enabled"`
+	OperationWeights map[string]float64 `json:"operation_weights"`
+	BytesPerUnit     int                `json:"bytes_per_unit"`
+	UnitWeight       float64            `json:"unit_weight"`
+}
+
+func defaultMeteringConfig() *meteringConfig {
+	weights := make(map[string]float64, len(defaultMeteringOperationWeights))
+	for op, weight := range defaultMeteringOperationWeights {
+		weights[op] = weight
+	}
+	return &meteringConfig{
+		OperationWeights: weights,
+		BytesPerUnit:     defaultMeteringBytesPerUnit,
+		UnitWeight:       defaultMeteringUnitWeight,
+	}
+}
+
+// cost returns the cost of a request of the operation with a payload of the
+// given size.
+func (m *meteringConfig) cost(operation string, size int64) float64 {
+	weight, ok := m.OperationWeights[operation]
+	if !ok {
+		weight = defaultMeteringWeight
+	}
+	if size <= 0 || m.BytesPerUnit <= 0 {
+		return weight
+	}
+	units := math.Ceil(float64(size) / float64(m.BytesPerUnit))
+	return weight + units*m.UnitWeight
+}
+
+// meteringKey identifies the requests of an entity, or of clients without an
+// entity, in a namespace and month.
+type meteringKey struct {
+	month       int64
+	namespaceID string
+	entityID    string
+}
+
+// meteringNamespaceKey identifies the stored costs of a namespace in a month.
+type meteringNamespaceKey struct {
+	month       int64
+	namespaceID string
+}
+
+// meteringUsage is the number of requests and their total cost.
+type meteringUsage struct {
+	Requests uint64  `json:"requests"`
+	Cost     float64 `json:"cost"`
+}
+
+// requestMeter attributes a cost to each request handled by the active node,
+// by namespace and entity, for chargeback. Costs are aggregated in memory and
+// merged into storage in the background, per month and namespace, so the last
+// minute of usage is lost if the node seals.
+type requestMeter struct {
+	view   *BarrierView
+	logger log.Logger
+
+	configLock sync.RWMutex
+	config     *meteringConfig
+
+	lock    sync.Mutex
+	pending map[meteringKey]*meteringUsage
+
+	// storageLock serializes merges into storage with exports reading it
+	storageLock sync.Mutex
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+}
+
+func (c *Core) setupRequestMeter(ctx context.Context) error {
+	m := &requestMeter{
+		view:    c.systemBarrierView.SubView(meteringSubPath),
+		logger:  c.baseLogger.Named("metering"),
+		pending: make(map[meteringKey]*meteringUsage),
+		doneCh:  make(chan struct{}),
+	}
+	c.AddLogger(m.logger)
+
+	config, err := m.loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	m.config = config
+
+	var runCtx context.Context
+	runCtx, m.cancel = context.WithCancel(c.activeContext)
+	go m.run(runCtx)
+
+	c.requestMeter.Store(m)
+	return nil
+}
+
+func (c *Core) stopRequestMeter() {
+	m := c.requestMeter.Swap(nil)
+	if m == nil {
+		return
+	}
+	m.cancel()
+	<-m.doneCh
+}
+
+func (m *requestMeter) run(ctx context.Context) {
+	defer close(m.doneCh)
+
+	ticker := time.NewTicker(meteringFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.flush(ctx); err != nil {
+				m.logger.Error("failed to store request costs", "error", err)
+			}
+		case <-ctx.Done():
+			// Storage may still be written to while the node steps down
+			if err := m.flush(context.Background()); err != nil {
+				m.logger.Error("failed to store request costs", "error", err)
+			}
+			return
+		}
+	}
+}
+
+func (m *requestMeter) loadConfig(ctx context.Context) (*meteringConfig, error) {
+	config := defaultMeteringConfig()
+	entry, err := m.view.Get(ctx, meteringConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return config, nil
+	}
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (m *requestMeter) getConfig() *meteringConfig {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.config
+}
+
+func (m *requestMeter) setConfig(ctx context.Context, config *meteringConfig) error {
+	entry, err := logical.StorageEntryJSON(meteringConfigPath, config)
+	if err != nil {
+		return err
+	}
+	if err := m.view.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	m.config = config
+	return nil
+}
+
+// meterRequest attributes the cost of the request to its namespace and entity,
+// if metering is enabled.
+func (c *Core) meterRequest(ctx context.Context, req *logical.Request, login bool) {
+	m := c.requestMeter.Load()
+	if m == nil {
+		return
+	}
+	config := m.getConfig()
+	if !config.Enabled {
+		return
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	operation := string(req.Operation)
+	if login {
+		operation = meteringLoginOperation
+	}
+	var size int64
+	if req.HTTPRequest != nil && req.HTTPRequest.ContentLength > 0 {
+		size = req.HTTPRequest.ContentLength
+	}
+
+	key := meteringKey{
+		month:       timeutil.StartOfMonth(time.Now().UTC()).Unix(),
+		namespaceID: ns.ID,
+		entityID:    req.EntityID,
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	usage := m.pending[key]
+	if usage == nil {
+		usage = &meteringUsage{}
+		m.pending[key] = usage
+	}
+	usage.Requests++
+	usage.Cost += config.cost(operation, size)
+}
+
+func meteringUsagePath(month int64, namespaceID string) string {
+	return fmt.Sprintf("%s%d/%s", meteringUsagePrefix, month, namespaceID)
+}
+
+// flush merges the costs gathered in memory into storage.
+func (m *requestMeter) flush(ctx context.Context) error {
+	m.lock.Lock()
+	pending := m.pending
+	m.pending = make(map[meteringKey]*meteringUsage)
+	m.lock.Unlock()
+
+	byNamespace := make(map[meteringNamespaceKey]map[string]*meteringUsage)
+	for key, usage := range pending {
+		nsKey := meteringNamespaceKey{month: key.month, namespaceID: key.namespaceID}
+		if byNamespace[nsKey] == nil {
+			byNamespace[nsKey] = make(map[string]*meteringUsage)
+		}
+		byNamespace[nsKey][key.entityID] = usage
+	}
+
+	m.storageLock.Lock()
+	defer m.storageLock.Unlock()
+
+	for nsKey, entities := range byNamespace {
+		path := meteringUsagePath(nsKey.month, nsKey.namespaceID)
+		stored, err := m.load(ctx, path)
+		if err != nil {
+			return err
+		}
+		for entityID, usage := range entities {
+			s := stored[entityID]
+			if s == nil {
+				s = &meteringUsage{}
+				stored[entityID] = s
+			}
+			s.Requests += usage.Requests
+			s.Cost += usage.Cost
+		}
+
+		entry, err := logical.StorageEntryJSON(path, stored)
+		if err != nil {
+			return err
+		}
+		if err := m.view.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load reads the stored costs of a month and namespace, by entity ID.
+func (m *requestMeter) load(ctx context.Context, path string) (map[string]*meteringUsage, error) {
+	usage := make(map[string]*meteringUsage)
+	entry, err := m.view.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return usage, nil
+	}
+	if err := entry.DecodeJSON(&usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// chargebackRecord is a line of a chargeback export: the requests and cost of
+// an entity in a namespace during a month, next to the client count of the
+// namespace that month.
+type chargebackRecord struct {
+	Month            string  `json:"month"`
+	NamespaceID      string  `json:"namespace_id"`
+	NamespacePath    string  `json:"namespace_path"`
+	EntityID         string  `json:"entity_id"`
+	Requests         uint64  `json:"requests"`
+	Cost             float64 `json:"cost"`
+	NamespaceClients int     `json:"namespace_clients"`
+}
+
+func (r *chargebackRecord) csv() []string {
+	return []string{
+		r.Month,
+		r.NamespaceID,
+		r.NamespacePath,
+		r.EntityID,
+		strconv.FormatUint(r.Requests, 10),
+		strconv.FormatFloat(r.Cost, 'f', -1, 64),
+		strconv.Itoa(r.NamespaceClients),
+	}
+}
+
+var chargebackCSVHeader = []string{"month", "namespace_id", "namespace_path", "entity_id", "requests", "cost", "namespace_clients"}
+
+// chargebackRecords returns the costs of the months of the period in the
+// namespace and its children, ordered by month, namespace path and entity ID.
+// Client counts are taken from the activity log, if it is present.
+func (c *Core) chargebackRecords(ctx context.Context, ns *namespace.Namespace, startTime, endTime time.Time) ([]*chargebackRecord, error) {
+	m := c.requestMeter.Load()
+	if m == nil {
+		return nil, fmt.Errorf("metering is not running on this node")
+	}
+
+	// Merge pending costs first, so that the export is current
+	if err := m.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]map[string]int)
+	c.activityLogLock.RLock()
+	a := c.activityLog
+	c.activityLogLock.RUnlock()
+	if a != nil {
+		results, err := a.handleQuery(ctx, startTime, endTime, 0)
+		if err != nil {
+			return nil, err
+		}
+		months, _ := results["months"].([]*ResponseMonth)
+		for _, month := range months {
+			byNamespace := make(map[string]int)
+			for _, n := range month.Namespaces {
+				byNamespace[n.NamespaceID] = n.Counts.Clients
+			}
+			clients[month.Timestamp] = byNamespace
+		}
+	}
+
+	m.storageLock.Lock()
+	defer m.storageLock.Unlock()
+
+	monthKeys, err := m.view.List(ctx, meteringUsagePrefix)
+	if err != nil {
+		return nil, err
+	}
+	var records []*chargebackRecord
+	for _, monthKey := range monthKeys {
+		month, err := strconv.ParseInt(strings.TrimSuffix(monthKey, "/"), 10, 64)
+		if err != nil {
+			continue
+		}
+		monthTime := time.Unix(month, 0).UTC()
+		if monthTime.Before(timeutil.StartOfMonth(startTime.UTC())) || monthTime.After(endTime) {
+			continue
+		}
+		timestamp := monthTime.Format(time.RFC3339)
+
+		namespaceIDs, err := m.view.List(ctx, meteringUsagePrefix+monthKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespaceID := range namespaceIDs {
+			var namespacePath string
+			usageNS, err := NamespaceByID(ctx, namespaceID, c)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case usageNS != nil:
+				namespacePath = usageNS.Path
+			case ns.ID != namespace.RootNamespaceID:
+				// Deleted namespaces are only reported from the root
+				continue
+			}
+			if !strings.HasPrefix(namespacePath, ns.Path) {
+				continue
+			}
+
+			usage, err := m.load(ctx, meteringUsagePath(month, namespaceID))
+			if err != nil {
+				return nil, err
+			}
+			for entityID, u := range usage {
+				records = append(records, &chargebackRecord{
+					Month:            timestamp,
+					NamespaceID:      namespaceID,
+					NamespacePath:    namespacePath,
+					EntityID:         entityID,
+					Requests:         u.Requests,
+					Cost:             u.Cost,
+					NamespaceClients: clients[timestamp][namespaceID],
+				})
+			}
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		ri, rj := records[i], records[j]
+		if ri.Month != rj.Month {
+			return ri.Month < rj.Month
+		}
+		if ri.NamespacePath != rj.NamespacePath {
+			return ri.NamespacePath < rj.NamespacePath
+		}
+		return ri.EntityID < rj.EntityID
+	})
+	return records, nil
+}
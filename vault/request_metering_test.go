@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestMeteringConfig_Cost(t *testing.T) {
+	config := defaultMeteringConfig()
+	cases := []struct {
+		operation string
+		size      int64
+		expected  float64
+	}{
+		{string(logical.ReadOperation), 0, 1},
+		{string(logical.UpdateOperation), 1, 3},
+		{string(logical.UpdateOperation), 4096, 3},
+		{string(logical.UpdateOperation), 4097, 4},
+		{meteringLoginOperation, 0, 5},
+		{string(logical.RenewOperation), 0, 1},
+	}
+	for _, tc := range cases {
+		if cost := config.cost(tc.operation, tc.size); cost != tc.expected {
+			t.Fatalf("expected %s of %d bytes to cost %v, got %v", tc.operation, tc.size, tc.expected, cost)
+		}
+	}
+}
+
+// TestRequestMeter_Chargeback verifies that request costs are only accounted
+// once enabled, and exported per month, namespace and entity.
+func TestRequestMeter_Chargeback(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			ClientToken: root,
+			Operation:   op,
+			Path:        path,
+			Data:        data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+	}
+
+	request(logical.ReadOperation, "sys/mounts", nil)
+	request(logical.UpdateOperation, "sys/internal/counters/cost/config", map[string]interface{}{
+		"enabled": true,
+		"operation_weights": map[string]interface{}{
+			"read": 3,
+		},
+	})
+	request(logical.ReadOperation, "sys/mounts", nil)
+	request(logical.ListOperation, "sys/policies/acl", nil)
+
+	now := time.Now().UTC()
+	records, err := c.chargebackRecords(ctx, namespace.RootNamespace, timeutil.StartOfMonth(now), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected a single record, got %#v", records)
+	}
+	record := records[0]
+	if record.NamespaceID != namespace.RootNamespaceID || record.EntityID != "" {
+		t.Fatalf("unexpected record: %#v", record)
+	}
+	if record.Month != timeutil.StartOfMonth(now).Format(time.RFC3339) {
+		t.Fatalf("unexpected month: %s", record.Month)
+	}
+	// The read costs 3 and the list the default weight of 1
+	if record.Requests != 2 || record.Cost != 4 {
+		t.Fatalf("expected 2 requests costing 4, got %d costing %v", record.Requests, record.Cost)
+	}
+}
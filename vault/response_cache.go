@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// responseCacheSize is the number of responses kept across all the
+	// cached path templates.
+	responseCacheSize = 256
+
+	responseCacheActivity        = "sys/internal/counters/activity"
+	responseCacheActivityMonthly = "sys/internal/counters/activity/monthly"
+	responseCacheOpenAPI         = "sys/internal/specs/openapi"
+)
+
+// responseCacheTemplates are the path templates whose responses can be
+// cached, with whether the response depends on the access of the caller
+// beyond the ACL check of the path.
+var responseCacheTemplates = map[string]bool{
+	responseCacheActivity:        false,
+	responseCacheActivityMonthly: false,
+	responseCacheOpenAPI:         true,
+}
+
+// responseCache caches the responses of expensive computed reads, so that
+// dashboards polling them do not load the active node. Caching is opt-in per
+// path template, each with its own TTL. Responses are keyed by namespace and
+// request parameters, and are dropped when the TTL expires or when what they
+// are computed from changes.
+type responseCache struct {
+	ttls map[string]time.Duration
+	lru  *lru.TwoQueueCache
+
+	// generations are bumped by invalidations, so that responses computed
+	// before them are not served anymore.
+	l           sync.Mutex
+	generations map[string]uint64
+}
+
+type cachedResponse struct {
+	resp       *logical.Response
+	generation uint64
+	expires    time.Time
+}
+
+func newResponseCache(ttls map[string]time.Duration) (*responseCache, error) {
+	for template, ttl := range ttls {
+		if _, ok := responseCacheTemplates[template]; !ok {
+			return nil, fmt.Errorf("responses of %q cannot be cached", template)
+		}
+		if ttl <= 0 {
+			return nil, fmt.Errorf("invalid response cache TTL for %q", template)
+		}
+	}
+	cache, err := lru.New2Q(responseCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &responseCache{
+		ttls:        ttls,
+		lru:         cache,
+		generations: make(map[string]uint64),
+	}, nil
+}
+
+func (rc *responseCache) generation(template string) uint64 {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+	return rc.generations[template]
+}
+
+// invalidate drops the cached responses of the path templates.
+func (rc *responseCache) invalidate(templates ...string) {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+	for _, template := range templates {
+		rc.generations[template]++
+	}
+}
+
+// responseCacheKey returns the key of the request: its namespace and
+// parameters, and for templates whose response depends on the caller, its
+// entity and token policies.
+func responseCacheKey(ctx context.Context, template string, req *logical.Request, d *framework.FieldData) (string, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	params, err := json.Marshal(d.Raw)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", template, ns.ID, req.Operation, params)
+	if responseCacheTemplates[template] {
+		var policies []string
+		if te := req.TokenEntry(); te != nil {
+			policies = append(policies, te.Policies...)
+			sort.Strings(policies)
+		}
+		fmt.Fprintf(h, "%s\x00%s", req.EntityID, strings.Join(policies, ","))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedRead wraps the callback of an expensive read, serving its responses
+// from the response cache when caching is enabled for the path template.
+func (c *Core) cachedRead(template string, callback framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		rc := c.responseCache
+		if rc == nil || rc.ttls[template] == 0 {
+			return callback(ctx, req, d)
+		}
+		key, err := responseCacheKey(ctx, template, req, d)
+		if err != nil {
+			return callback(ctx, req, d)
+		}
+
+		labels := []metrics.Label{{Name: "path", Value: template}}
+		generation := rc.generation(template)
+		if raw, ok := rc.lru.Get(key); ok {
+			cached := raw.(*cachedResponse)
+			if cached.generation == generation && time.Now().Before(cached.expires) {
+				c.metricSink.IncrCounterWithLabels([]string{"core", "response_cache", "hit"}, 1, labels)
+				return copyCachedResponse(cached.resp), nil
+			}
+			rc.lru.Remove(key)
+		}
+		c.metricSink.IncrCounterWithLabels([]string{"core", "response_cache", "miss"}, 1, labels)
+
+		resp, err := callback(ctx, req, d)
+		if err != nil || resp == nil || resp.IsError() {
+			return resp, err
+		}
+		rc.lru.Add(key, &cachedResponse{
+			resp:       copyCachedResponse(resp),
+			generation: generation,
+			expires:    time.Now().Add(rc.ttls[template]),
+		})
+		return resp, nil
+	}
+}
+
+// copyCachedResponse copies the response, so that the cached one is not
+// modified as the request completes.
+func copyCachedResponse(resp *logical.Response) *logical.Response {
+	cp := *resp
+	if resp.Data != nil {
+		cp.Data = make(map[string]interface{}, len(resp.Data))
+		for k, v := range resp.Data {
+			cp.Data[k] = v
+		}
+	}
+	if resp.Headers != nil {
+		cp.Headers = make(map[string][]string, len(resp.Headers))
+		for k, v := range resp.Headers {
+			cp.Headers[k] = append([]string(nil), v...)
+		}
+	}
+	cp.Warnings = append([]string(nil), resp.Warnings...)
+	return &cp
+}
+
+// invalidateResponseCache drops the cached responses of the path templates,
+// once what they are computed from changes.
+func (c *Core) invalidateResponseCache(templates ...string) {
+	if c.responseCache != nil {
+		c.responseCache.invalidate(templates...)
+	}
+}
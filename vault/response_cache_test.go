@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResponseCache verifies that cached responses are keyed by parameters,
+// and are recomputed once their TTL expires or the cache is invalidated.
+func TestResponseCache(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	rc, err := newResponseCache(map[string]time.Duration{
+		responseCacheActivity: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	c.responseCache = rc
+
+	var calls int
+	read := c.cachedRead(responseCacheActivity, func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		calls++
+		return &logical.Response{Data: map[string]interface{}{"calls": calls}}, nil
+	})
+	ctx := namespace.RootContext(nil)
+	query := func(params map[string]interface{}) int {
+		resp, err := read(ctx, &logical.Request{Operation: logical.ReadOperation}, &framework.FieldData{Raw: params})
+		require.NoError(t, err)
+		return resp.Data["calls"].(int)
+	}
+
+	require.Equal(t, 1, query(nil))
+	require.Equal(t, 1, query(nil))
+	require.Equal(t, 2, query(map[string]interface{}{"limit_namespaces": 10}))
+
+	c.invalidateResponseCache(responseCacheActivity)
+	require.Equal(t, 3, query(nil))
+
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, 4, query(nil))
+
+	_, err = newResponseCache(map[string]time.Duration{"sys/mounts": time.Minute})
+	require.Error(t, err)
+}
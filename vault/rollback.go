@@ -349,6 +349,10 @@ func (m *RollbackManager) attemptRollback(ctx context.Context, fullPath string,
 	if err == logical.ErrUnsupportedOperation {
 		err = nil
 	}
+	// Sealed mounts have no backend to roll back
+	if errors.Is(err, logical.ErrMountSealed) {
+		err = nil
+	}
 	// If we failed due to read-only storage, we can't do anything; ignore
 	if (err != nil && strings.Contains(err.Error(), logical.ErrReadOnly.Error())) ||
 		(resp.IsError() && strings.Contains(resp.Error().Error(), logical.ErrReadOnly.Error())) {
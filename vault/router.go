@@ -61,6 +61,9 @@ func NewRouter() *Router {
 // routeEntry is used to represent a mount point in the router
 type routeEntry struct {
 	tainted atomic.Bool
+	// sealed is set when the mount is sealed, in which case all the
+	// requests are rejected and the backend is nil
+	sealed atomic.Bool
 	// backend is the actual backend instance for this route entry; lock l must
 	// be held to access this field.
 	backend       logical.Backend
@@ -71,6 +74,9 @@ type routeEntry struct {
 	loginPaths    atomic.Value
 	binaryPaths   atomic.Value
 	limitedPaths  atomic.Value
+	// paginatedListPaths are the paths whose LIST responses are sorted and
+	// paginated by the router
+	paginatedListPaths atomic.Value
 	// l is the lock used to protect access to backend during reloads
 	l sync.RWMutex
 }
@@ -137,6 +143,7 @@ func (entry *routeEntry) Deserialize() map[string]interface{} {
 	defer entry.l.RUnlock()
 	ret := map[string]interface{}{
 		"tainted":        entry.tainted.Load(),
+		"sealed":         entry.sealed.Load(),
 		"storage_prefix": entry.storagePrefix,
 	}
 	for k, v := range entry.mountEntry.Deserialize() {
@@ -206,6 +213,7 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 		storageView:   storageView,
 	}
 	re.tainted.Store(mountEntry.Tainted)
+	re.sealed.Store(mountEntry.Sealed)
 	re.rootPaths.Store(pathsToRadix(paths.Root))
 	loginPathsEntry, err := parseUnauthenticatedPaths(paths.Unauthenticated)
 	if err != nil {
@@ -225,6 +233,12 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 	}
 	re.limitedPaths.Store(limitedPathsEntry)
 
+	paginatedListPathsEntry, err := parseUnauthenticatedPaths(paths.PaginatedList)
+	if err != nil {
+		return err
+	}
+	re.paginatedListPaths.Store(paginatedListPathsEntry)
+
 	switch {
 	case prefix == "":
 		return fmt.Errorf("missing prefix to be used for router entry; mount_path: %q, mount_type: %q", re.mountEntry.Path, re.mountEntry.Type)
@@ -318,6 +332,52 @@ func (r *Router) Taint(ctx context.Context, path string) error {
 	return nil
 }
 
+// SealMount marks the mount at the path as sealed, so that all requests to it
+// are rejected, and drops its backend once in-flight requests have drained.
+func (r *Router) SealMount(ctx context.Context, path string) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	path = ns.Path + path
+
+	r.l.RLock()
+	raw, ok := r.root.Get(path)
+	r.l.RUnlock()
+	if !ok {
+		return fmt.Errorf("no mount at %q", path)
+	}
+	re := raw.(*routeEntry)
+	re.sealed.Store(true)
+
+	re.l.Lock()
+	defer re.l.Unlock()
+	if re.backend != nil {
+		re.backend.Cleanup(ctx)
+		re.backend = nil
+	}
+	return nil
+}
+
+// UnsealMount unmarks the mount at the path as sealed. Its backend must have
+// been set up again first.
+func (r *Router) UnsealMount(ctx context.Context, path string) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	path = ns.Path + path
+
+	r.l.RLock()
+	raw, ok := r.root.Get(path)
+	r.l.RUnlock()
+	if !ok {
+		return fmt.Errorf("no mount at %q", path)
+	}
+	raw.(*routeEntry).sealed.Store(false)
+	return nil
+}
+
 // Untaint is used to unmark a path as tainted.
 func (r *Router) Untaint(ctx context.Context, path string) error {
 	ns, err := namespace.FromContext(ctx)
@@ -606,6 +666,11 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		defer re.l.RUnlock()
 	}
 
+	// Sealed mounts reject all operations
+	if re.sealed.Load() {
+		return logical.ErrorResponse(fmt.Sprintf("mount %q is sealed", mount)), false, false, logical.ErrMountSealed
+	}
+
 	// Filtered mounts will have a nil backend
 	if re.backend == nil {
 		return logical.ErrorResponse(fmt.Sprintf("no handler for route %q. route entry found, but backend is nil.", req.Path)), false, false, logical.ErrUnsupportedPath
@@ -630,6 +695,8 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	req.SetMountRunningVersion(re.mountEntry.RunningVersion)
 	req.SetMountIsExternalPlugin(re.mountEntry.IsExternalPlugin())
 	req.SetMountClass(re.mountEntry.MountClass())
+	req.SetStrictFieldValidation(re.mountEntry.Config.StrictFieldValidation)
+	req.SetCompatibilityLevel(re.mountEntry.Config.CompatibilityLevel)
 
 	if req.Path == "/" {
 		req.Path = ""
@@ -750,6 +817,8 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		req.SetMountRunningVersion(re.mountEntry.RunningVersion)
 		req.SetMountIsExternalPlugin(re.mountEntry.IsExternalPlugin())
 		req.SetMountClass(re.mountEntry.MountClass())
+		req.SetStrictFieldValidation(re.mountEntry.Config.StrictFieldValidation)
+		req.SetCompatibilityLevel(re.mountEntry.Config.CompatibilityLevel)
 
 		req.Connection = originalConn
 		req.ID = originalReqID
@@ -782,12 +851,29 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		req.ControlGroup = originalControlGroup
 	}()
 
+	// Take the pagination parameters out of LIST requests to paths the
+	// backend has opted in for, the router applies them to the response
+	var pagination *listPagination
+	if !existenceCheck && req.Operation == logical.ListOperation &&
+		re.paginatedListPaths.Load().(*specialPathsEntry).matches(req.Path) {
+		pagination, err = popListPagination(req)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), false, false, logical.ErrInvalidRequest
+		}
+		defer pagination.restore(req)
+	}
+
 	// Invoke the backend
 	if existenceCheck {
 		ok, exists, err := re.backend.HandleExistenceCheck(ctx, req)
 		return nil, ok, exists, err
 	} else {
+		restorePhase := enterInFlightPhase(ctx, inFlightPhaseBackend)
 		resp, err := re.backend.HandleRequest(ctx, req)
+		restorePhase()
+		if pagination != nil && err == nil {
+			pagination.apply(resp)
+		}
 		if resp != nil {
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
@@ -839,6 +925,12 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 							resp.Auth.TokenType = logical.TokenTypeService
 						}
 					}
+
+					// Roles opting into batch tokens can constrain their
+					// policies
+					if resp.Auth.TokenType == logical.TokenTypeBatch && len(resp.Auth.BatchTokenPolicies) > 0 {
+						resp.Auth.Policies = constrainBatchTokenPolicies(resp.Auth.Policies, resp.Auth.BatchTokenPolicies)
+					}
 				}
 			}
 		}
@@ -847,6 +939,18 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	}
 }
 
+// constrainBatchTokenPolicies returns the policies which are in the allowed
+// list.
+func constrainBatchTokenPolicies(policies, allowed []string) []string {
+	constrained := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		if strutil.StrListContains(allowed, policy) {
+			constrained = append(constrained, policy)
+		}
+	}
+	return constrained
+}
+
 // RootPath checks if the given path requires root privileges
 func (r *Router) RootPath(ctx context.Context, path string) bool {
 	ns, err := namespace.FromContext(ctx)
@@ -935,7 +1039,12 @@ func (r *Router) specialPath(ctx context.Context, path string, lookup specialPat
 	remain := strings.TrimPrefix(adjustedPath, mount)
 
 	// Check the specialPath of this backend as specified by the caller.
-	pe := lookup(re)
+	return lookup(re).matches(remain)
+}
+
+// matches returns whether the path, relative to the mount, matches one of
+// the special paths.
+func (pe *specialPathsEntry) matches(remain string) bool {
 	match, raw, ok := pe.paths.LongestPrefix(remain)
 	if !ok && len(pe.wildcardPaths) == 0 {
 		// no match found
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	listPaginationAfter = "after"
+	listPaginationLimit = "limit"
+)
+
+// listPagination holds the pagination parameters of a LIST request to a
+// path for which the backend has opted in to router pagination. Responses
+// are sorted lexicographically, and only the keys strictly after "after"
+// are returned, up to "limit" of them.
+type listPagination struct {
+	after string
+	limit int
+
+	// data is the original data of the request, put back once the request
+	// has been handled.
+	data map[string]interface{}
+}
+
+// popListPagination parses the pagination parameters of the request, and
+// replaces the request data with a copy that does not contain them.
+func popListPagination(req *logical.Request) (*listPagination, error) {
+	p := &listPagination{data: req.Data}
+	if req.Data == nil {
+		return p, nil
+	}
+
+	if rawAfter, ok := req.Data[listPaginationAfter]; ok {
+		after, ok := rawAfter.(string)
+		if !ok {
+			return nil, errors.New(`"after" must be a string`)
+		}
+		p.after = after
+	}
+
+	if rawLimit, ok := req.Data[listPaginationLimit]; ok && rawLimit != "" {
+		limit, err := parseutil.SafeParseInt(rawLimit)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid "limit": %w`, err)
+		}
+		if limit < 0 {
+			return nil, errors.New(`"limit" cannot be negative`)
+		}
+		p.limit = limit
+	}
+
+	data := make(map[string]interface{}, len(req.Data))
+	for k, v := range req.Data {
+		if k != listPaginationAfter && k != listPaginationLimit {
+			data[k] = v
+		}
+	}
+	req.Data = data
+
+	return p, nil
+}
+
+// restore puts the original data back on the request.
+func (p *listPagination) restore(req *logical.Request) {
+	req.Data = p.data
+}
+
+// apply sorts the keys of the LIST response and keeps the requested page.
+// The key_info of the response, if any, is trimmed to the returned keys.
+func (p *listPagination) apply(resp *logical.Response) {
+	if resp == nil || resp.IsError() || resp.Data == nil {
+		return
+	}
+	keys, ok := resp.Data["keys"].([]string)
+	if !ok {
+		return
+	}
+
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+
+	if p.after != "" {
+		start := sort.Search(len(sorted), func(i int) bool {
+			return sorted[i] > p.after
+		})
+		sorted = sorted[start:]
+	}
+	if p.limit > 0 && len(sorted) > p.limit {
+		sorted = sorted[:p.limit]
+	}
+	resp.Data["keys"] = sorted
+
+	if keyInfo, ok := resp.Data["key_info"].(map[string]interface{}); ok && len(sorted) != len(keys) {
+		paged := make(map[string]interface{}, len(sorted))
+		for _, key := range sorted {
+			if info, ok := keyInfo[key]; ok {
+				paged[key] = info
+			}
+		}
+		resp.Data["key_info"] = paged
+	}
+}
@@ -495,6 +495,78 @@ func TestRouter_Taint(t *testing.T) {
 	}
 }
 
+func TestRouter_PaginatedList(t *testing.T) {
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &NoopBackend{
+		PaginatedList: []string{"paged/"},
+		Response: &logical.Response{
+			Data: map[string]interface{}{
+				"keys": []string{"d", "b", "a", "c"},
+				"key_info": map[string]interface{}{
+					"a": "info-a",
+					"b": "info-b",
+					"c": "info-c",
+					"d": "info-d",
+				},
+			},
+		},
+	}
+	err = r.Mount(n, "prod/aws/", &MountEntry{UUID: meUUID, Accessor: "awsaccessor", NamespaceID: namespace.RootNamespaceID, namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	list := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		n.Response.Data["keys"] = []string{"d", "b", "a", "c"}
+		return r.Route(namespace.RootContext(nil), &logical.Request{
+			Operation: logical.ListOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+
+	resp, err := list("prod/aws/paged/", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, resp.Data["keys"])
+
+	resp, err = list("prod/aws/paged/", map[string]interface{}{"after": "a", "limit": "2"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assert.Equal(t, []string{"b", "c"}, resp.Data["keys"])
+	assert.Equal(t, map[string]interface{}{"b": "info-b", "c": "info-c"}, resp.Data["key_info"])
+	if _, ok := n.Requests[len(n.Requests)-1].Data["limit"]; ok {
+		t.Fatal("expected pagination parameters not to reach the backend")
+	}
+
+	resp, err = list("prod/aws/paged/", map[string]interface{}{"after": "d"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assert.Empty(t, resp.Data["keys"])
+
+	_, err = list("prod/aws/paged/", map[string]interface{}{"limit": "-1"})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got: %v", err)
+	}
+
+	// Paths the backend has not opted in for are left untouched.
+	resp, err = list("prod/aws/other/", map[string]interface{}{"limit": "1"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assert.Equal(t, []string{"d", "b", "a", "c"}, resp.Data["keys"])
+}
+
 func TestRouter_Untaint(t *testing.T) {
 	r := NewRouter()
 	_, barrier, _ := mockBarrier(t)
@@ -683,3 +755,11 @@ func TestWellKnownRedirectMatching(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRouter_ConstrainBatchTokenPolicies(t *testing.T) {
+	policies := constrainBatchTokenPolicies([]string{"default", "reader", "writer"}, []string{"reader", "auditor"})
+	assert.Equal(t, []string{"reader"}, policies)
+
+	policies = constrainBatchTokenPolicies([]string{"default", "writer"}, []string{"reader"})
+	assert.Empty(t, policies)
+}
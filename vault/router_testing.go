@@ -21,6 +21,7 @@ type NoopBackend struct {
 
 	Root            []string
 	Login           []string
+	PaginatedList   []string
 	Paths           []string
 	Requests        []*logical.Request
 	Response        *logical.Response
@@ -81,6 +82,7 @@ func (n *NoopBackend) SpecialPaths() *logical.Paths {
 	return &logical.Paths{
 		Root:            n.Root,
 		Unauthenticated: n.Login,
+		PaginatedList:   n.PaginatedList,
 	}
 }
 
@@ -0,0 +1,452 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+const (
+	storageIntegritySubPath    = "storage-integrity/"
+	storageIntegrityConfigPath = "config"
+	storageIntegrityReportPath = "report"
+
+	// storageIntegrityQuarantinePrefix is where the physical entries of
+	// quarantined keys are moved to, so they can still be inspected or
+	// restored by an operator. It is not sampled by the scrubber.
+	storageIntegrityQuarantinePrefix = "core/storage-integrity/quarantine/"
+
+	defaultStorageIntegrityInterval   = time.Hour
+	defaultStorageIntegritySampleSize = 100
+
+	// storageIntegrityMaxSampleSize bounds the entries checked by a single
+	// scrub, which holds the scrubber for its duration.
+	storageIntegrityMaxSampleSize = 100000
+
+	// storageIntegrityMaxCorruptEntries bounds the corrupt entries kept in
+	// the report.
+	storageIntegrityMaxCorruptEntries = 1000
+
+	// storageIntegrityMaxDepth bounds the descent into the storage key
+	// hierarchy when sampling an entry.
+	storageIntegrityMaxDepth = 64
+)
+
+var (
+	// storageIntegrityPrefixes are the storage prefixes sampled by the
+	// scrubber. Their entries are all encrypted by the barrier, unlike some
+	// of the entries under core/.
+	storageIntegrityPrefixes = []string{backendBarrierPrefix, credentialBarrierPrefix, systemBarrierPrefix}
+
+	// storageIntegrityPlaintextKeys are the keys under the sampled prefixes
+	// which are stored in plaintext, and so are not checked.
+	storageIntegrityPlaintextKeys = map[string]struct{}{
+		systemBarrierPrefix + "ui/" + uiConfigPlaintextKey: {},
+	}
+
+	errStorageIntegrityNotRunning = errors.New("storage integrity scrubber is not running on this node")
+	errStorageEntryNotCorrupt     = errors.New("storage entry is not corrupt")
+)
+
+// storageIntegrityConfig configures the background scrubber.
+type storageIntegrityConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Interval is how often entries are sampled and checked.
+	Interval time.Duration `json:"interval"`
+
+	// SampleSize is the number of entries checked each time.
+	SampleSize int `json:"sample_size"`
+
+	// AutoQuarantine quarantines corrupt entries as soon as they are found.
+	AutoQuarantine bool `json:"auto_quarantine"`
+}
+
+func defaultStorageIntegrityConfig() *storageIntegrityConfig {
+	return &storageIntegrityConfig{
+		Enabled:    true,
+		Interval:   defaultStorageIntegrityInterval,
+		SampleSize: defaultStorageIntegritySampleSize,
+	}
+}
+
+// storageIntegrityReport is the outcome of the scrubs so far.
+type storageIntegrityReport struct {
+	LastScrubTime  time.Time                       `json:"last_scrub_time"`
+	Scrubs         uint64                          `json:"scrubs"`
+	EntriesChecked uint64                          `json:"entries_checked"`
+	CorruptEntries map[string]*corruptStorageEntry `json:"corrupt_entries"`
+}
+
+// corruptStorageEntry is a storage entry which failed to decrypt, meaning
+// its ciphertext or its authentication tag no longer match.
+type corruptStorageEntry struct {
+	Key             string    `json:"key"`
+	Error           string    `json:"error"`
+	DetectedTime    time.Time `json:"detected_time"`
+	Quarantined     bool      `json:"quarantined"`
+	QuarantinedTime time.Time `json:"quarantined_time,omitempty"`
+}
+
+// storageIntegrityScrubber samples storage entries in the background while
+// the node is active, and checks that they decrypt. As the barrier encrypts
+// entries with AES-GCM, decryption also verifies the integrity of each entry
+// against its authentication tag, which catches silent corruption of the
+// storage before a failover or a restore has to read the entry.
+type storageIntegrityScrubber struct {
+	core   *Core
+	view   *BarrierView
+	logger log.Logger
+
+	// prefixes are the storage prefixes sampled, replaced in tests.
+	prefixes []string
+
+	// l serializes scrubs and quarantines, and guards the config and the
+	// report
+	l      sync.Mutex
+	config *storageIntegrityConfig
+	report *storageIntegrityReport
+
+	// configCh signals the run loop that the config changed.
+	configCh chan struct{}
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+}
+
+func (c *Core) setupStorageIntegrity(ctx context.Context) error {
+	s := &storageIntegrityScrubber{
+		core:     c,
+		view:     c.systemBarrierView.SubView(storageIntegritySubPath),
+		logger:   c.baseLogger.Named("storage-integrity"),
+		prefixes: storageIntegrityPrefixes,
+		config:   defaultStorageIntegrityConfig(),
+		report:   &storageIntegrityReport{CorruptEntries: make(map[string]*corruptStorageEntry)},
+		configCh: make(chan struct{}, 1),
+		doneCh:   make(chan struct{}),
+	}
+	c.AddLogger(s.logger)
+
+	entry, err := s.view.Get(ctx, storageIntegrityConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read storage integrity config: %w", err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(s.config); err != nil {
+			return fmt.Errorf("failed to decode storage integrity config: %w", err)
+		}
+	}
+
+	entry, err = s.view.Get(ctx, storageIntegrityReportPath)
+	if err != nil {
+		return fmt.Errorf("failed to read storage integrity report: %w", err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(s.report); err != nil {
+			return fmt.Errorf("failed to decode storage integrity report: %w", err)
+		}
+		if s.report.CorruptEntries == nil {
+			s.report.CorruptEntries = make(map[string]*corruptStorageEntry)
+		}
+	}
+
+	var runCtx context.Context
+	runCtx, s.cancel = context.WithCancel(c.activeContext)
+	go s.run(runCtx)
+
+	c.storageIntegrity.Store(s)
+	return nil
+}
+
+func (c *Core) stopStorageIntegrity() {
+	s := c.storageIntegrity.Swap(nil)
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.doneCh
+}
+
+func (s *storageIntegrityScrubber) run(ctx context.Context) {
+	defer close(s.doneCh)
+
+	for {
+		s.l.Lock()
+		enabled, interval := s.config.Enabled, s.config.Interval
+		s.l.Unlock()
+
+		var timer *time.Timer
+		var timerCh <-chan time.Time
+		if enabled {
+			timer = time.NewTimer(interval)
+			timerCh = timer.C
+		}
+
+		select {
+		case <-timerCh:
+			if _, err := s.scrub(ctx, 0); err != nil && ctx.Err() == nil {
+				s.logger.Error("failed to scrub storage", "error", err)
+			}
+		case <-s.configCh:
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// storageIntegrityScrubResult is the outcome of a single scrub.
+type storageIntegrityScrubResult struct {
+	EntriesChecked int
+	CorruptEntries []*corruptStorageEntry
+}
+
+// scrub samples entries from storage and checks them, recording those which
+// are corrupt in the report. If sampleSize is zero, the configured sample
+// size is used.
+func (s *storageIntegrityScrubber) scrub(ctx context.Context, sampleSize int) (*storageIntegrityScrubResult, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if sampleSize == 0 {
+		sampleSize = s.config.SampleSize
+	}
+
+	result := &storageIntegrityScrubResult{}
+	var scrubErr error
+	for i := 0; i < sampleSize; i++ {
+		// Scrubbing is held to the IO budget of tidies, and waits for memory
+		// pressure to be relieved
+		if err := s.core.tidyStep(ctx, nil); err != nil {
+			scrubErr = err
+			break
+		}
+
+		key, err := s.sampleKey(ctx)
+		if err != nil {
+			scrubErr = err
+			break
+		}
+		if key == "" {
+			continue
+		}
+
+		corruption, err := s.check(ctx, key)
+		if err != nil {
+			scrubErr = err
+			break
+		}
+		result.EntriesChecked++
+
+		if corruption == "" {
+			// The entry may have been rewritten or restored since it was found
+			// to be corrupt
+			delete(s.report.CorruptEntries, key)
+			continue
+		}
+
+		corrupt := s.recordCorruption(key, corruption)
+		result.CorruptEntries = append(result.CorruptEntries, corrupt)
+		if s.config.AutoQuarantine && !corrupt.Quarantined {
+			if err := s.quarantineLocked(ctx, key); err != nil {
+				s.logger.Error("failed to quarantine corrupt storage entry", "key", key, "error", err)
+			}
+		}
+	}
+
+	s.report.LastScrubTime = time.Now()
+	s.report.Scrubs++
+	s.report.EntriesChecked += uint64(result.EntriesChecked)
+	if err := s.persistReportLocked(ctx); err != nil && scrubErr == nil {
+		scrubErr = err
+	}
+
+	s.logger.Debug("scrubbed storage", "entries_checked", result.EntriesChecked, "corrupt_entries", len(result.CorruptEntries))
+	return result, scrubErr
+}
+
+// sampleKey picks a storage key at random by descending the key hierarchy
+// from one of the sampled prefixes. An empty key is returned if the descent
+// ends in an empty folder or a key which is not checked.
+func (s *storageIntegrityScrubber) sampleKey(ctx context.Context) (string, error) {
+	prefix := s.prefixes[rand.Intn(len(s.prefixes))]
+	for depth := 0; depth < storageIntegrityMaxDepth; depth++ {
+		keys, err := s.core.physical.List(ctx, prefix)
+		if err != nil {
+			return "", err
+		}
+		if len(keys) == 0 {
+			return "", nil
+		}
+
+		key := prefix + keys[rand.Intn(len(keys))]
+		if !strings.HasSuffix(key, "/") {
+			if _, ok := storageIntegrityPlaintextKeys[key]; ok {
+				return "", nil
+			}
+			return key, nil
+		}
+		prefix = key
+	}
+	return "", nil
+}
+
+// check reads the entry at the given key from storage, bypassing the cache,
+// and returns why it is corrupt, or an empty string if it decrypts or no
+// longer exists.
+func (s *storageIntegrityScrubber) check(ctx context.Context, key string) (string, error) {
+	pe, err := s.core.physical.Get(physical.CacheRefreshContext(ctx, true), key)
+	if err != nil {
+		return "", err
+	}
+	if pe == nil {
+		return "", nil
+	}
+
+	if _, err := s.core.barrier.Decrypt(ctx, key, pe.Value); err != nil {
+		if errors.Is(err, ErrBarrierSealed) {
+			return "", err
+		}
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+func (s *storageIntegrityScrubber) recordCorruption(key, corruption string) *corruptStorageEntry {
+	corrupt, ok := s.report.CorruptEntries[key]
+	if ok {
+		corrupt.Error = corruption
+		return corrupt
+	}
+
+	corrupt = &corruptStorageEntry{
+		Key:          key,
+		Error:        corruption,
+		DetectedTime: time.Now(),
+	}
+	if len(s.report.CorruptEntries) < storageIntegrityMaxCorruptEntries {
+		s.report.CorruptEntries[key] = corrupt
+	}
+
+	s.logger.Error("found corrupt storage entry", "key", key, "error", corruption)
+	metrics.IncrCounter([]string{"core", "storage_integrity", "corrupt_entries"}, 1)
+	return corrupt
+}
+
+// quarantine moves the physical entry of a corrupt key under the quarantine
+// prefix, so that Vault no longer reads it. The entry is checked again first,
+// and errStorageEntryNotCorrupt returned if it is no longer corrupt.
+func (s *storageIntegrityScrubber) quarantine(ctx context.Context, key string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.quarantineLocked(ctx, key); err != nil {
+		return err
+	}
+	return s.persistReportLocked(ctx)
+}
+
+func (s *storageIntegrityScrubber) quarantineLocked(ctx context.Context, key string) error {
+	corruption, err := s.check(ctx, key)
+	if err != nil {
+		return err
+	}
+	if corruption == "" {
+		delete(s.report.CorruptEntries, key)
+		return errStorageEntryNotCorrupt
+	}
+
+	pe, err := s.core.physical.Get(physical.CacheRefreshContext(ctx, true), key)
+	if err != nil {
+		return err
+	}
+	if pe == nil {
+		return errStorageEntryNotCorrupt
+	}
+
+	if err := s.core.physical.Put(ctx, &physical.Entry{
+		Key:   storageIntegrityQuarantinePrefix + key,
+		Value: pe.Value,
+	}); err != nil {
+		return fmt.Errorf("failed to copy entry to quarantine: %w", err)
+	}
+	if err := s.core.physical.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete quarantined entry: %w", err)
+	}
+
+	corrupt := s.recordCorruption(key, corruption)
+	corrupt.Quarantined = true
+	corrupt.QuarantinedTime = time.Now()
+
+	s.logger.Warn("quarantined corrupt storage entry", "key", key, "quarantine_key", storageIntegrityQuarantinePrefix+key)
+	return nil
+}
+
+func (s *storageIntegrityScrubber) persistReportLocked(ctx context.Context) error {
+	entry, err := logical.StorageEntryJSON(storageIntegrityReportPath, s.report)
+	if err != nil {
+		return err
+	}
+	return s.view.Put(ctx, entry)
+}
+
+func (s *storageIntegrityScrubber) getConfig() storageIntegrityConfig {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return *s.config
+}
+
+func (s *storageIntegrityScrubber) setConfig(ctx context.Context, config *storageIntegrityConfig) error {
+	entry, err := logical.StorageEntryJSON(storageIntegrityConfigPath, config)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.view.Put(ctx, entry); err != nil {
+		return err
+	}
+	s.config = config
+
+	select {
+	case s.configCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// getReport returns a copy of the report, with the corrupt entries sorted by
+// key.
+func (s *storageIntegrityScrubber) getReport() (storageIntegrityReport, []corruptStorageEntry) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	corrupt := make([]corruptStorageEntry, 0, len(s.report.CorruptEntries))
+	for _, entry := range s.report.CorruptEntries {
+		corrupt = append(corrupt, *entry)
+	}
+	sort.Slice(corrupt, func(i, j int) bool {
+		return corrupt[i].Key < corrupt[j].Key
+	})
+	return *s.report, corrupt
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorageIntegrity_ScrubAndQuarantine verifies that the scrubber reports
+// entries whose ciphertext was corrupted behind the back of Vault, and that
+// quarantining them moves their physical entry aside.
+func TestStorageIntegrity_ScrubAndQuarantine(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	s := c.storageIntegrity.Load()
+	require.NotNil(t, s)
+	s.prefixes = []string{"sys/integrity-test/"}
+
+	require.NoError(t, c.barrier.Put(ctx, &logical.StorageEntry{Key: "sys/integrity-test/good", Value: []byte("good")}))
+	require.NoError(t, c.barrier.Put(ctx, &logical.StorageEntry{Key: "sys/integrity-test/bad", Value: []byte("bad")}))
+
+	// Flip a bit of the ciphertext in the underlying storage, bypassing the
+	// cache
+	pe, err := c.underlyingPhysical.Get(ctx, "sys/integrity-test/bad")
+	require.NoError(t, err)
+	pe.Value[len(pe.Value)-1] ^= 1
+	require.NoError(t, c.underlyingPhysical.Put(ctx, pe))
+
+	result, err := s.scrub(ctx, 50)
+	require.NoError(t, err)
+	require.Equal(t, 50, result.EntriesChecked)
+	require.NotEmpty(t, result.CorruptEntries)
+
+	resp, err := c.systemBackend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "storage/integrity",
+	})
+	require.NoError(t, err)
+	corrupt := resp.Data["corrupt_entries"].([]map[string]interface{})
+	require.Len(t, corrupt, 1)
+	require.Equal(t, "sys/integrity-test/bad", corrupt[0]["key"])
+	require.Equal(t, false, corrupt[0]["quarantined"])
+
+	// Intact entries cannot be quarantined
+	_, err = c.systemBackend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "storage/integrity/quarantine",
+		Data:      map[string]interface{}{"key": "sys/integrity-test/good"},
+	})
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	_, err = c.systemBackend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "storage/integrity/quarantine",
+		Data:      map[string]interface{}{"key": "sys/integrity-test/bad"},
+	})
+	require.NoError(t, err)
+
+	entry, err := c.physical.Get(physical.CacheRefreshContext(ctx, true), "sys/integrity-test/bad")
+	require.NoError(t, err)
+	require.Nil(t, entry)
+	entry, err = c.physical.Get(ctx, storageIntegrityQuarantinePrefix+"sys/integrity-test/bad")
+	require.NoError(t, err)
+	require.Equal(t, pe.Value, entry.Value)
+
+	_, corruptEntries := s.getReport()
+	require.Len(t, corruptEntries, 1)
+	require.True(t, corruptEntries[0].Quarantined)
+
+	// The intact entry is still readable, and is the only one left to check
+	result, err = s.scrub(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 10, result.EntriesChecked)
+	require.Empty(t, result.CorruptEntries)
+}
@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !testonly
+
+package vault
+
+import (
+	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/framework"
+)
+
+func newCoreClock() timeutil.Clock { return timeutil.DefaultClock{} }
+
+func (b *SystemBackend) testingClockPath() *framework.Path { return nil }
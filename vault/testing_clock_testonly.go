@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/vault/helper/timeutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const testingClockHelpText = "Read, move, or reset the clock of the core, for testing purposes"
+
+// newCoreClock returns an adjustable clock in testonly builds, for tests of
+// lease expirations and month boundaries not to wait for them.
+func newCoreClock() timeutil.Clock { return &timeutil.AdjustableClock{} }
+
+func (b *SystemBackend) testingClockPath() *framework.Path {
+	return &framework.Path{
+		Pattern:         "internal/testing/clock$",
+		HelpDescription: testingClockHelpText,
+		HelpSynopsis:    testingClockHelpText,
+		Fields: map[string]*framework.FieldSchema{
+			"time": {
+				Type:        framework.TypeTime,
+				Description: "Time to set the clock to",
+			},
+			"advance": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Duration to move the clock by, back if negative",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleTestingClockRead,
+				Summary:  "Read the clock",
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleTestingClockUpdate,
+				Summary:  "Move the clock",
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.handleTestingClockDelete,
+				Summary:  "Reset the clock to the wall clock",
+			},
+		},
+	}
+}
+
+func (b *SystemBackend) testingClock() (*timeutil.AdjustableClock, error) {
+	clock, ok := b.Core.clock.(*timeutil.AdjustableClock)
+	if !ok {
+		return nil, errors.New("the clock of the core is not adjustable")
+	}
+	return clock, nil
+}
+
+func (b *SystemBackend) handleTestingClockRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	clock, err := b.testingClock()
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"now":    clock.Now().UTC().Format(time.RFC3339Nano),
+			"offset": int64(clock.Offset().Seconds()),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleTestingClockUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	clock, err := b.testingClock()
+	if err != nil {
+		return nil, err
+	}
+
+	t, setTime := d.GetOk("time")
+	advance, setAdvance := d.GetOk("advance")
+	switch {
+	case setTime && setAdvance:
+		return logical.ErrorResponse("only one of time and advance can be set"), logical.ErrInvalidRequest
+	case setTime:
+		clock.Set(t.(time.Time))
+	case setAdvance:
+		clock.Advance(time.Duration(advance.(int)) * time.Second)
+	default:
+		return logical.ErrorResponse("one of time and advance is required"), logical.ErrInvalidRequest
+	}
+	b.Core.clockAdjusted()
+	return b.handleTestingClockRead(ctx, req, d)
+}
+
+func (b *SystemBackend) handleTestingClockDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	clock, err := b.testingClock()
+	if err != nil {
+		return nil, err
+	}
+	clock.Reset()
+	b.Core.clockAdjusted()
+	return nil, nil
+}
+
+// clockAdjusted reschedules the lease expirations and the end of the month of
+// the activity log by the clock, once it is adjusted.
+func (c *Core) clockAdjusted() {
+	if c.expiration != nil {
+		c.expiration.rescheduleTimers()
+	}
+
+	c.activityLogLock.RLock()
+	defer c.activityLogLock.RUnlock()
+	if c.activityLog != nil {
+		c.activityLog.clockAdjusted()
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build testonly
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_testingClock verifies that moving the clock of the core
+// forward expires the leases which expire before the new time, without
+// waiting for them.
+func TestSystemBackend_testingClock(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["ttl"] = "1h"
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	token := resp.Auth.ClientToken
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/internal/testing/clock")
+	req.ClientToken = root
+	req.Data["advance"] = "2h"
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int64(7200), resp.Data["offset"])
+
+	require.Eventually(t, func() bool {
+		te, err := c.tokenStore.Lookup(ctx, token)
+		return err == nil && te == nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/internal/testing/clock")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, c.clock.(interface{ Offset() time.Duration }).Offset())
+}
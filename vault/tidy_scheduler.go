@@ -0,0 +1,301 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultTidyMaxConcurrency = 1
+
+	// tidyMaxFinishedOperations is the number of finished operations kept
+	// for their progress to remain readable.
+	tidyMaxFinishedOperations = 50
+
+	tidyKindLeases = "leases"
+	tidyKindTokens = "tokens"
+
+	tidyStateQueued    = "queued"
+	tidyStateRunning   = "running"
+	tidyStateCompleted = "completed"
+	tidyStateFailed    = "failed"
+	tidyStateCancelled = "cancelled"
+)
+
+var errTidyInProgress = errors.New("tidy operation already in progress")
+
+// tidyScheduler runs the tidy operations of core, so that overlapping tidies
+// do not cause storage latency spikes: at most a fixed number of them run at
+// once, the others being queued, and together they are held to an IO budget
+// of storage operations per second. Each operation reports its progress and
+// can be cancelled.
+type tidyScheduler struct {
+	logger log.Logger
+
+	// sem holds a token for each running operation.
+	sem chan struct{}
+
+	// limiter is the IO budget shared by the running operations, or nil if
+	// it is unlimited.
+	limiter *rate.Limiter
+
+	l          sync.Mutex
+	operations map[string]*tidyOperation
+}
+
+// tidyOperation is a tidy operation scheduled by the tidy scheduler.
+type tidyOperation struct {
+	ID        string
+	Kind      string
+	Namespace string
+
+	// processed and total are the number of entries processed so far, and
+	// the number of entries to process if known.
+	processed int64
+	total     int64
+
+	l          sync.Mutex
+	state      string
+	err        string
+	queuedAt   time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	cancelled  bool
+	cancel     context.CancelFunc
+}
+
+func newTidyScheduler(maxConcurrency int, ioRateLimit float64, logger log.Logger) *tidyScheduler {
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultTidyMaxConcurrency
+	}
+	s := &tidyScheduler{
+		logger:     logger,
+		sem:        make(chan struct{}, maxConcurrency),
+		operations: make(map[string]*tidyOperation),
+	}
+	if ioRateLimit > 0 {
+		burst := int(ioRateLimit)
+		if burst < 1 {
+			burst = 1
+		}
+		s.limiter = rate.NewLimiter(rate.Limit(ioRateLimit), burst)
+	}
+	return s
+}
+
+// start schedules the tidy function as an operation of the kind, unless one
+// is already queued or running, in which case that operation is returned
+// with errTidyInProgress. The function runs with a context derived from ctx,
+// which is cancelled when the operation is.
+func (s *tidyScheduler) start(ctx context.Context, kind string, fn func(context.Context, *tidyOperation) error) (*tidyOperation, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.l.Lock()
+	for _, op := range s.operations {
+		if op.Kind == kind && !op.finished() {
+			s.l.Unlock()
+			return op, errTidyInProgress
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	op := &tidyOperation{
+		ID:        id,
+		Kind:      kind,
+		Namespace: ns.Path,
+		state:     tidyStateQueued,
+		queuedAt:  time.Now(),
+		cancel:    cancel,
+	}
+	s.operations[id] = op
+	s.pruneLocked()
+	s.l.Unlock()
+
+	go func() {
+		defer cancel()
+
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			op.finish(ctx.Err())
+			return
+		}
+		defer func() { <-s.sem }()
+
+		op.l.Lock()
+		op.state = tidyStateRunning
+		op.startedAt = time.Now()
+		op.l.Unlock()
+		s.logger.Info("starting tidy operation", "id", op.ID, "kind", kind, "namespace", op.Namespace)
+
+		err := fn(ctx, op)
+		op.finish(err)
+		metrics.IncrCounterWithLabels([]string{"core", "tidy", "finished"}, 1, []metrics.Label{
+			{Name: "kind", Value: kind},
+			{Name: "state", Value: op.currentState()},
+		})
+		s.logger.Info("finished tidy operation", "id", op.ID, "kind", kind, "processed", atomic.LoadInt64(&op.processed), "error", err)
+	}()
+
+	return op, nil
+}
+
+// pruneLocked drops the oldest finished operations past the retention limit.
+func (s *tidyScheduler) pruneLocked() {
+	var finished []*tidyOperation
+	for _, op := range s.operations {
+		if op.finished() {
+			finished = append(finished, op)
+		}
+	}
+	if len(finished) <= tidyMaxFinishedOperations {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].queuedAt.Before(finished[j].queuedAt)
+	})
+	for _, op := range finished[:len(finished)-tidyMaxFinishedOperations] {
+		delete(s.operations, op.ID)
+	}
+}
+
+// list returns the operations of the namespace, most recent first.
+func (s *tidyScheduler) list(ns *namespace.Namespace) []*tidyOperation {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	var ops []*tidyOperation
+	for _, op := range s.operations {
+		if op.Namespace == ns.Path {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].queuedAt.After(ops[j].queuedAt)
+	})
+	return ops
+}
+
+// get returns the operation of the namespace with the ID, or nil.
+func (s *tidyScheduler) get(ns *namespace.Namespace, id string) *tidyOperation {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	op, ok := s.operations[id]
+	if !ok || op.Namespace != ns.Path {
+		return nil
+	}
+	return op
+}
+
+// setTotal records the number of entries the operation has to process, if
+// known.
+func (op *tidyOperation) setTotal(total int) {
+	if op != nil {
+		atomic.StoreInt64(&op.total, int64(total))
+	}
+}
+
+// cancelOperation cancels the operation, if not finished yet.
+func (op *tidyOperation) cancelOperation() {
+	op.l.Lock()
+	defer op.l.Unlock()
+	if op.state == tidyStateQueued || op.state == tidyStateRunning {
+		op.cancelled = true
+		op.cancel()
+	}
+}
+
+func (op *tidyOperation) finish(err error) {
+	op.l.Lock()
+	defer op.l.Unlock()
+
+	op.finishedAt = time.Now()
+	switch {
+	case op.cancelled:
+		op.state = tidyStateCancelled
+	case err != nil:
+		op.state = tidyStateFailed
+		op.err = err.Error()
+	default:
+		op.state = tidyStateCompleted
+	}
+}
+
+func (op *tidyOperation) currentState() string {
+	op.l.Lock()
+	defer op.l.Unlock()
+	return op.state
+}
+
+func (op *tidyOperation) finished() bool {
+	state := op.currentState()
+	return state != tidyStateQueued && state != tidyStateRunning
+}
+
+// status returns the progress of the operation, for the API.
+func (op *tidyOperation) status() map[string]interface{} {
+	op.l.Lock()
+	defer op.l.Unlock()
+
+	status := map[string]interface{}{
+		"id":        op.ID,
+		"kind":      op.Kind,
+		"namespace": op.Namespace,
+		"state":     op.state,
+		"processed": atomic.LoadInt64(&op.processed),
+		"queued_at": op.queuedAt.Format(time.RFC3339Nano),
+	}
+	if total := atomic.LoadInt64(&op.total); total > 0 {
+		status["total"] = total
+	}
+	if !op.startedAt.IsZero() {
+		status["started_at"] = op.startedAt.Format(time.RFC3339Nano)
+	}
+	if !op.finishedAt.IsZero() {
+		status["finished_at"] = op.finishedAt.Format(time.RFC3339Nano)
+	}
+	if op.err != "" {
+		status["error"] = op.err
+	}
+	return status
+}
+
+// tidyStep is called by tidy operations before processing each entry. It
+// counts the entry toward the progress of the operation, which is nil when
+// the tidy runs outside of the scheduler, and blocks as long as the IO budget
+// is exhausted or the memory is under pressure. An error is returned once
+// the context is done, meaning the tidy has to stop.
+func (c *Core) tidyStep(ctx context.Context, op *tidyOperation) error {
+	if op != nil {
+		atomic.AddInt64(&op.processed, 1)
+	}
+	if c.tidyScheduler != nil && c.tidyScheduler.limiter != nil {
+		if err := c.tidyScheduler.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.waitForMemoryPressureRelief(ctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTidyScheduler verifies that tidy operations past the concurrency limit
+// are queued, that an operation of a kind already in progress is not started
+// twice, and that operations can be cancelled through the API.
+func TestTidyScheduler(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	s := c.tidyScheduler
+
+	started := make(chan struct{})
+	blocking := func(ctx context.Context, op *tidyOperation) error {
+		close(started)
+		for {
+			if err := c.tidyStep(ctx, op); err != nil {
+				return err
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	first, err := s.start(ctx, "first", blocking)
+	require.NoError(t, err)
+	<-started
+
+	_, err = s.start(ctx, "first", blocking)
+	require.ErrorIs(t, err, errTidyInProgress)
+
+	second, err := s.start(ctx, "second", func(ctx context.Context, op *tidyOperation) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, tidyStateQueued, second.currentState(), "expected the concurrency limit to queue the operation")
+
+	req := logical.TestRequest(t, logical.ListOperation, "tidy/operations")
+	resp, err := c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{first.ID, second.ID}, resp.Data["keys"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "tidy/operations/"+first.ID)
+	_, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// Once the first operation stops, the queued one runs
+	require.Eventually(t, func() bool {
+		return second.currentState() == tidyStateCompleted
+	}, 10*time.Second, 10*time.Millisecond)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "tidy/operations/"+first.ID)
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, tidyStateCancelled, resp.Data["state"])
+	require.Greater(t, resp.Data["processed"].(int64), int64(0))
+}
@@ -0,0 +1,389 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/patrickmn/go-cache"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	tokenAnomalyConfigPath = "token-anomaly/"
+
+	// tokenAnomalyEventType is the type of events sent when anomalous token
+	// usage is detected.
+	tokenAnomalyEventType = "token/anomaly-detected"
+
+	// tokenUsageProfileTTL is how long the usage history of an idle token is
+	// retained.
+	tokenUsageProfileTTL = 24 * time.Hour
+
+	// tokenUsageWindow is the interval request rates are measured over.
+	tokenUsageWindow = time.Minute
+
+	// tokenUsageBaselineWindows is the number of windows a token must have
+	// been observed for before its request rate is compared to its history.
+	tokenUsageBaselineWindows = 5
+
+	// maxTrackedTokenUsageValues bounds the number of networks and user
+	// agents remembered per token. Once reached, further new values are no
+	// longer flagged.
+	maxTrackedTokenUsageValues = 64
+
+	tokenAnomalyNewNetwork   = "new_network"
+	tokenAnomalyNewUserAgent = "new_user_agent"
+	tokenAnomalyUnusualRate  = "unusual_rate"
+)
+
+// TokenAnomalyConfig configures detection of anomalous usage of tokens
+// issued by an auth mount.
+type TokenAnomalyConfig struct {
+	// DetectNewNetwork flags tokens used from a network (a /24 for IPv4, a
+	// /64 for IPv6) they have not been used from before.
+	DetectNewNetwork bool `json:"detect_new_network"`
+
+	// DetectNewUserAgent flags tokens used with a User-Agent they have not
+	// been used with before.
+	DetectNewUserAgent bool `json:"detect_new_user_agent"`
+
+	// RateMultiplier flags tokens whose requests per minute exceed their
+	// historical average by this factor. Zero disables the check.
+	RateMultiplier float64 `json:"rate_multiplier"`
+
+	// RateMinRequests is the minimum number of requests per minute before a
+	// token's request rate is flagged.
+	RateMinRequests int `json:"rate_min_requests"`
+
+	// ReduceTTLTo, if set, shortens the TTL of flagged tokens to at most this
+	// duration and makes them non-renewable.
+	ReduceTTLTo time.Duration `json:"reduce_ttl_to"`
+}
+
+func defaultTokenAnomalyConfig() *TokenAnomalyConfig {
+	return &TokenAnomalyConfig{
+		DetectNewNetwork:   true,
+		DetectNewUserAgent: true,
+		RateMultiplier:     10,
+		RateMinRequests:    100,
+	}
+}
+
+// tokenUsageProfile is the usage history of a single token.
+type tokenUsageProfile struct {
+	l           sync.Mutex
+	networks    map[string]struct{}
+	userAgents  map[string]struct{}
+	windowStart time.Time
+	windowCount int
+	rateFlagged bool
+	windows     int
+	baseline    float64
+	reduced     bool
+}
+
+func newTokenUsageProfile() *tokenUsageProfile {
+	return &tokenUsageProfile{
+		networks:   make(map[string]struct{}),
+		userAgents: make(map[string]struct{}),
+	}
+}
+
+// trackNew records value in seen and reports whether it had not been seen
+// before. The first value seen is not considered new.
+func trackNew(seen map[string]struct{}, value string) bool {
+	if value == "" {
+		return false
+	}
+	if _, ok := seen[value]; ok {
+		return false
+	}
+	if len(seen) >= maxTrackedTokenUsageValues {
+		return false
+	}
+	seen[value] = struct{}{}
+	return len(seen) > 1
+}
+
+// observe records a use of the token and returns the anomalies it exhibits
+// under config.
+func (p *tokenUsageProfile) observe(config *TokenAnomalyConfig, now time.Time, network, userAgent string) []string {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var anomalies []string
+	if trackNew(p.networks, network) && config.DetectNewNetwork {
+		anomalies = append(anomalies, tokenAnomalyNewNetwork)
+	}
+	if trackNew(p.userAgents, userAgent) && config.DetectNewUserAgent {
+		anomalies = append(anomalies, tokenAnomalyNewUserAgent)
+	}
+
+	if p.windowStart.IsZero() {
+		p.windowStart = now
+	}
+	if elapsed := int(now.Sub(p.windowStart) / tokenUsageWindow); elapsed > 0 {
+		// Fold the finished window, and any idle windows since, into the
+		// moving average
+		p.foldWindow(float64(p.windowCount))
+		for i := 1; i < elapsed && i < tokenUsageBaselineWindows*2; i++ {
+			p.foldWindow(0)
+		}
+		p.windowStart = p.windowStart.Add(time.Duration(elapsed) * tokenUsageWindow)
+		p.windowCount = 0
+		p.rateFlagged = false
+	}
+	p.windowCount++
+
+	if config.RateMultiplier > 0 && !p.rateFlagged &&
+		p.windows >= tokenUsageBaselineWindows &&
+		p.windowCount >= config.RateMinRequests &&
+		float64(p.windowCount) > config.RateMultiplier*p.baseline {
+		p.rateFlagged = true
+		anomalies = append(anomalies, tokenAnomalyUnusualRate)
+	}
+
+	return anomalies
+}
+
+func (p *tokenUsageProfile) foldWindow(count float64) {
+	const alpha = 0.2
+	if p.windows == 0 {
+		p.baseline = count
+	} else {
+		p.baseline = alpha*count + (1-alpha)*p.baseline
+	}
+	p.windows++
+}
+
+// tokenUsageNetwork returns the network remoteAddr belongs to, as tracked
+// by the anomaly detector.
+func tokenUsageNetwork(remoteAddr string) string {
+	ip := net.ParseIP(remoteAddr)
+	if ip == nil {
+		return ""
+	}
+	mask := net.CIDRMask(64, 128)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		mask = net.CIDRMask(24, 32)
+	}
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+// checkTokenAnomalies records the use of te by req, and if the auth mount
+// which issued the token has anomaly detection configured, flags anomalous
+// usage. Usage history is kept in memory, so each node only considers the
+// requests it handled itself.
+func (c *Core) checkTokenAnomalies(ctx context.Context, req *logical.Request, te *logical.TokenEntry) {
+	configs := c.tokenAnomalyConfigs.Load()
+	if configs == nil || len(*configs) == 0 {
+		return
+	}
+	if te.Type == logical.TokenTypeBatch || te.Accessor == "" {
+		return
+	}
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+	if err != nil || tokenNS == nil {
+		return
+	}
+	entry := c.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, tokenNS), te.Path)
+	if entry == nil {
+		return
+	}
+	config, ok := (*configs)[entry.Accessor]
+	if !ok {
+		return
+	}
+
+	var profile *tokenUsageProfile
+	if raw, ok := c.tokenUsageProfiles.Get(te.Accessor); ok {
+		profile = raw.(*tokenUsageProfile)
+	} else {
+		profile = newTokenUsageProfile()
+		if err := c.tokenUsageProfiles.Add(te.Accessor, profile, cache.DefaultExpiration); err != nil {
+			// Added concurrently by another request
+			if raw, ok := c.tokenUsageProfiles.Get(te.Accessor); ok {
+				profile = raw.(*tokenUsageProfile)
+			}
+		}
+	}
+
+	var network, userAgent string
+	if req.Connection != nil {
+		network = tokenUsageNetwork(req.Connection.RemoteAddr)
+	}
+	if ua := req.Headers["User-Agent"]; len(ua) > 0 {
+		userAgent = ua[0]
+	}
+
+	anomalies := profile.observe(config, time.Now(), network, userAgent)
+
+	// Keep the profile alive while the token is in use
+	c.tokenUsageProfiles.Set(te.Accessor, profile, cache.DefaultExpiration)
+
+	if len(anomalies) == 0 {
+		return
+	}
+
+	reduceTTL := false
+	if config.ReduceTTLTo > 0 && !c.PerfStandby() {
+		profile.l.Lock()
+		reduceTTL = !profile.reduced
+		profile.reduced = true
+		profile.l.Unlock()
+	}
+
+	for _, anomaly := range anomalies {
+		metrics.IncrCounterWithLabels([]string{"core", "token_anomaly", "detected"}, 1, []metrics.Label{
+			{Name: "anomaly", Value: anomaly},
+			{Name: "mount_point", Value: entry.Path},
+			{Name: "namespace", Value: tokenNS.Path},
+		})
+	}
+	c.logger.Warn("anomalous token usage detected", "accessor", te.Accessor, "mount_point", entry.Path,
+		"anomalies", strings.Join(anomalies, ","), "network", network, "reduce_ttl", reduceTTL)
+
+	c.sendTokenAnomalyEvent(ctx, tokenNS, entry, te, anomalies, network, userAgent, reduceTTL)
+
+	if reduceTTL {
+		te := *te
+		go func() {
+			if err := c.expiration.ReduceTokenTTL(c.activeContext, &te, config.ReduceTTLTo); err != nil {
+				c.logger.Error("failed to reduce TTL of anomalous token", "accessor", te.Accessor, "error", err)
+			}
+		}()
+	}
+}
+
+func (c *Core) sendTokenAnomalyEvent(ctx context.Context, ns *namespace.Namespace, entry *MountEntry, te *logical.TokenEntry, anomalies []string, network, userAgent string, reduceTTL bool) {
+	if c.events == nil {
+		return
+	}
+
+	ev, err := logical.NewEvent()
+	if err != nil {
+		c.logger.Error("failed to create token anomaly event", "error", err)
+		return
+	}
+	if te.EntityID != "" {
+		ev.EntityIds = []string{te.EntityID}
+	}
+	ev.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+		"accessor":     structpb.NewStringValue(te.Accessor),
+		"anomalies":    structpb.NewStringValue(strings.Join(anomalies, ",")),
+		"network":      structpb.NewStringValue(network),
+		"user_agent":   structpb.NewStringValue(userAgent),
+		"ttl_reduced":  structpb.NewStringValue(strconv.FormatBool(reduceTTL)),
+		"display_name": structpb.NewStringValue(te.DisplayName),
+	}}
+
+	pluginInfo := &logical.EventPluginInfo{
+		MountClass:    entry.MountClass(),
+		MountAccessor: entry.Accessor,
+		MountPath:     entry.Path,
+		Plugin:        entry.Type,
+	}
+	if err := c.events.SendEventInternal(ctx, ns, pluginInfo, tokenAnomalyEventType, ev); err != nil {
+		c.logger.Debug("failed to send token anomaly event", "error", err)
+	}
+}
+
+// TokenAnomalyConfig returns the anomaly detection configuration of the auth
+// mount with the given accessor, or nil if it has none.
+func (c *Core) TokenAnomalyConfig(accessor string) *TokenAnomalyConfig {
+	configs := c.tokenAnomalyConfigs.Load()
+	if configs == nil {
+		return nil
+	}
+	return (*configs)[accessor]
+}
+
+// setTokenAnomalyConfig replaces the configuration of the mount with the
+// given accessor, removing it if config is nil.
+func (c *Core) setTokenAnomalyConfig(accessor string, config *TokenAnomalyConfig) {
+	c.tokenAnomalyConfigsLock.Lock()
+	defer c.tokenAnomalyConfigsLock.Unlock()
+
+	configs := make(map[string]*TokenAnomalyConfig)
+	if current := c.tokenAnomalyConfigs.Load(); current != nil {
+		for k, v := range *current {
+			configs[k] = v
+		}
+	}
+	if config == nil {
+		delete(configs, accessor)
+	} else {
+		configs[accessor] = config
+	}
+	c.tokenAnomalyConfigs.Store(&configs)
+}
+
+func (c *Core) saveTokenAnomalyConfig(ctx context.Context, accessor string, config *TokenAnomalyConfig) error {
+	view := c.systemBarrierView.SubView("config/" + tokenAnomalyConfigPath)
+
+	entry, err := logical.StorageEntryJSON(accessor, config)
+	if err != nil {
+		return fmt.Errorf("failed to create token anomaly config entry: %w", err)
+	}
+
+	if err := view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save token anomaly config: %w", err)
+	}
+
+	c.setTokenAnomalyConfig(accessor, config)
+	return nil
+}
+
+func (c *Core) deleteTokenAnomalyConfig(ctx context.Context, accessor string) error {
+	view := c.systemBarrierView.SubView("config/" + tokenAnomalyConfigPath)
+
+	if err := view.Delete(ctx, accessor); err != nil {
+		return fmt.Errorf("failed to delete token anomaly config: %w", err)
+	}
+
+	c.setTokenAnomalyConfig(accessor, nil)
+	return nil
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadTokenAnomalyConfigs(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/" + tokenAnomalyConfigPath)
+
+	accessors, err := view.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list token anomaly configs: %w", err)
+	}
+
+	configs := make(map[string]*TokenAnomalyConfig, len(accessors))
+	for _, accessor := range accessors {
+		out, err := view.Get(ctx, accessor)
+		if err != nil {
+			return fmt.Errorf("failed to read token anomaly config: %w", err)
+		}
+		if out == nil {
+			continue
+		}
+
+		config := defaultTokenAnomalyConfig()
+		if err := out.DecodeJSON(config); err != nil {
+			return err
+		}
+		configs[accessor] = config
+	}
+
+	c.tokenAnomalyConfigs.Store(&configs)
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenUsageNetwork(t *testing.T) {
+	require.Equal(t, "192.0.2.0/24", tokenUsageNetwork("192.0.2.17"))
+	require.Equal(t, "2001:db8:1:2::/64", tokenUsageNetwork("2001:db8:1:2:3::4"))
+	require.Equal(t, "", tokenUsageNetwork("not-an-ip"))
+}
+
+// TestTokenUsageProfile_Observe verifies which uses of a token are flagged
+// as anomalous.
+func TestTokenUsageProfile_Observe(t *testing.T) {
+	config := defaultTokenAnomalyConfig()
+	config.RateMultiplier = 5
+	config.RateMinRequests = 20
+
+	p := newTokenUsageProfile()
+	now := time.Now()
+
+	// The first use establishes the baseline
+	require.Empty(t, p.observe(config, now, "192.0.2.0/24", "agent/1"))
+	require.Empty(t, p.observe(config, now, "192.0.2.0/24", "agent/1"))
+
+	require.Equal(t, []string{tokenAnomalyNewNetwork}, p.observe(config, now, "198.51.100.0/24", "agent/1"))
+	require.Equal(t, []string{tokenAnomalyNewUserAgent}, p.observe(config, now, "198.51.100.0/24", "curl/8"))
+	require.Empty(t, p.observe(config, now, "192.0.2.0/24", "curl/8"))
+
+	// Build a history of a few requests per minute
+	for i := 1; i <= tokenUsageBaselineWindows; i++ {
+		for j := 0; j < 3; j++ {
+			require.Empty(t, p.observe(config, now.Add(time.Duration(i)*tokenUsageWindow), "192.0.2.0/24", "agent/1"))
+		}
+	}
+
+	// A burst is flagged once per window
+	burst := now.Add(time.Duration(tokenUsageBaselineWindows+1) * tokenUsageWindow)
+	var flagged int
+	for i := 0; i < 50; i++ {
+		for _, anomaly := range p.observe(config, burst, "192.0.2.0/24", "agent/1") {
+			require.Equal(t, tokenAnomalyUnusualRate, anomaly)
+			flagged++
+		}
+	}
+	require.Equal(t, 1, flagged)
+
+	// Disabled checks are not flagged
+	config.DetectNewNetwork = false
+	require.Empty(t, p.observe(config, burst, "203.0.113.0/24", "agent/1"))
+}
+
+// TestCore_CheckTokenAnomalies verifies that anomalous usage of a token
+// issued by a configured mount reduces its TTL.
+func TestCore_CheckTokenAnomalies(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	testMakeServiceTokenViaCore(t, c, root, "client", "1h", []string{"default"})
+	te, err := c.tokenStore.Lookup(ctx, "client")
+	require.NoError(t, err)
+
+	entry := c.router.MatchingMountEntry(ctx, "auth/token/")
+	require.NotNil(t, entry)
+	config := defaultTokenAnomalyConfig()
+	config.ReduceTTLTo = time.Minute
+	require.NoError(t, c.saveTokenAnomalyConfig(ctx, entry.Accessor, config))
+
+	lookup := func(remoteAddr string) {
+		req := logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+		req.ClientToken = "client"
+		req.Connection = &logical.Connection{RemoteAddr: remoteAddr}
+		resp, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp.IsError())
+	}
+
+	lookup("192.0.2.1")
+	lookup("192.0.2.2")
+	le, err := c.expiration.FetchLeaseTimesByToken(ctx, te)
+	require.NoError(t, err)
+	require.True(t, le.ExpireTime.After(time.Now().Add(30*time.Minute)))
+
+	lookup("198.51.100.1")
+	require.Eventually(t, func() bool {
+		le, err := c.expiration.FetchLeaseTimesByToken(ctx, te)
+		return err == nil && le.ExpireTime.Before(time.Now().Add(time.Minute+time.Second))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The configuration survives a reload
+	require.NoError(t, c.loadTokenAnomalyConfigs(ctx))
+	require.Equal(t, config, c.TokenAnomalyConfig(entry.Accessor))
+	require.NoError(t, c.deleteTokenAnomalyConfig(ctx, entry.Accessor))
+	require.Nil(t, c.TokenAnomalyConfig(entry.Accessor))
+}
@@ -11,11 +11,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -652,6 +650,12 @@ func (ts *TokenStore) paths() []*framework.Path {
 				Type:        framework.TypeCommaStringSlice,
 				Description: "String or JSON list of allowed entity aliases. If set, specifies the entity aliases which are allowed to be used during token generation. This field supports globbing.",
 			},
+
+			"max_child_token_depth": {
+				Type:        framework.TypeInt,
+				Default:     -1,
+				Description: tokenMaxChildTokenDepthHelp,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -665,7 +669,8 @@ func (ts *TokenStore) paths() []*framework.Path {
 	}
 
 	tokenutil.AddTokenFieldsWithAllowList(rolesPath.Fields, []string{"token_bound_cidrs", "token_explicit_max_ttl", "token_period", "token_type", "token_no_default_policy", "token_num_uses"})
-	p = append(p, rolesPath)
+	p = append(p, rolesPath, ts.tokenExchangePath())
+	p = append(p, ts.tokenInventoryPaths()...)
 
 	return p
 }
@@ -720,12 +725,18 @@ type TokenStore struct {
 	parentBarrierView   *BarrierView
 	rolesBarrierView    *BarrierView
 
+	entityTokensBarrierView *BarrierView
+
 	expiration *ExpirationManager
 
 	cubbyholeBackend *CubbyholeBackend
 
 	tokenLocks []*locksutil.LockEntry
 
+	// entityTokenLocks serialize the logins of an entity to an auth mount
+	// limiting its number of tokens
+	entityTokenLocks []*locksutil.LockEntry
+
 	// tokenPendingDeletion stores tokens that are being revoked. If the token is
 	// not in the map, it means that there's no deletion in progress. If the value
 	// is true it means deletion is in progress, and if false it means deletion
@@ -739,8 +750,6 @@ type TokenStore struct {
 	saltLock sync.RWMutex
 	salts    map[string]*salt.Salt
 
-	tidyLock *uint32
-
 	identityPoliciesDeriverFunc func(string) (*identity.Entity, []string, error)
 
 	quitContext context.Context
@@ -760,22 +769,23 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 
 	// Initialize the store
 	t := &TokenStore{
-		activeContext:         ctx,
-		core:                  core,
-		batchTokenEncryptor:   core.barrier,
-		baseBarrierView:       view,
-		idBarrierView:         view.SubView(idPrefix),
-		accessorBarrierView:   view.SubView(accessorPrefix),
-		parentBarrierView:     view.SubView(parentPrefix),
-		rolesBarrierView:      view.SubView(rolesPrefix),
-		cubbyholeDestroyer:    destroyCubbyhole,
-		logger:                logger,
-		tokenLocks:            locksutil.CreateLocks(),
-		tokensPendingDeletion: &sync.Map{},
-		saltLock:              sync.RWMutex{},
-		tidyLock:              new(uint32),
-		quitContext:           core.activeContext,
-		salts:                 make(map[string]*salt.Salt),
+		activeContext:           ctx,
+		core:                    core,
+		batchTokenEncryptor:     core.barrier,
+		baseBarrierView:         view,
+		idBarrierView:           view.SubView(idPrefix),
+		accessorBarrierView:     view.SubView(accessorPrefix),
+		parentBarrierView:       view.SubView(parentPrefix),
+		rolesBarrierView:        view.SubView(rolesPrefix),
+		entityTokensBarrierView: view.SubView(entityTokensPrefix),
+		cubbyholeDestroyer:      destroyCubbyhole,
+		logger:                  logger,
+		tokenLocks:              locksutil.CreateLocks(),
+		entityTokenLocks:        locksutil.CreateLocks(),
+		tokensPendingDeletion:   &sync.Map{},
+		saltLock:                sync.RWMutex{},
+		quitContext:             core.activeContext,
+		salts:                   make(map[string]*salt.Salt),
 	}
 
 	// Setup the framework endpoints
@@ -794,6 +804,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 				idPrefix,
 				accessorPrefix,
 				parentPrefix,
+				entityTokensPrefix,
 				salt.DefaultLocation,
 			},
 		},
@@ -892,6 +903,10 @@ type tsRoleEntry struct {
 
 	// The set of allowed entity aliases used during token creation
 	AllowedEntityAliases []string `json:"allowed_entity_aliases" mapstructure:"allowed_entity_aliases" structs:"allowed_entity_aliases"`
+
+	// If set, the number of levels of child tokens which tokens created using
+	// this role may create below them
+	MaxChildTokenDepth *int `json:"max_child_token_depth,omitempty" mapstructure:"max_child_token_depth" structs:"max_child_token_depth"`
 }
 
 type accessorEntry struct {
@@ -1138,6 +1153,12 @@ func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) err
 		if err != nil {
 			return err
 		}
+
+		if entry.EntityID != "" {
+			if err := ts.indexEntityInventoryToken(ctx, tokenNS, entry); err != nil {
+				return fmt.Errorf("failed to index the token of the entity: %w", err)
+			}
+		}
 		entry.ExternalID = entry.ID
 		if !userSelectedID && !ts.core.DisableSSCTokens() {
 			entry.ExternalID = ts.GenerateSSCTokenID(entry.ID, logical.IndexStateFromContext(ctx), entry)
@@ -1899,6 +1920,12 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		if err = ts.accessorView(tokenNS).Delete(ctx, accessorSaltedID); err != nil {
 			return fmt.Errorf("failed to delete entry: %w", err)
 		}
+
+		if entry.EntityID != "" {
+			if err = ts.removeEntityInventoryToken(ctx, entry, accessorSaltedID); err != nil {
+				return fmt.Errorf("failed to delete entry: %w", err)
+			}
+		}
 	}
 
 	if !skipOrphan {
@@ -2172,20 +2199,13 @@ func (ts *TokenStore) lookupByAccessor(ctx context.Context, id string, salted, t
 // handleTidy handles the cleaning up of leaked accessor storage entries and
 // cleaning up of leases that are associated to tokens that are expired.
 func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	if !atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1) {
-		resp := &logical.Response{}
-		resp.AddWarning("Tidy operation already in progress.")
-		return resp, nil
-	}
-
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get namespace from context: %w", err)
 	}
 
-	go func() {
-		defer atomic.StoreUint32(ts.tidyLock, 0)
-
+	tidyCtx := namespace.ContextWithNamespace(ts.quitContext, ns)
+	op, err := ts.core.tidyScheduler.start(tidyCtx, tidyKindTokens, func(quitCtx context.Context, op *tidyOperation) error {
 		logger := ts.logger.Named("tidy")
 
 		var tidyErrors *multierror.Error
@@ -2194,8 +2214,6 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 			ts.logger.Info("beginning tidy operation on tokens")
 			defer ts.logger.Info("finished tidy operation on tokens")
 
-			quitCtx := namespace.ContextWithNamespace(ts.quitContext, ns)
-
 			// List out all the accessors
 			saltedAccessorList, err := ts.accessorView(ns).List(quitCtx, "")
 			if err != nil {
@@ -2220,11 +2238,16 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 				return fmt.Errorf("failed to fetch cubbyhole storage keys: %w", err)
 			}
 
+			op.setTotal(len(parentList) + len(saltedAccessorList) + len(cubbyholeKeys))
+
 			var countParentEntries, deletedCountParentEntries, countParentList, deletedCountParentList int64
 
 			// Scan through the secondary index entries; if there is an entry
 			// with the token's salt ID at the end, remove it
 			for _, parent := range parentList {
+				if err := ts.core.tidyStep(quitCtx, op); err != nil {
+					return err
+				}
 				countParentEntries++
 
 				// Get the children
@@ -2306,6 +2329,9 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 			// a valid one. If not, delete the leases associated with that token
 			// and delete the accessor as well.
 			for index, saltedAccessor := range saltedAccessorList {
+				if err := ts.core.tidyStep(quitCtx, op); err != nil {
+					return err
+				}
 				countAccessorList++
 				if countAccessorList%500 == 0 {
 					percentComplete := float64(index) / float64(len(saltedAccessorList)) * 100
@@ -2407,6 +2433,9 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 
 			// Revoke invalid cubbyhole storage keys
 			for index, key := range cubbyholeKeys {
+				if err := ts.core.tidyStep(quitCtx, op); err != nil {
+					return err
+				}
 				countCubbyholeKeys++
 				if countCubbyholeKeys%500 == 0 {
 					percentComplete := float64(index) / float64(len(cubbyholeKeys)) * 100
@@ -2439,13 +2468,11 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 
 		if err := doTidy(); err != nil {
 			logger.Error("error running tidy", "error", err)
-			return
+			return err
 		}
-	}()
-
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+		return nil
+	})
+	return tidyStartedResponse(req, ns, op, err)
 }
 
 // handleUpdateLookupAccessor handles the auth/token/lookup-accessor path for returning
@@ -2627,6 +2654,13 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 			logical.ErrInvalidRequest
 	}
 
+	// Orphan tokens are subject to the restriction as well, otherwise it
+	// could be escaped by creating them instead.
+	if parent.ChildTokenDepthLimited && parent.ChildTokenDepth <= 0 {
+		return logical.ErrorResponse("token is not allowed to create child tokens"),
+			logical.ErrPermissionDenied
+	}
+
 	// Check if the client token has sudo/root privileges for the requested path
 	isSudo := ts.System().(extendedSystemView).SudoPrivilege(ctx, req.MountPoint+req.Path, req.ClientToken)
 
@@ -2836,6 +2870,18 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		}
 	}
 
+	// The new token may create child tokens down to one level less than its
+	// parent, and no deeper than its role and the token mount allow.
+	if parent.ChildTokenDepthLimited {
+		limitChildTokenDepth(&te, parent.ChildTokenDepth-1)
+	}
+	if role != nil && role.MaxChildTokenDepth != nil {
+		limitChildTokenDepth(&te, *role.MaxChildTokenDepth)
+	}
+	if depth := ts.core.mountMaxChildTokenDepth(ctx, "auth/token/"); depth != nil {
+		limitChildTokenDepth(&te, *depth)
+	}
+
 	// Attach the given display name if any
 	if displayName := d.Get("display_name").(string); displayName != "" {
 		full := "token-" + displayName
@@ -3241,6 +3287,28 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	return resp, nil
 }
 
+// limitChildTokenDepth restricts the number of levels of child tokens which
+// the token may create below it to at most depth.
+func limitChildTokenDepth(te *logical.TokenEntry, depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	if !te.ChildTokenDepthLimited || depth < te.ChildTokenDepth {
+		te.ChildTokenDepthLimited = true
+		te.ChildTokenDepth = depth
+	}
+}
+
+// mountMaxChildTokenDepth returns the restriction on the depth of child
+// tokens tuned on the auth mount matching path, or nil if there is none.
+func (c *Core) mountMaxChildTokenDepth(ctx context.Context, path string) *int {
+	entry := c.router.MatchingMountEntry(ctx, path)
+	if entry == nil {
+		return nil
+	}
+	return entry.Config.MaxChildTokenDepth
+}
+
 // handleRevokeSelf handles the auth/token/revoke-self path for revocation of tokens
 // in a way that revokes all child tokens. Normally, using sys/revoke/leaseID will revoke
 // the token and all children anyways, but that is only available when there is a lease.
@@ -3398,6 +3466,10 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["bound_cidrs"] = out.BoundCIDRs
 	}
 
+	if out.ChildTokenDepthLimited {
+		resp.Data["max_child_token_depth"] = out.ChildTokenDepth
+	}
+
 	tokenNS, err := NamespaceByID(ctx, out.NamespaceID, ts.core)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -3624,6 +3696,9 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 	if role.TokenNumUses > 0 {
 		resp.Data["token_num_uses"] = role.TokenNumUses
 	}
+	if role.MaxChildTokenDepth != nil {
+		resp.Data["max_child_token_depth"] = *role.MaxChildTokenDepth
+	}
 
 	return resp, nil
 }
@@ -3869,6 +3944,19 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		entry.AllowedEntityAliases = strutil.RemoveDuplicates(allowedEntityAliasesRaw.([]string), true)
 	}
 
+	maxChildTokenDepthRaw, ok := data.GetOk("max_child_token_depth")
+	if ok {
+		maxChildTokenDepth := maxChildTokenDepthRaw.(int)
+		switch {
+		case maxChildTokenDepth < -1:
+			return logical.ErrorResponse("'max_child_token_depth' must be -1 or greater"), nil
+		case maxChildTokenDepth == -1:
+			entry.MaxChildTokenDepth = nil
+		default:
+			entry.MaxChildTokenDepth = &maxChildTokenDepth
+		}
+	}
+
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -4222,6 +4310,11 @@ no requested policies glob match any of policies in this list.
 The parameter is a comma-delimited string of policy name globs.`
 	tokenOrphanHelp = `If true, tokens created via this role
 will be orphan tokens (have no parent)`
+	tokenMaxChildTokenDepthHelp = `If set, the number of levels of child
+tokens which tokens created via this role
+may create below them. Zero forbids them
+from creating child tokens, and -1, the
+default, removes the restriction.`
 	tokenPeriodHelp = `If set, tokens created via this role
 will have no max lifetime; instead, their
 renewal period will be fixed to this value.
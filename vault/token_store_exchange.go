@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// tokenExchangeAudienceMetaKey is the metadata key which holds the audience
+// of the tokens issued by the token exchange.
+const tokenExchangeAudienceMetaKey = "audience"
+
+// tokenExchangePath returns the path which exchanges the calling token for a
+// derived token narrowed to an audience, in the style of RFC 8693.
+func (ts *TokenStore) tokenExchangePath() *framework.Path {
+	return &framework.Path{
+		Pattern: "exchange$",
+
+		Fields: map[string]*framework.FieldSchema{
+			"audience": {
+				Type:        framework.TypeString,
+				Description: "Audience the derived token is intended for. Recorded in its metadata.",
+				Required:    true,
+			},
+			"policies": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Policies of the derived token. They must be a subset of the policies of the calling token, which are used by default.",
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Time to live of the derived token. It is capped to the remaining time to live of the calling token.",
+			},
+			"num_uses": {
+				Type:        framework.TypeInt,
+				Description: "Max number of uses of the derived token. Zero means unlimited.",
+			},
+		},
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "token",
+			OperationVerb:   "exchange",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: ts.handleExchange,
+		},
+
+		HelpSynopsis:    strings.TrimSpace(tokenExchangeHelp),
+		HelpDescription: strings.TrimSpace(tokenExchangeDesc),
+	}
+}
+
+// handleExchange handles the auth/token/exchange path. The derived token is a
+// non-renewable child of the calling token, so it is revoked along with it,
+// and never grants more than the calling token: its policies are a subset of
+// the calling token's, identity policies do not apply to it, and it expires no
+// later than the calling token.
+func (ts *TokenStore) handleExchange(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	audience := strings.TrimSpace(d.Get("audience").(string))
+	if audience == "" {
+		return logical.ErrorResponse("missing audience"), logical.ErrInvalidRequest
+	}
+
+	parent, err := ts.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return nil, fmt.Errorf("parent token lookup failed: %w", err)
+	}
+	if parent == nil {
+		return logical.ErrorResponse("parent token lookup failed: no parent found"), logical.ErrInvalidRequest
+	}
+	if parent.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be exchanged"), logical.ErrInvalidRequest
+	}
+	if parent.NumUses > 0 {
+		return logical.ErrorResponse("restricted use tokens cannot be exchanged"), logical.ErrInvalidRequest
+	}
+	if parent.ChildTokenDepthLimited && parent.ChildTokenDepth <= 0 {
+		return logical.ErrorResponse("token is not allowed to create child tokens"), logical.ErrPermissionDenied
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != parent.NamespaceID {
+		return logical.ErrorResponse("tokens can only be exchanged in their own namespace"), logical.ErrInvalidRequest
+	}
+
+	numUses := d.Get("num_uses").(int)
+	if numUses < 0 {
+		return logical.ErrorResponse("number of uses cannot be negative"), logical.ErrInvalidRequest
+	}
+
+	parentIsRoot := strutil.StrListContains(parent.Policies, "root")
+	policies := policyutil.SanitizePolicies(d.Get("policies").([]string), policyutil.DoNotAddDefaultPolicy)
+	switch {
+	case len(policies) == 0 && parentIsRoot:
+		return logical.ErrorResponse("policies must be provided to exchange a root token"), logical.ErrInvalidRequest
+	case len(policies) == 0:
+		policies = policyutil.SanitizePolicies(parent.Policies, policyutil.DoNotAddDefaultPolicy)
+	case !parentIsRoot && !strutil.StrListSubset(parent.Policies, policies):
+		return logical.ErrorResponse("derived token policies must be a subset of the calling token's policies"), logical.ErrInvalidRequest
+	}
+	for _, policy := range policies {
+		if policy == "root" {
+			return logical.ErrorResponse("root tokens cannot be issued by the token exchange"), logical.ErrInvalidRequest
+		}
+		if strutil.StrListContains(nonAssignablePolicies, policy) {
+			return logical.ErrorResponse(fmt.Sprintf("cannot assign policy %q", policy)), logical.ErrInvalidRequest
+		}
+	}
+
+	te := logical.TokenEntry{
+		Parent:             req.ClientToken,
+		Path:               "auth/token/exchange",
+		Policies:           policies,
+		NoIdentityPolicies: true,
+		Meta:               map[string]string{tokenExchangeAudienceMetaKey: audience},
+		DisplayName:        "token-exchange",
+		NumUses:            numUses,
+		CreationTime:       time.Now().Unix(),
+		NamespaceID:        ns.ID,
+		EntityID:           parent.EntityID,
+		BoundCIDRs:         parent.BoundCIDRs,
+		Type:               logical.TokenTypeService,
+	}
+	if parent.ChildTokenDepthLimited {
+		limitChildTokenDepth(&te, parent.ChildTokenDepth-1)
+	}
+	if depth := ts.core.mountMaxChildTokenDepth(ctx, "auth/token/"); depth != nil {
+		limitChildTokenDepth(&te, *depth)
+	}
+
+	resp := &logical.Response{}
+
+	ttl, warnings, err := framework.CalculateTTL(ts.System(), 0, time.Duration(d.Get("ttl").(int))*time.Second, 0, 0, 0, time.Unix(te.CreationTime, 0))
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+
+	// The derived token must not outlive the calling token
+	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	if leaseTimes != nil && !leaseTimes.ExpireTime.IsZero() {
+		remaining := time.Until(leaseTimes.ExpireTime).Truncate(time.Second)
+		if remaining <= 0 {
+			return logical.ErrorResponse("calling token is expired"), logical.ErrPermissionDenied
+		}
+		if ttl > remaining {
+			resp.AddWarning(fmt.Sprintf("TTL of %d seconds is greater than the remaining TTL of the calling token; capping to %d seconds", int64(ttl.Seconds()), int64(remaining.Seconds())))
+			ttl = remaining
+		}
+	}
+	te.TTL = ttl
+	te.ExplicitMaxTTL = ttl
+
+	if ts.core.perfStandby {
+		forwardedTokenEntry, err := forwardCreateTokenRegisterAuth(ctx, ts.core, &te, "", false, 0, te.ExplicitMaxTTL)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), ErrInternalError
+		}
+		te = *forwardedTokenEntry
+	} else {
+		if err := ts.create(ctx, &te); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	resp.Auth = &logical.Auth{
+		NumUses:     te.NumUses,
+		DisplayName: te.DisplayName,
+		Policies:    te.Policies,
+		Metadata:    te.Meta,
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       te.TTL,
+			Renewable: false,
+		},
+		ClientToken:    te.ID,
+		Accessor:       te.Accessor,
+		EntityID:       te.EntityID,
+		ExplicitMaxTTL: te.ExplicitMaxTTL,
+		CreationPath:   te.Path,
+		TokenType:      te.Type,
+	}
+	if ts.core.perfStandby && te.ExternalID != "" {
+		resp.Auth.ClientToken = te.ExternalID
+	}
+
+	return resp, nil
+}
+
+const (
+	tokenExchangeHelp = `Exchange the calling token for a narrowed token intended for an audience.`
+	tokenExchangeDesc = `
+This endpoint exchanges the calling token for a derived token, in the style of
+RFC 8693 token exchange, so that it can be delegated without sharing the
+calling token. The derived token is a child of the calling token, carries the
+requested audience in its metadata, holds a subset of the calling token's
+policies without any identity policies, and is not renewable past the
+remaining lifetime of the calling token.
+`
+)
@@ -0,0 +1,450 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// tokenInventoryPaths returns the paths which let the caller list the tokens
+// of its entity and revoke those other than the calling token.
+func (ts *TokenStore) tokenInventoryPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "self/inventory$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "token",
+				OperationVerb:   "list",
+				OperationSuffix: "self-inventory",
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: ts.handleInventorySelf,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenInventorySelfHelp),
+			HelpDescription: strings.TrimSpace(tokenInventorySelfDesc),
+		},
+
+		{
+			Pattern: "self/inventory/revoke-others$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "token",
+				OperationVerb:   "revoke",
+				OperationSuffix: "self-inventory-others",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessors": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Accessors of the tokens of the entity to revoke. All the other tokens of the entity are revoked by default.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: ts.handleInventoryRevokeOthers,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenInventoryRevokeOthersHelp),
+			HelpDescription: strings.TrimSpace(tokenInventoryRevokeOthersDesc),
+		},
+	}
+}
+
+// entityTokenInventoryPrefix is the prefix, in the entity tokens view of the
+// root namespace, of the index of the service tokens of each entity, by entity
+// ID and salted token accessor. Mount accessors never take this form, so it
+// does not collide with the index of the auth mounts limiting the tokens of
+// entities.
+const entityTokenInventoryPrefix = "inventory/"
+
+// entityTokenInventoryBackfilledKey is set in the entity tokens view of the
+// root namespace once the tokens issued before the index was introduced have
+// been added to it.
+const entityTokenInventoryBackfilledKey = "inventory-backfilled"
+
+// entityTokenInventoryEntry is stored for each service token of an entity. The
+// index is always kept in the root namespace, and records the namespace of the
+// token, which the accessor is salted and looked up with.
+type entityTokenInventoryEntry struct {
+	IssueTime   time.Time `json:"issue_time"`
+	NamespaceID string    `json:"namespace_id"`
+}
+
+// entityTokenInventoryKey returns the prefix of the index of the service
+// tokens of the entity.
+func entityTokenInventoryKey(entityID string) string {
+	return entityTokenInventoryPrefix + entityID + "/"
+}
+
+// indexEntityInventoryToken records the service token in the index of the
+// tokens of its entity, so that the inventory of the entity does not have to
+// scan every token. The entry is removed when the token is revoked.
+func (ts *TokenStore) indexEntityInventoryToken(ctx context.Context, tokenNS *namespace.Namespace, te *logical.TokenEntry) error {
+	saltedAccessor, err := ts.SaltID(namespace.ContextWithNamespace(ctx, tokenNS), te.Accessor)
+	if err != nil {
+		return err
+	}
+	return ts.putEntityInventoryToken(ctx, tokenNS, te, saltedAccessor)
+}
+
+func (ts *TokenStore) putEntityInventoryToken(ctx context.Context, tokenNS *namespace.Namespace, te *logical.TokenEntry, saltedAccessor string) error {
+	issueTime := time.Now().UTC()
+	if te.CreationTime != 0 {
+		issueTime = time.Unix(te.CreationTime, 0).UTC()
+	}
+	entry, err := logical.StorageEntryJSON(entityTokenInventoryKey(te.EntityID)+saltedAccessor, &entityTokenInventoryEntry{
+		IssueTime:   issueTime,
+		NamespaceID: tokenNS.ID,
+	})
+	if err != nil {
+		return err
+	}
+	return ts.entityTokensView(namespace.RootNamespace).Put(ctx, entry)
+}
+
+// removeEntityInventoryToken removes the revoked service token from the index
+// of the tokens of its entity.
+func (ts *TokenStore) removeEntityInventoryToken(ctx context.Context, te *logical.TokenEntry, saltedAccessor string) error {
+	return ts.entityTokensView(namespace.RootNamespace).Delete(ctx, entityTokenInventoryKey(te.EntityID)+saltedAccessor)
+}
+
+// backfillEntityTokenInventory adds the service tokens issued before the index
+// of the tokens of entities was introduced to it. It scans every token once,
+// and is skipped on later unseals.
+func (ts *TokenStore) backfillEntityTokenInventory(ctx context.Context) error {
+	view := ts.entityTokensView(namespace.RootNamespace)
+	done, err := view.Get(ctx, entityTokenInventoryBackfilledKey)
+	if err != nil {
+		return err
+	}
+	if done != nil {
+		return nil
+	}
+
+	var indexed int
+	for _, ns := range ts.core.ListNamespaces(false) {
+		nsCtx := namespace.ContextWithNamespace(ctx, ns)
+		accessors, err := ts.accessorView(ns).List(nsCtx, "")
+		if err != nil {
+			return fmt.Errorf("failed to list token accessors: %w", err)
+		}
+		for _, saltedAccessor := range accessors {
+			aEntry, err := ts.lookupByAccessor(nsCtx, saltedAccessor, true, false)
+			if err != nil {
+				return err
+			}
+			if aEntry == nil || aEntry.TokenID == "" {
+				continue
+			}
+			te, err := ts.lookupInternal(nsCtx, aEntry.TokenID, false, true)
+			if err != nil {
+				return err
+			}
+			if te == nil || te.EntityID == "" || te.Type == logical.TokenTypeBatch {
+				continue
+			}
+			if err := ts.putEntityInventoryToken(ctx, ns, te, saltedAccessor); err != nil {
+				return fmt.Errorf("failed to index the token of the entity: %w", err)
+			}
+			indexed++
+		}
+	}
+
+	if err := view.Put(ctx, &logical.StorageEntry{Key: entityTokenInventoryBackfilledKey, Value: []byte("1")}); err != nil {
+		return err
+	}
+	if indexed > 0 {
+		ts.logger.Info("indexed the existing tokens of entities", "tokens", indexed)
+	}
+	return nil
+}
+
+// setupEntityTokenInventory backfills the index of the tokens of entities on
+// the active node.
+func (c *Core) setupEntityTokenInventory(ctx context.Context) error {
+	if c.perfStandby || c.tokenStore == nil {
+		return nil
+	}
+	return c.tokenStore.backfillEntityTokenInventory(ctx)
+}
+
+// entityInventoryTokens returns the valid tokens indexed for the entity, oldest
+// first. Entries of tokens which are no longer valid are removed.
+func (ts *TokenStore) entityInventoryTokens(ctx context.Context, entityID string) ([]*logical.TokenEntry, error) {
+	view := ts.entityTokensView(namespace.RootNamespace)
+	prefix := entityTokenInventoryKey(entityID)
+	keys, err := view.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tokens of the entity: %w", err)
+	}
+
+	var tokens []*entityToken
+	for _, saltedAccessor := range keys {
+		key := prefix + saltedAccessor
+
+		var index entityTokenInventoryEntry
+		raw, err := view.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		if err := jsonutil.DecodeJSON(raw.Value, &index); err != nil {
+			return nil, err
+		}
+
+		var te *logical.TokenEntry
+		tokenNS, err := NamespaceByID(ctx, index.NamespaceID, ts.core)
+		if err != nil {
+			return nil, err
+		}
+		if tokenNS != nil {
+			nsCtx := namespace.ContextWithNamespace(ctx, tokenNS)
+			aEntry, err := ts.lookupByAccessor(nsCtx, saltedAccessor, true, false)
+			if err != nil {
+				return nil, err
+			}
+			if aEntry != nil && aEntry.TokenID != "" {
+				te, err = ts.Lookup(nsCtx, aEntry.TokenID)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if te == nil {
+			if err := view.Delete(ctx, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tokens = append(tokens, &entityToken{key: key, te: te, issueTime: index.IssueTime})
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].issueTime.Before(tokens[j].issueTime)
+	})
+	entries := make([]*logical.TokenEntry, 0, len(tokens))
+	for _, token := range tokens {
+		entries = append(entries, token.te)
+	}
+	return entries, nil
+}
+
+// inventoryTokens returns the calling token, and the valid service tokens of
+// its entity, oldest first.
+func (ts *TokenStore) inventoryTokens(ctx context.Context, req *logical.Request) (*logical.TokenEntry, []*logical.TokenEntry, *logical.Response, error) {
+	if req.EntityID == "" {
+		return nil, nil, logical.ErrorResponse("calling token is not associated with an entity"), logical.ErrInvalidRequest
+	}
+
+	caller, err := ts.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if caller == nil {
+		return nil, nil, logical.ErrorResponse("bad token"), logical.ErrPermissionDenied
+	}
+
+	entity, err := ts.core.identityStore.MemDBEntityByID(req.EntityID, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if entity == nil {
+		return nil, nil, logical.ErrorResponse("entity of the calling token not found"), logical.ErrInvalidRequest
+	}
+
+	lock := locksutil.LockForKey(ts.entityTokenLocks, entityTokenInventoryKey(entity.ID))
+	lock.Lock()
+	indexed, err := ts.entityInventoryTokens(ctx, entity.ID)
+	lock.Unlock()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tokens := make([]*logical.TokenEntry, 0, len(indexed))
+	for _, te := range indexed {
+		if te.EntityID != entity.ID || te.Type == logical.TokenTypeBatch {
+			continue
+		}
+		tokens = append(tokens, te)
+	}
+	return caller, tokens, nil, nil
+}
+
+// handleInventorySelf handles the auth/token/self/inventory path, which lists
+// the active tokens of the entity of the calling token. The token IDs are never
+// returned, only their accessors and properties.
+func (ts *TokenStore) handleInventorySelf(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	caller, tokens, resp, err := ts.inventoryTokens(ctx, req)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	inventory := make([]map[string]interface{}, 0, len(tokens))
+	for _, te := range tokens {
+		tokenNS, err := NamespaceByID(ctx, te.NamespaceID, ts.core)
+		if err != nil {
+			return nil, err
+		}
+		if tokenNS == nil {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"accessor":       te.Accessor,
+			"display_name":   te.DisplayName,
+			"path":           te.Path,
+			"policies":       te.Policies,
+			"meta":           te.Meta,
+			"creation_time":  te.CreationTime,
+			"expire_time":    nil,
+			"ttl":            int64(0),
+			"orphan":         te.Parent == "",
+			"namespace_path": tokenNS.Path,
+			"current":        te.ID == caller.ID,
+		}
+
+		leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(namespace.ContextWithNamespace(ctx, tokenNS), te)
+		if err != nil {
+			return nil, err
+		}
+		if leaseTimes != nil && !leaseTimes.ExpireTime.IsZero() {
+			entry["expire_time"] = leaseTimes.ExpireTime
+			entry["ttl"] = leaseTimes.ttl()
+		}
+		inventory = append(inventory, entry)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"entity_id": req.EntityID,
+			"tokens":    inventory,
+		},
+	}, nil
+}
+
+// handleInventoryRevokeOthers handles the auth/token/self/inventory/revoke-others
+// path, which revokes the tokens of the entity of the calling token other than
+// the calling token. The parents of the calling token are skipped, as revoking
+// them would revoke the calling token as well.
+func (ts *TokenStore) handleInventoryRevokeOthers(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	caller, tokens, resp, err := ts.inventoryTokens(ctx, req)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	requested := strutil.RemoveDuplicates(data.Get("accessors").([]string), false)
+	if len(requested) > 0 {
+		byAccessor := make(map[string]*logical.TokenEntry, len(tokens))
+		for _, te := range tokens {
+			byAccessor[te.Accessor] = te
+		}
+		selected := make([]*logical.TokenEntry, 0, len(requested))
+		for _, accessor := range requested {
+			te, ok := byAccessor[accessor]
+			if !ok {
+				return logical.ErrorResponse(fmt.Sprintf("accessor %q is not an active token of the entity", accessor)), logical.ErrInvalidRequest
+			}
+			selected = append(selected, te)
+		}
+		tokens = selected
+	}
+
+	ancestors, err := ts.tokenAncestors(ctx, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]string, 0, len(tokens))
+	var skipped []string
+	for _, te := range tokens {
+		if te.ID == caller.ID || ancestors[te.ID] {
+			skipped = append(skipped, te.Accessor)
+			continue
+		}
+
+		// The token may have been revoked along with one of its parents
+		tokenNS, err := NamespaceByID(ctx, te.NamespaceID, ts.core)
+		if err != nil {
+			return nil, err
+		}
+		if tokenNS == nil {
+			continue
+		}
+		current, err := ts.lookupInternal(namespace.ContextWithNamespace(ctx, tokenNS), te.ID, false, false)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			revoked = append(revoked, te.Accessor)
+			continue
+		}
+
+		if err := ts.core.revokeEntityToken(current); err != nil {
+			return nil, fmt.Errorf("failed to revoke token with accessor %q: %w", te.Accessor, err)
+		}
+		revoked = append(revoked, te.Accessor)
+	}
+
+	resp = &logical.Response{
+		Data: map[string]interface{}{
+			"revoked": revoked,
+		},
+	}
+	if len(skipped) > 0 {
+		resp.Data["skipped"] = skipped
+		resp.AddWarning("the calling token and its parents were not revoked")
+	}
+	return resp, nil
+}
+
+// tokenAncestors returns the IDs of the parents of the token, up to its root.
+func (ts *TokenStore) tokenAncestors(ctx context.Context, te *logical.TokenEntry) (map[string]bool, error) {
+	ancestors := make(map[string]bool)
+	for parentID := te.Parent; parentID != "" && !ancestors[parentID]; {
+		ancestors[parentID] = true
+		parent, err := ts.lookupInternal(ctx, parentID, false, true)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			break
+		}
+		parentID = parent.Parent
+	}
+	return ancestors, nil
+}
+
+const (
+	tokenInventorySelfHelp = `List the active tokens of the entity of the calling token.`
+	tokenInventorySelfDesc = `
+This endpoint lists the active service tokens of the entity the calling token
+is associated with, so that a user can see where they are logged in. Only the
+accessors and properties of the tokens are returned, never their IDs. The
+calling token is flagged as current.
+`
+
+	tokenInventoryRevokeOthersHelp = `Revoke the tokens of the entity of the calling token, other than the calling token.`
+	tokenInventoryRevokeOthersDesc = `
+This endpoint revokes the active service tokens of the entity the calling
+token is associated with, along with their child tokens and leases, so that a
+user can sign out of their other sessions. The calling token and its parents
+are never revoked. The tokens to revoke can be narrowed to a list of accessors.
+`
+)
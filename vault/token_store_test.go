@@ -1761,6 +1761,272 @@ func TestTokenStore_HandleRequest_CreateToken_NumUses_Restricted(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleRequest_CreateToken_MaxChildTokenDepth(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "roles/limited")
+	req.ClientToken = root
+	req.Data["max_child_token_depth"] = 1
+	resp, err := ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "roles/limited")
+	req.ClientToken = root
+	resp, err = ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["max_child_token_depth"] != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	create := func(path, clientToken string) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = clientToken
+		return ts.HandleRequest(namespace.RootContext(nil), req)
+	}
+
+	// The token of the role may create a single level of child tokens
+	resp, err = create("create/limited", root)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	parent := resp.Auth.ClientToken
+
+	resp, err = create("create", parent)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	child := resp.Auth.ClientToken
+
+	out, err := ts.Lookup(namespace.RootContext(nil), child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.ChildTokenDepthLimited || out.ChildTokenDepth != 0 {
+		t.Fatalf("expected the child token to be restricted, got %#v", out)
+	}
+
+	// Neither child nor orphan tokens may be created below that level
+	for _, path := range []string{"create", "create-orphan"} {
+		resp, err = create(path, child)
+		if err != logical.ErrPermissionDenied {
+			t.Fatalf("%s: expected permission denied, got err: %v resp: %#v", path, err, resp)
+		}
+	}
+
+	// Unsetting the restriction does not lift it from the issued tokens
+	req = logical.TestRequest(t, logical.UpdateOperation, "roles/limited")
+	req.ClientToken = root
+	req.Data["max_child_token_depth"] = -1
+	resp, err = ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if _, err = create("create", child); err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	resp, err = create("create/limited", root)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	out, err = ts.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ChildTokenDepthLimited {
+		t.Fatalf("expected the token to be unrestricted, got %#v", out)
+	}
+}
+
+func TestTokenStore_HandleRequest_Exchange(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	exchange := func(clientToken string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "exchange")
+		req.ClientToken = clientToken
+		req.Data = data
+		return ts.HandleRequest(namespace.RootContext(nil), req)
+	}
+
+	// Root tokens must be narrowed to explicit policies
+	resp, err := exchange(root, map[string]interface{}{"audience": "ci"})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v resp: %#v", err, resp)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = root
+	req.Data["policies"] = []string{"foo", "bar"}
+	req.Data["ttl"] = "1h"
+	parent := testMakeTokenViaRequest(t, ts, req).Auth.ClientToken
+
+	// The policies of the calling token are used by default
+	resp, err = exchange(parent, map[string]interface{}{"audience": "ci"})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Auth.Policies, []string{"bar", "default", "foo"}) {
+		t.Fatalf("bad policies: %#v", resp.Auth.Policies)
+	}
+
+	resp, err = exchange(parent, map[string]interface{}{
+		"audience": "ci",
+		"policies": "foo,baz",
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected policies outside of the parent's to be rejected, got err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = exchange(parent, map[string]interface{}{
+		"audience": "ci",
+		"policies": "foo",
+		"ttl":      "2h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if len(resp.Warnings) == 0 {
+		t.Fatalf("expected a warning about the capped TTL")
+	}
+	if resp.Auth.TTL > time.Hour || resp.Auth.Renewable {
+		t.Fatalf("expected a non-renewable token expiring with its parent, got %#v", resp.Auth)
+	}
+
+	out, err := ts.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Parent != parent || !out.NoIdentityPolicies || out.ExplicitMaxTTL != out.TTL {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !reflect.DeepEqual(out.Policies, []string{"foo"}) {
+		t.Fatalf("bad policies: %#v", out.Policies)
+	}
+	if out.Meta["audience"] != "ci" {
+		t.Fatalf("bad metadata: %#v", out.Meta)
+	}
+}
+
+func TestTokenStore_HandleRequest_SelfInventory(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"name": "testentity"},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+
+	// The calling token is a child of another token of the entity
+	parent := &logical.TokenEntry{Path: "auth/userpass/login/jane", Policies: []string{"default"}, EntityID: entityID, TTL: time.Hour}
+	testMakeTokenDirectly(t, ts, parent)
+	caller := &logical.TokenEntry{Parent: parent.ID, Path: "auth/token/create", Policies: []string{"default"}, EntityID: entityID, TTL: time.Hour}
+	testMakeTokenDirectly(t, ts, caller)
+	other := &logical.TokenEntry{Path: "auth/userpass/login/jane", Policies: []string{"default"}, EntityID: entityID, TTL: time.Hour}
+	testMakeTokenDirectly(t, ts, other)
+	unrelated := &logical.TokenEntry{Path: "auth/token/create", Policies: []string{"default"}, TTL: time.Hour}
+	testMakeTokenDirectly(t, ts, unrelated)
+
+	// Tokens issued before the index was introduced are added to it on unseal
+	legacy := &logical.TokenEntry{Path: "auth/userpass/login/jane", Policies: []string{"default"}, EntityID: entityID, TTL: time.Hour}
+	testMakeTokenDirectly(t, ts, legacy)
+	saltedAccessor, err := ts.SaltID(ctx, legacy.Accessor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.removeEntityInventoryToken(ctx, legacy, saltedAccessor); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.entityTokensView(namespace.RootNamespace).Delete(ctx, entityTokenInventoryBackfilledKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.setupEntityTokenInventory(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = caller.ID
+		req.EntityID = entityID
+		req.Data = data
+		resp, err := ts.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v\nresp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	resp = request(logical.ReadOperation, "self/inventory", nil)
+	inventory := resp.Data["tokens"].([]map[string]interface{})
+	if len(inventory) != 4 {
+		t.Fatalf("expected the 4 tokens of the entity, got %#v", inventory)
+	}
+	accessors := make(map[string]bool)
+	for _, entry := range inventory {
+		accessors[entry["accessor"].(string)] = entry["current"].(bool)
+	}
+	if !reflect.DeepEqual(accessors, map[string]bool{parent.Accessor: false, caller.Accessor: true, other.Accessor: false, legacy.Accessor: false}) {
+		t.Fatalf("bad inventory: %#v", inventory)
+	}
+
+	// Accessors of tokens outside of the entity are rejected
+	req := logical.TestRequest(t, logical.UpdateOperation, "self/inventory/revoke-others")
+	req.ClientToken = caller.ID
+	req.EntityID = entityID
+	req.Data["accessors"] = unrelated.Accessor
+	if resp, err := ts.HandleRequest(ctx, req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v resp: %#v", err, resp)
+	}
+
+	resp = request(logical.UpdateOperation, "self/inventory/revoke-others", nil)
+	revoked := resp.Data["revoked"].([]string)
+	sort.Strings(revoked)
+	expected := []string{other.Accessor, legacy.Accessor}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(revoked, expected) {
+		t.Fatalf("bad revoked tokens: %#v", resp.Data)
+	}
+	if len(resp.Data["skipped"].([]string)) != 2 {
+		t.Fatalf("expected the calling token and its parent to be skipped: %#v", resp.Data)
+	}
+
+	for _, te := range []*logical.TokenEntry{parent, caller, unrelated} {
+		out, err := ts.Lookup(ctx, te.ID)
+		if err != nil || out == nil {
+			t.Fatalf("expected token %q to remain, err: %v", te.Accessor, err)
+		}
+	}
+	for _, te := range []*logical.TokenEntry{other, legacy} {
+		out, err := ts.Lookup(ctx, te.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			t.Fatalf("expected token %q of the entity to be revoked", te.Accessor)
+		}
+	}
+
+	// Revoked tokens are removed from the index of the tokens of the entity
+	keys, err := ts.entityTokensView(namespace.RootNamespace).List(ctx, entityTokenInventoryKey(entityID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected the 2 remaining tokens of the entity to be indexed, got %v", keys)
+	}
+}
+
 func TestTokenStore_HandleRequest_CreateToken_NoPolicy(t *testing.T) {
 	c, _, root := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -28,3 +28,7 @@ func (ts *TokenStore) parentView(ns *namespace.Namespace) *BarrierView {
 func (ts *TokenStore) rolesView(ns *namespace.Namespace) *BarrierView {
 	return ts.rolesBarrierView
 }
+
+func (ts *TokenStore) entityTokensView(ns *namespace.Namespace) *BarrierView {
+	return ts.entityTokensBarrierView
+}
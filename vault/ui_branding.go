@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	uiBrandingConfigPath = "ui-branding"
+
+	// maxUIBrandingLogoSize bounds the size of logos, which may be inlined as
+	// data URLs, as they are served to unauthenticated clients.
+	maxUIBrandingLogoSize = 64 * 1024
+
+	maxUIBrandingLoginMessageLength = 4096
+	maxUIBrandingFeatureFlags       = 64
+)
+
+var (
+	uiBrandingColorRe       = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	uiBrandingFeatureFlagRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+	uiBrandingLogoDataRe    = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp|svg\+xml);base64,[A-Za-z0-9+/]+=*$`)
+)
+
+// UIBranding customizes how the UI presents a namespace: the logo, primary
+// color and login message shown to its users, and the UI features enabled for
+// them. Each setting is inherited from the closest ancestor namespace which
+// sets it, so tenants only need to configure what they change.
+type UIBranding struct {
+	Logo         string   `json:"logo,omitempty"`
+	PrimaryColor string   `json:"primary_color,omitempty"`
+	LoginMessage string   `json:"login_message,omitempty"`
+	FeatureFlags []string `json:"feature_flags,omitempty"`
+}
+
+// empty returns whether the branding sets none of the settings.
+func (b *UIBranding) empty() bool {
+	return b.Logo == "" && b.PrimaryColor == "" && b.LoginMessage == "" && len(b.FeatureFlags) == 0
+}
+
+// validate checks the settings of the branding, and sorts and deduplicates its
+// feature flags.
+func (b *UIBranding) validate() error {
+	if b.Logo != "" {
+		if len(b.Logo) > maxUIBrandingLogoSize {
+			return fmt.Errorf("logo cannot be larger than %d bytes", maxUIBrandingLogoSize)
+		}
+		if strings.HasPrefix(b.Logo, "data:") {
+			if !uiBrandingLogoDataRe.MatchString(b.Logo) {
+				return errors.New("logo data URLs must be base64 encoded png, jpeg, gif, webp or svg images")
+			}
+		} else if u, err := url.Parse(b.Logo); err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("logo must be an https URL or a data URL")
+		}
+	}
+	if b.PrimaryColor != "" && !uiBrandingColorRe.MatchString(b.PrimaryColor) {
+		return errors.New("primary_color must be a hex color such as #1563ff")
+	}
+	if len(b.LoginMessage) > maxUIBrandingLoginMessageLength {
+		return fmt.Errorf("login_message cannot be longer than %d characters", maxUIBrandingLoginMessageLength)
+	}
+
+	if len(b.FeatureFlags) > maxUIBrandingFeatureFlags {
+		return fmt.Errorf("at most %d feature flags can be set", maxUIBrandingFeatureFlags)
+	}
+	flags := make(map[string]struct{}, len(b.FeatureFlags))
+	for _, flag := range b.FeatureFlags {
+		if !uiBrandingFeatureFlagRe.MatchString(flag) {
+			return fmt.Errorf("invalid feature flag %q", flag)
+		}
+		flags[flag] = struct{}{}
+	}
+	b.FeatureFlags = b.FeatureFlags[:0]
+	for flag := range flags {
+		b.FeatureFlags = append(b.FeatureFlags, flag)
+	}
+	sort.Strings(b.FeatureFlags)
+	if len(b.FeatureFlags) == 0 {
+		b.FeatureFlags = nil
+	}
+
+	return nil
+}
+
+// uiBrandingView returns the storage view of the system configuration of the
+// namespace.
+func (c *Core) uiBrandingView(ns *namespace.Namespace) (*BarrierView, error) {
+	view, err := c.barrierViewForNamespace(ns.ID)
+	if err != nil {
+		return nil, err
+	}
+	return view.SubView("config/"), nil
+}
+
+// UIBranding returns the branding configured in the namespace itself, or nil
+// if it has none.
+func (c *Core) UIBranding(ctx context.Context, ns *namespace.Namespace) (*UIBranding, error) {
+	view, err := c.uiBrandingView(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := view.Get(ctx, uiBrandingConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read UI branding config: %w", err)
+	}
+	if out == nil {
+		return nil, nil
+	}
+
+	branding := new(UIBranding)
+	if err := out.DecodeJSON(branding); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+// EffectiveUIBranding returns the branding of the namespace, with the settings
+// it does not set inherited from its closest ancestor which does.
+func (c *Core) EffectiveUIBranding(ctx context.Context, ns *namespace.Namespace) (*UIBranding, error) {
+	effective := new(UIBranding)
+	seen := make(map[string]struct{})
+	for {
+		if _, ok := seen[ns.ID]; ok {
+			break
+		}
+		seen[ns.ID] = struct{}{}
+
+		branding, err := c.UIBranding(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		if branding != nil {
+			if effective.Logo == "" {
+				effective.Logo = branding.Logo
+			}
+			if effective.PrimaryColor == "" {
+				effective.PrimaryColor = branding.PrimaryColor
+			}
+			if effective.LoginMessage == "" {
+				effective.LoginMessage = branding.LoginMessage
+			}
+			if effective.FeatureFlags == nil {
+				effective.FeatureFlags = branding.FeatureFlags
+			}
+		}
+
+		if ns.ID == namespace.RootNamespaceID {
+			break
+		}
+		ns = c.namespaceByPath(parentNamespacePath(ns.Path))
+		if ns == nil {
+			break
+		}
+	}
+	return effective, nil
+}
+
+// parentNamespacePath returns the path of the parent of the namespace with the
+// given canonical path.
+func parentNamespacePath(nsPath string) string {
+	nsPath = strings.TrimSuffix(nsPath, "/")
+	i := strings.LastIndex(nsPath, "/")
+	if i < 0 {
+		return ""
+	}
+	return nsPath[:i+1]
+}
+
+// SetUIBranding validates and stores the branding of the namespace, or deletes
+// it if it sets none of the settings.
+func (c *Core) SetUIBranding(ctx context.Context, ns *namespace.Namespace, branding *UIBranding) error {
+	if err := branding.validate(); err != nil {
+		return err
+	}
+	if branding.empty() {
+		return c.DeleteUIBranding(ctx, ns)
+	}
+
+	view, err := c.uiBrandingView(ns)
+	if err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(uiBrandingConfigPath, branding)
+	if err != nil {
+		return fmt.Errorf("failed to create UI branding config entry: %w", err)
+	}
+	if err := view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save UI branding config: %w", err)
+	}
+	return nil
+}
+
+// DeleteUIBranding deletes the branding of the namespace, which then inherits
+// the branding of its ancestors.
+func (c *Core) DeleteUIBranding(ctx context.Context, ns *namespace.Namespace) error {
+	view, err := c.uiBrandingView(ns)
+	if err != nil {
+		return err
+	}
+	if err := view.Delete(ctx, uiBrandingConfigPath); err != nil {
+		return fmt.Errorf("failed to delete UI branding config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package uicustommessages
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// acknowledgmentsStoragePrefix is the prefix of the storage entries holding
+// the acknowledgments of each message. Message IDs are unique across
+// namespaces, so the entries are keyed by message ID alone.
+const acknowledgmentsStoragePrefix = "sys/config/ui/custom-message-acknowledgments/"
+
+// Acknowledgment records that an entity acknowledged a version of a Message.
+type Acknowledgment struct {
+	// EntityID is the ID of the entity which acknowledged the Message.
+	EntityID string `json:"entity_id"`
+	// Version is the version of the Message which was acknowledged.
+	Version int `json:"version"`
+	// Time is when the Message was acknowledged.
+	Time time.Time `json:"time"`
+}
+
+// acknowledgmentsEntry is the storage entry of the acknowledgments of a
+// Message, keyed by entity ID. Only the latest acknowledgment of each entity
+// is kept.
+type acknowledgmentsEntry struct {
+	Acknowledgments map[string]Acknowledgment `json:"acknowledgments"`
+}
+
+// acknowledgmentsStorageKey returns the storage entry key of the
+// acknowledgments of the message with the provided id.
+func acknowledgmentsStorageKey(id string) string {
+	return acknowledgmentsStoragePrefix + id
+}
+
+// PendingAcknowledgments returns the active unauthenticated messages in the
+// current namespace or any of its ancestors which require acknowledgment, and
+// whose current version the entity with the provided entityID has not
+// acknowledged.
+func (m *Manager) PendingAcknowledgments(ctx context.Context, entityID string) ([]Message, error) {
+	filter := FindFilter{
+		IncludeAncestors: true,
+	}
+	filter.Active(true)
+	filter.Authenticated(false)
+
+	messages, err := m.FindMessages(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Message
+	for _, message := range messages {
+		if !message.RequireAcknowledgment {
+			continue
+		}
+
+		entry, err := m.getAcknowledgments(ctx, message.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ack, ok := entry.Acknowledgments[entityID]; ok && ack.Version >= message.Version {
+			continue
+		}
+		pending = append(pending, message)
+	}
+
+	return pending, nil
+}
+
+// Acknowledge records that the entity with the provided entityID acknowledged
+// the provided version of the message with the provided id.
+func (m *Manager) Acknowledge(ctx context.Context, id, entityID string, version int) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	entry, err := m.getAcknowledgments(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if entry.Acknowledgments == nil {
+		entry.Acknowledgments = make(map[string]Acknowledgment)
+	}
+	entry.Acknowledgments[entityID] = Acknowledgment{
+		EntityID: entityID,
+		Version:  version,
+		Time:     time.Now().UTC(),
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return m.view.Put(ctx, &logical.StorageEntry{
+		Key:   acknowledgmentsStorageKey(id),
+		Value: value,
+	})
+}
+
+// ReadAcknowledgments returns the latest acknowledgment of each entity which
+// acknowledged the message with the provided id, sorted by entity ID.
+func (m *Manager) ReadAcknowledgments(ctx context.Context, id string) ([]Acknowledgment, error) {
+	entry, err := m.getAcknowledgments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	acks := make([]Acknowledgment, 0, len(entry.Acknowledgments))
+	for _, ack := range entry.Acknowledgments {
+		acks = append(acks, ack)
+	}
+	sort.Slice(acks, func(i, j int) bool {
+		return acks[i].EntityID < acks[j].EntityID
+	})
+
+	return acks, nil
+}
+
+// getAcknowledgments retrieves the acknowledgments of the message with the
+// provided id from the logical.Storage.
+func (m *Manager) getAcknowledgments(ctx context.Context, id string) (*acknowledgmentsEntry, error) {
+	storageEntry, err := m.view.Get(ctx, acknowledgmentsStorageKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := new(acknowledgmentsEntry)
+	if storageEntry == nil {
+		return entry, nil
+	}
+	if err := storageEntry.DecodeJSON(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package uicustommessages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestManagerAcknowledgments verifies that messages requiring acknowledgment
+// are pending for an entity until it acknowledges their current version, and
+// that updating the content of a message requires it to be acknowledged again.
+func TestManagerAcknowledgments(t *testing.T) {
+	var (
+		testManager = NewManager(&logical.InmemStorage{})
+
+		nsCtx = namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+	)
+
+	message, err := testManager.AddMessage(nsCtx, Message{
+		Title:                 "Acceptable use",
+		Message:               "dGVybXM=",
+		Type:                  ModalMessageType,
+		StartTime:             time.Now().Add(-time.Hour),
+		RequireAcknowledgment: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, message.Version)
+
+	// Messages which do not require acknowledgment are never pending
+	_, err = testManager.AddMessage(nsCtx, Message{
+		Title:     "Maintenance",
+		Message:   "bWFpbnRlbmFuY2U=",
+		Type:      BannerMessageType,
+		StartTime: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	pending, err := testManager.PendingAcknowledgments(nsCtx, "entity-1")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, message.ID, pending[0].ID)
+
+	require.NoError(t, testManager.Acknowledge(nsCtx, message.ID, "entity-1", message.Version))
+
+	pending, err = testManager.PendingAcknowledgments(nsCtx, "entity-1")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	pending, err = testManager.PendingAcknowledgments(nsCtx, "entity-2")
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	acks, err := testManager.ReadAcknowledgments(nsCtx, message.ID)
+	require.NoError(t, err)
+	require.Len(t, acks, 1)
+	assert.Equal(t, "entity-1", acks[0].EntityID)
+	assert.Equal(t, 1, acks[0].Version)
+
+	// Updating the content of the message requires a new acknowledgment
+	updated := *message
+	updated.Message = "bmV3IHRlcm1z"
+	message, err = testManager.UpdateMessage(nsCtx, updated)
+	require.NoError(t, err)
+	assert.Equal(t, 2, message.Version)
+
+	pending, err = testManager.PendingAcknowledgments(nsCtx, "entity-1")
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Acknowledgments are deleted along with their message
+	require.NoError(t, testManager.DeleteMessage(nsCtx, message.ID))
+	acks, err = testManager.ReadAcknowledgments(nsCtx, message.ID)
+	require.NoError(t, err)
+	assert.Empty(t, acks)
+
+	// Only unauthenticated messages can require acknowledgment
+	_, err = testManager.AddMessage(nsCtx, Message{
+		Title:                 "Welcome",
+		Message:               "d2VsY29tZQ==",
+		Type:                  BannerMessageType,
+		StartTime:             time.Now(),
+		Authenticated:         true,
+		RequireAcknowledgment: true,
+	})
+	assert.Error(t, err)
+}
@@ -53,6 +53,10 @@ func (e *Entry) addMessage(message *Message) error {
 		return errors.New("unrecognized message type")
 	}
 
+	if !message.HasValidAcknowledgment() {
+		return errors.New("only unauthenticated messages can require acknowledgment")
+	}
+
 	// This condition should be evaluated last, because if anything else was to
 	// prevent the creation of the message, there's no use bringing up the
 	// limit.
@@ -61,6 +65,7 @@ func (e *Entry) addMessage(message *Message) error {
 	}
 
 	message.ID = uuid
+	message.Version = 1
 
 	if e.Messages == nil {
 		e.Messages = make(map[string]Message)
@@ -79,7 +84,8 @@ func (e *Entry) updateMessage(message *Message) error {
 		e.Messages = make(map[string]Message)
 	}
 
-	if _, ok := e.Messages[message.ID]; !ok {
+	existing, ok := e.Messages[message.ID]
+	if !ok {
 		return fmt.Errorf("custom message %w", logical.ErrNotFound)
 	}
 
@@ -91,6 +97,14 @@ func (e *Entry) updateMessage(message *Message) error {
 		return errors.New("unrecognized message type")
 	}
 
+	if !message.HasValidAcknowledgment() {
+		return errors.New("only unauthenticated messages can require acknowledgment")
+	}
+
+	message.Version = existing.Version
+	if existing.contentChanged(message) {
+		message.Version++
+	}
 	e.Messages[message.ID] = *message
 
 	return nil
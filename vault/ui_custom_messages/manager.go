@@ -144,6 +144,12 @@ func (m *Manager) DeleteMessage(ctx context.Context, id string) error {
 		return err
 	}
 
+	if _, ok := entry.Messages[id]; ok {
+		if err := m.view.Delete(ctx, acknowledgmentsStorageKey(id)); err != nil {
+			return err
+		}
+	}
+
 	delete(entry.Messages, id)
 
 	return m.putEntry(ctx, entry)
@@ -40,6 +40,13 @@ type Message struct {
 	// Link can hold a MessageLink struct to represent a hyperlink in the
 	// Message.
 	Link *MessageLink `json:"link"`
+	// RequireAcknowledgment indicates that users of interactive auth methods
+	// must acknowledge the Message before they are issued a token. Only
+	// unauthenticated messages can require acknowledgment.
+	RequireAcknowledgment bool `json:"require_acknowledgment"`
+	// Version is incremented every time the Message is updated, so that
+	// acknowledgments of a previous version do not count for the current one.
+	Version int `json:"version"`
 
 	active *bool
 }
@@ -109,6 +116,28 @@ func (m *Message) HasValidStartAndEndTimes() bool {
 	return m.StartTime.Before(*m.EndTime)
 }
 
+// HasValidAcknowledgment evaluates the RequireAcknowledgment field of the
+// receiver Message. This method returns false if acknowledgment is required
+// of a Message shown to authenticated users, as acknowledgments are collected
+// on login.
+func (m *Message) HasValidAcknowledgment() bool {
+	return !m.RequireAcknowledgment || !m.Authenticated
+}
+
+// contentChanged returns whether the content of the Message presented to the
+// end user differs between the receiver Message and the provided Message, in
+// which case acknowledgments of the receiver do not count for the other.
+func (m *Message) contentChanged(other *Message) bool {
+	if m.Title != other.Title || m.Message != other.Message || m.Type != other.Type ||
+		m.RequireAcknowledgment != other.RequireAcknowledgment {
+		return true
+	}
+	if m.Link == nil || other.Link == nil {
+		return m.Link != other.Link
+	}
+	return *m.Link != *other.Link
+}
+
 // MessageLink is a structure that represents a hyperlink included into a
 // Message.
 type MessageLink struct {